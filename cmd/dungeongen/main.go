@@ -1,35 +1,133 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/dungeon/conformance"
 	"github.com/dshills/dungo/pkg/export"
 	"github.com/dshills/dungo/pkg/validation"
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	version = "1.0.0"
 )
 
+// cli holds the command-line flags. Parse errors are returned rather than
+// exiting, so they get the usage exit code and a -result-json summary.
+var cli = flag.NewFlagSet("dungeongen", flag.ContinueOnError)
+
 // CLI flags
 var (
-	configPath = flag.String("config", "", "Path to YAML configuration file (required)")
-	outputDir  = flag.String("output", ".", "Output directory for generated files")
-	format     = flag.String("format", "json", "Export format: json, tmj, svg, or all")
-	seedFlag   = flag.Uint64("seed", 0, "Override the seed from config (0 = use config seed)")
-	verbose    = flag.Bool("verbose", false, "Enable verbose output")
-	versionF   = flag.Bool("version", false, "Print version and exit")
-	help       = flag.Bool("help", false, "Show help message")
+	configPath = cli.String("config", "", "Path to YAML configuration file, or - to read it from stdin (required unless -preset is given)")
+	presetName = cli.String("preset", "", "Generate from a built-in config preset instead of -config: "+strings.Join(dungeon.Presets(), ", "))
+	listPreset = cli.Bool("list-presets", false, "List the built-in config presets and exit")
+	outputDir  = cli.String("output", ".", "Output directory for generated files, or - to write a single format to stdout")
+	format     = cli.String("format", "json", "Export format: "+formatList()+", or all")
+	seedFlag   = cli.Uint64("seed", 0, "Override the seed from config (0 = use config seed)")
+	challenge  = cli.String("challenge", "", "Derive the seed of the current daily, weekly or monthly challenge from the config seed")
+	stopAfter  = cli.String("stop-after", "", "End the pipeline after this stage ("+strings.Join(dungeon.PipelineStages, ", ")+") and export what exists")
+	recarveArt = cli.String("recarve", "", "Carve this saved artifact JSON again instead of generating, keeping its graph and layout (needs the config it was generated with)")
+	tileSize   = cli.Int("tile-size", 0, "With -recarve, the tile size in pixels (0 = keep the artifact's)")
+	decorPath  = cli.String("decorations", "", "With -recarve, a YAML file of decoration rules by theme name, overriding the themes' own")
+	recontent  = cli.Bool("recarve-content", false, "With -recarve, place content again instead of keeping the artifact's")
+	reportN    = cli.Int("report", 0, "Generate N consecutive seeds and print an aggregate validation report (0 = disabled)")
+	minPass    = cli.Float64("min-pass-rate", 0, "In report mode, exit with an error if the pass rate is below this fraction (0.0-1.0)")
+	resultJSON = cli.String("result-json", "", "Write a machine-readable run summary (files written, metrics, validation status) to this JSON file")
+	ledgerPath = cli.String("ledger", "", "Append a JSONL record of every generation request (seed, config hash, outcome, duration, metrics) to this file")
+	verbose    = cli.Bool("verbose", false, "Enable verbose output")
+	versionF   = cli.Bool("version", false, "Print version and exit")
+	help       = cli.Bool("help", false, "Show help message")
+)
+
+// cliFormats are the formats the CLI composes from several export files.
+// The other formats are the exporters in the export package's registry.
+var cliFormats = map[string]bool{"stats": true, "route": true, "gates": true}
+
+// formatAvailable reports whether format can export an artifact whose
+// pipeline stopped after stage. The CLI formats read the complete artifact.
+func formatAvailable(format string, stage dungeon.CheckpointStage) bool {
+	if cliFormats[format] {
+		return stage == "" || stage == dungeon.StageValidation
+	}
+	return export.Available(format, stage)
+}
+
+// formatList returns the valid -format values other than all, sorted.
+func formatList() string {
+	names := export.Names()
+	for name := range cliFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// The streams -config - reads and -output - writes, replaced in tests.
+var (
+	stdin  io.Reader = os.Stdin
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
 )
 
+// logOut receives progress and status messages. run points it at stderr
+// when the dungeon itself is written to stdout.
+var logOut io.Writer = os.Stdout
+
+func init() {
+	cli.StringVar(outputDir, "o", ".", "Shorthand for -output")
+	// main reports parse errors itself
+	cli.SetOutput(io.Discard)
+}
+
 func main() {
-	flag.Parse()
+	// Subcommands take their own flags
+	if len(os.Args) > 1 {
+		var subcommand func([]string) error
+		switch os.Args[1] {
+		case "migrate":
+			subcommand = runMigrate
+		case "conformance":
+			subcommand = runConformance
+		case "ledger":
+			subcommand = runLedger
+		}
+		if subcommand != nil {
+			if err := subcommand(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	}
+
+	started := time.Now()
+	if err := cli.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			os.Exit(exitOK)
+		}
+		if *resultJSON == "" {
+			*resultJSON = resultPathArg(os.Args[1:])
+		}
+		failUsage(err, started)
+	}
 
 	// Handle version flag
 	if *versionF {
@@ -43,207 +141,1034 @@ func main() {
 		os.Exit(0)
 	}
 
+	// List the built-in presets
+	if *listPreset {
+		for _, name := range dungeon.Presets() {
+			fmt.Println(name)
+		}
+		os.Exit(0)
+	}
+
 	// Validate required flags
-	if *configPath == "" {
-		fmt.Fprintln(os.Stderr, "Error: -config flag is required")
-		printUsage()
-		os.Exit(1)
+	if *configPath == "" && *presetName == "" {
+		failUsage(errors.New("-config or -preset flag is required"), started)
+	}
+	if *configPath != "" && *presetName != "" {
+		failUsage(errors.New("-config and -preset cannot be used together"), started)
 	}
 
 	// Validate format
-	validFormats := map[string]bool{
-		"json": true,
-		"tmj":  true,
-		"svg":  true,
-		"all":  true,
+	if _, ok := export.Lookup(*format); !ok && !cliFormats[*format] && *format != "all" {
+		failUsage(fmt.Errorf("invalid format %q, must be one of: %s, all", *format, formatList()), started)
+	}
+
+	// Stopping early leaves some formats nothing to export
+	if *stopAfter != "" {
+		if *reportN > 0 {
+			failUsage(errors.New("-stop-after cannot be used with -report, which validates every dungeon"), started)
+		}
+		stage := dungeon.CheckpointStage(*stopAfter)
+		if slices.Contains(dungeon.PipelineStages, *stopAfter) && *format != "all" && !formatAvailable(*format, stage) {
+			failUsage(fmt.Errorf("format %q needs more of the pipeline than -stop-after %s", *format, stage), started)
+		}
+	}
+
+	// Recarving replaces generation, and its settings need it
+	if *recarveArt == "" && (*tileSize != 0 || *decorPath != "" || *recontent) {
+		failUsage(errors.New("-tile-size, -decorations and -recarve-content need -recarve"), started)
+	}
+	if *recarveArt != "" && *reportN > 0 {
+		failUsage(errors.New("-recarve cannot be used with -report"), started)
 	}
-	if !validFormats[*format] {
-		fmt.Fprintf(os.Stderr, "Error: invalid format %q, must be one of: json, tmj, svg, all\n", *format)
-		os.Exit(1)
+	if *tileSize < 0 {
+		failUsage(fmt.Errorf("-tile-size must not be negative, got %d", *tileSize), started)
+	}
+
+	// Writing to stdout needs a single format
+	if *outputDir == "-" && (*format == "all" || *reportN > 0) {
+		failUsage(errors.New("-output - writes a single format to stdout and cannot be used with -format all or -report"), started)
 	}
 
 	// Run the generator
-	if err := run(); err != nil {
+	runner := run
+	if *reportN > 0 {
+		runner = runReport
+	}
+	err := runner()
+	if werr := saveResult(err, started); werr != nil && err == nil {
+		os.Exit(exitExport)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// failUsage reports a command-line usage error with the usage line and
+// exits with exitError, writing the -result-json summary first.
+func failUsage(err error, started time.Time) {
+	err = withCode(exitError, err)
+	_ = saveResult(err, started)
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	printUsage()
+	os.Exit(exitError)
+}
+
+// resultPathArg finds the -result-json path among args, for when parsing
+// stopped at a bad flag before reaching it.
+func resultPathArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "result-json" {
+			continue
+		}
+		if ok {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
 	}
+	return ""
+}
+
+// saveResult writes the -result-json summary of a run that ended with err,
+// if requested, reporting a failure to write it on stderr.
+func saveResult(err error, started time.Time) error {
+	if *resultJSON == "" {
+		return nil
+	}
+	werr := writeResult(*resultJSON, err, started)
+	if werr != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write result JSON: %v\n", werr)
+	}
+	return werr
 }
 
 // nolint:gocyclo // Complexity acceptable: CLI argument handling and output formatting
 func run() error {
-	ctx := context.Background()
+	// Interrupting stops the exports without leaving partial files
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Keep stdout for the dungeon when it is written there
+	if *outputDir == "-" {
+		logOut = stderr
+	}
 
 	// Load configuration
 	if *verbose {
-		fmt.Printf("Loading configuration from %s\n", *configPath)
+		if *presetName != "" {
+			fmt.Fprintf(logOut, "Loading preset %s\n", *presetName)
+		} else {
+			fmt.Fprintf(logOut, "Loading configuration from %s\n", *configPath)
+		}
 	}
 
-	cfg, err := dungeon.LoadConfig(*configPath)
+	cfg, err := loadInput()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return withCode(exitInvalidConfig, fmt.Errorf("failed to load config: %w", err))
 	}
 
 	// Override seed if specified
 	if *seedFlag != 0 {
 		if *verbose {
-			fmt.Printf("Overriding seed from %d to %d\n", cfg.Seed, *seedFlag)
+			fmt.Fprintf(logOut, "Overriding seed from %d to %d\n", cfg.Seed, *seedFlag)
 		}
 		cfg.Seed = *seedFlag
 	}
 
+	// Derive the current challenge's seed
+	if *challenge != "" {
+		now := time.Now()
+		period := dungeon.Period(*challenge)
+		challengeCfg, err := dungeon.ChallengeConfig(cfg, now, period)
+		if err != nil {
+			return withCode(exitInvalidConfig, fmt.Errorf("failed to derive challenge: %w", err))
+		}
+		if *verbose {
+			fmt.Fprintf(logOut, "Challenge %s (%s) from base seed %d\n", dungeon.PeriodKey(now, period), period, cfg.Seed)
+		}
+		cfg = challengeCfg
+	}
+
+	if *stopAfter != "" {
+		cfg.StopAfter = dungeon.CheckpointStage(*stopAfter)
+	}
+
 	if *verbose {
-		fmt.Printf("Using seed: %d\n", cfg.Seed)
-		fmt.Printf("Room count: %d-%d\n", cfg.Size.RoomsMin, cfg.Size.RoomsMax)
-		fmt.Printf("Themes: %v\n", cfg.Themes)
+		fmt.Fprintf(logOut, "Using seed: %d\n", cfg.Seed)
+		fmt.Fprintf(logOut, "Room count: %d-%d\n", cfg.Size.RoomsMin, cfg.Size.RoomsMax)
+		fmt.Fprintf(logOut, "Themes: %v\n", cfg.Themes)
 	}
 
 	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(*outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	if *outputDir != "-" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			return withCode(exitExport, fmt.Errorf("failed to create output directory: %w", err))
+		}
 	}
 
+	result.Seed = cfg.Seed
+
 	// Create generator with validator
 	validator := validation.NewValidator()
-	gen := dungeon.NewGeneratorWithValidator(validator)
-
-	// Generate dungeon
-	start := time.Now()
-	if *verbose {
-		fmt.Println("Generating dungeon...")
+	base := dungeon.NewGeneratorWithValidator(validator)
+	gen, closeLedger, err := openLedger(base)
+	if err != nil {
+		return err
 	}
+	defer closeLedger()
 
-	artifact, err := gen.Generate(ctx, cfg)
-	if err != nil {
-		return fmt.Errorf("generation failed: %w", err)
+	// Generate dungeon, or carve a saved one again
+	start := time.Now()
+	var artifact *dungeon.Artifact
+	if *recarveArt != "" {
+		if *verbose {
+			fmt.Fprintf(logOut, "Recarving %s...\n", *recarveArt)
+		}
+		if artifact, err = recarve(ctx, base, cfg); err != nil {
+			return fmt.Errorf("recarving failed: %w", err)
+		}
+	} else {
+		if *verbose {
+			fmt.Fprintln(logOut, "Generating dungeon...")
+		}
+		if artifact, err = gen.Generate(ctx, cfg); err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
 	}
 
 	elapsed := time.Since(start)
+
+	// Quality gates may have accepted a later seed
+	if retry := artifact.Debug.Quality; retry != nil {
+		if *verbose {
+			fmt.Fprintf(logOut, "Quality gates rejected %d seeds, accepted seed %d\n", retry.Rejected, retry.Seed)
+		}
+		cfg.Seed = retry.Seed
+		result.Seed = cfg.Seed
+	}
+	recordArtifact(artifact)
+	var stage dungeon.CheckpointStage
+	if artifact.Debug != nil {
+		stage = dungeon.CheckpointStage(artifact.Debug.StoppedAt)
+	}
 	if *verbose {
-		fmt.Printf("Generation completed in %v\n", elapsed)
+		if stage != "" {
+			fmt.Fprintf(logOut, "Pipeline stopped after %s\n", stage)
+		}
+		fmt.Fprintf(logOut, "Generation completed in %v\n", elapsed)
 		printStats(artifact)
 	}
 
+	// Write the single requested format to stdout
+	if *outputDir == "-" {
+		data, err := renderFormat(ctx, artifact, cfg, *format)
+		if err != nil {
+			return withCode(exitExport, err)
+		}
+		if _, err := stdout.Write(data); err != nil {
+			return withCode(exitExport, fmt.Errorf("failed to write to stdout: %w", err))
+		}
+		fmt.Fprintf(logOut, "Successfully generated dungeon (seed=%d) in %v\n", cfg.Seed, elapsed)
+		return nil
+	}
+
 	// Export to requested format(s)
 	baseName := fmt.Sprintf("dungeon_%d", cfg.Seed)
+	jobs := []exportJob{
+		{"json", func(ctx context.Context) error { return exportJSON(ctx, artifact, baseName) }},
+		{"tmj", func(ctx context.Context) error { return exportTMJ(ctx, artifact, baseName) }},
+		{"svg", func(ctx context.Context) error { return exportSVG(ctx, artifact, baseName) }},
+		{"gltf", func(ctx context.Context) error { return exportGLTF(ctx, artifact, baseName, cfg.Themes) }},
+		{"obj", func(ctx context.Context) error { return exportOBJ(ctx, artifact, baseName, cfg.Themes) }},
+		{"collision", func(ctx context.Context) error { return exportCollision(ctx, artifact, baseName) }},
+		{"heightmap", func(ctx context.Context) error { return exportHeightmap(ctx, artifact, baseName) }},
+		{"stats", func(ctx context.Context) error { return exportStats(ctx, artifact, baseName) }},
+		{"route", func(ctx context.Context) error { return exportRoute(ctx, artifact, baseName) }},
+		{"gates", func(ctx context.Context) error { return exportGates(ctx, artifact, baseName) }},
+		{"anchors", func(ctx context.Context) error { return exportAnchors(ctx, artifact, baseName) }},
+		{"summary", func(ctx context.Context) error { return exportSummary(ctx, artifact, cfg, baseName) }},
+	}
+	builtin := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		builtin[job.format] = true
+	}
+	for _, name := range export.Names() {
+		if !builtin[name] {
+			jobs = append(jobs, exportJob{name, func(ctx context.Context) error { return exportRegistered(ctx, artifact, cfg, baseName, name) }})
+		}
+	}
+	selected := jobs[:0]
+	for _, job := range jobs {
+		if *format == job.format || (*format == "all" && formatAvailable(job.format, stage)) {
+			selected = append(selected, job)
+		}
+	}
+	if err := runExports(ctx, selected); err != nil {
+		return withCode(exitExport, err)
+	}
 
-	if *format == "json" || *format == "all" {
-		if err := exportJSON(artifact, baseName); err != nil {
-			return err
+	fmt.Fprintf(logOut, "Successfully generated dungeon (seed=%d) in %v\n", cfg.Seed, elapsed)
+	return nil
+}
+
+// recarve loads the -recarve artifact and carves it again with the
+// -tile-size and -decorations settings.
+func recarve(ctx context.Context, gen dungeon.Generator, cfg *dungeon.Config) (*dungeon.Artifact, error) {
+	artifact, err := dungeon.LoadArtifact(*recarveArt)
+	if err != nil {
+		return nil, err
+	}
+	opts := dungeon.RecarveOptions{TileWidth: *tileSize, TileHeight: *tileSize, Content: *recontent}
+	if *decorPath != "" {
+		data, err := os.ReadFile(*decorPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading decorations: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &opts.Decorations); err != nil {
+			return nil, fmt.Errorf("parsing decorations: %w", err)
 		}
 	}
+	return dungeon.Recarve(ctx, gen, artifact, cfg, opts)
+}
 
-	if *format == "tmj" || *format == "all" {
-		if err := exportTMJ(artifact, baseName); err != nil {
+// exportJob writes the files of one export format. run passes ctx to its
+// file writes, so a cancelled export leaves no partially written files.
+type exportJob struct {
+	format string
+	run    func(ctx context.Context) error
+}
+
+// runExports runs the export jobs concurrently, at most GOMAXPROCS at once.
+// Exports only read the artifact, so they share it without locking. The
+// first failure, or cancelling ctx, stops the jobs that have not written
+// their files yet.
+func runExports(ctx context.Context, jobs []exportJob) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(jobs))
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if ctx.Err() != nil {
+				errs[i] = fmt.Errorf("%s export: %w", job.format, ctx.Err())
+				return
+			}
+			if err := job.run(ctx); err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the other jobs, not the cancellations
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// loadInput loads the config named by -preset, or else by -config.
+func loadInput() (*dungeon.Config, error) {
+	if *presetName != "" {
+		return dungeon.LoadPreset(*presetName)
+	}
+	return loadConfig(*configPath)
+}
+
+// loadConfig loads the YAML config at path, or from stdin when path is "-".
+func loadConfig(path string) (*dungeon.Config, error) {
+	if path != "-" {
+		return dungeon.LoadConfig(path)
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("reading stdin: %w", err)
+	}
+	return dungeon.LoadConfigFromBytes(data)
+}
+
+// renderFormat returns the document a format writes to stdout. Formats
+// writing several files render their primary one: per-room CSV for stats
+// and JSON for route and gates.
+func renderFormat(ctx context.Context, artifact *dungeon.Artifact, cfg *dungeon.Config, format string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch format {
+	case "stats":
+		data, err = export.ExportRoomStatsCSV(artifact)
+	case "route":
+		var route *validation.SpeedrunRoute
+		if route, err = validation.FindSpeedrunRoute(artifact.ADG.Graph, artifact.Layout); err == nil {
+			data, err = validation.ExportRouteJSON(route)
+		}
+	case "gates":
+		var audit *export.GateAudit
+		if audit, err = export.ExportGateAudit(artifact); err == nil {
+			data, err = export.MarshalGateAudit(audit)
+		}
+	default:
+		exporter, ok := export.Lookup(format)
+		if !ok {
+			return nil, fmt.Errorf("format %q cannot be written to stdout", format)
+		}
+		data, err = exporter.Export(artifact, exportOptions(ctx, artifact, cfg))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", format, err)
+	}
+	return data, nil
+}
+
+// recordingValidator wraps a validator and keeps every report it produces,
+// including reports of dungeons rejected for failing hard constraints.
+type recordingValidator struct {
+	inner   dungeon.Validator
+	reports []*dungeon.ValidationReport
+}
+
+// Validate delegates to the wrapped validator and records the report.
+func (v *recordingValidator) Validate(ctx context.Context, artifact *dungeon.Artifact, cfg *dungeon.Config) (*dungeon.ValidationReport, error) {
+	report, err := v.inner.Validate(ctx, artifact, cfg)
+	if report != nil {
+		v.reports = append(v.reports, report)
+	}
+	return report, err
+}
+
+// runReport generates *reportN consecutive seeds starting at the config seed,
+// aggregates their validation reports and writes the aggregate as JSON.
+func runReport() error {
+	ctx := context.Background()
+
+	cfg, err := loadInput()
+	if err != nil {
+		return withCode(exitInvalidConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+	if *seedFlag != 0 {
+		cfg.Seed = *seedFlag
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return withCode(exitExport, fmt.Errorf("failed to create output directory: %w", err))
+	}
+
+	recorder := &recordingValidator{inner: validation.NewValidator()}
+	gen, closeLedger, err := openLedger(dungeon.NewGeneratorWithValidator(recorder))
+	if err != nil {
+		return err
+	}
+	defer closeLedger()
+
+	start := time.Now()
+	baseSeed := cfg.Seed
+	result.Seed = baseSeed
+	errored := 0
+	// Consecutive dungeons are compared to gauge the diversity of seeds
+	var previous *dungeon.Artifact
+	var similarity []float64
+	for i := 0; i < *reportN; i++ {
+		cfg.Seed = baseSeed + uint64(i)
+		recorded := len(recorder.reports)
+		artifact, err := gen.Generate(ctx, cfg)
+		if err != nil && len(recorder.reports) == recorded {
+			// Generation failed before validation, so no report was recorded
+			errored++
+			if *verbose {
+				fmt.Printf("Seed %d: %v\n", cfg.Seed, err)
+			}
+		}
+		if artifact != nil {
+			if previous != nil {
+				similarity = append(similarity, validation.CompareDungeons(previous, artifact).Score)
+			}
+			previous = artifact
+		}
+	}
+	elapsed := time.Since(start)
+
+	agg := validation.AggregateReports(recorder.reports)
+	fmt.Print(validation.AggregateSummary(agg))
+	if errored > 0 {
+		fmt.Printf("\nGeneration errors (no report): %d\n", errored)
+	}
+	if len(similarity) > 0 {
+		d := validation.NewDistribution(similarity)
+		fmt.Printf("\nSimilarity of consecutive seeds: mean %.3f (min %.3f, max %.3f)\n", d.Mean, d.Min, d.Max)
+	}
+
+	filename := filepath.Join(*outputDir, fmt.Sprintf("aggregate_%d_%d.json", baseSeed, *reportN))
+	if err := validation.SaveAggregateToFile(agg, filename); err != nil {
+		return withCode(exitExport, fmt.Errorf("failed to export aggregate report: %w", err))
+	}
+	recordFiles(filename)
+	if *verbose {
+		info, _ := os.Stat(filename)
+		fmt.Printf("Wrote %d bytes to %s\n", info.Size(), filename)
+	}
+
+	fmt.Printf("\nAggregated %d seeds (%d-%d) in %v\n", *reportN, baseSeed, baseSeed+uint64(*reportN)-1, elapsed)
+
+	// Seeds that errored before validation count as failures
+	passRate := 0.0
+	if *reportN > 0 {
+		passRate = float64(agg.Passed) / float64(*reportN)
+	}
+	if passRate < *minPass {
+		return withCode(exitUnsatisfied, fmt.Errorf("pass rate %.1f%% is below required %.1f%%", passRate*100, *minPass*100))
+	}
+	return nil
+}
+
+// openLedger wraps gen to record its requests in the -ledger file, if one
+// was given. The returned function closes the ledger, warning on stderr
+// about requests that could not be recorded.
+func openLedger(gen dungeon.Generator) (dungeon.Generator, func(), error) {
+	if *ledgerPath == "" {
+		return gen, func() {}, nil
+	}
+	ledger, err := dungeon.OpenLedger(*ledgerPath, dungeon.LedgerOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	closeLedger := func() {
+		err := errors.Join(ledger.Err(), ledger.Close())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record ledger: %v\n", err)
+		}
+	}
+	return dungeon.LedgerGenerator(gen, ledger), closeLedger, nil
+}
+
+// runLedger summarizes the failure rates of -ledger files, overall and by
+// config, worst config first.
+func runLedger(args []string) error {
+	fs := flag.NewFlagSet("ledger", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the summary as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("ledger needs at least one ledger file")
+	}
+
+	var entries []dungeon.LedgerEntry
+	for _, path := range fs.Args() {
+		read, err := dungeon.LoadLedger(path)
+		if err != nil {
+			return fmt.Errorf("reading ledger: %w", err)
+		}
+		entries = append(entries, read...)
+	}
+	summary := dungeon.SummarizeLedger(entries)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+	if summary.Requests == 0 {
+		fmt.Println("No requests recorded")
+		return nil
+	}
+	fmt.Printf("%d requests from %s to %s\n", summary.Requests, summary.From.Format(time.RFC3339), summary.To.Format(time.RFC3339))
+	fmt.Printf("Failures: %d (%.1f%%)  %s\n", summary.Failures, summary.FailureRate*100, outcomeCounts(summary.Outcomes))
+	fmt.Printf("\n%-16s %8s %8s %7s %10s %10s  %s\n", "CONFIG", "REQUESTS", "FAILURES", "RATE", "MEAN MS", "MAX MS", "OUTCOMES")
+	for _, c := range summary.Configs {
+		hash := c.ConfigHash
+		if len(hash) > 16 {
+			hash = hash[:16]
+		}
+		fmt.Printf("%-16s %8d %8d %6.1f%% %10.1f %10.1f  %s\n", hash, c.Requests, c.Failures, c.FailureRate*100, c.MeanDuration, c.MaxDuration, outcomeCounts(c.Outcomes))
+	}
+	return nil
+}
+
+// outcomeCounts formats ledger outcome counts as "name=count" pairs, most
+// frequent first.
+func outcomeCounts(outcomes map[dungeon.LedgerOutcome]int) string {
+	names := make([]dungeon.LedgerOutcome, 0, len(outcomes))
+	for name := range outcomes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if outcomes[names[i]] != outcomes[names[j]] {
+			return outcomes[names[i]] > outcomes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, outcomes[name])
+	}
+	return strings.Join(parts, " ")
+}
 
-	if *format == "svg" || *format == "all" {
-		if err := exportSVG(artifact, baseName); err != nil {
+// runMigrate upgrades config files to the current schema version. A single
+// file is printed to stdout unless -write is given; warnings go to stderr.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	write := fs.Bool("write", false, "Rewrite the config files in place")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		return fmt.Errorf("migrate needs at least one config file")
+	}
+	if len(paths) > 1 && !*write {
+		return fmt.Errorf("migrating several config files requires -write")
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading config file: %w", err)
+		}
+		migrated, warnings, err := dungeon.MigrateConfig(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, warning)
+		}
+
+		if !*write {
+			_, err := os.Stdout.Write(migrated)
 			return err
 		}
+		if bytes.Equal(migrated, data) {
+			continue
+		}
+		// Rewrite atomically so an interrupted migration keeps the old
+		// config, and keep the file's permissions
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("writing config file: %w", err)
+		}
+		if err := dungeon.WriteFile(path, migrated, dungeon.WithFileMode(info.Mode().Perm())); err != nil {
+			return fmt.Errorf("writing config file: %w", err)
+		}
+		fmt.Printf("Migrated %s to config version %d\n", path, dungeon.CurrentConfigVersion)
+	}
+	return nil
+}
+
+// runConformance generates a config corpus and compares its artifact
+// hashes with recorded ones, or records them with -update.
+func runConformance(args []string) error {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	corpus := fs.String("corpus", filepath.Join("testdata", "conformance", "corpus"), "Directory of YAML configs to generate")
+	seeds := fs.Int("seeds", conformance.DefaultSeeds, "Consecutive seeds generated per config")
+	hashesPath := fs.String("hashes", filepath.Join("testdata", "conformance", "hashes.json"), "Recorded hashes to verify against")
+	update := fs.Bool("update", false, "Record the hashes of this platform instead of verifying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("conformance takes no arguments, got %q", fs.Arg(0))
+	}
+
+	cases, err := conformance.Corpus(*corpus, *seeds)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	got, err := conformance.Run(ctx, dungeon.NewGeneratorWithValidator(validation.NewValidator()), cases)
+	if err != nil {
+		return err
+	}
+
+	if *update {
+		if err := got.Save(*hashesPath); err != nil {
+			return fmt.Errorf("writing hashes: %w", err)
+		}
+		fmt.Printf("Recorded %d cases on %s (%s) to %s\n", len(got.Cases), got.Platform, got.GoVersion, *hashesPath)
+		return nil
 	}
 
-	fmt.Printf("Successfully generated dungeon (seed=%d) in %v\n", cfg.Seed, elapsed)
+	want, err := conformance.Load(*hashesPath)
+	if err != nil {
+		return fmt.Errorf("reading hashes: %w", err)
+	}
+	mismatches := conformance.Verify(want, got)
+	for _, m := range mismatches {
+		fmt.Println(m)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d cases differ from the hashes recorded on %s (%s)", len(mismatches), want.Platform, want.GoVersion)
+	}
+	fmt.Printf("%d cases match the hashes recorded on %s (%s)\n", len(got.Cases), want.Platform, want.GoVersion)
 	return nil
 }
 
 // exportJSON exports the artifact to JSON format
-func exportJSON(artifact *dungeon.Artifact, baseName string) error {
+func exportJSON(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
 	filename := filepath.Join(*outputDir, baseName+".json")
 	if *verbose {
-		fmt.Printf("Exporting JSON to %s\n", filename)
+		fmt.Fprintf(logOut, "Exporting JSON to %s\n", filename)
 	}
 
-	if err := export.SaveJSONToFile(artifact, filename); err != nil {
+	if err := export.SaveJSONToFile(artifact, filename, export.WithContext(ctx)); err != nil {
 		return fmt.Errorf("failed to export JSON: %w", err)
 	}
+	recordFiles(filename)
 
 	if *verbose {
 		info, _ := os.Stat(filename)
-		fmt.Printf("  Wrote %d bytes\n", info.Size())
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
 	}
 
 	return nil
 }
 
 // exportTMJ exports the artifact to Tiled Map JSON format
-func exportTMJ(artifact *dungeon.Artifact, baseName string) error {
+func exportTMJ(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
 	filename := filepath.Join(*outputDir, baseName+".tmj")
 	if *verbose {
-		fmt.Printf("Exporting TMJ to %s\n", filename)
+		fmt.Fprintf(logOut, "Exporting TMJ to %s\n", filename)
 	}
 
 	// Compress tile data for efficiency
-	compress := true
-	if err := export.SaveArtifactToTMJFile(artifact, filename, compress); err != nil {
+	if err := export.SaveArtifactToTMJFileWithOptions(artifact, filename, export.DefaultTMJOptions(), export.WithContext(ctx)); err != nil {
 		return fmt.Errorf("failed to export TMJ: %w", err)
 	}
+	recordFiles(filename)
 
 	if *verbose {
 		info, _ := os.Stat(filename)
-		fmt.Printf("  Wrote %d bytes\n", info.Size())
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
 	}
 
 	return nil
 }
 
 // exportSVG exports the artifact to SVG visualization format
-func exportSVG(artifact *dungeon.Artifact, baseName string) error {
+func exportSVG(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
 	filename := filepath.Join(*outputDir, baseName+".svg")
 	if *verbose {
-		fmt.Printf("Exporting SVG to %s\n", filename)
+		fmt.Fprintf(logOut, "Exporting SVG to %s\n", filename)
+	}
+
+	if err := export.SaveSVGToFile(artifact, filename, svgOptions(artifact), export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export SVG: %w", err)
+	}
+	recordFiles(filename)
+
+	if *verbose {
+		info, _ := os.Stat(filename)
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
 	}
 
+	return nil
+}
+
+// exportOptions returns the options passed to registered exporters
+func exportOptions(ctx context.Context, artifact *dungeon.Artifact, cfg *dungeon.Config) export.Options {
+	opts := export.Options{Title: svgOptions(artifact).Title, Config: cfg, Context: ctx}
+	if len(cfg.Themes) > 0 {
+		opts.Theme = cfg.Themes[0]
+	}
+	return opts
+}
+
+// exportRegistered exports the artifact with an exporter from the registry,
+// such as a downstream project's format
+func exportRegistered(ctx context.Context, artifact *dungeon.Artifact, cfg *dungeon.Config, baseName, name string) error {
+	filename := filepath.Join(*outputDir, baseName+export.Extension(name))
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting %s to %s\n", name, filename)
+	}
+
+	exporter, _ := export.Lookup(name)
+	data, err := exporter.Export(artifact, exportOptions(ctx, artifact, cfg))
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	if err := dungeon.WriteFile(filename, data, dungeon.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	recordFiles(filename)
+
+	if *verbose {
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", len(data))
+	}
+
+	return nil
+}
+
+// svgOptions returns the options of the SVG export, titled with the seed
+func svgOptions(artifact *dungeon.Artifact) export.SVGOptions {
 	opts := export.DefaultSVGOptions()
 	opts.Title = fmt.Sprintf("Dungeon (seed=%d)", artifact.ADG.Graph.Seed)
+	return opts
+}
 
-	if err := export.SaveSVGToFile(artifact, filename, opts); err != nil {
-		return fmt.Errorf("failed to export SVG: %w", err)
+// gltfOptions returns the options of the glTF export, naming material slots
+// after the primary theme
+func gltfOptions(themes []string) export.GLTFOptions {
+	opts := export.DefaultGLTFOptions()
+	if len(themes) > 0 {
+		opts.Theme = themes[0]
+	}
+	return opts
+}
+
+// objOptions returns the options of the OBJ export, naming materials after
+// the primary theme
+func objOptions(themes []string) export.OBJOptions {
+	opts := export.DefaultOBJOptions()
+	if len(themes) > 0 {
+		opts.Theme = themes[0]
+	}
+	return opts
+}
+
+// exportGLTF exports the artifact as extruded 3D geometry in glTF format
+func exportGLTF(ctx context.Context, artifact *dungeon.Artifact, baseName string, themes []string) error {
+	filename := filepath.Join(*outputDir, baseName+".gltf")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting glTF to %s\n", filename)
+	}
+
+	if err := export.SaveGLTFToFile(artifact, filename, gltfOptions(themes), export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export glTF: %w", err)
+	}
+	recordFiles(filename)
+
+	if *verbose {
+		info, _ := os.Stat(filename)
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
+	}
+
+	return nil
+}
+
+// exportOBJ exports per-room and per-corridor blockout meshes in OBJ format
+func exportOBJ(ctx context.Context, artifact *dungeon.Artifact, baseName string, themes []string) error {
+	filename := filepath.Join(*outputDir, baseName+".obj")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting OBJ to %s\n", filename)
 	}
 
+	if err := export.SaveOBJToFile(artifact, filename, objOptions(themes), export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export OBJ: %w", err)
+	}
+	recordFiles(filename)
+
 	if *verbose {
 		info, _ := os.Stat(filename)
-		fmt.Printf("  Wrote %d bytes\n", info.Size())
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
+	}
+
+	return nil
+}
+
+// exportCollision exports the artifact's collision layer and merged shapes
+func exportCollision(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	filename := filepath.Join(*outputDir, baseName+".collision.json")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting collision to %s\n", filename)
+	}
+
+	if err := export.SaveCollisionToFile(artifact, filename, export.DefaultCollisionOptions(), export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export collision: %w", err)
+	}
+	recordFiles(filename)
+
+	if *verbose {
+		info, _ := os.Stat(filename)
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
+	}
+
+	return nil
+}
+
+// exportHeightmap exports the artifact's per-tile elevation levels
+func exportHeightmap(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	filename := filepath.Join(*outputDir, baseName+".heightmap.json")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting heightmap to %s\n", filename)
+	}
+
+	if err := export.SaveHeightmapToFile(artifact, filename, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export heightmap: %w", err)
+	}
+	recordFiles(filename)
+
+	if *verbose {
+		info, _ := os.Stat(filename)
+		fmt.Fprintf(logOut, "  Wrote %d bytes\n", info.Size())
+	}
+
+	return nil
+}
+
+// exportStats exports per-room and per-dungeon statistics as CSV
+func exportStats(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	roomsFile := filepath.Join(*outputDir, baseName+".rooms.csv")
+	dungeonFile := filepath.Join(*outputDir, baseName+".stats.csv")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting stats to %s and %s\n", roomsFile, dungeonFile)
 	}
 
+	if err := export.SaveStatsCSVToFilesWithOptions(roomsFile, dungeonFile, []*dungeon.Artifact{artifact}, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export stats: %w", err)
+	}
+	recordFiles(roomsFile, dungeonFile)
+
+	return nil
+}
+
+// exportGates exports the gate audit as JSON and as a Markdown table
+func exportGates(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	jsonFile := filepath.Join(*outputDir, baseName+".gates.json")
+	mdFile := filepath.Join(*outputDir, baseName+".gates.md")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting gate audit to %s and %s\n", jsonFile, mdFile)
+	}
+
+	if err := export.SaveGateAuditToFiles(artifact, jsonFile, mdFile, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export gate audit: %w", err)
+	}
+	recordFiles(jsonFile, mdFile)
+
+	return nil
+}
+
+// exportSummary exports the one-page summary as JSON and Markdown
+func exportSummary(ctx context.Context, artifact *dungeon.Artifact, cfg *dungeon.Config, baseName string) error {
+	jsonFile := filepath.Join(*outputDir, baseName+".summary.json")
+	mdFile := filepath.Join(*outputDir, baseName+".summary.md")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting summary to %s and %s\n", jsonFile, mdFile)
+	}
+
+	if err := export.SaveSummaryToFiles(artifact, cfg, jsonFile, mdFile, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export summary: %w", err)
+	}
+	recordFiles(jsonFile, mdFile)
+
+	return nil
+}
+
+// exportAnchors exports the save-game anchors of doors, chests, bosses and
+// other stateful entities
+func exportAnchors(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	anchorsFile := filepath.Join(*outputDir, baseName+".anchors.json")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting save-game anchors to %s\n", anchorsFile)
+	}
+
+	if err := export.SaveAnchorsToFile(artifact, anchorsFile, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export anchors: %w", err)
+	}
+	recordFiles(anchorsFile)
+
+	return nil
+}
+
+// exportRoute exports the optimal completion route as an ordered room list
+// and as an SVG overlay on the dungeon graph
+func exportRoute(ctx context.Context, artifact *dungeon.Artifact, baseName string) error {
+	routeFile := filepath.Join(*outputDir, baseName+".route.json")
+	svgFile := filepath.Join(*outputDir, baseName+".route.svg")
+	if *verbose {
+		fmt.Fprintf(logOut, "Exporting speedrun route to %s and %s\n", routeFile, svgFile)
+	}
+
+	route, err := validation.FindSpeedrunRoute(artifact.ADG.Graph, artifact.Layout)
+	if err != nil {
+		return fmt.Errorf("failed to find speedrun route: %w", err)
+	}
+
+	if err := validation.SaveRouteToFile(route, routeFile, dungeon.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export route: %w", err)
+	}
+
+	opts := export.DefaultSVGOptions()
+	opts.Title = fmt.Sprintf("Speedrun Route (seed=%d): %d rooms (%d revisits), %d tiles",
+		artifact.ADG.Graph.Seed, route.RoomCount, route.Revisits, route.TileLength)
+	opts.Route = route.Rooms
+	if err := export.SaveSVGToFile(artifact, svgFile, opts, export.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to export route SVG: %w", err)
+	}
+	recordFiles(routeFile, svgFile)
+
 	return nil
 }
 
 // printStats prints dungeon statistics
 func printStats(artifact *dungeon.Artifact) {
-	fmt.Println("\nDungeon Statistics:")
-	fmt.Printf("  Rooms: %d\n", len(artifact.ADG.Graph.Rooms))
-	fmt.Printf("  Connectors: %d\n", len(artifact.ADG.Graph.Connectors))
+	fmt.Fprintln(logOut, "\nDungeon Statistics:")
+	fmt.Fprintf(logOut, "  Rooms: %d\n", len(artifact.ADG.Graph.Rooms))
+	fmt.Fprintf(logOut, "  Connectors: %d\n", len(artifact.ADG.Graph.Connectors))
 
 	if artifact.TileMap != nil {
-		fmt.Printf("  Tile Map: %dx%d tiles\n", artifact.TileMap.Width, artifact.TileMap.Height)
+		fmt.Fprintf(logOut, "  Tile Map: %dx%d tiles\n", artifact.TileMap.Width, artifact.TileMap.Height)
 	}
 
 	if artifact.Content != nil {
-		fmt.Printf("  Spawns: %d\n", len(artifact.Content.Spawns))
-		fmt.Printf("  Loot: %d\n", len(artifact.Content.Loot))
-		fmt.Printf("  Puzzles: %d\n", len(artifact.Content.Puzzles))
-		fmt.Printf("  Secrets: %d\n", len(artifact.Content.Secrets))
+		fmt.Fprintf(logOut, "  Spawns: %d\n", len(artifact.Content.Spawns))
+		fmt.Fprintf(logOut, "  Loot: %d\n", len(artifact.Content.Loot))
+		fmt.Fprintf(logOut, "  Puzzles: %d\n", len(artifact.Content.Puzzles))
+		fmt.Fprintf(logOut, "  Secrets: %d\n", len(artifact.Content.Secrets))
+		fmt.Fprintf(logOut, "  Traps: %d\n", len(artifact.Content.Traps))
 	}
 
 	if artifact.Metrics != nil {
-		fmt.Println("\nMetrics:")
-		fmt.Printf("  BranchingFactor: %.3f\n", artifact.Metrics.BranchingFactor)
-		fmt.Printf("  PathLength: %d\n", artifact.Metrics.PathLength)
-		fmt.Printf("  CycleCount: %d\n", artifact.Metrics.CycleCount)
-		fmt.Printf("  PacingDeviation: %.3f\n", artifact.Metrics.PacingDeviation)
-		fmt.Printf("  SecretFindability: %.3f\n", artifact.Metrics.SecretFindability)
+		fmt.Fprintln(logOut, "\nMetrics:")
+		fmt.Fprintf(logOut, "  BranchingFactor: %.3f\n", artifact.Metrics.BranchingFactor)
+		fmt.Fprintf(logOut, "  PathLength: %d\n", artifact.Metrics.PathLength)
+		fmt.Fprintf(logOut, "  CycleCount: %d\n", artifact.Metrics.CycleCount)
+		fmt.Fprintf(logOut, "  PacingDeviation: %.3f\n", artifact.Metrics.PacingDeviation)
+		fmt.Fprintf(logOut, "  SecretFindability: %.3f\n", artifact.Metrics.SecretFindability)
+		fmt.Fprintf(logOut, "  SpeedrunRoute: %d rooms (%d revisits), %d tiles\n", artifact.Metrics.SpeedrunRooms, artifact.Metrics.SpeedrunRevisits, artifact.Metrics.SpeedrunTiles)
+		fmt.Fprintf(logOut, "  CorridorLength: %d tiles (variance %.1f)\n", artifact.Metrics.CorridorLength, artifact.Metrics.CorridorLengthVariance)
+		fmt.Fprintf(logOut, "  CorridorCrossings: %d\n", artifact.Metrics.CorridorCrossings)
+		fmt.Fprintf(logOut, "  BoundsUtilization: %.3f\n", artifact.Metrics.BoundsUtilization)
+		fmt.Fprintf(logOut, "  RoomSpacing: %.1f\n", artifact.Metrics.RoomSpacing)
 	}
 
 	if artifact.Debug != nil && artifact.Debug.Report != nil {
 		report := artifact.Debug.Report
-		fmt.Printf("\nValidation: %s\n", validationStatus(report.Passed))
+		fmt.Fprintf(logOut, "\nValidation: %s\n", validationStatus(report.Passed))
 		if len(report.Warnings) > 0 {
-			fmt.Printf("  Warnings: %d\n", len(report.Warnings))
+			fmt.Fprintf(logOut, "  Warnings: %d\n", len(report.Warnings))
 		}
 		if len(report.Errors) > 0 {
-			fmt.Printf("  Errors: %d\n", len(report.Errors))
+			fmt.Fprintf(logOut, "  Errors: %d\n", len(report.Errors))
+		}
+	}
+
+	if len(artifact.Warnings) > 0 {
+		fmt.Fprintln(logOut, "\nWarnings:")
+		for _, w := range artifact.Warnings {
+			fmt.Fprintf(logOut, "  %s\n", w)
 		}
 	}
 }
@@ -258,7 +1183,7 @@ func validationStatus(passed bool) string {
 
 // printUsage prints basic usage information
 func printUsage() {
-	fmt.Fprintln(os.Stderr, "\nUsage: dungeongen -config <config.yaml> [options]")
+	fmt.Fprintln(os.Stderr, "\nUsage: dungeongen -config <config.yaml> | -preset <name> [options]")
 	fmt.Fprintln(os.Stderr, "\nRun 'dungeongen -help' for detailed help")
 }
 
@@ -268,16 +1193,47 @@ func printHelp() {
 	fmt.Println("A command-line tool for generating procedural dungeons.")
 	fmt.Println("\nUsage:")
 	fmt.Println("  dungeongen -config <config.yaml> [options]")
-	fmt.Println("\nRequired Flags:")
+	fmt.Println("  dungeongen -preset <name> [options]")
+	fmt.Println("  dungeongen migrate [-write] <config.yaml>...")
+	fmt.Println("  dungeongen conformance [-corpus dir] [-seeds n] [-hashes file] [-update]")
+	fmt.Println("  dungeongen ledger [-json] <ledger.jsonl>...")
+	fmt.Println("\nRequired Flags (one of):")
 	fmt.Println("  -config string")
-	fmt.Println("        Path to YAML configuration file")
+	fmt.Println("        Path to YAML configuration file, or - to read it from stdin")
+	fmt.Println("  -preset string")
+	fmt.Printf("        Built-in config preset: %s\n", strings.Join(dungeon.Presets(), ", "))
 	fmt.Println("\nOptional Flags:")
-	fmt.Println("  -output string")
-	fmt.Println("        Output directory for generated files (default: current directory)")
+	fmt.Println("  -output, -o string")
+	fmt.Println("        Output directory for generated files (default: current directory), or - to")
+	fmt.Println("        write a single format to stdout with messages on stderr")
 	fmt.Println("  -format string")
-	fmt.Println("        Export format: json, tmj, svg, or all (default: json)")
+	fmt.Printf("        Export format: %s, or all (default: json)\n", formatList())
 	fmt.Println("  -seed uint")
 	fmt.Println("        Override the seed from config (0 = use config seed) (default: 0)")
+	fmt.Println("  -challenge string")
+	fmt.Println("        Derive the seed of the current daily, weekly or monthly challenge (UTC) from the config seed")
+	fmt.Println("  -stop-after string")
+	fmt.Printf("        End the pipeline after this stage (%s) and export\n", strings.Join(dungeon.PipelineStages, ", "))
+	fmt.Println("        what exists; -format all skips formats that need later stages")
+	fmt.Println("  -recarve string")
+	fmt.Println("        Carve this saved artifact JSON again instead of generating, keeping its graph")
+	fmt.Println("        and layout; needs the config it was generated with")
+	fmt.Println("  -tile-size int")
+	fmt.Println("        With -recarve, the tile size in pixels (default: the artifact's)")
+	fmt.Println("  -decorations string")
+	fmt.Println("        With -recarve, a YAML file of decoration rules by theme name")
+	fmt.Println("  -recarve-content")
+	fmt.Println("        With -recarve, place content again instead of keeping the artifact's")
+	fmt.Println("  -report int")
+	fmt.Println("        Generate N consecutive seeds and print an aggregate validation report (default: 0)")
+	fmt.Println("  -min-pass-rate float")
+	fmt.Println("        In report mode, fail if the pass rate is below this fraction (default: 0)")
+	fmt.Println("  -list-presets")
+	fmt.Println("        List the built-in config presets and exit")
+	fmt.Println("  -result-json string")
+	fmt.Println("        Write a machine-readable run summary (files written, metrics, validation status)")
+	fmt.Println("  -ledger string")
+	fmt.Println("        Append a JSONL record of every generation request to this file, rotated at 64 MiB")
 	fmt.Println("  -verbose")
 	fmt.Println("        Enable verbose output")
 	fmt.Println("  -version")
@@ -287,10 +1243,35 @@ func printHelp() {
 	fmt.Println("\nExamples:")
 	fmt.Println("  # Generate dungeon with default JSON export")
 	fmt.Println("  dungeongen -config dungeon.yaml")
+	fmt.Println("\n  # Generate from a built-in preset, no config file needed")
+	fmt.Println("  dungeongen -preset zelda_small -format svg")
 	fmt.Println("\n  # Generate with custom seed and all export formats")
 	fmt.Println("  dungeongen -config dungeon.yaml -seed 12345 -format all -output ./out")
+	fmt.Println("\n  # Generate today's daily challenge")
+	fmt.Println("  dungeongen -config dungeon.yaml -challenge daily")
+	fmt.Println("\n  # Iterate on the graph topology only, rendered with Graphviz")
+	fmt.Println("  dungeongen -config dungeon.yaml -stop-after synthesis -format dot -o - | dot -Tpng > graph.png")
+	fmt.Println("\n  # Carve a saved dungeon again with 32 pixel tiles and new decorations")
+	fmt.Println("  dungeongen -config dungeon.yaml -recarve dungeon_42.json -tile-size 32 -decorations decor.yaml -format tmj")
+	fmt.Println("\n  # Aggregate metrics over 100 seeds and require a 95% pass rate")
+	fmt.Println("  dungeongen -config dungeon.yaml -report 100 -min-pass-rate 0.95")
+	fmt.Println("\n  # Upgrade config files from an older schema version in place")
+	fmt.Println("  dungeongen migrate -write configs/*.yaml")
+	fmt.Println("\n  # Check this platform generates the dungeons recorded in testdata/conformance")
+	fmt.Println("  dungeongen conformance")
+	fmt.Println("\n  # Record a report run in a ledger and summarize its failure rates by config")
+	fmt.Println("  dungeongen -config dungeon.yaml -report 100 -ledger gen.jsonl")
+	fmt.Println("  dungeongen ledger gen.jsonl")
+	fmt.Println("\n  # Pipe a config in and the TMJ map out")
+	fmt.Println("  cat dungeon.yaml | dungeongen -config - -format tmj -o - > map.tmj")
 	fmt.Println("\n  # Generate SVG visualization with verbose output")
 	fmt.Println("  dungeongen -config dungeon.yaml -format svg -verbose")
+	fmt.Println("\nExit Codes:")
+	fmt.Println("  0  Success")
+	fmt.Println("  1  Usage or other error")
+	fmt.Println("  2  Invalid config")
+	fmt.Println("  3  Hard constraints or quality gates not satisfied (or report pass rate below -min-pass-rate)")
+	fmt.Println("  4  Export error")
 	fmt.Println("\nConfiguration File:")
 	fmt.Println("  The YAML configuration file specifies dungeon parameters including:")
 	fmt.Println("  - Seed (for deterministic generation)")
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestRunExports_CancelLeavesNoPartialFiles cancels the exports while the
+// first job is running and checks that its file is never written and no
+// temporary files are left behind.
+func TestRunExports_CancelLeavesNoPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	saved := *outputDir
+	*outputDir = dir
+	defer func() { *outputDir = saved }()
+
+	cfg := &dungeon.Config{
+		Seed:          7,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 12},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 3},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+		OptionalRatio: 0.2,
+	}
+	artifact, err := dungeon.NewGeneratorWithValidator(validation.NewValidator()).Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs := []exportJob{
+		{"json", func(ctx context.Context) error {
+			cancel() // Interrupted while rendering
+			return exportJSON(ctx, artifact, "dungeon")
+		}},
+		{"tmj", func(ctx context.Context) error { return exportTMJ(ctx, artifact, "dungeon") }},
+		{"gates", func(ctx context.Context) error { return exportGates(ctx, artifact, "dungeon") }},
+		{"route", func(ctx context.Context) error { return exportRoute(ctx, artifact, "dungeon") }},
+	}
+
+	if err := runExports(ctx, jobs); !errors.Is(err, context.Canceled) {
+		t.Fatalf("runExports() error = %v, want context.Canceled", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "dungeon.json" {
+			t.Error("the cancelled JSON export wrote its file")
+		}
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+// setFlags sets command-line flags for one test, restoring them when it
+// ends, and starts the test with an empty run summary.
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	result = runResult{Version: version, Files: []string{}}
+	for name, value := range values {
+		saved := cli.Lookup(name).Value.String()
+		if err := cli.Set(name, value); err != nil {
+			t.Fatalf("setting -%s: %v", name, err)
+		}
+		t.Cleanup(func() { _ = cli.Set(name, saved) })
+	}
+	t.Cleanup(func() { result = runResult{Version: version, Files: []string{}} })
+}
+
+// TestExitCode checks the exit code of each kind of error a run can end
+// with, tagged or classified by the generator's sentinel errors.
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"untagged", errors.New("boom"), exitError},
+		{"usage", withCode(exitError, errors.New("bad flag")), exitError},
+		{"config", withCode(exitInvalidConfig, errors.New("bad config")), exitInvalidConfig},
+		{"export", withCode(exitExport, errors.New("disk full")), exitExport},
+		{"wrapped tag", fmt.Errorf("run: %w", withCode(exitExport, errors.New("disk full"))), exitExport},
+		{"tag wins over sentinel", withCode(exitExport, dungeon.ErrInvalidConfig), exitExport},
+		{"invalid config", fmt.Errorf("generation failed: %w", dungeon.ErrInvalidConfig), exitInvalidConfig},
+		{"constraints", fmt.Errorf("generation failed: %w", dungeon.ErrConstraintUnsatisfied), exitUnsatisfied},
+		{"quality gate", fmt.Errorf("generation failed: %w", dungeon.ErrQualityGate), exitUnsatisfied},
+		{"timeout", fmt.Errorf("generation failed: %w", dungeon.ErrTimeout), exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+			if _, ok := exitStatus[tt.want]; !ok {
+				t.Errorf("exit code %d has no status name", tt.want)
+			}
+		})
+	}
+	if withCode(exitExport, nil) != nil {
+		t.Error("withCode(nil) is not nil")
+	}
+}
+
+// TestRun_InvalidConfigResult runs the CLI on a config that fails
+// validation and checks it exits with exitInvalidConfig, writes no dungeon
+// and records the failure in the -result-json summary.
+func TestRun_InvalidConfigResult(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	config := "seed: 1\nsize: {roomsMin: 10, roomsMax: 12}\nbranching: {avg: 2.0, max: 3}\n" +
+		"pacing: {curve: LINEAR, variance: 0.1}\nthemes: [crypt]\noptionalRatio: 0.2\nvaults: 9\n"
+	if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resultFile := filepath.Join(dir, "result.json")
+	setFlags(t, map[string]string{
+		"config":      configFile,
+		"output":      filepath.Join(dir, "out"),
+		"result-json": resultFile,
+	})
+
+	// As main does
+	err := run()
+	if werr := saveResult(err, time.Now()); werr != nil {
+		t.Fatalf("saveResult() error = %v", werr)
+	}
+	if code := exitCode(err); code != exitInvalidConfig {
+		t.Fatalf("run() error = %v, exit code %d, want %d", err, code, exitInvalidConfig)
+	}
+
+	data, err := os.ReadFile(resultFile)
+	if err != nil {
+		t.Fatalf("reading result JSON: %v", err)
+	}
+	var got runResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("parsing result JSON: %v", err)
+	}
+	if got.ExitCode != exitInvalidConfig || got.Status != "invalid_config" {
+		t.Errorf("result exit code %d status %q, want %d %q", got.ExitCode, got.Status, exitInvalidConfig, "invalid_config")
+	}
+	if !strings.Contains(got.Error, "vaults") {
+		t.Errorf("result error = %q, want the validation failure", got.Error)
+	}
+	if len(got.Files) != 0 || got.Version != version || got.Format != "json" {
+		t.Errorf("result = %+v, want no files, version %s and format json", got, version)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("output directory created for an invalid config: %v", err)
+	}
+}
+
+// TestRun_Pipe feeds a config on stdin with -config - and writes the
+// dungeon to stdout with -output -, checking stdout holds only the artifact
+// JSON and every message, verbose ones included, goes to stderr.
+func TestRun_Pipe(t *testing.T) {
+	config := "seed: 7\nsize: {roomsMin: 10, roomsMax: 12}\nbranching: {avg: 2.0, max: 3}\n" +
+		"pacing: {curve: LINEAR, variance: 0.1}\nthemes: [crypt]\noptionalRatio: 0.2\n"
+	var out, logs bytes.Buffer
+	savedIn, savedOut, savedErr, savedLog := stdin, stdout, stderr, logOut
+	stdin, stdout, stderr = strings.NewReader(config), &out, &logs
+	defer func() { stdin, stdout, stderr, logOut = savedIn, savedOut, savedErr, savedLog }()
+	setFlags(t, map[string]string{"config": "-", "output": "-", "format": "json", "verbose": "true"})
+
+	if err := run(); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var artifact dungeon.Artifact
+	dec := json.NewDecoder(&out)
+	if err := dec.Decode(&artifact); err != nil {
+		t.Fatalf("stdout is not an artifact: %v", err)
+	}
+	if dec.More() {
+		t.Error("stdout has more after the artifact")
+	}
+	if artifact.ADG == nil || len(artifact.ADG.Rooms) < 10 {
+		t.Errorf("artifact from stdout has no dungeon: %+v", artifact.ADG)
+	}
+	if !strings.Contains(logs.String(), "Loading configuration from -") || !strings.Contains(logs.String(), "Successfully generated dungeon (seed=7)") {
+		t.Errorf("stderr = %q, want the progress messages", logs.String())
+	}
+	if len(result.Files) != 0 {
+		t.Errorf("files written = %v, want none", result.Files)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// Exit codes, so scripts and CI can tell failures apart without parsing
+// messages.
+const (
+	exitOK            = 0 // Dungeon generated and exported
+	exitError         = 1 // Usage or other error
+	exitInvalidConfig = 2 // Config could not be read, parsed or validated
+	exitUnsatisfied   = 3 // Hard constraints or quality gates not satisfied, or report pass rate too low
+	exitExport        = 4 // An output file could not be written
+)
+
+// exitStatus names each exit code in the result JSON.
+var exitStatus = map[int]string{
+	exitOK:            "ok",
+	exitError:         "error",
+	exitInvalidConfig: "invalid_config",
+	exitUnsatisfied:   "constraints_unsatisfied",
+	exitExport:        "export_error",
+}
+
+// codeError carries the exit code of a failure through run.
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// withCode tags err with an exit code. A nil err stays nil.
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{code: code, err: err}
+}
+
+// exitCode returns the exit code for the error a run ended with. Untagged
+// errors are classified by the generator's sentinel errors.
+func exitCode(err error) int {
+	var ce *codeError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, dungeon.ErrInvalidConfig):
+		return exitInvalidConfig
+	case errors.Is(err, dungeon.ErrConstraintUnsatisfied), errors.Is(err, dungeon.ErrQualityGate):
+		return exitUnsatisfied
+	}
+	return exitError
+}
+
+// runResult is the machine-readable summary of a run written by
+// -result-json.
+type runResult struct {
+	Version    string            `json:"version"`
+	ExitCode   int               `json:"exitCode"`
+	Status     string            `json:"status"` // Name of the exit code, e.g. "ok" or "invalid_config"
+	Error      string            `json:"error,omitempty"`
+	Seed       uint64            `json:"seed,omitempty"`
+	Format     string            `json:"format"`
+	Files      []string          `json:"files"` // Files written, sorted
+	ElapsedMS  int64             `json:"elapsedMs"`
+	Metrics    *dungeon.Metrics  `json:"metrics,omitempty"`
+	Validation *resultValidation `json:"validation,omitempty"`
+}
+
+// resultValidation is the validation outcome of the generated dungeon.
+type resultValidation struct {
+	Passed   bool     `json:"passed"`
+	Failed   []string `json:"failed,omitempty"` // Kinds of the unsatisfied hard constraints
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// result collects the run summary as run progresses. resultMu guards
+// Files, which concurrent exports append to.
+var (
+	result   = runResult{Version: version, Files: []string{}}
+	resultMu sync.Mutex
+)
+
+// recordFiles adds written files to the run summary.
+func recordFiles(files ...string) {
+	resultMu.Lock()
+	defer resultMu.Unlock()
+	result.Files = append(result.Files, files...)
+}
+
+// recordArtifact adds the metrics and validation report of a generated
+// dungeon to the run summary.
+func recordArtifact(artifact *dungeon.Artifact) {
+	result.Metrics = artifact.Metrics
+	if artifact.Debug == nil || artifact.Debug.Report == nil {
+		return
+	}
+	report := artifact.Debug.Report
+	result.Validation = &resultValidation{
+		Passed:   report.Passed,
+		Errors:   report.Errors,
+		Warnings: report.Warnings,
+	}
+}
+
+// writeResult completes the run summary with the outcome of the run and
+// writes it to path as indented JSON.
+func writeResult(path string, err error, started time.Time) error {
+	result.ExitCode = exitCode(err)
+	result.Status = exitStatus[result.ExitCode]
+	result.Format = *format
+	result.ElapsedMS = time.Since(started).Milliseconds()
+	sort.Strings(result.Files)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	// A dungeon rejected by validation still reports what failed
+	var cerr *dungeon.ConstraintError
+	if errors.As(err, &cerr) && result.Validation == nil {
+		result.Validation = &resultValidation{Errors: cerr.Errors}
+		for _, r := range cerr.Failed {
+			if r.Constraint != nil {
+				result.Validation.Failed = append(result.Validation.Failed, r.Constraint.Kind)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(&result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return dungeon.WriteFile(path, append(data, '\n'))
+}
@@ -63,7 +63,7 @@ func main() {
 		log.Printf("Warning: Failed to save JSON: %v", err)
 	}
 
-	if err := export.SaveArtifactToTMJFile(artifact, absBaseName+".tmj", true); err != nil {
+	if err := export.SaveArtifactToTMJFileWithOptions(artifact, absBaseName+".tmj", export.DefaultTMJOptions()); err != nil {
 		log.Printf("Warning: Failed to save TMJ: %v", err)
 	}
 
@@ -13,7 +13,7 @@ import (
 
 func main() {
 	// Load configuration - use an existing test config
-	cfg, err := dungeon.LoadConfig("../../testdata/seeds/small_crypt.yaml")
+	cfg, err := dungeon.LoadConfig("../../pkg/dungeon/testseeds/small_crypt.yaml")
 	if err != nil {
 		log.Fatal(err)
 	}
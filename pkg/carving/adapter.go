@@ -18,22 +18,24 @@ func NewGraphAdapter(rooms map[string]*graph.Room, connectors map[string]*graph.
 	}
 }
 
-// GetRoom retrieves a room by ID.
+// GetRoom retrieves a room by ID. The adapter is returned by value so
+// lookups in the carving loops do not allocate.
 func (g *GraphAdapter) GetRoom(id string) Room {
 	room := g.rooms[id]
 	if room == nil {
 		return nil
 	}
-	return &RoomAdapter{room: room}
+	return RoomAdapter{room: room}
 }
 
-// GetConnector retrieves a connector by ID.
+// GetConnector retrieves a connector by ID. The adapter is returned by value
+// so lookups in the carving loops do not allocate.
 func (g *GraphAdapter) GetConnector(id string) Connector {
 	conn := g.connectors[id]
 	if conn == nil {
 		return nil
 	}
-	return &ConnectorAdapter{conn: conn}
+	return ConnectorAdapter{conn: conn}
 }
 
 // GetRoomIDs returns all room IDs.
@@ -60,47 +62,62 @@ type RoomAdapter struct {
 }
 
 // GetID returns the room ID.
-func (r *RoomAdapter) GetID() string {
+func (r RoomAdapter) GetID() string {
 	return r.room.ID
 }
 
 // GetSize returns the room size.
-func (r *RoomAdapter) GetSize() RoomSize {
+func (r RoomAdapter) GetSize() RoomSize {
 	return RoomSize(r.room.Size)
 }
 
+// GetTags returns the room tags.
+func (r RoomAdapter) GetTags() map[string]string {
+	return r.room.Tags
+}
+
+// GetArchetype returns the name of the room archetype, such as "Boss".
+func (r RoomAdapter) GetArchetype() string {
+	return r.room.Archetype.String()
+}
+
 // ConnectorAdapter adapts graph.Connector to the carving.Connector interface.
 type ConnectorAdapter struct {
 	conn *graph.Connector
 }
 
 // GetID returns the connector ID.
-func (c *ConnectorAdapter) GetID() string {
+func (c ConnectorAdapter) GetID() string {
 	return c.conn.ID
 }
 
 // GetFrom returns the from room ID.
-func (c *ConnectorAdapter) GetFrom() string {
+func (c ConnectorAdapter) GetFrom() string {
 	return c.conn.From
 }
 
 // GetTo returns the to room ID.
-func (c *ConnectorAdapter) GetTo() string {
+func (c ConnectorAdapter) GetTo() string {
 	return c.conn.To
 }
 
 // GetType returns the connector type.
-func (c *ConnectorAdapter) GetType() ConnectorType {
+func (c ConnectorAdapter) GetType() ConnectorType {
 	return ConnectorType(c.conn.Type)
 }
 
 // GetGate returns the gate information.
-func (c *ConnectorAdapter) GetGate() *Gate {
+func (c ConnectorAdapter) GetGate() *Gate {
 	if c.conn.Gate == nil {
 		return nil
 	}
-	return &Gate{
+	gate := &Gate{
 		Type:  c.conn.Gate.Type,
 		Value: c.conn.Gate.Value,
+		Any:   c.conn.Gate.Any,
+	}
+	for _, req := range c.conn.Gate.Requirements {
+		gate.Requirements = append(gate.Requirements, GateRequirement{Type: req.Type, Value: req.Value})
 	}
+	return gate
 }
@@ -0,0 +1,282 @@
+package carving
+
+import (
+	"fmt"
+	"sort"
+)
+
+// transitionRoomMix is the share of a transition room's floor that takes on
+// the neighbouring biome it blends towards.
+const transitionRoomMix = 1.0 / 3.0
+
+// bayer4 is a 4x4 ordered-dither matrix. Comparing (value+0.5)/16 against a
+// blend fraction spreads the blended tiles evenly instead of in stripes.
+var bayer4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ditherThreshold returns the dither threshold (0.0-1.0) of a tile.
+func ditherThreshold(x, y int) float64 {
+	return (float64(bayer4[y&3][x&3]) + 0.5) / 16
+}
+
+// BiomePalette returns the distinct "biome" tags of the laid-out rooms in
+// sorted order. The "biome" tile layer stores 1 + a biome's palette index.
+func BiomePalette(g Graph, layout *Layout) []string {
+	seen := make(map[string]bool)
+	palette := []string{}
+	for id := range layout.Poses {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+		if biome := room.GetTags()["biome"]; biome != "" && !seen[biome] {
+			seen[biome] = true
+			palette = append(palette, biome)
+		}
+	}
+	sort.Strings(palette)
+	return palette
+}
+
+// BuildBiomeLayer derives a "biome" tile layer for the carved map. Each floor
+// tile stores 1 + the palette index (see BiomePalette) of the biome it is
+// drawn with; 0 means no biome.
+//
+// Seams between biomes are blended rather than cut on a single tile:
+//   - Corridors joining rooms of different biomes fade from the From room's
+//     biome to the To room's biome along their length
+//   - Rooms tagged "biome_blend" mix a share of the blended biome into their
+//     floor, so zone borders get transition rooms with mixed decoration
+//   - Remaining floor tiles (wide corridors, doorways) take the biome of the
+//     nearest labelled floor tile
+//
+// Blending uses ordered dithering, so the layer is deterministic.
+func BuildBiomeLayer(tm *TileMap, g Graph, layout *Layout) *Layer {
+	data := make([]uint32, tm.Width*tm.Height)
+	floor := tm.Layers["floor"].Data
+
+	index := make(map[string]uint32)
+	for i, biome := range BiomePalette(g, layout) {
+		index[biome] = uint32(i + 1)
+	}
+
+	isFloor := func(x, y int) bool {
+		return GetTile(floor, x, y, tm.Width, tm.Height) == uint32(TileFloor)
+	}
+
+	// Rooms, with transition rooms dithered towards their blend biome
+	roomIDs := make([]string, 0, len(layout.Poses))
+	for id := range layout.Poses {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+		tags := room.GetTags()
+		own, blend := index[tags["biome"]], index[tags["biome_blend"]]
+		if own == 0 {
+			continue
+		}
+
+		b := RoomBounds(room.GetSize(), layout.Poses[id])
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if !isFloor(x, y) {
+					continue
+				}
+				value := own
+				if blend != 0 && ditherThreshold(x, y) < transitionRoomMix {
+					value = blend
+				}
+				data[y*tm.Width+x] = value
+			}
+		}
+	}
+
+	// Corridors fade from one end's biome to the other's
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	for _, id := range connIDs {
+		conn := g.GetConnector(id)
+		if conn == nil {
+			continue
+		}
+		from, to := roomBiome(g, conn.GetFrom(), index), roomBiome(g, conn.GetTo(), index)
+		if from == 0 && to == 0 {
+			continue
+		}
+
+		tiles := pathTiles(layout.CorridorPaths[id], floor, tm.Width, tm.Height)
+		for i, t := range tiles {
+			idx := t.Y*tm.Width + t.X
+			if data[idx] != 0 {
+				continue // Room tiles and earlier corridors keep their biome
+			}
+			value := from
+			progress := (float64(i) + 0.5) / float64(len(tiles))
+			if from == 0 || (to != 0 && ditherThreshold(t.X, t.Y) < progress) {
+				value = to
+			}
+			data[idx] = value
+		}
+	}
+
+	// Flood the remaining floor from labelled tiles, in row-major order
+	queue := make([]Point, 0, len(data))
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			if data[y*tm.Width+x] != 0 {
+				queue = append(queue, Point{X: x, Y: y})
+			}
+		}
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range []Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}} {
+			n := Point{X: p.X + d.X, Y: p.Y + d.Y}
+			if !isFloor(n.X, n.Y) || data[n.Y*tm.Width+n.X] != 0 {
+				continue
+			}
+			data[n.Y*tm.Width+n.X] = data[p.Y*tm.Width+p.X]
+			queue = append(queue, n)
+		}
+	}
+
+	return &Layer{
+		ID:      len(tm.Layers),
+		Name:    "biome",
+		Type:    "tilelayer",
+		Visible: false,
+		Opacity: 1.0,
+		Data:    data,
+	}
+}
+
+// roomBiome returns the biome layer value of a room, or 0 without a biome.
+func roomBiome(g Graph, roomID string, index map[string]uint32) uint32 {
+	room := g.GetRoom(roomID)
+	if room == nil {
+		return 0
+	}
+	return index[room.GetTags()["biome"]]
+}
+
+// buildTransitions records biome seams in a "transitions" object layer: one
+// "biome_transition" object per corridor joining rooms of different biomes,
+// covering the corridor's tiles, and one "transition_room" object per room
+// tagged "biome_blend", covering the room. Connectors and rooms are
+// processed in ID order for determinism.
+func (c *DefaultCarver) buildTransitions(g Graph, layout *Layout, tm *TileMap) *Layer {
+	layer := &Layer{
+		ID:      len(tm.Layers),
+		Name:    "transitions",
+		Type:    "objectgroup",
+		Visible: true,
+		Opacity: 1.0,
+		Objects: []Object{},
+	}
+	floor := tm.Layers["floor"].Data
+
+	biomeOf := func(roomID string) string {
+		if room := g.GetRoom(roomID); room != nil {
+			return room.GetTags()["biome"]
+		}
+		return ""
+	}
+
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	objID := 1
+	for _, id := range connIDs {
+		conn := g.GetConnector(id)
+		if conn == nil {
+			continue
+		}
+		from, to := biomeOf(conn.GetFrom()), biomeOf(conn.GetTo())
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		tiles := pathTiles(layout.CorridorPaths[id], floor, tm.Width, tm.Height)
+		if len(tiles) == 0 {
+			continue
+		}
+
+		minX, minY, maxX, maxY := tiles[0].X, tiles[0].Y, tiles[0].X, tiles[0].Y
+		for _, t := range tiles[1:] {
+			minX, minY = min(minX, t.X), min(minY, t.Y)
+			maxX, maxY = max(maxX, t.X), max(maxY, t.Y)
+		}
+		layer.Objects = append(layer.Objects, Object{
+			ID:      objID,
+			Name:    fmt.Sprintf("transition_%s", id),
+			Type:    "biome_transition",
+			X:       float64(minX * c.tileWidth),
+			Y:       float64(minY * c.tileHeight),
+			Width:   float64((maxX - minX + 1) * c.tileWidth),
+			Height:  float64((maxY - minY + 1) * c.tileHeight),
+			Visible: true,
+			Properties: map[string]interface{}{
+				"connector_id": id,
+				"from_room":    conn.GetFrom(),
+				"to_room":      conn.GetTo(),
+				"from_biome":   from,
+				"to_biome":     to,
+				"length":       len(tiles),
+			},
+		})
+		objID++
+	}
+
+	roomIDs := make([]string, 0, len(layout.Poses))
+	for id := range layout.Poses {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+		tags := room.GetTags()
+		if tags["biome"] == "" || tags["biome_blend"] == "" {
+			continue
+		}
+		b := RoomBounds(room.GetSize(), layout.Poses[id])
+		layer.Objects = append(layer.Objects, Object{
+			ID:      objID,
+			Name:    fmt.Sprintf("transition_room_%s", id),
+			Type:    "transition_room",
+			X:       float64(b.X * c.tileWidth),
+			Y:       float64(b.Y * c.tileHeight),
+			Width:   float64(b.Width * c.tileWidth),
+			Height:  float64(b.Height * c.tileHeight),
+			Visible: true,
+			Properties: map[string]interface{}{
+				"room_id":     id,
+				"biome":       tags["biome"],
+				"blend_biome": tags["biome_blend"],
+			},
+		})
+		objID++
+	}
+
+	return layer
+}
@@ -0,0 +1,103 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestBuildBiomeLayer verifies corridors between biomes fade from one to the
+// other, transition rooms mix in their blend biome, and seams are recorded.
+func TestBuildBiomeLayer(t *testing.T) {
+	rooms := map[string]*graph.Room{
+		"crypt":  {ID: "crypt", Size: graph.SizeM, Tags: map[string]string{"biome": "crypt"}},
+		"fungal": {ID: "fungal", Size: graph.SizeM, Tags: map[string]string{"biome": "fungal", "biome_blend": "crypt"}},
+	}
+	connectors := map[string]*graph.Connector{
+		"conn1": {ID: "conn1", From: "crypt", To: "fungal", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1.0},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+
+	layout := &Layout{
+		Poses: map[string]Pose{
+			"crypt":  {X: 10, Y: 10},
+			"fungal": {X: 50, Y: 10},
+		},
+		CorridorPaths: map[string]Path{
+			"conn1": {Points: []Point{{X: 10, Y: 10}, {X: 50, Y: 10}}},
+		},
+		Bounds: Rect{Width: 60, Height: 20},
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+
+	if palette := BiomePalette(g, layout); len(palette) != 2 || palette[0] != "crypt" || palette[1] != "fungal" {
+		t.Fatalf("BiomePalette() = %v, want [crypt fungal]", palette)
+	}
+
+	layer, ok := tm.Layers["biome"]
+	if !ok {
+		t.Fatal("Carve() did not create biome layer")
+	}
+	at := func(x, y int) uint32 { return GetTile(layer.Data, x, y, tm.Width, tm.Height) }
+
+	// Every floor tile is painted
+	floor := tm.Layers["floor"].Data
+	for i, tile := range floor {
+		if tile == uint32(TileFloor) && layer.Data[i] == 0 {
+			t.Fatalf("floor tile (%d,%d) has no biome", i%tm.Width, i/tm.Width)
+		}
+	}
+
+	// The corridor blends: both biomes appear between the rooms, starting
+	// from crypt and ending in fungal
+	counts := map[uint32]int{}
+	for x := 16; x <= 44; x++ {
+		counts[at(x, 10)]++
+	}
+	if counts[1] < 2 || counts[2] < 2 {
+		t.Errorf("corridor biome counts = %v, want a blend of both", counts)
+	}
+	if at(16, 10) != 1 || at(44, 10) != 2 {
+		t.Errorf("corridor ends = %d..%d, want 1..2", at(16, 10), at(44, 10))
+	}
+
+	// The crypt room is pure; the fungal transition room mixes in crypt
+	mixed := false
+	for y := 7; y <= 12; y++ {
+		for x := 7; x <= 12; x++ {
+			if at(x, y) != 1 {
+				t.Fatalf("crypt room tile (%d,%d) = %d, want 1", x, y, at(x, y))
+			}
+			if at(x+40, y) == 1 {
+				mixed = true
+			}
+		}
+	}
+	if !mixed {
+		t.Error("transition room has no blended tiles")
+	}
+
+	types := map[string]int{}
+	for _, obj := range tm.Layers["transitions"].Objects {
+		types[obj.Type]++
+	}
+	if types["biome_transition"] != 1 || types["transition_room"] != 1 {
+		t.Errorf("transition objects = %v, want one corridor and one room", types)
+	}
+
+	// Untagged maps get no biome layers
+	delete(rooms["crypt"].Tags, "biome")
+	rooms["fungal"].Tags = nil
+	tm, err = NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	if _, ok := tm.Layers["biome"]; ok {
+		t.Error("untagged map should have no biome layer")
+	}
+}
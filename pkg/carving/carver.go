@@ -3,6 +3,10 @@ package carving
 import (
 	"context"
 	"fmt"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/themes"
 )
 
 // Carver converts spatial layouts into rasterized tile maps.
@@ -97,8 +101,10 @@ func (t TileType) String() string {
 
 // DefaultCarver is a basic implementation of the Carver interface.
 type DefaultCarver struct {
-	tileWidth  int
-	tileHeight int
+	tileWidth   int
+	tileHeight  int
+	themeLoader *themes.Loader                // Optional source of unregistered theme packs
+	decorations map[string][]themes.Decorator // Decoration rules overriding those of a biome
 }
 
 // NewDefaultCarver creates a new carver with the specified tile dimensions.
@@ -115,6 +121,69 @@ func NewDefaultCarver(tileWidth, tileHeight int) *DefaultCarver {
 	}
 }
 
+// WithThemeLoader sets the loader used to look up the elevation and
+// decoration rules of rooms whose "biome" tag names an unregistered theme
+// pack. Registered themes are always used first.
+func (c *DefaultCarver) WithThemeLoader(loader *themes.Loader) *DefaultCarver {
+	c.themeLoader = loader
+	return c
+}
+
+// WithDecorations overrides the decoration rules of the biomes named in
+// rules, so a map can be decorated again without changing its themes. An
+// empty rule list leaves a biome undecorated.
+func (c *DefaultCarver) WithDecorations(rules map[string][]themes.Decorator) *DefaultCarver {
+	c.decorations = rules
+	return c
+}
+
+// elevationRules returns the elevation rules of a room's biome: those of
+// the registered theme, else those of its pack if a loader is set.
+func (c *DefaultCarver) elevationRules(room Room) []themes.ElevationRule {
+	biome := room.GetTags()["biome"]
+	if biome == "" {
+		return nil
+	}
+	if theme, ok := themes.Lookup(biome); ok {
+		return theme.ElevationRules()
+	}
+	if c.themeLoader == nil {
+		return nil
+	}
+	pack, err := c.themeLoader.Load(biome)
+	if err != nil {
+		return nil
+	}
+	return pack.Elevation
+}
+
+// decorationRules returns the decoration rules of a room: VaultDecorations
+// for a vault room, else those of its biome: its override, else those of
+// the registered theme, else those of its pack if a loader is set.
+func (c *DefaultCarver) decorationRules(room Room) []themes.Decorator {
+	if room.GetTags()[graph.VaultTag] != "" {
+		return VaultDecorations
+	}
+	biome := room.GetTags()["biome"]
+	if biome == "" {
+		return nil
+	}
+	if rules, ok := c.decorations[biome]; ok {
+		return rules
+	}
+	if theme, ok := themes.Lookup(biome); ok {
+		return theme.DecorationRules()
+	}
+	if c.themeLoader == nil {
+		return nil
+	}
+	pack, err := c.themeLoader.Load(biome)
+	if err != nil {
+		return nil
+	}
+	return pack.Decorators
+}
+
 // Carve implements the Carver interface.
 func (c *DefaultCarver) Carve(ctx context.Context, g Graph, layout *Layout) (*TileMap, error) {
 	if g == nil {
@@ -166,9 +235,19 @@ func (c *DefaultCarver) Carve(ctx context.Context, g Graph, layout *Layout) (*Ti
 		Objects: []Object{},
 	}
 
+	destructibleLayer := &Layer{
+		ID:      3,
+		Name:    "destructibles",
+		Type:    "objectgroup",
+		Visible: true,
+		Opacity: 1.0,
+		Objects: []Object{},
+	}
+
 	tm.Layers["floor"] = floorLayer
 	tm.Layers["walls"] = wallLayer
 	tm.Layers["doors"] = doorLayer
+	tm.Layers["destructibles"] = destructibleLayer
 
 	// Stamp room footprints
 	stamper := NewStamper(width, height)
@@ -202,37 +281,83 @@ func (c *DefaultCarver) Carve(ctx context.Context, g Graph, layout *Layout) (*Ti
 	// Place doors at room/corridor junctions
 	c.placeDoors(g, layout, floorLayer.Data, doorLayer)
 
+	// Seal hidden connectors behind secrets
+	c.placeDestructibles(g, layout, tm, destructibleLayer)
+
+	// Raise platforms and sink pits from room tags and theme rules
+	tm.Layers["elevation"] = BuildElevationLayer(tm, g, layout, c.elevationRules)
+
+	// Spend environment budgets on hazards, slow terrain and darkness
+	if len(environmentRooms(g, layout)) > 0 {
+		tm.Layers["hazards"] = c.buildHazards(g, layout, tm)
+		tm.Layers["terrain"] = BuildTerrainLayer(tm, g, layout)
+		tm.Layers["environment"] = c.buildEnvironment(g, layout, tm)
+	}
+
+	// Derive the collision layer from the carved geometry
+	tm.Layers["collision"] = BuildCollisionLayer(tm, oneWayPaths(g, layout))
+
+	// Paint themed zones, blending biomes across their borders
+	if len(BiomePalette(g, layout)) > 0 {
+		tm.Layers["biome"] = BuildBiomeLayer(tm, g, layout)
+		tm.Layers["transitions"] = c.buildTransitions(g, layout, tm)
+	}
+
+	// Scatter each theme's decorations along room walls
+	if len(BiomePalette(g, layout)) > 0 {
+		tm.Layers["decor"] = BuildDecorLayer(tm, g, layout, c.decorationRules)
+	}
+
 	return tm, nil
 }
 
+// oneWayPaths returns the corridor paths of one-way connectors in connector ID order.
+func oneWayPaths(g Graph, layout *Layout) []Path {
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for connID := range layout.CorridorPaths {
+		connIDs = append(connIDs, connID)
+	}
+	sort.Strings(connIDs)
+
+	paths := []Path{}
+	for _, connID := range connIDs {
+		conn := g.GetConnector(connID)
+		if conn != nil && conn.GetType() == TypeOneWay {
+			paths = append(paths, layout.CorridorPaths[connID])
+		}
+	}
+	return paths
+}
+
 // generateWalls creates walls around all floor tiles.
 func (c *DefaultCarver) generateWalls(floorData, wallData []uint32, width, height int) {
-	// For each floor tile, add walls to adjacent empty tiles
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
-			idx := y*width + x
-			if floorData[idx] != uint32(TileFloor) {
+			if floorData[y*width+x] == uint32(TileFloor) {
+				wallAround(floorData, wallData, x, y, width, height)
+			}
+		}
+	}
+}
+
+// wallAround turns the empty neighbours of a floor tile into walls.
+func wallAround(floorData, wallData []uint32, x, y, width, height int) {
+	// Check all 8 neighbors
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= width || ny < 0 || ny >= height {
 				continue
 			}
 
-			// Check all 8 neighbors
-			for dy := -1; dy <= 1; dy++ {
-				for dx := -1; dx <= 1; dx++ {
-					if dx == 0 && dy == 0 {
-						continue
-					}
-
-					nx, ny := x+dx, y+dy
-					if nx < 0 || nx >= width || ny < 0 || ny >= height {
-						continue
-					}
-
-					nidx := ny*width + nx
-					// If neighbor is empty and not already a wall, make it a wall
-					if floorData[nidx] == uint32(TileEmpty) && wallData[nidx] == uint32(TileEmpty) {
-						wallData[nidx] = uint32(TileWall)
-					}
-				}
+			nidx := ny*width + nx
+			// If neighbor is empty and not already a wall, make it a wall
+			if floorData[nidx] == uint32(TileEmpty) && wallData[nidx] == uint32(TileEmpty) {
+				wallData[nidx] = uint32(TileWall)
 			}
 		}
 	}
@@ -242,50 +367,51 @@ func (c *DefaultCarver) generateWalls(floorData, wallData []uint32, width, heigh
 func (c *DefaultCarver) placeDoors(g Graph, layout *Layout, floorData []uint32, doorLayer *Layer) {
 	doorID := 1
 
-	// For each connector, find the junction point and place a door
-	for connID, path := range layout.CorridorPaths {
+	// Visit connectors in ID order so door object IDs are deterministic
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for connID := range layout.CorridorPaths {
+		connIDs = append(connIDs, connID)
+	}
+	sort.Strings(connIDs)
+
+	// Every corridor gets at most two doors
+	doorLayer.Objects = append(make([]Object, 0, len(doorLayer.Objects)+2*len(connIDs)), doorLayer.Objects...)
+	for _, connID := range connIDs {
 		conn := g.GetConnector(connID)
 		if conn == nil || conn.GetType() != TypeCorridor {
 			continue
 		}
+		doors := doorObjects(conn, connID, layout.CorridorPaths[connID], c.tileWidth, c.tileHeight, doorID)
+		doorLayer.Objects = append(doorLayer.Objects, doors...)
+		doorID += len(doors)
+	}
+}
 
-		// Find junction points at start and end of corridor
-		if len(path.Points) < 2 {
-			continue
-		}
+// doorObjects returns the door objects at the entrance and exit of a
+// corridor, numbered from firstID. Paths with fewer than two points get no
+// doors.
+func doorObjects(conn Connector, connID string, path Path, tileWidth, tileHeight, firstID int) []Object {
+	if len(path.Points) < 2 {
+		return nil
+	}
 
-		// Place door at corridor entrance
-		startPoint := path.Points[0]
-		doorObj := Object{
-			ID:       doorID,
-			Name:     fmt.Sprintf("door_%s_start", connID),
-			Type:     "door",
-			X:        float64(startPoint.X * c.tileWidth),
-			Y:        float64(startPoint.Y * c.tileHeight),
-			Width:    float64(c.tileWidth),
-			Height:   float64(c.tileHeight),
-			Rotation: 0,
-			Visible:  true,
-			Properties: map[string]interface{}{
-				"connector_id": connID,
-				"from_room":    conn.GetFrom(),
-				"to_room":      conn.GetTo(),
-				"gate":         conn.GetGate(),
-			},
-		}
-		doorLayer.Objects = append(doorLayer.Objects, doorObj)
-		doorID++
-
-		// Place door at corridor exit
-		endPoint := path.Points[len(path.Points)-1]
-		doorObj = Object{
-			ID:       doorID,
-			Name:     fmt.Sprintf("door_%s_end", connID),
+	ends := []struct {
+		suffix string
+		at     Point
+	}{
+		{"start", path.Points[0]},
+		{"end", path.Points[len(path.Points)-1]},
+	}
+	doors := make([]Object, 0, len(ends))
+	for i, end := range ends {
+		doors = append(doors, Object{
+			ID:       firstID + i,
+			Name:     "door_" + connID + "_" + end.suffix,
 			Type:     "door",
-			X:        float64(endPoint.X * c.tileWidth),
-			Y:        float64(endPoint.Y * c.tileHeight),
-			Width:    float64(c.tileWidth),
-			Height:   float64(c.tileHeight),
+			X:        float64(end.at.X * tileWidth),
+			Y:        float64(end.at.Y * tileHeight),
+			Width:    float64(tileWidth),
+			Height:   float64(tileHeight),
 			Rotation: 0,
 			Visible:  true,
 			Properties: map[string]interface{}{
@@ -294,8 +420,7 @@ func (c *DefaultCarver) placeDoors(g Graph, layout *Layout, floorData []uint32,
 				"to_room":      conn.GetTo(),
 				"gate":         conn.GetGate(),
 			},
-		}
-		doorLayer.Objects = append(doorLayer.Objects, doorObj)
-		doorID++
+		})
 	}
+	return doors
 }
@@ -0,0 +1,71 @@
+package carving
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// BenchmarkCarve benchmarks carving for different dungeon sizes, reporting
+// allocations so regressions in the per-tile loops show up.
+func BenchmarkCarve(b *testing.B) {
+	for _, roomCount := range []int{30, 100, 300} {
+		b.Run(fmt.Sprintf("%d_rooms", roomCount), func(b *testing.B) {
+			g, layout := createGridDungeon(roomCount)
+			carver := NewDefaultCarver(16, 16)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := carver.Carve(context.Background(), g, layout); err != nil {
+					b.Fatalf("Carve() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+// createGridDungeon lays rooms out on a square grid, chained row by row, with
+// a hidden connector below every fourth room.
+func createGridDungeon(roomCount int) (Graph, *Layout) {
+	const spacing = 20
+	cols := 1
+	for cols*cols < roomCount {
+		cols++
+	}
+
+	rooms := make(map[string]*graph.Room, roomCount)
+	connectors := make(map[string]*graph.Connector)
+	layout := &Layout{
+		Poses:         make(map[string]Pose, roomCount),
+		CorridorPaths: make(map[string]Path),
+	}
+	center := func(i int) Point {
+		return Point{X: 8 + i%cols*spacing, Y: 8 + i/cols*spacing}
+	}
+
+	for i := 0; i < roomCount; i++ {
+		id := fmt.Sprintf("room_%03d", i)
+		rooms[id] = &graph.Room{ID: id, Size: graph.SizeM}
+		layout.Poses[id] = Pose{X: center(i).X - 4, Y: center(i).Y - 4}
+
+		if i > 0 {
+			connID := fmt.Sprintf("c_%03d", i)
+			prev := fmt.Sprintf("room_%03d", i-1)
+			connectors[connID] = &graph.Connector{ID: connID, From: prev, To: id, Type: graph.TypeCorridor, Bidirectional: true, Cost: 1}
+			from, to := center(i-1), center(i)
+			layout.CorridorPaths[connID] = Path{Points: []Point{from, {X: to.X, Y: from.Y}, to}}
+		}
+		if below := i + cols; i%4 == 0 && below < roomCount {
+			connID := fmt.Sprintf("h_%03d", i)
+			connectors[connID] = &graph.Connector{ID: connID, From: id, To: fmt.Sprintf("room_%03d", below), Type: graph.TypeHidden, Bidirectional: true, Cost: 1}
+			layout.CorridorPaths[connID] = Path{Points: []Point{center(i), center(below)}}
+		}
+	}
+
+	rows := (roomCount + cols - 1) / cols
+	layout.Bounds = Rect{Width: cols*spacing + 8, Height: rows*spacing + 8}
+	return NewGraphAdapter(rooms, connectors), layout
+}
@@ -215,6 +215,44 @@ func TestStamper(t *testing.T) {
 		}
 	})
 
+	t.Run("StampRoom rotated hall", func(t *testing.T) {
+		room := &graph.Room{ID: "hall", Size: graph.SizeM}
+		adapter := &RoomAdapter{room: room}
+
+		for _, tt := range []struct {
+			rotation      int
+			width, height int
+		}{
+			{0, 7, 4},
+			{90, 4, 7},
+			{180, 7, 4},
+			{270, 4, 7},
+		} {
+			pose := Pose{X: 25, Y: 25, Rotation: tt.rotation, FootprintID: graph.FootprintHall}
+			b := RoomBounds(SizeM, pose)
+			if b.Width != tt.width || b.Height != tt.height {
+				t.Errorf("RoomBounds(rotation %d) = %dx%d, want %dx%d", tt.rotation, b.Width, b.Height, tt.width, tt.height)
+			}
+			if b.X != 25-tt.width/2 || b.Y != 25-tt.height/2 {
+				t.Errorf("RoomBounds(rotation %d) at (%d, %d), want centered on the pose", tt.rotation, b.X, b.Y)
+			}
+
+			stamper := NewStamper(50, 50)
+			data := make([]uint32, 2500)
+			if err := stamper.StampRoom(adapter, pose, data); err != nil {
+				t.Fatalf("StampRoom() error = %v", err)
+			}
+			for y := 0; y < 50; y++ {
+				for x := 0; x < 50; x++ {
+					inside := x >= b.X && x < b.X+b.Width && y >= b.Y && y < b.Y+b.Height
+					if floor := data[y*50+x] == uint32(TileFloor); floor != inside {
+						t.Fatalf("rotation %d: tile (%d, %d) floor = %v, want %v", tt.rotation, x, y, floor, inside)
+					}
+				}
+			}
+		}
+	})
+
 	t.Run("StampShape rectangle", func(t *testing.T) {
 		stamper := NewStamper(50, 50)
 		data := make([]uint32, 2500)
@@ -415,6 +453,91 @@ func TestDefaultCarver(t *testing.T) {
 		}
 	})
 
+	t.Run("Carve hidden connector", func(t *testing.T) {
+		rooms := map[string]*graph.Room{
+			"room1":  {ID: "room1", Size: graph.SizeM},
+			"secret": {ID: "secret", Size: graph.SizeS},
+		}
+		connectors := map[string]*graph.Connector{
+			"hidden": {
+				ID:   "hidden",
+				From: "room1",
+				To:   "secret",
+				Type: graph.TypeHidden,
+				Cost: 1.0,
+			},
+		}
+		g := NewGraphAdapter(rooms, connectors)
+
+		layout := &Layout{
+			Poses: map[string]Pose{
+				"room1":  {X: 10, Y: 10},
+				"secret": {X: 30, Y: 10},
+			},
+			CorridorPaths: map[string]Path{
+				"hidden": {Points: []Point{{X: 10, Y: 10}, {X: 30, Y: 10}}},
+			},
+			Bounds: Rect{Width: 40, Height: 20},
+		}
+
+		tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+		if err != nil {
+			t.Fatalf("Carve() error = %v", err)
+		}
+
+		objs := tm.Layers["destructibles"].Objects
+		if len(objs) != 1 {
+			t.Fatalf("Carve() placed %d destructible walls, want 1", len(objs))
+		}
+		if objs[0].Properties["connector_id"] != "hidden" {
+			t.Errorf("destructible connector_id = %v, want hidden", objs[0].Properties["connector_id"])
+		}
+
+		// The wall must sit just outside the 5x5 secret room and cut it off
+		x, y := int(objs[0].X)/16, int(objs[0].Y)/16
+		if x != 27 || y != 10 {
+			t.Errorf("destructible wall at (%d,%d), want (27,10)", x, y)
+		}
+		if ReachableTiles(tm, Point{X: 10, Y: 10})[10*tm.Width+30] {
+			t.Error("secret room reachable through destructible wall")
+		}
+	})
+
+	t.Run("Carve hidden connector over a visible corridor", func(t *testing.T) {
+		rooms := map[string]*graph.Room{
+			"room1":  {ID: "room1", Size: graph.SizeM},
+			"secret": {ID: "secret", Size: graph.SizeS},
+		}
+		connectors := map[string]*graph.Connector{
+			"hidden": {ID: "hidden", From: "room1", To: "secret", Type: graph.TypeHidden, Cost: 1.0},
+			"door":   {ID: "door", From: "room1", To: "secret", Type: graph.TypeDoor, Cost: 1.0},
+		}
+		g := NewGraphAdapter(rooms, connectors)
+
+		path := Path{Points: []Point{{X: 10, Y: 10}, {X: 30, Y: 10}}}
+		layout := &Layout{
+			Poses: map[string]Pose{
+				"room1":  {X: 10, Y: 10},
+				"secret": {X: 30, Y: 10},
+			},
+			CorridorPaths: map[string]Path{"hidden": path, "door": path},
+			Bounds:        Rect{Width: 40, Height: 20},
+		}
+
+		tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+		if err != nil {
+			t.Fatalf("Carve() error = %v", err)
+		}
+
+		// Every tile of the hidden corridor is open, so none may be sealed
+		if objs := tm.Layers["destructibles"].Objects; len(objs) != 0 {
+			t.Errorf("Carve() placed %d destructible walls over a visible corridor, want 0", len(objs))
+		}
+		if !ReachableTiles(tm, Point{X: 10, Y: 10})[10*tm.Width+30] {
+			t.Error("visible corridor to the secret room was sealed")
+		}
+	})
+
 	t.Run("Carve with nil inputs", func(t *testing.T) {
 		carver := NewDefaultCarver(16, 16)
 
@@ -431,6 +554,103 @@ func TestDefaultCarver(t *testing.T) {
 	})
 }
 
+// TestCarveSecretKinds verifies each secret kind seals the hidden corridor
+// with its own carving effect, and unknown kinds fall back to a destructible
+// wall.
+func TestCarveSecretKinds(t *testing.T) {
+	tests := []struct {
+		tag      string
+		kind     string
+		walkable bool // The secret room is reachable by walking
+	}{
+		{tag: "", kind: SecretDestructibleWall},
+		{tag: "bogus", kind: SecretDestructibleWall},
+		{tag: SecretIllusoryWall, kind: SecretIllusoryWall, walkable: true},
+		{tag: SecretCollapsibleFloor, kind: SecretCollapsibleFloor},
+		{tag: SecretMovableBlock, kind: SecretMovableBlock},
+		{tag: SecretShrineRiddle, kind: SecretShrineRiddle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.tag, func(t *testing.T) {
+			rooms := map[string]*graph.Room{
+				"room1":  {ID: "room1", Size: graph.SizeM},
+				"secret": {ID: "secret", Size: graph.SizeS, Tags: map[string]string{"secret_kind": tt.tag}},
+			}
+			connectors := map[string]*graph.Connector{
+				"hidden": {ID: "hidden", From: "room1", To: "secret", Type: graph.TypeHidden, Cost: 1.0},
+			}
+			layout := &Layout{
+				Poses: map[string]Pose{
+					"room1":  {X: 10, Y: 10},
+					"secret": {X: 30, Y: 10},
+				},
+				CorridorPaths: map[string]Path{
+					"hidden": {Points: []Point{{X: 10, Y: 10}, {X: 30, Y: 10}}},
+				},
+				Bounds: Rect{Width: 40, Height: 20},
+			}
+
+			tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), NewGraphAdapter(rooms, connectors), layout)
+			if err != nil {
+				t.Fatalf("Carve() error = %v", err)
+			}
+
+			objs := tm.Layers["destructibles"].Objects
+			if len(objs) != 1 {
+				t.Fatalf("Carve() placed %d secrets, want 1", len(objs))
+			}
+			if objs[0].Type != tt.kind {
+				t.Errorf("secret type = %q, want %q", objs[0].Type, tt.kind)
+			}
+			breach := Point{X: int(objs[0].X) / 16, Y: int(objs[0].Y) / 16}
+			if breach != (Point{X: 27, Y: 10}) {
+				t.Errorf("secret at %v, want (27,10)", breach)
+			}
+			if got := ReachableTiles(tm, Point{X: 10, Y: 10})[10*tm.Width+30]; got != tt.walkable {
+				t.Errorf("secret room reachable = %v, want %v", got, tt.walkable)
+			}
+
+			floor := GetTile(tm.Layers["floor"].Data, breach.X, breach.Y, tm.Width, tm.Height)
+			wall := GetTile(tm.Layers["walls"].Data, breach.X, breach.Y, tm.Width, tm.Height)
+			collision := GetTile(tm.Layers["collision"].Data, breach.X, breach.Y, tm.Width, tm.Height)
+			switch tt.kind {
+			case SecretCollapsibleFloor:
+				if floor != uint32(TileEmpty) || wall != uint32(TileEmpty) {
+					t.Errorf("collapsible floor breach has floor %d and wall %d, want neither", floor, wall)
+				}
+			case SecretIllusoryWall:
+				if wall != uint32(TileWall) || collision != uint32(CollisionTrigger) {
+					t.Errorf("illusory wall breach has wall %d and collision %d, want a trigger wall", wall, collision)
+				}
+			default:
+				if floor != uint32(TileFloor) || wall != uint32(TileWall) {
+					t.Errorf("breach has floor %d and wall %d, want a wall over floor", floor, wall)
+				}
+			}
+
+			if tt.kind == SecretMovableBlock && (objs[0].Properties["push_dx"] != 1 || objs[0].Properties["push_dy"] != 0) {
+				t.Errorf("block push = (%v,%v), want (1,0)", objs[0].Properties["push_dx"], objs[0].Properties["push_dy"])
+			}
+
+			triggers := tm.Layers["triggers"]
+			if tt.kind != SecretShrineRiddle {
+				if triggers != nil {
+					t.Errorf("Carve() added a triggers layer for a %s", tt.kind)
+				}
+				return
+			}
+			if triggers == nil || len(triggers.Objects) != 1 || triggers.Objects[0].Type != "shrine" {
+				t.Fatalf("triggers = %+v, want one shrine", triggers)
+			}
+			shrine := Point{X: int(triggers.Objects[0].X) / 16, Y: int(triggers.Objects[0].Y) / 16}
+			room1 := RoomBounds(RoomSize(graph.SizeM), layout.Poses["room1"])
+			if shrine.X < room1.X || shrine.X >= room1.X+room1.Width || shrine.Y < room1.Y || shrine.Y >= room1.Y+room1.Height || shrine.Y == 10 {
+				t.Errorf("shrine at %v, want in room1 %+v beside the corridor", shrine, room1)
+			}
+		})
+	}
+}
+
 // TestPathUtilities tests path manipulation utilities.
 func TestPathUtilities(t *testing.T) {
 	t.Run("SimplifyPath", func(t *testing.T) {
@@ -0,0 +1,258 @@
+package carving
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CollisionType classifies how a tile interacts with a physics engine.
+// Values are stored directly in the "collision" tile layer.
+type CollisionType uint32
+
+const (
+	CollisionNone    CollisionType = iota // Passable, no collider
+	CollisionSolid                        // Blocks movement (walls)
+	CollisionOneWay                       // Passable in one direction only
+	CollisionHazard                       // Passable but damaging
+	CollisionTrigger                      // Passable, fires an event (doors, pressure plates)
+)
+
+// String returns the string representation of a CollisionType.
+func (c CollisionType) String() string {
+	switch c {
+	case CollisionNone:
+		return "None"
+	case CollisionSolid:
+		return "Solid"
+	case CollisionOneWay:
+		return "OneWay"
+	case CollisionHazard:
+		return "Hazard"
+	case CollisionTrigger:
+		return "Trigger"
+	default:
+		return fmt.Sprintf("Unknown(%d)", c)
+	}
+}
+
+// CollisionShape is an axis-aligned rectangle of tiles that share a collision type.
+// Coordinates are in tiles. RoomID is empty for shapes outside every room
+// (corridors and the walls around them).
+type CollisionShape struct {
+	RoomID string
+	Type   CollisionType
+	Bounds Rect
+}
+
+// BuildCollisionLayer derives a "collision" tile layer from the carved layers.
+// Walls become solid, door objects, trigger objects and passable secrets
+// become triggers, hazard objects become hazards, and floor tiles along
+// oneWay paths become one-way.
+// Later classifications win, so a door on a one-way corridor is a trigger.
+func BuildCollisionLayer(tm *TileMap, oneWay []Path) *Layer {
+	data := make([]uint32, tm.Width*tm.Height)
+
+	if walls, ok := tm.Layers["walls"]; ok {
+		for i, v := range walls.Data {
+			if v != uint32(TileEmpty) {
+				data[i] = uint32(CollisionSolid)
+			}
+		}
+	}
+
+	if floor, ok := tm.Layers["floor"]; ok {
+		for _, path := range oneWay {
+			markPath(data, floor.Data, path, tm.Width, tm.Height, CollisionOneWay)
+		}
+	}
+
+	markObjects(tm, "hazards", data, CollisionHazard)
+	markObjects(tm, "doors", data, CollisionTrigger)
+	markObjects(tm, "triggers", data, CollisionTrigger)
+	markPassable(tm, data)
+
+	return &Layer{
+		ID:      len(tm.Layers),
+		Name:    "collision",
+		Type:    "tilelayer",
+		Visible: false,
+		Opacity: 1.0,
+		Data:    data,
+	}
+}
+
+// markPath classifies floor tiles along a corridor path.
+func markPath(data, floor []uint32, path Path, width, height int, value CollisionType) {
+	for i := 0; i < len(path.Points)-1; i++ {
+		p1, p2 := path.Points[i], path.Points[i+1]
+		walkLine(p1.X, p1.Y, p2.X, p2.Y, func(x, y int) {
+			if GetTile(floor, x, y, width, height) == uint32(TileFloor) {
+				_ = SetTile(data, x, y, width, height, uint32(value))
+			}
+		})
+	}
+}
+
+// walkLine visits every tile on the Bresenham line between two points,
+// matching the tiles DrawLine would set.
+func walkLine(x0, y0, x1, y1 int, visit func(x, y int)) {
+	dx := abs(x1 - x0)
+	dy := abs(y1 - y0)
+	sx, sy := -1, -1
+	if x0 < x1 {
+		sx = 1
+	}
+	if y0 < y1 {
+		sy = 1
+	}
+	err := dx - dy
+	for {
+		visit(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// markObjects classifies the tiles covered by every object in an object layer.
+// Object coordinates are in pixels and are converted using the map's tile size.
+func markObjects(tm *TileMap, layerName string, data []uint32, value CollisionType) {
+	layer, ok := tm.Layers[layerName]
+	if !ok || layer.Type != "objectgroup" || tm.TileWidth <= 0 || tm.TileHeight <= 0 {
+		return
+	}
+
+	for _, obj := range layer.Objects {
+		x0 := int(obj.X) / tm.TileWidth
+		y0 := int(obj.Y) / tm.TileHeight
+		w := int(obj.Width) / tm.TileWidth
+		h := int(obj.Height) / tm.TileHeight
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				_ = SetTile(data, x, y, tm.Width, tm.Height, uint32(value))
+			}
+		}
+	}
+}
+
+// markPassable turns the tiles of passable secrets, such as illusory walls,
+// into triggers.
+func markPassable(tm *TileMap, data []uint32) {
+	layer, ok := tm.Layers["destructibles"]
+	if !ok || tm.TileWidth <= 0 || tm.TileHeight <= 0 {
+		return
+	}
+	for _, obj := range layer.Objects {
+		if passable, _ := obj.Properties["passable"].(bool); passable {
+			_ = SetTile(data, int(obj.X)/tm.TileWidth, int(obj.Y)/tm.TileHeight, tm.Width, tm.Height, uint32(CollisionTrigger))
+		}
+	}
+}
+
+// MergeCollisionRects greedily merges tiles of the given type into rectangles.
+// See MergeRects for the merge order.
+func MergeCollisionRects(data []uint32, width, height int, t CollisionType) []Rect {
+	return MergeRects(data, width, height, uint32(t))
+}
+
+// MergeRects greedily merges tiles equal to value into rectangles.
+// Tiles are scanned in row-major order; each rectangle grows right as far as
+// possible and then down while the full row segment matches, so the result is
+// deterministic and covers every matching tile exactly once.
+func MergeRects(data []uint32, width, height int, value uint32) []Rect {
+	used := make([]bool, len(data))
+	match := func(x, y int) bool {
+		idx := y*width + x
+		return !used[idx] && data[idx] == value
+	}
+
+	rects := []Rect{}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !match(x, y) {
+				continue
+			}
+
+			w := 1
+			for x+w < width && match(x+w, y) {
+				w++
+			}
+
+			h := 1
+		grow:
+			for y+h < height {
+				for dx := 0; dx < w; dx++ {
+					if !match(x+dx, y+h) {
+						break grow
+					}
+				}
+				h++
+			}
+
+			for dy := 0; dy < h; dy++ {
+				for dx := 0; dx < w; dx++ {
+					used[(y+dy)*width+x+dx] = true
+				}
+			}
+			rects = append(rects, Rect{X: x, Y: y, Width: w, Height: h})
+		}
+	}
+
+	return rects
+}
+
+// BuildCollisionShapes merges a collision layer into rectangles and assigns each
+// to the room whose bounds (grown by one tile to include its walls) contain the
+// rectangle's center. Shapes are ordered by room ID, type, then position.
+func BuildCollisionShapes(collision *Layer, width, height int, rooms map[string]Rect) []CollisionShape {
+	if collision == nil {
+		return nil
+	}
+
+	roomIDs := make([]string, 0, len(rooms))
+	for id := range rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	shapes := []CollisionShape{}
+	for _, t := range []CollisionType{CollisionSolid, CollisionOneWay, CollisionHazard, CollisionTrigger} {
+		for _, r := range MergeCollisionRects(collision.Data, width, height, t) {
+			cx := r.X + r.Width/2
+			cy := r.Y + r.Height/2
+			owner := ""
+			for _, id := range roomIDs {
+				b := rooms[id]
+				if cx >= b.X-1 && cx <= b.X+b.Width && cy >= b.Y-1 && cy <= b.Y+b.Height {
+					owner = id
+					break
+				}
+			}
+			shapes = append(shapes, CollisionShape{RoomID: owner, Type: t, Bounds: r})
+		}
+	}
+
+	sort.SliceStable(shapes, func(i, j int) bool {
+		if shapes[i].RoomID != shapes[j].RoomID {
+			return shapes[i].RoomID < shapes[j].RoomID
+		}
+		return shapes[i].Type < shapes[j].Type
+	})
+
+	return shapes
+}
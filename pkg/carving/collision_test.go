@@ -0,0 +1,73 @@
+package carving
+
+import "testing"
+
+// TestBuildCollisionLayer tests collision classification of carved layers.
+func TestBuildCollisionLayer(t *testing.T) {
+	tm := NewTileMap(6, 3, 16, 16)
+	floor := AddLayer(tm, "floor", "tilelayer")
+	walls := AddLayer(tm, "walls", "tilelayer")
+	doors := AddLayer(tm, "doors", "objectgroup")
+
+	for x := 0; x < 6; x++ {
+		_ = SetTile(walls.Data, x, 0, 6, 3, uint32(TileWall))
+		_ = SetTile(floor.Data, x, 1, 6, 3, uint32(TileFloor))
+		_ = SetTile(walls.Data, x, 2, 6, 3, uint32(TileWall))
+	}
+	doors.Objects = append(doors.Objects, Object{X: 5 * 16, Y: 16, Width: 16, Height: 16})
+
+	oneWay := []Path{{Points: []Point{{X: 0, Y: 1}, {X: 5, Y: 1}}}}
+	layer := BuildCollisionLayer(tm, oneWay)
+
+	if layer.Name != "collision" || layer.Type != "tilelayer" {
+		t.Fatalf("layer = %s/%s, want collision/tilelayer", layer.Name, layer.Type)
+	}
+
+	tests := []struct {
+		x, y int
+		want CollisionType
+	}{
+		{0, 0, CollisionSolid},
+		{0, 1, CollisionOneWay},
+		{4, 1, CollisionOneWay},
+		{5, 1, CollisionTrigger}, // Door wins over one-way
+		{3, 2, CollisionSolid},
+	}
+	for _, tt := range tests {
+		got := CollisionType(GetTile(layer.Data, tt.x, tt.y, 6, 3))
+		if got != tt.want {
+			t.Errorf("collision at (%d,%d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+// TestMergeCollisionRects tests greedy rectangle merging.
+func TestMergeCollisionRects(t *testing.T) {
+	s := uint32(CollisionSolid)
+	data := []uint32{
+		s, s, s, 0,
+		s, s, s, 0,
+		0, 0, 0, s,
+	}
+
+	rects := MergeCollisionRects(data, 4, 3, CollisionSolid)
+	if len(rects) != 2 {
+		t.Fatalf("MergeCollisionRects() returned %d rects, want 2", len(rects))
+	}
+	if rects[0] != (Rect{X: 0, Y: 0, Width: 3, Height: 2}) {
+		t.Errorf("rects[0] = %+v, want 3x2 at origin", rects[0])
+	}
+	if rects[1] != (Rect{X: 3, Y: 2, Width: 1, Height: 1}) {
+		t.Errorf("rects[1] = %+v, want 1x1 at (3,2)", rects[1])
+	}
+
+	shapes := BuildCollisionShapes(&Layer{Data: data}, 4, 3, map[string]Rect{
+		"R1": {X: 0, Y: 0, Width: 2, Height: 1},
+	})
+	if len(shapes) != 2 {
+		t.Fatalf("BuildCollisionShapes() returned %d shapes, want 2", len(shapes))
+	}
+	if shapes[0].RoomID != "" || shapes[1].RoomID != "R1" {
+		t.Errorf("shape rooms = %q, %q; want \"\", \"R1\"", shapes[0].RoomID, shapes[1].RoomID)
+	}
+}
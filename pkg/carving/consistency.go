@@ -0,0 +1,264 @@
+package carving
+
+import (
+	"sort"
+	"strconv"
+)
+
+// FloorLink is a pair of rooms joined by carved floor that does not run
+// through a third room. Path is one such route, from a tile next to From's
+// floor to a tile next to To's, or from a floor tile of From to a floor tile
+// of To when the two rooms touch.
+type FloorLink struct {
+	From, To string
+	Path     Path
+}
+
+// Passage is a connector the graph must gain to match the layout, found by
+// SyncPassages where carved floor joins rooms no connector joins, or placed
+// by BreakSharedWalls in a wall two such rooms share.
+type Passage struct {
+	ID       string
+	From, To string
+}
+
+// neighbours are the offsets of the eight tiles around a tile. Carved
+// corridors step diagonally, so floor joins across corners too.
+var neighbours = [8]Point{
+	{X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1},
+	{X: -1, Y: 0}, {X: 1, Y: 0},
+	{X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1},
+}
+
+// FloorLinks returns every pair of rooms the carved floor joins directly,
+// sorted by From, then To, with From before To. Rooms are joined when their
+// floors touch, or when a run of floor outside every room touches both.
+// Floor under a wall, such as a bombable wall sealing a secret room, blocks
+// the way. walls may be nil.
+func FloorLinks(floor, walls []uint32, width, height int, rooms map[string]Rect) []FloorLink {
+	ids := make([]string, 0, len(rooms))
+	for id := range rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	open := func(p Point) bool {
+		if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+			return false
+		}
+		i := p.Y*width + p.X
+		return floor[i] == uint32(TileFloor) && (walls == nil || walls[i] == uint32(TileEmpty))
+	}
+
+	// owner holds 1 + the index of the room each tile belongs to
+	owner := make([]int, width*height)
+	linked := make(map[[2]int]bool)
+	var links []FloorLink
+	link := func(a, b int, path Path) {
+		if a > b {
+			a, b = b, a
+			points := make([]Point, len(path.Points))
+			for i, p := range path.Points {
+				points[len(points)-1-i] = p
+			}
+			path.Points = points
+		}
+		if !linked[[2]int{a, b}] {
+			linked[[2]int{a, b}] = true
+			links = append(links, FloorLink{From: ids[a], To: ids[b], Path: path})
+		}
+	}
+
+	for i, id := range ids {
+		r := rooms[id]
+		for y := r.Y; y < r.Y+r.Height; y++ {
+			for x := r.X; x < r.X+r.Width; x++ {
+				p := Point{X: x, Y: y}
+				if !open(p) {
+					continue
+				}
+				if o := owner[y*width+x]; o > 0 {
+					link(o-1, i, Path{Points: []Point{p, p}})
+					continue
+				}
+				owner[y*width+x] = i + 1
+			}
+		}
+	}
+
+	// Rooms whose floors touch
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			o := owner[y*width+x]
+			if o == 0 {
+				continue
+			}
+			p := Point{X: x, Y: y}
+			for _, d := range neighbours {
+				q := Point{X: x + d.X, Y: y + d.Y}
+				if open(q) && owner[q.Y*width+q.X] > 0 && owner[q.Y*width+q.X] != o {
+					link(o-1, owner[q.Y*width+q.X]-1, Path{Points: []Point{p, q}})
+				}
+			}
+		}
+	}
+
+	// Runs of floor outside the rooms, and the rooms each one touches
+	seen := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			start := Point{X: x, Y: y}
+			if seen[y*width+x] || owner[y*width+x] > 0 || !open(start) {
+				continue
+			}
+			run := []Point{start}
+			seen[y*width+x] = true
+			touched := make(map[int][]Point)
+			for k := 0; k < len(run); k++ {
+				p := run[k]
+				for _, d := range neighbours {
+					q := Point{X: p.X + d.X, Y: p.Y + d.Y}
+					if !open(q) {
+						continue
+					}
+					qi := q.Y*width + q.X
+					if o := owner[qi]; o > 0 {
+						if ends := touched[o-1]; len(ends) == 0 || ends[len(ends)-1] != p {
+							touched[o-1] = append(ends, p)
+						}
+					} else if !seen[qi] {
+						seen[qi] = true
+						run = append(run, q)
+					}
+				}
+			}
+
+			rooms := make([]int, 0, len(touched))
+			for r := range touched {
+				rooms = append(rooms, r)
+			}
+			sort.Ints(rooms)
+			for i, a := range rooms {
+				for _, b := range rooms[i+1:] {
+					if !linked[[2]int{a, b}] {
+						link(a, b, runRoute(touched[a], touched[b], open, owner, width))
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].From != links[j].From {
+			return links[i].From < links[j].From
+		}
+		return links[i].To < links[j].To
+	})
+	return links
+}
+
+// runRoute returns the shortest route through floor outside the rooms from
+// one of the from tiles to one of the to tiles, with its straight runs
+// reduced to their ends.
+func runRoute(from, to []Point, open func(Point) bool, owner []int, width int) Path {
+	goal := make(map[Point]bool, len(to))
+	for _, p := range to {
+		goal[p] = true
+	}
+	prev := make(map[Point]Point)
+	queue := make([]Point, 0, len(from))
+	for _, p := range from {
+		if _, ok := prev[p]; !ok {
+			prev[p] = p
+			queue = append(queue, p)
+		}
+	}
+
+	for k := 0; k < len(queue); k++ {
+		p := queue[k]
+		if !goal[p] {
+			for _, d := range neighbours {
+				q := Point{X: p.X + d.X, Y: p.Y + d.Y}
+				if _, ok := prev[q]; ok || !open(q) || owner[q.Y*width+q.X] > 0 {
+					continue
+				}
+				prev[q] = p
+				queue = append(queue, q)
+			}
+			continue
+		}
+
+		tiles := []Point{p}
+		for prev[p] != p {
+			p = prev[p]
+			tiles = append(tiles, p)
+		}
+		points := []Point{tiles[len(tiles)-1]}
+		for i := len(tiles) - 2; i >= 0; i-- {
+			if n := len(points); n > 1 {
+				a, b := points[n-2], points[n-1]
+				if b.X-a.X == tiles[i].X-b.X && b.Y-a.Y == tiles[i].Y-b.Y {
+					points[n-1] = tiles[i]
+					continue
+				}
+			}
+			points = append(points, tiles[i])
+		}
+		if len(points) == 1 {
+			points = append(points, points[0])
+		}
+		return Path{Points: points}
+	}
+	return Path{}
+}
+
+// SyncPassages finds the rooms the carved map joins without a connector
+// between them, such as rooms whose floors touch or corridors that cross,
+// and records a passage for each pair: its route in layout.CorridorPaths
+// and, where the route ends beside the rooms, door anchors on their walls.
+// Passages are numbered passage_1, passage_2, ... in room order; the graph
+// must gain a connector for each before the layout is used further.
+func SyncPassages(g Graph, layout *Layout, tm *TileMap) []Passage {
+	floor := tm.Layers["floor"]
+	if floor == nil {
+		return nil
+	}
+	var walls []uint32
+	if layer := tm.Layers["walls"]; layer != nil {
+		walls = layer.Data
+	}
+
+	rooms := make(map[string]Rect, len(layout.Poses))
+	for id, pose := range layout.Poses {
+		if room := g.GetRoom(id); room != nil {
+			rooms[id] = RoomBounds(room.GetSize(), pose)
+		}
+	}
+
+	joined := make(map[[2]string]bool)
+	for _, id := range g.GetConnectorIDs() {
+		conn := g.GetConnector(id)
+		joined[[2]string{conn.GetFrom(), conn.GetTo()}] = true
+		joined[[2]string{conn.GetTo(), conn.GetFrom()}] = true
+	}
+
+	var passages []Passage
+	for _, link := range FloorLinks(floor.Data, walls, tm.Width, tm.Height, rooms) {
+		if joined[[2]string{link.From, link.To}] {
+			continue
+		}
+		id := "passage_" + strconv.Itoa(len(passages)+1)
+		passages = append(passages, Passage{ID: id, From: link.From, To: link.To})
+		layout.CorridorPaths[id] = link.Path
+
+		first, last := link.Path.Points[0], link.Path.Points[len(link.Path.Points)-1]
+		from, to := rooms[link.From], rooms[link.To]
+		if !contains(from, first) && !contains(to, last) {
+			if layout.Doors == nil {
+				layout.Doors = make(map[string][]DoorAnchor)
+			}
+			layout.Doors[id] = []DoorAnchor{wallDoor(link.From, from, first), wallDoor(link.To, to, last)}
+		}
+	}
+	return passages
+}
@@ -0,0 +1,94 @@
+package carving
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestFloorLinks verifies rooms are linked by touching floors and by runs of
+// floor outside the rooms, but not through walls or other rooms.
+func TestFloorLinks(t *testing.T) {
+	const width, height = 30, 10
+	floor := make([]uint32, width*height)
+	walls := make([]uint32, width*height)
+	fill := func(r Rect) {
+		_ = FillRect(floor, r.X, r.Y, r.Width, r.Height, width, height, uint32(TileFloor))
+	}
+	rooms := map[string]Rect{
+		"a": {X: 1, Y: 1, Width: 4, Height: 4},
+		"b": {X: 5, Y: 1, Width: 4, Height: 4}, // touches a
+		"c": {X: 12, Y: 1, Width: 4, Height: 4},
+		"d": {X: 19, Y: 1, Width: 4, Height: 4},
+		"e": {X: 26, Y: 1, Width: 3, Height: 4},
+	}
+	for _, r := range rooms {
+		fill(r)
+	}
+	fill(Rect{X: 9, Y: 2, Width: 3, Height: 1})  // b to c
+	fill(Rect{X: 16, Y: 2, Width: 3, Height: 1}) // c to d, stopping at c
+	fill(Rect{X: 23, Y: 2, Width: 3, Height: 1}) // d to e, sealed below
+	walls[2*width+24] = uint32(TileWall)
+
+	got := FloorLinks(floor, walls, width, height, rooms)
+
+	want := []FloorLink{
+		{From: "a", To: "b", Path: Path{Points: []Point{{X: 4, Y: 1}, {X: 5, Y: 1}}}},
+		{From: "b", To: "c", Path: Path{Points: []Point{{X: 9, Y: 2}, {X: 11, Y: 2}}}},
+		{From: "c", To: "d", Path: Path{Points: []Point{{X: 16, Y: 2}, {X: 18, Y: 2}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FloorLinks() = %+v, want %+v", got, want)
+	}
+}
+
+// TestSyncPassages verifies crossing corridors give every pair of rooms
+// they join a passage, routed along the floor between door anchors on the
+// rooms' walls.
+func TestSyncPassages(t *testing.T) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 30}, "b": {X: 70, Y: 30},
+		"c": {X: 40, Y: 8}, "d": {X: 40, Y: 52},
+	}, [2]string{"a", "b"}, [2]string{"c", "d"})
+	for id, conn := range connectors {
+		layout.CorridorPaths[id] = Path{Points: []Point{
+			{X: layout.Poses[conn.From].X, Y: layout.Poses[conn.From].Y},
+			{X: layout.Poses[conn.To].X, Y: layout.Poses[conn.To].Y},
+		}}
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	AnchorDoors(g, layout)
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+
+	passages := SyncPassages(g, layout, tm)
+
+	want := []Passage{
+		{ID: "passage_1", From: "a", To: "c"},
+		{ID: "passage_2", From: "a", To: "d"},
+		{ID: "passage_3", From: "b", To: "c"},
+		{ID: "passage_4", From: "b", To: "d"},
+	}
+	if !reflect.DeepEqual(passages, want) {
+		t.Fatalf("SyncPassages() = %+v, want %+v", passages, want)
+	}
+	for _, p := range passages {
+		doors := layout.Doors[p.ID]
+		if len(doors) != 2 || doors[0].Room != p.From || doors[1].Room != p.To {
+			t.Fatalf("Doors[%s] = %+v, want anchors at %s and %s", p.ID, doors, p.From, p.To)
+		}
+		for _, door := range doors {
+			b := RoomBounds(g.GetRoom(door.Room).GetSize(), layout.Poses[door.Room])
+			if !onWall(b, door) {
+				t.Errorf("Doors[%s] anchor %+v is not on the %s wall of %v", p.ID, door, door.Side, b)
+			}
+		}
+		for _, tile := range RouteTiles(layout.CorridorPaths[p.ID]) {
+			if GetTile(tm.Layers["floor"].Data, tile.X, tile.Y, tm.Width, tm.Height) != uint32(TileFloor) {
+				t.Errorf("passage %s runs over uncarved tile %+v", p.ID, tile)
+			}
+		}
+	}
+}
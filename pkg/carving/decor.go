@@ -0,0 +1,116 @@
+package carving
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// VaultDecorations are the decorations of treasure vault rooms, whatever
+// their biome: heaped coins and gilded statues mark a vault apart from the
+// rest of the dungeon.
+var VaultDecorations = []themes.Decorator{
+	{Type: "gold_pile", Density: 0.3},
+	{Type: "gilded_statue", Density: 0.1},
+}
+
+// BuildDecorLayer derives a "decor" tile layer scattering each room's theme
+// decorations along its walls. A room floor tile next to a wall takes the
+// first rule whose Density its roll falls under, storing 1 + the rule's
+// index in the room's rules; 0 means undecorated. Corridor tiles, and so
+// doorways, stay clear. Rolls hash the room and tile rather than drawing
+// from an RNG, so decoration never shifts other random decisions.
+// rulesFor returns the decoration rules for a room and may be nil.
+func BuildDecorLayer(tm *TileMap, g Graph, layout *Layout, rulesFor func(Room) []themes.Decorator) *Layer {
+	data := make([]uint32, tm.Width*tm.Height)
+
+	corridor := make([]bool, len(data))
+	for _, path := range layout.CorridorPaths {
+		for i := 0; i < len(path.Points)-1; i++ {
+			p1, p2 := path.Points[i], path.Points[i+1]
+			walkLine(p1.X, p1.Y, p2.X, p2.Y, func(x, y int) {
+				if x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+					corridor[y*tm.Width+x] = true
+				}
+			})
+		}
+	}
+
+	var floor, walls []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+	if layer, ok := tm.Layers["walls"]; ok {
+		walls = layer.Data
+	}
+	byWall := func(x, y int) bool {
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			if GetTile(walls, x+d[0], y+d[1], tm.Width, tm.Height) != 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Sort room IDs for deterministic iteration
+	roomIDs := make([]string, 0, len(layout.Poses))
+	for id := range layout.Poses {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		room := g.GetRoom(id)
+		if room == nil || rulesFor == nil {
+			continue
+		}
+		rules := rulesFor(room)
+		if len(rules) == 0 {
+			continue
+		}
+
+		b := RoomBounds(room.GetSize(), layout.Poses[id])
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if x < 0 || x >= tm.Width || y < 0 || y >= tm.Height {
+					continue
+				}
+				idx := y*tm.Width + x
+				if data[idx] != 0 || corridor[idx] || GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) || !byWall(x, y) {
+					continue
+				}
+				for i, rule := range rules {
+					if decorRoll(id, x, y, i) < rule.Density {
+						data[idx] = uint32(i + 1)
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return &Layer{
+		ID:      len(tm.Layers),
+		Name:    "decor",
+		Type:    "tilelayer",
+		Visible: true,
+		Opacity: 1.0,
+		Data:    data,
+	}
+}
+
+// decorRoll returns a fixed roll in [0.0, 1.0) for one decoration rule of a
+// room tile.
+func decorRoll(roomID string, x, y, rule int) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(roomID))
+	h.Write([]byte{0})
+	h.Write(strconv.AppendInt(nil, int64(x), 10))
+	h.Write([]byte{','})
+	h.Write(strconv.AppendInt(nil, int64(y), 10))
+	h.Write([]byte{','})
+	h.Write(strconv.AppendInt(nil, int64(rule), 10))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
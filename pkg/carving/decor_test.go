@@ -0,0 +1,107 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// TestBuildDecorLayer verifies theme decorations line room walls, stay out of
+// corridors, and are the same on every carve.
+func TestBuildDecorLayer(t *testing.T) {
+	rooms := map[string]*graph.Room{
+		"crypt":  {ID: "crypt", Size: graph.SizeM, Tags: map[string]string{"biome": "crypt"}},
+		"fungal": {ID: "fungal", Size: graph.SizeM, Tags: map[string]string{"biome": "fungal"}},
+	}
+	connectors := map[string]*graph.Connector{
+		"conn1": {ID: "conn1", From: "crypt", To: "fungal", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1.0},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	layout := &Layout{
+		Poses: map[string]Pose{
+			"crypt":  {X: 10, Y: 10},
+			"fungal": {X: 50, Y: 10},
+		},
+		CorridorPaths: map[string]Path{
+			"conn1": {Points: []Point{{X: 10, Y: 10}, {X: 50, Y: 10}}},
+		},
+		Bounds: Rect{Width: 60, Height: 20},
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	layer, ok := tm.Layers["decor"]
+	if !ok {
+		t.Fatal("Carve() did not create decor layer")
+	}
+
+	crypt, _ := themes.Lookup("crypt")
+	rules := len(crypt.DecorationRules())
+	decorated := 0
+	for i, v := range layer.Data {
+		if v == 0 {
+			continue
+		}
+		decorated++
+		x, y := i%tm.Width, i/tm.Width
+		if int(v) > rules {
+			t.Errorf("decor tile (%d,%d) = %d, want at most %d", x, y, v, rules)
+		}
+		if y == 10 && x >= 13 && x <= 47 {
+			t.Errorf("decor tile (%d,%d) is in the corridor", x, y)
+		}
+		if tm.Layers["floor"].Data[i] != uint32(TileFloor) {
+			t.Errorf("decor tile (%d,%d) is not floor", x, y)
+		}
+	}
+	if decorated == 0 {
+		t.Error("no tiles were decorated")
+	}
+
+	again := BuildDecorLayer(tm, g, layout, func(room Room) []themes.Decorator {
+		theme, _ := themes.Lookup(room.GetTags()["biome"])
+		return theme.DecorationRules()
+	})
+	for i := range layer.Data {
+		if again.Data[i] != layer.Data[i] {
+			t.Fatalf("decor tile %d differs between builds", i)
+		}
+	}
+
+	// Overridden biomes take the override's rules, the others their own
+	override := map[string][]themes.Decorator{"crypt": {{Type: "torch", Density: 1}}}
+	tm, err = NewDefaultCarver(16, 16).WithDecorations(override).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	for i, v := range tm.Layers["decor"].Data {
+		if x := i % tm.Width; x < 30 && v != 0 && v != 1 {
+			t.Errorf("crypt decor tile %d = %d, want the override's only rule", i, v)
+		}
+		if x := i % tm.Width; x >= 30 && v != layer.Data[i] {
+			t.Errorf("fungal decor tile %d = %d, want %d", i, v, layer.Data[i])
+		}
+	}
+}
+
+// TestDecorationRules_Vault verifies vault rooms take VaultDecorations
+// whatever their biome.
+func TestDecorationRules_Vault(t *testing.T) {
+	c := NewDefaultCarver(16, 16)
+	vault := &graph.Room{ID: "vault_0", Tags: map[string]string{"biome": "crypt", graph.VaultTag: "vault_0"}}
+	rules := c.decorationRules(NewGraphAdapter(map[string]*graph.Room{vault.ID: vault}, nil).GetRoom(vault.ID))
+	if len(rules) != len(VaultDecorations) || rules[0] != VaultDecorations[0] {
+		t.Errorf("vault rules = %v, want %v", rules, VaultDecorations)
+	}
+
+	crypt, _ := themes.Lookup("crypt")
+	room := &graph.Room{ID: "crypt", Tags: map[string]string{"biome": "crypt"}}
+	rules = c.decorationRules(NewGraphAdapter(map[string]*graph.Room{room.ID: room}, nil).GetRoom(room.ID))
+	if len(rules) != len(crypt.DecorationRules()) {
+		t.Errorf("crypt rules = %v, want the theme's", rules)
+	}
+}
@@ -0,0 +1,365 @@
+package carving
+
+import (
+	"sort"
+)
+
+// Secret kinds sealing a hidden connector's corridor. The secret (To) room
+// picks the kind with its "secret_kind" tag; rooms without one, and breaches
+// that break the kind's placement rule, get a destructible wall.
+const (
+	// SecretDestructibleWall is a wall bombed open.
+	SecretDestructibleWall = "destructible_wall"
+
+	// SecretIllusoryWall is a wall the player walks through. It needs solid
+	// ground on both sides of the breach across the corridor, so it reads as
+	// part of the corridor wall; its collision is a trigger, not solid.
+	SecretIllusoryWall = "illusory_wall"
+
+	// SecretCollapsibleFloor is a drop into the passage: the breach has no
+	// floor until the floor before it gives way. It needs that floor to be
+	// corridor, outside both rooms.
+	SecretCollapsibleFloor = "collapsible_floor"
+
+	// SecretMovableBlock is a block pushed along the corridor. It needs floor
+	// past the breach, in the direction the corridor enters it, for the block
+	// to slide onto, and records that direction as push_dx and push_dy.
+	SecretMovableBlock = "movable_block"
+
+	// SecretShrineRiddle is a wall that opens when the riddle at a shrine in
+	// the From room is answered. The shrine is a "triggers" object on the
+	// From room's floor tile nearest the breach.
+	SecretShrineRiddle = "shrine_riddle"
+)
+
+// SecretKinds lists the secret kinds in the order of the constants above.
+var SecretKinds = []string{
+	SecretDestructibleWall,
+	SecretIllusoryWall,
+	SecretCollapsibleFloor,
+	SecretMovableBlock,
+	SecretShrineRiddle,
+}
+
+// secretNames are the object name prefixes of the secret kinds, e.g.
+// "destructible_" for the wall sealing connector "conn_a_b".
+var secretNames = map[string]string{
+	SecretDestructibleWall: "destructible_",
+	SecretIllusoryWall:     "illusory_",
+	SecretCollapsibleFloor: "collapsible_",
+	SecretMovableBlock:     "block_",
+	SecretShrineRiddle:     "shrine_riddle_",
+}
+
+// placeDestructibles seals every hidden connector's corridor with a secret
+// and records it in the destructibles object layer (see sealHidden). The
+// secret sits on the first corridor tile outside the secret (To) room, so
+// the secret room is cut off from the map until the secret is found.
+// Hidden corridors running entirely over visible corridors or the rooms
+// they join are left unsealed; UnsealedHidden lists them. Connectors are
+// processed in ID order for determinism.
+func (c *DefaultCarver) placeDestructibles(g Graph, layout *Layout, tm *TileMap, layer *Layer) {
+	floorData := tm.Layers["floor"].Data
+
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for connID := range layout.CorridorPaths {
+		connIDs = append(connIDs, connID)
+	}
+	sort.Strings(connIDs)
+
+	// Tiles carried by visible corridors must never be sealed
+	open := openTiles(g, layout, floorData, tm.Width, tm.Height)
+
+	objID := 1
+	var tiles []Point
+	for _, connID := range connIDs {
+		conn := g.GetConnector(connID)
+		if conn == nil || conn.GetType() != TypeHidden {
+			continue
+		}
+
+		tiles = appendPathTiles(tiles[:0], layout.CorridorPaths[connID], floorData, tm.Width, tm.Height)
+		if sealHidden(g, layout, tm, layer, conn, connID, tiles, open, objID) {
+			objID++
+		}
+	}
+}
+
+// sealHidden seals a hidden connector's corridor at its breach (see
+// breachIndex) with the secret kind its To room asks for, falling back to a
+// destructible wall where the corridor breaks the kind's placement rule.
+// Every kind but a collapsible floor stands a wall on the breach over its
+// floor, so opening it only clears the wall layer; a collapsible floor
+// clears the breach's floor instead. The secret is appended to layer with
+// the given object ID. It reports false, sealing nothing, when the corridor
+// has no breach.
+func sealHidden(g Graph, layout *Layout, tm *TileMap, layer *Layer, conn Connector, connID string, tiles []Point, open []bool, objID int) bool {
+	floor, walls := tm.Layers["floor"].Data, tm.Layers["walls"].Data
+	rooms := connectorRooms(g, layout, conn)
+	i, ok := breachIndex(rooms, tiles, open, tm.Width)
+	if !ok {
+		return false
+	}
+	breach := tiles[i]
+
+	kind := SecretDestructibleWall
+	if room := g.GetRoom(conn.GetTo()); room != nil {
+		kind = room.GetTags()["secret_kind"]
+	}
+	var shrine, push Point
+	switch kind {
+	case SecretIllusoryWall:
+		if !flanked(tiles, i, floor, tm.Width, tm.Height) {
+			kind = SecretDestructibleWall
+		}
+	case SecretCollapsibleFloor:
+		if i == 0 || !adjacent(tiles[i-1], breach) || inRects(tiles[i-1], rooms) {
+			kind = SecretDestructibleWall
+		}
+	case SecretMovableBlock:
+		if push, ok = pushSpace(tiles, i, floor, tm.Width, tm.Height); !ok {
+			kind = SecretDestructibleWall
+		}
+	case SecretShrineRiddle:
+		if shrine, ok = shrineTile(g, layout, conn, tiles, breach, floor, tm.Width, tm.Height); !ok {
+			kind = SecretDestructibleWall
+		}
+	default:
+		kind = SecretDestructibleWall
+	}
+
+	obj := secretObject(kind, conn, connID, breach, tm.TileWidth, tm.TileHeight, objID)
+	switch kind {
+	case SecretCollapsibleFloor:
+		_ = SetTile(floor, breach.X, breach.Y, tm.Width, tm.Height, uint32(TileEmpty))
+	case SecretIllusoryWall:
+		_ = SetTile(walls, breach.X, breach.Y, tm.Width, tm.Height, uint32(TileWall))
+		obj.Properties["passable"] = true
+	case SecretMovableBlock:
+		_ = SetTile(walls, breach.X, breach.Y, tm.Width, tm.Height, uint32(TileWall))
+		obj.Properties["push_dx"] = push.X
+		obj.Properties["push_dy"] = push.Y
+	case SecretShrineRiddle:
+		_ = SetTile(walls, breach.X, breach.Y, tm.Width, tm.Height, uint32(TileWall))
+		triggers, ok := tm.Layers["triggers"]
+		if !ok {
+			triggers = AddLayer(tm, "triggers", "objectgroup")
+		}
+		triggers.Objects = append(triggers.Objects, Object{
+			ID:      nextObjectID(triggers),
+			Name:    "shrine_" + connID,
+			Type:    "shrine",
+			X:       float64(shrine.X * tm.TileWidth),
+			Y:       float64(shrine.Y * tm.TileHeight),
+			Width:   float64(tm.TileWidth),
+			Height:  float64(tm.TileHeight),
+			Visible: true,
+			Properties: map[string]interface{}{
+				"connector_id": connID,
+			},
+		})
+	default:
+		_ = SetTile(walls, breach.X, breach.Y, tm.Width, tm.Height, uint32(TileWall))
+	}
+	layer.Objects = append(layer.Objects, obj)
+	return true
+}
+
+// UnsealedHidden returns the hidden connectors of a carved map that have
+// no secret sealing them, in ID order. Visible corridors, or the rooms the
+// connector joins, carry their whole corridor, so they are open to anyone.
+func UnsealedHidden(g Graph, tm *TileMap) []string {
+	if g == nil || tm == nil || tm.Layers["destructibles"] == nil {
+		return nil
+	}
+	sealed := make(map[string]bool)
+	for _, obj := range tm.Layers["destructibles"].Objects {
+		if connID, ok := obj.Properties["connector_id"].(string); ok {
+			sealed[connID] = true
+		}
+	}
+
+	var open []string
+	for _, connID := range g.GetConnectorIDs() {
+		if conn := g.GetConnector(connID); conn != nil && conn.GetType() == TypeHidden && !sealed[connID] {
+			open = append(open, connID)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// openTiles marks the floor tiles carried by the visible (non-hidden)
+// corridors of a layout in a row-major grid the size of the map.
+func openTiles(g Graph, layout *Layout, floorData []uint32, width, height int) []bool {
+	open := make([]bool, width*height)
+	var tiles []Point
+	for connID, path := range layout.CorridorPaths {
+		if conn := g.GetConnector(connID); conn != nil && conn.GetType() != TypeHidden {
+			tiles = appendPathTiles(tiles[:0], path, floorData, width, height)
+			for _, t := range tiles {
+				open[t.Y*width+t.X] = true
+			}
+		}
+	}
+	return open
+}
+
+// secretObject returns the object of a secret of the given kind sealing a
+// hidden connector at the breach tile.
+func secretObject(kind string, conn Connector, connID string, breach Point, tileWidth, tileHeight, id int) Object {
+	return Object{
+		ID:      id,
+		Name:    secretNames[kind] + connID,
+		Type:    kind,
+		X:       float64(breach.X * tileWidth),
+		Y:       float64(breach.Y * tileHeight),
+		Width:   float64(tileWidth),
+		Height:  float64(tileHeight),
+		Visible: true,
+		Properties: map[string]interface{}{
+			"connector_id": connID,
+			"from_room":    conn.GetFrom(),
+			"to_room":      conn.GetTo(),
+		},
+	}
+}
+
+// connectorRooms returns the footprints of a connector's To and From rooms,
+// skipping rooms without a pose.
+func connectorRooms(g Graph, layout *Layout, conn Connector) []Rect {
+	var rooms []Rect
+	for _, id := range []string{conn.GetTo(), conn.GetFrom()} {
+		if room := g.GetRoom(id); room != nil {
+			if pose, ok := layout.Poses[id]; ok {
+				rooms = append(rooms, RoomBounds(room.GetSize(), pose))
+			}
+		}
+	}
+	return rooms
+}
+
+// breachIndex finds where a hidden corridor meets its secret room.
+// It walks the corridor tiles from the To end and returns the index of the
+// first tile outside the rooms' footprints that no visible corridor uses. When every
+// tile is inside a room or open, it reports false and the connector is left
+// unsealed rather than cutting a visible corridor. open is the grid returned
+// by openTiles for a map of the given width.
+func breachIndex(rooms []Rect, tiles []Point, open []bool, width int) (int, bool) {
+	for i := len(tiles) - 1; i >= 0; i-- {
+		t := tiles[i]
+		if open[t.Y*width+t.X] {
+			continue
+		}
+		if !inRects(t, rooms) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// inRects reports whether a tile lies inside any of the rectangles.
+func inRects(t Point, rects []Rect) bool {
+	for _, b := range rects {
+		if t.X >= b.X && t.X < b.X+b.Width && t.Y >= b.Y && t.Y < b.Y+b.Height {
+			return true
+		}
+	}
+	return false
+}
+
+// flanked reports whether the corridor runs one tile wide through tiles[i]:
+// both tiles beside it, across the corridor, are off the floor.
+func flanked(tiles []Point, i int, floor []uint32, width, height int) bool {
+	t := tiles[i]
+	var d Point
+	switch {
+	case i+1 < len(tiles) && adjacent(t, tiles[i+1]):
+		d = Point{X: tiles[i+1].X - t.X, Y: tiles[i+1].Y - t.Y}
+	case i > 0 && adjacent(t, tiles[i-1]):
+		d = Point{X: t.X - tiles[i-1].X, Y: t.Y - tiles[i-1].Y}
+	default:
+		return false
+	}
+	for _, s := range []int{-1, 1} {
+		if GetTile(floor, t.X+s*d.Y, t.Y+s*d.X, width, height) == uint32(TileFloor) {
+			return false
+		}
+	}
+	return true
+}
+
+// pushSpace returns the direction the corridor enters tiles[i] from the From
+// end, when the tile past it in that direction is floor a block pushed along
+// the corridor can slide onto.
+func pushSpace(tiles []Point, i int, floor []uint32, width, height int) (Point, bool) {
+	if i == 0 || !adjacent(tiles[i-1], tiles[i]) {
+		return Point{}, false
+	}
+	t := tiles[i]
+	d := Point{X: t.X - tiles[i-1].X, Y: t.Y - tiles[i-1].Y}
+	if GetTile(floor, t.X+d.X, t.Y+d.Y, width, height) != uint32(TileFloor) {
+		return Point{}, false
+	}
+	return d, true
+}
+
+// adjacent reports whether two tiles share an edge.
+func adjacent(a, b Point) bool {
+	return abs(a.X-b.X)+abs(a.Y-b.Y) == 1
+}
+
+// shrineTile returns the floor tile of a connector's From room nearest the
+// breach, off the corridor itself, for a riddle shrine. Ties go to the first
+// tile in row-major order.
+func shrineTile(g Graph, layout *Layout, conn Connector, tiles []Point, breach Point, floor []uint32, width, height int) (Point, bool) {
+	room := g.GetRoom(conn.GetFrom())
+	pose, ok := layout.Poses[conn.GetFrom()]
+	if room == nil || !ok {
+		return Point{}, false
+	}
+	onPath := make(map[Point]bool, len(tiles))
+	for _, t := range tiles {
+		onPath[t] = true
+	}
+
+	b := RoomBounds(room.GetSize(), pose)
+	best, bestDist := Point{}, -1
+	for y := b.Y; y < b.Y+b.Height; y++ {
+		for x := b.X; x < b.X+b.Width; x++ {
+			t := Point{X: x, Y: y}
+			if onPath[t] || GetTile(floor, x, y, width, height) != uint32(TileFloor) {
+				continue
+			}
+			if d := abs(x-breach.X) + abs(y-breach.Y); bestDist < 0 || d < bestDist {
+				best, bestDist = t, d
+			}
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// pathTiles returns the floor tiles along a corridor path in path order.
+func pathTiles(path Path, floor []uint32, width, height int) []Point {
+	return appendPathTiles(make([]Point, 0, PathLength(path)+1), path, floor, width, height)
+}
+
+// appendPathTiles appends the floor tiles along a corridor path to dst in
+// path order, so callers walking many paths can reuse one buffer.
+func appendPathTiles(dst []Point, path Path, floor []uint32, width, height int) []Point {
+	tiles := dst
+	visit := func(x, y int) {
+		if GetTile(floor, x, y, width, height) == uint32(TileFloor) {
+			tiles = append(tiles, Point{X: x, Y: y})
+		}
+	}
+
+	if len(path.Points) == 1 {
+		visit(path.Points[0].X, path.Points[0].Y)
+	}
+	for i := 0; i < len(path.Points)-1; i++ {
+		p1, p2 := path.Points[i], path.Points[i+1]
+		walkLine(p1.X, p1.Y, p2.X, p2.Y, visit)
+	}
+	return tiles
+}
@@ -0,0 +1,275 @@
+package carving
+
+import "sort"
+
+// MaxDoorsPerWall is the most doors AnchorDoors puts on one wall of a room
+// before moving on to the next best wall. Walls too short to keep a wall
+// tile between their doors hold fewer. When every wall is full, doors go on
+// the wall facing the other room anyway.
+const MaxDoorsPerWall = 2
+
+// doorEnd is one end of a connector, waiting for its anchor.
+type doorEnd struct {
+	conn  string
+	index int    // 0 for the From room, 1 for the To room
+	room  string // Room the door opens into
+	other Point  // Center of the room at the other end
+	side  Side
+}
+
+// AnchorDoors picks the wall of each room where each of its corridors meets
+// it, records the door anchors in layout.Doors and reroutes every corridor
+// to run between its anchors, so carving opens the walls exactly at the
+// anchors and places the doors there.
+//
+// Algorithm:
+//  1. Visit connectors in ID order, the From room then the To room of each,
+//     and pick the wall facing the other room along the axis where it is
+//     furthest away, then the wall facing it along the other axis, then the
+//     remaining two, skipping walls that hold their share of doors
+//  2. Order the doors of each wall by where their other room lies along it,
+//     so corridors leaving one wall do not cross, and spread them evenly
+//  3. Route each corridor from its From anchor one tile straight out, along
+//     a Manhattan path to one tile outside its To anchor, and in
+//  4. Move everything right and down, and grow the bounds, until the new
+//     corridors fit the map
+//
+// Connectors missing a room or pose keep their paths and get no anchors.
+func AnchorDoors(g Graph, layout *Layout) {
+	if g == nil || layout == nil {
+		return
+	}
+
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	bounds := make(map[string]Rect)
+	roomBounds := func(id string) (Rect, bool) {
+		if b, ok := bounds[id]; ok {
+			return b, true
+		}
+		room := g.GetRoom(id)
+		pose, ok := layout.Poses[id]
+		if room == nil || !ok {
+			return Rect{}, false
+		}
+		bounds[id] = RoomBounds(room.GetSize(), pose)
+		return bounds[id], true
+	}
+
+	// 1. Walls
+	type wall struct {
+		room string
+		side Side
+	}
+	walls := make(map[wall][]*doorEnd)
+	var ends []*doorEnd
+	for _, connID := range connIDs {
+		conn := g.GetConnector(connID)
+		if conn == nil {
+			continue
+		}
+		ids := [2]string{conn.GetFrom(), conn.GetTo()}
+		_, okFrom := roomBounds(ids[0])
+		_, okTo := roomBounds(ids[1])
+		if !okFrom || !okTo {
+			continue
+		}
+		for i, id := range ids {
+			other := layout.Poses[ids[1-i]]
+			end := &doorEnd{conn: connID, index: i, room: id, other: Point{X: other.X, Y: other.Y}}
+			pose := layout.Poses[id]
+			sides := facingSides(other.X-pose.X, other.Y-pose.Y)
+			end.side = sides[0]
+			for _, side := range sides {
+				if len(walls[wall{id, side}]) < wallCapacity(bounds[id], side) {
+					end.side = side
+					break
+				}
+			}
+			walls[wall{id, end.side}] = append(walls[wall{id, end.side}], end)
+			ends = append(ends, end)
+		}
+	}
+	if len(ends) == 0 {
+		return
+	}
+
+	// 2. Anchors spread along each wall
+	anchors := make(map[*doorEnd]DoorAnchor, len(ends))
+	for w, members := range walls {
+		along := func(p Point) int {
+			if w.side == SideNorth || w.side == SideSouth {
+				return p.X
+			}
+			return p.Y
+		}
+		sort.Slice(members, func(i, j int) bool {
+			a, b := members[i], members[j]
+			if along(a.other) != along(b.other) {
+				return along(a.other) < along(b.other)
+			}
+			if a.conn != b.conn {
+				return a.conn < b.conn
+			}
+			return a.index < b.index
+		})
+		b := bounds[w.room]
+		for k, end := range members {
+			anchors[end] = wallAnchor(w.room, w.side, b, k, len(members))
+		}
+	}
+
+	// 3. Corridors between the anchors
+	layout.Doors = make(map[string][]DoorAnchor, len(ends)/2)
+	for _, end := range ends {
+		if end.index == 0 {
+			layout.Doors[end.conn] = make([]DoorAnchor, 2)
+		}
+		layout.Doors[end.conn][end.index] = anchors[end]
+	}
+	for connID, doors := range layout.Doors {
+		layout.CorridorPaths[connID] = doorRoute(doors[0], doors[1])
+	}
+
+	// 4. Keep the corridors on the map
+	minX, minY := 0, 0
+	maxX, maxY := layout.Bounds.Width-1, layout.Bounds.Height-1
+	for _, path := range layout.CorridorPaths {
+		for _, p := range path.Points {
+			minX, minY = min(minX, p.X), min(minY, p.Y)
+			maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+		}
+	}
+	dx, dy := -minX, -minY
+	if dx > 0 || dy > 0 {
+		for id, pose := range layout.Poses {
+			pose.X, pose.Y = pose.X+dx, pose.Y+dy
+			layout.Poses[id] = pose
+		}
+		for _, path := range layout.CorridorPaths {
+			for i := range path.Points {
+				path.Points[i].X += dx
+				path.Points[i].Y += dy
+			}
+		}
+		for _, doors := range layout.Doors {
+			for i := range doors {
+				doors[i].X += dx
+				doors[i].Y += dy
+			}
+		}
+	}
+	layout.Bounds.Width = maxX + dx + 1
+	layout.Bounds.Height = maxY + dy + 1
+}
+
+// facingSides returns the sides of a room in the order AnchorDoors tries
+// them for a door towards a room (dx, dy) away.
+func facingSides(dx, dy int) [4]Side {
+	horizontal, vertical := SideEast, SideSouth
+	if dx < 0 {
+		horizontal = SideWest
+	}
+	if dy < 0 {
+		vertical = SideNorth
+	}
+	if abs(dx) >= abs(dy) {
+		return [4]Side{horizontal, vertical, opposite(vertical), opposite(horizontal)}
+	}
+	return [4]Side{vertical, horizontal, opposite(horizontal), opposite(vertical)}
+}
+
+// opposite returns the side facing the other way.
+func opposite(side Side) Side {
+	switch side {
+	case SideNorth:
+		return SideSouth
+	case SideSouth:
+		return SideNorth
+	case SideEast:
+		return SideWest
+	default:
+		return SideEast
+	}
+}
+
+// wallLength returns the number of floor tiles along one side of a room.
+func wallLength(b Rect, side Side) int {
+	if side == SideNorth || side == SideSouth {
+		return b.Width
+	}
+	return b.Height
+}
+
+// wallCapacity returns the number of doors a wall takes with a wall tile
+// between each two, up to MaxDoorsPerWall.
+func wallCapacity(b Rect, side Side) int {
+	return min(MaxDoorsPerWall, (wallLength(b, side)+1)/2)
+}
+
+// wallAnchor returns the anchor of door k of n on one side of a room: the
+// wall tile next to the floor, at the middle of the k-th of n equal
+// stretches of the wall.
+func wallAnchor(room string, side Side, b Rect, k, n int) DoorAnchor {
+	offset := (2*k + 1) * wallLength(b, side) / (2 * n)
+	anchor := DoorAnchor{Room: room, Side: side}
+	switch side {
+	case SideNorth:
+		anchor.X, anchor.Y = b.X+offset, b.Y-1
+	case SideSouth:
+		anchor.X, anchor.Y = b.X+offset, b.Y+b.Height
+	case SideWest:
+		anchor.X, anchor.Y = b.X-1, b.Y+offset
+	case SideEast:
+		anchor.X, anchor.Y = b.X+b.Width, b.Y+offset
+	}
+	return anchor
+}
+
+// outside returns the tile one step out of the room from a door anchor.
+func outside(anchor DoorAnchor) Point {
+	switch anchor.Side {
+	case SideNorth:
+		return Point{X: anchor.X, Y: anchor.Y - 1}
+	case SideSouth:
+		return Point{X: anchor.X, Y: anchor.Y + 1}
+	case SideWest:
+		return Point{X: anchor.X - 1, Y: anchor.Y}
+	default:
+		return Point{X: anchor.X + 1, Y: anchor.Y}
+	}
+}
+
+// doorRoute returns the corridor from one door anchor to another: straight
+// out of the first room, along the axis it left on, across to the tile
+// outside the second anchor and in. Repeated points and points in the
+// middle of a straight run are dropped.
+func doorRoute(from, to DoorAnchor) Path {
+	a, b := outside(from), outside(to)
+	corner := Point{X: b.X, Y: a.Y}
+	if from.Side == SideNorth || from.Side == SideSouth {
+		corner = Point{X: a.X, Y: b.Y}
+	}
+
+	var points []Point
+	for _, p := range []Point{{X: from.X, Y: from.Y}, a, corner, b, {X: to.X, Y: to.Y}} {
+		if n := len(points); n > 0 && points[n-1] == p {
+			continue
+		}
+		if n := len(points); n > 1 {
+			prev, last := points[n-2], points[n-1]
+			dx1, dy1 := last.X-prev.X, last.Y-prev.Y
+			dx2, dy2 := p.X-last.X, p.Y-last.Y
+			if dx1*dy2 == dy1*dx2 && dx1*dx2+dy1*dy2 > 0 {
+				points[n-1] = p
+				continue
+			}
+		}
+		points = append(points, p)
+	}
+	return Path{Points: points}
+}
@@ -0,0 +1,99 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestAnchorDoors verifies wall choice, the per-wall door limit, anchor
+// placement and corridor routes for a room with three neighbours to its
+// east.
+func TestAnchorDoors(t *testing.T) {
+	rooms := map[string]*graph.Room{}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		rooms[id] = &graph.Room{ID: id, Size: graph.SizeS}
+	}
+	connectors := map[string]*graph.Connector{
+		"ab": {ID: "ab", From: "a", To: "b", Type: graph.TypeDoor, Bidirectional: true, Cost: 1},
+		"ac": {ID: "ac", From: "a", To: "c", Type: graph.TypeDoor, Bidirectional: true, Cost: 1},
+		"ad": {ID: "ad", From: "a", To: "d", Type: graph.TypeDoor, Bidirectional: true, Cost: 1},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	layout := &Layout{
+		Poses: map[string]Pose{
+			"a": {X: 20, Y: 20}, "b": {X: 40, Y: 20},
+			"c": {X: 40, Y: 30}, "d": {X: 40, Y: 10},
+		},
+		CorridorPaths: map[string]Path{
+			"ab": {Points: []Point{{X: 20, Y: 20}, {X: 40, Y: 20}}},
+			"ac": {Points: []Point{{X: 20, Y: 20}, {X: 40, Y: 30}}},
+			"ad": {Points: []Point{{X: 20, Y: 20}, {X: 40, Y: 10}}},
+		},
+		Bounds: Rect{Width: 50, Height: 40},
+	}
+
+	AnchorDoors(g, layout)
+
+	wantSides := map[string][2]Side{
+		"ab": {SideEast, SideWest},
+		"ac": {SideEast, SideWest},
+		"ad": {SideNorth, SideWest}, // a's east wall is full
+	}
+	for connID, want := range wantSides {
+		doors := layout.Doors[connID]
+		if len(doors) != 2 {
+			t.Fatalf("Doors[%s] = %v, want 2 anchors", connID, doors)
+		}
+		for i, door := range doors {
+			if door.Side != want[i] {
+				t.Errorf("Doors[%s][%d].Side = %s, want %s", connID, i, door.Side, want[i])
+			}
+			b := RoomBounds(g.GetRoom(door.Room).GetSize(), layout.Poses[door.Room])
+			if !onWall(b, door) {
+				t.Errorf("Doors[%s][%d] = %+v is not on the %s wall of %v", connID, i, door, door.Side, b)
+			}
+		}
+		points := layout.CorridorPaths[connID].Points
+		first, last := points[0], points[len(points)-1]
+		if first != (Point{X: doors[0].X, Y: doors[0].Y}) || last != (Point{X: doors[1].X, Y: doors[1].Y}) {
+			t.Errorf("CorridorPaths[%s] = %v, want it to run between %+v and %+v", connID, points, doors[0], doors[1])
+		}
+	}
+
+	// b lies north of c, so its door comes first down a's east wall
+	if layout.Doors["ab"][0].Y >= layout.Doors["ac"][0].Y {
+		t.Errorf("door to b at y=%d, want above door to c at y=%d", layout.Doors["ab"][0].Y, layout.Doors["ac"][0].Y)
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	for connID, doors := range layout.Doors {
+		for _, door := range doors {
+			if GetTile(tm.Layers["floor"].Data, door.X, door.Y, tm.Width, tm.Height) != uint32(TileFloor) {
+				t.Errorf("anchor %+v of %s is not carved", door, connID)
+			}
+		}
+	}
+}
+
+// onWall reports whether an anchor is the wall tile next to the floor on
+// its side of b.
+func onWall(b Rect, d DoorAnchor) bool {
+	inX := d.X >= b.X && d.X < b.X+b.Width
+	inY := d.Y >= b.Y && d.Y < b.Y+b.Height
+	switch d.Side {
+	case SideNorth:
+		return inX && d.Y == b.Y-1
+	case SideSouth:
+		return inX && d.Y == b.Y+b.Height
+	case SideWest:
+		return inY && d.X == b.X-1
+	case SideEast:
+		return inY && d.X == b.X+b.Width
+	}
+	return false
+}
@@ -0,0 +1,322 @@
+package carving
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// ElevationOffset is added to every level stored in the "elevation" tile layer
+// so that sunken (negative) levels fit in unsigned layer data. A stored value of
+// ElevationOffset is ground level.
+const ElevationOffset = 128
+
+// MaxElevationStep is the largest height difference that can be walked between
+// two adjacent tiles. Larger differences are cliffs or pit walls.
+const MaxElevationStep = 1
+
+// RoomElevation resolves the interior level of a room from its archetype
+// name and tags. An explicit "elevation" tag wins: "raised" is +1, "sunken"
+// is -1, and an integer is used as-is, clamped to ±themes.MaxElevationLevel.
+// Otherwise the first theme rule whose archetype and tag match applies.
+// Rooms without a match stay at ground level (0).
+func RoomElevation(archetype string, tags map[string]string, rules []themes.ElevationRule) int {
+	if v, ok := tags["elevation"]; ok {
+		switch v {
+		case "raised":
+			return 1
+		case "sunken":
+			return -1
+		default:
+			if level, err := strconv.Atoi(v); err == nil {
+				return max(-themes.MaxElevationLevel, min(themes.MaxElevationLevel, level))
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Archetype != "" && !strings.EqualFold(rule.Archetype, archetype) {
+			continue
+		}
+		if rule.Tag != "" {
+			v, ok := tags[rule.Tag]
+			if !ok || (rule.Value != "" && rule.Value != v) {
+				continue
+			}
+		}
+		return rule.Level
+	}
+
+	return 0
+}
+
+// BuildElevationLayer derives an "elevation" tile layer for the carved map.
+// Each room's interior (its footprint minus a one-tile ring) is set to the
+// room's level, so doorways and the room edge stay at ground level. Corridor
+// tiles are never raised or sunk, which keeps routed corridors walkable.
+//
+// Levels steeper than MaxElevationStep are cliffs at the interior's edge,
+// broken by a ramp of one level per tile running in from the middle of one
+// side. Rooms where no ramp reaches the whole interior, such as those split
+// by a corridor, are built one step high instead.
+// rulesFor returns the theme rules for a room and may be nil.
+func BuildElevationLayer(tm *TileMap, g Graph, layout *Layout, rulesFor func(Room) []themes.ElevationRule) *Layer {
+	data := make([]uint32, tm.Width*tm.Height)
+	for i := range data {
+		data[i] = ElevationOffset
+	}
+	layer := &Layer{
+		ID:      len(tm.Layers),
+		Name:    "elevation",
+		Type:    "tilelayer",
+		Visible: false,
+		Opacity: 1.0,
+		Data:    data,
+	}
+
+	corridor := make([]bool, len(data))
+	for _, path := range layout.CorridorPaths {
+		for i := 0; i < len(path.Points)-1; i++ {
+			p1, p2 := path.Points[i], path.Points[i+1]
+			walkLine(p1.X, p1.Y, p2.X, p2.Y, func(x, y int) {
+				if x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+					corridor[y*tm.Width+x] = true
+				}
+			})
+		}
+	}
+
+	// The map as walked so far, for checking ramps
+	view := &TileMap{Width: tm.Width, Height: tm.Height, Layers: map[string]*Layer{"elevation": layer}}
+	var floor []uint32
+	if fl, ok := tm.Layers["floor"]; ok {
+		floor = fl.Data
+		view.Layers["floor"] = fl
+	}
+
+	// Sort room IDs for deterministic iteration
+	roomIDs := make([]string, 0, len(layout.Poses))
+	for id := range layout.Poses {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+
+		var rules []themes.ElevationRule
+		if rulesFor != nil {
+			rules = rulesFor(room)
+		}
+		level := RoomElevation(room.GetArchetype(), room.GetTags(), rules)
+		if level == 0 {
+			continue
+		}
+
+		b := RoomBounds(room.GetSize(), layout.Poses[id])
+		var interior []Point
+		for y := b.Y + 1; y < b.Y+b.Height-1; y++ {
+			for x := b.X + 1; x < b.X+b.Width-1; x++ {
+				if x < 0 || x >= tm.Width || y < 0 || y >= tm.Height {
+					continue
+				}
+				if corridor[y*tm.Width+x] || GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) {
+					continue
+				}
+				interior = append(interior, Point{X: x, Y: y})
+			}
+		}
+
+		if !raiseInterior(view, b, interior, level) {
+			raiseInterior(view, b, interior, level/abs(level))
+		}
+	}
+
+	return layer
+}
+
+// raiseInterior sets the interior tiles of a room with bounds b to level in
+// view's elevation layer. Levels steeper than MaxElevationStep get a ramp
+// from the middle of the west, east, north or south side, the first that
+// leaves every interior tile reachable from the room's edge. It reports
+// false, leaving the tiles at ground level, when no side does.
+func raiseInterior(view *TileMap, b Rect, interior []Point, level int) bool {
+	data := view.Layers["elevation"].Data
+	set := func(p Point, level int) {
+		data[p.Y*view.Width+p.X] = uint32(ElevationOffset + level)
+	}
+	for _, p := range interior {
+		set(p, level)
+	}
+	if abs(level) <= MaxElevationStep {
+		return true
+	}
+
+	inside := make(map[Point]bool, len(interior))
+	for _, p := range interior {
+		inside[p] = true
+	}
+
+	steps := abs(level) - 1
+	sign := level / abs(level)
+	midX, midY := b.X+b.Width/2, b.Y+b.Height/2
+	sides := []struct {
+		entry  Point
+		dx, dy int
+	}{
+		{Point{X: b.X, Y: midY}, 1, 0},
+		{Point{X: b.X + b.Width - 1, Y: midY}, -1, 0},
+		{Point{X: midX, Y: b.Y}, 0, 1},
+		{Point{X: midX, Y: b.Y + b.Height - 1}, 0, -1},
+	}
+
+	for _, side := range sides {
+		ramp := make([]Point, 0, steps)
+		for i := 1; i <= steps+1; i++ {
+			p := Point{X: side.entry.X + i*side.dx, Y: side.entry.Y + i*side.dy}
+			if !inside[p] {
+				ramp = nil
+				break
+			}
+			if i <= steps {
+				ramp = append(ramp, p)
+			}
+		}
+		if ramp == nil {
+			continue
+		}
+
+		for i, p := range ramp {
+			set(p, sign*(i+1))
+		}
+		reached := reachableWithin(view, side.entry, &b)
+		connected := true
+		for _, p := range interior {
+			connected = connected && reached[p.Y*view.Width+p.X]
+		}
+		if connected {
+			return true
+		}
+		for _, p := range ramp {
+			set(p, level)
+		}
+	}
+
+	for _, p := range interior {
+		set(p, 0)
+	}
+	return false
+}
+
+// ElevationAt returns the signed level of a tile in an elevation layer.
+// Tiles outside the map, or maps without an elevation layer, are ground level.
+func ElevationAt(layer *Layer, x, y, width, height int) int {
+	if layer == nil {
+		return 0
+	}
+	v := GetTile(layer.Data, x, y, width, height)
+	if v == 0 {
+		return 0
+	}
+	return int(v) - ElevationOffset
+}
+
+// ReachableTiles returns which tiles can be walked to from start.
+// Movement is 4-directional over floor tiles, is blocked by solid collision,
+// and may only step between tiles whose elevation differs by at most
+// MaxElevationStep. The result is indexed in row-major order.
+func ReachableTiles(tm *TileMap, start Point) []bool {
+	return reachableWithin(tm, start, nil)
+}
+
+// reachableWithin is ReachableTiles limited to tiles inside bounds when
+// bounds is non-nil.
+func reachableWithin(tm *TileMap, start Point, bounds *Rect) []bool {
+	reached := make([]bool, tm.Width*tm.Height)
+	floor, ok := tm.Layers["floor"]
+	if !ok {
+		return reached
+	}
+	elevation := tm.Layers["elevation"]
+	collision := tm.Layers["collision"]
+
+	walkable := func(x, y int) bool {
+		if bounds != nil && (x < bounds.X || x >= bounds.X+bounds.Width || y < bounds.Y || y >= bounds.Y+bounds.Height) {
+			return false
+		}
+		if GetTile(floor.Data, x, y, tm.Width, tm.Height) != uint32(TileFloor) {
+			return false
+		}
+		return collision == nil ||
+			GetTile(collision.Data, x, y, tm.Width, tm.Height) != uint32(CollisionSolid)
+	}
+
+	if !walkable(start.X, start.Y) {
+		return reached
+	}
+
+	reached[start.Y*tm.Width+start.X] = true
+	queue := []Point{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		level := ElevationAt(elevation, p.X, p.Y, tm.Width, tm.Height)
+
+		for _, n := range []Point{{p.X - 1, p.Y}, {p.X + 1, p.Y}, {p.X, p.Y - 1}, {p.X, p.Y + 1}} {
+			if !walkable(n.X, n.Y) || reached[n.Y*tm.Width+n.X] {
+				continue
+			}
+			if abs(ElevationAt(elevation, n.X, n.Y, tm.Width, tm.Height)-level) > MaxElevationStep {
+				continue
+			}
+			reached[n.Y*tm.Width+n.X] = true
+			queue = append(queue, n)
+		}
+	}
+
+	return reached
+}
+
+// PatrolRoute returns a looping patrol around a room's edge: the four corners
+// of bounds in clockwise order starting at the top-left, keeping only the
+// corners reachable from the first walkable one without leaving the room.
+// Corners cut off by a cliff or pit are dropped, so patrols never cross
+// impassable elevation.
+// Returns nil when fewer than two waypoints remain.
+func PatrolRoute(tm *TileMap, bounds Rect) []Point {
+	corners := []Point{
+		{X: bounds.X, Y: bounds.Y},
+		{X: bounds.X + bounds.Width - 1, Y: bounds.Y},
+		{X: bounds.X + bounds.Width - 1, Y: bounds.Y + bounds.Height - 1},
+		{X: bounds.X, Y: bounds.Y + bounds.Height - 1},
+	}
+
+	var reached []bool
+	route := []Point{}
+	for _, c := range corners {
+		if c.X < 0 || c.X >= tm.Width || c.Y < 0 || c.Y >= tm.Height {
+			continue
+		}
+		idx := c.Y*tm.Width + c.X
+		if reached == nil {
+			r := reachableWithin(tm, c, &bounds)
+			if !r[idx] {
+				continue
+			}
+			reached = r
+		}
+		if reached[idx] {
+			route = append(route, c)
+		}
+	}
+
+	if len(route) < 2 {
+		return nil
+	}
+	return route
+}
@@ -0,0 +1,157 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// TestRoomElevation tests elevation resolution from tags and theme rules.
+func TestRoomElevation(t *testing.T) {
+	rules := []themes.ElevationRule{
+		{Tag: "feature", Value: "altar", Level: 2},
+		{Tag: "flooded", Level: -1},
+		{Archetype: "Treasure", Tag: "vault", Level: -2},
+		{Archetype: "Boss", Level: 3},
+	}
+
+	tests := []struct {
+		name      string
+		archetype string
+		tags      map[string]string
+		want      int
+	}{
+		{"no tags", "", nil, 0},
+		{"raised tag", "", map[string]string{"elevation": "raised"}, 1},
+		{"sunken tag", "", map[string]string{"elevation": "sunken"}, -1},
+		{"numeric tag", "", map[string]string{"elevation": "-3"}, -3},
+		{"numeric tag clamped high", "", map[string]string{"elevation": "4000000000"}, themes.MaxElevationLevel},
+		{"numeric tag clamped low", "", map[string]string{"elevation": "-99"}, -themes.MaxElevationLevel},
+		{"explicit tag wins", "Boss", map[string]string{"elevation": "raised", "feature": "altar"}, 1},
+		{"rule with value", "", map[string]string{"feature": "altar"}, 2},
+		{"rule value mismatch", "", map[string]string{"feature": "well"}, 0},
+		{"rule any value", "", map[string]string{"flooded": "yes"}, -1},
+		{"archetype rule", "Boss", nil, 3},
+		{"archetype ignores case", "boss", nil, 3},
+		{"archetype and tag", "Treasure", map[string]string{"vault": "gold"}, -2},
+		{"archetype without tag", "Treasure", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoomElevation(tt.archetype, tt.tags, rules); got != tt.want {
+				t.Errorf("RoomElevation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReachableTilesElevation tests that steep elevation blocks movement.
+func TestReachableTilesElevation(t *testing.T) {
+	tm := NewTileMap(5, 1, 16, 16)
+	floor := AddLayer(tm, "floor", "tilelayer")
+	elevation := AddLayer(tm, "elevation", "tilelayer")
+
+	levels := []int{0, 1, 2, 0, 0} // 2 -> 0 is a cliff
+	for x, level := range levels {
+		floor.Data[x] = uint32(TileFloor)
+		elevation.Data[x] = uint32(ElevationOffset + level)
+	}
+
+	reached := ReachableTiles(tm, Point{X: 0, Y: 0})
+	want := []bool{true, true, true, false, false}
+	for x := range want {
+		if reached[x] != want[x] {
+			t.Errorf("reached[%d] = %v, want %v", x, reached[x], want[x])
+		}
+	}
+
+	for _, p := range PatrolRoute(tm, Rect{X: 0, Y: 0, Width: 5, Height: 1}) {
+		if p.X > 2 {
+			t.Errorf("PatrolRoute() crossed a cliff to %+v", p)
+		}
+	}
+}
+
+// TestBuildElevationLayerRamp tests that a crypt boss dais is a cliff on
+// every side but one, where a ramp leads up, and that a dais split by a
+// corridor drops to one step.
+func TestBuildElevationLayerRamp(t *testing.T) {
+	rooms := map[string]*graph.Room{
+		"boss": {ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeL, Tags: map[string]string{"biome": "crypt"}},
+	}
+	g := NewGraphAdapter(rooms, map[string]*graph.Connector{})
+	layout := &Layout{
+		Poses:         map[string]Pose{"boss": {X: 10, Y: 20}},
+		CorridorPaths: map[string]Path{},
+		Bounds:        Rect{Width: 20, Height: 40},
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	elevation := tm.Layers["elevation"]
+	b := RoomBounds(SizeL, layout.Poses["boss"])
+	midY := b.Y + b.Height/2
+	ramp := Point{X: b.X + 1, Y: midY}
+	if got := ElevationAt(elevation, ramp.X, ramp.Y, tm.Width, tm.Height); got != 1 {
+		t.Errorf("ramp level = %d, want 1", got)
+	}
+	if got := ElevationAt(elevation, b.X+b.Width/2, midY, tm.Width, tm.Height); got != 2 {
+		t.Errorf("dais level = %d, want 2", got)
+	}
+
+	// The ramp leads from the room's edge onto the whole dais...
+	edge := Point{X: b.X + b.Width - 1, Y: midY}
+	dais := func(reached []bool) (n int) {
+		for y := b.Y + 1; y < b.Y+b.Height-1; y++ {
+			for x := b.X + 1; x < b.X+b.Width-1; x++ {
+				if reached[y*tm.Width+x] {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if got, want := dais(ReachableTiles(tm, edge)), (b.Width-2)*(b.Height-2); got != want {
+		t.Errorf("reached %d dais tiles, want %d", got, want)
+	}
+
+	// ...and is the only way up: without it the cliffs cut the dais off.
+	tm.Layers["floor"].Data[ramp.Y*tm.Width+ramp.X] = uint32(TileEmpty)
+	if got := dais(ReachableTiles(tm, edge)); got != 0 {
+		t.Errorf("reached %d dais tiles without the ramp, want 0", got)
+	}
+
+	// A corridor across the room leaves no ramp that reaches both halves
+	rooms["north"] = &graph.Room{ID: "north", Size: graph.SizeS}
+	rooms["south"] = &graph.Room{ID: "south", Size: graph.SizeS}
+	g = NewGraphAdapter(rooms, map[string]*graph.Connector{
+		"cut": {ID: "cut", From: "north", To: "south", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1.0},
+	})
+	layout.Poses["north"] = Pose{X: 10, Y: 3}
+	layout.Poses["south"] = Pose{X: 10, Y: 36}
+	layout.CorridorPaths["cut"] = Path{Points: []Point{{X: 10, Y: 3}, {X: 10, Y: 36}}}
+	tm, err = NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	raised := 0
+	for y := b.Y + 1; y < b.Y+b.Height-1; y++ {
+		for x := b.X + 1; x < b.X+b.Width-1; x++ {
+			level := ElevationAt(tm.Layers["elevation"], x, y, tm.Width, tm.Height)
+			if level > 1 {
+				t.Fatalf("split dais tile (%d,%d) level = %d, want at most 1", x, y, level)
+			}
+			if level == 1 {
+				raised++
+			}
+		}
+	}
+	if raised == 0 {
+		t.Error("split dais was not raised one step")
+	}
+}
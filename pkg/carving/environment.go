@@ -0,0 +1,213 @@
+package carving
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// TerrainSlow marks tiles of the "terrain" layer that slow movement.
+const TerrainSlow uint32 = 1
+
+// roomEnvironment holds a room's environmental effects, parsed from the tags
+// written during synthesis.
+type roomEnvironment struct {
+	budget   float64 // Difficulty carried by the environment
+	hazard   float64 // Share of the interior covered in hazards
+	darkness float64 // Light level removed (0.0-1.0)
+	slow     float64 // Share of the interior covered in slow terrain
+}
+
+// environmentOf parses a room's environment tags. ok is false for rooms
+// without an "environment" tag.
+func environmentOf(room Room) (env roomEnvironment, ok bool) {
+	tags := room.GetTags()
+	raw, ok := tags["environment"]
+	if !ok {
+		return roomEnvironment{}, false
+	}
+	budget, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return roomEnvironment{}, false
+	}
+	share := func(key string) float64 {
+		raw, ok := tags[key]
+		if !ok {
+			return 0
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	return roomEnvironment{
+		budget:   budget,
+		hazard:   share("hazard_coverage"),
+		darkness: share("darkness"),
+		slow:     share("slow_terrain"),
+	}, true
+}
+
+// environmentRooms returns the IDs of laid-out rooms with environment tags in
+// sorted order.
+func environmentRooms(g Graph, layout *Layout) []string {
+	ids := []string{}
+	for id := range layout.Poses {
+		if room := g.GetRoom(id); room != nil {
+			if _, ok := environmentOf(room); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// paintEnvironment calls visit for every interior floor tile of a room that
+// carries an effect, with hazard true for hazard tiles and false for slow
+// terrain. The room's outer ring of tiles stays clear so doorways are never
+// blocked. Ordered dithering spreads each effect evenly over the interior,
+// hazards taking the lowest thresholds and slow terrain the next ones.
+func paintEnvironment(tm *TileMap, room Room, pose Pose, env roomEnvironment, visit func(x, y int, hazard bool)) {
+	floor := tm.Layers["floor"].Data
+	b := RoomBounds(room.GetSize(), pose)
+	for y := b.Y + 1; y < b.Y+b.Height-1; y++ {
+		for x := b.X + 1; x < b.X+b.Width-1; x++ {
+			if GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) {
+				continue
+			}
+			switch t := ditherThreshold(x, y); {
+			case t < env.hazard:
+				visit(x, y, true)
+			case t < env.hazard+env.slow:
+				visit(x, y, false)
+			}
+		}
+	}
+}
+
+// BuildTerrainLayer derives a "terrain" tile layer marking the slow terrain of
+// rooms with an environment budget with TerrainSlow. Slow terrain stays
+// passable.
+func BuildTerrainLayer(tm *TileMap, g Graph, layout *Layout) *Layer {
+	data := make([]uint32, tm.Width*tm.Height)
+	for _, id := range environmentRooms(g, layout) {
+		room := g.GetRoom(id)
+		env, _ := environmentOf(room)
+		paintEnvironment(tm, room, layout.Poses[id], env, func(x, y int, hazard bool) {
+			if !hazard {
+				data[y*tm.Width+x] = TerrainSlow
+			}
+		})
+	}
+
+	return &Layer{
+		ID:      len(tm.Layers),
+		Name:    "terrain",
+		Type:    "tilelayer",
+		Visible: false,
+		Opacity: 1.0,
+		Data:    data,
+	}
+}
+
+// buildHazards records the hazard tiles of rooms with an environment budget
+// in a "hazards" object layer. Each object covers a horizontal run of hazard
+// tiles in one room. Rooms are processed in ID order for determinism.
+func (c *DefaultCarver) buildHazards(g Graph, layout *Layout, tm *TileMap) *Layer {
+	layer := &Layer{
+		ID:      len(tm.Layers),
+		Name:    "hazards",
+		Type:    "objectgroup",
+		Visible: true,
+		Opacity: 1.0,
+		Objects: []Object{},
+	}
+
+	objID := 1
+	for _, id := range environmentRooms(g, layout) {
+		room := g.GetRoom(id)
+		env, _ := environmentOf(room)
+
+		// Collect hazard tiles row by row, then merge adjacent tiles into runs
+		rows := make(map[int][]int)
+		var ys []int
+		paintEnvironment(tm, room, layout.Poses[id], env, func(x, y int, hazard bool) {
+			if !hazard {
+				return
+			}
+			if len(rows[y]) == 0 {
+				ys = append(ys, y)
+			}
+			rows[y] = append(rows[y], x)
+		})
+
+		for _, y := range ys {
+			xs := rows[y]
+			for start := 0; start < len(xs); {
+				end := start + 1
+				for end < len(xs) && xs[end] == xs[end-1]+1 {
+					end++
+				}
+				layer.Objects = append(layer.Objects, Object{
+					ID:      objID,
+					Name:    fmt.Sprintf("hazard_%s_%d", id, objID),
+					Type:    "hazard",
+					X:       float64(xs[start] * c.tileWidth),
+					Y:       float64(y * c.tileHeight),
+					Width:   float64((end - start) * c.tileWidth),
+					Height:  float64(c.tileHeight),
+					Visible: true,
+					Properties: map[string]interface{}{
+						"room_id": id,
+					},
+				})
+				objID++
+				start = end
+			}
+		}
+	}
+
+	return layer
+}
+
+// buildEnvironment records each room's environment budget in an
+// "environment" object layer: one "environment" object per room, covering
+// the room, with the budget and the hazard coverage, darkness and slow
+// terrain it was spent on.
+func (c *DefaultCarver) buildEnvironment(g Graph, layout *Layout, tm *TileMap) *Layer {
+	layer := &Layer{
+		ID:      len(tm.Layers),
+		Name:    "environment",
+		Type:    "objectgroup",
+		Visible: true,
+		Opacity: 1.0,
+		Objects: []Object{},
+	}
+
+	for i, id := range environmentRooms(g, layout) {
+		room := g.GetRoom(id)
+		env, _ := environmentOf(room)
+		b := RoomBounds(room.GetSize(), layout.Poses[id])
+		layer.Objects = append(layer.Objects, Object{
+			ID:      i + 1,
+			Name:    fmt.Sprintf("environment_%s", id),
+			Type:    "environment",
+			X:       float64(b.X * c.tileWidth),
+			Y:       float64(b.Y * c.tileHeight),
+			Width:   float64(b.Width * c.tileWidth),
+			Height:  float64(b.Height * c.tileHeight),
+			Visible: true,
+			Properties: map[string]interface{}{
+				"room_id":         id,
+				"budget":          env.budget,
+				"hazard_coverage": env.hazard,
+				"darkness":        env.darkness,
+				"slow_terrain":    env.slow,
+			},
+		})
+	}
+
+	return layer
+}
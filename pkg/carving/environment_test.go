@@ -0,0 +1,91 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestEnvironmentLayers verifies a room's environment budget is painted as
+// hazard and slow terrain tiles inside the room, marked in the collision
+// layer, and recorded with its darkness in the environment layer.
+func TestEnvironmentLayers(t *testing.T) {
+	rooms := map[string]*graph.Room{
+		"lair": {ID: "lair", Size: graph.SizeL, Tags: map[string]string{
+			"environment":     "0.400",
+			"hazard_coverage": "0.250",
+			"darkness":        "0.200",
+			"slow_terrain":    "0.125",
+		}},
+	}
+	g := NewGraphAdapter(rooms, map[string]*graph.Connector{})
+
+	layout := &Layout{
+		Poses:         map[string]Pose{"lair": {X: 15, Y: 15}},
+		CorridorPaths: map[string]Path{},
+		Bounds:        Rect{Width: 30, Height: 30},
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+
+	for _, name := range []string{"hazards", "terrain", "environment"} {
+		if _, ok := tm.Layers[name]; !ok {
+			t.Fatalf("Carve() did not create %s layer", name)
+		}
+	}
+
+	// The 10x10 room spans (10,10)-(19,19); effects stay in its 8x8 interior
+	inside := func(x, y int) bool { return x >= 11 && x <= 18 && y >= 11 && y <= 18 }
+
+	hazards := make(map[Point]bool)
+	for _, obj := range tm.Layers["hazards"].Objects {
+		if obj.Type != "hazard" || obj.Properties["room_id"] != "lair" {
+			t.Errorf("unexpected hazard object %+v", obj)
+		}
+		y := int(obj.Y) / 16
+		for x := int(obj.X) / 16; x < int(obj.X+obj.Width)/16; x++ {
+			hazards[Point{X: x, Y: y}] = true
+		}
+	}
+	if len(hazards) != 16 {
+		t.Errorf("hazard tiles = %d, want 16 (25%% of 64)", len(hazards))
+	}
+
+	collision := tm.Layers["collision"].Data
+	for p := range hazards {
+		if !inside(p.X, p.Y) {
+			t.Errorf("hazard tile %v outside room interior", p)
+		}
+		if CollisionType(GetTile(collision, p.X, p.Y, tm.Width, tm.Height)) != CollisionHazard {
+			t.Errorf("hazard tile %v not marked in collision layer", p)
+		}
+	}
+
+	slow := 0
+	terrain := tm.Layers["terrain"].Data
+	for i, v := range terrain {
+		if v != TerrainSlow {
+			continue
+		}
+		p := Point{X: i % tm.Width, Y: i / tm.Width}
+		if !inside(p.X, p.Y) || hazards[p] {
+			t.Errorf("slow tile %v outside interior or on a hazard", p)
+		}
+		slow++
+	}
+	if slow != 8 {
+		t.Errorf("slow tiles = %d, want 8 (12.5%% of 64)", slow)
+	}
+
+	env := tm.Layers["environment"].Objects
+	if len(env) != 1 {
+		t.Fatalf("environment objects = %d, want 1", len(env))
+	}
+	if env[0].Properties["darkness"] != 0.2 || env[0].Properties["budget"] != 0.4 {
+		t.Errorf("environment properties = %v", env[0].Properties)
+	}
+}
@@ -0,0 +1,553 @@
+package carving
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// junctionSpacing is the least Chebyshev distance between the centers of two
+// junctions, leaving at least one corridor tile between their walls.
+const junctionSpacing = 6
+
+// junctionClearance is the least number of tiles between the floor of a
+// junction and the floor of a room.
+const junctionClearance = 3
+
+// Junction is a small room MergeCorridors puts where three or more corridor
+// branches meet.
+type Junction struct {
+	Room      string   // Room ID of the junction
+	X, Y      int      // Center tile, the junction's pose
+	Corridors []string // IDs of the corridors cut at the junction, sorted
+}
+
+// CorridorPiece is the stretch of a corridor between two rooms or junctions
+// along it.
+type CorridorPiece struct {
+	ID       string // Connector ID; a corridor's first piece keeps its ID
+	Corridor string // ID of the corridor it was cut from
+	From, To string // Room IDs at its ends, in the corridor's direction
+}
+
+// JunctionPlan lists the junctions MergeCorridors added to a layout and the
+// corridor pieces replacing the corridors they cut. The graph must gain the
+// same rooms and connectors before the layout is carved.
+type JunctionPlan struct {
+	Junctions []Junction
+	Pieces    []CorridorPiece // In corridor ID order, each From to To
+}
+
+// sharedRun is an inner stretch of a corridor moved onto another corridor,
+// with the tiles where it turns off it again.
+type sharedRun struct {
+	corridor string
+	ends     [2]Point
+}
+
+// junctionSite is a junction being planned.
+type junctionSite struct {
+	center Point
+	floor  Rect
+	cuts   map[string][2]int // Corridor ID → indices of the tiles where it crosses the walls
+}
+
+// MergeCorridors merges corridors running side by side into shared passages
+// and puts a junction room wherever three or more corridor branches meet,
+// cutting the corridors through it into pieces that end at its walls. The
+// junction poses and the pieces' paths and door anchors are written into the
+// layout; the returned plan lists the rooms and connectors the graph must
+// gain to match. Run it after AnchorDoors.
+//
+// Algorithm:
+//  1. Take the anchored door and corridor connectors without gates whose
+//     paths run along the grid and that do not lead to an Exit room. Gated,
+//     hidden, one-way and other corridors are left alone, and no junction
+//     touches them
+//  2. Visit those corridors in ID order and move each inner stretch running
+//     one or two tiles beside another corridor onto it, unless that brings
+//     it against a room or a corridor left alone
+//  3. Put a 3x3 junction on each tile shared by two or more corridors with
+//     corridors leaving it on three or more sides, top to bottom then left
+//     to right, if it keeps clear of rooms, of the corridors left alone and
+//     of earlier junctions, and every corridor crossing its walls runs
+//     straight through its floor
+//  4. Undo the moves of step 2 whose shared passage does not end in a
+//     junction at both ends, and repeat step 3 until no such move is left
+//  5. Cut each corridor at its junctions, dropping the pieces that repeat a
+//     piece of an earlier corridor between the same two door anchors
+func MergeCorridors(g Graph, layout *Layout) *JunctionPlan {
+	plan := &JunctionPlan{}
+	if g == nil || layout == nil {
+		return plan
+	}
+
+	// 1. Corridors to merge
+	connIDs := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	var corridors []string
+	blocked := make(map[Point]bool)
+	for _, id := range connIDs {
+		if mergeable(g, layout, id) {
+			corridors = append(corridors, id)
+			continue
+		}
+		for _, p := range RouteTiles(layout.CorridorPaths[id]) {
+			blocked[p] = true
+		}
+	}
+	if len(corridors) < 2 {
+		return plan
+	}
+
+	rooms := make([]Rect, 0, len(layout.Poses))
+	for id, pose := range layout.Poses {
+		if room := g.GetRoom(id); room != nil {
+			rooms = append(rooms, RoomBounds(room.GetSize(), pose))
+		}
+	}
+	clearOf := func(p Point) bool {
+		if blocked[p] {
+			return false
+		}
+		for _, r := range rooms {
+			if contains(grow(r, 1), p) {
+				return false
+			}
+		}
+		return true
+	}
+	site := func(p Point) (Rect, bool) {
+		floor := RoomBounds(SizeXS, Pose{X: p.X, Y: p.Y})
+		walls := grow(floor, 1)
+		if walls.X < 0 || walls.Y < 0 ||
+			walls.X+walls.Width > layout.Bounds.Width || walls.Y+walls.Height > layout.Bounds.Height {
+			return Rect{}, false
+		}
+		for y := walls.Y; y < walls.Y+walls.Height; y++ {
+			for x := walls.X; x < walls.X+walls.Width; x++ {
+				if blocked[Point{X: x, Y: y}] {
+					return Rect{}, false
+				}
+			}
+		}
+		for _, r := range rooms {
+			if overlaps(grow(floor, junctionClearance), r) {
+				return Rect{}, false
+			}
+		}
+		return floor, true
+	}
+
+	// 2. Shared passages
+	original := make(map[string]Path)
+	var runs []sharedRun
+	for _, id := range corridors {
+		path := layout.CorridorPaths[id]
+		points := append([]Point(nil), path.Points...)
+		var moves []sharedRun
+		for k := 1; k+2 < len(points); k++ {
+			for _, other := range corridors {
+				if other == id {
+					continue
+				}
+				segment, shared, ok := besideSegment(points[k], points[k+1], layout.CorridorPaths[other])
+				if !ok {
+					continue
+				}
+				moved := append([]Point(nil), points...)
+				moved[k], moved[k+1] = segment[0], segment[1]
+				_, okStart := site(shared[0])
+				_, okEnd := site(shared[1])
+				if !okStart || !okEnd || !newTilesClear(path, Path{Points: moved}, clearOf) {
+					continue
+				}
+				points = moved
+				moves = append(moves, sharedRun{corridor: id, ends: shared})
+				break
+			}
+		}
+		if len(moves) > 0 {
+			original[id] = path
+			layout.CorridorPaths[id] = SimplifyPath(Path{Points: dropRepeats(points)})
+			runs = append(runs, moves...)
+		}
+	}
+
+	// 3-4. Junctions, undoing the moves they do not join up
+	var sites []junctionSite
+	tiles := make(map[string][]Point, len(corridors))
+	for {
+		for _, id := range corridors {
+			tiles[id] = RouteTiles(layout.CorridorPaths[id])
+		}
+		sites = findJunctions(corridors, tiles, site)
+
+		undone := false
+		for _, run := range runs {
+			if _, moved := original[run.corridor]; !moved {
+				continue
+			}
+			if !inJunction(run.ends[0], sites) || !inJunction(run.ends[1], sites) {
+				layout.CorridorPaths[run.corridor] = original[run.corridor]
+				delete(original, run.corridor)
+				undone = true
+			}
+		}
+		if !undone {
+			break
+		}
+	}
+	if len(sites) == 0 {
+		return plan
+	}
+
+	// 5. Corridor pieces
+	n := 0
+	for i := range sites {
+		var id string
+		for {
+			n++
+			id = "junction_" + strconv.Itoa(n)
+			if _, taken := layout.Poses[id]; !taken && g.GetRoom(id) == nil {
+				break
+			}
+		}
+		corridorIDs := make([]string, 0, len(sites[i].cuts))
+		for connID := range sites[i].cuts {
+			corridorIDs = append(corridorIDs, connID)
+		}
+		sort.Strings(corridorIDs)
+		plan.Junctions = append(plan.Junctions, Junction{Room: id, X: sites[i].center.X, Y: sites[i].center.Y, Corridors: corridorIDs})
+		layout.Poses[id] = Pose{X: sites[i].center.X, Y: sites[i].center.Y}
+	}
+
+	type pieceEnds struct{ a, b DoorAnchor }
+	seen := make(map[pieceEnds]bool)
+	for _, id := range corridors {
+		type cut struct {
+			site       int
+			first, end int
+		}
+		var cuts []cut
+		for i, s := range sites {
+			if c, ok := s.cuts[id]; ok {
+				cuts = append(cuts, cut{site: i, first: c[0], end: c[1]})
+			}
+		}
+		if len(cuts) == 0 {
+			continue
+		}
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i].first < cuts[j].first })
+
+		conn := g.GetConnector(id)
+		t := tiles[id]
+		doors := layout.Doors[id]
+		from, fromDoor, start := conn.GetFrom(), doors[0], 0
+		addPiece := func(pieceID, to string, toDoor DoorAnchor, end int) {
+			ends := pieceEnds{fromDoor, toDoor}
+			if anchorLess(toDoor, fromDoor) {
+				ends = pieceEnds{toDoor, fromDoor}
+			}
+			if seen[ends] {
+				return
+			}
+			seen[ends] = true
+			plan.Pieces = append(plan.Pieces, CorridorPiece{ID: pieceID, Corridor: id, From: from, To: to})
+			layout.CorridorPaths[pieceID] = SimplifyPath(Path{Points: append([]Point(nil), t[start:end+1]...)})
+			layout.Doors[pieceID] = []DoorAnchor{fromDoor, toDoor}
+		}
+		pieceID := id
+		for _, c := range cuts {
+			junction := plan.Junctions[c.site].Room
+			floor := sites[c.site].floor
+			addPiece(pieceID, junction, wallDoor(junction, floor, t[c.first]), c.first)
+			from, fromDoor, start = junction, wallDoor(junction, floor, t[c.end]), c.end
+			pieceID = id + "_" + junction
+		}
+		addPiece(pieceID, conn.GetTo(), doors[1], len(t)-1)
+	}
+
+	return plan
+}
+
+// mergeable reports whether MergeCorridors may merge and cut a corridor: an
+// ungated door or corridor connector, anchored by AnchorDoors, whose path
+// runs along the grid. The corridor to an Exit room is never merged, as a
+// junction on it would join the Exit to rooms other than the Boss.
+func mergeable(g Graph, layout *Layout, id string) bool {
+	conn := g.GetConnector(id)
+	if conn == nil || conn.GetGate() != nil || len(layout.Doors[id]) != 2 {
+		return false
+	}
+	for _, end := range []string{conn.GetFrom(), conn.GetTo()} {
+		if room := g.GetRoom(end); room != nil && room.GetArchetype() == graph.ArchetypeExit.String() {
+			return false
+		}
+	}
+	if t := conn.GetType(); t != TypeDoor && t != TypeCorridor {
+		return false
+	}
+	points := layout.CorridorPaths[id].Points
+	if len(points) < 2 {
+		return false
+	}
+	for i := 0; i+1 < len(points); i++ {
+		if points[i].X != points[i+1].X && points[i].Y != points[i+1].Y {
+			return false
+		}
+	}
+	return true
+}
+
+// besideSegment finds a segment of other running one or two tiles beside
+// the segment from a to b, alongside it for at least junctionSpacing tiles.
+// It returns the segment from a to b moved onto it, and the ends of the
+// stretch the two then share.
+func besideSegment(a, b Point, other Path) (moved, shared [2]Point, ok bool) {
+	if a == b {
+		return moved, shared, false
+	}
+	horizontal := a.Y == b.Y
+	along := func(p Point) int {
+		if horizontal {
+			return p.X
+		}
+		return p.Y
+	}
+	across := func(p Point) int {
+		if horizontal {
+			return p.Y
+		}
+		return p.X
+	}
+	at := func(pos, line int) Point {
+		if horizontal {
+			return Point{X: pos, Y: line}
+		}
+		return Point{X: line, Y: pos}
+	}
+
+	for i := 0; i+1 < len(other.Points); i++ {
+		c, d := other.Points[i], other.Points[i+1]
+		if c == d || across(c) != across(d) {
+			continue
+		}
+		if gap := abs(across(c) - across(a)); gap < 1 || gap > 2 {
+			continue
+		}
+		lo := max(min(along(a), along(b)), min(along(c), along(d)))
+		hi := min(max(along(a), along(b)), max(along(c), along(d)))
+		if hi-lo < junctionSpacing {
+			continue
+		}
+		line := across(c)
+		moved = [2]Point{at(along(a), line), at(along(b), line)}
+		shared = [2]Point{at(lo, line), at(hi, line)}
+		return moved, shared, true
+	}
+	return moved, shared, false
+}
+
+// newTilesClear reports whether clear holds for every tile of moved that
+// before does not cover.
+func newTilesClear(before, moved Path, clear func(Point) bool) bool {
+	old := make(map[Point]bool)
+	for _, p := range RouteTiles(before) {
+		old[p] = true
+	}
+	for _, p := range RouteTiles(moved) {
+		if !old[p] && !clear(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// findJunctions returns the junctions for the corridors' current tiles, as
+// in step 3 of MergeCorridors.
+func findJunctions(corridors []string, tiles map[string][]Point, site func(Point) (Rect, bool)) []junctionSite {
+	cover := make(map[Point]int)
+	for _, id := range corridors {
+		t := tiles[id]
+		counted := make(map[Point]bool, len(t))
+		for _, p := range t[1 : len(t)-1] {
+			if !counted[p] {
+				counted[p] = true
+				cover[p]++
+			}
+		}
+	}
+
+	var candidates []Point
+	for p, n := range cover {
+		if n < 2 {
+			continue
+		}
+		branches := 0
+		for _, d := range [4]Point{{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}} {
+			if cover[Point{X: p.X + d.X, Y: p.Y + d.Y}] > 0 {
+				branches++
+			}
+		}
+		if branches >= 3 {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Y != candidates[j].Y {
+			return candidates[i].Y < candidates[j].Y
+		}
+		return candidates[i].X < candidates[j].X
+	})
+
+	var sites []junctionSite
+next:
+	for _, c := range candidates {
+		for _, s := range sites {
+			if max(abs(c.X-s.center.X), abs(c.Y-s.center.Y)) < junctionSpacing {
+				continue next
+			}
+		}
+		floor, ok := site(c)
+		if !ok {
+			continue
+		}
+		walls := grow(floor, 1)
+
+		cuts := make(map[string][2]int)
+		doors := make(map[Side]map[Point]bool)
+		for _, id := range corridors {
+			t := tiles[id]
+			first, last := -1, -1
+			for i, p := range t {
+				if contains(walls, p) {
+					if first < 0 {
+						first = i
+					}
+					last = i
+				}
+			}
+			if first < 0 {
+				continue
+			}
+			// One run through the floor, crossing the walls where it
+			// enters and leaves
+			if first == 0 || last == len(t)-1 || last-first < 2 {
+				continue next
+			}
+			for i := first; i <= last; i++ {
+				if !contains(walls, t[i]) || contains(floor, t[i]) == (i == first || i == last) {
+					continue next
+				}
+			}
+			cuts[id] = [2]int{first, last}
+			for _, i := range [2]int{first, last} {
+				side := wallDoor("", floor, t[i]).Side
+				if doors[side] == nil {
+					doors[side] = make(map[Point]bool)
+				}
+				doors[side][t[i]] = true
+			}
+		}
+		if len(cuts) < 2 {
+			continue
+		}
+		for _, anchors := range doors {
+			if len(anchors) > MaxDoorsPerWall {
+				continue next
+			}
+		}
+		sites = append(sites, junctionSite{center: c, floor: floor, cuts: cuts})
+	}
+	return sites
+}
+
+// inJunction reports whether p lies on the floor of one of the junctions.
+func inJunction(p Point, sites []junctionSite) bool {
+	for _, s := range sites {
+		if contains(s.floor, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// wallDoor returns the door anchor of a wall tile p of a room with the
+// given floor.
+func wallDoor(room string, floor Rect, p Point) DoorAnchor {
+	anchor := DoorAnchor{Room: room, X: p.X, Y: p.Y}
+	switch {
+	case p.Y < floor.Y:
+		anchor.Side = SideNorth
+	case p.Y >= floor.Y+floor.Height:
+		anchor.Side = SideSouth
+	case p.X < floor.X:
+		anchor.Side = SideWest
+	default:
+		anchor.Side = SideEast
+	}
+	return anchor
+}
+
+// anchorLess orders door anchors by room, then tile.
+func anchorLess(a, b DoorAnchor) bool {
+	if a.Room != b.Room {
+		return a.Room < b.Room
+	}
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
+
+// RouteTiles returns the tiles along a corridor path in path order, without
+// repeating the tiles where its segments meet.
+func RouteTiles(path Path) []Point {
+	tiles := make([]Point, 0, PathLength(path)+1)
+	visit := func(x, y int) {
+		p := Point{X: x, Y: y}
+		if n := len(tiles); n == 0 || tiles[n-1] != p {
+			tiles = append(tiles, p)
+		}
+	}
+	if len(path.Points) == 1 {
+		visit(path.Points[0].X, path.Points[0].Y)
+	}
+	for i := 0; i+1 < len(path.Points); i++ {
+		p1, p2 := path.Points[i], path.Points[i+1]
+		walkLine(p1.X, p1.Y, p2.X, p2.Y, visit)
+	}
+	return tiles
+}
+
+// dropRepeats removes points equal to the point before them.
+func dropRepeats(points []Point) []Point {
+	out := points[:0]
+	for _, p := range points {
+		if n := len(out); n == 0 || out[n-1] != p {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// grow returns r extended by n tiles on every side.
+func grow(r Rect, n int) Rect {
+	return Rect{X: r.X - n, Y: r.Y - n, Width: r.Width + 2*n, Height: r.Height + 2*n}
+}
+
+// contains reports whether p lies in r.
+func contains(r Rect, p Point) bool {
+	return p.X >= r.X && p.X < r.X+r.Width && p.Y >= r.Y && p.Y < r.Y+r.Height
+}
+
+// overlaps reports whether a and b share a tile.
+func overlaps(a, b Rect) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width && a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
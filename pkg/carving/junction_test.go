@@ -0,0 +1,192 @@
+package carving
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// junctionGraph returns small rooms at the given centers and the plain
+// corridors joining the given pairs, keyed by the pair's IDs.
+func junctionGraph(centers map[string]Point, pairs ...[2]string) (map[string]*graph.Room, map[string]*graph.Connector, *Layout) {
+	rooms := map[string]*graph.Room{}
+	layout := &Layout{
+		Poses:         map[string]Pose{},
+		CorridorPaths: map[string]Path{},
+		Doors:         map[string][]DoorAnchor{},
+		Bounds:        Rect{Width: 80, Height: 60},
+	}
+	for id, c := range centers {
+		rooms[id] = &graph.Room{ID: id, Size: graph.SizeS}
+		layout.Poses[id] = Pose{X: c.X, Y: c.Y}
+	}
+	connectors := map[string]*graph.Connector{}
+	for _, pair := range pairs {
+		id := pair[0] + pair[1]
+		connectors[id] = &graph.Connector{ID: id, From: pair[0], To: pair[1], Type: graph.TypeCorridor, Bidirectional: true, Cost: 1}
+	}
+	return rooms, connectors, layout
+}
+
+// TestMergeCorridors_Crossing verifies two crossing corridors get a junction
+// where they cross and are each cut in two at its walls.
+func TestMergeCorridors_Crossing(t *testing.T) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 30}, "b": {X: 70, Y: 30},
+		"c": {X: 40, Y: 8}, "d": {X: 40, Y: 52},
+	}, [2]string{"a", "b"}, [2]string{"c", "d"})
+	for id, conn := range connectors {
+		layout.CorridorPaths[id] = Path{Points: []Point{
+			{X: layout.Poses[conn.From].X, Y: layout.Poses[conn.From].Y},
+			{X: layout.Poses[conn.To].X, Y: layout.Poses[conn.To].Y},
+		}}
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	AnchorDoors(g, layout)
+
+	plan := MergeCorridors(g, layout)
+
+	wantJunctions := []Junction{{Room: "junction_1", X: 40, Y: 30, Corridors: []string{"ab", "cd"}}}
+	if !reflect.DeepEqual(plan.Junctions, wantJunctions) {
+		t.Fatalf("Junctions = %+v, want %+v", plan.Junctions, wantJunctions)
+	}
+	wantPieces := []CorridorPiece{
+		{ID: "ab", Corridor: "ab", From: "a", To: "junction_1"},
+		{ID: "ab_junction_1", Corridor: "ab", From: "junction_1", To: "b"},
+		{ID: "cd", Corridor: "cd", From: "c", To: "junction_1"},
+		{ID: "cd_junction_1", Corridor: "cd", From: "junction_1", To: "d"},
+	}
+	if !reflect.DeepEqual(plan.Pieces, wantPieces) {
+		t.Fatalf("Pieces = %+v, want %+v", plan.Pieces, wantPieces)
+	}
+	if pose := layout.Poses["junction_1"]; pose.X != 40 || pose.Y != 30 {
+		t.Errorf("junction pose = %+v, want (40, 30)", pose)
+	}
+
+	// Every piece runs between door anchors on the walls of its rooms
+	rooms["junction_1"] = &graph.Room{ID: "junction_1", Archetype: graph.ArchetypeCorridor, Size: graph.SizeXS}
+	for _, piece := range plan.Pieces {
+		conn := *connectors[piece.Corridor]
+		conn.ID, conn.From, conn.To = piece.ID, piece.From, piece.To
+		connectors[piece.ID] = &conn
+	}
+	for _, piece := range plan.Pieces {
+		doors := layout.Doors[piece.ID]
+		points := layout.CorridorPaths[piece.ID].Points
+		if len(doors) != 2 || doors[0].Room != piece.From || doors[1].Room != piece.To {
+			t.Fatalf("Doors[%s] = %+v, want anchors at %s and %s", piece.ID, doors, piece.From, piece.To)
+		}
+		if points[0] != (Point{X: doors[0].X, Y: doors[0].Y}) || points[len(points)-1] != (Point{X: doors[1].X, Y: doors[1].Y}) {
+			t.Errorf("CorridorPaths[%s] = %v, want it between %+v and %+v", piece.ID, points, doors[0], doors[1])
+		}
+		for _, door := range doors {
+			b := RoomBounds(g.GetRoom(door.Room).GetSize(), layout.Poses[door.Room])
+			if !onWall(b, door) {
+				t.Errorf("Doors[%s] anchor %+v is not on the %s wall of %v", piece.ID, door, door.Side, b)
+			}
+		}
+	}
+
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	if _, areas := floorTiles(tm); areas != 1 {
+		t.Errorf("floor areas = %d, want 1", areas)
+	}
+}
+
+// TestMergeCorridors_Parallel verifies a corridor running a tile beside
+// another is moved onto it, the shared passage gets a junction at either
+// end, and only one corridor keeps the piece between them.
+func TestMergeCorridors_Parallel(t *testing.T) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 30}, "b": {X: 70, Y: 30},
+		"c": {X: 20, Y: 10}, "d": {X: 60, Y: 10},
+	}, [2]string{"a", "b"}, [2]string{"c", "d"})
+	layout.CorridorPaths["ab"] = Path{Points: []Point{{X: 13, Y: 30}, {X: 67, Y: 30}}}
+	layout.Doors["ab"] = []DoorAnchor{{Room: "a", Side: SideEast, X: 13, Y: 30}, {Room: "b", Side: SideWest, X: 67, Y: 30}}
+	layout.CorridorPaths["cd"] = Path{Points: []Point{{X: 20, Y: 13}, {X: 20, Y: 31}, {X: 60, Y: 31}, {X: 60, Y: 13}}}
+	layout.Doors["cd"] = []DoorAnchor{{Room: "c", Side: SideSouth, X: 20, Y: 13}, {Room: "d", Side: SideSouth, X: 60, Y: 13}}
+	g := NewGraphAdapter(rooms, connectors)
+
+	plan := MergeCorridors(g, layout)
+
+	if len(plan.Junctions) != 2 || plan.Junctions[0].X != 20 || plan.Junctions[1].X != 60 ||
+		plan.Junctions[0].Y != 30 || plan.Junctions[1].Y != 30 {
+		t.Fatalf("Junctions = %+v, want junctions at (20, 30) and (60, 30)", plan.Junctions)
+	}
+	var ids []string
+	for _, piece := range plan.Pieces {
+		ids = append(ids, piece.ID)
+	}
+	wantIDs := []string{"ab", "ab_junction_1", "ab_junction_2", "cd", "cd_junction_2"}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("piece IDs = %v, want %v", ids, wantIDs)
+	}
+	if _, ok := layout.CorridorPaths["cd_junction_1"]; ok {
+		t.Error("the shared passage was kept twice")
+	}
+	want := []Point{{X: 22, Y: 30}, {X: 58, Y: 30}}
+	if got := layout.CorridorPaths["ab_junction_1"].Points; !reflect.DeepEqual(got, want) {
+		t.Errorf("shared passage = %v, want %v", got, want)
+	}
+	want = []Point{{X: 20, Y: 13}, {X: 20, Y: 28}}
+	if got := layout.CorridorPaths["cd"].Points; !reflect.DeepEqual(got, want) {
+		t.Errorf("CorridorPaths[cd] = %v, want %v", got, want)
+	}
+}
+
+// TestMergeCorridors_Gated verifies gated corridors are neither merged nor
+// cut.
+func TestMergeCorridors_Gated(t *testing.T) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 30}, "b": {X: 70, Y: 30},
+		"c": {X: 20, Y: 10}, "d": {X: 60, Y: 10},
+	}, [2]string{"a", "b"}, [2]string{"c", "d"})
+	connectors["cd"].Gate = &graph.Gate{Type: "key", Value: "silver"}
+	layout.CorridorPaths["ab"] = Path{Points: []Point{{X: 13, Y: 30}, {X: 67, Y: 30}}}
+	layout.Doors["ab"] = []DoorAnchor{{Room: "a", Side: SideEast, X: 13, Y: 30}, {Room: "b", Side: SideWest, X: 67, Y: 30}}
+	cd := Path{Points: []Point{{X: 20, Y: 13}, {X: 20, Y: 31}, {X: 60, Y: 31}, {X: 60, Y: 13}}}
+	layout.CorridorPaths["cd"] = cd
+	layout.Doors["cd"] = []DoorAnchor{{Room: "c", Side: SideSouth, X: 20, Y: 13}, {Room: "d", Side: SideSouth, X: 60, Y: 13}}
+
+	plan := MergeCorridors(NewGraphAdapter(rooms, connectors), layout)
+
+	if len(plan.Junctions) != 0 || len(plan.Pieces) != 0 {
+		t.Errorf("plan = %+v, want no junctions", plan)
+	}
+	if !reflect.DeepEqual(layout.CorridorPaths["cd"], cd) {
+		t.Errorf("CorridorPaths[cd] = %v, want it unchanged", layout.CorridorPaths["cd"])
+	}
+}
+
+// TestMergeCorridors_Exit verifies the corridor to an Exit room is left
+// alone, so no junction joins the Exit to other rooms.
+func TestMergeCorridors_Exit(t *testing.T) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 30}, "b": {X: 70, Y: 30},
+		"c": {X: 40, Y: 8}, "d": {X: 40, Y: 52},
+	}, [2]string{"a", "b"}, [2]string{"c", "d"})
+	rooms["d"].Archetype = graph.ArchetypeExit
+	for id, conn := range connectors {
+		layout.CorridorPaths[id] = Path{Points: []Point{
+			{X: layout.Poses[conn.From].X, Y: layout.Poses[conn.From].Y},
+			{X: layout.Poses[conn.To].X, Y: layout.Poses[conn.To].Y},
+		}}
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	AnchorDoors(g, layout)
+	cd := layout.CorridorPaths["cd"]
+
+	plan := MergeCorridors(g, layout)
+
+	if len(plan.Junctions) != 0 || len(plan.Pieces) != 0 {
+		t.Errorf("plan = %+v, want no junctions", plan)
+	}
+	if !reflect.DeepEqual(layout.CorridorPaths["cd"], cd) {
+		t.Errorf("CorridorPaths[cd] = %v, want it unchanged", layout.CorridorPaths["cd"])
+	}
+}
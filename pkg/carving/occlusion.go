@@ -0,0 +1,148 @@
+package carving
+
+import "sort"
+
+// MaxOcclusionWall is the thickest wall, in tiles, through which
+// OcclusionGraph considers two rooms neighbours: sound carries through a
+// wall of one or two tiles.
+const MaxOcclusionWall = 2
+
+// RoomOcclusion is how sound and sight pass between two rooms, for audio
+// and visibility systems deciding which neighbours leak sound or show
+// through openings.
+type RoomOcclusion struct {
+	From, To string // Room IDs, From before To
+
+	// WallTiles is the length of wall between the rooms' floors, and
+	// WallThickness its thickness in tiles (0 when they share no wall).
+	WallTiles     int
+	WallThickness int
+
+	// Openings counts the tiles along the wall where floor runs through
+	// it, joining the rooms directly.
+	Openings int
+
+	// VisibleFrom counts the floor tiles of To seen from From's floor,
+	// VisibleTo those of From seen from To's. Sight passes through doors,
+	// openings and corridors up to the sight radius.
+	VisibleFrom int
+	VisibleTo   int
+}
+
+// OcclusionGraph returns the room pairs that share a wall at most
+// MaxOcclusionWall tiles thick, or see into each other within radius tiles,
+// sorted by From, then To. Rooms are given by their floor bounds; sight is
+// computed over the floor layer as in FieldOfView.
+func OcclusionGraph(tm *TileMap, rooms map[string]Rect, radius int) []RoomOcclusion {
+	ids := make([]string, 0, len(rooms))
+	for id := range rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var floor []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+	isFloor := func(x, y int) bool {
+		return GetTile(floor, x, y, tm.Width, tm.Height) == uint32(TileFloor)
+	}
+
+	// seen[id][other] counts the floor tiles of other visible from id
+	seen := make(map[string]map[string]int, len(ids))
+	visible := make([]bool, tm.Width*tm.Height)
+	for _, id := range ids {
+		clear(visible)
+		r := rooms[id]
+		for y := r.Y; y < r.Y+r.Height; y++ {
+			for x := r.X; x < r.X+r.Width; x++ {
+				if isFloor(x, y) {
+					markFieldOfView(tm, Point{X: x, Y: y}, radius, visible)
+				}
+			}
+		}
+		for _, other := range ids {
+			if other == id {
+				continue
+			}
+			if n := countVisibleFloor(rooms[other], visible, isFloor, tm.Width, tm.Height); n > 0 {
+				if seen[id] == nil {
+					seen[id] = make(map[string]int)
+				}
+				seen[id][other] = n
+			}
+		}
+	}
+
+	var graph []RoomOcclusion
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			o := RoomOcclusion{From: a, To: b, VisibleFrom: seen[a][b], VisibleTo: seen[b][a]}
+			o.WallThickness, o.WallTiles, o.Openings = wallBetween(rooms[a], rooms[b], isFloor)
+			if o.WallTiles+o.Openings == 0 {
+				o.WallThickness = 0
+			}
+			if o.WallTiles+o.Openings > 0 || o.VisibleFrom+o.VisibleTo > 0 {
+				graph = append(graph, o)
+			}
+		}
+	}
+	return graph
+}
+
+// countVisibleFloor counts the visible floor tiles within r.
+func countVisibleFloor(r Rect, visible []bool, isFloor func(x, y int) bool, width, height int) int {
+	n := 0
+	for y := max(0, r.Y); y < min(height, r.Y+r.Height); y++ {
+		for x := max(0, r.X); x < min(width, r.X+r.Width); x++ {
+			if visible[y*width+x] && isFloor(x, y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// wallBetween returns the thickness of the gap between a and b when they
+// lie side by side at most MaxOcclusionWall tiles apart, and how many tiles
+// along it are solid wall and how many are floor all the way through. Tiles
+// where a corridor runs along the gap are neither.
+func wallBetween(a, b Rect, isFloor func(x, y int) bool) (thickness, wall, openings int) {
+	x0, x1 := max(a.X, b.X), min(a.X+a.Width, b.X+b.Width)
+	y0, y1 := max(a.Y, b.Y), min(a.Y+a.Height, b.Y+b.Height)
+
+	// The gap runs from (gx, gy) for thickness tiles along (dx, dy), at
+	// every tile of the shared stretch along (sx, sy)
+	var gx, gy, dx, dy, sx, sy, length int
+	switch {
+	case x1 > x0 && b.Y > a.Y+a.Height:
+		thickness, gx, gy, dy, sx, length = b.Y-a.Y-a.Height, x0, a.Y+a.Height, 1, 1, x1-x0
+	case x1 > x0 && a.Y > b.Y+b.Height:
+		thickness, gx, gy, dy, sx, length = a.Y-b.Y-b.Height, x0, b.Y+b.Height, 1, 1, x1-x0
+	case y1 > y0 && b.X > a.X+a.Width:
+		thickness, gx, gy, dx, sy, length = b.X-a.X-a.Width, a.X+a.Width, y0, 1, 1, y1-y0
+	case y1 > y0 && a.X > b.X+b.Width:
+		thickness, gx, gy, dx, sy, length = a.X-b.X-b.Width, b.X+b.Width, y0, 1, 1, y1-y0
+	default:
+		return 0, 0, 0
+	}
+	if thickness > MaxOcclusionWall {
+		return 0, 0, 0
+	}
+
+	for i := 0; i < length; i++ {
+		floors := 0
+		for j := 0; j < thickness; j++ {
+			if isFloor(gx+i*sx+j*dx, gy+i*sy+j*dy) {
+				floors++
+			}
+		}
+		switch floors {
+		case 0:
+			wall++
+		case thickness:
+			openings++
+		}
+	}
+	return thickness, wall, openings
+}
@@ -0,0 +1,56 @@
+package carving
+
+import "testing"
+
+// TestOcclusionGraph verifies shared walls are measured with their
+// openings, thick walls block sight, and sight carries down a corridor
+// within the radius.
+func TestOcclusionGraph(t *testing.T) {
+	tm := NewTileMap(40, 20, 16, 16)
+	floor := AddLayer(tm, "floor", "tilelayer")
+	rooms := map[string]Rect{
+		"a": {X: 2, Y: 2, Width: 6, Height: 6},
+		"b": {X: 9, Y: 2, Width: 6, Height: 6},  // One-tile wall east of a
+		"c": {X: 2, Y: 10, Width: 6, Height: 6}, // Two-tile wall south of a
+		"d": {X: 30, Y: 2, Width: 5, Height: 5}, // Down a corridor east of b
+	}
+	for _, r := range rooms {
+		_ = FillRect(floor.Data, r.X, r.Y, r.Width, r.Height, tm.Width, tm.Height, uint32(TileFloor))
+	}
+	_ = FillRect(floor.Data, 8, 4, 1, 1, tm.Width, tm.Height, uint32(TileFloor))   // Opening between a and b
+	_ = FillRect(floor.Data, 15, 4, 15, 1, tm.Width, tm.Height, uint32(TileFloor)) // Corridor from b to d
+
+	links := make(map[string]RoomOcclusion)
+	for _, o := range OcclusionGraph(tm, rooms, 24) {
+		links[o.From+"-"+o.To] = o
+	}
+
+	ab := links["a-b"]
+	if ab.WallThickness != 1 || ab.WallTiles != 5 || ab.Openings != 1 {
+		t.Errorf("a-b wall = %d tiles %d thick with %d openings, want 5 tiles 1 thick with 1 opening", ab.WallTiles, ab.WallThickness, ab.Openings)
+	}
+	if ab.VisibleFrom == 0 || ab.VisibleTo == 0 {
+		t.Errorf("a-b visible = %d/%d, want sight through the opening both ways", ab.VisibleFrom, ab.VisibleTo)
+	}
+
+	ac, ok := links["a-c"]
+	if !ok || ac.WallThickness != 2 || ac.WallTiles != 6 || ac.Openings != 0 {
+		t.Errorf("a-c = %+v, want a solid wall 6 tiles long and 2 thick", ac)
+	}
+	if ac.VisibleFrom+ac.VisibleTo != 0 {
+		t.Errorf("a-c visible = %d/%d, want none through a solid wall", ac.VisibleFrom, ac.VisibleTo)
+	}
+
+	if bd := links["b-d"]; bd.VisibleFrom == 0 || bd.WallTiles != 0 {
+		t.Errorf("b-d = %+v, want sight down the corridor and no shared wall", bd)
+	}
+	if _, ok := links["c-d"]; ok {
+		t.Error("c and d are linked without a wall or sight between them")
+	}
+
+	for _, o := range OcclusionGraph(tm, rooms, 3) {
+		if o.From == "b" && o.To == "d" {
+			t.Errorf("b-d linked beyond the sight radius: %+v", o)
+		}
+	}
+}
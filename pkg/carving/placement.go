@@ -0,0 +1,161 @@
+package carving
+
+import "github.com/dshills/dungo/pkg/rng"
+
+// PlacementSampler picks entity positions on carved floor. Candidates are
+// floor tiles without a collider - not walls, doors, hazards or one-way
+// corridor tiles - that do not touch a door, so doorways stay clear.
+//
+// Sampling is Poisson-disk dart throwing over the candidates of a room:
+// random candidates are drawn until one lies more than Radius tiles
+// (Chebyshev distance) from every position taken so far. No two positions
+// Sample hands out are within Radius of each other; a Radius of 0 only
+// keeps positions on distinct tiles. Spread places entities in rooms too
+// crowded for that.
+type PlacementSampler struct {
+	tm      *TileMap
+	radius  int
+	blocked []bool  // Tiles entities may not stand on
+	taken   []bool  // Tiles holding a position
+	points  []Point // Taken positions, in order
+}
+
+// NewPlacementSampler creates a sampler over a carved tile map. Negative
+// radii are treated as 0.
+func NewPlacementSampler(tm *TileMap, radius int) *PlacementSampler {
+	s := &PlacementSampler{
+		tm:      tm,
+		radius:  max(0, radius),
+		blocked: make([]bool, tm.Width*tm.Height),
+		taken:   make([]bool, tm.Width*tm.Height),
+	}
+
+	var floor, collision []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+	if layer, ok := tm.Layers["collision"]; ok {
+		collision = layer.Data
+	}
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			if GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) ||
+				GetTile(collision, x, y, tm.Width, tm.Height) != uint32(CollisionNone) {
+				s.blocked[y*tm.Width+x] = true
+			}
+		}
+	}
+
+	// Keep the tiles next to doors clear
+	if layer, ok := tm.Layers["doors"]; ok && tm.TileWidth > 0 && tm.TileHeight > 0 {
+		for _, obj := range layer.Objects {
+			dx, dy := int(obj.X)/tm.TileWidth, int(obj.Y)/tm.TileHeight
+			for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				x, y := dx+d[0], dy+d[1]
+				if x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+					s.blocked[y*tm.Width+x] = true
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// Radius returns the spacing the sampler keeps between positions.
+func (s *PlacementSampler) Radius() int {
+	return s.radius
+}
+
+// Reserve marks a position as taken, so later samples keep clear of it.
+// Positions outside the map are ignored.
+func (s *PlacementSampler) Reserve(p Point) {
+	if p.X >= 0 && p.X < s.tm.Width && p.Y >= 0 && p.Y < s.tm.Height {
+		s.taken[p.Y*s.tm.Width+p.X] = true
+		s.points = append(s.points, p)
+	}
+}
+
+// Sample takes and returns a random candidate tile within bounds that is
+// clear of every taken position. It returns false, drawing nothing more,
+// once no candidate in bounds is clear.
+func (s *PlacementSampler) Sample(bounds Rect, r *rng.RNG) (Point, bool) {
+	return s.SampleMasked(bounds, nil, r)
+}
+
+// SampleMasked is Sample restricted to the tiles set in mask, a row-major
+// tile mask such as HiddenFromEntrances returns. A nil mask allows every
+// tile.
+func (s *PlacementSampler) SampleMasked(bounds Rect, mask []bool, r *rng.RNG) (Point, bool) {
+	candidates := s.candidates(bounds)
+	if mask != nil {
+		allowed := candidates[:0]
+		for _, p := range candidates {
+			if mask[p.Y*s.tm.Width+p.X] {
+				allowed = append(allowed, p)
+			}
+		}
+		candidates = allowed
+	}
+	for len(candidates) > 0 {
+		i := r.Intn(len(candidates))
+		p := candidates[i]
+		if s.clear(p) {
+			s.Reserve(p)
+			return p, true
+		}
+		// Drop the rejected dart
+		candidates[i] = candidates[len(candidates)-1]
+		candidates = candidates[:len(candidates)-1]
+	}
+	return Point{}, false
+}
+
+// Spread takes and returns the candidate tile within bounds farthest from
+// every taken position, preferring the first in row-major order on ties. It
+// is the fallback for rooms Sample has filled, keeping entities as far apart
+// as the room allows, and draws no randomness. It returns false when bounds
+// has no candidate tile at all.
+func (s *PlacementSampler) Spread(bounds Rect) (Point, bool) {
+	best, bestDist := Point{}, -1
+	for _, p := range s.candidates(bounds) {
+		dist := s.tm.Width + s.tm.Height
+		for _, q := range s.points {
+			dist = min(dist, max(abs(p.X-q.X), abs(p.Y-q.Y)))
+		}
+		if dist > bestDist {
+			best, bestDist = p, dist
+		}
+	}
+	if bestDist < 0 {
+		return Point{}, false
+	}
+	s.Reserve(best)
+	return best, true
+}
+
+// candidates returns the tiles within bounds entities may stand on, in
+// row-major order.
+func (s *PlacementSampler) candidates(bounds Rect) []Point {
+	var candidates []Point
+	for y := max(0, bounds.Y); y < min(s.tm.Height, bounds.Y+bounds.Height); y++ {
+		for x := max(0, bounds.X); x < min(s.tm.Width, bounds.X+bounds.Width); x++ {
+			if !s.blocked[y*s.tm.Width+x] {
+				candidates = append(candidates, Point{X: x, Y: y})
+			}
+		}
+	}
+	return candidates
+}
+
+// clear reports whether no taken position lies within the radius of p.
+func (s *PlacementSampler) clear(p Point) bool {
+	for y := p.Y - s.radius; y <= p.Y+s.radius; y++ {
+		for x := p.X - s.radius; x <= p.X+s.radius; x++ {
+			if x >= 0 && x < s.tm.Width && y >= 0 && y < s.tm.Height && s.taken[y*s.tm.Width+x] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// TestPlacementSampler verifies samples stand on clear floor away from doors,
+// keep their spacing, and fall back to spreading out once a room is full.
+func TestPlacementSampler(t *testing.T) {
+	rooms := map[string]*graph.Room{
+		"hall":  {ID: "hall", Size: graph.SizeL},
+		"cell":  {ID: "cell", Size: graph.SizeXS},
+		"vault": {ID: "vault", Size: graph.SizeM},
+	}
+	connectors := map[string]*graph.Connector{
+		"conn1": {ID: "conn1", From: "hall", To: "cell", Type: graph.TypeDoor, Bidirectional: true, Cost: 1.0},
+		"conn2": {ID: "conn2", From: "hall", To: "vault", Type: graph.TypeDoor, Bidirectional: true, Cost: 1.0},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	layout := &Layout{
+		Poses: map[string]Pose{
+			"hall":  {X: 20, Y: 20},
+			"cell":  {X: 40, Y: 20},
+			"vault": {X: 20, Y: 40},
+		},
+		CorridorPaths: map[string]Path{
+			"conn1": {Points: []Point{{X: 20, Y: 20}, {X: 40, Y: 20}}},
+			"conn2": {Points: []Point{{X: 20, Y: 20}, {X: 20, Y: 40}}},
+		},
+		Bounds: Rect{Width: 50, Height: 50},
+	}
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+
+	const radius = 2
+	sampler := NewPlacementSampler(tm, radius)
+	r := rng.NewRNG(12345, "placement_test", []byte("test"))
+	hall := RoomBounds(SizeL, layout.Poses["hall"])
+
+	var points []Point
+	for {
+		p, ok := sampler.Sample(hall, r)
+		if !ok {
+			break
+		}
+		points = append(points, p)
+	}
+	if len(points) < 4 {
+		t.Fatalf("sampled %d positions in a large room, want at least 4", len(points))
+	}
+
+	doors := map[Point]bool{}
+	for _, obj := range tm.Layers["doors"].Objects {
+		doors[Point{X: int(obj.X) / tm.TileWidth, Y: int(obj.Y) / tm.TileHeight}] = true
+	}
+	for i, p := range points {
+		if p.X < hall.X || p.X >= hall.X+hall.Width || p.Y < hall.Y || p.Y >= hall.Y+hall.Height {
+			t.Errorf("position %v is outside the room", p)
+		}
+		if GetTile(tm.Layers["floor"].Data, p.X, p.Y, tm.Width, tm.Height) != uint32(TileFloor) {
+			t.Errorf("position %v is not floor", p)
+		}
+		if GetTile(tm.Layers["collision"].Data, p.X, p.Y, tm.Width, tm.Height) != uint32(CollisionNone) {
+			t.Errorf("position %v has a collider", p)
+		}
+		for _, d := range []Point{{0, 0}, {1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			if doors[Point{X: p.X + d.X, Y: p.Y + d.Y}] {
+				t.Errorf("position %v blocks a door", p)
+			}
+		}
+		for _, q := range points[:i] {
+			if max(abs(p.X-q.X), abs(p.Y-q.Y)) <= radius {
+				t.Errorf("positions %v and %v are within radius %d", p, q, radius)
+			}
+		}
+	}
+
+	// A full room still spreads entities onto untaken tiles
+	p, ok := sampler.Spread(hall)
+	if !ok {
+		t.Fatal("Spread() found no tile in the room")
+	}
+	for _, q := range points {
+		if p == q {
+			t.Errorf("Spread() returned taken position %v", p)
+		}
+	}
+
+	// The same seed samples the same positions
+	again := NewPlacementSampler(tm, radius)
+	r = rng.NewRNG(12345, "placement_test", []byte("test"))
+	for i, want := range points {
+		if got, _ := again.Sample(hall, r); got != want {
+			t.Fatalf("sample %d = %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package carving
+
+import (
+	"sort"
+	"strconv"
+)
+
+// SharedWall is a stretch of wall between two rooms that lie side by side
+// one tile apart, with no connector between them. Their floors are a single
+// wall tile away from each other all along it.
+type SharedWall struct {
+	From, To string
+	Side     Side // Side of From the wall is on
+	Stretch  Rect // Wall tiles with floor of both rooms on either side
+}
+
+// SharedWalls returns the walls shared by rooms no connector joins, sorted
+// by From, then To, with From before To. Rooms joined by a connector may
+// share walls.
+func SharedWalls(g Graph, layout *Layout) []SharedWall {
+	if g == nil || layout == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(layout.Poses))
+	rects := make(map[string]Rect, len(layout.Poses))
+	for id, pose := range layout.Poses {
+		if room := g.GetRoom(id); room != nil {
+			ids = append(ids, id)
+			rects[id] = RoomBounds(room.GetSize(), pose)
+		}
+	}
+	sort.Strings(ids)
+
+	joined := make(map[[2]string]bool)
+	for _, id := range g.GetConnectorIDs() {
+		conn := g.GetConnector(id)
+		joined[[2]string{conn.GetFrom(), conn.GetTo()}] = true
+		joined[[2]string{conn.GetTo(), conn.GetFrom()}] = true
+	}
+
+	var walls []SharedWall
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			if joined[[2]string{a, b}] {
+				continue
+			}
+			if stretch, side, ok := sharedStretch(rects[a], rects[b]); ok {
+				walls = append(walls, SharedWall{From: a, To: b, Side: side, Stretch: stretch})
+			}
+		}
+	}
+	return walls
+}
+
+// sharedStretch returns the wall tiles between a and b when they lie side
+// by side one tile apart, and the side of a they are on.
+func sharedStretch(a, b Rect) (Rect, Side, bool) {
+	x0, x1 := max(a.X, b.X), min(a.X+a.Width, b.X+b.Width)
+	y0, y1 := max(a.Y, b.Y), min(a.Y+a.Height, b.Y+b.Height)
+	switch {
+	case x1 > x0 && b.Y == a.Y+a.Height+1:
+		return Rect{X: x0, Y: a.Y + a.Height, Width: x1 - x0, Height: 1}, SideSouth, true
+	case x1 > x0 && a.Y == b.Y+b.Height+1:
+		return Rect{X: x0, Y: b.Y + b.Height, Width: x1 - x0, Height: 1}, SideNorth, true
+	case y1 > y0 && b.X == a.X+a.Width+1:
+		return Rect{X: a.X + a.Width, Y: y0, Width: 1, Height: y1 - y0}, SideEast, true
+	case y1 > y0 && a.X == b.X+b.Width+1:
+		return Rect{X: b.X + b.Width, Y: y0, Width: 1, Height: y1 - y0}, SideWest, true
+	}
+	return Rect{}, "", false
+}
+
+// SeparateRooms moves rooms apart until no two rooms without a connector
+// share a wall, giving each its own. Each shared wall is widened to two
+// tiles by moving every room, corridor point and door anchor past it one
+// tile further away, which never narrows another gap. The bounds grow to
+// match. Run it before AnchorDoors, while corridors are still routed
+// between room centers.
+func SeparateRooms(g Graph, layout *Layout) {
+	for {
+		walls := SharedWalls(g, layout)
+		if len(walls) == 0 {
+			return
+		}
+
+		w := walls[0]
+		horizontal := w.Side == SideEast || w.Side == SideWest
+		seam := w.Stretch.Y
+		if horizontal {
+			seam = w.Stretch.X
+		}
+		move := func(x, y *int) {
+			if horizontal && *x > seam {
+				*x++
+			} else if !horizontal && *y > seam {
+				*y++
+			}
+		}
+
+		for id, pose := range layout.Poses {
+			move(&pose.X, &pose.Y)
+			layout.Poses[id] = pose
+		}
+		for _, path := range layout.CorridorPaths {
+			for i := range path.Points {
+				move(&path.Points[i].X, &path.Points[i].Y)
+			}
+		}
+		for _, doors := range layout.Doors {
+			for i := range doors {
+				move(&doors[i].X, &doors[i].Y)
+			}
+		}
+		if horizontal {
+			layout.Bounds.Width++
+		} else {
+			layout.Bounds.Height++
+		}
+	}
+}
+
+// BreakSharedWalls puts a breakable wall in each wall shared by rooms no
+// connector joins, at the middle of the tiles along it that no corridor
+// crosses. Each becomes a one-tile corridor, recorded as a passage numbered
+// breakable_1, breakable_2, ... in room order, with a door anchor for both
+// rooms on the wall tile. Carving seals it with a destructible wall once
+// the graph gains a hidden connector for it. Run it after AnchorDoors.
+func BreakSharedWalls(g Graph, layout *Layout) []Passage {
+	walls := SharedWalls(g, layout)
+	if len(walls) == 0 {
+		return nil
+	}
+
+	used := make(map[Point]bool)
+	for _, path := range layout.CorridorPaths {
+		for _, p := range RouteTiles(path) {
+			used[p] = true
+		}
+	}
+
+	var passages []Passage
+	for _, w := range walls {
+		var free []Point
+		for y := w.Stretch.Y; y < w.Stretch.Y+w.Stretch.Height; y++ {
+			for x := w.Stretch.X; x < w.Stretch.X+w.Stretch.Width; x++ {
+				if p := (Point{X: x, Y: y}); !used[p] {
+					free = append(free, p)
+				}
+			}
+		}
+		if len(free) == 0 {
+			continue
+		}
+		p := free[len(free)/2]
+		used[p] = true
+
+		id := "breakable_" + strconv.Itoa(len(passages)+1)
+		passages = append(passages, Passage{ID: id, From: w.From, To: w.To})
+		layout.CorridorPaths[id] = Path{Points: []Point{p, p}}
+		if layout.Doors == nil {
+			layout.Doors = make(map[string][]DoorAnchor)
+		}
+		layout.Doors[id] = []DoorAnchor{
+			{Room: w.From, Side: w.Side, X: p.X, Y: p.Y},
+			{Room: w.To, Side: opposite(w.Side), X: p.X, Y: p.Y},
+		}
+	}
+	return passages
+}
@@ -0,0 +1,92 @@
+package carving
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// sharedWallGraph returns 5x5 rooms a and b sharing a wall, a and e sharing
+// one across the corridor joining them, and d further east.
+func sharedWallGraph() (map[string]*graph.Room, map[string]*graph.Connector, *Layout) {
+	rooms, connectors, layout := junctionGraph(map[string]Point{
+		"a": {X: 10, Y: 10}, "b": {X: 16, Y: 10},
+		"d": {X: 30, Y: 10}, "e": {X: 10, Y: 16},
+	}, [2]string{"a", "e"})
+	layout.CorridorPaths["ae"] = Path{Points: []Point{{X: 10, Y: 10}, {X: 10, Y: 16}}}
+	return rooms, connectors, layout
+}
+
+// TestSharedWalls verifies only rooms without a connector between them are
+// reported, with the wall tiles between them.
+func TestSharedWalls(t *testing.T) {
+	rooms, connectors, layout := sharedWallGraph()
+
+	got := SharedWalls(NewGraphAdapter(rooms, connectors), layout)
+
+	want := []SharedWall{{From: "a", To: "b", Side: SideEast, Stretch: Rect{X: 13, Y: 8, Width: 1, Height: 5}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SharedWalls() = %+v, want %+v", got, want)
+	}
+}
+
+// TestSeparateRooms verifies everything past a shared wall moves a tile
+// further away and nothing else moves.
+func TestSeparateRooms(t *testing.T) {
+	rooms, connectors, layout := sharedWallGraph()
+	g := NewGraphAdapter(rooms, connectors)
+
+	SeparateRooms(g, layout)
+
+	if walls := SharedWalls(g, layout); len(walls) != 0 {
+		t.Errorf("SharedWalls() after SeparateRooms = %+v, want none", walls)
+	}
+	want := map[string]Pose{"a": {X: 10, Y: 10}, "b": {X: 17, Y: 10}, "d": {X: 31, Y: 10}, "e": {X: 10, Y: 16}}
+	if !reflect.DeepEqual(layout.Poses, want) {
+		t.Errorf("Poses = %+v, want %+v", layout.Poses, want)
+	}
+	if layout.Bounds.Width != 81 || layout.Bounds.Height != 60 {
+		t.Errorf("Bounds = %+v, want 81x60", layout.Bounds)
+	}
+}
+
+// TestBreakSharedWalls verifies a shared wall gets a one-tile passage that
+// carving seals with a destructible wall once it is a hidden connector.
+func TestBreakSharedWalls(t *testing.T) {
+	rooms, connectors, layout := sharedWallGraph()
+	g := NewGraphAdapter(rooms, connectors)
+	AnchorDoors(g, layout)
+
+	passages := BreakSharedWalls(g, layout)
+
+	if want := []Passage{{ID: "breakable_1", From: "a", To: "b"}}; !reflect.DeepEqual(passages, want) {
+		t.Fatalf("BreakSharedWalls() = %+v, want %+v", passages, want)
+	}
+	wall := Point{X: 13, Y: 10}
+	if got := layout.CorridorPaths["breakable_1"].Points; !reflect.DeepEqual(got, []Point{wall, wall}) {
+		t.Errorf("CorridorPaths[breakable_1] = %v, want the wall tile %v", got, wall)
+	}
+	for _, door := range layout.Doors["breakable_1"] {
+		if !onWall(RoomBounds(RoomSize(graph.SizeS), layout.Poses[door.Room]), door) {
+			t.Errorf("anchor %+v is not on the %s wall of %s", door, door.Side, door.Room)
+		}
+	}
+
+	connectors["breakable_1"] = &graph.Connector{ID: "breakable_1", From: "a", To: "b", Type: graph.TypeHidden,
+		Visibility: graph.VisibilitySecret, Bidirectional: true, Cost: 1}
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	objects := tm.Layers["destructibles"].Objects
+	if len(objects) != 1 || objects[0].Properties["connector_id"] != "breakable_1" ||
+		objects[0].X != float64(wall.X*16) || objects[0].Y != float64(wall.Y*16) {
+		t.Fatalf("destructibles = %+v, want one wall for breakable_1 at %v", objects, wall)
+	}
+	if GetTile(tm.Layers["walls"].Data, wall.X, wall.Y, tm.Width, tm.Height) != uint32(TileWall) ||
+		GetTile(tm.Layers["floor"].Data, wall.X, wall.Y, tm.Width, tm.Height) != uint32(TileFloor) {
+		t.Error("the breakable wall is not a wall over floor")
+	}
+}
@@ -2,6 +2,8 @@ package carving
 
 import (
 	"fmt"
+
+	"github.com/dshills/dungo/pkg/graph"
 )
 
 // Stamper handles stamping room footprints onto tile layers.
@@ -24,26 +26,12 @@ func (s *Stamper) StampRoom(room Room, pose Pose, tileData []uint32) error {
 		return fmt.Errorf("room cannot be nil")
 	}
 
-	// Get room dimensions based on size
-	roomWidth, roomHeight := s.getRoomDimensions(room.GetSize())
-
-	// Calculate stamp position based on pose
-	// The pose gives the center position, so we offset by half the room size
-	startX := pose.X - roomWidth/2
-	startY := pose.Y - roomHeight/2
-
-	// Apply rotation if needed (0, 90, 180, 270 degrees)
-	rotatedWidth, rotatedHeight := roomWidth, roomHeight
-	if pose.Rotation == 90 || pose.Rotation == 270 {
-		rotatedWidth, rotatedHeight = roomHeight, roomWidth
-	}
-
 	// Stamp the room shape
 	switch room.GetSize() {
 	case SizeXS, SizeS, SizeM, SizeL, SizeXL:
-		// For now, all rooms are rectangular
-		// Future enhancement: support different shapes based on FootprintID
-		if err := s.stampRectangle(startX, startY, rotatedWidth, rotatedHeight, tileData); err != nil {
+		// Footprints are rectangles, turned by the pose rotation
+		b := RoomBounds(room.GetSize(), pose)
+		if err := s.stampRectangle(b.X, b.Y, b.Width, b.Height, tileData); err != nil {
 			return fmt.Errorf("stamping rectangle for room %s: %w", room.GetID(), err)
 		}
 	default:
@@ -53,14 +41,14 @@ func (s *Stamper) StampRoom(room Room, pose Pose, tileData []uint32) error {
 	return nil
 }
 
-// getRoomDimensions returns the tile dimensions for a given room size.
+// roomDimensions returns the tile dimensions for a given room size.
 // Mapping:
 // XS: 3x3 tiles
 // S: 5x5 tiles
 // M: 7x7 tiles
 // L: 10x10 tiles
 // XL: 15x15 tiles
-func (s *Stamper) getRoomDimensions(size RoomSize) (width, height int) {
+func roomDimensions(size RoomSize) (width, height int) {
 	switch size {
 	case SizeXS:
 		return 3, 3
@@ -77,6 +65,30 @@ func (s *Stamper) getRoomDimensions(size RoomSize) (width, height int) {
 	}
 }
 
+// footprintDimensions returns the unrotated tile dimensions of a room's
+// footprint. Halls are as wide as their size class and half as deep,
+// rounded up; any other footprint is the size class square.
+func footprintDimensions(size RoomSize, footprintID string) (width, height int) {
+	width, height = roomDimensions(size)
+	if footprintID == graph.FootprintHall {
+		height = (height + 1) / 2
+	}
+	return width, height
+}
+
+// RoomBounds returns the tile rectangle a room of the given size occupies when
+// stamped at pose. It mirrors the placement used by StampRoom, so callers that
+// need room extents after carving (collision, content placement, exporters)
+// agree with the carved floor tiles. The pose's footprint is turned by its
+// rotation in 90° steps and centered on the pose.
+func RoomBounds(size RoomSize, pose Pose) Rect {
+	w, h := footprintDimensions(size, pose.FootprintID)
+	if pose.Rotation == 90 || pose.Rotation == 270 {
+		w, h = h, w
+	}
+	return Rect{X: pose.X - w/2, Y: pose.Y - h/2, Width: w, Height: h}
+}
+
 // stampRectangle stamps a rectangular room at the given position.
 func (s *Stamper) stampRectangle(x, y, w, h int, tileData []uint32) error {
 	return FillRect(tileData, x, y, w, h, s.width, s.height, uint32(TileFloor))
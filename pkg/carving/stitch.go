@@ -0,0 +1,182 @@
+package carving
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PasteTileMap copies src into dst with src's top-left tile at (x, y), for
+// joining maps that were carved separately. Tile layers are copied tile by
+// tile, clipped to dst. Object layers have their objects moved by the
+// offset and renumbered after the objects already in dst. Layers missing
+// from dst are added in src's layer order. Object properties are shared with
+// src.
+func PasteTileMap(dst, src *TileMap, x, y int) {
+	names := make([]string, 0, len(src.Layers))
+	for name := range src.Layers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := src.Layers[names[i]], src.Layers[names[j]]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		from := src.Layers[name]
+		to, ok := dst.Layers[name]
+		if !ok {
+			to = &Layer{
+				ID:      len(dst.Layers),
+				Name:    from.Name,
+				Type:    from.Type,
+				Visible: from.Visible,
+				Opacity: from.Opacity,
+			}
+			if from.Type == "tilelayer" {
+				to.Data = make([]uint32, dst.Width*dst.Height)
+			} else {
+				to.Objects = []Object{}
+			}
+			dst.Layers[name] = to
+		}
+
+		if from.Type == "tilelayer" {
+			for sy := 0; sy < src.Height; sy++ {
+				for sx := 0; sx < src.Width; sx++ {
+					_ = SetTile(to.Data, x+sx, y+sy, dst.Width, dst.Height, GetTile(from.Data, sx, sy, src.Width, src.Height))
+				}
+			}
+			continue
+		}
+
+		nextID := nextObjectID(to)
+		for _, obj := range from.Objects {
+			obj.ID = nextID
+			obj.X += float64(x * dst.TileWidth)
+			obj.Y += float64(y * dst.TileHeight)
+			to.Objects = append(to.Objects, obj)
+			nextID++
+		}
+	}
+}
+
+// CarveCorridors carves the corridors of the given connectors into an
+// already carved tile map the way Carve does, for joining maps that were
+// carved separately. Each path becomes floor, walls it cuts through are
+// opened (secrets stay) and the new floor is walled in. Corridor
+// connectors get a door at each end and hidden connectors are sealed by the
+// secret kind their To room asks for. The collision layer and, when present, the biome layer
+// are rebuilt for the whole map.
+//
+// g and layout must cover the whole map: breach points avoid every visible
+// corridor in layout. Connectors are processed in ID order for determinism.
+func CarveCorridors(tm *TileMap, g Graph, layout *Layout, connIDs []string) error {
+	floor, walls := tm.Layers["floor"], tm.Layers["walls"]
+	if floor == nil || walls == nil {
+		return fmt.Errorf("tile map must have floor and walls layers")
+	}
+
+	ids := append([]string(nil), connIDs...)
+	sort.Strings(ids)
+
+	// Secrets stand on floor tiles and must not be opened; collapsible
+	// floors must not be floored over
+	sealed := make(map[Point]bool)
+	var collapsed []Point
+	if layer, ok := tm.Layers["destructibles"]; ok && tm.TileWidth > 0 && tm.TileHeight > 0 {
+		for _, obj := range layer.Objects {
+			t := Point{X: int(obj.X) / tm.TileWidth, Y: int(obj.Y) / tm.TileHeight}
+			sealed[t] = true
+			if obj.Type == SecretCollapsibleFloor {
+				collapsed = append(collapsed, t)
+			}
+		}
+	}
+
+	// Route corridors, then wall them in
+	router := NewCorridorRouter(tm.Width, tm.Height)
+	for _, connID := range ids {
+		if g.GetConnector(connID) == nil {
+			return fmt.Errorf("connector %s not found in graph", connID)
+		}
+		path, ok := layout.CorridorPaths[connID]
+		if !ok {
+			return fmt.Errorf("connector %s has no corridor path", connID)
+		}
+		if err := router.RouteCorridor(path, floor.Data); err != nil {
+			return fmt.Errorf("routing corridor %s: %w", connID, err)
+		}
+
+		tiles := pathTiles(path, floor.Data, tm.Width, tm.Height)
+		for _, t := range tiles {
+			if !sealed[t] {
+				walls.Data[t.Y*tm.Width+t.X] = uint32(TileEmpty)
+			}
+		}
+		for _, t := range tiles {
+			wallAround(floor.Data, walls.Data, t.X, t.Y, tm.Width, tm.Height)
+		}
+	}
+	for _, t := range collapsed {
+		_ = SetTile(floor.Data, t.X, t.Y, tm.Width, tm.Height, uint32(TileEmpty))
+		_ = SetTile(walls.Data, t.X, t.Y, tm.Width, tm.Height, uint32(TileEmpty))
+	}
+
+	// Place doors at room/corridor junctions
+	if layer, ok := tm.Layers["doors"]; ok {
+		doorID := nextObjectID(layer)
+		for _, connID := range ids {
+			conn := g.GetConnector(connID)
+			if conn.GetType() != TypeCorridor {
+				continue
+			}
+			doors := doorObjects(conn, connID, layout.CorridorPaths[connID], tm.TileWidth, tm.TileHeight, doorID)
+			layer.Objects = append(layer.Objects, doors...)
+			doorID += len(doors)
+		}
+	}
+
+	// Seal hidden connectors behind secrets
+	if layer, ok := tm.Layers["destructibles"]; ok {
+		open := openTiles(g, layout, floor.Data, tm.Width, tm.Height)
+		objID := nextObjectID(layer)
+		for _, connID := range ids {
+			conn := g.GetConnector(connID)
+			if conn.GetType() != TypeHidden {
+				continue
+			}
+			tiles := pathTiles(layout.CorridorPaths[connID], floor.Data, tm.Width, tm.Height)
+			if sealHidden(g, layout, tm, layer, conn, connID, tiles, open, objID) {
+				objID++
+			}
+		}
+	}
+
+	// Rebuild the derived layers that span the new corridors
+	if old, ok := tm.Layers["collision"]; ok {
+		layer := BuildCollisionLayer(tm, oneWayPaths(g, layout))
+		layer.ID = old.ID
+		tm.Layers["collision"] = layer
+	}
+	if old, ok := tm.Layers["biome"]; ok {
+		layer := BuildBiomeLayer(tm, g, layout)
+		layer.ID = old.ID
+		tm.Layers["biome"] = layer
+	}
+
+	return nil
+}
+
+// nextObjectID returns the ID following the highest object ID in a layer.
+func nextObjectID(layer *Layer) int {
+	next := 1
+	for _, obj := range layer.Objects {
+		if obj.ID >= next {
+			next = obj.ID + 1
+		}
+	}
+	return next
+}
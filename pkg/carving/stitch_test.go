@@ -0,0 +1,98 @@
+package carving
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestPasteAndCarveCorridors verifies that two halves carved separately,
+// pasted side by side and joined with CarveCorridors match the map carved in
+// one piece.
+func TestPasteAndCarveCorridors(t *testing.T) {
+	rooms := map[string]*graph.Room{}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		rooms[id] = &graph.Room{ID: id, Size: graph.SizeS}
+	}
+	connectors := map[string]*graph.Connector{
+		"ab": {ID: "ab", From: "a", To: "b", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1},
+		"cd": {ID: "cd", From: "c", To: "d", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1},
+		"bc": {ID: "bc", From: "b", To: "c", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1},
+		"ad": {ID: "ad", From: "a", To: "d", Type: graph.TypeHidden, Bidirectional: true, Cost: 1},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	carver := NewDefaultCarver(16, 16)
+
+	whole := &Layout{
+		Poses: map[string]Pose{
+			"a": {X: 5, Y: 10}, "b": {X: 20, Y: 10},
+			"c": {X: 35, Y: 10}, "d": {X: 50, Y: 10},
+		},
+		CorridorPaths: map[string]Path{
+			"ab": {Points: []Point{{X: 5, Y: 10}, {X: 20, Y: 10}}},
+			"cd": {Points: []Point{{X: 35, Y: 10}, {X: 50, Y: 10}}},
+			"bc": {Points: []Point{{X: 20, Y: 10}, {X: 35, Y: 10}}},
+			"ad": {Points: []Point{{X: 5, Y: 10}, {X: 5, Y: 16}, {X: 50, Y: 16}, {X: 50, Y: 10}}},
+		},
+		Bounds: Rect{Width: 60, Height: 20},
+	}
+	want, err := carver.Carve(context.Background(), g, whole)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+
+	half := func(a, b, conn string) *TileMap {
+		layout := &Layout{
+			Poses:         map[string]Pose{a: {X: 5, Y: 10}, b: {X: 20, Y: 10}},
+			CorridorPaths: map[string]Path{conn: {Points: []Point{{X: 5, Y: 10}, {X: 20, Y: 10}}}},
+			Bounds:        Rect{Width: 30, Height: 20},
+		}
+		tm, err := carver.Carve(context.Background(), g, layout)
+		if err != nil {
+			t.Fatalf("Carve() error = %v", err)
+		}
+		return tm
+	}
+
+	got := &TileMap{Width: 60, Height: 20, TileWidth: 16, TileHeight: 16, Layers: map[string]*Layer{}}
+	PasteTileMap(got, half("a", "b", "ab"), 0, 0)
+	PasteTileMap(got, half("c", "d", "cd"), 30, 0)
+	if err := CarveCorridors(got, g, whole, []string{"bc", "ad"}); err != nil {
+		t.Fatalf("CarveCorridors() error = %v", err)
+	}
+
+	for _, name := range []string{"floor", "walls", "collision"} {
+		if !reflect.DeepEqual(got.Layers[name].Data, want.Layers[name].Data) {
+			t.Errorf("%s layer differs from the map carved in one piece", name)
+		}
+	}
+
+	objects := func(tm *TileMap, name string) []string {
+		var s []string
+		for _, obj := range tm.Layers[name].Objects {
+			s = append(s, obj.Name)
+		}
+		sort.Strings(s)
+		return s
+	}
+	for _, name := range []string{"doors", "destructibles"} {
+		if gotNames, wantNames := objects(got, name), objects(want, name); !reflect.DeepEqual(gotNames, wantNames) {
+			t.Errorf("%s objects = %v, want %v", name, gotNames, wantNames)
+		}
+	}
+
+	ids := make(map[int]bool)
+	for _, obj := range got.Layers["doors"].Objects {
+		if ids[obj.ID] {
+			t.Errorf("duplicate door object ID %d", obj.ID)
+		}
+		ids[obj.ID] = true
+	}
+
+	if err := CarveCorridors(got, g, whole, []string{"missing"}); err == nil {
+		t.Error("CarveCorridors() accepted an unknown connector")
+	}
+}
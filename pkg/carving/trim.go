@@ -0,0 +1,338 @@
+package carving
+
+import "sort"
+
+// RepackGap is the number of identical tiles repacking leaves before every
+// removed one, so branches and the corridors between them stay apart.
+const RepackGap = 3
+
+// TrimOptions controls the dead-space trimming post-pass.
+type TrimOptions struct {
+	Margin int  // Empty tiles kept around the carved area
+	Repack bool // Also collapse whitespace between distant branches
+}
+
+// Trim removes dead space from a carved tile map. It crops the empty border
+// down to the margin and, with Repack, removes seams of whitespace between
+// distant branches. A seam holds one tile of every row (or column), each
+// repeating the RepackGap tiles before it in every tile layer and clear of
+// rooms, objects and corridor points; it only steps sideways across tiles
+// empty in both rows, so straight corridors stay straight and nothing it
+// passes is cut apart. The tile map and layout are updated in place so
+// room poses, corridor paths, door anchors and objects keep matching the
+// tiles. Returns the number of tiles removed.
+//
+// Algorithm:
+//  1. Mark the tiles of rooms (with their walls), objects and corridor points as fixed
+//  2. Crop to the non-empty and fixed tiles, grown by the margin
+//  3. With Repack, remove vertical seams while one exists, then horizontal ones
+//  4. Move poses, corridor points, door anchors and objects along with their tiles
+func Trim(tm *TileMap, g Graph, layout *Layout, opts TrimOptions) int {
+	if tm == nil || tm.Width <= 0 || tm.Height <= 0 {
+		return 0
+	}
+	width, height := tm.Width, tm.Height
+
+	grid := &trimGrid{width: width, height: height, fixed: make([]uint32, width*height)}
+	for i, layer := range trimTileLayers(tm) {
+		grid.layers = append(grid.layers, layer.Data)
+		if layer.Name == "floor" || layer.Name == "walls" {
+			grid.geometry = append(grid.geometry, i)
+		}
+	}
+
+	// 1. Points moved along with the tiles, and the fixed tiles
+	var points []*Point
+	fix := func(x0, y0, x1, y1 int) {
+		for y := max(y0, 0); y <= min(y1, height-1); y++ {
+			for x := max(x0, 0); x <= min(x1, width-1); x++ {
+				grid.fixed[y*width+x] = 1
+			}
+		}
+	}
+	poses := make(map[string]*Point)
+	paths := make(map[string][]Point)
+	anchors := make(map[*DoorAnchor]*Point)
+	if layout != nil {
+		for id, pose := range layout.Poses {
+			poses[id] = &Point{X: pose.X, Y: pose.Y}
+			points = append(points, poses[id])
+			if room := g.GetRoom(id); room != nil {
+				b := RoomBounds(room.GetSize(), pose)
+				fix(b.X-1, b.Y-1, b.X+b.Width, b.Y+b.Height)
+			}
+		}
+		for id, path := range layout.CorridorPaths {
+			paths[id] = append([]Point(nil), path.Points...)
+			for i := range paths[id] {
+				pt := &paths[id][i]
+				points = append(points, pt)
+				fix(pt.X, pt.Y, pt.X, pt.Y)
+			}
+		}
+		for _, doors := range layout.Doors {
+			for i := range doors {
+				anchors[&doors[i]] = &Point{X: doors[i].X, Y: doors[i].Y}
+				points = append(points, anchors[&doors[i]])
+			}
+		}
+	}
+	objectTiles := make(map[*Object]*Point)
+	for _, layer := range tm.Layers {
+		for i := range layer.Objects {
+			obj := &layer.Objects[i]
+			x0, y0 := objectTile(obj.X, tm.TileWidth), objectTile(obj.Y, tm.TileHeight)
+			x1 := max(x0, objectTile(obj.X+obj.Width-1, tm.TileWidth))
+			y1 := max(y0, objectTile(obj.Y+obj.Height-1, tm.TileHeight))
+			fix(x0, y0, x1, y1)
+			objectTiles[obj] = &Point{X: x0, Y: y0}
+			points = append(points, objectTiles[obj])
+		}
+	}
+
+	// 2. Crop the empty border
+	minX, minY, maxX, maxY := width, height, -1, -1
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !grid.empty(x, y) || grid.fixed[y*width+x] != 0 {
+				minX, maxX = min(minX, x), max(maxX, x)
+				minY, maxY = min(minY, y), max(maxY, y)
+			}
+		}
+	}
+	if maxX < 0 {
+		return 0
+	}
+	minX, maxX = max(minX-opts.Margin, 0), min(maxX+opts.Margin, width-1)
+	minY, maxY = max(minY-opts.Margin, 0), min(maxY+opts.Margin, height-1)
+	grid.crop(minX, minY, maxX-minX+1, maxY-minY+1)
+	for _, pt := range points {
+		pt.X, pt.Y = pt.X-minX, pt.Y-minY
+	}
+
+	// 3. Remove seams, columns first
+	if opts.Repack {
+		for pass := 0; pass < 2; pass++ {
+			for seam := grid.findSeam(); seam != nil; seam = grid.findSeam() {
+				grid.removeSeam(seam)
+				for _, pt := range points {
+					if pt.Y >= 0 && pt.Y < len(seam) && pt.X > seam[pt.Y] {
+						pt.X--
+					}
+				}
+			}
+			grid.transpose()
+			for _, pt := range points {
+				pt.X, pt.Y = pt.Y, pt.X
+			}
+		}
+	}
+
+	// 4. Write the smaller map and the moved positions back
+	layers := trimTileLayers(tm)
+	for i, layer := range layers {
+		layer.Data = grid.layers[i]
+	}
+	for obj, pt := range objectTiles {
+		obj.X = float64(pt.X*tm.TileWidth) + obj.X - float64(objectTile(obj.X, tm.TileWidth)*tm.TileWidth)
+		obj.Y = float64(pt.Y*tm.TileHeight) + obj.Y - float64(objectTile(obj.Y, tm.TileHeight)*tm.TileHeight)
+	}
+	if layout != nil {
+		for id, pt := range poses {
+			pose := layout.Poses[id]
+			pose.X, pose.Y = pt.X, pt.Y
+			layout.Poses[id] = pose
+		}
+		for id, points := range paths {
+			layout.CorridorPaths[id] = Path{Points: points}
+		}
+		for anchor, pt := range anchors {
+			anchor.X, anchor.Y = pt.X, pt.Y
+		}
+		layout.Bounds = Rect{Width: grid.width, Height: grid.height}
+	}
+
+	tm.Width, tm.Height = grid.width, grid.height
+	return width*height - grid.width*grid.height
+}
+
+// trimTileLayers returns the tile layers of a map in name order.
+func trimTileLayers(tm *TileMap) []*Layer {
+	names := make([]string, 0, len(tm.Layers))
+	for name, layer := range tm.Layers {
+		if layer.Type == "tilelayer" && len(layer.Data) == tm.Width*tm.Height {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	layers := make([]*Layer, len(names))
+	for i, name := range names {
+		layers[i] = tm.Layers[name]
+	}
+	return layers
+}
+
+// objectTile returns the tile index of a pixel coordinate.
+func objectTile(v float64, tileSize int) int {
+	if tileSize <= 0 {
+		return 0
+	}
+	return int(v) / tileSize
+}
+
+// trimGrid holds the tile layers being trimmed, with the fixed tiles as one
+// more layer so they are cropped and cut along with the others.
+type trimGrid struct {
+	width, height int
+	layers        [][]uint32
+	geometry      []int // Indices of the floor and wall layers
+	fixed         []uint32
+}
+
+// all returns the tile layers and the fixed layer.
+func (t *trimGrid) all() [][]uint32 {
+	return append(append([][]uint32(nil), t.layers...), t.fixed)
+}
+
+// empty reports whether nothing is carved at (x, y). Other layers only
+// decorate carved tiles, elevation giving every tile a value.
+func (t *trimGrid) empty(x, y int) bool {
+	for _, i := range t.geometry {
+		if t.layers[i][y*t.width+x] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// removable reports whether (x, y) is free and repeats the RepackGap tiles
+// before it in every layer.
+func (t *trimGrid) removable(x, y int) bool {
+	if x < RepackGap || t.fixed[y*t.width+x] != 0 {
+		return false
+	}
+	for _, data := range t.all() {
+		v := data[y*t.width+x]
+		for k := 1; k <= RepackGap; k++ {
+			if data[y*t.width+x-k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findSeam returns the column of a vertical seam in every row, or nil when
+// there is none. Row by row, a removable tile continues the seam from the
+// same column, or from any column of a run of free tiles empty in both rows
+// that contains it.
+func (t *trimGrid) findSeam() []int {
+	if t.height == 0 {
+		return nil
+	}
+	// from[y][x] is the seam column in row y-1 leading to (x, y), or -1
+	from := make([][]int, t.height)
+	reached := make([]bool, t.width)
+	for x := 0; x < t.width; x++ {
+		reached[x] = t.removable(x, 0)
+	}
+	for y := 1; y < t.height; y++ {
+		from[y] = make([]int, t.width)
+		next := make([]bool, t.width)
+		free := func(x int) bool {
+			return t.empty(x, y-1) && t.empty(x, y) &&
+				t.fixed[(y-1)*t.width+x] == 0 && t.fixed[y*t.width+x] == 0
+		}
+		for x := 0; x < t.width; {
+			if !free(x) {
+				from[y][x] = -1
+				if reached[x] && t.removable(x, y) {
+					next[x], from[y][x] = true, x
+				}
+				x++
+				continue
+			}
+			// A run of free tiles is crossed sideways from its first reached column
+			end, entry := x, -1
+			for ; end < t.width && free(end); end++ {
+				if entry < 0 && reached[end] {
+					entry = end
+				}
+			}
+			for ; x < end; x++ {
+				from[y][x] = -1
+				if entry >= 0 && t.removable(x, y) {
+					next[x], from[y][x] = true, entry
+				}
+			}
+		}
+		reached = next
+	}
+
+	last := -1
+	for x := 0; x < t.width && last < 0; x++ {
+		if reached[x] {
+			last = x
+		}
+	}
+	if last < 0 {
+		return nil
+	}
+	seam := make([]int, t.height)
+	seam[t.height-1] = last
+	for y := t.height - 1; y > 0; y-- {
+		seam[y-1] = from[y][seam[y]]
+	}
+	return seam
+}
+
+// removeSeam drops the seam's tile from every row.
+func (t *trimGrid) removeSeam(seam []int) {
+	w := t.width - 1
+	for i, data := range t.all() {
+		out := make([]uint32, 0, w*t.height)
+		for y := 0; y < t.height; y++ {
+			row := data[y*t.width : (y+1)*t.width]
+			out = append(out, row[:seam[y]]...)
+			out = append(out, row[seam[y]+1:]...)
+		}
+		t.set(i, out)
+	}
+	t.width = w
+}
+
+// crop keeps the w×h tiles from (x, y).
+func (t *trimGrid) crop(x, y, w, h int) {
+	for i, data := range t.all() {
+		out := make([]uint32, 0, w*h)
+		for row := y; row < y+h; row++ {
+			out = append(out, data[row*t.width+x:row*t.width+x+w]...)
+		}
+		t.set(i, out)
+	}
+	t.width, t.height = w, h
+}
+
+// transpose swaps rows and columns, so horizontal seams are found as vertical ones.
+func (t *trimGrid) transpose() {
+	for i, data := range t.all() {
+		out := make([]uint32, len(data))
+		for y := 0; y < t.height; y++ {
+			for x := 0; x < t.width; x++ {
+				out[x*t.height+y] = data[y*t.width+x]
+			}
+		}
+		t.set(i, out)
+	}
+	t.width, t.height = t.height, t.width
+}
+
+// set replaces layer i of all().
+func (t *trimGrid) set(i int, data []uint32) {
+	if i < len(t.layers) {
+		t.layers[i] = data
+		return
+	}
+	t.fixed = data
+}
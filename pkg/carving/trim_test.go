@@ -0,0 +1,127 @@
+package carving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// carveTrimTest carves rooms a and b joined by a long corridor, and c below
+// a, in the middle of a 100x60 map.
+func carveTrimTest(t *testing.T) (*TileMap, Graph, *Layout) {
+	t.Helper()
+	rooms := map[string]*graph.Room{}
+	for _, id := range []string{"a", "b", "c"} {
+		rooms[id] = &graph.Room{ID: id, Size: graph.SizeS}
+	}
+	connectors := map[string]*graph.Connector{
+		"ab": {ID: "ab", From: "a", To: "b", Type: graph.TypeCorridor, Bidirectional: true, Cost: 1},
+		"ac": {ID: "ac", From: "a", To: "c", Type: graph.TypeDoor, Bidirectional: true, Cost: 1},
+	}
+	g := NewGraphAdapter(rooms, connectors)
+	layout := &Layout{
+		Poses: map[string]Pose{"a": {X: 20, Y: 10}, "b": {X: 70, Y: 10}, "c": {X: 20, Y: 40}},
+		CorridorPaths: map[string]Path{
+			"ab": {Points: []Point{{X: 20, Y: 10}, {X: 70, Y: 10}}},
+			"ac": {Points: []Point{{X: 20, Y: 10}, {X: 20, Y: 40}}},
+		},
+		Bounds: Rect{Width: 100, Height: 60},
+	}
+	tm, err := NewDefaultCarver(16, 16).Carve(context.Background(), g, layout)
+	if err != nil {
+		t.Fatalf("Carve() error = %v", err)
+	}
+	return tm, g, layout
+}
+
+// floorTiles counts floor tiles and the separate floor areas they form.
+func floorTiles(tm *TileMap) (tiles, areas int) {
+	data := append([]uint32(nil), tm.Layers["floor"].Data...)
+	for i, v := range data {
+		if v != uint32(TileFloor) {
+			continue
+		}
+		tiles++
+		if data[i] == uint32(TileFloor) {
+			areas++
+			_ = FloodFill(data, i%tm.Width, i/tm.Width, tm.Width, tm.Height, uint32(TileFloor)+1)
+		}
+	}
+	return tiles, areas
+}
+
+func TestTrim_Crop(t *testing.T) {
+	tm, g, layout := carveTrimTest(t)
+	tiles, _ := floorTiles(tm)
+	doors := append([]Object(nil), tm.Layers["doors"].Objects...)
+
+	removed := Trim(tm, g, layout, TrimOptions{Margin: 1})
+
+	// Walls reach from a's left at x=17, y=7 to b's right at x=73 and c's bottom at y=43
+	if tm.Width != 59 || tm.Height != 39 {
+		t.Fatalf("trimmed map is %dx%d, want 59x39", tm.Width, tm.Height)
+	}
+	if removed != 100*60-59*39 {
+		t.Errorf("Trim() = %d, want %d", removed, 100*60-59*39)
+	}
+	if layout.Bounds != (Rect{Width: 59, Height: 39}) {
+		t.Errorf("layout bounds = %+v, want 59x39", layout.Bounds)
+	}
+	if got := layout.Poses["a"]; got.X != 4 || got.Y != 4 {
+		t.Errorf("room a at (%d, %d), want (4, 4)", got.X, got.Y)
+	}
+	if got := layout.CorridorPaths["ab"].Points[1]; got != (Point{X: 54, Y: 4}) {
+		t.Errorf("corridor ab ends at %v, want (54, 4)", got)
+	}
+	if got, _ := floorTiles(tm); got != tiles {
+		t.Errorf("floor tiles = %d after trimming, want %d", got, tiles)
+	}
+	for i, obj := range tm.Layers["doors"].Objects {
+		if obj.X != doors[i].X-16*16 || obj.Y != doors[i].Y-6*16 {
+			t.Errorf("door %d at (%v, %v), want (%v, %v)", i, obj.X, obj.Y, doors[i].X-16*16, doors[i].Y-6*16)
+		}
+	}
+	for name, layer := range tm.Layers {
+		if layer.Type == "tilelayer" && len(layer.Data) != tm.Width*tm.Height {
+			t.Errorf("layer %s has %d tiles, want %d", name, len(layer.Data), tm.Width*tm.Height)
+		}
+	}
+}
+
+func TestTrim_Repack(t *testing.T) {
+	tm, g, layout := carveTrimTest(t)
+
+	Trim(tm, g, layout, TrimOptions{Margin: 1, Repack: true})
+
+	if tm.Width >= 59 || tm.Height >= 39 {
+		t.Fatalf("repacked map is %dx%d, want smaller than the cropped 59x39", tm.Width, tm.Height)
+	}
+	if _, areas := floorTiles(tm); areas != 1 {
+		t.Errorf("floor falls apart into %d areas, want 1", areas)
+	}
+
+	// Rooms keep their size and corridors stay straight between their centers
+	floor := tm.Layers["floor"].Data
+	for id, pose := range layout.Poses {
+		b := RoomBounds(SizeS, pose)
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) {
+					t.Fatalf("room %s lost floor at (%d, %d)", id, x, y)
+				}
+			}
+		}
+	}
+	a, b, c := layout.Poses["a"], layout.Poses["b"], layout.Poses["c"]
+	ab, ac := layout.CorridorPaths["ab"].Points, layout.CorridorPaths["ac"].Points
+	if ab[0] != (Point{X: a.X, Y: a.Y}) || ab[1] != (Point{X: b.X, Y: b.Y}) || a.Y != b.Y {
+		t.Errorf("corridor ab = %v, want a straight line from a %v to b %v", ab, a, b)
+	}
+	if ac[0] != (Point{X: a.X, Y: a.Y}) || ac[1] != (Point{X: c.X, Y: c.Y}) || a.X != c.X {
+		t.Errorf("corridor ac = %v, want a straight line from a %v to c %v", ac, a, c)
+	}
+	if b.X-a.X < 5+RepackGap {
+		t.Errorf("rooms a and b are %d apart, want at least %d", b.X-a.X, 5+RepackGap)
+	}
+}
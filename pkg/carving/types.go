@@ -23,12 +23,32 @@ type Path struct {
 	Points []Point
 }
 
+// Side is a wall of a room.
+type Side string
+
+// Room sides, in the order they are tried when no side faces the other room.
+const (
+	SideNorth Side = "north"
+	SideEast  Side = "east"
+	SideSouth Side = "south"
+	SideWest  Side = "west"
+)
+
+// DoorAnchor is where a connector's corridor meets one of its rooms: the
+// wall tile opened for the door, on the given side of the room.
+type DoorAnchor struct {
+	Room string
+	Side Side
+	X, Y int
+}
+
 // Layout contains the spatial embedding of the dungeon graph.
 // It maps abstract rooms to concrete positions and corridors to paths.
 type Layout struct {
-	Poses         map[string]Pose // Room ID → position/rotation
-	CorridorPaths map[string]Path // Connector ID → polyline path
-	Bounds        Rect            // Overall dungeon extents
+	Poses         map[string]Pose         // Room ID → position/rotation
+	CorridorPaths map[string]Path         // Connector ID → polyline path
+	Doors         map[string][]DoorAnchor // Connector ID → anchors at its From and To rooms (see AnchorDoors)
+	Bounds        Rect                    // Overall dungeon extents
 }
 
 // TileMap is the rasterized dungeon with layered tiles.
@@ -93,8 +113,16 @@ const (
 
 // Gate represents an optional gating requirement for a connector.
 type Gate struct {
-	Type  string // "key", "puzzle", "ability"
-	Value string // Specific gate (e.g., "silver_key", "runes_3")
+	Type         string            // "key", "puzzle", "ability"
+	Value        string            // Specific gate (e.g., "silver_key", "runes_3")
+	Requirements []GateRequirement // Further capabilities checked with Type/Value
+	Any          bool              // Any one capability opens the gate instead of all
+}
+
+// GateRequirement is one further capability a gate checks.
+type GateRequirement struct {
+	Type  string
+	Value string
 }
 
 // Graph represents the Abstract Dungeon Graph for carving purposes.
@@ -110,6 +138,8 @@ type Graph interface {
 type Room interface {
 	GetID() string
 	GetSize() RoomSize
+	GetTags() map[string]string
+	GetArchetype() string
 }
 
 // Connector represents connector data needed for carving.
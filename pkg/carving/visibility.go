@@ -0,0 +1,168 @@
+package carving
+
+// fovOctants transforms the first octant into each of the eight octants
+// around an origin: xx, xy, yx, yy in turn.
+var fovOctants = [8][4]int{
+	{1, 0, 0, 1}, {0, 1, 1, 0}, {0, -1, 1, 0}, {-1, 0, 0, 1},
+	{-1, 0, 0, -1}, {0, -1, -1, 0}, {0, 1, -1, 0}, {1, 0, 0, -1},
+}
+
+// FieldOfView returns the tiles visible from origin within radius tiles,
+// computed by recursive shadowcasting over the floor layer: floor tiles are
+// transparent, and walls, void and tiles outside the map block sight.
+// Blocking tiles are visible themselves, so a wall seen from origin counts
+// as visible. The result is indexed in row-major order.
+func FieldOfView(tm *TileMap, origin Point, radius int) []bool {
+	visible := make([]bool, tm.Width*tm.Height)
+	markFieldOfView(tm, origin, radius, visible)
+	return visible
+}
+
+// markFieldOfView marks the tiles visible from origin in visible, see
+// FieldOfView. Tiles already marked stay marked.
+func markFieldOfView(tm *TileMap, origin Point, radius int, visible []bool) {
+	if origin.X < 0 || origin.X >= tm.Width || origin.Y < 0 || origin.Y >= tm.Height {
+		return
+	}
+	visible[origin.Y*tm.Width+origin.X] = true
+
+	var floor []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+	opaque := func(x, y int) bool {
+		return GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor)
+	}
+
+	var cast func(row int, start, end float64, t [4]int)
+	cast = func(row int, start, end float64, t [4]int) {
+		if start < end {
+			return
+		}
+		for j := row; j <= radius; j++ {
+			blocked := false
+			newStart := start
+			for dx, dy := -j, -j; dx <= 0; dx++ {
+				x := origin.X + dx*t[0] + dy*t[1]
+				y := origin.Y + dx*t[2] + dy*t[3]
+				left := (float64(dx) - 0.5) / (float64(dy) + 0.5)
+				right := (float64(dx) + 0.5) / (float64(dy) - 0.5)
+				if start < right {
+					continue
+				}
+				if end > left {
+					break
+				}
+
+				if dx*dx+dy*dy <= radius*radius && x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+					visible[y*tm.Width+x] = true
+				}
+				switch {
+				case blocked && opaque(x, y):
+					newStart = right
+				case blocked:
+					blocked = false
+					start = newStart
+				case opaque(x, y) && j < radius:
+					blocked = true
+					cast(j+1, start, left, t)
+					newStart = right
+				}
+			}
+			if blocked {
+				return
+			}
+		}
+	}
+
+	for _, t := range fovOctants {
+		cast(1, 1.0, 0.0, t)
+	}
+}
+
+// VisibleFromPaths returns the tiles visible within radius from any floor
+// tile along the given polylines, such as corridor paths. The result is
+// indexed in row-major order.
+func VisibleFromPaths(tm *TileMap, paths []Path, radius int) []bool {
+	visible := make([]bool, tm.Width*tm.Height)
+	var floor []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+
+	walked := make(map[Point]bool)
+	for _, path := range paths {
+		for i := 0; i < len(path.Points)-1; i++ {
+			p1, p2 := path.Points[i], path.Points[i+1]
+			walkLine(p1.X, p1.Y, p2.X, p2.Y, func(x, y int) {
+				p := Point{X: x, Y: y}
+				if walked[p] || GetTile(floor, x, y, tm.Width, tm.Height) != uint32(TileFloor) {
+					return
+				}
+				walked[p] = true
+				markFieldOfView(tm, p, radius, visible)
+			})
+		}
+	}
+	return visible
+}
+
+// RoomEntrances returns the floor tiles in the ring just outside bounds,
+// where corridors break through the walls around a room, in row-major order.
+func RoomEntrances(tm *TileMap, bounds Rect) []Point {
+	var floor []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+
+	var entrances []Point
+	for y := bounds.Y - 1; y <= bounds.Y+bounds.Height; y++ {
+		for x := bounds.X - 1; x <= bounds.X+bounds.Width; x++ {
+			inside := x >= bounds.X && x < bounds.X+bounds.Width && y >= bounds.Y && y < bounds.Y+bounds.Height
+			if !inside && GetTile(floor, x, y, tm.Width, tm.Height) == uint32(TileFloor) {
+				entrances = append(entrances, Point{X: x, Y: y})
+			}
+		}
+	}
+	return entrances
+}
+
+// HiddenFromEntrances returns the tiles within bounds that cannot be seen
+// by someone approaching the room: from any corridor tile one step behind
+// one of its entrances. Tiles beside an entrance, along the walls it
+// breaks through, are typically hidden. Returns nil when the room has no
+// entrances. The result is indexed in row-major order.
+func HiddenFromEntrances(tm *TileMap, bounds Rect) []bool {
+	entrances := RoomEntrances(tm, bounds)
+	if len(entrances) == 0 {
+		return nil
+	}
+
+	var floor []uint32
+	if layer, ok := tm.Layers["floor"]; ok {
+		floor = layer.Data
+	}
+	inRing := func(p Point) bool {
+		return p.X >= bounds.X-1 && p.X <= bounds.X+bounds.Width && p.Y >= bounds.Y-1 && p.Y <= bounds.Y+bounds.Height
+	}
+	radius := bounds.Width + bounds.Height + 2
+
+	seen := make([]bool, tm.Width*tm.Height)
+	for _, e := range entrances {
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			approach := Point{X: e.X + d[0], Y: e.Y + d[1]}
+			if inRing(approach) || GetTile(floor, approach.X, approach.Y, tm.Width, tm.Height) != uint32(TileFloor) {
+				continue
+			}
+			markFieldOfView(tm, approach, radius, seen)
+		}
+	}
+
+	hidden := make([]bool, tm.Width*tm.Height)
+	for y := max(0, bounds.Y); y < min(tm.Height, bounds.Y+bounds.Height); y++ {
+		for x := max(0, bounds.X); x < min(tm.Width, bounds.X+bounds.Width); x++ {
+			hidden[y*tm.Width+x] = !seen[y*tm.Width+x]
+		}
+	}
+	return hidden
+}
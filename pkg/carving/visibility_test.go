@@ -0,0 +1,93 @@
+package carving
+
+import "testing"
+
+// visibilityMap returns a map with a 9x9 room at (5,5) and a one-tile
+// corridor entering it from the west along row 9.
+func visibilityMap() (*TileMap, Rect) {
+	tm := NewTileMap(20, 20, 16, 16)
+	floor := AddLayer(tm, "floor", "tilelayer")
+	room := Rect{X: 5, Y: 5, Width: 9, Height: 9}
+	_ = FillRect(floor.Data, room.X, room.Y, room.Width, room.Height, tm.Width, tm.Height, uint32(TileFloor))
+	_ = FillRect(floor.Data, 0, 9, 5, 1, tm.Width, tm.Height, uint32(TileFloor))
+	return tm, room
+}
+
+// TestFieldOfView verifies walls block sight and are seen themselves.
+func TestFieldOfView(t *testing.T) {
+	tm, _ := visibilityMap()
+	at := func(visible []bool, x, y int) bool { return visible[y*tm.Width+x] }
+
+	inside := FieldOfView(tm, Point{X: 9, Y: 9}, 10)
+	for y := 5; y < 14; y++ {
+		for x := 5; x < 14; x++ {
+			if !at(inside, x, y) {
+				t.Errorf("room tile (%d,%d) hidden from the room center", x, y)
+			}
+		}
+	}
+	if !at(inside, 4, 5) {
+		t.Error("wall beside the room is not visible from the center")
+	}
+	if at(inside, 2, 5) {
+		t.Error("tile behind the wall is visible from the center")
+	}
+
+	near := FieldOfView(tm, Point{X: 9, Y: 9}, 2)
+	if at(near, 13, 9) {
+		t.Error("tile beyond the radius is visible")
+	}
+}
+
+// TestHiddenFromEntrances verifies the corners beside an entrance are hidden
+// from the corridor while the far side of the room is in sight.
+func TestHiddenFromEntrances(t *testing.T) {
+	tm, room := visibilityMap()
+
+	if got := RoomEntrances(tm, room); len(got) != 1 || got[0] != (Point{X: 4, Y: 9}) {
+		t.Fatalf("RoomEntrances() = %v, want [(4,9)]", got)
+	}
+
+	hidden := HiddenFromEntrances(tm, room)
+	if hidden == nil {
+		t.Fatal("HiddenFromEntrances() = nil for a room with an entrance")
+	}
+	at := func(x, y int) bool { return hidden[y*tm.Width+x] }
+	for _, p := range []Point{{X: 5, Y: 5}, {X: 5, Y: 13}} {
+		if !at(p.X, p.Y) {
+			t.Errorf("corner %v beside the entrance is visible", p)
+		}
+	}
+	for _, p := range []Point{{X: 5, Y: 9}, {X: 13, Y: 9}} {
+		if at(p.X, p.Y) {
+			t.Errorf("tile %v facing the entrance is hidden", p)
+		}
+	}
+	if at(2, 9) {
+		t.Error("corridor tile outside the room is marked hidden")
+	}
+
+	sealed := NewTileMap(20, 20, 16, 16)
+	_ = FillRect(AddLayer(sealed, "floor", "tilelayer").Data, 5, 5, 9, 9, 20, 20, uint32(TileFloor))
+	if HiddenFromEntrances(sealed, room) != nil {
+		t.Error("HiddenFromEntrances() is not nil for a room without entrances")
+	}
+}
+
+// TestVisibleFromPaths verifies everything in sight of a corridor path is
+// visible, and the room behind its wall is not.
+func TestVisibleFromPaths(t *testing.T) {
+	tm, _ := visibilityMap()
+	visible := VisibleFromPaths(tm, []Path{{Points: []Point{{X: 0, Y: 9}, {X: 3, Y: 9}}}}, 6)
+	at := func(x, y int) bool { return visible[y*tm.Width+x] }
+
+	if !at(0, 9) || !at(3, 9) {
+		t.Error("path tiles are not visible")
+	}
+	if !at(7, 9) {
+		t.Error("room tile straight ahead of the path is not visible")
+	}
+	if at(6, 5) {
+		t.Error("room tile behind the wall is visible from the path")
+	}
+}
@@ -0,0 +1,98 @@
+package content
+
+import "github.com/dshills/dungo/pkg/graph"
+
+// Capacity is the content budget of one room, derived from its floor area
+// and archetype. Placement never exceeds it, and it is exported with the
+// content so runtimes spawning extra entities can respect the same budget.
+type Capacity struct {
+	FloorTiles int `json:"floorTiles"` // Walkable floor tiles in the room
+	Enemies    int `json:"enemies"`    // Most enemies the room holds at once
+	Props      int `json:"props"`      // Most props (crates, furniture, decor) the room holds
+	LootSlots  int `json:"lootSlots"`  // Most loot pickups the room holds
+}
+
+// capacityDensity is the floor area, in tiles, each enemy, prop and loot
+// slot needs in a room of some archetype. A zero means none fit.
+type capacityDensity struct {
+	tilesPerEnemy int
+	tilesPerProp  int
+	tilesPerLoot  int
+}
+
+// defaultDensity applies to archetypes without an entry in
+// archetypeDensity.
+var defaultDensity = capacityDensity{tilesPerEnemy: 4, tilesPerProp: 6, tilesPerLoot: 12}
+
+// archetypeDensity adjusts the default densities: corridors stay clear to
+// walk through, boss arenas pack in more fighters, and treasure rooms more
+// loot.
+var archetypeDensity = map[graph.RoomArchetype]capacityDensity{
+	graph.ArchetypeCorridor: {tilesPerEnemy: 6, tilesPerProp: 12, tilesPerLoot: 24},
+	graph.ArchetypeBoss:     {tilesPerEnemy: 3, tilesPerProp: 8, tilesPerLoot: 12},
+	graph.ArchetypeTreasure: {tilesPerEnemy: 4, tilesPerProp: 6, tilesPerLoot: 6},
+}
+
+// NominalFloorTiles returns the floor area of a room size's stamped
+// footprint, used when the carved area of a room is not known.
+func NominalFloorTiles(size graph.RoomSize) int {
+	switch size {
+	case graph.SizeXS:
+		return 9
+	case graph.SizeS:
+		return 25
+	case graph.SizeM:
+		return 49
+	case graph.SizeL:
+		return 100
+	case graph.SizeXL:
+		return 225
+	default:
+		return 25
+	}
+}
+
+// RoomCapacity computes the capacity of a room with the given floor area.
+// Enemies are capped at maxEnemiesPerRoom and are zero in safe rooms (see
+// shouldSkipEnemyPlacement). Any room with floor holds at least one enemy,
+// unless safe, and one loot slot.
+func RoomCapacity(room *graph.Room, floorTiles, maxEnemiesPerRoom int) Capacity {
+	density, ok := archetypeDensity[room.Archetype]
+	if !ok {
+		density = defaultDensity
+	}
+
+	c := Capacity{FloorTiles: max(0, floorTiles)}
+	if c.FloorTiles == 0 {
+		return c
+	}
+	if !shouldSkipEnemyPlacement(room) {
+		c.Enemies = max(1, min(maxEnemiesPerRoom, c.FloorTiles/density.tilesPerEnemy))
+	}
+	c.Props = c.FloorTiles / density.tilesPerProp
+	c.LootSlots = max(1, c.FloorTiles/density.tilesPerLoot)
+	return c
+}
+
+// roomCapacities computes the capacity of every room in g. Rooms missing
+// from floorTiles use their nominal area.
+func roomCapacities(g *graph.Graph, floorTiles map[string]int, maxEnemiesPerRoom int) map[string]Capacity {
+	capacities := make(map[string]Capacity, len(g.Rooms))
+	for id, room := range g.Rooms {
+		area, ok := floorTiles[id]
+		if !ok {
+			area = NominalFloorTiles(room.Size)
+		}
+		capacities[id] = RoomCapacity(room, area, maxEnemiesPerRoom)
+	}
+	return capacities
+}
+
+// enemyLimit returns the most enemies a room may hold: its capacity when
+// known, otherwise maxEnemiesPerRoom.
+func enemyLimit(capacities map[string]Capacity, roomID string, maxEnemiesPerRoom int) int {
+	if c, ok := capacities[roomID]; ok {
+		return min(c.Enemies, maxEnemiesPerRoom)
+	}
+	return maxEnemiesPerRoom
+}
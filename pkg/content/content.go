@@ -38,6 +38,12 @@ type Content struct {
 	Loot    []Loot           `json:"loot"`    // Item pickups
 	Puzzles []PuzzleInstance `json:"puzzles"` // Puzzle encounters
 	Secrets []SecretInstance `json:"secrets"` // Hidden discoveries
+	Traps   []Trap           `json:"traps"`   // Hazards
+
+	PlayerStarts []PlayerStart `json:"playerStarts,omitempty"` // Co-op player entry points
+	Waves        []Wave        `json:"waves,omitempty"`        // Horde-mode spawn schedule, in wave order
+
+	Capacities map[string]Capacity `json:"capacities,omitempty"` // Room ID → content budget
 }
 
 // NewContent creates an empty Content container.
@@ -47,6 +53,7 @@ func NewContent() *Content {
 		Loot:    make([]Loot, 0),
 		Puzzles: make([]PuzzleInstance, 0),
 		Secrets: make([]SecretInstance, 0),
+		Traps:   make([]Trap, 0),
 	}
 }
 
@@ -80,21 +87,57 @@ func (c *Content) Validate(g *graph.Graph) error {
 		}
 	}
 
+	// Check that all traps reference valid rooms
+	for _, trap := range c.Traps {
+		if _, exists := g.Rooms[trap.RoomID]; !exists {
+			return fmt.Errorf("trap %s references non-existent room %s", trap.ID, trap.RoomID)
+		}
+	}
+
+	// Check that all player starts reference valid rooms
+	for _, start := range c.PlayerStarts {
+		if _, exists := g.Rooms[start.RoomID]; !exists {
+			return fmt.Errorf("player start %s references non-existent room %s", start.ID, start.RoomID)
+		}
+	}
+
+	// Check that all wave spawns reference valid rooms
+	for _, wave := range c.Waves {
+		for _, spawn := range wave.Spawns {
+			if _, exists := g.Rooms[spawn.RoomID]; !exists {
+				return fmt.Errorf("wave %d spawn %s references non-existent room %s", wave.Index, spawn.ID, spawn.RoomID)
+			}
+		}
+	}
+
 	return nil
 }
 
 // String returns a human-readable summary of content.
 func (c *Content) String() string {
-	return fmt.Sprintf("Content[Spawns=%d, Loot=%d, Puzzles=%d, Secrets=%d]",
-		len(c.Spawns), len(c.Loot), len(c.Puzzles), len(c.Secrets))
+	return fmt.Sprintf("Content[Spawns=%d, Loot=%d, Puzzles=%d, Secrets=%d, Traps=%d]",
+		len(c.Spawns), len(c.Loot), len(c.Puzzles), len(c.Secrets), len(c.Traps))
 }
 
 // DefaultContentPass is the standard implementation of ContentPass.
-// It places content in a balanced way based on room properties.
+// It places content in a balanced way based on room properties, as a
+// pipeline of sub-passes - keys, loot, factions, enemies, ambushes, puzzles, traps, party
+// scaling and waves by default - run in order over a shared PassContext.
+// Sub-passes can be reordered, replaced, or added with WithOrder and
+// WithSubPass.
 type DefaultContentPass struct {
-	maxEnemiesPerRoom int  // Capacity limit for enemies
-	lootBudgetBase    int  // Base treasure value
-	keyPlacementFirst bool // Whether to place keys before general loot
+	maxEnemiesPerRoom int                   // Capacity limit for enemies
+	lootBudgetBase    int                   // Base treasure value
+	trapDensity       float64               // Chance (0.0-1.0) that an eligible room holds traps
+	ambushRatio       float64               // Chance (0.0-1.0) that a spawn lies in wait
+	partySize         int                   // Co-op players; scaling applies above 1
+	waveSchedule      bool                  // Whether to build horde-mode wave schedules
+	enemyFactions     bool                  // Whether to keep regions to one enemy faction
+	skirmishRatio     float64               // Chance (0.0-1.0) that a room on a faction border mixes both factions
+	floorTiles        map[string]int        // Carved floor area per room; nominal when missing
+	extents           map[string]RoomExtent // Carved floor bounds per room; nominal when missing
+	order             []string              // Sub-pass names in run order
+	subPasses         map[string]SubPass    // User sub-passes, shadowing built-ins
 }
 
 // NewDefaultContentPass creates a DefaultContentPass with default settings.
@@ -102,70 +145,78 @@ func NewDefaultContentPass() *DefaultContentPass {
 	return &DefaultContentPass{
 		maxEnemiesPerRoom: 10,
 		lootBudgetBase:    1000,
-		keyPlacementFirst: true,
+		order:             append([]string(nil), DefaultOrder...),
 	}
 }
 
-// Place implements ContentPass by orchestrating all content placement.
+// Place implements ContentPass by running the sub-passes in order.
 func (d *DefaultContentPass) Place(ctx context.Context, g *graph.Graph, rng *rng.RNG) (*Content, error) {
-	content := NewContent()
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	pc := &PassContext{
+		Graph:             g,
+		Content:           NewContent(),
+		RNG:               rng,
+		MaxEnemiesPerRoom: d.maxEnemiesPerRoom,
+		LootBudget:        d.lootBudgetBase,
+		TrapDensity:       d.trapDensity,
+		AmbushRatio:       d.ambushRatio,
+		PartySize:         d.partySize,
+		WaveSchedule:      d.waveSchedule,
+		EnemyFactions:     d.enemyFactions,
+		SkirmishRatio:     d.skirmishRatio,
+		Capacities:        roomCapacities(g, d.floorTiles, d.maxEnemiesPerRoom),
+		Extents:           d.extents,
 	}
+	pc.Content.Capacities = pc.Capacities
 
-	// Step 1: Find key-locked connectors and place keys before locks
-	if d.keyPlacementFirst {
-		if err := placeRequiredKeys(g, content, rng); err != nil {
-			return nil, fmt.Errorf("placing required keys: %w", err)
-		}
+	passes := make(map[string]SubPass, len(builtinSubPasses)+len(d.subPasses))
+	for name, pass := range builtinSubPasses {
+		passes[name] = pass
 	}
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	for name, pass := range d.subPasses {
+		passes[name] = pass
 	}
-
-	// Step 2: Distribute treasure based on room.Reward values
-	if err := distributeLoot(g, content, d.lootBudgetBase, rng); err != nil {
-		return nil, fmt.Errorf("distributing loot: %w", err)
+	if err := runSubPasses(ctx, pc, d.order, passes); err != nil {
+		return nil, err
 	}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	// Validate the result
+	if err := pc.Content.Validate(g); err != nil {
+		return nil, fmt.Errorf("content validation failed: %w", err)
 	}
 
-	// Step 3: Spawn enemies based on room.Difficulty values
-	if err := spawnEnemies(g, content, d.maxEnemiesPerRoom, rng); err != nil {
-		return nil, fmt.Errorf("spawning enemies: %w", err)
-	}
+	return pc.Content, nil
+}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// WithSubPass registers a sub-pass under name. A built-in sub-pass of the
+// same name is replaced in place; a new name is appended to the order.
+func (d *DefaultContentPass) WithSubPass(name string, pass SubPass) *DefaultContentPass {
+	subPasses := make(map[string]SubPass, len(d.subPasses)+1)
+	for n, p := range d.subPasses {
+		subPasses[n] = p
 	}
+	subPasses[name] = pass
+	d.subPasses = subPasses
 
-	// Step 4: Place puzzles in puzzle rooms
-	if err := placePuzzles(g, content, rng); err != nil {
-		return nil, fmt.Errorf("placing puzzles: %w", err)
+	for _, n := range d.order {
+		if n == name {
+			return d
+		}
 	}
+	d.order = append(append([]string(nil), d.order...), name)
+	return d
+}
 
-	// Validate the result
-	if err := content.Validate(g); err != nil {
-		return nil, fmt.Errorf("content validation failed: %w", err)
-	}
+// WithOrder sets the sub-passes to run, by name and in order. Names must be
+// built-in or registered with WithSubPass by the time Place runs; sub-passes
+// left out do not run.
+func (d *DefaultContentPass) WithOrder(names ...string) *DefaultContentPass {
+	d.order = append([]string(nil), names...)
+	return d
+}
 
-	return content, nil
+// Order returns the names of the sub-passes in run order.
+func (d *DefaultContentPass) Order() []string {
+	return append([]string(nil), d.order...)
 }
 
 // WithMaxEnemiesPerRoom sets the capacity limit for enemies in a room.
@@ -179,3 +230,64 @@ func (d *DefaultContentPass) WithLootBudget(budget int) *DefaultContentPass {
 	d.lootBudgetBase = budget
 	return d
 }
+
+// WithTrapDensity sets the chance (0.0-1.0) that an eligible room holds traps.
+// The default of 0 places no traps.
+func (d *DefaultContentPass) WithTrapDensity(density float64) *DefaultContentPass {
+	d.trapDensity = density
+	return d
+}
+
+// WithAmbushRatio sets the chance (0.0-1.0) that a spawn lies in wait out of
+// sight of its room's entrances. Spawns in rooms tagged "ambush" always do.
+// The default of 0 leaves other spawns in the open.
+func (d *DefaultContentPass) WithAmbushRatio(ratio float64) *DefaultContentPass {
+	d.ambushRatio = ratio
+	return d
+}
+
+// WithPartySize sets the number of co-op players. Sizes above 1 scale
+// encounters and loot and place one start point per player next to the Start
+// room. The default of 0 is single player.
+func (d *DefaultContentPass) WithPartySize(size int) *DefaultContentPass {
+	d.partySize = size
+	return d
+}
+
+// WithWaveSchedule enables horde-mode wave schedules. Rooms tagged "spawner"
+// with a "wave" index release growing enemy groups from that wave onwards.
+func (d *DefaultContentPass) WithWaveSchedule(enabled bool) *DefaultContentPass {
+	d.waveSchedule = enabled
+	return d
+}
+
+// WithFactions keeps each region of the dungeon to one enemy faction,
+// drawn from its theme's factions (see themes.FactionProvider) or the
+// default ones, instead of selecting every spawn's enemies independently.
+// A combat room bordering another faction's region becomes a border
+// skirmish, mixing both factions, with probability skirmishRatio (0.0-1.0).
+func (d *DefaultContentPass) WithFactions(skirmishRatio float64) *DefaultContentPass {
+	d.enemyFactions = true
+	d.skirmishRatio = skirmishRatio
+	return d
+}
+
+// WithFloorTiles sets the carved floor area of each room, from which room
+// capacities are derived. Rooms left out use the nominal area of their size.
+func (d *DefaultContentPass) WithFloorTiles(floorTiles map[string]int) *DefaultContentPass {
+	d.floorTiles = floorTiles
+	return d
+}
+
+// WithRoomExtents sets the size of each room's carved floor bounds, to
+// which puzzle mechanisms are fitted. Rooms left out use the nominal
+// bounds of their size.
+func (d *DefaultContentPass) WithRoomExtents(extents map[string]RoomExtent) *DefaultContentPass {
+	d.extents = extents
+	return d
+}
+
+// MaxEnemiesPerRoom returns the capacity limit for enemies in a room.
+func (d *DefaultContentPass) MaxEnemiesPerRoom() int {
+	return d.maxEnemiesPerRoom
+}
@@ -2,10 +2,12 @@ package content
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/dshills/dungo/pkg/graph"
 	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/themes"
 )
 
 // TestDefaultContentPass_Place tests the complete content placement pipeline.
@@ -510,6 +512,113 @@ func TestSelectEnemyType(t *testing.T) {
 	}
 }
 
+// TestSelectEnemyType_RegisteredTheme tests rooms draw enemies from the
+// encounter table of their registered biome theme.
+func TestSelectEnemyType_RegisteredTheme(t *testing.T) {
+	swamp := &themes.Definition{
+		Name:       "swamp_content_test",
+		Tilesets:   []themes.Tileset{{Name: "floor", Path: "swamp.png", TileWidth: 16, TileHeight: 16}},
+		Encounters: []themes.EncounterTable{{Difficulty: 0.5, Entries: []themes.WeightedEntry{{Type: "bog_witch", Weight: 1}}}},
+		Colors:     themes.PaletteOf(themes.DefaultTheme),
+	}
+	if err := themes.RegisterDefinition(swamp); err != nil {
+		t.Fatalf("RegisterDefinition() error = %v", err)
+	}
+
+	r := rng.NewRNG(42, "test", []byte("test"))
+	room := &graph.Room{ID: "r", Difficulty: 0.7, Tags: map[string]string{"biome": "swamp_content_test"}}
+	if enemy := selectEnemyTypeWithTheme(room, r, nil); enemy != "bog_witch" {
+		t.Errorf("enemy = %q, want bog_witch from the theme", enemy)
+	}
+
+	// Built-in themes keep the default table
+	room.Tags["biome"] = "crypt"
+	if enemy := selectEnemyTypeWithTheme(room, r, nil); enemy == "" || enemy == "bog_witch" {
+		t.Errorf("crypt enemy = %q, want one from the default table", enemy)
+	}
+}
+
+// TestFactions verifies each region fights as its theme's faction, that
+// border rooms mix both factions in skirmishes, and that spawns carry no
+// faction unless factions are enabled.
+func TestFactions(t *testing.T) {
+	for name, faction := range map[string]themes.Faction{
+		"swarm_faction_test": {Name: "swarm", Enemies: []string{"rat", "spider"}},
+		"horde_faction_test": {Name: "horde", Enemies: []string{"orc", "troll"}},
+	} {
+		theme := &themes.Definition{
+			Name:     name,
+			Tilesets: []themes.Tileset{{Name: "floor", Path: "floor.png", TileWidth: 16, TileHeight: 16}},
+			Colors:   themes.PaletteOf(themes.DefaultTheme),
+			Factions: []themes.Faction{faction},
+		}
+		if err := themes.RegisterDefinition(theme); err != nil {
+			t.Fatalf("RegisterDefinition() error = %v", err)
+		}
+	}
+
+	// Two triangles of rooms joined by a chokepoint, one per theme:
+	// a1-a2-a3 - b1-b2-b3
+	g := graph.NewGraph(12345)
+	for _, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3"} {
+		biome := "swarm_faction_test"
+		if id[0] == 'b' {
+			biome = "horde_faction_test"
+		}
+		_ = g.AddRoom(&graph.Room{ID: id, Archetype: graph.ArchetypeOptional, Size: graph.SizeM,
+			Difficulty: 0.5, Tags: map[string]string{"biome": biome}})
+	}
+	for i, pair := range [][2]string{{"a1", "a2"}, {"a2", "a3"}, {"a3", "a1"}, {"a3", "b1"}, {"b1", "b2"}, {"b2", "b3"}, {"b3", "b1"}} {
+		_ = g.AddConnector(&graph.Connector{ID: fmt.Sprintf("c%d", i), From: pair[0], To: pair[1],
+			Type: graph.TypeCorridor, Cost: 1, Visibility: graph.VisibilityNormal, Bidirectional: true})
+	}
+
+	place := func(pass *DefaultContentPass) *Content {
+		pass.WithOrder(SubPassFactions, SubPassEnemies)
+		content, err := pass.Place(context.Background(), g, rng.NewRNG(12345, "faction_test", []byte("test")))
+		if err != nil {
+			t.Fatalf("Place() failed: %v", err)
+		}
+		return content
+	}
+	members := map[string]map[string]bool{
+		"swarm": {"rat": true, "spider": true},
+		"horde": {"orc": true, "troll": true},
+	}
+
+	for _, spawn := range place(NewDefaultContentPass().WithFactions(0)).Spawns {
+		want := "swarm"
+		if spawn.RoomID[0] == 'b' {
+			want = "horde"
+		}
+		if spawn.Faction != want || !members[want][spawn.EnemyType] {
+			t.Errorf("%s: %s of faction %q, want a %s enemy", spawn.RoomID, spawn.EnemyType, spawn.Faction, want)
+		}
+	}
+
+	factions := make(map[string]map[string]bool)
+	for _, spawn := range place(NewDefaultContentPass().WithFactions(1.0)).Spawns {
+		if factions[spawn.RoomID] == nil {
+			factions[spawn.RoomID] = make(map[string]bool)
+		}
+		factions[spawn.RoomID][spawn.Faction] = true
+		if !members[spawn.Faction][spawn.EnemyType] {
+			t.Errorf("%s: %s is not of faction %q", spawn.RoomID, spawn.EnemyType, spawn.Faction)
+		}
+	}
+	for id, f := range factions {
+		if border := id == "a3" || id == "b1"; border != (len(f) == 2) {
+			t.Errorf("%s: factions %v, want two only on the border", id, f)
+		}
+	}
+
+	for _, spawn := range place(NewDefaultContentPass()).Spawns {
+		if spawn.Faction != "" {
+			t.Errorf("%s: faction %q without factions enabled", spawn.ID, spawn.Faction)
+		}
+	}
+}
+
 // TestSelectLootType tests loot type selection logic.
 func TestSelectLootType(t *testing.T) {
 	r := rng.NewRNG(42, "test", []byte("test"))
@@ -533,6 +642,61 @@ func TestSelectLootType(t *testing.T) {
 	}
 }
 
+// TestSubPassPipeline tests sub-passes can be added, replaced and reordered.
+func TestSubPassPipeline(t *testing.T) {
+	setupGraph := func() *graph.Graph {
+		g := graph.NewGraph(1)
+		_ = g.AddRoom(&graph.Room{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM})
+		_ = g.AddRoom(&graph.Room{ID: "room1", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5, Reward: 0.6})
+		_ = g.AddRoom(&graph.Room{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 1.0, Reward: 1.0})
+		_ = g.AddConnector(&graph.Connector{ID: "c1", From: "start", To: "room1", Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true})
+		_ = g.AddConnector(&graph.Connector{ID: "c2", From: "room1", To: "boss", Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true})
+		return g
+	}
+	place := func(pass *DefaultContentPass) (*Content, error) {
+		return pass.Place(context.Background(), setupGraph(), rng.NewRNG(7, "content", []byte("test")))
+	}
+
+	// A user sub-pass runs last and sees the content placed before it
+	var seen int
+	props := func(ctx context.Context, pc *PassContext) error {
+		seen = len(pc.Content.Spawns)
+		pc.Content.Secrets = append(pc.Content.Secrets, SecretInstance{ID: "prop_cache", RoomID: "room1", Type: "cache"})
+		return nil
+	}
+	pass := NewDefaultContentPass().WithSubPass("props", props)
+	if order := pass.Order(); order[len(order)-1] != "props" || len(order) != len(DefaultOrder)+1 {
+		t.Fatalf("Order() = %v, want props appended", order)
+	}
+	c, err := place(pass)
+	if err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+	if seen != len(c.Spawns) || seen == 0 || len(c.Secrets) != 1 {
+		t.Errorf("props saw %d spawns of %d, secrets = %v", seen, len(c.Spawns), c.Secrets)
+	}
+
+	// Replacing a built-in keeps its place; dropping one skips it
+	c, err = place(NewDefaultContentPass().
+		WithSubPass(SubPassEnemies, func(ctx context.Context, pc *PassContext) error { return nil }).
+		WithOrder(SubPassEnemies, SubPassKeys))
+	if err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+	if len(c.Spawns) != 0 || len(c.Loot) != 0 {
+		t.Errorf("replaced and dropped sub-passes placed content: %v", c)
+	}
+
+	// The default pipeline is unchanged by registrations on other passes
+	if order := NewDefaultContentPass().Order(); len(order) != len(DefaultOrder) {
+		t.Errorf("default Order() = %v", order)
+	}
+
+	if _, err := place(NewDefaultContentPass().WithOrder("missing")); err == nil {
+		t.Error("expected an error for an unknown sub-pass")
+	}
+}
+
 // TestDeterminism verifies that content placement is deterministic.
 func TestDeterminism(t *testing.T) {
 	setupGraph := func() *graph.Graph {
@@ -646,3 +810,508 @@ func TestCapacityLimits(t *testing.T) {
 		}
 	}
 }
+
+// TestRoomCapacity verifies capacities follow floor area and archetype, and
+// that placement keeps enemies and loot within them.
+func TestRoomCapacity(t *testing.T) {
+	hall := &graph.Room{ID: "hall", Archetype: graph.ArchetypeOptional, Size: graph.SizeXL}
+	if got := RoomCapacity(hall, 20, 10); got.Enemies != 5 || got.Props != 3 || got.LootSlots != 1 {
+		t.Errorf("RoomCapacity(hall, 20) = %+v, want 5 enemies, 3 props, 1 loot slot", got)
+	}
+	if got := RoomCapacity(hall, 200, 10); got.Enemies != 10 {
+		t.Errorf("RoomCapacity(hall, 200).Enemies = %d, want the per-room limit 10", got.Enemies)
+	}
+	shrine := &graph.Room{ID: "shrine", Archetype: graph.ArchetypeShrine, Size: graph.SizeM}
+	if got := RoomCapacity(shrine, 49, 10); got.Enemies != 0 {
+		t.Errorf("RoomCapacity(shrine).Enemies = %d, want 0 in a safe room", got.Enemies)
+	}
+	if got := RoomCapacity(hall, 0, 10); got != (Capacity{}) {
+		t.Errorf("RoomCapacity(hall, 0) = %+v, want no capacity", got)
+	}
+
+	g := graph.NewGraph(12345)
+	_ = g.AddRoom(&graph.Room{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM})
+	_ = g.AddRoom(&graph.Room{ID: "hard", Archetype: graph.ArchetypeOptional, Size: graph.SizeXL, Difficulty: 1.0, Reward: 1.0})
+	_ = g.AddConnector(&graph.Connector{
+		ID: "c1", From: "start", To: "hard",
+		Type: graph.TypeDoor, Cost: 1.0,
+		Bidirectional: true, Visibility: graph.VisibilityNormal,
+	})
+
+	// The XL room was carved with a cramped floor
+	r := rng.NewRNG(12345, "capacity_test", []byte("test"))
+	content, err := NewDefaultContentPass().WithPartySize(4).WithFloorTiles(map[string]int{"hard": 14}).
+		Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+
+	hard := content.Capacities["hard"]
+	if hard.FloorTiles != 14 || hard.Enemies != 3 || hard.LootSlots != 1 {
+		t.Errorf("capacity of hard = %+v, want 14 floor tiles, 3 enemies, 1 loot slot", hard)
+	}
+	if got := content.Capacities["start"].FloorTiles; got != NominalFloorTiles(graph.SizeM) {
+		t.Errorf("start floor tiles = %d, want the nominal %d", got, NominalFloorTiles(graph.SizeM))
+	}
+
+	enemies, loot := map[string]int{}, map[string]int{}
+	for _, spawn := range content.Spawns {
+		enemies[spawn.RoomID] += spawn.Count
+	}
+	for _, item := range content.Loot {
+		if !item.Required {
+			loot[item.RoomID]++
+		}
+	}
+	for roomID, capacity := range content.Capacities {
+		if enemies[roomID] > capacity.Enemies {
+			t.Errorf("room %s holds %d enemies, capacity %d", roomID, enemies[roomID], capacity.Enemies)
+		}
+		if loot[roomID] > capacity.LootSlots {
+			t.Errorf("room %s holds %d loot, capacity %d", roomID, loot[roomID], capacity.LootSlots)
+		}
+	}
+	if enemies["hard"] == 0 {
+		t.Error("no enemies placed in hard")
+	}
+}
+
+// TestAmbushRatio verifies spawns are marked as ambushes by room tag or by
+// the configured ratio, and that the default places none.
+func TestAmbushRatio(t *testing.T) {
+	g := graph.NewGraph(12345)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "hall", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5},
+		{ID: "lair", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5, Tags: map[string]string{"ambush": "true"}},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	place := func(ratio float64) map[string]bool {
+		r := rng.NewRNG(12345, "ambush_test", []byte("test"))
+		content, err := NewDefaultContentPass().WithAmbushRatio(ratio).Place(context.Background(), g, r)
+		if err != nil {
+			t.Fatalf("Place() failed: %v", err)
+		}
+		ambushes := make(map[string]bool)
+		for _, spawn := range content.Spawns {
+			ambushes[spawn.RoomID] = ambushes[spawn.RoomID] || spawn.Ambush
+		}
+		return ambushes
+	}
+
+	if got := place(0); got["hall"] || !got["lair"] {
+		t.Errorf("ambushes at ratio 0 = %v, want only the tagged lair", got)
+	}
+	if got := place(1.0); !got["hall"] || !got["lair"] {
+		t.Errorf("ambushes at ratio 1 = %v, want hall and lair", got)
+	}
+}
+
+// TestJunctionsHaveNoSpawns verifies the corridor rooms carving adds where
+// corridors meet get no enemies, which would lengthen the combat runs
+// between the checkpoints synthesis placed.
+func TestJunctionsHaveNoSpawns(t *testing.T) {
+	g := graph.NewGraph(12345)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "hall", Archetype: graph.ArchetypeCorridor, Size: graph.SizeM, Difficulty: 0.5},
+		{ID: "junction_1", Archetype: graph.ArchetypeCorridor, Size: graph.SizeXS, Difficulty: 0.5,
+			Tags: map[string]string{graph.JunctionTag: "true"}},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	r := rng.NewRNG(12345, "junction_test", []byte("test"))
+	content, err := NewDefaultContentPass().Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+	spawned := make(map[string]bool)
+	for _, spawn := range content.Spawns {
+		spawned[spawn.RoomID] = true
+	}
+	if !spawned["hall"] || spawned["junction_1"] {
+		t.Errorf("rooms with spawns = %v, want hall but not junction_1", spawned)
+	}
+}
+
+// TestExitRewardChest verifies an Exit room gets a single reward chest and
+// no enemies or traps.
+func TestExitRewardChest(t *testing.T) {
+	g := graph.NewGraph(12345)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 1.0, Reward: 0.8},
+		{ID: "exit", Archetype: graph.ArchetypeExit, Size: graph.SizeM, Reward: 1.0},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	r := rng.NewRNG(12345, "exit_test", []byte("test"))
+	content, err := NewDefaultContentPass().WithTrapDensity(1.0).Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+	for _, spawn := range content.Spawns {
+		if spawn.RoomID == "exit" {
+			t.Errorf("enemy spawned in the exit room: %v", spawn)
+		}
+	}
+	for _, trap := range content.Traps {
+		if trap.RoomID == "exit" {
+			t.Errorf("trap placed in the exit room: %v", trap)
+		}
+	}
+	var chests []Loot
+	for _, loot := range content.Loot {
+		if loot.RoomID == "exit" {
+			chests = append(chests, loot)
+		}
+	}
+	if len(chests) != 1 || chests[0].ItemType != RewardChest || chests[0].Value <= 0 {
+		t.Errorf("exit loot = %v, want one %s", chests, RewardChest)
+	}
+}
+
+// TestVaultLoot verifies a vault's treasure chamber holds only gems and
+// artifacts worth at least VaultItemValue.
+func TestVaultLoot(t *testing.T) {
+	g := graph.NewGraph(12345)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 1.0, Reward: 0.8},
+		{ID: "vault_0", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS, Reward: 0.1,
+			Tags: map[string]string{graph.VaultTag: "vault_0", "biome": "dungeon"}},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	r := rng.NewRNG(12345, "vault_test", []byte("test"))
+	content, err := NewDefaultContentPass().Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+	found := 0
+	for _, loot := range content.Loot {
+		if loot.RoomID != "vault_0" {
+			continue
+		}
+		found++
+		if loot.ItemType != "gem" && loot.ItemType != "artifact" {
+			t.Errorf("vault loot type = %s, want gem or artifact", loot.ItemType)
+		}
+		if loot.Value < VaultItemValue {
+			t.Errorf("vault loot value = %d, want at least %d", loot.Value, VaultItemValue)
+		}
+	}
+	if found == 0 {
+		t.Error("vault chamber has no loot")
+	}
+}
+
+// TestTrapDensity verifies traps are placed only in combat rooms and only
+// when a trap density is configured.
+func TestTrapDensity(t *testing.T) {
+	g := graph.NewGraph(12345)
+
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Difficulty: 0.0},
+		{ID: "hall", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5},
+		{ID: "shrine", Archetype: graph.ArchetypeShrine, Size: graph.SizeM, Difficulty: 0.5},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 1.0},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	place := func(density float64) *Content {
+		r := rng.NewRNG(12345, "trap_test", []byte("test"))
+		content, err := NewDefaultContentPass().WithTrapDensity(density).Place(context.Background(), g, r)
+		if err != nil {
+			t.Fatalf("Place() failed: %v", err)
+		}
+		return content
+	}
+
+	if traps := place(0).Traps; len(traps) != 0 {
+		t.Errorf("expected no traps at density 0, got %d", len(traps))
+	}
+
+	content := place(1.0)
+	trapped := make(map[string]bool)
+	for _, trap := range content.Traps {
+		if err := trap.Validate(); err != nil {
+			t.Errorf("invalid trap: %v", err)
+		}
+		trapped[trap.RoomID] = true
+	}
+	if !trapped["hall"] || !trapped["boss"] {
+		t.Errorf("expected traps in hall and boss at density 1, got rooms %v", trapped)
+	}
+	if trapped["start"] || trapped["shrine"] {
+		t.Errorf("traps placed in safe rooms: %v", trapped)
+	}
+}
+
+// TestPartyScaling verifies co-op parties get more enemies, support spawns,
+// extra loot and one start point per player next to the Start room.
+func TestPartyScaling(t *testing.T) {
+	g := graph.NewGraph(12345)
+
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Difficulty: 0.0},
+		{ID: "hall", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5},
+		{ID: "treasure", Archetype: graph.ArchetypeTreasure, Size: graph.SizeM, Difficulty: 0.5, Reward: 0.8},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 1.0, Reward: 1.0},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+	for _, pair := range [][2]string{{"start", "hall"}, {"start", "treasure"}, {"hall", "boss"}} {
+		_ = g.AddConnector(&graph.Connector{
+			ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1],
+			Type: graph.TypeDoor, Cost: 1.0, Visibility: graph.VisibilityNormal, Bidirectional: true,
+		})
+	}
+
+	place := func(size int) *Content {
+		r := rng.NewRNG(12345, "party_test", []byte("test"))
+		content, err := NewDefaultContentPass().WithPartySize(size).Place(context.Background(), g, r)
+		if err != nil {
+			t.Fatalf("Place() failed: %v", err)
+		}
+		if err := content.Validate(g); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+		return content
+	}
+
+	solo := place(1)
+	party := place(4)
+
+	if len(solo.PlayerStarts) != 0 {
+		t.Errorf("expected no player starts for a solo player, got %d", len(solo.PlayerStarts))
+	}
+	if len(party.PlayerStarts) != 4 {
+		t.Fatalf("expected 4 player starts, got %d", len(party.PlayerStarts))
+	}
+	for i, start := range party.PlayerStarts {
+		if start.Player != i+1 {
+			t.Errorf("player start %d has player %d", i, start.Player)
+		}
+		if start.RoomID != "hall" && start.RoomID != "treasure" {
+			t.Errorf("player %d starts in %s, want a room adjacent to start", start.Player, start.RoomID)
+		}
+	}
+
+	countEnemies := func(c *Content) int {
+		total := 0
+		for _, spawn := range c.Spawns {
+			total += spawn.Count
+		}
+		return total
+	}
+	if countEnemies(party) <= countEnemies(solo) {
+		t.Errorf("expected more enemies for a party: solo %d, party %d", countEnemies(solo), countEnemies(party))
+	}
+	if len(party.Spawns) <= len(solo.Spawns) {
+		t.Errorf("expected support spawns for a party: solo %d, party %d", len(solo.Spawns), len(party.Spawns))
+	}
+	if len(party.Loot) <= len(solo.Loot) {
+		t.Errorf("expected more loot for a party: solo %d, party %d", len(solo.Loot), len(party.Loot))
+	}
+}
+
+// TestWaveSchedule verifies spawners join the schedule in their opening wave
+// and waves never shrink.
+func TestWaveSchedule(t *testing.T) {
+	g := graph.NewGraph(12345)
+
+	rooms := []*graph.Room{
+		{ID: "hub", Archetype: graph.ArchetypeStart, Size: graph.SizeL},
+		{ID: "ring", Archetype: graph.ArchetypeCorridor, Size: graph.SizeM, Difficulty: 0.3},
+		{ID: "spawner_1", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.5,
+			Tags: map[string]string{"spawner": "true", "wave": "1"}},
+		{ID: "spawner_2", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.7,
+			Tags: map[string]string{"spawner": "true", "wave": "2"}},
+		{ID: "spawner_3", Archetype: graph.ArchetypeBoss, Size: graph.SizeL, Difficulty: 1.0,
+			Tags: map[string]string{"spawner": "true", "wave": "3"}},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+	for _, pair := range [][2]string{{"hub", "ring"}, {"ring", "spawner_1"}, {"ring", "spawner_2"}, {"ring", "spawner_3"}} {
+		_ = g.AddConnector(&graph.Connector{
+			ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1],
+			Type: graph.TypeDoor, Cost: 1.0, Visibility: graph.VisibilityNormal, Bidirectional: true,
+		})
+	}
+
+	r := rng.NewRNG(12345, "wave_test", []byte("test"))
+	content, err := NewDefaultContentPass().WithWaveSchedule(true).Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+
+	if len(content.Waves) != 3 {
+		t.Fatalf("expected 3 waves, got %d", len(content.Waves))
+	}
+	previous := 0
+	for i, wave := range content.Waves {
+		if wave.Index != i+1 {
+			t.Errorf("wave at position %d has index %d", i+1, wave.Index)
+		}
+		if len(wave.Spawns) != i+1 {
+			t.Errorf("wave %d: expected %d active spawners, got %d", wave.Index, i+1, len(wave.Spawns))
+		}
+		total := 0
+		for _, spawn := range wave.Spawns {
+			total += spawn.Count
+		}
+		if total < previous {
+			t.Errorf("wave %d has %d enemies, fewer than %d before it", wave.Index, total, previous)
+		}
+		previous = total
+	}
+
+	// Without the option no schedule is built
+	r = rng.NewRNG(12345, "wave_test", []byte("test"))
+	plain, err := NewDefaultContentPass().Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+	if len(plain.Waves) != 0 {
+		t.Errorf("expected no waves without WithWaveSchedule, got %d", len(plain.Waves))
+	}
+}
+
+// TestEnvironmentBudget verifies rooms whose environment carries part of
+// their difficulty get proportionally fewer enemies.
+func TestEnvironmentBudget(t *testing.T) {
+	g := graph.NewGraph(12345)
+
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Difficulty: 0.0},
+		{ID: "hall", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.8},
+		{ID: "mire", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.8,
+			Tags: map[string]string{"environment": "0.400"}},
+		{ID: "dark", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Difficulty: 0.8,
+			Tags: map[string]string{"environment": "0.800"}},
+	}
+	for _, room := range rooms {
+		_ = g.AddRoom(room)
+	}
+
+	r := rng.NewRNG(12345, "environment_test", []byte("test"))
+	content, err := NewDefaultContentPass().Place(context.Background(), g, r)
+	if err != nil {
+		t.Fatalf("Place() failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, spawn := range content.Spawns {
+		counts[spawn.RoomID] += spawn.Count
+	}
+	if counts["hall"] != 8 || counts["mire"] != 4 {
+		t.Errorf("enemy counts = %v, want hall 8 and mire 4", counts)
+	}
+	if counts["dark"] != 0 {
+		t.Errorf("room fully carried by its environment has %d enemies", counts["dark"])
+	}
+}
+
+// TestPuzzleGenerators verifies the built-in generators build puzzles that
+// fit their grid and that their own Verify accepts, across difficulties and
+// room sizes, and that Verify rejects a tampered solution.
+func TestPuzzleGenerators(t *testing.T) {
+	for _, name := range []string{"lever", "pressure_plate", "light_beam"} {
+		gen, ok := LookupPuzzle(name)
+		if !ok {
+			t.Fatalf("no %s generator registered", name)
+		}
+		for seed := uint64(1); seed <= 40; seed++ {
+			r := rng.NewRNGFromSeed(seed, "puzzle")
+			difficulty := float64(seed%11) / 10
+			width, height := MinPuzzleGrid+int(seed%9), MinPuzzleGrid+int(seed%5)
+
+			spec, err := gen.Generate(difficulty, width, height, r)
+			if err != nil {
+				t.Fatalf("%s seed %d on %dx%d: %v", name, seed, width, height, err)
+			}
+			if spec.Width > width || spec.Height > height {
+				t.Errorf("%s seed %d: %dx%d grid exceeds %dx%d", name, seed, spec.Width, spec.Height, width, height)
+			}
+			cells := make(map[Point]bool)
+			for _, f := range spec.Features {
+				if f.Cell.X < 0 || f.Cell.X >= spec.Width || f.Cell.Y < 0 || f.Cell.Y >= spec.Height || cells[f.Cell] {
+					t.Errorf("%s seed %d: feature %s off the grid or sharing a cell", name, seed, f.Cell)
+				}
+				cells[f.Cell] = true
+			}
+			if err := gen.Verify(spec); err != nil {
+				t.Errorf("%s seed %d: generated puzzle fails to verify: %v", name, seed, err)
+			}
+
+			spec.Solution = spec.Solution[:len(spec.Solution)-1]
+			if err := gen.Verify(spec); err == nil {
+				t.Errorf("%s seed %d: verified with the last step of the solution dropped", name, seed)
+			}
+		}
+	}
+}
+
+// TestPlacePuzzles verifies puzzle rooms get the mechanism of their type's
+// generator fitted inside the room's walkway, and rooms too small for a
+// grid get a bare puzzle.
+func TestPlacePuzzles(t *testing.T) {
+	g := graph.NewGraph(7)
+	_ = g.AddRoom(&graph.Room{ID: "big", Archetype: graph.ArchetypePuzzle, Size: graph.SizeL, Difficulty: 0.1})
+	_ = g.AddRoom(&graph.Room{ID: "tiny", Archetype: graph.ArchetypePuzzle, Size: graph.SizeXS, Difficulty: 0.1})
+	_ = g.AddRoom(&graph.Room{ID: "narrow", Archetype: graph.ArchetypePuzzle, Size: graph.SizeL, Difficulty: 0.1})
+
+	c := NewContent()
+	extents := map[string]RoomExtent{"narrow": {Width: 10, Height: 4}}
+	if err := placePuzzles(g, c, extents, rng.NewRNGFromSeed(7, "content")); err != nil {
+		t.Fatal(err)
+	}
+
+	byRoom := make(map[string]PuzzleInstance)
+	for _, p := range c.Puzzles {
+		byRoom[p.RoomID] = p
+	}
+	for _, id := range []string{"big", "narrow"} {
+		p := byRoom[id]
+		if p.Type != "lever" || p.Spec == nil {
+			t.Fatalf("%s: %s puzzle with spec %v, want a generated lever puzzle", id, p.Type, p.Spec)
+		}
+		if p.Spec.Height > 2 {
+			t.Errorf("%s: %dx%d grid, want it within the walkway", id, p.Spec.Width, p.Spec.Height)
+		}
+	}
+	if byRoom["big"].Spec.Width != 8 {
+		t.Errorf("big: grid %d wide, want 8 inside a 10-tile room", byRoom["big"].Spec.Width)
+	}
+	if p := byRoom["tiny"]; p.Spec != nil {
+		t.Errorf("tiny: got a %dx%d mechanism in a room without space for one", p.Spec.Width, p.Spec.Height)
+	}
+}
+
+// TestRegisterPuzzle verifies the puzzle registry rejects duplicate and
+// unnamed generators.
+func TestRegisterPuzzle(t *testing.T) {
+	if err := RegisterPuzzle("lever", leverPuzzle{}); err == nil {
+		t.Error("registering lever twice succeeded")
+	}
+	if err := RegisterPuzzle("", leverPuzzle{}); err == nil {
+		t.Error("registering an unnamed generator succeeded")
+	}
+	if names := PuzzleNames(); len(names) != 3 || names[0] != "lever" || names[1] != "light_beam" || names[2] != "pressure_plate" {
+		t.Errorf("PuzzleNames() = %v, want the built-ins in sorted order", names)
+	}
+}
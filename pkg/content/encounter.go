@@ -2,7 +2,9 @@ package content
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 
 	"github.com/dshills/dungo/pkg/graph"
 	"github.com/dshills/dungo/pkg/rng"
@@ -30,23 +32,51 @@ var enemyTable = map[string]struct {
 //
 // Algorithm:
 //  1. Skip Start, Boss (special handling), Treasure, Vendor, Shrine rooms
-//  2. For each eligible room, calculate enemy count from difficulty
-//  3. Select enemy type(s) matching difficulty range (using theme pack if available)
+//  2. For each eligible room, calculate enemy count from difficulty, less the
+//     share carried by the room's environment (see enemyDifficulty)
+//  3. Select enemy type(s) matching difficulty range (using theme pack if
+//     available), from the room's faction when factions are assigned; a
+//     border skirmish splits the enemies between its two factions
 //  4. Place spawn points with dummy positions (actual positions require layout)
-//  5. Respect maxEnemiesPerRoom capacity limit
+//  5. Respect the room's capacity, and the maxEnemiesPerRoom limit
 //
 // Theme Integration:
 //   - If room has "biome" tag, load corresponding theme pack
 //   - Use theme's encounter table for difficulty-based enemy selection
 //   - Fall back to default enemy table if theme not found or no biome tag
-func spawnEnemies(g *graph.Graph, content *Content, maxEnemiesPerRoom int, rng *rng.RNG) error {
-	return spawnEnemiesWithThemes(g, content, maxEnemiesPerRoom, rng, nil)
+//
+// Factions (see assignFactions) map rooms to their factions; nil selects
+// every spawn's enemies independently.
+func spawnEnemies(g *graph.Graph, content *Content, maxEnemiesPerRoom int, capacities map[string]Capacity, factions map[string][]themes.Faction, rng *rng.RNG) error {
+	return spawnEnemiesWithThemes(g, content, maxEnemiesPerRoom, capacities, factions, rng, nil)
 }
 
 // spawnEnemiesWithThemes is the internal implementation that supports theme pack injection.
-// themeLoader can be nil for default behavior.
-func spawnEnemiesWithThemes(g *graph.Graph, content *Content, maxEnemiesPerRoom int, rng *rng.RNG, themeLoader *themes.Loader) error {
+// themeLoader can be nil for default behavior, and capacities nil to limit
+// rooms by maxEnemiesPerRoom alone.
+func spawnEnemiesWithThemes(g *graph.Graph, content *Content, maxEnemiesPerRoom int, capacities map[string]Capacity, factions map[string][]themes.Faction, rng *rng.RNG, themeLoader *themes.Loader) error {
 	spawnID := 0
+	addSpawn := func(roomID, enemyType, faction string, count int) error {
+		// Create spawn point
+		// Position is placeholder (0,0) - actual position requires layout stage
+		spawn := Spawn{
+			ID:         fmt.Sprintf("spawn_%d", spawnID),
+			RoomID:     roomID,
+			Position:   Point{X: 0, Y: 0}, // Placeholder - needs layout
+			EnemyType:  enemyType,
+			Count:      count,
+			PatrolPath: nil, // Can be added later based on room layout
+			Faction:    faction,
+		}
+
+		if err := spawn.Validate(); err != nil {
+			return fmt.Errorf("invalid spawn: %w", err)
+		}
+
+		content.Spawns = append(content.Spawns, spawn)
+		spawnID++
+		return nil
+	}
 
 	// Sort room IDs for deterministic iteration
 	roomIDs := make([]string, 0, len(g.Rooms))
@@ -63,16 +93,18 @@ func spawnEnemiesWithThemes(g *graph.Graph, content *Content, maxEnemiesPerRoom
 			continue
 		}
 
-		// Calculate enemy count based on difficulty
+		// Calculate enemy count based on the difficulty left to enemies
 		// difficulty 0.0 = 0 enemies, difficulty 1.0 = maxEnemiesPerRoom
-		enemyCount := int(room.Difficulty * float64(maxEnemiesPerRoom))
-		if enemyCount == 0 && room.Difficulty > 0.0 {
+		difficulty := enemyDifficulty(room)
+		enemyCount := int(difficulty * float64(maxEnemiesPerRoom))
+		if enemyCount == 0 && difficulty > 0.0 {
 			enemyCount = 1 // At least 1 enemy if room has any difficulty
 		}
 
-		// Cap at maximum
-		if enemyCount > maxEnemiesPerRoom {
-			enemyCount = maxEnemiesPerRoom
+		// Cap at the room's capacity
+		limit := enemyLimit(capacities, roomID, maxEnemiesPerRoom)
+		if enemyCount > limit {
+			enemyCount = limit
 		}
 
 		// Skip rooms with no enemies - don't create invalid spawns
@@ -80,33 +112,66 @@ func spawnEnemiesWithThemes(g *graph.Graph, content *Content, maxEnemiesPerRoom
 			continue
 		}
 
-		// Select enemy type based on difficulty (with theme support)
-		enemyType := selectEnemyTypeWithTheme(room, rng, themeLoader)
-
-		// Create spawn point
-		// Position is placeholder (0,0) - actual position requires layout stage
-		spawn := Spawn{
-			ID:         fmt.Sprintf("spawn_%d", spawnID),
-			RoomID:     roomID,
-			Position:   Point{X: 0, Y: 0}, // Placeholder - needs layout
-			EnemyType:  enemyType,
-			Count:      enemyCount,
-			PatrolPath: nil, // Can be added later based on room layout
+		roomFactions := factions[roomID]
+		if len(roomFactions) == 0 {
+			// Select enemy type based on difficulty (with theme support)
+			enemyType := selectEnemyTypeWithTheme(room, rng, themeLoader)
+			if err := addSpawn(roomID, enemyType, "", enemyCount); err != nil {
+				return err
+			}
+			continue
 		}
 
-		if err := spawn.Validate(); err != nil {
-			return fmt.Errorf("invalid spawn: %w", err)
+		// A border skirmish splits the enemies between both factions
+		counts := []int{enemyCount}
+		if len(roomFactions) > 1 && enemyCount > 1 {
+			counts = []int{enemyCount - enemyCount/2, enemyCount / 2}
+		}
+		for i, count := range counts {
+			enemyType := selectFactionEnemyType(room, roomFactions[i], rng)
+			if err := addSpawn(roomID, enemyType, roomFactions[i].Name, count); err != nil {
+				return err
+			}
 		}
-
-		content.Spawns = append(content.Spawns, spawn)
-		spawnID++
 	}
 
 	return nil
 }
 
+// markAmbushes marks spawns that lie in wait for the party. Spawns in rooms
+// tagged "ambush" with "true" are always ambushes; others are with
+// probability ambushRatio, rolled in spawn order. Layout-aware placement
+// later hides ambushes from the room's entrances.
+func markAmbushes(g *graph.Graph, content *Content, ambushRatio float64, rng *rng.RNG) error {
+	for i := range content.Spawns {
+		spawn := &content.Spawns[i]
+		if room, ok := g.Rooms[spawn.RoomID]; ok && room.Tags["ambush"] == "true" {
+			spawn.Ambush = true
+			continue
+		}
+		if ambushRatio > 0 && rng.Float64() < ambushRatio {
+			spawn.Ambush = true
+		}
+	}
+	return nil
+}
+
+// enemyDifficulty returns the part of a room's difficulty left to enemies once
+// the budget in its "environment" tag (hazards, darkness, slow terrain) is
+// taken out. Rooms without the tag leave all of it to enemies.
+func enemyDifficulty(room *graph.Room) float64 {
+	budget, err := strconv.ParseFloat(room.Tags["environment"], 64)
+	if err != nil {
+		return room.Difficulty
+	}
+	return math.Max(0, room.Difficulty-budget)
+}
+
 // shouldSkipEnemyPlacement determines if a room should not have enemy spawns.
 func shouldSkipEnemyPlacement(room *graph.Room) bool {
+	if room.Tags[graph.JunctionTag] == "true" {
+		return true // Junctions are corridor crossings added after checkpoints are placed
+	}
 	switch room.Archetype {
 	case graph.ArchetypeStart:
 		return true // Start room should be safe
@@ -118,13 +183,16 @@ func shouldSkipEnemyPlacement(room *graph.Room) bool {
 		return true // Shrines are safe zones
 	case graph.ArchetypeCheckpoint:
 		return true // Checkpoints are safe zones
+	case graph.ArchetypeExit:
+		return true // The fight is over once past the Boss
 	default:
 		return false
 	}
 }
 
 // selectEnemyTypeWithTheme chooses an enemy type appropriate for the room's difficulty.
-// Attempts to use theme pack if room has biome tag, falls back to default table.
+// Attempts to use the theme pack, then the registered theme, of the room's
+// biome tag, and falls back to the default table.
 func selectEnemyTypeWithTheme(room *graph.Room, rng *rng.RNG, themeLoader *themes.Loader) string {
 	// Try theme-based selection if loader available
 	if themeLoader != nil && room.Tags != nil {
@@ -142,6 +210,13 @@ func selectEnemyTypeWithTheme(room *graph.Room, rng *rng.RNG, themeLoader *theme
 		}
 	}
 
+	// Then the encounter table of the registered theme
+	if theme, ok := themes.Lookup(room.Tags["biome"]); ok {
+		if enemyType := themes.SelectEncounter(theme, room.Difficulty, rng); enemyType != "" {
+			return enemyType
+		}
+	}
+
 	// Fall back to default enemy selection
 	return selectEnemyType(room.Difficulty, rng)
 }
@@ -150,16 +225,27 @@ func selectEnemyTypeWithTheme(room *graph.Room, rng *rng.RNG, themeLoader *theme
 // Uses weighted random selection from enemies matching the difficulty range.
 // This is the default fallback when no theme pack is available.
 func selectEnemyType(difficulty float64, rng *rng.RNG) string {
+	return selectEnemyTypeFrom(difficulty, nil, rng)
+}
+
+// selectEnemyTypeFrom chooses an enemy type for the given difficulty from
+// the default table, restricted to members when members is non-nil. With
+// no member in the difficulty range it takes the member whose range is
+// nearest, and returns "" when no member is in the table. Without members
+// it defaults to skeleton.
+func selectEnemyTypeFrom(difficulty float64, members map[string]bool, rng *rng.RNG) string {
 	// Sort enemy types for deterministic iteration
 	enemyTypes := make([]string, 0, len(enemyTable))
 	for et := range enemyTable {
-		enemyTypes = append(enemyTypes, et)
+		if members == nil || members[et] {
+			enemyTypes = append(enemyTypes, et)
+		}
 	}
 	sort.Strings(enemyTypes)
 
 	// Build list of eligible enemies
-	eligible := make([]string, 0, len(enemyTable))
-	weights := make([]float64, 0, len(enemyTable))
+	eligible := make([]string, 0, len(enemyTypes))
+	weights := make([]float64, 0, len(enemyTypes))
 
 	for _, enemyType := range enemyTypes {
 		diffRange := enemyTable[enemyType]
@@ -181,9 +267,12 @@ func selectEnemyType(difficulty float64, rng *rng.RNG) string {
 		}
 	}
 
-	// If no eligible enemies (shouldn't happen with our table), default to skeleton
 	if len(eligible) == 0 {
-		return "skeleton"
+		// If no eligible enemies (shouldn't happen with our table), default to skeleton
+		if members == nil {
+			return "skeleton"
+		}
+		return nearestEnemyType(difficulty, enemyTypes)
 	}
 
 	// Use weighted random selection
@@ -195,9 +284,26 @@ func selectEnemyType(difficulty float64, rng *rng.RNG) string {
 	return eligible[index]
 }
 
+// nearestEnemyType returns the enemy type of the default table, among the
+// sorted enemyTypes, whose difficulty range lies nearest to difficulty, or
+// "" when enemyTypes is empty.
+func nearestEnemyType(difficulty float64, enemyTypes []string) string {
+	nearest, best := "", math.Inf(1)
+	for _, enemyType := range enemyTypes {
+		diffRange := enemyTable[enemyType]
+		dist := math.Max(diffRange.minDifficulty-difficulty, difficulty-diffRange.maxDifficulty)
+		if dist < best {
+			nearest, best = enemyType, dist
+		}
+	}
+	return nearest
+}
+
 // placePuzzles places puzzle instances in puzzle rooms.
-// Each puzzle room gets one puzzle matching its difficulty.
-func placePuzzles(g *graph.Graph, content *Content, rng *rng.RNG) error {
+// Each puzzle room gets one puzzle matching its difficulty, with the
+// mechanism of its type's registered generator sized to the room's extent
+// (the nominal extent of its size when missing from extents).
+func placePuzzles(g *graph.Graph, content *Content, extents map[string]RoomExtent, rng *rng.RNG) error {
 	puzzleID := 0
 
 	// Sort room IDs for deterministic iteration
@@ -242,6 +348,16 @@ func placePuzzles(g *graph.Graph, content *Content, rng *rng.RNG) error {
 			})
 		}
 
+		extent, ok := extents[roomID]
+		if !ok {
+			extent = nominalExtent(room.Size)
+		}
+		spec, err := generatePuzzle(puzzleType, room, extent, rng)
+		if err != nil {
+			return fmt.Errorf("puzzle in room %s: %w", roomID, err)
+		}
+		puzzle.Spec = spec
+
 		if err := puzzle.Validate(); err != nil {
 			return fmt.Errorf("invalid puzzle: %w", err)
 		}
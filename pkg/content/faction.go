@@ -0,0 +1,125 @@
+package content
+
+import (
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// defaultFactions group the default enemy table into factions for themes
+// without factions of their own. Each spans low to high difficulty, so
+// every region can be fought by one faction.
+var defaultFactions = []themes.Faction{
+	{Name: "beasts", Enemies: []string{"rat", "spider", "troll"}},
+	{Name: "undead", Enemies: []string{"rat", "skeleton", "dragon"}},
+	{Name: "greenskins", Enemies: []string{"goblin", "orc", "troll"}},
+}
+
+// factionRegionMinRooms is the fewest rooms a chokepoint must cut off on
+// both sides to bound a faction's region.
+const factionRegionMinRooms = 3
+
+// assignFactions gives every region of the graph (see graph.Regions) one
+// enemy faction, drawn from the factions of the region's theme: the most
+// common "biome" tag among its rooms. Each combat room bordering a room of
+// another faction then becomes a border skirmish with probability
+// skirmishRatio, fought by its own faction and the first other one among
+// its neighbours in ID order. Returns each room's factions, its own first.
+func assignFactions(g *graph.Graph, skirmishRatio float64, rng *rng.RNG) map[string][]themes.Faction {
+	factions := make(map[string][]themes.Faction, len(g.Rooms))
+	for _, region := range g.Regions(graph.RegionOptions{MinRooms: factionRegionMinRooms}) {
+		pool := themeFactions(regionBiome(g, region.Rooms))
+		faction := pool[rng.Intn(len(pool))]
+		for _, id := range region.Rooms {
+			factions[id] = []themes.Faction{faction}
+		}
+	}
+	if skirmishRatio <= 0 {
+		return factions
+	}
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	for _, id := range roomIDs {
+		if shouldSkipEnemyPlacement(g.Rooms[id]) || len(factions[id]) == 0 {
+			continue
+		}
+		own := factions[id][0]
+		neighbors := append([]string(nil), g.Adjacency[id]...)
+		sort.Strings(neighbors)
+		for _, next := range neighbors {
+			if len(factions[next]) == 0 || factions[next][0].Name == own.Name {
+				continue
+			}
+			if rng.Float64() < skirmishRatio {
+				factions[id] = append(factions[id], factions[next][0])
+			}
+			break
+		}
+	}
+	return factions
+}
+
+// regionBiome returns the most common "biome" tag among rooms, the first
+// by name on ties, or "" if none is tagged.
+func regionBiome(g *graph.Graph, rooms []string) string {
+	counts := make(map[string]int)
+	for _, id := range rooms {
+		if biome := g.Rooms[id].Tags["biome"]; biome != "" {
+			counts[biome]++
+		}
+	}
+	best := ""
+	for biome, n := range counts {
+		if n > counts[best] || (n == counts[best] && biome < best) {
+			best = biome
+		}
+	}
+	return best
+}
+
+// themeFactions returns the factions of a registered theme, or the default
+// factions for themes without any.
+func themeFactions(biome string) []themes.Faction {
+	if theme, ok := themes.Lookup(biome); ok {
+		if provider, ok := theme.(themes.FactionProvider); ok && len(provider.EnemyFactions()) > 0 {
+			return provider.EnemyFactions()
+		}
+	}
+	return defaultFactions
+}
+
+// selectFactionEnemyType chooses an enemy type of a faction for the room's
+// difficulty: from the faction's entries in the encounter table of the
+// room's registered theme, else from its members of the default table in
+// difficulty range or nearest to it, else uniformly among its members.
+func selectFactionEnemyType(room *graph.Room, faction themes.Faction, rng *rng.RNG) string {
+	members := make(map[string]bool, len(faction.Enemies))
+	for _, enemy := range faction.Enemies {
+		members[enemy] = true
+	}
+
+	if theme, ok := themes.Lookup(room.Tags["biome"]); ok {
+		var eligible []string
+		var weights []float64
+		for _, entry := range theme.EncounterTable(room.Difficulty) {
+			if members[entry.Type] && entry.Weight > 0 {
+				eligible = append(eligible, entry.Type)
+				weights = append(weights, float64(entry.Weight))
+			}
+		}
+		if len(eligible) > 0 {
+			return eligible[rng.WeightedChoice(weights)]
+		}
+	}
+
+	if enemyType := selectEnemyTypeFrom(room.Difficulty, members, rng); enemyType != "" {
+		return enemyType
+	}
+	return faction.Enemies[rng.Intn(len(faction.Enemies))]
+}
@@ -13,10 +13,13 @@ import (
 // This ensures that keys are always reachable before their corresponding locks.
 //
 // Algorithm:
-//  1. Find all connectors with gate.type == "key"
+//  1. Find all connectors whose gates need keys (see gateKeys)
 //  2. For each key, find the path from Start to the locked connector
 //  3. Place the key in a room on that path, before the lock
 //  4. Mark the loot as Required=true
+//
+// Vault keys are instead placed in the room holding them, which synthesis
+// chose with the accessibility settings in mind.
 func placeRequiredKeys(g *graph.Graph, content *Content, rng *rng.RNG) error {
 	// Find start room
 	startRoom := findStartRoom(g)
@@ -37,76 +40,142 @@ func placeRequiredKeys(g *graph.Graph, content *Content, rng *rng.RNG) error {
 	// Find all key-locked connectors
 	for _, connID := range connIDs {
 		conn := g.Connectors[connID]
-		if conn.Gate == nil || conn.Gate.Type != "key" {
-			continue
-		}
+		for _, need := range gateKeys(conn.Gate) {
+			// Skip if we've already placed this key; small keys are used
+			// up, so every small-key door gets its own
+			keyName := need.Value
+			if need.Type == "key" && keysSeen[keyName] {
+				continue
+			}
 
-		keyName := conn.Gate.Value
+			// Find rooms on path from start to the locked door
+			// Place key in one of these rooms (before the lock)
+			var candidateRooms []string
+			if holder := vaultKeyHolder(g, conn, need); holder != "" {
+				candidateRooms = []string{holder}
+			} else {
+				candidateRooms = findRoomsBeforeLock(g, startRoom, conn.From, conn.To)
+			}
 
-		// Skip if we've already placed this key
-		if keysSeen[keyName] {
-			continue
-		}
+			if len(candidateRooms) == 0 {
+				// If no path found, place in a random accessible room
+				// This handles edge cases in graph structure
+				candidateRooms = findAccessibleRooms(g, startRoom, conn.From)
+			}
 
-		// Find rooms on path from start to the locked door
-		// Place key in one of these rooms (before the lock)
-		candidateRooms := findRoomsBeforeLock(g, startRoom, conn.From, conn.To)
+			if len(candidateRooms) == 0 {
+				return fmt.Errorf("no suitable room found for key %s", keyName)
+			}
 
-		if len(candidateRooms) == 0 {
-			// If no path found, place in a random accessible room
-			// This handles edge cases in graph structure
-			candidateRooms = findAccessibleRooms(g, startRoom, conn.From)
-		}
+			// Select a room from candidates (prefer high-reward rooms for keys)
+			roomID := selectKeyPlacementRoom(g, candidateRooms, rng)
 
-		if len(candidateRooms) == 0 {
-			return fmt.Errorf("no suitable room found for key %s", keyName)
-		}
+			// Place the key
+			key := Loot{
+				ID:       fmt.Sprintf("loot_%d", lootID),
+				RoomID:   roomID,
+				Position: Point{X: 0, Y: 0}, // Placeholder - needs layout
+				ItemType: fmt.Sprintf("%s_%s", need.Type, keyName),
+				Value:    1,
+				Required: true,
+			}
 
-		// Select a room from candidates (prefer high-reward rooms for keys)
-		roomID := selectKeyPlacementRoom(g, candidateRooms, rng)
-
-		// Place the key
-		key := Loot{
-			ID:       fmt.Sprintf("loot_%d", lootID),
-			RoomID:   roomID,
-			Position: Point{X: 0, Y: 0}, // Placeholder - needs layout
-			ItemType: fmt.Sprintf("key_%s", keyName),
-			Value:    1,
-			Required: true,
-		}
+			if err := key.Validate(); err != nil {
+				return fmt.Errorf("invalid key loot: %w", err)
+			}
 
-		if err := key.Validate(); err != nil {
-			return fmt.Errorf("invalid key loot: %w", err)
+			content.Loot = append(content.Loot, key)
+			keysSeen[keyName] = true
+			lootID++
 		}
+	}
+
+	return nil
+}
 
-		content.Loot = append(content.Loot, key)
-		keysSeen[keyName] = true
-		lootID++
+// placeAbilities places a required pickup for every ability a room provides,
+// in that room: unlike keys, which may lie anywhere before their lock,
+// abilities are placed where synthesis put them so routes that double back
+// for them stay intact.
+func placeAbilities(g *graph.Graph, content *Content) error {
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
 	}
+	sort.Strings(roomIDs)
 
+	for _, id := range roomIDs {
+		for _, cap := range g.Rooms[id].Provides {
+			if cap.Type != "ability" {
+				continue
+			}
+			pickup := Loot{
+				ID:       fmt.Sprintf("loot_%d", len(content.Loot)),
+				RoomID:   id,
+				ItemType: fmt.Sprintf("%s_%s", cap.Type, cap.Value),
+				Value:    1,
+				Required: true,
+			}
+			if err := pickup.Validate(); err != nil {
+				return fmt.Errorf("invalid ability loot: %w", err)
+			}
+			content.Loot = append(content.Loot, pickup)
+		}
+	}
 	return nil
 }
 
+// gateKeys returns the keys and small keys a gate needs placed: every key of
+// an all-of gate, or the first key of an any-of gate, since one is enough to
+// open it.
+func gateKeys(gate *graph.Gate) []graph.Requirement {
+	if gate == nil {
+		return nil
+	}
+	var keys []graph.Requirement
+	for _, need := range gate.Needs() {
+		if need.Type != "key" && need.Type != graph.SmallKey {
+			continue
+		}
+		keys = append(keys, need)
+		if gate.Any {
+			break
+		}
+	}
+	return keys
+}
+
+// RewardChest is the item type of the chest holding an Exit room's loot.
+const RewardChest = "reward_chest"
+
+// VaultItemValue is the least value of an item in a vault's treasure
+// chamber. Its items are gems and artifacts, the loot types this value
+// selects.
+const VaultItemValue = 400
+
 // distributeLoot places treasure loot based on room.Reward values.
 // Higher reward rooms get more valuable loot.
 //
 // Algorithm:
 //  1. Calculate total reward budget from lootBudgetBase
 //  2. For each room, allocate loot proportional to room.Reward
-//  3. Place loot items in eligible rooms (using theme pack if available)
+//  3. Place loot items in eligible rooms (using theme pack if available),
+//     no more than the room's loot slots; an Exit room's share goes into a
+//     single RewardChest, and a vault chamber's items are worth at least
+//     VaultItemValue
 //  4. Skip rooms that shouldn't have loot (Start, corridors, etc.)
 //
 // Theme Integration:
 //   - If room has "biome" tag, load corresponding theme pack
 //   - Use theme's loot table for reward-based item selection
 //   - Fall back to default loot table if theme not found or no biome tag
-func distributeLoot(g *graph.Graph, content *Content, budgetBase int, rng *rng.RNG) error {
-	return distributeLootWithThemes(g, content, budgetBase, rng, nil)
+func distributeLoot(g *graph.Graph, content *Content, budgetBase int, capacities map[string]Capacity, rng *rng.RNG) error {
+	return distributeLootWithThemes(g, content, budgetBase, capacities, rng, nil)
 }
 
 // distributeLootWithThemes is the internal implementation that supports theme pack injection.
 // themeLoader can be nil for default behavior.
-func distributeLootWithThemes(g *graph.Graph, content *Content, budgetBase int, rng *rng.RNG, themeLoader *themes.Loader) error {
+func distributeLootWithThemes(g *graph.Graph, content *Content, budgetBase int, capacities map[string]Capacity, rng *rng.RNG, themeLoader *themes.Loader) error {
 	// Sort room IDs for deterministic iteration
 	roomIDs := make([]string, 0, len(g.Rooms))
 	for id := range g.Rooms {
@@ -161,13 +230,29 @@ func distributeLootWithThemes(g *graph.Graph, content *Content, budgetBase int,
 		case graph.SizeXL:
 			itemCount = rng.IntRange(2, 4)
 		}
+		if c, ok := capacities[room.ID]; ok {
+			itemCount = max(1, min(itemCount, c.LootSlots))
+		}
+		if room.Archetype == graph.ArchetypeExit {
+			itemCount = 1
+		}
 
 		// Distribute budget across items
 		for i := 0; i < itemCount; i++ {
 			itemValue := roomBudget / itemCount
 
-			// Select loot type based on value (with theme support)
-			lootType := selectLootTypeWithTheme(room, itemValue, rng, themeLoader)
+			// Select loot type based on value (with theme support); vault
+			// treasure always comes from the top of the default table
+			var lootType string
+			switch {
+			case room.Archetype == graph.ArchetypeExit:
+				lootType = RewardChest
+			case isVaultChamber(room):
+				itemValue = max(itemValue, VaultItemValue)
+				lootType = selectLootType(VaultItemValue, rng)
+			default:
+				lootType = selectLootTypeWithTheme(room, itemValue, rng, themeLoader)
+			}
 
 			loot := Loot{
 				ID:       fmt.Sprintf("loot_%d", lootID),
@@ -190,14 +275,39 @@ func distributeLootWithThemes(g *graph.Graph, content *Content, budgetBase int,
 	return nil
 }
 
-// findStartRoom returns the ID of the start room.
+// vaultKeyHolder returns the room providing need when conn leads into a
+// vault, or "" for other locks. The lowest room ID wins if several do.
+func vaultKeyHolder(g *graph.Graph, conn *graph.Connector, need graph.Requirement) string {
+	from, to := g.Rooms[conn.From], g.Rooms[conn.To]
+	if (from == nil || from.Tags[graph.VaultTag] == "") && (to == nil || to.Tags[graph.VaultTag] == "") {
+		return ""
+	}
+	holder := ""
+	for id, room := range g.Rooms {
+		for _, cap := range room.Provides {
+			if cap.Type == need.Type && cap.Value == need.Value && (holder == "" || id < holder) {
+				holder = id
+			}
+		}
+	}
+	return holder
+}
+
+// isVaultChamber reports whether room is the treasure chamber of a vault.
+func isVaultChamber(room *graph.Room) bool {
+	return room.Tags[graph.VaultTag] != "" && room.Archetype == graph.ArchetypeTreasure
+}
+
+// findStartRoom returns the ID of the start room, the lowest ID when an arena
+// graph has one per team.
 func findStartRoom(g *graph.Graph) string {
+	start := ""
 	for id, room := range g.Rooms {
-		if room.Archetype == graph.ArchetypeStart {
-			return id
+		if room.Archetype == graph.ArchetypeStart && (start == "" || id < start) {
+			start = id
 		}
 	}
-	return ""
+	return start
 }
 
 // findRoomsBeforeLock finds rooms on the path from start to the lock.
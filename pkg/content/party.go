@@ -0,0 +1,192 @@
+package content
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// partyScale returns the content multiplier for a party: each player beyond
+// the first adds half a player's worth of enemies and loot.
+func partyScale(partySize int) float64 {
+	return 1.0 + 0.5*float64(partySize-1)
+}
+
+// scaleForParty adapts placed content to a co-op party. Single-player
+// parties (size <= 1) are left untouched.
+//
+// Algorithm:
+//  1. Multiply each spawn's enemy count by partyScale, capped at the room's
+//     enemy capacity less the space its support groups need
+//  2. For every two extra players, add a support spawn of a different enemy
+//     type to each combat room, so encounter compositions change with the
+//     party, while the room has enemy capacity left; a spawn of a faction
+//     is supported by its own faction
+//  3. Add copies of non-required loot until each room holds partyScale times
+//     its original item count, up to its loot slots; required items (keys)
+//     stay shared
+//  4. Place one start point per player in rooms adjacent to Start
+func scaleForParty(g *graph.Graph, content *Content, partySize, maxEnemiesPerRoom int, capacities map[string]Capacity, factions map[string][]themes.Faction, rng *rng.RNG) error {
+	if partySize <= 1 {
+		return nil
+	}
+	scale := partyScale(partySize)
+
+	// Step 1 and 2: Encounters
+	supportGroups := (partySize - 1) / 2
+	spawnID := len(content.Spawns)
+	baseSpawns := len(content.Spawns)
+	enemies := make(map[string]int) // Enemies placed per room so far
+	for i := 0; i < baseSpawns; i++ {
+		spawn := &content.Spawns[i]
+		base := spawn.Count
+		limit := enemyLimit(capacities, spawn.RoomID, maxEnemiesPerRoom)
+		supportSize := max(1, base/2)
+		// Leave room for the support groups when the room can fit them
+		free := limit - enemies[spawn.RoomID]
+		reserved := min(supportGroups*supportSize, free-1)
+		spawn.Count = max(1, min(free-max(0, reserved), int(math.Ceil(float64(base)*scale))))
+		enemies[spawn.RoomID] += spawn.Count
+
+		room := g.Rooms[spawn.RoomID]
+		var members map[string]bool
+		for _, faction := range factions[spawn.RoomID] {
+			if faction.Name != spawn.Faction {
+				continue
+			}
+			members = make(map[string]bool, len(faction.Enemies))
+			for _, enemy := range faction.Enemies {
+				members[enemy] = true
+			}
+		}
+		for j := 0; j < supportGroups; j++ {
+			if enemies[spawn.RoomID] >= limit {
+				break
+			}
+			support := Spawn{
+				ID:        fmt.Sprintf("spawn_%d", spawnID),
+				RoomID:    spawn.RoomID,
+				Position:  spawn.Position,
+				EnemyType: selectSupportEnemyType(room, spawn.EnemyType, members, rng),
+				Count:     min(limit-enemies[spawn.RoomID], supportSize),
+				Faction:   spawn.Faction,
+			}
+			if err := support.Validate(); err != nil {
+				return fmt.Errorf("invalid support spawn: %w", err)
+			}
+			content.Spawns = append(content.Spawns, support)
+			enemies[spawn.RoomID] += support.Count
+			spawnID++
+		}
+	}
+
+	// Step 3: Loot
+	byRoom := make(map[string][]Loot)
+	var roomIDs []string
+	for _, loot := range content.Loot {
+		if loot.Required {
+			continue
+		}
+		if _, seen := byRoom[loot.RoomID]; !seen {
+			roomIDs = append(roomIDs, loot.RoomID)
+		}
+		byRoom[loot.RoomID] = append(byRoom[loot.RoomID], loot)
+	}
+	sort.Strings(roomIDs)
+
+	lootID := len(content.Loot)
+	for _, roomID := range roomIDs {
+		items := byRoom[roomID]
+		target := int(math.Round(float64(len(items)) * scale))
+		if c, ok := capacities[roomID]; ok {
+			target = min(target, max(len(items), c.LootSlots))
+		}
+		for i := len(items); i < target; i++ {
+			copied := items[i%len(items)]
+			copied.ID = fmt.Sprintf("loot_%d", lootID)
+			content.Loot = append(content.Loot, copied)
+			lootID++
+		}
+	}
+
+	// Step 4: Player starts
+	return placePlayerStarts(g, content, partySize)
+}
+
+// selectSupportEnemyType picks an enemy type for a support group that differs
+// from the primary type when the difficulty range allows it, among members
+// when members is non-nil.
+func selectSupportEnemyType(room *graph.Room, primary string, members map[string]bool, rng *rng.RNG) string {
+	var candidates []string
+	for name, entry := range enemyTable {
+		if members != nil && !members[name] {
+			continue
+		}
+		if name != primary && room.Difficulty >= entry.minDifficulty && room.Difficulty <= entry.maxDifficulty {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return primary
+	}
+	sort.Strings(candidates) // Deterministic order before the random pick
+	return candidates[rng.Intn(len(candidates))]
+}
+
+// placePlayerStarts places one start point per player in rooms that can be
+// entered from Start and left back to it: ungated, visible, bidirectional
+// connections to rooms without requirements. Players are spread across those
+// rooms in sorted order; without any, everyone starts in the Start room.
+func placePlayerStarts(g *graph.Graph, content *Content, partySize int) error {
+	startRoom := findStartRoom(g)
+	if startRoom == "" {
+		return fmt.Errorf("no start room found in graph")
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, conn := range g.Connectors {
+		if !conn.Bidirectional || conn.Gate != nil ||
+			conn.Type == graph.TypeHidden || conn.Visibility == graph.VisibilitySecret {
+			continue
+		}
+		var neighbor string
+		switch startRoom {
+		case conn.From:
+			neighbor = conn.To
+		case conn.To:
+			neighbor = conn.From
+		default:
+			continue
+		}
+		room := g.Rooms[neighbor]
+		if seen[neighbor] || room.Archetype == graph.ArchetypeSecret || len(room.Requirements) > 0 {
+			continue
+		}
+		seen[neighbor] = true
+		candidates = append(candidates, neighbor)
+	}
+	sort.Strings(candidates)
+	if len(candidates) == 0 {
+		candidates = []string{startRoom}
+	}
+
+	for player := 1; player <= partySize; player++ {
+		start := PlayerStart{
+			ID:       fmt.Sprintf("player_start_%d", player),
+			RoomID:   candidates[(player-1)%len(candidates)],
+			Position: Point{X: 0, Y: 0}, // Placeholder - needs layout
+			Player:   player,
+		}
+		if err := start.Validate(); err != nil {
+			return fmt.Errorf("invalid player start: %w", err)
+		}
+		content.PlayerStarts = append(content.PlayerStarts, start)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package content
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// Names of the built-in sub-passes, in their default order.
+const (
+	SubPassKeys     = "keys"     // Keys in reach before their locks, and abilities
+	SubPassLoot     = "loot"     // Treasure from room rewards
+	SubPassFactions = "factions" // Enemy factions of regions
+	SubPassEnemies  = "enemies"  // Spawns from room difficulty
+	SubPassAmbush   = "ambush"   // Spawns lying in wait
+	SubPassPuzzles  = "puzzles"  // Puzzles in puzzle rooms
+	SubPassTraps    = "traps"    // Traps in combat rooms
+	SubPassParty    = "party"    // Co-op scaling and player starts
+	SubPassWaves    = "waves"    // Horde-mode wave schedule
+)
+
+// DefaultOrder is the order the built-in sub-passes run in. Keys come first
+// so required items are placed before general loot competes for rooms, and
+// party scaling runs after everything it scales.
+var DefaultOrder = []string{
+	SubPassKeys, SubPassLoot, SubPassFactions, SubPassEnemies, SubPassAmbush, SubPassPuzzles, SubPassTraps, SubPassParty, SubPassWaves,
+}
+
+// PassContext is the state shared by the sub-passes of one placement: the
+// graph being populated, the content placed so far, the stage RNG, and the
+// pass settings. Sub-passes read and extend Content in turn.
+type PassContext struct {
+	Graph   *graph.Graph
+	Content *Content
+	RNG     *rng.RNG
+
+	MaxEnemiesPerRoom int     // Capacity limit for enemies
+	LootBudget        int     // Base treasure value
+	TrapDensity       float64 // Chance (0.0-1.0) that an eligible room holds traps
+	AmbushRatio       float64 // Chance (0.0-1.0) that a spawn lies in wait
+	PartySize         int     // Co-op players; scaling applies above 1
+	WaveSchedule      bool    // Whether to build horde-mode wave schedules
+	EnemyFactions     bool    // Whether to keep regions to one enemy faction
+	SkirmishRatio     float64 // Chance (0.0-1.0) that a room on a faction border mixes both factions
+
+	// Factions is each room's enemy factions, its own first and a second
+	// in border skirmishes, once the factions sub-pass has assigned them.
+	// Nil leaves enemies to be selected independently.
+	Factions map[string][]themes.Faction
+
+	// Capacities is the content budget of each room. Sub-passes keep
+	// enemies and loot within it.
+	Capacities map[string]Capacity
+
+	// Extents is the size of each room's carved floor bounds, where
+	// known. Puzzle mechanisms are sized to fit.
+	Extents map[string]RoomExtent
+}
+
+// SubPass places one kind of content. It must draw all randomness from
+// pc.RNG so placement stays deterministic.
+type SubPass func(ctx context.Context, pc *PassContext) error
+
+// builtinSubPasses are the sub-passes every DefaultContentPass starts with.
+var builtinSubPasses = map[string]SubPass{
+	SubPassKeys: func(ctx context.Context, pc *PassContext) error {
+		if err := placeRequiredKeys(pc.Graph, pc.Content, pc.RNG); err != nil {
+			return err
+		}
+		return placeAbilities(pc.Graph, pc.Content)
+	},
+	SubPassLoot: func(ctx context.Context, pc *PassContext) error {
+		return distributeLoot(pc.Graph, pc.Content, pc.LootBudget, pc.Capacities, pc.RNG)
+	},
+	SubPassFactions: func(ctx context.Context, pc *PassContext) error {
+		if !pc.EnemyFactions {
+			return nil
+		}
+		pc.Factions = assignFactions(pc.Graph, pc.SkirmishRatio, pc.RNG)
+		return nil
+	},
+	SubPassEnemies: func(ctx context.Context, pc *PassContext) error {
+		return spawnEnemies(pc.Graph, pc.Content, pc.MaxEnemiesPerRoom, pc.Capacities, pc.Factions, pc.RNG)
+	},
+	SubPassAmbush: func(ctx context.Context, pc *PassContext) error {
+		return markAmbushes(pc.Graph, pc.Content, pc.AmbushRatio, pc.RNG)
+	},
+	SubPassPuzzles: func(ctx context.Context, pc *PassContext) error {
+		return placePuzzles(pc.Graph, pc.Content, pc.Extents, pc.RNG)
+	},
+	SubPassTraps: func(ctx context.Context, pc *PassContext) error {
+		return placeTraps(pc.Graph, pc.Content, pc.TrapDensity, pc.RNG)
+	},
+	SubPassParty: func(ctx context.Context, pc *PassContext) error {
+		return scaleForParty(pc.Graph, pc.Content, pc.PartySize, pc.MaxEnemiesPerRoom, pc.Capacities, pc.Factions, pc.RNG)
+	},
+	SubPassWaves: func(ctx context.Context, pc *PassContext) error {
+		if !pc.WaveSchedule {
+			return nil
+		}
+		return buildWaveSchedule(pc.Graph, pc.Content, pc.MaxEnemiesPerRoom, pc.PartySize, pc.Capacities, pc.RNG)
+	},
+}
+
+// runSubPasses runs the named sub-passes in order, checking for
+// cancellation before each.
+func runSubPasses(ctx context.Context, pc *PassContext, order []string, passes map[string]SubPass) error {
+	for _, name := range order {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		pass, ok := passes[name]
+		if !ok {
+			return fmt.Errorf("unknown content sub-pass %q", name)
+		}
+		if err := pass(ctx, pc); err != nil {
+			return fmt.Errorf("content sub-pass %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,534 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// MinPuzzleGrid is the smallest side, in cells, of a puzzle grid. Rooms
+// whose grid would be smaller get puzzles without a generated mechanism.
+const MinPuzzleGrid = 2
+
+// PuzzleSpec is the mechanism of a generated puzzle: its parameters, the
+// features it needs in its room and a solution its generator verified.
+// Features stand on a Width×Height grid of cells centered in the room's
+// floor, inside a one-tile walkway along the walls.
+type PuzzleSpec struct {
+	Width    int             `json:"width"`    // Grid columns
+	Height   int             `json:"height"`   // Grid rows
+	Params   map[string]int  `json:"params"`   // Generator parameters, e.g. "levers"
+	Features []PuzzleFeature `json:"features"` // Room features, in generator order
+	Solution []int           `json:"solution"` // Features to act on, by index, in order
+}
+
+// PuzzleFeature is an object a puzzle needs in its room: a lever, a plate
+// set in the floor, or a statue such as a mirror.
+type PuzzleFeature struct {
+	Kind    string `json:"kind"`              // e.g. "lever", "plate", "mirror"
+	Cell    Point  `json:"cell"`              // Grid cell, from the grid's top-left
+	State   int    `json:"state"`             // Initial state, meaning depends on Kind
+	Solid   bool   `json:"solid,omitempty"`   // Blocks movement, like a statue
+	Targets []int  `json:"targets,omitempty"` // Features it acts on, by index
+}
+
+// RoomExtent is the size in tiles of a room's floor bounds.
+type RoomExtent struct {
+	Width  int
+	Height int
+}
+
+// PuzzleGenerator builds the mechanism of one puzzle type. Generators must
+// draw all randomness from the RNG they are given so puzzles stay
+// deterministic.
+type PuzzleGenerator interface {
+	// Generate builds a puzzle of the given difficulty (0.0-1.0) on a grid
+	// of at most width×height cells, both at least MinPuzzleGrid.
+	Generate(difficulty float64, width, height int, rng *rng.RNG) (*PuzzleSpec, error)
+
+	// Verify replays the spec's solution from the features' initial
+	// states, returning an error unless it solves a puzzle that was not
+	// already solved.
+	Verify(spec *PuzzleSpec) error
+}
+
+var (
+	puzzlesMu sync.RWMutex
+	puzzles   = map[string]PuzzleGenerator{}
+)
+
+// RegisterPuzzle adds a generator to the registry under a puzzle type, so
+// puzzles of that type get a generated mechanism. Plugins call it from an
+// init function. Registering a type twice is an error.
+func RegisterPuzzle(name string, gen PuzzleGenerator) error {
+	if name == "" {
+		return errors.New("puzzle type is required")
+	}
+	if gen == nil {
+		return fmt.Errorf("puzzle generator %q is nil", name)
+	}
+
+	puzzlesMu.Lock()
+	defer puzzlesMu.Unlock()
+	if _, ok := puzzles[name]; ok {
+		return fmt.Errorf("puzzle generator %q is already registered", name)
+	}
+	puzzles[name] = gen
+	return nil
+}
+
+// MustRegisterPuzzle is like RegisterPuzzle but panics on error, for init
+// functions.
+func MustRegisterPuzzle(name string, gen PuzzleGenerator) {
+	if err := RegisterPuzzle(name, gen); err != nil {
+		panic(err)
+	}
+}
+
+// LookupPuzzle returns the generator registered for a puzzle type.
+func LookupPuzzle(name string) (PuzzleGenerator, bool) {
+	puzzlesMu.RLock()
+	defer puzzlesMu.RUnlock()
+	gen, ok := puzzles[name]
+	return gen, ok
+}
+
+// PuzzleNames returns the puzzle types with a registered generator in
+// sorted order.
+func PuzzleNames() []string {
+	puzzlesMu.RLock()
+	defer puzzlesMu.RUnlock()
+	names := make([]string, 0, len(puzzles))
+	for name := range puzzles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The built-in generators. Rune sequences and ciphers are left to the
+// runtime, or to plugins registering generators for them.
+func init() {
+	MustRegisterPuzzle("lever", leverPuzzle{})
+	MustRegisterPuzzle("pressure_plate", platePuzzle{})
+	MustRegisterPuzzle("light_beam", beamPuzzle{})
+}
+
+// generatePuzzle builds and verifies the mechanism of a puzzle in a room of
+// the given extent. It returns nil for types without a generator and rooms
+// too small for a grid.
+func generatePuzzle(puzzleType string, room *graph.Room, extent RoomExtent, rng *rng.RNG) (*PuzzleSpec, error) {
+	gen, ok := LookupPuzzle(puzzleType)
+	if !ok {
+		return nil, nil
+	}
+	width, height := extent.Width-2, extent.Height-2
+	if width < MinPuzzleGrid || height < MinPuzzleGrid {
+		return nil, nil
+	}
+
+	spec, err := gen.Generate(room.Difficulty, width, height, rng)
+	if err != nil {
+		return nil, fmt.Errorf("%s puzzle: %w", puzzleType, err)
+	}
+	if spec.Width > width || spec.Height > height {
+		return nil, fmt.Errorf("%s puzzle: %dx%d grid exceeds %dx%d", puzzleType, spec.Width, spec.Height, width, height)
+	}
+	for i, f := range spec.Features {
+		if f.Cell.X < 0 || f.Cell.X >= spec.Width || f.Cell.Y < 0 || f.Cell.Y >= spec.Height {
+			return nil, fmt.Errorf("%s puzzle: feature %d at %s is off the grid", puzzleType, i, f.Cell)
+		}
+	}
+	if err := gen.Verify(spec); err != nil {
+		return nil, fmt.Errorf("%s puzzle is unsolvable: %w", puzzleType, err)
+	}
+	return spec, nil
+}
+
+// nominalExtent returns the floor bounds of a room size's stamped
+// footprint, used when the carved bounds of a room are not known.
+func nominalExtent(size graph.RoomSize) RoomExtent {
+	side := int(math.Sqrt(float64(NominalFloorTiles(size))))
+	return RoomExtent{Width: side, Height: side}
+}
+
+// leverPuzzle is a row of levers, each of which flips itself and some of
+// the levers after it. The puzzle is solved when every lever is down (state
+// 0); the solution lists the levers to pull. Since a lever only flips
+// levers after it, every start has exactly one set of pulls solving it.
+type leverPuzzle struct{}
+
+func (leverPuzzle) Generate(difficulty float64, width, height int, rng *rng.RNG) (*PuzzleSpec, error) {
+	n := min(width, 3+int(difficulty*4))
+	spec := &PuzzleSpec{
+		Width:    width,
+		Height:   1,
+		Features: make([]PuzzleFeature, n),
+	}
+
+	// Harder puzzles link more levers
+	link := 0.3 + difficulty*0.6
+	for i := range spec.Features {
+		f := PuzzleFeature{
+			Kind:    "lever",
+			Cell:    Point{X: i * (width - 1) / (n - 1), Y: 0},
+			Solid:   true,
+			Targets: []int{i},
+		}
+		for j := i + 1; j < min(n, i+3); j++ {
+			if rng.Float64() < link {
+				f.Targets = append(f.Targets, j)
+			}
+		}
+		spec.Features[i] = f
+	}
+
+	// Pull a random set of levers from the solved state to get the start
+	for i := 0; i < n; i++ {
+		if rng.Bool() {
+			spec.Solution = append(spec.Solution, i)
+		}
+	}
+	if len(spec.Solution) == 0 {
+		spec.Solution = []int{rng.Intn(n)}
+	}
+	for _, i := range spec.Solution {
+		for _, t := range spec.Features[i].Targets {
+			spec.Features[t].State ^= 1
+		}
+	}
+
+	spec.Params = map[string]int{"levers": n, "pulls": len(spec.Solution)}
+	return spec, nil
+}
+
+func (leverPuzzle) Verify(spec *PuzzleSpec) error {
+	states := make([]int, len(spec.Features))
+	solved := true
+	for i, f := range spec.Features {
+		states[i] = f.State
+		solved = solved && f.State == 0
+	}
+	if solved {
+		return errors.New("every lever starts down")
+	}
+	for _, i := range spec.Solution {
+		if i < 0 || i >= len(spec.Features) {
+			return fmt.Errorf("no lever %d", i)
+		}
+		for _, t := range spec.Features[i].Targets {
+			if t < 0 || t >= len(states) {
+				return fmt.Errorf("lever %d flips missing lever %d", i, t)
+			}
+			states[t] ^= 1
+		}
+	}
+	for i, s := range states {
+		if s != 0 {
+			return fmt.Errorf("lever %d is still up", i)
+		}
+	}
+	return nil
+}
+
+// platePuzzle is a floor of pressure plates crossed by one safe path from
+// the first row to the last; every other plate springs a trap. The solution
+// lists the plates of the path in walking order. Harder puzzles have larger
+// floors and paths that wander further sideways.
+type platePuzzle struct{}
+
+func (platePuzzle) Generate(difficulty float64, width, height int, rng *rng.RNG) (*PuzzleSpec, error) {
+	size := 3 + int(difficulty*4)
+	w, h := min(width, size), min(height, size)
+	spec := &PuzzleSpec{
+		Width:    w,
+		Height:   h,
+		Features: make([]PuzzleFeature, 0, w*h),
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			spec.Features = append(spec.Features, PuzzleFeature{Kind: "plate", Cell: Point{X: x, Y: y}})
+		}
+	}
+
+	// Walk down the rows, wandering sideways in one direction before
+	// leaving each, until the path steps onto the last
+	x := rng.Intn(w)
+	spec.Solution = []int{x}
+	for y := 0; y < h-1; y++ {
+		if steps := rng.Intn(1 + int(difficulty*float64(w-1))); steps > 0 {
+			dx := 1
+			if rng.Bool() {
+				dx = -1
+			}
+			for ; steps > 0 && x+dx >= 0 && x+dx < w; steps-- {
+				x += dx
+				spec.Solution = append(spec.Solution, y*w+x)
+			}
+		}
+		spec.Solution = append(spec.Solution, (y+1)*w+x)
+	}
+
+	spec.Params = map[string]int{"plates": w * h, "steps": len(spec.Solution)}
+	return spec, nil
+}
+
+func (platePuzzle) Verify(spec *PuzzleSpec) error {
+	if len(spec.Solution) == 0 {
+		return errors.New("no path")
+	}
+	seen := make(map[int]bool, len(spec.Solution))
+	var prev Point
+	for step, i := range spec.Solution {
+		if i < 0 || i >= len(spec.Features) || spec.Features[i].Kind != "plate" {
+			return fmt.Errorf("step %d is not on a plate", step)
+		}
+		if seen[i] {
+			return fmt.Errorf("step %d returns to plate %d", step, i)
+		}
+		seen[i] = true
+		cell := spec.Features[i].Cell
+		switch {
+		case step == 0 && cell.Y != 0:
+			return fmt.Errorf("path starts on row %d, not the first", cell.Y)
+		case step > 0 && abs(cell.X-prev.X)+abs(cell.Y-prev.Y) != 1:
+			return fmt.Errorf("step %d jumps from %s to %s", step, prev, cell)
+		}
+		prev = cell
+	}
+	if prev.Y != spec.Height-1 {
+		return fmt.Errorf("path ends on row %d, not the last", prev.Y)
+	}
+	return nil
+}
+
+// Light beam features. The emitter's state is the direction it shines, a
+// mirror's is its orientation: 0 for "/" and 1 for "\".
+const (
+	mirrorSlash     = 0
+	mirrorBackslash = 1
+)
+
+// beamDirections are the directions an emitter shines, by state: east,
+// south, west and north.
+var beamDirections = [4]Point{{X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 0, Y: -1}}
+
+// beamPuzzle is an emitter statue shining a beam across the room, mirror
+// statues that turn it, and a receiver statue the beam must reach. Feature
+// 0 is the emitter and feature 1 the receiver. The solution lists the
+// mirrors to turn, each turn flipping its orientation. Harder puzzles bend
+// the beam more and add decoy mirrors off its path.
+type beamPuzzle struct{}
+
+// beamAttempts is how many beams Generate lays out before giving up on
+// finding one the start position does not already solve through decoys.
+const beamAttempts = 8
+
+func (beamPuzzle) Generate(difficulty float64, width, height int, rng *rng.RNG) (*PuzzleSpec, error) {
+	size := 3 + int(difficulty*5)
+	w, h := min(width, size), min(height, size)
+	for attempt := 0; attempt < beamAttempts; attempt++ {
+		spec, err := layBeam(difficulty, w, h, rng)
+		if err != nil {
+			return nil, err
+		}
+		if !traceBeam(spec, spec.Features) {
+			return spec, nil
+		}
+	}
+	return nil, fmt.Errorf("every %dx%d beam starts solved", w, h)
+}
+
+// layBeam lays out a beam puzzle on a w×h grid.
+func layBeam(difficulty float64, w, h int, rng *rng.RNG) (*PuzzleSpec, error) {
+	spec := &PuzzleSpec{Width: w, Height: h}
+
+	used := make([]bool, w*h)
+	free := func(p Point) bool {
+		return p.X >= 0 && p.X < w && p.Y >= 0 && p.Y < h && !used[p.Y*w+p.X]
+	}
+	// run returns the free cells from p along d, up to the first taken one
+	run := func(p, d Point) []Point {
+		var cells []Point
+		for q := (Point{X: p.X + d.X, Y: p.Y + d.Y}); free(q); q = (Point{X: q.X + d.X, Y: q.Y + d.Y}) {
+			cells = append(cells, q)
+		}
+		return cells
+	}
+	take := func(from, to, d Point) {
+		for p := from; ; p = (Point{X: p.X + d.X, Y: p.Y + d.Y}) {
+			used[p.Y*w+p.X] = true
+			if p == to {
+				return
+			}
+		}
+	}
+
+	pos, dir := Point{X: 0, Y: rng.Intn(h)}, beamDirections[0]
+	used[pos.Y*w+pos.X] = true
+	spec.Features = []PuzzleFeature{{Kind: "emitter", Cell: pos, Solid: true}, {Kind: "receiver", Solid: true}}
+
+	// Bend the beam at mirrors with room to shine on after the turn
+	var mirrors []PuzzleFeature
+	var want []int
+	for bends := 1 + int(difficulty*3); len(mirrors) < bends; {
+		var candidates []Point
+		for _, p := range run(pos, dir) {
+			for _, d := range [2]Point{{X: dir.Y, Y: dir.X}, {X: -dir.Y, Y: -dir.X}} {
+				if free(Point{X: p.X + d.X, Y: p.Y + d.Y}) {
+					candidates = append(candidates, p)
+					break
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		at := candidates[rng.Intn(len(candidates))]
+		var sides []Point
+		for _, d := range [2]Point{{X: dir.Y, Y: dir.X}, {X: -dir.Y, Y: -dir.X}} {
+			if free(Point{X: at.X + d.X, Y: at.Y + d.Y}) {
+				sides = append(sides, d)
+			}
+		}
+		next := sides[rng.Intn(len(sides))]
+
+		take(pos, at, dir)
+		orientation := mirrorBackslash
+		if next == reflectBeam(dir, mirrorSlash) {
+			orientation = mirrorSlash
+		}
+		mirrors = append(mirrors, PuzzleFeature{Kind: "mirror", Cell: at, State: rng.Intn(2), Solid: true})
+		want = append(want, orientation)
+		pos, dir = at, next
+	}
+	if len(mirrors) == 0 {
+		return nil, fmt.Errorf("no room to bend the beam on a %dx%d grid", w, h)
+	}
+
+	// The receiver stands somewhere along the last stretch
+	stretch := run(pos, dir)
+	spec.Features[1].Cell = stretch[rng.Intn(len(stretch))]
+	take(pos, spec.Features[1].Cell, dir)
+
+	// Set some mirrors wrong, at least one
+	wrong := false
+	for i := range mirrors {
+		wrong = wrong || mirrors[i].State != want[i]
+	}
+	if !wrong {
+		i := rng.Intn(len(mirrors))
+		mirrors[i].State ^= 1
+	}
+
+	// Scatter decoys off the path
+	var open []Point
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !used[y*w+x] {
+				open = append(open, Point{X: x, Y: y})
+			}
+		}
+	}
+	decoys := min(len(open), int(difficulty*3))
+	for i := 0; i < decoys; i++ {
+		j := i + rng.Intn(len(open)-i)
+		open[i], open[j] = open[j], open[i]
+		mirrors = append(mirrors, PuzzleFeature{Kind: "mirror", Cell: open[i], State: rng.Intn(2), Solid: true})
+	}
+
+	// Shuffle the mirrors so feature order does not give the path away
+	order := make([]int, len(mirrors))
+	for i := range order {
+		order[i] = i
+	}
+	rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	for _, i := range order {
+		if i < len(want) && mirrors[i].State != want[i] {
+			spec.Solution = append(spec.Solution, len(spec.Features))
+		}
+		spec.Features = append(spec.Features, mirrors[i])
+	}
+
+	spec.Params = map[string]int{"mirrors": len(mirrors), "bends": len(want)}
+	return spec, nil
+}
+
+func (beamPuzzle) Verify(spec *PuzzleSpec) error {
+	if len(spec.Features) < 2 || spec.Features[0].Kind != "emitter" || spec.Features[1].Kind != "receiver" {
+		return errors.New("no emitter and receiver")
+	}
+	if traceBeam(spec, spec.Features) {
+		return errors.New("the beam already reaches the receiver")
+	}
+
+	features := append([]PuzzleFeature(nil), spec.Features...)
+	turned := make(map[int]bool, len(spec.Solution))
+	for _, i := range spec.Solution {
+		if i < 0 || i >= len(features) || features[i].Kind != "mirror" {
+			return fmt.Errorf("feature %d is not a mirror", i)
+		}
+		if turned[i] {
+			return fmt.Errorf("mirror %d is turned twice", i)
+		}
+		turned[i] = true
+		features[i].State ^= 1
+	}
+	if !traceBeam(spec, features) {
+		return errors.New("the beam misses the receiver")
+	}
+	return nil
+}
+
+// traceBeam reports whether the emitter's beam reaches the receiver with the
+// features in the given states. The beam stops at the grid's edge and at
+// statues other than mirrors.
+func traceBeam(spec *PuzzleSpec, features []PuzzleFeature) bool {
+	at := make(map[Point]PuzzleFeature, len(features))
+	for _, f := range features {
+		at[f.Cell] = f
+	}
+	emitter := features[0]
+	pos, dir := emitter.Cell, beamDirections[emitter.State&3]
+
+	// A beam still going after visiting every cell in every direction loops
+	for steps := 0; steps < 4*spec.Width*spec.Height; steps++ {
+		pos = Point{X: pos.X + dir.X, Y: pos.Y + dir.Y}
+		if pos.X < 0 || pos.X >= spec.Width || pos.Y < 0 || pos.Y >= spec.Height {
+			return false
+		}
+		f, ok := at[pos]
+		switch {
+		case !ok:
+		case f.Kind == "receiver":
+			return true
+		case f.Kind == "mirror":
+			dir = reflectBeam(dir, f.State)
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// reflectBeam returns the direction a beam heading along d leaves a mirror of
+// the given orientation.
+func reflectBeam(d Point, orientation int) Point {
+	if orientation == mirrorSlash {
+		return Point{X: -d.Y, Y: -d.X}
+	}
+	return Point{X: d.Y, Y: d.X}
+}
+
+// abs returns the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package content
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// trapTypes maps difficulty ranges to trap types.
+var trapTypes = []struct {
+	name          string
+	minDifficulty float64
+	maxDifficulty float64
+}{
+	{"spike_trap", 0.0, 0.4},
+	{"dart_trap", 0.2, 0.6},
+	{"pit_trap", 0.4, 0.8},
+	{"fire_trap", 0.6, 1.0},
+}
+
+// placeTraps places hazards in combat rooms.
+// Each eligible room holds traps with probability trapDensity; harder rooms
+// get more and deadlier traps.
+//
+// Algorithm:
+//  1. Skip safe rooms (same rules as enemy placement) and rooms without difficulty
+//  2. Roll trapDensity per room in sorted room ID order
+//  3. Place 1-3 traps scaled by difficulty, typed by the trap table
+func placeTraps(g *graph.Graph, content *Content, trapDensity float64, rng *rng.RNG) error {
+	if trapDensity <= 0 {
+		return nil
+	}
+
+	trapID := 0
+
+	// Sort room IDs for deterministic iteration
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, roomID := range roomIDs {
+		room := g.Rooms[roomID]
+		if shouldSkipEnemyPlacement(room) || room.Difficulty <= 0 {
+			continue
+		}
+		if rng.Float64() >= trapDensity {
+			continue
+		}
+
+		count := 1 + int(room.Difficulty*2)
+		for i := 0; i < count; i++ {
+			trap := Trap{
+				ID:       fmt.Sprintf("trap_%d", trapID),
+				RoomID:   roomID,
+				Position: Point{X: 0, Y: 0}, // Placeholder - needs layout
+				TrapType: selectTrapType(room.Difficulty, rng),
+				Damage:   10 + int(room.Difficulty*40),
+			}
+
+			if err := trap.Validate(); err != nil {
+				return fmt.Errorf("invalid trap: %w", err)
+			}
+
+			content.Traps = append(content.Traps, trap)
+			trapID++
+		}
+	}
+
+	return nil
+}
+
+// selectTrapType chooses a trap type appropriate for the given difficulty.
+func selectTrapType(difficulty float64, rng *rng.RNG) string {
+	eligible := make([]string, 0, len(trapTypes))
+	for _, tt := range trapTypes {
+		if difficulty >= tt.minDifficulty && difficulty <= tt.maxDifficulty {
+			eligible = append(eligible, tt.name)
+		}
+	}
+
+	if len(eligible) == 0 {
+		return "spike_trap" // Default fallback
+	}
+
+	return eligible[rng.Intn(len(eligible))]
+}
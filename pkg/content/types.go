@@ -16,12 +16,14 @@ func (p Point) String() string {
 // Spawn represents an enemy spawn point in a room.
 // Enemies are placed based on room.Difficulty values.
 type Spawn struct {
-	ID         string  `json:"id"`         // Unique spawn identifier
-	RoomID     string  `json:"roomId"`     // Room containing this spawn
-	Position   Point   `json:"position"`   // Spawn location in tile coords
-	EnemyType  string  `json:"enemyType"`  // Type of enemy to spawn
-	Count      int     `json:"count"`      // Number of enemies at this spawn
-	PatrolPath []Point `json:"patrolPath"` // Optional patrol waypoints
+	ID         string  `json:"id"`                // Unique spawn identifier
+	RoomID     string  `json:"roomId"`            // Room containing this spawn
+	Position   Point   `json:"position"`          // Spawn location in tile coords
+	EnemyType  string  `json:"enemyType"`         // Type of enemy to spawn
+	Count      int     `json:"count"`             // Number of enemies at this spawn
+	PatrolPath []Point `json:"patrolPath"`        // Optional patrol waypoints
+	Ambush     bool    `json:"ambush,omitempty"`  // Lies in wait out of sight of the room's entrances
+	Faction    string  `json:"faction,omitempty"` // Enemy faction, when factions are assigned
 }
 
 // String returns a human-readable representation of a Spawn.
@@ -94,6 +96,10 @@ type PuzzleInstance struct {
 	Requirements []Requirement `json:"requirements"` // What's needed to solve
 	Provides     []Capability  `json:"provides"`     // What solving grants
 	Difficulty   float64       `json:"difficulty"`   // Puzzle difficulty 0.0-1.0
+
+	// Spec is the mechanism built by the generator registered for Type,
+	// nil for types without one and rooms too small to hold it.
+	Spec *PuzzleSpec `json:"spec,omitempty"`
 }
 
 // Requirement represents a prerequisite to solve a puzzle or enter a room.
@@ -160,3 +166,97 @@ func (s *SecretInstance) Validate() error {
 	}
 	return nil
 }
+
+// Trap represents a hazard in a room.
+// Traps are placed in combat rooms according to the trap density.
+type Trap struct {
+	ID       string `json:"id"`       // Unique trap identifier
+	RoomID   string `json:"roomId"`   // Room containing this trap
+	Position Point  `json:"position"` // Trap location in tile coords
+	TrapType string `json:"trapType"` // Type of trap (e.g., "spike_trap", "fire_trap")
+	Damage   int    `json:"damage"`   // Damage dealt when triggered
+}
+
+// String returns a human-readable representation of a Trap.
+func (t Trap) String() string {
+	return fmt.Sprintf("Trap[%s: %s (damage=%d) in %s at %s]",
+		t.ID, t.TrapType, t.Damage, t.RoomID, t.Position)
+}
+
+// Validate checks if the trap data is valid.
+func (t *Trap) Validate() error {
+	if t.ID == "" {
+		return fmt.Errorf("trap ID cannot be empty")
+	}
+	if t.RoomID == "" {
+		return fmt.Errorf("trap %s: RoomID cannot be empty", t.ID)
+	}
+	if t.TrapType == "" {
+		return fmt.Errorf("trap %s: TrapType cannot be empty", t.ID)
+	}
+	if t.Damage <= 0 {
+		return fmt.Errorf("trap %s: Damage must be > 0, got %d", t.ID, t.Damage)
+	}
+	return nil
+}
+
+// PlayerStart represents where one player of a co-op party enters the dungeon.
+// Player starts are placed in rooms adjacent to the Start room.
+type PlayerStart struct {
+	ID       string `json:"id"`       // Unique player start identifier
+	RoomID   string `json:"roomId"`   // Room containing this start point
+	Position Point  `json:"position"` // Start location in tile coords
+	Player   int    `json:"player"`   // Player number (1-based)
+}
+
+// String returns a human-readable representation of a PlayerStart.
+func (p PlayerStart) String() string {
+	return fmt.Sprintf("PlayerStart[%s: player %d in %s at %s]",
+		p.ID, p.Player, p.RoomID, p.Position)
+}
+
+// Validate checks if the player start data is valid.
+func (p *PlayerStart) Validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("player start ID cannot be empty")
+	}
+	if p.RoomID == "" {
+		return fmt.Errorf("player start %s: RoomID cannot be empty", p.ID)
+	}
+	if p.Player < 1 {
+		return fmt.Errorf("player start %s: Player must be >= 1, got %d", p.ID, p.Player)
+	}
+	return nil
+}
+
+// Wave describes the enemies released during one wave of a horde-mode map.
+// Each spawn belongs to a spawner room that has opened by this wave.
+type Wave struct {
+	Index  int     `json:"index"`  // Wave number (1-based)
+	Spawns []Spawn `json:"spawns"` // Enemies released by each active spawner
+}
+
+// String returns a human-readable representation of a Wave.
+func (w Wave) String() string {
+	enemies := 0
+	for _, spawn := range w.Spawns {
+		enemies += spawn.Count
+	}
+	return fmt.Sprintf("Wave[%d: %d enemies from %d spawners]", w.Index, enemies, len(w.Spawns))
+}
+
+// Validate checks if the wave data is valid.
+func (w *Wave) Validate() error {
+	if w.Index < 1 {
+		return fmt.Errorf("wave index must be >= 1, got %d", w.Index)
+	}
+	if len(w.Spawns) == 0 {
+		return fmt.Errorf("wave %d: must release at least one spawn", w.Index)
+	}
+	for i := range w.Spawns {
+		if err := w.Spawns[i].Validate(); err != nil {
+			return fmt.Errorf("wave %d: %w", w.Index, err)
+		}
+	}
+	return nil
+}
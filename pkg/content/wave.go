@@ -0,0 +1,91 @@
+package content
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// buildWaveSchedule describes the waves of a horde-mode map. Spawner rooms are
+// tagged "spawner" with "wave" naming the 1-based wave in which they open.
+// Graphs without spawner rooms get no schedule.
+//
+// Algorithm:
+//  1. Collect spawner rooms and the number of waves (the highest wave tag)
+//  2. For each wave, every spawner opened so far releases a group whose size
+//     grows with the room's enemy difficulty and the wave's share of the total
+//  3. Enemy types follow the group's effective difficulty, and counts are
+//     multiplied by partyScale for co-op parties, up to the room's capacity
+//
+// Group sizes never shrink from one wave to the next, so each wave is at
+// least as large as the one before it.
+func buildWaveSchedule(g *graph.Graph, content *Content, maxEnemiesPerRoom, partySize int, capacities map[string]Capacity, rng *rng.RNG) error {
+	// Step 1: Spawner rooms, ordered by opening wave then ID
+	type spawner struct {
+		room *graph.Room
+		wave int
+	}
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	var spawners []spawner
+	waves := 0
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		if room.Tags["spawner"] != "true" {
+			continue
+		}
+		wave, err := strconv.Atoi(room.Tags["wave"])
+		if err != nil || wave < 1 {
+			return fmt.Errorf("spawner %s has invalid wave tag %q", id, room.Tags["wave"])
+		}
+		spawners = append(spawners, spawner{room: room, wave: wave})
+		waves = max(waves, wave)
+	}
+	sort.SliceStable(spawners, func(i, j int) bool {
+		return spawners[i].wave < spawners[j].wave
+	})
+
+	scale := 1.0
+	if partySize > 1 {
+		scale = partyScale(partySize)
+	}
+
+	// Step 2 and 3: One entry per wave
+	for index := 1; index <= waves; index++ {
+		pressure := 0.5 + 0.5*float64(index)/float64(waves)
+		wave := Wave{Index: index}
+		for _, s := range spawners {
+			if s.wave > index {
+				break
+			}
+			difficulty := math.Min(1.0, enemyDifficulty(s.room)*pressure)
+			count := int(math.Ceil(difficulty * float64(maxEnemiesPerRoom) * scale))
+			count = max(1, min(enemyLimit(capacities, s.room.ID, maxEnemiesPerRoom), count))
+
+			wave.Spawns = append(wave.Spawns, Spawn{
+				ID:        fmt.Sprintf("wave_%d_spawn_%d", index, len(wave.Spawns)),
+				RoomID:    s.room.ID,
+				Position:  Point{X: 0, Y: 0}, // Placeholder - needs layout
+				EnemyType: selectEnemyType(difficulty, rng),
+				Count:     count,
+			})
+		}
+		if len(wave.Spawns) == 0 {
+			return fmt.Errorf("no spawner opens by wave %d", index)
+		}
+		if err := wave.Validate(); err != nil {
+			return fmt.Errorf("invalid wave: %w", err)
+		}
+		content.Waves = append(content.Waves, wave)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package dungeon
+
+import (
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// mirrorArenaContent makes arena content identical for both teams: content in
+// team B rooms is discarded and replaced by copies of the content in their
+// team A mirrors. Copies keep their source ID with a "_mirror" suffix.
+// Content in neutral rooms is left as placed.
+func mirrorArenaContent(c *Content, g *graph.Graph) {
+	if c == nil {
+		return
+	}
+
+	team := func(roomID string) string {
+		if room, ok := g.Rooms[roomID]; ok {
+			return room.Tags["team"]
+		}
+		return ""
+	}
+	mirror := func(roomID string) string {
+		return g.Rooms[roomID].Tags["mirror"]
+	}
+
+	spawns := make([]Spawn, 0, len(c.Spawns))
+	for _, spawn := range c.Spawns {
+		if team(spawn.RoomID) != "b" {
+			spawns = append(spawns, spawn)
+		}
+	}
+	for _, spawn := range spawns {
+		if team(spawn.RoomID) == "a" {
+			spawn.ID += "_mirror"
+			spawn.RoomID = mirror(spawn.RoomID)
+			spawn.PatrolPath = nil // Re-routed for the mirror room after carving
+			spawns = append(spawns, spawn)
+		}
+	}
+	c.Spawns = spawns
+
+	loot := make([]Loot, 0, len(c.Loot))
+	for _, item := range c.Loot {
+		if team(item.RoomID) != "b" {
+			loot = append(loot, item)
+		}
+	}
+	for _, item := range loot {
+		if team(item.RoomID) == "a" {
+			item.ID += "_mirror"
+			item.RoomID = mirror(item.RoomID)
+			loot = append(loot, item)
+		}
+	}
+	c.Loot = loot
+
+	puzzles := make([]PuzzleInstance, 0, len(c.Puzzles))
+	for _, puzzle := range c.Puzzles {
+		if team(puzzle.RoomID) != "b" {
+			puzzles = append(puzzles, puzzle)
+		}
+	}
+	for _, puzzle := range puzzles {
+		if team(puzzle.RoomID) == "a" {
+			puzzle = puzzle.clone()
+			puzzle.ID += "_mirror"
+			puzzle.RoomID = mirror(puzzle.RoomID)
+			puzzles = append(puzzles, puzzle)
+		}
+	}
+	c.Puzzles = puzzles
+
+	secrets := make([]SecretInstance, 0, len(c.Secrets))
+	for _, secret := range c.Secrets {
+		if team(secret.RoomID) != "b" {
+			secrets = append(secrets, secret)
+		}
+	}
+	for _, secret := range secrets {
+		if team(secret.RoomID) == "a" {
+			secret.ID += "_mirror"
+			secret.RoomID = mirror(secret.RoomID)
+			secrets = append(secrets, secret)
+		}
+	}
+	c.Secrets = secrets
+
+	traps := make([]Trap, 0, len(c.Traps))
+	for _, trap := range c.Traps {
+		if team(trap.RoomID) != "b" {
+			traps = append(traps, trap)
+		}
+	}
+	for _, trap := range traps {
+		if team(trap.RoomID) == "a" {
+			trap.ID += "_mirror"
+			trap.RoomID = mirror(trap.RoomID)
+			traps = append(traps, trap)
+		}
+	}
+	c.Traps = traps
+}
@@ -3,9 +3,11 @@ package dungeon
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
 )
 
 // ErrNotImplemented is returned by export methods that are not yet implemented.
@@ -33,13 +35,19 @@ type Capability = graph.Capability
 //	Content - Gameplay elements (enemies, loot, puzzles)
 //	Metrics - Quality measurements (branching, path length, etc.)
 //	Debug - Optional validation reports and visualizations
+//	Warnings - Non-fatal issues found while generating, with codes
+//	Metadata - Game-defined parameters copied from Config.Metadata
+//	Strings - Generated room names and clue texts keyed by stable string IDs
 type Artifact struct {
-	ADG     *Graph
-	Layout  *Layout
-	TileMap *TileMap
-	Content *Content
-	Metrics *Metrics
-	Debug   *DebugArtifacts
+	ADG      *Graph
+	Layout   *Layout
+	TileMap  *TileMap
+	Content  *Content
+	Metrics  *Metrics
+	Debug    *DebugArtifacts
+	Warnings []Warning   `json:",omitempty"`
+	Metadata Metadata    `json:",omitempty"`
+	Strings  StringTable `json:",omitempty"` // Generated text by string ID, for localization
 }
 
 // Point represents a 2D coordinate.
@@ -60,6 +68,14 @@ type Pose struct {
 	FootprintID string // Reference to room template shape
 }
 
+// DoorAnchor is where a corridor meets one of its rooms: the wall tile
+// opened for the door, on the room's north, east, south or west side.
+type DoorAnchor struct {
+	Room string
+	Side string
+	X, Y int
+}
+
 // Path represents a polyline path (for corridors).
 type Path struct {
 	Points []Point
@@ -68,9 +84,10 @@ type Path struct {
 // Layout contains the spatial embedding of the dungeon graph.
 // It maps abstract rooms to concrete positions and corridors to paths.
 type Layout struct {
-	Poses         map[string]Pose // Room ID → position/rotation
-	CorridorPaths map[string]Path // Connector ID → polyline path
-	Bounds        Rect            // Overall dungeon extents
+	Poses         map[string]Pose         // Room ID → position/rotation
+	CorridorPaths map[string]Path         // Connector ID → polyline path
+	Doors         map[string][]DoorAnchor `json:",omitempty"` // Connector ID → door anchors at its From and To rooms
+	Bounds        Rect                    // Overall dungeon extents
 }
 
 // TileMap is the rasterized dungeon with layered tiles.
@@ -116,6 +133,24 @@ type Content struct {
 	Loot    []Loot           // Treasure items
 	Puzzles []PuzzleInstance // Interactive puzzles
 	Secrets []SecretInstance // Hidden elements
+	Traps   []Trap           // Hazards
+
+	PlayerStarts []PlayerStart // Co-op start points, one per player (empty for single player)
+	Waves        []Wave        // Horde-mode spawn schedule in wave order (empty outside wave mode)
+	Timers       []Timer       // Patrol, trap re-arm and door auto-close timing, by kind in entity order
+	Escape       *Escape       // Post-boss escape route and collapse schedule (nil unless Config.Escape is enabled)
+
+	Capacity map[string]RoomCapacity // Room ID → content budget
+}
+
+// RoomCapacity is the content budget of a room, derived from its carved floor
+// area and archetype. Placed content stays within it; runtimes spawning extra
+// entities should too.
+type RoomCapacity struct {
+	FloorTiles int // Walkable floor tiles in the room
+	Enemies    int // Most enemies the room holds at once
+	Props      int // Most props (crates, furniture, decor) the room holds
+	LootSlots  int // Most loot pickups the room holds
 }
 
 // Spawn represents an enemy spawn point.
@@ -126,6 +161,8 @@ type Spawn struct {
 	EnemyType  string  // Reference to encounter table entry
 	Count      int     // Number of enemies (1-10)
 	PatrolPath []Point // Optional waypoints
+	Ambush     bool    // Lies in wait out of sight of the room's entrances
+	Faction    string  `json:",omitempty"` // Enemy faction (set when content.factions is enabled)
 }
 
 // Loot represents a treasure item.
@@ -136,6 +173,12 @@ type Loot struct {
 	ItemType string // Reference to loot table entry
 	Value    int    // Gold/experience worth
 	Required bool   // Required for progression (keys, abilities)
+
+	// Key is the name of the key (KeyCfg.Name) this loot is, empty for
+	// other loot; its display name and description are in the string table
+	// under KeyNameID and KeyDescriptionID. Icon is the key's icon.
+	Key  string `json:",omitempty"`
+	Icon string `json:",omitempty"`
 }
 
 // PuzzleInstance represents an interactive challenge.
@@ -146,32 +189,170 @@ type PuzzleInstance struct {
 	Requirements []Requirement // Prerequisites
 	Provides     []Capability  // Grants on solve
 	Difficulty   float64       // Challenge rating (0.0-1.0)
+
+	// Grid, Params, Features and Solution are the mechanism built by the
+	// generator registered for Type (see content.RegisterPuzzle), empty for
+	// types without one. Grid is the tiles the features stand on, centered
+	// in the room's floor; Solution lists the features to act on, by index
+	// and in order: levers to pull, plates to step on, mirrors to turn.
+	Grid     Rect            `json:",omitzero"`
+	Params   map[string]int  `json:",omitempty"`
+	Features []PuzzleFeature `json:",omitempty"`
+	Solution []int           `json:",omitempty"`
+}
+
+// PuzzleFeature is an object a puzzle needs in its room, such as a lever, a
+// pressure plate or a mirror statue.
+type PuzzleFeature struct {
+	Kind     string // e.g. "lever", "plate", "mirror", "emitter", "receiver"
+	Cell     Point  // Cell of the puzzle grid
+	Position Point  // Tile the feature stands on
+	State    int    // Initial state: lever up (1) or down, emitter direction, mirror orientation
+	Solid    bool   `json:",omitempty"` // Blocks movement, like a statue
+	Targets  []int  `json:",omitempty"` // Features it acts on, by index
+}
+
+// clone returns a copy of p whose features can be moved without touching
+// p's.
+func (p PuzzleInstance) clone() PuzzleInstance {
+	p.Features = append([]PuzzleFeature(nil), p.Features...)
+	return p
 }
 
 // SecretInstance represents a hidden element.
 type SecretInstance struct {
 	ID       string   // Unique identifier
 	RoomID   string   // Parent room
-	Type     string   // Secret type
+	Type     string   // Secret kind, see carving.SecretKinds
 	Position Point    // Location within room
 	Clues    []string // Hints for discovery
+	ClueIDs  []string // String table ID of each clue's text (see ClueID)
+
+	// CluePosition is the floor tile of the clue prop hinting at the secret,
+	// in sight of the main path where the map allows. A shrine riddle's clue
+	// is its shrine.
+	CluePosition Point
+}
+
+// Trap represents a hazard.
+type Trap struct {
+	ID       string // Unique identifier
+	RoomID   string // Parent room
+	Position Point  // Location within room
+	TrapType string // Trap mechanism ("spike_trap", "fire_trap")
+	Damage   int    // Damage dealt when triggered
+}
+
+// PlayerStart represents a co-op player's start point.
+type PlayerStart struct {
+	ID       string // Unique identifier
+	RoomID   string // Room adjacent to (or equal to) the Start room
+	Position Point  // Location within room
+	Player   int    // Player number (1-based)
+}
+
+// Wave describes the enemies released during one wave of a horde-mode map.
+type Wave struct {
+	Index  int     // Wave number (1-based)
+	Spawns []Spawn // One group per spawner room open by this wave
 }
 
 // Metrics contains generation statistics and measurements.
 type Metrics struct {
 	BranchingFactor   float64 // Actual average connections per room
 	PathLength        int     // Start→Boss path length
-	CycleCount        int     // Number of graph cycles
+	CycleCount        int     // Number of independent graph cycles
 	PacingDeviation   float64 // L2 distance from target difficulty curve
 	SecretFindability float64 // Heuristic score (0.0-1.0)
+	SpeedrunRooms     int     // Rooms entered on the optimal completion route
+	SpeedrunTiles     int     // Tiles walked on the optimal completion route
+	SpeedrunRevisits  int     // Rooms re-entered on the optimal completion route, counting every repeat
+	SymmetryScore     float64 // Arena mirror checks passed (0.0-1.0, 0 outside arena mode)
+	TeamBalance       float64 // Arena content fairness between teams (0.0-1.0, 0 outside arena mode)
+	EnvironmentShare  float64 // Share of combat difficulty carried by hazards, darkness and slow terrain (0.0-1.0)
+	FloorArea         int     // Floor tiles carved for rooms and corridors
+
+	// Layout quality
+	CorridorLength         int     // Total corridor path length in tiles
+	CorridorLengthVariance float64 // Variance of the individual corridor lengths
+	BoundsUtilization      float64 // Share of the layout bounding box covered by rooms (0.0-1.0)
+	CorridorCrossings      int     // Points where corridor paths cross each other
+	RoomSpacing            float64 // Average gap in tiles from each room to its nearest neighbour
+
+	// Difficulty breaks the pacing down by room
+	Difficulty *DifficultyProfile `json:",omitempty"`
+}
+
+// DifficultyProfile is the per-room breakdown behind PacingDeviation: each
+// room's difficulty against the pacing curve, the threat of its content and
+// its hazard coverage, with percentile summaries across rooms.
+type DifficultyProfile struct {
+	Rooms []RoomDifficulty // In order of progress, then room ID
+
+	Difficulty     Percentiles // Room difficulty
+	Deviation      Percentiles // Room difficulty minus target
+	Threat         Percentiles // Enemies over enemy capacity
+	HazardCoverage Percentiles // Share of floor tiles that are hazards
+}
+
+// RoomDifficulty is one room's contribution to the difficulty profile.
+type RoomDifficulty struct {
+	Room           string  // Room ID
+	Archetype      string  // Room archetype
+	Progress       float64 // Position along the critical path, or that of the path rooms next to it (0.0-1.0)
+	CriticalPath   bool    // On the shortest path from Start to Boss
+	Difficulty     float64 // Room difficulty (0.0-1.0)
+	Target         float64 // Pacing curve value at Progress
+	Deviation      float64 // Difficulty minus Target
+	Enemies        int     // Enemies spawned in the room
+	Traps          int     // Traps placed in the room
+	Threat         float64 // Enemies over the room's enemy capacity (0 without capacity)
+	HazardCoverage float64 // Share of the room's floor tiles that are hazards (0.0-1.0)
+}
+
+// Percentiles summarizes a per-room value across a dungeon. Percentiles use
+// linear interpolation between the closest ranks.
+type Percentiles struct {
+	Min float64
+	P25 float64
+	P50 float64
+	P75 float64
+	P90 float64
+	Max float64
 }
 
 // DebugArtifacts contains optional debug outputs.
 // These are generated when debug mode is enabled in the configuration.
 type DebugArtifacts struct {
-	ADGSVG    []byte            // SVG visualization of graph
-	LayoutPNG []byte            // Heatmap overlay image
-	Report    *ValidationReport // Detailed validation metrics
+	ADGSVG    []byte             // SVG visualization of graph
+	LayoutPNG []byte             // Heatmap overlay image
+	Report    *ValidationReport  // Detailed validation metrics
+	Stages    []StageSeed        // RNG seed of each stage that drew randomness, in pipeline order
+	Embedding *EmbeddingFallback // Set when the first layout was rejected as pathological
+	Quality   *QualityRetry      `json:",omitempty"` // Set when quality gates rejected the dungeons of earlier seeds
+	StoppedAt string             `json:",omitempty"` // Last stage run when Config.StopAfter ended the pipeline early
+}
+
+// EmbeddingFallback records an embedding that was laid out again: the
+// strategies rejected first, each as "strategy: reason", and the embedder
+// whose layout was kept.
+type EmbeddingFallback struct {
+	Embedder string   `json:"embedder"`
+	Rejected []string `json:"rejected"`
+}
+
+// StageSeed records the RNG of one pipeline stage: the sub-seed derived
+// from the master seed, stage name and config hash. A stage re-run with the
+// RNG from StageSeed.RNG draws exactly the stream it drew during generation.
+type StageSeed struct {
+	Stage      string `json:"stage"`      // RNG stage name, e.g. "synthesis", "content"
+	Seed       uint64 `json:"seed"`       // Derived sub-seed of the stage
+	ConfigHash string `json:"configHash"` // Hex Config.Hash() the seed was derived with
+}
+
+// RNG returns a fresh RNG for the stage, at the start of its stream.
+func (s StageSeed) RNG() *rng.RNG {
+	return rng.NewRNGFromSeed(s.Seed, s.Stage)
 }
 
 // ValidationReport contains validation results and constraint satisfaction.
@@ -205,24 +386,45 @@ func (a *Artifact) ExportJSONCompact() ([]byte, error) {
 	return json.Marshal(a)
 }
 
-// SaveJSON exports the artifact to a JSON file with indentation.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func (a *Artifact) SaveJSON(path string) error {
+// SaveJSON exports the artifact to a JSON file with indentation, written
+// with WriteFile.
+func (a *Artifact) SaveJSON(path string, options ...WriteOption) error {
 	data, err := a.ExportJSON()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return WriteFile(path, data, options...)
 }
 
-// SaveJSONCompact exports the artifact to a compact JSON file.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func (a *Artifact) SaveJSONCompact(path string) error {
+// SaveJSONCompact exports the artifact to a compact JSON file with WriteFile.
+func (a *Artifact) SaveJSONCompact(path string, options ...WriteOption) error {
 	data, err := a.ExportJSONCompact()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	return WriteFile(path, data, options...)
+}
+
+// LoadArtifact reads an artifact JSON file written by SaveJSON or
+// SaveJSONCompact, such as one to recarve.
+func LoadArtifact(path string) (*Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading artifact file: %w", err)
+	}
+	return LoadArtifactFromBytes(data)
+}
+
+// LoadArtifactFromBytes parses artifact JSON and checks it has a graph.
+func LoadArtifactFromBytes(data []byte) (*Artifact, error) {
+	var a Artifact
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("parsing artifact: %w", err)
+	}
+	if a.ADG == nil || a.ADG.Graph == nil || len(a.ADG.Graph.Rooms) == 0 {
+		return nil, fmt.Errorf("artifact has no graph")
+	}
+	return &a, nil
 }
 
 // ExportTMJ exports the artifact to Tiled TMJ (JSON map) format.
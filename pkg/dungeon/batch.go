@@ -0,0 +1,137 @@
+package dungeon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultBatchAttempts is the number of candidate seeds GenerateBatch tries
+// per level before giving up.
+const DefaultBatchAttempts = 10
+
+// SimilarityFunc scores how similar two dungeons are, from 0.0 (nothing in
+// common) to 1.0 (indistinguishable). validation.SimilarityScore is the
+// standard implementation.
+type SimilarityFunc func(a, b *Artifact) float64
+
+// BatchOptions configures GenerateBatch.
+type BatchOptions struct {
+	// MaxSimilarity rejects a candidate whose similarity to any accepted
+	// dungeon exceeds it, guaranteeing a minimum diversity across the batch.
+	// 0 disables the check.
+	MaxSimilarity float64
+
+	// Similarity compares candidates to accepted dungeons. Required when
+	// MaxSimilarity is set.
+	Similarity SimilarityFunc
+
+	// MaxAttempts is the number of candidates tried per level, including the
+	// accepted one (0 = DefaultBatchAttempts).
+	MaxAttempts int
+}
+
+// BatchLevel is one dungeon of a batch.
+type BatchLevel struct {
+	Seed     uint64    // Seed the dungeon was generated from
+	Artifact *Artifact // Generated dungeon
+	Rejected int       // Candidates rejected before this one was accepted
+}
+
+// GenerateBatch generates n dungeons from cfg, such as the levels of a
+// campaign. Candidates are generated from consecutive seeds starting at the
+// config seed; a candidate that fails to generate, or is more similar than
+// opts.MaxSimilarity to a dungeon already accepted, is rejected and the next
+// seed tried. The batch is deterministic: the same config and options accept
+// the same seeds.
+//
+// When a level exhausts opts.MaxAttempts candidates, GenerateBatch fails
+// with a StageError for the "batch" stage, which matches ErrRetryExhausted.
+// cfg is not modified.
+func GenerateBatch(ctx context.Context, gen Generator, cfg *Config, n int, opts BatchOptions) ([]BatchLevel, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", n)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	levels := make([]BatchLevel, 0, n)
+	seed := cfg.Seed
+	for len(levels) < n {
+		level, err := nextLevel(ctx, gen, cfg, seed, levels, opts)
+		if err != nil {
+			return nil, fmt.Errorf("level %d: %w", len(levels), err)
+		}
+		levels = append(levels, level)
+		seed = level.Seed + 1
+	}
+	return levels, nil
+}
+
+// validate checks BatchOptions constraints.
+func (o *BatchOptions) validate() error {
+	if o.MaxSimilarity < 0 || o.MaxSimilarity > 1 {
+		return fmt.Errorf("max similarity must be in [0.0, 1.0], got %.2f", o.MaxSimilarity)
+	}
+	if o.MaxSimilarity > 0 && o.Similarity == nil {
+		return fmt.Errorf("max similarity requires a similarity function")
+	}
+	if o.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must be non-negative, got %d", o.MaxAttempts)
+	}
+	return nil
+}
+
+// nextLevel generates candidates from cfg with consecutive seeds starting at
+// seed until one generates and is diverse enough from the accepted levels.
+func nextLevel(ctx context.Context, gen Generator, cfg *Config, seed uint64, accepted []BatchLevel, opts BatchOptions) (BatchLevel, error) {
+	attempts := opts.MaxAttempts
+	if attempts == 0 {
+		attempts = DefaultBatchAttempts
+	}
+
+	candidate := *cfg
+	var last error
+	for rejected := 0; rejected < attempts; rejected++ {
+		candidate.Seed = seed + uint64(rejected)
+		artifact, err := gen.Generate(ctx, &candidate)
+		if errors.Is(err, ErrCancelled) {
+			return BatchLevel{}, err
+		}
+		if err != nil {
+			last = err
+			continue
+		}
+		if similar := tooSimilar(artifact, accepted, opts); similar >= 0 {
+			last = fmt.Errorf("seed %d is too similar to level %d (seed %d)", candidate.Seed, similar, accepted[similar].Seed)
+			continue
+		}
+		return BatchLevel{Seed: candidate.Seed, Artifact: artifact, Rejected: rejected}, nil
+	}
+	return BatchLevel{}, &StageError{
+		Stage:    "batch",
+		Attempts: attempts,
+		Err:      fmt.Errorf("no acceptable candidate: %w", last),
+	}
+}
+
+// tooSimilar returns the index of the first accepted level artifact is more
+// similar to than opts allows, or -1.
+func tooSimilar(artifact *Artifact, levels []BatchLevel, opts BatchOptions) int {
+	if opts.MaxSimilarity <= 0 {
+		return -1
+	}
+	for i, level := range levels {
+		if opts.Similarity(artifact, level.Artifact) > opts.MaxSimilarity {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,73 @@
+package dungeon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestGenerateBatch verifies batches respect the similarity threshold, are
+// deterministic, and fail when no candidate is diverse enough.
+func TestGenerateBatch(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          300,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 15},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	opts := dungeon.BatchOptions{MaxSimilarity: 0.5, Similarity: validation.SimilarityScore, MaxAttempts: 20}
+
+	levels, err := dungeon.GenerateBatch(context.Background(), gen, cfg, 4, opts)
+	if err != nil {
+		t.Fatalf("GenerateBatch failed: %v", err)
+	}
+	if len(levels) != 4 {
+		t.Fatalf("got %d levels, want 4", len(levels))
+	}
+	rejected := 0
+	for i := range levels {
+		rejected += levels[i].Rejected
+		for j := i + 1; j < len(levels); j++ {
+			if s := validation.SimilarityScore(levels[i].Artifact, levels[j].Artifact); s > opts.MaxSimilarity {
+				t.Errorf("levels %d and %d have similarity %.3f, above %.2f", i, j, s, opts.MaxSimilarity)
+			}
+		}
+	}
+	if rejected == 0 {
+		t.Error("expected the threshold to reject some candidates")
+	}
+	if cfg.Seed != 300 {
+		t.Errorf("config seed changed to %d", cfg.Seed)
+	}
+
+	again, err := dungeon.GenerateBatch(context.Background(), gen, cfg, 4, opts)
+	if err != nil {
+		t.Fatalf("second GenerateBatch failed: %v", err)
+	}
+	for i := range levels {
+		if again[i].Seed != levels[i].Seed || again[i].Rejected != levels[i].Rejected {
+			t.Errorf("level %d: seed %d (%d rejected), want seed %d (%d rejected)",
+				i, again[i].Seed, again[i].Rejected, levels[i].Seed, levels[i].Rejected)
+		}
+	}
+
+	strict := dungeon.BatchOptions{MaxSimilarity: 0.01, Similarity: validation.SimilarityScore, MaxAttempts: 3}
+	_, err = dungeon.GenerateBatch(context.Background(), gen, cfg, 2, strict)
+	if !errors.Is(err, dungeon.ErrRetryExhausted) {
+		t.Fatalf("error %v does not match ErrRetryExhausted", err)
+	}
+	var stage *dungeon.StageError
+	if !errors.As(err, &stage) || stage.Stage != "batch" || stage.Attempts != 3 {
+		t.Errorf("StageError = %+v, want batch after 3 attempts", stage)
+	}
+
+	if _, err := dungeon.GenerateBatch(context.Background(), gen, cfg, 2, dungeon.BatchOptions{MaxSimilarity: 0.5}); err == nil {
+		t.Error("expected an error for a threshold without a similarity function")
+	}
+}
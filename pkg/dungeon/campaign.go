@@ -0,0 +1,290 @@
+package dungeon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/synthesis"
+)
+
+// campaignKeys names the key types campaign levels introduce, in order.
+var campaignKeys = []string{"bronze", "silver", "gold", "crystal", "obsidian"}
+
+// LevelProfile is the target of one campaign level. Levels between two
+// profiles interpolate them, so mechanics escalate gradually: a campaign
+// going from no keys at level 1 to 3 keys at level 10 introduces the keys
+// one at a time along the way.
+type LevelProfile struct {
+	// Level is the 1-based level number the profile applies to.
+	Level int `yaml:"level" json:"level"`
+
+	// Rooms is the target room count (10-300).
+	Rooms int `yaml:"rooms" json:"rooms"`
+
+	// Difficulty is the preset whose pacing and content the level uses.
+	// Empty means DifficultyNormal.
+	Difficulty Difficulty `yaml:"difficulty,omitempty" json:"difficulty,omitempty"`
+
+	// Keys is the number of key types to find (0-5).
+	Keys int `yaml:"keys,omitempty" json:"keys,omitempty"`
+
+	// SecretDensity is the target ratio of secret rooms (0.0-0.3).
+	SecretDensity float64 `yaml:"secretDensity,omitempty" json:"secretDensity,omitempty"`
+}
+
+// Validate checks LevelProfile constraints.
+func (p *LevelProfile) Validate() error {
+	if p.Level < 1 {
+		return fmt.Errorf("level must be at least 1, got %d", p.Level)
+	}
+	if p.Rooms < synthesis.MinRooms || p.Rooms > synthesis.MaxRooms {
+		return fmt.Errorf("rooms must be in range [%d, %d], got %d", synthesis.MinRooms, synthesis.MaxRooms, p.Rooms)
+	}
+	if p.Difficulty != "" {
+		if _, ok := DifficultyPresets[p.Difficulty]; !ok {
+			return fmt.Errorf("unknown difficulty preset %q, must be one of: casual, normal, brutal", p.Difficulty)
+		}
+	}
+	if p.Keys < 0 || p.Keys > len(campaignKeys) {
+		return fmt.Errorf("keys must be in range [0, %d], got %d", len(campaignKeys), p.Keys)
+	}
+	if p.SecretDensity < 0 || p.SecretDensity > 0.3 {
+		return fmt.Errorf("secretDensity must be in range [0.0, 0.3], got %f", p.SecretDensity)
+	}
+	return nil
+}
+
+// PlanCampaign returns the config of each of levels campaign levels, derived
+// from base by overriding its size, pacing, content, keys and secret density
+// with the level's profile. Levels before the first profile or after the
+// last take that profile; levels between two profiles interpolate their
+// numbers, and take the difficulty curve of the nearer one.
+//
+// Each level is seeded with SeedForLevel. base is not modified.
+func PlanCampaign(base *Config, levels int, profiles []LevelProfile) ([]*Config, error) {
+	if base == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if base.Seed == 0 {
+		return nil, fmt.Errorf("campaign base config needs a fixed seed")
+	}
+	if levels <= 0 {
+		return nil, fmt.Errorf("level count must be positive, got %d", levels)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("campaign needs at least one level profile")
+	}
+
+	sorted := append([]LevelProfile(nil), profiles...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Level < sorted[j].Level })
+	for i := range sorted {
+		if err := sorted[i].Validate(); err != nil {
+			return nil, fmt.Errorf("profile %d: %w", i, err)
+		}
+		if sorted[i].Level > levels {
+			return nil, fmt.Errorf("profile %d: level %d is beyond the campaign's %d levels", i, sorted[i].Level, levels)
+		}
+		if i > 0 && sorted[i].Level == sorted[i-1].Level {
+			return nil, fmt.Errorf("level %d has more than one profile", sorted[i].Level)
+		}
+	}
+
+	configs := make([]*Config, levels)
+	for level := 1; level <= levels; level++ {
+		cfg := levelConfig(base, sorted, level)
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("level %d: %w", level, invalidConfig(err))
+		}
+		configs[level-1] = cfg
+	}
+	return configs, nil
+}
+
+// levelConfig returns the config of a level from the sorted profiles.
+func levelConfig(base *Config, profiles []LevelProfile, level int) *Config {
+	// Find the profiles around the level
+	next := sort.Search(len(profiles), func(i int) bool { return profiles[i].Level >= level })
+	from, to := profiles[max(next-1, 0)], profiles[min(next, len(profiles)-1)]
+	t := 0.0
+	if to.Level > from.Level {
+		t = float64(level-from.Level) / float64(to.Level-from.Level)
+	}
+	lerp := func(a, b float64) float64 { return a + (b-a)*t }
+
+	fromPreset, toPreset := presetOf(from.Difficulty), presetOf(to.Difficulty)
+	nearer := from
+	if t > 0.5 {
+		nearer = to
+	}
+
+	cfg := *base
+	cfg.Seed = SeedForLevel(base.Seed, level)
+
+	rooms := int(math.Round(lerp(float64(from.Rooms), float64(to.Rooms))))
+	spread := max(rooms/10, 1)
+	cfg.Size = SizeCfg{
+		RoomsMin: max(rooms-spread, synthesis.MinRooms),
+		RoomsMax: min(rooms+spread, synthesis.MaxRooms),
+	}
+
+	cfg.Difficulty = nearer.Difficulty
+	if cfg.Difficulty == "" {
+		cfg.Difficulty = DifficultyNormal
+	}
+	cfg.Pacing = PacingCfg{
+		Curve:    presetOf(nearer.Difficulty).Pacing.Curve,
+		Variance: lerp(fromPreset.Pacing.Variance, toPreset.Pacing.Variance),
+	}
+	cfg.Content.SpawnDensity = lerp(fromPreset.Content.SpawnDensity, toPreset.Content.SpawnDensity)
+	cfg.Content.LootBudget = int(math.Round(lerp(float64(fromPreset.Content.LootBudget), float64(toPreset.Content.LootBudget))))
+	cfg.Content.TrapDensity = lerp(fromPreset.Content.TrapDensity, toPreset.Content.TrapDensity)
+
+	cfg.Keys = nil
+	keys := int(math.Round(lerp(float64(from.Keys), float64(to.Keys))))
+	for _, name := range campaignKeys[:keys] {
+		cfg.Keys = append(cfg.Keys, KeyCfg{Name: name, Count: 1})
+	}
+	cfg.SecretDensity = lerp(from.SecretDensity, to.SecretDensity)
+	return &cfg
+}
+
+// presetOf returns the settings of a difficulty preset, or of
+// DifficultyNormal when d is empty.
+func presetOf(d Difficulty) DifficultyPreset {
+	if d == "" {
+		d = DifficultyNormal
+	}
+	return DifficultyPresets[d]
+}
+
+// SeedForLevel derives the seed of a campaign level from the campaign's
+// base seed. Seeds are never 0, which would request a random seed.
+func SeedForLevel(base uint64, level int) uint64 {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], base)
+	h.Write(buf[:])
+	h.Write([]byte(fmt.Sprintf("campaign:level-%d", level)))
+
+	seed := binary.BigEndian.Uint64(h.Sum(nil)[:8])
+	if seed == 0 {
+		seed = 1
+	}
+	return seed
+}
+
+// Campaign is an ordered set of generated levels and their manifest.
+type Campaign struct {
+	Manifest  *CampaignManifest
+	Configs   []*Config   // Config of each level, seeded with the accepted seed
+	Artifacts []*Artifact // Dungeon of each level
+}
+
+// CampaignManifest describes the levels of a campaign, for games shipping
+// the seeds and configs they regenerate levels from.
+type CampaignManifest struct {
+	BaseSeed uint64          `json:"baseSeed"`
+	Levels   []CampaignLevel `json:"levels"` // In level order
+}
+
+// CampaignLevel is one level of a campaign manifest.
+type CampaignLevel struct {
+	Level      int        `json:"level"`                // 1-based level number
+	Seed       uint64     `json:"seed"`                 // Seed of the accepted dungeon
+	ConfigHash string     `json:"configHash"`           // Hex Config.Hash() of the level config
+	Difficulty Difficulty `json:"difficulty"`           // Preset of the level's difficulty curve
+	Rooms      int        `json:"rooms"`                // Rooms generated
+	Keys       []string   `json:"keys,omitempty"`       // Key types to find
+	Introduces []string   `json:"introduces,omitempty"` // Mechanics first appearing in this level
+	Metrics    *Metrics   `json:"metrics,omitempty"`
+}
+
+// ExportJSON serializes the manifest to JSON with indentation.
+func (m *CampaignManifest) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// SaveJSON writes the manifest to a JSON file with WriteFile.
+func (m *CampaignManifest) SaveJSON(path string, options ...WriteOption) error {
+	data, err := m.ExportJSON()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, options...)
+}
+
+// GenerateCampaign plans the campaign with PlanCampaign and generates each
+// level in order. A level that fails to generate, or is more similar than
+// opts.MaxSimilarity to an earlier level, is retried from the next seed as
+// in GenerateBatch.
+//
+// The manifest lists the mechanics each level introduces: traps, secrets
+// and each key type ("key:silver"), the first time they appear.
+func GenerateCampaign(ctx context.Context, gen Generator, base *Config, levels int, profiles []LevelProfile, opts BatchOptions) (*Campaign, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	configs, err := PlanCampaign(base, levels, profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := &Campaign{Manifest: &CampaignManifest{BaseSeed: base.Seed, Levels: []CampaignLevel{}}}
+	var accepted []BatchLevel
+	introduced := make(map[string]bool)
+	for i, cfg := range configs {
+		level, err := nextLevel(ctx, gen, cfg, cfg.Seed, accepted, opts)
+		if err != nil {
+			return nil, fmt.Errorf("level %d: %w", i+1, err)
+		}
+		accepted = append(accepted, level)
+
+		levelCfg := *cfg
+		levelCfg.Seed = level.Seed
+		entry := CampaignLevel{
+			Level:      i + 1,
+			Seed:       level.Seed,
+			ConfigHash: hex.EncodeToString(levelCfg.Hash()),
+			Difficulty: levelCfg.Difficulty,
+			Metrics:    level.Artifact.Metrics,
+		}
+		if level.Artifact.ADG != nil && level.Artifact.ADG.Graph != nil {
+			entry.Rooms = len(level.Artifact.ADG.Rooms)
+		}
+		for _, mechanic := range levelMechanics(&levelCfg) {
+			if !introduced[mechanic] {
+				introduced[mechanic] = true
+				entry.Introduces = append(entry.Introduces, mechanic)
+			}
+		}
+		for _, key := range levelCfg.Keys {
+			entry.Keys = append(entry.Keys, key.Name)
+		}
+
+		campaign.Manifest.Levels = append(campaign.Manifest.Levels, entry)
+		campaign.Configs = append(campaign.Configs, &levelCfg)
+		campaign.Artifacts = append(campaign.Artifacts, level.Artifact)
+	}
+	return campaign, nil
+}
+
+// levelMechanics lists the mechanics a level config enables.
+func levelMechanics(cfg *Config) []string {
+	var mechanics []string
+	if cfg.Content.TrapDensity > 0 {
+		mechanics = append(mechanics, "traps")
+	}
+	if cfg.SecretDensity > 0 {
+		mechanics = append(mechanics, "secrets")
+	}
+	for _, key := range cfg.Keys {
+		mechanics = append(mechanics, "key:"+key.Name)
+	}
+	return mechanics
+}
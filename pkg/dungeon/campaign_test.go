@@ -0,0 +1,96 @@
+package dungeon_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestGenerateCampaign verifies a campaign escalates from its first profile
+// to its last, introducing keys one at a time, and emits a manifest.
+func TestGenerateCampaign(t *testing.T) {
+	base := &dungeon.Config{
+		Seed:          77,
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	profiles := []dungeon.LevelProfile{
+		{Level: 5, Rooms: 40, Difficulty: dungeon.DifficultyBrutal, Keys: 3, SecretDensity: 0.1},
+		{Level: 1, Rooms: 12, Difficulty: dungeon.DifficultyCasual},
+	}
+
+	configs, err := dungeon.PlanCampaign(base, 5, profiles)
+	if err != nil {
+		t.Fatalf("PlanCampaign failed: %v", err)
+	}
+	for i := 1; i < len(configs); i++ {
+		prev, cur := configs[i-1], configs[i]
+		if cur.Size.RoomsMax < prev.Size.RoomsMax || len(cur.Keys) < len(prev.Keys) || len(cur.Keys) > len(prev.Keys)+1 {
+			t.Errorf("level %d does not escalate gradually: rooms %d->%d, keys %d->%d",
+				i+1, prev.Size.RoomsMax, cur.Size.RoomsMax, len(prev.Keys), len(cur.Keys))
+		}
+		if cur.Content.SpawnDensity < prev.Content.SpawnDensity {
+			t.Errorf("level %d spawn density drops from %.2f to %.2f", i+1, prev.Content.SpawnDensity, cur.Content.SpawnDensity)
+		}
+	}
+	if configs[0].Difficulty != dungeon.DifficultyCasual || configs[4].Difficulty != dungeon.DifficultyBrutal {
+		t.Errorf("difficulties = %s..%s, want casual..brutal", configs[0].Difficulty, configs[4].Difficulty)
+	}
+	if base.Seed != 77 || base.Keys != nil {
+		t.Error("PlanCampaign modified the base config")
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	campaign, err := dungeon.GenerateCampaign(context.Background(), gen, base, 5, profiles, dungeon.BatchOptions{MaxAttempts: 20})
+	if err != nil {
+		t.Fatalf("GenerateCampaign failed: %v", err)
+	}
+	levels := campaign.Manifest.Levels
+	if len(levels) != 5 || len(campaign.Artifacts) != 5 {
+		t.Fatalf("got %d levels and %d artifacts, want 5", len(levels), len(campaign.Artifacts))
+	}
+	if got := levels[4].Keys; !slices.Equal(got, []string{"bronze", "silver", "gold"}) {
+		t.Errorf("last level keys = %v, want bronze, silver and gold", got)
+	}
+	var introduced []string
+	for _, level := range levels {
+		keys := 0
+		for _, mechanic := range level.Introduces {
+			if strings.HasPrefix(mechanic, "key:") {
+				keys++
+			}
+		}
+		if keys > 1 {
+			t.Errorf("level %d introduces %d keys at once", level.Level, keys)
+		}
+		introduced = append(introduced, level.Introduces...)
+	}
+	for _, want := range []string{"traps", "secrets", "key:bronze", "key:silver", "key:gold"} {
+		if !slices.Contains(introduced, want) {
+			t.Errorf("no level introduces %s (introduced %v)", want, introduced)
+		}
+	}
+
+	// The manifest regenerates each level
+	again, err := gen.Generate(context.Background(), campaign.Configs[2])
+	if err != nil {
+		t.Fatalf("regenerating level 3 failed: %v", err)
+	}
+	if again.Metrics.PathLength != levels[2].Metrics.PathLength || len(again.ADG.Rooms) != levels[2].Rooms {
+		t.Error("level 3 does not regenerate from its config")
+	}
+	data, err := campaign.Manifest.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var decoded dungeon.CampaignManifest
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Levels) != 5 {
+		t.Errorf("manifest does not round-trip: %v", err)
+	}
+}
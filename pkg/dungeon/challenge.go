@@ -0,0 +1,176 @@
+package dungeon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Period is how long a challenge dungeon lasts before the next one.
+type Period string
+
+const (
+	// PeriodDaily challenges start at midnight UTC.
+	PeriodDaily Period = "daily"
+
+	// PeriodWeekly challenges start on Monday at midnight UTC, numbered by
+	// ISO week.
+	PeriodWeekly Period = "weekly"
+
+	// PeriodMonthly challenges start on the first of the month at midnight UTC.
+	PeriodMonthly Period = "monthly"
+)
+
+// Validate checks that the period is known.
+func (p Period) Validate() error {
+	switch p {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly:
+		return nil
+	}
+	return fmt.Errorf("unknown period %q, must be one of: daily, weekly, monthly", p)
+}
+
+// PeriodStart returns the start of the period containing t. Periods are
+// counted in UTC, so players in every timezone share them. Unknown periods
+// are daily.
+func PeriodStart(t time.Time, period Period) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeekly:
+		// Monday is day 0 of an ISO week
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// PeriodEnd returns the end of the period containing t, which is the start of
+// the next one.
+func PeriodEnd(t time.Time, period Period) time.Time {
+	start := PeriodStart(t, period)
+	switch period {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// PeriodKey names the period containing t: "2026-10-17" for days, "2026-W42"
+// for ISO weeks and "2026-10" for months.
+func PeriodKey(t time.Time, period Period) string {
+	start := PeriodStart(t, period)
+	switch period {
+	case PeriodWeekly:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case PeriodMonthly:
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+// SeedForDate derives the seed of the challenge dungeon for the period
+// containing t from a base seed. Every time within one UTC period gives the
+// same seed, wherever it is observed, so all players generating from the
+// same base config get the same dungeon. Seeds are never 0, which would
+// request a random seed.
+func SeedForDate(base uint64, t time.Time, period Period) uint64 {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], base)
+	h.Write(buf[:])
+	h.Write([]byte("challenge:" + PeriodKey(t, period)))
+
+	seed := binary.BigEndian.Uint64(h.Sum(nil)[:8])
+	if seed == 0 {
+		seed = 1
+	}
+	return seed
+}
+
+// ChallengeConfig returns a copy of cfg seeded for the challenge of the
+// period containing t, with cfg's seed as the base. cfg is not modified.
+func ChallengeConfig(cfg *Config, t time.Time, period Period) (*Config, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := period.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Seed == 0 {
+		return nil, fmt.Errorf("challenge base config needs a fixed seed")
+	}
+
+	challenge := *cfg
+	challenge.Seed = SeedForDate(cfg.Seed, t, period)
+	return &challenge, nil
+}
+
+// ChallengeManifest lists upcoming challenges derived from one base config,
+// for servers publishing the schedule clients generate from.
+type ChallengeManifest struct {
+	Period     Period      `json:"period"`
+	BaseSeed   uint64      `json:"baseSeed"`
+	Challenges []Challenge `json:"challenges"` // In period order
+}
+
+// Challenge is one period's challenge dungeon.
+type Challenge struct {
+	Key        string    `json:"key"`        // See PeriodKey
+	Start      time.Time `json:"start"`      // UTC, inclusive
+	End        time.Time `json:"end"`        // UTC, exclusive
+	Seed       uint64    `json:"seed"`       // Seed of the challenge config
+	ConfigHash string    `json:"configHash"` // Hex Config.Hash() of the challenge config
+}
+
+// NewChallengeManifest lists count consecutive challenges, starting with the
+// period containing from. Clients holding the same base config can check
+// their ChallengeConfig against the config hash.
+func NewChallengeManifest(cfg *Config, from time.Time, period Period, count int) (*ChallengeManifest, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("challenge count must be positive, got %d", count)
+	}
+
+	manifest := &ChallengeManifest{Period: period, Challenges: []Challenge{}}
+	if cfg != nil {
+		manifest.BaseSeed = cfg.Seed
+	}
+	start := PeriodStart(from, period)
+	for i := 0; i < count; i++ {
+		challenge, err := ChallengeConfig(cfg, start, period)
+		if err != nil {
+			return nil, err
+		}
+		end := PeriodEnd(start, period)
+		manifest.Challenges = append(manifest.Challenges, Challenge{
+			Key:        PeriodKey(start, period),
+			Start:      start,
+			End:        end,
+			Seed:       challenge.Seed,
+			ConfigHash: hex.EncodeToString(challenge.Hash()),
+		})
+		start = end
+	}
+	return manifest, nil
+}
+
+// ExportJSON serializes the manifest to JSON with indentation.
+func (m *ChallengeManifest) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// SaveJSON writes the manifest to a JSON file with WriteFile.
+func (m *ChallengeManifest) SaveJSON(path string, options ...WriteOption) error {
+	data, err := m.ExportJSON()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, options...)
+}
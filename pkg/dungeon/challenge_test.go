@@ -0,0 +1,123 @@
+package dungeon
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPeriodKey(t *testing.T) {
+	// Saturday 17 October 2026, 23:30 UTC
+	at := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period Period
+		key    string
+		start  time.Time
+		end    time.Time
+	}{
+		{PeriodDaily, "2026-10-17", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeekly, "2026-W42", time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonthly, "2026-10", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			if got := PeriodKey(at, tt.period); got != tt.key {
+				t.Errorf("PeriodKey() = %q, want %q", got, tt.key)
+			}
+			if got := PeriodStart(at, tt.period); !got.Equal(tt.start) {
+				t.Errorf("PeriodStart() = %v, want %v", got, tt.start)
+			}
+			if got := PeriodEnd(at, tt.period); !got.Equal(tt.end) {
+				t.Errorf("PeriodEnd() = %v, want %v", got, tt.end)
+			}
+		})
+	}
+
+	// ISO week 1 of 2027 starts in 2026
+	if got := PeriodKey(time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC), PeriodWeekly); got != "2026-W53" {
+		t.Errorf("PeriodKey(2027-01-01) = %q, want 2026-W53", got)
+	}
+}
+
+func TestSeedForDate(t *testing.T) {
+	at := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+
+	// The same instant seen from another timezone is the same day
+	tokyo := at.In(time.FixedZone("JST", 9*60*60))
+	if SeedForDate(42, at, PeriodDaily) != SeedForDate(42, tokyo, PeriodDaily) {
+		t.Error("SeedForDate() depends on the timezone")
+	}
+	if SeedForDate(42, at, PeriodDaily) != SeedForDate(42, at.Add(-23*time.Hour), PeriodDaily) {
+		t.Error("SeedForDate() differs within one day")
+	}
+	if SeedForDate(42, at, PeriodDaily) == SeedForDate(42, at.Add(time.Hour), PeriodDaily) {
+		t.Error("SeedForDate() is the same on consecutive days")
+	}
+	if SeedForDate(42, at, PeriodDaily) == SeedForDate(43, at, PeriodDaily) {
+		t.Error("SeedForDate() ignores the base seed")
+	}
+	if SeedForDate(42, at, PeriodWeekly) != SeedForDate(42, at.AddDate(0, 0, -5), PeriodWeekly) {
+		t.Error("SeedForDate() differs within one week")
+	}
+}
+
+func TestChallengeConfig(t *testing.T) {
+	cfg := &Config{Seed: 42}
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	challenge, err := ChallengeConfig(cfg, at, PeriodDaily)
+	if err != nil {
+		t.Fatalf("ChallengeConfig() error = %v", err)
+	}
+	if challenge.Seed != SeedForDate(42, at, PeriodDaily) || cfg.Seed != 42 {
+		t.Errorf("challenge seed = %d, base seed = %d", challenge.Seed, cfg.Seed)
+	}
+
+	if _, err := ChallengeConfig(&Config{}, at, PeriodDaily); err == nil {
+		t.Error("expected an error for a random base seed")
+	}
+	if _, err := ChallengeConfig(cfg, at, Period("hourly")); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+}
+
+func TestNewChallengeManifest(t *testing.T) {
+	cfg := &Config{Seed: 42}
+	from := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	manifest, err := NewChallengeManifest(cfg, from, PeriodWeekly, 3)
+	if err != nil {
+		t.Fatalf("NewChallengeManifest() error = %v", err)
+	}
+	if len(manifest.Challenges) != 3 || manifest.BaseSeed != 42 {
+		t.Fatalf("manifest = %+v, want 3 challenges from base seed 42", manifest)
+	}
+	for i, c := range manifest.Challenges {
+		if c.Seed != SeedForDate(42, c.Start, PeriodWeekly) {
+			t.Errorf("challenge %s seed = %d, want the derived seed", c.Key, c.Seed)
+		}
+		if i > 0 && !c.Start.Equal(manifest.Challenges[i-1].End) {
+			t.Errorf("challenge %s starts at %v, want the previous end", c.Key, c.Start)
+		}
+	}
+	if manifest.Challenges[2].Key != "2026-W44" {
+		t.Errorf("last challenge = %s, want 2026-W44", manifest.Challenges[2].Key)
+	}
+
+	data, err := manifest.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() error = %v", err)
+	}
+	var decoded ChallengeManifest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if decoded.Challenges[1].Seed != manifest.Challenges[1].Seed || !decoded.Challenges[1].Start.Equal(manifest.Challenges[1].Start) {
+		t.Errorf("JSON round trip lost challenges: %s", data)
+	}
+
+	if _, err := NewChallengeManifest(cfg, from, PeriodDaily, 0); err == nil {
+		t.Error("expected an error for a zero count")
+	}
+}
@@ -0,0 +1,255 @@
+package dungeon
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// CheckpointStage names a pipeline stage: the one a checkpoint was taken
+// after, or the one Config.StopAfter ends generation after. Checkpoints are
+// only taken after synthesis or embedding.
+type CheckpointStage string
+
+const (
+	// StageSynthesis checkpoints hold the post-synthesis graph.
+	StageSynthesis CheckpointStage = "synthesis"
+
+	// StageEmbedding checkpoints hold the graph and its normalized layout.
+	StageEmbedding CheckpointStage = "embedding"
+
+	// StageCarving is the stage rasterizing the layout to a tile map.
+	StageCarving CheckpointStage = "carving"
+
+	// StageContent is the stage placing enemies, loot and puzzles.
+	StageContent CheckpointStage = "content"
+
+	// StageValidation is the last stage, checking constraints and
+	// computing metrics.
+	StageValidation CheckpointStage = "validation"
+)
+
+// CheckpointVersion is the checkpoint format written by this package.
+// Checkpoints of other versions are rejected on load.
+const CheckpointVersion = 1
+
+// Checkpoint is serializable intermediate pipeline state. A long generation
+// can be checkpointed and resumed later, and the embedding of a huge dungeon
+// can run in another process: one process saves a synthesis checkpoint,
+// another advances it to an embedding checkpoint, and a third resumes it.
+//
+// Each stage derives its RNG from the config seed and hash alone, so resuming
+// with the config the checkpoint was taken with produces exactly the artifact
+// Generate would have produced.
+type Checkpoint struct {
+	Version    int               `json:"version"`
+	Stage      CheckpointStage   `json:"stage"`
+	ConfigHash string            `json:"configHash"`       // Hex Config.Hash() of the generating config
+	Graph      *graph.Graph      `json:"graph"`            // Post-synthesis room graph
+	Layout     *embedding.Layout `json:"layout,omitempty"` // Normalized layout, embedding stage only
+}
+
+// GenerateCheckpoint runs the pipeline up to and including stage and returns
+// its state. gen must be a *DefaultGenerator.
+func GenerateCheckpoint(ctx context.Context, gen Generator, cfg *Config, stage CheckpointStage) (*Checkpoint, error) {
+	g, err := checkpointGenerator(gen)
+	if err != nil {
+		return nil, err
+	}
+	if stage != StageSynthesis && stage != StageEmbedding {
+		return nil, fmt.Errorf("unknown checkpoint stage %q, must be one of: synthesis, embedding", stage)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("checkpoints do not support zoned configs")
+	}
+
+	adg, err := g.synthesize(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{
+		Version:    CheckpointVersion,
+		Stage:      StageSynthesis,
+		ConfigHash: hex.EncodeToString(cfg.Hash()),
+		Graph:      adg,
+	}
+	if stage == StageSynthesis {
+		return cp, nil
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	return AdvanceCheckpoint(ctx, gen, cfg, cp)
+}
+
+// AdvanceCheckpoint runs the embedding stage on a synthesis checkpoint and
+// returns the resulting embedding checkpoint. cp is not modified. cfg must be
+// the config the checkpoint was taken with.
+func AdvanceCheckpoint(ctx context.Context, gen Generator, cfg *Config, cp *Checkpoint) (*Checkpoint, error) {
+	g, err := checkpointGenerator(gen)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.matches(cfg); err != nil {
+		return nil, err
+	}
+	if cp.Stage != StageSynthesis {
+		return nil, fmt.Errorf("cannot advance a %s checkpoint", cp.Stage)
+	}
+
+	// Embed a copy so the synthesis checkpoint stays reusable
+	adg := copyGraph(cp.Graph)
+	layout, err := g.embed(ctx, cfg, adg, rng.NewRNG(cfg.Seed, "embedding", cfg.Hash()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{
+		Version:    CheckpointVersion,
+		Stage:      StageEmbedding,
+		ConfigHash: cp.ConfigHash,
+		Graph:      adg,
+		Layout:     layout,
+	}, nil
+}
+
+// ResumeCheckpoint completes the pipeline from a checkpoint of either stage
+// and returns the finished, validated artifact. cp is not modified. cfg must
+// be the config the checkpoint was taken with.
+func ResumeCheckpoint(ctx context.Context, gen Generator, cfg *Config, cp *Checkpoint) (*Artifact, error) {
+	g, err := checkpointGenerator(gen)
+	if err != nil {
+		return nil, err
+	}
+	if err := cp.matches(cfg); err != nil {
+		return nil, err
+	}
+
+	if cp.Stage == StageSynthesis {
+		if cp, err = AdvanceCheckpoint(ctx, gen, cfg, cp); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	// The artifact gets its own graph so the checkpoint stays reusable; the
+	// layout is only read
+	return g.finish(ctx, cfg, copyGraph(cp.Graph), cp.Layout, rng.NewRNG(cfg.Seed, "carving", cfg.Hash()), stageRNG(ctx, cfg, "content"))
+}
+
+// checkpointGenerator returns gen as the default generator that checkpoints
+// need for access to the individual pipeline stages.
+func checkpointGenerator(gen Generator) (*DefaultGenerator, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("checkpoints require a *DefaultGenerator, got %T", gen)
+	}
+	return g, nil
+}
+
+// matches checks that the checkpoint is complete and was taken with cfg.
+func (cp *Checkpoint) matches(cfg *Config) error {
+	if cp == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if err := cp.Validate(); err != nil {
+		return fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return fmt.Errorf("checkpoints do not support zoned configs")
+	}
+	if hex.EncodeToString(cfg.Hash()) != cp.ConfigHash {
+		return fmt.Errorf("checkpoint was taken with a different config")
+	}
+	return nil
+}
+
+// Validate checks the checkpoint version and that it holds the state its
+// stage requires.
+func (cp *Checkpoint) Validate() error {
+	if cp.Version != CheckpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d, want %d", cp.Version, CheckpointVersion)
+	}
+	if cp.ConfigHash == "" {
+		return fmt.Errorf("configHash must not be empty")
+	}
+	if cp.Graph == nil || len(cp.Graph.Rooms) == 0 {
+		return fmt.Errorf("graph must not be empty")
+	}
+	switch cp.Stage {
+	case StageSynthesis:
+		if cp.Layout != nil {
+			return fmt.Errorf("synthesis checkpoint must not have a layout")
+		}
+	case StageEmbedding:
+		if cp.Layout == nil {
+			return fmt.Errorf("embedding checkpoint must have a layout")
+		}
+		for id := range cp.Graph.Rooms {
+			if _, ok := cp.Layout.Poses[id]; !ok {
+				return fmt.Errorf("room %s has no pose", id)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown checkpoint stage %q, must be one of: synthesis, embedding", cp.Stage)
+	}
+	return nil
+}
+
+// ExportJSON serializes the checkpoint to JSON with indentation.
+func (cp *Checkpoint) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(cp, "", "  ")
+}
+
+// SaveJSON writes the checkpoint to a JSON file with WriteFile.
+func (cp *Checkpoint) SaveJSON(path string, options ...WriteOption) error {
+	data, err := cp.ExportJSON()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, options...)
+}
+
+// LoadCheckpoint reads and validates a checkpoint JSON file.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint file: %w", err)
+	}
+	return LoadCheckpointFromBytes(data)
+}
+
+// LoadCheckpointFromBytes parses and validates checkpoint JSON.
+func LoadCheckpointFromBytes(data []byte) (*Checkpoint, error) {
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint: %w", err)
+	}
+	if err := cp.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return &cp, nil
+}
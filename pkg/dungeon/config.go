@@ -3,11 +3,19 @@ package dungeon
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/synthesis"
+	"github.com/dshills/dungo/pkg/themes"
 	"gopkg.in/yaml.v3"
 )
 
@@ -28,6 +36,11 @@ import (
 //	  Themes: []string{"dungeon", "crypt"},
 //	}
 type Config struct {
+	// Version is the config schema version; see CurrentConfigVersion.
+	// 0 marks an unversioned config. Older configs are upgraded with
+	// MigrateConfig.
+	Version int `yaml:"version,omitempty" json:"version,omitempty"`
+
 	// Seed is the master seed for deterministic generation.
 	// Use 0 to auto-generate from current time.
 	Seed uint64 `yaml:"seed" json:"seed"`
@@ -41,12 +54,28 @@ type Config struct {
 	// Pacing defines the difficulty curve.
 	Pacing PacingCfg `yaml:"pacing" json:"pacing"`
 
-	// Themes lists biome/theme names to use.
+	// Themes lists biome/theme names to use. Each must be registered in
+	// the theme registry (see themes.Register).
 	Themes []string `yaml:"themes" json:"themes"`
 
 	// Keys defines key/lock configurations.
 	Keys []KeyCfg `yaml:"keys,omitempty" json:"keys,omitempty"`
 
+	// Archetypes sets target shares of room archetypes, e.g. 10% treasure
+	// rooms and exactly one vendor. Archetypes without a target share the
+	// remaining rooms.
+	Archetypes []ArchetypeCfg `yaml:"archetypes,omitempty" json:"archetypes,omitempty"`
+
+	// Rooms controls room footprints: the size class distribution and a
+	// budget for the carved floor area.
+	// Zero values keep the default sizes and leave the area unbounded.
+	Rooms RoomsCfg `yaml:"rooms,omitempty" json:"rooms,omitempty"`
+
+	// Map bounds the tile map dimensions and trims its dead space. Layouts
+	// are compacted to fit, and generation fails when they cannot be.
+	// Zero values leave the map unbounded and untrimmed.
+	Map MapCfg `yaml:"map,omitempty" json:"map,omitempty"`
+
 	// Constraints lists hard and soft constraints.
 	Constraints []Constraint `yaml:"constraints,omitempty" json:"constraints,omitempty"`
 
@@ -58,14 +87,575 @@ type Config struct {
 
 	// OptionalRatio is the target ratio of optional rooms (0.1-0.4).
 	OptionalRatio float64 `yaml:"optionalRatio" json:"optionalRatio"`
+
+	// Exit adds an Exit room past the Boss, joined only to it: the end area
+	// for an escape sequence or a final reward chest. The Boss keeps a
+	// connection free for it. Arena and wave modes and map.adjacency sync
+	// do not support it.
+	Exit bool `yaml:"exit,omitempty" json:"exit,omitempty"`
+
+	// Vaults is the number of treasure vaults to add (0-3): compact,
+	// optional dead ends of a guarded antechamber and a treasure chamber
+	// behind two key locks, with high-tier loot and decorations of their
+	// own. Each takes two rooms of the room count and needs branching.max
+	// 3 or above; size.roomsMin must leave room for the core rooms, the
+	// Exit and backtracking abilities besides. Arena and wave modes do not
+	// support them.
+	Vaults int `yaml:"vaults,omitempty" json:"vaults,omitempty"`
+
+	// Difficulty names a preset (casual, normal, brutal) applied before the
+	// explicit pacing and content settings when loading from YAML.
+	Difficulty Difficulty `yaml:"difficulty,omitempty" json:"difficulty,omitempty"`
+
+	// Content tunes spawn density, loot budget, trap density and how much
+	// difficulty the environment carries.
+	// Zero values keep the content pass defaults.
+	Content ContentCfg `yaml:"content,omitempty" json:"content,omitempty"`
+
+	// Secrets weights the kinds of secret sealing hidden connectors.
+	// Zero values leave the kinds to each secret room's theme.
+	Secrets SecretsCfg `yaml:"secrets,omitempty" json:"secrets,omitzero"`
+
+	// Accessibility enables optional accessibility guarantees, each enforced
+	// as a hard validation constraint.
+	Accessibility AccessibilityCfg `yaml:"accessibility,omitempty" json:"accessibility,omitempty"`
+
+	// Party configures co-op play: room footprints, encounters and loot scale
+	// with the party size, and one start point is placed per player.
+	Party PartyCfg `yaml:"party,omitempty" json:"party,omitempty"`
+
+	// Mode selects the kind of dungeon produced by the pipeline.
+	// Empty means ModeStandard.
+	Mode Mode `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Backtrack tunes backtrack mode. Zero values keep the defaults.
+	Backtrack BacktrackCfg `yaml:"backtrack,omitempty" json:"backtrack,omitempty"`
+
+	// Escape adds a timed escape after the Boss falls: a route to the Exit
+	// room when Exit is set, else back to the Start room, whose tiles
+	// collapse behind a clock. Zero values leave it out. Arena and wave
+	// modes and zones do not support it.
+	Escape EscapeCfg `yaml:"escape,omitempty" json:"escape,omitzero"`
+
+	// Zones splits a mega-dungeon into zones that are embedded and carved
+	// independently, possibly in other processes, and stitched back together.
+	// Zero values generate the dungeon in one piece.
+	Zones ZoneCfg `yaml:"zones,omitempty" json:"zones,omitempty"`
+
+	// LayoutPresets replaces the built-in force-directed layout parameters
+	// by room count, see DefaultLayoutPresets. Empty keeps the built-in ones.
+	LayoutPresets []LayoutPreset `yaml:"layoutPresets,omitempty" json:"layoutPresets,omitempty"`
+
+	// Quality declares thresholds on the dungeon's metrics that Generate
+	// retries with later seeds to meet. Empty accepts the first dungeon.
+	Quality QualityCfg `yaml:"quality,omitempty" json:"quality,omitzero"`
+
+	// Limits bounds generation time and synthesis attempts.
+	// Nil leaves generation unbounded.
+	Limits *LimitsCfg `yaml:"limits,omitempty" json:"limits,omitempty"`
+
+	// StopAfter ends the pipeline after the named stage (see
+	// PipelineStages), returning a partial artifact with only what exists
+	// by then, e.g. just the graph after "synthesis". Empty runs every
+	// stage. Stopping early does not change the stages that run, so it is
+	// left out of the config hash.
+	StopAfter CheckpointStage `yaml:"stopAfter,omitempty" json:"stopAfter,omitempty"`
+
+	// Metadata carries game-defined parameters, such as a campaign ID, to the
+	// artifact. Keys must be declared with RegisterMetadata. Metadata does
+	// not affect generation.
+	Metadata Metadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// Mode defines valid generation modes.
+type Mode string
+
+const (
+	// ModeStandard is a single-entrance dungeon progressing from Start to Boss.
+	ModeStandard Mode = "standard"
+
+	// ModeArena is a symmetric competitive map: two mirrored halves, each
+	// with its own Start room, meeting at a shared contested Boss room.
+	ModeArena Mode = "arena"
+
+	// ModeWave is a compact horde-mode map: a central Start hub inside
+	// concentric defensive rings, with spawner rooms that open wave by wave.
+	ModeWave Mode = "wave"
+
+	// ModeBacktrack is a standard dungeon whose Boss is sealed behind
+	// abilities found far from it, each sealing the next, so the route
+	// doubles back through earlier rooms after picking up each one.
+	ModeBacktrack Mode = "backtrack"
+)
+
+// BacktrackCfg tunes backtrack mode.
+type BacktrackCfg struct {
+	// Passes is the number of abilities the route doubles back for
+	// (1-3, 0 = default 2).
+	Passes int `yaml:"passes,omitempty" json:"passes,omitempty"`
+}
+
+// DefaultBacktrackPasses is the number of passes used when
+// BacktrackCfg.Passes is zero.
+const DefaultBacktrackPasses = 2
+
+// PassCount returns the effective number of passes.
+func (b *BacktrackCfg) PassCount() int {
+	if b.Passes == 0 {
+		return DefaultBacktrackPasses
+	}
+	return b.Passes
+}
+
+// EscapeCfg configures the post-boss escape sequence.
+type EscapeCfg struct {
+	// Enabled turns on the escape sequence.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// TimeLimitMs is the time allowed to reach the end of the route once the
+	// Boss falls (0-3600000, 0 = the walk time at Speed times EscapeSlack).
+	TimeLimitMs int `yaml:"timeLimitMs,omitempty" json:"timeLimitMs,omitempty"`
+
+	// Speed is the movement speed the escape must be completable at, in
+	// tiles per second (0-50, 0 = default 4).
+	Speed float64 `yaml:"speed,omitempty" json:"speed,omitempty"`
+}
+
+// DefaultEscapeSpeed is the movement speed used when EscapeCfg.Speed is
+// zero, in tiles per second.
+const DefaultEscapeSpeed = 4.0
+
+// EscapeSlack is the multiple of the walk time given as the time limit when
+// EscapeCfg.TimeLimitMs is zero.
+const EscapeSlack = 1.5
+
+// MoveSpeed returns the effective movement speed in tiles per second.
+func (e *EscapeCfg) MoveSpeed() float64 {
+	if e.Speed == 0 {
+		return DefaultEscapeSpeed
+	}
+	return e.Speed
+}
+
+// Validate checks EscapeCfg constraints.
+func (e *EscapeCfg) Validate() error {
+	if !e.Enabled && (e.TimeLimitMs != 0 || e.Speed != 0) {
+		return errors.New("timeLimitMs and speed need enabled")
+	}
+	if e.TimeLimitMs < 0 || e.TimeLimitMs > 3600000 {
+		return fmt.Errorf("timeLimitMs must be in range [0, 3600000], got %d", e.TimeLimitMs)
+	}
+	if e.Speed < 0 || e.Speed > 50 {
+		return fmt.Errorf("speed must be in range [0, 50], got %f", e.Speed)
+	}
+	return nil
+}
+
+// PartyCfg configures co-op party generation. Zero values mean single player.
+type PartyCfg struct {
+	// Size is the number of players (0-8, 0 or 1 = single player).
+	Size int `yaml:"size,omitempty" json:"size,omitempty"`
+
+	// ConvergeWithin is the maximum number of rooms between any player start
+	// and the Start room (0-10, 0 = default 2).
+	ConvergeWithin int `yaml:"convergeWithin,omitempty" json:"convergeWithin,omitempty"`
+}
+
+// DefaultPartyConvergeWithin is the convergence distance used when
+// PartyCfg.ConvergeWithin is zero.
+const DefaultPartyConvergeWithin = 2
+
+// ConvergenceLimit returns the effective convergence distance.
+func (p *PartyCfg) ConvergenceLimit() int {
+	if p.ConvergeWithin == 0 {
+		return DefaultPartyConvergeWithin
+	}
+	return p.ConvergeWithin
+}
+
+// ZoneCfg configures zoned generation of mega-dungeons, see
+// GenerateDistributed. Zero values mean no zoning.
+type ZoneCfg struct {
+	// Size is the target number of rooms per zone (20-300, 0 = no zoning).
+	// Zoned dungeons may have up to 2000 rooms.
+	Size int `yaml:"size,omitempty" json:"size,omitempty"`
+}
+
+// AccessibilityCfg enables accessibility guarantees. Zero values disable them.
+type AccessibilityCfg struct {
+	// NoRequiredSecrets keeps keys and the Boss reachable without crossing a
+	// hidden connector or entering a secret room.
+	NoRequiredSecrets bool `yaml:"noRequiredSecrets,omitempty" json:"noRequiredSecrets,omitempty"`
+
+	// MaxCombatBetweenCheckpoints caps the combat rooms on the Start→Boss path
+	// between checkpoints (0-20, 0 = unlimited).
+	MaxCombatBetweenCheckpoints int `yaml:"maxCombatBetweenCheckpoints,omitempty" json:"maxCombatBetweenCheckpoints,omitempty"`
+
+	// LowBacktracking keeps every key on or adjacent to the Start→Boss path.
+	LowBacktracking bool `yaml:"lowBacktracking,omitempty" json:"lowBacktracking,omitempty"`
+}
+
+// ContentCfg tunes content placement density.
+type ContentCfg struct {
+	// SpawnDensity scales the enemies placed per room (0.25-2.0, 0 = default 1.0).
+	SpawnDensity float64 `yaml:"spawnDensity,omitempty" json:"spawnDensity,omitempty"`
+
+	// LootBudget is the base treasure value (0-100000, 0 = default 1000).
+	LootBudget int `yaml:"lootBudget,omitempty" json:"lootBudget,omitempty"`
+
+	// TrapDensity is the chance that a combat room holds traps (0.0-1.0).
+	TrapDensity float64 `yaml:"trapDensity,omitempty" json:"trapDensity,omitempty"`
+
+	// EnvironmentRatio is the share of each combat room's difficulty
+	// expressed through hazard coverage, darkness and slow terrain instead of
+	// enemy spawns (0.0-0.8, 0 = enemies only).
+	EnvironmentRatio float64 `yaml:"environmentRatio,omitempty" json:"environmentRatio,omitempty"`
+
+	// AmbushRatio is the chance that a spawn lies in wait, placed out of
+	// sight of its room's entrances (0.0-1.0).
+	AmbushRatio float64 `yaml:"ambushRatio,omitempty" json:"ambushRatio,omitempty"`
+
+	// Factions keeps each region of the dungeon to one enemy faction, drawn
+	// from its theme's factions or the default ones, instead of selecting
+	// every spawn's enemies independently.
+	Factions bool `yaml:"factions,omitempty" json:"factions,omitempty"`
+
+	// SkirmishRatio is the chance that a combat room bordering another
+	// faction's region mixes both factions (0.0-1.0). Requires factions.
+	SkirmishRatio float64 `yaml:"skirmishRatio,omitempty" json:"skirmishRatio,omitempty"`
+
+	// EntityRadius is the spacing, in tiles, kept between placed entities:
+	// no two stand within this Chebyshev distance of each other while their
+	// room has space, and crowded rooms spread them as far apart as they fit
+	// (0-4, 0 = distinct tiles).
+	EntityRadius int `yaml:"entityRadius,omitempty" json:"entityRadius,omitempty"`
+}
+
+// SecretsCfg weights the kinds of secret sealing hidden connectors: a
+// destructible wall, an illusory wall, a collapsible floor, a movable block
+// or a shrine riddle (see carving.SecretKinds). Each secret room draws its
+// kind from the config's weights, or else from its theme's; rooms with
+// neither, and secrets whose corridor does not suit the kind drawn, get a
+// destructible wall.
+type SecretsCfg struct {
+	// Weights maps secret kinds to relative weights (0.0-100.0). Kinds left
+	// out are never drawn.
+	Weights map[string]float64 `yaml:"weights,omitempty" json:"weights,omitempty"`
+}
+
+// Validate checks SecretsCfg constraints.
+func (s *SecretsCfg) Validate() error {
+	total := 0.0
+	for kind, weight := range s.Weights {
+		if !slices.Contains(carving.SecretKinds, kind) {
+			return fmt.Errorf("unknown secret kind %q, must be one of: %s", kind, strings.Join(carving.SecretKinds, ", "))
+		}
+		if weight < 0 || weight > 100 {
+			return fmt.Errorf("weight of %s must be in range [0.0, 100.0], got %g", kind, weight)
+		}
+		total += weight
+	}
+	if len(s.Weights) > 0 && total == 0 {
+		return errors.New("weights must not all be zero")
+	}
+	return nil
+}
+
+// DefaultSpawnDensity and DefaultLootBudget are the content settings used
+// when ContentCfg.SpawnDensity and ContentCfg.LootBudget are zero.
+const (
+	DefaultSpawnDensity = 1.0
+	DefaultLootBudget   = 1000
+)
+
+// RoomsCfg controls room footprints.
+type RoomsCfg struct {
+	// SizeWeights sets the relative weight of each room size class by name
+	// (XS, S, M, L, XL). Classes left out are not picked; empty keeps the
+	// default distribution. Start, hub, Boss and key rooms keep their
+	// fixed sizes.
+	SizeWeights map[string]float64 `yaml:"sizeWeights,omitempty" json:"sizeWeights,omitempty"`
+
+	// FloorBudget caps the carved floor area in tiles, rooms and corridors
+	// together (0 = unlimited). Synthesis shrinks rooms to fit and embedding
+	// tightens layouts whose corridors overrun the rest.
+	FloorBudget int `yaml:"floorBudget,omitempty" json:"floorBudget,omitempty"`
+
+	// HallRatio is the share of rooms other than Start and Boss given a
+	// long hall footprint, half as deep as it is wide (0.0-1.0, 0 = all
+	// square). Embedding turns each hall to suit the layout and carving
+	// stamps it rotated. Arena and wave modes ignore it.
+	HallRatio float64 `yaml:"hallRatio,omitempty" json:"hallRatio,omitempty"`
+}
+
+// Weights returns SizeWeights indexed by graph.RoomSize, or nil when unset.
+func (r *RoomsCfg) Weights() []float64 {
+	if len(r.SizeWeights) == 0 {
+		return nil
+	}
+	weights := make([]float64, graph.SizeXL+1)
+	for name, w := range r.SizeWeights {
+		if size, ok := graph.ParseSize(name); ok {
+			weights[size] = w
+		}
+	}
+	return weights
+}
+
+// Validate checks RoomsCfg constraints.
+func (r *RoomsCfg) Validate() error {
+	total := 0.0
+	for name, w := range r.SizeWeights {
+		if _, ok := graph.ParseSize(name); !ok {
+			return fmt.Errorf("sizeWeights: unknown size %q, must be one of: XS, S, M, L, XL", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("sizeWeights: weight of %s must be non-negative, got %f", name, w)
+		}
+		total += w
+	}
+	if len(r.SizeWeights) > 0 && total == 0 {
+		return errors.New("sizeWeights: at least one weight must be positive")
+	}
+	if r.FloorBudget < 0 {
+		return fmt.Errorf("floorBudget must be non-negative, got %d", r.FloorBudget)
+	}
+	if r.HallRatio < 0 || r.HallRatio > 1 {
+		return fmt.Errorf("hallRatio must be in range [0.0, 1.0], got %f", r.HallRatio)
+	}
+	return nil
+}
+
+// MinMapDimension is the smallest maximum map width or height accepted, room
+// for a Boss arena and its approach.
+const MinMapDimension = 32
+
+// MapCfg bounds the tile map dimensions and trims its dead space.
+type MapCfg struct {
+	// MaxWidth is the maximum map width in tiles (0 = unlimited, else at
+	// least 32).
+	MaxWidth int `yaml:"maxWidth,omitempty" json:"maxWidth,omitempty"`
+
+	// MaxHeight is the maximum map height in tiles (0 = unlimited, else at
+	// least 32).
+	MaxHeight int `yaml:"maxHeight,omitempty" json:"maxHeight,omitempty"`
+
+	// Trim crops the empty border of the carved map.
+	Trim bool `yaml:"trim,omitempty" json:"trim,omitempty"`
+
+	// Repack also collapses the whitespace between distant branches,
+	// shortening the straight corridors crossing it. Implies Trim. Not
+	// supported in arena mode, whose halves must stay mirrored.
+	Repack bool `yaml:"repack,omitempty" json:"repack,omitempty"`
+
+	// Junctions merges corridors running side by side into shared passages
+	// and adds a small corridor room, tagged graph.JunctionTag, wherever
+	// three or more corridors meet, splitting the corridors through it in
+	// the graph too; the corridor to the Exit room is left alone. Standard
+	// and backtrack modes only, without zones or path-based accessibility
+	// guarantees.
+	Junctions bool `yaml:"junctions,omitempty" json:"junctions,omitempty"`
+
+	// Adjacency selects what happens when the carved floor joins two rooms
+	// no connector joins, as where corridors cross or rooms touch. Empty
+	// leaves such rooms unchecked; AdjacencyStrict fails validation and
+	// AdjacencySync adds the missing connectors to the graph. Sync is
+	// standard and backtrack modes only, without zones, Exit or path-based
+	// accessibility guarantees.
+	Adjacency AdjacencyMode `yaml:"adjacency,omitempty" json:"adjacency,omitempty"`
+
+	// SharedWalls selects what happens to rooms laid out a single wall
+	// apart with no connector between them. Empty leaves them be;
+	// SharedWallsSeparate moves them apart and SharedWallsBreakable puts a
+	// bombable wall between them. Standard and backtrack modes only,
+	// without zones; separate does not support Boundary and breakable does
+	// not support path-based accessibility guarantees.
+	SharedWalls SharedWallMode `yaml:"sharedWalls,omitempty" json:"sharedWalls,omitempty"`
+
+	// Layout selects how rooms are laid out in standard and backtrack
+	// modes. Empty means LayoutForce.
+	Layout LayoutStyle `yaml:"layout,omitempty" json:"layout,omitempty"`
+
+	// AspectRatio is the target width/height ratio of the layout, e.g. 2
+	// for a map twice as wide as tall (0 = any, else 0.25-4). It is a soft
+	// goal: rooms are pulled toward it during embedding.
+	AspectRatio float64 `yaml:"aspectRatio,omitempty" json:"aspectRatio,omitempty"`
+
+	// Boundary is a polygon of [x, y] tile coordinates that every room must
+	// lie inside, such as the outline of a mountain on the world map. The
+	// layout keeps the boundary's coordinates: Layout.Bounds locates the
+	// map in it. At least 3 vertices, all non-negative; empty = unbounded.
+	Boundary [][2]float64 `yaml:"boundary,omitempty" json:"boundary,omitempty"`
+
+	// FixedPoint runs the force-directed layout in fixed-point arithmetic,
+	// so a seed gives the same layout on every CPU architecture; float
+	// layouts can differ where the compiler fuses multiply-adds. It
+	// changes the layouts of every seed.
+	FixedPoint bool `yaml:"fixedPoint,omitempty" json:"fixedPoint,omitempty"`
+}
+
+// LayoutStyle names a room layout algorithm.
+type LayoutStyle string
+
+const (
+	// LayoutForce spreads rooms organically with a force-directed
+	// simulation: connected rooms attract and all rooms repel.
+	LayoutForce LayoutStyle = "force"
+
+	// LayoutRings places rooms in concentric rings by their distance from
+	// Start, with the Boss outermost and branches fanning out from their
+	// parents. It suits hub-and-spoke dungeons and is cheap to compute.
+	LayoutRings LayoutStyle = "rings"
+
+	// LayoutLayered places rooms in columns by progress along the critical
+	// path, which runs straight from left to right, with side rooms
+	// stacked above and below and ordered to reduce crossing corridors. It
+	// suits linear, gauntlet-style dungeons.
+	LayoutLayered LayoutStyle = "layered"
+)
+
+// AdjacencyMode selects how rooms joined only by carved floor are handled.
+type AdjacencyMode string
+
+const (
+	// AdjacencyStrict fails validation when the carved floor joins two
+	// rooms that no connector joins.
+	AdjacencyStrict AdjacencyMode = "strict"
+
+	// AdjacencySync adds a connector, with its ID prefixed "passage_",
+	// for every pair of rooms the carved floor joins that no connector
+	// joins, routed along the floor between them.
+	AdjacencySync AdjacencyMode = "sync"
+)
+
+// SharedWallMode selects how rooms sharing a wall without a connector are
+// handled.
+type SharedWallMode string
+
+const (
+	// SharedWallsSeparate widens every wall shared by rooms without a
+	// connector to two tiles, so each room keeps a wall of its own.
+	SharedWallsSeparate SharedWallMode = "separate"
+
+	// SharedWallsBreakable adds an optional hidden connector, with its ID
+	// prefixed "breakable_", through every wall shared by rooms without a
+	// connector, sealed by a secret (see SecretsCfg).
+	SharedWallsBreakable SharedWallMode = "breakable"
+)
+
+// embedder returns the name of the embedder implementing the layout.
+func (l LayoutStyle) embedder() string {
+	switch l {
+	case LayoutRings, LayoutLayered:
+		return string(l)
+	default:
+		return "force_directed"
+	}
+}
+
+// MinAspectRatio and MaxAspectRatio bound MapCfg.AspectRatio.
+const (
+	MinAspectRatio = 0.25
+	MaxAspectRatio = 4.0
+)
+
+// boundary returns the boundary as an embedding polygon, nil if unset.
+func (m *MapCfg) boundary() embedding.Polygon {
+	if len(m.Boundary) == 0 {
+		return nil
+	}
+	poly := make(embedding.Polygon, len(m.Boundary))
+	for i, v := range m.Boundary {
+		poly[i] = embedding.Point{X: v[0], Y: v[1]}
+	}
+	return poly
+}
+
+// shaped reports whether the map has a target aspect ratio or boundary.
+func (m *MapCfg) shaped() bool {
+	return m.AspectRatio > 0 || len(m.Boundary) > 0
+}
+
+// Fits reports whether a map of the given dimensions is within the limits.
+func (m *MapCfg) Fits(width, height int) bool {
+	return m.overrun(width, height) == 0
+}
+
+// overrun returns how many tiles a map of the given dimensions exceeds the
+// limits by, width and height together.
+func (m *MapCfg) overrun(width, height int) float64 {
+	over := 0
+	if m.MaxWidth > 0 && width > m.MaxWidth {
+		over += width - m.MaxWidth
+	}
+	if m.MaxHeight > 0 && height > m.MaxHeight {
+		over += height - m.MaxHeight
+	}
+	return float64(over)
+}
+
+// limit describes the limits as "WxH", with "any" for unlimited dimensions.
+func (m *MapCfg) limit() string {
+	dim := func(n int) string {
+		if n == 0 {
+			return "any"
+		}
+		return fmt.Sprint(n)
+	}
+	return dim(m.MaxWidth) + "x" + dim(m.MaxHeight)
+}
+
+// Validate checks MapCfg constraints.
+func (m *MapCfg) Validate() error {
+	if m.MaxWidth < 0 || (m.MaxWidth > 0 && m.MaxWidth < MinMapDimension) {
+		return fmt.Errorf("maxWidth must be 0 or at least %d, got %d", MinMapDimension, m.MaxWidth)
+	}
+	if m.MaxHeight < 0 || (m.MaxHeight > 0 && m.MaxHeight < MinMapDimension) {
+		return fmt.Errorf("maxHeight must be 0 or at least %d, got %d", MinMapDimension, m.MaxHeight)
+	}
+	switch m.Layout {
+	case "", LayoutForce, LayoutRings, LayoutLayered:
+	default:
+		return fmt.Errorf("unknown layout %q, must be one of: force, rings, layered", m.Layout)
+	}
+	switch m.Adjacency {
+	case "", AdjacencyStrict, AdjacencySync:
+	default:
+		return fmt.Errorf("unknown adjacency %q, must be one of: strict, sync", m.Adjacency)
+	}
+	switch m.SharedWalls {
+	case "", SharedWallsSeparate, SharedWallsBreakable:
+	default:
+		return fmt.Errorf("unknown sharedWalls %q, must be one of: separate, breakable", m.SharedWalls)
+	}
+	if m.SharedWalls == SharedWallsSeparate && len(m.Boundary) > 0 {
+		return errors.New("sharedWalls separate does not support boundary")
+	}
+	if m.Layout != "" && m.Layout != LayoutForce && m.shaped() {
+		return errors.New("aspectRatio and boundary need the force layout")
+	}
+	if m.AspectRatio != 0 && (m.AspectRatio < MinAspectRatio || m.AspectRatio > MaxAspectRatio) {
+		return fmt.Errorf("aspectRatio must be 0 or in range [%g, %g], got %f", MinAspectRatio, MaxAspectRatio, m.AspectRatio)
+	}
+	if len(m.Boundary) > 0 {
+		for i, v := range m.Boundary {
+			if v[0] < 0 || v[1] < 0 {
+				return fmt.Errorf("boundary[%d]: coordinates must be non-negative, got [%g, %g]", i, v[0], v[1])
+			}
+		}
+		if err := m.boundary().Validate(); err != nil {
+			return fmt.Errorf("boundary: %w", err)
+		}
+	}
+	return nil
 }
 
 // SizeCfg specifies room count constraints.
 type SizeCfg struct {
-	// RoomsMin is the minimum number of rooms (10-300).
+	// RoomsMin is the minimum number of rooms (10-300, up to 2000 with zones).
 	RoomsMin int `yaml:"roomsMin" json:"roomsMin"`
 
-	// RoomsMax is the maximum number of rooms (10-300).
+	// RoomsMax is the maximum number of rooms (10-300, up to 2000 with zones).
 	RoomsMax int `yaml:"roomsMax" json:"roomsMax"`
 }
 
@@ -123,6 +713,96 @@ type KeyCfg struct {
 
 	// Count is the number of this key type (1-5).
 	Count int `yaml:"count" json:"count"`
+
+	// Opens lists other keys whose locks this key also opens, making it a
+	// master key (e.g., "master" opening "silver" and "gold"). Hierarchies
+	// nest: a master key also opens whatever the keys it opens do.
+	Opens []string `yaml:"opens,omitempty" json:"opens,omitempty"`
+
+	// Requires lists other keys this key's locks also need, for doors with
+	// several locks. The door opens with this key and all of them, or with
+	// any one of the keys when RequireAny is set.
+	Requires []string `yaml:"requires,omitempty" json:"requires,omitempty"`
+
+	// RequireAny opens this key's locks with any one of the key and Requires.
+	RequireAny bool `yaml:"requireAny,omitempty" json:"requireAny,omitempty"`
+
+	// Consumable makes this a pool of small keys: each key is used up by the
+	// door it opens, and Count is how many doors, each with its own key, may
+	// be placed. Small keys cannot be part of hierarchies or multi-key locks.
+	Consumable bool `yaml:"consumable,omitempty" json:"consumable,omitempty"`
+
+	// DisplayName, Description and Icon present the key in game
+	// inventories. The name and description go to the artifact's string
+	// table (see KeyNameID); the name defaults to one made from Name, e.g.
+	// "Silver Key". Icon is an identifier of the game's choosing, carried on
+	// the key's loot. They do not affect generation.
+	DisplayName string `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Icon        string `yaml:"icon,omitempty" json:"icon,omitempty"`
+}
+
+// DefaultArchetypeTolerance is the deviation accepted from an archetype's
+// target fraction when ArchetypeCfg.Tolerance is zero.
+const DefaultArchetypeTolerance = 0.05
+
+// ArchetypeCfg is a target for how many rooms have one archetype.
+type ArchetypeCfg struct {
+	// Archetype names the room archetype, ignoring case: Treasure, Puzzle,
+	// Hub, Corridor, Optional, Vendor or Shrine.
+	Archetype string `yaml:"archetype" json:"archetype"`
+
+	// Fraction is the target share of all rooms (0.0-1.0).
+	Fraction float64 `yaml:"fraction,omitempty" json:"fraction,omitempty"`
+
+	// Count is the exact target number of rooms, instead of Fraction.
+	Count int `yaml:"count,omitempty" json:"count,omitempty"`
+
+	// Tolerance is the accepted deviation from the target: a share of all
+	// rooms with Fraction (0.0-1.0, 0 = default 0.05), a number of rooms
+	// with Count (0 = exact).
+	Tolerance float64 `yaml:"tolerance,omitempty" json:"tolerance,omitempty"`
+}
+
+// Target returns the target number of rooms and the accepted deviation, in
+// rooms, for a dungeon with the given room count.
+func (a *ArchetypeCfg) Target(rooms int) (want, tolerance float64) {
+	if a.Count > 0 {
+		return float64(a.Count), a.Tolerance
+	}
+	tolerance = a.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultArchetypeTolerance
+	}
+	return a.Fraction * float64(rooms), tolerance * float64(rooms)
+}
+
+// Validate checks ArchetypeCfg constraints.
+func (a *ArchetypeCfg) Validate() error {
+	archetype, ok := graph.ParseArchetype(a.Archetype)
+	if !ok {
+		return fmt.Errorf("unknown archetype %q", a.Archetype)
+	}
+	switch archetype {
+	case graph.ArchetypeStart, graph.ArchetypeBoss, graph.ArchetypeSecret, graph.ArchetypeCheckpoint, graph.ArchetypeExit:
+		return fmt.Errorf("%s rooms are placed by their own rules and cannot be targeted", archetype)
+	}
+	if (a.Fraction > 0) == (a.Count > 0) {
+		return errors.New("exactly one of fraction and count must be set")
+	}
+	if a.Fraction < 0.0 || a.Fraction > 1.0 {
+		return fmt.Errorf("fraction must be in range [0.0, 1.0], got %f", a.Fraction)
+	}
+	if a.Count < 0 {
+		return fmt.Errorf("count must be positive, got %d", a.Count)
+	}
+	if a.Tolerance < 0.0 {
+		return fmt.Errorf("tolerance must be non-negative, got %f", a.Tolerance)
+	}
+	if a.Fraction > 0 && a.Tolerance > 1.0 {
+		return fmt.Errorf("tolerance must be in range [0.0, 1.0] with fraction, got %f", a.Tolerance)
+	}
+	return nil
 }
 
 // Constraint represents a rule that must be satisfied or optimized.
@@ -150,9 +830,9 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parsing YAML: %w", err)
+	cfg, err := parseConfigYAML(data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Auto-generate seed if not provided
@@ -162,18 +842,18 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Validate the configuration
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, invalidConfig(err)
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 // LoadConfigFromBytes parses YAML configuration from a byte slice.
 // Useful for testing and programmatic config generation.
 func LoadConfigFromBytes(data []byte) (*Config, error) {
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parsing YAML: %w", err)
+	cfg, err := parseConfigYAML(data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Auto-generate seed if not provided
@@ -183,7 +863,30 @@ func LoadConfigFromBytes(data []byte) (*Config, error) {
 
 	// Validate the configuration
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, invalidConfig(err)
+	}
+
+	return cfg, nil
+}
+
+// parseConfigYAML parses YAML into a Config. If a difficulty preset is named,
+// the preset is applied first and the YAML is decoded over it, so explicit
+// pacing and content values take precedence over the preset.
+func parseConfigYAML(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+
+	if cfg.Difficulty != "" {
+		var preset Config
+		if err := preset.ApplyDifficulty(cfg.Difficulty); err != nil {
+			return nil, fmt.Errorf("difficulty: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &preset); err != nil {
+			return nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+		cfg = preset
 	}
 
 	return &cfg, nil
@@ -192,8 +895,17 @@ func LoadConfigFromBytes(data []byte) (*Config, error) {
 // Validate checks all configuration constraints.
 // Returns an error describing the first validation failure, or nil if valid.
 func (c *Config) Validate() error {
-	// Validate Size
-	if err := c.Size.Validate(); err != nil {
+	// Validate Version
+	if c.Version < 0 || c.Version > CurrentConfigVersion {
+		return fmt.Errorf("version %d is not supported, must be at most %d", c.Version, CurrentConfigVersion)
+	}
+
+	// Validate Size; zoned dungeons may be larger
+	maxRooms := synthesis.MaxRooms
+	if c.Zones.Size > 0 {
+		maxRooms = synthesis.MaxZonedRooms
+	}
+	if err := c.Size.validate(maxRooms); err != nil {
 		return fmt.Errorf("size: %w", err)
 	}
 
@@ -211,12 +923,65 @@ func (c *Config) Validate() error {
 	if len(c.Themes) == 0 {
 		return errors.New("at least one theme must be specified")
 	}
+	for i, name := range c.Themes {
+		if _, ok := themes.Lookup(name); !ok {
+			return fmt.Errorf("themes[%d]: unknown theme %q, must be one of: %s", i, name, strings.Join(themes.Names(), ", "))
+		}
+	}
 
 	// Validate Keys
+	keyNames := make(map[string]bool, len(c.Keys))
+	smallKeys := make(map[string]bool)
 	for i, key := range c.Keys {
 		if err := key.Validate(); err != nil {
 			return fmt.Errorf("key[%d]: %w", i, err)
 		}
+		keyNames[key.Name] = true
+		if key.Consumable {
+			smallKeys[key.Name] = true
+		}
+	}
+	for i, key := range c.Keys {
+		for _, name := range append(append([]string(nil), key.Opens...), key.Requires...) {
+			if !keyNames[name] {
+				return fmt.Errorf("key[%d]: %q is not a configured key", i, name)
+			}
+			if smallKeys[name] {
+				return fmt.Errorf("key[%d]: small key %q cannot be opened or required by another key", i, name)
+			}
+		}
+	}
+
+	// Validate Archetypes
+	targeted := make(map[graph.RoomArchetype]bool, len(c.Archetypes))
+	fractions, counts := 0.0, 0
+	for i, target := range c.Archetypes {
+		if err := target.Validate(); err != nil {
+			return fmt.Errorf("archetypes[%d]: %w", i, err)
+		}
+		archetype, _ := graph.ParseArchetype(target.Archetype)
+		if targeted[archetype] {
+			return fmt.Errorf("archetypes[%d]: %s is targeted twice", i, archetype)
+		}
+		targeted[archetype] = true
+		fractions += target.Fraction
+		counts += target.Count
+	}
+	if fractions > 1.0 {
+		return fmt.Errorf("archetypes: fractions sum to %f, must be at most 1.0", fractions)
+	}
+	if counts > c.Size.RoomsMin {
+		return fmt.Errorf("archetypes: counts sum to %d, more than size.roomsMin (%d)", counts, c.Size.RoomsMin)
+	}
+
+	// Validate Rooms
+	if err := c.Rooms.Validate(); err != nil {
+		return fmt.Errorf("rooms: %w", err)
+	}
+
+	// Validate Map
+	if err := c.Map.Validate(); err != nil {
+		return fmt.Errorf("map: %w", err)
 	}
 
 	// Validate SecretDensity
@@ -224,11 +989,99 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("secretDensity must be in range [0.0, 0.3], got %f", c.SecretDensity)
 	}
 
+	// Validate Vaults
+	if c.Vaults < 0 || c.Vaults > synthesis.MaxVaults {
+		return fmt.Errorf("vaults must be in range [0, %d], got %d", synthesis.MaxVaults, c.Vaults)
+	}
+	if c.Vaults > 0 && c.Branching.Max < 3 {
+		return fmt.Errorf("vaults need branching.max >= 3, got %d", c.Branching.Max)
+	}
+	if need := c.vaultRoomsMin(); c.Vaults > 0 && c.Size.RoomsMin < need {
+		return fmt.Errorf("%d vaults need size.roomsMin >= %d, got %d", c.Vaults, need, c.Size.RoomsMin)
+	}
+	if c.Vaults > 0 {
+		for i, key := range c.Keys {
+			if strings.HasPrefix(key.Name, synthesis.VaultKeyPrefix) {
+				return fmt.Errorf("key[%d]: names starting with %q are reserved for vault keys", i, synthesis.VaultKeyPrefix)
+			}
+		}
+	}
+
+	// Validate Exit; synced passages could join it to rooms other than the Boss
+	if c.Exit && c.Map.Adjacency == AdjacencySync {
+		return errors.New("exit does not support map.adjacency sync")
+	}
+
+	// Validate Escape
+	if err := c.Escape.Validate(); err != nil {
+		return fmt.Errorf("escape: %w", err)
+	}
+
 	// Validate OptionalRatio
 	if c.OptionalRatio < 0.1 || c.OptionalRatio > 0.4 {
 		return fmt.Errorf("optionalRatio must be in range [0.1, 0.4], got %f", c.OptionalRatio)
 	}
 
+	// Validate Difficulty
+	if c.Difficulty != "" {
+		if _, ok := DifficultyPresets[c.Difficulty]; !ok {
+			return fmt.Errorf("difficulty: unknown preset %q, must be one of: casual, normal, brutal", c.Difficulty)
+		}
+	}
+
+	// Validate Content
+	if err := c.Content.Validate(); err != nil {
+		return fmt.Errorf("content: %w", err)
+	}
+
+	// Validate Secrets
+	if err := c.Secrets.Validate(); err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	// Validate Accessibility
+	if err := c.Accessibility.Validate(); err != nil {
+		return fmt.Errorf("accessibility: %w", err)
+	}
+	if err := c.validateCriticalPathMap(); err != nil {
+		return fmt.Errorf("accessibility: %w", err)
+	}
+
+	// Validate Party
+	if err := c.Party.Validate(); err != nil {
+		return fmt.Errorf("party: %w", err)
+	}
+
+	// Validate Mode
+	if err := c.validateMode(); err != nil {
+		return fmt.Errorf("mode: %w", err)
+	}
+
+	// Validate Zones
+	if err := c.validateZones(); err != nil {
+		return fmt.Errorf("zones: %w", err)
+	}
+
+	// Validate LayoutPresets
+	if err := validateLayoutPresets(c.LayoutPresets); err != nil {
+		return fmt.Errorf("layoutPresets%w", err)
+	}
+
+	// Validate Quality
+	if err := c.Quality.Validate(); err != nil {
+		return fmt.Errorf("quality: %w", err)
+	}
+
+	// Validate Limits
+	if err := c.Limits.Validate(); err != nil {
+		return fmt.Errorf("limits: %w", err)
+	}
+
+	// Validate StopAfter
+	if err := c.validateStopAfter(); err != nil {
+		return fmt.Errorf("stopAfter: %w", err)
+	}
+
 	// Validate Constraints
 	for i, constraint := range c.Constraints {
 		if err := constraint.Validate(); err != nil {
@@ -236,16 +1089,26 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate Metadata
+	if err := c.Metadata.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // Validate checks SizeCfg constraints.
 func (s *SizeCfg) Validate() error {
-	if s.RoomsMin < 10 {
-		return fmt.Errorf("roomsMin must be at least 10, got %d", s.RoomsMin)
+	return s.validate(synthesis.MaxRooms)
+}
+
+// validate checks SizeCfg constraints with the given room count limit.
+func (s *SizeCfg) validate(maxRooms int) error {
+	if s.RoomsMin < synthesis.MinRooms {
+		return fmt.Errorf("roomsMin must be at least %d, got %d", synthesis.MinRooms, s.RoomsMin)
 	}
-	if s.RoomsMax > 300 {
-		return fmt.Errorf("roomsMax must be at most 300, got %d", s.RoomsMax)
+	if s.RoomsMax > maxRooms {
+		return fmt.Errorf("roomsMax must be at most %d, got %d", maxRooms, s.RoomsMax)
 	}
 	if s.RoomsMin > s.RoomsMax {
 		return fmt.Errorf("roomsMin (%d) must be <= roomsMax (%d)", s.RoomsMin, s.RoomsMax)
@@ -305,6 +1168,214 @@ func (p *PacingCfg) Validate() error {
 	return nil
 }
 
+// Validate checks ContentCfg constraints.
+func (c *ContentCfg) Validate() error {
+	if c.SpawnDensity != 0 && (c.SpawnDensity < 0.25 || c.SpawnDensity > 2.0) {
+		return fmt.Errorf("spawnDensity must be 0 or in range [0.25, 2.0], got %f", c.SpawnDensity)
+	}
+	if c.LootBudget < 0 || c.LootBudget > 100000 {
+		return fmt.Errorf("lootBudget must be in range [0, 100000], got %d", c.LootBudget)
+	}
+	if c.TrapDensity < 0.0 || c.TrapDensity > 1.0 {
+		return fmt.Errorf("trapDensity must be in range [0.0, 1.0], got %f", c.TrapDensity)
+	}
+	if c.EnvironmentRatio < 0.0 || c.EnvironmentRatio > 0.8 {
+		return fmt.Errorf("environmentRatio must be in range [0.0, 0.8], got %f", c.EnvironmentRatio)
+	}
+	if c.AmbushRatio < 0.0 || c.AmbushRatio > 1.0 {
+		return fmt.Errorf("ambushRatio must be in range [0.0, 1.0], got %f", c.AmbushRatio)
+	}
+	if c.SkirmishRatio < 0.0 || c.SkirmishRatio > 1.0 {
+		return fmt.Errorf("skirmishRatio must be in range [0.0, 1.0], got %f", c.SkirmishRatio)
+	}
+	if c.SkirmishRatio > 0 && !c.Factions {
+		return errors.New("skirmishRatio needs factions")
+	}
+	if c.EntityRadius < 0 || c.EntityRadius > 4 {
+		return fmt.Errorf("entityRadius must be in range [0, 4], got %d", c.EntityRadius)
+	}
+	return nil
+}
+
+// Validate checks AccessibilityCfg constraints.
+func (a *AccessibilityCfg) Validate() error {
+	if a.MaxCombatBetweenCheckpoints < 0 || a.MaxCombatBetweenCheckpoints > 20 {
+		return fmt.Errorf("maxCombatBetweenCheckpoints must be in range [0, 20], got %d", a.MaxCombatBetweenCheckpoints)
+	}
+	return nil
+}
+
+// vaultRoomsMin returns the fewest rooms that fit the vaults: the core
+// rooms, the Exit, each vault's rooms and a room per backtracking ability,
+// as the vaults are cut from the room count before the abilities are
+// placed.
+func (c *Config) vaultRoomsMin() int {
+	need := synthesis.CoreRooms + c.Vaults*synthesis.VaultRooms
+	if c.Exit {
+		need++
+	}
+	if c.Mode == ModeBacktrack {
+		need += c.Backtrack.PassCount()
+	}
+	return need
+}
+
+// validateMode checks the mode and the settings it cannot be combined with.
+func (c *Config) validateMode() error {
+	if c.Backtrack.Passes != 0 && c.Mode != ModeBacktrack {
+		return errors.New("backtrack.passes needs backtrack mode")
+	}
+
+	// Arena and wave layouts have fixed shapes of their own
+	if c.Mode == ModeArena || c.Mode == ModeWave {
+		if c.Map.shaped() {
+			return fmt.Errorf("%s mode does not support map.aspectRatio or map.boundary", c.Mode)
+		}
+		if c.Map.Layout != "" {
+			return fmt.Errorf("%s mode does not support map.layout", c.Mode)
+		}
+		if c.Map.Junctions {
+			return fmt.Errorf("%s mode does not support map.junctions", c.Mode)
+		}
+		if c.Map.Adjacency == AdjacencySync {
+			return fmt.Errorf("%s mode does not support map.adjacency sync", c.Mode)
+		}
+		if c.Map.SharedWalls != "" {
+			return fmt.Errorf("%s mode does not support map.sharedWalls", c.Mode)
+		}
+		if c.Exit {
+			return fmt.Errorf("%s mode does not support exit", c.Mode)
+		}
+		if c.Vaults > 0 {
+			return fmt.Errorf("%s mode does not support vaults", c.Mode)
+		}
+		if c.Escape.Enabled {
+			return fmt.Errorf("%s mode does not support escape", c.Mode)
+		}
+	}
+
+	switch c.Mode {
+	case "", ModeStandard:
+		return nil
+	case ModeArena:
+		if c.Map.Repack {
+			return errors.New("arena mode does not support map.repack")
+		}
+		if len(c.Keys) > 0 {
+			return errors.New("arena mode does not support keys")
+		}
+		if c.Party.Size > 1 {
+			return errors.New("arena mode does not support party.size")
+		}
+		if c.Size.RoomsMin == c.Size.RoomsMax && c.Size.RoomsMin%2 == 0 {
+			return fmt.Errorf("arena mode needs an odd room count, size allows only %d", c.Size.RoomsMin)
+		}
+		return nil
+	case ModeWave:
+		if len(c.Keys) > 0 {
+			return errors.New("wave mode does not support keys")
+		}
+		if c.Branching.Max < 3 {
+			return fmt.Errorf("wave mode needs branching.max >= 3, got %d", c.Branching.Max)
+		}
+		if c.Content.Factions {
+			return errors.New("wave mode does not support content.factions")
+		}
+		return nil
+	case ModeBacktrack:
+		if c.Backtrack.Passes < 0 || c.Backtrack.Passes > synthesis.MaxBacktrackPasses {
+			return fmt.Errorf("backtrack.passes must be in range [0, %d], got %d", synthesis.MaxBacktrackPasses, c.Backtrack.Passes)
+		}
+		if c.Accessibility.LowBacktracking {
+			return errors.New("backtrack mode does not support accessibility.lowBacktracking")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q, must be one of: standard, arena, wave, backtrack", c.Mode)
+	}
+}
+
+// validateZones checks the zone settings. Zoning is only supported in
+// standard mode, whose content does not depend on mirrored or ringed rooms
+// spread over several zones.
+func (c *Config) validateZones() error {
+	if c.Zones.Size == 0 {
+		return nil
+	}
+	if c.Zones.Size < 20 || c.Zones.Size > synthesis.MaxRooms {
+		return fmt.Errorf("size must be 0 or in range [20, %d], got %d", synthesis.MaxRooms, c.Zones.Size)
+	}
+	if c.Mode != "" && c.Mode != ModeStandard {
+		return fmt.Errorf("%s mode does not support zones", c.Mode)
+	}
+	if c.Map.shaped() {
+		return errors.New("zones do not support map.aspectRatio or map.boundary")
+	}
+	if c.Map.Junctions {
+		return errors.New("zones do not support map.junctions")
+	}
+	if c.Map.Adjacency == AdjacencySync {
+		return errors.New("zones do not support map.adjacency sync")
+	}
+	if c.Map.SharedWalls != "" {
+		return errors.New("zones do not support map.sharedWalls")
+	}
+	if c.Escape.Enabled {
+		return errors.New("zones do not support escape")
+	}
+	return nil
+}
+
+// validateStopAfter checks the stop stage. Quality gates judge validation
+// metrics, and zones embed, carve and place content zone by zone, so they
+// can only stop after synthesis.
+func (c *Config) validateStopAfter() error {
+	if c.StopAfter == "" || c.StopAfter == StageValidation {
+		return nil
+	}
+	if !isPipelineStage(string(c.StopAfter)) {
+		return fmt.Errorf("unknown stage %q, must be one of: %s", c.StopAfter, strings.Join(PipelineStages, ", "))
+	}
+	if len(c.Quality.Gates) > 0 {
+		return errors.New("quality gates need the validation stage")
+	}
+	if c.Zones.Size > 0 && c.StopAfter != StageSynthesis {
+		return fmt.Errorf("zones can only stop after %s, got %s", StageSynthesis, c.StopAfter)
+	}
+	return nil
+}
+
+// validateCriticalPathMap rejects map options that add connectors after
+// synthesis when a guarantee synthesis places along the critical path is
+// enabled: the new connectors can move the path off the keys and around the
+// checkpoints placed for it.
+func (c *Config) validateCriticalPathMap() error {
+	if !c.Accessibility.LowBacktracking && c.Accessibility.MaxCombatBetweenCheckpoints == 0 {
+		return nil
+	}
+	if c.Map.Junctions {
+		return errors.New("lowBacktracking and maxCombatBetweenCheckpoints do not support map.junctions")
+	}
+	if c.Map.Adjacency == AdjacencySync {
+		return errors.New("lowBacktracking and maxCombatBetweenCheckpoints do not support map.adjacency sync")
+	}
+	if c.Map.SharedWalls == SharedWallsBreakable {
+		return errors.New("lowBacktracking and maxCombatBetweenCheckpoints do not support map.sharedWalls breakable")
+	}
+	return nil
+}
+
+// Validate checks PartyCfg constraints.
+func (p *PartyCfg) Validate() error {
+	if p.Size < 0 || p.Size > 8 {
+		return fmt.Errorf("size must be in range [0, 8], got %d", p.Size)
+	}
+	if p.ConvergeWithin < 0 || p.ConvergeWithin > 10 {
+		return fmt.Errorf("convergeWithin must be in range [0, 10], got %d", p.ConvergeWithin)
+	}
+	return nil
+}
+
 // Validate checks KeyCfg constraints.
 func (k *KeyCfg) Validate() error {
 	if k.Name == "" {
@@ -313,6 +1384,22 @@ func (k *KeyCfg) Validate() error {
 	if k.Count < 1 || k.Count > 5 {
 		return fmt.Errorf("count must be in range [1, 5], got %d", k.Count)
 	}
+	for _, name := range k.Opens {
+		if name == k.Name {
+			return fmt.Errorf("key %q cannot open itself", k.Name)
+		}
+	}
+	for _, name := range k.Requires {
+		if name == k.Name {
+			return fmt.Errorf("key %q cannot require itself", k.Name)
+		}
+	}
+	if k.RequireAny && len(k.Requires) == 0 {
+		return errors.New("requireAny needs requires")
+	}
+	if k.Consumable && (len(k.Opens) > 0 || len(k.Requires) > 0) {
+		return fmt.Errorf("small key %q cannot open or require other keys", k.Name)
+	}
 	return nil
 }
 
@@ -335,13 +1422,101 @@ func (c *Config) ToYAML() ([]byte, error) {
 	return yaml.Marshal(c)
 }
 
+// Canonical returns the normalized form of the config that Hash hashes:
+// a copy with every default spelled out and every setting that cannot affect
+// generation cleared, so configs that generate the same dungeon are equal.
+// The schema version, difficulty preset name, quality gates, limits,
+// stop stage, metadata and key presentation are cleared (presets are applied when the config is loaded),
+// zero values with a documented default
+// take that default, names are case-normalized, zero size weights and the
+// custom points of other curves are dropped, and Repack sets Trim.
+func (c *Config) Canonical() *Config {
+	n := *c
+	n.Version = 0
+	n.Difficulty = ""
+	n.Metadata = nil
+	n.Quality = QualityCfg{}
+	n.Limits = nil
+	n.StopAfter = ""
+
+	if n.Mode == "" {
+		n.Mode = ModeStandard
+	}
+	if n.Pacing.Curve != PacingCustom {
+		n.Pacing.CustomPoints = nil
+	}
+	if n.Party.Size == 1 {
+		n.Party.Size = 0
+	}
+	n.Party.ConvergeWithin = n.Party.ConvergenceLimit()
+	if n.Content.SpawnDensity == 0 {
+		n.Content.SpawnDensity = DefaultSpawnDensity
+	}
+	if n.Content.LootBudget == 0 {
+		n.Content.LootBudget = DefaultLootBudget
+	}
+	if len(n.Secrets.Weights) == 0 {
+		n.Secrets.Weights = nil
+	}
+	if n.Map.Repack {
+		n.Map.Trim = true
+	}
+	if n.Map.Layout == LayoutForce {
+		n.Map.Layout = ""
+	}
+	if n.Mode == ModeBacktrack {
+		n.Backtrack.Passes = n.Backtrack.PassCount()
+	}
+	if n.Escape.Enabled {
+		n.Escape.Speed = n.Escape.MoveSpeed()
+	}
+
+	if len(c.Keys) > 0 {
+		n.Keys = make([]KeyCfg, len(c.Keys))
+		for i, k := range c.Keys {
+			k.DisplayName, k.Description, k.Icon = "", "", ""
+			n.Keys[i] = k
+		}
+	}
+
+	if len(c.Archetypes) > 0 {
+		n.Archetypes = make([]ArchetypeCfg, len(c.Archetypes))
+		for i, a := range c.Archetypes {
+			if archetype, ok := graph.ParseArchetype(a.Archetype); ok {
+				a.Archetype = archetype.String()
+			}
+			if a.Fraction > 0 && a.Tolerance == 0 {
+				a.Tolerance = DefaultArchetypeTolerance
+			}
+			n.Archetypes[i] = a
+		}
+	}
+
+	if len(c.Rooms.SizeWeights) > 0 {
+		n.Rooms.SizeWeights = make(map[string]float64, len(c.Rooms.SizeWeights))
+		for name, w := range c.Rooms.SizeWeights {
+			if w == 0 {
+				continue
+			}
+			if size, ok := graph.ParseSize(name); ok {
+				name = size.String()
+			}
+			n.Rooms.SizeWeights[name] = w
+		}
+	}
+
+	return &n
+}
+
 // Hash computes a deterministic hash of the configuration.
-// Used for deriving per-stage RNG seeds.
+// Used for deriving per-stage RNG seeds. It hashes the JSON encoding of
+// Canonical, with object keys in declaration order and map keys sorted, so
+// the hash depends neither on how the config was written - key order,
+// comments, spelled-out defaults - nor on the YAML encoder.
 func (c *Config) Hash() []byte {
-	// For deterministic hashing, we serialize to YAML and hash that
-	data, err := c.ToYAML()
+	data, err := json.Marshal(c.Canonical())
 	if err != nil {
-		// Fallback: just hash the seed if YAML fails
+		// Fallback: just hash the seed if encoding fails
 		h := sha256.New()
 		var buf [8]byte
 		binary.LittleEndian.PutUint64(buf[:], c.Seed)
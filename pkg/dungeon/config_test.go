@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_ValidConfig(t *testing.T) {
@@ -395,6 +396,36 @@ func TestConfig_ValidateKeys(t *testing.T) {
 			key:     KeyCfg{Name: "platinum", Count: 5},
 			wantErr: false,
 		},
+		{
+			name:    "master key",
+			key:     KeyCfg{Name: "master", Count: 1, Opens: []string{"silver", "gold"}},
+			wantErr: false,
+		},
+		{
+			name:    "opens itself",
+			key:     KeyCfg{Name: "master", Count: 1, Opens: []string{"master"}},
+			wantErr: true,
+		},
+		{
+			name:    "requires itself",
+			key:     KeyCfg{Name: "gold", Count: 1, Requires: []string{"gold"}},
+			wantErr: true,
+		},
+		{
+			name:    "requireAny without requires",
+			key:     KeyCfg{Name: "gold", Count: 1, RequireAny: true},
+			wantErr: true,
+		},
+		{
+			name:    "small keys",
+			key:     KeyCfg{Name: "castle", Count: 3, Consumable: true},
+			wantErr: false,
+		},
+		{
+			name:    "small master key",
+			key:     KeyCfg{Name: "castle", Count: 3, Consumable: true, Opens: []string{"silver"}},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -407,6 +438,764 @@ func TestConfig_ValidateKeys(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateKeyReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []KeyCfg
+		wantErr bool
+	}{
+		{
+			name: "master key over configured keys",
+			keys: []KeyCfg{
+				{Name: "silver", Count: 1},
+				{Name: "gold", Count: 1, Requires: []string{"silver"}},
+				{Name: "master", Count: 1, Opens: []string{"silver", "gold"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "opens unknown key",
+			keys:    []KeyCfg{{Name: "master", Count: 1, Opens: []string{"silver"}}},
+			wantErr: true,
+		},
+		{
+			name: "master key over small keys",
+			keys: []KeyCfg{
+				{Name: "castle", Count: 2, Consumable: true},
+				{Name: "master", Count: 1, Opens: []string{"castle"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "requires unknown key",
+			keys:    []KeyCfg{{Name: "gold", Count: 1, Requires: []string{"silver"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				Keys:          tt.keys,
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateArchetypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		archetypes []ArchetypeCfg
+		wantErr    bool
+	}{
+		{
+			name: "fractions and exact count",
+			archetypes: []ArchetypeCfg{
+				{Archetype: "treasure", Fraction: 0.1},
+				{Archetype: "Puzzle", Fraction: 0.08, Tolerance: 0.02},
+				{Archetype: "vendor", Count: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name:       "unknown archetype",
+			archetypes: []ArchetypeCfg{{Archetype: "tavern", Fraction: 0.1}},
+			wantErr:    true,
+		},
+		{
+			name:       "boss cannot be targeted",
+			archetypes: []ArchetypeCfg{{Archetype: "boss", Count: 1}},
+			wantErr:    true,
+		},
+		{
+			name:       "exit cannot be targeted",
+			archetypes: []ArchetypeCfg{{Archetype: "exit", Count: 1}},
+			wantErr:    true,
+		},
+		{
+			name:       "fraction and count",
+			archetypes: []ArchetypeCfg{{Archetype: "shrine", Fraction: 0.1, Count: 2}},
+			wantErr:    true,
+		},
+		{
+			name:       "neither fraction nor count",
+			archetypes: []ArchetypeCfg{{Archetype: "shrine"}},
+			wantErr:    true,
+		},
+		{
+			name:       "fraction tolerance above 1",
+			archetypes: []ArchetypeCfg{{Archetype: "hub", Fraction: 0.1, Tolerance: 2}},
+			wantErr:    true,
+		},
+		{
+			name: "archetype targeted twice",
+			archetypes: []ArchetypeCfg{
+				{Archetype: "treasure", Fraction: 0.1},
+				{Archetype: "Treasure", Count: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fractions above 1",
+			archetypes: []ArchetypeCfg{
+				{Archetype: "treasure", Fraction: 0.6},
+				{Archetype: "corridor", Fraction: 0.5},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "counts above roomsMin",
+			archetypes: []ArchetypeCfg{{Archetype: "vendor", Count: 25}},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				Archetypes:    tt.archetypes,
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchetypeCfg_Target(t *testing.T) {
+	fraction := ArchetypeCfg{Archetype: "treasure", Fraction: 0.1}
+	if want, tolerance := fraction.Target(40); want != 4 || tolerance != 2 {
+		t.Errorf("Target(40) = %v, %v, want 4, 2 with the default tolerance", want, tolerance)
+	}
+
+	count := ArchetypeCfg{Archetype: "vendor", Count: 1}
+	if want, tolerance := count.Target(40); want != 1 || tolerance != 0 {
+		t.Errorf("Target(40) = %v, %v, want exactly 1", want, tolerance)
+	}
+}
+
+func TestRoomsCfg_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rooms   RoomsCfg
+		wantErr bool
+	}{
+		{name: "zero value", rooms: RoomsCfg{}, wantErr: false},
+		{name: "weights and budget", rooms: RoomsCfg{SizeWeights: map[string]float64{"s": 1, "M": 2}, FloorBudget: 2000}, wantErr: false},
+		{name: "unknown size", rooms: RoomsCfg{SizeWeights: map[string]float64{"huge": 1}}, wantErr: true},
+		{name: "negative weight", rooms: RoomsCfg{SizeWeights: map[string]float64{"S": -1, "M": 1}}, wantErr: true},
+		{name: "all weights zero", rooms: RoomsCfg{SizeWeights: map[string]float64{"S": 0}}, wantErr: true},
+		{name: "negative budget", rooms: RoomsCfg{FloorBudget: -1}, wantErr: true},
+		{name: "all halls", rooms: RoomsCfg{HallRatio: 1}, wantErr: false},
+		{name: "hall ratio too high", rooms: RoomsCfg{HallRatio: 1.5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rooms.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RoomsCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoomsCfg_Weights(t *testing.T) {
+	if weights := (&RoomsCfg{}).Weights(); weights != nil {
+		t.Errorf("Weights() = %v, want nil without size weights", weights)
+	}
+
+	rooms := RoomsCfg{SizeWeights: map[string]float64{"xs": 0.5, "L": 2}}
+	want := []float64{0.5, 0, 0, 2, 0}
+	weights := rooms.Weights()
+	if len(weights) != len(want) {
+		t.Fatalf("Weights() = %v, want %v", weights, want)
+	}
+	for i := range want {
+		if weights[i] != want[i] {
+			t.Errorf("Weights() = %v, want %v", weights, want)
+			break
+		}
+	}
+}
+
+func TestMapCfg(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  MapCfg
+		wantErr bool
+	}{
+		{name: "unlimited", limits: MapCfg{}, wantErr: false},
+		{name: "width only", limits: MapCfg{MaxWidth: 200}, wantErr: false},
+		{name: "both", limits: MapCfg{MaxWidth: 128, MaxHeight: 96}, wantErr: false},
+		{name: "too narrow", limits: MapCfg{MaxWidth: 16}, wantErr: true},
+		{name: "negative height", limits: MapCfg{MaxHeight: -1}, wantErr: true},
+		{name: "aspect ratio", limits: MapCfg{AspectRatio: 2}, wantErr: false},
+		{name: "extreme aspect ratio", limits: MapCfg{AspectRatio: 10}, wantErr: true},
+		{name: "boundary", limits: MapCfg{Boundary: [][2]float64{{0, 0}, {100, 0}, {50, 80}}}, wantErr: false},
+		{name: "boundary too short", limits: MapCfg{Boundary: [][2]float64{{0, 0}, {100, 0}}}, wantErr: true},
+		{name: "negative boundary", limits: MapCfg{Boundary: [][2]float64{{-5, 0}, {100, 0}, {50, 80}}}, wantErr: true},
+		{name: "rings layout", limits: MapCfg{Layout: LayoutRings}, wantErr: false},
+		{name: "unknown layout", limits: MapCfg{Layout: "spiral"}, wantErr: true},
+		{name: "shaped rings", limits: MapCfg{Layout: LayoutRings, AspectRatio: 2}, wantErr: true},
+		{name: "layered layout", limits: MapCfg{Layout: LayoutLayered}, wantErr: false},
+		{name: "bounded layered", limits: MapCfg{Layout: LayoutLayered, Boundary: [][2]float64{{0, 0}, {100, 0}, {50, 80}}}, wantErr: true},
+		{name: "strict adjacency", limits: MapCfg{Adjacency: AdjacencyStrict}, wantErr: false},
+		{name: "unknown adjacency", limits: MapCfg{Adjacency: "merge"}, wantErr: true},
+		{name: "breakable shared walls", limits: MapCfg{SharedWalls: SharedWallsBreakable}, wantErr: false},
+		{name: "unknown shared walls", limits: MapCfg{SharedWalls: "merge"}, wantErr: true},
+		{name: "bounded separate shared walls", limits: MapCfg{SharedWalls: SharedWallsSeparate, Boundary: [][2]float64{{0, 0}, {100, 0}, {50, 80}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MapCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	limits := MapCfg{MaxWidth: 100}
+	if !limits.Fits(100, 5000) {
+		t.Error("Fits(100, 5000) = false, want true with unlimited height")
+	}
+	if limits.Fits(101, 10) {
+		t.Error("Fits(101, 10) = true, want false")
+	}
+}
+
+func TestConfig_ValidateContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content ContentCfg
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			content: ContentCfg{},
+			wantErr: false,
+		},
+		{
+			name:    "environment ratio",
+			content: ContentCfg{TrapDensity: 0.5, EnvironmentRatio: 0.8},
+			wantErr: false,
+		},
+		{
+			name:    "negative environment ratio",
+			content: ContentCfg{EnvironmentRatio: -0.1},
+			wantErr: true,
+		},
+		{
+			name:    "environment ratio too high",
+			content: ContentCfg{EnvironmentRatio: 0.9},
+			wantErr: true,
+		},
+		{
+			name:    "entity radius",
+			content: ContentCfg{EntityRadius: 4},
+			wantErr: false,
+		},
+		{
+			name:    "ambush ratio too high",
+			content: ContentCfg{AmbushRatio: 1.5},
+			wantErr: true,
+		},
+		{
+			name:    "entity radius too high",
+			content: ContentCfg{EntityRadius: 5},
+			wantErr: true,
+		},
+		{
+			name:    "factions with skirmishes",
+			content: ContentCfg{Factions: true, SkirmishRatio: 0.3},
+			wantErr: false,
+		},
+		{
+			name:    "skirmish ratio without factions",
+			content: ContentCfg{SkirmishRatio: 0.3},
+			wantErr: true,
+		},
+		{
+			name:    "skirmish ratio too high",
+			content: ContentCfg{Factions: true, SkirmishRatio: 1.5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.content.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ContentCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets SecretsCfg
+		wantErr bool
+	}{
+		{name: "theme weights", secrets: SecretsCfg{}, wantErr: false},
+		{name: "weights", secrets: SecretsCfg{Weights: map[string]float64{"illusory_wall": 2, "shrine_riddle": 1}}, wantErr: false},
+		{name: "unknown kind", secrets: SecretsCfg{Weights: map[string]float64{"trapdoor": 1}}, wantErr: true},
+		{name: "negative weight", secrets: SecretsCfg{Weights: map[string]float64{"movable_block": -1}}, wantErr: true},
+		{name: "all zero", secrets: SecretsCfg{Weights: map[string]float64{"movable_block": 0}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.secrets.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SecretsCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateQuality(t *testing.T) {
+	eight, limit := 8.0, 0.15
+	tests := []struct {
+		name    string
+		quality QualityCfg
+		wantErr bool
+	}{
+		{name: "no gates", quality: QualityCfg{}, wantErr: false},
+		{name: "gates", quality: QualityCfg{Gates: []QualityGate{{Metric: "pathLength", Min: &eight}, {Metric: "pacingDeviation", Max: &limit}}}, wantErr: false},
+		{name: "range", quality: QualityCfg{Gates: []QualityGate{{Metric: "pacingDeviation", Min: &limit, Max: &eight}}}, wantErr: false},
+		{name: "unknown metric", quality: QualityCfg{Gates: []QualityGate{{Metric: "fun", Min: &eight}}}, wantErr: true},
+		{name: "no bounds", quality: QualityCfg{Gates: []QualityGate{{Metric: "pathLength"}}}, wantErr: true},
+		{name: "empty range", quality: QualityCfg{Gates: []QualityGate{{Metric: "pathLength", Min: &eight, Max: &limit}}}, wantErr: true},
+		{name: "negative attempts", quality: QualityCfg{MaxAttempts: -1}, wantErr: true},
+		{name: "too many attempts", quality: QualityCfg{MaxAttempts: MaxQualityAttempts + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quality.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("QualityCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateAccessibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		acc     AccessibilityCfg
+		wantErr bool
+	}{
+		{
+			name:    "disabled",
+			acc:     AccessibilityCfg{},
+			wantErr: false,
+		},
+		{
+			name:    "all enabled",
+			acc:     AccessibilityCfg{NoRequiredSecrets: true, MaxCombatBetweenCheckpoints: 3, LowBacktracking: true},
+			wantErr: false,
+		},
+		{
+			name:    "negative max combat",
+			acc:     AccessibilityCfg{MaxCombatBetweenCheckpoints: -1},
+			wantErr: true,
+		},
+		{
+			name:    "max combat too high",
+			acc:     AccessibilityCfg{MaxCombatBetweenCheckpoints: 21},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.acc.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AccessibilityCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateParty(t *testing.T) {
+	tests := []struct {
+		name    string
+		party   PartyCfg
+		wantErr bool
+	}{
+		{
+			name:    "single player",
+			party:   PartyCfg{},
+			wantErr: false,
+		},
+		{
+			name:    "four players",
+			party:   PartyCfg{Size: 4, ConvergeWithin: 3},
+			wantErr: false,
+		},
+		{
+			name:    "size too high",
+			party:   PartyCfg{Size: 9},
+			wantErr: true,
+		},
+		{
+			name:    "negative convergence",
+			party:   PartyCfg{Size: 2, ConvergeWithin: -1},
+			wantErr: true,
+		},
+		{
+			name:    "convergence too high",
+			party:   PartyCfg{Size: 2, ConvergeWithin: 11},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.party.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PartyCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  LimitsCfg
+		wantErr bool
+	}{
+		{
+			name:    "unbounded",
+			limits:  LimitsCfg{},
+			wantErr: false,
+		},
+		{
+			name:    "bounded",
+			limits:  LimitsCfg{Timeout: time.Second, Stages: map[string]time.Duration{"carving": time.Millisecond}, MaxSynthesisAttempts: 3},
+			wantErr: false,
+		},
+		{
+			name:    "negative timeout",
+			limits:  LimitsCfg{Timeout: -time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "unknown stage",
+			limits:  LimitsCfg{Stages: map[string]time.Duration{"export": time.Second}},
+			wantErr: true,
+		},
+		{
+			name:    "zero stage deadline",
+			limits:  LimitsCfg{Stages: map[string]time.Duration{"content": 0}},
+			wantErr: true,
+		},
+		{
+			name:    "too many attempts",
+			limits:  LimitsCfg{MaxSynthesisAttempts: MaxSynthesisAttempts + 1},
+			wantErr: true,
+		},
+		{
+			name:    "negative memory budget",
+			limits:  LimitsCfg{MaxMemoryMB: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LimitsCfg.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateMode(t *testing.T) {
+	base := func(mode Mode) *Config {
+		return &Config{
+			Seed:          1,
+			Size:          SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Mode:          mode,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "default", modify: func(c *Config) { c.Mode = "" }, wantErr: false},
+		{name: "standard", modify: func(c *Config) { c.Mode = ModeStandard }, wantErr: false},
+		{name: "arena", modify: func(c *Config) {}, wantErr: false},
+		{name: "unknown mode", modify: func(c *Config) { c.Mode = "battle" }, wantErr: true},
+		{name: "arena with keys", modify: func(c *Config) { c.Keys = []KeyCfg{{Name: "silver", Count: 1}} }, wantErr: true},
+		{name: "arena with party", modify: func(c *Config) { c.Party.Size = 2 }, wantErr: true},
+		{name: "arena with even fixed size", modify: func(c *Config) { c.Size = SizeCfg{RoomsMin: 20, RoomsMax: 20} }, wantErr: true},
+		{name: "arena with odd fixed size", modify: func(c *Config) { c.Size = SizeCfg{RoomsMin: 21, RoomsMax: 21} }, wantErr: false},
+		{name: "wave", modify: func(c *Config) { c.Mode = ModeWave }, wantErr: false},
+		{name: "wave with party", modify: func(c *Config) { c.Mode, c.Party.Size = ModeWave, 3 }, wantErr: false},
+		{name: "wave with keys", modify: func(c *Config) { c.Mode, c.Keys = ModeWave, []KeyCfg{{Name: "silver", Count: 1}} }, wantErr: true},
+		{name: "wave with low branching", modify: func(c *Config) { c.Mode, c.Branching.Max = ModeWave, 2 }, wantErr: true},
+		{name: "backtrack", modify: func(c *Config) { c.Mode, c.Backtrack.Passes = ModeBacktrack, 3 }, wantErr: false},
+		{name: "backtrack with keys", modify: func(c *Config) { c.Mode, c.Keys = ModeBacktrack, []KeyCfg{{Name: "silver", Count: 1}} }, wantErr: false},
+		{name: "backtrack with too many passes", modify: func(c *Config) { c.Mode, c.Backtrack.Passes = ModeBacktrack, 4 }, wantErr: true},
+		{name: "backtrack with low backtracking", modify: func(c *Config) { c.Mode, c.Accessibility.LowBacktracking = ModeBacktrack, true }, wantErr: true},
+		{name: "passes without backtrack mode", modify: func(c *Config) { c.Mode, c.Backtrack.Passes = ModeStandard, 2 }, wantErr: true},
+		{name: "arena with junctions", modify: func(c *Config) { c.Map.Junctions = true }, wantErr: true},
+		{name: "backtrack with junctions", modify: func(c *Config) { c.Mode, c.Map.Junctions = ModeBacktrack, true }, wantErr: false},
+		{name: "arena with adjacency sync", modify: func(c *Config) { c.Map.Adjacency = AdjacencySync }, wantErr: true},
+		{name: "arena with strict adjacency", modify: func(c *Config) { c.Map.Adjacency = AdjacencyStrict }, wantErr: false},
+		{name: "arena with shared walls", modify: func(c *Config) { c.Map.SharedWalls = SharedWallsSeparate }, wantErr: true},
+		{name: "arena with repack", modify: func(c *Config) { c.Map.Repack = true }, wantErr: true},
+		{name: "arena with trim", modify: func(c *Config) { c.Map.Trim = true }, wantErr: false},
+		{name: "wave with repack", modify: func(c *Config) { c.Mode, c.Map.Repack = ModeWave, true }, wantErr: false},
+		{name: "arena with exit", modify: func(c *Config) { c.Exit = true }, wantErr: true},
+		{name: "wave with exit", modify: func(c *Config) { c.Mode, c.Exit = ModeWave, true }, wantErr: true},
+		{name: "wave with factions", modify: func(c *Config) { c.Mode, c.Content.Factions = ModeWave, true }, wantErr: true},
+		{name: "arena with factions", modify: func(c *Config) { c.Content.Factions = true }, wantErr: false},
+		{name: "standard with exit", modify: func(c *Config) { c.Mode, c.Exit = ModeStandard, true }, wantErr: false},
+		{name: "exit with adjacency sync", modify: func(c *Config) {
+			c.Mode, c.Exit, c.Map.Adjacency = ModeStandard, true, AdjacencySync
+		}, wantErr: true},
+		{name: "exit with strict adjacency", modify: func(c *Config) {
+			c.Mode, c.Exit, c.Map.Adjacency = ModeStandard, true, AdjacencyStrict
+		}, wantErr: false},
+		{name: "exit with junctions", modify: func(c *Config) { c.Mode, c.Exit, c.Map.Junctions = ModeStandard, true, true }, wantErr: false},
+		{name: "arena with vaults", modify: func(c *Config) { c.Vaults = 1 }, wantErr: true},
+		{name: "wave with vaults", modify: func(c *Config) { c.Mode, c.Vaults = ModeWave, 1 }, wantErr: true},
+		{name: "standard with vaults", modify: func(c *Config) { c.Mode, c.Vaults = ModeStandard, 3 }, wantErr: false},
+		{name: "too many vaults", modify: func(c *Config) { c.Mode, c.Vaults = ModeStandard, 4 }, wantErr: true},
+		{name: "vaults at narrow branching", modify: func(c *Config) {
+			c.Mode, c.Vaults, c.Branching.Max = ModeStandard, 1, 2
+		}, wantErr: true},
+		{name: "backtrack vaults in too few rooms", modify: func(c *Config) {
+			c.Mode, c.Vaults, c.Exit, c.Size = ModeBacktrack, 3, true, SizeCfg{RoomsMin: 10, RoomsMax: 10}
+		}, wantErr: true},
+		{name: "backtrack vaults in just enough rooms", modify: func(c *Config) {
+			c.Mode, c.Vaults, c.Exit, c.Size = ModeBacktrack, 3, true, SizeCfg{RoomsMin: 12, RoomsMax: 12}
+		}, wantErr: false},
+		{name: "standard vaults in fewest rooms", modify: func(c *Config) {
+			c.Mode, c.Vaults, c.Exit, c.Size = ModeStandard, 3, true, SizeCfg{RoomsMin: 10, RoomsMax: 10}
+		}, wantErr: false},
+		{name: "vault key name", modify: func(c *Config) {
+			c.Mode, c.Vaults, c.Keys = ModeStandard, 1, []KeyCfg{{Name: "vault_0_outer", Count: 1}}
+		}, wantErr: true},
+		{name: "arena with escape", modify: func(c *Config) { c.Escape.Enabled = true }, wantErr: true},
+		{name: "wave with escape", modify: func(c *Config) { c.Mode, c.Escape.Enabled = ModeWave, true }, wantErr: true},
+		{name: "standard with escape", modify: func(c *Config) {
+			c.Mode, c.Escape = ModeStandard, EscapeCfg{Enabled: true, TimeLimitMs: 60000, Speed: 5}
+		}, wantErr: false},
+		{name: "escape speed without enabled", modify: func(c *Config) { c.Mode, c.Escape.Speed = ModeStandard, 5 }, wantErr: true},
+		{name: "negative escape time limit", modify: func(c *Config) {
+			c.Mode, c.Escape = ModeStandard, EscapeCfg{Enabled: true, TimeLimitMs: -1}
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base(ModeArena)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateZones(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "no zones", modify: func(c *Config) {}, wantErr: false},
+		{name: "large without zones", modify: func(c *Config) { c.Size.RoomsMax = 500 }, wantErr: true},
+		{name: "large with zones", modify: func(c *Config) { c.Size.RoomsMax, c.Zones.Size = 500, 60 }, wantErr: false},
+		{name: "too large with zones", modify: func(c *Config) { c.Size.RoomsMax, c.Zones.Size = 2500, 60 }, wantErr: true},
+		{name: "zone too small", modify: func(c *Config) { c.Zones.Size = 10 }, wantErr: true},
+		{name: "zone too large", modify: func(c *Config) { c.Zones.Size = 400 }, wantErr: true},
+		{name: "arena with zones", modify: func(c *Config) { c.Mode, c.Zones.Size = ModeArena, 40 }, wantErr: true},
+		{name: "zones with junctions", modify: func(c *Config) { c.Zones.Size, c.Map.Junctions = 40, true }, wantErr: true},
+		{name: "zones with adjacency sync", modify: func(c *Config) { c.Zones.Size, c.Map.Adjacency = 40, AdjacencySync }, wantErr: true},
+		{name: "zones with shared walls", modify: func(c *Config) { c.Zones.Size, c.Map.SharedWalls = 40, SharedWallsBreakable }, wantErr: true},
+		{name: "zones with escape", modify: func(c *Config) { c.Zones.Size, c.Escape.Enabled = 40, true }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+			}
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateStopAfter(t *testing.T) {
+	eight := 8.0
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "complete pipeline", modify: func(c *Config) {}, wantErr: false},
+		{name: "synthesis", modify: func(c *Config) { c.StopAfter = StageSynthesis }, wantErr: false},
+		{name: "content", modify: func(c *Config) { c.StopAfter = StageContent }, wantErr: false},
+		{name: "validation", modify: func(c *Config) { c.StopAfter = StageValidation }, wantErr: false},
+		{name: "unknown stage", modify: func(c *Config) { c.StopAfter = "graph" }, wantErr: true},
+		{name: "quality gates", modify: func(c *Config) {
+			c.StopAfter = StageCarving
+			c.Quality.Gates = []QualityGate{{Metric: "pathLength", Min: &eight}}
+		}, wantErr: true},
+		{name: "zones after synthesis", modify: func(c *Config) { c.StopAfter, c.Zones.Size = StageSynthesis, 40 }, wantErr: false},
+		{name: "zones after embedding", modify: func(c *Config) { c.StopAfter, c.Zones.Size = StageEmbedding, 40 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+			}
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestConfig_ValidateCriticalPathMap verifies map options that add
+// connectors after synthesis are rejected alongside the accessibility
+// guarantees placed along the critical path.
+func TestConfig_ValidateCriticalPathMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "junctions", modify: func(c *Config) { c.Map.Junctions = true }, wantErr: false},
+		{name: "low backtracking", modify: func(c *Config) { c.Accessibility.LowBacktracking = true }, wantErr: false},
+		{name: "junctions with low backtracking", modify: func(c *Config) {
+			c.Map.Junctions, c.Accessibility.LowBacktracking = true, true
+		}, wantErr: true},
+		{name: "junctions with checkpoints", modify: func(c *Config) {
+			c.Map.Junctions, c.Accessibility.MaxCombatBetweenCheckpoints = true, 3
+		}, wantErr: true},
+		{name: "adjacency sync with low backtracking", modify: func(c *Config) {
+			c.Map.Adjacency, c.Accessibility.LowBacktracking = AdjacencySync, true
+		}, wantErr: true},
+		{name: "strict adjacency with checkpoints", modify: func(c *Config) {
+			c.Map.Adjacency, c.Accessibility.MaxCombatBetweenCheckpoints = AdjacencyStrict, 3
+		}, wantErr: false},
+		{name: "breakable walls with checkpoints", modify: func(c *Config) {
+			c.Map.SharedWalls, c.Accessibility.MaxCombatBetweenCheckpoints = SharedWallsBreakable, 3
+		}, wantErr: true},
+		{name: "separate walls with low backtracking", modify: func(c *Config) {
+			c.Map.SharedWalls, c.Accessibility.LowBacktracking = SharedWallsSeparate, true
+		}, wantErr: false},
+		{name: "no required secrets with junctions", modify: func(c *Config) {
+			c.Map.Junctions, c.Accessibility.NoRequiredSecrets = true, true
+		}, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+			}
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateLayoutPresets(t *testing.T) {
+	tests := []struct {
+		name    string
+		presets []LayoutPreset
+		wantErr bool
+	}{
+		{name: "built-in", presets: nil, wantErr: false},
+		{name: "custom", presets: DefaultLayoutPresets(), wantErr: false},
+		{name: "single", presets: []LayoutPreset{{MaxRooms: 30, SpringConstant: 1, RepulsionConstant: 400, CorridorMaxLength: 300}}, wantErr: false},
+		{name: "zero repulsion", presets: []LayoutPreset{{MaxRooms: 30, SpringConstant: 1, CorridorMaxLength: 300}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Seed:          1,
+				Size:          SizeCfg{RoomsMin: 21, RoomsMax: 30},
+				Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+				LayoutPresets: tt.presets,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestConfig_ValidateConstraints(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -518,6 +1307,28 @@ optionalRatio: 0.25
 			wantErr: true,
 			errMsg:  "at least one theme",
 		},
+		{
+			name: "unknown theme",
+			yaml: `
+seed: 42
+size:
+  roomsMin: 20
+  roomsMax: 50
+branching:
+  avg: 2.0
+  max: 3
+pacing:
+  curve: LINEAR
+  variance: 0.15
+themes:
+  - crypt
+  - swamp
+secretDensity: 0.15
+optionalRatio: 0.25
+`,
+			wantErr: true,
+			errMsg:  `themes[1]: unknown theme "swamp"`,
+		},
 		{
 			name: "secret density too high",
 			yaml: `
@@ -796,6 +1607,91 @@ func TestConfig_Hash(t *testing.T) {
 	}
 }
 
+// TestConfig_HashCanonical verifies the hash ignores how a config is written:
+// key order, comments, spelled-out defaults and name case, but not settings
+// that change the dungeon.
+func TestConfig_HashCanonical(t *testing.T) {
+	minimal := `
+seed: 42
+size: {roomsMin: 20, roomsMax: 30}
+branching: {avg: 2.0, max: 3}
+pacing: {curve: LINEAR, variance: 0.1}
+themes: [crypt]
+secretDensity: 0.1
+optionalRatio: 0.2
+archetypes:
+  - archetype: treasure
+    fraction: 0.1
+rooms:
+  sizeWeights: {m: 2, l: 1}
+keys:
+  - name: silver
+    count: 1
+`
+	rewritten := `
+# Same dungeon, written differently
+version: 1
+optionalRatio: 0.2   # optional rooms
+secretDensity: 0.1
+themes: [crypt]
+pacing:
+  variance: 0.1
+  curve: LINEAR
+  customPoints: [[0.0, 0.1], [1.0, 0.9]]
+branching: {max: 3, avg: 2.0}
+size: {roomsMax: 30, roomsMin: 20}
+seed: 42
+mode: standard
+difficulty: normal
+content: {spawnDensity: 1.0, lootBudget: 1000, trapDensity: 0.15}
+party: {size: 1, convergeWithin: 2}
+limits: {timeout: 2s, stages: {embedding: 500ms}, maxSynthesisAttempts: 3}
+archetypes:
+  - archetype: Treasure
+    fraction: 0.1
+    tolerance: 0.05
+rooms:
+  sizeWeights: {L: 1, M: 2, XS: 0}
+keys:
+  - name: silver
+    count: 1
+    displayName: Tarnished Silver Key
+    description: Opens the vault.
+    icon: icons/key_silver
+`
+	load := func(data string) *Config {
+		t.Helper()
+		cfg, err := LoadConfigFromBytes([]byte(data))
+		if err != nil {
+			t.Fatalf("LoadConfigFromBytes() error = %v", err)
+		}
+		return cfg
+	}
+
+	// The normal preset's trap density is spelled out in the rewrite
+	base := load(minimal + "content: {trapDensity: 0.15}\n")
+	if string(base.Hash()) != string(load(rewritten).Hash()) {
+		t.Error("equivalent configs produce different hashes")
+	}
+
+	changed := load(minimal + "content: {trapDensity: 0.2}\n")
+	if string(base.Hash()) == string(changed.Hash()) {
+		t.Error("configs with different trap densities produce identical hashes")
+	}
+
+	if limits := load(rewritten).Limits; limits.Timeout != 2*time.Second || limits.Stages["embedding"] != 500*time.Millisecond {
+		t.Errorf("Limits = %+v, want a 2s timeout and a 500ms embedding deadline", limits)
+	}
+
+	if keys := load(rewritten).Keys; keys[0].DisplayName != "Tarnished Silver Key" || keys[0].Icon != "icons/key_silver" {
+		t.Errorf("Keys = %+v, want the presentation kept outside the hash", keys)
+	}
+
+	if c := base.Canonical(); c == base || base.Mode != "" || c.Mode != ModeStandard {
+		t.Error("Canonical() modified the config instead of a copy")
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
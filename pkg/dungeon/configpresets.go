@@ -0,0 +1,55 @@
+package dungeon
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// presetFS holds the curated config presets shipped in the binary, one YAML
+// file per preset named after it.
+//
+//go:embed presets/*.yaml
+var presetFS embed.FS
+
+// Presets returns the names of the built-in config presets, sorted. Load
+// one with LoadPreset.
+func Presets() []string {
+	entries, err := presetFS.ReadDir("presets")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPreset returns a fresh copy of the built-in config preset name, such
+// as "zelda_small", validated like LoadConfig. Presets have fixed seeds, so
+// a preset generates the same dungeon until the seed is changed.
+func LoadPreset(name string) (*Config, error) {
+	data, err := PresetYAML(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfigFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// PresetYAML returns the YAML source of the built-in config preset name, as
+// a starting point for a config file of one's own.
+func PresetYAML(name string) ([]byte, error) {
+	data, err := presetFS.ReadFile(path.Join("presets", name+".yaml"))
+	if err != nil || strings.ContainsAny(name, "/.") {
+		return nil, fmt.Errorf("unknown preset %q, must be one of: %s", name, strings.Join(Presets(), ", "))
+	}
+	return data, nil
+}
@@ -0,0 +1,199 @@
+// Package conformance backs the determinism guarantee with something
+// integrators can run: it generates a corpus of configs and seeds, hashes
+// each part of the artifacts, and compares the hashes with ones recorded on
+// another platform or Go version. A mismatch means the same seed and config
+// gave a different dungeon there, and names the parts that differ.
+//
+// The repository records its hashes in testdata/conformance/hashes.json for
+// the configs in testdata/conformance/corpus. They are checked by
+//
+//	go test -tags conformance ./pkg/dungeon/conformance
+//
+// and by the dungeongen conformance subcommand.
+package conformance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// DefaultSeeds is the number of consecutive seeds generated per config.
+const DefaultSeeds = 3
+
+// Case is one dungeon of the corpus.
+type Case struct {
+	Name   string // "<config file name>/<seed>", e.g. "standard/12345"
+	Config *dungeon.Config
+}
+
+// Corpus loads every .yaml config in dir, in name order, and returns a
+// case for each of seeds consecutive seeds starting at the config's own.
+func Corpus(dir string, seeds int) ([]Case, error) {
+	if seeds < 1 {
+		return nil, fmt.Errorf("seeds must be positive, got %d", seeds)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .yaml configs in %s", dir)
+	}
+	sort.Strings(paths)
+
+	var cases []Case
+	for _, path := range paths {
+		cfg, err := dungeon.LoadConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".yaml")
+		for i := 0; i < seeds; i++ {
+			seeded := *cfg
+			seeded.Seed = cfg.Seed + uint64(i)
+			cases = append(cases, Case{Name: fmt.Sprintf("%s/%d", name, seeded.Seed), Config: &seeded})
+		}
+	}
+	return cases, nil
+}
+
+// Digest is the hashes of an artifact's parts: the hex SHA-256 of each
+// top-level field of its JSON, such as "ADG", "Layout" and "TileMap". A
+// mismatch shows which stages diverged.
+type Digest map[string]string
+
+// Hash returns the digest of an artifact.
+func Hash(artifact *dungeon.Artifact) (Digest, error) {
+	data, err := artifact.ExportJSONCompact()
+	if err != nil {
+		return nil, err
+	}
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return nil, err
+	}
+	digest := make(Digest, len(parts))
+	for name, part := range parts {
+		sum := sha256.Sum256(part)
+		digest[name] = hex.EncodeToString(sum[:])
+	}
+	return digest, nil
+}
+
+// Hashes are the artifact digests of a corpus, recorded with the platform
+// and Go version that generated them.
+type Hashes struct {
+	GoVersion string            `json:"goVersion"`
+	Platform  string            `json:"platform"` // GOOS/GOARCH
+	Cases     map[string]Digest `json:"cases"`    // Artifact digest by case name
+}
+
+// Run generates every case with gen and hashes the artifacts. It stops at
+// the first case that fails to generate.
+func Run(ctx context.Context, gen dungeon.Generator, cases []Case) (*Hashes, error) {
+	hashes := &Hashes{
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Cases:     make(map[string]Digest, len(cases)),
+	}
+	for _, c := range cases {
+		artifact, err := gen.Generate(ctx, c.Config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		digest, err := Hash(artifact)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		hashes.Cases[c.Name] = digest
+	}
+	return hashes, nil
+}
+
+// Mismatch is a case whose digest differs from the recorded one.
+type Mismatch struct {
+	Case    string
+	Missing string   // "recorded" or "generated" when one side lacks the case
+	Parts   []string // Artifact parts whose hashes differ, sorted
+}
+
+func (m Mismatch) String() string {
+	if m.Missing != "" {
+		return fmt.Sprintf("%s: not %s", m.Case, m.Missing)
+	}
+	return fmt.Sprintf("%s: %s differ", m.Case, strings.Join(m.Parts, ", "))
+}
+
+// Verify compares generated digests with recorded ones and returns the
+// mismatches, sorted by case name.
+func Verify(want, got *Hashes) []Mismatch {
+	var mismatches []Mismatch
+	for _, name := range unionKeys(want.Cases, got.Cases) {
+		w, inWant := want.Cases[name]
+		g, inGot := got.Cases[name]
+		switch {
+		case !inWant:
+			mismatches = append(mismatches, Mismatch{Case: name, Missing: "recorded"})
+		case !inGot:
+			mismatches = append(mismatches, Mismatch{Case: name, Missing: "generated"})
+		default:
+			var parts []string
+			for _, part := range unionKeys(w, g) {
+				if w[part] != g[part] {
+					parts = append(parts, part)
+				}
+			}
+			if len(parts) > 0 {
+				mismatches = append(mismatches, Mismatch{Case: name, Parts: parts})
+			}
+		}
+	}
+	return mismatches
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Load reads hashes saved by Save.
+func Load(path string) (*Hashes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hashes Hashes
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &hashes, nil
+}
+
+// Save writes the hashes as indented JSON, cases in name order.
+func (h *Hashes) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return dungeon.WriteFile(path, append(data, '\n'))
+}
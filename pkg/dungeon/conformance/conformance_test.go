@@ -0,0 +1,86 @@
+package conformance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/dungeon/conformance"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+func TestVerify(t *testing.T) {
+	want := &conformance.Hashes{Cases: map[string]conformance.Digest{
+		"a/1": {"ADG": "1", "Layout": "2"},
+		"a/2": {"ADG": "3", "Layout": "4"},
+		"b/1": {"ADG": "5"},
+	}}
+	got := &conformance.Hashes{Cases: map[string]conformance.Digest{
+		"a/1": {"ADG": "1", "Layout": "2"},
+		"a/2": {"ADG": "3", "Layout": "x", "TileMap": "6"},
+		"c/1": {"ADG": "7"},
+	}}
+
+	mismatches := conformance.Verify(want, got)
+	wantMismatches := []conformance.Mismatch{
+		{Case: "a/2", Parts: []string{"Layout", "TileMap"}},
+		{Case: "b/1", Missing: "generated"},
+		{Case: "c/1", Missing: "recorded"},
+	}
+	if !reflect.DeepEqual(mismatches, wantMismatches) {
+		t.Errorf("Verify() = %v, want %v", mismatches, wantMismatches)
+	}
+	if got := mismatches[0].String(); got != "a/2: Layout, TileMap differ" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+// TestRun checks a corpus hashes the same on every run and survives a
+// save and load.
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	config := []byte("seed: 5\nsize: {roomsMin: 10, roomsMax: 12}\nbranching: {avg: 2.0, max: 3}\n" +
+		"pacing: {curve: LINEAR, variance: 0.1}\nthemes: [crypt]\noptionalRatio: 0.2\n")
+	if err := os.WriteFile(filepath.Join(dir, "small.yaml"), config, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := conformance.Corpus(dir, 2)
+	if err != nil {
+		t.Fatalf("Corpus() error = %v", err)
+	}
+	if len(cases) != 2 || cases[0].Name != "small/5" || cases[1].Name != "small/6" {
+		t.Fatalf("Corpus() = %v, want small/5 and small/6", cases)
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	first, err := conformance.Run(context.Background(), gen, cases)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	path := filepath.Join(dir, "hashes.json")
+	if err := first.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := conformance.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	again, err := conformance.Run(context.Background(), gen, cases)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if mismatches := conformance.Verify(saved, again); len(mismatches) > 0 {
+		t.Errorf("Verify() = %v, want no mismatches", mismatches)
+	}
+	if digest := again.Cases["small/5"]; digest["Layout"] == "" || digest["TileMap"] == "" {
+		t.Errorf("digest %v lacks the layout or tile map", digest)
+	}
+
+	if _, err := conformance.Corpus(t.TempDir(), 1); err == nil {
+		t.Error("Corpus() of an empty directory succeeded")
+	}
+}
@@ -0,0 +1,48 @@
+//go:build conformance
+
+package conformance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/dungeon/conformance"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestConformance generates the seed corpus and compares its hashes with
+// the recorded ones. Run with UPDATE_GOLDEN=1 to record them again after a
+// deliberate change to generation.
+func TestConformance(t *testing.T) {
+	root := filepath.Join("..", "..", "..", "testdata")
+	hashesPath := filepath.Join(root, "conformance", "hashes.json")
+
+	cases, err := conformance.Corpus(filepath.Join(root, "conformance", "corpus"), conformance.DefaultSeeds)
+	if err != nil {
+		t.Fatalf("Corpus() error = %v", err)
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	got, err := conformance.Run(context.Background(), gen, cases)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		if err := got.Save(hashesPath); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		t.Logf("Recorded %d hashes on %s with %s", len(got.Cases), got.Platform, got.GoVersion)
+		return
+	}
+
+	want, err := conformance.Load(hashesPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, m := range conformance.Verify(want, got) {
+		t.Errorf("%s (recorded on %s with %s, generated on %s with %s)", m, want.Platform, want.GoVersion, got.Platform, got.GoVersion)
+	}
+}
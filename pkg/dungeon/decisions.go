@@ -0,0 +1,229 @@
+package dungeon
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// DecisionLogVersion is the decision log format written by this package.
+// Logs of other versions are rejected on load.
+const DecisionLogVersion = 1
+
+// DecisionLog records every random decision a generation made - each rule
+// picked, room chosen and position rolled - as the outcome each stage
+// received, not the RNG state that produced it. Replaying the log
+// re-executes the generation without any RNG, so a dungeon stays
+// reproducible even if the RNG internals change between versions.
+type DecisionLog struct {
+	Version    int             `json:"version"`
+	ConfigHash string          `json:"configHash"` // Hex Config.Hash() of the generating config
+	Stages     []*rng.StageLog `json:"stages"`     // In the order the stages started
+}
+
+// GenerateWithLog generates a dungeon like Generate and returns the
+// decision log of the generation with it. gen must be a *DefaultGenerator.
+func GenerateWithLog(ctx context.Context, gen Generator, cfg *Config) (*Artifact, *DecisionLog, error) {
+	g, err := decisionGenerator(gen, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log := &DecisionLog{Version: DecisionLogVersion, Stages: []*rng.StageLog{}}
+	artifact, err := g.Generate(context.WithValue(ctx, decisionKey{}, &decisionSession{record: log}), cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.ConfigHash = hex.EncodeToString(cfg.Hash())
+	return artifact, log, nil
+}
+
+// Replay re-executes a logged generation, answering every random decision
+// from the log, and returns the finished, validated artifact. cfg must be
+// the config the log was recorded with. Replay fails if the pipeline asks
+// for decisions the log does not hold, as it does when the generation code
+// itself has changed.
+func Replay(ctx context.Context, gen Generator, cfg *Config, log *DecisionLog) (*Artifact, error) {
+	g, err := decisionGenerator(gen, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		return nil, fmt.Errorf("decision log cannot be nil")
+	}
+	if err := log.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid decision log: %w", err)
+	}
+	if hex.EncodeToString(cfg.Hash()) != log.ConfigHash {
+		return nil, fmt.Errorf("decision log was recorded with a different config")
+	}
+
+	session := &decisionSession{replay: make(map[string]*rng.StageLog)}
+	for _, stage := range log.Stages {
+		session.replay[stage.Stage] = stage
+	}
+	artifact, genErr := g.Generate(context.WithValue(ctx, decisionKey{}, session), cfg)
+
+	// A divergence explains any generation failure it caused
+	for _, r := range session.replayed {
+		if err := r.Err(); err != nil {
+			return nil, fmt.Errorf("replay diverged: %w", err)
+		}
+	}
+	if genErr != nil {
+		return nil, genErr
+	}
+	for _, stage := range log.Stages {
+		if !session.used[stage.Stage] {
+			return nil, fmt.Errorf("replay diverged: stage %s was not replayed", stage.Stage)
+		}
+	}
+	return artifact, nil
+}
+
+// decisionGenerator returns gen as the default generator whose stages draw
+// from stageRNG, and checks cfg can be logged.
+func decisionGenerator(gen Generator, cfg *Config) (*DefaultGenerator, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("decision logs require a *DefaultGenerator, got %T", gen)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("decision logs do not support zoned configs")
+	}
+	return g, nil
+}
+
+// decisionKey is the context key of the decision session of a generation.
+type decisionKey struct{}
+
+// decisionSession records or replays the stage RNGs of one generation.
+type decisionSession struct {
+	record   *DecisionLog             // Recording: stage logs are appended here
+	replay   map[string]*rng.StageLog // Replaying: stage logs by stage name
+	replayed []*rng.RNG               // Replay RNGs handed out, checked afterwards
+	used     map[string]bool          // Stages replayed
+}
+
+// stageRNG returns the RNG of a pipeline stage. It is derived from the
+// config seed and hash unless ctx carries a decision session, which records
+// the stage's draws or replays them from a log.
+func stageRNG(ctx context.Context, cfg *Config, stage string) *rng.RNG {
+	session, _ := ctx.Value(decisionKey{}).(*decisionSession)
+	if session == nil {
+		return rng.NewRNG(cfg.Seed, stage, cfg.Hash())
+	}
+
+	if session.replay != nil {
+		log, ok := session.replay[stage]
+		if !ok {
+			log = &rng.StageLog{Stage: stage}
+		}
+		if session.used == nil {
+			session.used = make(map[string]bool)
+		}
+		session.used[stage] = true
+		r := rng.NewReplayRNG(log)
+		session.replayed = append(session.replayed, r)
+		return r
+	}
+
+	r := rng.NewRNG(cfg.Seed, stage, cfg.Hash())
+	log := &rng.StageLog{}
+	r.Record(log)
+	session.record.Stages = append(session.record.Stages, log)
+	return r
+}
+
+// stageSeed returns the seed record of a stage RNG derived from cfg, as
+// stageRNG derives it outside of a decision session.
+func stageSeed(cfg *Config, stage string) StageSeed {
+	hash := cfg.Hash()
+	return StageSeed{
+		Stage:      stage,
+		Seed:       rng.NewRNG(cfg.Seed, stage, hash).Seed(),
+		ConfigHash: hex.EncodeToString(hash),
+	}
+}
+
+// inheritedStages returns the stage seeds of a source artifact, except the
+// stages a derived artifact re-runs.
+func inheritedStages(artifact *Artifact, rerun ...string) []StageSeed {
+	if artifact.Debug == nil {
+		return nil
+	}
+	var stages []StageSeed
+	for _, s := range artifact.Debug.Stages {
+		if !slices.Contains(rerun, s.Stage) {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// Validate checks the log version and that its stages are named and unique.
+func (l *DecisionLog) Validate() error {
+	if l.Version != DecisionLogVersion {
+		return fmt.Errorf("unsupported decision log version %d, want %d", l.Version, DecisionLogVersion)
+	}
+	if l.ConfigHash == "" {
+		return fmt.Errorf("configHash must not be empty")
+	}
+	seen := make(map[string]bool)
+	for i, stage := range l.Stages {
+		if stage == nil || stage.Stage == "" {
+			return fmt.Errorf("stage %d has no name", i)
+		}
+		if seen[stage.Stage] {
+			return fmt.Errorf("stage %s is logged twice", stage.Stage)
+		}
+		seen[stage.Stage] = true
+	}
+	return nil
+}
+
+// ExportJSON serializes the decision log to JSON.
+func (l *DecisionLog) ExportJSON() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+// SaveJSON writes the decision log to a JSON file with WriteFile.
+func (l *DecisionLog) SaveJSON(path string, options ...WriteOption) error {
+	data, err := l.ExportJSON()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, options...)
+}
+
+// LoadDecisionLog reads and validates a decision log JSON file.
+func LoadDecisionLog(path string) (*DecisionLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading decision log file: %w", err)
+	}
+	return LoadDecisionLogFromBytes(data)
+}
+
+// LoadDecisionLogFromBytes parses and validates decision log JSON.
+func LoadDecisionLogFromBytes(data []byte) (*DecisionLog, error) {
+	var log DecisionLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("parsing decision log: %w", err)
+	}
+	if err := log.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return &log, nil
+}
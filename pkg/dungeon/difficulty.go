@@ -0,0 +1,284 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Difficulty names a difficulty preset.
+type Difficulty string
+
+const (
+	// DifficultyCasual is a gentle linear curve with few enemies and traps
+	// and generous loot.
+	DifficultyCasual Difficulty = "casual"
+
+	// DifficultyNormal is the balanced default experience.
+	DifficultyNormal Difficulty = "normal"
+
+	// DifficultyBrutal is a steep exponential curve with dense enemies and
+	// traps and scarce loot.
+	DifficultyBrutal Difficulty = "brutal"
+)
+
+// DifficultyPreset is a coherent set of pacing and content settings.
+type DifficultyPreset struct {
+	Pacing  PacingCfg
+	Content ContentCfg
+}
+
+// DifficultyPresets maps each named difficulty to its settings.
+var DifficultyPresets = map[Difficulty]DifficultyPreset{
+	DifficultyCasual: {
+		Pacing:  PacingCfg{Curve: PacingLinear, Variance: 0.05},
+		Content: ContentCfg{SpawnDensity: 0.6, LootBudget: 1500, TrapDensity: 0.05},
+	},
+	DifficultyNormal: {
+		Pacing:  PacingCfg{Curve: PacingSCurve, Variance: 0.1},
+		Content: ContentCfg{SpawnDensity: 1.0, LootBudget: 1000, TrapDensity: 0.15},
+	},
+	DifficultyBrutal: {
+		Pacing:  PacingCfg{Curve: PacingExponential, Variance: 0.2},
+		Content: ContentCfg{SpawnDensity: 1.6, LootBudget: 700, TrapDensity: 0.35},
+	},
+}
+
+// ApplyDifficulty overwrites the pacing and content settings with the named
+// preset and records the preset name.
+func (c *Config) ApplyDifficulty(d Difficulty) error {
+	preset, ok := DifficultyPresets[d]
+	if !ok {
+		return fmt.Errorf("unknown difficulty preset %q, must be one of: casual, normal, brutal", d)
+	}
+	c.Difficulty = d
+	c.Pacing = preset.Pacing
+	c.Content = preset.Content
+	return nil
+}
+
+// TargetMetrics are the generated metrics AutoTune aims for.
+// Zero-valued targets are ignored.
+type TargetMetrics struct {
+	BranchingFactor float64 // Target average connections per room
+	PathLength      float64 // Target Start→Boss path length
+	CycleCount      float64 // Target number of independent graph cycles
+	PacingDeviation float64 // Target L2 distance from the pacing curve
+
+	Tolerance     float64 // Relative tolerance per metric (default: 0.1)
+	Samples       int     // Seeds averaged per evaluation (default: 3)
+	MaxIterations int     // Search iteration limit (default: 20)
+}
+
+// TuneResult is the outcome of AutoTune.
+type TuneResult struct {
+	Config     *Config // Best config found
+	Metrics    Metrics // Metrics of Config, averaged over the samples
+	Error      float64 // Normalized squared error of Metrics against the targets
+	StartError float64 // Error of the input config, for comparison
+	Iterations int     // Search iterations performed
+	Converged  bool    // All targets are within tolerance
+}
+
+// tuneKnob is one config parameter AutoTune can adjust.
+type tuneKnob struct {
+	name  string
+	step  float64
+	apply func(cfg *Config, delta float64) bool // Returns false if delta has no effect
+}
+
+// tuneKnobs lists the parameters AutoTune searches over: size drives path
+// length, branching drives branching factor and cycles, and pacing variance
+// drives pacing deviation.
+var tuneKnobs = []tuneKnob{
+	{
+		name: "size",
+		step: 8,
+		apply: func(cfg *Config, delta float64) bool {
+			d := int(math.Round(delta))
+			if cfg.Size.RoomsMin+d < 10 || cfg.Size.RoomsMax+d > 300 || d == 0 {
+				return false
+			}
+			cfg.Size.RoomsMin += d
+			cfg.Size.RoomsMax += d
+			return true
+		},
+	},
+	{
+		name: "branching",
+		step: 0.4,
+		apply: func(cfg *Config, delta float64) bool {
+			avg := math.Max(1.5, math.Min(3.0, cfg.Branching.Avg+delta))
+			if avg == cfg.Branching.Avg {
+				return false
+			}
+			cfg.Branching.Avg = avg
+			return true
+		},
+	},
+	{
+		name: "variance",
+		step: 0.08,
+		apply: func(cfg *Config, delta float64) bool {
+			variance := math.Max(0.0, math.Min(0.3, cfg.Pacing.Variance+delta))
+			if variance == cfg.Pacing.Variance {
+				return false
+			}
+			cfg.Pacing.Variance = variance
+			return true
+		},
+	},
+}
+
+// AutoTune adjusts size, branching and pacing variance of cfg by coordinate
+// search until metrics generated by gen hit target within tolerance, or the
+// iteration limit is reached. Each candidate is evaluated by generating
+// target.Samples consecutive seeds starting at cfg.Seed and averaging their
+// metrics. cfg is not modified; the best config found is returned.
+//
+// gen must produce metrics, i.e. have a validator set.
+func AutoTune(ctx context.Context, gen Generator, cfg *Config, target TargetMetrics) (*TuneResult, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if target.Tolerance <= 0 {
+		target.Tolerance = 0.1
+	}
+	if target.Samples <= 0 {
+		target.Samples = 3
+	}
+	if target.MaxIterations <= 0 {
+		target.MaxIterations = 20
+	}
+
+	best := *cfg
+	bestMetrics, err := sampleMetrics(ctx, gen, &best, target.Samples)
+	if err != nil {
+		return nil, err
+	}
+	bestErr := target.score(bestMetrics)
+
+	steps := make([]float64, len(tuneKnobs))
+	for i, knob := range tuneKnobs {
+		steps[i] = knob.step
+	}
+
+	result := &TuneResult{StartError: bestErr}
+	for result.Iterations < target.MaxIterations && !target.within(bestMetrics) {
+		result.Iterations++
+		improved := false
+
+		for i, knob := range tuneKnobs {
+			for _, dir := range []float64{1, -1} {
+				candidate := best
+				if !knob.apply(&candidate, dir*steps[i]) {
+					continue
+				}
+				metrics, err := sampleMetrics(ctx, gen, &candidate, target.Samples)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, cancelled(ctx)
+					}
+					continue // Candidate fails to generate; try the next one
+				}
+				if score := target.score(metrics); score < bestErr {
+					best, bestMetrics, bestErr = candidate, metrics, score
+					improved = true
+					break
+				}
+			}
+		}
+
+		if !improved {
+			// Refine the search around the current best
+			for i := range steps {
+				steps[i] /= 2
+			}
+		}
+	}
+
+	result.Config = &best
+	result.Metrics = bestMetrics
+	result.Error = bestErr
+	result.Converged = target.within(bestMetrics)
+	return result, nil
+}
+
+// sampleMetrics generates samples consecutive seeds and averages their metrics.
+// Returns an error if every sample fails to generate.
+func sampleMetrics(ctx context.Context, gen Generator, cfg *Config, samples int) (Metrics, error) {
+	var sum Metrics
+	var pathLength, cycles float64
+	ok := 0
+	var lastErr error
+
+	for i := 0; i < samples; i++ {
+		sample := *cfg
+		sample.Seed = cfg.Seed + uint64(i)
+		artifact, err := gen.Generate(ctx, &sample)
+		if err != nil {
+			if ctx.Err() != nil {
+				return Metrics{}, cancelled(ctx)
+			}
+			lastErr = err
+			continue
+		}
+		if artifact.Metrics == nil {
+			return Metrics{}, fmt.Errorf("generator produced no metrics (is a validator set?)")
+		}
+		m := artifact.Metrics
+		sum.BranchingFactor += m.BranchingFactor
+		sum.PacingDeviation += m.PacingDeviation
+		sum.SecretFindability += m.SecretFindability
+		pathLength += float64(m.PathLength)
+		cycles += float64(m.CycleCount)
+		ok++
+	}
+
+	if ok == 0 {
+		return Metrics{}, fmt.Errorf("all %d samples failed: %w", samples, lastErr)
+	}
+
+	n := float64(ok)
+	return Metrics{
+		BranchingFactor:   sum.BranchingFactor / n,
+		PathLength:        int(math.Round(pathLength / n)),
+		CycleCount:        int(math.Round(cycles / n)),
+		PacingDeviation:   sum.PacingDeviation / n,
+		SecretFindability: sum.SecretFindability / n,
+	}, nil
+}
+
+// deviations returns the relative deviation of each targeted metric.
+func (t TargetMetrics) deviations(m Metrics) []float64 {
+	var devs []float64
+	add := func(target, actual float64) {
+		if target != 0 {
+			devs = append(devs, math.Abs(actual-target)/math.Max(math.Abs(target), 1))
+		}
+	}
+	add(t.BranchingFactor, m.BranchingFactor)
+	add(t.PathLength, float64(m.PathLength))
+	add(t.CycleCount, float64(m.CycleCount))
+	add(t.PacingDeviation, m.PacingDeviation)
+	return devs
+}
+
+// score returns the sum of squared relative deviations from the targets.
+func (t TargetMetrics) score(m Metrics) float64 {
+	total := 0.0
+	for _, d := range t.deviations(m) {
+		total += d * d
+	}
+	return total
+}
+
+// within reports whether every targeted metric is within tolerance.
+func (t TargetMetrics) within(m Metrics) bool {
+	for _, d := range t.deviations(m) {
+		if d > t.Tolerance {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package dungeon_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestDifficultyPresets_Ordering verifies presets scale enemies, traps and
+// loot coherently from casual to brutal.
+func TestDifficultyPresets_Ordering(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	type totals struct{ enemies, traps, loot int }
+	generate := func(d dungeon.Difficulty) totals {
+		cfg := &dungeon.Config{
+			Seed:          4242,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+		}
+		if err := cfg.ApplyDifficulty(d); err != nil {
+			t.Fatalf("ApplyDifficulty(%s) failed: %v", d, err)
+		}
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Generate(%s) failed: %v", d, err)
+		}
+
+		var tot totals
+		for _, s := range artifact.Content.Spawns {
+			tot.enemies += s.Count
+		}
+		for _, l := range artifact.Content.Loot {
+			tot.loot += l.Value
+		}
+		tot.traps = len(artifact.Content.Traps)
+		return tot
+	}
+
+	casual := generate(dungeon.DifficultyCasual)
+	brutal := generate(dungeon.DifficultyBrutal)
+
+	if casual.enemies >= brutal.enemies {
+		t.Errorf("expected fewer enemies on casual (%d) than brutal (%d)", casual.enemies, brutal.enemies)
+	}
+	if casual.traps >= brutal.traps {
+		t.Errorf("expected fewer traps on casual (%d) than brutal (%d)", casual.traps, brutal.traps)
+	}
+	if casual.loot <= brutal.loot {
+		t.Errorf("expected more loot on casual (%d) than brutal (%d)", casual.loot, brutal.loot)
+	}
+
+	cfg := &dungeon.Config{}
+	if err := cfg.ApplyDifficulty("nightmare"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
+
+// TestLoadConfig_DifficultyPreset verifies explicit YAML settings override
+// the named preset.
+func TestLoadConfig_DifficultyPreset(t *testing.T) {
+	cfg, err := dungeon.LoadConfigFromBytes([]byte(`
+seed: 1
+difficulty: brutal
+size: {roomsMin: 10, roomsMax: 15}
+branching: {avg: 2.0, max: 3}
+themes: [crypt]
+optionalRatio: 0.2
+content:
+  lootBudget: 2000
+`))
+	if err != nil {
+		t.Fatalf("LoadConfigFromBytes() failed: %v", err)
+	}
+
+	brutal := dungeon.DifficultyPresets[dungeon.DifficultyBrutal]
+	if cfg.Pacing.Curve != brutal.Pacing.Curve || cfg.Pacing.Variance != brutal.Pacing.Variance {
+		t.Errorf("Pacing = %+v, want preset %+v", cfg.Pacing, brutal.Pacing)
+	}
+	if cfg.Content.LootBudget != 2000 {
+		t.Errorf("LootBudget = %d, want explicit 2000", cfg.Content.LootBudget)
+	}
+	if cfg.Content.TrapDensity != brutal.Content.TrapDensity {
+		t.Errorf("TrapDensity = %f, want preset %f", cfg.Content.TrapDensity, brutal.Content.TrapDensity)
+	}
+}
+
+// TestAutoTune_ImprovesTowardTarget verifies AutoTune never returns a config
+// worse than the starting one and leaves the input config unchanged.
+func TestAutoTune_ImprovesTowardTarget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping auto-tune search in short mode")
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          99,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 12},
+		Branching:     dungeon.BranchingCfg{Avg: 1.5, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	original := *cfg
+
+	target := dungeon.TargetMetrics{BranchingFactor: 2.6, Samples: 1, MaxIterations: 4}
+	result, err := dungeon.AutoTune(context.Background(), gen, cfg, target)
+	if err != nil {
+		t.Fatalf("AutoTune() failed: %v", err)
+	}
+
+	if result.Error > result.StartError {
+		t.Errorf("tuned error %f is worse than starting error %f", result.Error, result.StartError)
+	}
+	if result.Iterations > target.MaxIterations {
+		t.Errorf("Iterations = %d, want <= %d", result.Iterations, target.MaxIterations)
+	}
+	if err := result.Config.Validate(); err != nil {
+		t.Errorf("tuned config is invalid: %v", err)
+	}
+	if cfg.Branching != original.Branching || cfg.Size != original.Size || cfg.Pacing.Variance != original.Pacing.Variance {
+		t.Error("AutoTune modified the input config")
+	}
+}
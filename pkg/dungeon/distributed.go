@@ -0,0 +1,730 @@
+package dungeon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/content"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// zoneSpacing is the number of empty tiles between stitched zones.
+const zoneSpacing = 4
+
+// ZoneJob is the work for one zone of a zoned dungeon. Jobs are
+// self-contained and serialize to JSON, so they can be shipped to worker
+// processes.
+type ZoneJob struct {
+	Zone    int          `json:"zone"`
+	Config  *Config      `json:"config"`
+	Graph   *graph.Graph `json:"graph"`   // Zone rooms and the connectors between them
+	Content *Content     `json:"content"` // Content placed in the zone's rooms
+}
+
+// ZoneResult is a zone's embedded and carved map with its content, in
+// zone-local tile coordinates. Results serialize to JSON.
+type ZoneResult struct {
+	Zone    int      `json:"zone"`
+	Layout  *Layout  `json:"layout"`
+	TileMap *TileMap `json:"tileMap"`
+	Content *Content `json:"content"`
+}
+
+// ZoneWorker runs zone jobs for GenerateDistributed. Implementations may run
+// jobs in the calling process or hand them to worker processes that call
+// RunZoneJob. RunZone is called concurrently.
+type ZoneWorker interface {
+	RunZone(ctx context.Context, job *ZoneJob) (*ZoneResult, error)
+}
+
+// LocalWorker runs zone jobs in the calling process.
+type LocalWorker struct {
+	Generator Generator
+}
+
+// RunZone implements ZoneWorker.
+func (w LocalWorker) RunZone(ctx context.Context, job *ZoneJob) (*ZoneResult, error) {
+	return RunZoneJob(ctx, w.Generator, job)
+}
+
+// DistributedOptions controls how GenerateDistributed runs zone jobs. The
+// options never change the generated dungeon.
+type DistributedOptions struct {
+	Worker      ZoneWorker // Runs zone jobs; nil runs them in this process
+	Parallelism int        // Zone jobs run at once; 0 means runtime.GOMAXPROCS(0)
+}
+
+// GenerateDistributed generates a zoned mega-dungeon (cfg.Zones.Size > 0).
+//
+// Algorithm:
+//  1. Synthesize the room graph and place its content for the whole
+//     dungeon; both are graph-only, so pacing, keys and loot budgets stay
+//     global
+//  2. Partition the rooms into connected zones of about cfg.Zones.Size rooms
+//  3. Run one job per zone: embed and carve the zone and fit its content to
+//     the carved map
+//  4. Stitch the zone maps together in a grid, then route and carve the
+//     corridors of connectors between zones
+//  5. Validate the stitched dungeon
+//
+// Zone jobs derive their RNGs from the config and zone index alone, so the
+// artifact does not depend on the worker or parallelism. gen must be a
+// *DefaultGenerator. Generate uses GenerateDistributed for zoned configs.
+func GenerateDistributed(ctx context.Context, gen Generator, cfg *Config, opts DistributedOptions) (*Artifact, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("distributed generation requires a *DefaultGenerator, got %T", gen)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size == 0 {
+		return nil, fmt.Errorf("distributed generation requires zones.size")
+	}
+
+	// Refuse dungeons too large for the memory budget
+	if err := checkMemory(cfg); err != nil {
+		return nil, err
+	}
+
+	// Bound the whole generation by its time limit
+	ctx, cancel := cfg.Limits.generationContext(ctx)
+	defer cancel()
+
+	// Step 1: Synthesize the graph and place content
+	adg, err := g.synthesize(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StopAfter == StageSynthesis {
+		return partialArtifact(cfg, StageSynthesis, adg, nil, nil, nil), nil
+	}
+	contentRNG := rng.NewRNG(cfg.Seed, "content", cfg.Hash())
+	contentInternal, err := runStage(ctx, cfg, "content", func(ctx context.Context) (*content.Content, error) {
+		placed, err := g.contentPassFor(cfg).Place(ctx, adg, contentRNG)
+		if err != nil {
+			return nil, stageError("content", err)
+		}
+		return placed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	contentData := convertContent(contentInternal)
+	assignStableIDs(contentData, cfg.Seed)
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	// Step 2: Choose how secret rooms are sealed, then partition into zone
+	// jobs
+	assignSecretKinds(adg, cfg, rng.NewRNG(cfg.Seed, "carving", cfg.Hash()))
+	zones := partitionZones(adg, cfg.Zones.Size)
+	jobs := make([]*ZoneJob, len(zones))
+	for i, rooms := range zones {
+		sub, err := zoneGraph(adg, rooms)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", i, err)
+		}
+		jobs[i] = &ZoneJob{
+			Zone:    i,
+			Config:  cfg,
+			Graph:   sub,
+			Content: zoneContent(contentData, sub),
+		}
+	}
+
+	// Step 3: Run the zone jobs
+	worker := opts.Worker
+	if worker == nil {
+		worker = LocalWorker{Generator: g}
+	}
+	results, err := runZoneJobs(ctx, worker, jobs, opts.Parallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Stitch the zones together
+	artifact, err := stitchZones(adg, results)
+	if err != nil {
+		return nil, err
+	}
+	artifact.Metadata = cfg.Metadata.clone()
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	// Step 5: Validation
+	if err := g.validate(ctx, artifact, cfg); err != nil {
+		return nil, err
+	}
+	artifact.Debug.Stages = []StageSeed{stageSeed(cfg, "synthesis"), stageSeed(cfg, "content")}
+	for _, job := range jobs {
+		artifact.Debug.Stages = append(artifact.Debug.Stages,
+			stageSeed(cfg, fmt.Sprintf("zone_%d_embedding", job.Zone)),
+			stageSeed(cfg, fmt.Sprintf("zone_%d_placement", job.Zone)))
+	}
+
+	return artifact, nil
+}
+
+// RunZoneJob embeds and carves one zone and fits its content to the carved
+// map: entities are positioned on clear floor, spawns get patrol routes and
+// bombable walls are recorded as secrets.
+// Worker processes call it with jobs received from GenerateDistributed; gen
+// must be a *DefaultGenerator set up like the coordinating generator. The
+// job is not modified.
+func RunZoneJob(ctx context.Context, gen Generator, job *ZoneJob) (*ZoneResult, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("zone jobs require a *DefaultGenerator, got %T", gen)
+	}
+	if job == nil || job.Config == nil || job.Graph == nil || len(job.Graph.Rooms) == 0 {
+		return nil, fmt.Errorf("zone job must have a config and a non-empty graph")
+	}
+	cfg := job.Config
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+
+	// Embed the zone with its own RNG stream
+	embeddingRNG := rng.NewRNG(cfg.Seed, fmt.Sprintf("zone_%d_embedding", job.Zone), cfg.Hash())
+	layoutInternal, err := g.embed(ctx, cfg, job.Graph, embeddingRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	layout := convertEmbeddingLayout(layoutInternal)
+	carvingLayout := convertToCarvingLayout(layout)
+	graphAdapter := carving.NewGraphAdapter(job.Graph.Rooms, job.Graph.Connectors)
+	carving.AnchorDoors(graphAdapter, carvingLayout)
+	applyCarvingLayout(layout, carvingLayout)
+	tileMapInternal, err := g.carver.Carve(ctx, graphAdapter, carvingLayout)
+	if err != nil {
+		return nil, stageError("carving", err)
+	}
+
+	// Fit the content to the carved map, leaving the job's content untouched
+	contentData := &Content{}
+	if job.Content != nil {
+		*contentData = *job.Content
+		contentData.Spawns = append([]Spawn(nil), job.Content.Spawns...)
+		contentData.Secrets = append([]SecretInstance(nil), job.Content.Secrets...)
+		contentData.Loot = append([]Loot(nil), job.Content.Loot...)
+		contentData.Traps = append([]Trap(nil), job.Content.Traps...)
+		contentData.PlayerStarts = append([]PlayerStart(nil), job.Content.PlayerStarts...)
+		contentData.Puzzles = make([]PuzzleInstance, len(job.Content.Puzzles))
+		for i, p := range job.Content.Puzzles {
+			contentData.Puzzles[i] = p.clone()
+		}
+	}
+	placementRNG := rng.NewRNG(cfg.Seed, fmt.Sprintf("zone_%d_placement", job.Zone), cfg.Hash())
+	assignPositions(contentData, tileMapInternal, graphAdapter, carvingLayout, cfg.Content.EntityRadius, placementRNG)
+	assignPatrolPaths(contentData, tileMapInternal, graphAdapter, carvingLayout)
+	addDestructibleSecrets(contentData, tileMapInternal)
+	if len(contentData.Secrets) > 0 {
+		placeSecretClues(contentData.Secrets, tileMapInternal, mainPathVisibility(tileMapInternal, job.Graph, carvingLayout))
+	}
+
+	return &ZoneResult{
+		Zone:    job.Zone,
+		Layout:  layout,
+		TileMap: convertCarvingTileMap(tileMapInternal),
+		Content: contentData,
+	}, nil
+}
+
+// runZoneJobs runs the jobs on worker with at most parallelism running at
+// once and returns the results in job order. The first failure cancels the
+// remaining jobs.
+func runZoneJobs(ctx context.Context, worker ZoneWorker, jobs []*ZoneJob, parallelism int) ([]*ZoneResult, error) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*ZoneResult, len(jobs))
+	errs := make([]error, len(jobs))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if ctx.Err() != nil {
+				errs[i] = cancelled(ctx)
+				return
+			}
+			result, err := worker.RunZone(ctx, job)
+			if err == nil {
+				err = result.check(job)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("zone %d: %w", job.Zone, err)
+				cancel()
+				return
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the other jobs, not the cancellations
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// check verifies that a worker's result answers the job: it is for the same
+// zone, lays out every room and has complete tile layers.
+func (r *ZoneResult) check(job *ZoneJob) error {
+	if r == nil || r.Layout == nil || r.TileMap == nil || r.Content == nil {
+		return fmt.Errorf("zone result must have a layout, tile map and content")
+	}
+	if r.Zone != job.Zone {
+		return fmt.Errorf("result is for zone %d", r.Zone)
+	}
+	for id := range job.Graph.Rooms {
+		if _, ok := r.Layout.Poses[id]; !ok {
+			return fmt.Errorf("room %s has no pose", id)
+		}
+	}
+	for name, layer := range r.TileMap.Layers {
+		if layer.Type == "tilelayer" && len(layer.Data) != r.TileMap.Width*r.TileMap.Height {
+			return fmt.Errorf("layer %s has %d tiles, want %d", name, len(layer.Data), r.TileMap.Width*r.TileMap.Height)
+		}
+	}
+	return nil
+}
+
+// partitionZones splits the rooms of g into connected zones of about size
+// rooms and returns each zone's room IDs in sorted order.
+//
+// Algorithm:
+//  1. Order the rooms breadth-first from the Start room, following
+//     connectors in both directions
+//  2. In that order, grow a zone breadth-first from each room not yet in a
+//     zone until it holds size rooms or runs out of free neighbours
+//  3. Merge zones that stopped below half the target size into the
+//     smallest neighbouring zone that stays within one and a half times
+//     the target size; zones with no such neighbour are kept as they are
+//
+// Growing zones in breadth-first order keeps zones that follow each other
+// close in the graph, so they end up close in the stitched grid.
+func partitionZones(g *graph.Graph, size int) [][]string {
+	roomIDs := make([]string, 0, len(g.Rooms))
+	startID := ""
+	for id, room := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+		if room.Archetype == graph.ArchetypeStart && (startID == "" || id < startID) {
+			startID = id
+		}
+	}
+	sort.Strings(roomIDs)
+	if startID == "" && len(roomIDs) > 0 {
+		startID = roomIDs[0]
+	}
+
+	// Undirected, sorted neighbour lists
+	neighbors := make(map[string][]string)
+	for _, conn := range g.Connectors {
+		neighbors[conn.From] = append(neighbors[conn.From], conn.To)
+		neighbors[conn.To] = append(neighbors[conn.To], conn.From)
+	}
+	for id := range neighbors {
+		sort.Strings(neighbors[id])
+	}
+
+	// Step 1: Breadth-first order from Start, then any unreached rooms
+	order := []string{}
+	seen := make(map[string]bool)
+	visit := func(root string) {
+		if seen[root] {
+			return
+		}
+		seen[root] = true
+		for queue := []string{root}; len(queue) > 0; queue = queue[1:] {
+			id := queue[0]
+			order = append(order, id)
+			for _, next := range neighbors[id] {
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	if startID != "" {
+		visit(startID)
+	}
+	for _, id := range roomIDs {
+		visit(id)
+	}
+
+	// Step 2: Grow zones
+	zoneOf := make(map[string]int)
+	zones := [][]string{}
+	for _, root := range order {
+		if _, ok := zoneOf[root]; ok {
+			continue
+		}
+		index := len(zones)
+		zone := []string{root}
+		zoneOf[root] = index
+		for queue := []string{root}; len(queue) > 0 && len(zone) < size; queue = queue[1:] {
+			for _, next := range neighbors[queue[0]] {
+				if _, ok := zoneOf[next]; ok || len(zone) >= size {
+					continue
+				}
+				zoneOf[next] = index
+				zone = append(zone, next)
+				queue = append(queue, next)
+			}
+		}
+
+		// Step 3: Merge small zones into their smallest neighbour
+		if len(zone) < size/2 {
+			target := -1
+			for _, id := range zone {
+				for _, next := range neighbors[id] {
+					z, ok := zoneOf[next]
+					if !ok || z == index || len(zones[z])+len(zone) > size*3/2 {
+						continue
+					}
+					if target == -1 || len(zones[z]) < len(zones[target]) ||
+						(len(zones[z]) == len(zones[target]) && z < target) {
+						target = z
+					}
+				}
+			}
+			if target != -1 {
+				for _, id := range zone {
+					zoneOf[id] = target
+				}
+				zones[target] = append(zones[target], zone...)
+				continue
+			}
+		}
+		zones = append(zones, zone)
+	}
+
+	for _, zone := range zones {
+		sort.Strings(zone)
+	}
+	return zones
+}
+
+// zoneGraph returns the subgraph of g holding the given rooms and the
+// connectors between them. Rooms and connectors are shared with g.
+func zoneGraph(g *graph.Graph, rooms []string) (*graph.Graph, error) {
+	sub := graph.NewGraph(g.Seed)
+	for _, id := range rooms {
+		if err := sub.AddRoom(g.Rooms[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		_, from := sub.Rooms[conn.From]
+		_, to := sub.Rooms[conn.To]
+		if from && to {
+			if err := sub.AddConnector(conn); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sub, nil
+}
+
+// zoneContent returns the content placed in the rooms of a zone graph.
+func zoneContent(c *Content, zone *graph.Graph) *Content {
+	in := func(roomID string) bool {
+		_, ok := zone.Rooms[roomID]
+		return ok
+	}
+
+	zc := &Content{
+		Spawns:  []Spawn{},
+		Loot:    []Loot{},
+		Puzzles: []PuzzleInstance{},
+		Secrets: []SecretInstance{},
+		Traps:   []Trap{},
+	}
+	for _, s := range c.Spawns {
+		if in(s.RoomID) {
+			zc.Spawns = append(zc.Spawns, s)
+		}
+	}
+	for _, l := range c.Loot {
+		if in(l.RoomID) {
+			zc.Loot = append(zc.Loot, l)
+		}
+	}
+	for _, p := range c.Puzzles {
+		if in(p.RoomID) {
+			zc.Puzzles = append(zc.Puzzles, p)
+		}
+	}
+	for _, s := range c.Secrets {
+		if in(s.RoomID) {
+			zc.Secrets = append(zc.Secrets, s)
+		}
+	}
+	for _, t := range c.Traps {
+		if in(t.RoomID) {
+			zc.Traps = append(zc.Traps, t)
+		}
+	}
+	for _, p := range c.PlayerStarts {
+		if in(p.RoomID) {
+			zc.PlayerStarts = append(zc.PlayerStarts, p)
+		}
+	}
+	for roomID, capacity := range c.Capacity {
+		if in(roomID) {
+			if zc.Capacity == nil {
+				zc.Capacity = make(map[string]RoomCapacity)
+			}
+			zc.Capacity[roomID] = capacity
+		}
+	}
+	return zc
+}
+
+// stitchZones joins the zone results into one artifact for the whole graph.
+//
+// Zones are placed row by row in a grid of ceil(sqrt(n)) columns,
+// zoneSpacing tiles apart, each row as tall as its tallest zone. Connectors
+// between zones get an L-shaped corridor between the centers of their rooms
+// that moves along the longer axis first, like the embedders' corridors, and
+// are carved into the stitched map. Content is grouped by zone.
+func stitchZones(adg *graph.Graph, results []*ZoneResult) (*Artifact, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no zones to stitch")
+	}
+
+	// Place the zones in a grid
+	columns := int(math.Ceil(math.Sqrt(float64(len(results)))))
+	origins := make([]Point, len(results))
+	width, height := 0, 0
+	for row := 0; row*columns < len(results); row++ {
+		x, rowHeight := 0, 0
+		for i := row * columns; i < len(results) && i < (row+1)*columns; i++ {
+			origins[i] = Point{X: x, Y: height}
+			x += results[i].TileMap.Width + zoneSpacing
+			rowHeight = max(rowHeight, results[i].TileMap.Height)
+		}
+		width = max(width, x-zoneSpacing)
+		height += rowHeight + zoneSpacing
+	}
+	height -= zoneSpacing
+
+	// Stitch the layouts
+	layout := &Layout{
+		Poses:         make(map[string]Pose),
+		CorridorPaths: make(map[string]Path),
+		Bounds:        Rect{Width: width, Height: height},
+	}
+	for i, r := range results {
+		o := origins[i]
+		for id, pose := range r.Layout.Poses {
+			pose.X += o.X
+			pose.Y += o.Y
+			layout.Poses[id] = pose
+		}
+		for id, path := range r.Layout.CorridorPaths {
+			points := make([]Point, len(path.Points))
+			for j, pt := range path.Points {
+				points[j] = Point{X: pt.X + o.X, Y: pt.Y + o.Y}
+			}
+			layout.CorridorPaths[id] = Path{Points: points}
+		}
+		for id, doors := range r.Layout.Doors {
+			if layout.Doors == nil {
+				layout.Doors = make(map[string][]DoorAnchor)
+			}
+			anchors := make([]DoorAnchor, len(doors))
+			for j, d := range doors {
+				d.X += o.X
+				d.Y += o.Y
+				anchors[j] = d
+			}
+			layout.Doors[id] = anchors
+		}
+	}
+
+	// Route the corridors between zones
+	connIDs := make([]string, 0, len(adg.Connectors))
+	for id := range adg.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	crossing := []string{}
+	for _, id := range connIDs {
+		if _, ok := layout.CorridorPaths[id]; ok {
+			continue
+		}
+		conn := adg.Connectors[id]
+		from, fromOK := layout.Poses[conn.From]
+		to, toOK := layout.Poses[conn.To]
+		if !fromOK || !toOK {
+			return nil, fmt.Errorf("connector %s joins a room without a pose", id)
+		}
+		layout.CorridorPaths[id] = zoneCorridor(from, to)
+		crossing = append(crossing, id)
+	}
+
+	// Stitch the tile maps and carve the corridors between zones
+	first := results[0].TileMap
+	tm := &carving.TileMap{
+		Width:      width,
+		Height:     height,
+		TileWidth:  first.TileWidth,
+		TileHeight: first.TileHeight,
+		Layers:     make(map[string]*carving.Layer),
+	}
+	for i, r := range results {
+		if r.TileMap.TileWidth != tm.TileWidth || r.TileMap.TileHeight != tm.TileHeight {
+			return nil, fmt.Errorf("zone %d has %dx%d tiles, want %dx%d", r.Zone, r.TileMap.TileWidth, r.TileMap.TileHeight, tm.TileWidth, tm.TileHeight)
+		}
+		carving.PasteTileMap(tm, convertToCarvingTileMap(r.TileMap), origins[i].X, origins[i].Y)
+	}
+	zoneWalls := 0
+	if layer, ok := tm.Layers["destructibles"]; ok {
+		zoneWalls = len(layer.Objects)
+	}
+	graphAdapter := carving.NewGraphAdapter(adg.Rooms, adg.Connectors)
+	if err := carving.CarveCorridors(tm, graphAdapter, convertToCarvingLayout(layout), crossing); err != nil {
+		return nil, fmt.Errorf("carving corridors between zones: %w", err)
+	}
+	// A hidden corridor between zones running over open floor cannot be sealed
+	revealHidden(adg, carving.UnsealedHidden(graphAdapter, tm))
+
+	// Stitch the content, adding secrets for walls sealing corridors between zones
+	contentData := &Content{}
+	for i, r := range results {
+		appendZoneContent(contentData, r.Content, origins[i])
+	}
+	if layer, ok := tm.Layers["destructibles"]; ok {
+		zoneSecrets := len(contentData.Secrets)
+		addWallSecrets(contentData, layer.Objects[zoneWalls:], tm)
+		if len(contentData.Secrets) > zoneSecrets {
+			visible := mainPathVisibility(tm, adg, convertToCarvingLayout(layout))
+			placeSecretClues(contentData.Secrets[zoneSecrets:], tm, visible)
+		}
+	}
+	sort.SliceStable(contentData.PlayerStarts, func(i, j int) bool {
+		return contentData.PlayerStarts[i].Player < contentData.PlayerStarts[j].Player
+	})
+	assignTimers(contentData, tm, adg)
+
+	return &Artifact{
+		ADG:     &Graph{Graph: adg},
+		Layout:  layout,
+		TileMap: convertCarvingTileMap(tm),
+		Content: contentData,
+	}, nil
+}
+
+// zoneCorridor returns an L-shaped corridor between two room centers that
+// moves along the longer axis first.
+func zoneCorridor(from, to Pose) Path {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	bend := Point{X: from.X, Y: to.Y}
+	if dx*dx > dy*dy {
+		bend = Point{X: to.X, Y: from.Y}
+	}
+	return Path{Points: []Point{{X: from.X, Y: from.Y}, bend, {X: to.X, Y: to.Y}}}
+}
+
+// appendZoneContent appends a zone's content to c, moving its tile
+// positions from zone-local to stitched coordinates.
+func appendZoneContent(c, zone *Content, origin Point) {
+	move := func(p Point) Point {
+		return Point{X: p.X + origin.X, Y: p.Y + origin.Y}
+	}
+
+	for _, s := range zone.Spawns {
+		s.Position = move(s.Position)
+		path := make([]Point, len(s.PatrolPath))
+		for i, pt := range s.PatrolPath {
+			path[i] = move(pt)
+		}
+		s.PatrolPath = path
+		c.Spawns = append(c.Spawns, s)
+	}
+	for _, l := range zone.Loot {
+		l.Position = move(l.Position)
+		c.Loot = append(c.Loot, l)
+	}
+	for _, p := range zone.Puzzles {
+		p = p.clone()
+		if len(p.Features) > 0 {
+			p.Grid.X += origin.X
+			p.Grid.Y += origin.Y
+		}
+		for i := range p.Features {
+			p.Features[i].Position = move(p.Features[i].Position)
+		}
+		c.Puzzles = append(c.Puzzles, p)
+	}
+	for _, s := range zone.Secrets {
+		s.Position = move(s.Position)
+		s.CluePosition = move(s.CluePosition)
+		c.Secrets = append(c.Secrets, s)
+	}
+	for _, t := range zone.Traps {
+		t.Position = move(t.Position)
+		c.Traps = append(c.Traps, t)
+	}
+	for _, p := range zone.PlayerStarts {
+		p.Position = move(p.Position)
+		c.PlayerStarts = append(c.PlayerStarts, p)
+	}
+	for roomID, capacity := range zone.Capacity {
+		if c.Capacity == nil {
+			c.Capacity = make(map[string]RoomCapacity)
+		}
+		c.Capacity[roomID] = capacity
+	}
+}
@@ -0,0 +1,58 @@
+package dungeon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestPartitionZones verifies zones cover every room once, stay connected and
+// stay near the target size.
+func TestPartitionZones(t *testing.T) {
+	cfg := &Config{
+		Seed:          7,
+		Size:          SizeCfg{RoomsMin: 120, RoomsMax: 140},
+		Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        PacingCfg{Curve: PacingLinear},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		Zones:         ZoneCfg{Size: 40},
+	}
+	g := NewGenerator().(*DefaultGenerator)
+	adg, err := g.synthesize(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("synthesize() error = %v", err)
+	}
+
+	zones := partitionZones(adg, cfg.Zones.Size)
+	if len(zones) < 3 {
+		t.Fatalf("got %d zones for %d rooms, want at least 3: %v", len(zones), len(adg.Rooms), zones)
+	}
+	if again := partitionZones(adg, cfg.Zones.Size); !reflect.DeepEqual(again, zones) {
+		t.Error("partitionZones() is not deterministic")
+	}
+
+	seen := make(map[string]bool)
+	for i, rooms := range zones {
+		if len(rooms) > cfg.Zones.Size*3/2 {
+			t.Errorf("zone %d has %d rooms, want at most %d", i, len(rooms), cfg.Zones.Size*3/2)
+		}
+		for _, id := range rooms {
+			if seen[id] {
+				t.Errorf("room %s is in more than one zone", id)
+			}
+			seen[id] = true
+		}
+
+		sub, err := zoneGraph(adg, rooms)
+		if err != nil {
+			t.Fatalf("zoneGraph() error = %v", err)
+		}
+		if !sub.IsWeaklyConnected() {
+			t.Errorf("zone %d is not connected", i)
+		}
+	}
+	if len(seen) != len(adg.Rooms) {
+		t.Errorf("zones cover %d rooms, want %d", len(seen), len(adg.Rooms))
+	}
+}
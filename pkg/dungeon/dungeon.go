@@ -4,27 +4,15 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/dshills/dungo/pkg/carving"
 	"github.com/dshills/dungo/pkg/content"
 	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
 	"github.com/dshills/dungo/pkg/rng"
 	"github.com/dshills/dungo/pkg/synthesis"
-)
-
-// Corridor length scaling constants
-const (
-	// corridorScaleMultiplier is the multiplier applied to sqrt(roomCount) for corridor length calculation.
-	// Set to 59 based on empirical analysis of pathological seed 0x4400f4, which showed
-	// force-directed layouts can produce corridors up to 51*sqrt(N) units. The value 59 provides
-	// a 15% safety margin above the observed maximum.
-	corridorScaleMultiplier = 59.0
-
-	// corridorMinLength is the minimum corridor length for small dungeons.
-	corridorMinLength = 100.0
-
-	// corridorMaxLength is the maximum corridor length cap for very large dungeons.
-	corridorMaxLength = 600.0
+	"github.com/dshills/dungo/pkg/themes"
 )
 
 // Generator is the main entry point for procedural dungeon generation.
@@ -86,15 +74,14 @@ func NewGenerator() Generator {
 	// Create base embedding config that will be adjusted per dungeon size
 	// These are base values optimized for general use
 	embeddingCfg := embedding.DefaultConfig()
-	embeddingCfg.MinRoomSpacing = 1.0      // Decrease from 2.0 to 1.0 for tighter layouts
-	embeddingCfg.CorridorMaxBends = 6      // Increase from 4 to 6 for more routing flexibility
-	embeddingCfg.MaxIterations = 1000      // Increase for better convergence on large graphs
-	embeddingCfg.CorridorMaxLength = 100.0 // Base value, will be scaled per dungeon
+	embeddingCfg.MinRoomSpacing = 1.0 // Decrease from 2.0 to 1.0 for tighter layouts
+	embeddingCfg.CorridorMaxBends = 6 // Increase from 4 to 6 for more routing flexibility
+	embeddingCfg.MaxIterations = 1000 // Increase for better convergence on large graphs
 
 	return &DefaultGenerator{
 		synthesizer:     synthesis.Get("grammar"),
 		embeddingConfig: embeddingCfg,
-		carver:          carving.NewDefaultCarver(16, 16), // 16x16 pixel tiles
+		carver:          carving.NewDefaultCarver(16, 16).WithThemeLoader(themes.NewLoader(themes.DefaultPackDir)), // 16x16 pixel tiles
 		contentPass:     content.NewDefaultContentPass(),
 		validator:       nil, // Must be set via SetValidator or use NewGeneratorWithValidator
 	}
@@ -113,32 +100,113 @@ func (g *DefaultGenerator) SetValidator(validator Validator) {
 	g.validator = validator
 }
 
+// SetSynthesizer replaces the graph synthesizer used in stage A.
+// This allows custom synthesizers to run through the full pipeline,
+// for example under dungeontest.FuzzGenerate.
+func (g *DefaultGenerator) SetSynthesizer(synthesizer synthesis.GraphSynthesizer) {
+	g.synthesizer = synthesizer
+}
+
+// SetContentPass replaces the content pass used in stage D.
+func (g *DefaultGenerator) SetContentPass(pass content.ContentPass) {
+	g.contentPass = pass
+}
+
+// contentPassFor applies cfg.Content tuning to a copy of the default content
+// pass, keeping its sub-passes. Other content passes are returned unchanged.
+func (g *DefaultGenerator) contentPassFor(cfg *Config) content.ContentPass {
+	d, ok := g.contentPass.(*content.DefaultContentPass)
+	if !ok {
+		return g.contentPass
+	}
+
+	tuned := *d
+	if cfg.Content.SpawnDensity > 0 {
+		maxEnemies := int(math.Round(float64(d.MaxEnemiesPerRoom()) * cfg.Content.SpawnDensity))
+		if maxEnemies < 1 {
+			maxEnemies = 1
+		}
+		tuned.WithMaxEnemiesPerRoom(maxEnemies)
+	}
+	if cfg.Content.LootBudget > 0 {
+		tuned.WithLootBudget(cfg.Content.LootBudget)
+	}
+	if cfg.Content.TrapDensity > 0 {
+		tuned.WithTrapDensity(cfg.Content.TrapDensity)
+	}
+	if cfg.Content.AmbushRatio > 0 {
+		tuned.WithAmbushRatio(cfg.Content.AmbushRatio)
+	}
+	if cfg.Content.Factions {
+		tuned.WithFactions(cfg.Content.SkirmishRatio)
+	}
+	if cfg.Party.Size > 1 {
+		tuned.WithPartySize(cfg.Party.Size)
+	}
+	if cfg.Mode == ModeWave {
+		tuned.WithWaveSchedule(true)
+	}
+	return &tuned
+}
+
 // Generate creates a complete dungeon.
 // Orchestrates all five pipeline stages with deterministic RNG seeding.
-// nolint:gocyclo // Complexity acceptable: pipeline orchestration with multiple stages
 func (g *DefaultGenerator) Generate(ctx context.Context, cfg *Config) (*Artifact, error) {
 	// Stage 0: Validate config
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
+		return nil, invalidConfig(err)
 	}
 
-	// Compute config hash for RNG derivation
-	configHash := cfg.Hash()
+	// Retry with later seeds until the dungeon meets the quality gates
+	if len(cfg.Quality.Gates) > 0 {
+		return g.generateGated(ctx, cfg)
+	}
+	return g.generate(ctx, cfg)
+}
 
-	// Create stage-specific RNGs: H(master_seed, stage_name, config_hash)
-	synthesisRNG := rng.NewRNG(cfg.Seed, "synthesis", configHash)
-	embeddingRNG := rng.NewRNG(cfg.Seed, "embedding", configHash)
-	// carvingRNG := rng.NewRNG(cfg.Seed, "carving", configHash) // TODO: Use when carving needs RNG
-	contentRNG := rng.NewRNG(cfg.Seed, "content", configHash)
+// generate runs the pipeline on a validated config.
+func (g *DefaultGenerator) generate(ctx context.Context, cfg *Config) (*Artifact, error) {
+	// Zoned mega-dungeons run stages B and C zone by zone
+	if cfg.Zones.Size > 0 {
+		return GenerateDistributed(ctx, g, cfg, DistributedOptions{})
+	}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	// Refuse dungeons too large for the memory budget
+	if err := checkMemory(cfg); err != nil {
+		return nil, err
 	}
 
+	// Bound the whole generation by its time limit
+	ctx, cancel := cfg.Limits.generationContext(ctx)
+	defer cancel()
+
 	// Stage A: Graph Synthesis
+	adgInternal, err := g.synthesize(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StopAfter == StageSynthesis {
+		return partialArtifact(cfg, StageSynthesis, adgInternal, nil, nil, nil), nil
+	}
+
+	// Stage B: Spatial Embedding
+	layoutInternal, err := g.embed(ctx, cfg, adgInternal, stageRNG(ctx, cfg, "embedding"))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StopAfter == StageEmbedding {
+		return partialArtifact(cfg, StageEmbedding, adgInternal, convertEmbeddingLayout(layoutInternal), nil, nil), nil
+	}
+
+	// Stages C-E: Carving, content population and validation
+	return g.finish(ctx, cfg, adgInternal, layoutInternal, rng.NewRNG(cfg.Seed, "carving", cfg.Hash()), stageRNG(ctx, cfg, "content"))
+}
+
+// synthesize runs stage A: it builds the room graph and enlarges room
+// footprints for co-op parties.
+func (g *DefaultGenerator) synthesize(ctx context.Context, cfg *Config) (*graph.Graph, error) {
+	synthesisRNG := stageRNG(ctx, cfg, "synthesis")
+
 	synthesisCfg := &synthesis.Config{
 		Seed:          cfg.Seed,
 		RoomsMin:      cfg.Size.RoomsMin,
@@ -154,67 +222,153 @@ func (g *DefaultGenerator) Generate(ctx context.Context, cfg *Config) (*Artifact
 			CustomPoints: cfg.Pacing.CustomPoints,
 		},
 		Themes: cfg.Themes,
+		Accessibility: synthesis.AccessibilityConfig{
+			NoRequiredSecrets:           cfg.Accessibility.NoRequiredSecrets,
+			MaxCombatBetweenCheckpoints: cfg.Accessibility.MaxCombatBetweenCheckpoints,
+			LowBacktracking:             cfg.Accessibility.LowBacktracking,
+		},
+		EnvironmentRatio: cfg.Content.EnvironmentRatio,
+		Zoned:            cfg.Zones.Size > 0,
+		SizeWeights:      cfg.Rooms.Weights(),
+		FloorBudget:      cfg.Rooms.FloorBudget,
+		HallRatio:        cfg.Rooms.HallRatio,
+		Exit:             cfg.Exit,
+		Vaults:           cfg.Vaults,
+		MaxAttempts:      cfg.Limits.maxSynthesisAttempts(),
+	}
+	if cfg.Mode == ModeBacktrack {
+		synthesisCfg.BacktrackPasses = cfg.Backtrack.PassCount()
+	}
+	for _, a := range cfg.Archetypes {
+		archetype, _ := graph.ParseArchetype(a.Archetype)
+		synthesisCfg.Archetypes = append(synthesisCfg.Archetypes, synthesis.ArchetypeTarget{
+			Archetype: archetype,
+			Fraction:  a.Fraction,
+			Count:     a.Count,
+		})
 	}
 	for i, k := range cfg.Keys {
 		synthesisCfg.Keys[i] = synthesis.KeyConfig{
-			Name:  k.Name,
-			Count: k.Count,
+			Name:       k.Name,
+			Count:      k.Count,
+			Opens:      k.Opens,
+			Requires:   k.Requires,
+			RequireAny: k.RequireAny,
+			Consumable: k.Consumable,
 		}
 	}
 
-	adgInternal, err := g.synthesizer.Synthesize(ctx, synthesisRNG, synthesisCfg)
-	if err != nil {
-		return nil, fmt.Errorf("synthesis failed: %w", err)
+	// Arena, wave and backtrack modes always use their own synthesizer
+	synthesizer := g.synthesizer
+	switch cfg.Mode {
+	case ModeArena:
+		synthesizer = synthesis.Get("symmetric")
+	case ModeWave:
+		synthesizer = synthesis.Get("wave")
+	case ModeBacktrack:
+		synthesizer = synthesis.Get("grammar")
 	}
 
-	// Wrap internal graph.Graph in dungeon.Graph
-	adg := &Graph{
-		Graph: adgInternal,
+	adgInternal, err := runStage(ctx, cfg, "synthesis", func(ctx context.Context) (*graph.Graph, error) {
+		adg, err := synthesizer.Synthesize(ctx, synthesisRNG, synthesisCfg)
+		if err != nil {
+			return nil, stageError("synthesis", err)
+		}
+		return adg, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
+	// Enlarge room footprints so the whole party fits
+	scaleRoomSizesForParty(adgInternal, cfg.Party.Size)
 
-	// Stage B: Spatial Embedding
-	// Create embedder with parameters scaled to dungeon size
-	embedderCfg := *g.embeddingConfig // Copy base config
-	roomCount := len(adgInternal.Rooms)
-	embedderCfg.CorridorMaxLength = calculateCorridorMaxLength(roomCount)
+	return adgInternal, nil
+}
 
-	// For medium-to-large dungeons (>25 rooms), adjust force balance to keep layout more compact
-	// This prevents excessively spread-out layouts that exceed corridor length limits
-	// Pathological seeds can create very spread-out layouts even with small dungeon counts
-	if roomCount > 25 {
-		// More aggressive force balancing:
-		// - Increase spring constant (attraction) up to 10x
-		// - Decrease repulsion constant down to 0.2x
-		// This shifts the force balance dramatically for larger dungeons
-		scaleFactor := 1.0 + (float64(roomCount-25) / 10.0) // Faster scaling: 1.0→6.5 for 25→80 rooms
-		if scaleFactor > 10.0 {
-			scaleFactor = 10.0 // Cap at 10x
+// embed runs stage B under its deadline in cfg.Limits: it lays the graph
+// out in 2D and normalizes the layout to non-negative coordinates.
+func (g *DefaultGenerator) embed(ctx context.Context, cfg *Config, adgInternal *graph.Graph, embeddingRNG *rng.RNG) (*embedding.Layout, error) {
+	return runStage(ctx, cfg, "embedding", func(context.Context) (*embedding.Layout, error) {
+		return g.layOut(cfg, adgInternal, embeddingRNG)
+	})
+}
+
+// layOut lays the graph out for embed.
+func (g *DefaultGenerator) layOut(cfg *Config, adgInternal *graph.Graph, embeddingRNG *rng.RNG) (*embedding.Layout, error) {
+	// Arena and wave modes always use their own embedder
+	embedderName := cfg.Map.Layout.embedder()
+	switch cfg.Mode {
+	case ModeArena:
+		embedderName = "symmetric"
+	case ModeWave:
+		embedderName = "radial"
+	}
+
+	// Fail early when the rooms alone cannot fit the map
+	if cfg.Map.MaxWidth > 0 && cfg.Map.MaxHeight > 0 {
+		area := 0
+		for _, room := range adgInternal.Rooms {
+			w, h := embedding.RoomDimensions(room, 0)
+			area += w * h
 		}
-		embedderCfg.SpringConstant *= scaleFactor
+		if area > cfg.Map.MaxWidth*cfg.Map.MaxHeight {
+			return nil, stageError("embedding", fmt.Errorf("rooms cover %d tiles, more than a %s map holds", area, cfg.Map.limit()))
+		}
+	}
 
-		// Inversely scale repulsion - reduce it as dungeons get larger
-		repulsionScale := 1.0 / (1.0 + float64(roomCount-25)/50.0)
-		if repulsionScale < 0.2 {
-			repulsionScale = 0.2 // Floor at 0.2x (don't eliminate repulsion completely)
+	// Fail early when the rooms alone cannot fit the boundary
+	boundary := cfg.Map.boundary()
+	if boundary != nil {
+		area := 0
+		for _, room := range adgInternal.Rooms {
+			w, h := embedding.RoomDimensions(room, 0)
+			area += w * h
+		}
+		if float64(area) > boundary.Area() {
+			return nil, stageError("embedding", fmt.Errorf("rooms cover %d tiles, more than the %.0f-tile boundary holds", area, boundary.Area()))
 		}
-		embedderCfg.RepulsionConstant *= repulsionScale
 	}
 
-	embedder, err := embedding.Get("force_directed", &embedderCfg)
+	// Create embedder with the layout preset for the dungeon's size
+	embedderCfg := *g.embeddingConfig // Copy base config
+	preset := LayoutPresetFor(cfg.LayoutPresets, len(adgInternal.Rooms))
+	embedderCfg.SpringConstant = preset.SpringConstant
+	embedderCfg.RepulsionConstant = preset.RepulsionConstant
+	embedderCfg.CorridorMaxLength = preset.CorridorMaxLength
+	embedderCfg.AspectRatio = cfg.Map.AspectRatio
+	embedderCfg.Boundary = boundary
+	embedderCfg.FixedPoint = cfg.Map.FixedPoint
+
+	// Lay out again with a fallback strategy when the layout is pathological
+	layoutInternal, err := embedWithFallback(embeddingStrategies(cfg, embedderName), embedderCfg, adgInternal, embeddingRNG)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create embedder: %w", err)
+		return nil, stageError("embedding", err)
+	}
+	forceDirected := layoutInternal.Algorithm == "force_directed"
+
+	// Keep corridors within the floor budget the rooms leave. Zones each
+	// hold part of the rooms, so the budget only applies to whole dungeons
+	if cfg.Rooms.FloorBudget > 0 && forceDirected && cfg.Zones.Size == 0 {
+		allowance := float64(cfg.Rooms.FloorBudget)
+		for _, room := range adgInternal.Rooms {
+			allowance -= float64(synthesis.RoomFloorTiles(room.Size))
+		}
+		layoutInternal = compactLayout(embedderCfg, adgInternal, layoutInternal, embeddingRNG, func(l *embedding.Layout) float64 {
+			return corridorLength(l) - allowance
+		})
 	}
 
-	layoutInternal, err := embedder.Embed(adgInternal, embeddingRNG)
-	if err != nil {
-		return nil, fmt.Errorf("embedding failed: %w", err)
+	// Keep the map within its maximum dimensions
+	if cfg.Map.MaxWidth > 0 || cfg.Map.MaxHeight > 0 {
+		if forceDirected {
+			layoutInternal = compactLayout(embedderCfg, adgInternal, layoutInternal, embeddingRNG, func(l *embedding.Layout) float64 {
+				return cfg.Map.overrun(int(l.Bounds.Width()), int(l.Bounds.Height()))
+			})
+		}
+		if width, height := int(layoutInternal.Bounds.Width()), int(layoutInternal.Bounds.Height()); cfg.Map.overrun(width, height) > 0 {
+			return nil, stageError("embedding", fmt.Errorf("layout is %dx%d tiles, larger than the %s map limit", width, height, cfg.Map.limit()))
+		}
 	}
 
 	// Normalize embedding layout BEFORE converting to center coordinates
@@ -227,15 +381,68 @@ func (g *DefaultGenerator) Generate(ctx context.Context, cfg *Config) (*Artifact
 	// after translation to get accurate min/max coordinates for the normalized layout.
 	layoutInternal.ComputeBounds()
 
-	// Convert embedding.Layout to dungeon.Layout (corner → center coordinates)
-	layout := convertEmbeddingLayout(layoutInternal)
+	return layoutInternal, nil
+}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// maxCompactions bounds the tighter layouts compactLayout tries.
+const maxCompactions = 6
+
+// compactLayout lays adg out again ever more tightly (stronger springs,
+// weaker repulsion, a smaller initial spread), up to maxCompactions times,
+// while overrun reports the layout over its limit (a positive amount). It
+// returns the layout with the least overrun; relayouts that fail are
+// skipped.
+func compactLayout(embedderCfg embedding.Config, adg *graph.Graph, layout *embedding.Layout, embeddingRNG *rng.RNG, overrun func(*embedding.Layout) float64) *embedding.Layout {
+	best := overrun(layout)
+	for attempt := 0; attempt < maxCompactions && best > 0; attempt++ {
+		embedderCfg.SpringConstant *= 2
+		embedderCfg.RepulsionConstant /= 2
+		embedderCfg.InitialSpread *= 0.7
+		embedder, err := embedding.Get("force_directed", &embedderCfg)
+		if err != nil {
+			break
+		}
+		tighter, err := embedder.Embed(adg, embeddingRNG)
+		if err != nil {
+			continue
+		}
+		if over := overrun(tighter); over < best {
+			tighter.Rejected = layout.Rejected
+			layout, best = tighter, over
+		}
+	}
+	return layout
+}
+
+// corridorLength returns the total length of a layout's corridors in grid
+// units, about the floor tiles they carve.
+func corridorLength(layout *embedding.Layout) float64 {
+	ids := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		ids = append(ids, id)
 	}
+	sort.Strings(ids)
+
+	total := 0.0
+	for _, id := range ids {
+		total += layout.CorridorPaths[id].Length()
+	}
+	return total
+}
+
+// trimMargin is the empty border kept around a trimmed map.
+const trimMargin = 1
+
+// finish runs stages C to E on an embedded graph: carving, drawing secret
+// kinds from carvingRNG, content population from contentRNG and validation.
+func (g *DefaultGenerator) finish(ctx context.Context, cfg *Config, adgInternal *graph.Graph, layoutInternal *embedding.Layout, carvingRNG, contentRNG *rng.RNG) (*Artifact, error) {
+	// Wrap internal graph.Graph in dungeon.Graph
+	adg := &Graph{
+		Graph: adgInternal,
+	}
+
+	// Convert embedding.Layout to dungeon.Layout (corner → center coordinates)
+	layout := convertEmbeddingLayout(layoutInternal)
 
 	// Stage C: Carving
 	// Create graph adapter for carving
@@ -244,63 +451,218 @@ func (g *DefaultGenerator) Generate(ctx context.Context, cfg *Config) (*Artifact
 	// Convert dungeon.Layout to carving.Layout
 	carvingLayout := convertToCarvingLayout(layout)
 
-	tileMapInternal, err := g.carver.Carve(ctx, graphAdapter, carvingLayout)
+	tileMapInternal, err := runStage(ctx, cfg, "carving", func(ctx context.Context) (*carving.TileMap, error) {
+		// Give rooms without a connector between them walls of their own
+		if cfg.Map.SharedWalls == SharedWallsSeparate {
+			carving.SeparateRooms(graphAdapter, carvingLayout)
+		}
+
+		// Put each corridor's doors on the walls facing the rooms it joins
+		carving.AnchorDoors(graphAdapter, carvingLayout)
+
+		// Share side-by-side corridors and give their meeting points junctions
+		if cfg.Map.Junctions {
+			plan := carving.MergeCorridors(graphAdapter, carvingLayout)
+			if err := insertJunctions(adgInternal, plan); err != nil {
+				return nil, stageError("carving", err)
+			}
+		}
+
+		// Turn the walls rooms without a connector share into secret shortcuts
+		if cfg.Map.SharedWalls == SharedWallsBreakable {
+			walls := carving.BreakSharedWalls(graphAdapter, carvingLayout)
+			if err := insertPassages(adgInternal, walls, graph.Connector{Type: graph.TypeHidden, Visibility: graph.VisibilitySecret}); err != nil {
+				return nil, stageError("carving", err)
+			}
+		}
+		applyCarvingLayout(layout, carvingLayout)
+
+		// Choose how each secret room's hidden passages are sealed
+		assignSecretKinds(adgInternal, cfg, carvingRNG)
+
+		tileMapInternal, err := g.carver.Carve(ctx, graphAdapter, carvingLayout)
+		if err != nil {
+			return nil, stageError("carving", err)
+		}
+
+		// A hidden corridor visible corridors run along cannot be sealed
+		revealHidden(adgInternal, carving.UnsealedHidden(graphAdapter, tileMapInternal))
+
+		// Join rooms the carved floor joins without a connector
+		if cfg.Map.Adjacency == AdjacencySync {
+			passages := carving.SyncPassages(graphAdapter, carvingLayout, tileMapInternal)
+			if err := insertPassages(adgInternal, passages, graph.Connector{Type: graph.TypeDoor, Visibility: graph.VisibilityNormal}); err != nil {
+				return nil, stageError("carving", err)
+			}
+			applyCarvingLayout(layout, carvingLayout)
+		}
+
+		// Trim dead space before content is placed on the map
+		if cfg.Map.Trim || cfg.Map.Repack {
+			carving.Trim(tileMapInternal, graphAdapter, carvingLayout, carving.TrimOptions{
+				Margin: trimMargin,
+				Repack: cfg.Map.Repack,
+			})
+			applyCarvingLayout(layout, carvingLayout)
+		}
+
+		return tileMapInternal, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("carving failed: %w", err)
+		return nil, err
 	}
 
 	// Convert carving.TileMap to dungeon.TileMap
 	tileMap := convertCarvingTileMap(tileMapInternal)
-
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if cfg.StopAfter == StageCarving {
+		return partialArtifact(cfg, StageCarving, adgInternal, layout, tileMap, nil), nil
 	}
 
 	// Stage D: Content Population
-	contentInternal, err := g.contentPass.Place(ctx, adgInternal, contentRNG)
+	contentData, err := runStage(ctx, cfg, "content", func(ctx context.Context) (*Content, error) {
+		return g.placeContent(ctx, cfg, adgInternal, tileMapInternal, carvingLayout, contentRNG)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("content failed: %w", err)
+		return nil, err
+	}
+	if cfg.StopAfter == StageContent {
+		return partialArtifact(cfg, StageContent, adgInternal, layout, tileMap, contentData), nil
 	}
-
-	// Convert content.Content to dungeon.Content
-	contentData := convertContent(contentInternal)
 
 	// Create artifact before validation
 	artifact := &Artifact{
-		ADG:     adg,
-		Layout:  layout,
-		TileMap: tileMap,
-		Content: contentData,
+		ADG:      adg,
+		Layout:   layout,
+		TileMap:  tileMap,
+		Content:  contentData,
+		Metadata: cfg.Metadata.clone(),
+	}
+
+	// Stage E: Validation
+	if _, err := runStage(ctx, cfg, "validation", func(ctx context.Context) (*Artifact, error) {
+		return artifact, g.validate(ctx, artifact, cfg)
+	}); err != nil {
+		return nil, err
 	}
+	artifact.Debug.Stages = []StageSeed{
+		stageSeed(cfg, "synthesis"),
+		stageSeed(cfg, "embedding"),
+		stageSeed(cfg, "content"),
+	}
+	if len(layoutInternal.Rejected) > 0 {
+		artifact.Debug.Embedding = &EmbeddingFallback{
+			Embedder: layoutInternal.Algorithm,
+			Rejected: layoutInternal.Rejected,
+		}
+	}
+
+	return artifact, nil
+}
 
-	// Check for cancellation
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// partialArtifact returns the artifact of a pipeline ended after stage by
+// cfg.StopAfter: the outputs of the stages run so far, with no metrics or
+// validation report. Debug records the stage and the stage seeds drawn.
+func partialArtifact(cfg *Config, stage CheckpointStage, adg *graph.Graph, layout *Layout, tm *TileMap, content *Content) *Artifact {
+	debug := &DebugArtifacts{StoppedAt: string(stage)}
+	for _, s := range PipelineStages {
+		if s == "synthesis" || s == "embedding" || s == "content" {
+			debug.Stages = append(debug.Stages, stageSeed(cfg, s))
+		}
+		if s == string(stage) {
+			break
+		}
+	}
+	return &Artifact{
+		ADG:      &Graph{Graph: adg},
+		Layout:   layout,
+		TileMap:  tm,
+		Content:  content,
+		Debug:    debug,
+		Metadata: cfg.Metadata.clone(),
+	}
+}
+
+// placeContent runs the content pass over a carved dungeon and reconciles
+// the result with the map: arena teams get identical content, entities are
+// positioned on clear floor, spawns get patrol routes and bombable walls are
+// recorded as secrets with clues in sight of the main path.
+func (g *DefaultGenerator) placeContent(ctx context.Context, cfg *Config, adg *graph.Graph, tm *carving.TileMap, layout *carving.Layout, rng *rng.RNG) (*Content, error) {
+	graphAdapter := carving.NewGraphAdapter(adg.Rooms, adg.Connectors)
+
+	// Budget rooms by the floor actually carved for them
+	pass := g.contentPassFor(cfg)
+	if d, ok := pass.(*content.DefaultContentPass); ok {
+		d.WithFloorTiles(roomFloorTiles(tm, graphAdapter, layout))
+		d.WithRoomExtents(roomExtents(graphAdapter, layout))
+	}
+
+	contentInternal, err := pass.Place(ctx, adg, rng)
+	if err != nil {
+		return nil, stageError("content", err)
+	}
+
+	// Convert content.Content to dungeon.Content, naming entities stably
+	contentData := convertContent(contentInternal)
+	assignStableIDs(contentData, cfg.Seed)
+
+	// Give both arena teams identical content
+	if cfg.Mode == ModeArena {
+		mirrorArenaContent(contentData, adg)
+	}
+
+	// Stand entities on clear carved floor, drawing after the content pass
+	assignPositions(contentData, tm, graphAdapter, layout, cfg.Content.EntityRadius, rng)
+
+	// Route spawn patrols around their rooms, respecting carved elevation
+	assignPatrolPaths(contentData, tm, graphAdapter, layout)
+
+	// Time patrols, trap re-arms and door auto-close for every client alike
+	assignTimers(contentData, tm, adg)
+
+	// Route the post-boss escape and schedule its collapse
+	assignEscape(contentData, cfg.Escape, tm, adg, graphAdapter, layout)
+
+	// Record bombable walls as secrets so content matches the carved map
+	addDestructibleSecrets(contentData, tm)
+	if len(contentData.Secrets) > 0 {
+		placeSecretClues(contentData.Secrets, tm, mainPathVisibility(tm, adg, layout))
+	}
+
+	return contentData, nil
+}
+
+// validate runs the validator over an artifact and attaches the metrics,
+// warnings, report and string table. Returns an error if any hard constraint is not satisfied.
+func (g *DefaultGenerator) validate(ctx context.Context, artifact *Artifact, cfg *Config) error {
+	if g.validator == nil {
+		return fmt.Errorf("no validator set")
 	}
 
-	// Stage E: Validation
 	report, err := g.validator.Validate(ctx, artifact, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return stageError("validation", err)
 	}
 
-	// Add metrics and debug info to artifact
+	// Add metrics, warnings and debug info to artifact
 	artifact.Metrics = report.Metrics
+	artifact.Warnings = collectWarnings(artifact, report)
+	buildStrings(artifact, cfg.Keys)
 	artifact.Debug = &DebugArtifacts{
 		Report: report,
 	}
 
 	// Check if hard constraints were satisfied
 	if !report.Passed {
-		return nil, fmt.Errorf("hard constraints not satisfied: %v", report.Errors)
+		cerr := &ConstraintError{Errors: report.Errors}
+		for _, result := range report.HardConstraintResults {
+			if !result.Satisfied {
+				cerr.Failed = append(cerr.Failed, result)
+			}
+		}
+		return cerr
 	}
 
-	return artifact, nil
+	return nil
 }
 
 // convertEmbeddingLayout converts embedding.Layout to dungeon.Layout
@@ -392,9 +754,175 @@ func convertToCarvingLayout(dl *Layout) *carving.Layout {
 		carvingLayout.CorridorPaths[connID] = carving.Path{Points: points}
 	}
 
+	// Convert door anchors
+	if dl.Doors != nil {
+		carvingLayout.Doors = make(map[string][]carving.DoorAnchor, len(dl.Doors))
+		for connID, doors := range dl.Doors {
+			anchors := make([]carving.DoorAnchor, len(doors))
+			for i, d := range doors {
+				anchors[i] = carving.DoorAnchor{Room: d.Room, Side: carving.Side(d.Side), X: d.X, Y: d.Y}
+			}
+			carvingLayout.Doors[connID] = anchors
+		}
+	}
+
 	return carvingLayout
 }
 
+// applyCarvingLayout copies the positions of a carving.Layout, moved or
+// rerouted by a carving pass, back into the dungeon.Layout it was converted
+// from.
+func applyCarvingLayout(dl *Layout, cl *carving.Layout) {
+	for roomID, pose := range cl.Poses {
+		p := dl.Poses[roomID]
+		p.X, p.Y = pose.X, pose.Y
+		dl.Poses[roomID] = p
+	}
+	for connID, path := range cl.CorridorPaths {
+		points := make([]Point, len(path.Points))
+		for i, pt := range path.Points {
+			points[i] = Point{X: pt.X, Y: pt.Y}
+		}
+		dl.CorridorPaths[connID] = Path{Points: points}
+	}
+	if cl.Doors != nil {
+		dl.Doors = make(map[string][]DoorAnchor, len(cl.Doors))
+		for connID, anchors := range cl.Doors {
+			doors := make([]DoorAnchor, len(anchors))
+			for i, a := range anchors {
+				doors[i] = DoorAnchor{Room: a.Room, Side: string(a.Side), X: a.X, Y: a.Y}
+			}
+			dl.Doors[connID] = doors
+		}
+	}
+	dl.Bounds = Rect{X: cl.Bounds.X, Y: cl.Bounds.Y, Width: cl.Bounds.Width, Height: cl.Bounds.Height}
+}
+
+// insertJunctions adds the junction rooms and corridor pieces planned by
+// carving.MergeCorridors to the graph, so its rooms and connectors match the
+// corridors carved. Each junction takes the mean difficulty of the rooms the
+// corridors through it join; each piece copies the corridor it was cut from.
+func insertJunctions(g *graph.Graph, plan *carving.JunctionPlan) error {
+	cut := make(map[string]graph.Connector)
+	for _, piece := range plan.Pieces {
+		if conn, ok := g.Connectors[piece.Corridor]; ok {
+			cut[piece.Corridor] = *conn
+		}
+	}
+
+	for _, j := range plan.Junctions {
+		difficulty := 0.0
+		for _, id := range j.Corridors {
+			conn := cut[id]
+			difficulty += g.Rooms[conn.From].Difficulty + g.Rooms[conn.To].Difficulty
+		}
+		room := &graph.Room{
+			ID:         j.Room,
+			Archetype:  graph.ArchetypeCorridor,
+			Size:       graph.SizeXS,
+			Tags:       map[string]string{graph.JunctionTag: "true"},
+			Difficulty: difficulty / float64(2*len(j.Corridors)),
+		}
+		if err := g.AddRoom(room); err != nil {
+			return fmt.Errorf("adding junction %s: %w", j.Room, err)
+		}
+	}
+
+	for _, piece := range plan.Pieces {
+		conn := cut[piece.Corridor]
+		conn.ID, conn.From, conn.To = piece.ID, piece.From, piece.To
+		if piece.ID == piece.Corridor {
+			if err := g.RemoveConnector(piece.ID); err != nil {
+				return fmt.Errorf("cutting corridor %s: %w", piece.Corridor, err)
+			}
+		}
+		if err := g.AddConnector(&conn); err != nil {
+			return fmt.Errorf("cutting corridor %s: %w", piece.Corridor, err)
+		}
+	}
+	return nil
+}
+
+// revealHidden turns the hidden connectors carving could not seal into
+// ordinary open corridors, as players find them.
+func revealHidden(g *graph.Graph, connIDs []string) {
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		conn.Type = graph.TypeCorridor
+		conn.Visibility = graph.VisibilityNormal
+	}
+}
+
+// assignSecretKinds tags the secret (To) room of every hidden connector with
+// the kind of secret sealing it, "secret_kind", drawn from the config's
+// secret weights or else from the weights of the room's theme (see
+// themes.SecretWeighter). Rooms without weights are left untagged, so
+// carving seals them with destructible walls. Rooms draw in ID order for
+// determinism.
+func assignSecretKinds(g *graph.Graph, cfg *Config, r *rng.RNG) {
+	secret := make(map[string]bool)
+	for _, conn := range g.Connectors {
+		if conn.Type == graph.TypeHidden {
+			secret[conn.To] = true
+		}
+	}
+	roomIDs := make([]string, 0, len(secret))
+	for id := range secret {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		room, ok := g.Rooms[id]
+		if !ok {
+			continue
+		}
+		weights := cfg.Secrets.Weights
+		if len(weights) == 0 {
+			if theme, ok := themes.Lookup(room.Tags["biome"]); ok {
+				if w, ok := theme.(themes.SecretWeighter); ok {
+					weights = w.SecretWeights()
+				}
+			}
+		}
+		if len(weights) == 0 {
+			continue
+		}
+
+		kinds := make([]float64, len(carving.SecretKinds))
+		for i, kind := range carving.SecretKinds {
+			kinds[i] = weights[kind]
+		}
+		if i := r.WeightedChoice(kinds); i >= 0 {
+			if room.Tags == nil {
+				room.Tags = make(map[string]string)
+			}
+			room.Tags["secret_kind"] = carving.SecretKinds[i]
+		}
+	}
+}
+
+// insertPassages adds a two-way connector to the graph for each passage
+// found by carving.SyncPassages or carving.BreakSharedWalls, with the type
+// and visibility of kind.
+func insertPassages(g *graph.Graph, passages []carving.Passage, kind graph.Connector) error {
+	for _, p := range passages {
+		conn := &graph.Connector{
+			ID:            p.ID,
+			From:          p.From,
+			To:            p.To,
+			Type:          kind.Type,
+			Cost:          1.0,
+			Visibility:    kind.Visibility,
+			Bidirectional: true,
+		}
+		if err := g.AddConnector(conn); err != nil {
+			return fmt.Errorf("adding passage %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
 // convertCarvingTileMap converts carving.TileMap to dungeon.TileMap
 func convertCarvingTileMap(ct *carving.TileMap) *TileMap {
 	if ct == nil {
@@ -442,6 +970,381 @@ func convertCarvingTileMap(ct *carving.TileMap) *TileMap {
 	return tileMap
 }
 
+// convertToCarvingTileMap converts dungeon.TileMap back to carving.TileMap.
+// Layer data and object properties are shared, not copied.
+func convertToCarvingTileMap(tm *TileMap) *carving.TileMap {
+	if tm == nil {
+		return nil
+	}
+
+	carvingTileMap := &carving.TileMap{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+		Layers:     make(map[string]*carving.Layer),
+	}
+
+	for name, layer := range tm.Layers {
+		objects := make([]carving.Object, len(layer.Objects))
+		for i, obj := range layer.Objects {
+			objects[i] = carving.Object{
+				ID:         obj.ID,
+				Name:       obj.Name,
+				Type:       obj.Type,
+				X:          obj.X,
+				Y:          obj.Y,
+				Width:      obj.Width,
+				Height:     obj.Height,
+				Rotation:   obj.Rotation,
+				GID:        obj.GID,
+				Visible:    obj.Visible,
+				Properties: obj.Properties,
+			}
+		}
+
+		carvingTileMap.Layers[name] = &carving.Layer{
+			ID:      layer.ID,
+			Name:    layer.Name,
+			Type:    layer.Type,
+			Visible: layer.Visible,
+			Opacity: layer.Opacity,
+			Data:    layer.Data,
+			Objects: objects,
+		}
+	}
+
+	return carvingTileMap
+}
+
+// roomFloorTiles counts the floor tiles carved within each posed room's
+// bounds.
+func roomFloorTiles(tm *carving.TileMap, g carving.Graph, layout *carving.Layout) map[string]int {
+	floorTiles := make(map[string]int)
+	if tm == nil || layout == nil {
+		return floorTiles
+	}
+	layer, ok := tm.Layers["floor"]
+	if !ok {
+		return floorTiles
+	}
+
+	for id, pose := range layout.Poses {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+		b := carving.RoomBounds(room.GetSize(), pose)
+		count := 0
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if carving.GetTile(layer.Data, x, y, tm.Width, tm.Height) == uint32(carving.TileFloor) {
+					count++
+				}
+			}
+		}
+		floorTiles[id] = count
+	}
+	return floorTiles
+}
+
+// roomExtents returns the size of each posed room's floor bounds.
+func roomExtents(g carving.Graph, layout *carving.Layout) map[string]content.RoomExtent {
+	extents := make(map[string]content.RoomExtent)
+	if layout == nil {
+		return extents
+	}
+	for id, pose := range layout.Poses {
+		room := g.GetRoom(id)
+		if room == nil {
+			continue
+		}
+		b := carving.RoomBounds(room.GetSize(), pose)
+		extents[id] = content.RoomExtent{Width: b.Width, Height: b.Height}
+	}
+	return extents
+}
+
+// assignPositions places player starts, spawns, loot, traps and wave
+// spawners on clear floor tiles of their rooms with a PlacementSampler, so
+// no two lie within radius tiles of each other. Puzzle features come first,
+// standing on their grid centered in the room, and other entities keep
+// clear of them. Ambush spawns take tiles
+// hidden from the room's entrances when there are any. Rooms too crowded
+// for the radius spread their remaining entities as far apart as they fit,
+// and entities of rooms without clear floor stand at the room center. A
+// spawner room keeps one position across waves. Entities are placed in that
+// order, each kind in content order, so positions are deterministic for an
+// RNG.
+func assignPositions(c *Content, tm *carving.TileMap, g carving.Graph, layout *carving.Layout, radius int, r *rng.RNG) {
+	if c == nil || tm == nil || layout == nil {
+		return
+	}
+	sampler := carving.NewPlacementSampler(tm, radius)
+
+	hidden := make(map[string][]bool) // Room ID → tiles hidden from its entrances
+	place := func(roomID string, ambush bool) Point {
+		room := g.GetRoom(roomID)
+		pose, ok := layout.Poses[roomID]
+		if room == nil || !ok {
+			return Point{}
+		}
+		bounds := carving.RoomBounds(room.GetSize(), pose)
+		ok = false
+		var p carving.Point
+		if ambush {
+			mask, seen := hidden[roomID]
+			if !seen {
+				mask = carving.HiddenFromEntrances(tm, bounds)
+				hidden[roomID] = mask
+			}
+			if mask != nil {
+				p, ok = sampler.SampleMasked(bounds, mask, r)
+			}
+		}
+		if !ok {
+			p, ok = sampler.Sample(bounds, r)
+		}
+		if !ok {
+			p, ok = sampler.Spread(bounds)
+		}
+		if !ok {
+			p = carving.Point{X: pose.X, Y: pose.Y}
+		}
+		return Point{X: p.X, Y: p.Y}
+	}
+
+	for i := range c.Puzzles {
+		puzzle := &c.Puzzles[i]
+		room := g.GetRoom(puzzle.RoomID)
+		pose, ok := layout.Poses[puzzle.RoomID]
+		if len(puzzle.Features) == 0 || room == nil || !ok {
+			continue
+		}
+		bounds := carving.RoomBounds(room.GetSize(), pose)
+		puzzle.Grid.X = bounds.X + (bounds.Width-puzzle.Grid.Width)/2
+		puzzle.Grid.Y = bounds.Y + (bounds.Height-puzzle.Grid.Height)/2
+		for j := range puzzle.Features {
+			f := &puzzle.Features[j]
+			f.Position = Point{X: puzzle.Grid.X + f.Cell.X, Y: puzzle.Grid.Y + f.Cell.Y}
+			sampler.Reserve(carving.Point{X: f.Position.X, Y: f.Position.Y})
+		}
+	}
+	for i := range c.PlayerStarts {
+		c.PlayerStarts[i].Position = place(c.PlayerStarts[i].RoomID, false)
+	}
+	for i := range c.Spawns {
+		c.Spawns[i].Position = place(c.Spawns[i].RoomID, c.Spawns[i].Ambush)
+	}
+	for i := range c.Loot {
+		c.Loot[i].Position = place(c.Loot[i].RoomID, false)
+	}
+	for i := range c.Traps {
+		c.Traps[i].Position = place(c.Traps[i].RoomID, false)
+	}
+
+	spawners := make(map[string]Point)
+	for i := range c.Waves {
+		for j := range c.Waves[i].Spawns {
+			spawn := &c.Waves[i].Spawns[j]
+			p, ok := spawners[spawn.RoomID]
+			if !ok {
+				p = place(spawn.RoomID, false)
+				spawners[spawn.RoomID] = p
+			}
+			spawn.Position = p
+		}
+	}
+}
+
+// assignPatrolPaths fills each spawn's patrol path from the carved tile map.
+// Spawns whose room has no pose or no walkable route keep an empty path.
+func assignPatrolPaths(c *Content, tm *carving.TileMap, g carving.Graph, layout *carving.Layout) {
+	if c == nil || tm == nil || layout == nil {
+		return
+	}
+
+	for i := range c.Spawns {
+		spawn := &c.Spawns[i]
+		room := g.GetRoom(spawn.RoomID)
+		pose, ok := layout.Poses[spawn.RoomID]
+		if room == nil || !ok {
+			continue
+		}
+
+		route := carving.PatrolRoute(tm, carving.RoomBounds(room.GetSize(), pose))
+		spawn.PatrolPath = make([]Point, len(route))
+		for j, pt := range route {
+			spawn.PatrolPath[j] = Point{X: pt.X, Y: pt.Y}
+		}
+	}
+}
+
+// addDestructibleSecrets adds a secret for every secret carved for a hidden
+// connector. The secret belongs to the sealed (To) room and is positioned on
+// the breach tile.
+func addDestructibleSecrets(c *Content, tm *carving.TileMap) {
+	if c == nil || tm == nil {
+		return
+	}
+	layer, ok := tm.Layers["destructibles"]
+	if !ok {
+		return
+	}
+	addWallSecrets(c, layer.Objects, tm)
+}
+
+// secretClues are the clue props hinting at each kind of secret.
+var secretClues = map[string]string{
+	carving.SecretDestructibleWall: "cracked_wall",
+	carving.SecretIllusoryWall:     "flickering_wall",
+	carving.SecretCollapsibleFloor: "hollow_floor",
+	carving.SecretMovableBlock:     "scrape_marks",
+	carving.SecretShrineRiddle:     "riddle",
+}
+
+// addWallSecrets adds a secret for each of the given secret objects of tm's
+// destructibles layer, see addDestructibleSecrets. A shrine riddle's clue is
+// its shrine.
+func addWallSecrets(c *Content, walls []carving.Object, tm *carving.TileMap) {
+	if tm.TileWidth <= 0 || tm.TileHeight <= 0 {
+		return
+	}
+	shrines := make(map[string]Point)
+	if layer, ok := tm.Layers["triggers"]; ok {
+		for _, obj := range layer.Objects {
+			if connID, _ := obj.Properties["connector_id"].(string); obj.Type == "shrine" && connID != "" {
+				shrines[connID] = Point{X: int(obj.X) / tm.TileWidth, Y: int(obj.Y) / tm.TileHeight}
+			}
+		}
+	}
+
+	for _, obj := range walls {
+		roomID, _ := obj.Properties["to_room"].(string)
+		if roomID == "" {
+			continue
+		}
+		clue, ok := secretClues[obj.Type]
+		if !ok {
+			clue = secretClues[carving.SecretDestructibleWall]
+		}
+		secret := SecretInstance{
+			ID:     fmt.Sprintf("secret_%s", obj.Name),
+			RoomID: roomID,
+			Type:   obj.Type,
+			Position: Point{
+				X: int(obj.X) / tm.TileWidth,
+				Y: int(obj.Y) / tm.TileHeight,
+			},
+			Clues: []string{clue},
+		}
+		if connID, _ := obj.Properties["connector_id"].(string); obj.Type == carving.SecretShrineRiddle {
+			secret.CluePosition = shrines[connID]
+		}
+		c.Secrets = append(c.Secrets, secret)
+	}
+}
+
+// clueSightRadius is how far, in tiles, a clue prop can be seen from the main
+// path.
+const clueSightRadius = 8
+
+// clueReach is how far, in tiles, a secret's clue prop may stand from the
+// secret.
+const clueReach = 2
+
+// mainPathVisibility returns the tiles in sight of the main path: the
+// corridors on the shortest route from the Start room to the Boss room, or
+// every corridor when the graph has no such route.
+func mainPathVisibility(tm *carving.TileMap, adg *graph.Graph, layout *carving.Layout) []bool {
+	roomIDs := make([]string, 0, len(adg.Rooms))
+	for id := range adg.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	connIDs := make([]string, 0, len(adg.Connectors))
+	for id := range adg.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	start, boss := "", ""
+	for _, id := range roomIDs {
+		switch adg.Rooms[id].Archetype {
+		case graph.ArchetypeStart:
+			if start == "" {
+				start = id
+			}
+		case graph.ArchetypeBoss:
+			if boss == "" {
+				boss = id
+			}
+		}
+	}
+	var route []string
+	if start != "" && boss != "" {
+		route, _ = adg.GetPath(start, boss)
+	}
+
+	var paths []carving.Path
+	if len(route) > 1 {
+		for i := 0; i+1 < len(route); i++ {
+			for _, id := range connIDs {
+				conn := adg.Connectors[id]
+				if (conn.From == route[i] && conn.To == route[i+1]) || (conn.From == route[i+1] && conn.To == route[i]) {
+					if path, ok := layout.CorridorPaths[id]; ok {
+						paths = append(paths, path)
+					}
+					break
+				}
+			}
+		}
+	} else {
+		for _, id := range connIDs {
+			if path, ok := layout.CorridorPaths[id]; ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return carving.VisibleFromPaths(tm, paths, clueSightRadius)
+}
+
+// placeSecretClues puts each secret's clue prop on a floor tile within
+// clueReach tiles of the secret, preferring tiles in sight of the main path
+// (visible), then the nearest, then the first in row-major order. Secrets
+// without floor nearby keep the clue on the secret itself, and shrine
+// riddles keep theirs on the shrine.
+func placeSecretClues(secrets []SecretInstance, tm *carving.TileMap, visible []bool) {
+	layer, ok := tm.Layers["floor"]
+	if !ok {
+		return
+	}
+
+	for i := range secrets {
+		secret := &secrets[i]
+		if secret.Type == carving.SecretShrineRiddle {
+			continue
+		}
+		secret.CluePosition = secret.Position
+		bestScore := -1
+		for y := secret.Position.Y - clueReach; y <= secret.Position.Y+clueReach; y++ {
+			for x := secret.Position.X - clueReach; x <= secret.Position.X+clueReach; x++ {
+				if carving.GetTile(layer.Data, x, y, tm.Width, tm.Height) != uint32(carving.TileFloor) {
+					continue
+				}
+				score := max(max(x-secret.Position.X, secret.Position.X-x), max(y-secret.Position.Y, secret.Position.Y-y))
+				if !visible[y*tm.Width+x] {
+					score += clueReach + 1
+				}
+				if bestScore < 0 || score < bestScore {
+					secret.CluePosition = Point{X: x, Y: y}
+					bestScore = score
+				}
+			}
+		}
+	}
+}
+
 // convertContent converts content.Content to dungeon.Content
 func convertContent(cc *content.Content) *Content {
 	if cc == nil {
@@ -453,6 +1356,7 @@ func convertContent(cc *content.Content) *Content {
 		Loot:    make([]Loot, len(cc.Loot)),
 		Puzzles: make([]PuzzleInstance, len(cc.Puzzles)),
 		Secrets: make([]SecretInstance, len(cc.Secrets)),
+		Traps:   make([]Trap, len(cc.Traps)),
 	}
 
 	// Convert spawns
@@ -469,6 +1373,8 @@ func convertContent(cc *content.Content) *Content {
 			EnemyType:  spawn.EnemyType,
 			Count:      spawn.Count,
 			PatrolPath: patrolPath,
+			Ambush:     spawn.Ambush,
+			Faction:    spawn.Faction,
 		}
 	}
 
@@ -510,6 +1416,25 @@ func convertContent(cc *content.Content) *Content {
 			Provides:     provides,
 			Difficulty:   puzzle.Difficulty,
 		}
+		if spec := puzzle.Spec; spec != nil {
+			p := &dungeonContent.Puzzles[i]
+			p.Grid = Rect{Width: spec.Width, Height: spec.Height}
+			p.Params = make(map[string]int, len(spec.Params))
+			for k, v := range spec.Params {
+				p.Params[k] = v
+			}
+			p.Features = make([]PuzzleFeature, len(spec.Features))
+			for j, f := range spec.Features {
+				p.Features[j] = PuzzleFeature{
+					Kind:    f.Kind,
+					Cell:    Point{X: f.Cell.X, Y: f.Cell.Y},
+					State:   f.State,
+					Solid:   f.Solid,
+					Targets: append([]int(nil), f.Targets...),
+				}
+			}
+			p.Solution = append([]int(nil), spec.Solution...)
+		}
 	}
 
 	// Convert secrets
@@ -526,6 +1451,50 @@ func convertContent(cc *content.Content) *Content {
 		}
 	}
 
+	// Convert traps
+	for i, trap := range cc.Traps {
+		dungeonContent.Traps[i] = Trap{
+			ID:       trap.ID,
+			RoomID:   trap.RoomID,
+			Position: Point{X: trap.Position.X, Y: trap.Position.Y},
+			TrapType: trap.TrapType,
+			Damage:   trap.Damage,
+		}
+	}
+
+	// Convert player starts
+	for _, start := range cc.PlayerStarts {
+		dungeonContent.PlayerStarts = append(dungeonContent.PlayerStarts, PlayerStart{
+			ID:       start.ID,
+			RoomID:   start.RoomID,
+			Position: Point{X: start.Position.X, Y: start.Position.Y},
+			Player:   start.Player,
+		})
+	}
+
+	// Convert wave schedules
+	for _, wave := range cc.Waves {
+		converted := Wave{Index: wave.Index, Spawns: make([]Spawn, len(wave.Spawns))}
+		for i, spawn := range wave.Spawns {
+			converted.Spawns[i] = Spawn{
+				ID:        spawn.ID,
+				RoomID:    spawn.RoomID,
+				Position:  Point{X: spawn.Position.X, Y: spawn.Position.Y},
+				EnemyType: spawn.EnemyType,
+				Count:     spawn.Count,
+			}
+		}
+		dungeonContent.Waves = append(dungeonContent.Waves, converted)
+	}
+
+	// Convert room capacities
+	if len(cc.Capacities) > 0 {
+		dungeonContent.Capacity = make(map[string]RoomCapacity, len(cc.Capacities))
+		for roomID, c := range cc.Capacities {
+			dungeonContent.Capacity[roomID] = RoomCapacity(c)
+		}
+	}
+
 	return dungeonContent
 }
 
@@ -604,36 +1573,6 @@ func normalizeEmbeddingLayout(layout *embedding.Layout) {
 	}
 }
 
-// calculateCorridorMaxLength computes an appropriate corridor max length based on dungeon size.
-// The formula scales with the expected spatial extent of the dungeon:
-//   - For N rooms, force-directed layout with InitialSpread=100 creates a layout
-//     roughly proportional to sqrt(N) in each dimension
-//   - Use sqrt(N) * 59 to scale corridor length with dungeon spatial extent
-//   - Increased from 20→41→59 based on empirical analysis of pathological seeds
-//   - Pathological cases can create corridors up to 51*sqrt(N) units
-//   - This gives ~148 for 5 rooms, ~295 for 25 rooms, ~590 for 100 rooms, ~600 (max) for 103+ rooms
-//   - Minimum of 100 for small dungeons, maximum of 600 for very large dungeons
-//   - Combined with spring constant scaling (for dungeons >25 rooms) to keep layouts compact
-func calculateCorridorMaxLength(roomCount int) float64 {
-	if roomCount <= 0 {
-		return corridorMinLength
-	}
-
-	// Scale with square root: max_length = sqrt(N) * corridorScaleMultiplier
-	// See constants defined at top of file for rationale and empirical analysis.
-	length := math.Sqrt(float64(roomCount)) * corridorScaleMultiplier
-
-	// Apply bounds
-	if length < corridorMinLength {
-		length = corridorMinLength
-	}
-	if length > corridorMaxLength {
-		length = corridorMaxLength
-	}
-
-	return length
-}
-
 // normalizeLayout translates all positions to ensure they are non-negative.
 // This is necessary because force-directed embedding can produce negative coordinates.
 // DEPRECATED: Use normalizeEmbeddingLayout instead, which handles coordinate systems correctly.
@@ -472,6 +472,14 @@ func (m *mockRoom) GetSize() carving.RoomSize {
 	return carving.SizeM
 }
 
+func (m *mockRoom) GetTags() map[string]string {
+	return nil
+}
+
+func (m *mockRoom) GetArchetype() string {
+	return ""
+}
+
 // mockConnector implements carving.Connector
 type mockConnector struct {
 	id string
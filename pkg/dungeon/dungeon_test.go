@@ -1,12 +1,27 @@
 package dungeon_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/content"
 	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/embedding"
 	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/synthesis"
+	"github.com/dshills/dungo/pkg/themes"
 	"github.com/dshills/dungo/pkg/validation"
 )
 
@@ -119,7 +134,7 @@ func TestGenerateWithCancellation(t *testing.T) {
 // and allows sharing of seeds between players.
 // This test follows TDD - it will FAIL until full implementation is complete.
 func TestGolden_Determinism(t *testing.T) {
-	cfg, err := dungeon.LoadConfig("../../testdata/seeds/small_crypt.yaml")
+	cfg, err := dungeon.LoadConfig("testseeds/small_crypt.yaml")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -513,7 +528,7 @@ func TestGolden_ExponentialPacingCurve(t *testing.T) {
 			Curve:    dungeon.PacingExponential,
 			Variance: 0.1,
 		},
-		Themes:        []string{"cave"},
+		Themes:        []string{"fungal"},
 		SecretDensity: 0.1,
 		OptionalRatio: 0.2,
 	}
@@ -750,3 +765,2514 @@ func formatDifficulties(difficulties []float64) string {
 func formatFloat(v float64) string {
 	return fmt.Sprintf("%.2f", v)
 }
+
+// TestGenerate_Accessibility verifies accessibility settings produce dungeons
+// that pass the matching hard constraints.
+func TestGenerate_Accessibility(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.3,
+			OptionalRatio: 0.2,
+			Accessibility: dungeon.AccessibilityCfg{
+				NoRequiredSecrets:           true,
+				MaxCombatBetweenCheckpoints: 1,
+				LowBacktracking:             true,
+			},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		kinds := make(map[string]bool)
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			kinds[result.Constraint.Kind] = true
+			if !result.Satisfied {
+				t.Errorf("seed %d: %s failed: %s", seed, result.Constraint.Kind, result.Details)
+			}
+		}
+		for _, kind := range []string{"NoRequiredSecrets", "CheckpointSpacing", "LowBacktracking"} {
+			if !kinds[kind] {
+				t.Errorf("seed %d: report missing %s constraint", seed, kind)
+			}
+		}
+	}
+}
+
+// TestGenerate_Exit verifies an Exit room is generated behind the Boss with
+// a reward chest and no enemies, and passes the ExitAfterBoss check.
+func TestGenerate_Exit(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Exit:          true,
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "ExitAfterBoss" {
+				found = true
+				if !result.Satisfied {
+					t.Errorf("seed %d: ExitAfterBoss failed: %s", seed, result.Details)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing ExitAfterBoss constraint", seed)
+		}
+
+		for _, spawn := range artifact.Content.Spawns {
+			if spawn.RoomID == synthesis.ExitRoomID {
+				t.Errorf("seed %d: enemy spawned in the exit room", seed)
+			}
+		}
+		chest := false
+		for _, loot := range artifact.Content.Loot {
+			chest = chest || (loot.RoomID == synthesis.ExitRoomID && loot.ItemType == content.RewardChest)
+		}
+		if !chest {
+			t.Errorf("seed %d: exit room has no reward chest", seed)
+		}
+	}
+
+	// Junctions leave the corridor to the Exit alone
+	for seed := uint64(1); seed <= 10; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 20},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Exit:          true,
+			Map:           dungeon.MapCfg{Layout: dungeon.LayoutRings, Junctions: true},
+		}
+		if _, err := gen.Generate(context.Background(), cfg); err != nil {
+			t.Errorf("seed %d with junctions: Generate() error = %v", seed, err)
+		}
+	}
+}
+
+// TestGenerate_Vaults verifies vaults are generated with high-tier loot
+// and pass the VaultsOptional check.
+func TestGenerate_Vaults(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Vaults:        2,
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "VaultsOptional" {
+				found = true
+				if !result.Satisfied {
+					t.Errorf("seed %d: VaultsOptional failed: %s", seed, result.Details)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing VaultsOptional constraint", seed)
+		}
+
+		for n := 0; n < cfg.Vaults; n++ {
+			treasure := false
+			for _, loot := range artifact.Content.Loot {
+				if loot.RoomID == synthesis.VaultID(n) {
+					treasure = true
+					if loot.Value < content.VaultItemValue {
+						t.Errorf("seed %d: vault loot %s worth %d, want at least %d", seed, loot.ItemType, loot.Value, content.VaultItemValue)
+					}
+				}
+			}
+			if !treasure {
+				t.Errorf("seed %d: vault %s has no treasure", seed, synthesis.VaultID(n))
+			}
+		}
+	}
+
+	// The fewest rooms Config.Validate accepts fit the vaults, the Exit and
+	// every backtracking ability
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 13, RoomsMax: 13},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Exit:          true,
+			Vaults:        3,
+			Mode:          dungeon.ModeBacktrack,
+			Backtrack:     dungeon.BacktrackCfg{Passes: 3},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		if _, err := gen.Generate(context.Background(), cfg); err != nil {
+			t.Errorf("seed %d at %d rooms: Generate() error = %v", seed, cfg.Size.RoomsMin, err)
+		}
+	}
+}
+
+// TestGenerate_Party verifies co-op generation places one start point per
+// player near Start and passes the party convergence check.
+func TestGenerate_Party(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Party:         dungeon.PartyCfg{Size: 4},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		if got := len(artifact.Content.PlayerStarts); got != 4 {
+			t.Errorf("seed %d: expected 4 player starts, got %d", seed, got)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "PartyConvergence" {
+				found = true
+			}
+			if !result.Satisfied {
+				t.Errorf("seed %d: %s failed: %s", seed, result.Constraint.Kind, result.Details)
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing PartyConvergence constraint", seed)
+		}
+	}
+}
+
+// TestGenerate_RoomCapacity verifies the artifact budgets every room by its
+// carved floor and that placed enemies stay within the budget.
+func TestGenerate_RoomCapacity(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          7,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		Party:         dungeon.PartyCfg{Size: 3},
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	capacity := artifact.Content.Capacity
+	if len(capacity) != len(artifact.ADG.Rooms) {
+		t.Fatalf("capacity covers %d rooms, want %d", len(capacity), len(artifact.ADG.Rooms))
+	}
+	for roomID, c := range capacity {
+		if c.FloorTiles == 0 {
+			t.Errorf("room %s has no carved floor", roomID)
+		}
+	}
+
+	enemies := map[string]int{}
+	for _, spawn := range artifact.Content.Spawns {
+		enemies[spawn.RoomID] += spawn.Count
+	}
+	for roomID, count := range enemies {
+		if count > capacity[roomID].Enemies {
+			t.Errorf("room %s holds %d enemies, capacity %d", roomID, count, capacity[roomID].Enemies)
+		}
+	}
+}
+
+// TestGenerate_Timers verifies every patrol, trap and door gets a timer
+// within its period, that harder rooms re-arm traps sooner, and that the
+// schedule is reproducible.
+func TestGenerate_Timers(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          13,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		Content:       dungeon.ContentCfg{TrapDensity: 1.0},
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	timers := make(map[string]dungeon.Timer)
+	for _, timer := range artifact.Content.Timers {
+		if timer.PeriodMs <= 0 || timer.OffsetMs < 0 || timer.OffsetMs >= timer.PeriodMs {
+			t.Errorf("timer %s: offset %d outside period %d", timer.ID, timer.OffsetMs, timer.PeriodMs)
+		}
+		timers[timer.Kind+" "+timer.ID] = timer
+	}
+
+	patrols := 0
+	for _, spawn := range artifact.Content.Spawns {
+		if len(spawn.PatrolPath) < 2 {
+			continue
+		}
+		patrols++
+		if _, ok := timers[dungeon.TimerPatrol+" "+spawn.ID]; !ok {
+			t.Errorf("spawn %s patrols without a timer", spawn.ID)
+		}
+	}
+	if patrols == 0 || len(artifact.Content.Traps) == 0 {
+		t.Fatalf("expected patrols and traps, got %d and %d", patrols, len(artifact.Content.Traps))
+	}
+	for _, trap := range artifact.Content.Traps {
+		timer, ok := timers[dungeon.TimerTrapRearm+" "+trap.ID]
+		difficulty := artifact.ADG.Rooms[trap.RoomID].Difficulty
+		if !ok || timer.PeriodMs > dungeon.TrapRearmMs || timer.PeriodMs < dungeon.TrapRearmMs/2 {
+			t.Errorf("trap %s: timer %+v", trap.ID, timer)
+		} else if difficulty > 0 && timer.PeriodMs == dungeon.TrapRearmMs {
+			t.Errorf("trap %s in a room of difficulty %.2f re-arms at the base delay", trap.ID, difficulty)
+		}
+	}
+	for _, obj := range artifact.TileMap.Layers["doors"].Objects {
+		if _, ok := timers[dungeon.TimerDoorClose+" "+obj.Name]; !ok {
+			t.Errorf("door %s has no timer", obj.Name)
+		}
+	}
+
+	again, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !reflect.DeepEqual(artifact.Content.Timers, again.Content.Timers) {
+		t.Error("timers differ between runs of the same seed")
+	}
+}
+
+// TestGenerate_EntityPositions verifies entities and puzzle features stand
+// on distinct floor tiles rather than placeholder positions.
+func TestGenerate_EntityPositions(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          11,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+		OptionalRatio: 0.2,
+		Content:       dungeon.ContentCfg{TrapDensity: 0.5},
+		Party:         dungeon.PartyCfg{Size: 2},
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	tm := artifact.TileMap
+	floor := tm.Layers["floor"].Data
+	var positions []dungeon.Point
+	for _, s := range artifact.Content.Spawns {
+		positions = append(positions, s.Position)
+	}
+	for _, l := range artifact.Content.Loot {
+		positions = append(positions, l.Position)
+	}
+	for _, tr := range artifact.Content.Traps {
+		positions = append(positions, tr.Position)
+	}
+	for _, p := range artifact.Content.PlayerStarts {
+		positions = append(positions, p.Position)
+	}
+	features := 0
+	for _, p := range artifact.Content.Puzzles {
+		for _, f := range p.Features {
+			positions = append(positions, f.Position)
+			features++
+		}
+	}
+	if len(positions) == 0 || features == 0 {
+		t.Fatalf("%d entities placed, %d of them puzzle features", len(positions), features)
+	}
+
+	seen := map[dungeon.Point]bool{}
+	for _, p := range positions {
+		if p.X < 0 || p.X >= tm.Width || p.Y < 0 || p.Y >= tm.Height || floor[p.Y*tm.Width+p.X] != 1 {
+			t.Errorf("entity at %v is not on floor", p)
+		}
+		if seen[p] {
+			t.Errorf("two entities share tile %v", p)
+		}
+		seen[p] = true
+	}
+}
+
+// TestGenerate_SecretClues verifies every secret gets a clue prop on floor
+// next to it.
+func TestGenerate_SecretClues(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	secrets := 0
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.3,
+			OptionalRatio: 0.2,
+			Content:       dungeon.ContentCfg{AmbushRatio: 1.0},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		for _, spawn := range artifact.Content.Spawns {
+			if !spawn.Ambush {
+				t.Errorf("seed %d: spawn %s is not an ambush at ratio 1", seed, spawn.ID)
+			}
+		}
+
+		tm := artifact.TileMap
+		floor := tm.Layers["floor"].Data
+		for _, secret := range artifact.Content.Secrets {
+			secrets++
+			p := secret.CluePosition
+			if p.X < 0 || p.X >= tm.Width || p.Y < 0 || p.Y >= tm.Height || floor[p.Y*tm.Width+p.X] != 1 {
+				t.Errorf("seed %d: clue of %s at %v is not on floor", seed, secret.ID, p)
+			}
+			if math.Abs(float64(p.X-secret.Position.X)) > 2 || math.Abs(float64(p.Y-secret.Position.Y)) > 2 {
+				t.Errorf("seed %d: clue of %s at %v is far from the secret at %v", seed, secret.ID, p, secret.Position)
+			}
+		}
+	}
+	if secrets == 0 {
+		t.Fatal("no secrets generated")
+	}
+}
+
+// TestGenerate_StageSeeds verifies the artifact records the derived seed and
+// config hash of every stage RNG, and that variants record the stages they
+// re-run.
+func TestGenerate_StageSeeds(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          17,
+		Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 20},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	check := func(stages []dungeon.StageSeed, want ...string) {
+		t.Helper()
+		if len(stages) != len(want) {
+			t.Fatalf("stages = %v, want %v", stages, want)
+		}
+		for i, s := range stages {
+			if s.Stage != want[i] {
+				t.Errorf("stage %d = %q, want %q", i, s.Stage, want[i])
+			}
+			derived := rng.NewRNG(cfg.Seed, s.Stage, cfg.Hash())
+			if s.Seed != derived.Seed() {
+				t.Errorf("stage %q seed = %d, want %d", s.Stage, s.Seed, derived.Seed())
+			}
+			if s.ConfigHash != hex.EncodeToString(cfg.Hash()) {
+				t.Errorf("stage %q config hash = %s", s.Stage, s.ConfigHash)
+			}
+			rerun := s.RNG()
+			for j := 0; j < 10; j++ {
+				if a, b := derived.Uint64(), rerun.Uint64(); a != b {
+					t.Fatalf("stage %q draw %d = %d, want %d", s.Stage, j, b, a)
+				}
+			}
+		}
+	}
+	check(artifact.Debug.Stages, "synthesis", "embedding", "content")
+
+	variant, err := dungeon.Variant(context.Background(), gen, artifact, cfg, 2)
+	if err != nil {
+		t.Fatalf("Variant() error = %v", err)
+	}
+	check(variant.Debug.Stages, "synthesis", "embedding", "content_variation_2")
+}
+
+// TestGenerate_Warnings verifies a missed floor budget surfaces as a coded
+// warning on the artifact, and that a met one raises none.
+func TestGenerate_Warnings(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          3,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, w := range artifact.Warnings {
+		if w.Code == dungeon.WarnFloorBudget || w.Code == dungeon.WarnEntityPlacement {
+			t.Errorf("unexpected warning %v", w)
+		}
+	}
+
+	tight := *cfg
+	tight.Rooms = dungeon.RoomsCfg{FloorBudget: 400}
+	artifact, err = gen.Generate(context.Background(), &tight)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var budget *dungeon.Warning
+	for i, w := range artifact.Warnings {
+		if w.Code == dungeon.WarnFloorBudget {
+			budget = &artifact.Warnings[i]
+		}
+	}
+	if budget == nil {
+		t.Fatalf("no floor budget warning in %v", artifact.Warnings)
+	}
+	if budget.Stage != "embedding" || !strings.Contains(budget.Message, "over budget") {
+		t.Errorf("floor budget warning = %v", budget)
+	}
+	if len(artifact.Debug.Report.Warnings) == 0 {
+		t.Error("validation report lost its warnings")
+	}
+}
+
+// TestGenerate_Environment verifies an environment ratio moves difficulty
+// from enemies to hazards and slow terrain, and is reported in the metrics.
+func TestGenerate_Environment(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	base := dungeon.Config{
+		Seed:          7,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	enemies := func(a *dungeon.Artifact) int {
+		total := 0
+		for _, spawn := range a.Content.Spawns {
+			total += spawn.Count
+		}
+		return total
+	}
+
+	plain, err := gen.Generate(context.Background(), &base)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if plain.Metrics.EnvironmentShare != 0 {
+		t.Errorf("EnvironmentShare = %.2f without a ratio, want 0", plain.Metrics.EnvironmentShare)
+	}
+	if _, ok := plain.TileMap.Layers["terrain"]; ok {
+		t.Error("terrain layer created without a ratio")
+	}
+
+	cfg := base
+	cfg.Content.EnvironmentRatio = 0.5
+	artifact, err := gen.Generate(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !artifact.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", artifact.Debug.Report.Errors)
+	}
+	if share := artifact.Metrics.EnvironmentShare; math.Abs(share-0.5) > 0.01 {
+		t.Errorf("EnvironmentShare = %.3f, want 0.5", share)
+	}
+	for _, name := range []string{"hazards", "terrain", "environment"} {
+		if _, ok := artifact.TileMap.Layers[name]; !ok {
+			t.Errorf("missing %s layer", name)
+		}
+	}
+	if enemies(artifact) >= enemies(plain) {
+		t.Errorf("enemies = %d with environment, want fewer than %d", enemies(artifact), enemies(plain))
+	}
+}
+
+// TestGenerate_Elevation verifies the crypt theme raises boss rooms onto a
+// dais whose edge is a cliff the player cannot step up, yet which is still
+// reached from the start room by its ramp.
+func TestGenerate_Elevation(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := dungeon.Config{
+		Seed:          11,
+		Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 20},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 3},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+		OptionalRatio: 0.2,
+	}
+	artifact, err := gen.Generate(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !artifact.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", artifact.Debug.Report.Errors)
+	}
+
+	tm := artifact.TileMap
+	ctm := &carving.TileMap{Width: tm.Width, Height: tm.Height, Layers: map[string]*carving.Layer{}}
+	for name, layer := range tm.Layers {
+		ctm.Layers[name] = &carving.Layer{Name: layer.Name, Data: layer.Data}
+	}
+	elevation := ctm.Layers["elevation"]
+	level := func(x, y int) int { return carving.ElevationAt(elevation, x, y, tm.Width, tm.Height) }
+	floor := func(x, y int) bool {
+		return carving.GetTile(tm.Layers["floor"].Data, x, y, tm.Width, tm.Height) == uint32(carving.TileFloor)
+	}
+
+	var start carving.Point
+	for id, room := range artifact.ADG.Rooms {
+		if room.Archetype == graph.ArchetypeStart {
+			pose := artifact.Layout.Poses[id]
+			start = carving.Point{X: pose.X, Y: pose.Y}
+		}
+	}
+	reached := carving.ReachableTiles(ctm, start)
+
+	cliffs := 0
+	for id, room := range artifact.ADG.Rooms {
+		if room.Archetype != graph.ArchetypeBoss {
+			continue
+		}
+		pose := artifact.Layout.Poses[id]
+		b := carving.RoomBounds(carving.RoomSize(room.Size), carving.Pose{X: pose.X, Y: pose.Y, Rotation: pose.Rotation, FootprintID: pose.FootprintID})
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if level(x, y) != 2 {
+					continue
+				}
+				if !reached[y*tm.Width+x] {
+					t.Errorf("boss dais tile (%d,%d) is unreachable from the start", x, y)
+				}
+				for _, n := range []carving.Point{{X: x - 1, Y: y}, {X: x + 1, Y: y}, {X: x, Y: y - 1}, {X: x, Y: y + 1}} {
+					if floor(n.X, n.Y) && level(n.X, n.Y) == 0 {
+						cliffs++
+					}
+				}
+			}
+		}
+	}
+	if cliffs == 0 {
+		t.Error("no boss dais with a cliff edge was generated")
+	}
+}
+
+// TestGenerate_TrimmedMap verifies layout and content still match the tiles
+// of a repacked map.
+func TestGenerate_TrimmedMap(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	for seed := uint64(1); seed <= 3; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{Repack: true},
+		}
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		tm := artifact.TileMap
+		if artifact.Layout.Bounds.Width != tm.Width || artifact.Layout.Bounds.Height != tm.Height {
+			t.Errorf("seed %d: layout bounds %+v, map %dx%d", seed, artifact.Layout.Bounds, tm.Width, tm.Height)
+		}
+
+		isFloor := func(p dungeon.Point) bool {
+			return p.X >= 0 && p.X < tm.Width && p.Y >= 0 && p.Y < tm.Height &&
+				tm.Layers["floor"].Data[p.Y*tm.Width+p.X] != 0
+		}
+		for id, pose := range artifact.Layout.Poses {
+			if !isFloor(dungeon.Point{X: pose.X, Y: pose.Y}) {
+				t.Errorf("seed %d: room %s centre (%d, %d) is not floor", seed, id, pose.X, pose.Y)
+			}
+		}
+		for id, path := range artifact.Layout.CorridorPaths {
+			for _, pt := range path.Points {
+				if !isFloor(pt) {
+					t.Errorf("seed %d: corridor %s point %v is not floor", seed, id, pt)
+				}
+			}
+		}
+		for _, spawn := range artifact.Content.Spawns {
+			for _, pt := range spawn.PatrolPath {
+				if !isFloor(pt) {
+					t.Errorf("seed %d: spawn %s patrols off the floor at %v", seed, spawn.ID, pt)
+				}
+			}
+		}
+	}
+}
+
+// TestGenerate_MapLimits verifies layouts are compacted to fit the maximum
+// map dimensions, and that impossible limits fail early with a clear error.
+func TestGenerate_MapLimits(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	newConfig := func(seed uint64, limit int) *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{MaxWidth: limit, MaxHeight: limit},
+		}
+	}
+
+	for seed := uint64(1); seed <= 3; seed++ {
+		artifact, err := gen.Generate(context.Background(), newConfig(seed, 90))
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if artifact.TileMap.Width > 90 || artifact.TileMap.Height > 90 {
+			t.Errorf("seed %d: map is %dx%d, want at most 90x90", seed, artifact.TileMap.Width, artifact.TileMap.Height)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "MapDimensions" {
+				found = result.Satisfied
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: expected a satisfied MapDimensions constraint", seed)
+		}
+	}
+
+	_, err := gen.Generate(context.Background(), newConfig(1, 32))
+	if err == nil || !strings.Contains(err.Error(), "more than a 32x32 map holds") {
+		t.Errorf("Generate() error = %v, want rooms not fitting a 32x32 map", err)
+	}
+}
+
+// TestGenerate_MapShape verifies rooms are kept inside a boundary polygon,
+// located by the layout bounds, and that a target aspect ratio shapes the
+// map.
+func TestGenerate_MapShape(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	newConfig := func(seed uint64, m dungeon.MapCfg) *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           m,
+		}
+	}
+
+	// An L-shaped footprint
+	outline := [][2]float64{{0, 0}, {160, 0}, {160, 60}, {70, 60}, {70, 160}, {0, 160}}
+	poly := make(embedding.Polygon, len(outline))
+	for i, v := range outline {
+		poly[i] = embedding.Point{X: v[0], Y: v[1]}
+	}
+	for seed := uint64(1); seed <= 3; seed++ {
+		artifact, err := gen.Generate(context.Background(), newConfig(seed, dungeon.MapCfg{Boundary: outline}))
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		// Poses are room centers relative to the map origin
+		for id, pose := range artifact.Layout.Poses {
+			w, h := embedding.SizeToGridDimensions(artifact.ADG.Rooms[id].Size)
+			minX := float64(artifact.Layout.Bounds.X + pose.X - w/2)
+			minY := float64(artifact.Layout.Bounds.Y + pose.Y - h/2)
+			if !poly.ContainsRect(minX, minY, minX+float64(w), minY+float64(h)) {
+				t.Errorf("seed %d: room %s lies outside the boundary", seed, id)
+			}
+		}
+	}
+
+	for seed := uint64(1); seed <= 3; seed++ {
+		artifact, err := gen.Generate(context.Background(), newConfig(seed, dungeon.MapCfg{AspectRatio: 2}))
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if ratio := float64(artifact.TileMap.Width) / float64(artifact.TileMap.Height); ratio < 1.3 {
+			t.Errorf("seed %d: map is %dx%d, want clearly wider than tall", seed, artifact.TileMap.Width, artifact.TileMap.Height)
+		}
+	}
+
+	tiny := [][2]float64{{0, 0}, {40, 0}, {40, 40}, {0, 40}}
+	_, err := gen.Generate(context.Background(), newConfig(1, dungeon.MapCfg{Boundary: tiny}))
+	if err == nil || !strings.Contains(err.Error(), "boundary holds") {
+		t.Errorf("Generate() error = %v, want rooms not fitting the boundary", err)
+	}
+}
+
+// TestGenerate_FixedPoint verifies fixed-point layouts generate valid,
+// repeatable dungeons, including under a boundary.
+func TestGenerate_FixedPoint(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	outline := [][2]float64{{0, 0}, {160, 0}, {160, 60}, {70, 60}, {70, 160}, {0, 160}}
+
+	for _, m := range []dungeon.MapCfg{{FixedPoint: true}, {FixedPoint: true, Boundary: outline}} {
+		for seed := uint64(1); seed <= 3; seed++ {
+			cfg := &dungeon.Config{
+				Seed:          seed,
+				Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+				Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+				Map:           m,
+			}
+			first, err := gen.Generate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("seed %d: Generate() error = %v", seed, err)
+			}
+			again, err := gen.Generate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("seed %d: Generate() error = %v", seed, err)
+			}
+			for id, pose := range first.Layout.Poses {
+				if other := again.Layout.Poses[id]; other.X != pose.X || other.Y != pose.Y {
+					t.Errorf("seed %d: room %s at (%d, %d), then (%d, %d)", seed, id, pose.X, pose.Y, other.X, other.Y)
+				}
+			}
+		}
+	}
+}
+
+// TestGenerate_RevealsUnsealableSecrets generates a ring layout whose
+// mid-to-boss corridor runs straight through the corridor of a hidden
+// connector. Its wall cannot go anywhere without cutting the visible
+// corridor, so the connector becomes an ordinary one instead of failing
+// validation.
+func TestGenerate_RevealsUnsealableSecrets(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          1,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 10},
+		Branching:     dungeon.BranchingCfg{Avg: 1.5, Max: 2},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.3,
+		OptionalRatio: 0.2,
+		Map:           dungeon.MapCfg{Layout: dungeon.LayoutRings},
+	}
+	artifact, err := dungeon.NewGeneratorWithValidator(validation.NewValidator()).Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if conn := artifact.ADG.Connectors["conn_room_6_room_7"]; conn == nil || conn.Type != graph.TypeCorridor || conn.Visibility != graph.VisibilityNormal {
+		t.Errorf("conn_room_6_room_7 = %+v, want an ordinary corridor", conn)
+	}
+
+	// Corridors between zones are carved after stitching, and one running
+	// over open floor is revealed the same way
+	cfg = &dungeon.Config{
+		Seed:          44,
+		Size:          dungeon.SizeCfg{RoomsMin: 40, RoomsMax: 50},
+		Branching:     dungeon.BranchingCfg{Avg: 3, Max: 3},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+		SecretDensity: 0.3,
+		OptionalRatio: 0.4,
+		Rooms:         dungeon.RoomsCfg{HallRatio: 0.5},
+		Zones:         dungeon.ZoneCfg{Size: 20},
+		Map:           dungeon.MapCfg{Layout: dungeon.LayoutLayered},
+	}
+	artifact, err = dungeon.NewGeneratorWithValidator(validation.NewValidator()).Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("zoned Generate() error = %v", err)
+	}
+	if conn := artifact.ADG.Connectors["conn_room_35_room_36"]; conn == nil || conn.Type != graph.TypeCorridor {
+		t.Errorf("conn_room_35_room_36 = %+v, want an ordinary corridor", conn)
+	}
+}
+
+// TestGenerate_RingLayout verifies the rings layout generates valid
+// dungeons with Start in the middle and the Boss on the edge.
+func TestGenerate_RingLayout(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 3; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{Layout: dungeon.LayoutRings},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if !artifact.Debug.Report.Passed {
+			t.Errorf("seed %d: validation failed", seed)
+		}
+
+		cx, cy := float64(artifact.Layout.Bounds.Width)/2, float64(artifact.Layout.Bounds.Height)/2
+		distance := func(archetype graph.RoomArchetype) float64 {
+			for id, room := range artifact.ADG.Rooms {
+				if room.Archetype == archetype {
+					pose := artifact.Layout.Poses[id]
+					return math.Hypot(float64(pose.X)-cx, float64(pose.Y)-cy)
+				}
+			}
+			t.Fatalf("seed %d: no %v room", seed, archetype)
+			return 0
+		}
+		if start, boss := distance(graph.ArchetypeStart), distance(graph.ArchetypeBoss); start >= boss {
+			t.Errorf("seed %d: Start is %.0f from the middle, Boss only %.0f", seed, start, boss)
+		}
+	}
+}
+
+// TestGenerate_HallFootprints verifies hall footprints carry through
+// embedding, carving and export: each hall is carved as its rotated
+// footprint, and the layout records the rotation and footprint.
+func TestGenerate_HallFootprints(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          7,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		Rooms:         dungeon.RoomsCfg{HallRatio: 0.5},
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !artifact.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", artifact.Debug.Report.Errors)
+	}
+
+	rotations := make(map[int]int)
+	for id, room := range artifact.ADG.Rooms {
+		pose := artifact.Layout.Poses[id]
+		if pose.FootprintID != room.Footprint() {
+			t.Errorf("room %s pose footprint = %q, want %q", id, pose.FootprintID, room.Footprint())
+		}
+		if room.Footprint() != graph.FootprintHall {
+			continue
+		}
+		rotations[pose.Rotation]++
+
+		b := carving.RoomBounds(carving.RoomSize(room.Size), carving.Pose{X: pose.X, Y: pose.Y, Rotation: pose.Rotation, FootprintID: pose.FootprintID})
+		long, short := b.Width, b.Height
+		if pose.Rotation == 90 || pose.Rotation == 270 {
+			long, short = short, long
+		}
+		if long <= short {
+			t.Errorf("hall %s at rotation %d carved %dx%d", id, pose.Rotation, b.Width, b.Height)
+		}
+	}
+	if rotations[0] == 0 || rotations[90] == 0 {
+		t.Errorf("hall rotations = %v, want halls running both ways", rotations)
+	}
+
+	data, err := json.Marshal(artifact.Layout)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"FootprintID":"hall"`) {
+		t.Error("JSON layout lost the hall footprints")
+	}
+}
+
+// TestGenerate_DoorAnchors verifies every corridor opens into its rooms at
+// the door anchors recorded in the layout, on the room walls, with no wall
+// holding more than carving.MaxDoorsPerWall doors.
+func TestGenerate_DoorAnchors(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          11,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !artifact.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", artifact.Debug.Report.Errors)
+	}
+
+	layout := artifact.Layout
+	if len(layout.Doors) != len(artifact.ADG.Connectors) {
+		t.Errorf("len(Doors) = %d, want one entry per connector (%d)", len(layout.Doors), len(artifact.ADG.Connectors))
+	}
+	floor := artifact.TileMap.Layers["floor"]
+	perWall := make(map[string]int)
+	for connID, doors := range layout.Doors {
+		conn := artifact.ADG.Connectors[connID]
+		if len(doors) != 2 || doors[0].Room != conn.From || doors[1].Room != conn.To {
+			t.Errorf("Doors[%s] = %+v, want anchors at %s and %s", connID, doors, conn.From, conn.To)
+			continue
+		}
+		path := layout.CorridorPaths[connID].Points
+		if first, last := path[0], path[len(path)-1]; first.X != doors[0].X || first.Y != doors[0].Y ||
+			last.X != doors[1].X || last.Y != doors[1].Y {
+			t.Errorf("corridor %s runs %v, want it between its anchors %+v", connID, path, doors)
+		}
+		for _, door := range doors {
+			perWall[door.Room+"/"+door.Side]++
+			if floor.Data[door.Y*artifact.TileMap.Width+door.X] != uint32(carving.TileFloor) {
+				t.Errorf("anchor %+v of %s is not carved", door, connID)
+			}
+			pose := layout.Poses[door.Room]
+			b := carving.RoomBounds(carving.RoomSize(artifact.ADG.Rooms[door.Room].Size),
+				carving.Pose{X: pose.X, Y: pose.Y, Rotation: pose.Rotation, FootprintID: pose.FootprintID})
+			inX := door.X >= b.X && door.X < b.X+b.Width
+			inY := door.Y >= b.Y && door.Y < b.Y+b.Height
+			onWall := map[string]bool{
+				string(carving.SideNorth): inX && door.Y == b.Y-1,
+				string(carving.SideSouth): inX && door.Y == b.Y+b.Height,
+				string(carving.SideWest):  inY && door.X == b.X-1,
+				string(carving.SideEast):  inY && door.X == b.X+b.Width,
+			}[door.Side]
+			if !onWall {
+				t.Errorf("anchor %+v of %s is not on the %s wall of %v", door, connID, door.Side, b)
+			}
+		}
+	}
+	for wall, n := range perWall {
+		if n > carving.MaxDoorsPerWall {
+			t.Errorf("wall %s holds %d doors, want at most %d", wall, n, carving.MaxDoorsPerWall)
+		}
+	}
+}
+
+// TestGenerate_Junctions verifies junction rooms are carved where corridors
+// meet and added to the graph, with the corridors through them cut so every
+// connector has its own corridor between door anchors.
+func TestGenerate_Junctions(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          3,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		Map:           dungeon.MapCfg{Layout: dungeon.LayoutRings, Junctions: true},
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !artifact.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", artifact.Debug.Report.Errors)
+	}
+
+	junctions := 0
+	for id, room := range artifact.ADG.Rooms {
+		if room.Tags[graph.JunctionTag] != "true" {
+			continue
+		}
+		junctions++
+		if room.Archetype != graph.ArchetypeCorridor || room.Size != graph.SizeXS {
+			t.Errorf("junction %s is a %s %s room, want an XS corridor", id, room.Size, room.Archetype)
+		}
+		if n := len(artifact.ADG.Adjacency[id]); n < 3 {
+			t.Errorf("junction %s joins %d corridors, want at least 3", id, n)
+		}
+		pose := artifact.Layout.Poses[id]
+		if artifact.TileMap.Layers["floor"].Data[pose.Y*artifact.TileMap.Width+pose.X] != uint32(carving.TileFloor) {
+			t.Errorf("junction %s at (%d, %d) is not carved", id, pose.X, pose.Y)
+		}
+	}
+	if junctions == 0 {
+		t.Fatal("no junctions were added")
+	}
+
+	for id, conn := range artifact.ADG.Connectors {
+		doors := artifact.Layout.Doors[id]
+		path := artifact.Layout.CorridorPaths[id].Points
+		if len(doors) != 2 || doors[0].Room != conn.From || doors[1].Room != conn.To || len(path) == 0 {
+			t.Errorf("connector %s (%s-%s) has doors %+v and path %v", id, conn.From, conn.To, doors, path)
+			continue
+		}
+		if first, last := path[0], path[len(path)-1]; first.X != doors[0].X || first.Y != doors[0].Y ||
+			last.X != doors[1].X || last.Y != doors[1].Y {
+			t.Errorf("corridor %s runs %v, want it between its anchors %+v", id, path, doors)
+		}
+	}
+	if len(artifact.Layout.CorridorPaths) != len(artifact.ADG.Connectors) {
+		t.Errorf("%d corridor paths for %d connectors", len(artifact.Layout.CorridorPaths), len(artifact.ADG.Connectors))
+	}
+}
+
+// TestGenerate_Adjacency verifies strict adjacency rejects a layered map
+// whose corridors join rooms without connectors, and sync adds a passage
+// connector for every such pair instead.
+func TestGenerate_Adjacency(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := func(mode dungeon.AdjacencyMode) *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          3,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{Layout: dungeon.LayoutLayered, Adjacency: mode},
+		}
+	}
+
+	_, err := gen.Generate(context.Background(), cfg(dungeon.AdjacencyStrict))
+	var constraint *dungeon.ConstraintError
+	if !errors.As(err, &constraint) || len(constraint.Failed) != 1 || constraint.Failed[0].Constraint.Kind != "GraphConsistency" {
+		t.Fatalf("strict Generate() error = %v, want only the GraphConsistency constraint to fail", err)
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg(dungeon.AdjacencySync))
+	if err != nil {
+		t.Fatalf("sync Generate() error = %v", err)
+	}
+	passages := 0
+	for id, conn := range artifact.ADG.Connectors {
+		if !strings.HasPrefix(id, "passage_") {
+			continue
+		}
+		passages++
+		if conn.Type != graph.TypeDoor || conn.Gate != nil || !conn.Bidirectional {
+			t.Errorf("passage %s = %+v, want an open two-way door", id, conn)
+		}
+		if len(artifact.Layout.CorridorPaths[id].Points) < 2 {
+			t.Errorf("passage %s has path %v", id, artifact.Layout.CorridorPaths[id])
+		}
+	}
+	if passages == 0 {
+		t.Fatal("no passages were added")
+	}
+}
+
+// TestGenerate_SharedWalls verifies separate leaves no rooms without a
+// connector a wall apart, and breakable turns such walls into hidden
+// connectors sealed by destructible walls.
+func TestGenerate_SharedWalls(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := func(mode dungeon.SharedWallMode) *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          16,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{SharedWalls: mode},
+		}
+	}
+
+	artifact, err := gen.Generate(context.Background(), cfg(dungeon.SharedWallsSeparate))
+	if err != nil {
+		t.Fatalf("separate Generate() error = %v", err)
+	}
+	layout := &carving.Layout{Poses: map[string]carving.Pose{}}
+	for id, pose := range artifact.Layout.Poses {
+		layout.Poses[id] = carving.Pose{X: pose.X, Y: pose.Y, Rotation: pose.Rotation, FootprintID: pose.FootprintID}
+	}
+	if walls := carving.SharedWalls(carving.NewGraphAdapter(artifact.ADG.Rooms, artifact.ADG.Connectors), layout); len(walls) != 0 {
+		t.Errorf("rooms still share walls: %+v", walls)
+	}
+
+	artifact, err = gen.Generate(context.Background(), cfg(dungeon.SharedWallsBreakable))
+	if err != nil {
+		t.Fatalf("breakable Generate() error = %v", err)
+	}
+	sealed := make(map[string]bool)
+	for _, obj := range artifact.TileMap.Layers["destructibles"].Objects {
+		sealed[obj.Properties["connector_id"].(string)] = true
+	}
+	breakable := 0
+	for id, conn := range artifact.ADG.Connectors {
+		if !strings.HasPrefix(id, "breakable_") {
+			continue
+		}
+		breakable++
+		if conn.Type != graph.TypeHidden || conn.Visibility != graph.VisibilitySecret {
+			t.Errorf("breakable wall %s = %+v, want a secret hidden connector", id, conn)
+		}
+		if !sealed[id] {
+			t.Errorf("breakable wall %s has no destructible wall", id)
+		}
+	}
+	if breakable == 0 {
+		t.Fatal("no breakable walls were added")
+	}
+}
+
+// TestGenerate_SecretKinds verifies config weights choose the kinds of the
+// secrets sealing hidden connectors, each secret takes its room's kind or
+// falls back to a destructible wall, and carries its kind's clue.
+func TestGenerate_SecretKinds(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	weights := make(map[string]float64)
+	for _, kind := range carving.SecretKinds {
+		weights[kind] = 1
+	}
+	cfg := &dungeon.Config{
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Map:           dungeon.MapCfg{SharedWalls: dungeon.SharedWallsBreakable},
+		Secrets:       dungeon.SecretsCfg{Weights: weights},
+	}
+	clues := map[string]string{
+		carving.SecretDestructibleWall: "cracked_wall",
+		carving.SecretIllusoryWall:     "flickering_wall",
+		carving.SecretCollapsibleFloor: "hollow_floor",
+		carving.SecretMovableBlock:     "scrape_marks",
+		carving.SecretShrineRiddle:     "riddle",
+	}
+
+	kinds := make(map[string]int)
+	for seed := uint64(1); seed <= 7; seed++ {
+		cfg.Seed = seed
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if !artifact.Debug.Report.Passed {
+			t.Fatalf("seed %d: validation failed", seed)
+		}
+
+		shrines := make(map[dungeon.Point]bool)
+		if layer, ok := artifact.TileMap.Layers["triggers"]; ok {
+			for _, obj := range layer.Objects {
+				if obj.Type == "shrine" {
+					shrines[dungeon.Point{X: int(obj.X) / artifact.TileMap.TileWidth, Y: int(obj.Y) / artifact.TileMap.TileHeight}] = true
+				}
+			}
+		}
+		for _, secret := range artifact.Content.Secrets {
+			kinds[secret.Type]++
+			want := artifact.ADG.Rooms[secret.RoomID].Tags["secret_kind"]
+			if secret.Type != want && secret.Type != carving.SecretDestructibleWall {
+				t.Errorf("seed %d: secret %s is a %s, want %s or a destructible wall", seed, secret.ID, secret.Type, want)
+			}
+			if len(secret.Clues) != 1 || secret.Clues[0] != clues[secret.Type] {
+				t.Errorf("seed %d: secret %s (%s) clues = %v, want [%s]", seed, secret.ID, secret.Type, secret.Clues, clues[secret.Type])
+			}
+			if secret.Type == carving.SecretShrineRiddle && !shrines[secret.CluePosition] {
+				t.Errorf("seed %d: shrine riddle %s clue at %v, want on its shrine", seed, secret.ID, secret.CluePosition)
+			}
+		}
+	}
+	if len(kinds) < 4 {
+		t.Errorf("secret kinds = %v, want at least 4 kinds", kinds)
+	}
+
+	// Without weights the dungeon theme keeps destructible walls
+	cfg.Secrets = dungeon.SecretsCfg{}
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() without weights error = %v", err)
+	}
+	for _, secret := range artifact.Content.Secrets {
+		if secret.Type != carving.SecretDestructibleWall {
+			t.Errorf("secret %s is a %s without weights, want a destructible wall", secret.ID, secret.Type)
+		}
+	}
+}
+
+// TestGenerate_LayeredLayout verifies the layered layout generates valid
+// dungeons running from Start on the left to the Boss on the right.
+func TestGenerate_LayeredLayout(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 3; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 25, RoomsMax: 35},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{Layout: dungeon.LayoutLayered},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if !artifact.Debug.Report.Passed {
+			t.Errorf("seed %d: validation failed", seed)
+		}
+
+		var startX, bossX int
+		for id, room := range artifact.ADG.Rooms {
+			switch room.Archetype {
+			case graph.ArchetypeStart:
+				startX = artifact.Layout.Poses[id].X
+			case graph.ArchetypeBoss:
+				bossX = artifact.Layout.Poses[id].X
+			}
+		}
+		for id, pose := range artifact.Layout.Poses {
+			// Smaller rooms sharing the Boss column are centered on it,
+			// less than half an XL room to its right
+			if pose.X < startX || pose.X > bossX+8 {
+				t.Errorf("seed %d: room %s at x %d is outside Start (%d) to Boss (%d)", seed, id, pose.X, startX, bossX)
+			}
+		}
+	}
+}
+
+// TestGenerate_Arena verifies arena mode produces mirrored halves with two
+// Start rooms, a shared Boss room and identical content for both teams.
+func TestGenerate_Arena(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.2,
+			OptionalRatio: 0.2,
+			Mode:          dungeon.ModeArena,
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		starts, bosses := 0, 0
+		for _, room := range artifact.ADG.Rooms {
+			switch room.Archetype {
+			case graph.ArchetypeStart:
+				starts++
+			case graph.ArchetypeBoss:
+				bosses++
+			}
+		}
+		if starts != 2 || bosses != 1 {
+			t.Errorf("seed %d: expected 2 Start and 1 Boss room, got %d and %d", seed, starts, bosses)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "Symmetry" {
+				found = true
+			}
+			if !result.Satisfied {
+				t.Errorf("seed %d: %s failed: %s", seed, result.Constraint.Kind, result.Details)
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing Symmetry constraint", seed)
+		}
+
+		if artifact.Metrics.SymmetryScore != 1.0 {
+			t.Errorf("seed %d: SymmetryScore = %.2f, want 1.0", seed, artifact.Metrics.SymmetryScore)
+		}
+		if artifact.Metrics.TeamBalance != 1.0 {
+			t.Errorf("seed %d: TeamBalance = %.2f, want 1.0", seed, artifact.Metrics.TeamBalance)
+		}
+	}
+}
+
+// TestGenerate_Wave verifies wave mode produces a hub-centered ring map with
+// a progressive spawn schedule that passes validation.
+func TestGenerate_Wave(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 40},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Mode:          dungeon.ModeWave,
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		if hub := artifact.ADG.Rooms["hub"]; hub == nil || hub.Archetype != graph.ArchetypeStart {
+			t.Fatalf("seed %d: missing Start hub", seed)
+		}
+		if len(artifact.Content.Waves) == 0 {
+			t.Fatalf("seed %d: no wave schedule", seed)
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "WaveSchedule" {
+				found = true
+			}
+			if !result.Satisfied {
+				t.Errorf("seed %d: %s failed: %s", seed, result.Constraint.Kind, result.Details)
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing WaveSchedule constraint", seed)
+		}
+	}
+}
+
+// TestGenerate_Backtrack verifies backtrack mode seals the Boss behind a
+// chain of abilities whose pickups force the optimal route to double back.
+func TestGenerate_Backtrack(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+			Mode:          dungeon.ModeBacktrack,
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		if !artifact.Debug.Report.Passed {
+			t.Errorf("seed %d: validation failed: %v", seed, artifact.Debug.Report.Errors)
+		}
+
+		abilities := make(map[string]string) // Ability -> room providing it
+		for id, room := range artifact.ADG.Rooms {
+			for _, c := range room.Provides {
+				if c.Type == "ability" {
+					abilities[c.Value] = id
+				}
+			}
+		}
+		if len(abilities) != dungeon.DefaultBacktrackPasses {
+			t.Fatalf("seed %d: %d abilities, want %d", seed, len(abilities), dungeon.DefaultBacktrackPasses)
+		}
+		pickups := 0
+		for _, loot := range artifact.Content.Loot {
+			if strings.HasPrefix(loot.ItemType, "ability_") && loot.Required {
+				pickups++
+				if room := abilities[strings.TrimPrefix(loot.ItemType, "ability_")]; loot.RoomID != room {
+					t.Errorf("seed %d: %s placed in %s, want %s", seed, loot.ItemType, loot.RoomID, room)
+				}
+			}
+		}
+		if pickups != len(abilities) {
+			t.Errorf("seed %d: %d ability pickups, want %d", seed, pickups, len(abilities))
+		}
+
+		route, err := validation.FindSpeedrunRoute(artifact.ADG.Graph, artifact.Layout)
+		if err != nil {
+			t.Fatalf("seed %d: no route: %v", seed, err)
+		}
+		if len(route.Pickups) < len(abilities) {
+			t.Errorf("seed %d: route picks up %v, want every ability", seed, route.Pickups)
+		}
+		// Every ability but the last lies behind the next, and each return trip
+		// re-enters rooms
+		if artifact.Metrics.SpeedrunRevisits < len(abilities) || artifact.Metrics.SpeedrunRevisits != route.Revisits {
+			t.Errorf("seed %d: SpeedrunRevisits = %d (route %d), want at least %d",
+				seed, artifact.Metrics.SpeedrunRevisits, route.Revisits, len(abilities))
+		}
+	}
+}
+
+// TestRebalance verifies rebalancing re-paces difficulty and replaces content
+// while reusing the map, leaving the original artifact untouched.
+func TestRebalance(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for _, mode := range []dungeon.Mode{dungeon.ModeStandard, dungeon.ModeArena, dungeon.ModeWave} {
+		t.Run(string(mode), func(t *testing.T) {
+			cfg := &dungeon.Config{
+				Seed:          11,
+				Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+				Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+				Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				OptionalRatio: 0.2,
+				Mode:          mode,
+			}
+			artifact, err := gen.Generate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			before := make(map[string]float64)
+			for id, room := range artifact.ADG.Rooms {
+				before[id] = room.Difficulty
+			}
+
+			pacing := dungeon.PacingCfg{Curve: dungeon.PacingExponential}
+			rebalanced, err := dungeon.Rebalance(context.Background(), gen, artifact, cfg, pacing)
+			if err != nil {
+				t.Fatalf("Rebalance() error = %v", err)
+			}
+
+			if rebalanced.Layout != artifact.Layout || rebalanced.TileMap != artifact.TileMap {
+				t.Error("Rebalance() replaced the layout or tile map")
+			}
+			if len(rebalanced.ADG.Rooms) != len(artifact.ADG.Rooms) || len(rebalanced.ADG.Connectors) != len(artifact.ADG.Connectors) {
+				t.Error("Rebalance() changed the graph topology")
+			}
+			for id, room := range artifact.ADG.Rooms {
+				if room.Difficulty != before[id] {
+					t.Fatalf("Rebalance() modified the original room %s", id)
+				}
+			}
+			if !rebalanced.Debug.Report.Passed {
+				t.Errorf("rebalanced artifact failed validation: %v", rebalanced.Debug.Report.Errors)
+			}
+
+			// Without variance the critical path follows the new curve exactly
+			repaced := *cfg
+			repaced.Pacing = pacing
+			if dev := validation.CalculatePacingDeviation(rebalanced.ADG.Graph, &repaced); dev > 1e-9 {
+				t.Errorf("pacing deviation = %f, want 0", dev)
+			}
+
+			again, err := dungeon.Rebalance(context.Background(), gen, artifact, cfg, pacing)
+			if err != nil {
+				t.Fatalf("Rebalance() error = %v", err)
+			}
+			if fmt.Sprint(again.Content) != fmt.Sprint(rebalanced.Content) {
+				t.Error("Rebalance() is not deterministic")
+			}
+		})
+	}
+}
+
+// TestGenerateVariants verifies variants share the structural dungeon and
+// differ only in content, reproducibly per variation.
+func TestGenerateVariants(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          17,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+	}
+
+	variants, err := dungeon.GenerateVariants(context.Background(), gen, cfg, 3)
+	if err != nil {
+		t.Fatalf("GenerateVariants() error = %v", err)
+	}
+	if len(variants) != 3 {
+		t.Fatalf("got %d variants, want 3", len(variants))
+	}
+
+	base, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if fmt.Sprint(variants[0].Content) != fmt.Sprint(base.Content) {
+		t.Error("variant 0 differs from the generated dungeon")
+	}
+
+	for i, v := range variants[1:] {
+		if v.Layout != variants[0].Layout || v.TileMap != variants[0].TileMap {
+			t.Errorf("variant %d replaced the layout or tile map", i+1)
+		}
+		for id, room := range variants[0].ADG.Rooms {
+			if other := v.ADG.Rooms[id]; other == nil || other.Archetype != room.Archetype || other.Difficulty != room.Difficulty {
+				t.Errorf("variant %d changed room %s", i+1, id)
+			}
+		}
+		if fmt.Sprint(v.Content) == fmt.Sprint(variants[0].Content) {
+			t.Errorf("variant %d has the same content as variant 0", i+1)
+		}
+		if !v.Debug.Report.Passed {
+			t.Errorf("variant %d failed validation: %v", i+1, v.Debug.Report.Errors)
+		}
+	}
+	if fmt.Sprint(variants[1].Content) == fmt.Sprint(variants[2].Content) {
+		t.Error("variants 1 and 2 have the same content")
+	}
+
+	again, err := dungeon.Variant(context.Background(), gen, base, cfg, 2)
+	if err != nil {
+		t.Fatalf("Variant() error = %v", err)
+	}
+	if fmt.Sprint(again.Content) != fmt.Sprint(variants[2].Content) {
+		t.Error("Variant() is not deterministic")
+	}
+}
+
+// TestGenerate_QualityGates verifies Generate retries later seeds until the
+// dungeon meets the quality gates, and reports the closest attempt when no
+// seed does.
+func TestGenerate_QualityGates(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          31,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+	}
+	ctx := context.Background()
+
+	base, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want, _ := base.ExportJSON()
+
+	t.Run("first seed passes", func(t *testing.T) {
+		floor := float64(base.Metrics.FloorArea)
+		gated := *cfg
+		gated.Quality = dungeon.QualityCfg{Gates: []dungeon.QualityGate{{Metric: "floorArea", Min: &floor}}}
+		if !bytes.Equal(gated.Hash(), cfg.Hash()) {
+			t.Error("quality gates changed the config hash")
+		}
+		artifact, err := gen.Generate(ctx, &gated)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if got, _ := artifact.ExportJSON(); string(got) != string(want) {
+			t.Error("gates changed a dungeon that passes them")
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		floor := float64(base.Metrics.FloorArea + 1)
+		gated := *cfg
+		gated.Quality = dungeon.QualityCfg{Gates: []dungeon.QualityGate{{Metric: "floorArea", Min: &floor}}, MaxAttempts: 20}
+		artifact, err := gen.Generate(ctx, &gated)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		retry := artifact.Debug.Quality
+		if retry == nil || retry.Seed <= cfg.Seed || retry.Rejected != int(retry.Seed-cfg.Seed) {
+			t.Fatalf("Debug.Quality = %+v, want a later seed", retry)
+		}
+		if float64(artifact.Metrics.FloorArea) < floor {
+			t.Errorf("accepted floor area %d, want at least %g", artifact.Metrics.FloorArea, floor)
+		}
+
+		// The accepted dungeon is the one its seed generates
+		seeded := *cfg
+		seeded.Seed = retry.Seed
+		plain, err := gen.Generate(ctx, &seeded)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if fmt.Sprint(plain.Content) != fmt.Sprint(artifact.Content) || !reflect.DeepEqual(plain.Layout, artifact.Layout) {
+			t.Error("accepted dungeon differs from its seed's")
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		far := 1000.0
+		gated := *cfg
+		gated.Quality = dungeon.QualityCfg{Gates: []dungeon.QualityGate{{Metric: "pathLength", Min: &far}}, MaxAttempts: 3}
+		_, err := gen.Generate(ctx, &gated)
+		if !errors.Is(err, dungeon.ErrQualityGate) || !errors.Is(err, dungeon.ErrRetryExhausted) {
+			t.Fatalf("Generate() error = %v, want quality gates exhausted", err)
+		}
+		var qerr *dungeon.QualityError
+		if !errors.As(err, &qerr) || qerr.Artifact == nil || len(qerr.Failed) != 1 {
+			t.Fatalf("error %v does not report the closest attempt", err)
+		}
+		if qerr.Seed < cfg.Seed || qerr.Seed >= cfg.Seed+3 {
+			t.Errorf("closest seed %d outside the attempts", qerr.Seed)
+		}
+		if qerr.Failed[0].Value != float64(qerr.Artifact.Metrics.PathLength) {
+			t.Errorf("failed gate value %g, want the closest attempt's path length", qerr.Failed[0].Value)
+		}
+	})
+}
+
+// TestPresets verifies every built-in config preset loads and generates a
+// dungeon that passes validation.
+// TestGenerate_StopAfter verifies a pipeline stopped early returns the
+// outputs of the stages it ran, identical to those of a complete run.
+func TestGenerate_StopAfter(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          37,
+		Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 20},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+	}
+	full, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if full.Debug.StoppedAt != "" {
+		t.Errorf("complete run has StoppedAt = %q", full.Debug.StoppedAt)
+	}
+
+	tests := []struct {
+		stage   dungeon.CheckpointStage
+		layout  bool
+		tileMap bool
+		content bool
+		seeds   int
+	}{
+		{dungeon.StageSynthesis, false, false, false, 1},
+		{dungeon.StageEmbedding, true, false, false, 2},
+		{dungeon.StageCarving, true, true, false, 2},
+		{dungeon.StageContent, true, true, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.stage), func(t *testing.T) {
+			stopped := *cfg
+			stopped.StopAfter = tt.stage
+			if !bytes.Equal(stopped.Hash(), cfg.Hash()) {
+				t.Error("StopAfter changed the config hash")
+			}
+			artifact, err := gen.Generate(context.Background(), &stopped)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if artifact.Debug.StoppedAt != string(tt.stage) || len(artifact.Debug.Stages) != tt.seeds {
+				t.Errorf("Debug = %+v, want stopped after %s with %d stage seeds", artifact.Debug, tt.stage, tt.seeds)
+			}
+			if artifact.Metrics != nil || artifact.Debug.Report != nil {
+				t.Error("a stopped pipeline should not be validated")
+			}
+			if (artifact.Layout != nil) != tt.layout || (artifact.TileMap != nil) != tt.tileMap || (artifact.Content != nil) != tt.content {
+				t.Errorf("layout %v, tile map %v, content %v; want %v, %v, %v",
+					artifact.Layout != nil, artifact.TileMap != nil, artifact.Content != nil, tt.layout, tt.tileMap, tt.content)
+			}
+
+			// The graph is the one the complete run carves
+			if !reflect.DeepEqual(artifact.ADG.Graph.Rooms, full.ADG.Graph.Rooms) {
+				t.Error("rooms differ from the complete run")
+			}
+			if len(artifact.ADG.Graph.Connectors) != len(full.ADG.Graph.Connectors) {
+				t.Errorf("%d connectors, want %d", len(artifact.ADG.Graph.Connectors), len(full.ADG.Graph.Connectors))
+			}
+			if tt.tileMap && !reflect.DeepEqual(artifact.TileMap.Layers, full.TileMap.Layers) {
+				t.Error("tile map differs from the complete run")
+			}
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	names := dungeon.Presets()
+	for _, want := range []string{"metroidvania_large", "souls_gauntlet", "zelda_small"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("Presets() = %v, missing %s", names, want)
+		}
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := dungeon.LoadPreset(name)
+			if err != nil {
+				t.Fatalf("LoadPreset() error = %v", err)
+			}
+			if cfg.Seed == 0 {
+				t.Error("preset has no fixed seed")
+			}
+			artifact, err := gen.Generate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !artifact.Debug.Report.Passed {
+				t.Errorf("preset failed validation: %v", artifact.Debug.Report.Errors)
+			}
+		})
+	}
+
+	if _, err := dungeon.LoadPreset("zelda_large"); err == nil || !strings.Contains(err.Error(), "zelda_small") {
+		t.Errorf("LoadPreset() error = %v, want the preset names", err)
+	}
+}
+
+// TestTestSeeds verifies every config of the blessed seed corpus generates
+// a dungeon within its expected ranges, and that Check reports one outside.
+func TestTestSeeds(t *testing.T) {
+	seeds, err := dungeon.TestSeeds()
+	if err != nil {
+		t.Fatalf("TestSeeds() error = %v", err)
+	}
+	if len(seeds) < 8 {
+		t.Fatalf("got %d test seeds, want at least 8", len(seeds))
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	for _, seed := range seeds {
+		t.Run(seed.Name, func(t *testing.T) {
+			artifact, err := gen.Generate(context.Background(), seed.Config)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if err := seed.Check(artifact); err != nil {
+				t.Error(err)
+			}
+
+			narrow := seed
+			narrow.Rooms = dungeon.MetricRange{Min: 0, Max: 1}
+			if err := narrow.Check(artifact); err == nil || !strings.Contains(err.Error(), "rooms") {
+				t.Errorf("Check() error = %v, want the room count out of range", err)
+			}
+		})
+	}
+}
+
+// TestRemix verifies remixes keep the room graph and re-roll the layout or
+// content, reproducibly and without touching the remixed artifact.
+func TestRemix(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          23,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+	}
+	ctx := context.Background()
+
+	base, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	before, _ := base.ExportJSON()
+
+	sameRooms := func(t *testing.T, remix *dungeon.Artifact) {
+		t.Helper()
+		if len(remix.ADG.Rooms) != len(base.ADG.Rooms) {
+			t.Fatalf("remix has %d rooms, want %d", len(remix.ADG.Rooms), len(base.ADG.Rooms))
+		}
+		for id, room := range base.ADG.Rooms {
+			if other := remix.ADG.Rooms[id]; other == nil || other.Archetype != room.Archetype || other.Difficulty != room.Difficulty {
+				t.Errorf("remix changed room %s", id)
+			}
+		}
+		if !remix.Debug.Report.Passed {
+			t.Errorf("remix failed validation: %v", remix.Debug.Report.Errors)
+		}
+	}
+
+	t.Run("layout", func(t *testing.T) {
+		remix, err := dungeon.Remix(ctx, gen, base, cfg, dungeon.RemixOptions{SubSeed: 1})
+		if err != nil {
+			t.Fatalf("Remix() error = %v", err)
+		}
+		sameRooms(t, remix)
+		if reflect.DeepEqual(remix.Layout.Poses, base.Layout.Poses) {
+			t.Error("remix kept the layout")
+		}
+
+		again, err := dungeon.Remix(ctx, gen, base, cfg, dungeon.RemixOptions{Mode: dungeon.RemixLayout, SubSeed: 1})
+		if err != nil {
+			t.Fatalf("Remix() error = %v", err)
+		}
+		want, _ := remix.ExportJSON()
+		if got, _ := again.ExportJSON(); string(got) != string(want) {
+			t.Error("Remix() is not deterministic")
+		}
+
+		other, err := dungeon.Remix(ctx, gen, base, cfg, dungeon.RemixOptions{SubSeed: 2})
+		if err != nil {
+			t.Fatalf("Remix() error = %v", err)
+		}
+		if reflect.DeepEqual(other.Layout.Poses, remix.Layout.Poses) {
+			t.Error("sub-seeds 1 and 2 have the same layout")
+		}
+	})
+
+	t.Run("content", func(t *testing.T) {
+		remix, err := dungeon.Remix(ctx, gen, base, cfg, dungeon.RemixOptions{Mode: dungeon.RemixContent, SubSeed: 1})
+		if err != nil {
+			t.Fatalf("Remix() error = %v", err)
+		}
+		sameRooms(t, remix)
+		if remix.Layout != base.Layout || remix.TileMap != base.TileMap {
+			t.Error("content remix replaced the layout or tile map")
+		}
+		if fmt.Sprint(remix.Content) == fmt.Sprint(base.Content) {
+			t.Error("content remix kept the content")
+		}
+	})
+
+	if got, _ := base.ExportJSON(); string(got) != string(before) {
+		t.Error("Remix() modified the input artifact")
+	}
+
+	// Passages carving added to the graph are found again on the new map
+	t.Run("carved passages", func(t *testing.T) {
+		carved := *cfg
+		carved.Map = dungeon.MapCfg{Adjacency: dungeon.AdjacencySync, SharedWalls: dungeon.SharedWallsBreakable}
+		artifact, err := gen.Generate(ctx, &carved)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		for subSeed := uint64(1); subSeed <= 3; subSeed++ {
+			remix, err := dungeon.Remix(ctx, gen, artifact, &carved, dungeon.RemixOptions{SubSeed: subSeed})
+			if err != nil {
+				t.Fatalf("Remix(%d) error = %v", subSeed, err)
+			}
+			if !remix.Debug.Report.Passed {
+				t.Errorf("remix %d failed validation: %v", subSeed, remix.Debug.Report.Errors)
+			}
+		}
+	})
+
+	if _, err := dungeon.Remix(ctx, gen, base, cfg, dungeon.RemixOptions{Mode: "tiles"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+// TestDecisionLog_Replay verifies replaying a decision log reproduces the
+// generated dungeon, and that replays fail when the log does not fit.
+// TestRecarve verifies a saved artifact carves again to the same map, and
+// that a new tile size or new decorations leave its floor plan unchanged.
+func TestRecarve(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          41,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+		SecretDensity: 0.2,
+		OptionalRatio: 0.2,
+	}
+	ctx := context.Background()
+
+	base, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	data, _ := base.ExportJSON()
+	saved, err := dungeon.LoadArtifactFromBytes(data)
+	if err != nil {
+		t.Fatalf("LoadArtifactFromBytes() error = %v", err)
+	}
+
+	decorated := func(a *dungeon.Artifact) int {
+		n := 0
+		for _, v := range a.TileMap.Layers["decor"].Data {
+			if v != 0 {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("same settings", func(t *testing.T) {
+		for _, opts := range []dungeon.RecarveOptions{{}, {Content: true}} {
+			recarved, err := dungeon.Recarve(ctx, gen, saved, cfg, opts)
+			if err != nil {
+				t.Fatalf("Recarve(%+v) error = %v", opts, err)
+			}
+			if !reflect.DeepEqual(recarved.TileMap, base.TileMap) {
+				t.Errorf("Recarve(%+v) changed the tile map", opts)
+			}
+			if !reflect.DeepEqual(recarved.Content, base.Content) || !reflect.DeepEqual(recarved.Metrics, base.Metrics) {
+				t.Errorf("Recarve(%+v) changed the content or metrics", opts)
+			}
+		}
+		if after, _ := saved.ExportJSON(); string(after) != string(data) {
+			t.Error("Recarve modified the input artifact")
+		}
+	})
+
+	t.Run("tile size", func(t *testing.T) {
+		recarved, err := dungeon.Recarve(ctx, gen, saved, cfg, dungeon.RecarveOptions{TileWidth: 32, TileHeight: 32})
+		if err != nil {
+			t.Fatalf("Recarve() error = %v", err)
+		}
+		if recarved.TileMap.TileWidth != 32 || recarved.TileMap.TileHeight != 32 {
+			t.Errorf("tile size = %dx%d, want 32x32", recarved.TileMap.TileWidth, recarved.TileMap.TileHeight)
+		}
+		if !reflect.DeepEqual(recarved.TileMap.Layers["floor"], base.TileMap.Layers["floor"]) {
+			t.Error("a new tile size changed the floor plan")
+		}
+		doors, baseDoors := recarved.TileMap.Layers["doors"].Objects, base.TileMap.Layers["doors"].Objects
+		if len(doors) == 0 || len(doors) != len(baseDoors) {
+			t.Fatalf("%d doors, want %d", len(doors), len(baseDoors))
+		}
+		for i, door := range doors {
+			if door.X != 2*baseDoors[i].X || door.Y != 2*baseDoors[i].Y {
+				t.Errorf("door %s at (%v, %v), want (%v, %v)", door.Name, door.X, door.Y, 2*baseDoors[i].X, 2*baseDoors[i].Y)
+			}
+		}
+	})
+
+	t.Run("decorations", func(t *testing.T) {
+		if decorated(base) == 0 {
+			t.Fatal("crypt rooms should be decorated")
+		}
+		dense, err := dungeon.Recarve(ctx, gen, saved, cfg, dungeon.RecarveOptions{
+			Decorations: map[string][]themes.Decorator{"crypt": {{Type: "torch", Density: 1}}},
+		})
+		if err != nil {
+			t.Fatalf("Recarve() error = %v", err)
+		}
+		if decorated(dense) <= decorated(base) {
+			t.Errorf("%d decorated tiles, want more than %d", decorated(dense), decorated(base))
+		}
+		if !reflect.DeepEqual(dense.TileMap.Layers["walls"], base.TileMap.Layers["walls"]) {
+			t.Error("new decorations changed the walls")
+		}
+
+		bare, err := dungeon.Recarve(ctx, gen, saved, cfg, dungeon.RecarveOptions{
+			Decorations: map[string][]themes.Decorator{"crypt": nil},
+		})
+		if err != nil {
+			t.Fatalf("Recarve() error = %v", err)
+		}
+		if n := decorated(bare); n != 0 {
+			t.Errorf("%d decorated tiles, want none", n)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		stopped := *cfg
+		stopped.StopAfter = dungeon.StageEmbedding
+		embedded, err := gen.Generate(ctx, &stopped)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if _, err := dungeon.Recarve(ctx, gen, embedded, cfg, dungeon.RecarveOptions{}); err == nil {
+			t.Error("expected an error recarving an artifact that was never carved")
+		}
+		if _, err := dungeon.Recarve(ctx, gen, saved, cfg, dungeon.RecarveOptions{TileWidth: -1}); err == nil {
+			t.Error("expected an error for a negative tile size")
+		}
+		if _, err := dungeon.LoadArtifactFromBytes([]byte(`{}`)); err == nil {
+			t.Error("expected an error loading an artifact without a graph")
+		}
+	})
+}
+
+func TestDecisionLog_Replay(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          29,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+	}
+
+	artifact, log, err := dungeon.GenerateWithLog(context.Background(), gen, cfg)
+	if err != nil {
+		t.Fatalf("GenerateWithLog() error = %v", err)
+	}
+	plain, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want, _ := plain.ExportJSON()
+	if got, _ := artifact.ExportJSON(); string(got) != string(want) {
+		t.Fatal("logging changed the generated dungeon")
+	}
+
+	stages := []string{}
+	for _, stage := range log.Stages {
+		stages = append(stages, stage.Stage)
+	}
+	if !reflect.DeepEqual(stages, []string{"synthesis", "embedding", "content"}) {
+		t.Errorf("logged stages = %v", stages)
+	}
+
+	data, err := log.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() error = %v", err)
+	}
+	loaded, err := dungeon.LoadDecisionLogFromBytes(data)
+	if err != nil {
+		t.Fatalf("LoadDecisionLogFromBytes() error = %v", err)
+	}
+	replayed, err := dungeon.Replay(context.Background(), gen, cfg, loaded)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got, _ := replayed.ExportJSON(); string(got) != string(want) {
+		t.Error("replay differs from the generated dungeon")
+	}
+
+	// A log cut short no longer answers every decision
+	content := loaded.Stages[2]
+	content.Draws = content.Draws[:len(content.Draws)/2]
+	if _, err := dungeon.Replay(context.Background(), gen, cfg, loaded); err == nil || !strings.Contains(err.Error(), "replay diverged") {
+		t.Errorf("Replay() error = %v, want a divergence", err)
+	}
+
+	other := *cfg
+	other.Seed = 30
+	if _, err := dungeon.Replay(context.Background(), gen, &other, log); err == nil {
+		t.Error("expected an error replaying with a different config")
+	}
+}
+
+func TestCheckpoint_Resume(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          23,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingSCurve, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	ctx := context.Background()
+
+	want, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// Synthesis checkpoint through a file
+	cp, err := dungeon.GenerateCheckpoint(ctx, gen, cfg, dungeon.StageSynthesis)
+	if err != nil {
+		t.Fatalf("GenerateCheckpoint() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "synthesis.json")
+	if err := cp.SaveJSON(path); err != nil {
+		t.Fatalf("SaveJSON() error = %v", err)
+	}
+	cp, err = dungeon.LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+
+	// Embedding checkpoint through bytes, as another process would
+	cp, err = dungeon.AdvanceCheckpoint(ctx, gen, cfg, cp)
+	if err != nil {
+		t.Fatalf("AdvanceCheckpoint() error = %v", err)
+	}
+	data, err := cp.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() error = %v", err)
+	}
+	cp, err = dungeon.LoadCheckpointFromBytes(data)
+	if err != nil {
+		t.Fatalf("LoadCheckpointFromBytes() error = %v", err)
+	}
+
+	got, err := dungeon.ResumeCheckpoint(ctx, gen, cfg, cp)
+	if err != nil {
+		t.Fatalf("ResumeCheckpoint() error = %v", err)
+	}
+
+	if len(got.ADG.Rooms) != len(want.ADG.Rooms) || len(got.ADG.Connectors) != len(want.ADG.Connectors) {
+		t.Errorf("resumed graph has %d rooms, %d connectors, want %d, %d",
+			len(got.ADG.Rooms), len(got.ADG.Connectors), len(want.ADG.Rooms), len(want.ADG.Connectors))
+	}
+	if !reflect.DeepEqual(got.Layout, want.Layout) {
+		t.Error("resumed layout differs from Generate()")
+	}
+	if !reflect.DeepEqual(got.TileMap, want.TileMap) {
+		t.Error("resumed tile map differs from Generate()")
+	}
+	if fmt.Sprint(got.Content) != fmt.Sprint(want.Content) {
+		t.Error("resumed content differs from Generate()")
+	}
+	if !reflect.DeepEqual(got.Metrics, want.Metrics) {
+		t.Errorf("resumed metrics = %+v, want %+v", got.Metrics, want.Metrics)
+	}
+
+	// A checkpoint can be resumed again
+	if _, err := dungeon.ResumeCheckpoint(ctx, gen, cfg, cp); err != nil {
+		t.Fatalf("second ResumeCheckpoint() error = %v", err)
+	}
+
+	t.Run("checkpoint unchanged", func(t *testing.T) {
+		// Carving tags secret rooms; the checkpoint keeps its graph
+		secrets := dungeon.Config{
+			Seed:          7,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Map:           dungeon.MapCfg{SharedWalls: dungeon.SharedWallsBreakable},
+			Secrets:       dungeon.SecretsCfg{Weights: map[string]float64{"illusory_wall": 1}},
+		}
+		cp, err := dungeon.GenerateCheckpoint(ctx, gen, &secrets, dungeon.StageEmbedding)
+		if err != nil {
+			t.Fatalf("GenerateCheckpoint() error = %v", err)
+		}
+		before, err := cp.ExportJSON()
+		if err != nil {
+			t.Fatalf("ExportJSON() error = %v", err)
+		}
+		if _, err := dungeon.ResumeCheckpoint(ctx, gen, &secrets, cp); err != nil {
+			t.Fatalf("ResumeCheckpoint() error = %v", err)
+		}
+		if after, _ := cp.ExportJSON(); string(after) != string(before) {
+			t.Error("ResumeCheckpoint() modified the checkpoint")
+		}
+	})
+
+	t.Run("different config", func(t *testing.T) {
+		other := *cfg
+		other.Seed++
+		if _, err := dungeon.ResumeCheckpoint(ctx, gen, &other, cp); err == nil {
+			t.Error("ResumeCheckpoint() with a different config succeeded")
+		}
+	})
+
+	t.Run("advance embedding checkpoint", func(t *testing.T) {
+		if _, err := dungeon.AdvanceCheckpoint(ctx, gen, cfg, cp); err == nil {
+			t.Error("AdvanceCheckpoint() on an embedding checkpoint succeeded")
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		bad := *cp
+		bad.Version = dungeon.CheckpointVersion + 1
+		data, err := bad.ExportJSON()
+		if err != nil {
+			t.Fatalf("ExportJSON() error = %v", err)
+		}
+		if _, err := dungeon.LoadCheckpointFromBytes(data); err == nil {
+			t.Error("LoadCheckpointFromBytes() accepted an unsupported version")
+		}
+	})
+
+	t.Run("unknown stage", func(t *testing.T) {
+		if _, err := dungeon.GenerateCheckpoint(ctx, gen, cfg, "carving"); err == nil {
+			t.Error("GenerateCheckpoint() accepted an unknown stage")
+		}
+	})
+}
+
+// jsonWorker runs zone jobs in process but passes jobs and results through
+// JSON, as a worker in another process would.
+type jsonWorker struct {
+	gen  dungeon.Generator
+	fail bool
+}
+
+func (w jsonWorker) RunZone(ctx context.Context, job *dungeon.ZoneJob) (*dungeon.ZoneResult, error) {
+	if w.fail {
+		return nil, errors.New("worker unavailable")
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	var remote dungeon.ZoneJob
+	if err := json.Unmarshal(data, &remote); err != nil {
+		return nil, err
+	}
+	result, err := dungeon.RunZoneJob(ctx, w.gen, &remote)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	var local dungeon.ZoneResult
+	if err := json.Unmarshal(data, &local); err != nil {
+		return nil, err
+	}
+	return &local, nil
+}
+
+func TestGenerateDistributed(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	cfg := &dungeon.Config{
+		Seed:          31,
+		Size:          dungeon.SizeCfg{RoomsMin: 120, RoomsMax: 150},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+		SecretDensity: 0.1,
+		Zones:         dungeon.ZoneCfg{Size: 40},
+	}
+	ctx := context.Background()
+
+	want, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !want.Debug.Report.Passed {
+		t.Errorf("validation failed: %v", want.Debug.Report.Errors)
+	}
+	for id := range want.ADG.Rooms {
+		if _, ok := want.Layout.Poses[id]; !ok {
+			t.Errorf("room %s has no pose", id)
+		}
+	}
+
+	// Remote workers must produce the same dungeon as local ones
+	got, err := dungeon.GenerateDistributed(ctx, gen, cfg, dungeon.DistributedOptions{
+		Worker:      jsonWorker{gen: gen},
+		Parallelism: 1,
+	})
+	if err != nil {
+		t.Fatalf("GenerateDistributed() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Layout, want.Layout) {
+		t.Error("layout differs from Generate()")
+	}
+	for name, layer := range want.TileMap.Layers {
+		other, ok := got.TileMap.Layers[name]
+		if !ok {
+			t.Errorf("layer %s missing", name)
+			continue
+		}
+		if !reflect.DeepEqual(other.Data, layer.Data) {
+			t.Errorf("layer %s data differs from Generate()", name)
+		}
+		if len(other.Objects) != len(layer.Objects) {
+			t.Errorf("layer %s has %d objects, want %d", name, len(other.Objects), len(layer.Objects))
+			continue
+		}
+		for i := range layer.Objects {
+			if other.Objects[i].Name != layer.Objects[i].Name {
+				t.Errorf("layer %s object %d = %s, want %s", name, i, other.Objects[i].Name, layer.Objects[i].Name)
+			}
+		}
+	}
+	if fmt.Sprint(got.Content) != fmt.Sprint(want.Content) {
+		t.Error("content differs from Generate()")
+	}
+
+	t.Run("worker error", func(t *testing.T) {
+		_, err := dungeon.GenerateDistributed(ctx, gen, cfg, dungeon.DistributedOptions{
+			Worker: jsonWorker{gen: gen, fail: true},
+		})
+		if err == nil {
+			t.Error("GenerateDistributed() succeeded with a failing worker")
+		}
+	})
+}
+
+// TestGenerate_VaultsLowBacktracking verifies vault keys stay where
+// synthesis put them, on or next to the critical path, so vaults generate
+// alongside accessibility.lowBacktracking even in small dungeons.
+func TestGenerate_VaultsLowBacktracking(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 10; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 10},
+			Branching:     dungeon.BranchingCfg{Avg: 1.5, Max: 3},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Vaults:        2,
+			Accessibility: dungeon.AccessibilityCfg{LowBacktracking: true},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		for _, loot := range artifact.Content.Loot {
+			if !strings.HasPrefix(loot.ItemType, "key_"+synthesis.VaultKeyPrefix) {
+				continue
+			}
+			held := false
+			for _, cap := range artifact.ADG.Rooms[loot.RoomID].Provides {
+				held = held || "key_"+cap.Value == loot.ItemType
+			}
+			if !held {
+				t.Errorf("seed %d: %s placed in %s, which does not hold it", seed, loot.ItemType, loot.RoomID)
+			}
+		}
+	}
+}
+
+func TestGenerate_Escape(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 25},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Escape:        dungeon.EscapeCfg{Enabled: true},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+
+		escape := artifact.Content.Escape
+		if escape == nil {
+			t.Fatalf("seed %d: no escape", seed)
+		}
+		if escape.Speed != dungeon.DefaultEscapeSpeed {
+			t.Errorf("seed %d: escape speed = %v, want %v", seed, escape.Speed, dungeon.DefaultEscapeSpeed)
+		}
+		if escape.TimeLimitMs < escape.WalkMs {
+			t.Errorf("seed %d: time limit %dms below walk time %dms", seed, escape.TimeLimitMs, escape.WalkMs)
+		}
+		for i, tile := range escape.Route {
+			if tile.Order != i {
+				t.Errorf("seed %d: tile %d has order %d", seed, i, tile.Order)
+			}
+			if i > 0 && tile.TimeMs < escape.Route[i-1].TimeMs {
+				t.Errorf("seed %d: tile %d collapses at %dms, before tile %d at %dms", seed, i, tile.TimeMs, i-1, escape.Route[i-1].TimeMs)
+			}
+		}
+
+		found := false
+		for _, result := range artifact.Debug.Report.HardConstraintResults {
+			if result.Constraint.Kind == "EscapeCompletable" {
+				found = true
+				if !result.Satisfied {
+					t.Errorf("seed %d: EscapeCompletable failed: %s", seed, result.Details)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("seed %d: report missing EscapeCompletable constraint", seed)
+		}
+	}
+
+	// With an Exit room the escape ends there
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 25},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			SecretDensity: 0.1,
+			OptionalRatio: 0.2,
+			Exit:          true,
+			Vaults:        1,
+			Escape:        dungeon.EscapeCfg{Enabled: true},
+		}
+
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() with exit error = %v", seed, err)
+		}
+		escape := artifact.Content.Escape
+		if escape == nil || artifact.ADG.Rooms[escape.ToRoom].Archetype != graph.ArchetypeExit {
+			t.Errorf("seed %d: escape does not end in the Exit room: %+v", seed, escape)
+		}
+	}
+
+	// A limit shorter than the walk cannot be met
+	cfg := &dungeon.Config{
+		Seed:          1,
+		Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 25},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Escape:        dungeon.EscapeCfg{Enabled: true, TimeLimitMs: 1, Speed: 1},
+	}
+	if _, err := gen.Generate(context.Background(), cfg); err == nil {
+		t.Error("Generate() with an impossible escape time limit succeeded")
+	}
+}
@@ -0,0 +1,5 @@
+// Package dungeontest provides property-test helpers for dungeon generators:
+// FuzzConfig draws random valid configs and FuzzGenerate runs a generator
+// on them, checking the pipeline invariants and determinism. It keeps the
+// rapid dependency out of the dungeon package itself.
+package dungeontest
@@ -0,0 +1,256 @@
+package dungeontest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/synthesis"
+	"pgregory.net/rapid"
+)
+
+// Fuzz config bounds. Room counts are kept well below the 300-room limit so a
+// property run of 100 cases finishes in seconds.
+const (
+	fuzzRoomsMin    = 10
+	fuzzRoomsMax    = 60
+	fuzzRoomsSpan   = 30
+	fuzzMaxKeys     = 2
+	fuzzMaxCustoms  = 5
+	fuzzMaxParty    = 4
+	fuzzZoneSizeMin = 20
+	fuzzZoneSizeMax = 40
+)
+
+// fuzzThemes lists the built-in themes drawn by FuzzConfig.
+var fuzzThemes = []string{"dungeon", "crypt", "fungal", "arcane"}
+
+// fuzzModes lists the modes drawn by FuzzConfig; empty means standard.
+var fuzzModes = []dungeon.Mode{"", dungeon.ModeStandard, dungeon.ModeArena, dungeon.ModeWave, dungeon.ModeBacktrack}
+
+// fuzzSizes lists the room size class names drawn for Rooms.SizeWeights.
+var fuzzSizes = []string{"XS", "S", "M", "L", "XL"}
+
+// FuzzConfig draws a random Config that passes Config.Validate. It draws the
+// seed, size, branching, pacing curve (including CUSTOM points), themes,
+// keys, secret density and kind weights, optional ratio, mode (with
+// backtrack passes), the Exit room, treasure vaults, the escape sequence,
+// content tuning (with factions and skirmishes), map layout and
+// post-processing, room footprints, party, zones and accessibility
+// guarantees, keeping each within what the drawn mode supports.
+//
+// Left at their zero values are the settings that can make a valid config
+// fail by design: archetype targets, constraints, difficulty presets,
+// layout presets, map size limits, boundaries, floor budgets, strict
+// adjacency and escape time limits.
+func FuzzConfig(t *rapid.T) *dungeon.Config {
+	mode := rapid.SampledFrom(fuzzModes).Draw(t, "mode")
+	graphOnly := mode == dungeon.ModeArena || mode == dungeon.ModeWave
+	standard := mode == "" || mode == dungeon.ModeStandard
+
+	roomsMin := rapid.IntRange(fuzzRoomsMin, fuzzRoomsMax).Draw(t, "roomsMin")
+	roomsMax := rapid.IntRange(roomsMin, roomsMin+fuzzRoomsSpan).Draw(t, "roomsMax")
+	if mode == dungeon.ModeArena && roomsMin == roomsMax && roomsMin%2 == 0 {
+		// Arenas mirror two halves around the Boss
+		roomsMax++
+	}
+	branchingMin := 2
+	if mode == dungeon.ModeWave {
+		// Wave rings fit every room count drawn only at the widest branching
+		branchingMin = 5
+	}
+
+	cfg := &dungeon.Config{
+		Seed: rapid.Uint64Min(1).Draw(t, "seed"),
+		Size: dungeon.SizeCfg{RoomsMin: roomsMin, RoomsMax: roomsMax},
+		Branching: dungeon.BranchingCfg{
+			Avg: rapid.Float64Range(1.5, 3.0).Draw(t, "branchingAvg"),
+			Max: rapid.IntRange(branchingMin, 5).Draw(t, "branchingMax"),
+		},
+		Pacing: dungeon.PacingCfg{
+			Curve:    rapid.SampledFrom(dungeon.ValidPacingCurves).Draw(t, "pacingCurve"),
+			Variance: rapid.Float64Range(0.0, 0.3).Draw(t, "pacingVariance"),
+		},
+		Themes:        rapid.SliceOfNDistinct(rapid.SampledFrom(fuzzThemes), 1, len(fuzzThemes), rapid.ID[string]).Draw(t, "themes"),
+		SecretDensity: rapid.Float64Range(0.0, 0.3).Draw(t, "secretDensity"),
+		OptionalRatio: rapid.Float64Range(0.1, 0.4).Draw(t, "optionalRatio"),
+		Mode:          mode,
+		Content: dungeon.ContentCfg{
+			SpawnDensity:     rapid.Float64Range(0.25, 2.0).Draw(t, "spawnDensity"),
+			LootBudget:       rapid.IntRange(0, 100000).Draw(t, "lootBudget"),
+			TrapDensity:      rapid.Float64Range(0.0, 1.0).Draw(t, "trapDensity"),
+			EnvironmentRatio: rapid.Float64Range(0.0, 0.8).Draw(t, "environmentRatio"),
+			AmbushRatio:      rapid.Float64Range(0.0, 1.0).Draw(t, "ambushRatio"),
+			EntityRadius:     rapid.IntRange(0, 4).Draw(t, "entityRadius"),
+		},
+		Rooms: dungeon.RoomsCfg{
+			HallRatio: rapid.Float64Range(0.0, 1.0).Draw(t, "hallRatio"),
+		},
+		Accessibility: dungeon.AccessibilityCfg{
+			NoRequiredSecrets:           rapid.Bool().Draw(t, "noRequiredSecrets"),
+			MaxCombatBetweenCheckpoints: rapid.IntRange(0, 20).Draw(t, "maxCombatBetweenCheckpoints"),
+		},
+	}
+
+	if mode != dungeon.ModeWave && rapid.Bool().Draw(t, "factions") {
+		cfg.Content.Factions = true
+		cfg.Content.SkirmishRatio = rapid.Float64Range(0.0, 1.0).Draw(t, "skirmishRatio")
+	}
+
+	if rapid.Bool().Draw(t, "secretWeighted") {
+		cfg.Secrets.Weights = make(map[string]float64)
+		for _, kind := range rapid.SliceOfNDistinct(rapid.SampledFrom(carving.SecretKinds), 1, len(carving.SecretKinds), rapid.ID[string]).Draw(t, "secretKinds") {
+			cfg.Secrets.Weights[kind] = rapid.Float64Range(0.1, 100.0).Draw(t, "secretWeight"+kind)
+		}
+	}
+
+	if cfg.Pacing.Curve == dungeon.PacingCustom {
+		// Strictly increasing progress values with arbitrary difficulty
+		n := rapid.IntRange(2, fuzzMaxCustoms).Draw(t, "customPointCount")
+		for i := 0; i < n; i++ {
+			progress := float64(i) / float64(n-1)
+			difficulty := rapid.Float64Range(0.0, 1.0).Draw(t, fmt.Sprintf("customDifficulty%d", i))
+			cfg.Pacing.CustomPoints = append(cfg.Pacing.CustomPoints, [2]float64{progress, difficulty})
+		}
+	}
+
+	if !graphOnly {
+		keyCount := rapid.IntRange(0, fuzzMaxKeys).Draw(t, "keyCount")
+		for i := 0; i < keyCount; i++ {
+			cfg.Keys = append(cfg.Keys, dungeon.KeyCfg{
+				Name:  fmt.Sprintf("key_%d", i),
+				Count: rapid.IntRange(1, 2).Draw(t, fmt.Sprintf("keyCount%d", i)),
+			})
+		}
+	}
+
+	if rapid.Bool().Draw(t, "sizeWeighted") {
+		cfg.Rooms.SizeWeights = make(map[string]float64)
+		for _, name := range rapid.SliceOfNDistinct(rapid.SampledFrom(fuzzSizes), 1, len(fuzzSizes), rapid.ID[string]).Draw(t, "sizes") {
+			cfg.Rooms.SizeWeights[name] = rapid.Float64Range(0.1, 1.0).Draw(t, "sizeWeight"+name)
+		}
+	}
+
+	if mode == dungeon.ModeBacktrack {
+		cfg.Backtrack.Passes = rapid.IntRange(0, synthesis.MaxBacktrackPasses).Draw(t, "backtrackPasses")
+	} else {
+		cfg.Accessibility.LowBacktracking = rapid.Bool().Draw(t, "lowBacktracking")
+	}
+
+	if mode != dungeon.ModeArena {
+		cfg.Party = dungeon.PartyCfg{
+			Size:           rapid.IntRange(0, fuzzMaxParty).Draw(t, "partySize"),
+			ConvergeWithin: rapid.IntRange(0, 10).Draw(t, "partyConvergeWithin"),
+		}
+	}
+
+	if standard && rapid.Bool().Draw(t, "zoned") {
+		cfg.Zones.Size = rapid.IntRange(fuzzZoneSizeMin, fuzzZoneSizeMax).Draw(t, "zoneSize")
+	}
+
+	if !graphOnly {
+		cfg.Exit = rapid.Bool().Draw(t, "exit")
+		if cfg.Branching.Max >= 3 {
+			// Vaults are cut from what the core rooms, the Exit and the
+			// backtracking abilities leave of the room count
+			spare := roomsMin - synthesis.CoreRooms
+			if cfg.Exit {
+				spare--
+			}
+			if mode == dungeon.ModeBacktrack {
+				spare -= cfg.Backtrack.PassCount()
+			}
+			cfg.Vaults = rapid.IntRange(0, min(synthesis.MaxVaults, spare/synthesis.VaultRooms)).Draw(t, "vaults")
+		}
+		if cfg.Zones.Size == 0 && rapid.Bool().Draw(t, "escape") {
+			cfg.Escape = dungeon.EscapeCfg{
+				Enabled: true,
+				Speed:   rapid.Float64Range(1.0, 10.0).Draw(t, "escapeSpeed"),
+			}
+		}
+	}
+
+	cfg.Map.Trim = rapid.Bool().Draw(t, "trim")
+	if mode != dungeon.ModeArena {
+		cfg.Map.Repack = rapid.Bool().Draw(t, "repack")
+	}
+	if !graphOnly {
+		cfg.Map.Layout = rapid.SampledFrom([]dungeon.LayoutStyle{"", dungeon.LayoutForce, dungeon.LayoutRings, dungeon.LayoutLayered}).Draw(t, "layout")
+	}
+	if !graphOnly && cfg.Zones.Size == 0 {
+		if cfg.Map.Layout == "" || cfg.Map.Layout == dungeon.LayoutForce {
+			if rapid.Bool().Draw(t, "aspect") {
+				cfg.Map.AspectRatio = rapid.Float64Range(dungeon.MinAspectRatio, dungeon.MaxAspectRatio).Draw(t, "aspectRatio")
+			}
+		}
+		// Options adding connectors after synthesis could move the
+		// critical path the accessibility guarantees were placed along
+		sharedWalls := []dungeon.SharedWallMode{"", dungeon.SharedWallsSeparate}
+		if !cfg.Accessibility.LowBacktracking && cfg.Accessibility.MaxCombatBetweenCheckpoints == 0 {
+			cfg.Map.Junctions = rapid.Bool().Draw(t, "junctions")
+			if !cfg.Exit {
+				// Synced passages could join the Exit to rooms besides the Boss
+				cfg.Map.Adjacency = rapid.SampledFrom([]dungeon.AdjacencyMode{"", dungeon.AdjacencySync}).Draw(t, "adjacency")
+			}
+			sharedWalls = append(sharedWalls, dungeon.SharedWallsBreakable)
+		}
+		cfg.Map.SharedWalls = rapid.SampledFrom(sharedWalls).Draw(t, "sharedWalls")
+	}
+
+	return cfg
+}
+
+// FuzzGenerate is a property harness for the whole pipeline. It draws a
+// random valid config with FuzzConfig, generates it twice with gen and fails
+// t if generation errors, dungeon.CheckInvariants reports a violation, or
+// the two runs differ (determinism).
+//
+// Custom synthesizers and content passes can be checked against the same
+// invariants:
+//
+//	rapid.Check(t, func(t *rapid.T) {
+//	  dungeontest.FuzzGenerate(t, myGenerator)
+//	})
+func FuzzGenerate(t *rapid.T, gen dungeon.Generator) {
+	cfg := FuzzConfig(t)
+	ctx := context.Background()
+
+	first, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if err := dungeon.CheckInvariants(first); err != nil {
+		t.Fatalf("invariant violated: %v", err)
+	}
+
+	second, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("second Generate() failed: %v", err)
+	}
+
+	a, err := first.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() failed: %v", err)
+	}
+	b, err := second.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() failed: %v", err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatalf("determinism: seed %d produced different artifacts: %s", cfg.Seed, firstDifference(a, b))
+	}
+}
+
+// firstDifference describes the first line at which two JSON documents differ.
+func firstDifference(a, b []byte) string {
+	linesA := bytes.Split(a, []byte("\n"))
+	linesB := bytes.Split(b, []byte("\n"))
+	for i := 0; i < len(linesA) && i < len(linesB); i++ {
+		if !bytes.Equal(linesA[i], linesB[i]) {
+			return fmt.Sprintf("line %d: %q vs %q", i+1, bytes.TrimSpace(linesA[i]), bytes.TrimSpace(linesB[i]))
+		}
+	}
+	return fmt.Sprintf("lengths differ (%d vs %d lines)", len(linesA), len(linesB))
+}
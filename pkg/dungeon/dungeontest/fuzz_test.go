@@ -0,0 +1,32 @@
+package dungeontest_test
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/dungeon/dungeontest"
+	"github.com/dshills/dungo/pkg/validation"
+	"pgregory.net/rapid"
+)
+
+// TestFuzzConfig_AlwaysValid verifies FuzzConfig only draws valid configs.
+func TestFuzzConfig_AlwaysValid(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		cfg := dungeontest.FuzzConfig(t)
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("FuzzConfig drew invalid config: %v", err)
+		}
+	})
+}
+
+// TestProperty_FuzzGenerate runs the pipeline property harness against the
+// default generator.
+func TestProperty_FuzzGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping pipeline property test in short mode")
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	rapid.Check(t, func(t *rapid.T) {
+		dungeontest.FuzzGenerate(t, gen)
+	})
+}
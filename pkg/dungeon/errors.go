@@ -0,0 +1,123 @@
+package dungeon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/synthesis"
+)
+
+// Sentinel errors for the kinds of generation failure. Errors returned by
+// the generator wrap them, so callers branch with errors.Is instead of
+// matching messages, and extract details with errors.As on StageError and
+// ConstraintError.
+var (
+	// ErrInvalidConfig marks a config that failed validation.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrConstraintUnsatisfied marks a dungeon that failed a hard
+	// constraint; see ConstraintError.
+	ErrConstraintUnsatisfied = errors.New("hard constraints not satisfied")
+
+	// ErrCancelled marks a generation stopped by its context. The context's
+	// error is wrapped as well, so errors.Is(err, context.Canceled) holds.
+	ErrCancelled = errors.New("generation cancelled")
+
+	// ErrRetryExhausted marks a stage that gave up after every attempt
+	// failed; StageError.Attempts holds the count.
+	ErrRetryExhausted = errors.New("retries exhausted")
+
+	// ErrTimeout marks a stage that ran past its deadline, from
+	// Config.Limits or the caller's context; StageError.Stage names it.
+	// context.DeadlineExceeded is wrapped as well, and ErrCancelled matches.
+	ErrTimeout = errors.New("deadline exceeded")
+
+	// ErrMemoryBudget marks a config refused before generation because its
+	// estimated memory exceeds Limits.MaxMemoryMB; see MemoryError.
+	ErrMemoryBudget = errors.New("memory budget exceeded")
+)
+
+// StageError reports the pipeline stage a generation failed in: synthesis,
+// embedding, carving, content or validation.
+type StageError struct {
+	Stage    string // Pipeline stage that failed
+	Attempts int    // Attempts made before giving up, 0 for stages without retries
+	Err      error  // Underlying failure
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrRetryExhausted when the stage ran out of attempts, and
+// ErrCancelled when the stage was stopped by its context.
+func (e *StageError) Is(target error) bool {
+	switch target {
+	case ErrRetryExhausted:
+		return e.Attempts > 0
+	case ErrCancelled:
+		return errors.Is(e.Err, context.Canceled) || errors.Is(e.Err, context.DeadlineExceeded)
+	}
+	return false
+}
+
+// ConstraintError reports the hard constraints a generated dungeon failed.
+// It matches ErrConstraintUnsatisfied.
+type ConstraintError struct {
+	Failed []ConstraintResult // Results of the unsatisfied hard constraints
+	Errors []string           // Validation error messages
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("hard constraints not satisfied: %v", e.Errors)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == ErrConstraintUnsatisfied
+}
+
+// MemoryError reports a config whose estimated peak memory exceeds its
+// Limits.MaxMemoryMB budget. It matches ErrMemoryBudget.
+type MemoryError struct {
+	Estimate          MemoryEstimate
+	Budget            int64 // Budget in bytes
+	SuggestedRoomsMax int   // Largest size.roomsMax within budget, 0 if none is
+}
+
+func (e *MemoryError) Error() string {
+	msg := fmt.Sprintf("estimated peak memory %d MiB exceeds the %d MiB budget", e.Estimate.PeakBytes>>20, e.Budget>>20)
+	if e.SuggestedRoomsMax > 0 {
+		return fmt.Sprintf("%s; at most %d rooms fit", msg, e.SuggestedRoomsMax)
+	}
+	return msg + " even at the smallest dungeon"
+}
+
+func (e *MemoryError) Is(target error) bool {
+	return target == ErrMemoryBudget
+}
+
+// stageError wraps the failure of a pipeline stage, recording the attempts
+// of synthesizers that exhausted their retries.
+func stageError(stage string, err error) error {
+	se := &StageError{Stage: stage, Err: err}
+	var retry *synthesis.RetryError
+	if errors.As(err, &retry) {
+		se.Attempts = retry.Attempts
+	}
+	return se
+}
+
+// invalidConfig wraps a config validation failure.
+func invalidConfig(err error) error {
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+}
+
+// cancelled returns the error for a generation stopped by ctx.
+func cancelled(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+}
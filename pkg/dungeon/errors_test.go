@@ -0,0 +1,156 @@
+package dungeon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/synthesis"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// exhaustedSynthesizer always fails as if it ran out of attempts.
+type exhaustedSynthesizer struct{}
+
+func (exhaustedSynthesizer) Synthesize(context.Context, *rng.RNG, *synthesis.Config) (*graph.Graph, error) {
+	return nil, &synthesis.RetryError{Attempts: 7, Err: errors.New("no boss room")}
+}
+
+func (exhaustedSynthesizer) Name() string { return "exhausted" }
+
+// cappedSynthesizer fails after the attempts the config allows.
+type cappedSynthesizer struct{}
+
+func (cappedSynthesizer) Synthesize(_ context.Context, _ *rng.RNG, cfg *synthesis.Config) (*graph.Graph, error) {
+	return nil, &synthesis.RetryError{Attempts: cfg.MaxAttempts, Err: errors.New("no boss room")}
+}
+
+func (cappedSynthesizer) Name() string { return "capped" }
+
+// failingValidator reports one unsatisfied hard constraint.
+type failingValidator struct{}
+
+func (failingValidator) Validate(context.Context, *dungeon.Artifact, *dungeon.Config) (*dungeon.ValidationReport, error) {
+	failed := dungeon.ConstraintResult{Constraint: &dungeon.Constraint{Kind: "Connectivity", Severity: "hard", Expr: "connected()"}}
+	return &dungeon.ValidationReport{
+		HardConstraintResults: []dungeon.ConstraintResult{{Satisfied: true}, failed},
+		Errors:                []string{"dungeon is not connected"},
+	}, nil
+}
+
+// TestErrorTaxonomy verifies each kind of failure matches its sentinel and
+// carries its details.
+func TestErrorTaxonomy(t *testing.T) {
+	cfg := func() *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          9,
+			Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 15},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+		}
+	}
+	sentinels := []error{dungeon.ErrInvalidConfig, dungeon.ErrConstraintUnsatisfied, dungeon.ErrCancelled, dungeon.ErrRetryExhausted, dungeon.ErrTimeout}
+	only := func(t *testing.T, err, want error) {
+		t.Helper()
+		if err == nil {
+			t.Fatalf("got no error, want %v", want)
+		}
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == want) {
+				t.Errorf("errors.Is(%v, %v) = %v", err, sentinel, got)
+			}
+		}
+	}
+
+	t.Run("invalid config", func(t *testing.T) {
+		invalid := cfg()
+		invalid.OptionalRatio = 0.9
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+		_, err := gen.Generate(context.Background(), invalid)
+		only(t, err, dungeon.ErrInvalidConfig)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+		_, err := gen.Generate(ctx, cfg())
+		only(t, err, dungeon.ErrCancelled)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("errors.Is(%v, context.Canceled) = false", err)
+		}
+	})
+
+	t.Run("retry exhausted", func(t *testing.T) {
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator()).(*dungeon.DefaultGenerator)
+		gen.SetSynthesizer(exhaustedSynthesizer{})
+		_, err := gen.Generate(context.Background(), cfg())
+		only(t, err, dungeon.ErrRetryExhausted)
+
+		var stage *dungeon.StageError
+		if !errors.As(err, &stage) || stage.Stage != "synthesis" || stage.Attempts != 7 {
+			t.Errorf("StageError = %+v, want synthesis after 7 attempts", stage)
+		}
+	})
+
+	t.Run("attempts capped", func(t *testing.T) {
+		capped := cfg()
+		capped.Limits = &dungeon.LimitsCfg{MaxSynthesisAttempts: 2}
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator()).(*dungeon.DefaultGenerator)
+		gen.SetSynthesizer(cappedSynthesizer{})
+		_, err := gen.Generate(context.Background(), capped)
+		only(t, err, dungeon.ErrRetryExhausted)
+
+		var stage *dungeon.StageError
+		if !errors.As(err, &stage) || stage.Attempts != 2 {
+			t.Errorf("StageError = %+v, want synthesis after 2 attempts", stage)
+		}
+	})
+
+	t.Run("stage timeout", func(t *testing.T) {
+		slow := cfg()
+		slow.Limits = &dungeon.LimitsCfg{Stages: map[string]time.Duration{"carving": time.Nanosecond}}
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+		_, err := gen.Generate(context.Background(), slow)
+		if !errors.Is(err, dungeon.ErrTimeout) || !errors.Is(err, dungeon.ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("error %v does not match ErrTimeout, ErrCancelled and context.DeadlineExceeded", err)
+		}
+
+		var stage *dungeon.StageError
+		if !errors.As(err, &stage) || stage.Stage != "carving" {
+			t.Errorf("StageError = %+v, want the carving stage", stage)
+		}
+	})
+
+	t.Run("generation timeout", func(t *testing.T) {
+		slow := cfg()
+		slow.Limits = &dungeon.LimitsCfg{Timeout: time.Nanosecond}
+		gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+		_, err := gen.Generate(context.Background(), slow)
+
+		var stage *dungeon.StageError
+		if !errors.Is(err, dungeon.ErrTimeout) || !errors.As(err, &stage) || stage.Stage != "synthesis" {
+			t.Errorf("error %v is not a synthesis timeout", err)
+		}
+	})
+
+	t.Run("constraint unsatisfied", func(t *testing.T) {
+		gen := dungeon.NewGeneratorWithValidator(failingValidator{})
+		_, err := gen.Generate(context.Background(), cfg())
+		only(t, err, dungeon.ErrConstraintUnsatisfied)
+
+		var constraint *dungeon.ConstraintError
+		if !errors.As(err, &constraint) {
+			t.Fatalf("error %v is not a ConstraintError", err)
+		}
+		if len(constraint.Failed) != 1 || constraint.Failed[0].Constraint.Kind != "Connectivity" {
+			t.Errorf("Failed = %+v, want the Connectivity constraint", constraint.Failed)
+		}
+	})
+}
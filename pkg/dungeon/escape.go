@@ -0,0 +1,202 @@
+package dungeon
+
+import (
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// Escape trigger types.
+const (
+	TriggerEscapeStart = "escape_start" // Starts the escape clock when the Boss falls
+	TriggerEscapeEnd   = "escape_end"   // Stops the clock: the escape is complete
+)
+
+// Escape is the timed run from the Boss room to the way out after the Boss
+// falls: the Exit room when the dungeon has one, else back to the Start
+// room. Route tiles collapse one by one from the Boss side,
+// chasing the player; a player moving at Speed along Route reaches every
+// tile before it collapses and the end within TimeLimitMs. Times are whole
+// milliseconds from the escape_start trigger.
+type Escape struct {
+	FromRoom    string         // Boss room the escape starts in
+	ToRoom      string         // Exit room the escape ends in, or the Start room without one
+	TimeLimitMs int            // Time allowed to reach the end of the route
+	Speed       float64        // Movement speed the escape is timed for, in tiles per second
+	WalkMs      int            // Time the route takes at Speed
+	Route       []CollapseTile // Walkable tiles from FromRoom to ToRoom, in collapse order
+	Triggers    []EscapeTrigger
+}
+
+// CollapseTile is a route tile that collapses during the escape.
+type CollapseTile struct {
+	Position Point
+	Order    int // Position in the collapse sequence, 0 first
+	TimeMs   int // Time the tile collapses, after the player at Speed has passed it
+}
+
+// EscapeTrigger is a tile that fires an escape event when a player steps
+// on it.
+type EscapeTrigger struct {
+	ID       string
+	Type     string // TriggerEscapeStart or TriggerEscapeEnd
+	RoomID   string
+	Position Point
+}
+
+// stepLength returns the tiles walked stepping from a to b, counting a
+// diagonal step as √2 tiles.
+func stepLength(a, b Point) float64 {
+	return math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y))
+}
+
+// assignEscape routes the escape over carved floor from the Boss room to
+// the Exit room, or to the Start room without one, and schedules the
+// collapse. The route is the shortest walk
+// between the floor tiles nearest the two rooms' centers; floor under a
+// wall, such as a bombable wall, blocks it. Tile i of a route of length D
+// collapses at TimeLimitMs × (d_i + 1) / (D + 1), where d_i is the walk to
+// it, so each tile falls after a player at Speed has passed it. Leaves
+// c.Escape nil when the rooms are missing or not joined by floor;
+// validation reports it.
+func assignEscape(c *Content, cfg EscapeCfg, tm *carving.TileMap, adg *graph.Graph, g carving.Graph, layout *carving.Layout) {
+	if c == nil || !cfg.Enabled || tm == nil || layout == nil {
+		return
+	}
+	c.Escape = nil
+	floor, ok := tm.Layers["floor"]
+	if !ok {
+		return
+	}
+	var walls []uint32
+	if layer, ok := tm.Layers["walls"]; ok {
+		walls = layer.Data
+	}
+	open := func(x, y int) bool {
+		if x < 0 || x >= tm.Width || y < 0 || y >= tm.Height {
+			return false
+		}
+		i := y*tm.Width + x
+		return floor.Data[i] == uint32(carving.TileFloor) && (walls == nil || walls[i] == uint32(carving.TileEmpty))
+	}
+
+	ids := g.GetRoomIDs()
+	sort.Strings(ids)
+	startID, bossID, exitID := "", "", ""
+	for _, id := range ids {
+		switch adg.Rooms[id].Archetype {
+		case graph.ArchetypeStart:
+			if startID == "" {
+				startID = id
+			}
+		case graph.ArchetypeBoss:
+			if bossID == "" {
+				bossID = id
+			}
+		case graph.ArchetypeExit:
+			if exitID == "" {
+				exitID = id
+			}
+		}
+	}
+	endID := startID
+	if exitID != "" {
+		endID = exitID
+	}
+	from, okFrom := nearestOpen(bossID, g, layout, open)
+	to, okTo := nearestOpen(endID, g, layout, open)
+	if !okFrom || !okTo {
+		return
+	}
+
+	// Breadth-first over open tiles, stepping diagonally like corridors do
+	prev := make(map[Point]Point)
+	seen := map[Point]bool{from: true}
+	queue := []Point{from}
+	for len(queue) > 0 && !seen[to] {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range escapeSteps {
+			next := Point{X: p.X + d.X, Y: p.Y + d.Y}
+			if seen[next] || !open(next.X, next.Y) {
+				continue
+			}
+			seen[next] = true
+			prev[next] = p
+			queue = append(queue, next)
+		}
+	}
+	if !seen[to] {
+		return
+	}
+	var path []Point
+	for p := to; p != from; p = prev[p] {
+		path = append(path, p)
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	escape := &Escape{
+		FromRoom: bossID,
+		ToRoom:   endID,
+		Speed:    cfg.MoveSpeed(),
+		Route:    make([]CollapseTile, len(path)),
+	}
+	walked := make([]float64, len(path))
+	for i := 1; i < len(path); i++ {
+		walked[i] = walked[i-1] + stepLength(path[i-1], path[i])
+	}
+	total := walked[len(path)-1]
+	escape.WalkMs = int(math.Ceil(total / escape.Speed * 1000))
+	escape.TimeLimitMs = cfg.TimeLimitMs
+	if escape.TimeLimitMs == 0 {
+		escape.TimeLimitMs = max(1, int(math.Ceil(float64(escape.WalkMs)*EscapeSlack)))
+	}
+	for i, p := range path {
+		escape.Route[i] = CollapseTile{
+			Position: p,
+			Order:    i,
+			TimeMs:   int(math.Ceil(float64(escape.TimeLimitMs) * (walked[i] + 1) / (total + 1))),
+		}
+	}
+	escape.Triggers = []EscapeTrigger{
+		{ID: "escape_start", Type: TriggerEscapeStart, RoomID: bossID, Position: from},
+		{ID: "escape_end", Type: TriggerEscapeEnd, RoomID: endID, Position: to},
+	}
+	c.Escape = escape
+}
+
+// escapeSteps are the moves along an escape route: orthogonal steps first,
+// so routes keep to rows and columns where they can.
+var escapeSteps = [8]Point{
+	{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0},
+	{X: -1, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 1}, {X: -1, Y: 1},
+}
+
+// nearestOpen returns the open tile in a room's bounds nearest its center,
+// the first in row-major order on ties.
+func nearestOpen(roomID string, g carving.Graph, layout *carving.Layout, open func(x, y int) bool) (Point, bool) {
+	room := g.GetRoom(roomID)
+	pose, ok := layout.Poses[roomID]
+	if room == nil || !ok {
+		return Point{}, false
+	}
+	b := carving.RoomBounds(room.GetSize(), pose)
+	best, bestDist := Point{}, -1
+	for y := b.Y; y < b.Y+b.Height; y++ {
+		for x := b.X; x < b.X+b.Width; x++ {
+			if !open(x, y) {
+				continue
+			}
+			dx, dy := x-pose.X, y-pose.Y
+			if d := dx*dx + dy*dy; bestDist < 0 || d < bestDist {
+				best, bestDist = Point{X: x, Y: y}, d
+			}
+		}
+	}
+	return best, bestDist >= 0
+}
@@ -224,7 +224,7 @@ func TestJSONExportDeterminism(t *testing.T) {
 			Curve:    dungeon.PacingLinear,
 			Variance: 0.1,
 		},
-		Themes: []string{"temple"},
+		Themes: []string{"arcane"},
 	}
 
 	gen := dungeon.NewGenerator()
@@ -0,0 +1,174 @@
+package dungeon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// Pathological embeddings. Healthy force-directed layouts keep every
+// corridor under about 10*sqrt(N) tiles for N rooms and have fewer corridor
+// crossings than a fifth of their corridors, but rare seeds sprawl far
+// beyond that (seed 0x4400f4 reached 51*sqrt(N)). Such layouts are laid out
+// again with a fallback strategy instead of being accepted under ever larger
+// corridor limits; the layout preset's CorridorMaxLength stays as the hard
+// backstop.
+// The other embedders are deterministic and their long corridors are by
+// design, so only their failures fall back.
+const (
+	// pathologicalCorridorScale times sqrt(N) is the longest corridor a
+	// healthy layout of N rooms has.
+	pathologicalCorridorScale = 20.0
+
+	// pathologicalCrossingRatio is the most corridor crossings per corridor
+	// a healthy layout has.
+	pathologicalCrossingRatio = 0.3
+)
+
+// embeddingStrategy is one way to lay out a graph: an embedder, an
+// adjustment to its config and whether its layouts can be pathological.
+type embeddingStrategy struct {
+	name     string
+	embedder string
+	adjust   func(*embedding.Config)
+	screen   bool // Reject pathological layouts
+}
+
+// embeddingStrategies returns the strategies to lay a graph out with, the
+// configured embedder first. Force-directed layouts are retried compacted
+// (stronger springs, weaker repulsion and a smaller initial spread), then
+// with the layered embedder unless the map is shaped, which only the
+// force-directed embedder honours. The ring and layered embedders fall back
+// to the force-directed one. Arena and wave modes keep their own embedder,
+// which their structure needs.
+func embeddingStrategies(cfg *Config, embedderName string) []embeddingStrategy {
+	strategies := []embeddingStrategy{{name: embedderName, embedder: embedderName}}
+	switch embedderName {
+	case "force_directed":
+		strategies[0].screen = true
+		strategies = append(strategies, embeddingStrategy{
+			name:     "force_directed (compact)",
+			embedder: "force_directed",
+			adjust: func(c *embedding.Config) {
+				c.SpringConstant *= 4
+				c.RepulsionConstant /= 4
+				c.InitialSpread *= 0.5
+			},
+			screen: true,
+		})
+		if !cfg.Map.shaped() {
+			strategies = append(strategies, embeddingStrategy{name: "layered", embedder: "layered"})
+		}
+	case "rings", "layered":
+		strategies = append(strategies, embeddingStrategy{name: "force_directed", embedder: "force_directed", screen: true})
+	}
+	return strategies
+}
+
+// embedWithFallback lays adg out with each strategy in turn until one
+// produces a healthy layout, recording the rejected strategies in the
+// layout. Only screened strategies can be pathological. If no layout is
+// healthy, it keeps the first one produced; if every strategy fails, it
+// returns the first strategy's error.
+func embedWithFallback(strategies []embeddingStrategy, embedderCfg embedding.Config, adg *graph.Graph, embeddingRNG *rng.RNG) (*embedding.Layout, error) {
+	var (
+		first    *embedding.Layout
+		firstErr error
+		rejected []string
+	)
+	for _, strategy := range strategies {
+		strategyCfg := embedderCfg
+		if strategy.adjust != nil {
+			strategy.adjust(&strategyCfg)
+		}
+		embedder, err := embedding.Get(strategy.embedder, &strategyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedder: %w", err)
+		}
+
+		layout, err := embedder.Embed(adg, embeddingRNG)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			rejected = append(rejected, fmt.Sprintf("%s: %v", strategy.name, err))
+			continue
+		}
+		reason := ""
+		if strategy.screen {
+			reason = pathology(layout, len(adg.Rooms))
+		}
+		if reason == "" {
+			layout.Rejected = rejected
+			return layout, nil
+		}
+		if first == nil {
+			first = layout
+		}
+		rejected = append(rejected, fmt.Sprintf("%s: %s", strategy.name, reason))
+	}
+
+	if first == nil {
+		return nil, firstErr
+	}
+	first.Rejected = rejected
+	return first, nil
+}
+
+// pathology returns why a layout of a graph with the given number of rooms
+// is pathological, or "" for a healthy layout.
+func pathology(layout *embedding.Layout, rooms int) string {
+	ids := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	limit := pathologicalCorridorScale * math.Sqrt(float64(rooms))
+	for _, id := range ids {
+		if length := layout.CorridorPaths[id].Length(); length > limit {
+			return fmt.Sprintf("corridor %s is %.0f tiles long, over %.0f", id, length, limit)
+		}
+	}
+
+	crossings := 0
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			crossings += pathCrossings(layout.CorridorPaths[a], layout.CorridorPaths[b])
+		}
+	}
+	if float64(crossings) > math.Max(1, pathologicalCrossingRatio*float64(len(ids))) {
+		return fmt.Sprintf("%d corridor crossings for %d corridors", crossings, len(ids))
+	}
+	return ""
+}
+
+// pathCrossings counts the points where two Manhattan paths cross, strictly
+// inside a segment of each.
+func pathCrossings(a, b *embedding.Path) int {
+	crossings := 0
+	for i := 1; i < len(a.Points); i++ {
+		for j := 1; j < len(b.Points); j++ {
+			h1, h2, v1, v2 := a.Points[i-1], a.Points[i], b.Points[j-1], b.Points[j]
+			if h1.Y != h2.Y {
+				h1, h2, v1, v2 = v1, v2, h1, h2
+			}
+			if h1.Y != h2.Y || v1.X != v2.X {
+				continue
+			}
+			if strictlyBetween(v1.X, h1.X, h2.X) && strictlyBetween(h1.Y, v1.Y, v2.Y) {
+				crossings++
+			}
+		}
+	}
+	return crossings
+}
+
+// strictlyBetween reports whether v lies strictly between a and b.
+func strictlyBetween(v, a, b float64) bool {
+	return v > math.Min(a, b) && v < math.Max(a, b)
+}
@@ -0,0 +1,160 @@
+package dungeon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// sprawlEmbedder lays a graph out like the layered embedder, then spreads
+// it 40 times wider, like a pathological force-directed seed.
+type sprawlEmbedder struct {
+	config *embedding.Config
+}
+
+func (e *sprawlEmbedder) Name() string { return "test_sprawl" }
+
+func (e *sprawlEmbedder) Embed(g *graph.Graph, r *rng.RNG) (*embedding.Layout, error) {
+	layout, err := embedding.NewLayeredEmbedder(e.config).Embed(g, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, pose := range layout.Poses {
+		pose.X *= 40
+	}
+	for _, path := range layout.CorridorPaths {
+		for i := range path.Points {
+			path.Points[i].X *= 40
+		}
+	}
+	layout.Algorithm = e.Name()
+	layout.ComputeBounds()
+	return layout, nil
+}
+
+func init() {
+	embedding.Register("test_sprawl", func(config *embedding.Config) embedding.Embedder {
+		return &sprawlEmbedder{config: config}
+	})
+}
+
+// chainGraph returns a graph of n rooms in a line from Start to Boss.
+func chainGraph(t *testing.T, n int) *graph.Graph {
+	t.Helper()
+	g := graph.NewGraph(1)
+	for i := 0; i < n; i++ {
+		archetype := graph.ArchetypeOptional
+		switch i {
+		case 0:
+			archetype = graph.ArchetypeStart
+		case n - 1:
+			archetype = graph.ArchetypeBoss
+		}
+		if err := g.AddRoom(&graph.Room{ID: fmt.Sprintf("r%d", i), Archetype: archetype, Size: graph.SizeM}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < n; i++ {
+		conn := &graph.Connector{ID: fmt.Sprintf("c%d", i), From: fmt.Sprintf("r%d", i-1), To: fmt.Sprintf("r%d", i), Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+// TestEmbedWithFallback verifies pathological layouts are replaced by the
+// next strategy and recorded, and kept when no strategy does better.
+func TestEmbedWithFallback(t *testing.T) {
+	g := chainGraph(t, 6)
+	embedderCfg := *embedding.DefaultConfig()
+	embedderCfg.CorridorMaxLength = 10000
+	sprawl := embeddingStrategy{name: "test_sprawl", embedder: "test_sprawl", screen: true}
+	layered := embeddingStrategy{name: "layered", embedder: "layered"}
+
+	layout, err := embedWithFallback([]embeddingStrategy{sprawl, layered}, embedderCfg, g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("embedWithFallback() error = %v", err)
+	}
+	if layout.Algorithm != "layered" {
+		t.Errorf("kept the %s layout, want the layered fallback", layout.Algorithm)
+	}
+	if len(layout.Rejected) != 1 || !strings.HasPrefix(layout.Rejected[0], "test_sprawl: corridor") {
+		t.Errorf("Rejected = %q, want the sprawling layout's corridor", layout.Rejected)
+	}
+
+	// Without a better strategy the first layout stays
+	layout, err = embedWithFallback([]embeddingStrategy{sprawl}, embedderCfg, g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("embedWithFallback() error = %v", err)
+	}
+	if layout.Algorithm != "test_sprawl" || len(layout.Rejected) != 1 {
+		t.Errorf("got the %s layout rejecting %q, want the sprawling layout kept", layout.Algorithm, layout.Rejected)
+	}
+
+	// Embedder failures fall back too, and the first error is reported
+	// when every strategy fails
+	embedderCfg.CorridorMaxLength = 1
+	if _, err := embedWithFallback([]embeddingStrategy{sprawl, layered}, embedderCfg, g, rng.NewRNG(1, "embedding", nil)); err == nil {
+		t.Error("expected an error when every strategy fails")
+	}
+}
+
+func TestPathology(t *testing.T) {
+	layout := embedding.NewLayout()
+	path := func(points ...embedding.Point) *embedding.Path {
+		return &embedding.Path{Points: points}
+	}
+	layout.CorridorPaths["a"] = path(embedding.Point{X: 0, Y: 5}, embedding.Point{X: 20, Y: 5})
+	layout.CorridorPaths["b"] = path(embedding.Point{X: 10, Y: 0}, embedding.Point{X: 10, Y: 10})
+	if reason := pathology(layout, 25); reason != "" {
+		t.Errorf("one crossing flagged as pathological: %s", reason)
+	}
+
+	// A corridor snaking across both
+	layout.CorridorPaths["c"] = path(embedding.Point{X: 5, Y: -5}, embedding.Point{X: 5, Y: 8}, embedding.Point{X: 15, Y: 8}, embedding.Point{X: 15, Y: -5})
+	if n := pathCrossings(layout.CorridorPaths["a"], layout.CorridorPaths["c"]); n != 2 {
+		t.Errorf("pathCrossings() = %d, want 2", n)
+	}
+	if reason := pathology(layout, 25); !strings.Contains(reason, "4 corridor crossings") {
+		t.Errorf("pathology() = %q, want 4 crossings", reason)
+	}
+
+	layout.CorridorPaths["d"] = path(embedding.Point{X: 0, Y: 0}, embedding.Point{X: 0, Y: 120})
+	if reason := pathology(layout, 25); !strings.HasPrefix(reason, "corridor d is 120 tiles long") {
+		t.Errorf("pathology() = %q, want the long corridor", reason)
+	}
+}
+
+func TestEmbeddingStrategies(t *testing.T) {
+	names := func(strategies []embeddingStrategy) string {
+		var out []string
+		for _, s := range strategies {
+			out = append(out, s.name)
+		}
+		return strings.Join(out, ", ")
+	}
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		embedder string
+		want     string
+	}{
+		{"force", Config{}, "force_directed", "force_directed, force_directed (compact), layered"},
+		{"shaped force", Config{Map: MapCfg{AspectRatio: 2}}, "force_directed", "force_directed, force_directed (compact)"},
+		{"rings", Config{}, "rings", "rings, force_directed"},
+		{"arena", Config{}, "symmetric", "symmetric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(embeddingStrategies(&tt.cfg, tt.embedder)); got != tt.want {
+				t.Errorf("embeddingStrategies() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package dungeon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// IDSchemeVersion is the version of the ID schemes below. It changes only
+// when a scheme does, so save games can store it next to entity IDs and
+// detect IDs that a newer library would no longer produce.
+//
+// Rooms and connectors are named by synthesis from the structure it builds:
+// fixed rooms keep fixed names ("start", "boss"), rooms added by grammar
+// rules are numbered in the order the rules add them ("room_12"), and
+// connectors are named after the rooms they join ("conn_<from>_<to>").
+//
+// Content entities are named by StableEntityID from the seed, the entity's
+// kind, its room, its type and its ordinal among the entities of that kind
+// and type in the room. IDs never depend on positions, on content in other
+// rooms or on the order the content pass placed kinds in, so regenerating
+// a seed keeps the ID of every entity whose room and type are unchanged.
+// Player starts are numbered by player ("player_start_1"), secrets sealing
+// hidden connectors are named after their kind and connector
+// ("secret_destructible_<conn>", "secret_illusory_<conn>"),
+// and arena copies append "_mirror" to the ID of the entity they copy.
+const IDSchemeVersion = 1
+
+// stableIDHexLen is the number of hex digits of the hash in entity IDs.
+const stableIDHexLen = 12
+
+// StableEntityID returns the ID of the ordinal-th (0-based) content entity
+// of a kind ("spawn", "loot", ...) and type in a room of the dungeon
+// generated from seed, e.g. "loot_3fa9c2d1e07b".
+func StableEntityID(seed uint64, kind, roomID, entityType string, ordinal int) string {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seed)
+	h.Write(buf[:])
+	for _, part := range []string{kind, roomID, entityType} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	binary.BigEndian.PutUint64(buf[:], uint64(ordinal))
+	h.Write(buf[:])
+	return kind + "_" + hex.EncodeToString(h.Sum(nil))[:stableIDHexLen]
+}
+
+// stableIDs assigns StableEntityID IDs to entities, numbering entities of
+// the same room and type in their order.
+type stableIDs struct {
+	seed  uint64
+	count map[string]int  // kind, room and type → entities named so far
+	used  map[string]bool // IDs handed out
+}
+
+func newStableIDs(seed uint64) *stableIDs {
+	return &stableIDs{seed: seed, count: make(map[string]int), used: make(map[string]bool)}
+}
+
+// next returns the ID of the next entity of a kind and type in a room.
+func (s *stableIDs) next(kind, roomID, entityType string) string {
+	key := fmt.Sprintf("%s\x00%s\x00%s", kind, roomID, entityType)
+	for {
+		ordinal := s.count[key]
+		s.count[key]++
+		id := StableEntityID(s.seed, kind, roomID, entityType, ordinal)
+		if !s.used[id] { // Skip the ordinal on a hash collision
+			s.used[id] = true
+			return id
+		}
+	}
+}
+
+// assignStableIDs renames the entities of the content pass to their
+// StableEntityID. Player starts keep their per-player IDs.
+func assignStableIDs(c *Content, seed uint64) {
+	if c == nil {
+		return
+	}
+
+	ids := newStableIDs(seed)
+	for i := range c.Spawns {
+		c.Spawns[i].ID = ids.next("spawn", c.Spawns[i].RoomID, c.Spawns[i].EnemyType)
+	}
+	for i := range c.Loot {
+		c.Loot[i].ID = ids.next("loot", c.Loot[i].RoomID, c.Loot[i].ItemType)
+	}
+	for i := range c.Puzzles {
+		c.Puzzles[i].ID = ids.next("puzzle", c.Puzzles[i].RoomID, c.Puzzles[i].Type)
+	}
+	for i := range c.Secrets {
+		c.Secrets[i].ID = ids.next("secret", c.Secrets[i].RoomID, c.Secrets[i].Type)
+	}
+	for i := range c.Traps {
+		c.Traps[i].ID = ids.next("trap", c.Traps[i].RoomID, c.Traps[i].TrapType)
+	}
+	for _, wave := range c.Waves {
+		kind := fmt.Sprintf("wave_%d_spawn", wave.Index)
+		for i := range wave.Spawns {
+			wave.Spawns[i].ID = ids.next(kind, wave.Spawns[i].RoomID, wave.Spawns[i].EnemyType)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package dungeon
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAssignStableIDs verifies entity IDs survive changes to other rooms
+// and to placement order, and differ between seeds.
+func TestAssignStableIDs(t *testing.T) {
+	content := func() *Content {
+		return &Content{
+			Spawns: []Spawn{
+				{RoomID: "room_1", EnemyType: "goblin"},
+				{RoomID: "room_1", EnemyType: "goblin"},
+				{RoomID: "room_2", EnemyType: "skeleton"},
+			},
+			Loot:  []Loot{{RoomID: "room_2", ItemType: "key_silver"}},
+			Traps: []Trap{{RoomID: "room_1", TrapType: "spike_trap"}},
+		}
+	}
+
+	base := content()
+	assignStableIDs(base, 42)
+
+	seen := make(map[string]bool)
+	for _, id := range []string{base.Spawns[0].ID, base.Spawns[1].ID, base.Spawns[2].ID, base.Loot[0].ID, base.Traps[0].ID} {
+		if seen[id] {
+			t.Errorf("duplicate ID %s", id)
+		}
+		seen[id] = true
+	}
+	if !strings.HasPrefix(base.Loot[0].ID, "loot_") || len(base.Loot[0].ID) != len("loot_")+stableIDHexLen {
+		t.Errorf("loot ID = %s, want loot_ and %d hex digits", base.Loot[0].ID, stableIDHexLen)
+	}
+
+	// New content in another room, placed first, leaves the IDs unchanged
+	changed := content()
+	changed.Spawns = append([]Spawn{{RoomID: "room_3", EnemyType: "goblin"}}, changed.Spawns...)
+	changed.Loot = append(changed.Loot, Loot{RoomID: "room_1", ItemType: "gold"})
+	assignStableIDs(changed, 42)
+	for i, spawn := range base.Spawns {
+		if changed.Spawns[i+1].ID != spawn.ID {
+			t.Errorf("spawn %d: ID changed from %s to %s", i, spawn.ID, changed.Spawns[i+1].ID)
+		}
+	}
+	if changed.Loot[0].ID != base.Loot[0].ID || changed.Traps[0].ID != base.Traps[0].ID {
+		t.Error("loot or trap ID changed with content in other rooms")
+	}
+
+	other := content()
+	assignStableIDs(other, 43)
+	if other.Loot[0].ID == base.Loot[0].ID {
+		t.Error("IDs should differ between seeds")
+	}
+}
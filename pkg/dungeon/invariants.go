@@ -0,0 +1,179 @@
+package dungeon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// CheckInvariants verifies the pipeline invariants every generated artifact
+// must satisfy, independent of the synthesizer, carver or content pass used:
+//   - Connectivity: every room is reachable ignoring edge direction
+//   - Key-before-lock: starting from the Start room and collecting keys along
+//     the way, every key-locked room and gate can eventually be opened
+//   - No overlaps: carved room rectangles do not intersect
+//
+// Returns nil if all invariants hold, or an error describing each violation.
+func CheckInvariants(a *Artifact) error {
+	if a == nil || a.ADG == nil || a.ADG.Graph == nil {
+		return errors.New("artifact has no graph")
+	}
+
+	var errs []error
+	if !a.ADG.IsWeaklyConnected() {
+		errs = append(errs, errors.New("connectivity: graph is disconnected"))
+	}
+	if err := checkKeyBeforeLock(a.ADG.Graph); err != nil {
+		errs = append(errs, fmt.Errorf("key-before-lock: %w", err))
+	}
+	if err := checkNoRoomOverlaps(a.ADG.Graph, a.Layout); err != nil {
+		errs = append(errs, fmt.Errorf("no-overlaps: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// checkKeyBeforeLock simulates a player starting in the Start room who
+// collects every key in each room they can enter. Rooms requiring a key and
+// connectors gated by a key open once that key is held. Any key-locked room
+// or gate left unopened once no further progress is possible is a violation.
+func checkKeyBeforeLock(g *graph.Graph) error {
+	start := ""
+	for _, id := range sortedGraphRoomIDs(g) {
+		if g.Rooms[id].Archetype == graph.ArchetypeStart {
+			start = id
+			break
+		}
+	}
+	if start == "" {
+		return errors.New("no start room")
+	}
+
+	// Undirected edges, matching the connectivity invariant
+	type edge struct {
+		to   string
+		gate *graph.Gate
+	}
+	edges := make(map[string][]edge)
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		c := g.Connectors[id]
+		edges[c.From] = append(edges[c.From], edge{c.To, c.Gate})
+		edges[c.To] = append(edges[c.To], edge{c.From, c.Gate})
+	}
+
+	keys := make(map[string]bool)
+	hasKey := func(capType, value string) bool { return capType != "key" || keys[value] }
+	canEnter := func(id string) bool {
+		for _, req := range g.Rooms[id].Requirements {
+			if req.Type == "key" && !keys[req.Value] {
+				return false
+			}
+		}
+		return true
+	}
+
+	visited := map[string]bool{start: true}
+	for progress := true; progress; {
+		progress = false
+		for _, id := range sortedGraphRoomIDs(g) {
+			if !visited[id] {
+				continue
+			}
+			for _, p := range g.Rooms[id].Provides {
+				if p.Type == "key" && !keys[p.Value] {
+					keys[p.Value] = true
+					progress = true
+				}
+			}
+			for _, e := range edges[id] {
+				if visited[e.to] || !canEnter(e.to) {
+					continue
+				}
+				if e.gate != nil && !e.gate.Opens(hasKey) {
+					continue
+				}
+				visited[e.to] = true
+				progress = true
+			}
+		}
+	}
+
+	var locked []string
+	for _, id := range sortedGraphRoomIDs(g) {
+		for _, req := range g.Rooms[id].Requirements {
+			if req.Type == "key" && !keys[req.Value] {
+				locked = append(locked, fmt.Sprintf("room %s needs %s", id, req.Value))
+			}
+		}
+	}
+	for _, id := range connIDs {
+		if gate := g.Connectors[id].Gate; gate != nil && !gate.Opens(hasKey) {
+			locked = append(locked, fmt.Sprintf("connector %s needs %s", id, gate))
+		}
+	}
+	if len(locked) > 0 {
+		return fmt.Errorf("keys never obtainable: %v", locked)
+	}
+	return nil
+}
+
+// checkNoRoomOverlaps reports pairs of rooms whose carved rectangles intersect.
+func checkNoRoomOverlaps(g *graph.Graph, layout *Layout) error {
+	if layout == nil {
+		return nil
+	}
+
+	ids := sortedGraphRoomIDs(g)
+	bounds := make(map[string]carving.Rect, len(ids))
+	for _, id := range ids {
+		pose, ok := layout.Poses[id]
+		if !ok {
+			continue
+		}
+		bounds[id] = carving.RoomBounds(carving.RoomSize(g.Rooms[id].Size), carving.Pose{
+			X:           pose.X,
+			Y:           pose.Y,
+			Rotation:    pose.Rotation,
+			FootprintID: pose.FootprintID,
+		})
+	}
+
+	var overlaps []string
+	for i, a := range ids {
+		ra, ok := bounds[a]
+		if !ok {
+			continue
+		}
+		for _, b := range ids[i+1:] {
+			rb, ok := bounds[b]
+			if !ok {
+				continue
+			}
+			if ra.X < rb.X+rb.Width && rb.X < ra.X+ra.Width &&
+				ra.Y < rb.Y+rb.Height && rb.Y < ra.Y+ra.Height {
+				overlaps = append(overlaps, a+"/"+b)
+			}
+		}
+	}
+	if len(overlaps) > 0 {
+		return fmt.Errorf("%d overlapping room pairs: %v", len(overlaps), overlaps)
+	}
+	return nil
+}
+
+// sortedGraphRoomIDs returns the graph's room IDs in sorted order.
+func sortedGraphRoomIDs(g *graph.Graph) []string {
+	ids := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,83 @@
+package dungeon_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestCheckInvariants_KeyBehindItsOwnLock verifies that a key only
+// obtainable behind its own lock is reported as a violation.
+func TestCheckInvariants_KeyBehindItsOwnLock(t *testing.T) {
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "vault", Archetype: graph.ArchetypeTreasure, Size: graph.SizeM,
+			Provides:     []graph.Capability{{Type: "key", Value: "gold"}},
+			Requirements: []graph.Requirement{{Type: "key", Value: "gold"}}},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeM},
+	}
+	for _, r := range rooms {
+		if err := g.AddRoom(r); err != nil {
+			t.Fatalf("AddRoom: %v", err)
+		}
+	}
+	for _, c := range []*graph.Connector{
+		{ID: "c1", From: "start", To: "vault", Type: graph.TypeDoor, Cost: 1, Visibility: graph.VisibilityNormal, Bidirectional: true},
+		{ID: "c2", From: "start", To: "boss", Type: graph.TypeDoor, Cost: 1, Visibility: graph.VisibilityNormal, Bidirectional: true},
+	} {
+		if err := g.AddConnector(c); err != nil {
+			t.Fatalf("AddConnector: %v", err)
+		}
+	}
+
+	artifact := &dungeon.Artifact{ADG: &dungeon.Graph{Graph: g}}
+	err := dungeon.CheckInvariants(artifact)
+	if err == nil || !strings.Contains(err.Error(), "key-before-lock") {
+		t.Fatalf("expected key-before-lock violation, got %v", err)
+	}
+
+	// Moving the key outside the locked room satisfies the invariant
+	g.Rooms["vault"].Provides = nil
+	g.Rooms["start"].Provides = []graph.Capability{{Type: "key", Value: "gold"}}
+	if err := dungeon.CheckInvariants(artifact); err != nil {
+		t.Fatalf("unexpected violation: %v", err)
+	}
+}
+
+// TestGenerate_SecretFindabilityDeterministic is a regression test for a
+// config FuzzGenerate found whose secret findability differed in the last
+// bits between runs, from summing per-room scores in map order.
+func TestGenerate_SecretFindabilityDeterministic(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          2,
+		Size:          dungeon.SizeCfg{RoomsMin: 33, RoomsMax: 33},
+		Branching:     dungeon.BranchingCfg{Avg: 1.5, Max: 2},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.3},
+		Themes:        []string{"dungeon", "crypt"},
+		SecretDensity: 0.2520559204276651,
+		OptionalRatio: 0.22359349039302814,
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	var want float64
+	for i := 0; i < 5; i++ {
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		got := artifact.Metrics.SecretFindability
+		if i == 0 {
+			want = got
+		} else if got != want {
+			t.Fatalf("run %d: SecretFindability = %v, want %v", i, got, want)
+		}
+	}
+	if want >= 1.0 {
+		t.Errorf("SecretFindability = %v, want a dungeon with secrets behind the visible map", want)
+	}
+}
@@ -0,0 +1,438 @@
+package dungeon
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ledger rotation defaults.
+const (
+	// DefaultLedgerMaxBytes is the size at which a ledger file is rotated.
+	DefaultLedgerMaxBytes int64 = 64 << 20
+
+	// DefaultLedgerFiles is the number of rotated ledger files kept besides
+	// the current one.
+	DefaultLedgerFiles = 5
+)
+
+// LedgerOutcome classifies how a generation request ended.
+type LedgerOutcome string
+
+const (
+	LedgerOK               LedgerOutcome = "ok"
+	LedgerInvalidConfig    LedgerOutcome = "invalid_config"
+	LedgerCancelled        LedgerOutcome = "cancelled"
+	LedgerTimeout          LedgerOutcome = "timeout"
+	LedgerMemoryBudget     LedgerOutcome = "memory_budget"
+	LedgerQualityGate      LedgerOutcome = "quality_gate"
+	LedgerConstraints      LedgerOutcome = "constraints_unsatisfied"
+	LedgerRetriesExhausted LedgerOutcome = "retries_exhausted"
+	LedgerError            LedgerOutcome = "error"
+)
+
+// LedgerEntry records one generation request and its result, as one line
+// of a ledger file.
+type LedgerEntry struct {
+	Time         time.Time     `json:"time"`
+	Seed         uint64        `json:"seed"`
+	AcceptedSeed uint64        `json:"acceptedSeed,omitempty"` // Seed quality gates accepted, when it differs from Seed
+	ConfigHash   string        `json:"configHash"`             // Hash of the config without its seed, shared by every seed of one config
+	Outcome      LedgerOutcome `json:"outcome"`
+	Stage        string        `json:"stage,omitempty"` // Pipeline stage that failed, when known
+	Error        string        `json:"error,omitempty"`
+	DurationMS   float64       `json:"durationMs"`
+	Rooms        int           `json:"rooms,omitempty"`
+	Metrics      *Metrics      `json:"metrics,omitempty"` // Without the per-room difficulty profile, to keep lines short
+}
+
+// Failed reports whether the request did not produce a dungeon.
+func (e LedgerEntry) Failed() bool {
+	return e.Outcome != LedgerOK
+}
+
+// LedgerOptions configures ledger file rotation.
+type LedgerOptions struct {
+	// MaxBytes rotates the ledger file before an entry would grow it past
+	// this size (0 = DefaultLedgerMaxBytes).
+	MaxBytes int64
+
+	// MaxFiles is the number of rotated files kept, named path.1 (newest)
+	// to path.N (0 = DefaultLedgerFiles). Older files are deleted.
+	MaxFiles int
+}
+
+// Ledger is an append-only JSONL log of generation requests, for live-ops
+// monitoring of servers running the generator. It is safe for concurrent
+// use. Each entry is written with a single append, so entries from
+// goroutines sharing a Ledger never interleave.
+type Ledger struct {
+	path string
+	opts LedgerOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	err  error // First error recording an entry
+}
+
+// OpenLedger opens the ledger file at path for appending, creating it if
+// needed.
+func OpenLedger(path string, opts LedgerOptions) (*Ledger, error) {
+	if opts.MaxBytes < 0 {
+		return nil, fmt.Errorf("ledger max bytes must be non-negative, got %d", opts.MaxBytes)
+	}
+	if opts.MaxFiles < 0 {
+		return nil, fmt.Errorf("ledger max files must be non-negative, got %d", opts.MaxFiles)
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultLedgerMaxBytes
+	}
+	if opts.MaxFiles == 0 {
+		opts.MaxFiles = DefaultLedgerFiles
+	}
+
+	l := &Ledger{path: path, opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open opens the current ledger file and reads its size.
+func (l *Ledger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, DefaultFileMode)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Record appends an entry to the ledger, rotating the file first if the
+// entry would take it past MaxBytes. The first error is also kept for Err.
+func (l *Ledger) Record(entry LedgerEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("ledger %s is closed", l.path)
+	}
+	err = l.write(data)
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+	return err
+}
+
+// write appends data, rotating first when needed. l.mu must be held.
+func (l *Ledger) write(data []byte) error {
+	if l.size > 0 && l.size+int64(len(data)) > l.opts.MaxBytes {
+		if err := l.rotate(); err != nil {
+			err = fmt.Errorf("rotating ledger: %w", err)
+			if l.file == nil {
+				return err
+			}
+			// Keep the entry; the rotation is retried with the next one
+			if l.err == nil {
+				l.err = err
+			}
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return err
+}
+
+// rotate shifts path.i to path.i+1, dropping the oldest, moves the current
+// file to path.1 and starts a new one. When rotation fails the ledger keeps
+// appending to whichever file is at path. l.mu must be held.
+func (l *Ledger) rotate() error {
+	err := errors.Join(l.file.Close(), l.shift())
+	if oerr := l.open(); oerr != nil {
+		l.file = nil
+		return errors.Join(err, oerr)
+	}
+	return err
+}
+
+// shift renames the ledger files one place down for rotate.
+func (l *Ledger) shift() error {
+	if err := os.Remove(rotatedLedger(l.path, l.opts.MaxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := l.opts.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedLedger(l.path, i), rotatedLedger(l.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(l.path, rotatedLedger(l.path, 1))
+}
+
+// rotatedLedger returns the name of the i-th rotated ledger file.
+func rotatedLedger(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Err returns the first error recording an entry, for callers recording
+// through LedgerGenerator, which does not fail generation over the ledger.
+func (l *Ledger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close closes the ledger file.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// LedgerGenerator returns a Generator that records every request to gen in
+// ledger: its seed, config hash, outcome, duration and, for dungeons it
+// produced, their metrics. Generation results are returned unchanged; a
+// failure to record is reported by ledger.Err rather than failing the
+// request.
+func LedgerGenerator(gen Generator, ledger *Ledger) Generator {
+	return &ledgerGenerator{gen: gen, ledger: ledger}
+}
+
+type ledgerGenerator struct {
+	gen    Generator
+	ledger *Ledger
+}
+
+// Generate generates through the wrapped generator and records the request.
+func (g *ledgerGenerator) Generate(ctx context.Context, cfg *Config) (*Artifact, error) {
+	start := time.Now()
+	artifact, err := g.gen.Generate(ctx, cfg)
+	_ = g.ledger.Record(NewLedgerEntry(cfg, artifact, err, start, time.Since(start)))
+	return artifact, err
+}
+
+// NewLedgerEntry builds the ledger entry of a generation request for cfg
+// that started at start, took elapsed and returned artifact and err.
+func NewLedgerEntry(cfg *Config, artifact *Artifact, err error, start time.Time, elapsed time.Duration) LedgerEntry {
+	entry := LedgerEntry{
+		Time:       start.UTC(),
+		Outcome:    ledgerOutcome(err),
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+	}
+	if cfg != nil {
+		entry.Seed = cfg.Seed
+		entry.ConfigHash = ledgerConfigHash(cfg)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		var se *StageError
+		if errors.As(err, &se) {
+			entry.Stage = se.Stage
+		}
+		return entry
+	}
+	if artifact != nil {
+		if artifact.Metrics != nil {
+			metrics := *artifact.Metrics
+			metrics.Difficulty = nil
+			entry.Metrics = &metrics
+		}
+		if artifact.ADG != nil && artifact.ADG.Graph != nil {
+			entry.Rooms = len(artifact.ADG.Rooms)
+		}
+		if artifact.Debug != nil && artifact.Debug.Quality != nil && artifact.Debug.Quality.Seed != entry.Seed {
+			entry.AcceptedSeed = artifact.Debug.Quality.Seed
+		}
+	}
+	return entry
+}
+
+// ledgerConfigHash hashes cfg without its seed, so the requests for every
+// seed of a config are summarized together.
+func ledgerConfigHash(cfg *Config) string {
+	unseeded := *cfg
+	unseeded.Seed = 0
+	return hex.EncodeToString(unseeded.Hash())
+}
+
+// ledgerOutcome classifies a generation error by the generator's sentinel
+// errors.
+func ledgerOutcome(err error) LedgerOutcome {
+	switch {
+	case err == nil:
+		return LedgerOK
+	case errors.Is(err, ErrInvalidConfig):
+		return LedgerInvalidConfig
+	case errors.Is(err, ErrTimeout):
+		return LedgerTimeout
+	case errors.Is(err, ErrCancelled):
+		return LedgerCancelled
+	case errors.Is(err, ErrMemoryBudget):
+		return LedgerMemoryBudget
+	case errors.Is(err, ErrQualityGate):
+		return LedgerQualityGate
+	case errors.Is(err, ErrConstraintUnsatisfied):
+		return LedgerConstraints
+	case errors.Is(err, ErrRetryExhausted):
+		return LedgerRetriesExhausted
+	}
+	return LedgerError
+}
+
+// ReadLedger parses the entries of a ledger file.
+func ReadLedger(r io.Reader) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("ledger line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// LoadLedger reads the ledger at path together with its rotated files,
+// oldest entries first. Missing rotated files are skipped.
+func LoadLedger(path string) ([]LedgerEntry, error) {
+	var rotated []string
+	for i := 1; ; i++ {
+		name := rotatedLedger(path, i)
+		if _, err := os.Stat(name); err != nil {
+			break
+		}
+		rotated = append(rotated, name)
+	}
+
+	var entries []LedgerEntry
+	for i := len(rotated) - 1; i >= -1; i-- {
+		name := path
+		if i >= 0 {
+			name = rotated[i]
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		read, err := ReadLedger(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, read...)
+	}
+	return entries, nil
+}
+
+// LedgerStats counts the requests of a group of ledger entries.
+type LedgerStats struct {
+	Requests     int                   `json:"requests"`
+	Failures     int                   `json:"failures"`
+	FailureRate  float64               `json:"failureRate"` // Failures / Requests
+	Outcomes     map[LedgerOutcome]int `json:"outcomes"`
+	MeanDuration float64               `json:"meanDurationMs"`
+	MaxDuration  float64               `json:"maxDurationMs"`
+}
+
+// add counts one entry.
+func (s *LedgerStats) add(entry LedgerEntry) {
+	if s.Outcomes == nil {
+		s.Outcomes = make(map[LedgerOutcome]int)
+	}
+	s.Requests++
+	if entry.Failed() {
+		s.Failures++
+	}
+	s.Outcomes[entry.Outcome]++
+	s.MeanDuration += (entry.DurationMS - s.MeanDuration) / float64(s.Requests)
+	if entry.DurationMS > s.MaxDuration {
+		s.MaxDuration = entry.DurationMS
+	}
+	s.FailureRate = float64(s.Failures) / float64(s.Requests)
+}
+
+// LedgerConfigStats counts the requests for one config.
+type LedgerConfigStats struct {
+	ConfigHash string `json:"configHash"`
+	LedgerStats
+}
+
+// LedgerSummary summarizes a ledger's failure rates overall and by config.
+type LedgerSummary struct {
+	LedgerStats
+	From    time.Time           `json:"from"`    // Time of the earliest entry
+	To      time.Time           `json:"to"`      // Time of the latest entry
+	Configs []LedgerConfigStats `json:"configs"` // Highest failure rate first
+}
+
+// SummarizeLedger summarizes ledger entries by config hash. Configs are
+// sorted by failure rate, then by number of requests, both descending, so
+// the configs most in need of attention come first.
+func SummarizeLedger(entries []LedgerEntry) LedgerSummary {
+	var summary LedgerSummary
+	byConfig := make(map[string]*LedgerConfigStats)
+	for _, entry := range entries {
+		summary.add(entry)
+		if summary.From.IsZero() || entry.Time.Before(summary.From) {
+			summary.From = entry.Time
+		}
+		if entry.Time.After(summary.To) {
+			summary.To = entry.Time
+		}
+
+		stats, ok := byConfig[entry.ConfigHash]
+		if !ok {
+			stats = &LedgerConfigStats{ConfigHash: entry.ConfigHash}
+			byConfig[entry.ConfigHash] = stats
+		}
+		stats.add(entry)
+	}
+
+	summary.Configs = make([]LedgerConfigStats, 0, len(byConfig))
+	for _, stats := range byConfig {
+		summary.Configs = append(summary.Configs, *stats)
+	}
+	sort.Slice(summary.Configs, func(i, j int) bool {
+		a, b := summary.Configs[i], summary.Configs[j]
+		if a.FailureRate != b.FailureRate {
+			return a.FailureRate > b.FailureRate
+		}
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.ConfigHash < b.ConfigHash
+	})
+	return summary
+}
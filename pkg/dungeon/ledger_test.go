@@ -0,0 +1,134 @@
+package dungeon_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestLedger verifies the ledger generator records successes and failures,
+// rotates its file, and that the summary groups every seed of a config.
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ledger, err := dungeon.OpenLedger(path, dungeon.LedgerOptions{MaxBytes: 1024, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("OpenLedger failed: %v", err)
+	}
+	gen := dungeon.LedgerGenerator(dungeon.NewGeneratorWithValidator(validation.NewValidator()), ledger)
+
+	cfg := &dungeon.Config{
+		Seed:          500,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 15},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		OptionalRatio: 0.2,
+	}
+	for seed := uint64(500); seed < 503; seed++ {
+		cfg.Seed = seed
+		if _, err := gen.Generate(context.Background(), cfg); err != nil {
+			t.Fatalf("seed %d: Generate failed: %v", seed, err)
+		}
+	}
+	invalid := *cfg
+	invalid.Size.RoomsMin = 0
+	if _, err := gen.Generate(context.Background(), &invalid); !errors.Is(err, dungeon.ErrInvalidConfig) {
+		t.Fatalf("Generate() error = %v, want ErrInvalidConfig", err)
+	}
+	if err := ledger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := ledger.Err(); err != nil {
+		t.Fatalf("ledger recorded an error: %v", err)
+	}
+
+	// Entries carry metrics, so four of them rotate a 1 KiB ledger
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("ledger was not rotated: %v", err)
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("ledger kept more rotated files than MaxFiles")
+	}
+
+	entries, err := dungeon.LoadLedger(path)
+	if err != nil {
+		t.Fatalf("LoadLedger failed: %v", err)
+	}
+	if len(entries) == 0 || len(entries) > 4 {
+		t.Fatalf("loaded %d entries, want 1-4", len(entries))
+	}
+	last := entries[len(entries)-1]
+	if last.Outcome != dungeon.LedgerInvalidConfig || last.Error == "" || last.Metrics != nil {
+		t.Errorf("last entry = %+v, want an invalid_config failure", last)
+	}
+	for i, entry := range entries[:len(entries)-1] {
+		if entry.Outcome != dungeon.LedgerOK || entry.Metrics == nil || entry.Rooms == 0 {
+			t.Errorf("entry %d = %+v, want a success with metrics", i, entry)
+		}
+		if i > 0 && entry.Seed != entries[i-1].Seed+1 {
+			t.Errorf("entry %d has seed %d, want entries oldest first", i, entry.Seed)
+		}
+	}
+
+	summary := dungeon.SummarizeLedger(entries)
+	if summary.Requests != len(entries) || summary.Failures != 1 {
+		t.Errorf("summary counts %d requests and %d failures, want %d and 1", summary.Requests, summary.Failures, len(entries))
+	}
+	if len(summary.Configs) != 2 {
+		t.Fatalf("summary has %d configs, want the seeds of each config grouped into 2", len(summary.Configs))
+	}
+	if worst := summary.Configs[0]; worst.FailureRate != 1 || worst.Outcomes[dungeon.LedgerInvalidConfig] != 1 {
+		t.Errorf("first config = %+v, want the failing config first", worst)
+	}
+	if summary.Configs[1].Requests != len(entries)-1 || summary.Configs[1].FailureRate != 0 {
+		t.Errorf("second config = %+v, want every successful seed", summary.Configs[1])
+	}
+}
+
+// TestNewLedgerEntry verifies failures are classified by the generator's
+// sentinel errors and attributed to their stage.
+func TestNewLedgerEntry(t *testing.T) {
+	cfg := &dungeon.Config{Seed: 7}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stageErr := &dungeon.StageError{Stage: "embedding", Attempts: 3, Err: errors.New("no layout")}
+
+	tests := []struct {
+		err  error
+		want dungeon.LedgerOutcome
+	}{
+		{nil, dungeon.LedgerOK},
+		{stageErr, dungeon.LedgerRetriesExhausted},
+		{dungeon.ErrTimeout, dungeon.LedgerTimeout},
+		{context.Canceled, dungeon.LedgerError},
+		{errors.New("boom"), dungeon.LedgerError},
+	}
+	for _, tt := range tests {
+		entry := dungeon.NewLedgerEntry(cfg, nil, tt.err, start, 1500*time.Microsecond)
+		if entry.Outcome != tt.want {
+			t.Errorf("outcome of %v = %q, want %q", tt.err, entry.Outcome, tt.want)
+		}
+		if entry.Seed != 7 || entry.DurationMS != 1.5 || !entry.Time.Equal(start) {
+			t.Errorf("entry = %+v, want seed 7, 1.5ms at %v", entry, start)
+		}
+	}
+	if entry := dungeon.NewLedgerEntry(cfg, nil, stageErr, start, 0); entry.Stage != "embedding" {
+		t.Errorf("stage = %q, want embedding", entry.Stage)
+	}
+
+	other := *cfg
+	other.Seed = 8
+	if a, b := dungeon.NewLedgerEntry(cfg, nil, nil, start, 0), dungeon.NewLedgerEntry(&other, nil, nil, start, 0); a.ConfigHash != b.ConfigHash {
+		t.Error("config hash depends on the seed")
+	}
+
+	if _, err := dungeon.ReadLedger(strings.NewReader("{\"seed\":1}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadLedger() error = %v, want a line 2 parse error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package dungeon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PipelineStages lists the pipeline stages in the order they run. They name
+// the stages of StageError, LimitsCfg.Stages and Config.StopAfter.
+var PipelineStages = []string{"synthesis", "embedding", "carving", "content", "validation"}
+
+// MaxSynthesisAttempts bounds LimitsCfg.MaxSynthesisAttempts.
+const MaxSynthesisAttempts = 100
+
+// LimitsCfg bounds the work a generation may do, so services can cap its
+// worst-case latency and memory. Deadlines are wall-clock and enforced with context
+// deadlines: a stage that overruns fails with a StageError matching
+// ErrTimeout. Stages that do not watch their context are checked when they
+// return. Limits never change a dungeon that generates within them, so they
+// are left out of the config hash.
+// Zero values, and a nil *LimitsCfg, leave generation unbounded.
+type LimitsCfg struct {
+	// Timeout bounds the whole generation, e.g. "2s".
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Stages bounds individual stages by name (see PipelineStages).
+	Stages map[string]time.Duration `yaml:"stages,omitempty" json:"stages,omitempty"`
+
+	// MaxSynthesisAttempts caps the graphs the synthesizer tries before
+	// giving up with ErrRetryExhausted (0 = the synthesizer's default, 1 to
+	// MaxSynthesisAttempts).
+	MaxSynthesisAttempts int `yaml:"maxSynthesisAttempts,omitempty" json:"maxSynthesisAttempts,omitempty"`
+
+	// MaxMemoryMB refuses configs whose estimated peak memory (see
+	// EstimateMemory) exceeds this many MiB, before any work is done, with
+	// a MemoryError suggesting a room count that fits. 0 = no budget.
+	MaxMemoryMB int `yaml:"maxMemoryMB,omitempty" json:"maxMemoryMB,omitempty"`
+}
+
+// Validate checks LimitsCfg constraints.
+func (l *LimitsCfg) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got %s", l.Timeout)
+	}
+
+	stages := make([]string, 0, len(l.Stages))
+	for stage := range l.Stages {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	for _, stage := range stages {
+		if !isPipelineStage(stage) {
+			return fmt.Errorf("stages: unknown stage %q, must be one of: %s", stage, strings.Join(PipelineStages, ", "))
+		}
+		if d := l.Stages[stage]; d <= 0 {
+			return fmt.Errorf("stages.%s must be positive, got %s", stage, d)
+		}
+	}
+
+	if l.MaxSynthesisAttempts < 0 || l.MaxSynthesisAttempts > MaxSynthesisAttempts {
+		return fmt.Errorf("maxSynthesisAttempts must be in range [0, %d], got %d", MaxSynthesisAttempts, l.MaxSynthesisAttempts)
+	}
+	if l.MaxMemoryMB < 0 {
+		return fmt.Errorf("maxMemoryMB must not be negative, got %d", l.MaxMemoryMB)
+	}
+	return nil
+}
+
+// maxSynthesisAttempts returns the synthesis attempt cap, 0 for the
+// synthesizer's default.
+func (l *LimitsCfg) maxSynthesisAttempts() int {
+	if l == nil {
+		return 0
+	}
+	return l.MaxSynthesisAttempts
+}
+
+// isPipelineStage reports whether name is one of PipelineStages.
+func isPipelineStage(name string) bool {
+	for _, stage := range PipelineStages {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// generationContext returns ctx bounded by the generation timeout.
+func (l *LimitsCfg) generationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l != nil && l.Timeout > 0 {
+		return context.WithTimeout(ctx, l.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stageContext returns ctx bounded by the deadline of stage.
+func (l *LimitsCfg) stageContext(ctx context.Context, stage string) (context.Context, context.CancelFunc) {
+	if l == nil {
+		return context.WithCancel(ctx)
+	}
+	if d := l.Stages[stage]; d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stageExpired returns the timeout error of a stage whose context passed its
+// deadline, and err otherwise. A stage that ran out of time fails with the
+// timeout even when it finished, so limits hold for stages that do not
+// watch their context.
+func stageExpired(ctx context.Context, stage string, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &StageError{Stage: stage, Err: fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded)}
+}
+
+// runStage runs one pipeline stage under its deadline in cfg.Limits. It
+// does not start the stage once ctx is done.
+func runStage[T any](ctx context.Context, cfg *Config, stage string, run func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if ctx.Err() != nil {
+		return zero, stageExpired(ctx, stage, cancelled(ctx))
+	}
+
+	stageCtx, cancel := cfg.Limits.stageContext(ctx, stage)
+	defer cancel()
+
+	result, err := run(stageCtx)
+	if err = stageExpired(stageCtx, stage, err); err != nil {
+		return zero, err
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package dungeon
+
+import (
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/synthesis"
+)
+
+// Constants of the memory model, measured on generated dungeons. They err
+// high so an estimate within budget is safe to generate.
+const (
+	// mapSpread is the map area per tile of room floor: force-directed
+	// layouts of 40-100 rooms leave up to 20 tiles of map for every floor
+	// tile of a room, larger ones fewer.
+	mapSpread = 24
+
+	// tileBytes is the size of one tile in one tile layer.
+	tileBytes = 4
+
+	// baseTileLayers counts the tile layers every carve creates: floor,
+	// walls, elevation, collision, biome and decor.
+	baseTileLayers = 6
+
+	// environmentTileLayers counts the hazards, terrain and environment
+	// layers added when the environment carries difficulty.
+	environmentTileLayers = 3
+
+	// roomBytes is the graph, layout and content memory per room.
+	roomBytes = 4 << 10
+
+	// workingCopies is the peak tile map memory during carving, as a
+	// multiple of the finished map, for the scratch grids carving keeps.
+	workingCopies = 2
+)
+
+// MemoryEstimate predicts the memory a config's dungeon takes, for the
+// largest dungeon the config allows.
+type MemoryEstimate struct {
+	Rooms         int   // Rooms at size.roomsMax
+	FloorTiles    int   // Floor tiles of the rooms
+	MapTiles      int   // Tiles of the map, within map.maxWidth x map.maxHeight
+	TileMapBytes  int64 // Tile layers of the finished map
+	ArtifactBytes int64 // The whole artifact: tile map, graph, layout and content
+	PeakBytes     int64 // Peak during generation, including carving scratch
+}
+
+// EstimateMemory predicts the memory a config's dungeon takes before it is
+// generated, from the room count, the room size distribution (with party
+// scaling and the floor budget) and the map limits.
+func EstimateMemory(cfg *Config) MemoryEstimate {
+	rooms := cfg.Size.RoomsMax
+
+	weights := cfg.Rooms.Weights()
+	if weights == nil {
+		weights = synthesis.DefaultSizeWeights()
+	}
+	perRoom := meanFloorTiles(weights, cfg.Party.Size/2)
+	floor := int(perRoom * float64(rooms))
+	if budget := cfg.Rooms.FloorBudget; budget > 0 && budget < floor {
+		floor = budget
+	}
+
+	mapTiles := floor * mapSpread
+	if cfg.Map.MaxWidth > 0 && cfg.Map.MaxHeight > 0 && cfg.Map.MaxWidth*cfg.Map.MaxHeight < mapTiles {
+		mapTiles = cfg.Map.MaxWidth * cfg.Map.MaxHeight
+	}
+
+	layers := baseTileLayers
+	if cfg.Content.EnvironmentRatio > 0 {
+		layers += environmentTileLayers
+	}
+	tileMap := int64(mapTiles) * int64(layers) * tileBytes
+	graphBytes := int64(rooms) * roomBytes
+
+	return MemoryEstimate{
+		Rooms:         rooms,
+		FloorTiles:    floor,
+		MapTiles:      mapTiles,
+		TileMapBytes:  tileMap,
+		ArtifactBytes: tileMap + graphBytes,
+		PeakBytes:     tileMap*workingCopies + graphBytes,
+	}
+}
+
+// meanFloorTiles returns the mean floor tiles of a room drawn from size
+// weights indexed by graph.RoomSize, each size grown by steps for parties.
+func meanFloorTiles(weights []float64, steps int) float64 {
+	total, tiles := 0.0, 0.0
+	for size, w := range weights {
+		grown := graph.RoomSize(size + steps)
+		if grown > graph.SizeXL {
+			grown = graph.SizeXL
+		}
+		total += w
+		tiles += w * float64(synthesis.RoomFloorTiles(grown))
+	}
+	if total == 0 {
+		return 0
+	}
+	return tiles / total
+}
+
+// checkMemory refuses configs whose estimated peak memory exceeds their
+// budget, suggesting the largest room count that fits.
+func checkMemory(cfg *Config) error {
+	if cfg.Limits == nil || cfg.Limits.MaxMemoryMB == 0 {
+		return nil
+	}
+	budget := int64(cfg.Limits.MaxMemoryMB) << 20
+	estimate := EstimateMemory(cfg)
+	if estimate.PeakBytes <= budget {
+		return nil
+	}
+
+	err := &MemoryError{Estimate: estimate, Budget: budget}
+	smaller := *cfg
+	for rooms := cfg.Size.RoomsMax - 1; rooms >= synthesis.MinRooms; rooms-- {
+		smaller.Size.RoomsMax = rooms
+		if EstimateMemory(&smaller).PeakBytes <= budget {
+			err.SuggestedRoomsMax = rooms
+			break
+		}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package dungeon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestEstimateMemory verifies estimates grow with the dungeon, respect the
+// map limits and err above what generation actually carves.
+func TestEstimateMemory(t *testing.T) {
+	cfg := func(rooms int) *dungeon.Config {
+		return &dungeon.Config{
+			Seed:          5,
+			Size:          dungeon.SizeCfg{RoomsMin: rooms - 5, RoomsMax: rooms},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 3},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"crypt", "fungal"},
+			OptionalRatio: 0.2,
+		}
+	}
+
+	small, large := dungeon.EstimateMemory(cfg(20)), dungeon.EstimateMemory(cfg(200))
+	if large.PeakBytes <= small.PeakBytes || large.MapTiles <= small.MapTiles {
+		t.Errorf("200 rooms estimated at %+v, not above 20 rooms at %+v", large, small)
+	}
+	if small.ArtifactBytes <= small.TileMapBytes || small.PeakBytes <= small.ArtifactBytes {
+		t.Errorf("estimate %+v does not add up", small)
+	}
+
+	environment := cfg(20)
+	environment.Content.EnvironmentRatio = 0.5
+	if got := dungeon.EstimateMemory(environment); got.TileMapBytes <= small.TileMapBytes {
+		t.Errorf("environment layers not counted: %d bytes, want more than %d", got.TileMapBytes, small.TileMapBytes)
+	}
+
+	bounded := cfg(200)
+	bounded.Map = dungeon.MapCfg{MaxWidth: 100, MaxHeight: 100}
+	if got := dungeon.EstimateMemory(bounded); got.MapTiles != 100*100 {
+		t.Errorf("MapTiles = %d within a 100x100 map, want %d", got.MapTiles, 100*100)
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	for _, rooms := range []int{20, 100} {
+		c := cfg(rooms)
+		artifact, err := gen.Generate(context.Background(), c)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var carved int64
+		for _, layer := range artifact.TileMap.Layers {
+			carved += int64(len(layer.Data)) * 4
+		}
+		if estimate := dungeon.EstimateMemory(c); carved > estimate.TileMapBytes {
+			t.Errorf("%d rooms carved %d bytes of tiles, estimated %d", rooms, carved, estimate.TileMapBytes)
+		}
+	}
+}
+
+// TestGenerate_MemoryBudget verifies configs over budget are refused with a
+// room count that fits.
+func TestGenerate_MemoryBudget(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          5,
+		Size:          dungeon.SizeCfg{RoomsMin: 100, RoomsMax: 300},
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 3},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+		OptionalRatio: 0.2,
+		Limits:        &dungeon.LimitsCfg{MaxMemoryMB: 4},
+	}
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+
+	_, err := gen.Generate(context.Background(), cfg)
+	var merr *dungeon.MemoryError
+	if !errors.Is(err, dungeon.ErrMemoryBudget) || !errors.As(err, &merr) {
+		t.Fatalf("error %v is not a MemoryError", err)
+	}
+	if merr.SuggestedRoomsMax <= 0 || merr.SuggestedRoomsMax >= 300 {
+		t.Fatalf("SuggestedRoomsMax = %d, want a smaller dungeon", merr.SuggestedRoomsMax)
+	}
+
+	cfg.Size = dungeon.SizeCfg{RoomsMin: merr.SuggestedRoomsMax - 5, RoomsMax: merr.SuggestedRoomsMax}
+	if got := dungeon.EstimateMemory(cfg); got.PeakBytes > 4<<20 {
+		t.Errorf("suggested %d rooms estimate %d bytes, over the budget", merr.SuggestedRoomsMax, got.PeakBytes)
+	}
+	if _, err := gen.Generate(context.Background(), cfg); err != nil {
+		t.Errorf("Generate() at the suggested size error = %v", err)
+	}
+}
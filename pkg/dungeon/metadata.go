@@ -0,0 +1,264 @@
+package dungeon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetaType is the declared type of a metadata key. The names match Tiled's
+// custom property types, so metadata exports to TMJ maps unchanged.
+type MetaType string
+
+const (
+	MetaString MetaType = "string" // Go string
+	MetaInt    MetaType = "int"    // Go int64
+	MetaFloat  MetaType = "float"  // Go float64
+	MetaBool   MetaType = "bool"   // Go bool
+)
+
+var (
+	metadataMu      sync.RWMutex
+	metadataSchemas = map[string]MetaType{}
+)
+
+// RegisterMetadata declares a metadata key and the type of its values,
+// making the key valid in Config.Metadata. Games call it from an init
+// function for their own parameters, such as a campaign ID or biome feature
+// flags. Registering a key twice is an error.
+func RegisterMetadata(key string, t MetaType) error {
+	if key == "" {
+		return errors.New("metadata key is required")
+	}
+	switch t {
+	case MetaString, MetaInt, MetaFloat, MetaBool:
+	default:
+		return fmt.Errorf("metadata %q: unknown type %q, must be one of: string, int, float, bool", key, t)
+	}
+
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+	if _, ok := metadataSchemas[key]; ok {
+		return fmt.Errorf("metadata %q is already registered", key)
+	}
+	metadataSchemas[key] = t
+	return nil
+}
+
+// MustRegisterMetadata is like RegisterMetadata but panics on error, for
+// init functions.
+func MustRegisterMetadata(key string, t MetaType) {
+	if err := RegisterMetadata(key, t); err != nil {
+		panic(err)
+	}
+}
+
+// MetadataType returns the declared type of a metadata key.
+func MetadataType(key string) (MetaType, bool) {
+	metadataMu.RLock()
+	defer metadataMu.RUnlock()
+	t, ok := metadataSchemas[key]
+	return t, ok
+}
+
+// Metadata is a bag of game-defined parameters carried by a Config and the
+// Artifact generated from it. Every key must be declared with
+// RegisterMetadata, and values are held as the Go type of their declared
+// MetaType. Metadata does not affect generation or the config hash.
+//
+// Decoding from YAML or JSON converts values to their declared types, so
+// metadata survives export round-trips: an int exported to JSON decodes as
+// an int64 again, not a float64.
+type Metadata map[string]any
+
+// Set stores a value under a registered key, converting it to the key's
+// declared type. Any Go integer is accepted for int keys, and any number
+// for float keys.
+func (m *Metadata) Set(key string, value any) error {
+	v, err := checkMetadata(key, value)
+	if err != nil {
+		return err
+	}
+	if *m == nil {
+		*m = Metadata{}
+	}
+	(*m)[key] = v
+	return nil
+}
+
+// String returns the value of a string key.
+func (m Metadata) String(key string) (string, bool) {
+	v, ok := m[key].(string)
+	return v, ok
+}
+
+// Int returns the value of an int key.
+func (m Metadata) Int(key string) (int64, bool) {
+	v, ok := m[key].(int64)
+	return v, ok
+}
+
+// Float returns the value of a float key.
+func (m Metadata) Float(key string) (float64, bool) {
+	v, ok := m[key].(float64)
+	return v, ok
+}
+
+// Bool returns the value of a bool key.
+func (m Metadata) Bool(key string) (bool, bool) {
+	v, ok := m[key].(bool)
+	return v, ok
+}
+
+// Keys returns the keys in sorted order.
+func (m Metadata) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks every key is registered and holds a value of its declared
+// type.
+func (m Metadata) Validate() error {
+	for _, key := range m.Keys() {
+		v, err := checkMetadata(key, m[key])
+		if err != nil {
+			return err
+		}
+		if v != m[key] {
+			return fmt.Errorf("metadata %q: value %v is a %T, want the Go type of %s", key, m[key], m[key], mustMetadataType(key))
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of m, or nil when m is empty.
+func (m Metadata) clone() Metadata {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(Metadata, len(m))
+	for key, v := range m {
+		c[key] = v
+	}
+	return c
+}
+
+// UnmarshalJSON decodes a JSON object, converting values to the declared
+// types of their keys. Unregistered keys keep their decoded value and are
+// reported by Validate.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	return m.decode(raw)
+}
+
+// UnmarshalYAML decodes a YAML mapping, converting values to the declared
+// types of their keys. Unregistered keys keep their decoded value and are
+// reported by Validate.
+func (m *Metadata) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]any
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	return m.decode(raw)
+}
+
+// decode replaces m with raw, converting the values of registered keys.
+func (m *Metadata) decode(raw map[string]any) error {
+	if raw == nil {
+		*m = nil
+		return nil
+	}
+	decoded := make(Metadata, len(raw))
+	for key, value := range raw {
+		if n, ok := value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				value = i
+			} else if f, err := n.Float64(); err == nil {
+				value = f
+			}
+		}
+		if _, ok := MetadataType(key); ok {
+			v, err := checkMetadata(key, value)
+			if err != nil {
+				return err
+			}
+			value = v
+		}
+		decoded[key] = value
+	}
+	*m = decoded
+	return nil
+}
+
+// checkMetadata converts value to the declared type of key, or returns an
+// error if the key is not registered or the value does not fit the type.
+func checkMetadata(key string, value any) (any, error) {
+	t, ok := MetadataType(key)
+	if !ok {
+		return nil, fmt.Errorf("metadata %q is not registered", key)
+	}
+
+	switch t {
+	case MetaString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case MetaBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case MetaInt:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case uint32:
+			return int64(v), nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		case float64:
+			// Integral floats, as JSON decoders without UseNumber produce
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return int64(v), nil
+			}
+		}
+	case MetaFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	}
+	return nil, fmt.Errorf("metadata %q: value %v is not a %s", key, value, t)
+}
+
+// mustMetadataType returns the declared type of a registered key.
+func mustMetadataType(key string) MetaType {
+	t, _ := MetadataType(key)
+	return t
+}
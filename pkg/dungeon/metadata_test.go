@@ -0,0 +1,142 @@
+package dungeon
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func init() {
+	MustRegisterMetadata("test.campaign", MetaString)
+	MustRegisterMetadata("test.act", MetaInt)
+	MustRegisterMetadata("test.fogLevel", MetaFloat)
+	MustRegisterMetadata("test.fungalBloom", MetaBool)
+}
+
+// TestRegisterMetadata verifies keys are declared once with a known type.
+func TestRegisterMetadata(t *testing.T) {
+	if err := RegisterMetadata("test.campaign", MetaString); err == nil {
+		t.Error("RegisterMetadata() accepted a key registered twice")
+	}
+	if err := RegisterMetadata("", MetaString); err == nil {
+		t.Error("RegisterMetadata() accepted an empty key")
+	}
+	if err := RegisterMetadata("test.list", MetaType("list")); err == nil {
+		t.Error("RegisterMetadata() accepted an unknown type")
+	}
+	if typ, ok := MetadataType("test.act"); !ok || typ != MetaInt {
+		t.Errorf("MetadataType(test.act) = %q, %v, want int, true", typ, ok)
+	}
+}
+
+// TestMetadata_Set verifies values are checked against and converted to
+// their declared types.
+func TestMetadata_Set(t *testing.T) {
+	var m Metadata
+	if err := m.Set("test.act", 3); err != nil {
+		t.Fatalf("Set(test.act, 3) error = %v", err)
+	}
+	if err := m.Set("test.fogLevel", 2); err != nil {
+		t.Fatalf("Set(test.fogLevel, 2) error = %v", err)
+	}
+	if act, ok := m.Int("test.act"); !ok || act != 3 {
+		t.Errorf("Int(test.act) = %d, %v, want 3, true", act, ok)
+	}
+	if fog, ok := m.Float("test.fogLevel"); !ok || fog != 2.0 {
+		t.Errorf("Float(test.fogLevel) = %f, %v, want 2, true", fog, ok)
+	}
+
+	tests := []struct {
+		key   string
+		value any
+	}{
+		{"test.campaign", 7},
+		{"test.act", 1.5},
+		{"test.fungalBloom", "yes"},
+		{"test.unknown", "x"},
+	}
+	for _, tt := range tests {
+		if err := m.Set(tt.key, tt.value); err == nil {
+			t.Errorf("Set(%s, %v) accepted a value of the wrong type", tt.key, tt.value)
+		}
+	}
+
+	if err := (Metadata{"test.act": 3}).Validate(); err == nil {
+		t.Error("Validate() accepted an int instead of an int64")
+	}
+}
+
+// TestConfig_Metadata verifies metadata loads from YAML with declared types,
+// is validated, reaches the artifact and leaves the config hash unchanged.
+func TestConfig_Metadata(t *testing.T) {
+	base := `
+seed: 42
+size: {roomsMin: 10, roomsMax: 15}
+branching: {avg: 2.0, max: 3}
+pacing: {curve: LINEAR, variance: 0.1}
+themes: [crypt]
+secretDensity: 0.1
+optionalRatio: 0.2
+`
+	cfg, err := LoadConfigFromBytes([]byte(base + `
+metadata:
+  test.campaign: c-17
+  test.act: 2
+  test.fogLevel: 1
+  test.fungalBloom: true
+`))
+	if err != nil {
+		t.Fatalf("LoadConfigFromBytes() error = %v", err)
+	}
+	want := Metadata{"test.campaign": "c-17", "test.act": int64(2), "test.fogLevel": 1.0, "test.fungalBloom": true}
+	if len(cfg.Metadata) != len(want) {
+		t.Fatalf("Metadata = %v, want %v", cfg.Metadata, want)
+	}
+	for key, v := range want {
+		if cfg.Metadata[key] != v {
+			t.Errorf("Metadata[%s] = %v (%T), want %v (%T)", key, cfg.Metadata[key], cfg.Metadata[key], v, v)
+		}
+	}
+
+	plain, err := LoadConfigFromBytes([]byte(base))
+	if err != nil {
+		t.Fatalf("LoadConfigFromBytes() error = %v", err)
+	}
+	if !bytes.Equal(cfg.Hash(), plain.Hash()) {
+		t.Error("metadata changes the config hash")
+	}
+
+	for _, doc := range []string{"metadata: {test.unknown: 1}\n", "metadata: {test.act: two}\n"} {
+		if _, err := LoadConfigFromBytes([]byte(base + doc)); err == nil {
+			t.Errorf("LoadConfigFromBytes() accepted %q", doc)
+		}
+	}
+
+	gen := NewGenerator().(*DefaultGenerator)
+	gen.SetValidator(&mockValidator{})
+	artifact, err := gen.Generate(t.Context(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if got, _ := artifact.Metadata.String("test.campaign"); got != "c-17" {
+		t.Errorf("artifact campaign = %q, want c-17", got)
+	}
+
+	// Values keep their types through a JSON round-trip
+	data, err := artifact.ExportJSONCompact()
+	if err != nil {
+		t.Fatalf("ExportJSONCompact() error = %v", err)
+	}
+	var decoded Artifact
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	for key, v := range want {
+		if decoded.Metadata[key] != v {
+			t.Errorf("decoded Metadata[%s] = %v (%T), want %v (%T)", key, decoded.Metadata[key], decoded.Metadata[key], v, v)
+		}
+	}
+	if err := decoded.Metadata.Validate(); err != nil {
+		t.Errorf("decoded Metadata.Validate() error = %v", err)
+	}
+}
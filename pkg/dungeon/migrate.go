@@ -0,0 +1,192 @@
+package dungeon
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config schema version this package reads.
+// Configs without a version field are version 0, the schema of the original
+// specification.
+const CurrentConfigVersion = 1
+
+// configMigrations upgrade a config document by one schema version each:
+// configMigrations[v] turns version v into version v+1 and returns a
+// warning for every change it made. Schema changes append a step here and
+// bump CurrentConfigVersion.
+var configMigrations = []func(root *yaml.Node) ([]string, error){
+	migrateConfigV0,
+}
+
+// MigrateConfig upgrades YAML config data written for an older schema
+// version to CurrentConfigVersion: renamed fields are moved to their new
+// names and required blocks an older schema lacked are filled with
+// defaults. It returns the upgraded YAML with a warning for every change,
+// so a library of config files can be reviewed and rewritten once per
+// release. Comments and the order of untouched fields are kept. Data that is
+// already current is returned unchanged.
+func MigrateConfig(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("config must be a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	version := 0
+	if node := mappingValue(root, "version"); node != nil {
+		v, err := strconv.Atoi(node.Value)
+		if err != nil || v < 0 {
+			return nil, nil, fmt.Errorf("invalid config version %q", node.Value)
+		}
+		version = v
+	}
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("config version %d is newer than supported version %d", version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return data, nil, nil
+	}
+
+	warnings := []string{}
+	for v := version; v < CurrentConfigVersion; v++ {
+		stepWarnings, err := configMigrations[v](root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("migrating from version %d: %w", v, err)
+		}
+		warnings = append(warnings, stepWarnings...)
+	}
+	setConfigVersion(root, CurrentConfigVersion)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	if _, err := parseConfigYAML(buf.Bytes()); err != nil {
+		return nil, nil, fmt.Errorf("migrated config: %w", err)
+	}
+	return buf.Bytes(), warnings, nil
+}
+
+// migrateConfigV0 upgrades configs written against the original
+// specification: "biomes" became "themes", constraint severities became
+// lowercase, and the size, branching, pacing and themes blocks and an
+// optionalRatio in range became required.
+func migrateConfigV0(root *yaml.Node) ([]string, error) {
+	var warnings []string
+
+	if mappingValue(root, "biomes") != nil {
+		if mappingValue(root, "themes") != nil {
+			deleteMappingKey(root, "biomes")
+			warnings = append(warnings, "removed biomes: themes is already set")
+		} else {
+			renameMappingKey(root, "biomes", "themes")
+			warnings = append(warnings, "renamed biomes to themes")
+		}
+	}
+
+	if constraints := mappingValue(root, "constraints"); constraints != nil && constraints.Kind == yaml.SequenceNode {
+		for i, c := range constraints.Content {
+			severity := mappingValue(c, "severity")
+			if severity == nil || severity.Value == strings.ToLower(severity.Value) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("constraints[%d]: lowercased severity %q", i, severity.Value))
+			severity.Value = strings.ToLower(severity.Value)
+		}
+	}
+
+	// Difficulty presets supply the pacing block
+	defaults := []struct {
+		key, yaml string
+	}{
+		{"size", "{roomsMin: 20, roomsMax: 30}"},
+		{"branching", "{avg: 2.0, max: 4}"},
+		{"pacing", "{curve: LINEAR, variance: 0.1}"},
+		{"themes", "[dungeon]"},
+		{"optionalRatio", "0.2"},
+	}
+	for _, d := range defaults {
+		if mappingValue(root, d.key) != nil || (d.key == "pacing" && mappingValue(root, "difficulty") != nil) {
+			continue
+		}
+		var value yaml.Node
+		if err := yaml.Unmarshal([]byte(d.yaml), &value); err != nil {
+			return nil, fmt.Errorf("default %s: %w", d.key, err)
+		}
+		setMappingNode(root, d.key, value.Content[0])
+		warnings = append(warnings, fmt.Sprintf("added missing %s: %s", d.key, d.yaml))
+	}
+
+	return warnings, nil
+}
+
+// mappingValue returns the value of key in a YAML mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingNode sets key to value, appending the key if it is missing.
+func setMappingNode(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// setConfigVersion sets the version field, adding it as the first field
+// (below any comment heading the file) if it is missing.
+func setConfigVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if mappingValue(root, "version") != nil {
+		setMappingNode(root, "version", value)
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// renameMappingKey renames key in place, keeping its value and position.
+func renameMappingKey(m *yaml.Node, key, name string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i].Value = name
+			return
+		}
+	}
+}
+
+// deleteMappingKey removes key and its value from a mapping.
+func deleteMappingKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package dungeon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// specV0Config is a config in the schema of the original specification.
+const specV0Config = `# Crypt from the spec
+seed: 987654321
+size: { roomsMin: 25, roomsMax: 35 }
+branching: { avg: 1.8, max: 3 }
+biomes: [crypt, arcane] # themes of the dungeon
+keys:
+  - { name: silver, count: 1 }
+constraints:
+  - { kind: Connectivity, severity: Hard, expr: "isConnected()" }
+  - { kind: KeyLock, severity: hard, expr: "keyBeforeLock('silver')" }
+`
+
+func TestMigrateConfig(t *testing.T) {
+	data, warnings, err := MigrateConfig([]byte(specV0Config))
+	if err != nil {
+		t.Fatalf("MigrateConfig() error = %v", err)
+	}
+
+	cfg, err := LoadConfigFromBytes(data)
+	if err != nil {
+		t.Fatalf("migrated config does not load: %v\n%s", err, data)
+	}
+	if cfg.Version != CurrentConfigVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentConfigVersion)
+	}
+	if len(cfg.Themes) != 2 || cfg.Themes[0] != "crypt" {
+		t.Errorf("Themes = %v, want the renamed biomes", cfg.Themes)
+	}
+	if cfg.Constraints[0].Severity != "hard" {
+		t.Errorf("Severity = %q, want hard", cfg.Constraints[0].Severity)
+	}
+	if cfg.Pacing.Curve != PacingLinear || cfg.OptionalRatio != 0.2 {
+		t.Errorf("missing blocks were not defaulted: pacing %+v, optionalRatio %f", cfg.Pacing, cfg.OptionalRatio)
+	}
+	if cfg.Size.RoomsMin != 25 || cfg.Branching.Max != 3 {
+		t.Errorf("existing values changed: size %+v, branching %+v", cfg.Size, cfg.Branching)
+	}
+
+	// One warning each: biomes, one severity, pacing and optionalRatio
+	if len(warnings) != 4 {
+		t.Errorf("warnings = %q, want 4", warnings)
+	}
+	if !strings.HasPrefix(string(data), "# Crypt from the spec\nversion: 1\n") || !strings.Contains(string(data), "# themes of the dungeon") {
+		t.Errorf("comments were not kept:\n%s", data)
+	}
+
+	// Current configs are left alone
+	again, warnings, err := MigrateConfig(data)
+	if err != nil || len(warnings) != 0 || !bytes.Equal(again, data) {
+		t.Errorf("migrating a current config = %q, %v, %v", again, warnings, err)
+	}
+}
+
+func TestMigrateConfig_Errors(t *testing.T) {
+	for name, data := range map[string]string{
+		"newer version": "version: 99\nseed: 1\n",
+		"bad version":   "version: latest\n",
+		"not a mapping": "- seed: 1\n",
+		"invalid YAML":  "seed: [1\n",
+	} {
+		if _, _, err := MigrateConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestConfig_HashIgnoresVersion(t *testing.T) {
+	cfg := &Config{Seed: 42, Themes: []string{"crypt"}}
+	versioned := *cfg
+	versioned.Version = CurrentConfigVersion
+	if !bytes.Equal(cfg.Hash(), versioned.Hash()) {
+		t.Error("Hash() depends on the schema version")
+	}
+}
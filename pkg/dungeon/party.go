@@ -0,0 +1,22 @@
+package dungeon
+
+import (
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// scaleRoomSizesForParty enlarges room footprints for co-op parties: every
+// two players grow each room by one size class, capped at SizeXL.
+// Single-player parties (size <= 1) leave the graph unchanged.
+func scaleRoomSizesForParty(g *graph.Graph, partySize int) {
+	steps := partySize / 2
+	if steps == 0 {
+		return
+	}
+	for _, room := range g.Rooms {
+		size := room.Size + graph.RoomSize(steps)
+		if size > graph.SizeXL {
+			size = graph.SizeXL
+		}
+		room.Size = size
+	}
+}
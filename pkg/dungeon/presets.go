@@ -0,0 +1,83 @@
+package dungeon
+
+import "fmt"
+
+// LayoutPreset holds the force-directed layout parameters for dungeons of up
+// to MaxRooms rooms. Bigger dungeons need stronger springs and weaker
+// repulsion to stay compact, and longer corridors to join rooms further
+// apart.
+type LayoutPreset struct {
+	// MaxRooms is the largest room count the preset applies to. Dungeons
+	// bigger than the last preset's MaxRooms use the last preset.
+	MaxRooms int `yaml:"maxRooms" json:"maxRooms"`
+
+	// SpringConstant is the pull of corridors on the rooms they join.
+	SpringConstant float64 `yaml:"springConstant" json:"springConstant"`
+
+	// RepulsionConstant is the push between every pair of rooms.
+	RepulsionConstant float64 `yaml:"repulsionConstant" json:"repulsionConstant"`
+
+	// CorridorMaxLength is the longest corridor a layout may have, in tiles.
+	// Longer corridors make the embedder fail and the layout fall back.
+	CorridorMaxLength float64 `yaml:"corridorMaxLength" json:"corridorMaxLength"`
+}
+
+// defaultLayoutPresets are the presets by room count, derived by the tuning
+// harness in presets_test.go (make tune-presets). For each band it picks
+// the spring and repulsion that give the fewest failed and pathological
+// force-directed layouts, free and inside a boundary, then the shortest
+// corridors, and allows corridors 15% longer than the longest any layout
+// style gave with them.
+var defaultLayoutPresets = []LayoutPreset{
+	{MaxRooms: 25, SpringConstant: 0.5, RepulsionConstant: 375, CorridorMaxLength: 290},
+	{MaxRooms: 40, SpringConstant: 0.5, RepulsionConstant: 500, CorridorMaxLength: 335},
+	{MaxRooms: 60, SpringConstant: 0.5, RepulsionConstant: 500, CorridorMaxLength: 420},
+	{MaxRooms: 90, SpringConstant: 0.5, RepulsionConstant: 375, CorridorMaxLength: 605},
+	{MaxRooms: 130, SpringConstant: 0.5, RepulsionConstant: 500, CorridorMaxLength: 640},
+	{MaxRooms: 200, SpringConstant: 7.5, RepulsionConstant: 375, CorridorMaxLength: 800},
+	{MaxRooms: 300, SpringConstant: 5, RepulsionConstant: 250, CorridorMaxLength: 1505},
+}
+
+// DefaultLayoutPresets returns a copy of the built-in layout presets, in
+// order of MaxRooms. Use it as a starting point for Config.LayoutPresets.
+func DefaultLayoutPresets() []LayoutPreset {
+	return append([]LayoutPreset(nil), defaultLayoutPresets...)
+}
+
+// LayoutPresetFor returns the preset for a dungeon of the given room count:
+// the first one whose MaxRooms it does not exceed, or the last one. Empty
+// presets mean the built-in ones.
+func LayoutPresetFor(presets []LayoutPreset, rooms int) LayoutPreset {
+	if len(presets) == 0 {
+		presets = defaultLayoutPresets
+	}
+	for _, preset := range presets {
+		if rooms <= preset.MaxRooms {
+			return preset
+		}
+	}
+	return presets[len(presets)-1]
+}
+
+// validateLayoutPresets checks presets are in increasing order of MaxRooms
+// and have positive parameters.
+func validateLayoutPresets(presets []LayoutPreset) error {
+	for i, preset := range presets {
+		if preset.MaxRooms < 1 {
+			return fmt.Errorf("[%d]: maxRooms must be at least 1, got %d", i, preset.MaxRooms)
+		}
+		if i > 0 && preset.MaxRooms <= presets[i-1].MaxRooms {
+			return fmt.Errorf("[%d]: maxRooms %d must be more than the previous preset's %d", i, preset.MaxRooms, presets[i-1].MaxRooms)
+		}
+		if preset.SpringConstant <= 0 {
+			return fmt.Errorf("[%d]: springConstant must be positive, got %f", i, preset.SpringConstant)
+		}
+		if preset.RepulsionConstant <= 0 {
+			return fmt.Errorf("[%d]: repulsionConstant must be positive, got %f", i, preset.RepulsionConstant)
+		}
+		if preset.CorridorMaxLength <= 0 {
+			return fmt.Errorf("[%d]: corridorMaxLength must be positive, got %f", i, preset.CorridorMaxLength)
+		}
+	}
+	return nil
+}
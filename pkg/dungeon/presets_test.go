@@ -0,0 +1,278 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/embedding"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/synthesis"
+)
+
+// TestLayoutPresetFor verifies every room count gets the first preset whose
+// band it falls in, and counts past the last band get the last preset.
+func TestLayoutPresetFor(t *testing.T) {
+	presets := []LayoutPreset{
+		{MaxRooms: 10, SpringConstant: 1, RepulsionConstant: 1, CorridorMaxLength: 1},
+		{MaxRooms: 20, SpringConstant: 2, RepulsionConstant: 2, CorridorMaxLength: 2},
+	}
+	tests := []struct {
+		rooms int
+		want  int
+	}{
+		{rooms: 0, want: 10},
+		{rooms: 10, want: 10},
+		{rooms: 11, want: 20},
+		{rooms: 20, want: 20},
+		{rooms: 500, want: 20},
+	}
+	for _, tt := range tests {
+		if got := LayoutPresetFor(presets, tt.rooms); got.MaxRooms != tt.want {
+			t.Errorf("LayoutPresetFor(%d) = band %d, want band %d", tt.rooms, got.MaxRooms, tt.want)
+		}
+	}
+
+	if got, want := LayoutPresetFor(nil, 30), LayoutPresetFor(DefaultLayoutPresets(), 30); got != want {
+		t.Errorf("LayoutPresetFor(nil, 30) = %+v, want the built-in %+v", got, want)
+	}
+}
+
+// TestDefaultLayoutPresets verifies the built-in presets are valid and cover
+// every dungeon size.
+func TestDefaultLayoutPresets(t *testing.T) {
+	presets := DefaultLayoutPresets()
+	if err := validateLayoutPresets(presets); err != nil {
+		t.Fatalf("built-in presets are invalid: %v", err)
+	}
+	if last := presets[len(presets)-1].MaxRooms; last < synthesis.MaxRooms {
+		t.Errorf("last preset covers %d rooms, want at least %d", last, synthesis.MaxRooms)
+	}
+
+	presets[0].SpringConstant = 99
+	if defaultLayoutPresets[0].SpringConstant == 99 {
+		t.Error("DefaultLayoutPresets() shares the built-in table")
+	}
+}
+
+// TestValidateLayoutPresets verifies misordered bands and non-positive
+// parameters are rejected.
+func TestValidateLayoutPresets(t *testing.T) {
+	valid := LayoutPreset{MaxRooms: 10, SpringConstant: 1, RepulsionConstant: 100, CorridorMaxLength: 100}
+	tests := []struct {
+		name   string
+		modify func(p []LayoutPreset) []LayoutPreset
+		want   string
+	}{
+		{name: "valid", modify: func(p []LayoutPreset) []LayoutPreset { return p }},
+		{name: "empty", modify: func(p []LayoutPreset) []LayoutPreset { return nil }},
+		{name: "zero max rooms", modify: func(p []LayoutPreset) []LayoutPreset { p[0].MaxRooms = 0; return p }, want: "maxRooms must be at least 1"},
+		{name: "unordered", modify: func(p []LayoutPreset) []LayoutPreset { p[1].MaxRooms = 10; return p }, want: "must be more than"},
+		{name: "zero spring", modify: func(p []LayoutPreset) []LayoutPreset { p[1].SpringConstant = 0; return p }, want: "springConstant"},
+		{name: "negative repulsion", modify: func(p []LayoutPreset) []LayoutPreset { p[0].RepulsionConstant = -1; return p }, want: "repulsionConstant"},
+		{name: "zero corridor", modify: func(p []LayoutPreset) []LayoutPreset { p[0].CorridorMaxLength = 0; return p }, want: "corridorMaxLength"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			second := valid
+			second.MaxRooms = 20
+			err := validateLayoutPresets(tt.modify([]LayoutPreset{valid, second}))
+			if tt.want == "" && err != nil {
+				t.Errorf("validateLayoutPresets() error = %v, want nil", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("validateLayoutPresets() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestGenerate_LayoutPresets verifies Config.LayoutPresets replaces the
+// built-in presets: a corridor limit no layout can meet fails embedding.
+func TestGenerate_LayoutPresets(t *testing.T) {
+	cfg := tuningConfig(15, 1)
+	gen := NewGenerator().(*DefaultGenerator)
+	gen.SetValidator(&mockValidator{})
+
+	if _, err := gen.Generate(context.Background(), cfg); err != nil {
+		t.Fatalf("Generate() with the built-in presets error = %v", err)
+	}
+
+	cfg.LayoutPresets = []LayoutPreset{{MaxRooms: 100, SpringConstant: 0.5, RepulsionConstant: 500, CorridorMaxLength: 1}}
+	_, err := gen.Generate(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "embedding") {
+		t.Errorf("Generate() with 1-tile corridors error = %v, want an embedding error", err)
+	}
+}
+
+// tuningConfig returns the config the tuning harness lays out: a standard
+// dungeon of exactly the given number of rooms.
+func tuningConfig(rooms int, seed uint64) *Config {
+	return &Config{
+		Seed:          seed,
+		Size:          SizeCfg{RoomsMin: rooms, RoomsMax: rooms},
+		Branching:     BranchingCfg{Avg: 2.0, Max: 4},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Keys:          []KeyCfg{{Name: "silver", Count: 1}},
+		Pacing:        PacingCfg{Curve: PacingLinear, Variance: 0.1},
+		Themes:        []string{"crypt"},
+	}
+}
+
+// Tuning harness. TestTuneLayoutPresets re-derives the built-in layout
+// presets and prints them as Go source for defaultLayoutPresets. For each
+// band it synthesizes graphs of the band's smallest, middle and largest room
+// count for tuneSeeds seeds, free and, up to tuneShapedRooms rooms, inside
+// an L-shaped boundary. It lays them all out with every spring and
+// repulsion constant in the tuning grid and no corridor limit, and keeps
+// the pair with the fewest failed force-directed layouts, then the fewest
+// pathological ones, then the shortest corridors. CorridorMaxLength is set
+// 15% above the longest corridor any layout style gives the free graphs
+// with that pair, since the fallback embedders share the limit. The graphs
+// and RNG streams depend only on the room count, seed and boundary, so
+// every run prints the same table.
+//
+//	DUNGO_TUNE_PRESETS=1 go test ./pkg/dungeon -run TestTuneLayoutPresets -v -timeout 0
+const (
+	tuneSeeds         = 8
+	tuneCorridorScale = 1.15
+	tuneShapedRooms   = 100 // Bigger shaped layouts take seconds each
+)
+
+// tuneSprings and tuneRepulsions are the tuning grid.
+var (
+	tuneSprings    = []float64{0.5, 1, 2, 3.5, 5, 7.5}
+	tuneRepulsions = []float64{100, 175, 250, 375, 500}
+)
+
+// tuneScore is how well a candidate preset lays out a band's graphs.
+type tuneScore struct {
+	failed, pathological int
+	corridors            float64
+}
+
+func (s tuneScore) better(o tuneScore) bool {
+	if s.failed != o.failed {
+		return s.failed < o.failed
+	}
+	if s.pathological != o.pathological {
+		return s.pathological < o.pathological
+	}
+	return s.corridors < o.corridors
+}
+
+// tuneBoundary is the L-shaped boundary the harness lays out 30 rooms in.
+// Other room counts get it scaled to the same area per room.
+var tuneBoundary = [][2]float64{{0, 0}, {160, 0}, {160, 60}, {70, 60}, {70, 160}, {0, 160}}
+
+// tuneSample is a graph the harness lays out and the config it came from.
+type tuneSample struct {
+	cfg *Config
+	adg *graph.Graph
+}
+
+// tuneSamples synthesizes the graphs of the given room counts for every
+// tuning seed, free or, when shaped, inside the tuning boundary. Shaped
+// graphs stop at tuneShapedRooms rooms.
+func tuneSamples(t *testing.T, gen *DefaultGenerator, sizes []int, shaped bool) []tuneSample {
+	var samples []tuneSample
+	for _, rooms := range sizes {
+		if shaped && rooms > tuneShapedRooms {
+			continue
+		}
+		scale := math.Sqrt(float64(rooms) / 30)
+		for seed := uint64(1); seed <= tuneSeeds; seed++ {
+			cfg := tuningConfig(rooms, seed)
+			if shaped {
+				for _, v := range tuneBoundary {
+					cfg.Map.Boundary = append(cfg.Map.Boundary, [2]float64{v[0] * scale, v[1] * scale})
+				}
+			}
+			adg, err := gen.synthesize(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("synthesize(%d rooms, seed %d) error = %v", rooms, seed, err)
+			}
+			samples = append(samples, tuneSample{cfg: cfg, adg: adg})
+		}
+	}
+	return samples
+}
+
+// tuneEmbed lays every sample out with the preset and layout style,
+// returning the layouts that did not fail and how many did.
+func tuneEmbed(gen *DefaultGenerator, samples []tuneSample, preset LayoutPreset, style LayoutStyle) ([]*embedding.Layout, int) {
+	var layouts []*embedding.Layout
+	failed := 0
+	for _, s := range samples {
+		cfg := *s.cfg
+		cfg.LayoutPresets = []LayoutPreset{preset}
+		cfg.Map.Layout = style
+		layout, err := gen.embed(context.Background(), &cfg, s.adg, rng.NewRNG(s.cfg.Seed, "embedding", s.cfg.Hash()))
+		if err != nil {
+			failed++
+			continue
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, failed
+}
+
+func TestTuneLayoutPresets(t *testing.T) {
+	if os.Getenv("DUNGO_TUNE_PRESETS") != "1" {
+		t.Skip("set DUNGO_TUNE_PRESETS=1 to run the layout preset tuning harness")
+	}
+
+	gen := NewGenerator().(*DefaultGenerator)
+	var out strings.Builder
+	out.WriteString("var defaultLayoutPresets = []LayoutPreset{\n")
+	lo := synthesis.MinRooms
+	for _, band := range defaultLayoutPresets {
+		hi := band.MaxRooms
+		sizes := []int{lo, (lo + hi) / 2, hi}
+		free := tuneSamples(t, gen, sizes, false)
+		samples := append(tuneSamples(t, gen, sizes, true), free...)
+
+		var best LayoutPreset
+		var bestScore tuneScore
+		for _, spring := range tuneSprings {
+			for _, repulsion := range tuneRepulsions {
+				candidate := LayoutPreset{MaxRooms: hi, SpringConstant: spring, RepulsionConstant: repulsion, CorridorMaxLength: math.Inf(1)}
+				layouts, failed := tuneEmbed(gen, samples, candidate, LayoutForce)
+				score := tuneScore{failed: failed}
+				for _, layout := range layouts {
+					if len(layout.Rejected) > 0 {
+						score.pathological++
+					}
+					score.corridors += corridorLength(layout)
+				}
+				if best.MaxRooms == 0 || score.better(bestScore) {
+					best, bestScore = candidate, score
+				}
+			}
+		}
+
+		longest := 0.0
+		for _, style := range []LayoutStyle{LayoutForce, LayoutRings, LayoutLayered} {
+			layouts, _ := tuneEmbed(gen, free, best, style)
+			for _, layout := range layouts {
+				for _, path := range layout.CorridorPaths {
+					longest = math.Max(longest, path.Length())
+				}
+			}
+		}
+		best.CorridorMaxLength = math.Ceil(longest*tuneCorridorScale/5) * 5
+
+		t.Logf("rooms %d-%d: %+v, %d failed, %d pathological of %d layouts, longest corridor %.0f",
+			lo, hi, best, bestScore.failed, bestScore.pathological, len(samples), longest)
+		fmt.Fprintf(&out, "\t{MaxRooms: %d, SpringConstant: %g, RepulsionConstant: %g, CorridorMaxLength: %g},\n",
+			best.MaxRooms, best.SpringConstant, best.RepulsionConstant, best.CorridorMaxLength)
+		lo = hi + 1
+	}
+	out.WriteString("}\n")
+	t.Log("\n" + out.String())
+}
@@ -0,0 +1,214 @@
+package dungeon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultQualityAttempts is the number of seeds Generate tries when quality
+// gates are set and QualityCfg.MaxAttempts is 0.
+const DefaultQualityAttempts = 10
+
+// MaxQualityAttempts bounds QualityCfg.MaxAttempts.
+const MaxQualityAttempts = 100
+
+// ErrQualityGate marks a dungeon whose metrics failed the config's quality
+// gates on every attempt; see QualityError.
+var ErrQualityGate = errors.New("quality gates not met")
+
+// QualityCfg declares acceptance thresholds on the generated dungeon's
+// metrics. Generate retries with consecutive seeds, starting at the config
+// seed, until a dungeon meets every gate. Gates only choose the seed, never
+// change the dungeon a seed generates, so they are left out of the config
+// hash. Zero values accept the first dungeon.
+type QualityCfg struct {
+	// Gates are the thresholds every accepted dungeon meets.
+	Gates []QualityGate `yaml:"gates,omitempty" json:"gates,omitempty"`
+
+	// MaxAttempts is the number of seeds tried, including the accepted one
+	// (0 = DefaultQualityAttempts, else 1 to MaxQualityAttempts).
+	MaxAttempts int `yaml:"maxAttempts,omitempty" json:"maxAttempts,omitempty"`
+}
+
+// QualityGate bounds one metric, by its name in camelCase such as
+// "pathLength" (see QualityMetrics). Either bound may be omitted.
+type QualityGate struct {
+	Metric string   `yaml:"metric" json:"metric"`
+	Min    *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max    *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// String formats the gate, e.g. "pathLength >= 8".
+func (q QualityGate) String() string {
+	switch {
+	case q.Min != nil && q.Max != nil:
+		return fmt.Sprintf("%g <= %s <= %g", *q.Min, q.Metric, *q.Max)
+	case q.Min != nil:
+		return fmt.Sprintf("%s >= %g", q.Metric, *q.Min)
+	case q.Max != nil:
+		return fmt.Sprintf("%s <= %g", q.Metric, *q.Max)
+	}
+	return q.Metric
+}
+
+// qualityMetrics reads each gateable metric by name.
+var qualityMetrics = map[string]func(*Metrics) float64{
+	"branchingFactor":        func(m *Metrics) float64 { return m.BranchingFactor },
+	"pathLength":             func(m *Metrics) float64 { return float64(m.PathLength) },
+	"cycleCount":             func(m *Metrics) float64 { return float64(m.CycleCount) },
+	"pacingDeviation":        func(m *Metrics) float64 { return m.PacingDeviation },
+	"secretFindability":      func(m *Metrics) float64 { return m.SecretFindability },
+	"speedrunRooms":          func(m *Metrics) float64 { return float64(m.SpeedrunRooms) },
+	"speedrunTiles":          func(m *Metrics) float64 { return float64(m.SpeedrunTiles) },
+	"speedrunRevisits":       func(m *Metrics) float64 { return float64(m.SpeedrunRevisits) },
+	"symmetryScore":          func(m *Metrics) float64 { return m.SymmetryScore },
+	"teamBalance":            func(m *Metrics) float64 { return m.TeamBalance },
+	"environmentShare":       func(m *Metrics) float64 { return m.EnvironmentShare },
+	"floorArea":              func(m *Metrics) float64 { return float64(m.FloorArea) },
+	"corridorLength":         func(m *Metrics) float64 { return float64(m.CorridorLength) },
+	"corridorLengthVariance": func(m *Metrics) float64 { return m.CorridorLengthVariance },
+	"boundsUtilization":      func(m *Metrics) float64 { return m.BoundsUtilization },
+	"corridorCrossings":      func(m *Metrics) float64 { return float64(m.CorridorCrossings) },
+	"roomSpacing":            func(m *Metrics) float64 { return m.RoomSpacing },
+}
+
+// QualityMetrics returns the metric names quality gates accept, sorted.
+func QualityMetrics() []string {
+	names := make([]string, 0, len(qualityMetrics))
+	for name := range qualityMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks QualityCfg constraints.
+func (q *QualityCfg) Validate() error {
+	for i, gate := range q.Gates {
+		if _, ok := qualityMetrics[gate.Metric]; !ok {
+			return fmt.Errorf("gates[%d]: unknown metric %q, must be one of: %s", i, gate.Metric, strings.Join(QualityMetrics(), ", "))
+		}
+		if gate.Min == nil && gate.Max == nil {
+			return fmt.Errorf("gates[%d]: %s needs a min or max", i, gate.Metric)
+		}
+		if gate.Min != nil && gate.Max != nil && *gate.Min > *gate.Max {
+			return fmt.Errorf("gates[%d]: %s min %g exceeds max %g", i, gate.Metric, *gate.Min, *gate.Max)
+		}
+	}
+	if q.MaxAttempts < 0 || q.MaxAttempts > MaxQualityAttempts {
+		return fmt.Errorf("maxAttempts must be in range [0, %d], got %d", MaxQualityAttempts, q.MaxAttempts)
+	}
+	return nil
+}
+
+// attempts returns the number of seeds to try.
+func (q *QualityCfg) attempts() int {
+	if q.MaxAttempts == 0 {
+		return DefaultQualityAttempts
+	}
+	return q.MaxAttempts
+}
+
+// GateResult is a quality gate a dungeon failed, with the metric's value.
+type GateResult struct {
+	Gate  QualityGate
+	Value float64
+}
+
+func (r GateResult) String() string {
+	return fmt.Sprintf("%s is %g, want %s", r.Gate.Metric, r.Value, r.Gate)
+}
+
+// QualityError reports the attempt that came closest to meeting the quality
+// gates when every attempt failed them. It matches ErrQualityGate.
+type QualityError struct {
+	Seed     uint64       // Seed of the closest attempt
+	Artifact *Artifact    // Dungeon of the closest attempt, valid but below the gates
+	Failed   []GateResult // Gates the closest attempt failed
+}
+
+func (e *QualityError) Error() string {
+	failed := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		failed[i] = f.String()
+	}
+	return fmt.Sprintf("quality gates not met, closest was seed %d: %s", e.Seed, strings.Join(failed, "; "))
+}
+
+func (e *QualityError) Is(target error) bool {
+	return target == ErrQualityGate
+}
+
+// QualityRetry records a generation whose quality gates rejected the
+// dungeons of earlier seeds.
+type QualityRetry struct {
+	Seed     uint64 `json:"seed"`     // Seed of the accepted dungeon
+	Rejected int    `json:"rejected"` // Attempts rejected before it
+}
+
+// checkGates returns the gates the metrics fail and how far they miss them
+// in total, each miss relative to its bound.
+func checkGates(gates []QualityGate, m *Metrics) ([]GateResult, float64) {
+	var failed []GateResult
+	shortfall := 0.0
+	for _, gate := range gates {
+		value := qualityMetrics[gate.Metric](m)
+		miss := 0.0
+		if gate.Min != nil && value < *gate.Min {
+			miss = (*gate.Min - value) / math.Max(math.Abs(*gate.Min), 1)
+		}
+		if gate.Max != nil && value > *gate.Max {
+			miss = (value - *gate.Max) / math.Max(math.Abs(*gate.Max), 1)
+		}
+		if miss > 0 {
+			failed = append(failed, GateResult{Gate: gate, Value: value})
+			shortfall += miss
+		}
+	}
+	return failed, shortfall
+}
+
+// generateGated generates dungeons from consecutive seeds until one meets
+// cfg's quality gates. A seed that fails to generate is skipped like one
+// below the gates. When every attempt fails it returns a StageError for the
+// "validation" stage wrapping a QualityError for the closest attempt, or
+// the last failure if no attempt generated.
+func (g *DefaultGenerator) generateGated(ctx context.Context, cfg *Config) (*Artifact, error) {
+	attempts := cfg.Quality.attempts()
+	candidate := *cfg
+	var closest *QualityError
+	closestShortfall := math.Inf(1)
+	var last error
+	for rejected := 0; rejected < attempts; rejected++ {
+		candidate.Seed = cfg.Seed + uint64(rejected)
+		artifact, err := g.generate(ctx, &candidate)
+		if errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			last = err
+			continue
+		}
+
+		failed, shortfall := checkGates(cfg.Quality.Gates, artifact.Metrics)
+		if len(failed) == 0 {
+			if rejected > 0 {
+				artifact.Debug.Quality = &QualityRetry{Seed: candidate.Seed, Rejected: rejected}
+			}
+			return artifact, nil
+		}
+		if shortfall < closestShortfall {
+			closest = &QualityError{Seed: candidate.Seed, Artifact: artifact, Failed: failed}
+			closestShortfall = shortfall
+		}
+	}
+
+	if closest != nil {
+		last = closest
+	}
+	return nil, &StageError{Stage: "validation", Attempts: attempts, Err: last}
+}
@@ -0,0 +1,203 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+	"github.com/dshills/dungo/pkg/synthesis"
+)
+
+// Rebalance re-paces an existing dungeon for a new difficulty curve without
+// changing the map, for live games that adapt difficulty to player skill.
+// Room difficulties are recomputed from pacing, content is placed again and
+// the result is re-validated. The room graph, layout and tile map are reused
+// unchanged, so hazards painted from environment budgets stay in place and
+// enemies cover the remaining difficulty.
+//
+// cfg must be the configuration the artifact was generated with, and gen must
+// be the *DefaultGenerator whose content pass and validator are used. The
+// input artifact is not modified. Rebalancing is deterministic: the same
+// artifact, cfg and pacing produce identical content.
+func Rebalance(ctx context.Context, gen Generator, artifact *Artifact, cfg *Config, pacing PacingCfg) (*Artifact, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("rebalance requires a *DefaultGenerator, got %T", gen)
+	}
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil || artifact.Layout == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact must have a graph, layout and tile map")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+
+	rebalanced := *cfg
+	rebalanced.Pacing = pacing
+	if err := rebalanced.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+
+	configHash := rebalanced.Hash()
+	pacingRNG := rng.NewRNG(rebalanced.Seed, "rebalance", configHash)
+	contentRNG := rng.NewRNG(rebalanced.Seed, "content", configHash)
+
+	// Recompute difficulties on a copy of the graph
+	adg := copyGraph(artifact.ADG.Graph)
+	if err := repaceDifficulty(adg, pacing, pacingRNG); err != nil {
+		return nil, fmt.Errorf("repacing difficulty: %w", err)
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	// Place content against the existing map
+	tileMap := convertToCarvingTileMap(artifact.TileMap)
+	layout := convertToCarvingLayout(artifact.Layout)
+	contentData, err := g.placeContent(ctx, &rebalanced, adg, tileMap, layout, contentRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Artifact{
+		ADG:      &Graph{Graph: adg},
+		Layout:   artifact.Layout,
+		TileMap:  artifact.TileMap,
+		Content:  contentData,
+		Metadata: cfg.Metadata.clone(),
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	if err := g.validate(ctx, result, &rebalanced); err != nil {
+		return nil, err
+	}
+	result.Debug.Stages = append(inheritedStages(artifact, "rebalance", "content"),
+		stageSeed(&rebalanced, "rebalance"), stageSeed(&rebalanced, "content"))
+
+	return result, nil
+}
+
+// copyGraph returns a copy of g whose rooms, room tags and connectors can be
+// modified independently, as carving tags secret rooms and opens hidden
+// connectors. Requirements and gates are shared with g, read-only.
+func copyGraph(g *graph.Graph) *graph.Graph {
+	c := graph.NewGraph(g.Seed)
+	for id, room := range g.Rooms {
+		copied := *room
+		if room.Tags != nil {
+			copied.Tags = maps.Clone(room.Tags)
+		}
+		c.Rooms[id] = &copied
+	}
+	for id, conn := range g.Connectors {
+		copied := *conn
+		c.Connectors[id] = &copied
+	}
+	for id, neighbors := range g.Adjacency {
+		c.Adjacency[id] = append([]string(nil), neighbors...)
+	}
+	for k, v := range g.Metadata {
+		c.Metadata[k] = v
+	}
+	return c
+}
+
+// repaceDifficulty assigns every room a difficulty from the pacing curve.
+//
+// Algorithm:
+//  1. Measure each room's distance in connectors from the nearest Start room
+//  2. Take progress as that distance over the Boss room's distance (capped
+//     at 1.0; unreachable rooms count as 1.0)
+//  3. Evaluate the curve at that progress with the pacing variance
+//  4. Give team B arena rooms the difficulty of their team A mirror, so the
+//     halves stay symmetric
+//
+// Rooms are processed in ID order for deterministic RNG consumption.
+func repaceDifficulty(g *graph.Graph, pacing PacingCfg, rng *rng.RNG) error {
+	curve, err := synthesis.NewPacingCurve(synthesis.PacingConfig{
+		Curve:        string(pacing.Curve),
+		Variance:     pacing.Variance,
+		CustomPoints: pacing.CustomPoints,
+	})
+	if err != nil {
+		return fmt.Errorf("creating pacing curve: %w", err)
+	}
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	// Step 1: Multi-source BFS from the Start rooms
+	dist := make(map[string]int)
+	queue := []string{}
+	bossID := ""
+	for _, id := range roomIDs {
+		switch g.Rooms[id].Archetype {
+		case graph.ArchetypeStart:
+			dist[id] = 0
+			queue = append(queue, id)
+		case graph.ArchetypeBoss:
+			if bossID == "" {
+				bossID = id
+			}
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range g.Adjacency[id] {
+			if _, seen := dist[next]; !seen {
+				dist[next] = dist[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	bossDist, ok := dist[bossID]
+	if bossID == "" || !ok || bossDist == 0 {
+		return fmt.Errorf("no path from Start to Boss")
+	}
+
+	// Step 2 and 3: Difficulty from progress
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		if room.Tags["team"] == "b" {
+			continue
+		}
+		progress := 1.0
+		if d, reached := dist[id]; reached {
+			progress = math.Min(1.0, float64(d)/float64(bossDist))
+		}
+		room.Difficulty = synthesis.EvaluateWithVariance(curve, progress, pacing.Variance, rng)
+	}
+
+	// Step 4: Mirror arena halves
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		if room.Tags["team"] != "b" {
+			continue
+		}
+		twin, ok := g.Rooms[room.Tags["mirror"]]
+		if !ok {
+			return fmt.Errorf("arena room %s has no mirror", id)
+		}
+		room.Difficulty = twin.Difficulty
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// RecarveOptions selects the carving settings Recarve changes.
+type RecarveOptions struct {
+	// TileWidth and TileHeight are the tile size in pixels, which the pixel
+	// coordinates of door, secret and biome objects follow (0 = keep the
+	// artifact's).
+	TileWidth  int
+	TileHeight int
+
+	// Decorations overrides the decoration rules of the themes it names,
+	// by theme name; an empty rule list leaves a theme undecorated. Other
+	// themes keep their own rules.
+	Decorations map[string][]themes.Decorator
+
+	// Content places content again on the new map. Otherwise the
+	// artifact's content is kept, as the floor plan does not change.
+	// Artifacts without content always get it placed.
+	Content bool
+}
+
+// Recarve rasterizes a carved artifact's graph and layout again with other
+// carving settings, so late art-direction changes - a new tile size or new
+// decorations - don't invalidate the level structure. The graph and
+// layout are reused unchanged: rooms, corridors, doors and secrets stay
+// where they are, and only the tile map is rebuilt. The result is
+// re-validated. Recarving with the settings the artifact was carved with
+// reproduces its tile map, except the decorations of a repacked map (see
+// MapCfg.Repack): its rooms moved after they were decorated.
+//
+// cfg must be the configuration the artifact was generated with, and gen
+// must be the *DefaultGenerator whose content pass and validator are used.
+// Content is placed from the config's content stream, as Generate places
+// it. A cfg.StopAfter of carving or content ends the pipeline there. The
+// input artifact is not modified.
+func Recarve(ctx context.Context, gen Generator, artifact *Artifact, cfg *Config, opts RecarveOptions) (*Artifact, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("recarving requires a *DefaultGenerator, got %T", gen)
+	}
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil || artifact.Layout == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact must have a graph, layout and tile map")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("recarving does not support zoned configs")
+	}
+	if opts.TileWidth < 0 || opts.TileHeight < 0 {
+		return nil, fmt.Errorf("tile size must not be negative, got %dx%d", opts.TileWidth, opts.TileHeight)
+	}
+
+	tileWidth, tileHeight := opts.TileWidth, opts.TileHeight
+	if tileWidth == 0 {
+		tileWidth = artifact.TileMap.TileWidth
+	}
+	if tileHeight == 0 {
+		tileHeight = artifact.TileMap.TileHeight
+	}
+	carver := carving.NewDefaultCarver(tileWidth, tileHeight).
+		WithThemeLoader(themes.NewLoader(themes.DefaultPackDir)).
+		WithDecorations(opts.Decorations)
+
+	// Carving only reads the graph and layout, which the carving stage of
+	// Generate already fitted to each other
+	adg := copyGraph(artifact.ADG.Graph)
+	graphAdapter := carving.NewGraphAdapter(adg.Rooms, adg.Connectors)
+	layout := convertToCarvingLayout(artifact.Layout)
+	tileMapInternal, err := runStage(ctx, cfg, "carving", func(ctx context.Context) (*carving.TileMap, error) {
+		tm, err := carver.Carve(ctx, graphAdapter, layout)
+		if err != nil {
+			return nil, stageError("carving", err)
+		}
+		return tm, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	tileMap := convertCarvingTileMap(tileMapInternal)
+	if cfg.StopAfter == StageCarving {
+		return partialArtifact(cfg, StageCarving, adg, artifact.Layout, tileMap, nil), nil
+	}
+
+	contentData := artifact.Content
+	if opts.Content || contentData == nil {
+		contentData, err = runStage(ctx, cfg, "content", func(ctx context.Context) (*Content, error) {
+			return g.placeContent(ctx, cfg, adg, tileMapInternal, layout, stageRNG(ctx, cfg, "content"))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.StopAfter == StageContent {
+		return partialArtifact(cfg, StageContent, adg, artifact.Layout, tileMap, contentData), nil
+	}
+
+	result := &Artifact{
+		ADG:      &Graph{Graph: adg},
+		Layout:   artifact.Layout,
+		TileMap:  tileMap,
+		Content:  contentData,
+		Metadata: cfg.Metadata.clone(),
+	}
+
+	if _, err := runStage(ctx, cfg, "validation", func(ctx context.Context) (*Artifact, error) {
+		return result, g.validate(ctx, result, cfg)
+	}); err != nil {
+		return nil, err
+	}
+	result.Debug.Stages = inheritedStages(artifact)
+	if artifact.Debug != nil {
+		result.Debug.Embedding = artifact.Debug.Embedding
+	}
+	return result, nil
+}
@@ -0,0 +1,143 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// RemixMode selects what a remix re-rolls.
+type RemixMode string
+
+const (
+	// RemixLayout keeps the room graph - its rooms, archetypes, keys and
+	// connectors - and re-rolls the embedding, carving and content.
+	RemixLayout RemixMode = "layout"
+
+	// RemixContent keeps the graph, layout and tile map and re-rolls the
+	// content only.
+	RemixContent RemixMode = "content"
+)
+
+// RemixOptions configures Remix.
+type RemixOptions struct {
+	// Mode selects what is re-rolled. Empty means RemixLayout.
+	Mode RemixMode
+
+	// SubSeed selects the remix: each sub-seed draws its own streams,
+	// derived from the config seed, hash and sub-seed alone, so remixing
+	// with the same sub-seed reproduces the same dungeon.
+	SubSeed uint64
+}
+
+// Remix derives a New Game+ variant from a known-good dungeon. RemixLayout
+// lays the same room graph out again: rooms are embedded, carved,
+// decorated and filled with content from the sub-seed's streams, so the
+// route through the graph is unchanged but the map is new. RemixContent
+// re-dresses the existing map like Variant. Either way the result is
+// re-validated.
+//
+// The graph is the artifact's own, less the passages carving added to it:
+// connectors through breakable shared walls and passages synced from the
+// carved floor are dropped and found again on the new map. Graphs with
+// corridor junctions cannot be laid out again.
+//
+// cfg must be the configuration the artifact was generated with, and gen
+// must be the *DefaultGenerator whose embedder, carver, content pass and
+// validator are used. Entity IDs keep following the config seed, so
+// entities of the same type in the same room keep their IDs. The input
+// artifact is not modified.
+func Remix(ctx context.Context, gen Generator, artifact *Artifact, cfg *Config, opts RemixOptions) (*Artifact, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("remixes require a *DefaultGenerator, got %T", gen)
+	}
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return nil, fmt.Errorf("artifact must have a graph")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("remixes do not support zoned configs")
+	}
+
+	switch opts.Mode {
+	case RemixContent:
+		if artifact.Layout == nil || artifact.TileMap == nil {
+			return nil, fmt.Errorf("artifact must have a layout and tile map")
+		}
+		result, err := g.redress(ctx, artifact, cfg, remixRNG(cfg, opts.SubSeed, "content"))
+		if err != nil {
+			return nil, fmt.Errorf("remix %d: %w", opts.SubSeed, err)
+		}
+		result.Debug.Stages = append(inheritedStages(artifact, "content"), stageSeed(cfg, remixStage(opts.SubSeed, "content")))
+		return result, nil
+	case "", RemixLayout:
+	default:
+		return nil, fmt.Errorf("unknown remix mode %q, must be one of: layout, content", opts.Mode)
+	}
+
+	adg, err := remixGraph(artifact.ADG.Graph)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := g.embed(ctx, cfg, adg, remixRNG(cfg, opts.SubSeed, "embedding"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	result, err := g.finish(ctx, cfg, adg, layout, remixRNG(cfg, opts.SubSeed, "carving"), remixRNG(cfg, opts.SubSeed, "content"))
+	if err != nil {
+		return nil, fmt.Errorf("remix %d: %w", opts.SubSeed, err)
+	}
+	result.Debug.Stages = append(inheritedStages(artifact, "embedding", "content"),
+		stageSeed(cfg, remixStage(opts.SubSeed, "embedding")),
+		stageSeed(cfg, remixStage(opts.SubSeed, "content")))
+	return result, nil
+}
+
+// remixGraph returns a copy of a carved artifact's graph as synthesis left
+// it, dropping the connectors carving added for breakable shared walls and
+// synced passages. It fails for graphs with corridor junctions, which
+// carving cut into the corridors.
+func remixGraph(g *graph.Graph) (*graph.Graph, error) {
+	adg := copyGraph(g)
+	for id, room := range adg.Rooms {
+		if room.Tags[graph.JunctionTag] != "" {
+			return nil, fmt.Errorf("cannot remix the layout of a graph with corridor junctions (room %s)", id)
+		}
+	}
+	for id := range adg.Connectors {
+		if strings.HasPrefix(id, "breakable_") || strings.HasPrefix(id, "passage_") {
+			if err := adg.RemoveConnector(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return adg, nil
+}
+
+// remixRNG returns the RNG of a pipeline stage of a remix.
+func remixRNG(cfg *Config, subSeed uint64, stage string) *rng.RNG {
+	return rng.NewRNG(cfg.Seed, remixStage(subSeed, stage), cfg.Hash())
+}
+
+// remixStage returns the RNG stage name of a remix's pipeline stage, e.g.
+// "remix_3_embedding".
+func remixStage(subSeed uint64, stage string) string {
+	return fmt.Sprintf("remix_%d_%s", subSeed, stage)
+}
@@ -0,0 +1,217 @@
+package dungeon
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// StringTable holds the human-readable text generated for a dungeon, keyed
+// by stable string IDs, with the default English text as values. Games
+// localize generated content by translating the table and looking text up
+// by ID instead of displaying the English strings directly.
+//
+// IDs depend only on the IDs of the rooms and entities they describe, so
+// the same seed and config produce the same IDs:
+//
+//   - "room.<room ID>.name": the room's display name (see RoomNameID)
+//   - "secret.<secret ID>.clue.<n>": the n-th clue of a secret, referenced
+//     from SecretInstance.ClueIDs
+//   - "key.<key name>.name" and "key.<key name>.description": the display
+//     name and description of a configured key (see KeyNameID), for keys
+//     placed as loot
+type StringTable map[string]string
+
+// RoomNameID returns the string ID of a room's display name.
+func RoomNameID(roomID string) string {
+	return "room." + roomID + ".name"
+}
+
+// ClueID returns the string ID of the n-th (0-based) clue of a secret.
+func ClueID(secretID string, n int) string {
+	return fmt.Sprintf("secret.%s.clue.%d", secretID, n)
+}
+
+// KeyNameID returns the string ID of a key's display name, by the key's
+// KeyCfg.Name.
+func KeyNameID(key string) string {
+	return "key." + key + ".name"
+}
+
+// KeyDescriptionID returns the string ID of a key's description. The table
+// has it only for keys configured with a description.
+func KeyDescriptionID(key string) string {
+	return "key." + key + ".description"
+}
+
+// Text returns the text of a string ID, or the ID itself when the table
+// has no entry for it, so missing translations stay visible.
+func (t StringTable) Text(id string) string {
+	if text, ok := t[id]; ok {
+		return text
+	}
+	return id
+}
+
+// IDs returns the string IDs in the table, sorted.
+func (t StringTable) IDs() []string {
+	ids := make([]string, 0, len(t))
+	for id := range t {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ExportJSON serializes the table to JSON with indentation, as a starting
+// point for translation files.
+func (t StringTable) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// SaveJSON writes the table to a JSON file with WriteFile.
+func (t StringTable) SaveJSON(path string, options ...WriteOption) error {
+	data, err := t.ExportJSON()
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, options...)
+}
+
+// RoomName returns the display name of a room from the artifact's string
+// table.
+func (a *Artifact) RoomName(roomID string) string {
+	return a.Strings.Text(RoomNameID(roomID))
+}
+
+// KeyName returns the display name of a key from the artifact's string
+// table.
+func (a *Artifact) KeyName(key string) string {
+	return a.Strings.Text(KeyNameID(key))
+}
+
+// roomNames are the display names of each archetype.
+var roomNames = map[graph.RoomArchetype]string{
+	graph.ArchetypeStart:      "Entrance",
+	graph.ArchetypeBoss:       "Boss Chamber",
+	graph.ArchetypeTreasure:   "Treasure Room",
+	graph.ArchetypePuzzle:     "Puzzle Room",
+	graph.ArchetypeHub:        "Great Hall",
+	graph.ArchetypeCorridor:   "Passage",
+	graph.ArchetypeSecret:     "Hidden Room",
+	graph.ArchetypeOptional:   "Side Chamber",
+	graph.ArchetypeVendor:     "Merchant's Nook",
+	graph.ArchetypeShrine:     "Shrine",
+	graph.ArchetypeCheckpoint: "Waystation",
+	graph.ArchetypeExit:       "Exit Hall",
+}
+
+// clueTexts are the descriptions of the clue props content placement uses.
+// Other clues are already text and are used as-is.
+var clueTexts = map[string]string{
+	"cracked_wall":    "Cracks run through this wall, and a draft seeps between the stones.",
+	"flickering_wall": "The stones of this wall shimmer faintly, as if not quite there.",
+	"hollow_floor":    "The floor rings hollow here, and dust trickles through its cracks.",
+	"scrape_marks":    "Deep scrape marks on the floor lead up to a heavy stone block.",
+	"riddle":          "A riddle is carved into the shrine, beside a small offering bowl.",
+}
+
+// buildStrings fills the artifact's string table from its rooms, content
+// and keys, points each secret's ClueIDs at its clue texts, and marks key
+// loot with its key and icon.
+func buildStrings(artifact *Artifact, keys []KeyCfg) {
+	table := make(StringTable)
+
+	if artifact.ADG != nil && artifact.ADG.Graph != nil {
+		roomIDs := make([]string, 0, len(artifact.ADG.Rooms))
+		count := make(map[graph.RoomArchetype]int)
+		for id, room := range artifact.ADG.Rooms {
+			roomIDs = append(roomIDs, id)
+			count[room.Archetype]++
+		}
+		sort.Strings(roomIDs)
+
+		// Number rooms sharing an archetype in room ID order
+		seen := make(map[graph.RoomArchetype]int)
+		for _, id := range roomIDs {
+			archetype := artifact.ADG.Rooms[id].Archetype
+			name, ok := roomNames[archetype]
+			if !ok {
+				name = archetype.String()
+			}
+			seen[archetype]++
+			if count[archetype] > 1 {
+				name = fmt.Sprintf("%s %d", name, seen[archetype])
+			}
+			table[RoomNameID(id)] = name
+		}
+	}
+
+	if artifact.Content != nil {
+		for i := range artifact.Content.Secrets {
+			secret := &artifact.Content.Secrets[i]
+			secret.ClueIDs = make([]string, len(secret.Clues))
+			for n, clue := range secret.Clues {
+				text, ok := clueTexts[clue]
+				if !ok {
+					text = clue
+				}
+				secret.ClueIDs[n] = ClueID(secret.ID, n)
+				table[secret.ClueIDs[n]] = text
+			}
+		}
+
+		for i := range artifact.Content.Loot {
+			loot := &artifact.Content.Loot[i]
+			key, ok := lootKey(loot.ItemType, keys)
+			if !ok {
+				continue
+			}
+			loot.Key, loot.Icon = key.Name, key.Icon
+			name := key.DisplayName
+			if name == "" {
+				name = defaultKeyName(key.Name)
+			}
+			table[KeyNameID(key.Name)] = name
+			if key.Description != "" {
+				table[KeyDescriptionID(key.Name)] = key.Description
+			}
+		}
+	}
+
+	artifact.Strings = table
+}
+
+// lootKey returns the configured key a loot item type places: "key_<name>"
+// for keys and "small_key_<name>" for small keys.
+func lootKey(itemType string, keys []KeyCfg) (KeyCfg, bool) {
+	for _, key := range keys {
+		prefix := "key_"
+		if key.Consumable {
+			prefix = graph.SmallKey + "_"
+		}
+		if itemType == prefix+key.Name {
+			return key, true
+		}
+	}
+	return KeyCfg{}, false
+}
+
+// defaultKeyName makes a display name from a key name: "silver" becomes
+// "Silver Key" and "crypt_door" "Crypt Door Key".
+func defaultKeyName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || unicode.IsSpace(r) })
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	if len(words) == 0 || !strings.EqualFold(words[len(words)-1], "key") {
+		words = append(words, "Key")
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,107 @@
+package dungeon
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestBuildStrings verifies rooms get numbered archetype names and secret
+// clues are keyed by stable IDs referenced from the secret.
+func TestBuildStrings(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, room := range []*graph.Room{
+		{ID: "R1", Archetype: graph.ArchetypeStart},
+		{ID: "R2", Archetype: graph.ArchetypeTreasure},
+		{ID: "R3", Archetype: graph.ArchetypeTreasure},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatalf("AddRoom: %v", err)
+		}
+	}
+	artifact := &Artifact{
+		ADG: &Graph{Graph: g},
+		Content: &Content{Secrets: []SecretInstance{
+			{ID: "secret_w1", RoomID: "R3", Clues: []string{"cracked_wall", "Strange air flow"}},
+		}},
+	}
+
+	buildStrings(artifact, nil)
+
+	names := map[string]string{"R1": "Entrance", "R2": "Treasure Room 1", "R3": "Treasure Room 2"}
+	for id, want := range names {
+		if got := artifact.RoomName(id); got != want {
+			t.Errorf("RoomName(%s) = %q, want %q", id, got, want)
+		}
+	}
+
+	secret := artifact.Content.Secrets[0]
+	if len(secret.ClueIDs) != 2 || secret.ClueIDs[0] != "secret.secret_w1.clue.0" {
+		t.Fatalf("ClueIDs = %v", secret.ClueIDs)
+	}
+	if got := artifact.Strings.Text(secret.ClueIDs[0]); got != clueTexts["cracked_wall"] {
+		t.Errorf("clue 0 text = %q", got)
+	}
+	if got := artifact.Strings.Text(secret.ClueIDs[1]); got != "Strange air flow" {
+		t.Errorf("clue 1 text = %q, want the clue as-is", got)
+	}
+
+	if ids := artifact.Strings.IDs(); len(ids) != 5 {
+		t.Errorf("IDs() = %v, want 5 strings", ids)
+	}
+	if got := artifact.Strings.Text("room.R9.name"); got != "room.R9.name" {
+		t.Errorf("missing ID should fall back to itself, got %q", got)
+	}
+}
+
+// TestBuildStrings_Keys verifies key loot is marked with its key and icon,
+// and keys get their configured or a default display name.
+func TestBuildStrings_Keys(t *testing.T) {
+	artifact := &Artifact{Content: &Content{Loot: []Loot{
+		{ID: "loot_a", ItemType: "key_silver", Required: true},
+		{ID: "loot_b", ItemType: "small_key_crypt_door", Required: true},
+		{ID: "loot_c", ItemType: "gold"},
+	}}}
+	keys := []KeyCfg{
+		{Name: "silver", Count: 1, DisplayName: "Tarnished Silver Key", Description: "Opens the vault.", Icon: "icons/key_silver"},
+		{Name: "crypt_door", Count: 2, Consumable: true},
+	}
+
+	buildStrings(artifact, keys)
+
+	loot := artifact.Content.Loot
+	if loot[0].Key != "silver" || loot[0].Icon != "icons/key_silver" {
+		t.Errorf("silver key loot = %+v, want key silver with its icon", loot[0])
+	}
+	if loot[1].Key != "crypt_door" || loot[1].Icon != "" {
+		t.Errorf("small key loot = %+v, want key crypt_door without an icon", loot[1])
+	}
+	if loot[2].Key != "" {
+		t.Errorf("gold loot = %+v, want no key", loot[2])
+	}
+
+	if got := artifact.KeyName("silver"); got != "Tarnished Silver Key" {
+		t.Errorf("KeyName(silver) = %q", got)
+	}
+	if got := artifact.Strings.Text(KeyDescriptionID("silver")); got != "Opens the vault." {
+		t.Errorf("silver description = %q", got)
+	}
+	if got := artifact.KeyName("crypt_door"); got != "Crypt Door Key" {
+		t.Errorf("KeyName(crypt_door) = %q, want the default name", got)
+	}
+	if _, ok := artifact.Strings[KeyDescriptionID("crypt_door")]; ok {
+		t.Error("key without a description has one in the table")
+	}
+}
+
+func TestDefaultKeyName(t *testing.T) {
+	for name, want := range map[string]string{
+		"silver":     "Silver Key",
+		"crypt_door": "Crypt Door Key",
+		"boss-key":   "Boss Key",
+	} {
+		if got := defaultKeyName(name); got != want {
+			t.Errorf("defaultKeyName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package dungeon
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// testSeedFS holds the blessed seed corpus: configs with fixed seeds that
+// generate dungeons passing validation with metrics in known ranges.
+//
+//go:embed testseeds/*.yaml
+var testSeedFS embed.FS
+
+// MetricRange is an inclusive range of expected metric values.
+type MetricRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Contains reports whether v lies within the range.
+func (r MetricRange) Contains(v float64) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// TestSeed is one config of the blessed seed corpus with the ranges its
+// dungeon's metrics are expected to fall in. Downstream integrations run
+// the corpus as a smoke test: generate each config and Check the result.
+type TestSeed struct {
+	Name   string  // Config file name without extension, e.g. "small_crypt"
+	Config *Config // Validated config with the corpus seed
+
+	Rooms           MetricRange // Room count, the config's size bounds
+	BranchingFactor MetricRange // Metrics.BranchingFactor
+	PathLength      MetricRange // Metrics.PathLength
+	PacingDeviation MetricRange // Metrics.PacingDeviation
+}
+
+// testSeedRanges are the expected branching factor, start to boss path
+// length and pacing deviation of each corpus config, with headroom around
+// the values of its first five seeds so tuning does not break downstream
+// smoke tests.
+var testSeedRanges = map[string]struct{ branching, path, pacing MetricRange }{
+	"golden_seed_1":    {MetricRange{Min: 1.7, Max: 2.1}, MetricRange{Min: 1, Max: 10}, MetricRange{Min: 0, Max: 0.2}},
+	"golden_seed_2":    {MetricRange{Min: 1.7, Max: 2.1}, MetricRange{Min: 1, Max: 15}, MetricRange{Min: 0, Max: 0.25}},
+	"golden_seed_3":    {MetricRange{Min: 1.7, Max: 2.2}, MetricRange{Min: 1, Max: 20}, MetricRange{Min: 0, Max: 0.3}},
+	"golden_seed_4":    {MetricRange{Min: 1.6, Max: 2.1}, MetricRange{Min: 1, Max: 10}, MetricRange{Min: 0, Max: 0.15}},
+	"golden_seed_5":    {MetricRange{Min: 1.7, Max: 2.2}, MetricRange{Min: 1, Max: 25}, MetricRange{Min: 0, Max: 0.25}},
+	"large_dual_biome": {MetricRange{Min: 1.7, Max: 2.2}, MetricRange{Min: 1, Max: 25}, MetricRange{Min: 0, Max: 0.3}},
+	"medium_fungal":    {MetricRange{Min: 1.7, Max: 2.1}, MetricRange{Min: 1, Max: 20}, MetricRange{Min: 0, Max: 0.25}},
+	"small_crypt":      {MetricRange{Min: 1.7, Max: 2.1}, MetricRange{Min: 1, Max: 15}, MetricRange{Min: 0, Max: 0.25}},
+}
+
+// TestSeeds returns the blessed seed corpus in name order, each config
+// freshly loaded so callers may modify it.
+func TestSeeds() ([]TestSeed, error) {
+	entries, err := testSeedFS.ReadDir("testseeds")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+
+	seeds := make([]TestSeed, 0, len(names))
+	for _, name := range names {
+		data, err := testSeedFS.ReadFile(path.Join("testseeds", name+".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := LoadConfigFromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("test seed %s: %w", name, err)
+		}
+		ranges, ok := testSeedRanges[name]
+		if !ok {
+			return nil, fmt.Errorf("test seed %s has no expected ranges", name)
+		}
+		seeds = append(seeds, TestSeed{
+			Name:            name,
+			Config:          cfg,
+			Rooms:           MetricRange{Min: float64(cfg.Size.RoomsMin), Max: float64(cfg.Size.RoomsMax)},
+			BranchingFactor: ranges.branching,
+			PathLength:      ranges.path,
+			PacingDeviation: ranges.pacing,
+		})
+	}
+	return seeds, nil
+}
+
+// Check returns an error naming every way the artifact generated from the
+// seed's config falls short: failing validation or a metric out of range.
+func (s TestSeed) Check(artifact *Artifact) error {
+	if artifact == nil || artifact.ADG == nil || artifact.Debug.Report == nil || artifact.Debug.Report.Metrics == nil {
+		return fmt.Errorf("test seed %s: artifact must have a graph and validation report with metrics", s.Name)
+	}
+	report := artifact.Debug.Report
+
+	var errs []error
+	if !report.Passed {
+		errs = append(errs, fmt.Errorf("validation failed: %v", report.Errors))
+	}
+	metrics := []struct {
+		name  string
+		value float64
+		want  MetricRange
+	}{
+		{"rooms", float64(len(artifact.ADG.Rooms)), s.Rooms},
+		{"branching factor", report.Metrics.BranchingFactor, s.BranchingFactor},
+		{"path length", float64(report.Metrics.PathLength), s.PathLength},
+		{"pacing deviation", report.Metrics.PacingDeviation, s.PacingDeviation},
+	}
+	for _, m := range metrics {
+		if !m.want.Contains(m.value) {
+			errs = append(errs, fmt.Errorf("%s %g outside [%g, %g]", m.name, m.value, m.want.Min, m.want.Max))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("test seed %s: %w", s.Name, errors.Join(errs...))
+	}
+	return nil
+}
@@ -77,7 +77,7 @@ func (a *Artifact) RenderText() string {
 
 		// Show if gated
 		if conn.Gate != nil {
-			sb.WriteString(fmt.Sprintf(" 🔒 Requires %s:%s", conn.Gate.Type, conn.Gate.Value))
+			sb.WriteString(fmt.Sprintf(" 🔒 Requires %s", conn.Gate))
 		}
 
 		// Show if secret
@@ -100,6 +100,7 @@ func (a *Artifact) RenderText() string {
 		sb.WriteString(fmt.Sprintf("   Loot: %d items\n", len(a.Content.Loot)))
 		sb.WriteString(fmt.Sprintf("   Puzzles: %d\n", len(a.Content.Puzzles)))
 		sb.WriteString(fmt.Sprintf("   Secrets: %d\n", len(a.Content.Secrets)))
+		sb.WriteString(fmt.Sprintf("   Traps: %d\n", len(a.Content.Traps)))
 
 		// Show required items (keys)
 		requiredItems := 0
@@ -239,6 +240,8 @@ func getRoomSymbol(archetype graph.RoomArchetype) string {
 		return "⛩️"
 	case graph.ArchetypeCheckpoint:
 		return "💾"
+	case graph.ArchetypeExit:
+		return "🏁"
 	default:
 		return "❓"
 	}
@@ -0,0 +1,124 @@
+package dungeon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// Timer kinds.
+const (
+	TimerPatrol    = "patrol"     // One lap of a spawn's patrol path
+	TimerTrapRearm = "trap_rearm" // Delay before a triggered trap re-arms
+	TimerDoorClose = "door_close" // Delay before an opened door closes again
+)
+
+// Timing defaults, in milliseconds of game time. Trap re-arm and door
+// auto-close delays shrink with the difficulty of their room, down to half
+// at difficulty 1.
+const (
+	PatrolTileMs = 500  // Time a patrol takes to walk one tile
+	TrapRearmMs  = 8000 // Re-arm delay of a trap in a room of difficulty 0
+	DoorCloseMs  = 6000 // Auto-close delay of a door of a room of difficulty 0
+)
+
+// Timer is the schedule of a dynamic element. Clients run each timer from
+// the moment the level starts: the element's first cycle begins at OffsetMs
+// and each later one PeriodMs after the last. Times are whole milliseconds,
+// so every client reading the artifact derives the same schedule.
+type Timer struct {
+	ID       string // Entity the timer drives: a spawn or trap ID, or a door object name
+	Kind     string // TimerPatrol, TimerTrapRearm or TimerDoorClose
+	RoomID   string // Room of the entity (the room a door opens from for door timers)
+	PeriodMs int    // Length of one cycle (always positive)
+	OffsetMs int    // Start of the first cycle, in [0, PeriodMs)
+}
+
+// assignTimers schedules the dynamic elements of placed content: spawns with
+// a patrol path, traps, and the door objects of the tile map. Offsets are
+// drawn from a hash of the seed and the entity ID, not from a stage RNG, so
+// the schedule of an entity never depends on the content around it.
+func assignTimers(c *Content, tm *carving.TileMap, g *graph.Graph) {
+	if c == nil || g == nil {
+		return
+	}
+	difficulty := func(roomID string) float64 {
+		if room, ok := g.Rooms[roomID]; ok {
+			return room.Difficulty
+		}
+		return 0
+	}
+
+	c.Timers = nil
+	add := func(id, kind, roomID string, periodMs int) {
+		periodMs = max(1, periodMs)
+		c.Timers = append(c.Timers, Timer{
+			ID:       id,
+			Kind:     kind,
+			RoomID:   roomID,
+			PeriodMs: periodMs,
+			OffsetMs: timerOffset(g.Seed, kind, id, periodMs),
+		})
+	}
+
+	for _, spawn := range c.Spawns {
+		if tiles := patrolLength(spawn.PatrolPath); tiles > 0 {
+			add(spawn.ID, TimerPatrol, spawn.RoomID, tiles*PatrolTileMs)
+		}
+	}
+	for _, trap := range c.Traps {
+		add(trap.ID, TimerTrapRearm, trap.RoomID, scaledDelay(TrapRearmMs, difficulty(trap.RoomID)))
+	}
+	if tm != nil {
+		if layer, ok := tm.Layers["doors"]; ok {
+			for _, obj := range layer.Objects {
+				// The door at a corridor's start belongs to its From room
+				room, _ := obj.Properties["from_room"].(string)
+				if strings.HasSuffix(obj.Name, "_end") {
+					room, _ = obj.Properties["to_room"].(string)
+				}
+				add(obj.Name, TimerDoorClose, room, scaledDelay(DoorCloseMs, difficulty(room)))
+			}
+		}
+	}
+}
+
+// patrolLength returns the tiles walked in one lap of a looping patrol
+// path, back to its first waypoint. Waypoints are room corners, so legs run
+// along rows and columns.
+func patrolLength(path []Point) int {
+	if len(path) < 2 {
+		return 0
+	}
+	tiles := 0
+	for i, from := range path {
+		to := path[(i+1)%len(path)]
+		dx, dy := to.X-from.X, to.Y-from.Y
+		tiles += int(math.Abs(float64(dx)) + math.Abs(float64(dy)))
+	}
+	return tiles
+}
+
+// scaledDelay shortens a base delay with room difficulty (0.0-1.0), down to
+// half the base at difficulty 1.
+func scaledDelay(baseMs int, difficulty float64) int {
+	difficulty = math.Min(1, math.Max(0, difficulty))
+	return int(float64(baseMs) * (1 - difficulty/2))
+}
+
+// timerOffset returns the phase of an entity's timer within its period,
+// from a hash of the seed, the timer kind and the entity ID.
+func timerOffset(seed uint64, kind, id string, periodMs int) int {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seed)
+	h.Write(buf[:])
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	return int(binary.BigEndian.Uint64(h.Sum(nil)) % uint64(periodMs))
+}
@@ -0,0 +1,122 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// Variant re-dresses an existing dungeon: the room graph, layout and tile
+// map are reused unchanged while content - enemy spawns and their
+// compositions, loot rolls, traps, puzzles and secrets - is placed again
+// from a variation sub-seed, and the result is re-validated. Variants of one
+// dungeon form a family sharing a route, for daily-challenge modes that
+// keep the map and change the encounters.
+//
+// Variation 0 reproduces the content Generate placed; every other variation
+// draws its own content stream, derived from the config seed, hash and
+// variation alone. cfg must be the configuration the artifact was generated
+// with, and gen must be the *DefaultGenerator whose content pass and
+// validator are used. The input artifact is not modified.
+func Variant(ctx context.Context, gen Generator, artifact *Artifact, cfg *Config, variation uint64) (*Artifact, error) {
+	g, ok := gen.(*DefaultGenerator)
+	if !ok {
+		return nil, fmt.Errorf("variants require a *DefaultGenerator, got %T", gen)
+	}
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil || artifact.Layout == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact must have a graph, layout and tile map")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, invalidConfig(err)
+	}
+	if cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("variants do not support zoned configs")
+	}
+
+	result, err := g.redress(ctx, artifact, cfg, variationRNG(cfg, variation))
+	if err != nil {
+		return nil, fmt.Errorf("variation %d: %w", variation, err)
+	}
+	result.Debug.Stages = append(inheritedStages(artifact, "content"), stageSeed(cfg, variationStage(variation)))
+
+	return result, nil
+}
+
+// redress places content on a copy of the artifact's graph, reusing its
+// layout and tile map, and validates the result. Debug stages are left to
+// the caller.
+func (g *DefaultGenerator) redress(ctx context.Context, artifact *Artifact, cfg *Config, contentRNG *rng.RNG) (*Artifact, error) {
+	// Content passes may annotate rooms, so each variant gets its own graph
+	adg := copyGraph(artifact.ADG.Graph)
+	tileMap := convertToCarvingTileMap(artifact.TileMap)
+	layout := convertToCarvingLayout(artifact.Layout)
+	contentData, err := g.placeContent(ctx, cfg, adg, tileMap, layout, contentRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Artifact{
+		ADG:      &Graph{Graph: adg},
+		Layout:   artifact.Layout,
+		TileMap:  artifact.TileMap,
+		Content:  contentData,
+		Metadata: cfg.Metadata.clone(),
+	}
+
+	// Check for cancellation
+	select {
+	case <-ctx.Done():
+		return nil, cancelled(ctx)
+	default:
+	}
+
+	if err := g.validate(ctx, result, cfg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateVariants generates the dungeon for cfg once and returns n variants
+// of it, for variations 0 to n-1; see Variant. The first is the artifact
+// Generate returns.
+func GenerateVariants(ctx context.Context, gen Generator, cfg *Config, n int) ([]*Artifact, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("variant count must be positive, got %d", n)
+	}
+	if cfg != nil && cfg.Zones.Size > 0 {
+		return nil, fmt.Errorf("variants do not support zoned configs")
+	}
+
+	base, err := gen.Generate(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := []*Artifact{base}
+	for variation := uint64(1); variation < uint64(n); variation++ {
+		variant, err := Variant(ctx, gen, base, cfg, variation)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// variationRNG returns the content RNG of a variation. Variation 0 is the
+// content stream Generate uses.
+func variationRNG(cfg *Config, variation uint64) *rng.RNG {
+	return rng.NewRNG(cfg.Seed, variationStage(variation), cfg.Hash())
+}
+
+// variationStage returns the RNG stage name of a variation's content.
+func variationStage(variation uint64) string {
+	if variation == 0 {
+		return "content"
+	}
+	return fmt.Sprintf("content_variation_%d", variation)
+}
@@ -0,0 +1,152 @@
+package dungeon
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WarningCode identifies a kind of non-fatal generation issue.
+type WarningCode string
+
+const (
+	// WarnPacing marks room difficulties that stray from the pacing curve.
+	WarnPacing WarningCode = "pacing"
+
+	// WarnBranching marks an average branching factor far from the target.
+	WarnBranching WarningCode = "branching"
+
+	// WarnArchetypes marks room archetype counts outside their targets.
+	WarnArchetypes WarningCode = "archetypes"
+
+	// WarnFloorBudget marks a carved floor area over Rooms.FloorBudget.
+	WarnFloorBudget WarningCode = "floor_budget"
+
+	// WarnEntityPlacement marks entities that found no clear floor tile of
+	// their own in a crowded room, and stand on a wall or share a tile.
+	WarnEntityPlacement WarningCode = "entity_placement"
+
+	// WarnSoftConstraint marks any other soft constraint the validator
+	// reported.
+	WarnSoftConstraint WarningCode = "soft_constraint"
+)
+
+// Warning is a non-fatal issue found while generating a dungeon: a target
+// the dungeon missed or a placement that had to be compromised. The dungeon
+// is still valid; warnings let tools show designers what to tune.
+type Warning struct {
+	Code     WarningCode `json:"code"`
+	Stage    string      `json:"stage"`              // Pipeline stage the issue arose in
+	Message  string      `json:"message"`            // Human-readable description
+	Entities []string    `json:"entities,omitempty"` // IDs of the affected rooms or entities
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s (%s): %s", w.Code, w.Stage, w.Message)
+}
+
+// softConstraintWarnings maps soft constraint kinds to their warning code
+// and the stage responsible for them.
+var softConstraintWarnings = map[string]struct {
+	code  WarningCode
+	stage string
+}{
+	"Pacing":                {WarnPacing, "synthesis"},
+	"BranchingFactor":       {WarnBranching, "synthesis"},
+	"ArchetypeDistribution": {WarnArchetypes, "synthesis"},
+	"FloorBudget":           {WarnFloorBudget, "embedding"},
+}
+
+// collectWarnings gathers the warnings of a validated artifact: the soft
+// constraints the report warns about, then entity placement issues.
+func collectWarnings(artifact *Artifact, report *ValidationReport) []Warning {
+	var warnings []Warning
+
+	warned := make(map[string]bool, len(report.Warnings))
+	for _, w := range report.Warnings {
+		warned[w] = true
+	}
+	for _, result := range report.SoftConstraintResults {
+		if !warned[result.Details] {
+			continue
+		}
+		delete(warned, result.Details)
+		w := Warning{Code: WarnSoftConstraint, Stage: "validation", Message: result.Details}
+		if result.Constraint != nil {
+			if mapped, ok := softConstraintWarnings[result.Constraint.Kind]; ok {
+				w.Code, w.Stage = mapped.code, mapped.stage
+			}
+		}
+		warnings = append(warnings, w)
+	}
+	// Report warnings that no soft constraint result explains, in order
+	for _, w := range report.Warnings {
+		if warned[w] {
+			warnings = append(warnings, Warning{Code: WarnSoftConstraint, Stage: "validation", Message: w})
+		}
+	}
+
+	if w, ok := entityPlacementWarning(artifact.Content, artifact.TileMap); ok {
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+// entityPlacementWarning reports the positioned entities that stand off the
+// floor or share a tile with another entity.
+func entityPlacementWarning(c *Content, tm *TileMap) (Warning, bool) {
+	if c == nil || tm == nil || tm.Layers["floor"] == nil {
+		return Warning{}, false
+	}
+	floor := tm.Layers["floor"].Data
+
+	type entity struct {
+		id string
+		p  Point
+	}
+	var entities []entity
+	for _, s := range c.PlayerStarts {
+		entities = append(entities, entity{s.ID, s.Position})
+	}
+	for _, s := range c.Spawns {
+		entities = append(entities, entity{s.ID, s.Position})
+	}
+	for _, l := range c.Loot {
+		entities = append(entities, entity{l.ID, l.Position})
+	}
+	for _, t := range c.Traps {
+		entities = append(entities, entity{t.ID, t.Position})
+	}
+
+	offFloor, shared := 0, 0
+	affected := make(map[string]bool)
+	first := make(map[Point]string)
+	for _, e := range entities {
+		i := e.p.Y*tm.Width + e.p.X
+		if e.p.X < 0 || e.p.X >= tm.Width || e.p.Y < 0 || e.p.Y >= tm.Height || i >= len(floor) || floor[i] != 1 {
+			offFloor++
+			affected[e.id] = true
+			continue
+		}
+		if other, ok := first[e.p]; ok {
+			shared++
+			affected[e.id], affected[other] = true, true
+			continue
+		}
+		first[e.p] = e.id
+	}
+	if len(affected) == 0 {
+		return Warning{}, false
+	}
+
+	ids := make([]string, 0, len(affected))
+	for id := range affected {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return Warning{
+		Code:     WarnEntityPlacement,
+		Stage:    "content",
+		Message:  fmt.Sprintf("%d entities off the floor and %d sharing a tile in crowded rooms", offFloor, shared),
+		Entities: ids,
+	}, true
+}
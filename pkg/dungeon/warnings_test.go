@@ -0,0 +1,35 @@
+package dungeon
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestEntityPlacementWarning verifies entities off the floor or sharing a
+// tile are reported, and clear placements are not.
+func TestEntityPlacementWarning(t *testing.T) {
+	// A 4x1 strip: wall, floor, floor, floor
+	tm := &TileMap{Width: 4, Height: 1, Layers: map[string]*Layer{
+		"floor": {Name: "floor", Type: "tilelayer", Data: []uint32{0, 1, 1, 1}},
+	}}
+	c := &Content{
+		Spawns: []Spawn{{ID: "spawn_a", Position: Point{X: 1}}, {ID: "spawn_b", Position: Point{X: 2}}},
+		Loot:   []Loot{{ID: "loot_a", Position: Point{X: 3}}},
+	}
+	if w, ok := entityPlacementWarning(c, tm); ok {
+		t.Fatalf("clear placement raised %v", w)
+	}
+
+	c.Loot[0].Position = Point{X: 1}
+	c.Traps = []Trap{{ID: "trap_a", Position: Point{X: 0}}}
+	w, ok := entityPlacementWarning(c, tm)
+	if !ok {
+		t.Fatal("crowded placement raised no warning")
+	}
+	if w.Code != WarnEntityPlacement || w.Stage != "content" {
+		t.Errorf("warning = %v", w)
+	}
+	if want := []string{"loot_a", "spawn_a", "trap_a"}; !reflect.DeepEqual(w.Entities, want) {
+		t.Errorf("Entities = %v, want %v", w.Entities, want)
+	}
+}
@@ -0,0 +1,120 @@
+package dungeon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// DefaultFileMode is the permission of files written by the Save helpers:
+// readable by all, writable by owner.
+const DefaultFileMode os.FileMode = 0644
+
+// WriteOption configures WriteFile.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	mode       os.FileMode
+	parentDirs bool
+	ctx        context.Context
+}
+
+// WithFileMode sets the permission of the written file (default
+// DefaultFileMode). It replaces the permission of an existing file.
+func WithFileMode(mode os.FileMode) WriteOption {
+	return func(o *writeOptions) {
+		o.mode = mode
+	}
+}
+
+// WithParentDirs creates missing parent directories of the file, with
+// permission 0755.
+func WithParentDirs() WriteOption {
+	return func(o *writeOptions) {
+		o.parentDirs = true
+	}
+}
+
+// WithContext makes WriteFile give up with ctx's error once ctx is done,
+// leaving path untouched. The check is made before the temporary file is
+// created and again before it is renamed over path.
+func WithContext(ctx context.Context) WriteOption {
+	return func(o *writeOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WriteFile writes data to path atomically: the data goes to a temporary
+// file in the same directory, which is synced and then renamed over path,
+// and the directory is synced so the rename survives a crash too. Readers
+// see either the old file or the complete new one, never a partially
+// written file.
+//
+// Every Save helper in this module writes through WriteFile, with
+// DefaultFileMode unless its options say otherwise.
+func WriteFile(path string, data []byte, options ...WriteOption) error {
+	opts := writeOptions{mode: DefaultFileMode, ctx: context.Background()}
+	for _, option := range options {
+		option(&opts)
+	}
+	if err := opts.ctx.Err(); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if opts.parentDirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Until the rename succeeds the temporary file is ours to remove
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Chmod(opts.mode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := opts.ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	committed = true
+	return syncDir(dir)
+}
+
+// syncDir flushes a directory's entries to disk. Windows cannot sync
+// directories and commits renames without it.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
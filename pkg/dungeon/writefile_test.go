@@ -0,0 +1,92 @@
+package dungeon_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// TestWriteFile verifies WriteFile replaces files without leaving temporary
+// files behind and applies its options.
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dungeon.json")
+
+	if err := dungeon.WriteFile(path, []byte("old")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := dungeon.WriteFile(path, []byte("new"), dungeon.WithFileMode(0600)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("file content = %q, want %q", data, "new")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("file mode = %v, want 0600", mode)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the written file", len(entries))
+	}
+}
+
+func TestWriteFileParentDirs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "maps", "dungeon.json")
+
+	if err := dungeon.WriteFile(path, []byte("{}")); err == nil {
+		t.Error("expected an error writing into a missing directory")
+	}
+	if err := dungeon.WriteFile(path, []byte("{}"), dungeon.WithParentDirs()); err != nil {
+		t.Fatalf("WriteFile() with WithParentDirs error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("file was not written: %v", err)
+	}
+}
+
+func TestWriteFileContext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dungeon.json")
+	if err := dungeon.WriteFile(path, []byte("old")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := dungeon.WriteFile(path, []byte("new"), dungeon.WithContext(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteFile() with a cancelled context error = %v, want context.Canceled", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old" {
+		t.Errorf("file content = %q, want the untouched %q", data, "old")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the original file", len(entries))
+	}
+}
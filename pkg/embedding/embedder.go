@@ -2,6 +2,7 @@ package embedding
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/dshills/dungo/pkg/graph"
 	"github.com/dshills/dungo/pkg/rng"
@@ -21,6 +22,8 @@ import (
 // Available implementations:
 //   - "force_directed" (ForceDirectedEmbedder): Physics simulation, organic layouts
 //   - "orthogonal" (OrthogonalEmbedder): Grid-based, Manhattan corridors, roguelike style
+//   - "rings" (RingEmbedder): Concentric rings by distance from Start, hub-and-spoke style
+//   - "layered" (LayeredEmbedder): Columns by critical-path progress, gauntlet style
 //
 // Embedders must be deterministic: given the same graph and RNG state,
 // they must produce identical layouts.
@@ -57,6 +60,21 @@ type Config struct {
 	DampingFactor      float64 // Movement damping (0.0-1.0)
 	StabilityThreshold float64 // Stop when max movement < threshold
 	InitialSpread      float64 // Initial random placement spread
+
+	// AspectRatio is the target width/height ratio of the layout
+	// (0 = any). It is a soft goal, pulled toward by the force-directed
+	// embedder.
+	AspectRatio float64
+
+	// Boundary is a polygon every room must lie inside (nil = unbounded).
+	// The force-directed embedder places rooms in it and pulls them back
+	// in; layouts with rooms outside it fail validation.
+	Boundary Polygon
+
+	// FixedPoint runs the force-directed simulation in fixed-point integer
+	// arithmetic, so a seed lays out bit-identically on every
+	// architecture. Layouts differ from the default float simulation.
+	FixedPoint bool
 }
 
 // DefaultConfig returns a config with sensible default values.
@@ -98,6 +116,14 @@ func (c *Config) Validate() error {
 	if c.StabilityThreshold < 0 {
 		return fmt.Errorf("StabilityThreshold must be >= 0, got %f", c.StabilityThreshold)
 	}
+	if c.AspectRatio < 0 {
+		return fmt.Errorf("AspectRatio must be >= 0, got %f", c.AspectRatio)
+	}
+	if len(c.Boundary) > 0 {
+		if err := c.Boundary.Validate(); err != nil {
+			return fmt.Errorf("Boundary: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -163,6 +189,31 @@ func SizeToGridDimensions(size graph.RoomSize) (width, height int) {
 	}
 }
 
+// RoomDimensions returns the grid dimensions of a room's footprint turned by
+// rotation degrees. Halls are half as deep as their size class, rounded up;
+// other rooms are squares that every rotation leaves unchanged.
+func RoomDimensions(room *graph.Room, rotation int) (width, height int) {
+	width, height = SizeToGridDimensions(room.Size)
+	if room.Footprint() != graph.FootprintHall {
+		return width, height
+	}
+	height = (height + 1) / 2
+	if rotation == 90 || rotation == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// orientFootprint returns the rotation that runs a room's footprint along
+// the direction (dx, dy): 0 for mostly horizontal, 90 for mostly vertical.
+// Square footprints always get 0.
+func orientFootprint(room *graph.Room, dx, dy float64) int {
+	if room.Footprint() == "" || math.Abs(dy) <= math.Abs(dx) {
+		return 0
+	}
+	return 90
+}
+
 // ValidateEmbedding performs spatial constraint validation on a layout.
 // It checks for overlaps, corridor feasibility, and other spatial requirements.
 func ValidateEmbedding(layout *Layout, g *graph.Graph, config *Config) error {
@@ -171,8 +222,13 @@ func ValidateEmbedding(layout *Layout, g *graph.Graph, config *Config) error {
 		return err
 	}
 
+	// Check in ID order so the error names the same corridor or room
+	// every run
+	roomIDs := sortedKeys(layout.Poses)
+
 	// Check corridor constraints
-	for connID, path := range layout.CorridorPaths {
+	for _, connID := range sortedKeys(layout.CorridorPaths) {
+		path := layout.CorridorPaths[connID]
 		// Check length constraint
 		length := path.Length()
 		if length > config.CorridorMaxLength {
@@ -188,13 +244,21 @@ func ValidateEmbedding(layout *Layout, g *graph.Graph, config *Config) error {
 		}
 	}
 
+	// Check rooms lie inside the boundary if configured
+	if len(config.Boundary) > 0 {
+		for _, id := range roomIDs {
+			minX, minY, maxX, maxY := layout.Poses[id].Bounds()
+			if !config.Boundary.ContainsRect(minX, minY, maxX, maxY) {
+				return fmt.Errorf("room %s lies outside the boundary", id)
+			}
+		}
+	}
+
 	// Check minimum room spacing if configured
 	if config.MinRoomSpacing > 0 {
-		rooms := make([]*Pose, 0, len(layout.Poses))
-		roomIDs := make([]string, 0, len(layout.Poses))
-		for id, pose := range layout.Poses {
-			rooms = append(rooms, pose)
-			roomIDs = append(roomIDs, id)
+		rooms := make([]*Pose, 0, len(roomIDs))
+		for _, id := range roomIDs {
+			rooms = append(rooms, layout.Poses[id])
 		}
 
 		for i := 0; i < len(rooms); i++ {
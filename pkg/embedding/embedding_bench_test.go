@@ -100,6 +100,31 @@ func BenchmarkForceDirectedEmbedding_ComplexGraph(b *testing.B) {
 	}
 }
 
+// BenchmarkForceDirectedEmbedding_Allocs reports allocations when embedding
+// large graphs, where per-iteration allocations would dominate.
+func BenchmarkForceDirectedEmbedding_Allocs(b *testing.B) {
+	for _, roomCount := range []int{100, 200} {
+		b.Run(fmt.Sprintf("%d_rooms", roomCount), func(b *testing.B) {
+			g := createBranchedGraph(roomCount, 2, 12345)
+
+			config := DefaultConfig()
+			config.MinRoomSpacing = 1.0
+			config.CorridorMaxLength = 400.0
+			config.CorridorMaxBends = 10
+			embedder := NewForceDirectedEmbedder(config)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rngInst := rng.NewRNG(uint64(12345+i), "embedding", []byte("benchmark"))
+				if _, err := embedder.Embed(g, rngInst); err != nil {
+					b.Fatalf("embedding failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkCorridorPathGeneration benchmarks just the corridor path generation
 // phase (A* pathfinding between rooms).
 func BenchmarkCorridorPathGeneration(b *testing.B) {
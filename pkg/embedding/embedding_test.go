@@ -1,7 +1,11 @@
 package embedding
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"sort"
 	"testing"
 
 	"github.com/dshills/dungo/pkg/graph"
@@ -402,6 +406,33 @@ func TestLayoutComputeBounds(t *testing.T) {
 	}
 }
 
+// TestValidateEmbeddingOrder tests that validation errors name the lowest
+// offending ID every run, since fallback reasons end up in the artifact.
+func TestValidateEmbeddingOrder(t *testing.T) {
+	g := graph.NewGraph(1)
+	config := DefaultConfig()
+
+	long := NewLayout()
+	overlapping := NewLayout()
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("conn_%d", i)
+		long.CorridorPaths[id] = &Path{Points: []Point{{X: 0, Y: 0}, {X: 100, Y: 0}}}
+		id = fmt.Sprintf("room_%d", i)
+		overlapping.Poses[id] = &Pose{X: 0, Y: 0, Width: 5, Height: 5}
+	}
+
+	for run := 0; run < 20; run++ {
+		err := ValidateEmbedding(long, g, config)
+		if err == nil || err.Error() != "corridor conn_0 exceeds max length: 100.0 > 50.0" {
+			t.Fatalf("run %d: ValidateEmbedding() error = %v, want corridor conn_0", run, err)
+		}
+		err = overlapping.Validate(g)
+		if err == nil || err.Error() != "rooms room_0 and room_1 have overlapping bounding boxes" {
+			t.Fatalf("run %d: Validate() error = %v, want rooms room_0 and room_1", run, err)
+		}
+	}
+}
+
 // TestConfigValidation tests config validation.
 func TestConfigValidation(t *testing.T) {
 	tests := []struct {
@@ -459,6 +490,24 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative aspect ratio",
+			config: Config{
+				MaxIterations:     100,
+				CorridorMaxLength: 50,
+				AspectRatio:       -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "degenerate boundary",
+			config: Config{
+				MaxIterations:     100,
+				CorridorMaxLength: 50,
+				Boundary:          Polygon{{0, 0}, {10, 10}, {20, 20}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -693,3 +742,565 @@ func TestForceDirectedEmbedDeterminism(t *testing.T) {
 		}
 	}
 }
+
+func TestPolygon(t *testing.T) {
+	// An L shape: a 100x40 bar with a 40x100 leg on its left
+	l := Polygon{{0, 0}, {100, 0}, {100, 40}, {40, 40}, {40, 100}, {0, 100}}
+
+	if area := l.Area(); area != 6400 {
+		t.Errorf("Area() = %v, want 6400", area)
+	}
+	if b := l.Bounds(); b.Width() != 100 || b.Height() != 100 {
+		t.Errorf("Bounds() = %+v, want 100x100", b)
+	}
+
+	points := []struct {
+		x, y float64
+		want bool
+	}{
+		{10, 10, true},
+		{90, 20, true},
+		{20, 90, true},
+		{70, 70, false},
+		{100, 20, true}, // on an edge
+		{-1, 10, false},
+	}
+	for _, p := range points {
+		if got := l.Contains(p.x, p.y); got != p.want {
+			t.Errorf("Contains(%v, %v) = %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+
+	if !l.ContainsRect(10, 10, 30, 90) {
+		t.Error("ContainsRect() = false for a room in the leg")
+	}
+	if l.ContainsRect(30, 30, 60, 60) {
+		t.Error("ContainsRect() = true for a room overlapping the notch")
+	}
+
+	// A slit cuts through a room whose corners are all inside
+	slit := Polygon{{0, 0}, {100, 0}, {100, 100}, {52, 100}, {52, 10}, {48, 10}, {48, 100}, {0, 100}}
+	if slit.ContainsRect(20, 40, 80, 60) {
+		t.Error("ContainsRect() = true for a room crossed by a slit")
+	}
+
+	if p := l.Nearest(70, 60); p.X != 70 || p.Y != 40 {
+		t.Errorf("Nearest(70, 60) = %+v, want (70, 40)", p)
+	}
+}
+
+// TestForceDirectedShape verifies the force-directed embedder keeps rooms
+// inside a boundary and leans toward a target aspect ratio.
+func TestForceDirectedShape(t *testing.T) {
+	boundary := Polygon{{0, 0}, {140, 0}, {140, 60}, {60, 60}, {60, 140}, {0, 140}}
+	newConfig := func() *Config {
+		config := DefaultConfig()
+		config.CorridorMaxLength = 200
+		config.MinRoomSpacing = 1 // As the dungeon generator uses
+		return config
+	}
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := createBranchedGraph(20, 2, seed)
+		config := newConfig()
+		config.Boundary = boundary
+
+		layout, err := NewForceDirectedEmbedder(config).Embed(g, rng.NewRNG(seed, "embedding", []byte("shape")))
+		if err != nil {
+			t.Fatalf("seed %d: Embed() error = %v", seed, err)
+		}
+		for id, pose := range layout.Poses {
+			minX, minY, maxX, maxY := pose.Bounds()
+			if !boundary.ContainsRect(minX, minY, maxX, maxY) {
+				t.Errorf("seed %d: room %s at %v lies outside the boundary", seed, id, pose)
+			}
+		}
+	}
+
+	for _, ratio := range []float64{0.5, 2} {
+		g := createBranchedGraph(20, 2, 7)
+		config := newConfig()
+		config.AspectRatio = ratio
+
+		layout, err := NewForceDirectedEmbedder(config).Embed(g, rng.NewRNG(7, "embedding", []byte("shape")))
+		if err != nil {
+			t.Fatalf("aspect %v: Embed() error = %v", ratio, err)
+		}
+		got := layout.Bounds.Width() / layout.Bounds.Height()
+		if (ratio > 1) != (got > 1) {
+			t.Errorf("aspect %v: layout is %.0fx%.0f", ratio, layout.Bounds.Width(), layout.Bounds.Height())
+		}
+	}
+}
+
+// TestForceDirectedFixedPoint verifies fixed-point layouts are valid and
+// pins one, bounded and unbounded, so a platform laying it out differently
+// fails here.
+func TestForceDirectedFixedPoint(t *testing.T) {
+	boundary := Polygon{{0, 0}, {140, 0}, {140, 60}, {60, 60}, {60, 140}, {0, 140}}
+	tests := []struct {
+		name     string
+		boundary Polygon
+		want     string
+	}{
+		{name: "unbounded", want: "d703b1f63481b720"},
+		{name: "boundary", boundary: boundary, want: "7cf7b2ebd41f808d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.CorridorMaxLength = 200
+			config.MinRoomSpacing = 1
+			config.Boundary = tt.boundary
+			config.FixedPoint = true
+
+			g := createBranchedGraph(20, 2, 3)
+			layout, err := NewForceDirectedEmbedder(config).Embed(g, rng.NewRNG(3, "embedding", []byte("fixed")))
+			if err != nil {
+				t.Fatalf("Embed() error = %v", err)
+			}
+
+			ids := make([]string, 0, len(layout.Poses))
+			for id := range layout.Poses {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			h := sha256.New()
+			for _, id := range ids {
+				pose := layout.Poses[id]
+				fmt.Fprintf(h, "%s:%v,%v,%d;", id, pose.X, pose.Y, pose.Rotation)
+			}
+			if got := hex.EncodeToString(h.Sum(nil))[:16]; got != tt.want {
+				t.Errorf("layout digest = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		a, b, c, want int64
+	}{
+		{6, 7, 2, 21},
+		{-6, 7, 4, -10}, // Truncated toward zero
+		{1 << 40, 1 << 40, 1 << 30, 1 << 50},
+		{math.MaxInt64, 4, 2, math.MaxInt64},
+		{-math.MaxInt64, 4, 2, -math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := mulDiv(tt.a, tt.b, tt.c); got != tt.want {
+			t.Errorf("mulDiv(%d, %d, %d) = %d, want %d", tt.a, tt.b, tt.c, got, tt.want)
+		}
+	}
+	for _, n := range []int64{0, 1, 3, 4, 99, 100, 1<<52 + 12345} {
+		r := isqrt(n)
+		if r*r > n || (r+1)*(r+1) <= n {
+			t.Errorf("isqrt(%d) = %d", n, r)
+		}
+	}
+}
+
+// TestForceSimulationAllocs verifies the force simulation allocates only
+// its setup, however many iterations it runs.
+func TestForceSimulationAllocs(t *testing.T) {
+	g := createBranchedGraph(60, 2, 12345)
+
+	allocs := func(iterations int) float64 {
+		config := DefaultConfig()
+		config.MaxIterations = iterations
+		config.StabilityThreshold = 0 // Never stop early
+		embedder := NewForceDirectedEmbedder(config)
+		r := rng.NewRNG(12345, "embedding", []byte("allocs"))
+		positions := embedder.initializePositions(g, r)
+
+		return testing.AllocsPerRun(5, func() {
+			if err := embedder.simulateForces(g, positions, r); err != nil {
+				t.Fatalf("simulateForces() error = %v", err)
+			}
+		})
+	}
+
+	few, many := allocs(10), allocs(500)
+	if many != few {
+		t.Errorf("simulateForces() allocates %v times for 500 iterations, %v for 10; want no per-iteration allocations", many, few)
+	}
+}
+
+// TestSymmetricEmbedMirrors verifies team B poses mirror team A about the
+// neutral room's vertical axis.
+func TestSymmetricEmbedMirrors(t *testing.T) {
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "arena", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Tags: map[string]string{"team": "neutral"}},
+		{ID: "a_start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Tags: map[string]string{"team": "a", "mirror": "b_start"}},
+		{ID: "a_lane", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS, Tags: map[string]string{"team": "a", "mirror": "b_lane"}},
+		{ID: "b_start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Tags: map[string]string{"team": "b", "mirror": "a_start"}},
+		{ID: "b_lane", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS, Tags: map[string]string{"team": "b", "mirror": "a_lane"}},
+	}
+	for _, room := range rooms {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pair := range [][2]string{{"a_start", "a_lane"}, {"a_lane", "arena"}, {"b_start", "b_lane"}, {"b_lane", "arena"}} {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embedder, err := Get("symmetric", nil)
+	if err != nil {
+		t.Fatalf("Get(symmetric) error = %v", err)
+	}
+	layout, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	axis, _ := layout.Poses["arena"].Center()
+	for _, id := range []string{"a_start", "a_lane"} {
+		a := layout.Poses[id]
+		b := layout.Poses[g.Rooms[id].Tags["mirror"]]
+		if a.X+float64(a.Width)+b.X != 2*axis || a.Y != b.Y {
+			t.Errorf("%s at (%.0f, %.0f) and its mirror at (%.0f, %.0f) are not mirrored about x=%.1f",
+				id, a.X, a.Y, b.X, b.Y, axis)
+		}
+		if a.X+float64(a.Width) > layout.Poses["arena"].X {
+			t.Errorf("%s crosses into the arena", id)
+		}
+	}
+
+	// A room without team tags cannot be embedded symmetrically
+	g.Rooms["a_lane"].Tags = nil
+	if _, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil)); err == nil {
+		t.Error("expected error for untagged room")
+	}
+}
+
+// TestRadialEmbedRings verifies rings are placed outwards from the center room
+// and rooms sharing a slot are spread apart.
+func TestRadialEmbedRings(t *testing.T) {
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "hub", Archetype: graph.ArchetypeStart, Size: graph.SizeL, Tags: map[string]string{"ring": "0", "slot": "0"}},
+		{ID: "spawner_1", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Tags: map[string]string{"ring": "2", "slot": "0"}},
+		{ID: "spawner_2", Archetype: graph.ArchetypeBoss, Size: graph.SizeL, Tags: map[string]string{"ring": "2", "slot": "0"}},
+	}
+	for i := 0; i < 4; i++ {
+		rooms = append(rooms, &graph.Room{
+			ID: fmt.Sprintf("ring_1_%d", i), Archetype: graph.ArchetypeCorridor, Size: graph.SizeM,
+			Tags: map[string]string{"ring": "1", "slot": fmt.Sprint(i)},
+		})
+	}
+	for _, room := range rooms {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs := [][2]string{
+		{"hub", "ring_1_1"}, {"hub", "ring_1_3"},
+		{"ring_1_0", "ring_1_1"}, {"ring_1_1", "ring_1_2"}, {"ring_1_2", "ring_1_3"}, {"ring_1_3", "ring_1_0"},
+		{"ring_1_0", "spawner_1"}, {"ring_1_0", "spawner_2"},
+	}
+	for _, pair := range pairs {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embedder, err := Get("radial", nil)
+	if err != nil {
+		t.Fatalf("Get(radial) error = %v", err)
+	}
+	layout, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	hx, hy := layout.Poses["hub"].Center()
+	distance := func(id string) float64 {
+		x, y := layout.Poses[id].Center()
+		return (x-hx)*(x-hx) + (y-hy)*(y-hy)
+	}
+	for i := 0; i < 4; i++ {
+		ring := fmt.Sprintf("ring_1_%d", i)
+		for _, spawner := range []string{"spawner_1", "spawner_2"} {
+			if distance(ring) >= distance(spawner) {
+				t.Errorf("%s is not inside %s", ring, spawner)
+			}
+		}
+	}
+	if minSpacing(layout.Poses["spawner_1"], layout.Poses["spawner_2"]) <= 0 {
+		t.Error("spawners sharing a slot overlap")
+	}
+
+	// A room without ring tags cannot be embedded radially
+	g.Rooms["ring_1_2"].Tags = nil
+	if _, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil)); err == nil {
+		t.Error("expected error for untagged room")
+	}
+}
+
+// TestRingEmbed verifies the ring embedder puts Start at the center, rooms
+// on rings by their distance from it and the Boss on the outermost ring.
+func TestRingEmbed(t *testing.T) {
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL},
+	}
+	for spoke := 0; spoke < 3; spoke++ {
+		for d := 1; d <= 3; d++ {
+			rooms = append(rooms, &graph.Room{ID: fmt.Sprintf("s%d_%d", spoke, d), Archetype: graph.ArchetypeOptional, Size: graph.SizeM})
+		}
+	}
+	for _, room := range rooms {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs := [][2]string{{"s0_1", "boss"}}
+	for spoke := 0; spoke < 3; spoke++ {
+		pairs = append(pairs, [2]string{"start", fmt.Sprintf("s%d_1", spoke)})
+		for d := 1; d < 3; d++ {
+			pairs = append(pairs, [2]string{fmt.Sprintf("s%d_%d", spoke, d), fmt.Sprintf("s%d_%d", spoke, d+1)})
+		}
+	}
+	for _, pair := range pairs {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.CorridorMaxLength = 100 // The dungeon generator's minimum
+	embedder, err := Get("rings", config)
+	if err != nil {
+		t.Fatalf("Get(rings) error = %v", err)
+	}
+	layout, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	distance := func(id string) float64 {
+		x, y := layout.Poses[id].Center()
+		return math.Hypot(x, y)
+	}
+	if d := distance("start"); d > 1 {
+		t.Errorf("start is %.1f from the center, want 0", d)
+	}
+	for spoke := 0; spoke < 3; spoke++ {
+		for d := 1; d < 3; d++ {
+			inner, outer := fmt.Sprintf("s%d_%d", spoke, d), fmt.Sprintf("s%d_%d", spoke, d+1)
+			if distance(inner) >= distance(outer) {
+				t.Errorf("%s is not inside %s", inner, outer)
+			}
+		}
+	}
+	// The Boss, two connectors from Start, moves out to the outermost ring
+	if math.Abs(distance("boss")-distance("s1_3")) > 2 {
+		t.Errorf("boss is %.1f from the center, outermost ring is at %.1f", distance("boss"), distance("s1_3"))
+	}
+
+	// Deterministic
+	again, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	for id, pose := range layout.Poses {
+		if other := again.Poses[id]; pose.X != other.X || pose.Y != other.Y {
+			t.Errorf("room %s moved between runs", id)
+		}
+	}
+}
+
+// TestLayeredEmbed verifies the layered embedder runs the critical path
+// straight from left to right, ends at the Boss and orders side rooms so
+// that corridors between columns do not cross.
+func TestLayeredEmbed(t *testing.T) {
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "p1", Archetype: graph.ArchetypeOptional, Size: graph.SizeS},
+		{ID: "p2", Archetype: graph.ArchetypeOptional, Size: graph.SizeL},
+		{ID: "p3", Archetype: graph.ArchetypeOptional, Size: graph.SizeM},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL},
+		// Side rooms whose ID order would cross corridors: b and p2 share a
+		// column, as do their children c and a
+		{ID: "a", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS},
+		{ID: "b", Archetype: graph.ArchetypeOptional, Size: graph.SizeM},
+		{ID: "c", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS},
+	}
+	for _, room := range rooms {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs := [][2]string{{"start", "p1"}, {"p1", "p2"}, {"p2", "p3"}, {"p3", "boss"}, {"p1", "b"}, {"b", "c"}, {"p2", "a"}}
+	for _, pair := range pairs {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.CorridorMaxLength = 100 // The dungeon generator's minimum
+	embedder, err := Get("layered", config)
+	if err != nil {
+		t.Fatalf("Get(layered) error = %v", err)
+	}
+	layout, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	path := []string{"start", "p1", "p2", "p3", "boss"}
+	_, lineY := layout.Poses["start"].Center()
+	prevX := math.Inf(-1)
+	for _, id := range path {
+		x, y := layout.Poses[id].Center()
+		if math.Abs(y-lineY) > 1 {
+			t.Errorf("path room %s is at y %.1f, want %.1f", id, y, lineY)
+		}
+		if x <= prevX {
+			t.Errorf("path room %s is not right of the room before it", id)
+		}
+		prevX = x
+	}
+	for id, pose := range layout.Poses {
+		if id != "boss" && pose.X+float64(pose.Width) > layout.Poses["boss"].X {
+			t.Errorf("room %s reaches past the boss", id)
+		}
+	}
+
+	column := map[string]int{"start": 0, "p1": 1, "p2": 2, "b": 2, "p3": 3, "a": 3, "c": 3, "boss": 4}
+	row := make(map[string]int, len(layout.Poses))
+	for id, pose := range layout.Poses {
+		_, y := pose.Center()
+		row[id] = int(y)
+	}
+	if n := countCrossings(g, column, row); n != 0 {
+		t.Errorf("layout has %d corridor crossings, want 0", n)
+	}
+
+	// Deterministic
+	again, err := embedder.Embed(g, rng.NewRNG(1, "embedding", nil))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	for id, pose := range layout.Poses {
+		if other := again.Poses[id]; pose.X != other.X || pose.Y != other.Y {
+			t.Errorf("room %s moved between runs", id)
+		}
+	}
+}
+
+// countCrossings returns the number of pairs of corridors between adjacent
+// columns that cross, given each room's column and row.
+func countCrossings(g *graph.Graph, column map[string]int, row map[string]int) int {
+	type edge struct{ col, a, b int }
+	var edges []edge
+	for _, conn := range g.Connectors {
+		from, to := conn.From, conn.To
+		if column[from] > column[to] {
+			from, to = to, from
+		}
+		if column[to]-column[from] == 1 {
+			edges = append(edges, edge{column[from], row[from], row[to]})
+		}
+	}
+	crossings := 0
+	for i := range edges {
+		for j := i + 1; j < len(edges); j++ {
+			e1, e2 := edges[i], edges[j]
+			if e1.col == e2.col && (e1.a-e2.a)*(e1.b-e2.b) < 0 {
+				crossings++
+			}
+		}
+	}
+	return crossings
+}
+
+// TestHallFootprints verifies hall rooms are laid out half as deep as their
+// size class, turned to suit each embedder, and keep their footprint.
+func TestHallFootprints(t *testing.T) {
+	hall := &graph.Room{ID: "h", Size: graph.SizeM, Tags: map[string]string{graph.FootprintTag: graph.FootprintHall}}
+	for _, tt := range []struct {
+		room          *graph.Room
+		rotation      int
+		width, height int
+	}{
+		{hall, 0, 8, 4},
+		{hall, 90, 4, 8},
+		{hall, 270, 4, 8},
+		{&graph.Room{ID: "s", Size: graph.SizeM}, 90, 8, 8},
+	} {
+		if w, h := RoomDimensions(tt.room, tt.rotation); w != tt.width || h != tt.height {
+			t.Errorf("RoomDimensions(%s, %d) = %dx%d, want %dx%d", tt.room.ID, tt.rotation, w, h, tt.width, tt.height)
+		}
+	}
+
+	// A path of halls with a hall branching off the middle
+	g := graph.NewGraph(1)
+	rooms := []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "p1", Archetype: graph.ArchetypeOptional, Size: graph.SizeM},
+		{ID: "p2", Archetype: graph.ArchetypeOptional, Size: graph.SizeL},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL},
+		{ID: "side", Archetype: graph.ArchetypeTreasure, Size: graph.SizeM},
+	}
+	for _, room := range rooms {
+		if room.ID != "start" && room.ID != "boss" {
+			room.Tags = map[string]string{graph.FootprintTag: graph.FootprintHall}
+		}
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs := [][2]string{{"start", "p1"}, {"p1", "p2"}, {"p2", "boss"}, {"p1", "side"}}
+	for _, pair := range pairs {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.CorridorMaxLength = 100
+	for _, name := range []string{"force_directed", "rings", "layered"} {
+		embedder, err := Get(name, config)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", name, err)
+		}
+		// Seed 5 runs the force-directed halls both ways
+		layout, err := embedder.Embed(g, rng.NewRNG(5, "embedding", nil))
+		if err != nil {
+			t.Fatalf("%s: Embed() error = %v", name, err)
+		}
+		for id, pose := range layout.Poses {
+			room := g.Rooms[id]
+			if pose.FootprintID != room.Footprint() {
+				t.Errorf("%s: room %s footprint = %q, want %q", name, id, pose.FootprintID, room.Footprint())
+			}
+			if w, h := RoomDimensions(room, pose.Rotation); pose.Width != w || pose.Height != h {
+				t.Errorf("%s: room %s is %dx%d at rotation %d, want %dx%d", name, id, pose.Width, pose.Height, pose.Rotation, w, h)
+			}
+		}
+
+		if name == "layered" {
+			// Halls run along the path, and off it along their column
+			if r := layout.Poses["p1"].Rotation; r != 0 {
+				t.Errorf("layered: path hall rotation = %d, want 0", r)
+			}
+			if r := layout.Poses["side"].Rotation; r != 90 {
+				t.Errorf("layered: side hall rotation = %d, want 90", r)
+			}
+		}
+	}
+}
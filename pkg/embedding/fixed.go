@@ -0,0 +1,247 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// Fixed-point layouts.
+//
+// Go rounds every float64 operation the same way on every architecture,
+// but it may fuse x*y + z into one FMA instruction (arm64, ppc64, s390x and
+// amd64 built for v3), and the math package has assembly versions of
+// Hypot, Sin and Cos on some architectures. The force-directed simulation
+// feeds each step's rounding into the next, so a single differing bit can
+// move rooms. With Config.FixedPoint the simulation runs on int64
+// coordinates instead. The float math left outside it rounds products
+// explicitly with float64 conversions, which the compiler may not fuse, and
+// avoids Hypot, Sin and Cos.
+
+// fixedShift is the number of fractional bits of a fixed-point value.
+const fixedShift = 16
+
+// fixedOne is 1.0 in fixed point.
+const fixedOne = 1 << fixedShift
+
+// toFixed converts v to fixed point. Scaling by a power of two is exact.
+func toFixed(v float64) int64 {
+	return int64(math.Round(v * fixedOne))
+}
+
+// fromFixed converts a fixed-point value to float64, exactly for values
+// within ±2^53.
+func fromFixed(v int64) float64 {
+	return float64(v) / fixedOne
+}
+
+// hypot returns the length of (dx, dy), portably with FixedPoint.
+func (e *ForceDirectedEmbedder) hypot(dx, dy float64) float64 {
+	if e.config.FixedPoint {
+		return math.Sqrt(float64(dx*dx) + float64(dy*dy))
+	}
+	return math.Hypot(dx, dy)
+}
+
+// mulDiv returns a*b/c truncated toward zero, with a 128-bit intermediate
+// product. Quotients beyond int64 saturate at ±MaxInt64.
+func mulDiv(a, b, c int64) int64 {
+	neg := (a < 0) != (b < 0) != (c < 0)
+	hi, lo := bits.Mul64(absFixed(a), absFixed(b))
+	d := absFixed(c)
+	q := uint64(math.MaxInt64)
+	if hi < d {
+		if q, _ = bits.Div64(hi, lo, d); q > math.MaxInt64 {
+			q = math.MaxInt64
+		}
+	}
+	if neg {
+		return -int64(q)
+	}
+	return int64(q)
+}
+
+// absFixed returns |v| as a uint64.
+func absFixed(v int64) uint64 {
+	if v < 0 {
+		return uint64(-v)
+	}
+	return uint64(v)
+}
+
+// isqrt returns the integer square root of n >= 0, rounded down.
+func isqrt(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	x := int64(1) << ((bits.Len64(uint64(n)) + 1) / 2)
+	for {
+		y := (x + n/x) / 2
+		if y >= x {
+			return x
+		}
+		x = y
+	}
+}
+
+// initialFixedPoint returns a random point in the disc of the initial
+// spread, as a fixed-point offset from the origin. Rejection sampling on
+// integers avoids the platform-dependent Sin and Cos.
+func (e *ForceDirectedEmbedder) initialFixedPoint(rng *rng.RNG) (int64, int64) {
+	spread := toFixed(e.config.InitialSpread)
+	if spread <= 0 {
+		return 0, 0
+	}
+	for {
+		x := int64(rng.Intn(int(2*spread+1))) - spread
+		y := int64(rng.Intn(int(2*spread+1))) - spread
+		if x*x+y*y <= spread*spread {
+			return x, y
+		}
+	}
+}
+
+// fixedRoom is a room's position and velocity in fixed point.
+type fixedRoom struct {
+	x, y, vx, vy int64
+}
+
+// simulateFixed runs the force-directed simulation of simulateForces in
+// fixed point. Springs and repulsion are computed on integers; shape
+// forces are computed in float64 from the exact room positions and rounded
+// into fixed point.
+func (e *ForceDirectedEmbedder) simulateFixed(g *graph.Graph, positions map[string]*position, _ *rng.RNG) error {
+	dt := toFixed(0.1) // Time step
+	k := toFixed(e.config.SpringConstant)
+	repulsion := toFixed(e.config.RepulsionConstant)
+	damping := toFixed(e.config.DampingFactor)
+	threshold := toFixed(e.config.StabilityThreshold)
+
+	// The float simulation skips pairs closer than 0.001: 1e-6 squared
+	// units, in 32 fractional bits
+	const minDistSq = fixedOne * fixedOne / 1000000
+
+	roomIDs := sortedPositionIDs(positions)
+	index := make(map[string]int, len(roomIDs))
+	rooms := make([]fixedRoom, len(roomIDs))
+	sizes := make([][2]float64, len(roomIDs))
+	for i, id := range roomIDs {
+		index[id] = i
+		rooms[i] = fixedRoom{x: toFixed(positions[id].x), y: toFixed(positions[id].y)}
+		w, h := RoomDimensions(g.Rooms[id], 0)
+		sizes[i] = [2]float64{float64(w), float64(h)}
+	}
+	shaped := e.config.AspectRatio > 0 || len(e.config.Boundary) > 0
+	var shapeRooms []*position
+	var shapeForces []force
+	if shaped {
+		shapeRooms = make([]*position, len(rooms))
+		for i := range shapeRooms {
+			shapeRooms[i] = &position{}
+		}
+		shapeForces = make([]force, len(rooms))
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	springs := make([][2]int, 0, len(connIDs))
+	for _, connID := range connIDs {
+		conn := g.Connectors[connID]
+		from, ok := index[conn.From]
+		if !ok {
+			return fmt.Errorf("connector %s references unknown room %s", connID, conn.From)
+		}
+		to, ok := index[conn.To]
+		if !ok {
+			return fmt.Errorf("connector %s references unknown room %s", connID, conn.To)
+		}
+		springs = append(springs, [2]int{from, to})
+	}
+
+	fx := make([]int64, len(rooms))
+	fy := make([]int64, len(rooms))
+	for iter := 0; iter < e.config.MaxIterations; iter++ {
+		clear(fx)
+		clear(fy)
+
+		// Spring force k * distance along the spring is k * (dx, dy)
+		for _, spring := range springs {
+			from, to := spring[0], spring[1]
+			dx := rooms[to].x - rooms[from].x
+			dy := rooms[to].y - rooms[from].y
+			if dx*dx+dy*dy <= minDistSq {
+				continue
+			}
+			sx := mulDiv(k, dx, fixedOne)
+			sy := mulDiv(k, dy, fixedOne)
+			fx[from] += sx
+			fy[from] += sy
+			fx[to] -= sx
+			fy[to] -= sy
+		}
+
+		// Repulsion force k / distance^2 away from every other room
+		for i := 0; i < len(rooms); i++ {
+			for j := i + 1; j < len(rooms); j++ {
+				dx := rooms[j].x - rooms[i].x
+				dy := rooms[j].y - rooms[i].y
+				distSq := dx*dx + dy*dy
+				if distSq <= minDistSq {
+					continue
+				}
+				dist := isqrt(distSq)
+				mag := mulDiv(repulsion, fixedOne*fixedOne, distSq)
+				rx := mulDiv(mag, dx, dist)
+				ry := mulDiv(mag, dy, dist)
+				fx[i] -= rx
+				fy[i] -= ry
+				fx[j] += rx
+				fy[j] += ry
+			}
+		}
+
+		if shaped {
+			for i, room := range rooms {
+				shapeRooms[i].x, shapeRooms[i].y = fromFixed(room.x), fromFixed(room.y)
+			}
+			clear(shapeForces)
+			e.shapeForces(shapeRooms, sizes, shapeForces)
+			for i, f := range shapeForces {
+				fx[i] += toFixed(f.fx)
+				fy[i] += toFixed(f.fy)
+			}
+		}
+
+		// A room moving at least the threshold along either axis is not
+		// settled; otherwise both components are small enough to square
+		stable := true
+		for i := range rooms {
+			room := &rooms[i]
+			room.vx = mulDiv(room.vx, damping, fixedOne) + mulDiv(fx[i], dt, fixedOne)
+			room.vy = mulDiv(room.vy, damping, fixedOne) + mulDiv(fy[i], dt, fixedOne)
+			room.x += mulDiv(room.vx, dt, fixedOne)
+			room.y += mulDiv(room.vy, dt, fixedOne)
+
+			if stable && (absFixed(room.vx) >= uint64(threshold) || absFixed(room.vy) >= uint64(threshold) ||
+				room.vx*room.vx+room.vy*room.vy >= threshold*threshold) {
+				stable = false
+			}
+		}
+		if stable {
+			break
+		}
+	}
+
+	for i, id := range roomIDs {
+		positions[id].x = fromFixed(rooms[i].x)
+		positions[id].y = fromFixed(rooms[i].y)
+	}
+	return nil
+}
@@ -47,18 +47,32 @@ func (e *ForceDirectedEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, er
 	positions := e.initializePositions(g, rng)
 
 	// Phase 2: Run force-directed simulation
-	if err := e.simulateForces(g, positions, rng); err != nil {
+	simulate := e.simulateForces
+	if e.config.FixedPoint {
+		simulate = e.simulateFixed
+	}
+	if err := simulate(g, positions, rng); err != nil {
 		return nil, fmt.Errorf("force simulation failed: %w", err)
 	}
 
 	// Phase 3: Quantize to grid
 	e.quantizeToGrid(positions)
 
+	// Phase 3b: Run hall footprints along their connections
+	e.orientFootprints(g, positions)
+
 	// Phase 4: Resolve overlaps
 	if err := e.resolveOverlaps(g, positions, rng); err != nil {
 		return nil, fmt.Errorf("overlap resolution failed: %w", err)
 	}
 
+	// Phase 4b: Move rooms pushed out of the boundary back inside
+	if len(e.config.Boundary) > 0 {
+		if err := e.confine(g, positions, rng); err != nil {
+			return nil, fmt.Errorf("boundary confinement failed: %w", err)
+		}
+	}
+
 	// Phase 5: Convert to Layout with Poses
 	// Use sorted room IDs for deterministic layout construction
 	layout := NewLayout()
@@ -75,14 +89,15 @@ func (e *ForceDirectedEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, er
 	for _, roomID := range roomIDs {
 		pos := positions[roomID]
 		room := g.Rooms[roomID]
-		width, height := SizeToGridDimensions(room.Size)
+		width, height := RoomDimensions(room, pos.rotation)
 
 		pose := &Pose{
-			X:        pos.x,
-			Y:        pos.y,
-			Width:    width,
-			Height:   height,
-			Rotation: 0, // Can be enhanced later for rotation support
+			X:           pos.x,
+			Y:           pos.y,
+			Width:       width,
+			Height:      height,
+			Rotation:    pos.rotation,
+			FootprintID: room.Footprint(),
 		}
 
 		if err := layout.AddPose(roomID, pose); err != nil {
@@ -108,8 +123,9 @@ func (e *ForceDirectedEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, er
 
 // position tracks continuous 2D position and velocity during simulation.
 type position struct {
-	x, y   float64 // Current position
-	vx, vy float64 // Current velocity
+	x, y     float64 // Current position
+	vx, vy   float64 // Current velocity
+	rotation int     // Footprint rotation in degrees
 }
 
 // initializePositions places rooms at random positions in a circle.
@@ -126,6 +142,20 @@ func (e *ForceDirectedEmbedder) initializePositions(g *graph.Graph, rng *rng.RNG
 
 	// Initialize positions in deterministic order
 	for _, roomID := range roomIDs {
+		// Rooms start centered on random points inside the boundary
+		if len(e.config.Boundary) > 0 {
+			x, y := e.insidePoint(rng)
+			w, h := RoomDimensions(g.Rooms[roomID], 0)
+			positions[roomID] = &position{x: x - float64(w)/2, y: y - float64(h)/2}
+			continue
+		}
+
+		if e.config.FixedPoint {
+			x, y := e.initialFixedPoint(rng)
+			positions[roomID] = &position{x: fromFixed(x), y: fromFixed(y)}
+			continue
+		}
+
 		// Random angle and radius for circular initial placement
 		angle := rng.Float64() * 2 * math.Pi
 		radius := rng.Float64() * e.config.InitialSpread
@@ -141,31 +171,64 @@ func (e *ForceDirectedEmbedder) initializePositions(g *graph.Graph, rng *rng.RNG
 	return positions
 }
 
+// force accumulates the net force on one room during a simulation step.
+type force struct {
+	fx, fy float64
+}
+
 // simulateForces runs the force-directed simulation.
 // CRITICAL: Uses sorted room IDs throughout to ensure deterministic force calculations.
+//
+// Rooms and connector endpoints are resolved to slice indexes once, and a
+// single force buffer is reused across iterations, so the inner loops do not
+// allocate. Forces are summed in the same order as a map-based walk over the
+// sorted IDs, keeping layouts identical.
 func (e *ForceDirectedEmbedder) simulateForces(g *graph.Graph, positions map[string]*position, rng *rng.RNG) error {
 	dt := 0.1 // Time step
 
 	// Create sorted room IDs once for deterministic iteration
-	roomIDs := make([]string, 0, len(positions))
-	for id := range positions {
-		roomIDs = append(roomIDs, id)
+	roomIDs := sortedPositionIDs(positions)
+	index := make(map[string]int, len(roomIDs))
+	rooms := make([]*position, len(roomIDs))
+	sizes := make([][2]float64, len(roomIDs))
+	for i, id := range roomIDs {
+		index[id] = i
+		rooms[i] = positions[id]
+		w, h := RoomDimensions(g.Rooms[id], 0)
+		sizes[i] = [2]float64{float64(w), float64(h)}
 	}
-	sort.Strings(roomIDs)
+	shaped := e.config.AspectRatio > 0 || len(e.config.Boundary) > 0
 
-	for iter := 0; iter < e.config.MaxIterations; iter++ {
-		// Calculate forces for each room
-		forces := make(map[string]struct{ fx, fy float64 }, len(positions))
-
-		// Initialize forces to zero in deterministic order
-		for _, roomID := range roomIDs {
-			forces[roomID] = struct{ fx, fy float64 }{0, 0}
+	// Sorted connector IDs keep floating-point force sums in a fixed order
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	springs := make([][2]int, 0, len(connIDs))
+	for _, connID := range connIDs {
+		conn := g.Connectors[connID]
+		from, ok := index[conn.From]
+		if !ok {
+			return fmt.Errorf("connector %s references unknown room %s", connID, conn.From)
 		}
+		to, ok := index[conn.To]
+		if !ok {
+			return fmt.Errorf("connector %s references unknown room %s", connID, conn.To)
+		}
+		springs = append(springs, [2]int{from, to})
+	}
+
+	forces := make([]force, len(rooms))
+	for iter := 0; iter < e.config.MaxIterations; iter++ {
+		// Reset forces
+		clear(forces)
 
 		// Apply spring forces (attraction between connected rooms)
-		for _, conn := range g.Connectors {
-			fromPos := positions[conn.From]
-			toPos := positions[conn.To]
+		for _, spring := range springs {
+			from, to := spring[0], spring[1]
+			fromPos := rooms[from]
+			toPos := rooms[to]
 
 			dx := toPos.x - fromPos.x
 			dy := toPos.y - fromPos.y
@@ -178,26 +241,19 @@ func (e *ForceDirectedEmbedder) simulateForces(g *graph.Graph, positions map[str
 				fy := forceMag * dy / dist
 
 				// Apply to both rooms (Newton's third law)
-				fromForce := forces[conn.From]
-				fromForce.fx += fx
-				fromForce.fy += fy
-				forces[conn.From] = fromForce
-
-				toForce := forces[conn.To]
-				toForce.fx -= fx
-				toForce.fy -= fy
-				forces[conn.To] = toForce
+				forces[from].fx += fx
+				forces[from].fy += fy
+				forces[to].fx -= fx
+				forces[to].fy -= fy
 			}
 		}
 
 		// Apply repulsion forces (all rooms repel each other)
-		// Use the sorted roomIDs from above for deterministic pair iteration
-		for i := 0; i < len(roomIDs); i++ {
-			for j := i + 1; j < len(roomIDs); j++ {
-				id1 := roomIDs[i]
-				id2 := roomIDs[j]
-				pos1 := positions[id1]
-				pos2 := positions[id2]
+		// Pairs are visited in sorted room ID order
+		for i := 0; i < len(rooms); i++ {
+			pos1 := rooms[i]
+			for j := i + 1; j < len(rooms); j++ {
+				pos2 := rooms[j]
 
 				dx := pos2.x - pos1.x
 				dy := pos2.y - pos1.y
@@ -212,24 +268,23 @@ func (e *ForceDirectedEmbedder) simulateForces(g *graph.Graph, positions map[str
 					fy := forceMag * dy / dist
 
 					// Apply to both rooms
-					force1 := forces[id1]
-					force1.fx -= fx
-					force1.fy -= fy
-					forces[id1] = force1
-
-					force2 := forces[id2]
-					force2.fx += fx
-					force2.fy += fy
-					forces[id2] = force2
+					forces[i].fx -= fx
+					forces[i].fy -= fy
+					forces[j].fx += fx
+					forces[j].fy += fy
 				}
 			}
 		}
 
+		// Apply forces pulling the layout toward its configured shape
+		if shaped {
+			e.shapeForces(rooms, sizes, forces)
+		}
+
 		// Update velocities and positions with damping in deterministic order
 		maxMovement := 0.0
-		for _, roomID := range roomIDs {
-			pos := positions[roomID]
-			force := forces[roomID]
+		for i, pos := range rooms {
+			force := forces[i]
 
 			// Update velocity: v = v * damping + F * dt
 			pos.vx = pos.vx*e.config.DampingFactor + force.fx*dt
@@ -255,6 +310,16 @@ func (e *ForceDirectedEmbedder) simulateForces(g *graph.Graph, positions map[str
 	return nil
 }
 
+// sortedPositionIDs returns the room IDs of a position map in sorted order.
+func sortedPositionIDs(positions map[string]*position) []string {
+	roomIDs := make([]string, 0, len(positions))
+	for id := range positions {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	return roomIDs
+}
+
 // quantizeToGrid snaps positions to the grid.
 func (e *ForceDirectedEmbedder) quantizeToGrid(positions map[string]*position) {
 	if e.config.GridQuantization <= 0 {
@@ -269,12 +334,46 @@ func (e *ForceDirectedEmbedder) quantizeToGrid(positions map[string]*position) {
 	}
 }
 
+// orientFootprints turns each hall to run along the connections it has to
+// its neighbours, measured between unrotated room centers. Rotations are
+// chosen before any are applied, so the result does not depend on order.
+func (e *ForceDirectedEmbedder) orientFootprints(g *graph.Graph, positions map[string]*position) {
+	center := func(id string) (float64, float64) {
+		w, h := RoomDimensions(g.Rooms[id], 0)
+		return positions[id].x + float64(w)/2, positions[id].y + float64(h)/2
+	}
+
+	neighbours := sortedNeighbours(g)
+	rotations := make(map[string]int)
+	for _, id := range sortedPositionIDs(positions) {
+		if g.Rooms[id].Footprint() == "" {
+			continue
+		}
+		x, y := center(id)
+		dx, dy := 0.0, 0.0
+		for _, next := range neighbours[id] {
+			nx, ny := center(next)
+			dx += math.Abs(nx - x)
+			dy += math.Abs(ny - y)
+		}
+		rotations[id] = orientFootprint(g.Rooms[id], dx, dy)
+	}
+	for id, rotation := range rotations {
+		positions[id].rotation = rotation
+	}
+}
+
 // resolveOverlaps uses an iterative algorithm to separate overlapping rooms.
 func (e *ForceDirectedEmbedder) resolveOverlaps(g *graph.Graph, positions map[string]*position, rng *rng.RNG) error {
 	maxAttempts := 200
 
+	// Sorted once: deterministic pair order, separation direction and
+	// perturbation order. The overlap buffer is reused across attempts.
+	roomIDs := sortedPositionIDs(positions)
+	var overlaps []overlap
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		overlaps := e.findOverlaps(g, positions)
+		overlaps = e.findOverlaps(g, positions, roomIDs, overlaps[:0])
 		if len(overlaps) == 0 {
 			return nil // Success
 		}
@@ -289,23 +388,16 @@ func (e *ForceDirectedEmbedder) resolveOverlaps(g *graph.Graph, positions map[st
 
 		// Add small deterministic perturbation to help escape local minima
 		if attempt%20 == 19 {
-			// Sort room IDs for deterministic iteration order
-			roomIDs := make([]string, 0, len(positions))
-			for id := range positions {
-				roomIDs = append(roomIDs, id)
-			}
-			sort.Strings(roomIDs)
-
 			for _, id := range roomIDs {
 				pos := positions[id]
-				pos.x += (rng.Float64() - 0.5) * e.config.GridQuantization
-				pos.y += (rng.Float64() - 0.5) * e.config.GridQuantization
+				pos.x += float64((rng.Float64() - 0.5) * e.config.GridQuantization)
+				pos.y += float64((rng.Float64() - 0.5) * e.config.GridQuantization)
 			}
 		}
 	}
 
 	// Failed to resolve all overlaps
-	overlaps := e.findOverlaps(g, positions)
+	overlaps = e.findOverlaps(g, positions, roomIDs, overlaps[:0])
 	if len(overlaps) > 0 {
 		return fmt.Errorf("failed to resolve %d overlaps after %d attempts", len(overlaps), maxAttempts)
 	}
@@ -317,14 +409,10 @@ type overlap struct {
 	id1, id2 string
 }
 
-// findOverlaps detects all pairs of rooms with overlapping bounding boxes.
-func (e *ForceDirectedEmbedder) findOverlaps(g *graph.Graph, positions map[string]*position) []overlap {
-	overlaps := []overlap{}
-
-	roomIDs := make([]string, 0, len(positions))
-	for id := range positions {
-		roomIDs = append(roomIDs, id)
-	}
+// findOverlaps appends all pairs of rooms with overlapping bounding boxes to
+// dst, visiting pairs in the order of roomIDs, which must be sorted.
+func (e *ForceDirectedEmbedder) findOverlaps(g *graph.Graph, positions map[string]*position, roomIDs []string, dst []overlap) []overlap {
+	overlaps := dst
 
 	for i := 0; i < len(roomIDs); i++ {
 		for j := i + 1; j < len(roomIDs); j++ {
@@ -347,8 +435,8 @@ func (e *ForceDirectedEmbedder) roomsOverlap(g *graph.Graph, positions map[strin
 	room1 := g.Rooms[id1]
 	room2 := g.Rooms[id2]
 
-	w1, h1 := SizeToGridDimensions(room1.Size)
-	w2, h2 := SizeToGridDimensions(room2.Size)
+	w1, h1 := RoomDimensions(room1, pos1.rotation)
+	w2, h2 := RoomDimensions(room2, pos2.rotation)
 
 	// Bounding boxes
 	minX1, minY1 := pos1.x, pos1.y
@@ -376,8 +464,8 @@ func (e *ForceDirectedEmbedder) separateRooms(g *graph.Graph, positions map[stri
 	room1 := g.Rooms[id1]
 	room2 := g.Rooms[id2]
 
-	w1, h1 := SizeToGridDimensions(room1.Size)
-	w2, h2 := SizeToGridDimensions(room2.Size)
+	w1, h1 := RoomDimensions(room1, pos1.rotation)
+	w2, h2 := RoomDimensions(room2, pos2.rotation)
 
 	// Calculate bounding boxes
 	minX1, minY1 := pos1.x, pos1.y
@@ -0,0 +1,318 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// LayeredEmbedder lays a graph out in columns by progress along the
+// critical path, Sugiyama style: the Start→Boss path runs straight from
+// left to right, and side rooms stack above and below the columns past the
+// point where they branch off. It suits linear, gauntlet-style dungeons.
+//
+// Algorithm:
+//  1. Find the critical path by breadth-first search from Start to Boss
+//     (the first room in ID order and the room furthest from it if either
+//     is missing); path room i goes in column i
+//  2. Put each side room in the column of the path room it branches from
+//     plus its distance from the path, then move the Boss to the last column
+//  3. Order the rooms of each column to reduce corridor crossings, with
+//     alternating barycenter sweeps
+//  4. Stack each column's rooms so its path room sits on the center line,
+//     halls running along the path on it and along the column off it
+//  5. Route corridors as Manhattan paths between room centers
+//
+// The layout is deterministic: the RNG is only recorded as the layout seed.
+type LayeredEmbedder struct {
+	config *Config
+}
+
+// NewLayeredEmbedder creates a layered embedder with the given config.
+func NewLayeredEmbedder(config *Config) *LayeredEmbedder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &LayeredEmbedder{config: config}
+}
+
+// Name returns the identifier for this embedder.
+func (e *LayeredEmbedder) Name() string {
+	return "layered"
+}
+
+// layeredSweeps is the number of barycenter sweeps, alternating left to
+// right and right to left.
+const layeredSweeps = 8
+
+// Embed performs layered layout of the graph.
+func (e *LayeredEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error) {
+	if g == nil {
+		return nil, fmt.Errorf("cannot embed nil graph")
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("cannot embed with nil RNG")
+	}
+	if len(g.Rooms) == 0 {
+		return nil, fmt.Errorf("cannot embed graph with no rooms")
+	}
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	neighbours := sortedNeighbours(g)
+
+	// Phase 1: Critical path
+	path := e.criticalPath(g, roomIDs, neighbours)
+	column := make(map[string]int, len(roomIDs))
+	onPath := make(map[string]bool, len(path))
+	for i, id := range path {
+		column[id] = i
+		onPath[id] = true
+	}
+	last := len(path) - 1
+
+	// Phase 2: Side rooms, by a breadth-first search out of the path
+	queue := append([]string(nil), path...)
+	distance := make(map[string]int, len(roomIDs))
+	anchor := make(map[string]int, len(roomIDs))
+	for _, id := range path {
+		anchor[id] = column[id]
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbours[id] {
+			if _, seen := anchor[next]; seen {
+				continue
+			}
+			anchor[next] = anchor[id]
+			distance[next] = distance[id] + 1
+			column[next] = anchor[next] + distance[next]
+			if column[next] > last {
+				last = column[next]
+			}
+			queue = append(queue, next)
+		}
+	}
+	// Rooms the path cannot reach go in the last column, and the end of the
+	// path moves there so the dungeon finishes at its far side
+	for _, id := range roomIDs {
+		if _, ok := column[id]; !ok {
+			column[id] = last
+		}
+	}
+	if len(path) > 1 {
+		column[path[len(path)-1]] = last
+	}
+
+	columns := make([][]string, last+1)
+	for _, id := range roomIDs {
+		columns[column[id]] = append(columns[column[id]], id)
+	}
+
+	// Phase 3: Crossing reduction
+	e.orderColumns(columns, column, neighbours)
+
+	// Phase 4: Coordinates
+	gap := e.config.MinRoomSpacing + 2
+	layout := NewLayout()
+	layout.Algorithm = e.Name()
+	layout.Seed = rng.Seed()
+
+	// Halls run along the path on it and along their column off it
+	rotation := func(id string) int {
+		if onPath[id] {
+			return orientFootprint(g.Rooms[id], 1, 0)
+		}
+		return orientFootprint(g.Rooms[id], 0, 1)
+	}
+
+	x := 0.0
+	for _, members := range columns {
+		colWidth := 0
+		for _, id := range members {
+			w, _ := RoomDimensions(g.Rooms[id], rotation(id))
+			if w > colWidth {
+				colWidth = w
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		// Stack top to bottom, then shift the path room onto y = 0
+		tops := make([]float64, len(members))
+		y, shift := 0.0, 0.0
+		for i, id := range members {
+			_, h := RoomDimensions(g.Rooms[id], rotation(id))
+			tops[i] = y
+			if onPath[id] {
+				shift = y + float64(h)/2
+			}
+			y += float64(h) + gap
+		}
+		if shift == 0 {
+			// No path room: center the column instead
+			shift = (y - gap) / 2
+		}
+
+		for i, id := range members {
+			w, h := RoomDimensions(g.Rooms[id], rotation(id))
+			pose := &Pose{
+				X:           math.Round(x + float64(colWidth-w)/2),
+				Y:           math.Round(tops[i] - shift),
+				Width:       w,
+				Height:      h,
+				Rotation:    rotation(id),
+				FootprintID: g.Rooms[id].Footprint(),
+			}
+			if err := layout.AddPose(id, pose); err != nil {
+				return nil, fmt.Errorf("failed to add pose: %w", err)
+			}
+		}
+		x += float64(colWidth) + gap + 2
+	}
+
+	// Phase 5: Route corridors
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		fromX, fromY := layout.Poses[conn.From].Center()
+		toX, toY := layout.Poses[conn.To].Center()
+		if err := layout.AddPath(id, manhattanRoute(fromX, fromY, toX, toY)); err != nil {
+			return nil, fmt.Errorf("failed to add path for %s: %w", id, err)
+		}
+	}
+
+	// Phase 6: Compute final bounds
+	layout.ComputeBounds()
+
+	// Phase 7: Validate the embedding
+	if err := ValidateEmbedding(layout, g, e.config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return layout, nil
+}
+
+// sortedNeighbours returns each room's neighbours over the connectors in
+// either direction, in sorted order.
+func sortedNeighbours(g *graph.Graph) map[string][]string {
+	neighbours := make(map[string][]string, len(g.Rooms))
+	for _, conn := range g.Connectors {
+		neighbours[conn.From] = append(neighbours[conn.From], conn.To)
+		neighbours[conn.To] = append(neighbours[conn.To], conn.From)
+	}
+	for id := range neighbours {
+		sort.Strings(neighbours[id])
+	}
+	return neighbours
+}
+
+// criticalPath returns a shortest path from the Start room to the Boss
+// room. Without a Start room it starts at the first room; without a Boss
+// room it ends at the room furthest from the start.
+func (e *LayeredEmbedder) criticalPath(g *graph.Graph, roomIDs []string, neighbours map[string][]string) []string {
+	start, boss := "", ""
+	for _, id := range roomIDs {
+		switch archetype := g.Rooms[id].Archetype; {
+		case archetype == graph.ArchetypeStart && start == "":
+			start = id
+		case archetype == graph.ArchetypeBoss && boss == "":
+			boss = id
+		}
+	}
+	if start == "" {
+		start = roomIDs[0]
+	}
+
+	parent := map[string]string{start: ""}
+	queue := []string{start}
+	furthest := start
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		furthest = id
+		for _, next := range neighbours[id] {
+			if _, seen := parent[next]; !seen {
+				parent[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	if _, ok := parent[boss]; !ok || boss == "" {
+		boss = furthest
+	}
+
+	var path []string
+	for id := boss; id != ""; id = parent[id] {
+		path = append(path, id)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// orderColumns orders the rooms of each column by the barycenter of their
+// neighbours' positions in the columns already swept, alternating sweep
+// direction. Rooms without such neighbours keep their position, and ties
+// keep the current order, so the result is deterministic.
+func (e *LayeredEmbedder) orderColumns(columns [][]string, column map[string]int, neighbours map[string][]string) {
+	position := make(map[string]float64)
+	record := func(members []string) {
+		for i, id := range members {
+			position[id] = float64(i)
+		}
+	}
+	for _, members := range columns {
+		record(members)
+	}
+
+	for sweep := 0; sweep < layeredSweeps; sweep++ {
+		forward := sweep%2 == 0
+		for k := range columns {
+			c := k
+			if !forward {
+				c = len(columns) - 1 - k
+			}
+			members := columns[c]
+			weights := make(map[string]float64, len(members))
+			for _, id := range members {
+				sum, n := 0.0, 0
+				for _, next := range neighbours[id] {
+					if (forward && column[next] < c) || (!forward && column[next] > c) {
+						sum += position[next]
+						n++
+					}
+				}
+				if n > 0 {
+					weights[id] = sum / float64(n)
+				} else {
+					weights[id] = position[id]
+				}
+			}
+			sort.SliceStable(members, func(i, j int) bool {
+				return weights[members[i]] < weights[members[j]]
+			})
+			record(members)
+		}
+	}
+}
+
+// Register the layered embedder
+func init() {
+	Register("layered", func(config *Config) Embedder {
+		return NewLayeredEmbedder(config)
+	})
+}
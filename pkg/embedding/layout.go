@@ -2,6 +2,7 @@ package embedding
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/dshills/dungo/pkg/graph"
 )
@@ -188,6 +189,10 @@ type Layout struct {
 
 	// Algorithm identifies which embedder produced this layout
 	Algorithm string `json:"algorithm,omitempty"`
+
+	// Rejected lists the layouts of the same graph discarded before this
+	// one, as "strategy: reason" (for debugging)
+	Rejected []string `json:"rejected,omitempty"`
 }
 
 // NewLayout creates an empty layout with initialized maps.
@@ -261,31 +266,26 @@ func (l *Layout) ComputeBounds() {
 
 // Validate checks that the layout is valid for the given graph.
 func (l *Layout) Validate(g *graph.Graph) error {
-	// Check that all rooms have poses
-	for roomID := range g.Rooms {
+	// Check that all rooms have poses, in ID order so the error names the
+	// same room every run
+	for _, roomID := range sortedKeys(g.Rooms) {
 		if _, exists := l.Poses[roomID]; !exists {
 			return fmt.Errorf("missing pose for room %s", roomID)
 		}
 	}
 
 	// Check that all connectors have paths
-	for connID := range g.Connectors {
+	for _, connID := range sortedKeys(g.Connectors) {
 		if _, exists := l.CorridorPaths[connID]; !exists {
 			return fmt.Errorf("missing path for connector %s", connID)
 		}
 	}
 
 	// Check for room overlaps
-	rooms := make([]*Pose, 0, len(l.Poses))
-	roomIDs := make([]string, 0, len(l.Poses))
-	for id, pose := range l.Poses {
-		rooms = append(rooms, pose)
-		roomIDs = append(roomIDs, id)
-	}
-
-	for i := 0; i < len(rooms); i++ {
-		for j := i + 1; j < len(rooms); j++ {
-			if rooms[i].Overlaps(rooms[j]) {
+	roomIDs := sortedKeys(l.Poses)
+	for i := 0; i < len(roomIDs); i++ {
+		for j := i + 1; j < len(roomIDs); j++ {
+			if l.Poses[roomIDs[i]].Overlaps(l.Poses[roomIDs[j]]) {
 				return fmt.Errorf("rooms %s and %s have overlapping bounding boxes",
 					roomIDs[i], roomIDs[j])
 			}
@@ -295,6 +295,16 @@ func (l *Layout) Validate(g *graph.Graph) error {
 	return nil
 }
 
+// sortedKeys returns the keys of an ID-keyed map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Helper functions
 
 func abs(x float64) float64 {
@@ -78,7 +78,7 @@ func (e *OrthogonalEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error
 
 	for roomID, gridPos := range gridPositions {
 		room := g.Rooms[roomID]
-		width, height := SizeToGridDimensions(room.Size)
+		width, height := RoomDimensions(room, 0)
 
 		// Convert grid position to world position
 		// Use spacing to prevent rooms from being too close
@@ -91,11 +91,12 @@ func (e *OrthogonalEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error
 		worldY := float64(gridPos.row * (12 + spacing))
 
 		pose := &Pose{
-			X:        worldX,
-			Y:        worldY,
-			Width:    width,
-			Height:   height,
-			Rotation: 0, // Orthogonal embedder doesn't use rotation
+			X:           worldX,
+			Y:           worldY,
+			Width:       width,
+			Height:      height,
+			Rotation:    0, // Orthogonal embedder doesn't use rotation
+			FootprintID: room.Footprint(),
 		}
 
 		if err := layout.AddPose(roomID, pose); err != nil {
@@ -0,0 +1,194 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// RadialEmbedder lays out concentric-ring graphs produced by the "wave"
+// synthesizer. Rooms must carry a "ring" tag (0 for the center room) and a
+// "slot" tag giving their angular position within the ring.
+//
+// Algorithm:
+//  1. Group rooms by ring; rooms sharing a slot split that slot's sector
+//  2. Place the ring 0 room at the origin
+//  3. Place each ring on a circle whose radius keeps neighbours on the ring
+//     and rooms on adjacent rings at least one room diagonal apart
+//  4. Route corridors as Manhattan paths between room centers
+//
+// The layout is deterministic: the RNG is only recorded as the layout seed.
+type RadialEmbedder struct {
+	config *Config
+}
+
+// NewRadialEmbedder creates a radial embedder with the given config.
+func NewRadialEmbedder(config *Config) *RadialEmbedder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &RadialEmbedder{config: config}
+}
+
+// Name returns the identifier for this embedder.
+func (e *RadialEmbedder) Name() string {
+	return "radial"
+}
+
+// Embed performs radial layout of a ring graph.
+func (e *RadialEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error) {
+	if g == nil {
+		return nil, fmt.Errorf("cannot embed nil graph")
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("cannot embed with nil RNG")
+	}
+	if len(g.Rooms) == 0 {
+		return nil, fmt.Errorf("cannot embed graph with no rooms")
+	}
+
+	// Phase 1: Group rooms by ring and slot
+	type placement struct {
+		id   string
+		slot int
+	}
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	rings := make(map[int][]placement)
+	maxRing, slots, maxDim := 0, 1, 0
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		ring, err := strconv.Atoi(room.Tags["ring"])
+		if err != nil || ring < 0 {
+			return nil, fmt.Errorf("room %s has no valid ring tag", id)
+		}
+		slot, err := strconv.Atoi(room.Tags["slot"])
+		if err != nil || slot < 0 {
+			return nil, fmt.Errorf("room %s has no valid slot tag", id)
+		}
+		rings[ring] = append(rings[ring], placement{id: id, slot: slot})
+		if ring > maxRing {
+			maxRing = ring
+		}
+		if slot >= slots {
+			slots = slot + 1
+		}
+
+		w, h := SizeToGridDimensions(room.Size)
+		if w > maxDim {
+			maxDim = w
+		}
+		if h > maxDim {
+			maxDim = h
+		}
+	}
+	if len(rings[0]) != 1 {
+		return nil, fmt.Errorf("radial embedding requires exactly one ring 0 room, found %d", len(rings[0]))
+	}
+
+	// Rooms whose centers are at least step apart cannot come within
+	// MinRoomSpacing of each other; the extra tiles absorb rounding.
+	step := (float64(maxDim)+e.config.MinRoomSpacing)*math.Sqrt2 + 2
+
+	layout := NewLayout()
+	layout.Algorithm = e.Name()
+	layout.Seed = rng.Seed()
+
+	place := func(id string, cx, cy float64) error {
+		w, h := SizeToGridDimensions(g.Rooms[id].Size)
+		return layout.AddPose(id, &Pose{
+			X:      math.Round(cx - float64(w)/2),
+			Y:      math.Round(cy - float64(h)/2),
+			Width:  w,
+			Height: h,
+		})
+	}
+
+	// Phase 2: Center room
+	if err := place(rings[0][0].id, 0, 0); err != nil {
+		return nil, fmt.Errorf("failed to add pose: %w", err)
+	}
+
+	// Phase 3: Rings, innermost first
+	radius := 0.0
+	sector := 2 * math.Pi / float64(slots)
+	for ring := 1; ring <= maxRing; ring++ {
+		members := rings[ring]
+		if len(members) == 0 {
+			return nil, fmt.Errorf("ring %d has no rooms", ring)
+		}
+
+		bySlot := make(map[int][]string)
+		crowd := 1
+		for _, p := range members {
+			bySlot[p.slot] = append(bySlot[p.slot], p.id)
+			if len(bySlot[p.slot]) > crowd {
+				crowd = len(bySlot[p.slot])
+			}
+		}
+
+		// Neighbouring rooms on this ring are at least sector/crowd apart
+		chord := 2 * math.Sin(math.Min(sector/float64(crowd), math.Pi)/2)
+		radius = math.Max(radius+step, step/chord)
+
+		slotIDs := make([]int, 0, len(bySlot))
+		for slot := range bySlot {
+			slotIDs = append(slotIDs, slot)
+		}
+		sort.Ints(slotIDs)
+		for _, slot := range slotIDs {
+			ids := bySlot[slot]
+			for j, id := range ids {
+				// Centered in the slot for one room, spread across it for more
+				offset := 0.0
+				if len(ids) > 1 {
+					offset = (float64(j)+0.5)/float64(len(ids)) - 0.5
+				}
+				angle := sector * (float64(slot) + offset)
+				if err := place(id, radius*math.Cos(angle), radius*math.Sin(angle)); err != nil {
+					return nil, fmt.Errorf("failed to add pose: %w", err)
+				}
+			}
+		}
+	}
+
+	// Phase 4: Route corridors
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		fromX, fromY := layout.Poses[conn.From].Center()
+		toX, toY := layout.Poses[conn.To].Center()
+		if err := layout.AddPath(id, manhattanRoute(fromX, fromY, toX, toY)); err != nil {
+			return nil, fmt.Errorf("failed to add path for %s: %w", id, err)
+		}
+	}
+
+	// Phase 5: Compute final bounds
+	layout.ComputeBounds()
+
+	// Phase 6: Validate the embedding
+	if err := ValidateEmbedding(layout, g, e.config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return layout, nil
+}
+
+// Register the radial embedder
+func init() {
+	Register("radial", func(config *Config) Embedder {
+		return NewRadialEmbedder(config)
+	})
+}
@@ -0,0 +1,298 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// RingEmbedder lays out any graph in concentric rings by graph distance
+// from the Start room: Start at the center, each room on the ring of its
+// depth, and the Boss on the outermost ring. It reads well for
+// hub-and-spoke grammar output and costs a single breadth-first search.
+//
+// Algorithm:
+//  1. Find depths by breadth-first search from Start (the first room in ID
+//     order if there is none), building a spanning tree; rooms it cannot
+//     reach go on an extra outer ring
+//  2. Move the Boss rooms to the outermost ring
+//  3. Give each subtree of the spanning tree an angular sector in
+//     proportion to its leaves, so branches fan out from their parents
+//  4. Spread rooms on a ring that would sit too close together, then place
+//     each ring on a circle large enough to keep its rooms apart
+//  5. Route corridors as Manhattan paths between room centers
+//
+// The layout is deterministic: the RNG is only recorded as the layout seed.
+type RingEmbedder struct {
+	config *Config
+}
+
+// NewRingEmbedder creates a ring embedder with the given config.
+func NewRingEmbedder(config *Config) *RingEmbedder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &RingEmbedder{config: config}
+}
+
+// Name returns the identifier for this embedder.
+func (e *RingEmbedder) Name() string {
+	return "rings"
+}
+
+// Embed performs depth-ring layout of the graph.
+func (e *RingEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error) {
+	if g == nil {
+		return nil, fmt.Errorf("cannot embed nil graph")
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("cannot embed with nil RNG")
+	}
+	if len(g.Rooms) == 0 {
+		return nil, fmt.Errorf("cannot embed graph with no rooms")
+	}
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	// Phase 1: Depths and spanning tree
+	root := roomIDs[0]
+	for _, id := range roomIDs {
+		if g.Rooms[id].Archetype == graph.ArchetypeStart {
+			root = id
+			break
+		}
+	}
+	depth, children := e.spanningTree(g, root)
+
+	maxDepth := 0
+	for _, d := range depth {
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	var orphans []string
+	for _, id := range roomIDs {
+		if _, ok := depth[id]; !ok {
+			orphans = append(orphans, id)
+		}
+	}
+	if len(orphans) > 0 {
+		maxDepth++
+		for _, id := range orphans {
+			depth[id] = maxDepth
+		}
+	}
+
+	// Phase 2: Boss rooms on the outermost ring
+	for _, id := range roomIDs {
+		if g.Rooms[id].Archetype == graph.ArchetypeBoss && id != root {
+			depth[id] = maxDepth
+		}
+	}
+
+	// Phase 3: Sectors of the spanning tree
+	angles := make(map[string]float64, len(roomIDs))
+	leaves := make(map[string]int, len(roomIDs))
+	countLeaves(root, children, leaves)
+	total := leaves[root]
+	for _, id := range orphans {
+		total++
+		leaves[id] = 1
+	}
+	next := assignSectors(root, 0, 2*math.Pi*float64(leaves[root])/float64(total), children, leaves, angles)
+	for _, id := range orphans {
+		width := 2 * math.Pi / float64(total)
+		angles[id] = next + width/2
+		next += width
+	}
+
+	// Phase 4: Rings, innermost first
+	rings := make([][]string, maxDepth+1)
+	ringDims := make([]int, maxDepth+1)
+	for _, id := range roomIDs {
+		d := depth[id]
+		rings[d] = append(rings[d], id)
+		w, h := RoomDimensions(g.Rooms[id], 0)
+		if w > ringDims[d] {
+			ringDims[d] = w
+		}
+		if h > ringDims[d] {
+			ringDims[d] = h
+		}
+	}
+
+	// Rooms whose centers are at least step(a, b) apart, for the largest
+	// room dimensions a and b of their rings, cannot come within
+	// MinRoomSpacing of each other; the extra tiles absorb rounding.
+	step := func(a, b int) float64 {
+		return (float64(a+b)/2+e.config.MinRoomSpacing)*math.Sqrt2 + 2
+	}
+
+	layout := NewLayout()
+	layout.Algorithm = e.Name()
+	layout.Seed = rng.Seed()
+
+	// Halls run outward from the center
+	place := func(id string, cx, cy float64) error {
+		rotation := orientFootprint(g.Rooms[id], cx, cy)
+		w, h := RoomDimensions(g.Rooms[id], rotation)
+		return layout.AddPose(id, &Pose{
+			X:           math.Round(cx - float64(w)/2),
+			Y:           math.Round(cy - float64(h)/2),
+			Width:       w,
+			Height:      h,
+			Rotation:    rotation,
+			FootprintID: g.Rooms[id].Footprint(),
+		})
+	}
+
+	radius, prevDim := 0.0, 0
+	for ring, members := range rings {
+		if len(members) == 0 {
+			continue
+		}
+		if ring == 0 {
+			if err := place(members[0], 0, 0); err != nil {
+				return nil, fmt.Errorf("failed to add pose: %w", err)
+			}
+			prevDim = ringDims[0]
+			continue
+		}
+
+		gap := spreadAngles(members, angles)
+		chord := 2 * math.Sin(math.Min(gap, math.Pi)/2)
+		radius = math.Max(radius+step(prevDim, ringDims[ring]), step(ringDims[ring], ringDims[ring])/chord)
+		prevDim = ringDims[ring]
+		for _, id := range members {
+			if err := place(id, radius*math.Cos(angles[id]), radius*math.Sin(angles[id])); err != nil {
+				return nil, fmt.Errorf("failed to add pose: %w", err)
+			}
+		}
+	}
+
+	// Phase 5: Route corridors
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		fromX, fromY := layout.Poses[conn.From].Center()
+		toX, toY := layout.Poses[conn.To].Center()
+		if err := layout.AddPath(id, manhattanRoute(fromX, fromY, toX, toY)); err != nil {
+			return nil, fmt.Errorf("failed to add path for %s: %w", id, err)
+		}
+	}
+
+	// Phase 6: Compute final bounds
+	layout.ComputeBounds()
+
+	// Phase 7: Validate the embedding
+	if err := ValidateEmbedding(layout, g, e.config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return layout, nil
+}
+
+// spanningTree runs a breadth-first search from root over the connectors in
+// either direction. It returns each reached room's depth and the sorted
+// children of each room in the resulting tree.
+func (e *RingEmbedder) spanningTree(g *graph.Graph, root string) (map[string]int, map[string][]string) {
+	neighbours := sortedNeighbours(g)
+
+	depth := map[string]int{root: 0}
+	children := make(map[string][]string)
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbours[id] {
+			if _, seen := depth[next]; seen {
+				continue
+			}
+			depth[next] = depth[id] + 1
+			children[id] = append(children[id], next)
+			queue = append(queue, next)
+		}
+	}
+	return depth, children
+}
+
+// countLeaves records the number of leaves under each room of the tree.
+func countLeaves(id string, children map[string][]string, leaves map[string]int) int {
+	if len(children[id]) == 0 {
+		leaves[id] = 1
+		return 1
+	}
+	n := 0
+	for _, child := range children[id] {
+		n += countLeaves(child, children, leaves)
+	}
+	leaves[id] = n
+	return n
+}
+
+// assignSectors gives the subtree of id the sector from start to end,
+// places id in its middle and splits the sector among its children by
+// their leaves. It returns end.
+func assignSectors(id string, start, end float64, children map[string][]string, leaves map[string]int, angles map[string]float64) float64 {
+	angles[id] = (start + end) / 2
+	at := start
+	for _, child := range children[id] {
+		width := (end - start) * float64(leaves[child]) / float64(leaves[id])
+		assignSectors(child, at, at+width, children, leaves, angles)
+		at += width
+	}
+	return end
+}
+
+// spreadAngles pushes apart rooms on one ring whose angles are closer than
+// half an even share of the circle, keeping their order, and returns the
+// smallest gap between neighbours. Members are sorted by angle.
+func spreadAngles(members []string, angles map[string]float64) float64 {
+	sort.SliceStable(members, func(i, j int) bool {
+		return angles[members[i]] < angles[members[j]]
+	})
+	if len(members) == 1 {
+		return 2 * math.Pi
+	}
+
+	even := 2 * math.Pi / float64(len(members))
+	minGap := even / 2
+	for i := 1; i < len(members); i++ {
+		prev := angles[members[i-1]]
+		if angles[members[i]] < prev+minGap {
+			angles[members[i]] = prev + minGap
+		}
+	}
+	// Pushed past the first room around the circle: space evenly
+	first, last := angles[members[0]], angles[members[len(members)-1]]
+	if last-first > 2*math.Pi-minGap {
+		for i, id := range members {
+			angles[id] = first + even*float64(i)
+		}
+		return even
+	}
+
+	gap := 2*math.Pi - (last - first)
+	for i := 1; i < len(members); i++ {
+		gap = math.Min(gap, angles[members[i]]-angles[members[i-1]])
+	}
+	return gap
+}
+
+// Register the ring embedder
+func init() {
+	Register("rings", func(config *Config) Embedder {
+		return NewRingEmbedder(config)
+	})
+}
@@ -0,0 +1,285 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// Polygon is a simple polygon given by its vertices in order, in grid
+// units. The last vertex connects back to the first.
+type Polygon []Point
+
+// Validate checks the polygon has at least three vertices and a positive
+// area.
+func (p Polygon) Validate() error {
+	if len(p) < 3 {
+		return fmt.Errorf("polygon needs at least 3 vertices, got %d", len(p))
+	}
+	if p.Area() == 0 {
+		return fmt.Errorf("polygon has no area")
+	}
+	return nil
+}
+
+// Area returns the area enclosed by the polygon.
+func (p Polygon) Area() float64 {
+	sum := 0.0
+	for i := range p {
+		j := (i + 1) % len(p)
+		sum += float64(p[i].X*p[j].Y) - float64(p[j].X*p[i].Y)
+	}
+	return math.Abs(sum) / 2
+}
+
+// Bounds returns the bounding box of the polygon.
+func (p Polygon) Bounds() Rect {
+	if len(p) == 0 {
+		return Rect{}
+	}
+	r := Rect{MinX: p[0].X, MinY: p[0].Y, MaxX: p[0].X, MaxY: p[0].Y}
+	for _, v := range p[1:] {
+		r.MinX = math.Min(r.MinX, v.X)
+		r.MinY = math.Min(r.MinY, v.Y)
+		r.MaxX = math.Max(r.MaxX, v.X)
+		r.MaxY = math.Max(r.MaxY, v.Y)
+	}
+	return r
+}
+
+// Contains reports whether a point lies inside the polygon or on its
+// edge.
+func (p Polygon) Contains(x, y float64) bool {
+	inside := false
+	for i := range p {
+		a, b := p[i], p[(i+1)%len(p)]
+		if onSegment(a, b, x, y) {
+			return true
+		}
+		// Ray casting to +X
+		if (a.Y > y) != (b.Y > y) && x < a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ContainsRect reports whether an axis-aligned rectangle lies entirely
+// inside the polygon: all its corners are inside and no polygon edge cuts
+// through it.
+func (p Polygon) ContainsRect(minX, minY, maxX, maxY float64) bool {
+	corners := [4]Point{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}}
+	for _, c := range corners {
+		if !p.Contains(c.X, c.Y) {
+			return false
+		}
+	}
+	// A concave polygon can reach into the rectangle between its corners
+	for i := range p {
+		if segmentCrossesRect(p[i], p[(i+1)%len(p)], minX, minY, maxX, maxY) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentCrossesRect reports whether the segment from a to b passes
+// through the interior of a rectangle. Segments along its edges do not.
+func segmentCrossesRect(a, b Point, minX, minY, maxX, maxY float64) bool {
+	// Clip the segment to the rectangle (Liang-Barsky)
+	t0, t1 := 0.0, 1.0
+	dx, dy := b.X-a.X, b.Y-a.Y
+	clip := func(p, q float64) bool {
+		if p == 0 {
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		return t0 <= t1
+	}
+	if !clip(-dx, a.X-minX) || !clip(dx, maxX-a.X) || !clip(-dy, a.Y-minY) || !clip(dy, maxY-a.Y) {
+		return false
+	}
+	// The clipped part crosses the interior if its midpoint does
+	t := (t0 + t1) / 2
+	x, y := a.X+float64(t*dx), a.Y+float64(t*dy)
+	return x > minX && x < maxX && y > minY && y < maxY
+}
+
+// Nearest returns the point on the polygon's edges closest to (x, y).
+func (p Polygon) Nearest(x, y float64) Point {
+	best := p[0]
+	bestDist := math.Inf(1)
+	for i := range p {
+		a, b := p[i], p[(i+1)%len(p)]
+		q := nearestOnSegment(a, b, x, y)
+		if d := float64((q.X-x)*(q.X-x)) + float64((q.Y-y)*(q.Y-y)); d < bestDist {
+			best, bestDist = q, d
+		}
+	}
+	return best
+}
+
+// onSegment reports whether (x, y) lies on the segment from a to b.
+func onSegment(a, b Point, x, y float64) bool {
+	q := nearestOnSegment(a, b, x, y)
+	return math.Abs(q.X-x) < 1e-9 && math.Abs(q.Y-y) < 1e-9
+}
+
+// nearestOnSegment returns the point on the segment from a to b closest
+// to (x, y).
+func nearestOnSegment(a, b Point, x, y float64) Point {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := float64(dx*dx) + float64(dy*dy)
+	if lenSq == 0 {
+		return a
+	}
+	t := (float64((x-a.X)*dx) + float64((y-a.Y)*dy)) / lenSq
+	t = math.Max(0, math.Min(1, t))
+	return Point{X: a.X + float64(t*dx), Y: a.Y + float64(t*dy)}
+}
+
+// shapeForces adds the forces pulling a layout toward the configured shape
+// to forces: rooms with corners outside the boundary are pulled back
+// toward its edge, and the spread of the rooms is stretched or squeezed
+// toward the target aspect ratio. Both scale with the spring constant so
+// they keep pace with the dungeon-size tuning of the other forces.
+func (e *ForceDirectedEmbedder) shapeForces(rooms []*position, sizes [][2]float64, forces []force) {
+	if len(e.config.Boundary) > 0 {
+		k := boundaryStrength * e.config.SpringConstant
+		for i, pos := range rooms {
+			w, h := sizes[i][0], sizes[i][1]
+			corners := [4]Point{{pos.x, pos.y}, {pos.x + w, pos.y}, {pos.x + w, pos.y + h}, {pos.x, pos.y + h}}
+			for _, c := range corners {
+				if e.config.Boundary.Contains(c.X, c.Y) {
+					continue
+				}
+				edge := e.config.Boundary.Nearest(c.X, c.Y)
+				forces[i].fx += float64(k * (edge.X - c.X))
+				forces[i].fy += float64(k * (edge.Y - c.Y))
+			}
+		}
+	}
+
+	if e.config.AspectRatio > 0 && len(rooms) > 1 {
+		// Spread of the room centers along each axis
+		var cx, cy float64
+		for i, pos := range rooms {
+			cx += pos.x + sizes[i][0]/2
+			cy += pos.y + sizes[i][1]/2
+		}
+		cx /= float64(len(rooms))
+		cy /= float64(len(rooms))
+		var sx, sy float64
+		for i, pos := range rooms {
+			dx := pos.x + sizes[i][0]/2 - cx
+			dy := pos.y + sizes[i][1]/2 - cy
+			sx += float64(dx * dx)
+			sy += float64(dy * dy)
+		}
+		if sx == 0 || sy == 0 {
+			return
+		}
+		ratio := math.Sqrt(sx / sy)
+
+		// Stretch one axis and squeeze the other by the same factor
+		stretch := math.Sqrt(e.config.AspectRatio/ratio) - 1
+		squeeze := math.Sqrt(ratio/e.config.AspectRatio) - 1
+		k := e.config.SpringConstant
+		for i, pos := range rooms {
+			forces[i].fx += float64(k * (pos.x + sizes[i][0]/2 - cx) * stretch)
+			forces[i].fy += float64(k * (pos.y + sizes[i][1]/2 - cy) * squeeze)
+		}
+	}
+}
+
+// boundaryStrength is the pull of the boundary on a room corner outside
+// it, relative to the spring constant.
+const boundaryStrength = 4.0
+
+// maxConfineAttempts bounds the rounds of confine.
+const maxConfineAttempts = 50
+
+// confine moves rooms left outside the boundary after overlap resolution
+// back inside it, resolving the overlaps this creates, until every room is
+// inside or the attempts run out. Rooms that stay outside fail validation.
+func (e *ForceDirectedEmbedder) confine(g *graph.Graph, positions map[string]*position, rng *rng.RNG) error {
+	roomIDs := sortedPositionIDs(positions)
+	for attempt := 0; attempt < maxConfineAttempts; attempt++ {
+		outside := 0
+		for _, id := range roomIDs {
+			pos := positions[id]
+			w, h := RoomDimensions(g.Rooms[id], pos.rotation)
+			if e.config.Boundary.ContainsRect(pos.x, pos.y, pos.x+float64(w), pos.y+float64(h)) {
+				continue
+			}
+			outside++
+			e.pullInside(pos, float64(w), float64(h))
+		}
+		if outside == 0 {
+			return nil
+		}
+		e.quantizeToGrid(positions)
+		if err := e.resolveOverlaps(g, positions, rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullInside moves a room by the distance from its corner furthest outside
+// the boundary to the boundary edge, plus a grid step so it lands inside
+// after quantization.
+func (e *ForceDirectedEmbedder) pullInside(pos *position, w, h float64) {
+	var moveX, moveY, moveDist float64
+	corners := [4]Point{{pos.x, pos.y}, {pos.x + w, pos.y}, {pos.x + w, pos.y + h}, {pos.x, pos.y + h}}
+	for _, c := range corners {
+		if e.config.Boundary.Contains(c.X, c.Y) {
+			continue
+		}
+		edge := e.config.Boundary.Nearest(c.X, c.Y)
+		dx, dy := edge.X-c.X, edge.Y-c.Y
+		if d := e.hypot(dx, dy); d > moveDist {
+			moveX, moveY, moveDist = dx, dy, d
+		}
+	}
+
+	step := math.Max(e.config.GridQuantization, 1)
+	if moveDist == 0 {
+		// All corners are inside but an edge cuts the room: step toward
+		// the middle of the boundary
+		bounds := e.config.Boundary.Bounds()
+		moveX = (bounds.MinX+bounds.MaxX)/2 - (pos.x + w/2)
+		moveY = (bounds.MinY+bounds.MaxY)/2 - (pos.y + h/2)
+		moveDist = e.hypot(moveX, moveY)
+		if moveDist == 0 {
+			return
+		}
+		pos.x += float64(moveX / moveDist * step)
+		pos.y += float64(moveY / moveDist * step)
+		return
+	}
+	scale := (moveDist + step) / moveDist
+	pos.x += float64(moveX * scale)
+	pos.y += float64(moveY * scale)
+}
+
+// insidePoint returns a random point inside the boundary, or the middle of
+// its bounding box if sampling keeps missing it.
+func (e *ForceDirectedEmbedder) insidePoint(rng *rng.RNG) (float64, float64) {
+	bounds := e.config.Boundary.Bounds()
+	for try := 0; try < 100; try++ {
+		x := bounds.MinX + float64(rng.Float64()*bounds.Width())
+		y := bounds.MinY + float64(rng.Float64()*bounds.Height())
+		if e.config.Boundary.Contains(x, y) {
+			return x, y
+		}
+	}
+	return (bounds.MinX + bounds.MaxX) / 2, (bounds.MinY + bounds.MaxY) / 2
+}
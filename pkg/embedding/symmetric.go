@@ -0,0 +1,271 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// SymmetricEmbedder lays out mirrored arena graphs produced by the
+// "symmetric" synthesizer. Rooms must carry a "team" tag ("a", "b" or
+// "neutral") and team rooms a "mirror" tag naming their counterpart.
+//
+// Algorithm:
+//  1. Embed team A's rooms with the force-directed embedder
+//  2. Orient the half (flip/transpose) so the rooms next to the neutral room
+//     face the mirror axis
+//  3. Place the neutral room on the mirror axis, right of the half
+//  4. Mirror every team A pose across the axis for its team B counterpart
+//  5. Route team A corridors as Manhattan paths and mirror them for team B
+//
+// The result is exactly mirror-symmetric about the vertical line through the
+// neutral room's center.
+type SymmetricEmbedder struct {
+	config *Config
+}
+
+// NewSymmetricEmbedder creates a symmetric embedder with the given config.
+func NewSymmetricEmbedder(config *Config) *SymmetricEmbedder {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &SymmetricEmbedder{config: config}
+}
+
+// Name returns the identifier for this embedder.
+func (e *SymmetricEmbedder) Name() string {
+	return "symmetric"
+}
+
+// Embed performs mirrored layout of an arena graph.
+func (e *SymmetricEmbedder) Embed(g *graph.Graph, rng *rng.RNG) (*Layout, error) {
+	if g == nil {
+		return nil, fmt.Errorf("cannot embed nil graph")
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("cannot embed with nil RNG")
+	}
+
+	// Phase 1: Split rooms into team A, the neutral room and team B mirrors
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	var neutral string
+	mirrorOf := make(map[string]string) // Team B room → team A counterpart
+	half := graph.NewGraph(g.Seed)
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		switch room.Tags["team"] {
+		case "neutral":
+			if neutral != "" {
+				return nil, fmt.Errorf("multiple neutral rooms: %s and %s", neutral, id)
+			}
+			neutral = id
+		case "a":
+			if err := half.AddRoom(room); err != nil {
+				return nil, fmt.Errorf("building half: %w", err)
+			}
+		case "b":
+			counterpart, ok := g.Rooms[room.Tags["mirror"]]
+			if !ok || counterpart.Tags["team"] != "a" {
+				return nil, fmt.Errorf("room %s has no team A mirror", id)
+			}
+			mirrorOf[id] = counterpart.ID
+		default:
+			return nil, fmt.Errorf("room %s has no team tag", id)
+		}
+	}
+	if neutral == "" {
+		return nil, fmt.Errorf("symmetric embedding requires a neutral room")
+	}
+	if len(half.Rooms) == 0 {
+		return nil, fmt.Errorf("symmetric embedding requires team A rooms")
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		if _, ok := half.Rooms[conn.From]; !ok {
+			continue
+		}
+		if _, ok := half.Rooms[conn.To]; !ok {
+			continue
+		}
+		if err := half.AddConnector(conn); err != nil {
+			return nil, fmt.Errorf("building half: %w", err)
+		}
+	}
+
+	// Phase 2: Embed team A
+	halfLayout, err := NewForceDirectedEmbedder(e.config).Embed(half, rng)
+	if err != nil {
+		return nil, fmt.Errorf("embedding half: %w", err)
+	}
+
+	// Phase 3: Orient the half so rooms joined to the neutral room face the axis
+	var anchors []string
+	for _, id := range g.Adjacency[neutral] {
+		if _, ok := half.Rooms[id]; ok {
+			anchors = append(anchors, id)
+		}
+	}
+	sort.Strings(anchors)
+	poses := orientTowardsAxis(halfLayout.Poses, anchors)
+
+	// Phase 4: Place the neutral room on the axis, clear of the half
+	maxRight := math.Inf(-1)
+	for _, pose := range poses {
+		maxRight = math.Max(maxRight, pose.X+float64(pose.Width))
+	}
+	anchorY := 0.0
+	for _, id := range anchors {
+		_, cy := poses[id].Center()
+		anchorY += cy
+	}
+	if len(anchors) > 0 {
+		anchorY /= float64(len(anchors))
+	}
+
+	nw, nh := SizeToGridDimensions(g.Rooms[neutral].Size)
+	neutralPose := &Pose{
+		X:      maxRight + math.Ceil(e.config.MinRoomSpacing),
+		Y:      math.Round(anchorY - float64(nh)/2),
+		Width:  nw,
+		Height: nh,
+	}
+	axis, _ := neutralPose.Center()
+
+	layout := NewLayout()
+	layout.Algorithm = e.Name()
+	layout.Seed = rng.Seed()
+
+	if err := layout.AddPose(neutral, neutralPose); err != nil {
+		return nil, fmt.Errorf("failed to add pose: %w", err)
+	}
+
+	// Phase 5: Mirror team A poses for team B
+	for _, id := range roomIDs {
+		switch {
+		case id == neutral:
+			continue
+		case mirrorOf[id] != "":
+			src := poses[mirrorOf[id]]
+			pose := &Pose{
+				X:      2*axis - src.X - float64(src.Width),
+				Y:      src.Y,
+				Width:  src.Width,
+				Height: src.Height,
+			}
+			if err := layout.AddPose(id, pose); err != nil {
+				return nil, fmt.Errorf("failed to add pose: %w", err)
+			}
+		default:
+			if err := layout.AddPose(id, poses[id]); err != nil {
+				return nil, fmt.Errorf("failed to add pose: %w", err)
+			}
+		}
+	}
+
+	// Phase 6: Route team A corridors and mirror them for team B
+	toTeamA := func(id string) string {
+		if src, ok := mirrorOf[id]; ok {
+			return src
+		}
+		return id
+	}
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		from, to := toTeamA(conn.From), toTeamA(conn.To)
+		mirrored := from != conn.From || to != conn.To
+
+		fromX, fromY := layout.Poses[from].Center()
+		toX, toY := layout.Poses[to].Center()
+		path := manhattanRoute(fromX, fromY, toX, toY)
+		if mirrored {
+			for i := range path.Points {
+				path.Points[i].X = 2*axis - path.Points[i].X
+			}
+		}
+
+		if err := layout.AddPath(id, path); err != nil {
+			return nil, fmt.Errorf("failed to add path for %s: %w", id, err)
+		}
+	}
+
+	// Phase 7: Compute final bounds
+	layout.ComputeBounds()
+
+	// Phase 8: Validate the embedding
+	if err := ValidateEmbedding(layout, g, e.config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return layout, nil
+}
+
+// orientTowardsAxis returns copies of poses flipped and/or transposed so the
+// anchor rooms sit as close as possible to the right edge of the half, where
+// the mirror axis will be. Flips and transposes keep integer corners integral.
+func orientTowardsAxis(poses map[string]*Pose, anchors []string) map[string]*Pose {
+	transforms := []func(p *Pose) *Pose{
+		func(p *Pose) *Pose { return &Pose{X: p.X, Y: p.Y, Width: p.Width, Height: p.Height} },
+		func(p *Pose) *Pose {
+			return &Pose{X: -p.X - float64(p.Width), Y: p.Y, Width: p.Width, Height: p.Height}
+		},
+		func(p *Pose) *Pose { return &Pose{X: p.Y, Y: p.X, Width: p.Height, Height: p.Width} },
+		func(p *Pose) *Pose {
+			return &Pose{X: -p.Y - float64(p.Height), Y: p.X, Width: p.Height, Height: p.Width}
+		},
+	}
+
+	var best map[string]*Pose
+	bestGap := math.Inf(1)
+	for _, transform := range transforms {
+		oriented := make(map[string]*Pose, len(poses))
+		maxRight := math.Inf(-1)
+		for id, pose := range poses {
+			oriented[id] = transform(pose)
+			maxRight = math.Max(maxRight, oriented[id].X+float64(oriented[id].Width))
+		}
+
+		// Total distance from the anchors' right edges to the half's right edge
+		gap := 0.0
+		for _, id := range anchors {
+			gap += maxRight - (oriented[id].X + float64(oriented[id].Width))
+		}
+		if gap < bestGap {
+			best, bestGap = oriented, gap
+		}
+	}
+	return best
+}
+
+// manhattanRoute creates an L-shaped path between two points, moving along
+// the longer axis first.
+func manhattanRoute(x1, y1, x2, y2 float64) *Path {
+	points := []Point{{X: x1, Y: y1}}
+	if math.Abs(x2-x1) > math.Abs(y2-y1) {
+		points = append(points, Point{X: x2, Y: y1})
+	} else {
+		points = append(points, Point{X: x1, Y: y2})
+	}
+	points = append(points, Point{X: x2, Y: y2})
+	return &Path{Points: points}
+}
+
+// Register the symmetric embedder
+func init() {
+	Register("symmetric", func(config *Config) Embedder {
+		return NewSymmetricEmbedder(config)
+	})
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// Anchor kinds: the stateful places a save system re-binds to.
+const (
+	AnchorDoor       = "door"       // A door at one end of a carved corridor
+	AnchorChest      = "chest"      // A loot pickup
+	AnchorBoss       = "boss"       // The Boss room
+	AnchorCheckpoint = "checkpoint" // A Checkpoint room
+	AnchorExit       = "exit"       // The Exit room past the Boss
+	AnchorSecret     = "secret"     // A hidden element
+	AnchorPuzzle     = "puzzle"     // A puzzle, anchored at its room's centre
+)
+
+// AnchorFile maps the stable IDs of a dungeon's stateful entities to their
+// kind and tile position, so a save system can re-bind persisted state
+// (opened doors, looted chests, defeated bosses) to a regenerated dungeon.
+// A save should keep IDScheme and discard state whose IDs it no longer
+// finds, rather than binding it to whatever now sits at the position.
+type AnchorFile struct {
+	Seed     uint64   `json:"seed"`
+	IDScheme int      `json:"idScheme"` // dungeon.IDSchemeVersion the IDs follow
+	Anchors  []Anchor `json:"anchors"`  // Ordered by ID
+}
+
+// Anchor is one stateful entity.
+type Anchor struct {
+	ID   string `json:"id"`             // Stable ID: room, connector door or content entity ID
+	Kind string `json:"kind"`           // One of the Anchor* kinds
+	Type string `json:"type,omitempty"` // Item, puzzle or secret type, or the door's gate
+	Room string `json:"room"`           // Room the anchor belongs to
+	X    int    `json:"x"`              // Tile column
+	Y    int    `json:"y"`              // Tile row
+}
+
+// ExportAnchors builds the anchor file of an artifact. Doors come from the
+// tile map's door objects and are named after them; boss, checkpoint and
+// exit rooms are anchored at their centre under their room ID; chests, secrets
+// and puzzles use their content IDs (see dungeon.StableEntityID).
+func ExportAnchors(artifact *dungeon.Artifact) (*AnchorFile, error) {
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return nil, fmt.Errorf("artifact has no graph")
+	}
+	g := artifact.ADG.Graph
+	bounds := roomTileBounds(artifact)
+	center := func(roomID string) (int, int) {
+		r := bounds[roomID]
+		return r.X + r.Width/2, r.Y + r.Height/2
+	}
+
+	file := &AnchorFile{Seed: g.Seed, IDScheme: dungeon.IDSchemeVersion, Anchors: []Anchor{}}
+	add := func(a Anchor) { file.Anchors = append(file.Anchors, a) }
+
+	for _, id := range sortedRoomIDs(g) {
+		var kind string
+		switch g.Rooms[id].Archetype {
+		case graph.ArchetypeBoss:
+			kind = AnchorBoss
+		case graph.ArchetypeCheckpoint:
+			kind = AnchorCheckpoint
+		case graph.ArchetypeExit:
+			kind = AnchorExit
+		default:
+			continue
+		}
+		x, y := center(id)
+		add(Anchor{ID: id, Kind: kind, Room: id, X: x, Y: y})
+	}
+
+	if tm := artifact.TileMap; tm != nil && tm.TileWidth > 0 && tm.TileHeight > 0 {
+		if layer, ok := tm.Layers["doors"]; ok {
+			for _, obj := range layer.Objects {
+				// The door at a corridor's start belongs to its From room
+				room, _ := obj.Properties["from_room"].(string)
+				if strings.HasSuffix(obj.Name, "_end") {
+					room, _ = obj.Properties["to_room"].(string)
+				}
+				var gate string
+				connID, _ := obj.Properties["connector_id"].(string)
+				if conn, ok := g.Connectors[connID]; ok && conn.Gate != nil {
+					gate = conn.Gate.String()
+				}
+				add(Anchor{
+					ID:   obj.Name,
+					Kind: AnchorDoor,
+					Type: gate,
+					Room: room,
+					X:    int(obj.X) / tm.TileWidth,
+					Y:    int(obj.Y) / tm.TileHeight,
+				})
+			}
+		}
+	}
+
+	if c := artifact.Content; c != nil {
+		for _, loot := range c.Loot {
+			add(Anchor{ID: loot.ID, Kind: AnchorChest, Type: loot.ItemType, Room: loot.RoomID, X: loot.Position.X, Y: loot.Position.Y})
+		}
+		for _, secret := range c.Secrets {
+			add(Anchor{ID: secret.ID, Kind: AnchorSecret, Type: secret.Type, Room: secret.RoomID, X: secret.Position.X, Y: secret.Position.Y})
+		}
+		for _, puzzle := range c.Puzzles {
+			x, y := center(puzzle.RoomID)
+			add(Anchor{ID: puzzle.ID, Kind: AnchorPuzzle, Type: puzzle.Type, Room: puzzle.RoomID, X: x, Y: y})
+		}
+	}
+
+	sort.Slice(file.Anchors, func(i, j int) bool { return file.Anchors[i].ID < file.Anchors[j].ID })
+	return file, nil
+}
+
+// MarshalAnchors serializes an AnchorFile to compact JSON.
+func MarshalAnchors(file *AnchorFile) ([]byte, error) {
+	return json.Marshal(file)
+}
+
+// ExportAnchorsTo writes an artifact's anchors to w as compact JSON.
+func ExportAnchorsTo(w io.Writer, artifact *dungeon.Artifact) error {
+	file, err := ExportAnchors(artifact)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, file, false)
+}
+
+// SaveAnchorsToFile exports an artifact's anchors to a compact JSON file
+// with dungeon.WriteFile.
+func SaveAnchorsToFile(artifact *dungeon.Artifact, filepath string, options ...ExportOption) error {
+	file, err := ExportAnchors(artifact)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalAnchors(file)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
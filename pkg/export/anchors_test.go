@@ -0,0 +1,75 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/export"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestExportAnchors verifies the anchor file lists the boss, doors and
+// chests of a generated dungeon under their stable IDs, and regenerating
+// the seed reproduces it exactly.
+func TestExportAnchors(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          777,
+		Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 25},
+		OptionalRatio: 0.2,
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		Keys:          []dungeon.KeyCfg{{Name: "silver", Count: 1}},
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	anchors := func() []byte {
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		file, err := export.ExportAnchors(artifact)
+		if err != nil {
+			t.Fatalf("ExportAnchors() error = %v", err)
+		}
+
+		if file.IDScheme != dungeon.IDSchemeVersion {
+			t.Errorf("IDScheme = %d, want %d", file.IDScheme, dungeon.IDSchemeVersion)
+		}
+		if !sort.SliceIsSorted(file.Anchors, func(i, j int) bool { return file.Anchors[i].ID < file.Anchors[j].ID }) {
+			t.Error("anchors are not ordered by ID")
+		}
+
+		kinds := make(map[string]int)
+		tm := artifact.TileMap
+		for _, a := range file.Anchors {
+			kinds[a.Kind]++
+			if a.X < 0 || a.X >= tm.Width || a.Y < 0 || a.Y >= tm.Height {
+				t.Errorf("anchor %s at (%d,%d) is off the map", a.ID, a.X, a.Y)
+			}
+		}
+		if kinds[export.AnchorBoss] != 1 || kinds[export.AnchorDoor] == 0 {
+			t.Errorf("kinds = %v, want one boss and some doors", kinds)
+		}
+		if kinds[export.AnchorChest] != len(artifact.Content.Loot) {
+			t.Errorf("%d chest anchors for %d loot", kinds[export.AnchorChest], len(artifact.Content.Loot))
+		}
+
+		data, err := export.MarshalAnchors(file)
+		if err != nil {
+			t.Fatalf("MarshalAnchors() error = %v", err)
+		}
+		if !json.Valid(data) || bytes.Contains(data, []byte("\n")) {
+			t.Error("expected compact JSON")
+		}
+		return data
+	}
+
+	if !bytes.Equal(anchors(), anchors()) {
+		t.Error("regenerating the seed changed the anchors")
+	}
+}
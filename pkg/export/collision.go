@@ -0,0 +1,176 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// CollisionMap is an engine-neutral description of a dungeon's colliders.
+// Layer holds one carving.CollisionType value per tile in row-major order;
+// Shapes holds the same data merged into rectangles and grouped by room,
+// so physics engines don't have to derive colliders from visual tiles.
+type CollisionMap struct {
+	Width      int              `json:"width"`
+	Height     int              `json:"height"`
+	TileWidth  int              `json:"tileWidth"`
+	TileHeight int              `json:"tileHeight"`
+	Legend     map[string]int   `json:"legend"`
+	Layer      []uint32         `json:"layer"`
+	Shapes     []CollisionShape `json:"shapes,omitempty"`
+}
+
+// CollisionShape is a merged collision rectangle.
+// Tile coordinates are given in X/Y/Width/Height; pixel coordinates are
+// derived from the map's tile size for engines that work in world units.
+type CollisionShape struct {
+	RoomID string  `json:"roomId,omitempty"`
+	Type   string  `json:"type"`
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	PixelX float64 `json:"pixelX"`
+	PixelY float64 `json:"pixelY"`
+	PixelW float64 `json:"pixelWidth"`
+	PixelH float64 `json:"pixelHeight"`
+}
+
+// CollisionOptions configures collision export.
+type CollisionOptions struct {
+	MergeShapes bool // Merge colliders into per-room rectangles in Shapes
+}
+
+// DefaultCollisionOptions returns the default collision export options:
+// with merged shapes.
+func DefaultCollisionOptions() CollisionOptions {
+	return CollisionOptions{MergeShapes: true}
+}
+
+// ExportCollision builds a CollisionMap from an artifact's "collision" tile
+// layer. This is the only export of the layer: its values are collision
+// types, not tile GIDs, so TMJ leaves it out.
+func ExportCollision(artifact *dungeon.Artifact, opts CollisionOptions, options ...ExportOption) (*CollisionMap, error) {
+	applyOptions(&opts, options)
+	if artifact == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact has no tile map")
+	}
+
+	tm := artifact.TileMap
+	layer, ok := tm.Layers["collision"]
+	if !ok || layer.Type != "tilelayer" {
+		return nil, fmt.Errorf("tile map has no collision layer")
+	}
+
+	cm := &CollisionMap{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+		Legend:     collisionLegend(),
+		Layer:      layer.Data,
+	}
+
+	if !opts.MergeShapes {
+		return cm, nil
+	}
+
+	rooms := roomTileBounds(artifact)
+	shapes := carving.BuildCollisionShapes(
+		&carving.Layer{Data: layer.Data}, tm.Width, tm.Height, rooms)
+
+	cm.Shapes = make([]CollisionShape, 0, len(shapes))
+	for _, s := range shapes {
+		cm.Shapes = append(cm.Shapes, CollisionShape{
+			RoomID: s.RoomID,
+			Type:   s.Type.String(),
+			X:      s.Bounds.X,
+			Y:      s.Bounds.Y,
+			Width:  s.Bounds.Width,
+			Height: s.Bounds.Height,
+			PixelX: float64(s.Bounds.X * tm.TileWidth),
+			PixelY: float64(s.Bounds.Y * tm.TileHeight),
+			PixelW: float64(s.Bounds.Width * tm.TileWidth),
+			PixelH: float64(s.Bounds.Height * tm.TileHeight),
+		})
+	}
+
+	return cm, nil
+}
+
+// collisionLegend maps collision type names to their layer values.
+func collisionLegend() map[string]int {
+	legend := make(map[string]int)
+	for _, t := range []carving.CollisionType{
+		carving.CollisionNone,
+		carving.CollisionSolid,
+		carving.CollisionOneWay,
+		carving.CollisionHazard,
+		carving.CollisionTrigger,
+	} {
+		legend[t.String()] = int(t)
+	}
+	return legend
+}
+
+// roomTileBounds returns the carved tile rectangle of every room with a pose.
+func roomTileBounds(artifact *dungeon.Artifact) map[string]carving.Rect {
+	rooms := make(map[string]carving.Rect)
+	if artifact.Layout == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return rooms
+	}
+
+	ids := make([]string, 0, len(artifact.Layout.Poses))
+	for id := range artifact.Layout.Poses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		room, ok := artifact.ADG.Rooms[id]
+		if !ok {
+			continue
+		}
+		pose := artifact.Layout.Poses[id]
+		rooms[id] = carving.RoomBounds(carving.RoomSize(room.Size), carving.Pose{
+			X:           pose.X,
+			Y:           pose.Y,
+			Rotation:    pose.Rotation,
+			FootprintID: pose.FootprintID,
+		})
+	}
+	return rooms
+}
+
+// MarshalCollision serializes a CollisionMap to JSON with indentation.
+func MarshalCollision(cm *CollisionMap) ([]byte, error) {
+	return json.MarshalIndent(cm, "", "  ")
+}
+
+// ExportCollisionTo writes an artifact's collision data to w as indented
+// JSON.
+func ExportCollisionTo(w io.Writer, artifact *dungeon.Artifact, opts CollisionOptions, options ...ExportOption) error {
+	cm, err := ExportCollision(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, cm, true)
+}
+
+// SaveCollisionToFile exports an artifact's collision data to a JSON file
+// with dungeon.WriteFile.
+func SaveCollisionToFile(artifact *dungeon.Artifact, filepath string, opts CollisionOptions, options ...ExportOption) error {
+	cm, err := ExportCollision(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalCollision(cm)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// createCollisionTestArtifact builds a 4x3 map whose collision layer has a
+// solid top row, a hazard and a trigger.
+func createCollisionTestArtifact() *dungeon.Artifact {
+	solid, hazard, trigger := uint32(carving.CollisionSolid), uint32(carving.CollisionHazard), uint32(carving.CollisionTrigger)
+	return &dungeon.Artifact{
+		TileMap: &dungeon.TileMap{
+			Width: 4, Height: 3, TileWidth: 16, TileHeight: 16,
+			Layers: map[string]*dungeon.Layer{
+				"floor": {Name: "floor", Type: "tilelayer", Data: []uint32{0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1}},
+				"collision": {Name: "collision", Type: "tilelayer", Data: []uint32{
+					solid, solid, solid, solid,
+					0, hazard, 0, 0,
+					0, 0, 0, trigger,
+				}},
+			},
+		},
+	}
+}
+
+func TestExportCollision(t *testing.T) {
+	artifact := createCollisionTestArtifact()
+
+	cm, err := ExportCollision(artifact, CollisionOptions{})
+	if err != nil {
+		t.Fatalf("ExportCollision() error = %v", err)
+	}
+	if cm.Width != 4 || cm.Height != 3 || len(cm.Layer) != 12 {
+		t.Fatalf("got %dx%d map with %d tiles, want 4x3 with 12", cm.Width, cm.Height, len(cm.Layer))
+	}
+	if len(cm.Shapes) != 0 {
+		t.Errorf("got %d shapes without MergeShapes, want none", len(cm.Shapes))
+	}
+	if cm.Legend["Solid"] != int(carving.CollisionSolid) {
+		t.Errorf("legend Solid = %d, want %d", cm.Legend["Solid"], carving.CollisionSolid)
+	}
+
+	cm, err = ExportCollision(artifact, DefaultCollisionOptions())
+	if err != nil {
+		t.Fatalf("ExportCollision() error = %v", err)
+	}
+	if len(cm.Shapes) == 0 {
+		t.Fatal("expected merged shapes with the default options")
+	}
+	first := cm.Shapes[0]
+	if first.Type != "Solid" || first.Width != 4 || first.Height != 1 || first.PixelW != 64 {
+		t.Errorf("first shape = %+v, want the solid top row", first)
+	}
+
+	if _, err := ExportCollision(&dungeon.Artifact{TileMap: &dungeon.TileMap{}}, DefaultCollisionOptions()); err == nil {
+		t.Error("expected an error for a tile map without a collision layer")
+	}
+}
+
+func TestSaveCollisionToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collision.json")
+	if err := SaveCollisionToFile(createCollisionTestArtifact(), path, DefaultCollisionOptions()); err != nil {
+		t.Fatalf("SaveCollisionToFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read collision file: %v", err)
+	}
+	var cm CollisionMap
+	if err := json.Unmarshal(data, &cm); err != nil {
+		t.Fatalf("collision file is not valid JSON: %v", err)
+	}
+	if len(cm.Layer) != 12 || len(cm.Shapes) == 0 {
+		t.Errorf("got %d tiles and %d shapes, want 12 tiles and merged shapes", len(cm.Layer), len(cm.Shapes))
+	}
+}
+
+// TestExportTMJ_NoCollisionLayer checks that TMJ leaves out the collision
+// layer, whose values are collision types rather than tile GIDs.
+func TestExportTMJ_NoCollisionLayer(t *testing.T) {
+	tmj, err := ExportTMJWithOptions(createCollisionTestArtifact(), DefaultTMJOptions())
+	if err != nil {
+		t.Fatalf("ExportTMJWithOptions() error = %v", err)
+	}
+
+	floor := false
+	for _, layer := range tmj.Layers {
+		if layer.Name == "collision" {
+			t.Error("TMJ export should not include the collision layer")
+		}
+		floor = floor || layer.Name == "floor"
+	}
+	if !floor {
+		t.Error("expected the floor layer in the TMJ export")
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// DifficultyChartOptions configures the difficulty profile chart.
+type DifficultyChartOptions struct {
+	Width  int // Canvas width in pixels (default: 480)
+	Height int // Canvas height in pixels (default: 120)
+}
+
+// Default size of the difficulty profile chart.
+const (
+	DefaultDifficultyChartWidth  = 480
+	DefaultDifficultyChartHeight = 120
+)
+
+// difficultyChartPad is the margin around the plot in pixels.
+const difficultyChartPad = 8
+
+// ExportDifficultyChart renders the artifact's difficulty profile
+// (Metrics.Difficulty) as an SVG sparkline: room difficulty against the
+// pacing target, in order of progress, over bars of each room's threat
+// and hazard coverage. The artifact must have been validated.
+func ExportDifficultyChart(artifact *dungeon.Artifact, opts DifficultyChartOptions) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := ExportDifficultyChartTo(buf, artifact, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportDifficultyChartTo writes the difficulty profile chart to w (see
+// ExportDifficultyChart).
+func ExportDifficultyChartTo(w io.Writer, artifact *dungeon.Artifact, opts DifficultyChartOptions) error {
+	if artifact == nil {
+		return fmt.Errorf("artifact cannot be nil")
+	}
+	if artifact.Metrics == nil || artifact.Metrics.Difficulty == nil || len(artifact.Metrics.Difficulty.Rooms) == 0 {
+		return fmt.Errorf("artifact has no difficulty profile")
+	}
+	if opts.Width == 0 {
+		opts.Width = DefaultDifficultyChartWidth
+	}
+	if opts.Height == 0 {
+		opts.Height = DefaultDifficultyChartHeight
+	}
+	if opts.Width <= 2*difficultyChartPad || opts.Height <= 2*difficultyChartPad {
+		return fmt.Errorf("chart size must exceed %d pixels, got %dx%d", 2*difficultyChartPad, opts.Width, opts.Height)
+	}
+	return writeBuffered(w, func(w io.Writer) error {
+		writeDifficultyChart(w, artifact.Metrics.Difficulty, opts)
+		return nil
+	})
+}
+
+// SaveDifficultyChartToFile exports the difficulty profile chart to an SVG
+// file, written with dungeon.WriteFile.
+func SaveDifficultyChartToFile(artifact *dungeon.Artifact, filepath string, opts DifficultyChartOptions, options ...ExportOption) error {
+	data, err := ExportDifficultyChart(artifact, opts)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
+
+// writeDifficultyChart draws a profile with validated options. Each room
+// gets an equal column; values of 0-1 span the plot height.
+func writeDifficultyChart(w io.Writer, profile *dungeon.DifficultyProfile, opts DifficultyChartOptions) {
+	canvas := svg.New(w)
+	canvas.Start(opts.Width, opts.Height)
+	canvas.Rect(0, 0, opts.Width, opts.Height, "fill:#1a1a2e")
+
+	plotW := opts.Width - 2*difficultyChartPad
+	plotH := opts.Height - 2*difficultyChartPad
+	column := float64(plotW) / float64(len(profile.Rooms))
+	x := func(i int) int {
+		return difficultyChartPad + int(column*(float64(i)+0.5))
+	}
+	y := func(v float64) int {
+		v = min(1, max(0, v))
+		return difficultyChartPad + int(float64(plotH)*(1-v))
+	}
+
+	// Threat and hazard coverage bars side by side in each column
+	barW := max(1, int(column/3))
+	for i, room := range profile.Rooms {
+		if room.Threat > 0 {
+			top := y(room.Threat)
+			canvas.Rect(x(i)-barW, top, barW, y(0)-top, "fill:#ef4444;opacity:0.35")
+		}
+		if room.HazardCoverage > 0 {
+			top := y(room.HazardCoverage)
+			canvas.Rect(x(i), top, barW, y(0)-top, "fill:#f59e0b;opacity:0.35")
+		}
+	}
+
+	xs := make([]int, len(profile.Rooms))
+	target := make([]int, len(profile.Rooms))
+	actual := make([]int, len(profile.Rooms))
+	for i, room := range profile.Rooms {
+		xs[i] = x(i)
+		target[i] = y(room.Target)
+		actual[i] = y(room.Difficulty)
+	}
+	canvas.Polyline(xs, target, "fill:none;stroke:#a0aec0;stroke-width:1;stroke-dasharray:4,3")
+	canvas.Polyline(xs, actual, "fill:none;stroke:#cbd5e0;stroke-width:1.5")
+	for i, room := range profile.Rooms {
+		canvas.Circle(xs[i], actual[i], 2, "fill:"+getHeatmapColor(room.Difficulty))
+	}
+
+	canvas.Text(difficultyChartPad, difficultyChartPad+8,
+		fmt.Sprintf("deviation p50 %+.2f p90 %+.2f", profile.Deviation.P50, profile.Deviation.P90),
+		"fill:#a0aec0;font-family:monospace;font-size:9px")
+	canvas.End()
+}
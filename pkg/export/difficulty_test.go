@@ -0,0 +1,52 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+func TestExportDifficultyChart(t *testing.T) {
+	artifact := createGatesTestArtifact()
+	artifact.Metrics = &dungeon.Metrics{Difficulty: &dungeon.DifficultyProfile{
+		Rooms: []dungeon.RoomDifficulty{
+			{Room: "S", Progress: 0, Difficulty: 0, Target: 0},
+			{Room: "K", Progress: 0.5, Difficulty: 0.7, Target: 0.5, Deviation: 0.2, Threat: 0.75, HazardCoverage: 0.25},
+			{Room: "B", Progress: 1, Difficulty: 0.9, Target: 1, Deviation: -0.1},
+		},
+		Deviation: dungeon.Percentiles{P50: 0, P90: 0.16},
+	}}
+
+	data, err := ExportDifficultyChart(artifact, DifficultyChartOptions{})
+	if err != nil {
+		t.Fatalf("ExportDifficultyChart() error = %v", err)
+	}
+	chart := string(data)
+	for _, want := range []string{
+		`width="480" height="120"`,
+		"<polyline", "stroke-dasharray",
+		"fill:#ef4444;opacity:0.35", "fill:#f59e0b;opacity:0.35",
+		"deviation p50 +0.00 p90 +0.16",
+	} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("chart missing %s:\n%s", want, chart)
+		}
+	}
+	if n := strings.Count(chart, "<circle"); n != 3 {
+		t.Errorf("chart has %d room points, want 3", n)
+	}
+	again, _ := ExportDifficultyChart(artifact, DifficultyChartOptions{})
+	if !bytes.Equal(data, again) {
+		t.Error("chart export is not deterministic")
+	}
+
+	if _, err := ExportDifficultyChart(artifact, DifficultyChartOptions{Width: 10, Height: 10}); err == nil {
+		t.Error("expected an error for a chart too small to plot")
+	}
+	artifact.Metrics = nil
+	if _, err := ExportDifficultyChart(artifact, DifficultyChartOptions{}); err == nil {
+		t.Error("expected an error for an artifact without a difficulty profile")
+	}
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// ExportDOT renders the dungeon graph in Graphviz DOT, for iterating on
+// topology with standard graph tools (`dot -Tpng`). It reads the graph only,
+// so it exports artifacts stopped after synthesis.
+func ExportDOT(artifact *dungeon.Artifact) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := ExportDOTTo(buf, artifact); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportDOTTo writes the dungeon graph in Graphviz DOT to w. Rooms are
+// filled with their SVG archetype colors and labelled with their ID and
+// archetype. One-way connectors have arrows, hidden ones are dashed and
+// gated ones are labelled with what opens them. Rooms and connectors are
+// written in ID order, so the same graph always renders the same file.
+func ExportDOTTo(w io.Writer, artifact *dungeon.Artifact) error {
+	if artifact == nil {
+		return fmt.Errorf("artifact cannot be nil")
+	}
+	if artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return fmt.Errorf("artifact must contain a valid ADG")
+	}
+	return writeBuffered(w, func(w io.Writer) error {
+		writeDOT(w, artifact.ADG.Graph)
+		return nil
+	})
+}
+
+// SaveDOTToFile exports the dungeon graph to a DOT file, written with
+// dungeon.WriteFile.
+func SaveDOTToFile(artifact *dungeon.Artifact, filepath string, options ...ExportOption) error {
+	data, err := ExportDOT(artifact)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
+
+// writeDOT writes g as a DOT digraph to a buffered writer.
+func writeDOT(w io.Writer, g *graph.Graph) {
+	colors := SVGOptions{ColorByType: true}
+
+	fmt.Fprintln(w, "digraph dungeon {")
+	fmt.Fprintln(w, `  node [shape=circle style=filled fontname="monospace" fontsize=10 fontcolor="#ffffff"];`)
+	fmt.Fprintln(w, `  edge [fontname="monospace" fontsize=9];`)
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		label := id + `\n` + room.Archetype.String()
+		fmt.Fprintf(w, "  %s [label=%s fillcolor=%q];\n", strconv.Quote(id), dotQuote(label), getNodeColor(room.Archetype, colors))
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		attrs := []string{"id=" + strconv.Quote(id)}
+		if conn.Bidirectional {
+			attrs = append(attrs, "dir=none")
+		}
+		if conn.Type == graph.TypeHidden {
+			attrs = append(attrs, "style=dashed")
+		}
+		if conn.Gate != nil {
+			needs := make([]string, 0, len(conn.Gate.Needs()))
+			for _, need := range conn.Gate.Needs() {
+				needs = append(needs, need.Type+":"+need.Value)
+			}
+			sep := " + "
+			if conn.Gate.Any {
+				sep = " | "
+			}
+			attrs = append(attrs, "label="+strconv.Quote(strings.Join(needs, sep)), `color="#d69e2e"`)
+		}
+		fmt.Fprintf(w, "  %s -> %s [%s];\n", strconv.Quote(conn.From), strconv.Quote(conn.To), strings.Join(attrs, " "))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotQuote quotes s as a DOT string, keeping its \n line breaks.
+func dotQuote(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), `\\n`, `\n`)
+}
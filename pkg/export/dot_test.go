@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+func TestExportDOT(t *testing.T) {
+	artifact := createGatesTestArtifact()
+	data, err := ExportDOT(artifact)
+	if err != nil {
+		t.Fatalf("ExportDOT() error = %v", err)
+	}
+	dot := string(data)
+	if !strings.HasPrefix(dot, "digraph dungeon {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("not a DOT digraph:\n%s", dot)
+	}
+	for _, want := range []string{
+		`"B" [label="B\nBoss" fillcolor="#f56565"];`,
+		`"S" -> "K" [id="c1" dir=none];`,
+		`"S" -> "B" [id="c2" dir=none label="key:silver" color="#d69e2e"];`,
+		`"X" -> "B" [id="c5" label="key:silver" color="#d69e2e"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %s:\n%s", want, dot)
+		}
+	}
+
+	// Rooms are written in ID order
+	if b, s := strings.Index(dot, `"B" [`), strings.Index(dot, `"S" [`); b > s {
+		t.Error("rooms are not sorted by ID")
+	}
+	again, _ := ExportDOT(artifact)
+	if !bytes.Equal(data, again) {
+		t.Error("DOT export is not deterministic")
+	}
+
+	if _, err := ExportDOT(&dungeon.Artifact{}); err == nil {
+		t.Error("expected an error for an artifact without a graph")
+	}
+}
@@ -44,7 +44,7 @@ func ExampleExportJSON() {
 	}
 
 	fmt.Printf("Exported %d bytes of JSON data\n", len(data))
-	// Output: Exported 612 bytes of JSON data
+	// Output: Exported 916 bytes of JSON data
 }
 
 // ExampleSaveJSONToFile demonstrates saving a dungeon artifact to a JSON file.
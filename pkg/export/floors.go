@@ -0,0 +1,229 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// FloorTag is the room tag holding the integer floor a room is on. Rooms
+// without it, or with a value that is not an integer, are on floor 0.
+const FloorTag = "floor"
+
+// FloorLayout selects how the SVG export arranges the floors of a
+// multi-floor dungeon.
+type FloorLayout string
+
+const (
+	// FloorsMerged draws every floor in a single view (the default).
+	FloorsMerged FloorLayout = ""
+
+	// FloorsSideBySide draws each floor in its own column, lowest first.
+	FloorsSideBySide FloorLayout = "side-by-side"
+
+	// FloorsStacked draws each floor in its own row, highest on top.
+	FloorsStacked FloorLayout = "stacked"
+)
+
+// floorLevels is the height of one floor in elevation levels, so any room
+// on a floor sits above every raised platform of the floor below.
+const floorLevels = 2*themes.MaxElevationLevel + 1
+
+// RoomFloor returns the floor of a room from its FloorTag.
+func RoomFloor(room *graph.Room) int {
+	if room == nil || room.Tags == nil {
+		return 0
+	}
+	floor, err := strconv.Atoi(room.Tags[FloorTag])
+	if err != nil {
+		return 0
+	}
+	return floor
+}
+
+// RoomHeight combines the floor of a room with the level of its interior
+// (see carving.RoomElevation), under its registered theme's elevation rules,
+// into a single height, in elevation levels.
+func RoomHeight(room *graph.Room) int {
+	if room == nil {
+		return 0
+	}
+	var rules []themes.ElevationRule
+	if theme, ok := themes.Lookup(room.Tags["biome"]); ok {
+		rules = theme.ElevationRules()
+	}
+	return RoomFloor(room)*floorLevels + carving.RoomElevation(room.Archetype.String(), room.Tags, rules)
+}
+
+// floorsOf returns the distinct floors of the rooms in g, lowest first.
+func floorsOf(g *graph.Graph) []int {
+	seen := make(map[int]bool)
+	var floors []int
+	for _, room := range g.Rooms {
+		if f := RoomFloor(room); !seen[f] {
+			seen[f] = true
+			floors = append(floors, f)
+		}
+	}
+	sort.Ints(floors)
+	return floors
+}
+
+// floorPanel is the canvas area one floor is drawn in.
+type floorPanel struct {
+	Floor         int
+	X, Y          float64 // Top-left corner
+	Width, Height float64
+}
+
+// floorPanels splits the drawable area into one panel per floor, or returns
+// nil when opts draws floors merged or the dungeon has a single floor.
+func floorPanels(g *graph.Graph, opts SVGOptions) []floorPanel {
+	if opts.Floors == FloorsMerged {
+		return nil
+	}
+	floors := floorsOf(g)
+	if len(floors) < 2 {
+		return nil
+	}
+
+	left, top := float64(opts.Margin), 100.0 // Leave room for the header
+	width := float64(opts.Width - 2*opts.Margin)
+	height := float64(opts.Height-opts.Margin) - top
+
+	panels := make([]floorPanel, len(floors))
+	for i, floor := range floors {
+		switch opts.Floors {
+		case FloorsStacked:
+			// Highest floor on top
+			row := len(floors) - 1 - i
+			h := height / float64(len(floors))
+			panels[i] = floorPanel{Floor: floor, X: left, Y: top + float64(row)*h, Width: width, Height: h}
+		default:
+			w := width / float64(len(floors))
+			panels[i] = floorPanel{Floor: floor, X: left + float64(i)*w, Y: top, Width: w, Height: height}
+		}
+	}
+	return panels
+}
+
+// floorLayout places each floor's rooms in a circle centred in its panel,
+// keeping regions together as calculateLayout does.
+func floorLayout(g *graph.Graph, regions []graph.Region, panels []floorPanel, opts SVGOptions) map[string]position {
+	order := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		order = append(order, id)
+	}
+	sort.Strings(order)
+	if len(regions) > 0 {
+		order = order[:0]
+		for _, region := range regions {
+			order = append(order, region.Rooms...)
+		}
+	}
+
+	positions := make(map[string]position)
+	for _, panel := range panels {
+		var ids []string
+		for _, id := range order {
+			if RoomFloor(g.Rooms[id]) == panel.Floor {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		radius := math.Min(panel.Width, panel.Height)/2 - float64(2*opts.NodeRadius) - 10
+		radius = math.Max(radius, float64(opts.NodeRadius))
+		if len(ids) == 1 {
+			radius = 0
+		}
+		centerX := panel.X + panel.Width/2
+		centerY := panel.Y + panel.Height/2
+		angleStep := 2 * math.Pi / float64(len(ids))
+		for i, id := range ids {
+			angle := float64(i) * angleStep
+			positions[id] = position{
+				X: centerX + radius*math.Cos(angle),
+				Y: centerY + radius*math.Sin(angle),
+			}
+		}
+	}
+	return positions
+}
+
+// drawFloorPanels outlines each floor's panel and labels it.
+func drawFloorPanels(canvas *svg.SVG, panels []floorPanel) {
+	for _, panel := range panels {
+		canvas.Rect(int(panel.X)+4, int(panel.Y)+4, int(panel.Width)-8, int(panel.Height)-8,
+			"fill:none;stroke:#4a5568;stroke-width:1;stroke-dasharray:6,4;rx:8")
+		canvas.Text(int(panel.X)+14, int(panel.Y)+22, fmt.Sprintf("Floor %d", panel.Floor),
+			"font-size:13px;font-weight:bold;fill:#a0aec0;font-family:sans-serif")
+	}
+}
+
+// Stair marker glyphs, drawn next to a room on the end of a connector that
+// leads to another floor.
+const (
+	stairsUpGlyph   = "▲"
+	stairsDownGlyph = "▼"
+	stairsColor     = "#f6ad55"
+)
+
+// drawStairs marks an inter-floor connector with a stairs glyph beside each
+// of its rooms, pointing up or down towards the other room's floor.
+func drawStairs(canvas *svg.SVG, from, to *graph.Room, fromPos, toPos position, opts SVGOptions) {
+	fromFloor, toFloor := RoomFloor(from), RoomFloor(to)
+	drawStairsMarker(canvas, fromPos, toPos, fromFloor < toFloor, getNodeRadius(from.Size, opts.NodeRadius))
+	drawStairsMarker(canvas, toPos, fromPos, toFloor < fromFloor, getNodeRadius(to.Size, opts.NodeRadius))
+}
+
+// drawStairsMarker draws one stairs glyph just outside the room at at,
+// on the side facing toward.
+func drawStairsMarker(canvas *svg.SVG, at, toward position, up bool, radius int) {
+	angle := math.Atan2(toward.Y-at.Y, toward.X-at.X)
+	x := at.X + float64(radius+10)*math.Cos(angle)
+	y := at.Y + float64(radius+10)*math.Sin(angle)
+
+	glyph := stairsDownGlyph
+	if up {
+		glyph = stairsUpGlyph
+	}
+	canvas.Circle(int(x), int(y), 8, fmt.Sprintf("fill:#1a1a2e;stroke:%s;stroke-width:1", stairsColor))
+	canvas.Text(int(x), int(y+4), glyph,
+		fmt.Sprintf("text-anchor:middle;font-size:10px;fill:%s", stairsColor))
+}
+
+// elevationRange returns the lowest and highest RoomHeight in g.
+func elevationRange(g *graph.Graph) (int, int) {
+	lo, hi, first := 0, 0, true
+	for _, room := range g.Rooms {
+		h := RoomHeight(room)
+		if first || h < lo {
+			lo = h
+		}
+		if first || h > hi {
+			hi = h
+		}
+		first = false
+	}
+	return lo, hi
+}
+
+// getElevationColor shades a room from deep blue (lowest) to pale yellow
+// (highest) by its RoomHeight within [lo, hi].
+func getElevationColor(room *graph.Room, lo, hi int) string {
+	t := 0.5
+	if hi > lo {
+		t = float64(RoomHeight(room)-lo) / float64(hi-lo)
+	}
+	lerp := func(a, b float64) int { return int(math.Round(a + (b-a)*t)) }
+	return fmt.Sprintf("#%02x%02x%02x", lerp(0x2b, 0xfa), lerp(0x4c, 0xf0), lerp(0x7e, 0x89))
+}
@@ -0,0 +1,252 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// GateAudit lists every gate of a dungeon with what opens it, for designers
+// auditing progression without reading the raw graph. Distances count
+// connectors and ignore gates; -1 means unreachable.
+type GateAudit struct {
+	Seed  uint64      `json:"seed"`
+	Start string      `json:"start"` // Start room ID, empty without one
+	Gates []GateEntry `json:"gates"` // Ordered by connector ID
+}
+
+// GateEntry describes one gated connector.
+type GateEntry struct {
+	Connector     string     `json:"connector"`
+	From          string     `json:"from"`
+	To            string     `json:"to"`
+	Bidirectional bool       `json:"bidirectional"`
+	Requirement   string     `json:"requirement"` // The gate's capabilities, e.g. "key:silver AND key:gold"
+	Any           bool       `json:"any"`         // Any one capability opens the gate
+	Needs         []GateNeed `json:"needs"`
+	Distance      int        `json:"distance"`       // From Start to the From room
+	Alternate     bool       `json:"alternateRoute"` // To is reachable from Start without this connector
+}
+
+// GateNeed is one capability a gate checks and the rooms providing it.
+type GateNeed struct {
+	Type      string         `json:"type"`
+	Value     string         `json:"value"`
+	Providers []GateProvider `json:"providers"` // Ordered by room ID
+}
+
+// GateProvider is a room providing a capability a gate needs.
+type GateProvider struct {
+	Room         string `json:"room"`
+	Distance     int    `json:"distance"`     // From Start to the room
+	GateDistance int    `json:"gateDistance"` // From the room to the gate's From room
+	BeforeGate   bool   `json:"beforeGate"`   // Reachable from Start without crossing this gate
+}
+
+// ExportGateAudit builds the gate audit of an artifact's graph.
+func ExportGateAudit(artifact *dungeon.Artifact) (*GateAudit, error) {
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return nil, fmt.Errorf("artifact has no graph")
+	}
+	g := artifact.ADG.Graph
+
+	audit := &GateAudit{Seed: g.Seed, Gates: []GateEntry{}}
+	for _, id := range sortedRoomIDs(g) {
+		if g.Rooms[id].Archetype == graph.ArchetypeStart {
+			audit.Start = id
+			break
+		}
+	}
+
+	// Providers of every capability, by room ID
+	providers := make(map[graph.Capability][]string)
+	for _, id := range sortedRoomIDs(g) {
+		for _, c := range g.Rooms[id].Provides {
+			providers[c] = append(providers[c], id)
+		}
+	}
+
+	var fromStart map[string]int
+	if audit.Start != "" {
+		fromStart = g.Distances([]string{audit.Start})
+	}
+	paths := g.ShortestPaths()
+	distance := func(from, to string) int {
+		if d, ok := paths.Distance(from, to); ok {
+			return d
+		}
+		return -1
+	}
+	startDistance := func(room string) int {
+		if d, ok := fromStart[room]; ok {
+			return d
+		}
+		return -1
+	}
+	reachableWithout := func(room, connID string) bool {
+		if audit.Start == "" {
+			return false
+		}
+		_, err := g.GetPathAvoiding(audit.Start, room, nil, []string{connID})
+		return err == nil
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id, conn := range g.Connectors {
+		if conn.Gate != nil {
+			connIDs = append(connIDs, id)
+		}
+	}
+	sort.Strings(connIDs)
+
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		entry := GateEntry{
+			Connector:     id,
+			From:          conn.From,
+			To:            conn.To,
+			Bidirectional: conn.Bidirectional,
+			Requirement:   conn.Gate.String(),
+			Any:           conn.Gate.Any,
+			Needs:         []GateNeed{},
+			Distance:      startDistance(conn.From),
+			Alternate:     reachableWithout(conn.To, id),
+		}
+		for _, need := range conn.Gate.Needs() {
+			n := GateNeed{Type: need.Type, Value: need.Value, Providers: []GateProvider{}}
+			for _, room := range providers[graph.Capability{Type: need.Type, Value: need.Value}] {
+				n.Providers = append(n.Providers, GateProvider{
+					Room:         room,
+					Distance:     startDistance(room),
+					GateDistance: distance(room, conn.From),
+					BeforeGate:   reachableWithout(room, id),
+				})
+			}
+			entry.Needs = append(entry.Needs, n)
+		}
+		audit.Gates = append(audit.Gates, entry)
+	}
+
+	return audit, nil
+}
+
+// MarshalGateAudit serializes a GateAudit to JSON with indentation.
+func MarshalGateAudit(audit *GateAudit) ([]byte, error) {
+	return json.MarshalIndent(audit, "", "  ")
+}
+
+// GateAuditMarkdown renders a GateAudit as a Markdown table, one row per
+// gate. Providers are listed with their distance from Start and to the
+// gate, and flagged when they lie behind the gate they open.
+func GateAuditMarkdown(audit *GateAudit) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Gate Audit (seed %d)\n\n", audit.Seed)
+
+	if len(audit.Gates) == 0 {
+		buf.WriteString("No gates.\n")
+		return buf.Bytes()
+	}
+
+	locks := 0
+	for _, gate := range audit.Gates {
+		if !gate.Alternate {
+			locks++
+		}
+	}
+	fmt.Fprintf(&buf, "%d gates, %d without an alternate route.\n\n", len(audit.Gates), locks)
+
+	buf.WriteString("| Connector | From → To | Requires | Providers | Distance | Alternate route |\n")
+	buf.WriteString("|---|---|---|---|---|---|\n")
+	for _, gate := range audit.Gates {
+		arrow := "→"
+		if gate.Bidirectional {
+			arrow = "↔"
+		}
+
+		var needs []string
+		for _, need := range gate.Needs {
+			var rooms []string
+			for _, p := range need.Providers {
+				room := fmt.Sprintf("%s (%s from Start, %s to gate)", p.Room, auditDistance(p.Distance), auditDistance(p.GateDistance))
+				if !p.BeforeGate {
+					room += " behind gate"
+				}
+				rooms = append(rooms, room)
+			}
+			if len(rooms) == 0 {
+				rooms = append(rooms, "none")
+			}
+			needs = append(needs, need.Type+":"+need.Value+": "+strings.Join(rooms, ", "))
+		}
+
+		alternate := "no"
+		if gate.Alternate {
+			alternate = "yes"
+		}
+		fmt.Fprintf(&buf, "| %s | %s %s %s | %s | %s | %s | %s |\n",
+			markdownCell(gate.Connector),
+			markdownCell(gate.From), arrow, markdownCell(gate.To),
+			markdownCell(gate.Requirement),
+			markdownCell(strings.Join(needs, "<br>")),
+			auditDistance(gate.Distance),
+			alternate)
+	}
+	return buf.Bytes()
+}
+
+// SaveGateAuditToFiles writes an artifact's gate audit as JSON and
+// Markdown with dungeon.WriteFile.
+func SaveGateAuditToFiles(artifact *dungeon.Artifact, jsonPath, markdownPath string, options ...ExportOption) error {
+	audit, err := ExportGateAudit(artifact)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalGateAudit(audit)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(jsonPath, data, options); err != nil {
+		return err
+	}
+	return writeFile(markdownPath, GateAuditMarkdown(audit), options)
+}
+
+// ExportGateAuditTo writes an artifact's gate audit to jsonW as indented
+// JSON and to markdownW as a Markdown table. Either writer may be nil to
+// skip that document.
+func ExportGateAuditTo(jsonW, markdownW io.Writer, artifact *dungeon.Artifact) error {
+	audit, err := ExportGateAudit(artifact)
+	if err != nil {
+		return err
+	}
+	if jsonW != nil {
+		if err := encodeJSON(jsonW, audit, true); err != nil {
+			return err
+		}
+	}
+	if markdownW != nil {
+		if _, err := markdownW.Write(GateAuditMarkdown(audit)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditDistance formats a distance, with "-" for unreachable.
+func auditDistance(d int) string {
+	if d < 0 {
+		return "-"
+	}
+	return fmt.Sprint(d)
+}
+
+// markdownCell escapes the pipes that would end a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
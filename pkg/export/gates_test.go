@@ -0,0 +1,110 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// createGatesTestArtifact builds S -c1- K, S -c2[silver]- B -c3[gold]- T and
+// S -c4- X -c5[silver]- B, with the silver key in K and the gold key in T,
+// behind its own gate.
+func createGatesTestArtifact() *dungeon.Artifact {
+	g := graph.NewGraph(7)
+	_ = g.AddRoom(&graph.Room{ID: "S", Archetype: graph.ArchetypeStart, Size: graph.SizeS})
+	_ = g.AddRoom(&graph.Room{ID: "K", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS,
+		Provides: []graph.Capability{{Type: "key", Value: "silver"}}})
+	_ = g.AddRoom(&graph.Room{ID: "B", Archetype: graph.ArchetypeBoss, Size: graph.SizeL})
+	_ = g.AddRoom(&graph.Room{ID: "T", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS,
+		Provides: []graph.Capability{{Type: "key", Value: "gold"}}})
+	_ = g.AddRoom(&graph.Room{ID: "X", Archetype: graph.ArchetypeOptional, Size: graph.SizeS})
+
+	silver := &graph.Gate{Type: "key", Value: "silver"}
+	_ = g.AddConnector(&graph.Connector{ID: "c1", From: "S", To: "K", Type: graph.TypeDoor, Cost: 1, Bidirectional: true})
+	_ = g.AddConnector(&graph.Connector{ID: "c2", From: "S", To: "B", Type: graph.TypeDoor, Cost: 1, Bidirectional: true, Gate: silver})
+	_ = g.AddConnector(&graph.Connector{ID: "c3", From: "B", To: "T", Type: graph.TypeDoor, Cost: 1, Bidirectional: true,
+		Gate: &graph.Gate{Type: "key", Value: "gold"}})
+	_ = g.AddConnector(&graph.Connector{ID: "c4", From: "S", To: "X", Type: graph.TypeCorridor, Cost: 1, Bidirectional: true})
+	_ = g.AddConnector(&graph.Connector{ID: "c5", From: "X", To: "B", Type: graph.TypeDoor, Cost: 1, Bidirectional: false, Gate: silver})
+
+	return &dungeon.Artifact{ADG: &dungeon.Graph{Graph: g}}
+}
+
+func TestExportGateAudit(t *testing.T) {
+	audit, err := ExportGateAudit(createGatesTestArtifact())
+	if err != nil {
+		t.Fatalf("ExportGateAudit() error = %v", err)
+	}
+	if audit.Seed != 7 || audit.Start != "S" {
+		t.Errorf("seed, start = %d, %q, want 7, \"S\"", audit.Seed, audit.Start)
+	}
+	if len(audit.Gates) != 3 {
+		t.Fatalf("got %d gates, want 3", len(audit.Gates))
+	}
+
+	c2, c3, c5 := audit.Gates[0], audit.Gates[1], audit.Gates[2]
+	if c2.Connector != "c2" || c3.Connector != "c3" || c5.Connector != "c5" {
+		t.Fatalf("gates out of connector order: %s, %s, %s", c2.Connector, c3.Connector, c5.Connector)
+	}
+
+	// c2 and c5 both lead to B, so each is an alternate for the other
+	if !c2.Alternate || !c5.Alternate {
+		t.Errorf("c2, c5 alternate = %v, %v, want both true", c2.Alternate, c5.Alternate)
+	}
+	if c2.Requirement != "key:silver" || c2.Distance != 0 || c5.Distance != 1 {
+		t.Errorf("c2 requirement %q distance %d, c5 distance %d", c2.Requirement, c2.Distance, c5.Distance)
+	}
+	want := GateProvider{Room: "K", Distance: 1, GateDistance: 1, BeforeGate: true}
+	if len(c2.Needs) != 1 || len(c2.Needs[0].Providers) != 1 || c2.Needs[0].Providers[0] != want {
+		t.Errorf("c2 needs = %+v, want silver from %+v", c2.Needs, want)
+	}
+
+	// The gold key lies behind the only way to T
+	if c3.Alternate {
+		t.Error("c3 has an alternate route, want none")
+	}
+	want = GateProvider{Room: "T", Distance: 2, GateDistance: 1, BeforeGate: false}
+	if len(c3.Needs) != 1 || len(c3.Needs[0].Providers) != 1 || c3.Needs[0].Providers[0] != want {
+		t.Errorf("c3 needs = %+v, want gold from %+v", c3.Needs, want)
+	}
+
+	data, err := MarshalGateAudit(audit)
+	if err != nil {
+		t.Fatalf("MarshalGateAudit() error = %v", err)
+	}
+	var decoded GateAudit
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(decoded.Gates) != 3 || decoded.Gates[1].Needs[0].Providers[0].Room != "T" {
+		t.Errorf("JSON round trip lost gates: %s", data)
+	}
+}
+
+func TestGateAuditMarkdown(t *testing.T) {
+	audit, err := ExportGateAudit(createGatesTestArtifact())
+	if err != nil {
+		t.Fatalf("ExportGateAudit() error = %v", err)
+	}
+	md := string(GateAuditMarkdown(audit))
+
+	for _, want := range []string{
+		"# Gate Audit (seed 7)",
+		"3 gates, 1 without an alternate route.",
+		"| c2 | S ↔ B | key:silver | key:silver: K (1 from Start, 1 to gate) | 0 | yes |",
+		"| c3 | B ↔ T | key:gold | key:gold: T (2 from Start, 1 to gate) behind gate | 1 | no |",
+		"| c5 | X → B |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	empty := string(GateAuditMarkdown(&GateAudit{Seed: 1}))
+	if !strings.Contains(empty, "No gates.") {
+		t.Errorf("empty audit = %q, want \"No gates.\"", empty)
+	}
+}
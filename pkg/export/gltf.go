@@ -0,0 +1,318 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// glTF constants used by the exporter.
+const (
+	gltfFloat        = 5126  // Accessor component type FLOAT
+	gltfUnsignedInt  = 5125  // Accessor component type UNSIGNED_INT
+	gltfArrayBuffer  = 34962 // Buffer view target for vertex data
+	gltfElementArray = 34963 // Buffer view target for index data
+)
+
+// GLTFOptions configures 3D mesh export.
+type GLTFOptions struct {
+	TileSize    float64 // World units per tile (default: 1)
+	WallHeight  float64 // Wall height in world units (default: 3)
+	LevelHeight float64 // Height of one elevation level in world units (default: 0.5)
+	Theme       string  // Theme of tiles outside every biome, such as unthemed maps (default: "dungeon")
+}
+
+// DefaultGLTFOptions returns sensible default glTF export options.
+func DefaultGLTFOptions() GLTFOptions {
+	return GLTFOptions{
+		TileSize:    1.0,
+		WallHeight:  3.0,
+		LevelHeight: 0.5,
+		Theme:       "dungeon",
+	}
+}
+
+// GLTFDocument is the subset of the glTF 2.0 JSON schema produced by ExportGLTF.
+type GLTFDocument struct {
+	Asset       GLTFAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []GLTFScene      `json:"scenes"`
+	Nodes       []GLTFNode       `json:"nodes,omitempty"`
+	Meshes      []GLTFMesh       `json:"meshes,omitempty"`
+	Materials   []GLTFMaterial   `json:"materials,omitempty"`
+	Accessors   []GLTFAccessor   `json:"accessors,omitempty"`
+	BufferViews []GLTFBufferView `json:"bufferViews,omitempty"`
+	Buffers     []GLTFBuffer     `json:"buffers,omitempty"`
+}
+
+// GLTFAsset holds glTF asset metadata.
+type GLTFAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+// GLTFScene lists the root nodes of a scene.
+type GLTFScene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes,omitempty"`
+}
+
+// GLTFNode places a mesh in the scene.
+type GLTFNode struct {
+	Name string `json:"name,omitempty"`
+	Mesh int    `json:"mesh"`
+}
+
+// GLTFMesh is a named set of primitives.
+type GLTFMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []GLTFPrimitive `json:"primitives"`
+}
+
+// GLTFPrimitive is an indexed triangle list with one material.
+type GLTFPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+// GLTFMaterial is a named PBR material slot.
+type GLTFMaterial struct {
+	Name                 string                 `json:"name"`
+	PBRMetallicRoughness GLTFPBRMaterial        `json:"pbrMetallicRoughness"`
+	Extras               map[string]interface{} `json:"extras,omitempty"`
+}
+
+// GLTFPBRMaterial holds metallic-roughness material parameters.
+type GLTFPBRMaterial struct {
+	BaseColorFactor [4]float64 `json:"baseColorFactor"`
+	MetallicFactor  float64    `json:"metallicFactor"`
+	RoughnessFactor float64    `json:"roughnessFactor"`
+}
+
+// GLTFAccessor describes typed data within a buffer view.
+type GLTFAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+// GLTFBufferView is a byte range within a buffer.
+type GLTFBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+// GLTFBuffer holds binary data, embedded as a base64 data URI.
+type GLTFBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri"`
+}
+
+// ExportGLTF extrudes an artifact's tile map into 3D geometry and returns it
+// as a glTF 2.0 document. Floors become quads raised by their elevation, walls
+// become boxes of opts.WallHeight, and doorways are left open. Each theme and
+// surface kind is a separate primitive with its own material slot, so engines
+// can swap materials per slot. Tiles take the theme of their biome (see
+// tileThemes); a map without surfaces exports an empty scene.
+func ExportGLTF(artifact *dungeon.Artifact, opts GLTFOptions, options ...ExportOption) (*GLTFDocument, error) {
+	applyOptions(&opts, options)
+	if artifact == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact has no tile map")
+	}
+
+	defaults := DefaultGLTFOptions()
+	if opts.TileSize <= 0 {
+		opts.TileSize = defaults.TileSize
+	}
+	if opts.WallHeight <= 0 {
+		opts.WallHeight = defaults.WallHeight
+	}
+	if opts.LevelHeight <= 0 {
+		opts.LevelHeight = defaults.LevelHeight
+	}
+	if opts.Theme == "" {
+		opts.Theme = defaults.Theme
+	}
+
+	tm := artifact.TileMap
+	kinds := classifySurfaces(tm)
+	names, tileTheme := tileThemes(artifact, opts.Theme)
+	ex := extrusion{
+		tileSize:    float32(opts.TileSize),
+		wallHeight:  float32(opts.WallHeight),
+		levelHeight: float32(opts.LevelHeight),
+	}
+
+	doc := &GLTFDocument{
+		Asset:  GLTFAsset{Version: "2.0", Generator: "dungo"},
+		Scene:  0,
+		Scenes: []GLTFScene{{Name: "dungeon"}},
+	}
+
+	full := carving.Rect{Width: tm.Width, Height: tm.Height}
+	var bin bytes.Buffer
+	var primitives []GLTFPrimitive
+	for t, theme := range names {
+		palette := themes.PaletteOf(theme)
+		include := func(idx int) bool { return tileTheme[idx] == t }
+		for _, slot := range surfaceSlots {
+			m := extrudeRegion(tm, kinds, slot.kind, full, include, ex)
+			if len(m.indices) == 0 {
+				continue
+			}
+
+			doc.Materials = append(doc.Materials, GLTFMaterial{
+				Name: fmt.Sprintf("%s_%s", theme, slot.name),
+				PBRMetallicRoughness: GLTFPBRMaterial{
+					BaseColorFactor: palette[slot.name],
+					MetallicFactor:  0,
+					RoughnessFactor: 1,
+				},
+				Extras: map[string]interface{}{"theme": theme, "slot": slot.name},
+			})
+
+			minV, maxV := m.bounds()
+			position := doc.addAccessor(&bin, m.positions, gltfFloat, len(m.positions)/3, "VEC3", gltfArrayBuffer)
+			doc.Accessors[position].Min = minV[:]
+			doc.Accessors[position].Max = maxV[:]
+			normal := doc.addAccessor(&bin, m.normals, gltfFloat, len(m.normals)/3, "VEC3", gltfArrayBuffer)
+			indices := doc.addAccessor(&bin, m.indices, gltfUnsignedInt, len(m.indices), "SCALAR", gltfElementArray)
+
+			primitives = append(primitives, GLTFPrimitive{
+				Attributes: map[string]int{"POSITION": position, "NORMAL": normal},
+				Indices:    indices,
+				Material:   len(doc.Materials) - 1,
+			})
+		}
+	}
+
+	// glTF forbids meshes without primitives and empty buffers
+	if len(primitives) == 0 {
+		return doc, nil
+	}
+	doc.Scenes[0].Nodes = []int{0}
+	doc.Nodes = []GLTFNode{{Name: "dungeon", Mesh: 0}}
+	doc.Meshes = []GLTFMesh{{Name: "dungeon", Primitives: primitives}}
+	doc.Buffers = []GLTFBuffer{{
+		ByteLength: bin.Len(),
+		URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(bin.Bytes()),
+	}}
+
+	return doc, nil
+}
+
+// tileThemes returns the themes present in an artifact and the index into
+// them of every tile's theme. Tiles of the "biome" layer take their biome;
+// walls, doors and other tiles outside it take the biome of the nearest
+// biome tile. Maps without biome tiles, such as those carved without
+// themes, use the fallback theme throughout.
+func tileThemes(artifact *dungeon.Artifact, fallback string) ([]string, []int) {
+	tm := artifact.TileMap
+	tileTheme := make([]int, tm.Width*tm.Height)
+	for i := range tileTheme {
+		tileTheme[i] = -1
+	}
+
+	var names []string
+	if layer, ok := tm.Layers["biome"]; ok && layer.Type == "tilelayer" && artifact.ADG != nil {
+		names = biomePalette(artifact.ADG)
+		for i, v := range layer.Data {
+			if v != 0 && int(v) <= len(names) {
+				tileTheme[i] = int(v) - 1
+			}
+		}
+	}
+
+	// Flood the unlabelled tiles from the labelled ones, in row-major order
+	queue := make([]int, 0, len(tileTheme))
+	for i, t := range tileTheme {
+		if t >= 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		x, y := i%tm.Width, i/tm.Width
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || nx >= tm.Width || ny < 0 || ny >= tm.Height || tileTheme[ny*tm.Width+nx] >= 0 {
+				continue
+			}
+			tileTheme[ny*tm.Width+nx] = tileTheme[i]
+			queue = append(queue, ny*tm.Width+nx)
+		}
+	}
+
+	if len(tileTheme) > 0 && tileTheme[0] < 0 {
+		// The flood reaches every tile unless nothing was labelled
+		names = []string{fallback}
+		for i := range tileTheme {
+			tileTheme[i] = len(names) - 1
+		}
+	}
+	return names, tileTheme
+}
+
+// addAccessor appends little-endian data to bin with its own buffer view and
+// accessor, returning the accessor index. Data is 4-byte aligned by construction.
+func (d *GLTFDocument) addAccessor(bin *bytes.Buffer, data interface{}, componentType, count int, typ string, target int) int {
+	offset := bin.Len()
+	_ = binary.Write(bin, binary.LittleEndian, data)
+
+	d.BufferViews = append(d.BufferViews, GLTFBufferView{
+		Buffer:     0,
+		ByteOffset: offset,
+		ByteLength: bin.Len() - offset,
+		Target:     target,
+	})
+	d.Accessors = append(d.Accessors, GLTFAccessor{
+		BufferView:    len(d.BufferViews) - 1,
+		ComponentType: componentType,
+		Count:         count,
+		Type:          typ,
+	})
+	return len(d.Accessors) - 1
+}
+
+// MarshalGLTF serializes a glTF document to JSON.
+func MarshalGLTF(doc *GLTFDocument) ([]byte, error) {
+	return json.Marshal(doc)
+}
+
+// ExportGLTFTo writes an artifact to w as a glTF document with an embedded
+// buffer.
+func ExportGLTFTo(w io.Writer, artifact *dungeon.Artifact, opts GLTFOptions, options ...ExportOption) error {
+	doc, err := ExportGLTF(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, doc, false)
+}
+
+// SaveGLTFToFile exports an artifact as a .gltf file with an embedded
+// buffer, written with dungeon.WriteFile.
+func SaveGLTFToFile(artifact *dungeon.Artifact, filepath string, opts GLTFOptions, options ...ExportOption) error {
+	doc, err := ExportGLTF(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalGLTF(doc)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
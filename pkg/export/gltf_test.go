@@ -0,0 +1,159 @@
+package export
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// createGLTFTestArtifact builds a 5x3 room: a floor row walled above and below,
+// with a door on the right end of the floor row.
+func createGLTFTestArtifact() *dungeon.Artifact {
+	floor := make([]uint32, 15)
+	walls := make([]uint32, 15)
+	for x := 0; x < 5; x++ {
+		walls[x] = 2
+		floor[5+x] = 1
+		walls[10+x] = 2
+	}
+
+	return &dungeon.Artifact{
+		TileMap: &dungeon.TileMap{
+			Width: 5, Height: 3, TileWidth: 16, TileHeight: 16,
+			Layers: map[string]*dungeon.Layer{
+				"floor": {Name: "floor", Type: "tilelayer", Data: floor},
+				"walls": {Name: "walls", Type: "tilelayer", Data: walls},
+				"doors": {Name: "doors", Type: "objectgroup", Objects: []dungeon.Object{
+					{Type: "door", X: 64, Y: 16, Width: 16, Height: 16},
+				}},
+			},
+		},
+	}
+}
+
+func TestExportGLTF(t *testing.T) {
+	doc, err := ExportGLTF(createGLTFTestArtifact(), GLTFOptions{Theme: "crypt"})
+	if err != nil {
+		t.Fatalf("ExportGLTF() error = %v", err)
+	}
+
+	if doc.Asset.Version != "2.0" {
+		t.Errorf("asset version = %q, want 2.0", doc.Asset.Version)
+	}
+
+	// Floor, wall and door slots are present; no destructibles were carved
+	wantMaterials := []string{"crypt_floor", "crypt_wall", "crypt_door"}
+	if len(doc.Materials) != len(wantMaterials) {
+		t.Fatalf("got %d materials, want %d", len(doc.Materials), len(wantMaterials))
+	}
+	for i, name := range wantMaterials {
+		if doc.Materials[i].Name != name {
+			t.Errorf("material %d = %q, want %q", i, doc.Materials[i].Name, name)
+		}
+	}
+	if len(doc.Meshes[0].Primitives) != len(wantMaterials) {
+		t.Errorf("got %d primitives, want %d", len(doc.Meshes[0].Primitives), len(wantMaterials))
+	}
+
+	// Walls are merged into two boxes of 5 faces each: 40 vertices
+	wall := doc.Meshes[0].Primitives[1]
+	if got := doc.Accessors[wall.Attributes["POSITION"]].Count; got != 40 {
+		t.Errorf("wall vertex count = %d, want 40", got)
+	}
+	if got := doc.Accessors[wall.Attributes["POSITION"]].Max[1]; got != 3 {
+		t.Errorf("wall height = %v, want default 3", got)
+	}
+
+	buf := doc.Buffers[0]
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(buf.URI, "data:application/octet-stream;base64,"))
+	if err != nil {
+		t.Fatalf("buffer URI is not valid base64: %v", err)
+	}
+	if len(data) != buf.ByteLength {
+		t.Errorf("buffer length = %d, want %d", len(data), buf.ByteLength)
+	}
+
+	raw, err := MarshalGLTF(doc)
+	if err != nil {
+		t.Fatalf("MarshalGLTF() error = %v", err)
+	}
+	if !strings.Contains(string(raw), `"pbrMetallicRoughness"`) {
+		t.Error("marshaled glTF is missing material parameters")
+	}
+}
+
+func TestExportGLTF_NoTileMap(t *testing.T) {
+	if _, err := ExportGLTF(&dungeon.Artifact{}, DefaultGLTFOptions()); err == nil {
+		t.Error("ExportGLTF() without tile map should error")
+	}
+}
+
+// TestExportGLTF_MultiTheme checks that materials come from the biomes of the
+// artifact rather than the fallback theme, with walls and doors taking the
+// biome of the nearest floor.
+func TestExportGLTF_MultiTheme(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+	g := graph.NewGraph(1)
+	_ = g.AddRoom(&graph.Room{ID: "a", Archetype: graph.ArchetypeStart, Size: graph.SizeS, Tags: map[string]string{"biome": "crypt"}})
+	_ = g.AddRoom(&graph.Room{ID: "b", Archetype: graph.ArchetypeBoss, Size: graph.SizeS, Tags: map[string]string{"biome": "fungal"}})
+	artifact.ADG = &dungeon.Graph{Graph: g}
+
+	// The floor row is crypt on the left and fungal on the right
+	biome := make([]uint32, 15)
+	copy(biome[5:], []uint32{1, 1, 2, 2, 0})
+	artifact.TileMap.Layers["biome"] = &dungeon.Layer{Name: "biome", Type: "tilelayer", Data: biome}
+
+	doc, err := ExportGLTF(artifact, GLTFOptions{Theme: "arcane"})
+	if err != nil {
+		t.Fatalf("ExportGLTF() error = %v", err)
+	}
+
+	wantMaterials := []string{"crypt_floor", "crypt_wall", "fungal_floor", "fungal_wall", "fungal_door"}
+	if len(doc.Materials) != len(wantMaterials) {
+		t.Fatalf("got %d materials, want %d", len(doc.Materials), len(wantMaterials))
+	}
+	for i, name := range wantMaterials {
+		if doc.Materials[i].Name != name {
+			t.Errorf("material %d = %q, want %q", i, doc.Materials[i].Name, name)
+		}
+	}
+	if doc.Materials[0].PBRMetallicRoughness == doc.Materials[2].PBRMetallicRoughness {
+		t.Error("crypt and fungal floors should use their own palettes")
+	}
+
+	// Each primitive uses its own material
+	for i, p := range doc.Meshes[0].Primitives {
+		if p.Material != i {
+			t.Errorf("primitive %d material = %d, want %d", i, p.Material, i)
+		}
+	}
+}
+
+// TestExportGLTF_Empty checks that a map without surfaces exports an empty
+// scene instead of empty primitives and buffers.
+func TestExportGLTF_Empty(t *testing.T) {
+	artifact := &dungeon.Artifact{
+		TileMap: &dungeon.TileMap{Width: 4, Height: 4, TileWidth: 16, TileHeight: 16, Layers: map[string]*dungeon.Layer{}},
+	}
+	doc, err := ExportGLTF(artifact, DefaultGLTFOptions())
+	if err != nil {
+		t.Fatalf("ExportGLTF() error = %v", err)
+	}
+	if len(doc.Meshes) != 0 || len(doc.Nodes) != 0 || len(doc.Materials) != 0 || len(doc.BufferViews) != 0 || len(doc.Buffers) != 0 {
+		t.Errorf("got %d meshes, %d nodes, %d materials, %d buffer views and %d buffers, want none",
+			len(doc.Meshes), len(doc.Nodes), len(doc.Materials), len(doc.BufferViews), len(doc.Buffers))
+	}
+
+	raw, err := MarshalGLTF(doc)
+	if err != nil {
+		t.Fatalf("MarshalGLTF() error = %v", err)
+	}
+	for _, field := range []string{"null", `"meshes"`, `"buffers"`, `"nodes"`} {
+		if strings.Contains(string(raw), field) {
+			t.Errorf("marshaled glTF contains %s: %s", field, raw)
+		}
+	}
+}
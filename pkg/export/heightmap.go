@@ -0,0 +1,87 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// Heightmap is an engine-neutral description of per-tile elevation.
+// Levels holds one signed level per tile in row-major order: 0 is ground,
+// positive levels are raised platforms and negative levels are sunken pits.
+// MaxStep is the largest level difference walkable between adjacent tiles.
+type Heightmap struct {
+	Width    int   `json:"width"`
+	Height   int   `json:"height"`
+	MinLevel int   `json:"minLevel"`
+	MaxLevel int   `json:"maxLevel"`
+	MaxStep  int   `json:"maxStep"`
+	Levels   []int `json:"levels"`
+}
+
+// ExportHeightmap builds a Heightmap from an artifact's "elevation" tile layer.
+func ExportHeightmap(artifact *dungeon.Artifact) (*Heightmap, error) {
+	if artifact == nil || artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact has no tile map")
+	}
+
+	tm := artifact.TileMap
+	layer, ok := tm.Layers["elevation"]
+	if !ok || layer.Type != "tilelayer" {
+		return nil, fmt.Errorf("tile map has no elevation layer")
+	}
+
+	elevation := &carving.Layer{Data: layer.Data}
+	hm := &Heightmap{
+		Width:   tm.Width,
+		Height:  tm.Height,
+		MaxStep: carving.MaxElevationStep,
+		Levels:  make([]int, tm.Width*tm.Height),
+	}
+
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			level := carving.ElevationAt(elevation, x, y, tm.Width, tm.Height)
+			hm.Levels[y*tm.Width+x] = level
+			if level < hm.MinLevel {
+				hm.MinLevel = level
+			}
+			if level > hm.MaxLevel {
+				hm.MaxLevel = level
+			}
+		}
+	}
+
+	return hm, nil
+}
+
+// MarshalHeightmap serializes a Heightmap to JSON with indentation.
+func MarshalHeightmap(hm *Heightmap) ([]byte, error) {
+	return json.MarshalIndent(hm, "", "  ")
+}
+
+// ExportHeightmapTo writes an artifact's heightmap to w as indented JSON.
+func ExportHeightmapTo(w io.Writer, artifact *dungeon.Artifact) error {
+	hm, err := ExportHeightmap(artifact)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, hm, true)
+}
+
+// SaveHeightmapToFile exports an artifact's heightmap to a JSON file with
+// dungeon.WriteFile.
+func SaveHeightmapToFile(artifact *dungeon.Artifact, filepath string, options ...ExportOption) error {
+	hm, err := ExportHeightmap(artifact)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalHeightmap(hm)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
@@ -2,7 +2,7 @@ package export
 
 import (
 	"encoding/json"
-	"os"
+	"io"
 
 	"github.com/dshills/dungo/pkg/dungeon"
 )
@@ -28,22 +28,34 @@ func ExportJSONCompact(artifact *dungeon.Artifact) ([]byte, error) {
 	return json.Marshal(artifact)
 }
 
-// SaveJSONToFile exports the artifact to a JSON file with indentation.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func SaveJSONToFile(artifact *dungeon.Artifact, filepath string) error {
+// SaveJSONToFile exports the artifact to a JSON file with indentation,
+// written with dungeon.WriteFile.
+func SaveJSONToFile(artifact *dungeon.Artifact, filepath string, options ...ExportOption) error {
 	data, err := ExportJSON(artifact)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return writeFile(filepath, data, options)
 }
 
-// SaveJSONCompactToFile exports the artifact to a compact JSON file.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func SaveJSONCompactToFile(artifact *dungeon.Artifact, filepath string) error {
+// ExportJSONTo writes the complete artifact to w as JSON with indentation,
+// followed by a newline.
+func ExportJSONTo(w io.Writer, artifact *dungeon.Artifact) error {
+	return encodeJSON(w, artifact, true)
+}
+
+// ExportJSONCompactTo writes the artifact to w as JSON without indentation,
+// followed by a newline.
+func ExportJSONCompactTo(w io.Writer, artifact *dungeon.Artifact) error {
+	return encodeJSON(w, artifact, false)
+}
+
+// SaveJSONCompactToFile exports the artifact to a compact JSON file with
+// dungeon.WriteFile.
+func SaveJSONCompactToFile(artifact *dungeon.Artifact, filepath string, options ...ExportOption) error {
 	data, err := ExportJSONCompact(artifact)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return writeFile(filepath, data, options)
 }
@@ -0,0 +1,202 @@
+package export
+
+import (
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// Surface kinds used when extruding a tile map into 3D geometry.
+const (
+	surfaceNone uint32 = iota
+	surfaceFloor
+	surfaceWall
+	surfaceDoor
+	surfaceDestructible
+)
+
+// surfaceSlots names the material slot of each surface kind, in slot order.
+var surfaceSlots = []struct {
+	kind uint32
+	name string
+}{
+	{surfaceFloor, "floor"},
+	{surfaceWall, "wall"},
+	{surfaceDoor, "door"},
+	{surfaceDestructible, "destructible"},
+}
+
+// meshBuilder accumulates indexed triangle geometry.
+// Positions and normals are flat XYZ triples; Y is up.
+type meshBuilder struct {
+	positions []float32
+	normals   []float32
+	indices   []uint32
+}
+
+// vertexCount returns the number of vertices added so far.
+func (m *meshBuilder) vertexCount() int {
+	return len(m.positions) / 3
+}
+
+// addQuad adds a quad with corners a, b, c, d (in order around the edge)
+// facing along normal n. Triangle winding is chosen to match n.
+func (m *meshBuilder) addQuad(a, b, c, d, n [3]float32) {
+	base := uint32(m.vertexCount())
+	for _, v := range [][3]float32{a, b, c, d} {
+		m.positions = append(m.positions, v[0], v[1], v[2])
+		m.normals = append(m.normals, n[0], n[1], n[2])
+	}
+
+	// Counter-clockwise when the cross product of (b-a) and (c-a) points along n
+	e1 := [3]float32{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+	e2 := [3]float32{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+	cross := [3]float32{
+		e1[1]*e2[2] - e1[2]*e2[1],
+		e1[2]*e2[0] - e1[0]*e2[2],
+		e1[0]*e2[1] - e1[1]*e2[0],
+	}
+	if cross[0]*n[0]+cross[1]*n[1]+cross[2]*n[2] >= 0 {
+		m.indices = append(m.indices, base, base+1, base+2, base, base+2, base+3)
+	} else {
+		m.indices = append(m.indices, base, base+2, base+1, base, base+3, base+2)
+	}
+}
+
+// addFloor adds an upward-facing rectangle at height y.
+func (m *meshBuilder) addFloor(x0, z0, x1, z1, y float32) {
+	m.addQuad(
+		[3]float32{x0, y, z0}, [3]float32{x1, y, z0},
+		[3]float32{x1, y, z1}, [3]float32{x0, y, z1},
+		[3]float32{0, 1, 0},
+	)
+}
+
+// addBox adds the top and four sides of a box from y0 to y1.
+// The bottom face is omitted since it always rests on the floor plane.
+func (m *meshBuilder) addBox(x0, z0, x1, z1, y0, y1 float32) {
+	m.addFloor(x0, z0, x1, z1, y1)
+	m.addQuad([3]float32{x0, y0, z0}, [3]float32{x1, y0, z0}, [3]float32{x1, y1, z0}, [3]float32{x0, y1, z0}, [3]float32{0, 0, -1})
+	m.addQuad([3]float32{x0, y0, z1}, [3]float32{x1, y0, z1}, [3]float32{x1, y1, z1}, [3]float32{x0, y1, z1}, [3]float32{0, 0, 1})
+	m.addQuad([3]float32{x0, y0, z0}, [3]float32{x0, y0, z1}, [3]float32{x0, y1, z1}, [3]float32{x0, y1, z0}, [3]float32{-1, 0, 0})
+	m.addQuad([3]float32{x1, y0, z0}, [3]float32{x1, y0, z1}, [3]float32{x1, y1, z1}, [3]float32{x1, y1, z0}, [3]float32{1, 0, 0})
+}
+
+// bounds returns the per-axis minimum and maximum of all positions.
+func (m *meshBuilder) bounds() (minV, maxV [3]float32) {
+	for i := 0; i < len(m.positions); i += 3 {
+		for axis := 0; axis < 3; axis++ {
+			v := m.positions[i+axis]
+			if i == 0 || v < minV[axis] {
+				minV[axis] = v
+			}
+			if i == 0 || v > maxV[axis] {
+				maxV[axis] = v
+			}
+		}
+	}
+	return minV, maxV
+}
+
+// extrusion describes how tile coordinates map to 3D world units.
+type extrusion struct {
+	tileSize    float32
+	wallHeight  float32
+	levelHeight float32
+}
+
+// classifySurfaces assigns a surface kind to every tile of a tile map.
+// Walls come from the walls layer, floors from the floor layer, and door and
+// destructible objects override whatever is beneath them, which leaves gaps
+// in the walls at doorways.
+func classifySurfaces(tm *dungeon.TileMap) []uint32 {
+	kinds := make([]uint32, tm.Width*tm.Height)
+
+	if floor, ok := tm.Layers["floor"]; ok {
+		for i, v := range floor.Data {
+			if v != uint32(carving.TileEmpty) {
+				kinds[i] = surfaceFloor
+			}
+		}
+	}
+	if walls, ok := tm.Layers["walls"]; ok {
+		for i, v := range walls.Data {
+			if v != uint32(carving.TileEmpty) {
+				kinds[i] = surfaceWall
+			}
+		}
+	}
+
+	markObjectSurfaces(tm, "doors", kinds, surfaceDoor)
+	markObjectSurfaces(tm, "destructibles", kinds, surfaceDestructible)
+
+	return kinds
+}
+
+// markObjectSurfaces sets the surface kind of each tile under an object layer's objects.
+func markObjectSurfaces(tm *dungeon.TileMap, layerName string, kinds []uint32, kind uint32) {
+	layer, ok := tm.Layers[layerName]
+	if !ok || layer.Type != "objectgroup" || tm.TileWidth <= 0 || tm.TileHeight <= 0 {
+		return
+	}
+	for _, obj := range layer.Objects {
+		x := int(obj.X) / tm.TileWidth
+		y := int(obj.Y) / tm.TileHeight
+		if x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+			kinds[y*tm.Width+x] = kind
+		}
+	}
+}
+
+// extrudeRegion builds geometry for every tile of the given kind inside area
+// whose index passes include (nil includes all tiles). Floors and doors become
+// quads at their elevation; walls and destructibles become boxes. Tiles are
+// merged into rectangles first to keep the triangle count low.
+func extrudeRegion(tm *dungeon.TileMap, kinds []uint32, kind uint32, area carving.Rect, include func(idx int) bool, ex extrusion) *meshBuilder {
+	var elevation []uint32
+	if layer, ok := tm.Layers["elevation"]; ok && layer.Type == "tilelayer" {
+		elevation = layer.Data
+	}
+
+	// Key each tile in area by its elevation so merged rectangles stay flat
+	keys := make([]uint32, area.Width*area.Height)
+	levels := make(map[uint32]bool)
+	for ay := 0; ay < area.Height; ay++ {
+		for ax := 0; ax < area.Width; ax++ {
+			i := (area.Y+ay)*tm.Width + area.X + ax
+			if kinds[i] != kind || (include != nil && !include(i)) {
+				continue
+			}
+			key := uint32(carving.ElevationOffset)
+			if elevation != nil && elevation[i] != 0 {
+				key = elevation[i]
+			}
+			keys[ay*area.Width+ax] = key
+			levels[key] = true
+		}
+	}
+
+	sorted := make([]uint32, 0, len(levels))
+	for key := range levels {
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	m := &meshBuilder{}
+	for _, key := range sorted {
+		y := float32(int(key)-carving.ElevationOffset) * ex.levelHeight
+		for _, r := range carving.MergeRects(keys, area.Width, area.Height, key) {
+			x0 := float32(area.X+r.X) * ex.tileSize
+			z0 := float32(area.Y+r.Y) * ex.tileSize
+			x1 := float32(area.X+r.X+r.Width) * ex.tileSize
+			z1 := float32(area.Y+r.Y+r.Height) * ex.tileSize
+			if kind == surfaceWall || kind == surfaceDestructible {
+				m.addBox(x0, z0, x1, z1, y, y+ex.wallHeight)
+			} else {
+				m.addFloor(x0, z0, x1, z1, y)
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,311 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+// OBJOptions configures Wavefront OBJ blockout export.
+type OBJOptions struct {
+	TileSize    float64 // World units per tile (default: 1)
+	WallHeight  float64 // Wall height in world units (default: 3)
+	LevelHeight float64 // Height of one elevation level in world units (default: 0.5)
+	Theme       string  // Theme used for the material library colors (default: "dungeon")
+	MaterialLib string  // Optional .mtl file name referenced by the OBJ
+	Precision   int     // Decimal places of vertex coordinates, 0 for exact (default: 0)
+}
+
+// DefaultOBJOptions returns sensible default OBJ export options.
+func DefaultOBJOptions() OBJOptions {
+	return OBJOptions{
+		TileSize:    1.0,
+		WallHeight:  3.0,
+		LevelHeight: 0.5,
+		Theme:       "dungeon",
+	}
+}
+
+// ExportOBJ extrudes an artifact's tile map into Wavefront OBJ geometry with
+// one object per room ("room_<id>") and per corridor ("corridor_<id>"), so
+// level designers can replace pieces individually while keeping the layout.
+// Each room claims its footprint and surrounding wall ring; each corridor
+// claims its path and adjacent walls; anything left over goes to "misc".
+// Faces within an object are grouped by material slot (floor, wall, door,
+// destructible).
+func ExportOBJ(artifact *dungeon.Artifact, opts OBJOptions, options ...ExportOption) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ExportOBJTo(&buf, artifact, opts, options...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportOBJTo writes an artifact's blockout meshes to w in OBJ format (see
+// ExportOBJ).
+func ExportOBJTo(w io.Writer, artifact *dungeon.Artifact, opts OBJOptions, options ...ExportOption) error {
+	applyOptions(&opts, options)
+	if artifact == nil || artifact.TileMap == nil {
+		return fmt.Errorf("artifact has no tile map")
+	}
+
+	defaults := DefaultOBJOptions()
+	if opts.TileSize <= 0 {
+		opts.TileSize = defaults.TileSize
+	}
+	if opts.WallHeight <= 0 {
+		opts.WallHeight = defaults.WallHeight
+	}
+	if opts.LevelHeight <= 0 {
+		opts.LevelHeight = defaults.LevelHeight
+	}
+
+	ex := extrusion{
+		tileSize:    float32(opts.TileSize),
+		wallHeight:  float32(opts.WallHeight),
+		levelHeight: float32(opts.LevelHeight),
+	}
+
+	return writeBuffered(w, func(buf io.Writer) error {
+		writeOBJ(buf, artifact, opts, ex)
+		return nil
+	})
+}
+
+// writeOBJ writes the blockout meshes of an artifact with the given extrusion.
+func writeOBJ(w io.Writer, artifact *dungeon.Artifact, opts OBJOptions, ex extrusion) {
+	tm := artifact.TileMap
+	kinds := classifySurfaces(tm)
+	owners, names := regionOwners(artifact, kinds)
+	areas := regionAreas(owners, len(names), tm.Width)
+
+	io.WriteString(w, "# dungo blockout\n")
+	if opts.MaterialLib != "" {
+		fmt.Fprintf(w, "mtllib %s\n", opts.MaterialLib)
+	}
+
+	vertexBase := 0
+	for owner, name := range names {
+		if owner == 0 {
+			continue
+		}
+		written := false
+		for _, slot := range surfaceSlots {
+			m := extrudeRegion(tm, kinds, slot.kind, areas[owner], func(idx int) bool {
+				return owners[idx] == owner
+			}, ex)
+			if len(m.indices) == 0 {
+				continue
+			}
+
+			if !written {
+				fmt.Fprintf(w, "o %s\n", name)
+				written = true
+			}
+			fmt.Fprintf(w, "usemtl %s\n", slot.name)
+
+			for i := 0; i < len(m.positions); i += 3 {
+				fmt.Fprintf(w, "v %s %s %s\n", formatCoord(m.positions[i], opts.Precision),
+					formatCoord(m.positions[i+1], opts.Precision), formatCoord(m.positions[i+2], opts.Precision))
+			}
+			for i := 0; i < len(m.normals); i += 3 {
+				fmt.Fprintf(w, "vn %s %s %s\n", formatCoord(m.normals[i], opts.Precision),
+					formatCoord(m.normals[i+1], opts.Precision), formatCoord(m.normals[i+2], opts.Precision))
+			}
+			for i := 0; i < len(m.indices); i += 3 {
+				a := vertexBase + int(m.indices[i]) + 1
+				b := vertexBase + int(m.indices[i+1]) + 1
+				c := vertexBase + int(m.indices[i+2]) + 1
+				fmt.Fprintf(w, "f %d//%d %d//%d %d//%d\n", a, a, b, b, c, c)
+			}
+			vertexBase += m.vertexCount()
+		}
+	}
+}
+
+func (o *OBJOptions) setPrecision(digits int) {
+	o.Precision = digits
+}
+
+// ExportMTL returns a material library defining one diffuse material per
+// surface slot, colored from the theme palette.
+func ExportMTL(theme string) []byte {
+	palette := themes.PaletteOf(theme)
+
+	var buf bytes.Buffer
+	for _, slot := range surfaceSlots {
+		c := palette[slot.name]
+		fmt.Fprintf(&buf, "newmtl %s\nKd %g %g %g\nd %g\n\n", slot.name, c[0], c[1], c[2], c[3])
+	}
+	return buf.Bytes()
+}
+
+// regionOwners assigns every non-empty tile to a named region and returns the
+// per-tile owner index alongside the region names. Index 0 is unowned.
+// Rooms are claimed first in ID order, then corridors in connector ID order.
+func regionOwners(artifact *dungeon.Artifact, kinds []uint32) ([]int, []string) {
+	tm := artifact.TileMap
+	owners := make([]int, len(kinds))
+	names := []string{""}
+
+	claim := func(x, y, owner int) {
+		if x < 0 || x >= tm.Width || y < 0 || y >= tm.Height {
+			return
+		}
+		idx := y*tm.Width + x
+		if owners[idx] == 0 && kinds[idx] != surfaceNone {
+			owners[idx] = owner
+		}
+	}
+
+	rooms := roomTileBounds(artifact)
+	roomIDs := make([]string, 0, len(rooms))
+	for id := range rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	for _, id := range roomIDs {
+		names = append(names, "room_"+id)
+		owner := len(names) - 1
+		b := rooms[id]
+		for y := b.Y - 1; y <= b.Y+b.Height; y++ {
+			for x := b.X - 1; x <= b.X+b.Width; x++ {
+				claim(x, y, owner)
+			}
+		}
+	}
+
+	if artifact.Layout != nil {
+		connIDs := make([]string, 0, len(artifact.Layout.CorridorPaths))
+		for id := range artifact.Layout.CorridorPaths {
+			connIDs = append(connIDs, id)
+		}
+		sort.Strings(connIDs)
+
+		for _, id := range connIDs {
+			names = append(names, "corridor_"+id)
+			owner := len(names) - 1
+			path := artifact.Layout.CorridorPaths[id]
+			for i := 0; i < len(path.Points)-1; i++ {
+				p1, p2 := path.Points[i], path.Points[i+1]
+				for _, p := range linePoints(p1.X, p1.Y, p2.X, p2.Y) {
+					for dy := -1; dy <= 1; dy++ {
+						for dx := -1; dx <= 1; dx++ {
+							claim(p.X+dx, p.Y+dy, owner)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	names = append(names, "misc")
+	misc := len(names) - 1
+	for i := range owners {
+		if owners[i] == 0 && kinds[i] != surfaceNone {
+			owners[i] = misc
+		}
+	}
+
+	return owners, names
+}
+
+// regionAreas returns the bounding rectangle of each owner's tiles.
+// Owners without tiles get an empty rectangle.
+func regionAreas(owners []int, count, width int) []carving.Rect {
+	minX := make([]int, count)
+	minY := make([]int, count)
+	maxX := make([]int, count)
+	maxY := make([]int, count)
+	for i := range minX {
+		minX[i], minY[i], maxX[i], maxY[i] = -1, -1, -1, -1
+	}
+
+	for idx, owner := range owners {
+		x, y := idx%width, idx/width
+		if minX[owner] < 0 || x < minX[owner] {
+			minX[owner] = x
+		}
+		if minY[owner] < 0 || y < minY[owner] {
+			minY[owner] = y
+		}
+		if x > maxX[owner] {
+			maxX[owner] = x
+		}
+		if y > maxY[owner] {
+			maxY[owner] = y
+		}
+	}
+
+	areas := make([]carving.Rect, count)
+	for i := range areas {
+		if minX[i] >= 0 {
+			areas[i] = carving.Rect{X: minX[i], Y: minY[i], Width: maxX[i] - minX[i] + 1, Height: maxY[i] - minY[i] + 1}
+		}
+	}
+	return areas
+}
+
+// linePoints returns the tiles on the Bresenham line between two points.
+func linePoints(x0, y0, x1, y1 int) []dungeon.Point {
+	points := []dungeon.Point{}
+	dx, dy := x1-x0, y1-y0
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	sx, sy := -1, -1
+	if x0 < x1 {
+		sx = 1
+	}
+	if y0 < y1 {
+		sy = 1
+	}
+	err := dx - dy
+	for {
+		points = append(points, dungeon.Point{X: x0, Y: y0})
+		if x0 == x1 && y0 == y1 {
+			return points
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// SaveOBJToFile exports an artifact as a Wavefront OBJ file and writes a
+// matching .mtl material library next to it, both with dungeon.WriteFile.
+func SaveOBJToFile(artifact *dungeon.Artifact, path string, opts OBJOptions, options ...ExportOption) error {
+	mtlPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mtl"
+	opts.MaterialLib = filepath.Base(mtlPath)
+
+	data, err := ExportOBJ(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, data, options); err != nil {
+		return err
+	}
+
+	theme := opts.Theme
+	if theme == "" {
+		theme = DefaultOBJOptions().Theme
+	}
+	return writeFile(mtlPath, ExportMTL(theme), options)
+}
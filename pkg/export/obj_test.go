@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+func TestExportOBJ(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+	g := graph.NewGraph(1)
+	_ = g.AddRoom(&graph.Room{ID: "R1", Size: graph.SizeXS})
+	artifact.ADG = &dungeon.Graph{Graph: g}
+	artifact.Layout = &dungeon.Layout{
+		Poses: map[string]dungeon.Pose{"R1": {X: 1, Y: 1}},
+		CorridorPaths: map[string]dungeon.Path{
+			"C1": {Points: []dungeon.Point{{X: 3, Y: 1}, {X: 4, Y: 1}}},
+		},
+	}
+
+	data, err := ExportOBJ(artifact, DefaultOBJOptions())
+	if err != nil {
+		t.Fatalf("ExportOBJ() error = %v", err)
+	}
+	obj := string(data)
+
+	for _, want := range []string{"o room_R1\n", "o corridor_C1\n", "usemtl floor\n", "usemtl wall\n", "usemtl door\n"} {
+		if !strings.Contains(obj, want) {
+			t.Errorf("OBJ output missing %q", want)
+		}
+	}
+	if strings.Contains(obj, "o misc\n") {
+		t.Error("OBJ output has unowned geometry, want every tile claimed by R1 or C1")
+	}
+
+	// Every face must reference an existing vertex
+	vertices := strings.Count(obj, "\nv ")
+	for _, line := range strings.Split(obj, "\n") {
+		if !strings.HasPrefix(line, "f ") {
+			continue
+		}
+		for _, ref := range strings.Fields(line)[1:] {
+			var v int
+			if _, err := fmt.Sscanf(ref, "%d//", &v); err != nil || v < 1 || v > vertices {
+				t.Fatalf("face %q references vertex %d of %d", line, v, vertices)
+			}
+		}
+	}
+}
+
+func TestSaveOBJToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dungeon.obj")
+
+	if err := SaveOBJToFile(createGLTFTestArtifact(), path, DefaultOBJOptions()); err != nil {
+		t.Fatalf("SaveOBJToFile() error = %v", err)
+	}
+
+	obj, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading OBJ: %v", err)
+	}
+	if !strings.Contains(string(obj), "mtllib dungeon.mtl") {
+		t.Error("OBJ does not reference its material library")
+	}
+
+	mtl, err := os.ReadFile(filepath.Join(dir, "dungeon.mtl"))
+	if err != nil {
+		t.Fatalf("reading MTL: %v", err)
+	}
+	if !strings.Contains(string(mtl), "newmtl wall") {
+		t.Error("MTL is missing the wall material")
+	}
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// DefaultSightRadius is the distance in tiles up to which the occlusion
+// graph traces sight between rooms.
+const DefaultSightRadius = 24
+
+// OcclusionFile is the occlusion graph of a dungeon: the room pairs that
+// share walls, are joined by connectors or see into each other, so audio
+// and visibility systems know which neighbours leak sound or show through
+// openings.
+type OcclusionFile struct {
+	Seed        uint64          `json:"seed"`
+	SightRadius int             `json:"sightRadius"`
+	Links       []OcclusionLink `json:"links"` // Ordered by From, then To
+}
+
+// OcclusionLink is how sound and sight pass between two rooms.
+type OcclusionLink struct {
+	From string `json:"from"` // Room ID, before To
+	To   string `json:"to"`
+
+	WallTiles     int `json:"wallTiles"`     // Length of wall between the floors, at most carving.MaxOcclusionWall thick
+	WallThickness int `json:"wallThickness"` // 0 without a shared wall
+	Openings      int `json:"openings"`      // Tiles along the wall where floor runs through it
+
+	// Connectors joining the rooms directly, ordered by ID
+	Connectors []OcclusionConnector `json:"connectors"`
+
+	VisibleFrom int  `json:"visibleFrom"` // Floor tiles of To seen from From's floor
+	VisibleTo   int  `json:"visibleTo"`   // Floor tiles of From seen from To's floor
+	LineOfSight bool `json:"lineOfSight"` // Either room sees into the other
+}
+
+// OcclusionConnector is a connector between the rooms of a link. Closed
+// doors muffle sound and block sight; teleporters carry neither.
+type OcclusionConnector struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`   // e.g. "Door", "Corridor", "Hidden"
+	Gated  bool   `json:"gated"`  // Locked until its gate opens
+	Hidden bool   `json:"hidden"` // Concealed until discovered
+}
+
+// ExportOcclusion builds the occlusion graph of an artifact, tracing sight
+// up to radius tiles (DefaultSightRadius when radius <= 0). Sight is traced
+// from every floor tile of each room over the carved floor, as
+// carving.FieldOfView does.
+func ExportOcclusion(artifact *dungeon.Artifact, radius int) (*OcclusionFile, error) {
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return nil, fmt.Errorf("artifact has no graph")
+	}
+	if artifact.TileMap == nil {
+		return nil, fmt.Errorf("artifact has no tile map")
+	}
+	layer, ok := artifact.TileMap.Layers["floor"]
+	if !ok || layer.Type != "tilelayer" {
+		return nil, fmt.Errorf("tile map has no floor layer")
+	}
+	if radius <= 0 {
+		radius = DefaultSightRadius
+	}
+
+	g := artifact.ADG.Graph
+	tm := &carving.TileMap{
+		Width:  artifact.TileMap.Width,
+		Height: artifact.TileMap.Height,
+		Layers: map[string]*carving.Layer{"floor": {Data: layer.Data}},
+	}
+
+	links := make(map[[2]string]*OcclusionLink)
+	link := func(a, b string) *OcclusionLink {
+		if b < a {
+			a, b = b, a
+		}
+		l, ok := links[[2]string{a, b}]
+		if !ok {
+			l = &OcclusionLink{From: a, To: b, Connectors: []OcclusionConnector{}}
+			links[[2]string{a, b}] = l
+		}
+		return l
+	}
+
+	for _, o := range carving.OcclusionGraph(tm, roomTileBounds(artifact), radius) {
+		l := link(o.From, o.To)
+		l.WallTiles, l.WallThickness, l.Openings = o.WallTiles, o.WallThickness, o.Openings
+		l.VisibleFrom, l.VisibleTo = o.VisibleFrom, o.VisibleTo
+		l.LineOfSight = o.VisibleFrom+o.VisibleTo > 0
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		if conn.From == conn.To {
+			continue
+		}
+		l := link(conn.From, conn.To)
+		l.Connectors = append(l.Connectors, OcclusionConnector{
+			ID:     id,
+			Type:   conn.Type.String(),
+			Gated:  conn.Gate != nil,
+			Hidden: conn.Type == graph.TypeHidden || conn.Visibility != graph.VisibilityNormal,
+		})
+	}
+
+	file := &OcclusionFile{Seed: g.Seed, SightRadius: radius, Links: make([]OcclusionLink, 0, len(links))}
+	for _, l := range links {
+		file.Links = append(file.Links, *l)
+	}
+	sort.Slice(file.Links, func(i, j int) bool {
+		a, b := file.Links[i], file.Links[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return file, nil
+}
+
+// MarshalOcclusion serializes an OcclusionFile to JSON with indentation.
+func MarshalOcclusion(file *OcclusionFile) ([]byte, error) {
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// ExportOcclusionTo writes an artifact's occlusion graph to w as indented
+// JSON.
+func ExportOcclusionTo(w io.Writer, artifact *dungeon.Artifact, radius int) error {
+	file, err := ExportOcclusion(artifact, radius)
+	if err != nil {
+		return err
+	}
+	return encodeJSON(w, file, true)
+}
+
+// SaveOcclusionToFile exports an artifact's occlusion graph to a JSON file
+// with dungeon.WriteFile.
+func SaveOcclusionToFile(artifact *dungeon.Artifact, filepath string, radius int, options ...ExportOption) error {
+	file, err := ExportOcclusion(artifact, radius)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalOcclusion(file)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath, data, options)
+}
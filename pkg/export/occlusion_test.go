@@ -0,0 +1,87 @@
+package export_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/export"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestExportOcclusion verifies every connector of a generated dungeon
+// appears on the link of its rooms, some rooms see each other, and the
+// graph round-trips through JSON.
+func TestExportOcclusion(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed:          4242,
+		Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 20},
+		OptionalRatio: 0.2,
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+	}
+	artifact, err := dungeon.NewGeneratorWithValidator(validation.NewValidator()).Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	file, err := export.ExportOcclusion(artifact, 0)
+	if err != nil {
+		t.Fatalf("ExportOcclusion() error = %v", err)
+	}
+	if file.SightRadius != export.DefaultSightRadius {
+		t.Errorf("SightRadius = %d, want the default %d", file.SightRadius, export.DefaultSightRadius)
+	}
+
+	joined := make(map[string]bool)
+	for i, link := range file.Links {
+		if link.From >= link.To {
+			t.Errorf("link %s-%s is not ordered", link.From, link.To)
+		}
+		if i > 0 {
+			prev := file.Links[i-1]
+			if prev.From > link.From || (prev.From == link.From && prev.To >= link.To) {
+				t.Errorf("links %s-%s and %s-%s are out of order", prev.From, prev.To, link.From, link.To)
+			}
+		}
+		if link.LineOfSight != (link.VisibleFrom+link.VisibleTo > 0) {
+			t.Errorf("link %s-%s LineOfSight = %v with %d/%d visible tiles", link.From, link.To, link.LineOfSight, link.VisibleFrom, link.VisibleTo)
+		}
+		for _, c := range link.Connectors {
+			joined[c.ID] = true
+		}
+	}
+	for id, conn := range artifact.ADG.Connectors {
+		if !joined[id] {
+			t.Errorf("connector %s (%s-%s) is on no link", id, conn.From, conn.To)
+		}
+	}
+
+	sighted := 0
+	for _, link := range file.Links {
+		if link.LineOfSight {
+			sighted++
+		}
+	}
+	if sighted == 0 {
+		t.Error("no rooms see each other")
+	}
+
+	data, err := export.MarshalOcclusion(file)
+	if err != nil {
+		t.Fatalf("MarshalOcclusion() error = %v", err)
+	}
+	var decoded export.OcclusionFile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("occlusion JSON does not decode: %v", err)
+	}
+	if len(decoded.Links) != len(file.Links) {
+		t.Errorf("decoded %d links, want %d", len(decoded.Links), len(file.Links))
+	}
+
+	if _, err := export.ExportOcclusion(&dungeon.Artifact{ADG: artifact.ADG}, 0); err == nil {
+		t.Error("ExportOcclusion() of an artifact without a tile map succeeded")
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// ExportOption adjusts the options struct of an export format. Each format has
+// its own options struct (SVGOptions, TMJOptions, ...); the generic options
+// below set a field that several formats share and leave formats without it
+// unchanged, so one option list can be passed to every export.
+type ExportOption func(opts any)
+
+// compressible is an options struct with a compression setting.
+type compressible interface {
+	setCompression(on bool)
+}
+
+// precise is an options struct with a coordinate precision setting.
+type precise interface {
+	setPrecision(digits int)
+}
+
+// WithCompression turns compression of the output on or off: gzip-encoded
+// tile layers in TMJ.
+func WithCompression(on bool) ExportOption {
+	return func(opts any) {
+		if o, ok := opts.(compressible); ok {
+			o.setCompression(on)
+		}
+	}
+}
+
+// WithPrecision rounds coordinates to the given number of decimal places,
+// trading accuracy for size: OBJ vertex positions and normals. Zero or
+// less keeps the shortest exact representation.
+func WithPrecision(digits int) ExportOption {
+	return func(opts any) {
+		if o, ok := opts.(precise); ok {
+			o.setPrecision(digits)
+		}
+	}
+}
+
+// fileOptions holds the settings of the files the Save functions write.
+// It is what the file options below see, so they can be mixed with the
+// format options in one list.
+type fileOptions struct {
+	write []dungeon.WriteOption
+}
+
+func (o *fileOptions) addWriteOption(option dungeon.WriteOption) {
+	o.write = append(o.write, option)
+}
+
+// writable is an options struct with file write settings.
+type writable interface {
+	addWriteOption(option dungeon.WriteOption)
+}
+
+// WithFileMode sets the permission of files written by the Save functions
+// (default dungeon.DefaultFileMode).
+func WithFileMode(mode os.FileMode) ExportOption {
+	return func(opts any) {
+		if o, ok := opts.(writable); ok {
+			o.addWriteOption(dungeon.WithFileMode(mode))
+		}
+	}
+}
+
+// WithParentDirs makes the Save functions create missing parent
+// directories of the files they write.
+func WithParentDirs() ExportOption {
+	return func(opts any) {
+		if o, ok := opts.(writable); ok {
+			o.addWriteOption(dungeon.WithParentDirs())
+		}
+	}
+}
+
+// WithContext makes the Save functions give up with ctx's error once ctx
+// is done, without leaving partially written files (see dungeon.WithContext).
+func WithContext(ctx context.Context) ExportOption {
+	return func(opts any) {
+		if o, ok := opts.(writable); ok {
+			o.addWriteOption(dungeon.WithContext(ctx))
+		}
+	}
+}
+
+// writeFile writes data to path atomically with dungeon.WriteFile, applying
+// the file options among options.
+func writeFile(path string, data []byte, options []ExportOption) error {
+	var fo fileOptions
+	applyOptions(&fo, options)
+	return dungeon.WriteFile(path, data, fo.write...)
+}
+
+// applyOptions applies options to a format's options struct in order.
+func applyOptions(opts any, options []ExportOption) {
+	for _, option := range options {
+		option(opts)
+	}
+}
+
+// formatCoord formats a coordinate with at most digits decimal places,
+// dropping trailing zeros, or exactly when digits is zero or less.
+func formatCoord(v float32, digits int) string {
+	if digits <= 0 {
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	}
+	s := strconv.FormatFloat(float64(v), 'f', digits, 32)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
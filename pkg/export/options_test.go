@@ -0,0 +1,99 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithCompression(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+
+	tmj, err := ExportTMJWithOptions(artifact, TMJOptions{}, WithCompression(true))
+	if err != nil {
+		t.Fatalf("ExportTMJWithOptions() error = %v", err)
+	}
+	for _, layer := range tmj.Layers {
+		if layer.Type == "tilelayer" && layer.Compression != "gzip" {
+			t.Errorf("layer %s compression = %q, want gzip", layer.Name, layer.Compression)
+		}
+	}
+
+	// Later options win
+	tmj, err = ExportTMJWithOptions(artifact, DefaultTMJOptions(), WithCompression(true), WithCompression(false))
+	if err != nil {
+		t.Fatalf("ExportTMJWithOptions() error = %v", err)
+	}
+	for _, layer := range tmj.Layers {
+		if layer.Compression != "" {
+			t.Errorf("layer %s compression = %q, want none", layer.Name, layer.Compression)
+		}
+	}
+
+	// Formats without the setting ignore it
+	if _, err := ExportGLTF(artifact, DefaultGLTFOptions(), WithCompression(true), WithPrecision(2)); err != nil {
+		t.Errorf("ExportGLTF() with generic options error = %v", err)
+	}
+}
+
+func TestWithPrecision(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+	opts := DefaultOBJOptions()
+	opts.TileSize = 1.0 / 3
+
+	data, err := ExportOBJ(artifact, opts, WithPrecision(2))
+	if err != nil {
+		t.Fatalf("ExportOBJ() error = %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "v ") {
+			continue
+		}
+		for _, coord := range strings.Fields(line)[1:] {
+			if i := strings.IndexByte(coord, '.'); i >= 0 && len(coord)-i-1 > 2 {
+				t.Fatalf("vertex %q has more than 2 decimal places", line)
+			}
+		}
+	}
+
+	exact, _ := ExportOBJ(artifact, opts)
+	if !strings.Contains(string(exact), "0.33333") {
+		t.Error("OBJ without precision should keep exact coordinates")
+	}
+}
+
+func TestFormatCoord(t *testing.T) {
+	tests := []struct {
+		v      float32
+		digits int
+		want   string
+	}{
+		{1.5, 0, "1.5"},
+		{1.0 / 3, 2, "0.33"},
+		{2.5, 3, "2.5"},
+		{3, 2, "3"},
+		{-0.001, 2, "0"},
+	}
+	for _, tt := range tests {
+		if got := formatCoord(tt.v, tt.digits); got != tt.want {
+			t.Errorf("formatCoord(%v, %d) = %q, want %q", tt.v, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestFileOptions(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+	path := filepath.Join(t.TempDir(), "maps", "dungeon.json")
+
+	if err := SaveJSONToFile(artifact, path, WithParentDirs(), WithFileMode(0600), WithCompression(true)); err != nil {
+		t.Fatalf("SaveJSONToFile() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("file was not written: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("file mode = %v, want 0600", mode)
+	}
+}
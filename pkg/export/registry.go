@@ -0,0 +1,230 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// Exporter renders an artifact as a single document in some format.
+// Exporters must only read the artifact: the CLI runs them concurrently.
+//
+// An exporter may also implement Extension() string to name the suffix of
+// the files it writes, e.g. ".summary.md"; the default is "." and the name
+// it is registered under. It may implement Stage() dungeon.CheckpointStage
+// to name the last pipeline stage whose output it reads, so it can export
+// artifacts of a pipeline ended early by Config.StopAfter; the default is
+// dungeon.StageValidation, the complete artifact.
+type Exporter interface {
+	Export(artifact *dungeon.Artifact, opts Options) ([]byte, error)
+}
+
+// ExporterFunc adapts a function to the Exporter interface.
+type ExporterFunc func(artifact *dungeon.Artifact, opts Options) ([]byte, error)
+
+// Export calls f.
+func (f ExporterFunc) Export(artifact *dungeon.Artifact, opts Options) ([]byte, error) {
+	return f(artifact, opts)
+}
+
+// Options are the settings shared by registered exporters. Each exporter
+// uses the ones it understands and ignores the rest.
+type Options struct {
+	Title   string            // Document title, for formats that have one
+	Theme   string            // Primary theme, naming materials
+	Config  *dungeon.Config   // Config the artifact was generated from, may be nil
+	Params  map[string]string // Exporter-specific settings
+	Context context.Context   // Cancels long-running exports, may be nil
+}
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]Exporter{}
+)
+
+// Register adds an exporter to the registry under name, making it a valid
+// -format of the CLI. Plugins call it from an init function. Registering a
+// name twice is an error.
+func Register(name string, e Exporter) error {
+	if name == "" {
+		return errors.New("exporter name is required")
+	}
+	if e == nil {
+		return fmt.Errorf("exporter %q is nil", name)
+	}
+
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+	if _, ok := exporters[name]; ok {
+		return fmt.Errorf("exporter %q is already registered", name)
+	}
+	exporters[name] = e
+	return nil
+}
+
+// MustRegister is like Register but panics on error, for init functions.
+func MustRegister(name string, e Exporter) {
+	if err := Register(name, e); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the registered exporter with the given name.
+func Lookup(name string) (Exporter, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	e, ok := exporters[name]
+	return e, ok
+}
+
+// Names returns the names of all registered exporters in sorted order.
+func Names() []string {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extension returns the file suffix of the exporter registered under name,
+// or "" if there is none.
+func Extension(name string) string {
+	e, ok := Lookup(name)
+	if !ok {
+		return ""
+	}
+	if x, ok := e.(interface{ Extension() string }); ok {
+		return x.Extension()
+	}
+	return "." + name
+}
+
+// Stage returns the last pipeline stage the exporter registered under name
+// reads the output of, or "" if there is none.
+func Stage(name string) dungeon.CheckpointStage {
+	e, ok := Lookup(name)
+	if !ok {
+		return ""
+	}
+	if x, ok := e.(interface {
+		Stage() dungeon.CheckpointStage
+	}); ok {
+		return x.Stage()
+	}
+	return dungeon.StageValidation
+}
+
+// Available reports whether the exporter registered under name can export
+// an artifact whose pipeline stopped after stage, as recorded in
+// Debug.StoppedAt. An empty stage is a complete artifact.
+func Available(name string, stage dungeon.CheckpointStage) bool {
+	need := Stage(name)
+	if need == "" {
+		return false
+	}
+	if stage == "" {
+		return true
+	}
+	return slices.Index(dungeon.PipelineStages, string(need)) <= slices.Index(dungeon.PipelineStages, string(stage))
+}
+
+// extExporter is a built-in exporter with the suffix of its files and the
+// last pipeline stage it reads.
+type extExporter struct {
+	ExporterFunc
+	ext   string
+	stage dungeon.CheckpointStage
+}
+
+func (e extExporter) Extension() string {
+	return e.ext
+}
+
+func (e extExporter) Stage() dungeon.CheckpointStage {
+	return e.stage
+}
+
+// The built-in single-document formats. Formats that write several files
+// (stats, route, gates) are composed by the CLI instead.
+func init() {
+	MustRegister("json", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		return ExportJSON(a)
+	}, ".json", dungeon.StageSynthesis})
+	MustRegister("dot", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		return ExportDOT(a)
+	}, ".dot", dungeon.StageSynthesis})
+	MustRegister("tmj", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		return ExportArtifactToTMJWithOptions(a, DefaultTMJOptions())
+	}, ".tmj", dungeon.StageCarving})
+	MustRegister("svg", extExporter{func(a *dungeon.Artifact, opts Options) ([]byte, error) {
+		svg := DefaultSVGOptions()
+		if opts.Title != "" {
+			svg.Title = opts.Title
+		}
+		return ExportSVG(a, svg)
+	}, ".svg", dungeon.StageSynthesis})
+	MustRegister("gltf", extExporter{func(a *dungeon.Artifact, opts Options) ([]byte, error) {
+		gltf := DefaultGLTFOptions()
+		if opts.Theme != "" {
+			gltf.Theme = opts.Theme
+		}
+		doc, err := ExportGLTF(a, gltf)
+		if err != nil {
+			return nil, err
+		}
+		return MarshalGLTF(doc)
+	}, ".gltf", dungeon.StageCarving})
+	MustRegister("obj", extExporter{func(a *dungeon.Artifact, opts Options) ([]byte, error) {
+		obj := DefaultOBJOptions()
+		if opts.Theme != "" {
+			obj.Theme = opts.Theme
+		}
+		return ExportOBJ(a, obj)
+	}, ".obj", dungeon.StageCarving})
+	MustRegister("collision", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		cm, err := ExportCollision(a, DefaultCollisionOptions())
+		if err != nil {
+			return nil, err
+		}
+		return MarshalCollision(cm)
+	}, ".collision.json", dungeon.StageCarving})
+	MustRegister("heightmap", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		hm, err := ExportHeightmap(a)
+		if err != nil {
+			return nil, err
+		}
+		return MarshalHeightmap(hm)
+	}, ".heightmap.json", dungeon.StageCarving})
+	MustRegister("anchors", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		file, err := ExportAnchors(a)
+		if err != nil {
+			return nil, err
+		}
+		return MarshalAnchors(file)
+	}, ".anchors.json", dungeon.StageContent})
+	MustRegister("occlusion", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		file, err := ExportOcclusion(a, DefaultSightRadius)
+		if err != nil {
+			return nil, err
+		}
+		return MarshalOcclusion(file)
+	}, ".occlusion.json", dungeon.StageCarving})
+	MustRegister("summary", extExporter{func(a *dungeon.Artifact, opts Options) ([]byte, error) {
+		s, err := ExportSummary(a, opts.Config)
+		if err != nil {
+			return nil, err
+		}
+		return SummaryMarkdown(s), nil
+	}, ".summary.md", dungeon.StageValidation})
+	MustRegister("difficulty", extExporter{func(a *dungeon.Artifact, _ Options) ([]byte, error) {
+		return ExportDifficultyChart(a, DifficultyChartOptions{})
+	}, ".difficulty.svg", dungeon.StageValidation})
+}
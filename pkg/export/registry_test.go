@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+func TestRegistry_BuiltIns(t *testing.T) {
+	artifact := createGatesTestArtifact()
+	for _, name := range []string{"json", "svg", "anchors", "summary"} {
+		e, ok := Lookup(name)
+		if !ok {
+			t.Fatalf("built-in exporter %q is not registered", name)
+		}
+		data, err := e.Export(artifact, Options{Title: "Test"})
+		if err != nil || len(data) == 0 {
+			t.Errorf("%s: Export() = %d bytes, error %v", name, len(data), err)
+		}
+	}
+	if data, _ := exporters["anchors"].Export(artifact, Options{}); !json.Valid(data) {
+		t.Error("anchors exporter should write JSON")
+	}
+
+	if got := Extension("json"); got != ".json" {
+		t.Errorf(`Extension("json") = %q`, got)
+	}
+	if got := Extension("anchors"); got != ".anchors.json" {
+		t.Errorf(`Extension("anchors") = %q`, got)
+	}
+	if got := Extension("nope"); got != "" {
+		t.Errorf(`Extension("nope") = %q, want ""`, got)
+	}
+
+	if got := Stage("dot"); got != dungeon.StageSynthesis {
+		t.Errorf(`Stage("dot") = %q, want synthesis`, got)
+	}
+	if !Available("svg", dungeon.StageSynthesis) || Available("tmj", dungeon.StageEmbedding) || !Available("tmj", dungeon.StageCarving) {
+		t.Error("Available() should match the stages each format reads")
+	}
+	if !Available("summary", "") || Available("summary", dungeon.StageContent) || Available("nope", "") {
+		t.Error("Available() should need the complete artifact by default and a registered exporter")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	roomCount := ExporterFunc(func(a *dungeon.Artifact, opts Options) ([]byte, error) {
+		return []byte(fmt.Sprintf("%s: %d rooms", opts.Params["label"], len(a.ADG.Graph.Rooms))), nil
+	})
+	if err := Register("test-rooms", roomCount); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := Register("test-rooms", roomCount); err == nil {
+		t.Error("registering a name twice should fail")
+	}
+	if err := Register("", roomCount); err == nil {
+		t.Error("registering without a name should fail")
+	}
+	if err := Register("test-nil", nil); err == nil {
+		t.Error("registering a nil exporter should fail")
+	}
+
+	found := false
+	for _, name := range Names() {
+		found = found || name == "test-rooms"
+	}
+	if !found {
+		t.Errorf("Names() = %v, missing test-rooms", Names())
+	}
+
+	e, _ := Lookup("test-rooms")
+	data, err := e.Export(createGatesTestArtifact(), Options{Params: map[string]string{"label": "count"}})
+	if err != nil || string(data) != "count: 5 rooms" {
+		t.Errorf("Export() = %q, error %v", data, err)
+	}
+}
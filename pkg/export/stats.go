@@ -0,0 +1,330 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// statsArchetypes lists every room archetype in column order for the
+// per-dungeon archetype distribution.
+var statsArchetypes = []graph.RoomArchetype{
+	graph.ArchetypeStart,
+	graph.ArchetypeBoss,
+	graph.ArchetypeTreasure,
+	graph.ArchetypePuzzle,
+	graph.ArchetypeHub,
+	graph.ArchetypeCorridor,
+	graph.ArchetypeSecret,
+	graph.ArchetypeOptional,
+	graph.ArchetypeVendor,
+	graph.ArchetypeShrine,
+	graph.ArchetypeCheckpoint,
+	graph.ArchetypeExit,
+}
+
+// RoomStatsHeader is the column header of ExportRoomStatsCSV.
+var RoomStatsHeader = []string{
+	"seed", "room_id", "archetype", "size", "biome", "difficulty", "reward", "degree",
+	"spawns", "enemies", "loot", "loot_value", "puzzles", "secrets",
+}
+
+// DungeonStatsHeader returns the column header of ExportDungeonStatsCSV.
+func DungeonStatsHeader() []string {
+	header := []string{"seed", "rooms", "connectors"}
+	for _, a := range statsArchetypes {
+		header = append(header, "rooms_"+a.String())
+	}
+	return append(header,
+		"difficulty_min", "difficulty_max", "difficulty_mean", "difficulty_stddev",
+		"spawns", "enemies", "loot", "loot_value", "puzzles", "secrets",
+		"branching_factor", "path_length", "cycle_count", "pacing_deviation",
+		"validation_passed", "validation_warnings", "validation_errors",
+	)
+}
+
+// ExportRoomStatsCSV flattens per-room statistics of one or more artifacts
+// into CSV, one row per room, for analysis in pandas or SQL.
+// Rows are ordered by artifact, then room ID.
+func ExportRoomStatsCSV(artifacts ...*dungeon.Artifact) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ExportRoomStatsCSVTo(&buf, artifacts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportRoomStatsCSVTo writes per-room statistics of one or more artifacts
+// to w as CSV (see ExportRoomStatsCSV).
+func ExportRoomStatsCSVTo(out io.Writer, artifacts ...*dungeon.Artifact) error {
+	if err := checkStatsArtifacts(artifacts); err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	if err := w.Write(RoomStatsHeader); err != nil {
+		return err
+	}
+
+	for _, artifact := range artifacts {
+		g := artifact.ADG.Graph
+		counts := contentCountsByRoom(artifact.Content)
+		degrees := roomDegrees(g)
+
+		for _, id := range sortedRoomIDs(g) {
+			room := g.Rooms[id]
+			c := counts[id]
+			row := []string{
+				strconv.FormatUint(g.Seed, 10),
+				id,
+				room.Archetype.String(),
+				room.Size.String(),
+				room.Tags["biome"],
+				formatFloat(room.Difficulty),
+				formatFloat(room.Reward),
+				strconv.Itoa(degrees[id]),
+				strconv.Itoa(c.spawns),
+				strconv.Itoa(c.enemies),
+				strconv.Itoa(c.loot),
+				strconv.Itoa(c.lootValue),
+				strconv.Itoa(c.puzzles),
+				strconv.Itoa(c.secrets),
+			}
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// ExportDungeonStatsCSV flattens per-dungeon statistics of one or more
+// artifacts into CSV, one row per dungeon: room counts, archetype
+// distribution, difficulty statistics, content counts, metrics and
+// validation outcome.
+func ExportDungeonStatsCSV(artifacts ...*dungeon.Artifact) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ExportDungeonStatsCSVTo(&buf, artifacts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportDungeonStatsCSVTo writes per-dungeon statistics of one or more
+// artifacts to w as CSV (see ExportDungeonStatsCSV).
+func ExportDungeonStatsCSVTo(out io.Writer, artifacts ...*dungeon.Artifact) error {
+	if err := checkStatsArtifacts(artifacts); err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	if err := w.Write(DungeonStatsHeader()); err != nil {
+		return err
+	}
+
+	for _, artifact := range artifacts {
+		if err := w.Write(dungeonStatsRow(artifact)); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// checkStatsArtifacts reports an artifact without a graph before any CSV
+// is written, so streamed exports fail without partial output.
+func checkStatsArtifacts(artifacts []*dungeon.Artifact) error {
+	for _, artifact := range artifacts {
+		if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+			return fmt.Errorf("artifact must contain a valid ADG")
+		}
+	}
+	return nil
+}
+
+// dungeonStatsRow builds the DungeonStatsHeader row for one artifact.
+func dungeonStatsRow(artifact *dungeon.Artifact) []string {
+	g := artifact.ADG.Graph
+
+	archetypes := make(map[graph.RoomArchetype]int)
+	difficulties := make([]float64, 0, len(g.Rooms))
+	// Sum in ID order so the float statistics are identical across runs
+	for _, id := range sortedRoomIDs(g) {
+		room := g.Rooms[id]
+		archetypes[room.Archetype]++
+		difficulties = append(difficulties, room.Difficulty)
+	}
+
+	row := []string{
+		strconv.FormatUint(g.Seed, 10),
+		strconv.Itoa(len(g.Rooms)),
+		strconv.Itoa(len(g.Connectors)),
+	}
+	for _, a := range statsArchetypes {
+		row = append(row, strconv.Itoa(archetypes[a]))
+	}
+
+	minD, maxD, mean, stddev := describe(difficulties)
+	row = append(row, formatFloat(minD), formatFloat(maxD), formatFloat(mean), formatFloat(stddev))
+
+	var total contentCounts
+	for _, c := range contentCountsByRoom(artifact.Content) {
+		total.spawns += c.spawns
+		total.enemies += c.enemies
+		total.loot += c.loot
+		total.lootValue += c.lootValue
+		total.puzzles += c.puzzles
+		total.secrets += c.secrets
+	}
+	row = append(row,
+		strconv.Itoa(total.spawns), strconv.Itoa(total.enemies),
+		strconv.Itoa(total.loot), strconv.Itoa(total.lootValue),
+		strconv.Itoa(total.puzzles), strconv.Itoa(total.secrets),
+	)
+
+	if m := artifact.Metrics; m != nil {
+		row = append(row,
+			formatFloat(m.BranchingFactor), strconv.Itoa(m.PathLength),
+			strconv.Itoa(m.CycleCount), formatFloat(m.PacingDeviation),
+		)
+	} else {
+		row = append(row, "", "", "", "")
+	}
+
+	if artifact.Debug != nil && artifact.Debug.Report != nil {
+		r := artifact.Debug.Report
+		row = append(row, strconv.FormatBool(r.Passed), strconv.Itoa(len(r.Warnings)), strconv.Itoa(len(r.Errors)))
+	} else {
+		row = append(row, "", "", "")
+	}
+
+	return row
+}
+
+// contentCounts tallies placed content for one room.
+type contentCounts struct {
+	spawns, enemies, loot, lootValue, puzzles, secrets int
+}
+
+// contentCountsByRoom tallies content per room ID.
+func contentCountsByRoom(c *dungeon.Content) map[string]contentCounts {
+	counts := make(map[string]contentCounts)
+	if c == nil {
+		return counts
+	}
+	for _, s := range c.Spawns {
+		rc := counts[s.RoomID]
+		rc.spawns++
+		rc.enemies += s.Count
+		counts[s.RoomID] = rc
+	}
+	for _, l := range c.Loot {
+		rc := counts[l.RoomID]
+		rc.loot++
+		rc.lootValue += l.Value
+		counts[l.RoomID] = rc
+	}
+	for _, p := range c.Puzzles {
+		rc := counts[p.RoomID]
+		rc.puzzles++
+		counts[p.RoomID] = rc
+	}
+	for _, s := range c.Secrets {
+		rc := counts[s.RoomID]
+		rc.secrets++
+		counts[s.RoomID] = rc
+	}
+	return counts
+}
+
+// roomDegrees counts the connectors touching each room.
+func roomDegrees(g *graph.Graph) map[string]int {
+	degrees := make(map[string]int)
+	for _, conn := range g.Connectors {
+		degrees[conn.From]++
+		degrees[conn.To]++
+	}
+	return degrees
+}
+
+// sortedRoomIDs returns the graph's room IDs in sorted order.
+func sortedRoomIDs(g *graph.Graph) []string {
+	ids := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// describe returns the min, max, mean and population standard deviation of values.
+func describe(values []float64) (minV, maxV, mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0, 0, 0
+	}
+	minV, maxV = values[0], values[0]
+	sum := 0.0
+	for _, v := range values {
+		minV = math.Min(minV, v)
+		maxV = math.Max(maxV, v)
+		sum += v
+	}
+	mean = sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev = math.Sqrt(variance / float64(len(values)))
+	return minV, maxV, mean, stddev
+}
+
+// formatFloat formats a float compactly for CSV output.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ExportStatsCSVTo writes per-room statistics of the given artifacts to
+// roomsW and per-dungeon statistics to dungeonsW. Either writer may be nil
+// to skip that table.
+func ExportStatsCSVTo(roomsW, dungeonsW io.Writer, artifacts ...*dungeon.Artifact) error {
+	if roomsW != nil {
+		if err := ExportRoomStatsCSVTo(roomsW, artifacts...); err != nil {
+			return err
+		}
+	}
+	if dungeonsW != nil {
+		return ExportDungeonStatsCSVTo(dungeonsW, artifacts...)
+	}
+	return nil
+}
+
+// SaveStatsCSVToFiles writes per-room and per-dungeon statistics for the given
+// artifacts to roomsPath and dungeonsPath with dungeon.WriteFile.
+func SaveStatsCSVToFiles(roomsPath, dungeonsPath string, artifacts ...*dungeon.Artifact) error {
+	return SaveStatsCSVToFilesWithOptions(roomsPath, dungeonsPath, artifacts)
+}
+
+// SaveStatsCSVToFilesWithOptions is like SaveStatsCSVToFiles, applying the
+// file options among options to both files.
+func SaveStatsCSVToFilesWithOptions(roomsPath, dungeonsPath string, artifacts []*dungeon.Artifact, options ...ExportOption) error {
+	rooms, err := ExportRoomStatsCSV(artifacts...)
+	if err != nil {
+		return err
+	}
+	dungeons, err := ExportDungeonStatsCSV(artifacts...)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(roomsPath, rooms, options); err != nil {
+		return err
+	}
+	return writeFile(dungeonsPath, dungeons, options)
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+func createStatsTestArtifact() *dungeon.Artifact {
+	g := graph.NewGraph(42)
+	_ = g.AddRoom(&graph.Room{ID: "A", Archetype: graph.ArchetypeStart, Size: graph.SizeS, Difficulty: 0.2})
+	_ = g.AddRoom(&graph.Room{ID: "B", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Difficulty: 0.8,
+		Tags: map[string]string{"biome": "crypt"}})
+	_ = g.AddConnector(&graph.Connector{ID: "c1", From: "A", To: "B", Type: graph.TypeDoor, Cost: 1, Bidirectional: true})
+
+	return &dungeon.Artifact{
+		ADG: &dungeon.Graph{Graph: g},
+		Content: &dungeon.Content{
+			Spawns: []dungeon.Spawn{{ID: "s1", RoomID: "B", EnemyType: "dragon", Count: 3}},
+			Loot:   []dungeon.Loot{{ID: "l1", RoomID: "B", ItemType: "gold", Value: 50}},
+		},
+		Metrics: &dungeon.Metrics{BranchingFactor: 1, PathLength: 1},
+		Debug:   &dungeon.DebugArtifacts{Report: &dungeon.ValidationReport{Passed: true}},
+	}
+}
+
+func TestExportRoomStatsCSV(t *testing.T) {
+	data, err := ExportRoomStatsCSV(createStatsTestArtifact())
+	if err != nil {
+		t.Fatalf("ExportRoomStatsCSV() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 rooms", len(rows))
+	}
+
+	want := []string{"42", "B", "Boss", "XL", "crypt", "0.8", "0", "1", "1", "3", "1", "50", "0", "0"}
+	for i, v := range want {
+		if rows[2][i] != v {
+			t.Errorf("room B column %s = %q, want %q", RoomStatsHeader[i], rows[2][i], v)
+		}
+	}
+}
+
+func TestExportDungeonStatsCSV(t *testing.T) {
+	data, err := ExportDungeonStatsCSV(createStatsTestArtifact(), createStatsTestArtifact())
+	if err != nil {
+		t.Fatalf("ExportDungeonStatsCSV() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 dungeons", len(rows))
+	}
+
+	got := make(map[string]string)
+	for i, name := range rows[0] {
+		got[name] = rows[1][i]
+	}
+	checks := map[string]string{
+		"rooms":             "2",
+		"rooms_Boss":        "1",
+		"rooms_Hub":         "0",
+		"difficulty_mean":   "0.5",
+		"enemies":           "3",
+		"validation_passed": "true",
+	}
+	for col, want := range checks {
+		if got[col] != want {
+			t.Errorf("column %s = %q, want %q", col, got[col], want)
+		}
+	}
+
+	if _, err := ExportDungeonStatsCSV(&dungeon.Artifact{}); err == nil {
+		t.Error("ExportDungeonStatsCSV() without ADG should error")
+	}
+}
+
+// TestExportDungeonStatsCSV_Deterministic verifies difficulty statistics
+// don't depend on map iteration order: summing these difficulties in
+// different orders gives different floats.
+func TestExportDungeonStatsCSV_Deterministic(t *testing.T) {
+	artifact := createStatsTestArtifact()
+	for i := 0; i < 40; i++ {
+		_ = artifact.ADG.AddRoom(&graph.Room{
+			ID:         fmt.Sprintf("r%02d", i),
+			Archetype:  graph.ArchetypeOptional,
+			Size:       graph.SizeM,
+			Difficulty: 0.1 + float64(i)/7 - float64(i*i)/300,
+		})
+	}
+
+	first, err := ExportDungeonStatsCSV(artifact)
+	if err != nil {
+		t.Fatalf("ExportDungeonStatsCSV() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := ExportDungeonStatsCSV(artifact)
+		if err != nil {
+			t.Fatalf("ExportDungeonStatsCSV() error = %v", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("ExportDungeonStatsCSV() differs between runs:\n%s\n%s", first, again)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// Summary thumbnail size in pixels.
+const (
+	summaryThumbWidth  = 480
+	summaryThumbHeight = 360
+)
+
+// Summary is a one-page overview of a dungeon, used as the cover page of
+// an artifact in content review.
+type Summary struct {
+	Seed         uint64             `json:"seed"`
+	ConfigDigest string             `json:"configDigest,omitempty"` // Hex Config.Hash(), empty without a config
+	Mode         dungeon.Mode       `json:"mode,omitempty"`
+	Difficulty   dungeon.Difficulty `json:"difficulty,omitempty"`
+	Rooms        int                `json:"rooms"`
+	Connectors   int                `json:"connectors"`
+	Metrics      *dungeon.Metrics   `json:"metrics,omitempty"`
+	Archetypes   map[string]int     `json:"archetypes"` // Archetype name → rooms, archetypes without rooms omitted
+	Locks        []SummaryLock      `json:"locks"`      // Ordered by capability
+	Boss         *SummaryBoss       `json:"boss,omitempty"`
+	Warnings     []string           `json:"warnings,omitempty"`
+	Thumbnail    string             `json:"thumbnail"` // SVG of the dungeon graph
+}
+
+// SummaryLock is a key or other capability and the gates it opens.
+type SummaryLock struct {
+	Capability string   `json:"capability"`     // e.g. "key:silver"
+	Name       string   `json:"name,omitempty"` // Display name of a key, from the artifact's string table
+	Providers  []string `json:"providers"`      // Rooms providing it, by ID
+	Gates      []string `json:"gates"`          // Connectors it opens, by ID
+}
+
+// SummaryBoss describes the Boss room.
+type SummaryBoss struct {
+	Room         string         `json:"room"`
+	Size         string         `json:"size"`
+	Difficulty   float64        `json:"difficulty"`
+	Distance     int            `json:"distance"`               // Connectors from Start, -1 when unreachable
+	Requirements []string       `json:"requirements,omitempty"` // Capabilities the room requires
+	Enemies      map[string]int `json:"enemies,omitempty"`      // Enemy type → count spawned in the room
+}
+
+// ExportSummary builds the one-page summary of an artifact. cfg is the
+// config it was generated from and may be nil, in which case the summary
+// has no config digest, mode or difficulty.
+func ExportSummary(artifact *dungeon.Artifact, cfg *dungeon.Config) (*Summary, error) {
+	if artifact == nil || artifact.ADG == nil || artifact.ADG.Graph == nil {
+		return nil, fmt.Errorf("artifact has no graph")
+	}
+	g := artifact.ADG.Graph
+
+	s := &Summary{
+		Seed:       g.Seed,
+		Rooms:      len(g.Rooms),
+		Connectors: len(g.Connectors),
+		Metrics:    artifact.Metrics,
+		Archetypes: make(map[string]int),
+		Locks:      []SummaryLock{},
+	}
+	if cfg != nil {
+		s.ConfigDigest = hex.EncodeToString(cfg.Hash())
+		s.Mode = cfg.Mode
+		s.Difficulty = cfg.Difficulty
+	}
+	for _, w := range artifact.Warnings {
+		s.Warnings = append(s.Warnings, w.String())
+	}
+
+	// Archetypes, locks and the boss
+	locks := make(map[string]*SummaryLock)
+	lock := func(capType, value string) *SummaryLock {
+		key := capType + ":" + value
+		if locks[key] == nil {
+			locks[key] = &SummaryLock{Capability: key, Providers: []string{}, Gates: []string{}}
+			if capType == "key" || capType == graph.SmallKey {
+				locks[key].Name = artifact.Strings[dungeon.KeyNameID(value)]
+			}
+		}
+		return locks[key]
+	}
+	var start string
+	for _, id := range sortedRoomIDs(g) {
+		room := g.Rooms[id]
+		s.Archetypes[room.Archetype.String()]++
+		for _, c := range room.Provides {
+			l := lock(c.Type, c.Value)
+			l.Providers = append(l.Providers, id)
+		}
+		if room.Archetype == graph.ArchetypeStart && start == "" {
+			start = id
+		}
+		if room.Archetype == graph.ArchetypeBoss && s.Boss == nil {
+			s.Boss = &SummaryBoss{Room: id, Size: room.Size.String(), Difficulty: room.Difficulty, Distance: -1}
+			for _, r := range room.Requirements {
+				s.Boss.Requirements = append(s.Boss.Requirements, r.Type+":"+r.Value)
+			}
+		}
+	}
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id, conn := range g.Connectors {
+		if conn.Gate != nil {
+			connIDs = append(connIDs, id)
+		}
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		for _, need := range g.Connectors[id].Gate.Needs() {
+			l := lock(need.Type, need.Value)
+			l.Gates = append(l.Gates, id)
+		}
+	}
+	for _, l := range locks {
+		s.Locks = append(s.Locks, *l)
+	}
+	sort.Slice(s.Locks, func(i, j int) bool { return s.Locks[i].Capability < s.Locks[j].Capability })
+
+	if s.Boss != nil {
+		if start != "" {
+			if d, ok := g.Distances([]string{start})[s.Boss.Room]; ok {
+				s.Boss.Distance = d
+			}
+		}
+		if artifact.Content != nil {
+			for _, spawn := range artifact.Content.Spawns {
+				if spawn.RoomID == s.Boss.Room {
+					if s.Boss.Enemies == nil {
+						s.Boss.Enemies = make(map[string]int)
+					}
+					s.Boss.Enemies[spawn.EnemyType] += spawn.Count
+				}
+			}
+		}
+	}
+
+	// Thumbnail of the graph without chrome
+	opts := DefaultSVGOptions()
+	opts.Width, opts.Height = summaryThumbWidth, summaryThumbHeight
+	opts.NodeRadius, opts.Margin = 10, 20
+	opts.ShowLabels, opts.ShowLegend, opts.ShowStats = false, false, false
+	opts.Title = ""
+	thumb, err := ExportSVG(artifact, opts)
+	if err != nil {
+		return nil, fmt.Errorf("thumbnail: %w", err)
+	}
+	s.Thumbnail = string(thumb)
+
+	return s, nil
+}
+
+// MarshalSummary serializes a Summary to JSON with indentation.
+func MarshalSummary(s *Summary) ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// SummaryMarkdown renders a Summary as a Markdown page, with the thumbnail
+// embedded as an SVG data URI.
+func SummaryMarkdown(s *Summary) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Dungeon %d\n\n", s.Seed)
+	fmt.Fprintf(&buf, "![Dungeon graph](data:image/svg+xml;base64,%s)\n\n",
+		base64.StdEncoding.EncodeToString([]byte(s.Thumbnail)))
+
+	fmt.Fprintf(&buf, "- **Rooms:** %d\n- **Connectors:** %d\n", s.Rooms, s.Connectors)
+	if s.ConfigDigest != "" {
+		fmt.Fprintf(&buf, "- **Config digest:** `%s`\n", s.ConfigDigest)
+	}
+	if s.Mode != "" {
+		fmt.Fprintf(&buf, "- **Mode:** %s\n", s.Mode)
+	}
+	if s.Difficulty != "" {
+		fmt.Fprintf(&buf, "- **Difficulty:** %s\n", s.Difficulty)
+	}
+
+	if m := s.Metrics; m != nil {
+		buf.WriteString("\n## Metrics\n\n| Metric | Value |\n|---|---|\n")
+		fmt.Fprintf(&buf, "| Branching factor | %.2f |\n", m.BranchingFactor)
+		fmt.Fprintf(&buf, "| Start → Boss path | %d |\n", m.PathLength)
+		fmt.Fprintf(&buf, "| Cycles | %d |\n", m.CycleCount)
+		fmt.Fprintf(&buf, "| Pacing deviation | %.3f |\n", m.PacingDeviation)
+		if d := m.Difficulty; d != nil {
+			fmt.Fprintf(&buf, "| Room deviation from target | p50 %+.2f, p90 %+.2f |\n", d.Deviation.P50, d.Deviation.P90)
+		}
+		fmt.Fprintf(&buf, "| Secret findability | %.2f |\n", m.SecretFindability)
+		fmt.Fprintf(&buf, "| Speedrun | %d rooms, %d tiles |\n", m.SpeedrunRooms, m.SpeedrunTiles)
+		fmt.Fprintf(&buf, "| Floor area | %d tiles |\n", m.FloorArea)
+		fmt.Fprintf(&buf, "| Corridors | %d tiles, %d crossings |\n", m.CorridorLength, m.CorridorCrossings)
+		fmt.Fprintf(&buf, "| Bounds utilization | %.2f |\n", m.BoundsUtilization)
+		fmt.Fprintf(&buf, "| Room spacing | %.1f tiles |\n", m.RoomSpacing)
+	}
+
+	buf.WriteString("\n## Archetypes\n\n| Archetype | Rooms |\n|---|---|\n")
+	for _, archetype := range statsArchetypes {
+		if n := s.Archetypes[archetype.String()]; n > 0 {
+			fmt.Fprintf(&buf, "| %s | %d |\n", archetype, n)
+		}
+	}
+
+	buf.WriteString("\n## Keys and Locks\n\n")
+	if len(s.Locks) == 0 {
+		buf.WriteString("No keys or locks.\n")
+	} else {
+		buf.WriteString("| Capability | Found in | Opens |\n|---|---|---|\n")
+		for _, l := range s.Locks {
+			capability := l.Capability
+			if l.Name != "" {
+				capability = l.Name + " (" + capability + ")"
+			}
+			fmt.Fprintf(&buf, "| %s | %s | %s |\n",
+				markdownCell(capability), summaryList(l.Providers), summaryList(l.Gates))
+		}
+	}
+
+	buf.WriteString("\n## Boss\n\n")
+	if b := s.Boss; b == nil {
+		buf.WriteString("No boss room.\n")
+	} else {
+		fmt.Fprintf(&buf, "- **Room:** %s (%s, difficulty %.2f)\n", markdownCell(b.Room), b.Size, b.Difficulty)
+		fmt.Fprintf(&buf, "- **Distance from Start:** %s\n", auditDistance(b.Distance))
+		if len(b.Requirements) > 0 {
+			fmt.Fprintf(&buf, "- **Requires:** %s\n", strings.Join(b.Requirements, ", "))
+		}
+		if len(b.Enemies) > 0 {
+			types := make([]string, 0, len(b.Enemies))
+			for t := range b.Enemies {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			for i, t := range types {
+				types[i] = fmt.Sprintf("%d× %s", b.Enemies[t], t)
+			}
+			fmt.Fprintf(&buf, "- **Enemies:** %s\n", strings.Join(types, ", "))
+		}
+	}
+
+	if len(s.Warnings) > 0 {
+		buf.WriteString("\n## Warnings\n\n")
+		for _, w := range s.Warnings {
+			fmt.Fprintf(&buf, "- %s\n", w)
+		}
+	}
+	return buf.Bytes()
+}
+
+// summaryList joins IDs for a Markdown cell, with "none" when empty.
+func summaryList(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	return markdownCell(strings.Join(ids, ", "))
+}
+
+// ExportSummaryTo writes an artifact's summary to jsonW as indented JSON
+// and to markdownW as a Markdown page. Either writer may be nil to skip
+// that document.
+func ExportSummaryTo(jsonW, markdownW io.Writer, artifact *dungeon.Artifact, cfg *dungeon.Config) error {
+	s, err := ExportSummary(artifact, cfg)
+	if err != nil {
+		return err
+	}
+	if jsonW != nil {
+		if err := encodeJSON(jsonW, s, true); err != nil {
+			return err
+		}
+	}
+	if markdownW != nil {
+		if _, err := markdownW.Write(SummaryMarkdown(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSummaryToFiles writes an artifact's summary as JSON and Markdown with
+// dungeon.WriteFile.
+func SaveSummaryToFiles(artifact *dungeon.Artifact, cfg *dungeon.Config, jsonPath, markdownPath string, options ...ExportOption) error {
+	s, err := ExportSummary(artifact, cfg)
+	if err != nil {
+		return err
+	}
+	data, err := MarshalSummary(s)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(jsonPath, data, options); err != nil {
+		return err
+	}
+	return writeFile(markdownPath, SummaryMarkdown(s), options)
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+func TestExportSummary(t *testing.T) {
+	artifact := createGatesTestArtifact()
+	artifact.Metrics = &dungeon.Metrics{BranchingFactor: 2.5, PathLength: 1}
+	artifact.Content = &dungeon.Content{Spawns: []dungeon.Spawn{
+		{ID: "spawn_a", RoomID: "B", EnemyType: "ogre", Count: 1},
+		{ID: "spawn_b", RoomID: "B", EnemyType: "goblin", Count: 3},
+		{ID: "spawn_c", RoomID: "K", EnemyType: "goblin", Count: 2},
+	}}
+	artifact.Strings = dungeon.StringTable{dungeon.KeyNameID("gold"): "Gilded Key"}
+	cfg := &dungeon.Config{Seed: 7, Mode: dungeon.ModeStandard}
+
+	s, err := ExportSummary(artifact, cfg)
+	if err != nil {
+		t.Fatalf("ExportSummary() error = %v", err)
+	}
+
+	if s.Rooms != 5 || s.Connectors != 5 || s.ConfigDigest == "" {
+		t.Errorf("summary header = %d rooms, %d connectors, digest %q", s.Rooms, s.Connectors, s.ConfigDigest)
+	}
+	if s.Archetypes["Treasure"] != 2 || s.Archetypes["Boss"] != 1 {
+		t.Errorf("Archetypes = %v", s.Archetypes)
+	}
+
+	wantLocks := []SummaryLock{
+		{Capability: "key:gold", Name: "Gilded Key", Providers: []string{"T"}, Gates: []string{"c3"}},
+		{Capability: "key:silver", Providers: []string{"K"}, Gates: []string{"c2", "c5"}},
+	}
+	if !reflect.DeepEqual(s.Locks, wantLocks) {
+		t.Errorf("Locks = %+v, want %+v", s.Locks, wantLocks)
+	}
+
+	if s.Boss == nil || s.Boss.Room != "B" || s.Boss.Distance != 1 {
+		t.Fatalf("Boss = %+v", s.Boss)
+	}
+	if !reflect.DeepEqual(s.Boss.Enemies, map[string]int{"ogre": 1, "goblin": 3}) {
+		t.Errorf("Boss enemies = %v", s.Boss.Enemies)
+	}
+	if !strings.HasPrefix(s.Thumbnail, "<?xml") || !strings.Contains(s.Thumbnail, "<svg") {
+		t.Error("Thumbnail should be an SVG document")
+	}
+
+	data, err := MarshalSummary(s)
+	if err != nil || !json.Valid(data) {
+		t.Fatalf("MarshalSummary() = invalid JSON, error %v", err)
+	}
+
+	md := string(SummaryMarkdown(s))
+	for _, want := range []string{
+		"# Dungeon 7",
+		"data:image/svg+xml;base64,",
+		"| key:silver | K | c2, c5 |",
+		"| Gilded Key (key:gold) | T | c3 |",
+		"- **Enemies:** 3× goblin, 1× ogre",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q", want)
+		}
+	}
+
+	// Without a config the summary still renders
+	s, err = ExportSummary(artifact, nil)
+	if err != nil || s.ConfigDigest != "" {
+		t.Errorf("ExportSummary(nil config) = digest %q, error %v", s.ConfigDigest, err)
+	}
+}
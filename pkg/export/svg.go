@@ -3,13 +3,14 @@ package export
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math"
-	"os"
 	"sort"
 
 	svg "github.com/ajstarks/svgo"
 	"github.com/dshills/dungo/pkg/dungeon"
 	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/themes"
 )
 
 // SVGOptions configures SVG visualization export.
@@ -25,6 +26,22 @@ type SVGOptions struct {
 	Margin      int    // Canvas margin in pixels (default: 50)
 	Title       string // Optional title for the visualization
 	ShowStats   bool   // Show dungeon statistics
+	ShowRegions bool   // Group rooms into graph regions (see graph.Regions) and shade each cluster
+	ShowThemes  bool   // Outline rooms in the floor color of their biome's theme
+
+	// ColorByElevation fills rooms by their combined floor and interior
+	// elevation (see RoomHeight), from deep blue to pale yellow, instead of
+	// by archetype.
+	ColorByElevation bool
+
+	// Floors arranges the floors of a multi-floor dungeon (see FloorTag)
+	// side by side or stacked. Connectors between floors are marked with
+	// stairs glyphs beside each room.
+	Floors FloorLayout
+
+	// Route is an optional ordered list of room IDs drawn as a highlighted
+	// overlay, e.g. validation.FindSpeedrunRoute(...).Rooms.
+	Route []string
 }
 
 // DefaultSVGOptions returns sensible default SVG export options.
@@ -46,12 +63,22 @@ func DefaultSVGOptions() SVGOptions {
 
 // ExportSVG generates an SVG visualization of the dungeon graph.
 // Returns the SVG as a byte slice or an error if generation fails.
-func ExportSVG(artifact *dungeon.Artifact, opts SVGOptions) ([]byte, error) {
+func ExportSVG(artifact *dungeon.Artifact, opts SVGOptions, options ...ExportOption) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := ExportSVGTo(buf, artifact, opts, options...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportSVGTo writes an SVG visualization of the dungeon graph to w.
+func ExportSVGTo(w io.Writer, artifact *dungeon.Artifact, opts SVGOptions, options ...ExportOption) error {
+	applyOptions(&opts, options)
 	if artifact == nil {
-		return nil, fmt.Errorf("artifact cannot be nil")
+		return fmt.Errorf("artifact cannot be nil")
 	}
 	if artifact.ADG == nil || artifact.ADG.Graph == nil {
-		return nil, fmt.Errorf("artifact must contain a valid ADG")
+		return fmt.Errorf("artifact must contain a valid ADG")
 	}
 
 	// Validate options
@@ -70,20 +97,50 @@ func ExportSVG(artifact *dungeon.Artifact, opts SVGOptions) ([]byte, error) {
 	if opts.Margin <= 0 {
 		opts.Margin = 60
 	}
+	switch opts.Floors {
+	case FloorsMerged, FloorsSideBySide, FloorsStacked:
+	default:
+		return fmt.Errorf("unknown floor layout %q", opts.Floors)
+	}
 
-	// Create buffer for SVG output
-	buf := new(bytes.Buffer)
-	canvas := svg.New(buf)
+	return writeBuffered(w, func(w io.Writer) error {
+		writeSVG(w, artifact, opts)
+		return nil
+	})
+}
+
+// writeSVG draws the visualization of an artifact with validated options.
+func writeSVG(w io.Writer, artifact *dungeon.Artifact, opts SVGOptions) {
+	canvas := svg.New(w)
 	canvas.Start(opts.Width, opts.Height)
 
 	// Add background
 	canvas.Rect(0, 0, opts.Width, opts.Height, "fill:#1a1a2e")
 
-	// Calculate layout positions for nodes
-	positions := calculateLayout(artifact.ADG.Graph, opts)
+	// Calculate layout positions for nodes, keeping regions together
+	var regions []graph.Region
+	if opts.ShowRegions {
+		regions = artifact.ADG.Graph.Regions(graph.RegionOptions{MinRooms: svgRegionMinRooms})
+	}
+	positions := calculateLayout(artifact.ADG.Graph, regions, opts)
+	panels := floorPanels(artifact.ADG.Graph, opts)
+	if len(panels) > 0 {
+		positions = floorLayout(artifact.ADG.Graph, regions, panels, opts)
+		drawFloorPanels(canvas, panels)
+	}
+
+	// Shade region clusters behind everything else
+	if opts.ShowRegions {
+		drawRegions(canvas, regions, positions, opts)
+	}
 
 	// Draw edges first (so they appear behind nodes)
-	drawEdges(canvas, artifact.ADG.Graph, positions, opts)
+	drawEdges(canvas, artifact.ADG.Graph, positions, len(panels) > 0, opts)
+
+	// Draw route overlay above edges but below nodes
+	if len(opts.Route) > 1 {
+		drawRoute(canvas, positions, opts)
+	}
 
 	// Draw nodes
 	drawNodes(canvas, artifact.ADG.Graph, positions, opts)
@@ -109,17 +166,16 @@ func ExportSVG(artifact *dungeon.Artifact, opts SVGOptions) ([]byte, error) {
 	}
 
 	canvas.End()
-	return buf.Bytes(), nil
 }
 
-// SaveSVGToFile generates an SVG visualization and saves it to a file.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func SaveSVGToFile(artifact *dungeon.Artifact, filepath string, opts SVGOptions) error {
-	data, err := ExportSVG(artifact, opts)
+// SaveSVGToFile generates an SVG visualization and saves it to a file with
+// dungeon.WriteFile.
+func SaveSVGToFile(artifact *dungeon.Artifact, filepath string, opts SVGOptions, options ...ExportOption) error {
+	data, err := ExportSVG(artifact, opts, options...)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return writeFile(filepath, data, options)
 }
 
 // position represents a 2D coordinate.
@@ -128,8 +184,9 @@ type position struct {
 }
 
 // calculateLayout computes positions for all rooms using a force-directed layout.
+// When regions are given, each region's rooms are placed next to each other.
 // Returns a map from room ID to position.
-func calculateLayout(g *graph.Graph, opts SVGOptions) map[string]position {
+func calculateLayout(g *graph.Graph, regions []graph.Region, opts SVGOptions) map[string]position {
 	positions := make(map[string]position)
 
 	// If no rooms, return empty
@@ -147,6 +204,12 @@ func calculateLayout(g *graph.Graph, opts SVGOptions) map[string]position {
 		roomIDs = append(roomIDs, id)
 	}
 	sort.Strings(roomIDs)
+	if len(regions) > 0 {
+		roomIDs = roomIDs[:0]
+		for _, region := range regions {
+			roomIDs = append(roomIDs, region.Rooms...)
+		}
+	}
 
 	// Center point
 	centerX := float64(opts.Width) / 2
@@ -168,8 +231,54 @@ func calculateLayout(g *graph.Graph, opts SVGOptions) map[string]position {
 	return positions
 }
 
-// drawEdges renders all connectors as lines between rooms.
-func drawEdges(canvas *svg.SVG, g *graph.Graph, positions map[string]position, opts SVGOptions) {
+// svgRegionMinRooms is the fewest rooms a chokepoint must cut off on both
+// sides to start a new cluster.
+const svgRegionMinRooms = 3
+
+// regionColors are the cluster shades, reused when there are more regions.
+var regionColors = []string{"#805ad5", "#38b2ac", "#d69e2e", "#e53e3e", "#3182ce", "#dd6b20", "#38a169", "#d53f8c"}
+
+// drawRegions shades each region's rooms with a halo in the region's colour
+// and labels the cluster just outside its rooms, away from the canvas centre.
+func drawRegions(canvas *svg.SVG, regions []graph.Region, positions map[string]position, opts SVGOptions) {
+	centerX := float64(opts.Width) / 2
+	centerY := float64(opts.Height-100) / 2
+
+	for _, region := range regions {
+		color := regionColors[region.ID%len(regionColors)]
+
+		sumX, sumY, count := 0.0, 0.0, 0
+		for _, id := range region.Rooms {
+			pos, ok := positions[id]
+			if !ok {
+				continue
+			}
+			canvas.Circle(int(pos.X), int(pos.Y), opts.NodeRadius+12,
+				fmt.Sprintf("fill:%s;opacity:0.25;stroke:none", color))
+			sumX += pos.X
+			sumY += pos.Y
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+
+		// Push the label out from the centre past the rooms
+		x, y := sumX/float64(count), sumY/float64(count)
+		dx, dy := x-centerX, y-centerY
+		if d := math.Hypot(dx, dy); d > 0 {
+			x += dx / d * float64(opts.NodeRadius*3)
+			y += dy / d * float64(opts.NodeRadius*3)
+		}
+		canvas.Text(int(x), int(y), fmt.Sprintf("Region %d", region.ID),
+			fmt.Sprintf("text-anchor:middle;font-size:12px;font-weight:bold;fill:%s", color))
+	}
+}
+
+// drawEdges renders all connectors as lines between rooms. Connectors
+// between floors get stairs markers, and when the floors are drawn apart
+// their line is faded so it doesn't cut across the other panels.
+func drawEdges(canvas *svg.SVG, g *graph.Graph, positions map[string]position, splitFloors bool, opts SVGOptions) {
 	// Sort connector IDs for deterministic output
 	connectorIDs := make([]string, 0, len(g.Connectors))
 	for id := range g.Connectors {
@@ -188,6 +297,11 @@ func drawEdges(canvas *svg.SVG, g *graph.Graph, positions map[string]position, o
 
 		// Determine edge color and style based on connector type
 		color, style := getEdgeStyle(conn, opts)
+		from, to := g.Rooms[conn.From], g.Rooms[conn.To]
+		interFloor := RoomFloor(from) != RoomFloor(to)
+		if interFloor && splitFloors {
+			style = "opacity:0.25;stroke-dasharray:2,6"
+		}
 
 		// Draw the line
 		canvas.Line(
@@ -207,6 +321,53 @@ func drawEdges(canvas *svg.SVG, g *graph.Graph, positions map[string]position, o
 			midY := (fromPos.Y + toPos.Y) / 2
 			drawGate(canvas, midX, midY, conn.Gate, opts)
 		}
+
+		if interFloor {
+			drawStairs(canvas, from, to, fromPos, toPos, opts)
+		}
+	}
+}
+
+// Route overlay colors: first visits, and second passes re-entering a room
+// the route has already been through.
+const (
+	routeColor        = "#f6e05e" // Yellow
+	routeRevisitColor = "#ed8936" // Orange
+)
+
+// drawRoute renders opts.Route as a highlighted walk between rooms, with each
+// step numbered at its midpoint so backtracking over a segment stays readable.
+// Steps into rooms the route already visited are second passes, drawn dashed
+// in their own color.
+func drawRoute(canvas *svg.SVG, positions map[string]position, opts SVGOptions) {
+	visited := map[string]bool{opts.Route[0]: true}
+	for i := 1; i < len(opts.Route); i++ {
+		revisit := visited[opts.Route[i]]
+		visited[opts.Route[i]] = true
+
+		fromPos, fromOK := positions[opts.Route[i-1]]
+		toPos, toOK := positions[opts.Route[i]]
+		if !fromOK || !toOK {
+			continue // Skip steps through unknown rooms
+		}
+
+		color, dash := routeColor, ""
+		if revisit {
+			color, dash = routeRevisitColor, fmt.Sprintf(";stroke-dasharray:%d,%d", opts.EdgeWidth*4, opts.EdgeWidth*3)
+		}
+		canvas.Line(
+			int(fromPos.X), int(fromPos.Y),
+			int(toPos.X), int(toPos.Y),
+			fmt.Sprintf("stroke:%s;stroke-width:%d;opacity:0.7;stroke-linecap:round%s", color, opts.EdgeWidth*3, dash),
+		)
+
+		// Offset repeated steps along the segment so their labels don't overlap
+		t := 0.35 + 0.3*float64(i%2)
+		labelX := fromPos.X + (toPos.X-fromPos.X)*t
+		labelY := fromPos.Y + (toPos.Y-fromPos.Y)*t
+		canvas.Circle(int(labelX), int(labelY), 8, fmt.Sprintf("fill:%s;stroke:#000;stroke-width:1", color))
+		canvas.Text(int(labelX), int(labelY+3), fmt.Sprintf("%d", i),
+			"text-anchor:middle;font-size:9px;font-weight:bold;fill:#000")
 	}
 }
 
@@ -279,10 +440,12 @@ func drawGate(canvas *svg.SVG, x, y float64, gate *graph.Gate, opts SVGOptions)
 	// Draw a small circle for gate indicator
 	canvas.Circle(int(x), int(y), 6, "fill:#ffd700;stroke:#000;stroke-width:1")
 
-	// Add first letter of gate type
+	// Add first letter of gate type, or + when it needs several capabilities
 	glyph := "?"
-	if len(gate.Type) > 0 {
-		glyph = string(gate.Type[0])
+	if needs := gate.Needs(); len(needs) > 1 {
+		glyph = "+"
+	} else if len(needs) == 1 && len(needs[0].Type) > 0 {
+		glyph = string(needs[0].Type[0])
 	}
 	canvas.Text(int(x), int(y+4), glyph,
 		"text-anchor:middle;font-size:10px;font-weight:bold;fill:#000")
@@ -296,6 +459,7 @@ func drawNodes(canvas *svg.SVG, g *graph.Graph, positions map[string]position, o
 		roomIDs = append(roomIDs, id)
 	}
 	sort.Strings(roomIDs)
+	lo, hi := elevationRange(g)
 
 	for _, id := range roomIDs {
 		room := g.Rooms[id]
@@ -304,16 +468,25 @@ func drawNodes(canvas *svg.SVG, g *graph.Graph, positions map[string]position, o
 			continue
 		}
 
-		// Get node color based on archetype
+		// Get node color based on archetype, or elevation if requested
 		color := getNodeColor(room.Archetype, opts)
+		if opts.ColorByElevation {
+			color = getElevationColor(room, lo, hi)
+		}
 
 		// Adjust size based on room size
 		radius := getNodeRadius(room.Size, opts.NodeRadius)
 
-		// Draw circle with stroke
+		// Draw circle with stroke, in the theme's color if requested
+		stroke, strokeWidth := "#fff", 2
+		if opts.ShowThemes {
+			if theme, ok := themes.Lookup(room.Tags["biome"]); ok {
+				stroke, strokeWidth = theme.Palette().Hex("floor"), 4
+			}
+		}
 		canvas.Circle(
 			int(pos.X), int(pos.Y), radius,
-			fmt.Sprintf("fill:%s;stroke:#fff;stroke-width:2;opacity:0.9", color),
+			fmt.Sprintf("fill:%s;stroke:%s;stroke-width:%d;opacity:0.9", color, stroke, strokeWidth),
 		)
 
 		// Draw inner circle for difficulty indication if heatmap not shown
@@ -357,6 +530,8 @@ func getNodeColor(archetype graph.RoomArchetype, opts SVGOptions) string {
 		return "#ecc94b" // Yellow
 	case graph.ArchetypeCheckpoint:
 		return "#4299e1" // Light blue
+	case graph.ArchetypeExit:
+		return "#9ae6b4" // Pale green
 	default:
 		return "#4a5568" // Gray
 	}
@@ -459,7 +634,7 @@ func drawLegend(canvas *svg.SVG, opts SVGOptions) {
 	legendY := opts.Margin + 20
 
 	// Legend background
-	canvas.Rect(legendX-10, legendY-15, 190, 320,
+	canvas.Rect(legendX-10, legendY-15, 190, 342,
 		"fill:#2d3748;stroke:#4a5568;stroke-width:1;opacity:0.95;rx:5")
 
 	// Legend title
@@ -484,6 +659,7 @@ func drawLegend(canvas *svg.SVG, opts SVGOptions) {
 		{"Vendor", getNodeColor(graph.ArchetypeVendor, opts)},
 		{"Shrine", getNodeColor(graph.ArchetypeShrine, opts)},
 		{"Checkpoint", getNodeColor(graph.ArchetypeCheckpoint, opts)},
+		{"Exit", getNodeColor(graph.ArchetypeExit, opts)},
 	}
 
 	for _, entry := range legendEntries {
@@ -36,6 +36,100 @@ func TestExportSVG_Basic(t *testing.T) {
 	}
 }
 
+// Test route overlay is drawn with numbered steps only when a route is set
+func TestExportSVG_RouteOverlay(t *testing.T) {
+	artifact := createSVGTestArtifact(t)
+
+	opts := DefaultSVGOptions()
+	plain, err := ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	if strings.Contains(string(plain), "#f6e05e") {
+		t.Error("SVG without a route should not contain the route overlay")
+	}
+
+	opts.Route = []string{"room1", "room2"}
+	data, err := ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	if strings.Count(string(data), "stroke:#f6e05e") != 1 {
+		t.Error("Expected one route segment in the overlay")
+	}
+
+	// Walking back into room1 is a second pass
+	opts.Route = []string{"room1", "room2", "room1"}
+	data, err = ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	if strings.Count(string(data), "stroke:#f6e05e") != 1 || strings.Count(string(data), "stroke:#ed8936") != 1 {
+		t.Error("Expected one first-pass and one second-pass route segment")
+	}
+	if !strings.Contains(string(data), "stroke-dasharray:8,6") {
+		t.Error("Expected the second pass to be dashed")
+	}
+}
+
+func TestExportSVG_Floors(t *testing.T) {
+	artifact := createSVGTestArtifact(t)
+	artifact.ADG.Rooms["room2"].Tags = map[string]string{FloorTag: "1"}
+
+	opts := DefaultSVGOptions()
+	data, err := ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	svgStr := string(data)
+	if strings.Contains(svgStr, "Floor 0") {
+		t.Error("Merged floors should not draw floor panels")
+	}
+	if strings.Count(svgStr, stairsUpGlyph) != 1 || strings.Count(svgStr, stairsDownGlyph) != 1 {
+		t.Error("Expected one stairs up and one stairs down marker on the inter-floor connector")
+	}
+
+	for _, layout := range []FloorLayout{FloorsSideBySide, FloorsStacked} {
+		opts.Floors = layout
+		data, err := ExportSVG(artifact, opts)
+		if err != nil {
+			t.Fatalf("ExportSVG(%s) failed: %v", layout, err)
+		}
+		svgStr := string(data)
+		if !strings.Contains(svgStr, "Floor 0") || !strings.Contains(svgStr, "Floor 1") {
+			t.Errorf("%s: expected a panel per floor", layout)
+		}
+		if !strings.Contains(svgStr, stairsUpGlyph) {
+			t.Errorf("%s: expected stairs markers", layout)
+		}
+	}
+
+	opts.Floors = "spiral"
+	if _, err := ExportSVG(artifact, opts); err == nil {
+		t.Error("Expected an error for an unknown floor layout")
+	}
+}
+
+func TestExportSVG_ColorByElevation(t *testing.T) {
+	artifact := createSVGTestArtifact(t)
+	artifact.ADG.Rooms["room2"].Tags = map[string]string{FloorTag: "1", "elevation": "sunken"}
+
+	if h := RoomHeight(artifact.ADG.Rooms["room2"]); h != floorLevels-1 {
+		t.Errorf("RoomHeight = %d, want %d", h, floorLevels-1)
+	}
+
+	opts := DefaultSVGOptions()
+	opts.ColorByElevation = true
+	data, err := ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	svgStr := string(data)
+	if !strings.Contains(svgStr, "fill:#2b4c7e") || !strings.Contains(svgStr, "fill:#faf089") {
+		t.Error("Expected the lowest and highest rooms at the ends of the elevation gradient")
+	}
+}
+
 // T095: Test SVG export with nil artifact
 func TestExportSVG_NilArtifact(t *testing.T) {
 	opts := DefaultSVGOptions()
@@ -522,6 +616,39 @@ func TestExportSVG_EmptyGraph(t *testing.T) {
 	}
 }
 
+// Test region clusters are shaded and labelled
+func TestExportSVG_Regions(t *testing.T) {
+	artifact := createTestArtifactForSVG(t)
+	artifact.ADG.Graph.Connectors["conn2"].Gate = &graph.Gate{Type: "key", Value: "boss_key"}
+
+	opts := DefaultSVGOptions()
+	opts.ShowRegions = true
+
+	data, err := ExportSVG(artifact, opts)
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+
+	svgStr := string(data)
+	for _, label := range []string{"Region 0", "Region 1"} {
+		if !strings.Contains(svgStr, label) {
+			t.Errorf("Expected %q label in output", label)
+		}
+	}
+	if strings.Contains(svgStr, "Region 2") {
+		t.Error("Expected only two regions")
+	}
+
+	// Off by default
+	data, err = ExportSVG(artifact, DefaultSVGOptions())
+	if err != nil {
+		t.Fatalf("ExportSVG failed: %v", err)
+	}
+	if strings.Contains(string(data), "Region 0") {
+		t.Error("Regions should not be drawn unless ShowRegions is set")
+	}
+}
+
 // Helper: Create a basic test artifact with a few rooms and connections
 func createTestArtifactForSVG(t *testing.T) *dungeon.Artifact {
 	t.Helper()
@@ -0,0 +1,276 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// DefaultThumbnailSize is the width and height of a thumbnail in pixels.
+const DefaultThumbnailSize = 256
+
+// ThumbnailFormat selects the image format of a thumbnail.
+type ThumbnailFormat string
+
+const (
+	// ThumbnailPNG encodes thumbnails as paletted PNG images.
+	ThumbnailPNG ThumbnailFormat = "png"
+
+	// ThumbnailSVG encodes thumbnails as SVG, one rectangle per run of
+	// same-colored pixels in a row.
+	ThumbnailSVG ThumbnailFormat = "svg"
+)
+
+// ThumbnailOptions configures thumbnail rendering.
+type ThumbnailOptions struct {
+	Size        int             // Width and height in pixels (default: 256)
+	Format      ThumbnailFormat // Empty means ThumbnailPNG
+	Parallelism int             // Thumbnails ExportThumbnails renders at once; 0 means runtime.GOMAXPROCS(0)
+}
+
+// Thumbnail pixel kinds, in increasing precedence where several tiles
+// share a pixel, so corridors stay visible when the map is shrunk.
+const (
+	thumbEmpty uint8 = iota
+	thumbWall
+	thumbFloor
+	thumbStart
+	thumbBoss
+)
+
+// thumbPalette is the color of each thumbnail pixel kind, matching the SVG
+// background and the start and boss room colors.
+var thumbPalette = color.Palette{
+	thumbEmpty: color.RGBA{0x1a, 0x1a, 0x2e, 0xff},
+	thumbWall:  color.RGBA{0x4a, 0x55, 0x68, 0xff},
+	thumbFloor: color.RGBA{0xcb, 0xd5, 0xe0, 0xff},
+	thumbStart: color.RGBA{0x48, 0xbb, 0x78, 0xff},
+	thumbBoss:  color.RGBA{0xf5, 0x65, 0x65, 0xff},
+}
+
+// ExportThumbnail renders a small square preview of an artifact's tile map
+// for level-select screens and editor browsers: floor, walls, and the start
+// and boss rooms highlighted, scaled to fit and centered. It reads the tile
+// map only, so it is much cheaper than the graph SVG.
+func ExportThumbnail(artifact *dungeon.Artifact, opts ThumbnailOptions) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := ExportThumbnailTo(buf, artifact, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportThumbnailTo writes a thumbnail of an artifact to w; see
+// ExportThumbnail.
+func ExportThumbnailTo(w io.Writer, artifact *dungeon.Artifact, opts ThumbnailOptions) error {
+	if err := opts.normalize(); err != nil {
+		return err
+	}
+	if artifact == nil || artifact.TileMap == nil {
+		return fmt.Errorf("artifact has no tile map")
+	}
+	pixels := rasterizeThumbnail(artifact, opts.Size)
+
+	if opts.Format == ThumbnailSVG {
+		return writeBuffered(w, func(w io.Writer) error {
+			writeThumbnailSVG(w, pixels, opts.Size)
+			return nil
+		})
+	}
+	img := &image.Paletted{
+		Pix:     pixels,
+		Stride:  opts.Size,
+		Rect:    image.Rect(0, 0, opts.Size, opts.Size),
+		Palette: thumbPalette,
+	}
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	return enc.Encode(w, img)
+}
+
+// ExportThumbnails renders a thumbnail of each artifact, in order, with at
+// most opts.Parallelism rendering at once. The first failure, or ctx being
+// cancelled, stops the thumbnails not started yet.
+func ExportThumbnails(ctx context.Context, artifacts []*dungeon.Artifact, opts ThumbnailOptions) ([][]byte, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	thumbs := make([][]byte, len(artifacts))
+	errs := make([]error, len(artifacts))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, artifact := range artifacts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			thumb, err := ExportThumbnail(artifact, opts)
+			if err != nil {
+				errs[i] = fmt.Errorf("artifact %d: %w", i, err)
+				cancel()
+				return
+			}
+			thumbs[i] = thumb
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the other thumbnails, not the
+	// cancellations, unless the caller cancelled
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return thumbs, nil
+}
+
+// normalize applies defaults and validates the options.
+func (o *ThumbnailOptions) normalize() error {
+	if o.Size <= 0 {
+		o.Size = DefaultThumbnailSize
+	}
+	switch o.Format {
+	case "":
+		o.Format = ThumbnailPNG
+	case ThumbnailPNG, ThumbnailSVG:
+	default:
+		return fmt.Errorf("unknown thumbnail format %q, must be png or svg", o.Format)
+	}
+	return nil
+}
+
+// rasterizeThumbnail returns the pixel kinds of a size x size thumbnail,
+// row-major. Each tile covers the pixels its scaled square overlaps, or at
+// least the one its corner falls in, so the cost is one pass over the tiles
+// and one over the pixels.
+func rasterizeThumbnail(artifact *dungeon.Artifact, size int) []uint8 {
+	tm := artifact.TileMap
+	pixels := make([]uint8, size*size)
+	if tm.Width <= 0 || tm.Height <= 0 {
+		return pixels
+	}
+
+	scale := float64(size) / float64(max(tm.Width, tm.Height))
+	offsetX := (float64(size) - float64(tm.Width)*scale) / 2
+	offsetY := (float64(size) - float64(tm.Height)*scale) / 2
+	span := func(offset float64, i int) (int, int) {
+		lo := min(int(offset+float64(i)*scale), size-1)
+		hi := min(max(int(offset+float64(i+1)*scale), lo+1), size)
+		return lo, hi
+	}
+	paint := func(x, y int, kind uint8) {
+		x0, x1 := span(offsetX, x)
+		y0, y1 := span(offsetY, y)
+		for py := y0; py < y1; py++ {
+			for px := x0; px < x1; px++ {
+				if i := py*size + px; pixels[i] < kind {
+					pixels[i] = kind
+				}
+			}
+		}
+	}
+
+	kinds := make([]uint8, tm.Width*tm.Height)
+	if walls, ok := tm.Layers["walls"]; ok {
+		for i, v := range walls.Data {
+			if v != uint32(carving.TileEmpty) && i < len(kinds) {
+				kinds[i] = thumbWall
+			}
+		}
+	}
+	if floor, ok := tm.Layers["floor"]; ok {
+		for i, v := range floor.Data {
+			if v != uint32(carving.TileEmpty) && i < len(kinds) {
+				kinds[i] = thumbFloor
+			}
+		}
+	}
+
+	// Highlight the floor of the start and boss rooms
+	if artifact.ADG != nil && artifact.ADG.Graph != nil {
+		for id, bounds := range roomTileBounds(artifact) {
+			kind := thumbEmpty
+			switch artifact.ADG.Rooms[id].Archetype {
+			case graph.ArchetypeStart:
+				kind = thumbStart
+			case graph.ArchetypeBoss:
+				kind = thumbBoss
+			default:
+				continue
+			}
+			for y := max(bounds.Y, 0); y < min(bounds.Y+bounds.Height, tm.Height); y++ {
+				for x := max(bounds.X, 0); x < min(bounds.X+bounds.Width, tm.Width); x++ {
+					if i := y*tm.Width + x; kinds[i] == thumbFloor {
+						kinds[i] = kind
+					}
+				}
+			}
+		}
+	}
+
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			if kind := kinds[y*tm.Width+x]; kind != thumbEmpty {
+				paint(x, y, kind)
+			}
+		}
+	}
+	return pixels
+}
+
+// writeThumbnailSVG writes thumbnail pixels as SVG to a buffered writer,
+// merging each row's runs of same-kind pixels into one rectangle.
+func writeThumbnailSVG(w io.Writer, pixels []uint8, size int) {
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", size, size, size, size)
+	fmt.Fprintf(w, `<rect width="%d" height="%d" fill="%s"/>`+"\n", size, size, thumbColor(thumbEmpty))
+	for y := 0; y < size; y++ {
+		row := pixels[y*size : (y+1)*size]
+		for x := 0; x < size; {
+			kind := row[x]
+			end := x + 1
+			for end < size && row[end] == kind {
+				end++
+			}
+			if kind != thumbEmpty {
+				fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="1" fill="%s"/>`+"\n", x, y, end-x, thumbColor(kind))
+			}
+			x = end
+		}
+	}
+	fmt.Fprintln(w, "</svg>")
+}
+
+// thumbColor returns the hex color of a thumbnail pixel kind.
+func thumbColor(kind uint8) string {
+	c := thumbPalette[kind].(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,112 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/export"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestExportThumbnails verifies thumbnails of generated dungeons decode as
+// fixed-size images with floor on them, in both formats and in order.
+func TestExportThumbnails(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	var artifacts []*dungeon.Artifact
+	for seed := uint64(1); seed <= 3; seed++ {
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 15, RoomsMax: 20},
+			OptionalRatio: 0.2,
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+		}
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	thumbs, err := export.ExportThumbnails(context.Background(), artifacts, export.ThumbnailOptions{Size: 128})
+	if err != nil {
+		t.Fatalf("ExportThumbnails() error = %v", err)
+	}
+	if len(thumbs) != len(artifacts) {
+		t.Fatalf("got %d thumbnails, want %d", len(thumbs), len(artifacts))
+	}
+	for i, thumb := range thumbs {
+		img, err := png.Decode(bytes.NewReader(thumb))
+		if err != nil {
+			t.Fatalf("thumbnail %d: png.Decode() error = %v", i, err)
+		}
+		if b := img.Bounds(); b.Dx() != 128 || b.Dy() != 128 {
+			t.Errorf("thumbnail %d is %dx%d, want 128x128", i, b.Dx(), b.Dy())
+		}
+		colors := make(map[uint32]bool)
+		for y := 0; y < 128; y++ {
+			for x := 0; x < 128; x++ {
+				r, g, b, _ := img.At(x, y).RGBA()
+				colors[r<<16|g<<8|b] = true
+			}
+		}
+		if len(colors) < 4 {
+			t.Errorf("thumbnail %d has %d colors, want background, walls, floor and rooms", i, len(colors))
+		}
+
+		single, err := export.ExportThumbnail(artifacts[i], export.ThumbnailOptions{Size: 128})
+		if err != nil {
+			t.Fatalf("ExportThumbnail() error = %v", err)
+		}
+		if !bytes.Equal(single, thumb) {
+			t.Errorf("thumbnail %d differs from ExportThumbnail", i)
+		}
+	}
+
+	svg, err := export.ExportThumbnail(artifacts[0], export.ThumbnailOptions{Format: export.ThumbnailSVG})
+	if err != nil {
+		t.Fatalf("ExportThumbnail(svg) error = %v", err)
+	}
+	if !strings.Contains(string(svg), `width="256" height="256"`) || !strings.Contains(string(svg), "#cbd5e0") {
+		t.Error("SVG thumbnail is not a default-size image with floor on it")
+	}
+
+	if _, err := export.ExportThumbnails(context.Background(), append(artifacts, &dungeon.Artifact{}), export.ThumbnailOptions{}); err == nil {
+		t.Error("expected an error for an artifact without a tile map")
+	}
+	if _, err := export.ExportThumbnail(artifacts[0], export.ThumbnailOptions{Format: "gif"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := export.ExportThumbnails(ctx, artifacts, export.ThumbnailOptions{}); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+// BenchmarkExportThumbnail measures rendering one default thumbnail.
+func BenchmarkExportThumbnail(b *testing.B) {
+	cfg := &dungeon.Config{
+		Seed:          1,
+		Size:          dungeon.SizeCfg{RoomsMin: 40, RoomsMax: 50},
+		OptionalRatio: 0.2,
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+		Themes:        []string{"dungeon"},
+	}
+	artifact, err := dungeon.NewGeneratorWithValidator(validation.NewValidator()).Generate(context.Background(), cfg)
+	if err != nil {
+		b.Fatalf("Generate() error = %v", err)
+	}
+	for b.Loop() {
+		if _, err := export.ExportThumbnail(artifact, export.ThumbnailOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"sort"
+	"strings"
 
 	"github.com/dshills/dungo/pkg/carving"
 	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/themes"
 )
 
 // TMJ Format Types
@@ -307,8 +309,30 @@ func ParseGID(gid uint32) (tileID uint32, flipH, flipV, flipD bool) {
 
 // Export Functions
 
+// TMJOptions configures TMJ export.
+type TMJOptions struct {
+	Compress bool // Gzip and base64-encode tile layer data
+}
+
+// DefaultTMJOptions returns the default TMJ export options: compressed.
+func DefaultTMJOptions() TMJOptions {
+	return TMJOptions{Compress: true}
+}
+
+func (o *TMJOptions) setCompression(on bool) {
+	o.Compress = on
+}
+
 // ExportTMJ converts a dungeon artifact to TMJ format.
+//
+// Deprecated: Use ExportTMJWithOptions.
 func ExportTMJ(artifact *dungeon.Artifact, compress bool) (*TMJMap, error) {
+	return ExportTMJWithOptions(artifact, TMJOptions{Compress: compress})
+}
+
+// ExportTMJWithOptions converts a dungeon artifact to TMJ format.
+func ExportTMJWithOptions(artifact *dungeon.Artifact, opts TMJOptions, options ...ExportOption) (*TMJMap, error) {
+	applyOptions(&opts, options)
 	if artifact.TileMap == nil {
 		return nil, fmt.Errorf("artifact has no tile map")
 	}
@@ -322,15 +346,17 @@ func ExportTMJ(artifact *dungeon.Artifact, compress bool) (*TMJMap, error) {
 	// Add default tileset
 	tmjMap.AddTileset("dungeon_tiles", "tilesets/dungeon.png", 16, 16, 256, 16)
 
-	// Export tile layers (floor, walls, doors, decor)
-	layerNames := []string{"floor", "walls", "doors", "decor"}
+	// Export tile layers (floor, walls, doors, decor, elevation, biome, terrain).
+	// The collision layer holds collision types rather than GIDs, so it is
+	// exported through ExportCollision instead.
+	layerNames := []string{"floor", "walls", "doors", "decor", "elevation", "biome", "terrain"}
 	for _, name := range layerNames {
 		if layer, exists := tm.Layers[name]; exists && layer.Type == "tilelayer" {
 			tmjLayer := tmjMap.AddTileLayer(name, layer.Data)
 			tmjLayer.Class = name
 
 			// Apply compression if requested
-			if compress {
+			if opts.Compress {
 				if err := tmjLayer.CompressLayerData(); err != nil {
 					return nil, fmt.Errorf("failed to compress layer %s: %w", name, err)
 				}
@@ -338,8 +364,8 @@ func ExportTMJ(artifact *dungeon.Artifact, compress bool) (*TMJMap, error) {
 		}
 	}
 
-	// Export object layers (entities, triggers)
-	objectLayerNames := []string{"entities", "triggers", "hazards"}
+	// Export object layers (entities, triggers, hazards, destructibles, transitions, environment)
+	objectLayerNames := []string{"entities", "triggers", "hazards", "destructibles", "transitions", "environment"}
 	for _, name := range objectLayerNames {
 		if layer, exists := tm.Layers[name]; exists && layer.Type == "objectgroup" {
 			tmjLayer := tmjMap.AddObjectLayer(name)
@@ -393,12 +419,75 @@ func ExportTMJ(artifact *dungeon.Artifact, compress bool) (*TMJMap, error) {
 		TMJProperty{Name: "generator", Type: "string", Value: "dungo"},
 	)
 
+	// Name the biome layer's values: value N is the Nth listed biome
+	if _, ok := tm.Layers["biome"]; ok && artifact.ADG != nil {
+		tmjMap.Properties = append(tmjMap.Properties,
+			TMJProperty{Name: "biomes", Type: "string", Value: strings.Join(biomePalette(artifact.ADG), ",")},
+		)
+	}
+
+	// Name each registered biome theme's tilesets, "role=path,..." by role
+	if artifact.ADG != nil {
+		for _, biome := range biomePalette(artifact.ADG) {
+			theme, ok := themes.Lookup(biome)
+			if !ok {
+				continue
+			}
+			mapping := theme.TilesetMapping()
+			roles := make([]string, 0, len(mapping))
+			for role := range mapping {
+				roles = append(roles, role)
+			}
+			sort.Strings(roles)
+			for i, role := range roles {
+				roles[i] = role + "=" + mapping[role].Path
+			}
+			tmjMap.Properties = append(tmjMap.Properties,
+				TMJProperty{Name: "tilesets." + biome, Type: "string", Value: strings.Join(roles, ",")},
+			)
+		}
+	}
+
+	// Carry game metadata as typed properties, "metadata.<key>" by key
+	for _, key := range artifact.Metadata.Keys() {
+		t, ok := dungeon.MetadataType(key)
+		if !ok {
+			continue
+		}
+		tmjMap.Properties = append(tmjMap.Properties,
+			TMJProperty{Name: "metadata." + key, Type: string(t), Value: artifact.Metadata[key]},
+		)
+	}
+
 	return tmjMap, nil
 }
 
+// biomePalette returns the distinct room biomes in sorted order, matching
+// carving.BiomePalette for a fully laid-out graph.
+func biomePalette(g *dungeon.Graph) []string {
+	seen := make(map[string]bool)
+	palette := []string{}
+	for _, room := range g.Rooms {
+		if biome := room.Tags["biome"]; biome != "" && !seen[biome] {
+			seen[biome] = true
+			palette = append(palette, biome)
+		}
+	}
+	sort.Strings(palette)
+	return palette
+}
+
 // ExportTMJFromCarving converts a carving.TileMap to TMJ format (helper for testing).
+//
+// Deprecated: Use ExportTMJFromCarvingWithOptions.
 func ExportTMJFromCarving(tm *carving.TileMap, compress bool) (*TMJMap, error) {
-	return ConvertTileMapToTMJ(tm, compress)
+	return ConvertTileMapToTMJWithOptions(tm, TMJOptions{Compress: compress})
+}
+
+// ExportTMJFromCarvingWithOptions converts a carving.TileMap to TMJ format
+// (helper for testing).
+func ExportTMJFromCarvingWithOptions(tm *carving.TileMap, opts TMJOptions, options ...ExportOption) (*TMJMap, error) {
+	return ConvertTileMapToTMJWithOptions(tm, opts, options...)
 }
 
 // MarshalTMJ serializes a TMJ map to JSON with indentation.
@@ -411,13 +500,13 @@ func MarshalTMJCompact(tmjMap *TMJMap) ([]byte, error) {
 	return json.Marshal(tmjMap)
 }
 
-// SaveTMJToFile exports a TMJ map to a file.
-func SaveTMJToFile(tmjMap *TMJMap, filepath string) error {
+// SaveTMJToFile exports a TMJ map to a file with dungeon.WriteFile.
+func SaveTMJToFile(tmjMap *TMJMap, filepath string, options ...ExportOption) error {
 	data, err := MarshalTMJ(tmjMap)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return writeFile(filepath, data, options)
 }
 
 // EncodeTMJ writes a TMJ map to a writer with indentation.
@@ -430,25 +519,58 @@ func EncodeTMJ(tmjMap *TMJMap, w io.Writer) error {
 // Convenience Functions
 
 // ExportArtifactToTMJ exports an artifact to TMJ format with options.
+//
+// Deprecated: Use ExportArtifactToTMJWithOptions.
 func ExportArtifactToTMJ(artifact *dungeon.Artifact, compress bool) ([]byte, error) {
-	tmjMap, err := ExportTMJ(artifact, compress)
+	return ExportArtifactToTMJWithOptions(artifact, TMJOptions{Compress: compress})
+}
+
+// ExportArtifactToTMJWithOptions exports an artifact to TMJ JSON.
+func ExportArtifactToTMJWithOptions(artifact *dungeon.Artifact, opts TMJOptions, options ...ExportOption) ([]byte, error) {
+	tmjMap, err := ExportTMJWithOptions(artifact, opts, options...)
 	if err != nil {
 		return nil, err
 	}
 	return MarshalTMJ(tmjMap)
 }
 
+// ExportTMJTo writes an artifact to w as indented TMJ JSON.
+func ExportTMJTo(w io.Writer, artifact *dungeon.Artifact, opts TMJOptions, options ...ExportOption) error {
+	tmjMap, err := ExportTMJWithOptions(artifact, opts, options...)
+	if err != nil {
+		return err
+	}
+	return EncodeTMJ(tmjMap, w)
+}
+
 // SaveArtifactToTMJFile exports an artifact directly to a TMJ file.
+//
+// Deprecated: Use SaveArtifactToTMJFileWithOptions.
 func SaveArtifactToTMJFile(artifact *dungeon.Artifact, filepath string, compress bool) error {
-	tmjMap, err := ExportTMJ(artifact, compress)
+	return SaveArtifactToTMJFileWithOptions(artifact, filepath, TMJOptions{Compress: compress})
+}
+
+// SaveArtifactToTMJFileWithOptions exports an artifact directly to a TMJ file.
+func SaveArtifactToTMJFileWithOptions(artifact *dungeon.Artifact, filepath string, opts TMJOptions, options ...ExportOption) error {
+	tmjMap, err := ExportTMJWithOptions(artifact, opts, options...)
 	if err != nil {
 		return err
 	}
-	return SaveTMJToFile(tmjMap, filepath)
+	return SaveTMJToFile(tmjMap, filepath, options...)
 }
 
 // ConvertTileMapToTMJ converts a carving.TileMap to TMJ format (used for testing).
+//
+// Deprecated: Use ConvertTileMapToTMJWithOptions.
 func ConvertTileMapToTMJ(tm *carving.TileMap, compress bool) (*TMJMap, error) {
+	return ConvertTileMapToTMJWithOptions(tm, TMJOptions{Compress: compress})
+}
+
+// ConvertTileMapToTMJWithOptions converts a carving.TileMap to TMJ format
+// (used for testing). Layers are added in name order; like
+// ExportTMJWithOptions it leaves out the collision layer.
+func ConvertTileMapToTMJWithOptions(tm *carving.TileMap, opts TMJOptions, options ...ExportOption) (*TMJMap, error) {
+	applyOptions(&opts, options)
 	if tm == nil {
 		return nil, fmt.Errorf("tile map is nil")
 	}
@@ -460,13 +582,22 @@ func ConvertTileMapToTMJ(tm *carving.TileMap, compress bool) (*TMJMap, error) {
 	// Add default tileset
 	tmjMap.AddTileset("dungeon_tiles", "tilesets/dungeon.png", 16, 16, 256, 16)
 
+	names := make([]string, 0, len(tm.Layers))
+	for name := range tm.Layers {
+		if name != "collision" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
 	// Export all tile layers
-	for name, layer := range tm.Layers {
+	for _, name := range names {
+		layer := tm.Layers[name]
 		if layer.Type == "tilelayer" {
 			tmjLayer := tmjMap.AddTileLayer(name, layer.Data)
 			tmjLayer.Class = name
 
-			if compress {
+			if opts.Compress {
 				if err := tmjLayer.CompressLayerData(); err != nil {
 					return nil, fmt.Errorf("failed to compress layer %s: %w", name, err)
 				}
@@ -387,9 +387,9 @@ func TestTMJ_Compression(t *testing.T) {
 	}
 
 	// Without compression
-	tmjUncompressed, err := ConvertTileMapToTMJ(tm, false)
+	tmjUncompressed, err := ConvertTileMapToTMJWithOptions(tm, DefaultTMJOptions(), WithCompression(false))
 	if err != nil {
-		t.Fatalf("ConvertTileMapToTMJ(compress=false) error = %v", err)
+		t.Fatalf("ConvertTileMapToTMJWithOptions(compress=false) error = %v", err)
 	}
 
 	uncompressedData, err := MarshalTMJ(tmjUncompressed)
@@ -398,9 +398,9 @@ func TestTMJ_Compression(t *testing.T) {
 	}
 
 	// With compression
-	tmjCompressed, err := ConvertTileMapToTMJ(tm, true)
+	tmjCompressed, err := ConvertTileMapToTMJWithOptions(tm, DefaultTMJOptions())
 	if err != nil {
-		t.Fatalf("ConvertTileMapToTMJ(compress=true) error = %v", err)
+		t.Fatalf("ConvertTileMapToTMJWithOptions(compress=true) error = %v", err)
 	}
 
 	compressedData, err := MarshalTMJ(tmjCompressed)
@@ -7,26 +7,28 @@ import (
 
 	"github.com/dshills/dungo/pkg/dungeon"
 	"github.com/dshills/dungo/pkg/export"
+	"github.com/dshills/dungo/pkg/validation"
 )
 
 // TMJMap represents the structure of a Tiled TMJ (JSON map) file.
 // This is a subset of the full Tiled format focusing on key fields we need to validate.
 type TMJMap struct {
-	Version         string       `json:"version"`
-	TiledVersion    string       `json:"tiledversion"`
-	Type            string       `json:"type"`
-	Orientation     string       `json:"orientation"`
-	Width           int          `json:"width"`
-	Height          int          `json:"height"`
-	TileWidth       int          `json:"tilewidth"`
-	TileHeight      int          `json:"tileheight"`
-	Infinite        bool         `json:"infinite"`
-	Layers          []TMJLayer   `json:"layers"`
-	Tilesets        []TMJTileset `json:"tilesets"`
-	NextObjectID    int          `json:"nextobjectid,omitempty"`
-	NextLayerID     int          `json:"nextlayerid,omitempty"`
-	RenderOrder     string       `json:"renderorder,omitempty"`
-	BackgroundColor string       `json:"backgroundcolor,omitempty"`
+	Version         string        `json:"version"`
+	TiledVersion    string        `json:"tiledversion"`
+	Type            string        `json:"type"`
+	Orientation     string        `json:"orientation"`
+	Width           int           `json:"width"`
+	Height          int           `json:"height"`
+	TileWidth       int           `json:"tilewidth"`
+	TileHeight      int           `json:"tileheight"`
+	Infinite        bool          `json:"infinite"`
+	Layers          []TMJLayer    `json:"layers"`
+	Tilesets        []TMJTileset  `json:"tilesets"`
+	NextObjectID    int           `json:"nextobjectid,omitempty"`
+	NextLayerID     int           `json:"nextlayerid,omitempty"`
+	RenderOrder     string        `json:"renderorder,omitempty"`
+	BackgroundColor string        `json:"backgroundcolor,omitempty"`
+	Properties      []TMJProperty `json:"properties,omitempty"`
 }
 
 // TMJLayer represents a layer in the TMJ format.
@@ -279,7 +281,7 @@ func TestTiledCompatibility(t *testing.T) {
 			Curve:    dungeon.PacingLinear,
 			Variance: 0.1,
 		},
-		Themes: []string{"cave"},
+		Themes: []string{"fungal"},
 	}
 
 	gen := dungeon.NewGenerator()
@@ -373,7 +375,7 @@ func TestTMJLayerStructure(t *testing.T) {
 			Curve:    dungeon.PacingLinear,
 			Variance: 0.15,
 		},
-		Themes: []string{"fortress"},
+		Themes: []string{"dungeon"},
 	}
 
 	gen := dungeon.NewGenerator()
@@ -436,3 +438,138 @@ func TestTMJLayerStructure(t *testing.T) {
 
 	t.Log("TMJ layer structure test passed")
 }
+
+// TestTMJBiomeTransitions validates that multi-theme maps export a biome
+// layer, transition objects and the biome palette.
+func TestTMJBiomeTransitions(t *testing.T) {
+	cfg := &dungeon.Config{
+		Seed: 4242,
+		Size: dungeon.SizeCfg{
+			RoomsMin: 20,
+			RoomsMax: 25,
+		},
+		OptionalRatio: 0.2,
+		Branching: dungeon.BranchingCfg{
+			Avg: 2.0,
+			Max: 4,
+		},
+		Pacing: dungeon.PacingCfg{
+			Curve:    dungeon.PacingLinear,
+			Variance: 0.15,
+		},
+		Themes: []string{"crypt", "fungal"},
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Skipf("Skipping biome transition test: generation failed: %v", err)
+	}
+
+	tmjMap, err := export.ExportTMJ(artifact, false)
+	if err != nil {
+		t.Fatalf("ExportTMJ failed: %v", err)
+	}
+
+	tmjData, err := json.Marshal(tmjMap)
+	if err != nil {
+		t.Fatalf("Failed to marshal TMJ: %v", err)
+	}
+
+	var tmj TMJMap
+	if err := json.Unmarshal(tmjData, &tmj); err != nil {
+		t.Fatalf("Failed to parse TMJ: %v", err)
+	}
+
+	var biome, transitions *TMJLayer
+	for i := range tmj.Layers {
+		switch tmj.Layers[i].Name {
+		case "biome":
+			biome = &tmj.Layers[i]
+		case "transitions":
+			transitions = &tmj.Layers[i]
+		}
+	}
+	if biome == nil || biome.Type != "tilelayer" {
+		t.Fatal("Expected a biome tile layer")
+	}
+	if transitions == nil || transitions.Type != "objectgroup" {
+		t.Fatal("Expected a transitions object layer")
+	}
+
+	if len(transitions.Objects) == 0 {
+		t.Error("Expected transition objects between the two biomes")
+	}
+
+	// Every object is either a corridor seam or a transition room
+	for _, obj := range transitions.Objects {
+		if obj.Type != "biome_transition" && obj.Type != "transition_room" {
+			t.Errorf("Transition object %q has unexpected type %q", obj.Name, obj.Type)
+		}
+	}
+
+	found, tilesets := false, false
+	for _, prop := range tmj.Properties {
+		if prop.Name == "biomes" {
+			found = true
+			if prop.Value != "crypt,fungal" {
+				t.Errorf("Expected biomes \"crypt,fungal\", got %v", prop.Value)
+			}
+		}
+		if prop.Name == "tilesets.crypt" {
+			tilesets = true
+			if prop.Value != "decor=tiles/crypt_decor.png,floor=tiles/crypt_floor.png,walls=tiles/crypt_walls.png" {
+				t.Errorf("Unexpected crypt tilesets %v", prop.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a biomes map property")
+	}
+	if !tilesets {
+		t.Error("Expected a tilesets.crypt map property")
+	}
+}
+
+// TestTMJMetadata verifies artifact metadata is exported as typed map
+// properties.
+func TestTMJMetadata(t *testing.T) {
+	dungeon.MustRegisterMetadata("tmj.campaign", dungeon.MetaString)
+	dungeon.MustRegisterMetadata("tmj.act", dungeon.MetaInt)
+
+	cfg := &dungeon.Config{
+		Seed:          77,
+		Size:          dungeon.SizeCfg{RoomsMin: 10, RoomsMax: 15},
+		OptionalRatio: 0.2,
+		Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+		Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.15},
+		Themes:        []string{"crypt"},
+	}
+	if err := cfg.Metadata.Set("tmj.campaign", "c-17"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cfg.Metadata.Set("tmj.act", 2); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	artifact, err := gen.Generate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	tmjMap, err := export.ExportTMJ(artifact, false)
+	if err != nil {
+		t.Fatalf("ExportTMJ failed: %v", err)
+	}
+
+	props := map[string]export.TMJProperty{}
+	for _, prop := range tmjMap.Properties {
+		props[prop.Name] = prop
+	}
+	if p := props["metadata.tmj.campaign"]; p.Type != "string" || p.Value != "c-17" {
+		t.Errorf("metadata.tmj.campaign = %+v, want string c-17", p)
+	}
+	if p := props["metadata.tmj.act"]; p.Type != "int" || p.Value != int64(2) {
+		t.Errorf("metadata.tmj.act = %+v, want int 2", p)
+	}
+}
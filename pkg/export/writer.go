@@ -0,0 +1,28 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// writeBuffered streams write's output to w through a buffer, so text
+// formats built from many small writes reach w in large chunks. It returns
+// the first write error.
+func writeBuffered(w io.Writer, write func(w io.Writer) error) error {
+	bw := bufio.NewWriter(w)
+	if err := write(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeJSON writes v to w as JSON followed by a newline, indented with
+// two spaces when indent is set.
+func encodeJSON(w io.Writer, v any, indent bool) error {
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
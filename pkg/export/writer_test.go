@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestExportTo verifies the writer variants produce the same documents as
+// the byte-slice exports, and report write errors.
+func TestExportTo(t *testing.T) {
+	artifact := createGLTFTestArtifact()
+	graphArtifact := createGatesTestArtifact()
+
+	tests := []struct {
+		name  string
+		bytes func() ([]byte, error)
+		to    func(w io.Writer) error
+	}{
+		{"json", func() ([]byte, error) { return ExportJSON(artifact) },
+			func(w io.Writer) error { return ExportJSONTo(w, artifact) }},
+		{"svg", func() ([]byte, error) { return ExportSVG(graphArtifact, DefaultSVGOptions()) },
+			func(w io.Writer) error { return ExportSVGTo(w, graphArtifact, DefaultSVGOptions()) }},
+		{"obj", func() ([]byte, error) { return ExportOBJ(artifact, DefaultOBJOptions()) },
+			func(w io.Writer) error { return ExportOBJTo(w, artifact, DefaultOBJOptions()) }},
+		{"tmj", func() ([]byte, error) { return ExportArtifactToTMJWithOptions(artifact, DefaultTMJOptions()) },
+			func(w io.Writer) error { return ExportTMJTo(w, artifact, DefaultTMJOptions()) }},
+		{"rooms csv", func() ([]byte, error) { return ExportRoomStatsCSV(graphArtifact) },
+			func(w io.Writer) error { return ExportStatsCSVTo(w, nil, graphArtifact) }},
+		{"gates markdown", func() ([]byte, error) {
+			audit, err := ExportGateAudit(graphArtifact)
+			if err != nil {
+				return nil, err
+			}
+			return GateAuditMarkdown(audit), nil
+		}, func(w io.Writer) error { return ExportGateAuditTo(nil, w, graphArtifact) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.bytes()
+			if err != nil {
+				t.Fatalf("byte export error = %v", err)
+			}
+			var buf bytes.Buffer
+			if err := tt.to(&buf); err != nil {
+				t.Fatalf("writer export error = %v", err)
+			}
+			// JSON encoders end the document with a newline
+			if got := bytes.TrimSuffix(buf.Bytes(), []byte("\n")); !bytes.Equal(got, bytes.TrimSuffix(want, []byte("\n"))) {
+				t.Errorf("writer export differs from byte export (%d vs %d bytes)", buf.Len(), len(want))
+			}
+
+			if err := tt.to(failingWriter{}); err == nil {
+				t.Error("expected the write error to be reported")
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package graph
+
+import "sort"
+
+// maxReachSources caps how many reachability sets a connectivity structure
+// keeps up to date. Synthesis queries a handful of sources (usually Start and
+// the first room), so older sets are simply dropped.
+const maxReachSources = 16
+
+// connectivity maintains reachability incrementally as rooms and connectors
+// are added, so repeated IsConnected, IsWeaklyConnected and GetReachable
+// calls between additions do not re-traverse the graph. Rooms are numbered
+// in the order they join the structure. Weak connectivity is tracked with a
+// union-find forest; directed reachability is kept per queried source and
+// extended by a BFS over only the newly reachable rooms when an edge is
+// added. Removals are not supported: RemoveRoom drops the structure and the
+// next query rebuilds it.
+type connectivity struct {
+	num    map[string]int // Room number by ID
+	ids    []string       // Room ID by number
+	out    [][]int        // Directed adjacency by number, mirroring Adjacency
+	parent []int          // Union-find parent by number
+	size   []int          // Union-find component size, valid at roots
+	minID  string         // Lexicographically first room ID, the connectivity root
+
+	sources []int             // Queried sources, oldest first
+	reach   map[int]*reachSet // Rooms reachable from each source
+
+	// Sizes of the maps the structure mirrors, for staleness checks
+	roomCount, connCount, adjCount int
+}
+
+// reachSet is the set of rooms reachable from one source.
+type reachSet struct {
+	marks []bool // Reached flag by room number
+	count int    // Number of reached rooms
+}
+
+// newConnectivity builds the structure from the graph's maps. Rooms are
+// numbered in sorted ID order, followed by IDs that only appear in Adjacency.
+func newConnectivity(g *Graph) *connectivity {
+	c := &connectivity{
+		num:   make(map[string]int, len(g.Rooms)),
+		reach: make(map[int]*reachSet),
+	}
+
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+	for _, id := range roomIDs {
+		c.addNode(id)
+	}
+	if len(roomIDs) > 0 {
+		c.minID = roomIDs[0]
+	}
+
+	fromIDs := make([]string, 0, len(g.Adjacency))
+	for id := range g.Adjacency {
+		fromIDs = append(fromIDs, id)
+	}
+	sort.Strings(fromIDs)
+	for _, from := range fromIDs {
+		for _, to := range g.Adjacency[from] {
+			c.addEdge(from, to)
+		}
+	}
+
+	c.sync(g)
+	return c
+}
+
+// sync records the sizes of the graph's maps after an update.
+func (c *connectivity) sync(g *Graph) {
+	c.roomCount, c.connCount, c.adjCount = len(g.Rooms), len(g.Connectors), len(g.Adjacency)
+}
+
+// stale reports whether g has gained or lost rooms, connectors or adjacency
+// lists without the structure being updated.
+func (c *connectivity) stale(g *Graph) bool {
+	return c.roomCount != len(g.Rooms) || c.connCount != len(g.Connectors) || c.adjCount != len(g.Adjacency)
+}
+
+// addNode numbers a room, returning its existing number if it has one.
+func (c *connectivity) addNode(id string) int {
+	if n, ok := c.num[id]; ok {
+		return n
+	}
+	n := len(c.ids)
+	c.num[id] = n
+	c.ids = append(c.ids, id)
+	c.out = append(c.out, nil)
+	c.parent = append(c.parent, n)
+	c.size = append(c.size, 1)
+	for _, set := range c.reach {
+		set.marks = append(set.marks, false)
+	}
+	return n
+}
+
+// addRoom adds a room, keeping track of the connectivity root.
+func (c *connectivity) addRoom(id string) {
+	c.addNode(id)
+	if c.minID == "" || id < c.minID {
+		c.minID = id
+	}
+}
+
+// addEdge adds a directed edge, merging the endpoints' components and
+// extending every cached reachability set that reaches from but not to.
+func (c *connectivity) addEdge(from, to string) {
+	u, v := c.addNode(from), c.addNode(to)
+	c.out[u] = append(c.out[u], v)
+	c.union(u, v)
+
+	for _, set := range c.reach {
+		if set.marks[u] && !set.marks[v] {
+			c.extend(set, v)
+		}
+	}
+}
+
+// find returns the root of a room's component, halving paths as it goes.
+func (c *connectivity) find(n int) int {
+	for c.parent[n] != n {
+		c.parent[n] = c.parent[c.parent[n]]
+		n = c.parent[n]
+	}
+	return n
+}
+
+// union merges the components of two rooms, by size.
+func (c *connectivity) union(a, b int) {
+	ra, rb := c.find(a), c.find(b)
+	if ra == rb {
+		return
+	}
+	if c.size[ra] < c.size[rb] {
+		ra, rb = rb, ra
+	}
+	c.parent[rb] = ra
+	c.size[ra] += c.size[rb]
+}
+
+// extend marks start and every room reachable from it that is not yet
+// marked. Rooms already marked are not revisited, so keeping a set current
+// costs one traversal of the graph in total.
+func (c *connectivity) extend(set *reachSet, start int) {
+	set.marks[start] = true
+	set.count++
+	queue := []int{start}
+	for head := 0; head < len(queue); head++ {
+		for _, next := range c.out[queue[head]] {
+			if !set.marks[next] {
+				set.marks[next] = true
+				set.count++
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// reachable returns the rooms reachable from a room, computing and caching
+// the set on first use. The returned set must not be modified.
+func (c *connectivity) reachable(from string) *reachSet {
+	src := c.num[from]
+	if set, ok := c.reach[src]; ok {
+		return set
+	}
+
+	if len(c.sources) == maxReachSources {
+		delete(c.reach, c.sources[0])
+		c.sources = c.sources[1:]
+	}
+	set := &reachSet{marks: make([]bool, len(c.ids))}
+	c.extend(set, src)
+	c.reach[src] = set
+	c.sources = append(c.sources, src)
+	return set
+}
+
+// weakComponentSize returns the number of rooms, including IDs that only
+// appear in Adjacency, in the weak component of a room.
+func (c *connectivity) weakComponentSize(id string) int {
+	return c.size[c.find(c.num[id])]
+}
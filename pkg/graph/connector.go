@@ -1,6 +1,9 @@
 package graph
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ConnectorType defines the connection mechanism between rooms.
 type ConnectorType int
@@ -58,9 +61,56 @@ func (v VisibilityType) String() string {
 }
 
 // Gate represents an optional gating requirement for a connector.
+//
+// A simple gate needs the one capability named by Type and Value. Requirements
+// adds further capabilities, such as a second key: by default the gate needs
+// all of them, and with Any it opens for whichever one is held first. Type may
+// be left empty when Requirements lists every capability.
 type Gate struct {
-	Type  string `json:"type"`  // "key", "puzzle", "ability"
-	Value string `json:"value"` // Specific gate (e.g., "silver_key", "runes_3")
+	Type         string        `json:"type"`                   // "key", "puzzle", "ability"
+	Value        string        `json:"value"`                  // Specific gate (e.g., "silver_key", "runes_3")
+	Requirements []Requirement `json:"requirements,omitempty"` // Further capabilities checked with Type/Value
+	Any          bool          `json:"any,omitempty"`          // Any one capability opens the gate instead of all
+}
+
+// Needs returns every capability the gate checks: Type/Value first, when set,
+// then Requirements.
+func (g *Gate) Needs() []Requirement {
+	needs := make([]Requirement, 0, len(g.Requirements)+1)
+	if g.Type != "" {
+		needs = append(needs, Requirement{Type: g.Type, Value: g.Value})
+	}
+	return append(needs, g.Requirements...)
+}
+
+// Opens reports whether the gate lets through a player holding the
+// capabilities for which has returns true. A gate that checks nothing is
+// always open.
+func (g *Gate) Opens(has func(capType, value string) bool) bool {
+	needs := g.Needs()
+	if len(needs) == 0 {
+		return true
+	}
+	for _, need := range needs {
+		if has(need.Type, need.Value) == g.Any {
+			return g.Any
+		}
+	}
+	return !g.Any
+}
+
+// String returns the gate's capabilities as "type:value", joined by AND or,
+// for an Any gate, OR.
+func (g *Gate) String() string {
+	sep := " AND "
+	if g.Any {
+		sep = " OR "
+	}
+	parts := make([]string, 0, len(g.Requirements)+1)
+	for _, need := range g.Needs() {
+		parts = append(parts, need.Type+":"+need.Value)
+	}
+	return strings.Join(parts, sep)
 }
 
 // Connector represents an edge in the Abstract Dungeon Graph (ADG).
@@ -118,7 +168,7 @@ func (c *Connector) String() string {
 	}
 	gateInfo := ""
 	if c.Gate != nil {
-		gateInfo = fmt.Sprintf(" [Gate: %s=%s]", c.Gate.Type, c.Gate.Value)
+		gateInfo = fmt.Sprintf(" [Gate: %s]", c.Gate)
 	}
 	return fmt.Sprintf("Connector[%s: %s %s %s (%s, Cost=%.2f)%s]",
 		c.ID, c.From, direction, c.To, c.Type, c.Cost, gateInfo)
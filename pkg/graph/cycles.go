@@ -0,0 +1,209 @@
+package graph
+
+import "sort"
+
+// CycleRank returns the number of independent cycles in the graph, E - V + C
+// for E connectors, V rooms and C connected components, with connectors
+// treated as undirected. Every cycle of the graph combines basis cycles from
+// CycleBasis, which has exactly this many. It takes one pass over the
+// connectors however densely the rooms are connected.
+func (g *Graph) CycleRank() int {
+	_, closing := spanningForest(g.topology())
+	return len(closing)
+}
+
+// CycleBasis returns a fundamental cycle basis of the graph, with connectors
+// treated as undirected. A spanning forest is grown over the connectors in ID
+// order; each connector left out of it closes one cycle: the forest path from
+// the connector's From room to its To room. Cycles list each room once,
+// starting at From and ending at To.
+//
+// The basis has CycleRank cycles and is deterministic, and each cycle costs
+// its own length to build, so it stays cheap on dense graphs where listing
+// every cycle does not.
+func (g *Graph) CycleBasis() [][]string {
+	idx := g.topology()
+	tree, closing := spanningForest(idx)
+
+	// Root each tree at its lowest-numbered room
+	n := len(idx.ids)
+	parent := make([]int, n)
+	depth := make([]int, n)
+	for i := range parent {
+		parent[i] = -1
+	}
+	queue := make([]int, 0, n)
+	for root := 0; root < n; root++ {
+		if parent[root] != -1 {
+			continue
+		}
+		parent[root] = root
+		queue = append(queue[:0], root)
+		for head := 0; head < len(queue); head++ {
+			current := queue[head]
+			for _, next := range tree[current] {
+				if parent[next] == -1 {
+					parent[next] = current
+					depth[next] = depth[current] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	// Climb from both ends of each closing connector to their common ancestor
+	cycles := make([][]string, 0, len(closing))
+	for _, edge := range closing {
+		a, b := edge[0], edge[1]
+		var up, down []int
+		for a != b {
+			if depth[a] >= depth[b] {
+				up = append(up, a)
+				a = parent[a]
+			} else {
+				down = append(down, b)
+				b = parent[b]
+			}
+		}
+
+		cycle := make([]string, 0, len(up)+len(down)+1)
+		for _, node := range up {
+			cycle = append(cycle, idx.ids[node])
+		}
+		cycle = append(cycle, idx.ids[a])
+		for i := len(down) - 1; i >= 0; i-- {
+			cycle = append(cycle, idx.ids[down[i]])
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
+
+// ShortCycles returns every simple cycle of at most k rooms, with connectors
+// treated as undirected, for metrics that only care about tight loops on
+// graphs too dense to enumerate all cycles. Each cycle is listed once,
+// starting at its lowest room in ID order and continuing toward the lower of
+// that room's two cycle neighbours. Two rooms joined by several connectors
+// form one cycle of two rooms.
+//
+// Cycles are returned in order of their starting room, then lexicographically
+// by room ID. The search only extends paths shorter than k, so its cost grows
+// with the number of short paths rather than the number of cycles overall.
+func (g *Graph) ShortCycles(k int) [][]string {
+	cycles := [][]string{}
+	if k < 1 {
+		return cycles
+	}
+	idx := g.topology()
+	rooms := len(g.Rooms)
+
+	// Distinct undirected neighbours by room, counting parallel connectors
+	neighbors := make([][]int, rooms)
+	parallel := make(map[[2]int]int)
+	loops := make([]bool, rooms)
+	for _, edge := range idx.edges {
+		a, b := edge[0], edge[1]
+		if a >= rooms || b >= rooms {
+			continue
+		}
+		if a == b {
+			loops[a] = true
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		parallel[[2]int{a, b}]++
+		if parallel[[2]int{a, b}] == 1 {
+			neighbors[a] = append(neighbors[a], b)
+			neighbors[b] = append(neighbors[b], a)
+		}
+	}
+	for _, list := range neighbors {
+		sort.Ints(list)
+	}
+
+	emit := func(path []int) {
+		cycle := make([]string, len(path))
+		for i, node := range path {
+			cycle[i] = idx.ids[node]
+		}
+		cycles = append(cycles, cycle)
+	}
+
+	// Extend paths from each start through higher-numbered rooms only, so
+	// each cycle is found from its lowest room
+	onPath := make([]bool, rooms)
+	path := make([]int, 0, k)
+	var extend func()
+	extend = func() {
+		start, last := path[0], path[len(path)-1]
+		for _, next := range neighbors[last] {
+			switch {
+			case next == start && len(path) == 2:
+				if parallel[[2]int{start, last}] > 1 {
+					emit(path)
+				}
+			case next == start && len(path) > 2:
+				// Each direction around the cycle is seen; keep one
+				if path[1] < last {
+					emit(path)
+				}
+			case next > start && !onPath[next] && len(path) < k:
+				onPath[next] = true
+				path = append(path, next)
+				extend()
+				path = path[:len(path)-1]
+				onPath[next] = false
+			}
+		}
+	}
+
+	for start := 0; start < rooms; start++ {
+		if loops[start] {
+			emit([]int{start})
+		}
+		onPath[start] = true
+		path = append(path[:0], start)
+		extend()
+		onPath[start] = false
+	}
+
+	return cycles
+}
+
+// spanningForest grows a spanning forest over the rooms of idx by adding
+// connectors in ID order. It returns the forest's adjacency and the
+// connectors left out, each of which closes a cycle. Connectors to IDs
+// outside Rooms are ignored.
+func spanningForest(idx *index) (tree [][]int, closing [][2]int) {
+	n := len(idx.ids)
+	root := make([]int, n)
+	for i := range root {
+		root[i] = i
+	}
+	find := func(x int) int {
+		for root[x] != x {
+			root[x] = root[root[x]]
+			x = root[x]
+		}
+		return x
+	}
+
+	tree = make([][]int, n)
+	for _, edge := range idx.edges {
+		a, b := edge[0], edge[1]
+		if idx.rooms[a] == nil || idx.rooms[b] == nil {
+			continue
+		}
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			closing = append(closing, edge)
+			continue
+		}
+		root[ra] = rb
+		tree[a] = append(tree[a], b)
+		tree[b] = append(tree[b], a)
+	}
+	return tree, closing
+}
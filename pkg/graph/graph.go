@@ -2,7 +2,8 @@ package graph
 
 import (
 	"fmt"
-	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 // Graph represents the complete Abstract Dungeon Graph (ADG).
@@ -15,12 +16,28 @@ import (
 //   - Adjacency: map of room ID to neighbor IDs (for pathfinding)
 //
 // All graph mutations (AddRoom, AddConnector) validate constraints and update indices.
+//
+// Traversals (GetPath, GetVisibleReachable, GetCycles) run on a cached index
+// that numbers rooms with stable ints in sorted ID order, so their inner
+// loops use slices instead of string-keyed maps. The index is rebuilt after
+// AddRoom, AddConnector and RemoveRoom. Connectivity queries (IsConnected,
+// IsWeaklyConnected, GetReachable) use a structure that AddRoom and
+// AddConnector update incrementally, so checking connectivity between
+// additions does not re-traverse the graph. Both are rebuilt when Rooms,
+// Connectors or Adjacency gain or lose entries. Code that edits those maps
+// directly in other ways, such as rewiring an adjacency list, must call
+// Reindex afterwards.
 type Graph struct {
 	Rooms      map[string]*Room
 	Connectors map[string]*Connector
 	Adjacency  map[string][]string // Adjacency list for pathfinding
 	Seed       uint64
 	Metadata   map[string]interface{}
+
+	idx atomic.Pointer[index] // Cached topology index, nil until first traversal
+
+	mu   sync.Mutex    // Guards conn
+	conn *connectivity // Incremental connectivity, nil until first query
 }
 
 // NewGraph creates a new empty graph with the given seed.
@@ -51,10 +68,18 @@ func (g *Graph) AddRoom(room *Room) error {
 	}
 
 	// Add room to map and initialize adjacency list
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	conn := g.currentConnectivity()
 	g.Rooms[room.ID] = room
 	if g.Adjacency[room.ID] == nil {
 		g.Adjacency[room.ID] = []string{}
 	}
+	if conn != nil {
+		conn.addRoom(room.ID)
+		conn.sync(g)
+	}
+	g.idx.Store(nil)
 
 	return nil
 }
@@ -84,13 +109,26 @@ func (g *Graph) AddConnector(conn *Connector) error {
 	}
 
 	// Add connector
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.currentConnectivity()
 	g.Connectors[conn.ID] = conn
 
 	// Update adjacency list
 	g.Adjacency[conn.From] = append(g.Adjacency[conn.From], conn.To)
+	if c != nil {
+		c.addEdge(conn.From, conn.To)
+	}
 	if conn.Bidirectional {
 		g.Adjacency[conn.To] = append(g.Adjacency[conn.To], conn.From)
+		if c != nil {
+			c.addEdge(conn.To, conn.From)
+		}
 	}
+	if c != nil {
+		c.sync(g)
+	}
+	g.idx.Store(nil)
 
 	return nil
 }
@@ -124,10 +162,67 @@ func (g *Graph) RemoveRoom(id string) error {
 	// Remove room and its adjacency list
 	delete(g.Rooms, id)
 	delete(g.Adjacency, id)
+	g.Reindex()
+
+	return nil
+}
+
+// RemoveConnector removes a connector and its adjacency entries from the
+// graph. Its rooms stay, even when left without connectors.
+func (g *Graph) RemoveConnector(id string) error {
+	conn, exists := g.Connectors[id]
+	if !exists {
+		return fmt.Errorf("connector %s does not exist", id)
+	}
+
+	delete(g.Connectors, id)
+	g.removeAdjacencyEntry(conn.From, conn.To)
+	if conn.Bidirectional {
+		g.removeAdjacencyEntry(conn.To, conn.From)
+	}
+	g.Reindex()
 
 	return nil
 }
 
+// Reindex discards the cached traversal index and connectivity structure so
+// the next query rebuilds them from Rooms, Connectors and Adjacency.
+func (g *Graph) Reindex() {
+	g.idx.Store(nil)
+	g.mu.Lock()
+	g.conn = nil
+	g.mu.Unlock()
+}
+
+// currentConnectivity returns the connectivity structure if it is current,
+// dropping a stale one. The caller must hold g.mu.
+func (g *Graph) currentConnectivity() *connectivity {
+	if g.conn != nil && g.conn.stale(g) {
+		g.conn = nil
+	}
+	return g.conn
+}
+
+// reachability returns the connectivity structure, building it if needed.
+// The caller must hold g.mu.
+func (g *Graph) reachability() *connectivity {
+	if g.currentConnectivity() == nil {
+		g.conn = newConnectivity(g)
+	}
+	return g.conn
+}
+
+// topology returns the traversal index, rebuilding it when missing or stale.
+// Concurrent traversals may each rebuild it; the snapshots are identical.
+func (g *Graph) topology() *index {
+	idx := g.idx.Load()
+	if idx == nil || idx.stale(g) {
+		idx = buildIndex(g)
+		g.idx.Store(idx)
+	}
+	return idx
+}
+
 // removeFromAdjacency removes 'to' from the adjacency list of 'from'.
 func (g *Graph) removeFromAdjacency(from, to string) {
 	adj, exists := g.Adjacency[from]
@@ -145,6 +240,18 @@ func (g *Graph) removeFromAdjacency(from, to string) {
 	g.Adjacency[from] = newAdj
 }
 
+// removeAdjacencyEntry removes one 'to' entry from the adjacency list of
+// 'from', keeping those of other connectors between the same rooms.
+func (g *Graph) removeAdjacencyEntry(from, to string) {
+	adj := g.Adjacency[from]
+	for i, neighbor := range adj {
+		if neighbor == to {
+			g.Adjacency[from] = append(adj[:i:i], adj[i+1:]...)
+			return
+		}
+	}
+}
+
 // GetPath finds the shortest path between two rooms using BFS.
 // Returns a slice of room IDs representing the path from 'from' to 'to',
 // including both endpoints. Returns an error if no path exists.
@@ -162,35 +269,39 @@ func (g *Graph) GetPath(from, to string) ([]string, error) {
 		return []string{from}, nil
 	}
 
-	// BFS to find shortest path
-	queue := []string{from}
-	visited := make(map[string]bool)
-	parent := make(map[string]string)
-	visited[from] = true
+	// BFS to find shortest path, recording each room's parent
+	idx := g.topology()
+	src, dst := idx.num[from], idx.num[to]
+	parent := make([]int, len(idx.ids))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[src] = src
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+	queue := make([]int, 1, len(idx.ids))
+	queue[0] = src
+	for head := 0; head < len(queue); head++ {
+		current := queue[head]
 
 		// Check neighbors
-		for _, neighbor := range g.Adjacency[current] {
-			if visited[neighbor] {
+		for _, neighbor := range idx.adj[current] {
+			if parent[neighbor] != -1 {
 				continue
 			}
 
-			visited[neighbor] = true
 			parent[neighbor] = current
 			queue = append(queue, neighbor)
 
 			// Found the target
-			if neighbor == to {
+			if neighbor == dst {
 				// Reconstruct path
-				path := []string{}
-				for node := to; node != ""; node = parent[node] {
-					path = append([]string{node}, path...)
-					if node == from {
-						break
-					}
+				length := 1
+				for node := dst; node != src; node = parent[node] {
+					length++
+				}
+				path := make([]string, length)
+				for node, i := dst, length-1; i >= 0; node, i = parent[node], i-1 {
+					path[i] = idx.ids[node]
 				}
 				return path, nil
 			}
@@ -211,19 +322,11 @@ func (g *Graph) IsConnected() bool {
 		return true
 	}
 
-	// Pick deterministic starting point (lexicographically first room ID)
-	roomIDs := make([]string, 0, len(g.Rooms))
-	for id := range g.Rooms {
-		roomIDs = append(roomIDs, id)
-	}
-	sort.Strings(roomIDs)
-	startID := roomIDs[0]
-
-	// Get all reachable rooms from start
-	reachable := g.GetReachable(startID)
-
-	// Check if all rooms are reachable
-	return len(reachable) == len(g.Rooms)
+	// Count rooms reachable from the lexicographically first room ID
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.reachability()
+	return c.reachable(c.minID).count == len(g.Rooms)
 }
 
 // IsWeaklyConnected checks if the graph is weakly connected.
@@ -234,44 +337,10 @@ func (g *Graph) IsWeaklyConnected() bool {
 		return true
 	}
 
-	// Pick deterministic starting point (lexicographically first room ID)
-	roomIDs := make([]string, 0, len(g.Rooms))
-	for id := range g.Rooms {
-		roomIDs = append(roomIDs, id)
-	}
-	sort.Strings(roomIDs)
-	startID := roomIDs[0]
-
-	// Build undirected adjacency (treat all edges as bidirectional)
-	undirectedAdj := make(map[string][]string)
-	for from, neighbors := range g.Adjacency {
-		for _, to := range neighbors {
-			// Add forward edge
-			undirectedAdj[from] = append(undirectedAdj[from], to)
-			// Add reverse edge
-			undirectedAdj[to] = append(undirectedAdj[to], from)
-		}
-	}
-
-	// BFS with undirected graph
-	visited := make(map[string]bool)
-	queue := []string{startID}
-	visited[startID] = true
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		for _, neighbor := range undirectedAdj[current] {
-			if !visited[neighbor] {
-				visited[neighbor] = true
-				queue = append(queue, neighbor)
-			}
-		}
-	}
-
-	// Check if all rooms were visited
-	return len(visited) == len(g.Rooms)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.reachability()
+	return c.weakComponentSize(c.minID) == len(g.Rooms)
 }
 
 // GetReachable returns all rooms reachable from the given room using BFS.
@@ -283,40 +352,78 @@ func (g *Graph) GetReachable(from string) map[string]bool {
 		return reachable
 	}
 
-	// BFS to find all reachable rooms
-	queue := []string{from}
-	reachable[from] = true
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.reachability()
+	for n, reached := range c.reachable(from).marks {
+		if reached {
+			reachable[c.ids[n]] = true
+		}
+	}
+	return reachable
+}
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+// GetVisibleReachable returns all rooms reachable from the given room without
+// discovering a secret: hidden or secret-visibility connectors are never
+// crossed and Secret rooms are never entered. Connector direction is respected.
+func (g *Graph) GetVisibleReachable(from string) map[string]bool {
+	// Check if starting room exists
+	if _, exists := g.Rooms[from]; !exists {
+		return make(map[string]bool)
+	}
 
-		for _, neighbor := range g.Adjacency[current] {
-			if !reachable[neighbor] {
-				reachable[neighbor] = true
-				queue = append(queue, neighbor)
+	idx := g.topology()
+	reached := make([]bool, len(idx.ids))
+	src := idx.num[from]
+	reached[src] = true
+
+	// BFS over visible connectors only
+	queue := make([]int, 1, len(idx.ids))
+	queue[0] = src
+	for head := 0; head < len(queue); head++ {
+		for _, l := range idx.links[queue[head]] {
+			if l.conn.Type == TypeHidden || l.conn.Visibility == VisibilitySecret {
+				continue
+			}
+			if reached[l.to] || (idx.rooms[l.to] != nil && idx.rooms[l.to].Archetype == ArchetypeSecret) {
+				continue
 			}
+			reached[l.to] = true
+			queue = append(queue, l.to)
 		}
 	}
 
+	reachable := make(map[string]bool, len(queue))
+	for _, i := range queue {
+		reachable[idx.ids[i]] = true
+	}
 	return reachable
 }
 
 // GetCycles detects all cycles in the graph and returns them as a list of paths.
 // Each cycle is represented as a slice of room IDs forming the cycle.
+// Rooms are searched in sorted ID order, so the result is deterministic.
+// The search finds at most one cycle per starting room, so on densely
+// connected graphs it undercounts loops; CycleRank counts independent cycles,
+// CycleBasis lists them and ShortCycles enumerates the tight ones.
 func (g *Graph) GetCycles() [][]string {
 	cycles := [][]string{}
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
-	parent := make(map[string]string)
+	idx := g.topology()
+	n := len(idx.ids)
+	visited := make([]bool, n)
+	recStack := make([]bool, n)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = -1
+	}
 
 	// DFS helper function to detect cycles
-	var dfs func(string) []string
-	dfs = func(node string) []string {
+	var dfs func(int) []string
+	dfs = func(node int) []string {
 		visited[node] = true
 		recStack[node] = true
 
-		for _, neighbor := range g.Adjacency[node] {
+		for _, neighbor := range idx.adj[node] {
 			// Skip back edges to immediate parent in undirected graphs
 			if parent[node] == neighbor {
 				continue
@@ -328,12 +435,15 @@ func (g *Graph) GetCycles() [][]string {
 					return cycle
 				}
 			} else if recStack[neighbor] {
-				// Found a cycle - reconstruct it
-				cycle := []string{neighbor}
+				// Found a cycle - reconstruct it from node back to neighbor
+				cycle := []string{}
 				for curr := node; curr != neighbor; curr = parent[curr] {
-					cycle = append([]string{curr}, cycle...)
+					cycle = append(cycle, idx.ids[curr])
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
 				}
-				cycle = append(cycle, neighbor) // Complete the cycle
+				cycle = append(cycle, idx.ids[neighbor], idx.ids[neighbor]) // Complete the cycle
 				return cycle
 			}
 		}
@@ -342,15 +452,17 @@ func (g *Graph) GetCycles() [][]string {
 		return nil
 	}
 
-	// Try DFS from each unvisited node
-	for roomID := range g.Rooms {
-		if !visited[roomID] {
-			if cycle := dfs(roomID); cycle != nil {
+	// Try DFS from each unvisited room
+	for room := 0; room < len(g.Rooms) && room < n; room++ {
+		if !visited[room] {
+			if cycle := dfs(room); cycle != nil {
 				cycles = append(cycles, cycle)
 				// Reset for finding more cycles
-				visited = make(map[string]bool)
-				recStack = make(map[string]bool)
-				parent = make(map[string]string)
+				clear(visited)
+				clear(recStack)
+				for i := range parent {
+					parent[i] = -1
+				}
 			}
 		}
 	}
@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkTraversals benchmarks the graph traversals used by synthesis and
+// validation on a 300-room graph.
+func BenchmarkTraversals(b *testing.B) {
+	g := createBenchGraph(300)
+	start, end := "R000", "R299"
+
+	b.Run("GetPath", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := g.GetPath(start, end); err != nil {
+				b.Fatalf("GetPath() error = %v", err)
+			}
+		}
+	})
+	b.Run("IsWeaklyConnected", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if !g.IsWeaklyConnected() {
+				b.Fatal("graph is not connected")
+			}
+		}
+	})
+	b.Run("GetVisibleReachable", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.GetVisibleReachable(start)
+		}
+	})
+	b.Run("GetCycles", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.GetCycles()
+		}
+	})
+	b.Run("AllPairsPaths", func(b *testing.B) {
+		ids := make([]string, 0, 30)
+		for j := 0; j < 300; j += 10 {
+			ids = append(ids, fmt.Sprintf("R%03d", j))
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, from := range ids {
+				for _, to := range ids {
+					if _, err := g.GetPath(from, to); err != nil {
+						b.Fatalf("GetPath() error = %v", err)
+					}
+				}
+			}
+		}
+	})
+	b.Run("AllPairsShortestPaths", func(b *testing.B) {
+		ids := make([]string, 0, 30)
+		for j := 0; j < 300; j += 10 {
+			ids = append(ids, fmt.Sprintf("R%03d", j))
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			paths := g.ShortestPaths()
+			for _, from := range ids {
+				for _, to := range ids {
+					if _, err := paths.Path(from, to); err != nil {
+						b.Fatalf("Path() error = %v", err)
+					}
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkIncrementalConnectivity benchmarks building a 300-room graph
+// BenchmarkCycles compares cycle enumeration with the cycle basis and
+// short-cycle search on a densely connected 100-room graph.
+func BenchmarkCycles(b *testing.B) {
+	g := createBenchGraph(100)
+	for i := 0; i < 100; i++ {
+		for _, step := range []int{2, 7, 11} {
+			_ = g.AddConnector(&Connector{
+				ID:            fmt.Sprintf("D%03d-%d", i, step),
+				From:          fmt.Sprintf("R%03d", i),
+				To:            fmt.Sprintf("R%03d", (i+step)%100),
+				Type:          TypeCorridor,
+				Cost:          1.0,
+				Visibility:    VisibilityNormal,
+				Bidirectional: true,
+			})
+		}
+	}
+
+	b.Run("GetCycles", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.GetCycles()
+		}
+	})
+	b.Run("CycleRank", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.CycleRank()
+		}
+	})
+	b.Run("CycleBasis", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.CycleBasis()
+		}
+	})
+	b.Run("ShortCycles", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.ShortCycles(4)
+		}
+	})
+}
+
+// while checking connectivity after every addition, as synthesis retries do.
+func BenchmarkIncrementalConnectivity(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g := NewGraph(1)
+		for j := 0; j < 300; j++ {
+			_ = g.AddRoom(&Room{ID: fmt.Sprintf("R%03d", j), Archetype: ArchetypeOptional, Size: SizeM, Difficulty: 0.5, Reward: 0.5})
+			if j > 0 {
+				_ = g.AddConnector(&Connector{
+					ID:            fmt.Sprintf("C%03d", j),
+					From:          fmt.Sprintf("R%03d", j/2),
+					To:            fmt.Sprintf("R%03d", j),
+					Type:          TypeDoor,
+					Cost:          1.0,
+					Visibility:    VisibilityNormal,
+					Bidirectional: true,
+				})
+			}
+			if !g.IsConnected() || !g.IsWeaklyConnected() {
+				b.Fatal("graph is not connected")
+			}
+			g.GetReachable("R000")
+		}
+	}
+}
+
+// createBenchGraph builds a chain of rooms with a shortcut every fifth room
+// and a hidden branch every seventh room.
+func createBenchGraph(roomCount int) *Graph {
+	g := NewGraph(1)
+	for i := 0; i < roomCount; i++ {
+		_ = g.AddRoom(&Room{ID: fmt.Sprintf("R%03d", i), Archetype: ArchetypeOptional, Size: SizeM, Difficulty: 0.5, Reward: 0.5})
+	}
+	connect := func(id string, from, to int, typ ConnectorType) {
+		_ = g.AddConnector(&Connector{
+			ID:            id,
+			From:          fmt.Sprintf("R%03d", from),
+			To:            fmt.Sprintf("R%03d", to),
+			Type:          typ,
+			Cost:          1.0,
+			Visibility:    VisibilityNormal,
+			Bidirectional: true,
+		})
+	}
+	for i := 1; i < roomCount; i++ {
+		connect(fmt.Sprintf("C%03d", i), i-1, i, TypeDoor)
+		if i%5 == 0 && i+3 < roomCount {
+			connect(fmt.Sprintf("S%03d", i), i, i+3, TypeCorridor)
+		}
+		if i%7 == 0 && i+2 < roomCount {
+			connect(fmt.Sprintf("H%03d", i), i, i+2, TypeHidden)
+		}
+	}
+	return g
+}
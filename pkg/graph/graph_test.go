@@ -307,6 +307,42 @@ func TestAddConnector_OneWay(t *testing.T) {
 	}
 }
 
+// Test RemoveConnector removes one connector and keeps its rooms
+func TestRemoveConnector(t *testing.T) {
+	g := NewGraph(1)
+	mustAddRoom(t, g, newTestRoom("R001", ArchetypeStart))
+	mustAddRoom(t, g, newTestRoom("R002", ArchetypeBoss))
+	mustAddConnector(t, g, newTestConnector("C001", "R001", "R002"))
+	mustAddConnector(t, g, newTestConnector("C002", "R001", "R002"))
+
+	if !g.IsConnected() {
+		t.Fatal("graph should be connected before removal")
+	}
+	if err := g.RemoveConnector("C001"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, exists := g.Connectors["C001"]; exists {
+		t.Error("Connector C001 should be removed")
+	}
+	if len(g.Rooms) != 2 {
+		t.Errorf("Expected 2 rooms, got %d", len(g.Rooms))
+	}
+	// C002 still joins the rooms
+	if len(g.Adjacency["R001"]) != 1 || len(g.Adjacency["R002"]) != 1 || !g.IsConnected() {
+		t.Errorf("Adjacency = %v, want R001 and R002 still joined", g.Adjacency)
+	}
+
+	if err := g.RemoveConnector("C002"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if g.IsConnected() {
+		t.Error("graph should be disconnected after removing both connectors")
+	}
+	if err := g.RemoveConnector("C002"); err == nil {
+		t.Error("Expected error removing a missing connector")
+	}
+}
+
 // Test RemoveRoom removes room and its connectors
 func TestRemoveRoom(t *testing.T) {
 	g := NewGraph(1)
@@ -569,6 +605,45 @@ func TestGetReachable_NonExistentRoom(t *testing.T) {
 	}
 }
 
+// Test GetVisibleReachable skips hidden connectors and secret rooms
+func TestGetVisibleReachable(t *testing.T) {
+	g := NewGraph(1)
+
+	// Create graph: R1 -> R2 -hidden-> R3 -> R5
+	//                      R2 -> R4(secret) -> R6
+	rooms := []*Room{
+		newTestRoom("R001", ArchetypeStart),
+		newTestRoom("R002", ArchetypeOptional),
+		newTestRoom("R003", ArchetypeTreasure),
+		newTestRoom("R004", ArchetypeSecret),
+		newTestRoom("R005", ArchetypeBoss),
+		newTestRoom("R006", ArchetypeTreasure),
+	}
+
+	for _, room := range rooms {
+		mustAddRoom(t, g, room)
+	}
+
+	mustAddConnector(t, g, newTestConnector("C001", "R001", "R002"))
+	hidden := newTestConnector("C002", "R002", "R003")
+	hidden.Type = TypeHidden
+	hidden.Visibility = VisibilitySecret
+	mustAddConnector(t, g, hidden)
+	mustAddConnector(t, g, newTestConnector("C003", "R003", "R005"))
+	mustAddConnector(t, g, newTestConnector("C004", "R002", "R004"))
+	mustAddConnector(t, g, newTestConnector("C005", "R004", "R006"))
+
+	reachable := g.GetVisibleReachable("R001")
+
+	if len(reachable) != 2 || !reachable["R001"] || !reachable["R002"] {
+		t.Errorf("Expected only R001 and R002 to be visibly reachable, got %v", reachable)
+	}
+
+	if all := g.GetReachable("R001"); len(all) != 6 {
+		t.Errorf("Expected all 6 rooms reachable ignoring secrets, got %d", len(all))
+	}
+}
+
 // Test GetCycles detects cycles
 func TestGetCycles_DetectsCycles(t *testing.T) {
 	g := NewGraph(1)
@@ -664,6 +739,642 @@ func TestGetCycles_EmptyGraph(t *testing.T) {
 	}
 }
 
+// Test CycleRank, CycleBasis and ShortCycles on a square with a diagonal, a
+// doubled edge and a separate tree
+func TestCycles(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"R1", "R2", "R3", "R4", "R5", "R6"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	for _, c := range [][3]string{
+		{"C1", "R1", "R2"}, {"C2", "R2", "R3"}, {"C3", "R3", "R4"}, {"C4", "R4", "R1"},
+		{"C5", "R1", "R3"}, {"C6", "R2", "R1"}, {"C7", "R5", "R6"},
+	} {
+		conn := newTestConnector(c[0], c[1], c[2])
+		conn.Bidirectional = c[0] != "C3" // Direction is ignored
+		mustAddConnector(t, g, conn)
+	}
+
+	// 7 connectors - 6 rooms + 2 components
+	if got := g.CycleRank(); got != 3 {
+		t.Errorf("CycleRank() = %d, want 3", got)
+	}
+
+	// Each basis cycle is a closed walk over connected rooms, closed by a
+	// different connector
+	adjacent := func(a, b string) bool {
+		for _, conn := range g.Connectors {
+			if (conn.From == a && conn.To == b) || (conn.From == b && conn.To == a) {
+				return true
+			}
+		}
+		return false
+	}
+	basis := g.CycleBasis()
+	if len(basis) != 3 {
+		t.Fatalf("CycleBasis() has %d cycles, want 3: %v", len(basis), basis)
+	}
+	for _, cycle := range basis {
+		seen := make(map[string]bool)
+		for i, id := range cycle {
+			if seen[id] {
+				t.Errorf("Cycle %v repeats room %s", cycle, id)
+			}
+			seen[id] = true
+			if next := cycle[(i+1)%len(cycle)]; !adjacent(id, next) {
+				t.Errorf("Cycle %v steps between unconnected rooms %s and %s", cycle, id, next)
+			}
+		}
+	}
+	if fmt.Sprint(basis) != fmt.Sprint(g.CycleBasis()) {
+		t.Error("CycleBasis() is not deterministic")
+	}
+
+	want := "[[R1 R2] [R1 R2 R3] [R1 R2 R3 R4] [R1 R3 R4]]"
+	if got := fmt.Sprint(g.ShortCycles(4)); got != want {
+		t.Errorf("ShortCycles(4) = %s, want %s", got, want)
+	}
+	want = "[[R1 R2] [R1 R2 R3] [R1 R3 R4]]"
+	if got := fmt.Sprint(g.ShortCycles(3)); got != want {
+		t.Errorf("ShortCycles(3) = %s, want %s", got, want)
+	}
+	if got := g.ShortCycles(0); len(got) != 0 {
+		t.Errorf("ShortCycles(0) = %v, want none", got)
+	}
+
+	// Trees have no cycles
+	tree := createBenchGraph(1)
+	if got := tree.CycleRank(); got != 0 {
+		t.Errorf("CycleRank() of a single room = %d, want 0", got)
+	}
+}
+
+// Test Regions splits at gates and at chokepoints with enough rooms on each side
+func TestRegions(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3", "c1", "c2", "c3", "d"} {
+		archetype := ArchetypeOptional
+		if id == "b1" {
+			archetype = ArchetypeStart
+		}
+		mustAddRoom(t, g, newTestRoom(id, archetype))
+	}
+	for _, c := range [][3]string{
+		{"A1", "a1", "a2"}, {"A2", "a2", "a3"}, {"A3", "a3", "a1"},
+		{"B1", "b1", "b2"}, {"B2", "b2", "b3"}, {"B3", "b3", "b1"},
+		{"C1", "c1", "c2"}, {"C2", "c2", "c3"}, {"C3", "c3", "c1"},
+		{"AB", "a1", "b1"}, {"BC", "b2", "c1"}, {"D", "a2", "d"},
+	} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+	// A gate on a loop leaves its rooms together; one on the only way through
+	// splits them even without a chokepoint
+	g.Connectors["C2"].Gate = &Gate{Type: "key", Value: "red"}
+	g.Connectors["BC"].Gate = &Gate{Type: "key", Value: "blue"}
+
+	regions := g.Regions(RegionOptions{MinRooms: 3})
+	want := []Region{
+		{ID: 0, Rooms: []string{"a1", "a2", "a3", "d"}, Exits: []string{"AB"}, Depth: 1},
+		{ID: 1, Rooms: []string{"b1", "b2", "b3"}, Exits: []string{"AB", "BC"}, Depth: 0},
+		{ID: 2, Rooms: []string{"c1", "c2", "c3"}, Exits: []string{"BC"}, Depth: 1},
+	}
+	if fmt.Sprint(regions) != fmt.Sprint(want) {
+		t.Errorf("Regions() = %v, want %v", regions, want)
+	}
+
+	// Without a minimum the dead end is a region of its own
+	regions = g.Regions(RegionOptions{})
+	if len(regions) != 4 || fmt.Sprint(regions[3]) != "{3 [d] [D] 2}" {
+		t.Errorf("Regions() without MinRooms = %v, want d split off at depth 2", regions)
+	}
+
+	// A disconnected room is an unreachable region
+	mustAddRoom(t, g, newTestRoom("e", ArchetypeOptional))
+	regions = g.Regions(RegionOptions{MinRooms: 3})
+	if last := regions[len(regions)-1]; fmt.Sprint(last) != "{3 [e] [] -1}" {
+		t.Errorf("Last region = %v, want {3 [e] [] -1}", last)
+	}
+}
+
+// Test Query filters rooms with the query DSL
+func TestQuery(t *testing.T) {
+	g := NewGraph(1)
+	rooms := []*Room{
+		{ID: "R1", Archetype: ArchetypeStart, Size: SizeM, Difficulty: 0.1, Tags: map[string]string{"zone": "crypt"}},
+		{ID: "R2", Archetype: ArchetypeTreasure, Size: SizeS, Difficulty: 0.8, Reward: 0.9, Tags: map[string]string{"zone": "crypt", "environment": "0.4"}},
+		{ID: "R3", Archetype: ArchetypeTreasure, Size: SizeS, Difficulty: 0.9, Tags: map[string]string{"zone": "fungal"}},
+		{ID: "R4", Archetype: ArchetypeBoss, Size: SizeXL, Difficulty: 1.0, Requirements: []Requirement{{Type: "key", Value: "silver"}}},
+		{ID: "R5", Archetype: ArchetypeOptional, Size: SizeM, Difficulty: 0.5, Provides: []Capability{{Type: "key", Value: "silver"}}},
+	}
+	for _, room := range rooms {
+		mustAddRoom(t, g, room)
+	}
+	for _, c := range [][3]string{{"C1", "R1", "R2"}, {"C2", "R1", "R3"}, {"C3", "R1", "R5"}, {"C4", "R5", "R4"}} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"archetype:treasure AND tag:zone=crypt AND difficulty>0.7", "[R2]"},
+		{"archetype:TREASURE", "[R2 R3]"},
+		{"archetype!=treasure", "[R1 R4 R5]"},
+		{"size:xl OR id:R1", "[R1 R4]"},
+		{"tag:zone", "[R1 R2 R3]"},
+		{"tag:zone!=crypt", "[R3 R4 R5]"},
+		{"tag:environment>=0.4", "[R2]"},
+		{"provides:key=silver", "[R5]"},
+		{"requires:key", "[R4]"},
+		{"requires:ability", "[]"},
+		{"reward>0.5", "[R2]"},
+		{"degree>=3", "[R1]"},
+		{"difficulty<=0.5 AND NOT archetype:start", "[R5]"},
+		{"not (archetype:treasure or archetype:boss) and difficulty:0.5", "[R5]"},
+		{"archetype:treasure OR archetype:boss AND difficulty>0.95", "[R2 R3 R4]"},
+		{"(archetype:treasure OR archetype:boss) AND difficulty>0.85", "[R3 R4]"},
+	}
+	for _, tt := range tests {
+		got, err := g.Query(tt.expr)
+		if err != nil {
+			t.Errorf("Query(%q) error = %v", tt.expr, err)
+			continue
+		}
+		ids := make([]string, len(got))
+		for i, room := range got {
+			ids[i] = room.ID
+		}
+		if fmt.Sprint(ids) != tt.want {
+			t.Errorf("Query(%q) = %v, want %s", tt.expr, ids, tt.want)
+		}
+	}
+
+	for _, expr := range []string{
+		"", "archetype:dragon", "size:huge", "color:red", "difficulty>high",
+		"archetype:boss AND", "(archetype:boss", "archetype:boss)", "OR id:R1",
+		"difficulty", "tag:", "tag:zone=", "archetype<boss", "provides=key",
+	} {
+		if _, err := ParseQuery(expr); err == nil {
+			t.Errorf("ParseQuery(%q) accepted a malformed query", expr)
+		}
+	}
+
+	q, err := ParseQuery("degree>1")
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	if !q.Match(g, g.Rooms["R5"]) || q.Match(g, g.Rooms["R4"]) {
+		t.Error("Match() disagrees with degree counts")
+	}
+	if q.String() != "degree>1" {
+		t.Errorf("String() = %q, want degree>1", q.String())
+	}
+}
+
+// Test Distances counts hops and respects direction
+func TestDistances(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"R001", "R002", "R003", "R004"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	mustAddConnector(t, g, newTestConnector("C001", "R001", "R002"))
+	mustAddConnector(t, g, newTestConnector("C002", "R002", "R003"))
+	oneWay := newTestConnector("C003", "R004", "R003")
+	oneWay.Bidirectional = false
+	mustAddConnector(t, g, oneWay)
+
+	got := g.Distances([]string{"R001"})
+	want := map[string]int{"R001": 0, "R002": 1, "R003": 2}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Distances(R001) = %v, want %v", got, want)
+	}
+
+	// Several sources measure from the nearest one
+	got = g.Distances([]string{"R001", "R004", "R999"})
+	want = map[string]int{"R001": 0, "R002": 1, "R003": 1, "R004": 0}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Distances(R001, R004) = %v, want %v", got, want)
+	}
+
+	if got := g.Distances([]string{"R999"}); len(got) != 0 {
+		t.Errorf("Distances(R999) = %v, want empty", got)
+	}
+}
+
+// Test GetPathAvoiding routes around avoided rooms and connectors
+func TestGetPathAvoiding(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	// Square a-b-c-d-a, with a one-way shortcut e -> c
+	for _, c := range [][3]string{{"ab", "a", "b"}, {"bc", "b", "c"}, {"cd", "c", "d"}, {"da", "d", "a"}, {"ae", "a", "e"}} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+	oneWay := newTestConnector("ec", "e", "c")
+	oneWay.Bidirectional = false
+	mustAddConnector(t, g, oneWay)
+
+	tests := []struct {
+		from, to    string
+		rooms, conn []string
+		want        string
+	}{
+		{"a", "c", nil, nil, "[a b c]"},
+		{"a", "c", []string{"b"}, nil, "[a e c]"}, // Connector ae sorts before da
+		{"a", "c", []string{"b", "e"}, nil, "[a d c]"},
+		{"a", "c", nil, []string{"bc", "cd"}, "[a e c]"},
+		{"a", "a", []string{"b"}, nil, "[a]"},
+		{"a", "c", []string{"b", "zz"}, []string{"da", "zz"}, "[a e c]"},
+	}
+	for _, tt := range tests {
+		path, err := g.GetPathAvoiding(tt.from, tt.to, tt.rooms, tt.conn)
+		if err != nil {
+			t.Errorf("GetPathAvoiding(%s, %s, %v, %v) error = %v", tt.from, tt.to, tt.rooms, tt.conn, err)
+			continue
+		}
+		if fmt.Sprint(path) != tt.want {
+			t.Errorf("GetPathAvoiding(%s, %s, %v, %v) = %v, want %s", tt.from, tt.to, tt.rooms, tt.conn, path, tt.want)
+		}
+	}
+
+	// The shortcut is one-way, so c cannot get back to a through e
+	if _, err := g.GetPathAvoiding("c", "a", []string{"b", "d"}, nil); err == nil {
+		t.Error("Expected no path against a one-way connector")
+	}
+	if _, err := g.GetPathAvoiding("a", "c", []string{"c"}, nil); err == nil {
+		t.Error("Expected an error for an avoided endpoint")
+	}
+	if _, err := g.GetPathAvoiding("a", "zz", nil, nil); err == nil {
+		t.Error("Expected an error for an unknown room")
+	}
+}
+
+// Test ReachableWithInventory collects keys in order and stops at locks whose
+// keys lie behind them
+func TestReachableWithInventory(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"start", "a", "b", "c", "d"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	// start - a - b - c, with d off start; a holds the red key, b needs it and
+	// holds the blue key, which opens the gate to c
+	for _, c := range [][3]string{{"sa", "start", "a"}, {"ab", "a", "b"}, {"bc", "b", "c"}, {"sd", "start", "d"}} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+	g.Rooms["a"].Provides = []Capability{{Type: "key", Value: "red"}}
+	g.Rooms["b"].Requirements = []Requirement{{Type: "key", Value: "red"}}
+	g.Rooms["b"].Provides = []Capability{{Type: "key", Value: "blue"}}
+	g.Connectors["bc"].Gate = &Gate{Type: "key", Value: "blue"}
+
+	reached, inventory := g.ReachableWithInventory("start")
+	if len(reached) != 5 {
+		t.Errorf("Chained keys: reached %v, want all 5 rooms", reached)
+	}
+	if !inventory[Capability{Type: "key", Value: "red"}] || !inventory[Capability{Type: "key", Value: "blue"}] {
+		t.Errorf("Chained keys: inventory %v, want red and blue", inventory)
+	}
+
+	// The red key moved behind its own lock strands b and c
+	g.Rooms["a"].Provides = nil
+	g.Rooms["c"].Provides = []Capability{{Type: "key", Value: "red"}}
+	reached, inventory = g.ReachableWithInventory("start")
+	if reached["b"] || reached["c"] || !reached["a"] || !reached["d"] {
+		t.Errorf("Key behind its own lock: reached %v, want start, a and d", reached)
+	}
+	if len(inventory) != 0 {
+		t.Errorf("Key behind its own lock: inventory %v, want empty", inventory)
+	}
+
+	// The start room is entered even when it has requirements
+	g.Rooms["start"].Requirements = []Requirement{{Type: "key", Value: "red"}}
+	if reached, _ = g.ReachableWithInventory("start"); !reached["start"] {
+		t.Error("Expected the start room to be reached")
+	}
+	if reached, _ = g.ReachableWithInventory("zz"); len(reached) != 0 {
+		t.Errorf("Unknown room: reached %v, want none", reached)
+	}
+}
+
+// Test FindSoftLock finds small keys spent on the wrong door
+func TestFindSoftLock(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"start", "closet", "hall", "boss"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	// One small key in start opens either the closet or the hall to the boss
+	for _, c := range [][3]string{{"c1", "start", "closet"}, {"c2", "start", "hall"}, {"c3", "hall", "boss"}} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+	g.Rooms["start"].Provides = []Capability{{Type: SmallKey, Value: "castle"}}
+	g.Connectors["c1"].Gate = &Gate{Type: SmallKey, Value: "castle"}
+	g.Connectors["c2"].Gate = &Gate{Type: SmallKey, Value: "castle"}
+
+	lock, err := g.FindSoftLock("start", "boss")
+	if err != nil {
+		t.Fatalf("FindSoftLock() error = %v", err)
+	}
+	if lock == nil || fmt.Sprint(lock.Opened) != "[c1]" || fmt.Sprint(lock.Reached) != "[closet start]" {
+		t.Errorf("FindSoftLock() = %+v, want the closet opened and the hall shut", lock)
+	}
+
+	// A second key in the closet makes every order safe
+	g.Rooms["closet"].Provides = []Capability{{Type: SmallKey, Value: "castle"}}
+	if lock, err = g.FindSoftLock("start", "boss"); err != nil || lock != nil {
+		t.Errorf("FindSoftLock() = %+v, %v, want no soft lock", lock, err)
+	}
+
+	// Keys of another pool do not help
+	g.Rooms["closet"].Provides = []Capability{{Type: SmallKey, Value: "tower"}}
+	if lock, err = g.FindSoftLock("start", "boss"); err != nil || lock == nil {
+		t.Errorf("FindSoftLock() = %+v, %v, want a soft lock with the wrong pool", lock, err)
+	}
+
+	// Small keys are never used up by ReachableWithInventory
+	if reached, _ := g.ReachableWithInventory("start"); !reached["boss"] || !reached["closet"] {
+		t.Errorf("ReachableWithInventory() = %v, want every room", reached)
+	}
+	if _, err := g.FindSoftLock("start", "zz"); err == nil {
+		t.Error("Expected an error for an unknown room")
+	}
+}
+
+// Test TraceSoftLocks finds one-way traps, gates whose key is outside and
+// wasted small keys
+func TestTraceSoftLocks(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"start", "pit", "cell", "hall", "boss"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	// One-way drops from start into a pit and a cell; the cell's way out
+	// needs the silver key from the hall
+	for _, c := range [][3]string{{"a_drop", "start", "pit"}, {"b_cell", "start", "cell"}, {"c_gate", "cell", "hall"}, {"d_hall", "start", "hall"}, {"e_boss", "hall", "boss"}} {
+		mustAddConnector(t, g, newTestConnector(c[0], c[1], c[2]))
+	}
+	g.Connectors["a_drop"].Bidirectional = false
+	g.Connectors["b_cell"].Bidirectional = false
+	g.Connectors["c_gate"].Gate = &Gate{Type: "key", Value: "silver"}
+	g.Rooms["hall"].Provides = []Capability{{Type: "key", Value: "silver"}}
+
+	traces, err := g.TraceSoftLocks("start", "boss")
+	if err != nil {
+		t.Fatalf("TraceSoftLocks() error = %v", err)
+	}
+	var got []string
+	for _, trace := range traces {
+		got = append(got, trace.String())
+	}
+	want := "[start -a_drop-> pit (no way out) start -b_cell-> cell (shut: c_gate)]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("TraceSoftLocks() = %v, want %s", got, want)
+	}
+
+	// With the gate gone the cell is a detour, not a trap
+	g.Connectors["c_gate"].Gate = nil
+	if traces, err = g.TraceSoftLocks("start", "boss"); err != nil || len(traces) != 1 || traces[0].Rooms[1] != "pit" {
+		t.Errorf("TraceSoftLocks() = %v, %v, want only the pit", traces, err)
+	}
+
+	// A single small key spent on the closet strands the player
+	k := NewGraph(1)
+	for _, id := range []string{"start", "closet", "hall", "boss"} {
+		mustAddRoom(t, k, newTestRoom(id, ArchetypeOptional))
+	}
+	for _, c := range [][3]string{{"c1", "start", "closet"}, {"c2", "start", "hall"}, {"c3", "hall", "boss"}} {
+		mustAddConnector(t, k, newTestConnector(c[0], c[1], c[2]))
+	}
+	k.Rooms["start"].Provides = []Capability{{Type: SmallKey, Value: "castle"}}
+	k.Connectors["c1"].Gate = &Gate{Type: SmallKey, Value: "castle"}
+	k.Connectors["c2"].Gate = &Gate{Type: SmallKey, Value: "castle"}
+
+	traces, err = k.TraceSoftLocks("start", "boss")
+	if err != nil || len(traces) != 1 {
+		t.Fatalf("TraceSoftLocks() = %v, %v, want one soft lock", traces, err)
+	}
+	trace := traces[0]
+	if fmt.Sprint(trace.Rooms, trace.Opened, trace.Trapped, trace.Blocked) != "[start closet] [c1] [closet start] [c2]" {
+		t.Errorf("TraceSoftLocks() = %+v, want the closet opened with the hall shut", trace)
+	}
+
+	if _, err := g.TraceSoftLocks("start", "zz"); err == nil {
+		t.Error("Expected an error for an unknown room")
+	}
+}
+
+// Test Gate.Opens for single, all-of and any-of gates
+func TestGateOpens(t *testing.T) {
+	held := map[string]bool{"key:silver": true, "ability:swim": true}
+	has := func(capType, value string) bool { return held[capType+":"+value] }
+	silver := Requirement{Type: "key", Value: "silver"}
+	gold := Requirement{Type: "key", Value: "gold"}
+
+	tests := []struct {
+		name string
+		gate Gate
+		want bool
+		text string
+	}{
+		{"single held", Gate{Type: "key", Value: "silver"}, true, "key:silver"},
+		{"single missing", Gate{Type: "key", Value: "gold"}, false, "key:gold"},
+		{"all held", Gate{Type: "ability", Value: "swim", Requirements: []Requirement{silver}}, true, "ability:swim AND key:silver"},
+		{"all missing one", Gate{Type: "key", Value: "silver", Requirements: []Requirement{gold}}, false, "key:silver AND key:gold"},
+		{"any held", Gate{Type: "key", Value: "gold", Requirements: []Requirement{silver}, Any: true}, true, "key:gold OR key:silver"},
+		{"any missing all", Gate{Requirements: []Requirement{gold}, Any: true}, false, "key:gold"},
+		{"empty", Gate{}, true, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.gate.Opens(has); got != tt.want {
+			t.Errorf("%s: Opens() = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := tt.gate.String(); got != tt.text {
+			t.Errorf("%s: String() = %q, want %q", tt.name, got, tt.text)
+		}
+	}
+}
+
+// Test ShortestPaths answers every pair the way GetPath does
+func TestShortestPaths(t *testing.T) {
+	g := createBenchGraph(60)
+	mustAddRoom(t, g, newTestRoom("R900", ArchetypeOptional))
+	oneWay := newTestConnector("X900", "R900", "R030")
+	oneWay.Bidirectional = false
+	mustAddConnector(t, g, oneWay)
+
+	paths := g.ShortestPaths()
+	for from := range g.Rooms {
+		for to := range g.Rooms {
+			want, wantErr := g.GetPath(from, to)
+			got, err := paths.Path(from, to)
+			if (err != nil) != (wantErr != nil) || fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("Path(%s, %s) = %v, %v; GetPath() = %v, %v", from, to, got, err, want, wantErr)
+			}
+
+			dist, ok := paths.Distance(from, to)
+			if ok != (wantErr == nil) || (ok && dist != len(want)-1) {
+				t.Fatalf("Distance(%s, %s) = %d, %v; want %d", from, to, dist, ok, len(want)-1)
+			}
+		}
+	}
+
+	if _, err := paths.Path("R000", "R999"); err == nil {
+		t.Error("Path() to unknown room should fail")
+	}
+	if _, ok := paths.Distance("R999", "R000"); ok {
+		t.Error("Distance() from unknown room should fail")
+	}
+
+	// A table taken after an edit sees the new connector
+	mustAddConnector(t, g, newTestConnector("X001", "R000", "R059"))
+	if dist, _ := paths.Distance("R000", "R059"); dist == 1 {
+		t.Error("Earlier table should not see later edits")
+	}
+	if dist, _ := g.ShortestPaths().Distance("R000", "R059"); dist != 1 {
+		t.Errorf("Distance(R000, R059) after edit = %d, want 1", dist)
+	}
+}
+
+// Test traversals see edits made directly to the graph maps
+func TestTraversalIndex_DirectEdits(t *testing.T) {
+	g := NewGraph(1)
+	mustAddRoom(t, g, newTestRoom("R001", ArchetypeStart))
+	mustAddRoom(t, g, newTestRoom("R002", ArchetypeBoss))
+	if _, err := g.GetPath("R001", "R002"); err == nil {
+		t.Fatal("Expected no path before connecting rooms")
+	}
+
+	// Adding entries to the maps is detected
+	g.Rooms["R003"] = newTestRoom("R003", ArchetypeHub)
+	g.Connectors["C001"] = newTestConnector("C001", "R001", "R003")
+	g.Adjacency["R001"] = []string{"R003"}
+	g.Adjacency["R003"] = []string{"R001", "R002"}
+	path, err := g.GetPath("R001", "R002")
+	if err != nil {
+		t.Fatalf("GetPath() after adding entries error = %v", err)
+	}
+	if fmt.Sprint(path) != "[R001 R003 R002]" {
+		t.Errorf("GetPath() = %v, want [R001 R003 R002]", path)
+	}
+
+	// Rewiring an adjacency list needs Reindex
+	g.Adjacency["R003"] = []string{"R001"}
+	g.Reindex()
+	if _, err := g.GetPath("R001", "R002"); err == nil {
+		t.Error("Expected no path after rewiring and Reindex")
+	}
+
+	// Connector and room fields are read when the traversal runs
+	g.Adjacency["R003"] = []string{"R001", "R002"}
+	g.Reindex()
+	g.Rooms["R003"].Archetype = ArchetypeSecret
+	if reachable := g.GetVisibleReachable("R001"); reachable["R003"] {
+		t.Error("GetVisibleReachable() entered a room that became secret")
+	}
+}
+
+// Test traversals agree on graphs built in different insertion orders
+func TestTraversalIndex_Deterministic(t *testing.T) {
+	build := func(reverse bool) *Graph {
+		g := NewGraph(1)
+		ids := []string{}
+		for i := 0; i < 30; i++ {
+			ids = append(ids, fmt.Sprintf("R%03d", i))
+		}
+		if reverse {
+			for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+				ids[i], ids[j] = ids[j], ids[i]
+			}
+		}
+		for _, id := range ids {
+			mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+		}
+		for i := 0; i < 30; i++ {
+			mustAddConnector(t, g, newTestConnector(fmt.Sprintf("C%03d", i), fmt.Sprintf("R%03d", i), fmt.Sprintf("R%03d", (i*7+3)%30)))
+		}
+		return g
+	}
+
+	first := build(false)
+	for i := 0; i < 5; i++ {
+		if got, want := fmt.Sprint(build(i%2 == 1).GetCycles()), fmt.Sprint(first.GetCycles()); got != want {
+			t.Fatalf("GetCycles() = %s, want %s", got, want)
+		}
+	}
+}
+
+// TestProperty_IncrementalConnectivity verifies that connectivity answers
+// kept up to date across additions match a graph built from scratch.
+func TestProperty_IncrementalConnectivity(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		g := NewGraph(1)
+		steps := rapid.IntRange(1, 60).Draw(t, "steps")
+		rooms := []string{}
+		for step := 0; step < steps; step++ {
+			if len(rooms) < 2 || rapid.Bool().Draw(t, fmt.Sprintf("room_%d", step)) {
+				// Random IDs so the connectivity root changes as rooms arrive
+				id := fmt.Sprintf("R%03d", rapid.IntRange(0, 999).Draw(t, fmt.Sprintf("id_%d", step)))
+				if _, exists := g.Rooms[id]; !exists {
+					_ = g.AddRoom(newTestRoom(id, ArchetypeOptional))
+					rooms = append(rooms, id)
+				}
+			} else {
+				from := rooms[rapid.IntRange(0, len(rooms)-1).Draw(t, fmt.Sprintf("from_%d", step))]
+				to := rooms[rapid.IntRange(0, len(rooms)-1).Draw(t, fmt.Sprintf("to_%d", step))]
+				conn := newTestConnector(fmt.Sprintf("C%03d", step), from, to)
+				conn.Bidirectional = rapid.Bool().Draw(t, fmt.Sprintf("bidi_%d", step))
+				_ = g.AddConnector(conn)
+			}
+
+			// Query between additions, as synthesis does
+			fresh := &Graph{Rooms: g.Rooms, Connectors: g.Connectors, Adjacency: g.Adjacency}
+			if g.IsConnected() != fresh.IsConnected() {
+				t.Fatalf("step %d: IsConnected() = %v, fresh graph says %v", step, g.IsConnected(), fresh.IsConnected())
+			}
+			if g.IsWeaklyConnected() != fresh.IsWeaklyConnected() {
+				t.Fatalf("step %d: IsWeaklyConnected() = %v, fresh graph says %v", step, g.IsWeaklyConnected(), fresh.IsWeaklyConnected())
+			}
+			from := rooms[rapid.IntRange(0, len(rooms)-1).Draw(t, fmt.Sprintf("query_%d", step))]
+			if got, want := fmt.Sprint(g.GetReachable(from)), fmt.Sprint(fresh.GetReachable(from)); got != want {
+				t.Fatalf("step %d: GetReachable(%s) = %s, fresh graph says %s", step, from, got, want)
+			}
+		}
+	})
+}
+
+// Test connectivity answers follow RemoveRoom and direct edits
+func TestConnectivity_RemovalsAndDirectEdits(t *testing.T) {
+	g := NewGraph(1)
+	for _, id := range []string{"R001", "R002", "R003"} {
+		mustAddRoom(t, g, newTestRoom(id, ArchetypeOptional))
+	}
+	mustAddConnector(t, g, newTestConnector("C001", "R001", "R002"))
+	if g.IsWeaklyConnected() {
+		t.Fatal("Expected R003 to be disconnected")
+	}
+
+	mustAddConnector(t, g, newTestConnector("C002", "R002", "R003"))
+	if !g.IsConnected() || !g.IsWeaklyConnected() {
+		t.Fatal("Expected connected graph after adding C002")
+	}
+
+	if err := g.RemoveRoom("R002"); err != nil {
+		t.Fatalf("RemoveRoom() error = %v", err)
+	}
+	if g.IsConnected() || g.IsWeaklyConnected() {
+		t.Error("Expected disconnected graph after removing R002")
+	}
+	if reachable := g.GetReachable("R001"); len(reachable) != 1 {
+		t.Errorf("GetReachable(R001) = %v, want only R001", reachable)
+	}
+
+	// Rewiring adjacency directly needs Reindex
+	g.Adjacency["R001"] = []string{"R003"}
+	g.Adjacency["R003"] = []string{"R001"}
+	g.Reindex()
+	if !g.IsConnected() {
+		t.Error("Expected connected graph after rewiring and Reindex")
+	}
+}
+
 // TestProperty_GraphConnectivity is a property-based test that verifies
 // any graph we generate must be fully connected (TDD: will fail until implementation)
 func TestProperty_GraphConnectivity(t *testing.T) {
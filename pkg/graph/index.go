@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// index is an immutable snapshot of a graph's topology that numbers rooms
+// 0..n-1, so traversals can use slices instead of string-keyed maps. Rooms
+// are numbered in sorted ID order, followed by any IDs that only appear in
+// Adjacency or as connector endpoints. Fields that may change after a room
+// or connector is added (archetype, type, visibility) are read through the
+// stored pointers when a traversal runs.
+type index struct {
+	ids   []string                  // Room ID by number
+	num   map[string]int            // Room number by ID
+	rooms []*Room                   // Room by number; nil for IDs missing from Rooms
+	adj   [][]int                   // Adjacency by number, in Adjacency list order
+	links [][]link                  // Connector traversals leaving each room, in connector ID order
+	edges [][2]int                  // Connector endpoints (From, To), in connector ID order
+	conns []*Connector              // Connectors, in ID order
+	rows  []atomic.Pointer[pathRow] // Shortest path rows by source, filled on demand
+
+	// Sizes of the maps the snapshot was built from, for staleness checks
+	roomCount, connCount, adjCount int
+}
+
+// link is a traversal of a connector from one room to another.
+type link struct {
+	to   int
+	conn *Connector
+}
+
+// buildIndex numbers the rooms of g and converts its adjacency lists and
+// connectors to slices.
+func buildIndex(g *Graph) *index {
+	idx := &index{
+		ids:       make([]string, 0, len(g.Rooms)),
+		num:       make(map[string]int, len(g.Rooms)),
+		roomCount: len(g.Rooms),
+		connCount: len(g.Connectors),
+		adjCount:  len(g.Adjacency),
+	}
+	for id := range g.Rooms {
+		idx.ids = append(idx.ids, id)
+	}
+	sort.Strings(idx.ids)
+
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+
+	// IDs outside Rooms are numbered after the rooms, in sorted order
+	extra := []string{}
+	known := make(map[string]bool, len(g.Rooms))
+	for _, id := range idx.ids {
+		known[id] = true
+	}
+	note := func(id string) {
+		if !known[id] {
+			known[id] = true
+			extra = append(extra, id)
+		}
+	}
+	for from, neighbors := range g.Adjacency {
+		note(from)
+		for _, to := range neighbors {
+			note(to)
+		}
+	}
+	for _, conn := range g.Connectors {
+		note(conn.From)
+		note(conn.To)
+	}
+	sort.Strings(extra)
+	idx.ids = append(idx.ids, extra...)
+
+	n := len(idx.ids)
+	idx.rooms = make([]*Room, n)
+	idx.rows = make([]atomic.Pointer[pathRow], n)
+	for i, id := range idx.ids {
+		idx.num[id] = i
+		idx.rooms[i] = g.Rooms[id]
+	}
+
+	idx.adj = make([][]int, n)
+	for i, id := range idx.ids {
+		for _, to := range g.Adjacency[id] {
+			idx.adj[i] = append(idx.adj[i], idx.num[to])
+		}
+	}
+
+	idx.links = make([][]link, n)
+	idx.edges = make([][2]int, 0, len(connIDs))
+	idx.conns = make([]*Connector, 0, len(connIDs))
+	for _, connID := range connIDs {
+		conn := g.Connectors[connID]
+		from, to := idx.num[conn.From], idx.num[conn.To]
+		idx.edges = append(idx.edges, [2]int{from, to})
+		idx.conns = append(idx.conns, conn)
+		idx.links[from] = append(idx.links[from], link{to: to, conn: conn})
+		if conn.Bidirectional {
+			idx.links[to] = append(idx.links[to], link{to: from, conn: conn})
+		}
+	}
+
+	return idx
+}
+
+// stale reports whether g has gained or lost rooms, connectors or adjacency
+// lists since the index was built.
+func (idx *index) stale(g *Graph) bool {
+	return idx.roomCount != len(g.Rooms) || idx.connCount != len(g.Connectors) || idx.adjCount != len(g.Adjacency)
+}
+
+// pathRow holds the result of a breadth-first search over Adjacency.
+type pathRow struct {
+	dist   []int32 // Hops from the nearest source by room number, -1 when unreached
+	parent []int32 // Room each room was first reached from, -1 for sources and unreached rooms
+}
+
+// search runs a breadth-first search from the given rooms at once, visiting
+// neighbours in Adjacency list order.
+func (idx *index) search(sources []int) *pathRow {
+	row := &pathRow{
+		dist:   make([]int32, len(idx.ids)),
+		parent: make([]int32, len(idx.ids)),
+	}
+	for i := range row.dist {
+		row.dist[i] = -1
+		row.parent[i] = -1
+	}
+
+	queue := make([]int, 0, len(idx.ids))
+	for _, src := range sources {
+		if row.dist[src] == -1 {
+			row.dist[src] = 0
+			queue = append(queue, src)
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		current := queue[head]
+		for _, next := range idx.adj[current] {
+			if row.dist[next] == -1 {
+				row.dist[next] = row.dist[current] + 1
+				row.parent[next] = int32(current)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return row
+}
+
+// row returns the cached search from a single room, running it on first use.
+// Concurrent callers may each run the search; the rows are identical.
+func (idx *index) row(src int) *pathRow {
+	if row := idx.rows[src].Load(); row != nil {
+		return row
+	}
+	row := idx.search([]int{src})
+	idx.rows[src].Store(row)
+	return row
+}
@@ -0,0 +1,167 @@
+package graph
+
+import "fmt"
+
+// Distances returns the number of connector hops from the nearest of the
+// given rooms to every room reachable from any of them, respecting connector
+// direction. The given rooms are at distance 0. Unknown rooms are ignored.
+func (g *Graph) Distances(from []string) map[string]int {
+	distances := make(map[string]int)
+	idx := g.topology()
+
+	sources := make([]int, 0, len(from))
+	for _, id := range from {
+		if _, exists := g.Rooms[id]; exists {
+			sources = append(sources, idx.num[id])
+		}
+	}
+	if len(sources) == 0 {
+		return distances
+	}
+
+	for i, d := range idx.search(sources).dist {
+		if d >= 0 {
+			distances[idx.ids[i]] = int(d)
+		}
+	}
+	return distances
+}
+
+// GetPathAvoiding finds the shortest path between two rooms that neither
+// enters the avoided rooms nor crosses the avoided connectors, for questions
+// such as whether a key can be reached without passing its own lock.
+// Connector direction is respected and connectors are tried in ID order.
+// Returns the path including both endpoints, or an error if either room is
+// unknown or avoided, or no such path exists. Unknown IDs in the avoid lists
+// are ignored.
+func (g *Graph) GetPathAvoiding(from, to string, avoidRooms, avoidConnectors []string) ([]string, error) {
+	if _, exists := g.Rooms[from]; !exists {
+		return nil, fmt.Errorf("room %s does not exist", from)
+	}
+	if _, exists := g.Rooms[to]; !exists {
+		return nil, fmt.Errorf("room %s does not exist", to)
+	}
+
+	idx := g.topology()
+	blocked := make([]bool, len(idx.ids))
+	for _, id := range avoidRooms {
+		if n, ok := idx.num[id]; ok {
+			blocked[n] = true
+		}
+	}
+	src, dst := idx.num[from], idx.num[to]
+	if blocked[src] {
+		return nil, fmt.Errorf("room %s is avoided", from)
+	}
+	if blocked[dst] {
+		return nil, fmt.Errorf("room %s is avoided", to)
+	}
+	if from == to {
+		return []string{from}, nil
+	}
+
+	closed := make(map[string]bool, len(avoidConnectors))
+	for _, id := range avoidConnectors {
+		closed[id] = true
+	}
+
+	// BFS over connector traversals, recording each room's parent
+	parent := make([]int, len(idx.ids))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[src] = src
+	queue := make([]int, 1, len(idx.ids))
+	queue[0] = src
+	for head := 0; head < len(queue) && parent[dst] == -1; head++ {
+		current := queue[head]
+		for _, l := range idx.links[current] {
+			if parent[l.to] != -1 || blocked[l.to] || closed[l.conn.ID] {
+				continue
+			}
+			parent[l.to] = current
+			queue = append(queue, l.to)
+		}
+	}
+	if parent[dst] == -1 {
+		return nil, fmt.Errorf("no path exists from %s to %s avoiding %d rooms and %d connectors",
+			from, to, len(avoidRooms), len(avoidConnectors))
+	}
+
+	path := []string{}
+	for node := dst; node != src; node = parent[node] {
+		path = append(path, idx.ids[node])
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// ShortestPaths answers shortest path and distance queries between the rooms
+// of a graph as it was when ShortestPaths was called. Each source room is
+// searched once, on its first query, and the result is cached with the
+// graph's traversal index, so repeated queries from the same rooms are table
+// lookups until the graph changes. Paths are the ones GetPath returns.
+//
+// Rows cost two ints per room for every source queried, so querying every
+// pair of a large graph holds a full all-pairs table in memory.
+type ShortestPaths struct {
+	idx *index
+}
+
+// ShortestPaths returns the cached shortest path table of the graph.
+func (g *Graph) ShortestPaths() *ShortestPaths {
+	return &ShortestPaths{idx: g.topology()}
+}
+
+// room returns the number of a room, or false for IDs that are not rooms.
+func (sp *ShortestPaths) room(id string) (int, bool) {
+	n, ok := sp.idx.num[id]
+	if !ok || sp.idx.rooms[n] == nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Distance returns the number of connector hops on the shortest path between
+// two rooms, and false if either room is unknown or to is unreachable.
+func (sp *ShortestPaths) Distance(from, to string) (int, bool) {
+	src, ok := sp.room(from)
+	if !ok {
+		return 0, false
+	}
+	dst, ok := sp.room(to)
+	if !ok {
+		return 0, false
+	}
+
+	d := sp.idx.row(src).dist[dst]
+	return int(d), d >= 0
+}
+
+// Path returns the shortest path between two rooms, including both
+// endpoints. Returns an error if either room is unknown or no path exists.
+func (sp *ShortestPaths) Path(from, to string) ([]string, error) {
+	src, ok := sp.room(from)
+	if !ok {
+		return nil, fmt.Errorf("room %s does not exist", from)
+	}
+	dst, ok := sp.room(to)
+	if !ok {
+		return nil, fmt.Errorf("room %s does not exist", to)
+	}
+
+	row := sp.idx.row(src)
+	if row.dist[dst] < 0 {
+		return nil, fmt.Errorf("no path exists from %s to %s", from, to)
+	}
+
+	path := make([]string, row.dist[dst]+1)
+	for node, i := dst, len(path)-1; i >= 0; i-- {
+		path[i] = sp.idx.ids[node]
+		node = int(row.parent[node])
+	}
+	return path, nil
+}
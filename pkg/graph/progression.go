@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SmallKey is the capability and gate type of small keys, consumable keys
+// where every key is used up by the door it opens so the number held
+// matters. Each {Type: SmallKey, Value: pool} entry in a room's Provides is
+// one key of that pool, and a connector gated {Type: SmallKey, Value: pool}
+// takes one key of the pool to open and then stays open.
+const SmallKey = "small_key"
+
+// maxSoftLockStates bounds the sets of opened doors FindSoftLock explores.
+const maxSoftLockStates = 1 << 16
+
+// ReachableWithInventory explores the graph from a room the way a player
+// collecting keys and abilities would: starting with nothing, it enters every
+// room it can reach, picks up what those rooms provide, and searches again
+// until nothing new is collected. A connector with a gate is crossed only
+// once the gate opens for the capabilities held (see Gate.Opens), and a room
+// with requirements is entered only once all of them are held; the starting
+// room is always entered. Connector direction is respected. Small keys are
+// treated as never used up; FindSoftLock checks that they suffice.
+//
+// Returns the rooms reached and the capabilities collected. Rooms the
+// exploration cannot reach are locked behind capabilities that are never
+// obtainable first, such as a key behind its own lock.
+func (g *Graph) ReachableWithInventory(from string) (reached map[string]bool, inventory map[Capability]bool) {
+	reached = make(map[string]bool)
+	if _, exists := g.Rooms[from]; !exists {
+		return reached, make(map[Capability]bool)
+	}
+
+	idx := g.topology()
+	nodes, inventory := explore(idx, idx.num[from], nil, nil)
+	for _, n := range nodes {
+		reached[idx.ids[n]] = true
+	}
+	return reached, inventory
+}
+
+// SoftLock is a dead end found by FindSoftLock.
+type SoftLock struct {
+	Opened  []string // Small-key doors opened before getting stuck, connector IDs sorted
+	Reached []string // Rooms reachable once stuck, sorted
+}
+
+// FindSoftLock checks that no way of spending small keys (see SmallKey)
+// strands a player who starts at from before they reach goal, for dungeons
+// where a key spent on the wrong door can leave too few for the right one.
+// It returns the first dead end found, or nil when every order of opening
+// doors still reaches goal. It fails if either room is missing or there are
+// too many combinations of doors to check.
+//
+// Algorithm:
+//  1. Number the small-key doors and start with none opened
+//  2. For a set of opened doors, find the reachable rooms the way
+//     ReachableWithInventory does, passing opened doors and stopping at
+//     closed ones
+//  3. Count each pool's keys in those rooms, less the pool's opened doors
+//  4. If goal is unreachable and no closed door can be approached with a key
+//     of its pool in hand, the set is a dead end
+//  5. Otherwise open each such door in turn and repeat from step 2, visiting
+//     every set of opened doors once
+func (g *Graph) FindSoftLock(from, goal string) (*SoftLock, error) {
+	if _, exists := g.Rooms[from]; !exists {
+		return nil, fmt.Errorf("room %s not found", from)
+	}
+	if _, exists := g.Rooms[goal]; !exists {
+		return nil, fmt.Errorf("room %s not found", goal)
+	}
+
+	idx := g.topology()
+	src, dst := idx.num[from], idx.num[goal]
+	door := make(map[*Connector]int)
+	var doors []*Connector
+	for _, conn := range idx.conns {
+		if conn.Gate != nil && conn.Gate.Type == SmallKey {
+			door[conn] = len(doors)
+			doors = append(doors, conn)
+		}
+	}
+
+	reached := make([]bool, len(idx.ids))
+	seen := make(map[string]bool)
+	stack := [][]bool{make([]bool, len(doors))}
+	for len(stack) > 0 {
+		opened := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		nodes, _ := explore(idx, src, door, opened)
+		clear(reached)
+		keys := make(map[string]int)
+		for _, n := range nodes {
+			reached[n] = true
+			for _, c := range idx.rooms[n].Provides {
+				if c.Type == SmallKey {
+					keys[c.Value]++
+				}
+			}
+		}
+		if reached[dst] {
+			continue
+		}
+		for d, conn := range doors {
+			if opened[d] {
+				keys[conn.Gate.Value]--
+			}
+		}
+
+		var next []int
+		for d, conn := range doors {
+			approached := reached[idx.num[conn.From]] || (conn.Bidirectional && reached[idx.num[conn.To]])
+			if !opened[d] && approached && keys[conn.Gate.Value] > 0 {
+				next = append(next, d)
+			}
+		}
+		if len(next) == 0 {
+			lock := &SoftLock{Opened: []string{}, Reached: []string{}}
+			for d, conn := range doors {
+				if opened[d] {
+					lock.Opened = append(lock.Opened, conn.ID)
+				}
+			}
+			for _, n := range nodes {
+				lock.Reached = append(lock.Reached, idx.ids[n])
+			}
+			sort.Strings(lock.Reached)
+			return lock, nil
+		}
+
+		// Push in reverse so doors are tried in connector ID order
+		for i := len(next) - 1; i >= 0; i-- {
+			child := append([]bool(nil), opened...)
+			child[next[i]] = true
+			key := fmt.Sprint(child)
+			if seen[key] {
+				continue
+			}
+			if len(seen) >= maxSoftLockStates {
+				return nil, fmt.Errorf("more than %d combinations of small-key doors to check", maxSoftLockStates)
+			}
+			seen[key] = true
+			stack = append(stack, child)
+		}
+	}
+	return nil, nil
+}
+
+// explore runs the search behind ReachableWithInventory from room src of
+// idx and returns the rooms reached, in the order of the last pass, and the
+// capabilities collected. door numbers the small-key doors, which are passed
+// only when opened; with door nil, small-key gates open like any other.
+func explore(idx *index, src int, door map[*Connector]int, opened []bool) ([]int, map[Capability]bool) {
+	inventory := make(map[Capability]bool)
+	has := func(capType, value string) bool {
+		return inventory[Capability{Type: capType, Value: value}]
+	}
+	passable := func(conn *Connector) bool {
+		if conn.Gate == nil {
+			return true
+		}
+		if d, ok := door[conn]; ok {
+			return opened[d]
+		}
+		return conn.Gate.Opens(has)
+	}
+	enterable := func(n int) bool {
+		room := idx.rooms[n]
+		if room == nil {
+			return false
+		}
+		for _, req := range room.Requirements {
+			if !has(req.Type, req.Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Each pass is a BFS with the inventory held so far; rooms found in
+	// earlier passes are found again, so only the last pass's set is kept
+	seen := make([]bool, len(idx.ids))
+	queue := make([]int, 0, len(idx.ids))
+	for {
+		clear(seen)
+		seen[src] = true
+		queue = append(queue[:0], src)
+		for head := 0; head < len(queue); head++ {
+			for _, l := range idx.links[queue[head]] {
+				if seen[l.to] || !passable(l.conn) || !enterable(l.to) {
+					continue
+				}
+				seen[l.to] = true
+				queue = append(queue, l.to)
+			}
+		}
+
+		collected := false
+		for _, n := range queue {
+			for _, c := range idx.rooms[n].Provides {
+				if !inventory[c] {
+					inventory[c] = true
+					collected = true
+				}
+			}
+		}
+		if !collected {
+			return queue, inventory
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query is a compiled room filter, see ParseQuery.
+type Query struct {
+	expr  string
+	match predicate
+}
+
+// predicate reports whether a room of g matches part of a query.
+type predicate func(g *Graph, room *Room) bool
+
+// ParseQuery compiles a room query such as
+//
+//	archetype:treasure AND tag:zone=crypt AND difficulty>0.7
+//
+// Terms are combined with AND, OR and NOT (any case) and grouped with
+// parentheses; AND binds tighter than OR. Terms contain no spaces:
+//
+//	archetype:NAME       room archetype, e.g. archetype:boss (any case)
+//	size:NAME            room size, e.g. size:XL (any case)
+//	id:ID                room ID
+//	tag:KEY              room has the tag
+//	tag:KEY=VALUE        tag equals VALUE; also != and, for numeric tags, < <= > >=
+//	provides:TYPE[=VAL]  room provides a capability, e.g. provides:key=silver_key
+//	requires:TYPE[=VAL]  room has a requirement, e.g. requires:ability
+//	difficulty OP N      room difficulty, OP one of = != < <= > >= (: means =)
+//	reward OP N          room reward
+//	degree OP N          number of rooms reachable in one step
+//
+// archetype, size and id also accept = and !=.
+func ParseQuery(expr string) (*Query, error) {
+	p := &queryParser{tokens: tokenizeQuery(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("query %q: empty query", expr)
+	}
+
+	match, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", expr, err)
+	}
+	return &Query{expr: expr, match: match}, nil
+}
+
+// Query returns the rooms matching a query expression (see ParseQuery),
+// sorted by ID.
+func (g *Graph) Query(expr string) ([]*Room, error) {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Rooms(g), nil
+}
+
+// Match reports whether a room of g matches the query. g is only consulted
+// for degree terms.
+func (q *Query) Match(g *Graph, room *Room) bool {
+	return q.match(g, room)
+}
+
+// Rooms returns the rooms of g matching the query, sorted by ID.
+func (q *Query) Rooms(g *Graph) []*Room {
+	ids := make([]string, 0, len(g.Rooms))
+	for id, room := range g.Rooms {
+		if q.match(g, room) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	rooms := make([]*Room, len(ids))
+	for i, id := range ids {
+		rooms[i] = g.Rooms[id]
+	}
+	return rooms
+}
+
+// String returns the query expression.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// tokenizeQuery splits a query into parentheses and space-separated words.
+func tokenizeQuery(expr string) []string {
+	var tokens []string
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, expr[start:end])
+			start = -1
+		}
+	}
+	for i, ch := range expr {
+		switch {
+		case ch == '(' || ch == ')':
+			flush(i)
+			tokens = append(tokens, string(ch))
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			flush(i)
+		case start < 0:
+			start = i
+		}
+	}
+	flush(len(expr))
+	return tokens
+}
+
+// queryParser is a recursive descent parser over query tokens.
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+// keyword consumes the next token if it is the given keyword.
+func (p *queryParser) keyword(word string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		a := left
+		left = func(g *Graph, room *Room) bool { return a(g, room) || right(g, room) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		a := left
+		left = func(g *Graph, room *Room) bool { return a(g, room) && right(g, room) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (predicate, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	switch {
+	case p.keyword("NOT"):
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(g *Graph, room *Room) bool { return !inner(g, room) }, nil
+
+	case p.keyword("("):
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+
+	token := p.tokens[p.pos]
+	switch strings.ToUpper(token) {
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	p.pos++
+	return parseQueryTerm(token)
+}
+
+// queryOps are the comparison operators, longest first so "<=" wins over "<".
+var queryOps = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+// splitQueryOp splits a term at its first operator into the text before it,
+// the operator and the text after it.
+func splitQueryOp(term string) (left, op, right string, ok bool) {
+	i := strings.IndexAny(term, ":=!<>")
+	if i < 0 {
+		return term, "", "", false
+	}
+	for _, op := range queryOps {
+		if strings.HasPrefix(term[i:], op) {
+			return term[:i], op, term[i+len(op):], true
+		}
+	}
+	return term, "", "", false
+}
+
+// parseQueryTerm compiles a single term.
+func parseQueryTerm(term string) (predicate, error) {
+	field, op, value, ok := splitQueryOp(term)
+	if !ok || field == "" || value == "" {
+		return nil, fmt.Errorf("malformed term %q", term)
+	}
+
+	switch strings.ToLower(field) {
+	case "archetype":
+		archetype, ok := ParseArchetype(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown archetype %q", value)
+		}
+		return equalityTerm(term, op, func(room *Room) bool { return room.Archetype == archetype })
+
+	case "size":
+		size, ok := ParseSize(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown size %q", value)
+		}
+		return equalityTerm(term, op, func(room *Room) bool { return room.Size == size })
+
+	case "id":
+		return equalityTerm(term, op, func(room *Room) bool { return room.ID == value })
+
+	case "tag":
+		if op != ":" {
+			return nil, fmt.Errorf("term %q: tag terms use tag:KEY", term)
+		}
+		return parseTagTerm(value)
+
+	case "provides", "requires":
+		if op != ":" {
+			return nil, fmt.Errorf("term %q: %s terms use %s:TYPE", term, field, field)
+		}
+		typ, want, hasValue := strings.Cut(value, "=")
+		provides := strings.EqualFold(field, "provides")
+		return func(_ *Graph, room *Room) bool {
+			if provides {
+				for _, c := range room.Provides {
+					if c.Type == typ && (!hasValue || c.Value == want) {
+						return true
+					}
+				}
+				return false
+			}
+			for _, r := range room.Requirements {
+				if r.Type == typ && (!hasValue || r.Value == want) {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "difficulty", "reward", "degree":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("term %q: %q is not a number", term, value)
+		}
+		var get func(g *Graph, room *Room) float64
+		switch strings.ToLower(field) {
+		case "difficulty":
+			get = func(_ *Graph, room *Room) float64 { return room.Difficulty }
+		case "reward":
+			get = func(_ *Graph, room *Room) float64 { return room.Reward }
+		default:
+			get = func(g *Graph, room *Room) float64 { return float64(len(g.Adjacency[room.ID])) }
+		}
+		return func(g *Graph, room *Room) bool { return compareQuery(get(g, room), op, n) }, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+// parseTagTerm compiles the KEY[OP VALUE] part of a tag term.
+func parseTagTerm(spec string) (predicate, error) {
+	key, op, want, hasOp := splitQueryOp(spec)
+	if key == "" || (hasOp && (op == ":" || want == "")) {
+		return nil, fmt.Errorf("malformed tag term %q", "tag:"+spec)
+	}
+
+	switch op {
+	case "":
+		return func(_ *Graph, room *Room) bool {
+			_, ok := room.Tags[key]
+			return ok
+		}, nil
+	case "=":
+		return func(_ *Graph, room *Room) bool {
+			v, ok := room.Tags[key]
+			return ok && v == want
+		}, nil
+	case "!=":
+		return func(_ *Graph, room *Room) bool { return room.Tags[key] != want }, nil
+	}
+
+	// Ordered comparisons need numbers; rooms whose tag is not one never match
+	n, err := strconv.ParseFloat(want, 64)
+	if err != nil {
+		return nil, fmt.Errorf("term %q: %q is not a number", "tag:"+spec, want)
+	}
+	return func(_ *Graph, room *Room) bool {
+		v, err := strconv.ParseFloat(room.Tags[key], 64)
+		return err == nil && compareQuery(v, op, n)
+	}, nil
+}
+
+// equalityTerm builds a predicate for fields that only support equality.
+func equalityTerm(term, op string, equal func(room *Room) bool) (predicate, error) {
+	switch op {
+	case ":", "=":
+		return func(_ *Graph, room *Room) bool { return equal(room) }, nil
+	case "!=":
+		return func(_ *Graph, room *Room) bool { return !equal(room) }, nil
+	}
+	return nil, fmt.Errorf("term %q: operator %s is not supported", term, op)
+}
+
+// compareQuery applies a comparison operator, with ":" meaning "=".
+func compareQuery(a float64, op string, b float64) bool {
+	switch op {
+	case ":", "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// ParseArchetype looks up an archetype by name, ignoring case.
+func ParseArchetype(name string) (RoomArchetype, bool) {
+	for a := ArchetypeStart; a <= ArchetypeExit; a++ {
+		if strings.EqualFold(a.String(), name) {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+// ParseSize looks up a room size by name, ignoring case.
+func ParseSize(name string) (RoomSize, bool) {
+	for s := SizeXS; s <= SizeXL; s++ {
+		if strings.EqualFold(s.String(), name) {
+			return s, true
+		}
+	}
+	return 0, false
+}
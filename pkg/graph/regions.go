@@ -0,0 +1,165 @@
+package graph
+
+// Region is a part of the dungeon bounded by gates and chokepoints, as found
+// by Regions.
+type Region struct {
+	ID    int      // Position in the Regions result
+	Rooms []string // Room IDs, sorted
+	Exits []string // IDs of the boundary connectors leading to other regions, sorted
+	Depth int      // Boundaries crossed from the Start room's region (region 0 without one); -1 if unreachable
+}
+
+// RegionOptions configures Regions.
+type RegionOptions struct {
+	// MinRooms is the fewest rooms a chokepoint may split off. Bridges with
+	// fewer rooms on one side, such as the connector to a dead-end room, stay
+	// inside a region. 0 splits at every bridge.
+	MinRooms int
+}
+
+// Regions decomposes the graph into regions separated by gates and
+// chokepoints, for theming zones, scaffolding quests around them and drawing
+// clusters. Connectors are treated as undirected.
+//
+// Algorithm:
+//  1. Find the bridges: connectors whose removal disconnects rooms
+//  2. Bound regions by gated connectors and by bridges with at least
+//     opts.MinRooms rooms on each side
+//  3. Take the rooms still connected without crossing a boundary as a region;
+//     a gate on a loop only bounds a region if the rest of the loop is cut too
+//  4. Number regions by their first room ID and measure each one's depth from
+//     the Start room's region, or region 0 without one, over the boundaries
+func (g *Graph) Regions(opts RegionOptions) []Region {
+	idx := g.topology()
+	rooms := len(g.Rooms)
+
+	type half struct{ to, edge int }
+	adj := make([][]half, rooms)
+	for e, edge := range idx.edges {
+		a, b := edge[0], edge[1]
+		if a >= rooms || b >= rooms || a == b {
+			continue
+		}
+		adj[a] = append(adj[a], half{to: b, edge: e})
+		adj[b] = append(adj[b], half{to: a, edge: e})
+	}
+
+	// Step 1-2: gates bound regions outright; bridges once the size of both
+	// sides is known, after the search of their component finishes
+	boundary := make([]bool, len(idx.edges))
+	for e, conn := range idx.conns {
+		if conn.Gate != nil {
+			boundary[e] = true
+		}
+	}
+
+	type bridge struct{ edge, child int }
+	var bridges []bridge
+	disc := make([]int, rooms) // Discovery time, 0 while unvisited
+	low := make([]int, rooms)  // Earliest discovery time reachable through one back edge
+	size := make([]int, rooms) // Rooms in the search subtree
+	time := 0
+
+	var visit func(node, via int)
+	visit = func(node, via int) {
+		time++
+		disc[node], low[node], size[node] = time, time, 1
+		for _, h := range adj[node] {
+			if h.edge == via {
+				continue
+			}
+			if disc[h.to] == 0 {
+				visit(h.to, h.edge)
+				size[node] += size[h.to]
+				if low[h.to] < low[node] {
+					low[node] = low[h.to]
+				}
+				if low[h.to] > disc[node] {
+					bridges = append(bridges, bridge{edge: h.edge, child: h.to})
+				}
+			} else if disc[h.to] < low[node] {
+				low[node] = disc[h.to]
+			}
+		}
+	}
+
+	for root := 0; root < rooms; root++ {
+		if disc[root] != 0 {
+			continue
+		}
+		bridges = bridges[:0]
+		visit(root, -1)
+		for _, b := range bridges {
+			if size[b.child] >= opts.MinRooms && size[root]-size[b.child] >= opts.MinRooms {
+				boundary[b.edge] = true
+			}
+		}
+	}
+
+	// Step 3: flood fill without crossing boundaries
+	region := make([]int, rooms)
+	for i := range region {
+		region[i] = -1
+	}
+	regions := []Region{}
+	queue := make([]int, 0, rooms)
+	for first := 0; first < rooms; first++ {
+		if region[first] != -1 {
+			continue
+		}
+		id := len(regions)
+		region[first] = id
+		queue = append(queue[:0], first)
+		for head := 0; head < len(queue); head++ {
+			for _, h := range adj[queue[head]] {
+				if !boundary[h.edge] && region[h.to] == -1 {
+					region[h.to] = id
+					queue = append(queue, h.to)
+				}
+			}
+		}
+		regions = append(regions, Region{ID: id, Exits: []string{}, Depth: -1})
+	}
+	for node := 0; node < rooms; node++ {
+		r := &regions[region[node]]
+		r.Rooms = append(r.Rooms, idx.ids[node])
+	}
+
+	// Step 4: exits and depth over the boundaries between regions
+	links := make([][]int, len(regions))
+	for e, edge := range idx.edges {
+		a, b := edge[0], edge[1]
+		if !boundary[e] || a >= rooms || b >= rooms || region[a] == region[b] {
+			continue
+		}
+		ra, rb := region[a], region[b]
+		regions[ra].Exits = append(regions[ra].Exits, idx.conns[e].ID)
+		regions[rb].Exits = append(regions[rb].Exits, idx.conns[e].ID)
+		links[ra] = append(links[ra], rb)
+		links[rb] = append(links[rb], ra)
+	}
+
+	if len(regions) == 0 {
+		return regions
+	}
+	start := 0
+	for node := 0; node < rooms; node++ {
+		if idx.rooms[node].Archetype == ArchetypeStart {
+			start = region[node]
+			break
+		}
+	}
+	regions[start].Depth = 0
+	queue = append(queue[:0], start)
+	for head := 0; head < len(queue); head++ {
+		current := queue[head]
+		for _, next := range links[current] {
+			if regions[next].Depth == -1 {
+				regions[next].Depth = regions[current].Depth + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return regions
+}
@@ -17,6 +17,7 @@ const (
 	ArchetypeVendor
 	ArchetypeShrine
 	ArchetypeCheckpoint
+	ArchetypeExit
 )
 
 // String returns the string representation of a RoomArchetype.
@@ -44,6 +45,8 @@ func (r RoomArchetype) String() string {
 		return "Shrine"
 	case ArchetypeCheckpoint:
 		return "Checkpoint"
+	case ArchetypeExit:
+		return "Exit"
 	default:
 		return fmt.Sprintf("Unknown(%d)", r)
 	}
@@ -78,6 +81,27 @@ func (s RoomSize) String() string {
 	}
 }
 
+// FootprintTag is the room tag naming the shape of the room's footprint.
+// Rooms without it are squares of their size class.
+const FootprintTag = "footprint"
+
+// FootprintHall is a long footprint as wide as its size class and half as
+// deep. Embedding chooses its rotation and carving stamps it rotated.
+const FootprintHall = "hall"
+
+// JunctionTag marks, with the value "true", the corridor rooms carving adds
+// where three or more corridors meet.
+const JunctionTag = "junction"
+
+// VaultTag names, on the rooms of a treasure vault, the vault they belong
+// to. Vaults are optional dead ends behind layered key locks.
+const VaultTag = "vault"
+
+// Footprint returns the room's footprint shape, "" for a square.
+func (r *Room) Footprint() string {
+	return r.Tags[FootprintTag]
+}
+
 // Requirement represents a prerequisite to enter a room.
 type Requirement struct {
 	Type  string `json:"type"`  // "key", "ability", "item"
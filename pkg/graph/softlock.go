@@ -0,0 +1,350 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxTraceStates bounds the player states TraceSoftLocks explores.
+const maxTraceStates = 1 << 18
+
+// SoftLockTrace is a walk after which a player can never reach the goal, as
+// found by TraceSoftLocks.
+type SoftLockTrace struct {
+	Rooms      []string // Rooms walked through, from the start to where the player is stuck
+	Connectors []string // Connectors taken between consecutive Rooms
+	Opened     []string // Small-key doors opened on the way, in order
+	Trapped    []string // Rooms the player can still reach afterwards, sorted
+	Blocked    []string // Connectors out of Trapped that stay shut, sorted; empty when only one-way connectors lead in
+}
+
+// playerState is a node of the TraceSoftLocks search: where the player is and
+// what they have collected and opened on the way.
+type playerState struct {
+	room      int
+	inventory map[Capability]bool // Capabilities held, small keys excluded
+	taken     []bool              // Small-key rooms emptied, by position in the search's list
+	opened    []bool              // Small-key doors opened, by position in the search's list
+	parent    int                 // State moved from, -1 for the first
+	via       *Connector          // Connector taken from parent
+	next      []int               // States one move away
+}
+
+// TraceSoftLocks searches for walks from one room after which a player can
+// never reach goal: dropping through a one-way connector into rooms with no
+// way out, spending small keys (see SmallKey) on the wrong doors, or getting
+// shut in behind a gate whose key lies outside. Unlike
+// ReachableWithInventory, which assumes the player always chooses well, it
+// follows every choice a player can make.
+//
+// One trace is returned per fatal connector, the move that first made goal
+// unreachable, with the shortest walk to it; traces are ordered by walk
+// length, with connectors tried in ID order at each step. It returns an empty
+// slice when goal stays reachable whatever the player does, and fails if
+// either room is missing or the search exceeds its state bound.
+//
+// Algorithm:
+//  1. Search every state (room, capabilities held, small-key rooms emptied,
+//     small-key doors opened) reachable from the first room, stopping at goal
+//  2. Mark the states from which goal is still reachable, working back from
+//     the states at goal
+//  3. Report each unmarked state entered from a marked one: the walk to it,
+//     the rooms it can still reach and the shut connectors leading out
+func (g *Graph) TraceSoftLocks(from, goal string) ([]SoftLockTrace, error) {
+	if _, exists := g.Rooms[from]; !exists {
+		return nil, fmt.Errorf("room %s not found", from)
+	}
+	if _, exists := g.Rooms[goal]; !exists {
+		return nil, fmt.Errorf("room %s not found", goal)
+	}
+
+	idx := g.topology()
+	dst := idx.num[goal]
+
+	// Small-key doors and the rooms holding small keys
+	door := make(map[*Connector]int)
+	var doors []*Connector
+	for _, conn := range idx.conns {
+		if conn.Gate != nil && conn.Gate.Type == SmallKey {
+			door[conn] = len(doors)
+			doors = append(doors, conn)
+		}
+	}
+	keyRoom := make(map[int]int)
+	var keyRooms []int
+	for n, room := range idx.rooms {
+		if room == nil {
+			continue
+		}
+		for _, c := range room.Provides {
+			if c.Type == SmallKey {
+				keyRoom[n] = len(keyRooms)
+				keyRooms = append(keyRooms, n)
+				break
+			}
+		}
+	}
+
+	states := playerStates{}
+	seen := make(map[string]int)
+	add := func(s *playerState) (int, error) {
+		key := s.key()
+		if i, ok := seen[key]; ok {
+			return i, nil
+		}
+		if len(states) >= maxTraceStates {
+			return 0, fmt.Errorf("more than %d player states to check", maxTraceStates)
+		}
+		seen[key] = len(states)
+		states = append(states, s)
+		return len(states) - 1, nil
+	}
+	enter := func(s *playerState, n int) {
+		s.room = n
+		for _, c := range idx.rooms[n].Provides {
+			if c.Type != SmallKey {
+				s.inventory[c] = true
+			}
+		}
+		if k, ok := keyRoom[n]; ok {
+			s.taken[k] = true
+		}
+	}
+
+	first := &playerState{
+		inventory: make(map[Capability]bool),
+		taken:     make([]bool, len(keyRooms)),
+		opened:    make([]bool, len(doors)),
+		parent:    -1,
+	}
+	enter(first, idx.num[from])
+	if _, err := add(first); err != nil {
+		return nil, err
+	}
+
+	// Step 1: breadth-first over player states
+	for i := 0; i < len(states); i++ {
+		s := states[i]
+		if s.room == dst {
+			continue
+		}
+		keys := s.smallKeys(idx, keyRooms, doors)
+		for _, l := range idx.links[s.room] {
+			opens := -1
+			if !s.passable(l, door, keys, &opens) || !s.enterable(idx.rooms[l.to]) {
+				continue
+			}
+			child := s.move(i, l.conn)
+			if opens >= 0 {
+				child.opened[opens] = true
+			}
+			enter(child, l.to)
+			j, err := add(child)
+			if err != nil {
+				return nil, err
+			}
+			s.next = append(s.next, j)
+		}
+	}
+
+	// Step 2: mark the states that can still reach goal
+	prev := make([][]int, len(states))
+	for i, s := range states {
+		for _, j := range s.next {
+			prev[j] = append(prev[j], i)
+		}
+	}
+	live := make([]bool, len(states))
+	queue := []int{}
+	for i, s := range states {
+		if s.room == dst {
+			live[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for head := 0; head < len(queue); head++ {
+		for _, i := range prev[queue[head]] {
+			if !live[i] {
+				live[i] = true
+				queue = append(queue, i)
+			}
+		}
+	}
+
+	// Step 3: report the first state past each fatal connector
+	traces := []SoftLockTrace{}
+	fatal := make(map[*Connector]bool)
+	for i, s := range states {
+		if live[i] || (s.parent >= 0 && !live[s.parent]) || fatal[s.via] {
+			continue
+		}
+		if s.via != nil {
+			fatal[s.via] = true
+		}
+		traces = append(traces, states.trace(idx, i))
+	}
+	return traces, nil
+}
+
+// key identifies the state for deduplication.
+func (s *playerState) key() string {
+	caps := make([]string, 0, len(s.inventory))
+	for c := range s.inventory {
+		caps = append(caps, c.Type+":"+c.Value)
+	}
+	sort.Strings(caps)
+	return fmt.Sprint(s.room, s.taken, s.opened, caps)
+}
+
+// smallKeys returns the small keys in hand by pool: those in the emptied
+// rooms less the doors already opened.
+func (s *playerState) smallKeys(idx *index, keyRooms []int, doors []*Connector) map[string]int {
+	keys := make(map[string]int)
+	for k, n := range keyRooms {
+		if !s.taken[k] {
+			continue
+		}
+		for _, c := range idx.rooms[n].Provides {
+			if c.Type == SmallKey {
+				keys[c.Value]++
+			}
+		}
+	}
+	for d, conn := range doors {
+		if s.opened[d] {
+			keys[conn.Gate.Value]--
+		}
+	}
+	return keys
+}
+
+// passable reports whether the player can take a link. Crossing a closed
+// small-key door opens it, which is reported through opens.
+func (s *playerState) passable(l link, door map[*Connector]int, keys map[string]int, opens *int) bool {
+	gate := l.conn.Gate
+	if gate == nil {
+		return true
+	}
+	if d, ok := door[l.conn]; ok {
+		if s.opened[d] {
+			return true
+		}
+		if keys[gate.Value] > 0 {
+			*opens = d
+			return true
+		}
+		return false
+	}
+	return gate.Opens(func(capType, value string) bool {
+		return s.inventory[Capability{Type: capType, Value: value}]
+	})
+}
+
+// enterable reports whether the player meets a room's requirements.
+func (s *playerState) enterable(room *Room) bool {
+	if room == nil {
+		return false
+	}
+	for _, req := range room.Requirements {
+		if !s.inventory[Capability{Type: req.Type, Value: req.Value}] {
+			return false
+		}
+	}
+	return true
+}
+
+// move copies the state for a move from state parent through conn.
+func (s *playerState) move(parent int, conn *Connector) *playerState {
+	child := &playerState{
+		inventory: make(map[Capability]bool, len(s.inventory)),
+		taken:     append([]bool(nil), s.taken...),
+		opened:    append([]bool(nil), s.opened...),
+		parent:    parent,
+		via:       conn,
+	}
+	for c := range s.inventory {
+		child.inventory[c] = true
+	}
+	return child
+}
+
+// playerStates is the TraceSoftLocks search, indexed by state number.
+type playerStates []*playerState
+
+// trace describes the walk to a stuck state and what it is stuck in.
+func (states playerStates) trace(idx *index, stuck int) SoftLockTrace {
+	t := SoftLockTrace{Connectors: []string{}, Opened: []string{}, Trapped: []string{}, Blocked: []string{}}
+
+	var walk []*playerState
+	for i := stuck; i >= 0; i = states[i].parent {
+		walk = append(walk, states[i])
+	}
+	for i := len(walk) - 1; i >= 0; i-- {
+		s := walk[i]
+		t.Rooms = append(t.Rooms, idx.ids[s.room])
+		if s.via == nil {
+			continue
+		}
+		t.Connectors = append(t.Connectors, s.via.ID)
+		if s.via.Gate != nil && s.via.Gate.Type == SmallKey && !states[s.parent].openedVia(s) {
+			t.Opened = append(t.Opened, s.via.ID)
+		}
+	}
+
+	// Every state reachable from the stuck one is stuck too
+	trapped := make(map[int]bool)
+	seen := map[int]bool{stuck: true}
+	queue := []int{stuck}
+	for head := 0; head < len(queue); head++ {
+		s := states[queue[head]]
+		trapped[s.room] = true
+		for _, j := range s.next {
+			if !seen[j] {
+				seen[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+	blocked := make(map[string]bool)
+	for n := range trapped {
+		t.Trapped = append(t.Trapped, idx.ids[n])
+		for _, l := range idx.links[n] {
+			if !trapped[l.to] {
+				blocked[l.conn.ID] = true
+			}
+		}
+	}
+	for id := range blocked {
+		t.Blocked = append(t.Blocked, id)
+	}
+	sort.Strings(t.Trapped)
+	sort.Strings(t.Blocked)
+	return t
+}
+
+// openedVia reports whether the small-key door a child state came through was
+// already open in s.
+func (s *playerState) openedVia(child *playerState) bool {
+	for d := range s.opened {
+		if s.opened[d] != child.opened[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// String summarises the trace as the walk and where it ends.
+func (t SoftLockTrace) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.Rooms[0])
+	for i, conn := range t.Connectors {
+		fmt.Fprintf(&sb, " -%s-> %s", conn, t.Rooms[i+1])
+	}
+	if len(t.Blocked) > 0 {
+		fmt.Fprintf(&sb, " (shut: %s)", strings.Join(t.Blocked, ", "))
+	} else {
+		sb.WriteString(" (no way out)")
+	}
+	return sb.String()
+}
@@ -39,6 +39,19 @@
 //	    // spawn optional room
 //	}
 //
+// # Recording and Replay
+//
+// Record makes an RNG log every draw as the outcome it returned. NewReplayRNG
+// answers calls from such a log instead of a generator, so a stage can be
+// re-executed exactly even if the generator behind NewRNG changes. Err
+// reports a replay whose calls stopped matching the log:
+//
+//	var log rng.StageLog
+//	r := rng.NewRNG(masterSeed, "embedding", configHash[:])
+//	r.Record(&log)
+//	// ... run the stage with r ...
+//	replay := rng.NewReplayRNG(&log)
+//
 // # Thread Safety
 //
 // RNG instances are NOT thread-safe. Each goroutine should use its own RNG
@@ -0,0 +1,97 @@
+package rng
+
+import "fmt"
+
+// Op names the RNG primitive a draw answered. Derived methods (IntRange,
+// Float64Range, Bool, WeightedChoice) are recorded as the primitives they
+// call.
+type Op string
+
+const (
+	OpUint64  Op = "u" // Uint64, Value is the result
+	OpIntn    Op = "i" // Intn(N), Value is the result
+	OpFloat64 Op = "f" // Float64, Value is the IEEE 754 bits of the result
+	OpShuffle Op = "s" // One swap of Shuffle, Value is j of swap(N-1, j)
+)
+
+// Draw is one recorded random decision: the outcome the caller received,
+// not the generator state that produced it.
+type Draw struct {
+	Op    Op     `json:"o"`
+	N     int    `json:"n,omitempty"` // Bound of Intn and Shuffle draws
+	Value uint64 `json:"v"`
+}
+
+// StageLog is the sequence of draws one stage's RNG made.
+type StageLog struct {
+	Stage string `json:"stage"`
+	Seed  uint64 `json:"seed"` // Derived seed, reported by Seed() on replay
+	Draws []Draw `json:"draws"`
+}
+
+// Record makes the RNG append every draw to log, after resetting it to this
+// RNG's stage. Recording does not change the values drawn.
+func (r *RNG) Record(log *StageLog) {
+	log.Stage, log.Seed, log.Draws = r.stageName, r.seed, []Draw{}
+	r.record = log
+}
+
+// NewReplayRNG returns an RNG that answers every call from a recorded log
+// instead of a generator, so a stage re-executes exactly as recorded even if
+// the generator behind NewRNG changes. A call that does not match the next
+// recorded draw, or comes after the last one, makes Err report the
+// divergence; such calls return zero values from then on.
+func NewReplayRNG(log *StageLog) *RNG {
+	return &RNG{
+		seed:      log.Seed,
+		stageName: log.Stage,
+		replay:    &replayer{log: log},
+	}
+}
+
+// Err returns the first divergence from the recorded log of a replaying
+// RNG, or an error if draws are left over. It is always nil for other RNGs.
+func (r *RNG) Err() error {
+	if r.replay == nil {
+		return nil
+	}
+	if r.replay.err != nil {
+		return r.replay.err
+	}
+	if left := len(r.replay.log.Draws) - r.replay.pos; left > 0 {
+		return fmt.Errorf("stage %s: %d recorded draws were not replayed", r.stageName, left)
+	}
+	return nil
+}
+
+// log appends a draw while recording.
+func (r *RNG) log(op Op, n int, value uint64) {
+	if r.record != nil {
+		r.record.Draws = append(r.record.Draws, Draw{Op: op, N: n, Value: value})
+	}
+}
+
+// replayer reads draws back from a StageLog.
+type replayer struct {
+	log *StageLog
+	pos int
+	err error
+}
+
+// next returns the value of the next draw, which must answer op with bound n.
+func (p *replayer) next(op Op, n int) uint64 {
+	if p.err != nil {
+		return 0
+	}
+	if p.pos >= len(p.log.Draws) {
+		p.err = fmt.Errorf("stage %s: replay asked for draw %d, log has %d", p.log.Stage, p.pos+1, len(p.log.Draws))
+		return 0
+	}
+	d := p.log.Draws[p.pos]
+	if d.Op != op || d.N != n {
+		p.err = fmt.Errorf("stage %s: draw %d was %s(%d), replay asked for %s(%d)", p.log.Stage, p.pos+1, d.Op, d.N, op, n)
+		return 0
+	}
+	p.pos++
+	return d.Value
+}
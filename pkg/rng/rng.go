@@ -3,6 +3,7 @@ package rng
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"math"
 	"math/rand"
 )
 
@@ -20,6 +21,8 @@ type RNG struct {
 	seed      uint64
 	stageName string
 	source    *rand.Rand
+	record    *StageLog // Draws are appended here while recording
+	replay    *replayer // Draws are read from here instead of source
 }
 
 // NewRNG creates a stage-specific RNG by deriving a sub-seed from the master seed.
@@ -51,6 +54,14 @@ func NewRNG(masterSeed uint64, stageName string, configHash []byte) *RNG {
 	hash := h.Sum(nil)
 	derivedSeed := binary.BigEndian.Uint64(hash[:8])
 
+	return NewRNGFromSeed(derivedSeed, stageName)
+}
+
+// NewRNGFromSeed creates an RNG from a sub-seed NewRNG derived earlier, as
+// reported by Seed. It produces the same sequence as the RNG the seed was
+// derived for, so a single stage can be re-run without the master seed or
+// configuration it came from.
+func NewRNGFromSeed(derivedSeed uint64, stageName string) *RNG {
 	return &RNG{
 		seed:      derivedSeed,
 		stageName: stageName,
@@ -61,7 +72,12 @@ func NewRNG(masterSeed uint64, stageName string, configHash []byte) *RNG {
 // Uint64 returns a pseudo-random 64-bit unsigned integer.
 // The sequence is deterministic based on the RNG's seed.
 func (r *RNG) Uint64() uint64 {
-	return r.source.Uint64()
+	if r.replay != nil {
+		return r.replay.next(OpUint64, 0)
+	}
+	v := r.source.Uint64()
+	r.log(OpUint64, 0, v)
+	return v
 }
 
 // Intn returns a pseudo-random integer in [0, n).
@@ -70,18 +86,38 @@ func (r *RNG) Intn(n int) int {
 	if n <= 0 {
 		panic("rng: Intn argument must be positive")
 	}
-	return r.source.Intn(n)
+	if r.replay != nil {
+		return int(r.replay.next(OpIntn, n) % uint64(n))
+	}
+	v := r.source.Intn(n)
+	r.log(OpIntn, n, uint64(v))
+	return v
 }
 
 // Float64 returns a pseudo-random float64 in [0.0, 1.0).
 func (r *RNG) Float64() float64 {
-	return r.source.Float64()
+	if r.replay != nil {
+		return math.Float64frombits(r.replay.next(OpFloat64, 0))
+	}
+	v := r.source.Float64()
+	r.log(OpFloat64, 0, math.Float64bits(v))
+	return v
 }
 
 // Shuffle pseudo-randomizes the order of elements in slice.
 // The shuffle is deterministic based on the RNG's seed.
 func (r *RNG) Shuffle(n int, swap func(i, j int)) {
-	r.source.Shuffle(n, swap)
+	if r.replay != nil {
+		// Swaps run from the last element down, like rand.Shuffle
+		for i := n - 1; i > 0; i-- {
+			swap(i, int(r.replay.next(OpShuffle, i+1)%uint64(i+1)))
+		}
+		return
+	}
+	r.source.Shuffle(n, func(i, j int) {
+		r.log(OpShuffle, i+1, uint64(j))
+		swap(i, j)
+	})
 }
 
 // Seed returns the derived seed for this RNG.
@@ -105,7 +141,7 @@ func (r *RNG) IntRange(min, max int) int {
 	if min == max {
 		return min
 	}
-	return min + r.source.Intn(max-min+1)
+	return min + r.Intn(max-min+1)
 }
 
 // Float64Range returns a pseudo-random float64 in [min, max).
@@ -114,12 +150,12 @@ func (r *RNG) Float64Range(min, max float64) float64 {
 	if min >= max {
 		panic("rng: Float64Range min must be < max")
 	}
-	return min + r.source.Float64()*(max-min)
+	return min + r.Float64()*(max-min)
 }
 
 // Bool returns a pseudo-random boolean value.
 func (r *RNG) Bool() bool {
-	return r.source.Intn(2) == 1
+	return r.Intn(2) == 1
 }
 
 // WeightedChoice selects an index from weights using weighted random selection.
@@ -3,6 +3,8 @@ package rng
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -59,6 +61,23 @@ func TestNewRNG_SequenceDeterminism(t *testing.T) {
 	}
 }
 
+// TestNewRNGFromSeed verifies an RNG rebuilt from a derived seed continues
+// the exact sequence of the RNG the seed was derived for.
+func TestNewRNGFromSeed(t *testing.T) {
+	configHash := sha256.Sum256([]byte("config_v1"))
+	derived := NewRNG(987654321, "content", configHash[:])
+	rebuilt := NewRNGFromSeed(derived.Seed(), derived.StageName())
+
+	if rebuilt.Seed() != derived.Seed() || rebuilt.StageName() != "content" {
+		t.Fatalf("rebuilt RNG = (%d, %q), want (%d, %q)", rebuilt.Seed(), rebuilt.StageName(), derived.Seed(), "content")
+	}
+	for i := 0; i < 50; i++ {
+		if a, b := derived.Uint64(), rebuilt.Uint64(); a != b {
+			t.Fatalf("Position %d: sequences differ: %d vs %d", i, a, b)
+		}
+	}
+}
+
 // TestNewRNG_DifferentStages verifies different stage names produce different sequences.
 func TestNewRNG_DifferentStages(t *testing.T) {
 	masterSeed := uint64(123456789)
@@ -515,3 +534,71 @@ func BenchmarkRNG_Float64(b *testing.B) {
 		_ = rng.Float64()
 	}
 }
+
+// drawMixed makes one call of every kind, returning what it drew.
+func drawMixed(r *RNG) []interface{} {
+	slice := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	r.Shuffle(len(slice), func(i, j int) {
+		slice[i], slice[j] = slice[j], slice[i]
+	})
+	return []interface{}{
+		r.Uint64(), r.Intn(10), r.Float64(), r.IntRange(3, 9),
+		r.Float64Range(0.5, 1.5), r.Bool(), r.WeightedChoice([]float64{1, 2, 3}), slice,
+	}
+}
+
+// TestRNG_RecordReplay verifies a replay RNG answers every call exactly as
+// recorded, without a generator, and that recording does not change draws.
+func TestRNG_RecordReplay(t *testing.T) {
+	configHash := sha256.Sum256([]byte("config"))
+	plain := drawMixed(NewRNG(42, "stage", configHash[:]))
+
+	recording := NewRNG(42, "stage", configHash[:])
+	var log StageLog
+	recording.Record(&log)
+	recorded := drawMixed(recording)
+	if !reflect.DeepEqual(recorded, plain) {
+		t.Fatalf("recording changed draws: %v, want %v", recorded, plain)
+	}
+	if log.Stage != "stage" || log.Seed != recording.Seed() || len(log.Draws) != 14 {
+		t.Fatalf("log = %s seed %d with %d draws, want stage seed %d with 14 draws", log.Stage, log.Seed, len(log.Draws), recording.Seed())
+	}
+
+	replay := NewReplayRNG(&log)
+	if got := drawMixed(replay); !reflect.DeepEqual(got, plain) {
+		t.Errorf("replayed draws = %v, want %v", got, plain)
+	}
+	if replay.Seed() != recording.Seed() || replay.StageName() != "stage" {
+		t.Errorf("replay seed, stage = %d, %q", replay.Seed(), replay.StageName())
+	}
+	if err := replay.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+// TestRNG_ReplayDivergence verifies replays report calls that do not match
+// the log.
+func TestRNG_ReplayDivergence(t *testing.T) {
+	log := &StageLog{Stage: "stage", Draws: []Draw{{Op: OpIntn, N: 6, Value: 4}, {Op: OpFloat64, Value: 0}}}
+
+	r := NewReplayRNG(log)
+	if r.Intn(6) != 4 {
+		t.Fatal("Intn(6) did not return the recorded 4")
+	}
+	if err := r.Err(); err == nil {
+		t.Error("expected an error for a draw left over")
+	}
+
+	r = NewReplayRNG(log)
+	if r.Intn(5) != 0 || r.Err() == nil {
+		t.Error("expected a divergence for a different Intn bound")
+	}
+
+	r = NewReplayRNG(log)
+	r.Intn(6)
+	r.Float64()
+	r.Uint64()
+	if err := r.Err(); err == nil || !strings.Contains(err.Error(), "draw 3") {
+		t.Errorf("Err() = %v, want a divergence at draw 3", err)
+	}
+}
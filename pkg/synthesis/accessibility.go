@@ -0,0 +1,210 @@
+package synthesis
+
+import (
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// isCombatArchetype reports whether rooms of the archetype receive enemy
+// spawns. Mirrors the safe-zone rules of the content pass.
+func isCombatArchetype(a graph.RoomArchetype) bool {
+	switch a {
+	case graph.ArchetypeStart, graph.ArchetypeTreasure, graph.ArchetypeVendor,
+		graph.ArchetypeShrine, graph.ArchetypeCheckpoint, graph.ArchetypeExit:
+		return false
+	default:
+		return true
+	}
+}
+
+// criticalPath returns the Start→Boss path, or an error if either room is
+// missing or unreachable. The path comes from the graph's cached shortest
+// path table, so repeated calls between edits search once.
+func criticalPath(g *graph.Graph) ([]string, error) {
+	var startID, bossID string
+	for _, id := range getSortedRoomIDs(g) {
+		switch g.Rooms[id].Archetype {
+		case graph.ArchetypeStart:
+			startID = id
+		case graph.ArchetypeBoss:
+			bossID = id
+		}
+	}
+	if startID == "" || bossID == "" {
+		return nil, fmt.Errorf("missing Start or Boss room")
+	}
+	return g.ShortestPaths().Path(startID, bossID)
+}
+
+// keyAttachCandidates filters rooms a key room may be attached to so the
+// accessibility settings hold: with NoRequiredSecrets only rooms reachable
+// from Start without discovering a secret qualify, and with LowBacktracking
+// only rooms on the critical path qualify.
+func keyAttachCandidates(g *graph.Graph, cfg *Config, rooms []*graph.Room) []*graph.Room {
+	acc := cfg.Accessibility
+	if !acc.NoRequiredSecrets && !acc.LowBacktracking {
+		return rooms
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return nil
+	}
+
+	var visible, onPath map[string]bool
+	if acc.NoRequiredSecrets {
+		visible = g.GetVisibleReachable(path[0])
+	}
+	if acc.LowBacktracking {
+		onPath = make(map[string]bool, len(path))
+		for _, id := range path {
+			onPath[id] = true
+		}
+	}
+
+	candidates := make([]*graph.Room, 0, len(rooms))
+	for _, room := range rooms {
+		if visible != nil && !visible[room.ID] {
+			continue
+		}
+		if onPath != nil && !onPath[room.ID] {
+			continue
+		}
+		candidates = append(candidates, room)
+	}
+	return candidates
+}
+
+// insertCheckpoints converts rooms on the critical path into Checkpoint rooms
+// until no more than cfg.Accessibility.MaxCombatBetweenCheckpoints combat rooms
+// occur between consecutive checkpoints. Start, Boss and rooms that provide or
+// require capabilities are never converted.
+func insertCheckpoints(g *graph.Graph, cfg *Config) error {
+	maxCombat := cfg.Accessibility.MaxCombatBetweenCheckpoints
+	if maxCombat <= 0 {
+		return nil
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return err
+	}
+
+	convertible := func(room *graph.Room) bool {
+		return room.Archetype != graph.ArchetypeStart &&
+			room.Archetype != graph.ArchetypeBoss &&
+			isCombatArchetype(room.Archetype) &&
+			len(room.Provides) == 0 && len(room.Requirements) == 0
+	}
+
+	runStart := 0 // Index of the first room after the last checkpoint
+	for i := 0; i < len(path); i++ {
+		room := g.Rooms[path[i]]
+		if room.Archetype == graph.ArchetypeCheckpoint {
+			runStart = i + 1
+			continue
+		}
+		if combatRoomsBetween(g, path, runStart, i) <= maxCombat {
+			continue
+		}
+
+		// Convert the latest convertible room in the current run
+		converted := false
+		for j := i; j >= runStart; j-- {
+			if candidate := g.Rooms[path[j]]; convertible(candidate) {
+				candidate.Archetype = graph.ArchetypeCheckpoint
+				if candidate.Tags == nil {
+					candidate.Tags = make(map[string]string)
+				}
+				candidate.Tags["checkpoint"] = "true"
+				runStart = j + 1
+				converted = true
+				break
+			}
+		}
+		if !converted {
+			return fmt.Errorf("cannot place checkpoint before room %s", room.ID)
+		}
+	}
+
+	return nil
+}
+
+// combatRoomsBetween counts combat rooms in path[from..to] inclusive.
+func combatRoomsBetween(g *graph.Graph, path []string, from, to int) int {
+	count := 0
+	for i := from; i <= to; i++ {
+		if isCombatArchetype(g.Rooms[path[i]].Archetype) {
+			count++
+		}
+	}
+	return count
+}
+
+// validateAccessibility checks the enabled accessibility guarantees.
+func validateAccessibility(g *graph.Graph, cfg *Config) error {
+	acc := cfg.Accessibility
+	if !acc.NoRequiredSecrets && acc.MaxCombatBetweenCheckpoints <= 0 && !acc.LowBacktracking {
+		return nil
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return err
+	}
+
+	var providers []string
+	for _, id := range getSortedRoomIDs(g) {
+		for _, cap := range g.Rooms[id].Provides {
+			if cap.Type == "key" {
+				providers = append(providers, id)
+				break
+			}
+		}
+	}
+
+	if acc.NoRequiredSecrets {
+		visible := g.GetVisibleReachable(path[0])
+		if !visible[path[len(path)-1]] {
+			return fmt.Errorf("boss is only reachable through a secret")
+		}
+		for _, id := range providers {
+			if !visible[id] {
+				return fmt.Errorf("key in room %s is only reachable through a secret", id)
+			}
+		}
+	}
+
+	if maxCombat := acc.MaxCombatBetweenCheckpoints; maxCombat > 0 {
+		run := 0
+		for _, id := range path {
+			room := g.Rooms[id]
+			if room.Archetype == graph.ArchetypeCheckpoint {
+				run = 0
+			} else if isCombatArchetype(room.Archetype) {
+				run++
+				if run > maxCombat {
+					return fmt.Errorf("%d combat rooms without a checkpoint before room %s, max %d", run, id, maxCombat)
+				}
+			}
+		}
+	}
+
+	if acc.LowBacktracking {
+		near := make(map[string]bool)
+		for _, id := range path {
+			near[id] = true
+			for _, neighbor := range g.Adjacency[id] {
+				near[neighbor] = true
+			}
+		}
+		for _, id := range providers {
+			if !near[id] {
+				return fmt.Errorf("key in room %s is more than one room off the critical path", id)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package synthesis
+
+import (
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// MaxBacktrackPasses is the largest number of backtracking passes.
+const MaxBacktrackPasses = 3
+
+// backtrackAbilities names the abilities that open backtracking gates, in
+// the order the passes seal them.
+var backtrackAbilities = [MaxBacktrackPasses]string{"grapple", "dash", "double_jump"}
+
+// insertBacktracking seals cfg.BacktrackPasses rooms behind ability gates so
+// the completion route has to double back. The Boss is sealed first, by an
+// ability placed in the room farthest from Start that can still be reached;
+// each further pass seals the room holding the previous ability the same
+// way. The player fetches the abilities in reverse order, walking back
+// through the rooms they came by after each one.
+//
+// Sealed rooms require their ability, and every open connector into them is
+// gated by it. Ability rooms are never Start, Boss or a room that already
+// provides or requires something, and with NoRequiredSecrets they are
+// reachable without discovering a secret.
+func insertBacktracking(g *graph.Graph, rng *rng.RNG, cfg *Config) error {
+	if cfg.BacktrackPasses <= 0 {
+		return nil
+	}
+	if cfg.BacktrackPasses > MaxBacktrackPasses {
+		return fmt.Errorf("at most %d backtracking passes, got %d", MaxBacktrackPasses, cfg.BacktrackPasses)
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return err
+	}
+	startID, sealed := path[0], path[len(path)-1]
+	depth := roomDepths(g, startID)
+
+	for pass := 0; pass < cfg.BacktrackPasses; pass++ {
+		ability := backtrackAbilities[pass]
+		sealRoom(g, g.Rooms[sealed], ability)
+
+		reached, _ := g.ReachableWithInventory(startID)
+		var visible map[string]bool
+		if cfg.Accessibility.NoRequiredSecrets {
+			visible = g.GetVisibleReachable(startID)
+		}
+
+		// Candidates are the reachable free rooms farthest from Start
+		var candidates []*graph.Room
+		farthest := 0
+		for _, id := range getSortedRoomIDs(g) {
+			room := g.Rooms[id]
+			if !reached[id] || (visible != nil && !visible[id]) || !freeForAbility(room) {
+				continue
+			}
+			switch d := depth[id]; {
+			case d > farthest:
+				candidates, farthest = []*graph.Room{room}, d
+			case d == farthest:
+				candidates = append(candidates, room)
+			}
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no room left for backtracking ability %q", ability)
+		}
+
+		holder := candidates[rng.Intn(len(candidates))]
+		holder.Provides = append(holder.Provides, graph.Capability{Type: "ability", Value: ability})
+		if holder.Tags == nil {
+			holder.Tags = make(map[string]string)
+		}
+		holder.Tags["contains"] = "ability_" + ability
+		sealed = holder.ID
+	}
+	return nil
+}
+
+// sealRoom makes room require an ability and gates the open connectors
+// leading into it.
+func sealRoom(g *graph.Graph, room *graph.Room, ability string) {
+	need := graph.Requirement{Type: "ability", Value: ability}
+	room.Requirements = append(room.Requirements, need)
+	if room.Tags == nil {
+		room.Tags = make(map[string]string)
+	}
+	room.Tags["locked_by"] = "ability_" + ability
+
+	for _, conn := range g.Connectors {
+		if conn.Gate != nil {
+			continue
+		}
+		if conn.To == room.ID || (conn.Bidirectional && conn.From == room.ID) {
+			conn.Gate = &graph.Gate{Type: need.Type, Value: need.Value}
+		}
+	}
+}
+
+// freeForAbility reports whether a backtracking ability may be placed in
+// room.
+func freeForAbility(room *graph.Room) bool {
+	return room.Archetype != graph.ArchetypeStart &&
+		room.Archetype != graph.ArchetypeBoss &&
+		len(room.Provides) == 0 && len(room.Requirements) == 0
+}
+
+// roomDepths returns the distance in rooms from start to every room,
+// ignoring gates.
+func roomDepths(g *graph.Graph, start string) map[string]int {
+	depth := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range g.Adjacency[id] {
+			if _, seen := depth[next]; !seen {
+				depth[next] = depth[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return depth
+}
@@ -0,0 +1,67 @@
+package synthesis
+
+import (
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// Difficulty carried by each environmental effect at full strength: a room
+// entirely covered in hazards or slow terrain, or in total darkness.
+const (
+	hazardDifficulty   = 1.0
+	darknessDifficulty = 0.5
+	slowDifficulty     = 1.0
+)
+
+// environmentMixes are the ways a room's environment budget can be split
+// between hazards, darkness and slow terrain. Each mix sums to 1.
+var environmentMixes = []struct {
+	hazard, darkness, slow float64
+}{
+	{0.5, 0.3, 0.2}, // Hazard-strewn
+	{0.2, 0.6, 0.2}, // Dark
+	{0.3, 0.2, 0.5}, // Mired
+}
+
+// assignEnvironment expresses part of each combat room's difficulty through
+// its environment instead of its enemies. A ratio of 0 leaves the graph
+// untouched.
+//
+// Each combat room with difficulty gets an environment budget of
+// ratio × difficulty, split by a randomly chosen mix into:
+//   - "hazard_coverage": share of the room's interior covered in hazards
+//   - "darkness": light level removed (0.0 lit - 1.0 pitch black)
+//   - "slow_terrain": share of the room's interior that slows movement
+//
+// The budget itself is recorded in the "environment" tag. Carving paints the
+// effects and the content pass spawns enemies only for the remaining
+// difficulty. Rooms are processed in ID order for determinism.
+func assignEnvironment(g *graph.Graph, ratio float64, rng *rng.RNG) {
+	if ratio <= 0 {
+		return
+	}
+
+	for _, id := range getSortedRoomIDs(g) {
+		room := g.Rooms[id]
+		if !isCombatArchetype(room.Archetype) || room.Difficulty <= 0 {
+			continue
+		}
+
+		budget := room.Difficulty * ratio
+		mix := environmentMixes[rng.Intn(len(environmentMixes))]
+		if room.Tags == nil {
+			room.Tags = make(map[string]string)
+		}
+		room.Tags["environment"] = formatShare(budget)
+		room.Tags["hazard_coverage"] = formatShare(budget * mix.hazard / hazardDifficulty)
+		room.Tags["darkness"] = formatShare(budget * mix.darkness / darknessDifficulty)
+		room.Tags["slow_terrain"] = formatShare(budget * mix.slow / slowDifficulty)
+	}
+}
+
+// formatShare formats a 0.0-1.0 value for a room tag.
+func formatShare(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
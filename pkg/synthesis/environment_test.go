@@ -0,0 +1,66 @@
+package synthesis
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// TestAssignEnvironment verifies combat rooms get an environment budget of
+// ratio × difficulty, spent exactly on hazards, darkness and slow terrain,
+// while safe rooms and a zero ratio are left untouched.
+func TestAssignEnvironment(t *testing.T) {
+	g := createTestGraph(10)
+	g.Rooms[roomID(5)].Archetype = graph.ArchetypeShrine
+
+	assignEnvironment(g, 0, rng.NewRNG(12345, "test", nil))
+	for id, room := range g.Rooms {
+		if _, ok := room.Tags["environment"]; ok {
+			t.Fatalf("Room %s has environment tag at ratio 0", id)
+		}
+	}
+
+	const ratio = 0.5
+	assignEnvironment(g, ratio, rng.NewRNG(12345, "test", nil))
+
+	tag := func(room *graph.Room, key string) float64 {
+		v, err := strconv.ParseFloat(room.Tags[key], 64)
+		if err != nil {
+			t.Fatalf("Room %s tag %s = %q: %v", room.ID, key, room.Tags[key], err)
+		}
+		return v
+	}
+
+	for _, id := range getSortedRoomIDs(g) {
+		room := g.Rooms[id]
+		_, tagged := room.Tags["environment"]
+		if id == roomID(5) || room.Difficulty == 0 {
+			if tagged {
+				t.Errorf("Room %s should have no environment budget", id)
+			}
+			continue
+		}
+		if !tagged {
+			t.Errorf("Room %s missing environment tag", id)
+			continue
+		}
+
+		budget := tag(room, "environment")
+		if math.Abs(budget-ratio*room.Difficulty) > 0.001 {
+			t.Errorf("Room %s budget = %.3f, want %.3f", id, budget, ratio*room.Difficulty)
+		}
+
+		spent := tag(room, "hazard_coverage")*hazardDifficulty +
+			tag(room, "darkness")*darknessDifficulty +
+			tag(room, "slow_terrain")*slowDifficulty
+		if math.Abs(spent-budget) > 0.003 {
+			t.Errorf("Room %s spends %.3f of its %.3f budget", id, spent, budget)
+		}
+		if tag(room, "hazard_coverage")+tag(room, "slow_terrain") > 1 || tag(room, "darkness") > 1 {
+			t.Errorf("Room %s effects exceed full strength: %v", id, room.Tags)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package synthesis
+
+import (
+	"fmt"
+
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// ExitRoomID is the ID of the Exit room added when Config.Exit is set.
+const ExitRoomID = "exit"
+
+// insertExit hangs an Exit room off the Boss when cfg.Exit is set: the end
+// area past the boss fight, such as an escape route or a reward room. The
+// Exit is a dead end joined only to the Boss, so it can only be reached
+// through the Boss room, and it is safe: no difficulty, full reward.
+func insertExit(g *graph.Graph, cfg *Config) error {
+	if !cfg.Exit {
+		return nil
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return err
+	}
+	bossID := path[len(path)-1]
+
+	exit := &graph.Room{
+		ID:         ExitRoomID,
+		Archetype:  graph.ArchetypeExit,
+		Size:       graph.SizeM,
+		Tags:       map[string]string{"type": "exit"},
+		Difficulty: 0.0,
+		Reward:     1.0,
+	}
+	if err := g.AddRoom(exit); err != nil {
+		return fmt.Errorf("adding exit room: %w", err)
+	}
+
+	conn := &graph.Connector{
+		ID:            fmt.Sprintf("conn_%s_%s", bossID, exit.ID),
+		From:          bossID,
+		To:            exit.ID,
+		Type:          graph.TypeDoor,
+		Cost:          1.0,
+		Visibility:    graph.VisibilityNormal,
+		Bidirectional: true,
+	}
+	if err := g.AddConnector(conn); err != nil {
+		return fmt.Errorf("connecting boss to exit: %w", err)
+	}
+	return nil
+}
+
+// maxConnections returns how many connectors production rules may give a
+// room: cfg.BranchingMax, less one for the Boss when an Exit will be hung
+// off it.
+func maxConnections(room *graph.Room, cfg *Config) int {
+	if cfg.Exit && room.Archetype == graph.ArchetypeBoss {
+		return cfg.BranchingMax - 1
+	}
+	return cfg.BranchingMax
+}
@@ -0,0 +1,31 @@
+package synthesis
+
+import (
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// assignFootprints gives each room other than Start, Boss and the compact
+// vault rooms a long hall footprint with probability ratio, recorded in its
+// graph.FootprintTag tag. Embedding chooses which way each hall runs and
+// carving stamps it rotated. A ratio of 0 leaves the graph untouched. Rooms
+// are processed in ID order for determinism.
+func assignFootprints(g *graph.Graph, ratio float64, rng *rng.RNG) {
+	if ratio <= 0 {
+		return
+	}
+
+	for _, id := range getSortedRoomIDs(g) {
+		room := g.Rooms[id]
+		if room.Archetype == graph.ArchetypeStart || room.Archetype == graph.ArchetypeBoss || room.Tags[graph.VaultTag] != "" {
+			continue
+		}
+		if rng.Float64() >= ratio {
+			continue
+		}
+		if room.Tags == nil {
+			room.Tags = make(map[string]string)
+		}
+		room.Tags[graph.FootprintTag] = graph.FootprintHall
+	}
+}
@@ -0,0 +1,49 @@
+package synthesis
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// TestAssignFootprints verifies halls go only to rooms other than Start and
+// Boss, at roughly the configured ratio, and that a zero ratio leaves the
+// graph untouched.
+func TestAssignFootprints(t *testing.T) {
+	g := createTestGraph(10)
+	g.Rooms[roomID(0)].Archetype = graph.ArchetypeStart
+	g.Rooms[roomID(9)].Archetype = graph.ArchetypeBoss
+
+	assignFootprints(g, 0, rng.NewRNG(12345, "test", nil))
+	for id, room := range g.Rooms {
+		if room.Footprint() != "" {
+			t.Fatalf("Room %s has footprint %q at ratio 0", id, room.Footprint())
+		}
+	}
+
+	assignFootprints(g, 1, rng.NewRNG(12345, "test", nil))
+	for id, room := range g.Rooms {
+		want := graph.FootprintHall
+		if id == roomID(0) || id == roomID(9) {
+			want = ""
+		}
+		if room.Footprint() != want {
+			t.Errorf("Room %s footprint = %q, want %q", id, room.Footprint(), want)
+		}
+	}
+
+	halls := 0
+	for i := 0; i < 100; i++ {
+		g := createTestGraph(10)
+		assignFootprints(g, 0.3, rng.NewRNG(uint64(i), "test", nil))
+		for _, room := range g.Rooms {
+			if room.Footprint() == graph.FootprintHall {
+				halls++
+			}
+		}
+	}
+	if halls < 200 || halls > 400 {
+		t.Errorf("%d of 1000 rooms became halls at ratio 0.3", halls)
+	}
+}
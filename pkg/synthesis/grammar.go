@@ -30,6 +30,7 @@ import (
 // - Exactly 1 Start and 1 Boss room
 // - All rooms reachable from Start
 // - Keys obtainable before their locks
+// - Accessibility guarantees from Config.Accessibility, when enabled
 // - Room count within Config bounds
 // - Critical path length compatible with branching architecture (see validation.CheckPathBounds)
 type GrammarSynthesizer struct {
@@ -51,7 +52,7 @@ func (s *GrammarSynthesizer) Name() string {
 // Synthesize generates a graph using grammar-based production rules.
 func (s *GrammarSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
 	// Basic config validation
-	if cfg.RoomsMin < 10 || cfg.RoomsMax > 300 || cfg.RoomsMin > cfg.RoomsMax {
+	if !cfg.validRoomBounds() {
 		return nil, fmt.Errorf("invalid room bounds: min=%d max=%d", cfg.RoomsMin, cfg.RoomsMax)
 	}
 	if cfg.BranchingMax < 2 || cfg.BranchingMax > 5 {
@@ -60,7 +61,8 @@ func (s *GrammarSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *
 
 	// Try multiple attempts if constraints fail
 	var lastErr error
-	for attempt := 0; attempt < s.maxRetries; attempt++ {
+	attempts := cfg.attempts(s.maxRetries)
+	for attempt := 0; attempt < attempts; attempt++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -74,7 +76,7 @@ func (s *GrammarSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *
 		lastErr = err
 	}
 
-	return nil, fmt.Errorf("failed to satisfy constraints after %d attempts: %w", s.maxRetries, lastErr)
+	return nil, &RetryError{Attempts: attempts, Err: lastErr}
 }
 
 // tryGenerate attempts a single generation pass.
@@ -89,6 +91,10 @@ func (s *GrammarSynthesizer) tryGenerate(ctx context.Context, rng *rng.RNG, cfg
 
 	// Step 2: Expand to target size using production rules
 	targetSize := rng.IntRange(cfg.RoomsMin, cfg.RoomsMax)
+	if cfg.Exit {
+		targetSize-- // Leave room for the Exit
+	}
+	targetSize -= cfg.Vaults * VaultRooms // And for the vaults
 	if err := s.expandToSize(ctx, g, rng, cfg, targetSize); err != nil {
 		return nil, fmt.Errorf("expanding graph: %w", err)
 	}
@@ -98,12 +104,38 @@ func (s *GrammarSynthesizer) tryGenerate(ctx context.Context, rng *rng.RNG, cfg
 		return nil, fmt.Errorf("assigning difficulty: %w", err)
 	}
 
-	// Step 4: Assign themes to rooms
+	// Step 4: Seal the Boss behind abilities the route doubles back for
+	if err := insertBacktracking(g, rng, cfg); err != nil {
+		return nil, fmt.Errorf("inserting backtracking: %w", err)
+	}
+
+	// Step 5: Place checkpoints on the critical path if configured
+	if err := insertCheckpoints(g, cfg); err != nil {
+		return nil, fmt.Errorf("inserting checkpoints: %w", err)
+	}
+
+	// Step 6: Hang the Exit room off the Boss if configured
+	if err := insertExit(g, cfg); err != nil {
+		return nil, fmt.Errorf("inserting exit: %w", err)
+	}
+
+	// Step 7: Add treasure vaults off the critical path if configured
+	if err := insertVaults(g, rng, cfg); err != nil {
+		return nil, fmt.Errorf("inserting vaults: %w", err)
+	}
+
+	// Step 8: Assign themes to rooms
 	if err := assignThemes(g, cfg.Themes, rng); err != nil {
 		return nil, fmt.Errorf("assigning themes: %w", err)
 	}
 
-	// Step 5: Validate hard constraints
+	// Step 9: Express part of the difficulty through the environment
+	assignEnvironment(g, cfg.EnvironmentRatio, rng)
+
+	// Step 10: Give some rooms long hall footprints
+	assignFootprints(g, cfg.HallRatio, rng)
+
+	// Step 11: Validate hard constraints
 	if err := s.validateHardConstraints(g, cfg); err != nil {
 		return nil, fmt.Errorf("constraint validation failed: %w", err)
 	}
@@ -254,7 +286,7 @@ func (s *GrammarSynthesizer) applyExpandHub(g *graph.Graph, rng *rng.RNG, cfg *C
 	var hub *graph.Room
 	for _, h := range hubs {
 		currentConnections := len(g.Adjacency[h.ID])
-		if currentConnections < cfg.BranchingMax {
+		if currentConnections < maxConnections(h, cfg) {
 			hub = h
 			break
 		}
@@ -267,7 +299,7 @@ func (s *GrammarSynthesizer) applyExpandHub(g *graph.Graph, rng *rng.RNG, cfg *C
 		for _, id := range roomIDs {
 			room := g.Rooms[id]
 			currentConnections := len(g.Adjacency[room.ID])
-			if currentConnections < cfg.BranchingMax {
+			if currentConnections < maxConnections(room, cfg) {
 				hub = room
 				break
 			}
@@ -279,7 +311,7 @@ func (s *GrammarSynthesizer) applyExpandHub(g *graph.Graph, rng *rng.RNG, cfg *C
 	}
 
 	// Determine how many spokes to add (1-3, but respect capacity)
-	maxSpokesPossible := cfg.BranchingMax - len(g.Adjacency[hub.ID])
+	maxSpokesPossible := maxConnections(hub, cfg) - len(g.Adjacency[hub.ID])
 	if maxSpokesPossible <= 0 {
 		return fmt.Errorf("hub at max capacity")
 	}
@@ -292,8 +324,8 @@ func (s *GrammarSynthesizer) applyExpandHub(g *graph.Graph, rng *rng.RNG, cfg *C
 		roomID := fmt.Sprintf("room_%d", *counter)
 		spoke := &graph.Room{
 			ID:         roomID,
-			Archetype:  s.pickRoomArchetype(rng, cfg),
-			Size:       s.pickRoomSize(rng),
+			Archetype:  s.pickRoomArchetype(rng, cfg, g),
+			Size:       s.pickRoomSize(rng, cfg, g),
 			Tags:       map[string]string{"spoke": hub.ID},
 			Difficulty: rng.Float64(),
 			Reward:     rng.Float64(),
@@ -334,24 +366,57 @@ func (s *GrammarSynthesizer) applyInsertKeyLoop(g *graph.Graph, rng *rng.RNG, cf
 
 	// Pick a random key type
 	keyConfig := cfg.Keys[rng.Intn(len(cfg.Keys))]
+	keyType := "key"
+	if keyConfig.Consumable {
+		// Small-key doors open from the room holding their own key, so a
+		// player always holds a key for every closed door they can reach
+		// however they spent the others
+		keyType = graph.SmallKey
+		if smallKeyDoors(g, keyConfig.Name) >= keyConfig.Count {
+			return fmt.Errorf("small key pool %q is used up", keyConfig.Name)
+		}
+	}
 
 	// Find an existing room with capacity to attach the key room to
-	availableRooms := s.getRoomsWithCapacity(g, cfg)
+	availableRooms := keyAttachCandidates(g, cfg, s.getRoomsWithCapacity(g, cfg))
+	opens := openedKeys(cfg, keyConfig.Name)
+	if len(opens) > 0 {
+		// Master keys are rewards for getting past a lock they open, so
+		// they never make the keys they replace pointless
+		availableRooms = roomsBehindLocks(availableRooms, opens)
+	}
 	if len(availableRooms) == 0 {
 		return fmt.Errorf("no rooms with capacity available")
 	}
 	attachPoint := availableRooms[rng.Intn(len(availableRooms))]
 
+	// A lock needing several keys is only placed once the others can be had
+	gate := &graph.Gate{Type: keyType, Value: keyConfig.Name, Any: keyConfig.RequireAny}
+	for _, name := range keyConfig.Requires {
+		gate.Requirements = append(gate.Requirements, graph.Requirement{Type: "key", Value: name})
+	}
+	if len(gate.Requirements) > 0 && !gate.Any {
+		_, inventory := g.ReachableWithInventory(s.findRoomsByArchetype(g, graph.ArchetypeStart)[0].ID)
+		for _, req := range gate.Requirements {
+			if !inventory[graph.Capability{Type: req.Type, Value: req.Value}] {
+				return fmt.Errorf("key %q for lock %q not obtainable yet", req.Value, keyConfig.Name)
+			}
+		}
+	}
+
 	// Create key room (comes before lock)
 	keyRoomID := fmt.Sprintf("room_%d", *counter)
 	keyRoom := &graph.Room{
 		ID:         keyRoomID,
 		Archetype:  graph.ArchetypeTreasure,
 		Size:       graph.SizeS,
-		Tags:       map[string]string{"contains": "key_" + keyConfig.Name},
+		Tags:       map[string]string{"contains": keyType + "_" + keyConfig.Name},
 		Difficulty: rng.Float64Range(0.3, 0.7),
 		Reward:     0.5,
-		Provides:   []graph.Capability{{Type: "key", Value: keyConfig.Name}},
+		Provides:   []graph.Capability{{Type: keyType, Value: keyConfig.Name}},
+	}
+	for _, name := range opens {
+		keyRoom.Provides = append(keyRoom.Provides, graph.Capability{Type: "key", Value: name})
 	}
 
 	if err := g.AddRoom(keyRoom); err != nil {
@@ -378,32 +443,35 @@ func (s *GrammarSynthesizer) applyInsertKeyLoop(g *graph.Graph, rng *rng.RNG, cf
 	// Create locked room (requires key)
 	lockedRoomID := fmt.Sprintf("room_%d", *counter)
 	lockedRoom := &graph.Room{
-		ID:           lockedRoomID,
-		Archetype:    graph.ArchetypePuzzle,
-		Size:         graph.SizeM,
-		Tags:         map[string]string{"locked_by": "key_" + keyConfig.Name},
-		Difficulty:   rng.Float64Range(0.5, 0.9),
-		Reward:       0.8,
-		Requirements: []graph.Requirement{{Type: "key", Value: keyConfig.Name}},
+		ID:         lockedRoomID,
+		Archetype:  graph.ArchetypePuzzle,
+		Size:       graph.SizeM,
+		Tags:       map[string]string{"locked_by": keyType + "_" + keyConfig.Name},
+		Difficulty: rng.Float64Range(0.5, 0.9),
+		Reward:     0.8,
+	}
+	if !gate.Any && !keyConfig.Consumable {
+		// Rooms need all their requirements, so an any-of lock leaves the
+		// room itself open, as does a small-key door, which takes its key
+		lockedRoom.Requirements = gate.Needs()
 	}
 
 	if err := g.AddRoom(lockedRoom); err != nil {
 		return err
 	}
 
-	// Connect key room to locked room
+	// Connect key room to locked room. The door stays two-way: whoever is
+	// behind it came through with the key, and a one-way door would trap
+	// them in the locked room's dead end
 	connToLocked := &graph.Connector{
-		ID:   fmt.Sprintf("conn_%s_%s", keyRoom.ID, lockedRoom.ID),
-		From: keyRoom.ID,
-		To:   lockedRoom.ID,
-		Type: graph.TypeDoor,
-		Gate: &graph.Gate{
-			Type:  "key",
-			Value: keyConfig.Name,
-		},
+		ID:            fmt.Sprintf("conn_%s_%s", keyRoom.ID, lockedRoom.ID),
+		From:          keyRoom.ID,
+		To:            lockedRoom.ID,
+		Type:          graph.TypeDoor,
+		Gate:          gate,
 		Cost:          1.0,
 		Visibility:    graph.VisibilityNormal,
-		Bidirectional: false, // One-way: can't go back through locked door without key
+		Bidirectional: true,
 	}
 
 	if err := g.AddConnector(connToLocked); err != nil {
@@ -415,6 +483,62 @@ func (s *GrammarSynthesizer) applyInsertKeyLoop(g *graph.Graph, rng *rng.RNG, cf
 	return nil
 }
 
+// openedKeys returns the keys whose locks a key also opens, following nested
+// master keys, sorted. It is empty for an ordinary key.
+func openedKeys(cfg *Config, name string) []string {
+	byName := make(map[string]KeyConfig, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		byName[key.Name] = key
+	}
+
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	var opens []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range byName[current].Opens {
+			if !seen[next] {
+				seen[next] = true
+				opens = append(opens, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	sort.Strings(opens)
+	return opens
+}
+
+// smallKeyDoors counts the connectors locked by small keys of a pool.
+func smallKeyDoors(g *graph.Graph, pool string) int {
+	doors := 0
+	for _, conn := range g.Connectors {
+		if conn.Gate != nil && conn.Gate.Type == graph.SmallKey && conn.Gate.Value == pool {
+			doors++
+		}
+	}
+	return doors
+}
+
+// roomsBehindLocks returns the rooms that require one of the given keys.
+func roomsBehindLocks(rooms []*graph.Room, keys []string) []*graph.Room {
+	locks := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		locks[key] = true
+	}
+
+	var behind []*graph.Room
+	for _, room := range rooms {
+		for _, req := range room.Requirements {
+			if req.Type == "key" && locks[req.Value] {
+				behind = append(behind, room)
+				break
+			}
+		}
+	}
+	return behind
+}
+
 // applyBranchOptional adds an optional side branch.
 // Implements the BranchOptional production rule.
 func (s *GrammarSynthesizer) applyBranchOptional(g *graph.Graph, rng *rng.RNG, cfg *Config, counter *int) error {
@@ -431,7 +555,7 @@ func (s *GrammarSynthesizer) applyBranchOptional(g *graph.Graph, rng *rng.RNG, c
 	optionalRoom := &graph.Room{
 		ID:         roomID,
 		Archetype:  graph.ArchetypeOptional,
-		Size:       s.pickRoomSize(rng),
+		Size:       s.pickRoomSize(rng, cfg, g),
 		Tags:       map[string]string{"optional": "true", "branch_from": branchPoint.ID},
 		Difficulty: rng.Float64Range(0.4, 0.8),
 		Reward:     rng.Float64Range(0.6, 1.0), // Higher rewards for optional content
@@ -540,44 +664,54 @@ func (s *GrammarSynthesizer) validateHardConstraints(g *graph.Graph, cfg *Config
 		}
 	}
 
+	// Constraint 8: Configured accessibility guarantees
+	if err := validateAccessibility(g, cfg); err != nil {
+		return fmt.Errorf("accessibility constraint violated: %w", err)
+	}
+
 	return nil
 }
 
 // validateKeyLockConstraints ensures keys are obtainable before their locks.
+// Every required key must be provided somewhere, and playing the dungeon
+// forward from Start with no keys, collecting keys and repeating until nothing
+// new is found (graph.ReachableWithInventory), must reach the Boss and every
+// locked room, and no way of spending small keys may strand the player
+// (graph.FindSoftLock).
 func (s *GrammarSynthesizer) validateKeyLockConstraints(g *graph.Graph) error {
-	// Find all rooms that provide keys
-	keyProviders := make(map[string]string) // key name -> room ID
-
+	provided := make(map[string]bool)
 	for _, room := range g.Rooms {
 		for _, cap := range room.Provides {
 			if cap.Type == "key" {
-				keyProviders[cap.Value] = room.ID
+				provided[cap.Value] = true
 			}
 		}
 	}
 
-	// Find all rooms that require keys
-	for _, room := range g.Rooms {
-		for _, req := range room.Requirements {
-			if req.Type == "key" {
-				keyProviderID, hasProvider := keyProviders[req.Value]
-				if !hasProvider {
-					return fmt.Errorf("room %s requires key %q but no room provides it", room.ID, req.Value)
-				}
-
-				// Verify key provider is reachable before locked room
-				// Start from Start room
-				startRoom := s.findRoomsByArchetype(g, graph.ArchetypeStart)[0]
-
-				// Can we reach key provider from Start?
-				_, err := g.GetPath(startRoom.ID, keyProviderID)
-				if err != nil {
-					return fmt.Errorf("key %q in room %s is not reachable from Start", req.Value, keyProviderID)
-				}
-
-				// This ensures key can be obtained before reaching locked room
+	startRoom := s.findRoomsByArchetype(g, graph.ArchetypeStart)[0]
+	bossRoom := s.findRoomsByArchetype(g, graph.ArchetypeBoss)[0]
+	reached, _ := g.ReachableWithInventory(startRoom.ID)
+
+	for _, id := range getSortedRoomIDs(g) {
+		for _, req := range g.Rooms[id].Requirements {
+			if req.Type == "key" && !provided[req.Value] {
+				return fmt.Errorf("room %s requires key %q but no room provides it", id, req.Value)
 			}
 		}
+		if len(g.Rooms[id].Requirements) > 0 && !reached[id] {
+			return fmt.Errorf("locked room %s cannot be opened with the keys obtainable before it", id)
+		}
+	}
+	if !reached[bossRoom.ID] {
+		return fmt.Errorf("boss room %s cannot be reached with the keys obtainable on the way", bossRoom.ID)
+	}
+
+	lock, err := g.FindSoftLock(startRoom.ID, bossRoom.ID)
+	if err != nil {
+		return fmt.Errorf("checking small keys: %w", err)
+	}
+	if lock != nil {
+		return fmt.Errorf("spending small keys on %v leaves boss room %s unreachable", lock.Opened, bossRoom.ID)
 	}
 
 	return nil
@@ -632,46 +766,197 @@ func (s *GrammarSynthesizer) getRoomsWithCapacity(g *graph.Graph, cfg *Config) [
 	for _, id := range roomIDs {
 		room := g.Rooms[id]
 		currentConnections := len(g.Adjacency[room.ID])
-		if currentConnections < cfg.BranchingMax {
+		if currentConnections < maxConnections(room, cfg) {
 			rooms = append(rooms, room)
 		}
 	}
 	return rooms
 }
 
-func (s *GrammarSynthesizer) pickRoomArchetype(rng *rng.RNG, cfg *Config) graph.RoomArchetype {
-	// Weight distribution for room types
-	weights := []float64{
-		0.0,  // Start (never random)
-		0.0,  // Boss (never random)
-		0.15, // Treasure
-		0.2,  // Puzzle
-		0.1,  // Hub
-		0.25, // Corridor
-		0.0,  // Secret (handled separately)
-		0.2,  // Optional
-		0.05, // Vendor
-		0.05, // Shrine
-		0.0,  // Checkpoint
+// defaultArchetypeWeights is the room archetype distribution picked from
+// when the config sets no targets, indexed by archetype.
+var defaultArchetypeWeights = []float64{
+	0.0,  // Start (never random)
+	0.0,  // Boss (never random)
+	0.15, // Treasure
+	0.2,  // Puzzle
+	0.1,  // Hub
+	0.25, // Corridor
+	0.0,  // Secret (handled separately)
+	0.2,  // Optional
+	0.05, // Vendor
+	0.05, // Shrine
+	0.0,  // Checkpoint
+	0.0,  // Exit (placed behind the Boss)
+}
+
+// pickRoomArchetype picks the archetype of a room about to be added to g.
+// The weights derive from cfg.Archetypes when targets are set.
+func (s *GrammarSynthesizer) pickRoomArchetype(rng *rng.RNG, cfg *Config, g *graph.Graph) graph.RoomArchetype {
+	weights := defaultArchetypeWeights
+	if len(cfg.Archetypes) > 0 {
+		weights = archetypeWeights(g, cfg.Archetypes)
 	}
 
 	choice := rng.WeightedChoice(weights)
 	return graph.RoomArchetype(choice)
 }
 
-func (s *GrammarSynthesizer) pickRoomSize(rng *rng.RNG) graph.RoomSize {
-	weights := []float64{
-		0.2,  // XS
-		0.3,  // S
-		0.3,  // M
-		0.15, // L
-		0.05, // XL
+// archetypeWeights derives room archetype weights from targets, steering the
+// distribution towards them whatever archetypes the other production rules
+// add.
+//
+// Algorithm:
+//  1. Weight each targeted archetype by the rooms it lacks once the new room
+//     is added: its fraction of the grown graph, or its exact count, less the
+//     rooms it already has
+//  2. Let the untargeted archetypes share the rooms the fractions leave,
+//     split by their default weights
+//  3. If no archetype lacks rooms, weight by the fractions and the leftover
+//     share instead, so exact counts are never exceeded
+func archetypeWeights(g *graph.Graph, targets []ArchetypeTarget) []float64 {
+	n := float64(len(g.Rooms) + 1)
+	have := make([]float64, len(defaultArchetypeWeights))
+	for _, room := range g.Rooms {
+		if int(room.Archetype) < len(have) {
+			have[room.Archetype]++
+		}
+	}
+
+	// Step 1: rooms lacked by the targeted archetypes
+	lacking := make([]float64, len(defaultArchetypeWeights))
+	steady := make([]float64, len(defaultArchetypeWeights))
+	targeted := make([]bool, len(defaultArchetypeWeights))
+	share := 1.0
+	for _, t := range targets {
+		targeted[t.Archetype] = true
+		if t.Count > 0 {
+			lacking[t.Archetype] = float64(t.Count) - have[t.Archetype]
+			continue
+		}
+		lacking[t.Archetype] = t.Fraction*n - have[t.Archetype]
+		steady[t.Archetype] = t.Fraction
+		share -= t.Fraction
+	}
+
+	// Step 2: the untargeted archetypes share the rest
+	share = max(share, 0)
+	rest, untargeted := share*n, 0.0
+	for a, w := range defaultArchetypeWeights {
+		if w > 0 && !targeted[a] {
+			rest -= have[a]
+			untargeted += w
+		}
+	}
+	for a, w := range defaultArchetypeWeights {
+		if w > 0 && !targeted[a] {
+			lacking[a] = rest * w / untargeted
+			steady[a] = share * w / untargeted
+		}
+	}
+
+	// Step 3: fall back to the steady distribution once every target is met
+	total := 0.0
+	for a := range lacking {
+		lacking[a] = max(lacking[a], 0)
+		total += lacking[a]
+	}
+	if total > 0 {
+		return lacking
+	}
+	for _, w := range steady {
+		if w > 0 {
+			return steady
+		}
+	}
+	return defaultArchetypeWeights
+}
+
+// defaultSizeWeights is the room size distribution picked from when the
+// config sets no weights, indexed by size.
+var defaultSizeWeights = []float64{
+	0.2,  // XS
+	0.3,  // S
+	0.3,  // M
+	0.15, // L
+	0.05, // XL
+}
+
+// DefaultSizeWeights returns a copy of the room size distribution used when
+// the config sets no weights, indexed by graph.RoomSize.
+func DefaultSizeWeights() []float64 {
+	return append([]float64(nil), defaultSizeWeights...)
+}
+
+// pickRoomSize picks the size of a room about to be added to g, from
+// cfg.SizeWeights when set, keeping within cfg.FloorBudget.
+func (s *GrammarSynthesizer) pickRoomSize(rng *rng.RNG, cfg *Config, g *graph.Graph) graph.RoomSize {
+	weights := defaultSizeWeights
+	if len(cfg.SizeWeights) > 0 {
+		weights = cfg.SizeWeights
+	}
+	if cfg.FloorBudget > 0 {
+		weights = fitFloorBudget(g, cfg, weights)
 	}
 
 	choice := rng.WeightedChoice(weights)
 	return graph.RoomSize(choice)
 }
 
+// fitFloorBudget restricts size weights so rooms stay within the share of
+// cfg.FloorBudget left after CorridorFloorShare. The rooms still to come
+// are assumed to number up to cfg.RoomsMax.
+//
+// Algorithm:
+//  1. Divide the room floor budget left by the rooms still to come
+//  2. Drop the largest size while the weighted mean floor area exceeds that
+//     allowance and a smaller size remains
+func fitFloorBudget(g *graph.Graph, cfg *Config, weights []float64) []float64 {
+	// Step 1: floor allowance per remaining room
+	left := float64(cfg.FloorBudget) * (1 - CorridorFloorShare)
+	for _, room := range g.Rooms {
+		left -= float64(RoomFloorTiles(room.Size))
+	}
+	allowance := left / float64(max(cfg.RoomsMax-len(g.Rooms), 1))
+
+	// Step 2: drop sizes from the largest down
+	fitted := append([]float64(nil), weights...)
+	for largest := len(fitted) - 1; largest > 0; largest-- {
+		total, tiles, smaller := 0.0, 0.0, false
+		for size, w := range fitted {
+			total += w
+			tiles += w * float64(RoomFloorTiles(graph.RoomSize(size)))
+			if size < largest && w > 0 {
+				smaller = true
+			}
+		}
+		if total == 0 || tiles/total <= allowance || !smaller {
+			break
+		}
+		fitted[largest] = 0
+	}
+	return fitted
+}
+
+// RoomFloorTiles returns the floor tiles the carving stage stamps for a room
+// of the given size.
+func RoomFloorTiles(size graph.RoomSize) int {
+	switch size {
+	case graph.SizeXS:
+		return 3 * 3
+	case graph.SizeS:
+		return 5 * 5
+	case graph.SizeM:
+		return 7 * 7
+	case graph.SizeL:
+		return 10 * 10
+	case graph.SizeXL:
+		return 15 * 15
+	default:
+		return 5 * 5
+	}
+}
+
 func (s *GrammarSynthesizer) pickConnectorType(rng *rng.RNG) graph.ConnectorType {
 	weights := []float64{
 		0.4,  // Door
@@ -709,8 +994,10 @@ func (s *GrammarSynthesizer) assignDifficulty(g *graph.Graph, rng *rng.RNG, cfg
 		return fmt.Errorf("missing Start or Boss room for difficulty assignment")
 	}
 
-	// Get the critical path from Start to Boss
-	criticalPath, err := g.GetPath(startRoom.ID, bossRoom.ID)
+	// Get the critical path from Start to Boss. Off-path rooms look up their
+	// nearest path room in the same table.
+	paths := g.ShortestPaths()
+	criticalPath, err := paths.Path(startRoom.ID, bossRoom.ID)
 	if err != nil {
 		return fmt.Errorf("no path from Start to Boss: %w", err)
 	}
@@ -744,7 +1031,7 @@ func (s *GrammarSynthesizer) assignDifficulty(g *graph.Graph, rng *rng.RNG, cfg
 			difficulty = EvaluateWithVariance(curve, progress, cfg.Pacing.Variance, rng)
 		} else {
 			// Room is optional/side room: interpolate from nearest path rooms
-			difficulty = s.interpolateOffPathDifficulty(g, roomID, progressMap, curve, cfg.Pacing.Variance, rng)
+			difficulty = s.interpolateOffPathDifficulty(g, paths, roomID, criticalPath, progressMap, curve, cfg.Pacing.Variance, rng)
 		}
 
 		// Apply difficulty to room
@@ -784,7 +1071,9 @@ func (s *GrammarSynthesizer) createPacingCurve(cfg PacingConfig) (PacingCurve, e
 // Uses nearest neighbors on the path to interpolate a reasonable difficulty.
 func (s *GrammarSynthesizer) interpolateOffPathDifficulty(
 	g *graph.Graph,
+	paths *graph.ShortestPaths,
 	roomID string,
+	criticalPath []string,
 	progressMap map[string]float64,
 	curve PacingCurve,
 	variance float64,
@@ -800,9 +1089,9 @@ func (s *GrammarSynthesizer) interpolateOffPathDifficulty(
 		}
 	}
 
-	// If no path neighbors, do a BFS to find nearest path room
+	// If no path neighbors, look up the nearest path room
 	if len(pathNeighbors) == 0 {
-		nearestProgress := s.findNearestPathRoom(g, roomID, progressMap)
+		nearestProgress := s.findNearestPathRoom(paths, roomID, criticalPath, progressMap)
 		if nearestProgress >= 0 {
 			pathNeighbors = append(pathNeighbors, nearestProgress)
 		}
@@ -823,31 +1112,16 @@ func (s *GrammarSynthesizer) interpolateOffPathDifficulty(
 }
 
 // findNearestPathRoom finds the progress value of the nearest room on the critical path.
-// Uses BFS to find the closest path room. Returns -1.0 if no path room found.
-func (s *GrammarSynthesizer) findNearestPathRoom(g *graph.Graph, startID string, progressMap map[string]float64) float64 {
-	visited := make(map[string]bool)
-	queue := []string{startID}
-	visited[startID] = true
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		// Check if this room is on the path
-		if progress, onPath := progressMap[current]; onPath {
-			return progress
-		}
-
-		// Add neighbors to queue
-		for _, neighborID := range g.Adjacency[current] {
-			if !visited[neighborID] {
-				visited[neighborID] = true
-				queue = append(queue, neighborID)
-			}
+// Distances come from the shared shortest path table; ties go to the room earliest on
+// the path. Returns -1.0 if no path room is reachable.
+func (s *GrammarSynthesizer) findNearestPathRoom(paths *graph.ShortestPaths, startID string, criticalPath []string, progressMap map[string]float64) float64 {
+	nearest, best := -1.0, -1
+	for _, pathID := range criticalPath {
+		if d, ok := paths.Distance(startID, pathID); ok && (best < 0 || d < best) {
+			nearest, best = progressMap[pathID], d
 		}
 	}
-
-	return -1.0 // Not found
+	return nearest
 }
 
 // min returns the minimum of two integers.
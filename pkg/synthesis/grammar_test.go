@@ -2,6 +2,7 @@ package synthesis
 
 import (
 	"context"
+	"slices"
 	"testing"
 
 	"github.com/dshills/dungo/pkg/graph"
@@ -248,6 +249,120 @@ func TestGrammarSynthesizer_KeyLockConstraints(t *testing.T) {
 	}
 }
 
+// TestGrammarSynthesizer_MasterAndMultiKeyLocks verifies master keys are
+// placed behind the locks they open and multi-key doors stay solvable.
+func TestGrammarSynthesizer_MasterAndMultiKeyLocks(t *testing.T) {
+	cfg := &Config{
+		RoomsMin:     30,
+		RoomsMax:     40,
+		BranchingAvg: 2.0,
+		BranchingMax: 4,
+		Keys: []KeyConfig{
+			{Name: "silver", Count: 1},
+			{Name: "gold", Count: 1, Requires: []string{"silver"}},
+			{Name: "master", Count: 1, Opens: []string{"silver", "gold"}},
+		},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+		Themes:        []string{"dungeon"},
+	}
+
+	multiKey, master := 0, 0
+	for seed := uint64(1); seed <= 20; seed++ {
+		cfg.Seed = seed
+		g, err := NewGrammarSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		for _, conn := range g.Connectors {
+			if conn.Gate != nil && conn.Gate.Value == "gold" {
+				multiKey++
+				if len(conn.Gate.Needs()) != 2 {
+					t.Errorf("seed %d: gold door %s needs %v, want gold and silver", seed, conn.ID, conn.Gate.Needs())
+				}
+			}
+		}
+
+		for _, room := range g.Rooms {
+			if room.Tags["contains"] != "key_master" {
+				continue
+			}
+			master++
+			if len(room.Provides) != 3 {
+				t.Errorf("seed %d: master key room %s provides %v, want master, gold and silver", seed, room.ID, room.Provides)
+			}
+			// The master key hangs off a room behind a silver or gold lock
+			behindLock := false
+			for _, id := range g.Adjacency[room.ID] {
+				for _, req := range g.Rooms[id].Requirements {
+					behindLock = behindLock || req.Value == "silver" || req.Value == "gold"
+				}
+			}
+			if !behindLock {
+				t.Errorf("seed %d: master key room %s is not next to a room it unlocks", seed, room.ID)
+			}
+		}
+	}
+	if multiKey == 0 || master == 0 {
+		t.Errorf("Expected multi-key doors and master keys across seeds, got %d and %d", multiKey, master)
+	}
+}
+
+// TestGrammarSynthesizer_SmallKeys verifies small-key pools place at most
+// Count doors, each with its own key, and never soft-lock.
+func TestGrammarSynthesizer_SmallKeys(t *testing.T) {
+	cfg := &Config{
+		RoomsMin:      30,
+		RoomsMax:      40,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		Keys:          []KeyConfig{{Name: "castle", Count: 3, Consumable: true}},
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+		Themes:        []string{"dungeon"},
+	}
+
+	placed := 0
+	for seed := uint64(1); seed <= 10; seed++ {
+		cfg.Seed = seed
+		g, err := NewGrammarSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		doors, keys := 0, 0
+		for _, conn := range g.Connectors {
+			if conn.Gate != nil && conn.Gate.Type == graph.SmallKey {
+				doors++
+			}
+		}
+		for _, room := range g.Rooms {
+			for _, c := range room.Provides {
+				if c.Type == graph.SmallKey {
+					keys++
+				}
+			}
+		}
+		if doors > 3 || keys != doors {
+			t.Errorf("seed %d: %d small-key doors and %d small keys, want at most 3 with one key each", seed, doors, keys)
+		}
+		placed += doors
+
+		synth := NewGrammarSynthesizer()
+		start := synth.findRoomsByArchetype(g, graph.ArchetypeStart)[0]
+		boss := synth.findRoomsByArchetype(g, graph.ArchetypeBoss)[0]
+		if lock, err := g.FindSoftLock(start.ID, boss.ID); err != nil || lock != nil {
+			t.Errorf("seed %d: FindSoftLock() = %+v, %v, want no soft lock", seed, lock, err)
+		}
+	}
+	if placed == 0 {
+		t.Error("Expected small-key doors across seeds")
+	}
+}
+
 // TestGrammarSynthesizer_Determinism verifies same seed produces same graph.
 func TestGrammarSynthesizer_Determinism(t *testing.T) {
 	cfg := &Config{
@@ -584,3 +699,389 @@ func TestGrammarSynthesizer_BranchingFactorBounds(t *testing.T) {
 		})
 	}
 }
+
+// TestGrammarSynthesizer_Accessibility verifies accessibility settings are
+// respected across seeds: keys stay out of secrets and next to the critical
+// path, and checkpoints break up combat on the critical path.
+func TestGrammarSynthesizer_Accessibility(t *testing.T) {
+	synth := NewGrammarSynthesizer()
+
+	for seed := uint64(1); seed <= 20; seed++ {
+		cfg := &Config{
+			Seed:          seed,
+			RoomsMin:      20,
+			RoomsMax:      35,
+			BranchingAvg:  2.0,
+			BranchingMax:  4,
+			Keys:          []KeyConfig{{Name: "silver", Count: 1}},
+			SecretDensity: 0.3,
+			OptionalRatio: 0.2,
+			Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			Accessibility: AccessibilityConfig{
+				NoRequiredSecrets:           true,
+				MaxCombatBetweenCheckpoints: 1,
+				LowBacktracking:             true,
+			},
+		}
+
+		g, err := synth.Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		path, err := criticalPath(g)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		visible := g.GetVisibleReachable(path[0])
+
+		for id, room := range g.Rooms {
+			if len(room.Provides) == 0 {
+				continue
+			}
+			if !visible[id] {
+				t.Errorf("seed %d: key room %s is behind a secret", seed, id)
+			}
+			adjacent := false
+			for _, p := range path {
+				for _, neighbor := range g.Adjacency[p] {
+					adjacent = adjacent || neighbor == id
+				}
+			}
+			if !adjacent {
+				t.Errorf("seed %d: key room %s is not next to the critical path %v", seed, id, path)
+			}
+		}
+
+		checkpoints := 0
+		for _, id := range path {
+			if g.Rooms[id].Archetype == graph.ArchetypeCheckpoint {
+				checkpoints++
+			}
+		}
+		if checkpoints == 0 {
+			t.Errorf("seed %d: expected a checkpoint on critical path %v", seed, path)
+		}
+	}
+}
+
+// TestInsertCheckpoints_LongPath verifies checkpoints are spaced on a long
+// critical path and that Start, Boss and key rooms are never converted.
+func TestInsertCheckpoints_LongPath(t *testing.T) {
+	g := graph.NewGraph(1)
+	ids := []string{"start", "a", "b", "c", "d", "e", "boss"}
+	for i, id := range ids {
+		archetype := graph.ArchetypeCorridor
+		switch i {
+		case 0:
+			archetype = graph.ArchetypeStart
+		case len(ids) - 1:
+			archetype = graph.ArchetypeBoss
+		}
+		room := &graph.Room{ID: id, Archetype: archetype, Size: graph.SizeM, Tags: map[string]string{}}
+		if id == "e" {
+			room.Provides = []graph.Capability{{Type: "key", Value: "silver"}}
+		}
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			if err := g.AddConnector(&graph.Connector{
+				ID: "c_" + id, From: ids[i-1], To: id, Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cfg := &Config{Accessibility: AccessibilityConfig{MaxCombatBetweenCheckpoints: 2}}
+	if err := insertCheckpoints(g, cfg); err != nil {
+		t.Fatalf("insertCheckpoints() error = %v", err)
+	}
+	if err := validateAccessibility(g, cfg); err != nil {
+		t.Errorf("validateAccessibility() error = %v", err)
+	}
+
+	for _, id := range []string{"start", "e", "boss"} {
+		if g.Rooms[id].Archetype == graph.ArchetypeCheckpoint {
+			t.Errorf("room %s must not be converted to a checkpoint", id)
+		}
+	}
+}
+
+func TestGrammarSynthesizer_ArchetypeTargets(t *testing.T) {
+	cfg := &Config{
+		RoomsMin:      30,
+		RoomsMax:      40,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		Archetypes: []ArchetypeTarget{
+			{Archetype: graph.ArchetypeTreasure, Fraction: 0.3},
+			{Archetype: graph.ArchetypeCorridor, Fraction: 0.05},
+			{Archetype: graph.ArchetypeVendor, Count: 1},
+		},
+	}
+
+	for seed := uint64(1); seed <= 8; seed++ {
+		cfg.Seed = seed
+		g, err := NewGrammarSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		counts := make(map[graph.RoomArchetype]int)
+		for _, room := range g.Rooms {
+			counts[room.Archetype]++
+		}
+		n := float64(len(g.Rooms))
+		if counts[graph.ArchetypeVendor] != 1 {
+			t.Errorf("seed %d: %d vendors, want exactly 1", seed, counts[graph.ArchetypeVendor])
+		}
+		if share := float64(counts[graph.ArchetypeTreasure]) / n; share < 0.15 || share > 0.4 {
+			t.Errorf("seed %d: treasure share %.2f, want near 0.3", seed, share)
+		}
+		if share := float64(counts[graph.ArchetypeCorridor]) / n; share > 0.15 {
+			t.Errorf("seed %d: corridor share %.2f, want about 0.05", seed, share)
+		}
+	}
+}
+
+func TestArchetypeWeights(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, a := range []graph.RoomArchetype{graph.ArchetypeStart, graph.ArchetypeBoss, graph.ArchetypeVendor} {
+		_ = g.AddRoom(&graph.Room{ID: a.String(), Archetype: a, Size: graph.SizeM})
+	}
+
+	weights := archetypeWeights(g, []ArchetypeTarget{
+		{Archetype: graph.ArchetypeVendor, Count: 1},
+		{Archetype: graph.ArchetypeTreasure, Fraction: 0.5},
+	})
+	if weights[graph.ArchetypeVendor] != 0 {
+		t.Errorf("vendor weight = %f, want 0 once its count is met", weights[graph.ArchetypeVendor])
+	}
+	if weights[graph.ArchetypeTreasure] != 2 {
+		t.Errorf("treasure weight = %f, want 2 rooms lacking of 4", weights[graph.ArchetypeTreasure])
+	}
+	for _, a := range []graph.RoomArchetype{graph.ArchetypeStart, graph.ArchetypeBoss, graph.ArchetypeSecret, graph.ArchetypeCheckpoint} {
+		if weights[a] != 0 {
+			t.Errorf("%s weight = %f, want 0", a, weights[a])
+		}
+	}
+}
+
+func TestGrammarSynthesizer_SizeWeights(t *testing.T) {
+	cfg := &Config{
+		RoomsMin:      30,
+		RoomsMax:      40,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+		Themes:        []string{"dungeon"},
+		SizeWeights:   []float64{0, 1, 1, 0, 0}, // S and M only
+	}
+
+	for seed := uint64(1); seed <= 5; seed++ {
+		cfg.Seed = seed
+		g, err := NewGrammarSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+		for id, room := range g.Rooms {
+			if id == "mid_hub" || id == "boss" {
+				continue // Fixed sizes
+			}
+			if room.Size != graph.SizeS && room.Size != graph.SizeM {
+				t.Errorf("seed %d: room %s has size %s, want S or M", seed, id, room.Size)
+			}
+		}
+	}
+}
+
+func TestGrammarSynthesizer_FloorBudget(t *testing.T) {
+	cfg := &Config{
+		RoomsMin:      30,
+		RoomsMax:      40,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		SecretDensity: 0.1,
+		OptionalRatio: 0.2,
+		Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+		Themes:        []string{"dungeon"},
+	}
+
+	floor := func(budget int) int {
+		cfg.FloorBudget = budget
+		total := 0
+		for seed := uint64(1); seed <= 5; seed++ {
+			cfg.Seed = seed
+			g, err := NewGrammarSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+			if err != nil {
+				t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+			}
+			for _, room := range g.Rooms {
+				total += RoomFloorTiles(room.Size)
+			}
+		}
+		return total / 5
+	}
+
+	unbounded, budgeted := floor(0), floor(1200)
+	if budgeted >= unbounded {
+		t.Errorf("mean room floor %d with a budget, want less than %d without", budgeted, unbounded)
+	}
+	if budgeted > 1200*(1-CorridorFloorShare)*1.1 {
+		t.Errorf("mean room floor %d, want about the budget's room share %.0f", budgeted, 1200*(1-CorridorFloorShare))
+	}
+}
+
+func TestFitFloorBudget(t *testing.T) {
+	g := graph.NewGraph(1)
+	_ = g.AddRoom(&graph.Room{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL})
+
+	// 500 room tiles less the boss leave 275 for 10 rooms: only XS and S fit
+	cfg := &Config{RoomsMax: 11, FloorBudget: 625}
+	weights := fitFloorBudget(g, cfg, defaultSizeWeights)
+	want := []float64{0.2, 0.3, 0, 0, 0}
+	for size := range want {
+		if weights[size] != want[size] {
+			t.Errorf("weights = %v, want %v", weights, want)
+			break
+		}
+	}
+	if defaultSizeWeights[graph.SizeM] == 0 {
+		t.Error("fitFloorBudget modified the default weights")
+	}
+
+	// Nothing fits: the smallest size is kept
+	cfg.FloorBudget = 100
+	weights = fitFloorBudget(g, cfg, defaultSizeWeights)
+	if weights[graph.SizeXS] == 0 || weights[graph.SizeS] != 0 {
+		t.Errorf("weights = %v, want XS only", weights)
+	}
+}
+
+// TestSynthesize_Exit verifies both synthesizers hang a single Exit room off
+// the Boss when configured, within the room count and branching limits.
+func TestSynthesize_Exit(t *testing.T) {
+	for _, name := range []string{"grammar", "template"} {
+		for seed := uint64(1); seed <= 10; seed++ {
+			cfg := &Config{
+				Seed:          seed,
+				RoomsMin:      15,
+				RoomsMax:      25,
+				BranchingAvg:  2.0,
+				BranchingMax:  3,
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+				Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				Exit:          true,
+			}
+
+			g, err := Get(name).Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+			if err != nil {
+				t.Fatalf("%s seed %d: Synthesize() error = %v", name, seed, err)
+			}
+			if n := len(g.Rooms); n < cfg.RoomsMin || n > cfg.RoomsMax {
+				t.Errorf("%s seed %d: %d rooms, want %d-%d", name, seed, n, cfg.RoomsMin, cfg.RoomsMax)
+			}
+
+			exits := 0
+			for _, room := range g.Rooms {
+				if room.Archetype == graph.ArchetypeExit {
+					exits++
+				}
+			}
+			exit, ok := g.Rooms[ExitRoomID]
+			if exits != 1 || !ok || exit.Archetype != graph.ArchetypeExit {
+				t.Fatalf("%s seed %d: %d exit rooms, want one with ID %q", name, seed, exits, ExitRoomID)
+			}
+			neighbors := g.Adjacency[ExitRoomID]
+			if len(neighbors) != 1 || g.Rooms[neighbors[0]].Archetype != graph.ArchetypeBoss {
+				t.Errorf("%s seed %d: exit neighbors = %v, want only the Boss", name, seed, neighbors)
+			}
+			for id, adj := range g.Adjacency {
+				if len(adj) > cfg.BranchingMax {
+					t.Errorf("%s seed %d: room %s has %d connections, exceeds max %d", name, seed, id, len(adj), cfg.BranchingMax)
+				}
+			}
+		}
+	}
+}
+
+// TestSynthesize_Vaults verifies both synthesizers add the configured vaults
+// as dead ends off the critical path, behind an outer and an inner key lock
+// whose keys are held outside every vault.
+func TestSynthesize_Vaults(t *testing.T) {
+	for _, name := range []string{"grammar", "template"} {
+		for seed := uint64(1); seed <= 10; seed++ {
+			cfg := &Config{
+				Seed:          seed,
+				RoomsMin:      15,
+				RoomsMax:      25,
+				BranchingAvg:  2.0,
+				BranchingMax:  4,
+				SecretDensity: 0.1,
+				OptionalRatio: 0.2,
+				Pacing:        PacingConfig{Curve: "LINEAR", Variance: 0.1},
+				Themes:        []string{"dungeon"},
+				Keys:          []KeyConfig{{Name: "silver", Count: 1}},
+				Exit:          true,
+				Vaults:        2,
+			}
+
+			g, err := Get(name).Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+			if err != nil {
+				t.Fatalf("%s seed %d: Synthesize() error = %v", name, seed, err)
+			}
+			if n := len(g.Rooms); n < cfg.RoomsMin || n > cfg.RoomsMax {
+				t.Errorf("%s seed %d: %d rooms, want %d-%d", name, seed, n, cfg.RoomsMin, cfg.RoomsMax)
+			}
+
+			path, err := criticalPath(g)
+			if err != nil {
+				t.Fatalf("%s seed %d: %v", name, seed, err)
+			}
+			reached, _ := g.ReachableWithInventory(path[0])
+			for n := 0; n < cfg.Vaults; n++ {
+				id := VaultID(n)
+				ante, chamber := g.Rooms[id+"_antechamber"], g.Rooms[id]
+				if ante == nil || chamber == nil {
+					t.Fatalf("%s seed %d: vault %s is missing rooms", name, seed, id)
+				}
+				if chamber.Tags[graph.VaultTag] != id || chamber.Tags["vault_role"] != VaultChamber {
+					t.Errorf("%s seed %d: chamber tags = %v", name, seed, chamber.Tags)
+				}
+				if adj := g.Adjacency[id]; len(adj) != 1 || adj[0] != ante.ID {
+					t.Errorf("%s seed %d: chamber neighbors = %v, want only %s", name, seed, adj, ante.ID)
+				}
+				for _, room := range []*graph.Room{ante, chamber} {
+					if !reached[room.ID] {
+						t.Errorf("%s seed %d: vault room %s cannot be opened", name, seed, room.ID)
+					}
+					if slices.Contains(path, room.ID) {
+						t.Errorf("%s seed %d: vault room %s is on the critical path", name, seed, room.ID)
+					}
+				}
+				for _, key := range []string{id + "_outer", id + "_inner"} {
+					held := false
+					for _, room := range g.Rooms {
+						if slices.Contains(room.Provides, graph.Capability{Type: "key", Value: key}) {
+							held = room.Tags[graph.VaultTag] == ""
+						}
+					}
+					if !held {
+						t.Errorf("%s seed %d: key %s is not held outside the vaults", name, seed, key)
+					}
+				}
+			}
+		}
+	}
+}
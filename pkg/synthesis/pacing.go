@@ -129,6 +129,23 @@ func (c *CustomCurve) Evaluate(progress float64) float64 {
 	return progress
 }
 
+// NewPacingCurve creates the curve named by a pacing config. An empty or
+// unknown curve name yields a linear curve.
+func NewPacingCurve(cfg PacingConfig) (PacingCurve, error) {
+	switch cfg.Curve {
+	case "LINEAR":
+		return &LinearCurve{}, nil
+	case "S_CURVE":
+		return NewSCurve(), nil
+	case "EXPONENTIAL":
+		return NewExponentialCurve(), nil
+	case "CUSTOM":
+		return NewCustomCurve(cfg.CustomPoints)
+	default:
+		return &LinearCurve{}, nil
+	}
+}
+
 // clamp ensures a value stays within [0.0, 1.0].
 func clamp(v float64) float64 {
 	if v < 0.0 {
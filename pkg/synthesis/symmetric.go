@@ -0,0 +1,389 @@
+package synthesis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// SymmetricSynthesizer generates mirrored arena graphs for competitive modes.
+// It builds one team's half around a contested center room, then mirrors that
+// half for the opposing team, so both teams see identical topology, room
+// types, difficulties and themes.
+//
+// Architecture: Mirrored Halves
+//   - Two Start rooms ("a_start" and "b_start"), one per team
+//   - One shared Boss room ("arena") acting as the contested objective
+//   - A lane of rooms from each Start to the arena, with side branches
+//   - A flank route from a side branch into the arena when branching allows
+//
+// Every team room is tagged with "team" ("a" or "b") and "mirror" (the ID of
+// its counterpart); the arena is tagged with team "neutral". Embedders and
+// validators use these tags to mirror and check the layout.
+//
+// Keys are not supported: competitive maps have no lock progression.
+type SymmetricSynthesizer struct {
+	maxRetries int // Maximum attempts to satisfy constraints
+}
+
+// NewSymmetricSynthesizer creates a new symmetric arena synthesizer.
+func NewSymmetricSynthesizer() *SymmetricSynthesizer {
+	return &SymmetricSynthesizer{
+		maxRetries: 10,
+	}
+}
+
+// Name returns the synthesizer identifier.
+func (s *SymmetricSynthesizer) Name() string {
+	return "symmetric"
+}
+
+// Synthesize generates a mirrored arena graph.
+func (s *SymmetricSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
+	if !cfg.validRoomBounds() {
+		return nil, fmt.Errorf("invalid room bounds: min=%d max=%d", cfg.RoomsMin, cfg.RoomsMax)
+	}
+	if cfg.RoomsMin == cfg.RoomsMax && cfg.RoomsMin%2 == 0 {
+		return nil, fmt.Errorf("symmetric graphs need an odd room count, bounds allow only %d", cfg.RoomsMin)
+	}
+	if cfg.BranchingMax < 2 || cfg.BranchingMax > 5 {
+		return nil, fmt.Errorf("invalid branching max: %d", cfg.BranchingMax)
+	}
+	if len(cfg.Keys) > 0 {
+		return nil, fmt.Errorf("symmetric graphs do not support keys")
+	}
+
+	var lastErr error
+	attempts := cfg.attempts(s.maxRetries)
+	for attempt := 0; attempt < attempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		g, err := s.tryGenerate(rng, cfg)
+		if err == nil {
+			return g, nil
+		}
+		lastErr = err
+	}
+
+	return nil, &RetryError{Attempts: attempts, Err: lastErr}
+}
+
+// tryGenerate attempts a single generation pass.
+func (s *SymmetricSynthesizer) tryGenerate(rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
+	// Step 1: Pick an odd total room count: two halves plus the arena
+	total := rng.IntRange(cfg.RoomsMin, cfg.RoomsMax)
+	if total%2 == 0 {
+		if total+1 <= cfg.RoomsMax {
+			total++
+		} else {
+			total--
+		}
+	}
+	halfSize := (total - 1) / 2
+
+	// Step 2: Build one team's half around the arena
+	half, err := s.buildHalf(rng, cfg, halfSize)
+	if err != nil {
+		return nil, fmt.Errorf("building half: %w", err)
+	}
+
+	// Step 3: Assign difficulty from Start towards the arena
+	if err := assignDifficultyTemplate(half, rng, cfg); err != nil {
+		return nil, fmt.Errorf("assigning difficulty: %w", err)
+	}
+
+	// Step 4: Place checkpoints on the lane if configured
+	if err := insertCheckpoints(half, cfg); err != nil {
+		return nil, fmt.Errorf("inserting checkpoints: %w", err)
+	}
+
+	// Step 5: Assign themes to the half
+	if err := assignThemes(half, cfg.Themes, rng); err != nil {
+		return nil, fmt.Errorf("assigning themes: %w", err)
+	}
+
+	// Step 6: Express part of the difficulty through the environment
+	assignEnvironment(half, cfg.EnvironmentRatio, rng)
+
+	// Step 7: Mirror the half for the opposing team
+	g, err := mirrorHalf(half, cfg.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("mirroring half: %w", err)
+	}
+
+	// Step 8: Validate hard constraints
+	if err := validateSymmetricGraph(g, cfg); err != nil {
+		return nil, fmt.Errorf("constraint validation failed: %w", err)
+	}
+
+	return g, nil
+}
+
+// buildHalf creates one team's rooms: a Start room, a lane to the arena, side
+// branches up to halfSize rooms, and an optional flank into the arena. The
+// returned graph includes the arena room.
+func (s *SymmetricSynthesizer) buildHalf(rng *rng.RNG, cfg *Config, halfSize int) (*graph.Graph, error) {
+	h := graph.NewGraph(cfg.Seed)
+
+	start := &graph.Room{
+		ID:        "start",
+		Archetype: graph.ArchetypeStart,
+		Size:      graph.SizeM,
+		Tags:      map[string]string{"type": "entrance"},
+	}
+	arena := &graph.Room{
+		ID:        "arena",
+		Archetype: graph.ArchetypeBoss,
+		Size:      graph.SizeXL,
+		Tags:      map[string]string{"type": "contested"},
+	}
+	for _, room := range []*graph.Room{start, arena} {
+		if err := h.AddRoom(room); err != nil {
+			return nil, fmt.Errorf("adding %s room: %w", room.ID, err)
+		}
+	}
+
+	connect := func(from, to string, connType graph.ConnectorType, visibility graph.VisibilityType) error {
+		return h.AddConnector(&graph.Connector{
+			ID:            fmt.Sprintf("conn_%s_%s", from, to),
+			From:          from,
+			To:            to,
+			Type:          connType,
+			Cost:          1.0,
+			Visibility:    visibility,
+			Bidirectional: true,
+		})
+	}
+
+	// Lane: Start → lane_1 → ... → lane_N → arena
+	laneLength := max(2, halfSize/3)
+	prev := start.ID
+	for i := 1; i <= laneLength; i++ {
+		archetype := graph.ArchetypeCorridor
+		if i%2 == 0 {
+			archetype = graph.ArchetypeHub
+		}
+		lane := &graph.Room{
+			ID:        fmt.Sprintf("lane_%d", i),
+			Archetype: archetype,
+			Size:      graph.SizeM,
+			Tags:      map[string]string{"type": "lane"},
+		}
+		if err := h.AddRoom(lane); err != nil {
+			return nil, err
+		}
+		if err := connect(prev, lane.ID, s.pickConnectorType(rng), graph.VisibilityNormal); err != nil {
+			return nil, err
+		}
+		prev = lane.ID
+	}
+	if err := connect(prev, arena.ID, graph.TypeDoor, graph.VisibilityNormal); err != nil {
+		return nil, err
+	}
+
+	// Side branches until the half is full. The arena is excluded so its
+	// degree stays within BranchingMax once both halves attach.
+	count := 1 + laneLength
+	for count < halfSize {
+		candidates := make([]*graph.Room, 0, len(h.Rooms))
+		for _, id := range getSortedRoomIDs(h) {
+			room := h.Rooms[id]
+			if room.ID != arena.ID && room.Archetype != graph.ArchetypeSecret &&
+				len(h.Adjacency[id]) < cfg.BranchingMax {
+				candidates = append(candidates, room)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no rooms with capacity available at %d/%d rooms", count, halfSize)
+		}
+		parent := candidates[rng.Intn(len(candidates))]
+
+		room := &graph.Room{
+			ID:        fmt.Sprintf("room_%d", count),
+			Archetype: s.pickRoomArchetype(rng),
+			Size:      s.pickRoomSize(rng),
+			Tags:      map[string]string{"branch_from": parent.ID},
+		}
+		connType, visibility := s.pickConnectorType(rng), graph.VisibilityNormal
+		if parent.ID != start.ID && rng.Float64() < cfg.SecretDensity*0.5 {
+			room.Archetype = graph.ArchetypeSecret
+			room.Size = graph.SizeS
+			room.Tags["secret"] = "true"
+			connType, visibility = graph.TypeHidden, graph.VisibilitySecret
+		}
+		if err := h.AddRoom(room); err != nil {
+			return nil, err
+		}
+		if err := connect(parent.ID, room.ID, connType, visibility); err != nil {
+			return nil, err
+		}
+		count++
+	}
+
+	// Flank: a second route into the arena from a side branch, which gives
+	// each team a loop. Needs room for two connections per half on the arena.
+	if cfg.BranchingMax >= 4 {
+		for _, id := range getSortedRoomIDs(h) {
+			room := h.Rooms[id]
+			if room.Tags["branch_from"] == "" || room.Archetype == graph.ArchetypeSecret ||
+				len(h.Adjacency[id]) >= cfg.BranchingMax {
+				continue
+			}
+			room.Tags["flank"] = "true"
+			if err := connect(id, arena.ID, graph.TypeCorridor, graph.VisibilityNormal); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return h, nil
+}
+
+// mirrorHalf builds the full arena graph from one half: every room except the
+// arena is copied once per team with an "a_" or "b_" prefix, and connectors
+// are copied with their endpoints mapped the same way.
+func mirrorHalf(half *graph.Graph, seed uint64) (*graph.Graph, error) {
+	g := graph.NewGraph(seed)
+
+	teamID := func(team, id string) string {
+		if id == "arena" {
+			return id
+		}
+		return team + "_" + id
+	}
+	other := map[string]string{"a": "b", "b": "a"}
+
+	for _, id := range getSortedRoomIDs(half) {
+		src := half.Rooms[id]
+		teams := []string{"a", "b"}
+		if id == "arena" {
+			teams = []string{"neutral"}
+		}
+		for _, team := range teams {
+			room := *src
+			room.ID = teamID(team, id)
+			room.Tags = make(map[string]string, len(src.Tags)+2)
+			for k, v := range src.Tags {
+				room.Tags[k] = v
+			}
+			if v := src.Tags["branch_from"]; v != "" {
+				room.Tags["branch_from"] = teamID(team, v)
+			}
+			room.Tags["team"] = team
+			if team != "neutral" {
+				room.Tags["mirror"] = teamID(other[team], id)
+			}
+			room.Requirements = append([]graph.Requirement(nil), src.Requirements...)
+			room.Provides = append([]graph.Capability(nil), src.Provides...)
+			if err := g.AddRoom(&room); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	connIDs := make([]string, 0, len(half.Connectors))
+	for id := range half.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		src := half.Connectors[id]
+		for _, team := range []string{"a", "b"} {
+			conn := *src
+			conn.ID = team + "_" + src.ID
+			conn.From = teamID(team, src.From)
+			conn.To = teamID(team, src.To)
+			if err := g.AddConnector(&conn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// validateSymmetricGraph checks hard constraints of a mirrored arena graph.
+func validateSymmetricGraph(g *graph.Graph, cfg *Config) error {
+	if len(g.Rooms) < cfg.RoomsMin || len(g.Rooms) > cfg.RoomsMax {
+		return fmt.Errorf("room count %d outside bounds [%d, %d]", len(g.Rooms), cfg.RoomsMin, cfg.RoomsMax)
+	}
+
+	if !g.IsConnected() {
+		return fmt.Errorf("graph is not connected")
+	}
+
+	starts, bosses := 0, 0
+	for _, room := range g.Rooms {
+		switch room.Archetype {
+		case graph.ArchetypeStart:
+			starts++
+		case graph.ArchetypeBoss:
+			bosses++
+		}
+	}
+	if starts != 2 || bosses != 1 {
+		return fmt.Errorf("expected 2 Start rooms and 1 Boss room, got %d and %d", starts, bosses)
+	}
+
+	if len(g.Adjacency["arena"]) > cfg.BranchingMax {
+		return fmt.Errorf("arena has %d connections, exceeds max %d", len(g.Adjacency["arena"]), cfg.BranchingMax)
+	}
+
+	if err := validateAccessibility(g, cfg); err != nil {
+		return fmt.Errorf("accessibility: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SymmetricSynthesizer) pickRoomArchetype(rng *rng.RNG) graph.RoomArchetype {
+	// Arena maps favour open fighting spaces over puzzles
+	weights := []float64{
+		0.0,  // Start (never random)
+		0.0,  // Boss (never random)
+		0.15, // Treasure
+		0.0,  // Puzzle
+		0.2,  // Hub
+		0.3,  // Corridor
+		0.0,  // Secret (handled separately)
+		0.25, // Optional
+		0.0,  // Vendor
+		0.1,  // Shrine
+		0.0,  // Checkpoint
+	}
+
+	return graph.RoomArchetype(rng.WeightedChoice(weights))
+}
+
+func (s *SymmetricSynthesizer) pickRoomSize(rng *rng.RNG) graph.RoomSize {
+	weights := []float64{
+		0.1,  // XS
+		0.35, // S
+		0.35, // M
+		0.2,  // L
+		0.0,  // XL (reserved for the arena)
+	}
+
+	return graph.RoomSize(rng.WeightedChoice(weights))
+}
+
+func (s *SymmetricSynthesizer) pickConnectorType(rng *rng.RNG) graph.ConnectorType {
+	// Two-way door or corridor only, so neither team gets a one-way shortcut
+	if rng.Float64() < 0.5 {
+		return graph.TypeDoor
+	}
+	return graph.TypeCorridor
+}
+
+// init registers the symmetric synthesizer.
+func init() {
+	Register("symmetric", NewSymmetricSynthesizer())
+}
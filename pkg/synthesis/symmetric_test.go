@@ -0,0 +1,83 @@
+package synthesis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+func symmetricTestConfig(seed uint64) *Config {
+	return &Config{
+		Seed:          seed,
+		RoomsMin:      20,
+		RoomsMax:      30,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		SecretDensity: 0.3,
+		OptionalRatio: 0.2,
+		Pacing: PacingConfig{
+			Curve:    "LINEAR",
+			Variance: 0.1,
+		},
+		Themes: []string{"dungeon", "crypt"},
+	}
+}
+
+// TestSymmetricSynthesizer_Mirrored verifies both teams get identical halves
+// joined at a single contested Boss room.
+func TestSymmetricSynthesizer_Mirrored(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		cfg := symmetricTestConfig(seed)
+		g, err := NewSymmetricSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		if len(g.Rooms) < cfg.RoomsMin || len(g.Rooms) > cfg.RoomsMax || len(g.Rooms)%2 == 0 {
+			t.Errorf("seed %d: expected an odd room count in [%d, %d], got %d", seed, cfg.RoomsMin, cfg.RoomsMax, len(g.Rooms))
+		}
+
+		starts := 0
+		for id, room := range g.Rooms {
+			if room.Archetype == graph.ArchetypeStart {
+				starts++
+			}
+			if room.Tags["team"] != "a" {
+				continue
+			}
+			twin := g.Rooms[room.Tags["mirror"]]
+			if twin == nil || twin.Tags["team"] != "b" || twin.Tags["mirror"] != id {
+				t.Fatalf("seed %d: room %s has no team B mirror", seed, id)
+			}
+			if twin.Archetype != room.Archetype || twin.Size != room.Size ||
+				twin.Difficulty != room.Difficulty || twin.Tags["biome"] != room.Tags["biome"] {
+				t.Errorf("seed %d: rooms %s and %s differ", seed, id, twin.ID)
+			}
+		}
+		if starts != 2 {
+			t.Errorf("seed %d: expected 2 Start rooms, got %d", seed, starts)
+		}
+
+		arena := g.Rooms["arena"]
+		if arena == nil || arena.Archetype != graph.ArchetypeBoss || arena.Tags["team"] != "neutral" {
+			t.Fatalf("seed %d: missing neutral arena Boss room", seed)
+		}
+		if len(g.Adjacency["arena"]) > cfg.BranchingMax {
+			t.Errorf("seed %d: arena has %d connections, max %d", seed, len(g.Adjacency["arena"]), cfg.BranchingMax)
+		}
+		if !g.IsConnected() {
+			t.Errorf("seed %d: graph is not connected", seed)
+		}
+	}
+}
+
+// TestSymmetricSynthesizer_RejectsKeys verifies lock progression is refused.
+func TestSymmetricSynthesizer_RejectsKeys(t *testing.T) {
+	cfg := symmetricTestConfig(1)
+	cfg.Keys = []KeyConfig{{Name: "silver", Count: 1}}
+	if _, err := NewSymmetricSynthesizer().Synthesize(context.Background(), rng.NewRNG(1, "test", nil), cfg); err == nil {
+		t.Error("expected error for keys in a symmetric graph")
+	}
+}
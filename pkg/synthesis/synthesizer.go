@@ -12,16 +12,76 @@ import (
 // Config contains the configuration parameters needed for graph synthesis.
 // This is a subset of dungeon.Config focused on synthesis concerns.
 type Config struct {
-	Seed          uint64
-	RoomsMin      int
-	RoomsMax      int
-	BranchingAvg  float64
-	BranchingMax  int
-	SecretDensity float64
-	OptionalRatio float64
-	Keys          []KeyConfig
-	Pacing        PacingConfig // Difficulty curve configuration
-	Themes        []string     // Theme names for biome assignment
+	Seed             uint64
+	RoomsMin         int
+	RoomsMax         int
+	BranchingAvg     float64
+	BranchingMax     int
+	SecretDensity    float64
+	OptionalRatio    float64
+	Keys             []KeyConfig
+	Archetypes       []ArchetypeTarget // Target room archetype distribution (grammar synthesizer only)
+	SizeWeights      []float64         // Room size weights indexed by graph.RoomSize; nil uses the defaults (grammar synthesizer only)
+	FloorBudget      int               // Carved floor tiles for rooms and corridors together; 0 = unlimited (grammar synthesizer only)
+	Pacing           PacingConfig      // Difficulty curve configuration
+	Themes           []string          // Theme names for biome assignment
+	Accessibility    AccessibilityConfig
+	EnvironmentRatio float64 // Share of combat difficulty carried by hazards, darkness and slow terrain
+	HallRatio        float64 // Share of rooms other than Start and Boss given a long hall footprint (grammar and template synthesizers only)
+	Zoned            bool    // Mega-dungeon laid out zone by zone; allows up to MaxZonedRooms rooms
+	BacktrackPasses  int     // Abilities the route doubles back for, 0-MaxBacktrackPasses (grammar synthesizer only)
+	Exit             bool    // Hang an Exit room off the Boss (grammar and template synthesizers only)
+	Vaults           int     // Treasure vaults to add, 0-MaxVaults (grammar and template synthesizers only)
+	MaxAttempts      int     // Graphs to try before giving up; 0 = the synthesizer's default
+}
+
+// attempts returns the number of graphs to try, cfg.MaxAttempts or def.
+func (c *Config) attempts(def int) int {
+	if c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return def
+}
+
+// RetryError reports that a synthesizer gave up after every attempt to
+// satisfy the constraints failed. Err is the failure of the last attempt.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed to satisfy constraints after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Room count bounds accepted by the synthesizers. Zoned mega-dungeons are
+// embedded and carved zone by zone, so they may be much larger.
+const (
+	MinRooms      = 10
+	MaxRooms      = 300
+	MaxZonedRooms = 2000
+)
+
+// CoreRooms is the number of rooms the grammar synthesizer starts from: the
+// Start, Mid and Boss trio.
+const CoreRooms = 3
+
+// CorridorFloorShare is the share of Config.FloorBudget left for corridors;
+// rooms are sized to fit the rest.
+const CorridorFloorShare = 0.2
+
+// validRoomBounds reports whether the configured room count bounds are
+// within range.
+func (c *Config) validRoomBounds() bool {
+	limit := MaxRooms
+	if c.Zoned {
+		limit = MaxZonedRooms
+	}
+	return c.RoomsMin >= MinRooms && c.RoomsMax <= limit && c.RoomsMin <= c.RoomsMax
 }
 
 // PacingConfig defines the difficulty curve for the dungeon.
@@ -31,10 +91,30 @@ type PacingConfig struct {
 	CustomPoints [][2]float64 // For CUSTOM curve
 }
 
+// AccessibilityConfig enables optional accessibility guarantees.
+// Zero values disable every guarantee.
+type AccessibilityConfig struct {
+	NoRequiredSecrets           bool // Keys are never placed behind hidden connectors or in secret rooms
+	MaxCombatBetweenCheckpoints int  // Max combat rooms on the critical path between checkpoints (0 = unlimited)
+	LowBacktracking             bool // Keys are placed on or adjacent to the critical path
+}
+
+// ArchetypeTarget is a target for how many rooms have one archetype: a
+// share of all rooms, or an exact count when Count is set.
+type ArchetypeTarget struct {
+	Archetype graph.RoomArchetype
+	Fraction  float64
+	Count     int
+}
+
 // KeyConfig defines a key/lock configuration.
 type KeyConfig struct {
-	Name  string
-	Count int
+	Name       string
+	Count      int
+	Opens      []string // Other keys whose locks this master key also opens
+	Requires   []string // Other keys this key's locks also need
+	RequireAny bool     // Locks open with any one of the key and Requires
+	Consumable bool     // Small keys: each is used up by its door; at most Count doors
 }
 
 // GraphSynthesizer is the interface for all graph synthesis strategies.
@@ -48,6 +128,8 @@ type KeyConfig struct {
 // Available implementations:
 //   - "grammar" (GrammarSynthesizer): Production rule-based, flexible, hub-and-spoke
 //   - "template" (TemplateSynthesizer): Template-stitching, predictable, architectural
+//   - "symmetric" (SymmetricSynthesizer): Mirrored arena halves for competitive modes
+//   - "wave" (WaveSynthesizer): Concentric defensive rings around a hub for horde modes
 //
 // Contract:
 // - Must use provided RNG for all randomness
@@ -76,13 +76,14 @@ func (s *TemplateSynthesizer) Name() string {
 // Synthesize generates a graph by stitching templates together.
 func (s *TemplateSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
 	// Basic config validation
-	if cfg.RoomsMin < 10 || cfg.RoomsMax > 300 || cfg.RoomsMin > cfg.RoomsMax {
+	if !cfg.validRoomBounds() {
 		return nil, fmt.Errorf("invalid room bounds: min=%d max=%d", cfg.RoomsMin, cfg.RoomsMax)
 	}
 
 	// Try multiple attempts if constraints fail
 	var lastErr error
-	for attempt := 0; attempt < s.maxRetries; attempt++ {
+	attempts := cfg.attempts(s.maxRetries)
+	for attempt := 0; attempt < attempts; attempt++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -96,7 +97,7 @@ func (s *TemplateSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg
 		lastErr = err
 	}
 
-	return nil, fmt.Errorf("failed to satisfy constraints after %d attempts: %w", s.maxRetries, lastErr)
+	return nil, &RetryError{Attempts: attempts, Err: lastErr}
 }
 
 // tryGenerate attempts a single generation pass.
@@ -129,6 +130,10 @@ func (s *TemplateSynthesizer) tryGenerate(ctx context.Context, rng *rng.RNG, cfg
 
 	// Step 3: Connect Start to Boss via Mid templates
 	targetSize := rng.IntRange(cfg.RoomsMin, cfg.RoomsMax)
+	if cfg.Exit {
+		targetSize-- // Leave room for the Exit
+	}
+	targetSize -= cfg.Vaults * VaultRooms // And for the vaults
 	lastAttachment := startAttachments[rng.Intn(len(startAttachments))]
 
 	for roomCount < targetSize {
@@ -210,12 +215,33 @@ func (s *TemplateSynthesizer) tryGenerate(ctx context.Context, rng *rng.RNG, cfg
 		return nil, fmt.Errorf("assigning difficulty: %w", err)
 	}
 
-	// Step 6: Assign themes
+	// Step 6: Place checkpoints on the critical path if configured
+	if err := insertCheckpoints(g, cfg); err != nil {
+		return nil, fmt.Errorf("inserting checkpoints: %w", err)
+	}
+
+	// Step 7: Hang the Exit room off the Boss if configured
+	if err := insertExit(g, cfg); err != nil {
+		return nil, fmt.Errorf("inserting exit: %w", err)
+	}
+
+	// Step 8: Add treasure vaults off the critical path if configured
+	if err := insertVaults(g, rng, cfg); err != nil {
+		return nil, fmt.Errorf("inserting vaults: %w", err)
+	}
+
+	// Step 9: Assign themes
 	if err := assignThemes(g, cfg.Themes, rng); err != nil {
 		return nil, fmt.Errorf("assigning themes: %w", err)
 	}
 
-	// Step 7: Validate
+	// Step 10: Express part of the difficulty through the environment
+	assignEnvironment(g, cfg.EnvironmentRatio, rng)
+
+	// Step 11: Give some rooms long hall footprints
+	assignFootprints(g, cfg.HallRatio, rng)
+
+	// Step 12: Validate
 	if err := validateTemplateGraph(g, cfg); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
@@ -423,7 +449,7 @@ func assignDifficultyTemplate(g *graph.Graph, rng *rng.RNG, cfg *Config) error {
 	}
 
 	// Create pacing curve
-	curve, err := createPacingCurveFromConfig(cfg.Pacing)
+	curve, err := NewPacingCurve(cfg.Pacing)
 	if err != nil {
 		return err
 	}
@@ -466,22 +492,6 @@ func assignDifficultyTemplate(g *graph.Graph, rng *rng.RNG, cfg *Config) error {
 	return nil
 }
 
-// createPacingCurveFromConfig creates a curve from config.
-func createPacingCurveFromConfig(cfg PacingConfig) (PacingCurve, error) {
-	switch cfg.Curve {
-	case "LINEAR":
-		return &LinearCurve{}, nil
-	case "S_CURVE":
-		return NewSCurve(), nil
-	case "EXPONENTIAL":
-		return NewExponentialCurve(), nil
-	case "CUSTOM":
-		return NewCustomCurve(cfg.CustomPoints)
-	default:
-		return &LinearCurve{}, nil
-	}
-}
-
 // validateTemplateGraph checks hard constraints.
 func validateTemplateGraph(g *graph.Graph, cfg *Config) error {
 	// Check room count
@@ -512,6 +522,11 @@ func validateTemplateGraph(g *graph.Graph, cfg *Config) error {
 		return fmt.Errorf("missing Boss room")
 	}
 
+	// Check configured accessibility guarantees
+	if err := validateAccessibility(g, cfg); err != nil {
+		return fmt.Errorf("accessibility: %w", err)
+	}
+
 	return nil
 }
 
@@ -15,10 +15,12 @@ import (
 // - Multi-theme: Creates clusters using graph regions with smooth transitions
 //
 // For multi-theme dungeons, the algorithm:
-// 1. Selects theme seed rooms (distributed across the graph)
-// 2. Grows regions from seed rooms using breadth-first expansion
-// 3. Prefers assigning same theme to connected rooms for smooth transitions
-// 4. Tags each room with "biome:themename"
+//  1. Themes whole graph regions (see graph.Regions) when there are at least as
+//     many as themes, so themes change at gates and chokepoints
+//  2. Otherwise selects theme seed rooms (distributed across the graph)
+//  3. Grows regions from seed rooms using breadth-first expansion
+//  4. Prefers assigning same theme to connected rooms for smooth transitions
+//  5. Tags each room with "biome:themename"
 func assignThemes(g *graph.Graph, themes []string, rng *rng.RNG) error {
 	if len(themes) == 0 {
 		return fmt.Errorf("at least one theme must be specified")
@@ -48,9 +50,19 @@ func assignSingleTheme(g *graph.Graph, theme string) error {
 	return nil
 }
 
+// themeRegionMinRooms is the fewest rooms a chokepoint must cut off on both
+// sides to start a new theme zone.
+const themeRegionMinRooms = 3
+
 // assignMultiTheme creates theme clusters across the graph with smooth transitions.
-// Uses region growing from seed rooms to create cohesive themed areas.
+// Uses the graph's regions when there are enough of them, and otherwise region
+// growing from seed rooms, to create cohesive themed areas.
 func assignMultiTheme(g *graph.Graph, themes []string, rng *rng.RNG) error {
+	if assignRegionThemes(g, themes, rng) {
+		markBiomeTransitions(g)
+		return nil
+	}
+
 	// Track which rooms have been assigned a theme
 	assigned := make(map[string]bool)
 	themeAssignments := make(map[string]string) // roomID -> theme
@@ -139,9 +151,80 @@ func assignMultiTheme(g *graph.Graph, themes []string, rng *rng.RNG) error {
 		room.Tags["biome"] = theme
 	}
 
+	// Step 5: Mark rooms on zone borders so later stages can blend them
+	markBiomeTransitions(g)
+
 	return nil
 }
 
+// assignRegionThemes themes the graph's regions, bounded by gates and
+// chokepoints, in runs of neighbouring regions ordered by depth from Start,
+// so each theme covers a stretch of the dungeon's progression. Themes are
+// shuffled first. Reports false, leaving the graph untouched, when there are
+// fewer regions than themes.
+func assignRegionThemes(g *graph.Graph, themes []string, rng *rng.RNG) bool {
+	regions := g.Regions(graph.RegionOptions{MinRooms: themeRegionMinRooms})
+	if len(regions) < len(themes) {
+		return false
+	}
+
+	// Unreachable regions (depth -1) go last
+	sort.SliceStable(regions, func(i, j int) bool {
+		di, dj := regions[i].Depth, regions[j].Depth
+		if (di < 0) != (dj < 0) {
+			return dj < 0
+		}
+		return di < dj
+	})
+
+	order := make([]string, len(themes))
+	copy(order, themes)
+	rng.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	for i, region := range regions {
+		theme := order[i*len(order)/len(regions)]
+		for _, id := range region.Rooms {
+			room := g.Rooms[id]
+			if room.Tags == nil {
+				room.Tags = make(map[string]string)
+			}
+			room.Tags["biome"] = theme
+		}
+	}
+	return true
+}
+
+// markBiomeTransitions tags every room that borders another biome with
+// "biome_blend", naming the neighbouring biome it should blend towards: the
+// one shared by the most neighbours, ties broken by name. Rooms whose
+// neighbours all share their biome get no tag.
+func markBiomeTransitions(g *graph.Graph) {
+	for _, id := range getSortedRoomIDs(g) {
+		room := g.Rooms[id]
+		biome := room.Tags["biome"]
+
+		counts := make(map[string]int)
+		for _, neighborID := range g.Adjacency[id] {
+			other := g.Rooms[neighborID].Tags["biome"]
+			if other != "" && other != biome {
+				counts[other]++
+			}
+		}
+
+		blend := ""
+		for other, n := range counts {
+			if blend == "" || n > counts[blend] || (n == counts[blend] && other < blend) {
+				blend = other
+			}
+		}
+		if blend != "" {
+			room.Tags["biome_blend"] = blend
+		}
+	}
+}
+
 // findFrontier returns rooms adjacent to the current theme's territory but not yet assigned.
 // These are candidates for expanding the theme region.
 func findFrontier(g *graph.Graph, theme string, assignments map[string]string, assigned map[string]bool) []string {
@@ -166,6 +249,9 @@ func findFrontier(g *graph.Graph, theme string, assignments map[string]string, a
 		}
 	}
 
+	// Sort so the caller's random pick is deterministic
+	sort.Strings(frontier)
+
 	return frontier
 }
 
@@ -116,6 +116,46 @@ func TestAssignThemes_ConnectedRoomsPreferSameTheme(t *testing.T) {
 	t.Logf("Theme transitions in linear graph: %d/%d", transitions, len(roomIDs)-1)
 }
 
+// TestAssignThemes_Regions verifies themes change only between regions and
+// follow the chain in order when the graph has enough regions.
+func TestAssignThemes_Regions(t *testing.T) {
+	// Every corridor of a 20-room chain past the third room is a chokepoint
+	g := createTestGraph(20)
+	rngInst := rng.NewRNG(12345, "test", nil)
+
+	themes := []string{"forest", "cave", "ruins"}
+	if err := assignThemes(g, themes, rngInst); err != nil {
+		t.Fatalf("assignThemes() error = %v", err)
+	}
+
+	// Each theme covers one stretch of the chain
+	seen := map[string]bool{}
+	prev := ""
+	for i := 0; i < 20; i++ {
+		theme := g.Rooms[roomID(i)].Tags["biome"]
+		if theme != prev {
+			if seen[theme] {
+				t.Errorf("Theme %q returns at %s", theme, roomID(i))
+			}
+			seen[theme] = true
+			prev = theme
+		}
+	}
+	if len(seen) != len(themes) {
+		t.Errorf("Expected %d themes along the chain, got %v", len(themes), seen)
+	}
+
+	// Regions are never split between themes
+	for _, region := range g.Regions(graph.RegionOptions{MinRooms: themeRegionMinRooms}) {
+		for _, id := range region.Rooms[1:] {
+			if g.Rooms[id].Tags["biome"] != g.Rooms[region.Rooms[0]].Tags["biome"] {
+				t.Errorf("Region %d has rooms in several themes", region.ID)
+				break
+			}
+		}
+	}
+}
+
 // TestAssignThemes_EmptyGraph verifies handling of edge case.
 func TestAssignThemes_EmptyGraph(t *testing.T) {
 	g := graph.NewGraph(12345)
@@ -305,3 +345,32 @@ func createLinearGraph(n int) *graph.Graph {
 func roomID(i int) string {
 	return "room" + string(rune('0'+i))
 }
+
+// TestMarkBiomeTransitions verifies border rooms are tagged with the
+// neighbouring biome they blend towards.
+func TestMarkBiomeTransitions(t *testing.T) {
+	g := graph.NewGraph(1)
+	biomes := map[string]string{"a": "crypt", "b": "crypt", "c": "fungal", "d": "arcane", "e": "fungal"}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		_ = g.AddRoom(&graph.Room{ID: id, Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Tags: map[string]string{"biome": biomes[id]}})
+	}
+	// a - b - c, b - d, b - e
+	for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"b", "d"}, {"b", "e"}} {
+		_ = g.AddConnector(&graph.Connector{ID: pair[0] + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true})
+	}
+
+	markBiomeTransitions(g)
+
+	want := map[string]string{
+		"a": "",       // Only crypt neighbours
+		"b": "fungal", // Two fungal neighbours outweigh one arcane
+		"c": "crypt",
+		"d": "crypt",
+		"e": "crypt",
+	}
+	for id, blend := range want {
+		if got := g.Rooms[id].Tags["biome_blend"]; got != blend {
+			t.Errorf("room %s biome_blend = %q, want %q", id, got, blend)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package synthesis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// MaxVaults is the largest number of treasure vaults.
+const MaxVaults = 3
+
+// VaultRooms is the number of rooms each vault adds: a guarded antechamber
+// and the treasure chamber behind it.
+const VaultRooms = 2
+
+// VaultKeyPrefix starts the names of the keys that open vaults, which
+// configured keys may not use.
+const VaultKeyPrefix = "vault_"
+
+// Vault room roles, the values of a vault room's "vault_role" tag.
+const (
+	VaultAntechamber = "antechamber"
+	VaultChamber     = "chamber"
+)
+
+// VaultID returns the ID of vault n, also the ID of its treasure chamber and
+// the value of its rooms' graph.VaultTag.
+func VaultID(n int) string {
+	return fmt.Sprintf("vault_%d", n)
+}
+
+// insertVaults adds cfg.Vaults treasure vaults: compact dead ends of a
+// guarded antechamber and a treasure chamber, each behind its own key lock.
+// The outer key opens the antechamber and the inner key the chamber; both
+// are held by rooms outside every vault that can be reached with the keys
+// obtainable before the vault, so a vault can always be opened but is never
+// needed to finish the dungeon. Vault rooms are tagged graph.VaultTag with
+// the vault's ID and provide nothing, so the critical path never runs
+// through them.
+//
+// Vaults hang off rooms with a free connection other than Start, Boss and
+// Exit, preferring rooms off the critical path, and off Start when no such
+// room is left. Key holders follow the accessibility settings, see
+// vaultKeyHolders.
+func insertVaults(g *graph.Graph, rng *rng.RNG, cfg *Config) error {
+	if cfg.Vaults <= 0 {
+		return nil
+	}
+	if cfg.Vaults > MaxVaults {
+		return fmt.Errorf("at most %d vaults, got %d", MaxVaults, cfg.Vaults)
+	}
+	if cfg.BranchingMax < 3 {
+		return fmt.Errorf("vaults need BranchingMax >= 3, got %d", cfg.BranchingMax)
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return err
+	}
+	onPath := make(map[string]bool, len(path))
+	for _, id := range path {
+		onPath[id] = true
+	}
+
+	for n := 0; n < cfg.Vaults; n++ {
+		// Anchor the vault off the critical path where there is room, or off
+		// Start when no other room has a free connection
+		var anchors, offPath, start []*graph.Room
+		for _, id := range getSortedRoomIDs(g) {
+			room := g.Rooms[id]
+			if len(g.Adjacency[id]) >= maxConnections(room, cfg) {
+				continue
+			}
+			if room.Archetype == graph.ArchetypeStart {
+				start = append(start, room)
+			}
+			if !vaultAnchor(room) {
+				continue
+			}
+			anchors = append(anchors, room)
+			if !onPath[id] {
+				offPath = append(offPath, room)
+			}
+		}
+		if len(offPath) > 0 {
+			anchors = offPath
+		}
+		if len(anchors) == 0 {
+			anchors = start
+		}
+		if len(anchors) == 0 {
+			return fmt.Errorf("no room with a free connection for vault %d", n)
+		}
+		anchor := anchors[rng.Intn(len(anchors))]
+
+		holders := vaultKeyHolders(g, cfg, path)
+		if len(holders) == 0 {
+			return fmt.Errorf("no room left for the keys of vault %d", n)
+		}
+
+		id := VaultID(n)
+		outer := graph.Requirement{Type: "key", Value: id + "_outer"}
+		inner := graph.Requirement{Type: "key", Value: id + "_inner"}
+		for i, key := range []graph.Requirement{outer, inner} {
+			// Hold the two keys in different rooms where possible
+			holder := holders[rng.Intn(len(holders))]
+			if i == 0 && len(holders) > 1 {
+				holders = removeRoom(holders, holder)
+			}
+			holder.Provides = append(holder.Provides, graph.Capability{Type: key.Type, Value: key.Value})
+			if holder.Tags == nil {
+				holder.Tags = make(map[string]string)
+			}
+			if contains := holder.Tags["contains"]; contains != "" {
+				holder.Tags["contains"] = contains + "," + key.Type + "_" + key.Value
+			} else {
+				holder.Tags["contains"] = key.Type + "_" + key.Value
+			}
+		}
+
+		antechamber := &graph.Room{
+			ID:           id + "_antechamber",
+			Archetype:    graph.ArchetypeOptional,
+			Size:         graph.SizeS,
+			Tags:         map[string]string{graph.VaultTag: id, "vault_role": VaultAntechamber, "locked_by": "key_" + outer.Value},
+			Difficulty:   rng.Float64Range(0.7, 0.9),
+			Reward:       0.3,
+			Requirements: []graph.Requirement{outer},
+		}
+		chamber := &graph.Room{
+			ID:           id,
+			Archetype:    graph.ArchetypeTreasure,
+			Size:         graph.SizeS,
+			Tags:         map[string]string{graph.VaultTag: id, "vault_role": VaultChamber, "locked_by": "key_" + inner.Value},
+			Difficulty:   0.0,
+			Reward:       1.0,
+			Requirements: []graph.Requirement{outer, inner},
+		}
+		for _, room := range []*graph.Room{antechamber, chamber} {
+			if err := g.AddRoom(room); err != nil {
+				return fmt.Errorf("adding vault room: %w", err)
+			}
+		}
+
+		for _, conn := range []*graph.Connector{
+			{
+				ID:            fmt.Sprintf("conn_%s_%s", anchor.ID, antechamber.ID),
+				From:          anchor.ID,
+				To:            antechamber.ID,
+				Type:          graph.TypeDoor,
+				Gate:          &graph.Gate{Type: outer.Type, Value: outer.Value},
+				Cost:          1.0,
+				Visibility:    graph.VisibilityNormal,
+				Bidirectional: true,
+			},
+			{
+				ID:            fmt.Sprintf("conn_%s_%s", antechamber.ID, chamber.ID),
+				From:          antechamber.ID,
+				To:            chamber.ID,
+				Type:          graph.TypeDoor,
+				Gate:          &graph.Gate{Type: inner.Type, Value: inner.Value},
+				Cost:          1.0,
+				Visibility:    graph.VisibilityNormal,
+				Bidirectional: true,
+			},
+		} {
+			if err := g.AddConnector(conn); err != nil {
+				return fmt.Errorf("connecting vault: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// vaultKeyHolders returns the rooms that may hold a vault's keys: rooms
+// outside every vault, other than Start, Boss and Exit, that the player can
+// reach with the keys found on the way. With NoRequiredSecrets they must be
+// reachable without discovering a secret, and with LowBacktracking they must
+// be on or next to the critical path. Rooms holding nothing are preferred;
+// small graphs fall back to rooms already holding only vault keys.
+func vaultKeyHolders(g *graph.Graph, cfg *Config, path []string) []*graph.Room {
+	reached, _ := g.ReachableWithInventory(path[0])
+	var visible, near map[string]bool
+	if cfg.Accessibility.NoRequiredSecrets {
+		visible = g.GetVisibleReachable(path[0])
+	}
+	if cfg.Accessibility.LowBacktracking {
+		near = make(map[string]bool)
+		for _, id := range path {
+			near[id] = true
+			for _, next := range g.Adjacency[id] {
+				near[next] = true
+			}
+		}
+	}
+
+	var free, shared []*graph.Room
+	for _, room := range sortedRooms(g) {
+		switch {
+		case !reached[room.ID] || !vaultAnchor(room) || len(room.Requirements) > 0:
+			continue
+		case visible != nil && !visible[room.ID]:
+			continue
+		case near != nil && !near[room.ID]:
+			continue
+		}
+		if freeForAbility(room) {
+			free = append(free, room)
+		} else if holdsOnlyVaultKeys(room) {
+			shared = append(shared, room)
+		}
+	}
+	if len(free) > 0 {
+		return free
+	}
+	return shared
+}
+
+// holdsOnlyVaultKeys reports whether everything room provides is a vault key.
+func holdsOnlyVaultKeys(room *graph.Room) bool {
+	for _, c := range room.Provides {
+		if c.Type != "key" || !strings.HasPrefix(c.Value, VaultKeyPrefix) {
+			return false
+		}
+	}
+	return len(room.Provides) > 0
+}
+
+// vaultAnchor reports whether a vault may hang off room, or its keys be
+// held there: never Start, Boss, Exit or a room of another vault.
+func vaultAnchor(room *graph.Room) bool {
+	switch room.Archetype {
+	case graph.ArchetypeStart, graph.ArchetypeBoss, graph.ArchetypeExit:
+		return false
+	}
+	return room.Tags[graph.VaultTag] == ""
+}
+
+// sortedRooms returns the rooms of g in ID order.
+func sortedRooms(g *graph.Graph) []*graph.Room {
+	ids := getSortedRoomIDs(g)
+	rooms := make([]*graph.Room, len(ids))
+	for i, id := range ids {
+		rooms[i] = g.Rooms[id]
+	}
+	return rooms
+}
+
+// removeRoom returns rooms without room.
+func removeRoom(rooms []*graph.Room, room *graph.Room) []*graph.Room {
+	kept := make([]*graph.Room, 0, len(rooms))
+	for _, r := range rooms {
+		if r != room {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
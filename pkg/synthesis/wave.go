@@ -0,0 +1,377 @@
+package synthesis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+// WaveSynthesizer generates compact horde-mode graphs: a central hub that
+// players defend, surrounded by concentric defensive rings, with enemy
+// spawner rooms on the outside.
+//
+// Architecture: Concentric Rings
+//   - The Start room ("hub") sits at the center
+//   - Each of the R rings is a cycle of K rooms ("ring_<r>_<i>")
+//   - Every ring room has exactly one radial door: alternate rooms lead inwards
+//     (or to the hub) and outwards, so rings are chokepoint-separated
+//   - Spawner rooms ("spawner_<n>") attach to the outer ring's outward ports
+//
+// Rooms are tagged with "ring" (0 for the hub, 1..R for rings, R+1 for spawners) and
+// "slot" (angular position, 0..K-1); spawners carry "spawner" and "wave", the
+// 1-based wave in which they first release enemies. The spawner opening in the
+// last wave is the Boss room. Embedders use the ring and slot tags to lay the
+// map out radially.
+//
+// Keys and secret rooms are not supported: horde maps are fully open.
+type WaveSynthesizer struct {
+	maxRetries int // Maximum attempts to satisfy constraints
+}
+
+// NewWaveSynthesizer creates a new concentric-ring horde synthesizer.
+func NewWaveSynthesizer() *WaveSynthesizer {
+	return &WaveSynthesizer{
+		maxRetries: 10,
+	}
+}
+
+// Name returns the synthesizer identifier.
+func (s *WaveSynthesizer) Name() string {
+	return "wave"
+}
+
+// waveShape describes the ring structure of a wave map.
+type waveShape struct {
+	ringSize int // Rooms per ring (K), even
+	rings    int // Number of defensive rings (R)
+	spawners int // Spawner rooms on the outer ring's ports
+}
+
+// Synthesize generates a concentric-ring horde graph.
+func (s *WaveSynthesizer) Synthesize(ctx context.Context, rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
+	if !cfg.validRoomBounds() {
+		return nil, fmt.Errorf("invalid room bounds: min=%d max=%d", cfg.RoomsMin, cfg.RoomsMax)
+	}
+	if cfg.BranchingMax < 3 || cfg.BranchingMax > 5 {
+		return nil, fmt.Errorf("wave graphs need branching max in [3, 5], got %d", cfg.BranchingMax)
+	}
+	if len(cfg.Keys) > 0 {
+		return nil, fmt.Errorf("wave graphs do not support keys")
+	}
+
+	var lastErr error
+	attempts := cfg.attempts(s.maxRetries)
+	for attempt := 0; attempt < attempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		g, err := s.tryGenerate(rng, cfg)
+		if err == nil {
+			return g, nil
+		}
+		lastErr = err
+	}
+
+	return nil, &RetryError{Attempts: attempts, Err: lastErr}
+}
+
+// tryGenerate attempts a single generation pass.
+func (s *WaveSynthesizer) tryGenerate(rng *rng.RNG, cfg *Config) (*graph.Graph, error) {
+	// Step 1: Pick a ring shape close to a random target room count
+	shape, err := pickWaveShape(rng.IntRange(cfg.RoomsMin, cfg.RoomsMax), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Build hub, rings and spawners
+	g, err := s.buildRings(rng, cfg, shape)
+	if err != nil {
+		return nil, fmt.Errorf("building rings: %w", err)
+	}
+
+	// Step 3: Assign difficulty outwards from the hub
+	if err := assignWaveDifficulty(g, rng, cfg, shape); err != nil {
+		return nil, fmt.Errorf("assigning difficulty: %w", err)
+	}
+
+	// Step 4: Place checkpoints if configured
+	if err := insertCheckpoints(g, cfg); err != nil {
+		return nil, fmt.Errorf("inserting checkpoints: %w", err)
+	}
+
+	// Step 5: Assign themes
+	if err := assignThemes(g, cfg.Themes, rng); err != nil {
+		return nil, fmt.Errorf("assigning themes: %w", err)
+	}
+
+	// Step 6: Express part of the difficulty through the environment
+	assignEnvironment(g, cfg.EnvironmentRatio, rng)
+
+	// Step 7: Validate hard constraints
+	if err := validateWaveGraph(g, cfg); err != nil {
+		return nil, fmt.Errorf("constraint validation failed: %w", err)
+	}
+
+	return g, nil
+}
+
+// pickWaveShape chooses the ring size, ring count and spawner count whose
+// room count lies within bounds and is closest to target. Ties prefer fewer
+// rings, keeping the map compact. The hub connects to K/2 ring rooms, so K is
+// limited to twice BranchingMax. Rings widen as they move out, so at most
+// K/2+2 rings are stacked to keep outer ring corridors short.
+func pickWaveShape(target int, cfg *Config) (waveShape, error) {
+	var best waveShape
+	bestDiff := -1
+	perPort := cfg.BranchingMax - 2
+	for rings := 1; rings <= cfg.BranchingMax+2; rings++ {
+		for k := max(4, 2*rings-4); k <= 2*cfg.BranchingMax; k += 2 {
+			lo := 1 + rings*k + k/2
+			hi := 1 + rings*k + (k/2)*perPort
+
+			// Clamp the target into the shape's range, then into bounds
+			n := max(lo, min(hi, target))
+			if n < cfg.RoomsMin || n > cfg.RoomsMax {
+				continue
+			}
+			diff := n - target
+			if diff < 0 {
+				diff = -diff
+			}
+			if bestDiff < 0 || diff < bestDiff {
+				bestDiff = diff
+				best = waveShape{ringSize: k, rings: rings, spawners: n - 1 - rings*k}
+			}
+		}
+	}
+	if bestDiff < 0 {
+		return waveShape{}, fmt.Errorf("no ring layout fits room bounds [%d, %d] with branching max %d",
+			cfg.RoomsMin, cfg.RoomsMax, cfg.BranchingMax)
+	}
+	return best, nil
+}
+
+// buildRings creates the hub, the defensive rings and the spawner rooms.
+func (s *WaveSynthesizer) buildRings(rng *rng.RNG, cfg *Config, shape waveShape) (*graph.Graph, error) {
+	g := graph.NewGraph(cfg.Seed)
+	k, rings := shape.ringSize, shape.rings
+
+	connect := func(from, to string, connType graph.ConnectorType) error {
+		return g.AddConnector(&graph.Connector{
+			ID:            fmt.Sprintf("conn_%s_%s", from, to),
+			From:          from,
+			To:            to,
+			Type:          connType,
+			Cost:          1.0,
+			Visibility:    graph.VisibilityNormal,
+			Bidirectional: true,
+		})
+	}
+	ringRoom := func(r, i int) string {
+		return fmt.Sprintf("ring_%d_%d", r, i)
+	}
+	// outward reports whether ring room (r, i) has its radial door on the
+	// outside; the others have theirs on the inside.
+	outward := func(r, i int) bool {
+		return (i+r)%2 == 0
+	}
+
+	hub := &graph.Room{
+		ID:        "hub",
+		Archetype: graph.ArchetypeStart,
+		Size:      graph.SizeL,
+		Tags:      map[string]string{"type": "hub", "ring": "0", "slot": "0"},
+	}
+	if err := g.AddRoom(hub); err != nil {
+		return nil, err
+	}
+
+	// Rings, innermost first. Ring 1 keeps a vendor and a shrine beside the
+	// hub doors so players can resupply between waves.
+	for r := 1; r <= rings; r++ {
+		for i := 0; i < k; i++ {
+			room := &graph.Room{
+				ID:        ringRoom(r, i),
+				Archetype: s.pickRoomArchetype(rng),
+				Size:      s.pickRoomSize(rng),
+				Tags: map[string]string{
+					"type": "ring",
+					"ring": strconv.Itoa(r),
+					"slot": strconv.Itoa(i),
+				},
+			}
+			if outward(r, i) {
+				// Rooms holding the door to the next ring are chokepoints
+				room.Archetype = graph.ArchetypeCorridor
+				room.Tags["chokepoint"] = "true"
+			}
+			if r == 1 && i == 1 {
+				room.Archetype = graph.ArchetypeVendor
+			}
+			if r == 1 && i == 1+2*(k/4) {
+				room.Archetype = graph.ArchetypeShrine
+			}
+			if err := g.AddRoom(room); err != nil {
+				return nil, err
+			}
+		}
+		for i := 0; i < k; i++ {
+			if err := connect(ringRoom(r, i), ringRoom(r, (i+1)%k), graph.TypeCorridor); err != nil {
+				return nil, err
+			}
+			if outward(r, i) {
+				continue
+			}
+			inner := hub.ID
+			if r > 1 {
+				inner = ringRoom(r-1, i)
+			}
+			if err := connect(inner, ringRoom(r, i), graph.TypeDoor); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Spawners on the outer ring's outward ports, dealt round-robin and
+	// alternating sides of the map so successive waves come from new angles
+	var ports []int
+	for i := 0; i < k; i++ {
+		if outward(rings, i) {
+			ports = append(ports, i)
+		}
+	}
+	half := (len(ports) + 1) / 2
+	order := make([]int, 0, len(ports))
+	for i := 0; i < half; i++ {
+		order = append(order, ports[i])
+		if half+i < len(ports) {
+			order = append(order, ports[half+i])
+		}
+	}
+	for n := 1; n <= shape.spawners; n++ {
+		port := order[(n-1)%len(order)]
+		room := &graph.Room{
+			ID:        fmt.Sprintf("spawner_%d", n),
+			Archetype: graph.ArchetypeOptional,
+			Size:      graph.SizeM,
+			Tags: map[string]string{
+				"type":    "spawner",
+				"spawner": "true",
+				"wave":    strconv.Itoa(n),
+				"ring":    strconv.Itoa(rings + 1),
+				"slot":    strconv.Itoa(port),
+			},
+		}
+		if n == shape.spawners {
+			// The last spawner to open is the final objective
+			room.Archetype = graph.ArchetypeBoss
+			room.Size = graph.SizeL
+		}
+		if err := g.AddRoom(room); err != nil {
+			return nil, err
+		}
+		if err := connect(ringRoom(rings, port), room.ID, graph.TypeDoor); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// assignWaveDifficulty sets difficulty by distance from the hub: ring r sits
+// at progress r/(R+1) along the pacing curve, and spawners rise from there to
+// 1.0 in wave order, so the Boss spawner is the hardest room.
+func assignWaveDifficulty(g *graph.Graph, rng *rng.RNG, cfg *Config, shape waveShape) error {
+	curve, err := NewPacingCurve(cfg.Pacing)
+	if err != nil {
+		return err
+	}
+
+	span := float64(shape.rings + 1)
+	for _, id := range getSortedRoomIDs(g) {
+		room := g.Rooms[id]
+		ring, err := strconv.Atoi(room.Tags["ring"])
+		if err != nil {
+			return fmt.Errorf("room %s has invalid ring tag: %w", id, err)
+		}
+
+		progress := float64(ring) / span
+		if room.Tags["spawner"] == "true" {
+			wave, err := strconv.Atoi(room.Tags["wave"])
+			if err != nil {
+				return fmt.Errorf("room %s has invalid wave tag: %w", id, err)
+			}
+			progress = (float64(shape.rings) + float64(wave)/float64(shape.spawners)) / span
+		}
+
+		room.Difficulty = EvaluateWithVariance(curve, progress, cfg.Pacing.Variance, rng)
+		room.Reward = room.Difficulty * 0.8 // Scale reward with difficulty
+	}
+
+	return nil
+}
+
+// validateWaveGraph checks hard constraints of a concentric-ring graph.
+func validateWaveGraph(g *graph.Graph, cfg *Config) error {
+	if len(g.Rooms) < cfg.RoomsMin || len(g.Rooms) > cfg.RoomsMax {
+		return fmt.Errorf("room count %d outside bounds [%d, %d]", len(g.Rooms), cfg.RoomsMin, cfg.RoomsMax)
+	}
+
+	if !g.IsConnected() {
+		return fmt.Errorf("graph is not connected")
+	}
+
+	for _, id := range getSortedRoomIDs(g) {
+		if degree := len(g.Adjacency[id]); degree > cfg.BranchingMax {
+			return fmt.Errorf("room %s has %d connections, exceeds max %d", id, degree, cfg.BranchingMax)
+		}
+	}
+
+	if err := validateAccessibility(g, cfg); err != nil {
+		return fmt.Errorf("accessibility: %w", err)
+	}
+
+	return nil
+}
+
+func (s *WaveSynthesizer) pickRoomArchetype(rng *rng.RNG) graph.RoomArchetype {
+	// Defensive rings are fighting positions with the odd cache
+	weights := []float64{
+		0.0,  // Start (never random)
+		0.0,  // Boss (never random)
+		0.15, // Treasure
+		0.0,  // Puzzle
+		0.25, // Hub
+		0.3,  // Corridor
+		0.0,  // Secret (not supported)
+		0.3,  // Optional
+		0.0,  // Vendor (placed on ring 1)
+		0.0,  // Shrine (placed on ring 1)
+		0.0,  // Checkpoint
+	}
+
+	return graph.RoomArchetype(rng.WeightedChoice(weights))
+}
+
+func (s *WaveSynthesizer) pickRoomSize(rng *rng.RNG) graph.RoomSize {
+	weights := []float64{
+		0.1, // XS
+		0.4, // S
+		0.4, // M
+		0.1, // L
+		0.0, // XL
+	}
+
+	return graph.RoomSize(rng.WeightedChoice(weights))
+}
+
+// init registers the wave synthesizer.
+func init() {
+	Register("wave", NewWaveSynthesizer())
+}
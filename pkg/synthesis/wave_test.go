@@ -0,0 +1,89 @@
+package synthesis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/graph"
+	"github.com/dshills/dungo/pkg/rng"
+)
+
+func waveTestConfig(seed uint64) *Config {
+	return &Config{
+		Seed:          seed,
+		RoomsMin:      20,
+		RoomsMax:      40,
+		BranchingAvg:  2.0,
+		BranchingMax:  4,
+		OptionalRatio: 0.2,
+		Pacing: PacingConfig{
+			Curve:    "LINEAR",
+			Variance: 0.1,
+		},
+		Themes: []string{"dungeon"},
+	}
+}
+
+// TestWaveSynthesizer_Rings verifies the hub, ring and spawner structure and
+// that spawners open in consecutive waves ending at the Boss.
+func TestWaveSynthesizer_Rings(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		cfg := waveTestConfig(seed)
+		g, err := NewWaveSynthesizer().Synthesize(context.Background(), rng.NewRNG(seed, "test", []byte("test")), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Synthesize() error = %v", seed, err)
+		}
+
+		if len(g.Rooms) < cfg.RoomsMin || len(g.Rooms) > cfg.RoomsMax {
+			t.Errorf("seed %d: room count %d outside [%d, %d]", seed, len(g.Rooms), cfg.RoomsMin, cfg.RoomsMax)
+		}
+		if hub := g.Rooms["hub"]; hub == nil || hub.Archetype != graph.ArchetypeStart || hub.Tags["ring"] != "0" {
+			t.Fatalf("seed %d: missing Start hub at ring 0", seed)
+		}
+
+		waves := map[int]bool{}
+		lastWave, bossWave := 0, 0
+		for id, room := range g.Rooms {
+			if len(g.Adjacency[id]) > cfg.BranchingMax {
+				t.Errorf("seed %d: room %s has %d connections, max %d", seed, id, len(g.Adjacency[id]), cfg.BranchingMax)
+			}
+			if room.Tags["spawner"] != "true" {
+				continue
+			}
+			wave, err := strconv.Atoi(room.Tags["wave"])
+			if err != nil {
+				t.Fatalf("seed %d: spawner %s has wave tag %q", seed, id, room.Tags["wave"])
+			}
+			waves[wave] = true
+			lastWave = max(lastWave, wave)
+			if room.Archetype == graph.ArchetypeBoss {
+				bossWave = wave
+			}
+		}
+		if len(waves) != lastWave {
+			t.Errorf("seed %d: spawner waves are not consecutive: %v", seed, waves)
+		}
+		if bossWave != lastWave {
+			t.Errorf("seed %d: Boss spawner opens in wave %d, want last wave %d", seed, bossWave, lastWave)
+		}
+		if !g.IsConnected() {
+			t.Errorf("seed %d: graph is not connected", seed)
+		}
+	}
+}
+
+// TestWaveSynthesizer_Rejects verifies keys and low branching are refused.
+func TestWaveSynthesizer_Rejects(t *testing.T) {
+	cfg := waveTestConfig(1)
+	cfg.Keys = []KeyConfig{{Name: "silver", Count: 1}}
+	if _, err := NewWaveSynthesizer().Synthesize(context.Background(), rng.NewRNG(1, "test", nil), cfg); err == nil {
+		t.Error("expected error for keys")
+	}
+
+	cfg = waveTestConfig(1)
+	cfg.BranchingMax = 2
+	if _, err := NewWaveSynthesizer().Synthesize(context.Background(), rng.NewRNG(1, "test", nil), cfg); err == nil {
+		t.Error("expected error for branching max 2")
+	}
+}
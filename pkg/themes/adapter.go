@@ -88,6 +88,28 @@ func SelectEncounterFromTheme(theme *ThemePack, difficulty float64, rng *rng.RNG
 	return entry.Type
 }
 
+// SelectEncounter selects an enemy type from a registered theme's encounter
+// table. Returns empty string, without drawing from rng, if the theme has no
+// entries for the difficulty.
+func SelectEncounter(theme Theme, difficulty float64, rng *rng.RNG) string {
+	if theme == nil {
+		return ""
+	}
+	entries := theme.EncounterTable(difficulty)
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// Convert to standard rand.Rand for SelectWeightedEntry
+	stdRand := rand.New(rand.NewSource(int64(rng.Uint64())))
+
+	entry := SelectWeightedEntry(entries, stdRand)
+	if entry == nil {
+		return ""
+	}
+	return entry.Type
+}
+
 // SelectLootFromTheme selects an item type from theme loot tables.
 // roomType is the room archetype (e.g., "treasure", "boss").
 // Returns empty string if theme is nil or no loot table matches.
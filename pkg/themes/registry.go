@@ -0,0 +1,213 @@
+package themes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultTheme is the generic theme exporters fall back to for names they do
+// not know.
+const DefaultTheme = "dungeon"
+
+// DefaultPackDir is the directory, relative to the working directory, that
+// holds theme packs as <name>/theme.yaml.
+const DefaultPackDir = "themes"
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Theme{}
+)
+
+// Register adds a theme to the registry under name, making the name valid
+// in configs. Plugins call it from an init function. The theme's palette
+// must color every slot, and themes with a Validate method must pass it.
+// Registering a name twice is an error.
+func Register(name string, t Theme) error {
+	if name == "" {
+		return errors.New("theme name is required")
+	}
+	if t == nil {
+		return fmt.Errorf("theme %q is nil", name)
+	}
+	if v, ok := t.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("theme %q: %w", name, err)
+		}
+	}
+	if err := validatePalette(t.Palette()); err != nil {
+		return fmt.Errorf("theme %q: %w", name, err)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("theme %q is already registered", name)
+	}
+	registry[name] = t
+	return nil
+}
+
+// MustRegister is like Register but panics on error, for init functions.
+func MustRegister(name string, t Theme) {
+	if err := Register(name, t); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterDefinition registers a declarative theme under its name.
+func RegisterDefinition(d *Definition) error {
+	return Register(d.Name, d)
+}
+
+// Lookup returns the registered theme with the given name.
+func Lookup(name string) (Theme, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Names returns the names of all registered themes in sorted order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PaletteOf returns the palette of a theme, or that of DefaultTheme if the
+// theme is not registered.
+func PaletteOf(name string) Palette {
+	t, ok := Lookup(name)
+	if !ok {
+		t, _ = Lookup(DefaultTheme)
+	}
+	return t.Palette()
+}
+
+// The built-in themes. Their packs live in the repository's themes/
+// directory; "dungeon" is the generic theme without a pack. Built-in themes
+// leave encounters to the generator's default table.
+func init() {
+	for _, d := range []*Definition{
+		{
+			Name:        "dungeon",
+			Description: "Generic stone dungeon",
+			Tilesets: []Tileset{
+				{Name: "tiles", Path: "tilesets/dungeon.png", TileWidth: 16, TileHeight: 16},
+			},
+			Decorations: []Decorator{
+				{Type: "torch", Density: 0.1},
+				{Type: "rubble", Density: 0.15},
+				{Type: "crate", Density: 0.1},
+			},
+			Enemies: []string{"rat", "spider", "skeleton", "goblin", "orc", "troll", "dragon"},
+			Colors: Palette{
+				"floor":        {0.45, 0.42, 0.38, 1},
+				"wall":         {0.30, 0.28, 0.26, 1},
+				"door":         {0.50, 0.33, 0.18, 1},
+				"destructible": {0.36, 0.30, 0.26, 1},
+			},
+		},
+		{
+			Name:        "crypt",
+			Description: "Dark crypts filled with undead horrors and ancient treasures",
+			Tilesets:    packTilesets("crypt"),
+			Decorations: []Decorator{
+				{Type: "cobwebs", Density: 0.3},
+				{Type: "bones", Density: 0.4},
+				{Type: "candles", Density: 0.2},
+				{Type: "rubble", Density: 0.15},
+			},
+			// Bosses wait on a raised dais reached by a ramp
+			Elevation: []ElevationRule{
+				{Archetype: "Boss", Level: 2},
+			},
+			Enemies: []string{
+				"skeleton", "zombie", "ghoul", "skeleton_warrior", "zombie_brute", "wraith", "ghast",
+				"wight", "lich", "death_knight", "vampire_lord", "bone_dragon",
+			},
+			// Ancient tombs hide their passages behind false walls and riddles
+			Secrets: map[string]float64{"destructible_wall": 1, "illusory_wall": 2, "shrine_riddle": 1},
+			Colors: Palette{
+				"floor":        {0.35, 0.35, 0.40, 1},
+				"wall":         {0.22, 0.22, 0.27, 1},
+				"door":         {0.40, 0.30, 0.22, 1},
+				"destructible": {0.30, 0.28, 0.30, 1},
+			},
+		},
+		{
+			Name:        "fungal",
+			Description: "Damp caverns overgrown with bioluminescent fungi and spore-infested creatures",
+			Tilesets:    packTilesets("fungal"),
+			Decorations: []Decorator{
+				{Type: "mushrooms", Density: 0.5},
+				{Type: "vines", Density: 0.3},
+				{Type: "spores", Density: 0.4},
+				{Type: "moss", Density: 0.6},
+			},
+			Enemies: []string{
+				"spore_walker", "fungal_rat", "myconid_sprout", "mushroom_giant", "spore_cloud", "myconid_guard",
+				"fungal_shambler", "spore_tyrant", "myconid_sovereign", "cordyceps_host", "ancient_myconid",
+				"spore_dragon", "fungal_hivemind", "mushroom_colossus",
+			},
+			// Rotten ground gives way into the tunnels beneath
+			Secrets: map[string]float64{"destructible_wall": 1, "collapsible_floor": 2},
+			Colors: Palette{
+				"floor":        {0.30, 0.38, 0.25, 1},
+				"wall":         {0.22, 0.26, 0.18, 1},
+				"door":         {0.45, 0.35, 0.20, 1},
+				"destructible": {0.32, 0.30, 0.22, 1},
+			},
+		},
+		{
+			Name:        "arcane",
+			Description: "Ancient magical towers filled with animated constructs and mystical energies",
+			Tilesets:    packTilesets("arcane"),
+			Decorations: []Decorator{
+				{Type: "runes", Density: 0.4},
+				{Type: "magical_symbols", Density: 0.3},
+				{Type: "floating_crystals", Density: 0.2},
+				{Type: "arcane_circles", Density: 0.15},
+			},
+			Enemies: []string{
+				"animated_broom", "magical_wisp", "arcane_apprentice", "fire_elemental", "ice_elemental",
+				"stone_golem", "arcane_guardian", "iron_golem", "lightning_elemental", "arcane_construct",
+				"animated_armor", "elder_elemental", "adamantine_golem", "archmage_construct", "prismatic_guardian",
+			},
+			// Towers guard their vaults with illusions, puzzles and riddles
+			Secrets: map[string]float64{"illusory_wall": 2, "movable_block": 1, "shrine_riddle": 2},
+			Colors: Palette{
+				"floor":        {0.32, 0.30, 0.45, 1},
+				"wall":         {0.20, 0.18, 0.32, 1},
+				"door":         {0.55, 0.45, 0.20, 1},
+				"destructible": {0.30, 0.25, 0.40, 1},
+			},
+		},
+	} {
+		if err := RegisterDefinition(d); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// packTilesets returns the floor, walls and decor tilesets of a built-in
+// theme pack.
+func packTilesets(name string) []Tileset {
+	tilesets := []Tileset{}
+	for _, role := range []string{"floor", "walls", "decor"} {
+		tilesets = append(tilesets, Tileset{
+			Name:       role,
+			Path:       fmt.Sprintf("tiles/%s_%s.png", name, role),
+			TileWidth:  32,
+			TileHeight: 32,
+		})
+	}
+	return tilesets
+}
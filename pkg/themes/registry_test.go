@@ -0,0 +1,131 @@
+package themes_test
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/themes"
+)
+
+func TestRegistry_BuiltinThemes(t *testing.T) {
+	for _, name := range []string{"dungeon", "crypt", "fungal", "arcane"} {
+		theme, ok := themes.Lookup(name)
+		if !ok {
+			t.Fatalf("built-in theme %q is not registered", name)
+		}
+		def, ok := theme.(*themes.Definition)
+		if !ok || len(def.Enemies) == 0 || len(def.Decorations) == 0 {
+			t.Errorf("theme %q has no enemy pool or decoration set", name)
+		}
+		if theme.EncounterTable(0.5) != nil {
+			t.Errorf("built-in theme %q overrides the default encounter table", name)
+		}
+	}
+
+	// Built-in themes with packs declare what their pack provides
+	loader := themes.NewLoader("../../themes")
+	for _, name := range []string{"crypt", "fungal", "arcane"} {
+		pack, err := loader.Load(name)
+		if err != nil {
+			t.Fatalf("loading pack %q: %v", name, err)
+		}
+		theme, _ := themes.Lookup(name)
+		def := theme.(*themes.Definition)
+		mapping := theme.TilesetMapping()
+		for _, tileset := range pack.Tilesets {
+			if mapping[tileset.Name] != tileset {
+				t.Errorf("theme %q maps %s to %+v, pack has %+v", name, tileset.Name, mapping[tileset.Name], tileset)
+			}
+		}
+		if len(pack.Decorators) != len(theme.DecorationRules()) {
+			t.Errorf("theme %q has %d decoration rules, pack has %d", name, len(theme.DecorationRules()), len(pack.Decorators))
+		}
+		for i, decorator := range pack.Decorators {
+			if theme.DecorationRules()[i] != decorator {
+				t.Errorf("theme %q decoration %d = %+v, pack has %+v", name, i, theme.DecorationRules()[i], decorator)
+			}
+		}
+		for _, table := range pack.EncounterTables {
+			for _, entry := range table.Entries {
+				if !contains(def.Enemies, entry.Type) {
+					t.Errorf("theme %q does not declare enemy %q", name, entry.Type)
+				}
+			}
+		}
+	}
+}
+
+// plugin is a third-party theme implementing the interface directly.
+type plugin struct{}
+
+func (plugin) TilesetMapping() map[string]themes.Tileset {
+	return map[string]themes.Tileset{"floor": {Name: "floor", Path: "swamp.png", TileWidth: 16, TileHeight: 16}}
+}
+
+func (plugin) DecorationRules() []themes.Decorator {
+	return []themes.Decorator{{Type: "reeds", Density: 0.5}}
+}
+
+func (plugin) ElevationRules() []themes.ElevationRule {
+	return []themes.ElevationRule{{Tag: "flooded", Level: -1}}
+}
+
+func (plugin) EncounterTable(difficulty float64) []themes.WeightedEntry {
+	return []themes.WeightedEntry{{Type: "bog_witch", Weight: 1}}
+}
+
+func (plugin) Palette() themes.Palette {
+	return themes.PaletteOf(themes.DefaultTheme)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	if err := themes.Register("swamp_test", plugin{}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, ok := themes.Lookup("swamp_test"); !ok {
+		t.Error("registered theme is not found")
+	}
+	if !contains(themes.Names(), "swamp_test") {
+		t.Errorf("Names() = %v, want swamp_test listed", themes.Names())
+	}
+	if err := themes.Register("swamp_test", plugin{}); err == nil {
+		t.Error("expected an error registering a name twice")
+	}
+
+	bare := &themes.Definition{Name: "bare", Tilesets: []themes.Tileset{{Name: "floor"}}}
+	if err := themes.RegisterDefinition(bare); err == nil {
+		t.Error("expected an error for a theme without a palette")
+	}
+	for _, factions := range [][]themes.Faction{
+		{{Name: "", Enemies: []string{"rat"}}},
+		{{Name: "swarm"}},
+		{{Name: "swarm", Enemies: []string{"rat"}}, {Name: "swarm", Enemies: []string{"spider"}}},
+	} {
+		d := &themes.Definition{Name: "factions_test", Tilesets: []themes.Tileset{{Name: "floor"}},
+			Colors: themes.PaletteOf(themes.DefaultTheme), Factions: factions}
+		if err := d.Validate(); err == nil {
+			t.Errorf("expected an error for factions %v", factions)
+		}
+	}
+	if got := themes.PaletteOf("unregistered")["floor"]; got != themes.PaletteOf(themes.DefaultTheme)["floor"] {
+		t.Errorf("unknown theme palette = %v, want the default theme's", got)
+	}
+}
+
+func TestPalette(t *testing.T) {
+	p := themes.Palette{"floor": {1, 0.5, 0, 1}}
+	if got := p.Hex("floor"); got != "#ff8000" {
+		t.Errorf("Hex() = %s, want #ff8000", got)
+	}
+	if got := p.RGBA("floor"); got.R != 255 || got.G != 128 || got.B != 0 || got.A != 255 {
+		t.Errorf("RGBA() = %v", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
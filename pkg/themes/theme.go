@@ -0,0 +1,217 @@
+package themes
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Theme is a theme the pipeline can draw rooms with. Rooms take the theme
+// named by their "biome" tag: carving scatters its decorations and raises or
+// sinks rooms by its elevation rules, content
+// placement draws enemies from its encounter table, and exporters color and
+// texture the map with its palette and tilesets.
+//
+// Third parties implement Theme and add it with Register; Definition is the
+// declarative implementation the built-in themes use.
+type Theme interface {
+	// TilesetMapping maps tileset roles ("floor", "walls", "decor") to the
+	// tilesets that draw them.
+	TilesetMapping() map[string]Tileset
+
+	// DecorationRules lists the decorations scattered over the theme's
+	// rooms. Each tile along a room's walls takes the first rule its roll
+	// falls under, with Density as the chance per rule.
+	DecorationRules() []Decorator
+
+	// ElevationRules lists the rules that raise or sink the theme's rooms.
+	// A room takes the first rule it matches.
+	ElevationRules() []ElevationRule
+
+	// EncounterTable returns the weighted enemy entries for a room
+	// difficulty in [0.0, 1.0], or nil to use the generator's default table.
+	EncounterTable(difficulty float64) []WeightedEntry
+
+	// Palette returns the colors exporters draw the theme with.
+	Palette() Palette
+}
+
+// PaletteSlots lists the material slots every theme palette colors.
+var PaletteSlots = []string{"floor", "wall", "door", "destructible"}
+
+// Palette maps material slots (see PaletteSlots) to RGBA colors with
+// components in [0.0, 1.0], as mesh exporters use them. Hex and RGBA convert
+// a slot for SVG and raster image output.
+type Palette map[string][4]float64
+
+// Hex returns a slot as an SVG color, "#rrggbb".
+func (p Palette) Hex(slot string) string {
+	c := p.RGBA(slot)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// RGBA returns a slot as an 8-bit color for raster (PNG) output. Missing
+// slots are black.
+func (p Palette) RGBA(slot string) color.RGBA {
+	c := p[slot]
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return color.RGBA{R: channel(c[0]), G: channel(c[1]), B: channel(c[2]), A: channel(c[3])}
+}
+
+// Definition declares a theme as data. Tilesets, decorations, elevation rules
+// and colors are returned as given; encounters are picked by difficulty bracket like a
+// ThemePack's.
+type Definition struct {
+	Name        string
+	Description string
+
+	// Tilesets lists the theme's tilesets, keyed by their Name as role.
+	Tilesets []Tileset
+
+	// Decorations is the theme's decoration set.
+	Decorations []Decorator
+
+	// Elevation lists the theme's platform and pit rules.
+	Elevation []ElevationRule
+
+	// Enemies is the theme's enemy pool.
+	Enemies []string
+
+	// Encounters weights the enemy pool by difficulty. Without encounters
+	// the generator's default table is used.
+	Encounters []EncounterTable
+
+	// Colors is the theme's palette.
+	Colors Palette
+
+	// Secrets weights the kinds of secret sealing passages into the theme's
+	// rooms, keyed by carving secret kind. Without weights every secret is a
+	// destructible wall.
+	Secrets map[string]float64
+
+	// Factions groups the theme's enemies into factions. Without factions
+	// the generator's default factions are used.
+	Factions []Faction
+}
+
+// SecretWeighter is implemented by themes that weight the kinds of secret
+// ("destructible_wall", "illusory_wall", ...) sealing hidden passages into
+// their rooms. Config weights override a theme's.
+type SecretWeighter interface {
+	SecretWeights() map[string]float64
+}
+
+// SecretWeights implements SecretWeighter.
+func (d *Definition) SecretWeights() map[string]float64 {
+	return d.Secrets
+}
+
+// Faction is a named group of enemy types that fight side by side, such as
+// the undead of a crypt. With factions enabled, content placement keeps
+// each region of the dungeon to one faction's enemies.
+type Faction struct {
+	Name    string
+	Enemies []string
+}
+
+// FactionProvider is implemented by themes that group their enemies into
+// factions. Regions of a theme without factions draw from the generator's
+// default factions.
+type FactionProvider interface {
+	EnemyFactions() []Faction
+}
+
+// EnemyFactions implements FactionProvider.
+func (d *Definition) EnemyFactions() []Faction {
+	return d.Factions
+}
+
+// TilesetMapping implements Theme.
+func (d *Definition) TilesetMapping() map[string]Tileset {
+	mapping := make(map[string]Tileset, len(d.Tilesets))
+	for _, tileset := range d.Tilesets {
+		mapping[tileset.Name] = tileset
+	}
+	return mapping
+}
+
+// DecorationRules implements Theme.
+func (d *Definition) DecorationRules() []Decorator {
+	return d.Decorations
+}
+
+// ElevationRules implements Theme.
+func (d *Definition) ElevationRules() []ElevationRule {
+	return d.Elevation
+}
+
+// EncounterTable implements Theme, merging the nearest difficulty brackets
+// (see ThemePack.GetEncountersForDifficulty).
+func (d *Definition) EncounterTable(difficulty float64) []WeightedEntry {
+	pack := ThemePack{EncounterTables: d.Encounters, Elevation: d.Elevation}
+	var entries []WeightedEntry
+	for _, table := range pack.GetEncountersForDifficulty(difficulty) {
+		entries = append(entries, table.Entries...)
+	}
+	return entries
+}
+
+// Palette implements Theme.
+func (d *Definition) Palette() Palette {
+	return d.Colors
+}
+
+// Validate checks the definition has a name, tilesets and a complete
+// palette, and that its decorations, elevation rules, encounters, secret
+// weights and factions are well formed.
+func (d *Definition) Validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(d.Tilesets) == 0 {
+		return errors.New("at least one tileset is required")
+	}
+	for _, decorator := range d.Decorations {
+		if decorator.Type == "" {
+			return errors.New("decoration type is required")
+		}
+		if decorator.Density < 0.0 || decorator.Density > 1.0 {
+			return fmt.Errorf("decoration %q density must be between 0.0 and 1.0", decorator.Type)
+		}
+	}
+	for kind, weight := range d.Secrets {
+		if weight < 0 {
+			return fmt.Errorf("secret %q weight must not be negative", kind)
+		}
+	}
+	factions := make(map[string]bool, len(d.Factions))
+	for _, faction := range d.Factions {
+		if faction.Name == "" {
+			return errors.New("faction name is required")
+		}
+		if factions[faction.Name] {
+			return fmt.Errorf("faction %q is defined twice", faction.Name)
+		}
+		factions[faction.Name] = true
+		if len(faction.Enemies) == 0 {
+			return fmt.Errorf("faction %q has no enemies", faction.Name)
+		}
+	}
+	if err := ValidateThemePack(&ThemePack{Name: d.Name, Tilesets: d.Tilesets, EncounterTables: d.Encounters, Elevation: d.Elevation}); err != nil {
+		return err
+	}
+	return validatePalette(d.Colors)
+}
+
+// validatePalette checks a palette colors every slot.
+func validatePalette(p Palette) error {
+	for _, slot := range PaletteSlots {
+		if _, ok := p[slot]; !ok {
+			return fmt.Errorf("palette is missing slot %q", slot)
+		}
+	}
+	return nil
+}
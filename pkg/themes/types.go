@@ -19,6 +19,7 @@ import (
 //   - EncounterTables: Enemy type distributions by difficulty
 //   - LootTables: Item drops by room type
 //   - Decorators: Environmental decoration rules
+//   - Elevation: Raised platform and sunken pit rules keyed by room tags or archetypes
 //
 // Themes are loaded from YAML files and can be mixed/blended in a single dungeon.
 // The content placement stage queries themes to determine what enemies and items
@@ -32,6 +33,7 @@ type ThemePack struct {
 	EncounterTables []EncounterTable `yaml:"encounter_tables" json:"encounter_tables"`
 	LootTables      []LootTable      `yaml:"loot_tables" json:"loot_tables"`
 	Decorators      []Decorator      `yaml:"decorators" json:"decorators"`
+	Elevation       []ElevationRule  `yaml:"elevation" json:"elevation,omitempty"`
 }
 
 // Tileset defines graphics and tile mappings for rendering.
@@ -66,6 +68,20 @@ type Decorator struct {
 	Density float64 `yaml:"density" json:"density"`
 }
 
+// ElevationRule raises or sinks the interior of rooms carrying a tag or of a
+// given archetype ("Boss", "Treasure", ...). A positive Level produces a
+// raised platform, a negative Level a sunken pit. An empty Value matches any
+// room that has the tag; a rule with both Tag and Archetype needs both.
+type ElevationRule struct {
+	Tag       string `yaml:"tag" json:"tag,omitempty"`
+	Value     string `yaml:"value" json:"value,omitempty"`
+	Archetype string `yaml:"archetype" json:"archetype,omitempty"`
+	Level     int    `yaml:"level" json:"level"`
+}
+
+// MaxElevationLevel bounds the absolute level an ElevationRule may assign.
+const MaxElevationLevel = 8
+
 // LoadThemeFromFile loads a theme pack from a YAML file.
 // Returns error if file cannot be read or YAML is invalid.
 func LoadThemeFromFile(path string) (*ThemePack, error) {
@@ -139,6 +155,16 @@ func ValidateThemePack(theme *ThemePack) error {
 		}
 	}
 
+	// Validate elevation rules
+	for _, rule := range theme.Elevation {
+		if rule.Tag == "" && rule.Archetype == "" {
+			return errors.New("elevation rule tag or archetype is required")
+		}
+		if rule.Level < -MaxElevationLevel || rule.Level > MaxElevationLevel {
+			return fmt.Errorf("elevation level must be between %d and %d", -MaxElevationLevel, MaxElevationLevel)
+		}
+	}
+
 	return nil
 }
 
@@ -57,12 +57,7 @@ func (a *Agent) AddCapability(capType, value string) {
 // CanTraverse checks if the agent can traverse a connector given current capabilities.
 // Returns true if all requirements are met (no gate, or gate satisfied).
 func (a *Agent) CanTraverse(conn *graph.Connector) bool {
-	if conn.Gate == nil {
-		return true
-	}
-
-	// Check if agent has the required capability
-	return a.HasCapability(conn.Gate.Type, conn.Gate.Value)
+	return gateOpens(conn, a.capabilities)
 }
 
 // Move attempts to move the agent through a connector to the target room.
@@ -89,8 +84,8 @@ func (a *Agent) Move(g *graph.Graph, conn *graph.Connector, targetRoom *graph.Ro
 
 	// Check if agent can traverse (gate requirements)
 	if !a.CanTraverse(conn) {
-		return fmt.Errorf("cannot traverse connector %s: missing capability %s=%s",
-			conn.ID, conn.Gate.Type, conn.Gate.Value)
+		return fmt.Errorf("cannot traverse connector %s: missing capability %s",
+			conn.ID, conn.Gate)
 	}
 
 	// Move to new room
@@ -193,11 +188,8 @@ func (a *Agent) FindPath(g *graph.Graph, targetArchetype graph.RoomArchetype) Ex
 			}
 
 			// Check gate requirements
-			if conn.Gate != nil {
-				capMap, exists := state.capabilities[conn.Gate.Type]
-				if !exists || !capMap[conn.Gate.Value] {
-					continue // Can't traverse this connector yet
-				}
+			if !gateOpens(conn, state.capabilities) {
+				continue // Can't traverse this connector yet
 			}
 
 			// Skip if already visited in this search
@@ -352,11 +344,8 @@ func VerifyKeyFindable(g *graph.Graph, keyType, keyValue string) (bool, []string
 			}
 
 			// Check gate requirements
-			if conn.Gate != nil {
-				capMap, exists := state.capabilities[conn.Gate.Type]
-				if !exists || !capMap[conn.Gate.Value] {
-					continue
-				}
+			if !gateOpens(conn, state.capabilities) {
+				continue
 			}
 
 			if visited[nextRoomID] {
@@ -379,6 +368,15 @@ func VerifyKeyFindable(g *graph.Graph, keyType, keyValue string) (bool, []string
 	return false, nil, nil
 }
 
+// gateOpens reports whether a connector's gate, if any, opens for the given
+// capabilities.
+func gateOpens(conn *graph.Connector, caps map[string]map[string]bool) bool {
+	if conn.Gate == nil {
+		return true
+	}
+	return conn.Gate.Opens(func(capType, value string) bool { return caps[capType][value] })
+}
+
 // copyCapabilities creates a deep copy of the capabilities map.
 func copyCapabilities(caps map[string]map[string]bool) map[string]map[string]bool {
 	result := make(map[string]map[string]bool)
@@ -478,11 +476,8 @@ func SimulateExploration(g *graph.Graph) (*ExplorationStats, error) {
 			}
 
 			// Check gate
-			if conn.Gate != nil {
-				capMap, exists := state.capabilities[conn.Gate.Type]
-				if !exists || !capMap[conn.Gate.Value] {
-					continue
-				}
+			if !gateOpens(conn, state.capabilities) {
+				continue
 			}
 
 			reachable[nextRoomID] = true
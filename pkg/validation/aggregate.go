@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+// Distribution summarizes one metric across a corpus of dungeons.
+// Percentiles use linear interpolation between the closest ranks.
+type Distribution struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
+	P5     float64 `json:"p5"`
+	P25    float64 `json:"p25"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+
+	sorted []float64 // Sorted sample values for Percentile and Fraction queries
+}
+
+// NewDistribution computes a Distribution from sample values.
+func NewDistribution(values []float64) Distribution {
+	d := Distribution{Count: len(values)}
+	if len(values) == 0 {
+		return d
+	}
+
+	d.sorted = make([]float64, len(values))
+	copy(d.sorted, values)
+	sort.Float64s(d.sorted)
+
+	sum := 0.0
+	for _, v := range d.sorted {
+		sum += v
+	}
+	d.Min = d.sorted[0]
+	d.Max = d.sorted[len(d.sorted)-1]
+	d.Mean = sum / float64(len(d.sorted))
+
+	variance := 0.0
+	for _, v := range d.sorted {
+		variance += (v - d.Mean) * (v - d.Mean)
+	}
+	d.StdDev = math.Sqrt(variance / float64(len(d.sorted)))
+
+	d.P5 = d.Percentile(5)
+	d.P25 = d.Percentile(25)
+	d.P50 = d.Percentile(50)
+	d.P75 = d.Percentile(75)
+	d.P95 = d.Percentile(95)
+
+	return d
+}
+
+// Percentile returns the p-th percentile (0-100) of the sample.
+// Returns 0 for an empty sample.
+func (d Distribution) Percentile(p float64) float64 {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return d.sorted[0]
+	}
+	if p >= 100 {
+		return d.sorted[len(d.sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(d.sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	frac := rank - float64(lo)
+	return d.sorted[lo] + (d.sorted[hi]-d.sorted[lo])*frac
+}
+
+// FractionAtLeast returns the fraction (0.0-1.0) of samples >= threshold.
+// For example, FractionAtLeast(8) >= 0.95 asserts that 95% of seeds have a
+// path length of at least 8.
+func (d Distribution) FractionAtLeast(threshold float64) float64 {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+	idx := sort.SearchFloat64s(d.sorted, threshold)
+	return float64(len(d.sorted)-idx) / float64(len(d.sorted))
+}
+
+// FractionAtMost returns the fraction (0.0-1.0) of samples <= threshold.
+func (d Distribution) FractionAtMost(threshold float64) float64 {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+	idx := sort.Search(len(d.sorted), func(i int) bool { return d.sorted[i] > threshold })
+	return float64(idx) / float64(len(d.sorted))
+}
+
+// AggregateReport summarizes validation reports across a corpus of dungeons,
+// so population-level properties can be asserted in CI.
+type AggregateReport struct {
+	Reports            int            `json:"reports"`
+	Passed             int            `json:"passed"`
+	PassRate           float64        `json:"passRate"`
+	PathLength         Distribution   `json:"pathLength"`
+	BranchingFactor    Distribution   `json:"branchingFactor"`
+	PacingDeviation    Distribution   `json:"pacingDeviation"`
+	CycleCount         Distribution   `json:"cycleCount"`
+	CorridorLength     Distribution   `json:"corridorLength"`
+	CorridorCrossings  Distribution   `json:"corridorCrossings"`
+	BoundsUtilization  Distribution   `json:"boundsUtilization"`
+	RoomSpacing        Distribution   `json:"roomSpacing"`
+	ConstraintFailures map[string]int `json:"constraintFailures"` // Hard constraint kind → failure count
+	WarningCount       int            `json:"warningCount"`
+}
+
+// AggregateReports computes metric distributions and pass rates across many
+// validation reports. Nil reports are skipped; reports without metrics count
+// toward pass rates but not toward the distributions.
+func AggregateReports(reports []*dungeon.ValidationReport) *AggregateReport {
+	agg := &AggregateReport{
+		ConstraintFailures: make(map[string]int),
+	}
+
+	var pathLength, branching, pacing, cycles []float64
+	var corridors, crossings, utilization, spacing []float64
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		agg.Reports++
+		if report.Passed {
+			agg.Passed++
+		}
+		agg.WarningCount += len(report.Warnings)
+
+		for _, result := range report.HardConstraintResults {
+			if !result.Satisfied && result.Constraint != nil {
+				agg.ConstraintFailures[result.Constraint.Kind]++
+			}
+		}
+
+		if m := report.Metrics; m != nil {
+			pathLength = append(pathLength, float64(m.PathLength))
+			branching = append(branching, m.BranchingFactor)
+			pacing = append(pacing, m.PacingDeviation)
+			cycles = append(cycles, float64(m.CycleCount))
+			corridors = append(corridors, float64(m.CorridorLength))
+			crossings = append(crossings, float64(m.CorridorCrossings))
+			utilization = append(utilization, m.BoundsUtilization)
+			spacing = append(spacing, m.RoomSpacing)
+		}
+	}
+
+	if agg.Reports > 0 {
+		agg.PassRate = float64(agg.Passed) / float64(agg.Reports)
+	}
+	agg.PathLength = NewDistribution(pathLength)
+	agg.BranchingFactor = NewDistribution(branching)
+	agg.PacingDeviation = NewDistribution(pacing)
+	agg.CycleCount = NewDistribution(cycles)
+	agg.CorridorLength = NewDistribution(corridors)
+	agg.CorridorCrossings = NewDistribution(crossings)
+	agg.BoundsUtilization = NewDistribution(utilization)
+	agg.RoomSpacing = NewDistribution(spacing)
+
+	return agg
+}
+
+// AggregateSummary returns a human-readable summary of an aggregate report.
+func AggregateSummary(agg *AggregateReport) string {
+	var b strings.Builder
+
+	b.WriteString("=== Aggregate Validation Report ===\n\n")
+	b.WriteString(fmt.Sprintf("Reports: %d\n", agg.Reports))
+	b.WriteString(fmt.Sprintf("Passed: %d (%.1f%%)\n", agg.Passed, agg.PassRate*100))
+	b.WriteString(fmt.Sprintf("Warnings: %d\n", agg.WarningCount))
+
+	b.WriteString("\n=== Distributions ===\n")
+	b.WriteString(fmt.Sprintf("%-18s %8s %8s %8s %8s %8s %8s %8s\n",
+		"Metric", "Min", "P5", "P25", "P50", "P75", "P95", "Max"))
+	for _, row := range []struct {
+		name string
+		d    Distribution
+	}{
+		{"Path Length", agg.PathLength},
+		{"Branching Factor", agg.BranchingFactor},
+		{"Pacing Deviation", agg.PacingDeviation},
+		{"Cycle Count", agg.CycleCount},
+		{"Corridor Length", agg.CorridorLength},
+		{"Corridor Crossings", agg.CorridorCrossings},
+		{"Bounds Utilization", agg.BoundsUtilization},
+		{"Room Spacing", agg.RoomSpacing},
+	} {
+		b.WriteString(fmt.Sprintf("%-18s %8.2f %8.2f %8.2f %8.2f %8.2f %8.2f %8.2f\n",
+			row.name, row.d.Min, row.d.P5, row.d.P25, row.d.P50, row.d.P75, row.d.P95, row.d.Max))
+	}
+
+	if len(agg.ConstraintFailures) > 0 {
+		b.WriteString("\n=== Constraint Failures ===\n")
+		kinds := make([]string, 0, len(agg.ConstraintFailures))
+		for kind := range agg.ConstraintFailures {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			b.WriteString(fmt.Sprintf("  %s: %d\n", kind, agg.ConstraintFailures[kind]))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package validation_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+func TestNewDistribution(t *testing.T) {
+	d := validation.NewDistribution([]float64{5, 1, 4, 2, 3})
+
+	if d.Count != 5 || d.Min != 1 || d.Max != 5 || d.Mean != 3 {
+		t.Errorf("unexpected summary: %+v", d)
+	}
+	if d.P50 != 3 {
+		t.Errorf("P50 = %v, want 3", d.P50)
+	}
+	if got := d.Percentile(25); got != 2 {
+		t.Errorf("P25 = %v, want 2", got)
+	}
+	if got := d.Percentile(90); math.Abs(got-4.6) > 1e-9 {
+		t.Errorf("P90 = %v, want 4.6", got)
+	}
+	if got := d.FractionAtLeast(4); got != 0.4 {
+		t.Errorf("FractionAtLeast(4) = %v, want 0.4", got)
+	}
+	if got := d.FractionAtMost(2); got != 0.4 {
+		t.Errorf("FractionAtMost(2) = %v, want 0.4", got)
+	}
+
+	empty := validation.NewDistribution(nil)
+	if empty.Count != 0 || empty.Percentile(50) != 0 || empty.FractionAtLeast(1) != 0 {
+		t.Errorf("empty distribution should report zeros: %+v", empty)
+	}
+}
+
+func TestAggregateReports(t *testing.T) {
+	failed := &dungeon.ValidationReport{
+		Passed: false,
+		HardConstraintResults: []dungeon.ConstraintResult{
+			{Constraint: &dungeon.Constraint{Kind: "Connectivity"}, Satisfied: true},
+			{Constraint: &dungeon.Constraint{Kind: "PathBounds"}, Satisfied: false},
+		},
+		Warnings: []string{"low variety"},
+	}
+
+	reports := []*dungeon.ValidationReport{
+		{Passed: true, Metrics: &dungeon.Metrics{PathLength: 6, BranchingFactor: 1.5, CycleCount: 0, PacingDeviation: 0.1}},
+		{Passed: true, Metrics: &dungeon.Metrics{PathLength: 8, BranchingFactor: 2.0, CycleCount: 1, PacingDeviation: 0.2}},
+		{Passed: true, Metrics: &dungeon.Metrics{PathLength: 10, BranchingFactor: 2.5, CycleCount: 2, PacingDeviation: 0.3}},
+		failed,
+		nil,
+	}
+
+	agg := validation.AggregateReports(reports)
+
+	if agg.Reports != 4 || agg.Passed != 3 || agg.PassRate != 0.75 {
+		t.Errorf("unexpected pass counts: reports=%d passed=%d rate=%v", agg.Reports, agg.Passed, agg.PassRate)
+	}
+	if agg.PathLength.Count != 3 || agg.PathLength.P50 != 8 {
+		t.Errorf("unexpected path length distribution: %+v", agg.PathLength)
+	}
+	if got := agg.PathLength.FractionAtLeast(8); math.Abs(got-2.0/3.0) > 1e-9 {
+		t.Errorf("FractionAtLeast(8) = %v, want 2/3", got)
+	}
+	if agg.BranchingFactor.Mean != 2.0 {
+		t.Errorf("BranchingFactor mean = %v, want 2.0", agg.BranchingFactor.Mean)
+	}
+	if agg.ConstraintFailures["PathBounds"] != 1 || agg.ConstraintFailures["Connectivity"] != 0 {
+		t.Errorf("unexpected constraint failures: %v", agg.ConstraintFailures)
+	}
+	if agg.WarningCount != 1 {
+		t.Errorf("WarningCount = %d, want 1", agg.WarningCount)
+	}
+
+	summary := validation.AggregateSummary(agg)
+	for _, want := range []string{"Reports: 4", "Path Length", "PathBounds: 1"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
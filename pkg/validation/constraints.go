@@ -3,7 +3,10 @@ package validation
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 
+	"github.com/dshills/dungo/pkg/carving"
 	"github.com/dshills/dungo/pkg/dungeon"
 	"github.com/dshills/dungo/pkg/graph"
 )
@@ -41,6 +44,13 @@ func CheckConnectivity(g *graph.Graph) dungeon.ConstraintResult {
 
 // CheckKeyReachability ensures keys are obtainable before locked rooms.
 // This is a hard constraint - players must be able to access keys before locks.
+// Starting from Start with no keys, the check explores what is reachable,
+// collects the keys found there and repeats until nothing new is collected
+// (see graph.ReachableWithInventory); the Boss and every locked room must be
+// reached by then, and every gate met on the way must open, whether it needs
+// one key, several or any one of a set. Small keys are used up by the doors
+// they open, so every order of spending them is simulated as well (see
+// graph.FindSoftLock).
 func CheckKeyReachability(g *graph.Graph, cfg *dungeon.Config) dungeon.ConstraintResult {
 	// Find all key providers and locked rooms
 	keyRooms := FindKeyRooms(g)
@@ -96,6 +106,54 @@ func CheckKeyReachability(g *graph.Graph, cfg *dungeon.Config) dungeon.Constrain
 		}
 	}
 
+	// Play the dungeon forward from Start collecting keys. A missing Start is
+	// reported by the connectivity check.
+	if startID := FindStartRoom(g); startID != "" {
+		reached, inventory := g.ReachableWithInventory(startID)
+		bossID := FindBossRoom(g)
+		if bossID != "" && !reached[bossID] {
+			violations = append(violations, fmt.Sprintf("Boss room %s cannot be reached with the keys obtainable on the way", bossID))
+		}
+		stuck := []string{}
+		for id, room := range g.Rooms {
+			if len(room.Requirements) > 0 && !reached[id] {
+				stuck = append(stuck, id)
+			}
+		}
+		sort.Strings(stuck)
+		for _, id := range stuck {
+			violations = append(violations, fmt.Sprintf("Locked room %s cannot be opened with the keys obtainable before it", id))
+		}
+
+		// A door with several locks may keep its room open through
+		// another route but still never open
+		has := func(capType, value string) bool {
+			return inventory[graph.Capability{Type: capType, Value: value}]
+		}
+		shut := []string{}
+		for id, conn := range g.Connectors {
+			approached := reached[conn.From] || (conn.Bidirectional && reached[conn.To])
+			if conn.Gate != nil && approached && !conn.Gate.Opens(has) {
+				shut = append(shut, id)
+			}
+		}
+		sort.Strings(shut)
+		for _, id := range shut {
+			violations = append(violations, fmt.Sprintf("Gate on connector %s (%s) never opens", id, g.Connectors[id].Gate))
+		}
+
+		// Small keys are used up, so the Boss must stay reachable whichever
+		// doors they are spent on
+		if bossID != "" && reached[bossID] {
+			lock, err := g.FindSoftLock(startID, bossID)
+			if err != nil {
+				violations = append(violations, fmt.Sprintf("Small keys could not be checked: %v", err))
+			} else if lock != nil {
+				violations = append(violations, fmt.Sprintf("Spending small keys on %v soft-locks the dungeon before Boss room %s", lock.Opened, bossID))
+			}
+		}
+	}
+
 	satisfied := len(violations) == 0
 	details := "All keys are reachable before locks"
 	if !satisfied {
@@ -110,6 +168,60 @@ func CheckKeyReachability(g *graph.Graph, cfg *dungeon.Config) dungeon.Constrain
 	)
 }
 
+// maxReportedSoftLocks caps the soft-lock walks listed in CheckSoftLocks
+// details.
+const maxReportedSoftLocks = 3
+
+// CheckSoftLocks ensures a player can never get permanently stuck on the
+// way from Start to Boss, whatever they do: drop through a one-way
+// connector into rooms without an exit, spend small keys on the wrong doors
+// or get shut in behind a gate whose key is outside (see
+// graph.TraceSoftLocks). This is a hard constraint. Each failure lists the
+// walk that gets the player stuck and the connectors that stay shut.
+func CheckSoftLocks(g *graph.Graph) dungeon.ConstraintResult {
+	startID, bossID := FindStartRoom(g), FindBossRoom(g)
+	if startID == "" || bossID == "" {
+		return NewHardConstraintResult(
+			"SoftLocks",
+			"player.cannotGetStuck()",
+			true,
+			"No Start→Boss route to check",
+		)
+	}
+
+	traces, err := g.TraceSoftLocks(startID, bossID)
+	if err != nil {
+		// Too many states to search is not evidence of a soft lock
+		return NewHardConstraintResult(
+			"SoftLocks",
+			"player.cannotGetStuck()",
+			true,
+			fmt.Sprintf("Soft-lock search skipped: %v", err),
+		)
+	}
+
+	satisfied := len(traces) == 0
+	details := "Boss stays reachable whatever the player does"
+	if !satisfied {
+		walks := make([]string, 0, maxReportedSoftLocks)
+		for i, trace := range traces {
+			if i == maxReportedSoftLocks {
+				walks = append(walks, fmt.Sprintf("and %d more", len(traces)-i))
+				break
+			}
+			walks = append(walks, trace.String())
+		}
+		details = fmt.Sprintf("Player can get stuck before the Boss: %s", strings.Join(walks, "; "))
+	}
+
+	return NewHardConstraintResult(
+		"SoftLocks",
+		"player.cannotGetStuck()",
+		satisfied,
+		details,
+	)
+}
+
 // CheckNoOverlaps ensures rooms don't overlap in spatial layout.
 // This is a hard constraint for embedded dungeons.
 func CheckNoOverlaps(g *graph.Graph, layout *dungeon.Layout) dungeon.ConstraintResult {
@@ -146,20 +258,20 @@ func CheckNoOverlaps(g *graph.Graph, layout *dungeon.Layout) dungeon.ConstraintR
 
 			// Simple overlap check using estimated room sizes
 			// In reality, this would use actual footprint geometry
-			size1 := estimateRoomSize(g.Rooms[id1].Size)
-			size2 := estimateRoomSize(g.Rooms[id2].Size)
+			w1, h1 := estimateRoomDimensions(g.Rooms[id1], pose1)
+			w2, h2 := estimateRoomDimensions(g.Rooms[id2], pose2)
 
 			// Convert center coordinates to corner (top-left) coordinates
 			// pose.X and pose.Y are center positions, so we subtract half the size
-			corner1X := pose1.X - size1/2
-			corner1Y := pose1.Y - size1/2
-			corner2X := pose2.X - size2/2
-			corner2Y := pose2.Y - size2/2
+			corner1X := pose1.X - w1/2
+			corner1Y := pose1.Y - h1/2
+			corner2X := pose2.X - w2/2
+			corner2Y := pose2.Y - h2/2
 
 			// Check if bounding boxes overlap
 			if rectOverlaps(
-				corner1X, corner1Y, size1, size1,
-				corner2X, corner2Y, size2, size2,
+				corner1X, corner1Y, w1, h1,
+				corner2X, corner2Y, w2, h2,
 			) {
 				overlaps = append(overlaps, fmt.Sprintf("%s and %s", id1, id2))
 			}
@@ -180,6 +292,182 @@ func CheckNoOverlaps(g *graph.Graph, layout *dungeon.Layout) dungeon.ConstraintR
 	)
 }
 
+// CheckSecretWalls ensures the carved secrets (destructible walls and the
+// other kinds of carving.SecretKinds) match the graph's hidden connectors:
+// every hidden connector is sealed by exactly one secret and every secret
+// belongs to a hidden connector.
+// This is a hard constraint; tile maps without a destructibles layer are skipped.
+func CheckSecretWalls(g *graph.Graph, tm *dungeon.TileMap) dungeon.ConstraintResult {
+	if tm == nil || tm.Layers["destructibles"] == nil {
+		return NewHardConstraintResult(
+			"SecretWalls",
+			"secrets.wallsMatchHiddenConnectors()",
+			true,
+			"No destructibles layer (skipping secret wall check)",
+		)
+	}
+
+	walls := make(map[string]int)
+	for _, obj := range tm.Layers["destructibles"].Objects {
+		connID, _ := obj.Properties["connector_id"].(string)
+		walls[connID]++
+	}
+
+	violations := []string{}
+	for connID, count := range walls {
+		conn, ok := g.Connectors[connID]
+		if !ok || conn.Type != graph.TypeHidden {
+			violations = append(violations, fmt.Sprintf("wall for non-hidden connector %q", connID))
+		} else if count != 1 {
+			violations = append(violations, fmt.Sprintf("connector %s has %d walls", connID, count))
+		}
+	}
+	for connID, conn := range g.Connectors {
+		if conn.Type == graph.TypeHidden && walls[connID] == 0 {
+			violations = append(violations, fmt.Sprintf("hidden connector %s has no wall", connID))
+		}
+	}
+	sort.Strings(violations)
+
+	satisfied := len(violations) == 0
+	details := "All hidden connectors are sealed by destructible walls"
+	if !satisfied {
+		details = fmt.Sprintf("Secret wall mismatch: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"SecretWalls",
+		"secrets.wallsMatchHiddenConnectors()",
+		satisfied,
+		details,
+	)
+}
+
+// CheckGraphConsistency ensures the carved map and the graph agree both
+// ways: every door belongs to a connector joining the rooms it names, every
+// connector's corridor is carved (the drop of a collapsible floor secret
+// counts as carved), and every floor tile outside the rooms lies on a
+// corridor. With strict set, every pair of rooms the carved floor joins,
+// through crossing corridors or touching floors, must also be joined by a
+// connector (see dungeon.MapCfg.Adjacency).
+// This is a hard constraint; artifacts without a layout or floor layer are skipped.
+func CheckGraphConsistency(g *graph.Graph, layout *dungeon.Layout, tm *dungeon.TileMap, strict bool) dungeon.ConstraintResult {
+	if layout == nil || tm == nil || tm.Layers["floor"] == nil {
+		return NewHardConstraintResult(
+			"GraphConsistency",
+			"tiles.matchGraph()",
+			true,
+			"No layout or floor layer (skipping graph consistency check)",
+		)
+	}
+	floor := tm.Layers["floor"].Data
+	isFloor := func(p carving.Point) bool {
+		return p.X >= 0 && p.X < tm.Width && p.Y >= 0 && p.Y < tm.Height &&
+			floor[p.Y*tm.Width+p.X] == uint32(carving.TileFloor)
+	}
+
+	violations := []string{}
+	if doors := tm.Layers["doors"]; doors != nil {
+		for _, obj := range doors.Objects {
+			connID, _ := obj.Properties["connector_id"].(string)
+			conn, ok := g.Connectors[connID]
+			if !ok {
+				violations = append(violations, fmt.Sprintf("door %s has no connector", obj.Name))
+			} else if obj.Properties["from_room"] != conn.From || obj.Properties["to_room"] != conn.To {
+				violations = append(violations, fmt.Sprintf("door %s does not join the rooms of connector %s", obj.Name, connID))
+			}
+		}
+	}
+
+	// Collapsible floors leave a drop in their corridor
+	drops := make(map[carving.Point]bool)
+	if layer := tm.Layers["destructibles"]; layer != nil && tm.TileWidth > 0 && tm.TileHeight > 0 {
+		for _, obj := range layer.Objects {
+			if obj.Type == carving.SecretCollapsibleFloor {
+				drops[carving.Point{X: int(obj.X) / tm.TileWidth, Y: int(obj.Y) / tm.TileHeight}] = true
+			}
+		}
+	}
+
+	// Mark the corridor tiles, checking each connector's corridor is carved
+	covered := make([]bool, tm.Width*tm.Height)
+	for connID := range g.Connectors {
+		path, ok := layout.CorridorPaths[connID]
+		if !ok || len(path.Points) == 0 {
+			violations = append(violations, fmt.Sprintf("connector %s has no corridor", connID))
+			continue
+		}
+		points := make([]carving.Point, len(path.Points))
+		for i, p := range path.Points {
+			points[i] = carving.Point{X: p.X, Y: p.Y}
+		}
+		carved := true
+		for _, p := range carving.RouteTiles(carving.Path{Points: points}) {
+			if !isFloor(p) {
+				carved = carved && drops[p]
+				continue
+			}
+			covered[p.Y*tm.Width+p.X] = true
+		}
+		if !carved {
+			violations = append(violations, fmt.Sprintf("corridor of connector %s is not carved", connID))
+		}
+	}
+
+	rooms := roomBounds(g, layout)
+	stray := 0
+	for _, r := range rooms {
+		for y := r.Y; y < r.Y+r.Height; y++ {
+			for x := r.X; x < r.X+r.Width; x++ {
+				if x >= 0 && x < tm.Width && y >= 0 && y < tm.Height {
+					covered[y*tm.Width+x] = true
+				}
+			}
+		}
+	}
+	for y := 0; y < tm.Height; y++ {
+		for x := 0; x < tm.Width; x++ {
+			if floor[y*tm.Width+x] == uint32(carving.TileFloor) && !covered[y*tm.Width+x] {
+				stray++
+			}
+		}
+	}
+	if stray > 0 {
+		violations = append(violations, fmt.Sprintf("%d floor tiles outside the rooms lie on no corridor", stray))
+	}
+
+	if strict {
+		joined := make(map[[2]string]bool, 2*len(g.Connectors))
+		for _, conn := range g.Connectors {
+			joined[[2]string{conn.From, conn.To}] = true
+			joined[[2]string{conn.To, conn.From}] = true
+		}
+		var walls []uint32
+		if layer := tm.Layers["walls"]; layer != nil {
+			walls = layer.Data
+		}
+		for _, link := range carving.FloorLinks(floor, walls, tm.Width, tm.Height, rooms) {
+			if !joined[[2]string{link.From, link.To}] {
+				violations = append(violations, fmt.Sprintf("floor joins %s and %s without a connector", link.From, link.To))
+			}
+		}
+	}
+	sort.Strings(violations)
+
+	satisfied := len(violations) == 0
+	details := "Carved map matches the graph"
+	if !satisfied {
+		details = fmt.Sprintf("Graph/tile mismatch: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"GraphConsistency",
+		"tiles.matchGraph()",
+		satisfied,
+		details,
+	)
+}
+
 // CheckPathBounds ensures Start-to-Boss path length is within reasonable bounds.
 // This is a hard constraint to prevent degenerate dungeons.
 //
@@ -210,7 +498,7 @@ func CheckPathBounds(g *graph.Graph, cfg *dungeon.Config) dungeon.ConstraintResu
 		)
 	}
 
-	path, err := g.GetPath(startID, bossID)
+	path, err := g.ShortestPaths().Path(startID, bossID)
 	if err != nil {
 		return NewHardConstraintResult(
 			"PathBounds",
@@ -240,6 +528,35 @@ func CheckPathBounds(g *graph.Graph, cfg *dungeon.Config) dungeon.ConstraintResu
 	)
 }
 
+// CheckMapDimensions ensures the tile map fits within Config.Map, so engine
+// importers with size limits can load it.
+// This is a hard constraint, checked when a maximum width or height is set.
+func CheckMapDimensions(tm *dungeon.TileMap, limits dungeon.MapCfg) dungeon.ConstraintResult {
+	if tm == nil {
+		return NewHardConstraintResult("MapDimensions", "map.fits(maxWidth, maxHeight)", true, "No tile map to check")
+	}
+
+	details := fmt.Sprintf("Map is %dx%d tiles", tm.Width, tm.Height)
+	satisfied := limits.Fits(tm.Width, tm.Height)
+	if satisfied {
+		details += " - within limits"
+	} else {
+		if limits.MaxWidth > 0 && tm.Width > limits.MaxWidth {
+			details += fmt.Sprintf(", wider than maxWidth %d", limits.MaxWidth)
+		}
+		if limits.MaxHeight > 0 && tm.Height > limits.MaxHeight {
+			details += fmt.Sprintf(", taller than maxHeight %d", limits.MaxHeight)
+		}
+	}
+
+	return NewHardConstraintResult(
+		"MapDimensions",
+		"map.fits(maxWidth, maxHeight)",
+		satisfied,
+		details,
+	)
+}
+
 // CheckPacingDeviation measures how well the dungeon follows the configured pacing curve.
 // This is a soft constraint - returns a score from 0.0 to 1.0.
 func CheckPacingDeviation(g *graph.Graph, cfg *dungeon.Config) dungeon.ConstraintResult {
@@ -293,8 +610,402 @@ func CheckBranchingFactor(g *graph.Graph, cfg *dungeon.Config) dungeon.Constrain
 	)
 }
 
+// CheckArchetypeDistribution measures how closely the room archetypes match
+// the targets in Config.Archetypes. Each target scores 1.0 within its
+// tolerance, falling to 0.0 as the rooms beyond it reach the target itself.
+// This is a soft constraint - returns the mean score from 0.0 to 1.0.
+func CheckArchetypeDistribution(g *graph.Graph, cfg *dungeon.Config) dungeon.ConstraintResult {
+	counts := make(map[graph.RoomArchetype]int)
+	for _, room := range g.Rooms {
+		counts[room.Archetype]++
+	}
+
+	total := 0.0
+	var parts, outside []string
+	for _, target := range cfg.Archetypes {
+		archetype, _ := graph.ParseArchetype(target.Archetype)
+		want, tolerance := target.Target(len(g.Rooms))
+		have := counts[archetype]
+
+		excess := math.Max(0.0, math.Abs(float64(have)-want)-tolerance)
+		total += 1.0 - math.Min(1.0, excess/math.Max(want, 1.0))
+
+		parts = append(parts, fmt.Sprintf("%s %d (target %.1f ±%.1f)", archetype, have, want, tolerance))
+		if excess > 0 {
+			outside = append(outside, archetype.String())
+		}
+	}
+
+	score := 1.0
+	if len(cfg.Archetypes) > 0 {
+		score = total / float64(len(cfg.Archetypes))
+	}
+
+	details := "Archetype distribution: " + strings.Join(parts, ", ")
+	if len(outside) > 0 {
+		details += " - " + strings.Join(outside, ", ") + " outside tolerance"
+	} else {
+		details += " - within tolerance"
+	}
+
+	return NewSoftConstraintResult(
+		"ArchetypeDistribution",
+		"archetypes.matchTargets()",
+		score,
+		details,
+	)
+}
+
+// CheckFloorBudget compares the carved floor area with Config.Rooms.FloorBudget.
+// Within budget scores 1.0; over it, the score is the budget's share of the
+// area.
+// This is a soft constraint - returns a score from 0.0 to 1.0.
+func CheckFloorBudget(tm *dungeon.TileMap, budget int) dungeon.ConstraintResult {
+	area := CalculateFloorArea(tm)
+
+	score := 1.0
+	details := fmt.Sprintf("Floor area: %d tiles (budget: %d)", area, budget)
+	if area > budget {
+		score = float64(budget) / float64(area)
+		details += fmt.Sprintf(" - over budget by %d tiles", area-budget)
+	} else {
+		details += " - within budget"
+	}
+
+	return NewSoftConstraintResult(
+		"FloorBudget",
+		"floor.area() <= budget",
+		score,
+		details,
+	)
+}
+
+// CheckNoRequiredSecrets ensures progression never depends on finding a secret:
+// the Boss, every key-providing room and every room holding required loot must
+// be reachable from Start without crossing a hidden connector or entering a
+// secret room.
+// This is a hard constraint, checked when Accessibility.NoRequiredSecrets is set.
+func CheckNoRequiredSecrets(g *graph.Graph, content *dungeon.Content) dungeon.ConstraintResult {
+	startID := FindStartRoom(g)
+	bossID := FindBossRoom(g)
+	if startID == "" || bossID == "" {
+		return NewHardConstraintResult(
+			"NoRequiredSecrets",
+			"accessibility.noRequiredSecrets()",
+			false,
+			"Missing Start or Boss room",
+		)
+	}
+
+	visible := g.GetVisibleReachable(startID)
+	violations := []string{}
+	if !visible[bossID] {
+		violations = append(violations, fmt.Sprintf("boss %s", bossID))
+	}
+	for _, id := range progressionRooms(g, content) {
+		if !visible[id] {
+			violations = append(violations, fmt.Sprintf("room %s", id))
+		}
+	}
+
+	satisfied := len(violations) == 0
+	details := "All progression items are reachable without secrets"
+	if !satisfied {
+		details = fmt.Sprintf("Progression behind secrets: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"NoRequiredSecrets",
+		"accessibility.noRequiredSecrets()",
+		satisfied,
+		details,
+	)
+}
+
+// CheckCheckpointSpacing ensures the Start→Boss path has no more than maxCombat
+// combat rooms between consecutive Checkpoint rooms. A room counts as combat
+// if it holds enemy spawns, or, without content, if its archetype is not a
+// safe zone.
+// This is a hard constraint, checked when Accessibility.MaxCombatBetweenCheckpoints is set.
+func CheckCheckpointSpacing(g *graph.Graph, content *dungeon.Content, maxCombat int) dungeon.ConstraintResult {
+	expr := fmt.Sprintf("accessibility.combatBetweenCheckpoints() <= %d", maxCombat)
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return NewHardConstraintResult("CheckpointSpacing", expr, false, err.Error())
+	}
+
+	isCombat := func(room *graph.Room) bool {
+		switch room.Archetype {
+		case graph.ArchetypeStart, graph.ArchetypeTreasure, graph.ArchetypeVendor,
+			graph.ArchetypeShrine, graph.ArchetypeCheckpoint, graph.ArchetypeExit:
+			return false
+		default:
+			return true
+		}
+	}
+	if content != nil {
+		spawnRooms := make(map[string]bool)
+		for _, spawn := range content.Spawns {
+			spawnRooms[spawn.RoomID] = true
+		}
+		isCombat = func(room *graph.Room) bool {
+			return spawnRooms[room.ID]
+		}
+	}
+
+	run, longest := 0, 0
+	for _, id := range path {
+		room := g.Rooms[id]
+		if room.Archetype == graph.ArchetypeCheckpoint {
+			run = 0
+		} else if isCombat(room) {
+			run++
+			longest = max(longest, run)
+		}
+	}
+
+	satisfied := longest <= maxCombat
+	details := fmt.Sprintf("Longest combat run between checkpoints: %d rooms (max: %d)", longest, maxCombat)
+
+	return NewHardConstraintResult("CheckpointSpacing", expr, satisfied, details)
+}
+
+// CheckLowBacktracking ensures every key-providing room and every room holding
+// required loot lies on the Start→Boss path or directly next to it, so fetching
+// a progression item never means a long detour.
+// This is a hard constraint, checked when Accessibility.LowBacktracking is set.
+func CheckLowBacktracking(g *graph.Graph, content *dungeon.Content) dungeon.ConstraintResult {
+	path, err := criticalPath(g)
+	if err != nil {
+		return NewHardConstraintResult(
+			"LowBacktracking",
+			"accessibility.progressionNearCriticalPath()",
+			false,
+			err.Error(),
+		)
+	}
+
+	onPath := make(map[string]bool, len(path))
+	for _, id := range path {
+		onPath[id] = true
+	}
+	near := make(map[string]bool)
+	for _, conn := range g.Connectors {
+		if onPath[conn.From] || onPath[conn.To] {
+			near[conn.From] = true
+			near[conn.To] = true
+		}
+	}
+
+	violations := []string{}
+	for _, id := range progressionRooms(g, content) {
+		if !onPath[id] && !near[id] {
+			violations = append(violations, id)
+		}
+	}
+
+	satisfied := len(violations) == 0
+	details := "All progression items are on or next to the critical path"
+	if !satisfied {
+		details = fmt.Sprintf("Progression items off the critical path in rooms: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"LowBacktracking",
+		"accessibility.progressionNearCriticalPath()",
+		satisfied,
+		details,
+	)
+}
+
+// CheckPartyConvergence ensures a co-op party can regroup quickly: content must
+// hold exactly one start point per player, and every player start must lie
+// within `within` rooms of the Start room.
+// This is a hard constraint, checked when Party.Size is greater than 1.
+func CheckPartyConvergence(g *graph.Graph, content *dungeon.Content, partySize, within int) dungeon.ConstraintResult {
+	expr := fmt.Sprintf("party.convergence() <= %d", within)
+
+	startID := FindStartRoom(g)
+	if startID == "" {
+		return NewHardConstraintResult("PartyConvergence", expr, false, "Missing Start room")
+	}
+
+	var starts []dungeon.PlayerStart
+	if content != nil {
+		starts = content.PlayerStarts
+	}
+	if len(starts) != partySize {
+		return NewHardConstraintResult(
+			"PartyConvergence",
+			expr,
+			false,
+			fmt.Sprintf("Expected %d player starts, found %d", partySize, len(starts)),
+		)
+	}
+
+	paths := g.ShortestPaths()
+	violations := []string{}
+	for _, start := range starts {
+		dist, ok := paths.Distance(start.RoomID, startID)
+		if !ok {
+			violations = append(violations, fmt.Sprintf("player %d in %s (unreachable)", start.Player, start.RoomID))
+			continue
+		}
+		if dist > within {
+			violations = append(violations, fmt.Sprintf("player %d in %s (%d rooms)", start.Player, start.RoomID, dist))
+		}
+	}
+
+	satisfied := len(violations) == 0
+	details := fmt.Sprintf("All %d player starts converge within %d rooms of Start", partySize, within)
+	if !satisfied {
+		details = fmt.Sprintf("Player starts too far from Start: %v", violations)
+	}
+
+	return NewHardConstraintResult("PartyConvergence", expr, satisfied, details)
+}
+
+// CheckExitAfterBoss ensures the dungeon has one Exit room and that it is
+// only reachable through the Boss: it can be reached from Start with the keys
+// found on the way, but not without entering the Boss room.
+// This is a hard constraint, checked when Config.Exit is set.
+func CheckExitAfterBoss(g *graph.Graph) dungeon.ConstraintResult {
+	const expr = "exit.afterBoss()"
+
+	var exits []string
+	for id, room := range g.Rooms {
+		if room.Archetype == graph.ArchetypeExit {
+			exits = append(exits, id)
+		}
+	}
+	if len(exits) != 1 {
+		return NewHardConstraintResult("ExitAfterBoss", expr, false, fmt.Sprintf("Expected 1 Exit room, found %d", len(exits)))
+	}
+	exitID := exits[0]
+
+	startID := FindStartRoom(g)
+	bossID := FindBossRoom(g)
+	if startID == "" || bossID == "" {
+		return NewHardConstraintResult("ExitAfterBoss", expr, false, "Missing Start or Boss room")
+	}
+
+	if reached, _ := g.ReachableWithInventory(startID); !reached[exitID] {
+		return NewHardConstraintResult("ExitAfterBoss", expr, false, fmt.Sprintf("Exit %s cannot be reached from Start", exitID))
+	}
+
+	// Walk from Start without entering the Boss room
+	seen := map[string]bool{startID: true}
+	queue := []string{startID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range g.Adjacency[id] {
+			if next == bossID || seen[next] {
+				continue
+			}
+			if next == exitID {
+				return NewHardConstraintResult("ExitAfterBoss", expr, false, fmt.Sprintf("Exit %s can be reached without passing Boss %s", exitID, bossID))
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return NewHardConstraintResult("ExitAfterBoss", expr, true, fmt.Sprintf("Exit %s is only reachable through Boss %s", exitID, bossID))
+}
+
+// CheckVaultsOptional ensures the dungeon has the configured number of
+// treasure vaults (rooms tagged graph.VaultTag, grouped by vault) and that
+// each is optional: no vault room lies on the critical path or provides
+// anything, the Boss can be reached without entering a vault, and every
+// vault room can be opened with the keys obtainable from Start.
+// This is a hard constraint, checked when Config.Vaults is set.
+func CheckVaultsOptional(g *graph.Graph, count int) dungeon.ConstraintResult {
+	const expr = "vaults.optional()"
+
+	vaults := make(map[string]bool)
+	var rooms []string
+	for id, room := range g.Rooms {
+		if vault := room.Tags[graph.VaultTag]; vault != "" {
+			vaults[vault] = true
+			rooms = append(rooms, id)
+		}
+	}
+	sort.Strings(rooms)
+	if len(vaults) != count {
+		return NewHardConstraintResult("VaultsOptional", expr, false, fmt.Sprintf("Expected %d vaults, found %d", count, len(vaults)))
+	}
+
+	path, err := criticalPath(g)
+	if err != nil {
+		return NewHardConstraintResult("VaultsOptional", expr, false, err.Error())
+	}
+	onPath := make(map[string]bool, len(path))
+	for _, id := range path {
+		onPath[id] = true
+	}
+	reached, _ := g.ReachableWithInventory(path[0])
+	for _, id := range rooms {
+		switch {
+		case onPath[id]:
+			return NewHardConstraintResult("VaultsOptional", expr, false, fmt.Sprintf("Vault room %s is on the critical path", id))
+		case len(g.Rooms[id].Provides) > 0:
+			return NewHardConstraintResult("VaultsOptional", expr, false, fmt.Sprintf("Vault room %s provides %v", id, g.Rooms[id].Provides))
+		case !reached[id]:
+			return NewHardConstraintResult("VaultsOptional", expr, false, fmt.Sprintf("Vault room %s cannot be opened with the keys obtainable from Start", id))
+		}
+	}
+
+	if _, err := g.GetPathAvoiding(path[0], path[len(path)-1], rooms, nil); err != nil {
+		return NewHardConstraintResult("VaultsOptional", expr, false, "Boss cannot be reached without entering a vault")
+	}
+
+	return NewHardConstraintResult("VaultsOptional", expr, true, fmt.Sprintf("%d vaults of %d rooms are optional and can be opened", len(vaults), len(rooms)))
+}
+
 // Helper functions
 
+// criticalPath returns the Start→Boss path from the graph's cached shortest
+// path table, shared by the pacing, spacing and accessibility checks.
+func criticalPath(g *graph.Graph) ([]string, error) {
+	startID := FindStartRoom(g)
+	bossID := FindBossRoom(g)
+	if startID == "" || bossID == "" {
+		return nil, fmt.Errorf("missing Start or Boss room")
+	}
+	path, err := g.ShortestPaths().Path(startID, bossID)
+	if err != nil {
+		return nil, fmt.Errorf("no path from Start to Boss: %w", err)
+	}
+	return path, nil
+}
+
+// progressionRooms returns the sorted IDs of rooms that provide a key or hold
+// required loot.
+func progressionRooms(g *graph.Graph, content *dungeon.Content) []string {
+	rooms := make(map[string]bool)
+	for _, ids := range FindKeyRooms(g) {
+		for _, id := range ids {
+			rooms[id] = true
+		}
+	}
+	if content != nil {
+		for _, loot := range content.Loot {
+			if loot.Required {
+				rooms[loot.RoomID] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(rooms))
+	for id := range rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func estimateRoomSize(size graph.RoomSize) int {
 	switch size {
 	case graph.SizeXS:
@@ -312,6 +1023,20 @@ func estimateRoomSize(size graph.RoomSize) int {
 	}
 }
 
+// estimateRoomDimensions returns the estimated width and height of a room
+// at pose: a square of its size class, or half as deep for a hall, turned
+// by the pose rotation.
+func estimateRoomDimensions(room *graph.Room, pose dungeon.Pose) (int, int) {
+	w, h := estimateRoomSize(room.Size), estimateRoomSize(room.Size)
+	if pose.FootprintID == graph.FootprintHall {
+		h = (h + 1) / 2
+	}
+	if pose.Rotation == 90 || pose.Rotation == 270 {
+		w, h = h, w
+	}
+	return w, h
+}
+
 func rectOverlaps(x1, y1, w1, h1, x2, y2, w2, h2 int) bool {
 	// Check if rectangles overlap
 	return !(x1+w1 <= x2 || x2+w2 <= x1 || y1+h1 <= y2 || y2+h2 <= y1)
@@ -325,3 +1050,85 @@ func max(a, b int) int {
 }
 
 // Removed unused min() function
+
+// CheckEscapeCompletable ensures the post-boss escape can be finished in
+// time: the route is a walk over open floor, stepping to a neighbouring tile
+// each time, from the Boss room to the Exit room (the Start room when there
+// is no Exit) with a trigger at each end, and a player moving at speed tiles per second reaches every tile no
+// later than it collapses and the end within the time limit. timeLimitMs is
+// the configured limit, 0 for the route's own.
+// This is a hard constraint, checked when Config.Escape is enabled.
+func CheckEscapeCompletable(g *graph.Graph, tm *dungeon.TileMap, content *dungeon.Content, speed float64, timeLimitMs int) dungeon.ConstraintResult {
+	const expr = "escape.completable(speed)"
+
+	if content == nil || content.Escape == nil || len(content.Escape.Route) == 0 {
+		return NewHardConstraintResult("EscapeCompletable", expr, false, "No escape route from the Boss")
+	}
+	escape := content.Escape
+	endID := FindStartRoom(g)
+	if exitID := findExitRoom(g); exitID != "" {
+		endID = exitID
+	}
+	if escape.FromRoom != FindBossRoom(g) || escape.ToRoom != endID {
+		return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape runs from %s to %s, not from the Boss to %s", escape.FromRoom, escape.ToRoom, endID))
+	}
+	limit := escape.TimeLimitMs
+	if timeLimitMs > 0 && timeLimitMs != limit {
+		return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape time limit is %dms, configured %dms", limit, timeLimitMs))
+	}
+
+	route := escape.Route
+	var triggers []string
+	for _, t := range escape.Triggers {
+		if (t.Type == dungeon.TriggerEscapeStart && t.Position == route[0].Position) ||
+			(t.Type == dungeon.TriggerEscapeEnd && t.Position == route[len(route)-1].Position) {
+			triggers = append(triggers, t.Type)
+		}
+	}
+	if len(triggers) != 2 || triggers[0] == triggers[1] {
+		return NewHardConstraintResult("EscapeCompletable", expr, false, "Escape needs a start trigger at the first route tile and an end trigger at the last")
+	}
+
+	var floor, walls []uint32
+	if tm != nil {
+		if layer, ok := tm.Layers["floor"]; ok {
+			floor = layer.Data
+		}
+		if layer, ok := tm.Layers["walls"]; ok {
+			walls = layer.Data
+		}
+	}
+	open := func(p dungeon.Point) bool {
+		if floor == nil || p.X < 0 || p.X >= tm.Width || p.Y < 0 || p.Y >= tm.Height {
+			return false
+		}
+		i := p.Y*tm.Width + p.X
+		return floor[i] == uint32(carving.TileFloor) && (walls == nil || walls[i] == uint32(carving.TileEmpty))
+	}
+
+	walked := 0.0
+	arrival := 0.0
+	for i, tile := range route {
+		p := tile.Position
+		if !open(p) {
+			return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape tile (%d,%d) is not open floor", p.X, p.Y))
+		}
+		if i > 0 {
+			q := route[i-1].Position
+			dx, dy := p.X-q.X, p.Y-q.Y
+			if max(abs(dx), abs(dy)) != 1 {
+				return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape steps from (%d,%d) to (%d,%d)", q.X, q.Y, p.X, p.Y))
+			}
+			walked += math.Hypot(float64(dx), float64(dy))
+		}
+		arrival = walked / speed * 1000
+		if arrival > float64(tile.TimeMs) {
+			return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape tile (%d,%d) collapses at %dms, before a player at %.1f tiles/s arrives at %.0fms", p.X, p.Y, tile.TimeMs, speed, arrival))
+		}
+	}
+	if arrival > float64(limit) {
+		return NewHardConstraintResult("EscapeCompletable", expr, false, fmt.Sprintf("Escape takes %.0fms at %.1f tiles/s, over the %dms limit", arrival, speed, limit))
+	}
+
+	return NewHardConstraintResult("EscapeCompletable", expr, true, fmt.Sprintf("Escape of %d tiles takes %.0fms at %.1f tiles/s, within %dms", len(route), arrival, speed, limit))
+}
@@ -297,3 +297,269 @@ func createLinearTestGraph(n int) *graph.Graph {
 func roomID(i int) string {
 	return "room" + string(rune('0'+i))
 }
+
+// addSideRoom attaches a new room to from with a connector of the given type.
+func addSideRoom(t *testing.T, g *graph.Graph, id, from string, archetype graph.RoomArchetype, connType graph.ConnectorType) *graph.Room {
+	t.Helper()
+	room := &graph.Room{ID: id, Archetype: archetype, Size: graph.SizeS}
+	if err := g.AddRoom(room); err != nil {
+		t.Fatalf("AddRoom(%s) error = %v", id, err)
+	}
+	conn := &graph.Connector{
+		ID:            from + "_" + id,
+		From:          from,
+		To:            id,
+		Type:          connType,
+		Cost:          1.0,
+		Visibility:    graph.VisibilityNormal,
+		Bidirectional: true,
+	}
+	if connType == graph.TypeHidden {
+		conn.Visibility = graph.VisibilitySecret
+	}
+	if err := g.AddConnector(conn); err != nil {
+		t.Fatalf("AddConnector(%s) error = %v", conn.ID, err)
+	}
+	return room
+}
+
+// TestCheckNoRequiredSecrets verifies keys behind hidden connectors are rejected.
+func TestCheckNoRequiredSecrets(t *testing.T) {
+	g := createLinearTestGraph(5)
+	secret := addSideRoom(t, g, "secret", roomID(2), graph.ArchetypeSecret, graph.TypeHidden)
+	secret.Provides = []graph.Capability{{Type: "key", Value: "silver"}}
+
+	if result := CheckNoRequiredSecrets(g, nil); result.Satisfied {
+		t.Errorf("key in secret room should violate constraint: %s", result.Details)
+	}
+
+	// Required loot in a visible room is fine; in the secret room it is not
+	secret.Provides = nil
+	content := &dungeon.Content{Loot: []dungeon.Loot{{RoomID: roomID(3), Required: true}}}
+	if result := CheckNoRequiredSecrets(g, content); !result.Satisfied {
+		t.Errorf("visible required loot should satisfy constraint: %s", result.Details)
+	}
+	content.Loot = append(content.Loot, dungeon.Loot{RoomID: "secret", Required: true})
+	if result := CheckNoRequiredSecrets(g, content); result.Satisfied {
+		t.Errorf("required loot in secret room should violate constraint: %s", result.Details)
+	}
+}
+
+// TestCheckCheckpointSpacing verifies combat runs are measured between checkpoints.
+func TestCheckCheckpointSpacing(t *testing.T) {
+	// Start, 4 combat rooms, Boss: a run of 5 combat rooms
+	g := createLinearTestGraph(6)
+	if result := CheckCheckpointSpacing(g, nil, 2); result.Satisfied {
+		t.Errorf("5 combat rooms should exceed max 2: %s", result.Details)
+	}
+
+	// A checkpoint in the middle splits the run into 2 + 2
+	g.Rooms[roomID(3)].Archetype = graph.ArchetypeCheckpoint
+	if result := CheckCheckpointSpacing(g, nil, 2); !result.Satisfied {
+		t.Errorf("runs of 2 should satisfy max 2: %s", result.Details)
+	}
+
+	// With content, only rooms holding spawns count as combat
+	content := &dungeon.Content{Spawns: []dungeon.Spawn{{RoomID: roomID(1)}, {RoomID: roomID(5)}}}
+	if result := CheckCheckpointSpacing(g, content, 1); !result.Satisfied {
+		t.Errorf("single spawn rooms should satisfy max 1: %s", result.Details)
+	}
+}
+
+// TestCheckLowBacktracking verifies keys must be on or next to the critical path.
+func TestCheckLowBacktracking(t *testing.T) {
+	g := createLinearTestGraph(5)
+	near := addSideRoom(t, g, "near", roomID(2), graph.ArchetypeOptional, graph.TypeDoor)
+	far := addSideRoom(t, g, "far", "near", graph.ArchetypeTreasure, graph.TypeDoor)
+
+	far.Provides = []graph.Capability{{Type: "key", Value: "gold"}}
+	if result := CheckLowBacktracking(g, nil); result.Satisfied {
+		t.Errorf("key two rooms off the path should violate constraint: %s", result.Details)
+	}
+
+	far.Provides = nil
+	near.Provides = []graph.Capability{{Type: "key", Value: "gold"}}
+	if result := CheckLowBacktracking(g, nil); !result.Satisfied {
+		t.Errorf("key next to the path should satisfy constraint: %s", result.Details)
+	}
+}
+
+// TestCheckPartyConvergence verifies player starts must match the party size
+// and lie within the convergence distance of Start.
+func TestCheckPartyConvergence(t *testing.T) {
+	g := createLinearTestGraph(5)
+
+	content := &dungeon.Content{PlayerStarts: []dungeon.PlayerStart{
+		{ID: "player_start_1", RoomID: roomID(1), Player: 1},
+		{ID: "player_start_2", RoomID: roomID(2), Player: 2},
+	}}
+	if result := CheckPartyConvergence(g, content, 2, 2); !result.Satisfied {
+		t.Errorf("starts within 2 rooms should satisfy constraint: %s", result.Details)
+	}
+	if result := CheckPartyConvergence(g, content, 3, 2); result.Satisfied {
+		t.Errorf("missing player start should violate constraint: %s", result.Details)
+	}
+
+	content.PlayerStarts[1].RoomID = roomID(3)
+	if result := CheckPartyConvergence(g, content, 2, 2); result.Satisfied {
+		t.Errorf("start 3 rooms away should violate max 2: %s", result.Details)
+	}
+}
+
+// TestCheckExitAfterBoss verifies the Exit must exist and be reachable only
+// through the Boss room.
+func TestCheckExitAfterBoss(t *testing.T) {
+	g := buildRouteGraph(t)
+	if result := CheckExitAfterBoss(g); result.Satisfied {
+		t.Errorf("missing exit should violate constraint: %s", result.Details)
+	}
+
+	if err := g.AddRoom(&graph.Room{ID: "exit", Archetype: graph.ArchetypeExit, Size: graph.SizeM}); err != nil {
+		t.Fatal(err)
+	}
+	if result := CheckExitAfterBoss(g); result.Satisfied {
+		t.Errorf("unreachable exit should violate constraint: %s", result.Details)
+	}
+
+	if err := g.AddConnector(&graph.Connector{ID: "c5", From: "boss", To: "exit", Cost: 1.0, Bidirectional: true}); err != nil {
+		t.Fatal(err)
+	}
+	if result := CheckExitAfterBoss(g); !result.Satisfied {
+		t.Errorf("exit behind the boss should satisfy constraint: %s", result.Details)
+	}
+
+	if err := g.AddConnector(&graph.Connector{ID: "c6", From: "vault", To: "exit", Cost: 1.0, Bidirectional: true}); err != nil {
+		t.Fatal(err)
+	}
+	if result := CheckExitAfterBoss(g); result.Satisfied {
+		t.Errorf("exit reachable around the boss should violate constraint: %s", result.Details)
+	}
+}
+
+// TestCheckVaultsOptional verifies vaults must exist, provide nothing, be
+// openable and lie off the way to the Boss.
+func TestCheckVaultsOptional(t *testing.T) {
+	g := buildRouteGraph(t)
+	if result := CheckVaultsOptional(g, 1); result.Satisfied {
+		t.Errorf("missing vault should violate constraint: %s", result.Details)
+	}
+
+	// The gold key room holds the key to the Boss, so it cannot be a vault
+	g.Rooms["vault"].Tags = map[string]string{graph.VaultTag: "vault"}
+	if result := CheckVaultsOptional(g, 1); result.Satisfied {
+		t.Errorf("vault providing the boss key should violate constraint: %s", result.Details)
+	}
+	g.Rooms["vault"].Tags = nil
+
+	g.Rooms["side"].Provides = []graph.Capability{{Type: "key", Value: "v_outer"}}
+	for _, room := range []*graph.Room{
+		{ID: "v_antechamber", Archetype: graph.ArchetypeOptional, Size: graph.SizeS, Tags: map[string]string{graph.VaultTag: "v"}},
+		{ID: "v", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS, Tags: map[string]string{graph.VaultTag: "v"}},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, conn := range []*graph.Connector{
+		{ID: "c5", From: "hub", To: "v_antechamber", Cost: 1.0, Bidirectional: true, Gate: &graph.Gate{Type: "key", Value: "v_outer"}},
+		{ID: "c6", From: "v_antechamber", To: "v", Cost: 1.0, Bidirectional: true, Gate: &graph.Gate{Type: "key", Value: "v_inner"}},
+	} {
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if result := CheckVaultsOptional(g, 1); result.Satisfied {
+		t.Errorf("vault without its inner key should violate constraint: %s", result.Details)
+	}
+
+	g.Rooms["side"].Provides = append(g.Rooms["side"].Provides, graph.Capability{Type: "key", Value: "v_inner"})
+	if result := CheckVaultsOptional(g, 1); !result.Satisfied {
+		t.Errorf("optional vault should satisfy constraint: %s", result.Details)
+	}
+	if result := CheckVaultsOptional(g, 2); result.Satisfied {
+		t.Errorf("one vault of two should violate constraint: %s", result.Details)
+	}
+
+	// Routing the Boss through the vault puts it on the critical path
+	if err := g.RemoveConnector("c4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddConnector(&graph.Connector{ID: "c7", From: "v", To: "boss", Cost: 1.0, Bidirectional: true}); err != nil {
+		t.Fatal(err)
+	}
+	if result := CheckVaultsOptional(g, 1); result.Satisfied {
+		t.Errorf("vault on the critical path should violate constraint: %s", result.Details)
+	}
+}
+
+func TestCheckEscapeCompletable(t *testing.T) {
+	g := buildRouteGraph(t)
+	// A 6x1 strip of floor from the Boss at x=0 to Start at x=5
+	tm := &dungeon.TileMap{
+		Width:  6,
+		Height: 1,
+		Layers: map[string]*dungeon.Layer{
+			"floor": {Name: "floor", Type: "tilelayer", Data: []uint32{1, 1, 1, 1, 1, 1}},
+		},
+	}
+	newEscape := func() *dungeon.Escape {
+		e := &dungeon.Escape{FromRoom: "boss", ToRoom: "start", TimeLimitMs: 3000, Speed: 2}
+		for x := 0; x < 6; x++ {
+			e.Route = append(e.Route, dungeon.CollapseTile{Position: dungeon.Point{X: x}, Order: x, TimeMs: 3000 * (x + 1) / 6})
+		}
+		e.Triggers = []dungeon.EscapeTrigger{
+			{ID: "escape_start", Type: dungeon.TriggerEscapeStart, RoomID: "boss", Position: dungeon.Point{X: 0}},
+			{ID: "escape_end", Type: dungeon.TriggerEscapeEnd, RoomID: "start", Position: dungeon.Point{X: 5}},
+		}
+		return e
+	}
+
+	if result := CheckEscapeCompletable(g, tm, &dungeon.Content{}, 2, 0); result.Satisfied {
+		t.Errorf("missing escape should violate constraint: %s", result.Details)
+	}
+
+	// 5 tiles at 2 tiles/s take 2500ms, ahead of every collapse
+	content := &dungeon.Content{Escape: newEscape()}
+	if result := CheckEscapeCompletable(g, tm, content, 2, 3000); !result.Satisfied {
+		t.Errorf("escape should be completable: %s", result.Details)
+	}
+
+	// At 1 tile/s the player reaches tile 2 at 2000ms, after it falls at 1500ms
+	if result := CheckEscapeCompletable(g, tm, content, 1, 3000); result.Satisfied {
+		t.Errorf("escape too slow to outrun the collapse should violate constraint: %s", result.Details)
+	}
+
+	if result := CheckEscapeCompletable(g, tm, content, 2, 2000); result.Satisfied {
+		t.Errorf("time limit differing from the configured one should violate constraint: %s", result.Details)
+	}
+
+	content.Escape.Route[3].Position = dungeon.Point{X: 4}
+	if result := CheckEscapeCompletable(g, tm, content, 2, 0); result.Satisfied {
+		t.Errorf("route skipping a tile should violate constraint: %s", result.Details)
+	}
+
+	content.Escape = newEscape()
+	tm.Layers["floor"].Data[2] = 0
+	if result := CheckEscapeCompletable(g, tm, content, 2, 0); result.Satisfied {
+		t.Errorf("route over a wall should violate constraint: %s", result.Details)
+	}
+	tm.Layers["floor"].Data[2] = 1
+
+	content.Escape.Triggers = content.Escape.Triggers[:1]
+	if result := CheckEscapeCompletable(g, tm, content, 2, 0); result.Satisfied {
+		t.Errorf("escape without an end trigger should violate constraint: %s", result.Details)
+	}
+
+	// With an Exit room the escape must end there, not at Start
+	if err := g.AddRoom(&graph.Room{ID: "exit", Archetype: graph.ArchetypeExit, Size: graph.SizeS}); err != nil {
+		t.Fatal(err)
+	}
+	content.Escape = newEscape()
+	if result := CheckEscapeCompletable(g, tm, content, 2, 0); result.Satisfied {
+		t.Errorf("escape to Start past an Exit should violate constraint: %s", result.Details)
+	}
+	content.Escape.ToRoom, content.Escape.Triggers[1].RoomID = "exit", "exit"
+	if result := CheckEscapeCompletable(g, tm, content, 2, 0); !result.Satisfied {
+		t.Errorf("escape to the Exit should be completable: %s", result.Details)
+	}
+}
@@ -0,0 +1,186 @@
+package validation
+
+import (
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// CalculateDifficultyProfile breaks the dungeon's pacing down by room.
+// A room's target is the pacing curve at its progress, which follows the
+// critical path from Start to Boss as in CalculatePacingDeviation; rooms
+// off the path take the progress of the path rooms next to them, as
+// synthesis assigns their difficulty. Threat and hazard coverage are zero
+// for artifacts without content or a collision layer. Returns nil for an
+// empty graph.
+func CalculateDifficultyProfile(artifact *dungeon.Artifact, cfg *dungeon.Config) *dungeon.DifficultyProfile {
+	g := artifact.ADG.Graph
+	if len(g.Rooms) == 0 {
+		return nil
+	}
+
+	progress, onPath := roomProgress(g)
+	enemies, traps := roomThreats(artifact.Content)
+	hazards := roomHazardCoverage(g, artifact.Layout, artifact.TileMap)
+
+	profile := &dungeon.DifficultyProfile{Rooms: make([]dungeon.RoomDifficulty, 0, len(g.Rooms))}
+	for id, room := range g.Rooms {
+		rd := dungeon.RoomDifficulty{
+			Room:           id,
+			Archetype:      room.Archetype.String(),
+			Progress:       progress[id],
+			CriticalPath:   onPath[id],
+			Difficulty:     room.Difficulty,
+			Target:         calculateExpectedDifficulty(progress[id], cfg.Pacing),
+			Enemies:        enemies[id],
+			Traps:          traps[id],
+			HazardCoverage: hazards[id],
+		}
+		rd.Deviation = rd.Difficulty - rd.Target
+		if artifact.Content != nil {
+			if capacity := artifact.Content.Capacity[id].Enemies; capacity > 0 {
+				rd.Threat = float64(rd.Enemies) / float64(capacity)
+			}
+		}
+		profile.Rooms = append(profile.Rooms, rd)
+	}
+	sort.Slice(profile.Rooms, func(i, j int) bool {
+		a, b := profile.Rooms[i], profile.Rooms[j]
+		if a.Progress != b.Progress {
+			return a.Progress < b.Progress
+		}
+		return a.Room < b.Room
+	})
+
+	difficulty := make([]float64, len(profile.Rooms))
+	deviation := make([]float64, len(profile.Rooms))
+	threat := make([]float64, len(profile.Rooms))
+	coverage := make([]float64, len(profile.Rooms))
+	for i, rd := range profile.Rooms {
+		difficulty[i] = rd.Difficulty
+		deviation[i] = rd.Deviation
+		threat[i] = rd.Threat
+		coverage[i] = rd.HazardCoverage
+	}
+	profile.Difficulty = percentiles(difficulty)
+	profile.Deviation = percentiles(deviation)
+	profile.Threat = percentiles(threat)
+	profile.HazardCoverage = percentiles(coverage)
+
+	return profile
+}
+
+// roomProgress returns each room's progress as synthesis paces it: rooms
+// on the critical path from Start to Boss get their position along it
+// (0.0-1.0), and other rooms the average progress of their neighbours on
+// the path, or that of the nearest path room (the earliest on ties), or 0.5
+// when no path room is reachable. Without a path from Start to Boss every
+// room gets 0.5. The second map marks the critical path rooms.
+func roomProgress(g *graph.Graph) (map[string]float64, map[string]bool) {
+	progress := make(map[string]float64, len(g.Rooms))
+	onPath := make(map[string]bool)
+
+	paths := g.ShortestPaths()
+	var criticalPath []string
+	if startID, bossID := FindStartRoom(g), FindBossRoom(g); startID != "" && bossID != "" {
+		criticalPath, _ = paths.Path(startID, bossID)
+	}
+	for i, id := range criticalPath {
+		onPath[id] = true
+		if len(criticalPath) > 1 {
+			progress[id] = float64(i) / float64(len(criticalPath)-1)
+		}
+	}
+
+	for id := range g.Rooms {
+		if onPath[id] {
+			continue
+		}
+		sum, n := 0.0, 0
+		for _, next := range g.Adjacency[id] {
+			if onPath[next] {
+				sum += progress[next]
+				n++
+			}
+		}
+		if n == 0 {
+			best := -1
+			for _, pathID := range criticalPath {
+				if d, ok := paths.Distance(id, pathID); ok && (best < 0 || d < best) {
+					sum, n, best = progress[pathID], 1, d
+				}
+			}
+		}
+		if n == 0 {
+			progress[id] = 0.5
+		} else {
+			progress[id] = sum / float64(n)
+		}
+	}
+	return progress, onPath
+}
+
+// roomThreats counts the enemies spawned and traps placed in each room.
+func roomThreats(content *dungeon.Content) (enemies, traps map[string]int) {
+	enemies = make(map[string]int)
+	traps = make(map[string]int)
+	if content == nil {
+		return enemies, traps
+	}
+	for _, spawn := range content.Spawns {
+		enemies[spawn.RoomID] += spawn.Count
+	}
+	for _, trap := range content.Traps {
+		traps[trap.RoomID]++
+	}
+	return enemies, traps
+}
+
+// roomHazardCoverage returns the share of each room's floor tiles that the
+// collision layer marks as hazards.
+func roomHazardCoverage(g *graph.Graph, layout *dungeon.Layout, tm *dungeon.TileMap) map[string]float64 {
+	coverage := make(map[string]float64)
+	if tm == nil || tm.Layers["floor"] == nil || tm.Layers["collision"] == nil {
+		return coverage
+	}
+	floor := tm.Layers["floor"].Data
+	collision := tm.Layers["collision"].Data
+
+	for id, r := range roomBounds(g, layout) {
+		tiles, hazards := 0, 0
+		for y := r.Y; y < r.Y+r.Height; y++ {
+			for x := r.X; x < r.X+r.Width; x++ {
+				if x < 0 || y < 0 || x >= tm.Width || y >= tm.Height {
+					continue
+				}
+				i := y*tm.Width + x
+				if floor[i] == 0 {
+					continue
+				}
+				tiles++
+				if carving.CollisionType(collision[i]) == carving.CollisionHazard {
+					hazards++
+				}
+			}
+		}
+		if tiles > 0 {
+			coverage[id] = float64(hazards) / float64(tiles)
+		}
+	}
+	return coverage
+}
+
+// percentiles summarizes per-room values.
+func percentiles(values []float64) dungeon.Percentiles {
+	d := NewDistribution(values)
+	return dungeon.Percentiles{
+		Min: d.Min,
+		P25: d.P25,
+		P50: d.P50,
+		P75: d.P75,
+		P90: d.Percentile(90),
+		Max: d.Max,
+	}
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+)
+
+func TestCalculateDifficultyProfile(t *testing.T) {
+	g := buildRouteGraph(t)
+	for id, d := range map[string]float64{"start": 0, "hub": 0.7, "side": 0.4, "vault": 0.2, "boss": 1} {
+		g.Rooms[id].Difficulty = d
+	}
+
+	// The hub is centered at (10,10) with hazards along its top row
+	layout := &dungeon.Layout{Poses: map[string]dungeon.Pose{"hub": {X: 10, Y: 10}}}
+	hub := roomBounds(g, layout)["hub"]
+	tm := &dungeon.TileMap{Width: 20, Height: 20, Layers: map[string]*dungeon.Layer{
+		"floor":     {Data: make([]uint32, 400)},
+		"collision": {Data: make([]uint32, 400)},
+	}}
+	for y := hub.Y; y < hub.Y+hub.Height; y++ {
+		for x := hub.X; x < hub.X+hub.Width; x++ {
+			tm.Layers["floor"].Data[y*20+x] = 1
+			if y == hub.Y {
+				tm.Layers["collision"].Data[y*20+x] = uint32(carving.CollisionHazard)
+			}
+		}
+	}
+
+	artifact := &dungeon.Artifact{
+		ADG:     &dungeon.Graph{Graph: g},
+		Layout:  layout,
+		TileMap: tm,
+		Content: &dungeon.Content{
+			Spawns:   []dungeon.Spawn{{RoomID: "hub", Count: 2}, {RoomID: "hub", Count: 1}},
+			Traps:    []dungeon.Trap{{RoomID: "side"}},
+			Capacity: map[string]dungeon.RoomCapacity{"hub": {Enemies: 4}},
+		},
+	}
+	cfg := &dungeon.Config{Pacing: dungeon.PacingCfg{Curve: dungeon.PacingLinear}}
+
+	profile := CalculateDifficultyProfile(artifact, cfg)
+	if profile == nil || len(profile.Rooms) != 5 {
+		t.Fatalf("profile = %+v, want 5 rooms", profile)
+	}
+
+	// The critical path is start-hub-boss; the side branch takes the hub's
+	// progress, next to it or nearest to it
+	wantOrder := []string{"start", "hub", "side", "vault", "boss"}
+	wantProgress := []float64{0, 0.5, 0.5, 0.5, 1}
+	for i, rd := range profile.Rooms {
+		if rd.Room != wantOrder[i] || rd.Progress != wantProgress[i] {
+			t.Errorf("room %d = %s at %.2f, want %s at %.2f", i, rd.Room, rd.Progress, wantOrder[i], wantProgress[i])
+		}
+		if onPath := rd.Room == "start" || rd.Room == "hub" || rd.Room == "boss"; rd.CriticalPath != onPath {
+			t.Errorf("%s: CriticalPath = %v", rd.Room, rd.CriticalPath)
+		}
+		if rd.Target != rd.Progress || math.Abs(rd.Deviation-(rd.Difficulty-rd.Target)) > 1e-9 {
+			t.Errorf("%s: target %.2f, deviation %.2f for difficulty %.2f", rd.Room, rd.Target, rd.Deviation, rd.Difficulty)
+		}
+	}
+
+	h := profile.Rooms[1]
+	if h.Enemies != 3 || h.Threat != 0.75 {
+		t.Errorf("hub enemies %d threat %.2f, want 3 and 0.75", h.Enemies, h.Threat)
+	}
+	if want := 1 / float64(hub.Height); math.Abs(h.HazardCoverage-want) > 1e-9 {
+		t.Errorf("hub hazard coverage = %.3f, want %.3f", h.HazardCoverage, want)
+	}
+	if profile.Rooms[2].Traps != 1 {
+		t.Errorf("side traps = %d, want 1", profile.Rooms[2].Traps)
+	}
+
+	if p := profile.Difficulty; p.Min != 0 || p.Max != 1 || p.P50 != 0.4 {
+		t.Errorf("difficulty percentiles = %+v", p)
+	}
+	if p := profile.Deviation; math.Abs(p.Min+0.3) > 1e-9 || math.Abs(p.Max-0.2) > 1e-9 {
+		t.Errorf("deviation percentiles = %+v", p)
+	}
+}
@@ -10,8 +10,10 @@
 //
 //   - Connectivity: All rooms must be reachable from any starting point
 //   - Key Reachability: Keys must be obtainable before their locks
+//   - No Soft Locks: The player can never get stuck before the Boss
 //   - No Overlaps: Rooms must not overlap in spatial layout
 //   - Path Bounds: Start-to-Boss path must be within reasonable length
+//   - Map Dimensions: The tile map must fit the configured maximum width and height
 //
 // # Soft Constraints
 //
@@ -19,6 +21,8 @@
 //
 //   - Pacing Deviation: Difficulty should follow the configured curve
 //   - Branching Factor: Connectivity should match target average
+//   - Archetype Distribution: Room archetypes should match the configured targets
+//   - Floor Budget: Carved floor area should stay within the configured budget
 //
 // # Metrics
 //
@@ -29,6 +33,11 @@
 //   - Cycle Count: Number of cycles in the graph
 //   - Pacing Deviation: L2 distance from target difficulty curve
 //   - Secret Findability: Heuristic score for secret discoverability (future)
+//   - Floor Area: Carved floor tiles, rooms and corridors together
+//   - Corridor Length: Total corridor length and the variance of single corridors
+//   - Bounds Utilization: Share of the layout bounding box covered by rooms
+//   - Corridor Crossings: Points where corridors cross each other
+//   - Room Spacing: Average gap from each room to its nearest neighbour
 //
 // # Usage Example
 //
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 
 	"github.com/dshills/dungo/pkg/dungeon"
@@ -19,24 +20,38 @@ func ExportReportJSONCompact(report *dungeon.ValidationReport) ([]byte, error) {
 	return json.Marshal(report)
 }
 
-// SaveReportToFile exports a ValidationReport to a JSON file with indentation.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func SaveReportToFile(report *dungeon.ValidationReport, filepath string) error {
+// ExportReportTo writes a ValidationReport to w as JSON with indentation,
+// followed by a newline.
+func ExportReportTo(w io.Writer, report *dungeon.ValidationReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// ExportReportCompactTo writes a ValidationReport to w as JSON without
+// indentation, followed by a newline.
+func ExportReportCompactTo(w io.Writer, report *dungeon.ValidationReport) error {
+	return json.NewEncoder(w).Encode(report)
+}
+
+// SaveReportToFile exports a ValidationReport to a JSON file with
+// indentation, written with dungeon.WriteFile.
+func SaveReportToFile(report *dungeon.ValidationReport, filepath string, options ...dungeon.WriteOption) error {
 	data, err := ExportReportJSON(report)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return dungeon.WriteFile(filepath, data, options...)
 }
 
-// SaveReportCompactToFile exports a ValidationReport to a compact JSON file.
-// The file is created with 0644 permissions (readable by all, writable by owner).
-func SaveReportCompactToFile(report *dungeon.ValidationReport, filepath string) error {
+// SaveReportCompactToFile exports a ValidationReport to a compact JSON file
+// with dungeon.WriteFile.
+func SaveReportCompactToFile(report *dungeon.ValidationReport, filepath string, options ...dungeon.WriteOption) error {
 	data, err := ExportReportJSONCompact(report)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, data, 0644)
+	return dungeon.WriteFile(filepath, data, options...)
 }
 
 // LoadReportFromFile loads a ValidationReport from a JSON file.
@@ -53,3 +68,47 @@ func LoadReportFromFile(filepath string) (*dungeon.ValidationReport, error) {
 
 	return &report, nil
 }
+
+// ExportRouteJSON serializes a SpeedrunRoute to JSON with indentation.
+func ExportRouteJSON(route *SpeedrunRoute) ([]byte, error) {
+	return json.MarshalIndent(route, "", "  ")
+}
+
+// ExportRouteTo writes a SpeedrunRoute to w as JSON with indentation.
+func ExportRouteTo(w io.Writer, route *SpeedrunRoute) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(route)
+}
+
+// SaveRouteToFile exports a SpeedrunRoute to a JSON file with indentation,
+// written with dungeon.WriteFile.
+func SaveRouteToFile(route *SpeedrunRoute, filepath string, options ...dungeon.WriteOption) error {
+	data, err := ExportRouteJSON(route)
+	if err != nil {
+		return err
+	}
+	return dungeon.WriteFile(filepath, data, options...)
+}
+
+// ExportAggregateJSON serializes an AggregateReport to JSON with indentation.
+func ExportAggregateJSON(agg *AggregateReport) ([]byte, error) {
+	return json.MarshalIndent(agg, "", "  ")
+}
+
+// ExportAggregateTo writes an AggregateReport to w as JSON with indentation.
+func ExportAggregateTo(w io.Writer, agg *AggregateReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(agg)
+}
+
+// SaveAggregateToFile exports an AggregateReport to a JSON file with
+// indentation, written with dungeon.WriteFile.
+func SaveAggregateToFile(agg *AggregateReport, filepath string, options ...dungeon.WriteOption) error {
+	data, err := ExportAggregateJSON(agg)
+	if err != nil {
+		return err
+	}
+	return dungeon.WriteFile(filepath, data, options...)
+}
@@ -0,0 +1,223 @@
+package validation
+
+import (
+	"sort"
+
+	"github.com/dshills/dungo/pkg/carving"
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// CalculateCorridorLengths returns the total length in tiles of the
+// layout's corridor paths and the variance of the individual lengths. A high
+// variance flags layouts with a few stretched corridors among short ones.
+func CalculateCorridorLengths(layout *dungeon.Layout) (int, float64) {
+	if layout == nil || len(layout.CorridorPaths) == 0 {
+		return 0, 0.0
+	}
+
+	// Sum in ID order so the float result is deterministic
+	ids := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	total := 0
+	lengths := make([]int, len(ids))
+	for i, id := range ids {
+		lengths[i] = pathTiles(layout.CorridorPaths[id])
+		total += lengths[i]
+	}
+
+	mean := float64(total) / float64(len(lengths))
+	variance := 0.0
+	for _, tiles := range lengths {
+		variance += (float64(tiles) - mean) * (float64(tiles) - mean)
+	}
+	return total, variance / float64(len(lengths))
+}
+
+// CalculateBoundsUtilization returns the share of the bounding box around
+// the rooms and corridors that the rooms cover, from 0.0 for an empty layout
+// to 1.0 for rooms packed edge to edge. Sprawling layouts score low.
+func CalculateBoundsUtilization(g *graph.Graph, layout *dungeon.Layout) float64 {
+	rects := roomRects(g, layout)
+	if len(rects) == 0 {
+		return 0.0
+	}
+
+	minX, minY := rects[0].X, rects[0].Y
+	maxX, maxY := rects[0].X+rects[0].Width, rects[0].Y+rects[0].Height
+	extend := func(x, y int) {
+		if x < minX {
+			minX = x
+		}
+		if y < minY {
+			minY = y
+		}
+		if x > maxX {
+			maxX = x
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+	area := 0
+	for _, r := range rects {
+		extend(r.X, r.Y)
+		extend(r.X+r.Width, r.Y+r.Height)
+		area += r.Width * r.Height
+	}
+	for _, path := range layout.CorridorPaths {
+		for _, p := range path.Points {
+			extend(p.X, p.Y)
+			extend(p.X+1, p.Y+1)
+		}
+	}
+
+	box := (maxX - minX) * (maxY - minY)
+	if box == 0 {
+		return 0.0
+	}
+	if area >= box {
+		return 1.0
+	}
+	return float64(area) / float64(box)
+}
+
+// CountCorridorCrossings counts the points where corridor paths of different
+// connectors cross each other. Corridors meeting at a shared room or
+// running alongside each other do not count.
+func CountCorridorCrossings(layout *dungeon.Layout) int {
+	if layout == nil {
+		return 0
+	}
+
+	ids := make([]string, 0, len(layout.CorridorPaths))
+	for id := range layout.CorridorPaths {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	crossings := 0
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			pa, pb := layout.CorridorPaths[a].Points, layout.CorridorPaths[b].Points
+			for j := 1; j < len(pa); j++ {
+				for k := 1; k < len(pb); k++ {
+					if segmentsCross(pa[j-1], pa[j], pb[k-1], pb[k]) {
+						crossings++
+					}
+				}
+			}
+		}
+	}
+	return crossings
+}
+
+// segmentsCross reports whether a horizontal and a vertical segment cross
+// strictly inside both. Corridor paths are Manhattan, so parallel segments
+// never cross.
+func segmentsCross(a1, a2, b1, b2 dungeon.Point) bool {
+	if a1.Y != a2.Y {
+		// Make a the horizontal segment
+		a1, a2, b1, b2 = b1, b2, a1, a2
+	}
+	if a1.Y != a2.Y || b1.X != b2.X {
+		return false
+	}
+	return between(b1.X, a1.X, a2.X) && between(a1.Y, b1.Y, b2.Y)
+}
+
+// between reports whether v lies strictly between a and b.
+func between(v, a, b int) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return v > a && v < b
+}
+
+// CalculateRoomSpacing returns the average gap in tiles between each room
+// and its nearest neighbour, measured along the axis where they are
+// furthest apart. Rooms that touch or overlap have a gap of 0.
+func CalculateRoomSpacing(g *graph.Graph, layout *dungeon.Layout) float64 {
+	rects := roomRects(g, layout)
+	if len(rects) < 2 {
+		return 0.0
+	}
+
+	sum := 0
+	for i, a := range rects {
+		nearest := -1
+		for j, b := range rects {
+			if i == j {
+				continue
+			}
+			gap := rectGap(a, b)
+			if nearest < 0 || gap < nearest {
+				nearest = gap
+			}
+		}
+		sum += nearest
+	}
+	return float64(sum) / float64(len(rects))
+}
+
+// rectGap returns the number of tiles between two rectangles along the axis
+// where they are furthest apart, or 0 when they touch or overlap.
+func rectGap(a, b carving.Rect) int {
+	dx := b.X - (a.X + a.Width)
+	if d := a.X - (b.X + b.Width); d > dx {
+		dx = d
+	}
+	dy := b.Y - (a.Y + a.Height)
+	if d := a.Y - (b.Y + b.Height); d > dy {
+		dy = d
+	}
+	if dy > dx {
+		dx = dy
+	}
+	if dx < 0 {
+		return 0
+	}
+	return dx
+}
+
+// roomRects returns the carved rectangle of every laid out room in ID order.
+func roomRects(g *graph.Graph, layout *dungeon.Layout) []carving.Rect {
+	bounds := roomBounds(g, layout)
+	ids := make([]string, 0, len(bounds))
+	for id := range bounds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	rects := make([]carving.Rect, len(ids))
+	for i, id := range ids {
+		rects[i] = bounds[id]
+	}
+	return rects
+}
+
+// roomBounds returns the carved rectangle of every laid out room by ID.
+func roomBounds(g *graph.Graph, layout *dungeon.Layout) map[string]carving.Rect {
+	if g == nil || layout == nil {
+		return nil
+	}
+
+	bounds := make(map[string]carving.Rect, len(layout.Poses))
+	for id, pose := range layout.Poses {
+		room, ok := g.Rooms[id]
+		if !ok {
+			continue
+		}
+		bounds[id] = carving.RoomBounds(carving.RoomSize(room.Size), carving.Pose{
+			X:           pose.X,
+			Y:           pose.Y,
+			Rotation:    pose.Rotation,
+			FootprintID: pose.FootprintID,
+		})
+	}
+	return bounds
+}
@@ -2,6 +2,8 @@ package validation
 
 import (
 	"math"
+	"sort"
+	"strconv"
 
 	"github.com/dshills/dungo/pkg/dungeon"
 	"github.com/dshills/dungo/pkg/graph"
@@ -42,7 +44,7 @@ func CalculatePathLength(g *graph.Graph) int {
 		return 0
 	}
 
-	path, err := g.GetPath(startID, bossID)
+	path, err := g.ShortestPaths().Path(startID, bossID)
 	if err != nil {
 		return 0
 	}
@@ -52,11 +54,12 @@ func CalculatePathLength(g *graph.Graph) int {
 	return len(path) - 1
 }
 
-// CountCycles counts the number of cycles in the graph.
-// Uses DFS-based cycle detection. Returns the total number of distinct cycles found.
+// CountCycles counts the independent cycles (loops) in the graph: E - V + C
+// for E connectors, V rooms and C connected components. Every loop a player
+// can walk combines these, and unlike enumerating cycles the count takes one
+// pass over the connectors, so it stays fast on densely connected graphs.
 func CountCycles(g *graph.Graph) int {
-	cycles := g.GetCycles()
-	return len(cycles)
+	return g.CycleRank()
 }
 
 // CalculatePacingDeviation measures how well room difficulties follow the configured pacing curve.
@@ -71,7 +74,7 @@ func CalculatePacingDeviation(g *graph.Graph, cfg *dungeon.Config) float64 {
 	}
 
 	// Get the critical path from Start to Boss
-	path, err := g.GetPath(startID, bossID)
+	path, err := g.ShortestPaths().Path(startID, bossID)
 	if err != nil {
 		return 1.0 // Maximum deviation if no path exists
 	}
@@ -210,6 +213,96 @@ func CalculateDifficultyStdDev(g *graph.Graph) float64 {
 	return math.Sqrt(variance)
 }
 
+// CalculateEnvironmentShare computes the share of combat room difficulty
+// expressed through the environment: the sum of the rooms' "environment"
+// budgets (hazards, darkness, slow terrain) over the sum of their
+// difficulties. The rest is carried by enemy spawns.
+func CalculateEnvironmentShare(g *graph.Graph) float64 {
+	// Sum in ID order so the float result is deterministic
+	ids := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	total, environment := 0.0, 0.0
+	for _, id := range ids {
+		room := g.Rooms[id]
+		switch room.Archetype {
+		case graph.ArchetypeStart, graph.ArchetypeTreasure, graph.ArchetypeVendor,
+			graph.ArchetypeShrine, graph.ArchetypeCheckpoint, graph.ArchetypeExit:
+			continue
+		}
+		total += room.Difficulty
+		if budget, err := strconv.ParseFloat(room.Tags["environment"], 64); err == nil {
+			environment += budget
+		}
+	}
+
+	if total == 0 {
+		return 0.0
+	}
+	return environment / total
+}
+
+// CalculateFloorArea counts the carved floor tiles of a tile map, rooms and
+// corridors together. Maps without a floor layer have no floor.
+func CalculateFloorArea(tm *dungeon.TileMap) int {
+	if tm == nil || tm.Layers["floor"] == nil {
+		return 0
+	}
+	area := 0
+	for _, tile := range tm.Layers["floor"].Data {
+		if tile != 0 {
+			area++
+		}
+	}
+	return area
+}
+
+// CalculateSecretFindability scores how discoverable the dungeon's secrets
+// are. Secret rooms are those a player cannot reach from Start without
+// discovering a secret. Each scores 1/d, where d is the number of hops from
+// the nearest room reachable without secrets, so a room directly behind a
+// bombable wall scores 1.0 and one buried three secrets deep scores 0.33.
+// Returns the average score, 1.0 when there are no secrets and 0.0 when Start
+// is missing.
+func CalculateSecretFindability(g *graph.Graph) float64 {
+	startID := FindStartRoom(g)
+	if startID == "" {
+		return 0.0
+	}
+
+	visible := g.GetVisibleReachable(startID)
+	if len(visible) == len(g.Rooms) {
+		return 1.0
+	}
+
+	// One search from the whole visible region gives every secret's depth
+	sources := make([]string, 0, len(visible))
+	for id := range visible {
+		sources = append(sources, id)
+	}
+	depths := g.Distances(sources)
+
+	// Sum in ID order so the float result is deterministic
+	secrets := make([]string, 0, len(g.Rooms)-len(visible))
+	for id := range g.Rooms {
+		if !visible[id] {
+			secrets = append(secrets, id)
+		}
+	}
+	sort.Strings(secrets)
+
+	sum := 0.0
+	for _, id := range secrets {
+		if d, ok := depths[id]; ok && d > 0 {
+			sum += 1.0 / float64(d)
+		}
+	}
+	return sum / float64(len(secrets))
+}
+
 // GetDegreeDistribution returns a map of degree (number of connections) to count of rooms.
 // Useful for analyzing branching patterns.
 func GetDegreeDistribution(g *graph.Graph) map[int]int {
@@ -257,26 +350,13 @@ func GetMinDegree(g *graph.Graph) int {
 
 // CalculateDiameter computes the longest shortest path between any two rooms.
 // This represents the maximum distance across the dungeon.
+// Unreachable pairs are skipped; connector direction is respected.
 func CalculateDiameter(g *graph.Graph) int {
-	if len(g.Rooms) == 0 {
-		return 0
-	}
-
 	maxDist := 0
 
-	// For each pair of rooms, find shortest path
-	rooms := make([]string, 0, len(g.Rooms))
+	// One BFS per room gives the shortest path to every other room
 	for id := range g.Rooms {
-		rooms = append(rooms, id)
-	}
-
-	for i := 0; i < len(rooms); i++ {
-		for j := i + 1; j < len(rooms); j++ {
-			path, err := g.GetPath(rooms[i], rooms[j])
-			if err != nil {
-				continue
-			}
-			dist := len(path) - 1
+		for _, dist := range g.Distances([]string{id}) {
 			if dist > maxDist {
 				maxDist = dist
 			}
@@ -84,6 +84,18 @@ func Summary(report *dungeon.ValidationReport) string {
 		b.WriteString(fmt.Sprintf("Cycle Count: %d\n", report.Metrics.CycleCount))
 		b.WriteString(fmt.Sprintf("Pacing Deviation: %.3f\n", report.Metrics.PacingDeviation))
 		b.WriteString(fmt.Sprintf("Secret Findability: %.2f\n", report.Metrics.SecretFindability))
+		b.WriteString(fmt.Sprintf("Speedrun Route: %d rooms (%d revisits), %d tiles\n", report.Metrics.SpeedrunRooms, report.Metrics.SpeedrunRevisits, report.Metrics.SpeedrunTiles))
+		if report.Metrics.SymmetryScore > 0 {
+			b.WriteString(fmt.Sprintf("Symmetry Score: %.2f\n", report.Metrics.SymmetryScore))
+			b.WriteString(fmt.Sprintf("Team Balance: %.2f\n", report.Metrics.TeamBalance))
+		}
+		if report.Metrics.EnvironmentShare > 0 {
+			b.WriteString(fmt.Sprintf("Environment Share: %.2f\n", report.Metrics.EnvironmentShare))
+		}
+		b.WriteString(fmt.Sprintf("Floor Area: %d tiles\n", report.Metrics.FloorArea))
+		b.WriteString(fmt.Sprintf("Corridors: %d tiles (variance %.1f), %d crossings\n", report.Metrics.CorridorLength, report.Metrics.CorridorLengthVariance, report.Metrics.CorridorCrossings))
+		b.WriteString(fmt.Sprintf("Bounds Utilization: %.2f\n", report.Metrics.BoundsUtilization))
+		b.WriteString(fmt.Sprintf("Room Spacing: %.1f tiles\n", report.Metrics.RoomSpacing))
 	}
 
 	// Hard constraints
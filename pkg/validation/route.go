@@ -0,0 +1,264 @@
+package validation
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// SpeedrunRoute is the theoretically optimal completion route: the shortest
+// walk from Start to Boss that collects every key needed to open the gates
+// and locked rooms along the way. Rooms may repeat when a key forces
+// backtracking.
+type SpeedrunRoute struct {
+	Rooms      []string `json:"rooms"`      // Ordered room IDs, Start first and Boss last
+	Connectors []string `json:"connectors"` // Connector IDs traversed between consecutive rooms
+	Pickups    []string `json:"pickups"`    // Capabilities ("type:value") in acquisition order
+	RoomCount  int      `json:"roomCount"`  // Rooms entered, counting repeats (len(Rooms))
+	Revisits   int      `json:"revisits"`   // Rooms re-entered, counting every repeat
+	TileLength int      `json:"tileLength"` // Tiles walked along corridors (0 without a layout)
+}
+
+// FindSpeedrunRoute computes the optimal completion route from Start to Boss.
+// The search tracks the player's inventory, so a connector gate or room
+// requirement can only be passed once the matching capability has been
+// collected, and rooms are revisited when a key opens new paths.
+//
+// With a layout, routes are ranked by tiles walked and then by rooms entered;
+// without one, by rooms entered only. Ties are broken deterministically.
+// Returns an error if the graph has no Start or Boss or the Boss cannot be
+// reached.
+func FindSpeedrunRoute(g *graph.Graph, layout *dungeon.Layout) (*SpeedrunRoute, error) {
+	startID := FindStartRoom(g)
+	bossID := FindBossRoom(g)
+	if startID == "" || bossID == "" {
+		return nil, fmt.Errorf("graph must have a Start and a Boss room")
+	}
+
+	// Sorted outgoing moves per room for deterministic expansion
+	type move struct {
+		to    string
+		conn  *graph.Connector
+		tiles int
+	}
+	moves := make(map[string][]move)
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id := range g.Connectors {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		tiles := corridorTiles(conn, layout)
+		moves[conn.From] = append(moves[conn.From], move{conn.To, conn, tiles})
+		if conn.Bidirectional {
+			moves[conn.To] = append(moves[conn.To], move{conn.From, conn, tiles})
+		}
+	}
+
+	start := &routeNode{room: startID, inventory: collect(nil, g.Rooms[startID])}
+	best := map[string]*routeNode{start.key(): start}
+	queue := &routeQueue{start}
+	seq := 0
+
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(*routeNode)
+		if best[node.key()] != node {
+			continue // Superseded by a cheaper node for the same state
+		}
+		if node.room == bossID {
+			return node.route(), nil
+		}
+
+		for _, m := range moves[node.room] {
+			if gate := m.conn.Gate; gate != nil && !gate.Opens(node.has) {
+				continue
+			}
+			next := g.Rooms[m.to]
+			if !meetsRequirements(node.inventory, next) {
+				continue
+			}
+
+			seq++
+			child := &routeNode{
+				room:      m.to,
+				inventory: collect(node.inventory, next),
+				parent:    node,
+				conn:      m.conn.ID,
+				tiles:     node.tiles + m.tiles,
+				rooms:     node.rooms + 1,
+				seq:       seq,
+			}
+			key := child.key()
+			if prev, ok := best[key]; ok && !child.less(prev) {
+				continue
+			}
+			best[key] = child
+			heap.Push(queue, child)
+		}
+	}
+
+	return nil, fmt.Errorf("boss room %s is not reachable from %s", bossID, startID)
+}
+
+// corridorTiles returns the walking length of a connector in tiles: the
+// length of its corridor path, or the Manhattan distance between the rooms'
+// poses when no path was recorded, or 0 without a layout.
+func corridorTiles(conn *graph.Connector, layout *dungeon.Layout) int {
+	if layout == nil {
+		return 0
+	}
+	if path, ok := layout.CorridorPaths[conn.ID]; ok && len(path.Points) > 1 {
+		return pathTiles(path)
+	}
+	from, okFrom := layout.Poses[conn.From]
+	to, okTo := layout.Poses[conn.To]
+	if okFrom && okTo {
+		return abs(to.X-from.X) + abs(to.Y-from.Y)
+	}
+	return 0
+}
+
+// pathTiles returns the length of a Manhattan corridor path in tiles.
+func pathTiles(path dungeon.Path) int {
+	tiles := 0
+	for i := 1; i < len(path.Points); i++ {
+		tiles += abs(path.Points[i].X-path.Points[i-1].X) + abs(path.Points[i].Y-path.Points[i-1].Y)
+	}
+	return tiles
+}
+
+// capabilityKey identifies a capability in an inventory.
+func capabilityKey(capType, value string) string {
+	return capType + ":" + value
+}
+
+// collect returns the inventory after entering room.
+func collect(inventory map[string]bool, room *graph.Room) map[string]bool {
+	if len(room.Provides) == 0 && inventory != nil {
+		return inventory
+	}
+	result := make(map[string]bool, len(inventory)+len(room.Provides))
+	for k := range inventory {
+		result[k] = true
+	}
+	for _, cap := range room.Provides {
+		result[capabilityKey(cap.Type, cap.Value)] = true
+	}
+	return result
+}
+
+// meetsRequirements reports whether the inventory satisfies every
+// requirement of room.
+func meetsRequirements(inventory map[string]bool, room *graph.Room) bool {
+	for _, req := range room.Requirements {
+		if !inventory[capabilityKey(req.Type, req.Value)] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// routeNode is a search state: a room plus the inventory held on arrival.
+type routeNode struct {
+	room      string
+	inventory map[string]bool
+	parent    *routeNode
+	conn      string // Connector used to arrive
+	tiles     int
+	rooms     int
+	seq       int // Insertion order, for deterministic tie-breaking
+}
+
+// key identifies the node's state.
+func (n *routeNode) key() string {
+	caps := make([]string, 0, len(n.inventory))
+	for k := range n.inventory {
+		caps = append(caps, k)
+	}
+	sort.Strings(caps)
+	return n.room + "|" + strings.Join(caps, ",")
+}
+
+// has reports whether the node's inventory holds a capability.
+func (n *routeNode) has(capType, value string) bool {
+	return n.inventory[capabilityKey(capType, value)]
+}
+
+// less orders nodes by tiles, then rooms, then insertion order.
+func (n *routeNode) less(o *routeNode) bool {
+	if n.tiles != o.tiles {
+		return n.tiles < o.tiles
+	}
+	if n.rooms != o.rooms {
+		return n.rooms < o.rooms
+	}
+	return n.seq < o.seq
+}
+
+// route reconstructs the walk ending at n.
+func (n *routeNode) route() *SpeedrunRoute {
+	var chain []*routeNode
+	for node := n; node != nil; node = node.parent {
+		chain = append(chain, node)
+	}
+
+	r := &SpeedrunRoute{
+		Rooms:      make([]string, 0, len(chain)),
+		Connectors: make([]string, 0, len(chain)-1),
+		Pickups:    []string{},
+		TileLength: n.tiles,
+	}
+	var held map[string]bool
+	for i := len(chain) - 1; i >= 0; i-- {
+		node := chain[i]
+		r.Rooms = append(r.Rooms, node.room)
+		if node.parent != nil {
+			r.Connectors = append(r.Connectors, node.conn)
+		}
+		gained := make([]string, 0)
+		for k := range node.inventory {
+			if !held[k] {
+				gained = append(gained, k)
+			}
+		}
+		sort.Strings(gained)
+		r.Pickups = append(r.Pickups, gained...)
+		held = node.inventory
+	}
+	r.RoomCount = len(r.Rooms)
+	seen := make(map[string]bool, len(r.Rooms))
+	for _, id := range r.Rooms {
+		if seen[id] {
+			r.Revisits++
+		}
+		seen[id] = true
+	}
+	return r
+}
+
+// routeQueue is a min-heap of route nodes.
+type routeQueue []*routeNode
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].less(q[j]) }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(*routeNode)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	node := old[n-1]
+	*q = old[:n-1]
+	return node
+}
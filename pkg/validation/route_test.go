@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// buildRouteGraph creates a graph where the Boss sits behind a gate whose key
+// lies in a side branch, forcing the route to backtrack:
+//
+//	start - hub -[gold]- boss
+//	         |
+//	       side - vault(gold key)
+func buildRouteGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+	g := graph.NewGraph(1)
+	for _, room := range []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "hub", Archetype: graph.ArchetypeHub, Size: graph.SizeM},
+		{ID: "side", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS},
+		{ID: "vault", Archetype: graph.ArchetypeTreasure, Size: graph.SizeS,
+			Provides: []graph.Capability{{Type: "key", Value: "gold"}}},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, conn := range []*graph.Connector{
+		{ID: "c1", From: "start", To: "hub", Bidirectional: true},
+		{ID: "c2", From: "hub", To: "side", Bidirectional: true},
+		{ID: "c3", From: "side", To: "vault", Bidirectional: true},
+		{ID: "c4", From: "hub", To: "boss", Bidirectional: true, Gate: &graph.Gate{Type: "key", Value: "gold"}},
+	} {
+		conn.Cost = 1.0
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestFindSpeedrunRoute_BacktracksForKey(t *testing.T) {
+	g := buildRouteGraph(t)
+
+	route, err := FindSpeedrunRoute(g, nil)
+	if err != nil {
+		t.Fatalf("FindSpeedrunRoute() error = %v", err)
+	}
+
+	want := []string{"start", "hub", "side", "vault", "side", "hub", "boss"}
+	if len(route.Rooms) != len(want) {
+		t.Fatalf("route = %v, want %v", route.Rooms, want)
+	}
+	for i := range want {
+		if route.Rooms[i] != want[i] {
+			t.Fatalf("route = %v, want %v", route.Rooms, want)
+		}
+	}
+	if route.RoomCount != 7 || len(route.Connectors) != 6 || route.TileLength != 0 {
+		t.Errorf("unexpected route summary: %+v", route)
+	}
+	if len(route.Pickups) != 1 || route.Pickups[0] != "key:gold" {
+		t.Errorf("Pickups = %v, want [key:gold]", route.Pickups)
+	}
+}
+
+func TestFindSpeedrunRoute_PrefersFewerTiles(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, room := range []*graph.Room{
+		{ID: "start", Archetype: graph.ArchetypeStart, Size: graph.SizeM},
+		{ID: "a", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS},
+		{ID: "b", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS},
+		{ID: "boss", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, conn := range []*graph.Connector{
+		{ID: "direct", From: "start", To: "boss", Bidirectional: true},
+		{ID: "sa", From: "start", To: "a", Bidirectional: true},
+		{ID: "ab", From: "a", To: "b", Bidirectional: true},
+		{ID: "bb", From: "b", To: "boss", Bidirectional: true},
+	} {
+		conn.Cost = 1.0
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The direct corridor winds for 40 tiles; the detour totals 12
+	layout := &dungeon.Layout{
+		CorridorPaths: map[string]dungeon.Path{
+			"direct": {Points: []dungeon.Point{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 20}}},
+			"sa":     {Points: []dungeon.Point{{X: 0, Y: 0}, {X: 4, Y: 0}}},
+			"ab":     {Points: []dungeon.Point{{X: 4, Y: 0}, {X: 8, Y: 0}}},
+			"bb":     {Points: []dungeon.Point{{X: 8, Y: 0}, {X: 8, Y: 4}}},
+		},
+	}
+
+	route, err := FindSpeedrunRoute(g, layout)
+	if err != nil {
+		t.Fatalf("FindSpeedrunRoute() error = %v", err)
+	}
+	if route.TileLength != 12 || route.RoomCount != 4 {
+		t.Errorf("expected 4-room, 12-tile detour, got %+v", route)
+	}
+
+	// Without a layout the direct connector wins
+	route, err = FindSpeedrunRoute(g, nil)
+	if err != nil {
+		t.Fatalf("FindSpeedrunRoute() error = %v", err)
+	}
+	if route.RoomCount != 2 {
+		t.Errorf("expected direct 2-room route without layout, got %v", route.Rooms)
+	}
+}
+
+func TestFindSpeedrunRoute_Unreachable(t *testing.T) {
+	g := buildRouteGraph(t)
+	g.Rooms["vault"].Provides = nil
+
+	if _, err := FindSpeedrunRoute(g, nil); err == nil {
+		t.Error("expected error when the boss key is unobtainable")
+	}
+}
@@ -0,0 +1,255 @@
+package validation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// Similarity compares two dungeons. Each component ranges from 0.0
+// (nothing in common) to 1.0 (indistinguishable by that measure).
+type Similarity struct {
+	Graph   float64 `json:"graph"`   // 1 - normalized approximate graph edit distance
+	Layout  float64 `json:"layout"`  // Intersection over union of the carved floor
+	Content float64 `json:"content"` // 1 - Jensen-Shannon divergence of the content mix
+	Score   float64 `json:"score"`   // Mean of the three components
+}
+
+// CompareDungeons computes how similar two dungeons are, to measure how
+// different seeds are or whether a config change affects the output.
+//
+// Room IDs are not comparable between dungeons, so the graph component
+// approximates the graph edit distance by its label lower bound: rooms are
+// labeled by archetype and connectors by connector type and the archetypes
+// they join, and every room or connector without a same-labeled counterpart
+// costs one edit. The distance is normalized by the larger graph's rooms and
+// connectors together.
+//
+// The layout component overlays the floor layers of both tile maps at the
+// origin. The content component compares the mix of enemy types (weighted
+// by enemy count), loot items, traps, puzzles and secrets.
+func CompareDungeons(a, b *dungeon.Artifact) Similarity {
+	s := Similarity{
+		Graph:   graphSimilarity(artifactGraph(a), artifactGraph(b)),
+		Layout:  floorIoU(a.TileMap, b.TileMap),
+		Content: 1 - jensenShannon(contentMix(a.Content), contentMix(b.Content)),
+	}
+	s.Score = (s.Graph + s.Layout + s.Content) / 3
+	return s
+}
+
+// SimilarityScore returns the overall score of CompareDungeons, for use as a
+// dungeon.SimilarityFunc.
+func SimilarityScore(a, b *dungeon.Artifact) float64 {
+	return CompareDungeons(a, b).Score
+}
+
+// MeanSimilarity returns the mean similarity over all pairs of dungeons, a
+// measure of how diverse a corpus is: the lower, the more the dungeons
+// differ. Fewer than two dungeons are fully similar.
+func MeanSimilarity(artifacts []*dungeon.Artifact) Similarity {
+	var sum Similarity
+	pairs := 0
+	for i := range artifacts {
+		for j := i + 1; j < len(artifacts); j++ {
+			s := CompareDungeons(artifacts[i], artifacts[j])
+			sum.Graph += s.Graph
+			sum.Layout += s.Layout
+			sum.Content += s.Content
+			sum.Score += s.Score
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return Similarity{Graph: 1, Layout: 1, Content: 1, Score: 1}
+	}
+	n := float64(pairs)
+	return Similarity{Graph: sum.Graph / n, Layout: sum.Layout / n, Content: sum.Content / n, Score: sum.Score / n}
+}
+
+// artifactGraph returns the room graph of an artifact, or nil.
+func artifactGraph(a *dungeon.Artifact) *graph.Graph {
+	if a == nil || a.ADG == nil {
+		return nil
+	}
+	return a.ADG.Graph
+}
+
+// graphSimilarity returns 1 minus the label lower bound of the graph edit
+// distance between g1 and g2, normalized to [0, 1].
+func graphSimilarity(g1, g2 *graph.Graph) float64 {
+	rooms1, conns1 := graphLabels(g1)
+	rooms2, conns2 := graphLabels(g2)
+	size1 := total(rooms1) + total(conns1)
+	size2 := total(rooms2) + total(conns2)
+	if size1 == 0 && size2 == 0 {
+		return 1
+	}
+
+	edits := labelEdits(rooms1, rooms2) + labelEdits(conns1, conns2)
+	return 1 - float64(edits)/float64(max(size1, size2))
+}
+
+// graphLabels counts the rooms of g by archetype and its connectors by type
+// and the archetypes of their ends.
+func graphLabels(g *graph.Graph) (rooms, conns map[string]int) {
+	rooms, conns = map[string]int{}, map[string]int{}
+	if g == nil {
+		return rooms, conns
+	}
+	for _, room := range g.Rooms {
+		rooms[room.Archetype.String()]++
+	}
+	for _, conn := range g.Connectors {
+		from, to := roomLabel(g, conn.From), roomLabel(g, conn.To)
+		if conn.Bidirectional && to < from {
+			from, to = to, from
+		}
+		conns[conn.Type.String()+":"+from+"-"+to]++
+	}
+	return rooms, conns
+}
+
+// roomLabel returns the archetype name of a room, or "" if it is missing.
+func roomLabel(g *graph.Graph, id string) string {
+	if room, ok := g.Rooms[id]; ok {
+		return room.Archetype.String()
+	}
+	return ""
+}
+
+// labelEdits returns the insertions, deletions and relabelings needed to
+// turn one labeled multiset into another: the larger size minus the
+// elements with a same-labeled counterpart.
+func labelEdits(a, b map[string]int) int {
+	matched := 0
+	for label, n := range a {
+		matched += min(n, b[label])
+	}
+	return max(total(a), total(b)) - matched
+}
+
+// total returns the size of a labeled multiset.
+func total(m map[string]int) int {
+	n := 0
+	for _, c := range m {
+		n += c
+	}
+	return n
+}
+
+// floorIoU returns the intersection over union of the floor tiles of two
+// tile maps overlaid at the origin. Two maps without floor are identical.
+func floorIoU(a, b *dungeon.TileMap) float64 {
+	floorA, floorB := floorLayer(a), floorLayer(b)
+	width, height := 0, 0
+	if floorA != nil {
+		width, height = max(width, a.Width), max(height, a.Height)
+	}
+	if floorB != nil {
+		width, height = max(width, b.Width), max(height, b.Height)
+	}
+
+	isFloor := func(tm *dungeon.TileMap, data []uint32, x, y int) bool {
+		return data != nil && x < tm.Width && y < tm.Height && data[y*tm.Width+x] != 0
+	}
+	inter, union := 0, 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			inA, inB := isFloor(a, floorA, x, y), isFloor(b, floorB, x, y)
+			if inA && inB {
+				inter++
+			}
+			if inA || inB {
+				union++
+			}
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(inter) / float64(union)
+}
+
+// floorLayer returns the floor tile data of a tile map, or nil.
+func floorLayer(tm *dungeon.TileMap) []uint32 {
+	if tm == nil {
+		return nil
+	}
+	layer, ok := tm.Layers["floor"]
+	if !ok || len(layer.Data) < tm.Width*tm.Height {
+		return nil
+	}
+	return layer.Data
+}
+
+// contentMix counts a dungeon's content by kind and type.
+func contentMix(c *dungeon.Content) map[string]float64 {
+	mix := map[string]float64{}
+	if c == nil {
+		return mix
+	}
+	for _, s := range c.Spawns {
+		mix["enemy:"+s.EnemyType] += float64(max(1, s.Count))
+	}
+	for _, l := range c.Loot {
+		mix["loot:"+l.ItemType]++
+	}
+	for _, t := range c.Traps {
+		mix["trap:"+t.TrapType]++
+	}
+	for _, p := range c.Puzzles {
+		mix["puzzle:"+p.Type]++
+	}
+	for _, s := range c.Secrets {
+		mix["secret:"+s.Type]++
+	}
+	return mix
+}
+
+// jensenShannon returns the Jensen-Shannon divergence, in bits, between two
+// unnormalized distributions: 0 for identical mixes, 1 for disjoint ones.
+// Two empty distributions are identical; an empty and a non-empty one are
+// disjoint. Keys are summed in sorted order, so the result is deterministic
+// and exactly symmetric.
+func jensenShannon(p, q map[string]float64) float64 {
+	keys := make([]string, 0, len(p)+len(q))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	for key := range q {
+		if _, ok := p[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	sumP, sumQ := 0.0, 0.0
+	for _, key := range keys {
+		sumP += p[key]
+		sumQ += q[key]
+	}
+	if sumP == 0 || sumQ == 0 {
+		if sumP == sumQ {
+			return 0
+		}
+		return 1
+	}
+
+	// Half the Kullback-Leibler divergence of each from their mean
+	term := func(v, m float64) float64 {
+		if v == 0 {
+			return 0
+		}
+		return v * math.Log2(v/m)
+	}
+	d := 0.0
+	for _, key := range keys {
+		pk, qk := p[key]/sumP, q[key]/sumQ
+		m := (pk + qk) / 2
+		d += term(pk, m) + term(qk, m)
+	}
+	return math.Min(1, math.Max(0, d/2))
+}
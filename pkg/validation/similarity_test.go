@@ -0,0 +1,64 @@
+package validation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/validation"
+)
+
+// TestCompareDungeons verifies a dungeon is fully similar to itself, that
+// other seeds differ, and that disjoint content mixes score zero.
+func TestCompareDungeons(t *testing.T) {
+	gen := dungeon.NewGeneratorWithValidator(validation.NewValidator())
+	generate := func(seed uint64) *dungeon.Artifact {
+		t.Helper()
+		cfg := &dungeon.Config{
+			Seed:          seed,
+			Size:          dungeon.SizeCfg{RoomsMin: 20, RoomsMax: 30},
+			Branching:     dungeon.BranchingCfg{Avg: 2.0, Max: 4},
+			Pacing:        dungeon.PacingCfg{Curve: dungeon.PacingLinear, Variance: 0.1},
+			Themes:        []string{"dungeon"},
+			OptionalRatio: 0.2,
+		}
+		artifact, err := gen.Generate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("seed %d: Generate() error = %v", seed, err)
+		}
+		return artifact
+	}
+	a, b, c := generate(1), generate(2), generate(3)
+
+	self := validation.CompareDungeons(a, a)
+	if self.Graph != 1 || self.Layout != 1 || self.Content != 1 || self.Score != 1 {
+		t.Errorf("CompareDungeons(a, a) = %+v, want all 1", self)
+	}
+
+	other := validation.CompareDungeons(a, b)
+	for name, v := range map[string]float64{"graph": other.Graph, "layout": other.Layout, "content": other.Content} {
+		if v < 0 || v > 1 {
+			t.Errorf("%s similarity %v outside [0, 1]", name, v)
+		}
+	}
+	if other.Score >= 1 || other.Layout >= 1 {
+		t.Errorf("CompareDungeons(a, b) = %+v, want different seeds to differ", other)
+	}
+	if back := validation.CompareDungeons(b, a); back != other {
+		t.Errorf("CompareDungeons is not symmetric: %+v vs %+v", back, other)
+	}
+
+	mean := validation.MeanSimilarity([]*dungeon.Artifact{a, b, c})
+	if mean.Score <= 0 || mean.Score >= 1 {
+		t.Errorf("MeanSimilarity() = %+v, want between 0 and 1", mean)
+	}
+	if single := validation.MeanSimilarity([]*dungeon.Artifact{a}); single.Score != 1 {
+		t.Errorf("MeanSimilarity of one dungeon = %+v, want 1", single)
+	}
+
+	goblins := &dungeon.Artifact{Content: &dungeon.Content{Spawns: []dungeon.Spawn{{EnemyType: "goblin", Count: 3}}}}
+	gold := &dungeon.Artifact{Content: &dungeon.Content{Loot: []dungeon.Loot{{ItemType: "gold"}}}}
+	if got := validation.CompareDungeons(goblins, gold).Content; got != 0 {
+		t.Errorf("content similarity of disjoint mixes = %v, want 0", got)
+	}
+}
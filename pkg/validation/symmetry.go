@@ -0,0 +1,180 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// CheckSymmetry ensures an arena dungeon is mirror-symmetric: every team room
+// has a counterpart with the same archetype, size, difficulty and reward,
+// every connector has a mirrored twin of the same type, and, with a layout,
+// mirrored rooms sit at mirrored positions about the neutral room's vertical
+// axis (within one tile, allowing for center rounding).
+// This is a hard constraint, checked when Config.Mode is arena.
+func CheckSymmetry(g *graph.Graph, layout *dungeon.Layout) dungeon.ConstraintResult {
+	_, violations := symmetryViolations(g, layout)
+
+	satisfied := len(violations) == 0
+	details := "Arena halves mirror each other"
+	if !satisfied {
+		details = fmt.Sprintf("Symmetry violations: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"Symmetry",
+		"arena.isSymmetric()",
+		satisfied,
+		details,
+	)
+}
+
+// CalculateSymmetryScore returns the fraction of mirror checks (room pairs and
+// connectors) that pass, from 0.0 (no symmetry) to 1.0 (perfect mirror).
+// Graphs without team tags score 0.0.
+func CalculateSymmetryScore(g *graph.Graph, layout *dungeon.Layout) float64 {
+	checked, violations := symmetryViolations(g, layout)
+	if checked == 0 {
+		return 0.0
+	}
+	return math.Max(0.0, 1.0-float64(len(violations))/float64(checked))
+}
+
+// CalculateTeamBalance compares the content each arena team faces: enemy
+// counts and loot values in team A rooms against team B rooms. Each is scored
+// 1 - |a-b|/(a+b) and the two scores are averaged; 1.0 is perfectly fair.
+// Without content, or with nothing placed, the balance is 1.0.
+func CalculateTeamBalance(g *graph.Graph, content *dungeon.Content) float64 {
+	if content == nil {
+		return 1.0
+	}
+
+	team := func(roomID string) string {
+		if room, ok := g.Rooms[roomID]; ok {
+			return room.Tags["team"]
+		}
+		return ""
+	}
+
+	enemies := map[string]int{}
+	for _, spawn := range content.Spawns {
+		enemies[team(spawn.RoomID)] += spawn.Count
+	}
+	loot := map[string]int{}
+	for _, item := range content.Loot {
+		loot[team(item.RoomID)] += item.Value
+	}
+
+	balance := func(a, b int) float64 {
+		if a+b == 0 {
+			return 1.0
+		}
+		return 1.0 - math.Abs(float64(a-b))/float64(a+b)
+	}
+
+	return (balance(enemies["a"], enemies["b"]) + balance(loot["a"], loot["b"])) / 2
+}
+
+// symmetryViolations runs every mirror check and returns how many checks ran
+// and which failed.
+func symmetryViolations(g *graph.Graph, layout *dungeon.Layout) (int, []string) {
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	var neutral []string
+	teamRooms := 0
+	for _, id := range roomIDs {
+		switch g.Rooms[id].Tags["team"] {
+		case "neutral":
+			neutral = append(neutral, id)
+		case "a", "b":
+			teamRooms++
+		}
+	}
+	if teamRooms == 0 {
+		return 0, []string{"no team-tagged rooms"}
+	}
+
+	checked := 1
+	violations := []string{}
+	if len(neutral) != 1 {
+		violations = append(violations, fmt.Sprintf("expected 1 neutral room, found %d", len(neutral)))
+	}
+
+	// mirror maps a room to its counterpart; neutral rooms map to themselves
+	mirror := func(id string) string {
+		room := g.Rooms[id]
+		if room.Tags["team"] == "neutral" {
+			return id
+		}
+		return room.Tags["mirror"]
+	}
+
+	// Room pairs, checked once from the team A side
+	axis, hasAxis := 0, false
+	if layout != nil && len(neutral) == 1 {
+		if pose, ok := layout.Poses[neutral[0]]; ok {
+			axis, hasAxis = pose.X, true
+		}
+	}
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		if room.Tags["team"] != "a" {
+			continue
+		}
+		checked++
+
+		twin, ok := g.Rooms[room.Tags["mirror"]]
+		switch {
+		case !ok || twin.Tags["team"] != "b" || twin.Tags["mirror"] != id:
+			violations = append(violations, fmt.Sprintf("room %s has no team B mirror", id))
+			continue
+		case twin.Archetype != room.Archetype || twin.Size != room.Size:
+			violations = append(violations, fmt.Sprintf("rooms %s and %s differ in type", id, twin.ID))
+			continue
+		case math.Abs(twin.Difficulty-room.Difficulty) > 1e-9 || math.Abs(twin.Reward-room.Reward) > 1e-9:
+			violations = append(violations, fmt.Sprintf("rooms %s and %s differ in difficulty or reward", id, twin.ID))
+			continue
+		}
+
+		if hasAxis {
+			a, okA := layout.Poses[id]
+			b, okB := layout.Poses[twin.ID]
+			if okA && okB && (abs(a.X+b.X-2*axis) > 1 || a.Y != b.Y) {
+				violations = append(violations, fmt.Sprintf("rooms %s and %s are not mirrored in the layout", id, twin.ID))
+			}
+		}
+	}
+
+	// Connectors, each of which must have a twin between mirrored endpoints
+	type endpoints struct{ from, to string }
+	byEndpoints := make(map[endpoints]*graph.Connector, len(g.Connectors))
+	connIDs := make([]string, 0, len(g.Connectors))
+	for id, conn := range g.Connectors {
+		byEndpoints[endpoints{conn.From, conn.To}] = conn
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		conn := g.Connectors[id]
+		from, to := mirror(conn.From), mirror(conn.To)
+		if from == conn.From && to == conn.To {
+			continue // Neutral-only connector mirrors itself
+		}
+		checked++
+
+		twin, ok := byEndpoints[endpoints{from, to}]
+		if !ok || twin.Type != conn.Type || twin.Visibility != conn.Visibility ||
+			twin.Bidirectional != conn.Bidirectional {
+			violations = append(violations, fmt.Sprintf("connector %s has no mirrored twin", id))
+		}
+	}
+
+	return checked, violations
+}
@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// buildArenaGraph creates a minimal mirrored arena:
+//
+//	a_start - a_lane - arena - b_lane - b_start
+func buildArenaGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+	g := graph.NewGraph(1)
+	for _, room := range []*graph.Room{
+		{ID: "arena", Archetype: graph.ArchetypeBoss, Size: graph.SizeXL, Tags: map[string]string{"team": "neutral"}},
+		{ID: "a_start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Tags: map[string]string{"team": "a", "mirror": "b_start"}},
+		{ID: "a_lane", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS, Difficulty: 0.5, Tags: map[string]string{"team": "a", "mirror": "b_lane"}},
+		{ID: "b_start", Archetype: graph.ArchetypeStart, Size: graph.SizeM, Tags: map[string]string{"team": "b", "mirror": "a_start"}},
+		{ID: "b_lane", Archetype: graph.ArchetypeCorridor, Size: graph.SizeS, Difficulty: 0.5, Tags: map[string]string{"team": "b", "mirror": "a_lane"}},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pair := range [][2]string{{"a_start", "a_lane"}, {"a_lane", "arena"}, {"b_start", "b_lane"}, {"b_lane", "arena"}} {
+		conn := &graph.Connector{ID: pair[0] + "_" + pair[1], From: pair[0], To: pair[1], Type: graph.TypeDoor, Cost: 1.0, Bidirectional: true}
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+// TestCheckSymmetry verifies mirrored graphs and layouts pass and any
+// asymmetry in rooms, connectors or poses is reported.
+func TestCheckSymmetry(t *testing.T) {
+	g := buildArenaGraph(t)
+	layout := &dungeon.Layout{Poses: map[string]dungeon.Pose{
+		"arena":   {X: 50, Y: 20},
+		"a_lane":  {X: 35, Y: 20},
+		"a_start": {X: 20, Y: 10},
+		"b_lane":  {X: 65, Y: 20},
+		"b_start": {X: 80, Y: 10},
+	}}
+
+	if result := CheckSymmetry(g, layout); !result.Satisfied {
+		t.Fatalf("mirrored arena should satisfy constraint: %s", result.Details)
+	}
+	if score := CalculateSymmetryScore(g, layout); score != 1.0 {
+		t.Errorf("CalculateSymmetryScore() = %.2f, want 1.0", score)
+	}
+
+	// Layout asymmetry
+	layout.Poses["b_start"] = dungeon.Pose{X: 84, Y: 10}
+	if result := CheckSymmetry(g, layout); result.Satisfied {
+		t.Errorf("shifted mirror room should violate constraint: %s", result.Details)
+	}
+	layout.Poses["b_start"] = dungeon.Pose{X: 80, Y: 10}
+
+	// Room asymmetry
+	g.Rooms["b_lane"].Difficulty = 0.9
+	if result := CheckSymmetry(g, layout); result.Satisfied {
+		t.Errorf("differing difficulty should violate constraint: %s", result.Details)
+	}
+	g.Rooms["b_lane"].Difficulty = 0.5
+
+	// Connector asymmetry
+	g.Connectors["b_lane_arena"].Type = graph.TypeCorridor
+	if result := CheckSymmetry(g, layout); result.Satisfied {
+		t.Errorf("differing connector type should violate constraint: %s", result.Details)
+	}
+	if score := CalculateSymmetryScore(g, layout); score <= 0 || score >= 1 {
+		t.Errorf("CalculateSymmetryScore() = %.2f, want partial score", score)
+	}
+
+	// Standard dungeons have no team tags
+	if score := CalculateSymmetryScore(createLinearTestGraph(5), nil); score != 0 {
+		t.Errorf("CalculateSymmetryScore() on untagged graph = %.2f, want 0", score)
+	}
+}
+
+// TestCalculateTeamBalance verifies content fairness between teams.
+func TestCalculateTeamBalance(t *testing.T) {
+	g := buildArenaGraph(t)
+
+	content := &dungeon.Content{
+		Spawns: []dungeon.Spawn{{RoomID: "a_lane", Count: 3}, {RoomID: "b_lane", Count: 3}, {RoomID: "arena", Count: 5}},
+		Loot:   []dungeon.Loot{{RoomID: "a_lane", Value: 100}, {RoomID: "b_lane", Value: 100}},
+	}
+	if balance := CalculateTeamBalance(g, content); balance != 1.0 {
+		t.Errorf("CalculateTeamBalance() = %.2f, want 1.0", balance)
+	}
+
+	// Team B gets 1 enemy to team A's 3: enemy balance 0.5, loot balance 1.0
+	content.Spawns[1].Count = 1
+	if balance := CalculateTeamBalance(g, content); balance != 0.75 {
+		t.Errorf("CalculateTeamBalance() = %.2f, want 0.75", balance)
+	}
+}
@@ -2,8 +2,11 @@ package validation
 
 import (
 	"context"
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/dshills/dungo/pkg/carving"
 	"github.com/dshills/dungo/pkg/dungeon"
 	"github.com/dshills/dungo/pkg/graph"
 )
@@ -262,6 +265,362 @@ func TestCheckKeyReachability_CircularDependency(t *testing.T) {
 	}
 }
 
+func TestCheckKeyReachability_KeyBehindOwnLock(t *testing.T) {
+	g := createTestGraph()
+	cfg := createTestConfig()
+	cfg.Keys = []dungeon.KeyCfg{{Name: "silver", Count: 1}}
+
+	// The key sits in mid2, but the only way there is through mid1, which
+	// needs the key
+	g.Rooms["mid1"].Requirements = []graph.Requirement{{Type: "key", Value: "silver"}}
+	g.Rooms["mid2"].Provides = []graph.Capability{{Type: "key", Value: "silver"}}
+
+	result := CheckKeyReachability(g, cfg)
+	if result.Satisfied {
+		t.Errorf("Expected key reachability to fail for a key behind its own lock")
+	}
+
+	// A gated connector locks the way just the same
+	g.Rooms["mid1"].Requirements = nil
+	g.Rooms["boss"].Requirements = []graph.Requirement{{Type: "key", Value: "silver"}}
+	g.Connectors["c2"].Gate = &graph.Gate{Type: "key", Value: "silver"}
+	if result := CheckKeyReachability(g, cfg); result.Satisfied {
+		t.Errorf("Expected key reachability to fail for a key behind its own gate")
+	}
+
+	// A second route around the gate fixes it
+	if err := g.AddConnector(&graph.Connector{
+		ID:            "c4",
+		From:          "start",
+		To:            "mid2",
+		Type:          graph.TypeCorridor,
+		Cost:          1.0,
+		Bidirectional: true,
+	}); err != nil {
+		t.Fatalf("Failed to add connector: %v", err)
+	}
+	if result := CheckKeyReachability(g, cfg); !result.Satisfied {
+		t.Errorf("Expected key reachability to pass with a route around the gate, got: %s", result.Details)
+	}
+}
+
+func TestCheckKeyReachability_ChainedKeys(t *testing.T) {
+	g := createTestGraph()
+	cfg := createTestConfig()
+	cfg.Keys = []dungeon.KeyCfg{{Name: "silver", Count: 1}, {Name: "gold", Count: 1}}
+
+	// The silver key opens mid2, which holds the gold key for the boss
+	g.Rooms["mid1"].Provides = []graph.Capability{{Type: "key", Value: "silver"}}
+	g.Rooms["mid2"].Requirements = []graph.Requirement{{Type: "key", Value: "silver"}}
+	g.Rooms["mid2"].Provides = []graph.Capability{{Type: "key", Value: "gold"}}
+	g.Rooms["boss"].Requirements = []graph.Requirement{{Type: "key", Value: "gold"}}
+
+	if result := CheckKeyReachability(g, cfg); !result.Satisfied {
+		t.Errorf("Expected key reachability to pass for chained keys, got: %s", result.Details)
+	}
+
+	// Locking mid1 with the gold key leaves no key obtainable first
+	g.Rooms["mid1"].Requirements = []graph.Requirement{{Type: "key", Value: "gold"}}
+	if result := CheckKeyReachability(g, cfg); result.Satisfied {
+		t.Errorf("Expected key reachability to fail when the first key is locked behind the second")
+	}
+}
+
+func TestCheckKeyReachability_MultiKeyGate(t *testing.T) {
+	g := createTestGraph()
+	cfg := createTestConfig()
+	cfg.Keys = []dungeon.KeyCfg{{Name: "silver", Count: 1}, {Name: "gold", Count: 1}}
+
+	// The boss door needs both keys, found on the way
+	g.Rooms["mid1"].Provides = []graph.Capability{{Type: "key", Value: "silver"}}
+	g.Rooms["mid2"].Provides = []graph.Capability{{Type: "key", Value: "gold"}}
+	g.Connectors["c3"].Gate = &graph.Gate{
+		Type:         "key",
+		Value:        "silver",
+		Requirements: []graph.Requirement{{Type: "key", Value: "gold"}},
+	}
+	if result := CheckKeyReachability(g, cfg); !result.Satisfied {
+		t.Errorf("Expected key reachability to pass with both keys obtainable, got: %s", result.Details)
+	}
+
+	// Without the gold key the door never opens
+	g.Rooms["mid2"].Provides = nil
+	if result := CheckKeyReachability(g, cfg); result.Satisfied {
+		t.Errorf("Expected key reachability to fail when one of the door's keys is missing")
+	}
+
+	// Any one key is enough for an any-of door
+	g.Connectors["c3"].Gate.Any = true
+	if result := CheckKeyReachability(g, cfg); !result.Satisfied {
+		t.Errorf("Expected key reachability to pass for an any-of door, got: %s", result.Details)
+	}
+}
+
+func TestCheckKeyReachability_SmallKeys(t *testing.T) {
+	g := createTestGraph()
+	cfg := createTestConfig()
+	cfg.Keys = []dungeon.KeyCfg{{Name: "castle", Count: 2, Consumable: true}}
+
+	// One small key opens either a side closet or the way to the boss
+	if err := g.AddRoom(&graph.Room{
+		ID:         "closet",
+		Archetype:  graph.ArchetypeOptional,
+		Size:       graph.SizeS,
+		Difficulty: 0.2,
+		Reward:     0.5,
+	}); err != nil {
+		t.Fatalf("Failed to add room: %v", err)
+	}
+	if err := g.AddConnector(&graph.Connector{
+		ID:            "c4",
+		From:          "mid1",
+		To:            "closet",
+		Type:          graph.TypeDoor,
+		Gate:          &graph.Gate{Type: graph.SmallKey, Value: "castle"},
+		Cost:          1.0,
+		Bidirectional: true,
+	}); err != nil {
+		t.Fatalf("Failed to add connector: %v", err)
+	}
+	g.Rooms["mid1"].Provides = []graph.Capability{{Type: graph.SmallKey, Value: "castle"}}
+	g.Connectors["c3"].Gate = &graph.Gate{Type: graph.SmallKey, Value: "castle"}
+
+	result := CheckKeyReachability(g, cfg)
+	if result.Satisfied {
+		t.Errorf("Expected key reachability to fail when a small key can be wasted on the closet")
+	}
+
+	// A second key on the way covers both doors
+	g.Rooms["mid2"].Provides = []graph.Capability{{Type: graph.SmallKey, Value: "castle"}}
+	if result := CheckKeyReachability(g, cfg); !result.Satisfied {
+		t.Errorf("Expected key reachability to pass with a key for each door, got: %s", result.Details)
+	}
+}
+
+func TestCheckSoftLocks(t *testing.T) {
+	g := createTestGraph()
+	if result := CheckSoftLocks(g); !result.Satisfied {
+		t.Errorf("Expected no soft locks, got: %s", result.Details)
+	}
+
+	// A one-way drop into a dead end traps the player
+	if err := g.AddRoom(&graph.Room{
+		ID:         "pit",
+		Archetype:  graph.ArchetypeOptional,
+		Size:       graph.SizeS,
+		Difficulty: 0.2,
+		Reward:     0.5,
+	}); err != nil {
+		t.Fatalf("Failed to add room: %v", err)
+	}
+	if err := g.AddConnector(&graph.Connector{
+		ID:   "drop",
+		From: "mid1",
+		To:   "pit",
+		Type: graph.TypeOneWay,
+		Cost: 1.0,
+	}); err != nil {
+		t.Fatalf("Failed to add connector: %v", err)
+	}
+
+	result := CheckSoftLocks(g)
+	if result.Satisfied {
+		t.Fatal("Expected a soft lock for a one-way drop into a dead end")
+	}
+	if !strings.Contains(result.Details, "start -c1-> mid1 -drop-> pit (no way out)") {
+		t.Errorf("Expected the walk into the pit in details, got: %s", result.Details)
+	}
+}
+
+func TestCheckArchetypeDistribution(t *testing.T) {
+	g := createTestGraph()
+	cfg := createTestConfig()
+
+	// One treasure room of four is on target
+	cfg.Archetypes = []dungeon.ArchetypeCfg{{Archetype: "treasure", Fraction: 0.25}}
+	result := CheckArchetypeDistribution(g, cfg)
+	if result.Score != 1.0 {
+		t.Errorf("Expected score 1.0 on target, got %.2f: %s", result.Score, result.Details)
+	}
+
+	// A missing vendor halves the score
+	cfg.Archetypes = append(cfg.Archetypes, dungeon.ArchetypeCfg{Archetype: "vendor", Count: 1})
+	result = CheckArchetypeDistribution(g, cfg)
+	if result.Score != 0.5 || result.Satisfied {
+		t.Errorf("Expected unsatisfied score 0.5, got %.2f", result.Score)
+	}
+	if !strings.Contains(result.Details, "Vendor 0 (target 1.0 ±0.0)") || !strings.Contains(result.Details, "Vendor outside tolerance") {
+		t.Errorf("Expected the vendor deviation in details, got: %s", result.Details)
+	}
+}
+
+func TestCheckFloorBudget(t *testing.T) {
+	tm := &dungeon.TileMap{
+		Width:  4,
+		Height: 2,
+		Layers: map[string]*dungeon.Layer{
+			"floor": {Name: "floor", Type: "tilelayer", Data: []uint32{1, 1, 0, 1, 0, 1, 1, 1}},
+		},
+	}
+	if area := CalculateFloorArea(tm); area != 6 {
+		t.Fatalf("Expected 6 floor tiles, got %d", area)
+	}
+
+	if result := CheckFloorBudget(tm, 8); result.Score != 1.0 || !strings.Contains(result.Details, "within budget") {
+		t.Errorf("Expected full score within budget, got %.2f: %s", result.Score, result.Details)
+	}
+
+	result := CheckFloorBudget(tm, 3)
+	if result.Score != 0.5 || result.Satisfied {
+		t.Errorf("Expected unsatisfied score 0.5 at twice the budget, got %.2f", result.Score)
+	}
+	if !strings.Contains(result.Details, "over budget by 3 tiles") {
+		t.Errorf("Expected the overrun in details, got: %s", result.Details)
+	}
+
+	if area := CalculateFloorArea(nil); area != 0 {
+		t.Errorf("Expected no floor without a tile map, got %d", area)
+	}
+}
+
+func TestLayoutMetrics(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, id := range []string{"a", "b", "c"} {
+		if err := g.AddRoom(&graph.Room{ID: id, Archetype: graph.ArchetypeOptional, Size: graph.SizeS}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 5x5 rooms centered on a row, 2 and 6 tiles apart
+	layout := &dungeon.Layout{
+		Poses: map[string]dungeon.Pose{
+			"a": {X: 2, Y: 2},
+			"b": {X: 9, Y: 2},
+			"c": {X: 20, Y: 2},
+		},
+		CorridorPaths: map[string]dungeon.Path{
+			"ab": {Points: []dungeon.Point{{X: 2, Y: 2}, {X: 9, Y: 2}}},
+			"bc": {Points: []dungeon.Point{{X: 9, Y: 2}, {X: 20, Y: 2}}},
+			// Crosses both corridors above
+			"x": {Points: []dungeon.Point{{X: 5, Y: -3}, {X: 5, Y: 7}, {X: 15, Y: 7}, {X: 15, Y: -3}}},
+		},
+	}
+
+	total, variance := CalculateCorridorLengths(layout)
+	if total != 48 {
+		t.Errorf("corridor length = %d, want 48", total)
+	}
+	// Lengths 7, 11 and 30 around a mean of 16
+	if math.Abs(variance-(81+25+196)/3.0) > 1e-9 {
+		t.Errorf("corridor length variance = %v, want %v", variance, (81+25+196)/3.0)
+	}
+	if n := CountCorridorCrossings(layout); n != 2 {
+		t.Errorf("corridor crossings = %d, want 2", n)
+	}
+	// Gaps of 2 (a, b) and 6 (c)
+	if spacing := CalculateRoomSpacing(g, layout); math.Abs(spacing-10.0/3.0) > 1e-9 {
+		t.Errorf("room spacing = %v, want %v", spacing, 10.0/3.0)
+	}
+	// 75 room tiles in the 23x11 box from (0, -3) to (23, 8)
+	if u := CalculateBoundsUtilization(g, layout); math.Abs(u-75.0/253.0) > 1e-9 {
+		t.Errorf("bounds utilization = %v, want %v", u, 75.0/253.0)
+	}
+
+	if total, variance := CalculateCorridorLengths(nil); total != 0 || variance != 0 {
+		t.Errorf("expected no corridors without a layout, got %d, %v", total, variance)
+	}
+	if u := CalculateBoundsUtilization(g, nil); u != 0 {
+		t.Errorf("expected no utilization without a layout, got %v", u)
+	}
+}
+
+func TestCheckMapDimensions(t *testing.T) {
+	tm := &dungeon.TileMap{Width: 120, Height: 80}
+
+	if result := CheckMapDimensions(tm, dungeon.MapCfg{MaxWidth: 120, MaxHeight: 80}); !result.Satisfied {
+		t.Errorf("Expected a map at the limits to pass, got: %s", result.Details)
+	}
+
+	result := CheckMapDimensions(tm, dungeon.MapCfg{MaxWidth: 100, MaxHeight: 100})
+	if result.Satisfied {
+		t.Fatal("Expected a map wider than maxWidth to fail")
+	}
+	if !strings.Contains(result.Details, "wider than maxWidth 100") || strings.Contains(result.Details, "taller") {
+		t.Errorf("Expected only the width in details, got: %s", result.Details)
+	}
+}
+
+func TestCheckGraphConsistency(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, id := range []string{"a", "b", "c"} {
+		if err := g.AddRoom(&graph.Room{ID: id, Archetype: graph.ArchetypeOptional, Size: graph.SizeS}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddConnector(&graph.Connector{ID: "ab", From: "a", To: "b", Type: graph.TypeCorridor, Cost: 1, Bidirectional: true}); err != nil {
+		t.Fatal(err)
+	}
+	// 5x5 rooms: b east of a down a corridor, c touching a from below
+	layout := &dungeon.Layout{
+		Poses: map[string]dungeon.Pose{
+			"a": {X: 2, Y: 2},
+			"b": {X: 12, Y: 2},
+			"c": {X: 2, Y: 7},
+		},
+		CorridorPaths: map[string]dungeon.Path{
+			"ab": {Points: []dungeon.Point{{X: 5, Y: 2}, {X: 9, Y: 2}}},
+		},
+	}
+	tm := &dungeon.TileMap{Width: 16, Height: 12, Layers: map[string]*dungeon.Layer{
+		"floor": {Data: make([]uint32, 16*12)},
+		"doors": {Objects: []dungeon.Object{
+			{Name: "door_ab_start", Properties: map[string]interface{}{"connector_id": "ab", "from_room": "a", "to_room": "b"}},
+		}},
+	}}
+	floor := tm.Layers["floor"].Data
+	fill := func(x, y, w, h int) {
+		for ty := y; ty < y+h; ty++ {
+			for tx := x; tx < x+w; tx++ {
+				floor[ty*16+tx] = uint32(carving.TileFloor)
+			}
+		}
+	}
+	fill(0, 0, 5, 5)
+	fill(10, 0, 5, 5)
+	fill(0, 5, 5, 5)
+	fill(5, 2, 5, 1)
+
+	if result := CheckGraphConsistency(g, layout, tm, false); !result.Satisfied {
+		t.Errorf("Expected a consistent map to pass, got: %s", result.Details)
+	}
+	result := CheckGraphConsistency(g, layout, tm, true)
+	if result.Satisfied || !strings.Contains(result.Details, "floor joins a and c without a connector") {
+		t.Errorf("Expected strict check to flag a and c, got: %s", result.Details)
+	}
+
+	// A stray floor tile and a door without a connector
+	floor[10*16+12] = uint32(carving.TileFloor)
+	tm.Layers["doors"].Objects[0].Properties["connector_id"] = "gone"
+	result = CheckGraphConsistency(g, layout, tm, false)
+	if result.Satisfied {
+		t.Fatal("Expected a stray tile and an orphaned door to fail")
+	}
+	for _, want := range []string{"1 floor tiles outside the rooms lie on no corridor", "door door_ab_start has no connector"} {
+		if !strings.Contains(result.Details, want) {
+			t.Errorf("Expected %q in details, got: %s", want, result.Details)
+		}
+	}
+
+	// A connector whose corridor was never carved
+	fill(5, 2, 5, 1)
+	floor[2*16+7] = uint32(carving.TileEmpty)
+	floor[10*16+12] = uint32(carving.TileEmpty)
+	tm.Layers["doors"].Objects = nil
+	result = CheckGraphConsistency(g, layout, tm, false)
+	if result.Satisfied || !strings.Contains(result.Details, "corridor of connector ab is not carved") {
+		t.Errorf("Expected an uncarved corridor to fail, got: %s", result.Details)
+	}
+}
+
 func TestCheckPathBounds_ValidPath(t *testing.T) {
 	g := createTestGraph()
 	cfg := createTestConfig()
@@ -386,6 +745,35 @@ func TestCalculatePacingDeviation_Exponential(t *testing.T) {
 	}
 }
 
+func TestCalculateSecretFindability(t *testing.T) {
+	g := createTestGraph()
+
+	// No secrets: everything is findable
+	if got := CalculateSecretFindability(g); got != 1.0 {
+		t.Errorf("Expected findability 1.0 without secrets, got %f", got)
+	}
+
+	// A vault behind a hidden connector, and a second vault behind it
+	for _, id := range []string{"vault1", "vault2"} {
+		if err := g.AddRoom(&graph.Room{ID: id, Archetype: graph.ArchetypeSecret, Size: graph.SizeS}); err != nil {
+			t.Fatalf("Failed to add room: %v", err)
+		}
+	}
+	for _, conn := range []*graph.Connector{
+		{ID: "h1", From: "mid1", To: "vault1", Type: graph.TypeHidden, Cost: 1.0, Visibility: graph.VisibilitySecret, Bidirectional: true},
+		{ID: "h2", From: "vault1", To: "vault2", Type: graph.TypeCorridor, Cost: 1.0, Bidirectional: true},
+	} {
+		if err := g.AddConnector(conn); err != nil {
+			t.Fatalf("Failed to add connector: %v", err)
+		}
+	}
+
+	// vault1 is one hop from the visible map, vault2 two: (1 + 1/2) / 2
+	if got := CalculateSecretFindability(g); math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("Expected findability 0.75, got %f", got)
+	}
+}
+
 func TestGetDegreeDistribution(t *testing.T) {
 	g := createTestGraph()
 
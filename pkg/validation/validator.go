@@ -17,19 +17,38 @@ import (
 // Hard constraints (must pass):
 //   - Graph connectivity (all rooms reachable from Start)
 //   - Key reachability (keys obtainable before locks)
+//   - No soft locks (the player can never get stuck before the Boss)
 //   - No spatial overlaps (rooms don't collide)
 //   - Path bounds (Start→Boss path within limits)
+//   - Map dimensions (tile map within Config.Map), when limited
+//   - Secret walls (hidden connectors sealed by secrets)
+//   - Accessibility (no required secrets, checkpoint spacing, low
+//     backtracking), each only when enabled in Config.Accessibility
+//   - Party convergence (player starts near Start), for co-op parties
+//   - Symmetry (mirrored halves and layout), in arena mode
+//   - Wave schedule (spawners open in order, waves never shrink), in wave mode
 //
 // Soft constraints (should optimize):
 //   - Pacing deviation (difficulty curve adherence)
 //   - Branching factor (connectivity targets)
+//   - Archetype distribution (room archetype targets), when configured
+//   - Floor budget (carved floor area), when configured
 //
 // Metrics computed:
 //   - BranchingFactor: average connections per room
 //   - PathLength: Start→Boss critical path length
-//   - CycleCount: number of graph cycles (loops)
+//   - CycleCount: number of independent graph cycles (loops)
 //   - PacingDeviation: L2 distance from target curve
-//   - SecretFindability: heuristic discoverability score
+//   - SecretFindability: average 1/depth of secret rooms behind the visible map
+//   - SpeedrunRooms/SpeedrunTiles: optimal completion route length
+//   - SpeedrunRevisits: rooms the optimal route re-enters
+//   - SymmetryScore/TeamBalance: arena mirror quality and fairness
+//   - EnvironmentShare: share of combat difficulty carried by the environment
+//   - FloorArea: carved floor tiles, rooms and corridors together
+//   - CorridorLength/CorridorLengthVariance: corridor path lengths
+//   - BoundsUtilization: share of the layout bounding box covered by rooms
+//   - CorridorCrossings: points where corridors cross
+//   - RoomSpacing: average gap from each room to its nearest neighbour
 type DefaultValidator struct {
 	// Configuration options could be added here in the future
 }
@@ -111,6 +130,15 @@ func (v *DefaultValidator) checkHardConstraints(ctx context.Context, artifact *d
 		report.HardConstraintResults = append(report.HardConstraintResults, result)
 	}
 
+	// Check the player cannot get stuck
+	if result := CheckSoftLocks(artifact.ADG.Graph); !result.Satisfied {
+		report.Passed = false
+		report.Errors = append(report.Errors, result.Details)
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	} else {
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
 	// Check no overlaps (spatial)
 	if artifact.Layout != nil {
 		if result := CheckNoOverlaps(artifact.ADG.Graph, artifact.Layout); !result.Satisfied {
@@ -122,6 +150,25 @@ func (v *DefaultValidator) checkHardConstraints(ctx context.Context, artifact *d
 		}
 	}
 
+	// Check secret walls (graph/tile map consistency)
+	if result := CheckSecretWalls(artifact.ADG.Graph, artifact.TileMap); !result.Satisfied {
+		report.Passed = false
+		report.Errors = append(report.Errors, result.Details)
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	} else {
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check the carved map matches the graph
+	if artifact.Layout != nil {
+		result := CheckGraphConsistency(artifact.ADG.Graph, artifact.Layout, artifact.TileMap, cfg.Map.Adjacency != "")
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
 	// Check path bounds
 	if result := CheckPathBounds(artifact.ADG.Graph, cfg); !result.Satisfied {
 		report.Passed = false
@@ -131,6 +178,95 @@ func (v *DefaultValidator) checkHardConstraints(ctx context.Context, artifact *d
 		report.HardConstraintResults = append(report.HardConstraintResults, result)
 	}
 
+	// Check map dimensions when limited
+	if cfg.Map.MaxWidth > 0 || cfg.Map.MaxHeight > 0 {
+		result := CheckMapDimensions(artifact.TileMap, cfg.Map)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check accessibility guarantees that are enabled
+	var accessibility []dungeon.ConstraintResult
+	if cfg.Accessibility.NoRequiredSecrets {
+		accessibility = append(accessibility, CheckNoRequiredSecrets(artifact.ADG.Graph, artifact.Content))
+	}
+	if cfg.Accessibility.MaxCombatBetweenCheckpoints > 0 {
+		accessibility = append(accessibility, CheckCheckpointSpacing(artifact.ADG.Graph, artifact.Content, cfg.Accessibility.MaxCombatBetweenCheckpoints))
+	}
+	if cfg.Accessibility.LowBacktracking {
+		accessibility = append(accessibility, CheckLowBacktracking(artifact.ADG.Graph, artifact.Content))
+	}
+	for _, result := range accessibility {
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check the Exit lies past the Boss
+	if cfg.Exit {
+		result := CheckExitAfterBoss(artifact.ADG.Graph)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check vaults stay off the critical path
+	if cfg.Vaults > 0 {
+		result := CheckVaultsOptional(artifact.ADG.Graph, cfg.Vaults)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check the post-boss escape can be finished in time
+	if cfg.Escape.Enabled {
+		result := CheckEscapeCompletable(artifact.ADG.Graph, artifact.TileMap, artifact.Content, cfg.Escape.MoveSpeed(), cfg.Escape.TimeLimitMs)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check party convergence for co-op parties
+	if cfg.Party.Size > 1 {
+		result := CheckPartyConvergence(artifact.ADG.Graph, artifact.Content, cfg.Party.Size, cfg.Party.ConvergenceLimit())
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check mirrored halves in arena mode
+	if cfg.Mode == dungeon.ModeArena {
+		result := CheckSymmetry(artifact.ADG.Graph, artifact.Layout)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
+	// Check spawner waves in wave mode
+	if cfg.Mode == dungeon.ModeWave {
+		result := CheckWaveSchedule(artifact.ADG.Graph, artifact.Content)
+		if !result.Satisfied {
+			report.Passed = false
+			report.Errors = append(report.Errors, result.Details)
+		}
+		report.HardConstraintResults = append(report.HardConstraintResults, result)
+	}
+
 	return nil
 }
 
@@ -152,6 +288,26 @@ func (v *DefaultValidator) checkSoftConstraints(ctx context.Context, artifact *d
 		report.SoftConstraintResults = append(report.SoftConstraintResults, result)
 	}
 
+	// Check archetype distribution, when targets are configured
+	if len(cfg.Archetypes) > 0 {
+		if result := CheckArchetypeDistribution(artifact.ADG.Graph, cfg); result.Score < 0.8 {
+			report.Warnings = append(report.Warnings, result.Details)
+			report.SoftConstraintResults = append(report.SoftConstraintResults, result)
+		} else {
+			report.SoftConstraintResults = append(report.SoftConstraintResults, result)
+		}
+	}
+
+	// Check floor area, when budgeted
+	if cfg.Rooms.FloorBudget > 0 {
+		if result := CheckFloorBudget(artifact.TileMap, cfg.Rooms.FloorBudget); result.Score < 0.8 {
+			report.Warnings = append(report.Warnings, result.Details)
+			report.SoftConstraintResults = append(report.SoftConstraintResults, result)
+		} else {
+			report.SoftConstraintResults = append(report.SoftConstraintResults, result)
+		}
+	}
+
 	return nil
 }
 
@@ -164,21 +320,43 @@ func (v *DefaultValidator) computeMetrics(ctx context.Context, artifact *dungeon
 		PathLength:        CalculatePathLength(g),
 		CycleCount:        CountCycles(g),
 		PacingDeviation:   CalculatePacingDeviation(g, cfg),
-		SecretFindability: 0.0, // TODO: Implement in future iterations
+		SecretFindability: CalculateSecretFindability(g),
+		EnvironmentShare:  CalculateEnvironmentShare(g),
+		FloorArea:         CalculateFloorArea(artifact.TileMap),
+		BoundsUtilization: CalculateBoundsUtilization(g, artifact.Layout),
+		CorridorCrossings: CountCorridorCrossings(artifact.Layout),
+		RoomSpacing:       CalculateRoomSpacing(g, artifact.Layout),
+	}
+	metrics.CorridorLength, metrics.CorridorLengthVariance = CalculateCorridorLengths(artifact.Layout)
+	metrics.Difficulty = CalculateDifficultyProfile(artifact, cfg)
+
+	// Unreachable bosses are reported by the hard constraints; leave the
+	// speedrun metrics at zero in that case
+	if route, err := FindSpeedrunRoute(g, artifact.Layout); err == nil {
+		metrics.SpeedrunRooms = route.RoomCount
+		metrics.SpeedrunTiles = route.TileLength
+		metrics.SpeedrunRevisits = route.Revisits
+	}
+
+	if cfg.Mode == dungeon.ModeArena {
+		metrics.SymmetryScore = CalculateSymmetryScore(g, artifact.Layout)
+		metrics.TeamBalance = CalculateTeamBalance(g, artifact.Content)
 	}
 
 	return metrics, nil
 }
 
 // FindStartRoom locates the Start room in the graph.
+// Arena graphs have one Start room per team; the lowest ID is returned.
 // Returns the room ID or empty string if not found.
 func FindStartRoom(g *graph.Graph) string {
+	start := ""
 	for id, room := range g.Rooms {
-		if room.Archetype == graph.ArchetypeStart {
-			return id
+		if room.Archetype == graph.ArchetypeStart && (start == "" || id < start) {
+			start = id
 		}
 	}
-	return ""
+	return start
 }
 
 // FindBossRoom locates the Boss room in the graph.
@@ -192,6 +370,18 @@ func FindBossRoom(g *graph.Graph) string {
 	return ""
 }
 
+// findExitRoom returns the ID of the Exit room, the lowest ID if there are
+// several, or "" when the dungeon has none.
+func findExitRoom(g *graph.Graph) string {
+	exitID := ""
+	for id, room := range g.Rooms {
+		if room.Archetype == graph.ArchetypeExit && (exitID == "" || id < exitID) {
+			exitID = id
+		}
+	}
+	return exitID
+}
+
 // FindKeyRooms locates all rooms that provide keys.
 // Returns a map of key name to room IDs that provide that key.
 func FindKeyRooms(g *graph.Graph) map[string][]string {
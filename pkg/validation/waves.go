@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// CheckWaveSchedule ensures a horde-mode dungeon has a coherent wave schedule:
+// spawner rooms open in waves 1..N with no gaps, the content lists waves
+// 1..N in order, each wave releases enemies only from spawners already open
+// and from every spawner opening in that wave, and no wave is smaller than
+// the one before it.
+// This is a hard constraint, checked when Config.Mode is wave.
+func CheckWaveSchedule(g *graph.Graph, content *dungeon.Content) dungeon.ConstraintResult {
+	violations := waveScheduleViolations(g, content)
+
+	satisfied := len(violations) == 0
+	details := "Waves open spawners in order and grow steadily"
+	if !satisfied {
+		details = fmt.Sprintf("Wave schedule violations: %v", violations)
+	}
+
+	return NewHardConstraintResult(
+		"WaveSchedule",
+		"waves.areProgressive()",
+		satisfied,
+		details,
+	)
+}
+
+// waveScheduleViolations runs every wave schedule check and returns the
+// failures.
+func waveScheduleViolations(g *graph.Graph, content *dungeon.Content) []string {
+	roomIDs := make([]string, 0, len(g.Rooms))
+	for id := range g.Rooms {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	// Spawner rooms by opening wave
+	opens := make(map[string]int)
+	opening := make(map[int][]string)
+	waves := 0
+	violations := []string{}
+	for _, id := range roomIDs {
+		room := g.Rooms[id]
+		if room.Tags["spawner"] != "true" {
+			continue
+		}
+		wave, err := strconv.Atoi(room.Tags["wave"])
+		if err != nil || wave < 1 {
+			violations = append(violations, fmt.Sprintf("spawner %s has invalid wave tag %q", id, room.Tags["wave"]))
+			continue
+		}
+		opens[id] = wave
+		opening[wave] = append(opening[wave], id)
+		waves = max(waves, wave)
+	}
+	if len(opens) == 0 {
+		return append(violations, "no spawner rooms")
+	}
+	for wave := 1; wave <= waves; wave++ {
+		if len(opening[wave]) == 0 {
+			violations = append(violations, fmt.Sprintf("no spawner opens in wave %d", wave))
+		}
+	}
+
+	if content == nil || len(content.Waves) != waves {
+		got := 0
+		if content != nil {
+			got = len(content.Waves)
+		}
+		return append(violations, fmt.Sprintf("expected %d waves, content has %d", waves, got))
+	}
+
+	previous := 0
+	for i, wave := range content.Waves {
+		if wave.Index != i+1 {
+			violations = append(violations, fmt.Sprintf("wave %d listed at position %d", wave.Index, i+1))
+			continue
+		}
+
+		released := make(map[string]bool)
+		total := 0
+		for _, spawn := range wave.Spawns {
+			open, ok := opens[spawn.RoomID]
+			switch {
+			case !ok:
+				violations = append(violations, fmt.Sprintf("wave %d spawns in non-spawner room %s", wave.Index, spawn.RoomID))
+			case open > wave.Index:
+				violations = append(violations, fmt.Sprintf("wave %d spawns in %s before it opens in wave %d", wave.Index, spawn.RoomID, open))
+			}
+			released[spawn.RoomID] = true
+			total += spawn.Count
+		}
+		for _, id := range opening[wave.Index] {
+			if !released[id] {
+				violations = append(violations, fmt.Sprintf("spawner %s releases nothing in its opening wave %d", id, wave.Index))
+			}
+		}
+		if total < previous {
+			violations = append(violations, fmt.Sprintf("wave %d has %d enemies, fewer than the %d before it", wave.Index, total, previous))
+		}
+		previous = total
+	}
+
+	return violations
+}
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/dshills/dungo/pkg/dungeon"
+	"github.com/dshills/dungo/pkg/graph"
+)
+
+// TestCheckWaveSchedule verifies progressive schedules pass and early,
+// missing or shrinking waves are reported.
+func TestCheckWaveSchedule(t *testing.T) {
+	g := graph.NewGraph(1)
+	for _, room := range []*graph.Room{
+		{ID: "hub", Archetype: graph.ArchetypeStart, Size: graph.SizeL},
+		{ID: "spawner_1", Archetype: graph.ArchetypeOptional, Size: graph.SizeM, Tags: map[string]string{"spawner": "true", "wave": "1"}},
+		{ID: "spawner_2", Archetype: graph.ArchetypeBoss, Size: graph.SizeL, Tags: map[string]string{"spawner": "true", "wave": "2"}},
+	} {
+		if err := g.AddRoom(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schedule := func(first, second []dungeon.Spawn) *dungeon.Content {
+		return &dungeon.Content{Waves: []dungeon.Wave{{Index: 1, Spawns: first}, {Index: 2, Spawns: second}}}
+	}
+	one := dungeon.Spawn{ID: "s1", RoomID: "spawner_1", EnemyType: "goblin", Count: 3}
+	two := dungeon.Spawn{ID: "s2", RoomID: "spawner_2", EnemyType: "orc", Count: 4}
+
+	if result := CheckWaveSchedule(g, schedule([]dungeon.Spawn{one}, []dungeon.Spawn{one, two})); !result.Satisfied {
+		t.Fatalf("progressive schedule should satisfy constraint: %s", result.Details)
+	}
+
+	// Spawner released before it opens
+	if result := CheckWaveSchedule(g, schedule([]dungeon.Spawn{one, two}, []dungeon.Spawn{one, two})); result.Satisfied {
+		t.Error("early spawner should violate constraint")
+	}
+
+	// Opening spawner missing from its wave
+	if result := CheckWaveSchedule(g, schedule([]dungeon.Spawn{one}, []dungeon.Spawn{one})); result.Satisfied {
+		t.Error("silent opening spawner should violate constraint")
+	}
+
+	// Shrinking wave
+	small := dungeon.Spawn{ID: "s2", RoomID: "spawner_2", EnemyType: "orc", Count: 1}
+	big := dungeon.Spawn{ID: "s1", RoomID: "spawner_1", EnemyType: "goblin", Count: 9}
+	if result := CheckWaveSchedule(g, schedule([]dungeon.Spawn{big}, []dungeon.Spawn{small})); result.Satisfied {
+		t.Error("shrinking wave should violate constraint")
+	}
+
+	// Missing waves
+	if result := CheckWaveSchedule(g, &dungeon.Content{}); result.Satisfied {
+		t.Error("missing schedule should violate constraint")
+	}
+}
@@ -150,7 +150,7 @@ func TestIntegration_CompletePipeline(t *testing.T) {
 
 // TestGolden_Determinism verifies that the same seed produces identical output.
 func TestGolden_Determinism(t *testing.T) {
-	cfg, err := dungeon.LoadConfig("../../testdata/seeds/small_crypt.yaml")
+	cfg, err := dungeon.LoadConfig("../../pkg/dungeon/testseeds/small_crypt.yaml")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -233,5 +233,17 @@ func TestIntegration_PathologicalSeed(t *testing.T) {
 		t.Error("Generated dungeon is not connected")
 	}
 
+	// The seed used to sprawl; keep its layout compact
+	m := artifact.Metrics
+	if mean := float64(m.CorridorLength) / float64(len(artifact.ADG.Connectors)); mean > 40 {
+		t.Errorf("Mean corridor length %.1f tiles, want at most 40", mean)
+	}
+	if m.BoundsUtilization < 0.05 {
+		t.Errorf("Rooms cover %.2f of the bounding box, want at least 0.05", m.BoundsUtilization)
+	}
+	if m.CorridorCrossings > 5 {
+		t.Errorf("%d corridor crossings, want at most 5", m.CorridorCrossings)
+	}
+
 	t.Logf("✓ Pathological seed 0x4400f4 handled successfully: %d rooms", len(artifact.ADG.Rooms))
 }